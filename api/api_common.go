@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -13,6 +14,7 @@ import (
 	protocol "github.com/libp2p/go-libp2p-core/protocol"
 
 	apitypes "github.com/filecoin-project/lotus/api/types"
+	"github.com/filecoin-project/lotus/journal"
 )
 
 //                       MODIFYING THE API INTERFACE
@@ -58,6 +60,11 @@ type Common interface {
 	// usage and current rate per protocol
 	NetBandwidthStatsByProtocol(ctx context.Context) (map[protocol.ID]metrics.Stats, error) //perm:read
 
+	// NetClassify returns the class of the connected peers. Bootstrap,
+	// protected and market peers are never trimmed, the pubsub and graphsync
+	// peers are trimmed down to their configured limits.
+	NetClassify(ctx context.Context) ([]NetPeerClass, error) //perm:read
+
 	// ConnectionGater API
 	NetBlockAdd(ctx context.Context, acl NetBlockList) error    //perm:admin
 	NetBlockRemove(ctx context.Context, acl NetBlockList) error //perm:admin
@@ -74,6 +81,12 @@ type Common interface {
 	// Version provides information about API provider
 	Version(context.Context) (APIVersion, error) //perm:read
 
+	// JournalQuery returns the journal entries of the given types recorded
+	// between from and to, oldest first. Zero times leave that side of the
+	// range open; an empty System or Event in types acts as a wildcard.
+	// A limit of 0 returns all matching entries.
+	JournalQuery(ctx context.Context, types []journal.EventType, from, to time.Time, limit int) ([]*journal.Event, error) //perm:read
+
 	LogList(context.Context) ([]string, error)         //perm:write
 	LogSetLevel(context.Context, string, string) error //perm:write
 
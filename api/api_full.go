@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p-core/peer"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-bitfield"
@@ -19,14 +21,19 @@ import (
 	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-state-types/crypto"
 	"github.com/filecoin-project/go-state-types/dline"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
 
 	apitypes "github.com/filecoin-project/lotus/api/types"
+	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/market"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/paych"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/power"
+	"github.com/filecoin-project/lotus/chain/beacon"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/subscription"
 	marketevents "github.com/filecoin-project/lotus/markets/loggers"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 )
@@ -65,8 +72,18 @@ type FullNode interface {
 
 	// ChainNotify returns channel with chain head updates.
 	// First message is guaranteed to be of len == 1, and type == 'current'.
+	// Head changes are never dropped, so a subscriber which doesn't keep up
+	// delays notifications to all subscribers; see ChainNotifyWithPolicy to
+	// subscribe with a buffering policy instead.
 	ChainNotify(context.Context) (<-chan []*HeadChange, error) //perm:read
 
+	// ChainNotifyWithPolicy is like ChainNotify, but with the given buffering
+	// policy, which decides what happens to head changes when the subscriber
+	// falls behind: 'drop-oldest' drops the oldest buffered head changes,
+	// 'coalesce' replaces all buffered head changes with a single 'current'
+	// head change for the latest head, and 'disconnect' closes the channel.
+	ChainNotifyWithPolicy(ctx context.Context, policy subscription.Policy) (<-chan []*HeadChange, error) //perm:read
+
 	// ChainHead returns the current head of the chain.
 	ChainHead(context.Context) (*types.TipSet, error) //perm:read
 
@@ -104,6 +121,13 @@ type FullNode interface {
 	// specified block.
 	ChainGetParentMessages(ctx context.Context, blockCid cid.Cid) ([]Message, error) //perm:read
 
+	// ChainGasUsage returns how the gas used by messages in the last epochs
+	// is distributed by recipient actor code and method, e.g. the share of
+	// block space used by window PoSts. Usage is tracked for a window of
+	// recent epochs, see Fees.GasUsageEpochs in the config; 0 returns the
+	// whole window.
+	ChainGasUsage(ctx context.Context, epochs abi.ChainEpoch) (*GasUsage, error) //perm:read
+
 	// ChainGetTipSetByHeight looks back for a tipset at the specified epoch.
 	// If there are no blocks at the specified epoch, a tipset at an earlier epoch
 	// will be returned.
@@ -160,6 +184,33 @@ type FullNode interface {
 	// If oldmsgskip is set, messages from before the requested roots are also not included.
 	ChainExport(ctx context.Context, nroots abi.ChainEpoch, oldmsgskip bool, tsk types.TipSetKey) (<-chan []byte, error) //perm:read
 
+	// ChainSnapshotImportProgress returns the progress of the snapshot being
+	// imported in the background, including the height ranges for which
+	// headers and state can already be queried.
+	ChainSnapshotImportProgress(ctx context.Context) (*SnapshotImportProgress, error) //perm:read
+
+	// ChainLatestSnapshot returns the metadata of the latest chain snapshot
+	// exported by the node, see Chainstore.EnableSnapshots in the config.
+	// Other nodes can bootstrap from the snapshot at the returned URL.
+	ChainLatestSnapshot(ctx context.Context) (*SnapshotInfo, error) //perm:read
+
+	// ChainSplitstoreStatus returns the progress of the ongoing splitstore
+	// compaction, and the outcome of the last one.
+	ChainSplitstoreStatus(ctx context.Context) (*SplitstoreStatus, error) //perm:read
+
+	// ChainSplitstorePauseCompaction pauses the ongoing splitstore compaction,
+	// and prevents new compactions from starting until it's resumed.
+	ChainSplitstorePauseCompaction(ctx context.Context) error //perm:admin
+
+	// ChainSplitstoreResumeCompaction resumes splitstore compaction.
+	ChainSplitstoreResumeCompaction(ctx context.Context) error //perm:admin
+
+	// ChainBlockstoreGC runs online garbage collection on the chain
+	// blockstore, reclaiming disk space without stopping the node. The
+	// returned channel reports the progress of the collection, and is closed
+	// once it finishes.
+	ChainBlockstoreGC(ctx context.Context, opts BlockstoreGCOpts) (<-chan BlockstoreGCProgress, error) //perm:admin
+
 	// MethodGroup: Beacon
 	// The Beacon method group contains methods for interacting with the random beacon (DRAND)
 
@@ -167,6 +218,10 @@ type FullNode interface {
 	// the entry has not yet been produced, the call will block until the entry
 	// becomes available
 	BeaconGetEntry(ctx context.Context, epoch abi.ChainEpoch) (*types.BeaconEntry, error) //perm:read
+	// BeaconHealth reports how the beacon of the current epoch is fetching
+	// entries: the health and latency of its endpoints, and the rounds which
+	// couldn't be fetched
+	BeaconHealth(context.Context) (*beacon.Health, error) //perm:read
 
 	// GasEstimateFeeCap estimates gas fee cap
 	GasEstimateFeeCap(context.Context, *types.Message, int64, types.TipSetKey) (types.BigInt, error) //perm:read
@@ -184,6 +239,12 @@ type FullNode interface {
 	// GasEstimateMessageGas estimates gas values for unset message gas fields
 	GasEstimateMessageGas(context.Context, *types.Message, *MessageSendSpec, types.TipSetKey) (*types.Message, error) //perm:read
 
+	// GasAdvisorFees returns recommended fee caps and premiums for messages
+	// to be included in the next epoch, within 5 epochs, or on a best effort
+	// basis. The advice is based on the base fee at the given tipset, premiums
+	// of recently included messages and the current mpool contents.
+	GasAdvisorFees(context.Context, types.TipSetKey) (*GasAdvice, error) //perm:read
+
 	// MethodGroup: Sync
 	// The Sync method group contains methods for interacting with and
 	// observing the lotus sync service.
@@ -199,6 +260,15 @@ type FullNode interface {
 	// yet synced block headers.
 	SyncIncomingBlocks(ctx context.Context) (<-chan *types.BlockHeader, error) //perm:read
 
+	// SyncConsensusFaults returns the consensus faults detected in incoming
+	// blocks, when the consensus fault detector is enabled.
+	SyncConsensusFaults(ctx context.Context) ([]ConsensusFault, error) //perm:read
+
+	// SyncValidationTiming returns a histogram of the time taken to validate
+	// recently validated tipsets, broken down by signature checks, state
+	// execution and proof verification.
+	SyncValidationTiming(ctx context.Context) (SyncValidationTiming, error) //perm:read
+
 	// SyncCheckpoint marks a blocks as checkpointed, meaning that it won't ever fork away from it.
 	SyncCheckpoint(ctx context.Context, tsk types.TipSetKey) error //perm:admin
 
@@ -263,6 +333,14 @@ type FullNode interface {
 	// Note that this method may not be atomic. Use MpoolPushMessage instead.
 	MpoolGetNonce(context.Context, address.Address) (uint64, error) //perm:read
 	MpoolSub(context.Context) (<-chan MpoolUpdate, error)           //perm:read
+	// MpoolReorgs notifies about the messages from local addresses which
+	// were included in tipsets reverted by chain re-orgs, whether they were
+	// re-included in the new chain, and their execution results there
+	MpoolReorgs(context.Context) (<-chan MpoolReorg, error) //perm:read
+	// MpoolNonceGaps reports the local addresses with pending messages which
+	// are stuck, either behind gaps in their nonces, or behind a message which
+	// can't be included
+	MpoolNonceGaps(context.Context) ([]NonceGapReport, error) //perm:read
 
 	// MpoolClear clears pending messages from the mpool
 	MpoolClear(context.Context, bool) error //perm:write
@@ -276,6 +354,17 @@ type FullNode interface {
 
 	MinerGetBaseInfo(context.Context, address.Address, abi.ChainEpoch, types.TipSetKey) (*MiningBaseInfo, error) //perm:read
 	MinerCreateBlock(context.Context, *BlockTemplate) (*types.BlockMsg, error)                                   //perm:write
+	// MinerComputeBlockTemplate is a dry run of mining a block for the epoch on
+	// top of the base tipset. It reports whether the miner would win, the
+	// messages it would select, and the expected rewards and penalties, without
+	// creating a block. Computing the ticket and the election proof requires
+	// the worker key of the miner in the wallet of the node.
+	//
+	// Messages are selected from the current message pool when the base is the
+	// chain head. For other bases the messages are the ones included in the
+	// chain at the epoch on top of the base, if any, and epochs past the chain
+	// head are rejected.
+	MinerComputeBlockTemplate(ctx context.Context, maddr address.Address, epoch abi.ChainEpoch, base types.TipSetKey) (*BlockTemplateReport, error) //perm:sign
 
 	// // UX ?
 
@@ -310,6 +399,25 @@ type FullNode interface {
 	WalletDelete(context.Context, address.Address) error //perm:admin
 	// WalletValidateAddress validates whether a given string can be decoded as a well-formed address
 	WalletValidateAddress(context.Context, string) (address.Address, error) //perm:read
+	// WalletAddressLabelSet sets a label on an address, e.g. the name of a
+	// counterparty, shown in the wallet history. An empty label removes it.
+	WalletAddressLabelSet(ctx context.Context, addr address.Address, label string) error //perm:write
+	// WalletMessageLabelSet sets a label (memo) on a message. An empty label
+	// removes it.
+	WalletMessageLabelSet(ctx context.Context, msg cid.Cid, label string) error //perm:write
+	// WalletLabels returns the labels of addresses and messages
+	WalletLabels(context.Context) (*WalletLabels, error) //perm:read
+
+	// AddressBookSet adds a named address to the address book of the node, or
+	// replaces the entry of the name. Names can be used in place of addresses
+	// by the CLI.
+	AddressBookSet(ctx context.Context, entry AddressBookEntry) error //perm:write
+	// AddressBookRemove removes a name from the address book
+	AddressBookRemove(ctx context.Context, name string) error //perm:write
+	// AddressBookGet returns the address book entry of a name
+	AddressBookGet(ctx context.Context, name string) (*AddressBookEntry, error) //perm:read
+	// AddressBookList returns all address book entries, sorted by name
+	AddressBookList(context.Context) ([]AddressBookEntry, error) //perm:read
 
 	// Other
 
@@ -409,24 +517,73 @@ type FullNode interface {
 	// different signature, but with all other parameters matching (source/destination,
 	// nonce, params, etc.)
 	StateReplay(context.Context, types.TipSetKey, cid.Cid) (*InvocResult, error) //perm:read
+	// StateReplayDetailed replays a message like StateReplay, with an
+	// instrumented VM recording every step of its execution: the actor calls
+	// and their outcome, the state objects read and written, and the gas
+	// charged per class of operation.
+	StateReplayDetailed(context.Context, types.TipSetKey, cid.Cid) (*ReplayTrace, error) //perm:read
 	// StateGetActor returns the indicated actor's nonce and balance.
 	StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*types.Actor, error) //perm:read
 	// StateReadState returns the indicated actor's state.
 	StateReadState(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*ActorState, error) //perm:read
+	// StateQuery resolves the requested fields of the state of the given actors
+	// at a single tipset, in one call. The fields available depend on the
+	// actor type: all actors have Actor, Balance and ID; miners additionally
+	// have Info, Power, AvailableBalance, ProvingDeadline, Deadlines,
+	// DeadlineSummaries, SectorCount, Faults and Recoveries; multisigs have
+	// AvailableBalance, Pending and VestingSchedule. Fields which can't be
+	// resolved, including all fields of actors which can't be loaded, are
+	// reported in the Errors of the actor result, without failing the whole
+	// query.
+	StateQuery(ctx context.Context, query []StateFieldQuery, tsk types.TipSetKey) (*StateQueryResult, error) //perm:read
 	// StateListMessages looks back and returns all messages with a matching to or from address, stopping at the given height.
 	StateListMessages(ctx context.Context, match *MessageMatch, tsk types.TipSetKey, toht abi.ChainEpoch) ([]cid.Cid, error) //perm:read
 	// StateDecodeParams attempts to decode the provided params, based on the recipient actor address and method number.
 	StateDecodeParams(ctx context.Context, toAddr address.Address, method abi.MethodNum, params []byte, tsk types.TipSetKey) (interface{}, error) //perm:read
+	// StateDecodeReturn attempts to decode the provided return value, based on the recipient actor address and method number.
+	StateDecodeReturn(ctx context.Context, toAddr address.Address, method abi.MethodNum, ret []byte, tsk types.TipSetKey) (interface{}, error) //perm:read
+	// StateDecodeMessage looks up a message, and decodes its params and, if it
+	// was executed, its return value, based on the recipient actor at the time
+	// of execution (or at the head for pending messages).
+	StateDecodeMessage(ctx context.Context, msg cid.Cid) (*DecodedMessage, error) //perm:read
+	// StateActorCodeCIDs returns the code CIDs of the builtin actors at the
+	// network version, by actor name, e.g. storageminer.
+	StateActorCodeCIDs(ctx context.Context, nv apitypes.NetworkVersion) (map[string]cid.Cid, error) //perm:read
+	// StateActorMethods returns the number, name, and params and return types
+	// of the methods of the actor with the given code.
+	StateActorMethods(ctx context.Context, code cid.Cid) ([]ActorMethod, error) //perm:read
+	// StateDecodeActorParams decodes the params of a method of the actor with
+	// the given code, without looking up a recipient address.
+	StateDecodeActorParams(ctx context.Context, code cid.Cid, method abi.MethodNum, params []byte) (interface{}, error) //perm:read
+	// StateDecodeActorReturn decodes the return value of a method of the
+	// actor with the given code.
+	StateDecodeActorReturn(ctx context.Context, code cid.Cid, method abi.MethodNum, ret []byte) (interface{}, error) //perm:read
+	// StateActorLifecycle returns when the actor, given by its ID or robust
+	// address, was created and deleted, from the actor index enabled by
+	// Chainstore.EnableActorIndex.
+	StateActorLifecycle(ctx context.Context, addr address.Address) (*ActorLifecycle, error) //perm:read
+	// StateListCreatedActors returns the actors created between the epochs,
+	// inclusive, from the actor index enabled by Chainstore.EnableActorIndex.
+	StateListCreatedActors(ctx context.Context, from, to abi.ChainEpoch) ([]ActorLifecycle, error) //perm:read
 
 	// StateNetworkName returns the name of the network the node is synced to
 	StateNetworkName(context.Context) (dtypes.NetworkName, error) //perm:read
 	// StateMinerSectors returns info about the given miner's sectors. If the filter bitfield is nil, all sectors are included.
 	StateMinerSectors(context.Context, address.Address, *bitfield.BitField, types.TipSetKey) ([]*miner.SectorOnChainInfo, error) //perm:read
+	// StateMinerSectorsPage returns a page of the given miner's sectors
+	// matching the query, in sector number order. Pages are requested with
+	// the NextCursor of the previous page, until it is nil. Pages hold fewer
+	// sectors than the limit when the query skips many sectors.
+	StateMinerSectorsPage(context.Context, address.Address, MinerSectorsQuery, types.TipSetKey) (*MinerSectorsPage, error) //perm:read
 	// StateMinerActiveSectors returns info about sectors that a given miner is actively proving.
 	StateMinerActiveSectors(context.Context, address.Address, types.TipSetKey) ([]*miner.SectorOnChainInfo, error) //perm:read
 	// StateMinerProvingDeadline calculates the deadline at some epoch for a proving period
 	// and returns the deadline-related calculations.
 	StateMinerProvingDeadline(context.Context, address.Address, types.TipSetKey) (*dline.Info, error) //perm:read
+	// StateMinerProvingSummary returns the current proving deadline of the
+	// miner, with the partition and sector counts of each of its deadlines,
+	// in one call. Summaries are cached until the deadlines change.
+	StateMinerProvingSummary(context.Context, address.Address, types.TipSetKey) (*MinerProvingSummary, error) //perm:read
 	// StateMinerPower returns the power of the indicated miner
 	StateMinerPower(context.Context, address.Address, types.TipSetKey) (*MinerPower, error) //perm:read
 	// StateMinerInfo returns info about the indicated miner
@@ -447,6 +604,10 @@ type FullNode interface {
 	StateMinerInitialPledgeCollateral(context.Context, address.Address, miner.SectorPreCommitInfo, types.TipSetKey) (types.BigInt, error) //perm:read
 	// StateMinerAvailableBalance returns the portion of a miner's balance that can be withdrawn or spent
 	StateMinerAvailableBalance(context.Context, address.Address, types.TipSetKey) (types.BigInt, error) //perm:read
+	// StateMinerTerminationPenalty returns the fee the miner actor would charge
+	// for terminating the given live sectors in the specified tipset, computed
+	// with the actor's own math from the reward and network power estimates
+	StateMinerTerminationPenalty(ctx context.Context, maddr address.Address, sectors []abi.SectorNumber, tsk types.TipSetKey) (abi.TokenAmount, error) //perm:read
 	// StateMinerSectorAllocated checks if a sector is allocated
 	StateMinerSectorAllocated(context.Context, address.Address, abi.SectorNumber, types.TipSetKey) (bool, error) //perm:read
 	// StateSectorPreCommitInfo returns the PreCommit info for the specified miner's sector
@@ -459,6 +620,10 @@ type FullNode interface {
 	StateSectorExpiration(context.Context, address.Address, abi.SectorNumber, types.TipSetKey) (*miner.SectorExpiration, error) //perm:read
 	// StateSectorPartition finds deadline/partition with the specified sector
 	StateSectorPartition(ctx context.Context, maddr address.Address, sectorNumber abi.SectorNumber, tok types.TipSetKey) (*miner.SectorLocation, error) //perm:read
+	// StateVerifyAggregateSeals verifies an aggregated ProveCommit proof against
+	// the given seal infos with the proof verifier of the node, without sending
+	// any message, e.g. to check an aggregate built by external tooling
+	StateVerifyAggregateSeals(context.Context, proof5.AggregateSealVerifyProofAndInfos) (bool, error) //perm:read
 	// StateSearchMsg looks back up to limit epochs in the chain for a message, and returns its receipt and the tipset where it was executed
 	//
 	// NOTE: If a replacing message is found on chain, this method will return
@@ -495,6 +660,11 @@ type FullNode interface {
 	// different signature, but with all other parameters matching (source/destination,
 	// nonce, params, etc.)
 	StateWaitMsg(ctx context.Context, cid cid.Cid, confidence uint64, limit abi.ChainEpoch, allowReplaced bool) (*MsgLookup, error) //perm:read
+	// StateWatchMsg waits for a message like StateWaitMsg, but reports every stage
+	// the message goes through: execution, the requested confidence depth and
+	// finality. The channel is closed after the finality event, or after an event
+	// carrying an error.
+	StateWatchMsg(ctx context.Context, cid cid.Cid, confidence uint64, limit abi.ChainEpoch, allowReplaced bool) (<-chan MsgWatchEvent, error) //perm:read
 	// StateListMiners returns the addresses of every miner that has claimed power in the Power Actor
 	StateListMiners(context.Context, types.TipSetKey) ([]address.Address, error) //perm:read
 	// StateListActors returns the addresses of every actor in the state
@@ -569,6 +739,14 @@ type FullNode interface {
 	// StateVMCirculatingSupplyInternal returns an approximation of the circulating supply of Filecoin at the given tipset.
 	// This is the value reported by the runtime interface to actors code.
 	StateVMCirculatingSupplyInternal(context.Context, types.TipSetKey) (CirculatingSupply, error) //perm:read
+	// StateCirculatingSupplyBreakdown returns the components of the circulating supply at the given
+	// tipset, with the locked funds split between pledge collateral and funds locked in deals.
+	StateCirculatingSupplyBreakdown(context.Context, types.TipSetKey) (*CirculatingSupplyBreakdown, error) //perm:read
+	// StateSectorPledgeProjection estimates the initial pledge for a sector with the given
+	// quality-adjusted power committed at a future epoch. Network power and circulating supply
+	// are extrapolated linearly from their change over the day before the given tipset; the
+	// reward and baseline inputs are taken from the tipset as-is.
+	StateSectorPledgeProjection(ctx context.Context, qaPower abi.StoragePower, epoch abi.ChainEpoch, tsk types.TipSetKey) (*SectorPledgeProjection, error) //perm:read
 	// StateNetworkVersion returns the network version at the given tipset
 	StateNetworkVersion(context.Context, types.TipSetKey) (apitypes.NetworkVersion, error) //perm:read
 
@@ -684,6 +862,14 @@ type FullNode interface {
 	PaychVoucherList(context.Context, address.Address) ([]*paych.SignedVoucher, error)                                  //perm:write
 	PaychVoucherSubmit(context.Context, address.Address, *paych.SignedVoucher, []byte, []byte) (cid.Cid, error)         //perm:sign
 
+	// NetPropagation returns when recent blocks and messages arrived over
+	// pubsub, relative to the start of their epoch, and how many of them each
+	// peer delivered first.
+	NetPropagation(ctx context.Context) (*PropagationReport, error) //perm:read
+
+	// NetPropagationReset drops the recorded arrivals, starting a new report
+	NetPropagationReset(ctx context.Context) error //perm:admin
+
 	// MethodGroup: Node
 	// These methods are general node management and status commands
 
@@ -747,6 +933,60 @@ type DealInfo struct {
 	DataTransfer      *DataTransferChannel
 }
 
+// WalletLabels are the labels set on addresses and messages, by address and
+// by message CID
+type WalletLabels struct {
+	Addresses map[string]string
+	Messages  map[string]string
+}
+
+// AddressBookEntry is a named address of the address book of the node
+type AddressBookEntry struct {
+	Name    string
+	Address address.Address
+	Note    string `json:",omitempty"`
+	Added   time.Time
+}
+
+// DecodedMessage is a message with its params and return value decoded for
+// the method of the recipient actor. Decoding errors don't fail the lookup,
+// they are set in DecodeError.
+type DecodedMessage struct {
+	Cid     cid.Cid
+	Message *types.Message
+
+	// Actor is the name of the recipient actor code, e.g. fil/5/storageminer
+	Actor  string
+	Method string
+	Params interface{}
+
+	Receipt *types.MessageReceipt `json:",omitempty"`
+	Return  interface{}           `json:",omitempty"`
+
+	DecodeError string `json:",omitempty"`
+}
+
+// ActorMethod is the signature of an actor method
+type ActorMethod struct {
+	Num  abi.MethodNum
+	Name string
+
+	// Go types of the params and return value, e.g.
+	// *miner.SubmitWindowedPoStParams, *abi.EmptyValue for none
+	Params string
+	Return string
+
+	// fields of struct params and return values, in the order they are
+	// CBOR-encoded in
+	ParamsFields []ActorMethodField `json:",omitempty"`
+	ReturnFields []ActorMethodField `json:",omitempty"`
+}
+
+type ActorMethodField struct {
+	Name string
+	Type string
+}
+
 type MsgLookup struct {
 	Message   cid.Cid // Can be different than requested, in case it was replaced, but only gas values changed
 	Receipt   types.MessageReceipt
@@ -755,6 +995,54 @@ type MsgLookup struct {
 	Height    abi.ChainEpoch
 }
 
+// ConfidenceMode names a depth at which a message is considered settled
+type ConfidenceMode string
+
+const (
+	// ConfidenceSoft only waits for the message to be executed
+	ConfidenceSoft ConfidenceMode = "soft"
+	// ConfidenceDefault waits for build.MessageConfidence epochs
+	ConfidenceDefault ConfidenceMode = "default"
+	// ConfidenceFinal waits for the message to be final and can't be re-orged out
+	ConfidenceFinal ConfidenceMode = "final"
+)
+
+// Confidence returns the number of epochs to wait for in this mode
+func (m ConfidenceMode) Confidence() (uint64, error) {
+	switch m {
+	case ConfidenceSoft:
+		return build.SoftMessageConfidence, nil
+	case ConfidenceDefault:
+		return build.MessageConfidence, nil
+	case ConfidenceFinal:
+		return build.FinalMessageConfidence, nil
+	}
+	return 0, xerrors.Errorf("unknown confidence mode %q", m)
+}
+
+// ParseConfidence accepts either a confidence mode or a number of epochs
+func ParseConfidence(s string) (uint64, error) {
+	if c, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return c, nil
+	}
+	return ConfidenceMode(s).Confidence()
+}
+
+type MsgWatchEventType string
+
+const (
+	MsgExecuted  MsgWatchEventType = "executed"
+	MsgConfirmed MsgWatchEventType = "confirmed"
+	MsgFinal     MsgWatchEventType = "final"
+)
+
+type MsgWatchEvent struct {
+	Type       MsgWatchEventType
+	Confidence uint64
+	Lookup     *MsgLookup
+	Error      string
+}
+
 type MsgGasCost struct {
 	Message            cid.Cid // Can be different than requested, in case it was replaced, but only gas values changed
 	GasUsed            abi.TokenAmount
@@ -766,6 +1054,65 @@ type MsgGasCost struct {
 	TotalCost          abi.TokenAmount
 }
 
+const (
+	GasAdviceNextEpoch  = "next-epoch"
+	GasAdviceFiveEpochs = "5-epochs"
+	GasAdviceBestEffort = "best-effort"
+)
+
+type GasAdvice struct {
+	Height  abi.ChainEpoch
+	BaseFee abi.TokenAmount
+
+	Targets []GasAdviceTarget
+}
+
+type GasAdviceTarget struct {
+	Target string
+	// Epochs the message is expected to be included within, 0 for best effort
+	Epochs int64
+
+	GasFeeCap  abi.TokenAmount
+	GasPremium abi.TokenAmount
+}
+
+// GasUsage is the distribution of the gas used by the messages executed in a
+// range of epochs
+type GasUsage struct {
+	From abi.ChainEpoch
+	To   abi.ChainEpoch
+
+	Tipsets  int
+	Blocks   int
+	Messages int64
+	GasUsed  int64
+	GasLimit int64
+	// BlockGasLimit is the total gas limit of the blocks in the range
+	BlockGasLimit int64
+
+	// Methods are ordered by decreasing gas used
+	Methods []GasUsageMethod
+}
+
+type GasUsageMethod struct {
+	ActorCode cid.Cid
+	// Actor is the builtin actor name, e.g. fil/5/storageminer, or unknown
+	// for recipients which couldn't be found in the state
+	Actor      string
+	Method     abi.MethodNum
+	MethodName string
+
+	Messages int64
+	GasUsed  int64
+	GasLimit int64
+
+	// GasUsedShare is the share of the gas used by all messages in the range,
+	// BlockSpaceShare the share of the block gas limit taken by the gas limit
+	// of the messages
+	GasUsedShare    float64
+	BlockSpaceShare float64
+}
+
 // BlsMessages[x].cid = Cids[x]
 // SecpkMessages[y].cid = Cids[BlsMessages.length + y]
 type BlockMessages struct {
@@ -925,6 +1272,42 @@ type InvocResult struct {
 	Duration       time.Duration
 }
 
+type ReplayTrace struct {
+	InvocResult *InvocResult
+	// Steps are the actor calls and returns, and the state reads and writes
+	// of the message, in execution order
+	Steps []ReplayStep
+	// GasByClass is the gas charged by the message per class of operation,
+	// most expensive first
+	GasByClass []ReplayGasClass
+}
+
+type ReplayStep struct {
+	// Op is call, return, get or put
+	Op    string
+	Depth uint64
+
+	// Msg is the invoked message, for calls
+	Msg *types.Message
+	// ExitCode, GasUsed and Error are the outcome of calls, for returns.
+	// GasUsed includes the gas used by subcalls
+	ExitCode exitcode.ExitCode
+	GasUsed  int64
+	Error    string
+
+	// Cid and Size are the state object read or written, for gets and puts
+	Cid  cid.Cid
+	Size int
+}
+
+type ReplayGasClass struct {
+	Name       string
+	Count      int
+	TotalGas   int64
+	ComputeGas int64
+	StorageGas int64
+}
+
 type MethodCall struct {
 	types.MessageReceipt
 	Error string
@@ -963,6 +1346,95 @@ type IpldObject struct {
 	Obj interface{}
 }
 
+// EpochRange is an inclusive range of epochs; From and To are -1 when the
+// range is empty
+type EpochRange struct {
+	From abi.ChainEpoch
+	To   abi.ChainEpoch
+}
+
+type SnapshotImportProgress struct {
+	// Root is the tipset the snapshot was taken at
+	Root types.TipSetKey
+
+	BytesRead  int64
+	TotalBytes int64 // 0 when unknown
+	Blocks     int64
+
+	// Headers is the range of tipsets with imported headers
+	Headers EpochRange
+	// State is the range of tipsets with imported state, which can be used
+	// for state queries
+	State EpochRange
+
+	Done  bool
+	Error string
+}
+
+// SnapshotInfo describes a chain snapshot exported by the node
+type SnapshotInfo struct {
+	// Name of the snapshot file
+	Name string
+	// Height and TipSet the snapshot was taken at
+	Height abi.ChainEpoch
+	TipSet types.TipSetKey
+
+	Size int64
+	// SHA256 is the hex-encoded sha256 of the snapshot file
+	SHA256  string
+	Created time.Time
+
+	// URL the snapshot can be fetched from, empty if unknown
+	URL string
+}
+
+type SplitstoreStatus struct {
+	Compacting bool
+	Paused     bool
+	// Phase is the current phase of the compaction: warmup, marking,
+	// collecting, moving, purging or gc
+	Phase   string
+	Started time.Time
+
+	// BaseEpoch is the epoch up to which the chain was compacted
+	BaseEpoch abi.ChainEpoch
+
+	Marked int64
+	Hot    int64
+	Cold   int64
+	Dead   int64
+	Moved  int64
+	Purged int64
+
+	LastCompaction time.Time
+	LastDuration   time.Duration
+	LastError      string
+	// LastReclaimed is the number of bytes the last compaction reclaimed in
+	// the hotstore, when known
+	LastReclaimed int64
+}
+
+type BlockstoreGCOpts struct {
+	// Threshold is the fraction of garbage a value log file must contain to
+	// be rewritten; lower values reclaim more space but take longer. The
+	// blockstore default is used when zero
+	Threshold float64
+	// Compact compacts the LSM tree before collecting garbage
+	Compact bool
+}
+
+type BlockstoreGCProgress struct {
+	// Rounds is the number of value log files rewritten so far
+	Rounds int
+	// SizeBefore and Size are the size of the blockstore on disk before
+	// the collection started, and now
+	SizeBefore int64
+	Size       int64
+
+	Done  bool
+	Error string
+}
+
 type ActiveSync struct {
 	WorkerID uint64
 	Base     *types.TipSet
@@ -976,6 +1448,79 @@ type ActiveSync struct {
 	Message string
 }
 
+// ConsensusFault is the evidence of a miner mining multiple blocks at one epoch
+type ConsensusFault struct {
+	Miner  address.Address
+	Epoch  abi.ChainEpoch
+	Block1 *types.BlockHeader
+	Block2 *types.BlockHeader
+
+	Detected time.Time
+
+	// Report is the ReportConsensusFault message, if the fault was reported
+	Report *cid.Cid
+	// ReportError is why the fault wasn't reported
+	ReportError string
+}
+
+// ActorLifecycle is when an actor was created and deleted
+type ActorLifecycle struct {
+	ID address.Address
+	// Robust is the robust address of the actor, undefined when unknown
+	Robust address.Address
+	Code   cid.Cid
+
+	// Created is the epoch of the first tipset whose state includes the
+	// actor, the creating message was executed in the tipset before it; -1
+	// when the actor was created before the indexed range
+	Created abi.ChainEpoch
+	// CreatedBy is the message creating the actor, when the actor was
+	// created by a message sent to the init or power actor, or by sending
+	// funds to a new key address
+	CreatedBy *cid.Cid
+
+	// Deleted is the epoch of the first tipset whose state doesn't include
+	// the actor, -1 while it exists
+	Deleted abi.ChainEpoch
+}
+
+// ValidationTiming is how long validating a tipset took, and how long its
+// main checks took. Blocks are validated in parallel, the time of a check is
+// the longest across the blocks of the tipset.
+type ValidationTiming struct {
+	TipSet types.TipSetKey
+	Height abi.ChainEpoch
+
+	Total      time.Duration
+	Signatures time.Duration // message and block signature checks
+	Execution  time.Duration // computing the parent state
+	// Proofs is the time spent verifying the winning PoSt, and the proofs
+	// verified while computing the parent state, which is also part of
+	// Execution. Proofs verified while the parent state was computed for
+	// another caller, e.g. the block producer, aren't counted.
+	Proofs time.Duration
+}
+
+type SyncValidationTiming struct {
+	// Budget is the validation time above which an alert is raised, 0 when
+	// not set
+	Budget time.Duration
+
+	// Tipsets is the number of recently validated tipsets in the histogram
+	Tipsets int
+
+	// Bounds are the upper bounds of the histogram buckets, the last bucket
+	// counting the tipsets taking longer than the last bound
+	Bounds     []time.Duration
+	Total      []int
+	Signatures []int
+	Execution  []int
+	Proofs     []int
+
+	Latest  *ValidationTiming
+	Slowest *ValidationTiming
+}
+
 type SyncState struct {
 	ActiveSyncs []ActiveSync
 
@@ -1027,6 +1572,64 @@ type MpoolUpdate struct {
 	Message *types.SignedMessage
 }
 
+// MpoolReorg reports the local messages un-included by a chain re-org
+type MpoolReorg struct {
+	// Reverted and Applied are the tipsets removed from and added to the
+	// chain by the re-org
+	Reverted []types.TipSetKey
+	Applied  []types.TipSetKey
+
+	Messages []ReorgedMessage
+}
+
+type ReorgedMessage struct {
+	Message *types.Message
+	Cid     cid.Cid
+	// RevertedIn is the tipset which included the message in the old chain
+	RevertedIn types.TipSetKey
+
+	// Reincluded is set when the message, or a message replacing it with the
+	// same nonce, was included in one of the applied tipsets. Otherwise the
+	// message was added back to the message pool.
+	Reincluded    bool
+	ReincludedCid cid.Cid
+	ReincludedIn  types.TipSetKey
+
+	// Receipt is the result of the execution of the re-included message in
+	// the new chain. It is nil until a tipset on top of ReincludedIn is
+	// applied.
+	Receipt *types.MessageReceipt
+}
+
+// NonceGapReport describes why the pending messages from a local address
+// can't be included on chain
+type NonceGapReport struct {
+	Address    address.Address
+	StateNonce uint64
+	Pending    int
+
+	// Gaps lists the ranges of nonces with no pending message. Every message
+	// after a gap is stuck until it is filled.
+	Gaps []NonceGap
+	// Blocking is set when the message with the state nonce is pending, but
+	// fails the checks required for its inclusion
+	Blocking *BlockingMessage
+}
+
+type NonceGap struct {
+	Start uint64 // first missing nonce
+	End   uint64 // first nonce after the gap
+
+	// Blocked is the number of pending messages stuck behind the gap
+	Blocked int
+}
+
+type BlockingMessage struct {
+	Cid     cid.Cid
+	Nonce   uint64
+	Reasons []string
+}
+
 type ComputeStateOutput struct {
 	Root  cid.Cid
 	Trace []*InvocResult
@@ -1046,6 +1649,43 @@ type CirculatingSupply struct {
 	FilReserveDisbursed abi.TokenAmount
 }
 
+// CirculatingSupplyBreakdown splits the circulating supply at a given height
+// into its components. FilCirculating is
+// FilVested + FilMined + FilReserveDisbursed - FilBurnt - FilLockedPledge - FilLockedDeals,
+// floored at zero.
+type CirculatingSupplyBreakdown struct {
+	Height abi.ChainEpoch
+
+	FilVested           abi.TokenAmount
+	FilMined            abi.TokenAmount
+	FilReserveDisbursed abi.TokenAmount
+	FilBurnt            abi.TokenAmount
+	// FilLockedPledge is the total pledge collateral locked by miners
+	FilLockedPledge abi.TokenAmount
+	// FilLockedDeals is the deal collateral and unpaid storage fees locked in the market actor
+	FilLockedDeals abi.TokenAmount
+	FilCirculating abi.TokenAmount
+}
+
+// SectorPledgeProjection is an estimate of the initial pledge required for a
+// sector committed at a future epoch.
+type SectorPledgeProjection struct {
+	// Epoch the projection is for
+	Epoch abi.ChainEpoch
+	// QAPower is the quality-adjusted power of the sector
+	QAPower abi.StoragePower
+	// InitialPledge is the projected initial pledge, without the safety margin
+	// applied by StateMinerInitialPledgeCollateral
+	InitialPledge abi.TokenAmount
+
+	// Projected inputs to the pledge calculation
+	NetworkQAPower abi.StoragePower
+	FilCirculating abi.TokenAmount
+
+	// SampleEpochs is the number of epochs the trends were sampled over
+	SampleEpochs abi.ChainEpoch
+}
+
 type MiningBaseInfo struct {
 	MinerPower        types.BigInt
 	NetworkPower      types.BigInt
@@ -1069,6 +1709,29 @@ type BlockTemplate struct {
 	WinningPoStProof []builtin.PoStProof
 }
 
+// BlockTemplateReport is the outcome of a dry run of mining a block
+type BlockTemplateReport struct {
+	Miner address.Address
+	Epoch abi.ChainEpoch
+	Base  types.TipSetKey
+
+	// Eligible is false when the miner can't mine at the epoch, e.g. without
+	// enough power
+	Eligible bool
+	Won      bool
+	WinCount int64
+
+	Ticket   *types.Ticket
+	Eproof   *types.ElectionProof
+	Messages []*types.SignedMessage
+
+	// BlockReward is paid by the reward actor for the won block; MinerTips
+	// and MinerPenalties are paid and charged when applying the messages
+	BlockReward    abi.TokenAmount
+	MinerTips      abi.TokenAmount
+	MinerPenalties abi.TokenAmount
+}
+
 type DataSize struct {
 	PayloadSize int64
 	PieceSize   abi.PaddedPieceSize
@@ -1114,6 +1777,75 @@ type Fault struct {
 	Epoch abi.ChainEpoch
 }
 
+// StateFieldQuery selects the state fields to resolve for a single actor.
+type StateFieldQuery struct {
+	Actor  address.Address
+	Fields []string
+}
+
+type StateQueryResult struct {
+	// TipSet is the tipset the query was resolved at
+	TipSet types.TipSetKey
+	Height abi.ChainEpoch
+
+	Actors []StateActorFields
+}
+
+type StateActorFields struct {
+	Actor  address.Address
+	Fields map[string]json.RawMessage
+	Errors map[string]string `json:",omitempty"`
+}
+
+// MinerSectorsQuery selects a page of the sectors of a miner. Epoch bounds
+// are inclusive, and not applied when 0.
+type MinerSectorsQuery struct {
+	// Cursor is the lowest sector number returned, the NextCursor of the
+	// previous page
+	Cursor abi.SectorNumber
+	// Limit is the maximum number of sectors returned, 1000 when 0
+	Limit int
+
+	ActivationMin abi.ChainEpoch
+	ActivationMax abi.ChainEpoch
+	ExpirationMin abi.ChainEpoch
+	ExpirationMax abi.ChainEpoch
+
+	// WithDeals only selects the sectors holding deals
+	WithDeals bool
+
+	// Deadline only selects the sectors of the deadline, and Partition the
+	// sectors of one of its partitions
+	Deadline  *uint64
+	Partition *uint64
+}
+
+type MinerSectorsPage struct {
+	Sectors []*miner.SectorOnChainInfo
+	// NextCursor is the Cursor of the next page, nil after the last page
+	NextCursor *abi.SectorNumber
+}
+
+// MinerProvingSummary is the state of the proving deadlines of a miner
+type MinerProvingSummary struct {
+	// ProvingDeadline is the current deadline
+	ProvingDeadline *dline.Info
+	Deadlines       []DeadlineSummary
+}
+
+// DeadlineSummary holds the partition and sector counts of a single miner
+// proving deadline.
+type DeadlineSummary struct {
+	Index            uint64
+	Partitions       uint64
+	PostedPartitions uint64
+
+	LiveSectors       uint64
+	ActiveSectors     uint64
+	FaultySectors     uint64
+	RecoveringSectors uint64
+}
+
 var EmptyVesting = MsigVesting{
 	InitialBalance: types.EmptyInt,
 	StartEpoch:     -1,
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+// SealingService is the API of external services which seal whole sectors on
+// behalf of miners, see sectorstorage.SealingService.
+type SealingService interface {
+	Version(context.Context) (Version, error) //perm:read
+
+	// SealPreCommit starts PC1 and PC2 of a sector, fetching the unsealed
+	// sector from the URLs in the request. It's a no-op for sectors already
+	// being sealed.
+	SealPreCommit(context.Context, storiface.SealPreCommitRequest) error //perm:write
+
+	// SealCommit starts C1 and C2 of a precommitted sector.
+	SealCommit(ctx context.Context, sector abi.SectorID, seed abi.InteractiveSealRandomness) error //perm:write
+
+	SealStatus(context.Context, abi.SectorID) (storiface.SealJob, error) //perm:read
+
+	// SealRelease removes all data of the sector from the service.
+	SealRelease(context.Context, abi.SectorID) error //perm:write
+}
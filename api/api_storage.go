@@ -12,19 +12,24 @@ import (
 	"github.com/libp2p/go-libp2p-core/peer"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
 	datatransfer "github.com/filecoin-project/go-data-transfer"
 	"github.com/filecoin-project/go-fil-markets/piecestore"
 	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
 	"github.com/filecoin-project/go-fil-markets/storagemarket"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/crypto"
 	"github.com/filecoin-project/specs-actors/v2/actors/builtin/market"
 	"github.com/filecoin-project/specs-storage/storage"
 
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/extern/sector-storage/fsutil"
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
 	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
 	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
 	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
+	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/lib/subscription"
 )
 
 //                       MODIFYING THE API INTERFACE
@@ -43,17 +48,47 @@ type StorageMiner interface {
 	Common
 
 	ActorAddress(context.Context) (address.Address, error) //perm:read
+	// ActorAddresses returns the addresses of all miner actors sealing with
+	// this node, the primary miner first
+	ActorAddresses(context.Context) ([]address.Address, error) //perm:read
 
 	ActorSectorSize(context.Context, address.Address) (abi.SectorSize, error) //perm:read
 	ActorAddressConfig(ctx context.Context) (AddressConfig, error)            //perm:read
+	// ActorSpending attributes the funds spent on gas by the addresses of a
+	// miner actor between two epochs (inclusive) to categories of messages
+	ActorSpending(ctx context.Context, maddr address.Address, from, to abi.ChainEpoch) (*MinerSpending, error) //perm:read
 
 	MiningBase(context.Context) (*types.TipSet, error) //perm:read
+	// MiningAttempts returns the recent attempts of the miner to mine blocks,
+	// oldest first
+	MiningAttempts(context.Context) ([]MiningAttempt, error) //perm:read
+	// MinerReport summarizes the performance of a miner actor between two
+	// epochs (inclusive), from the chain and the journal of this node
+	MinerReport(ctx context.Context, maddr address.Address, from, to abi.ChainEpoch) (*MinerReport, error) //perm:read
+	// MinerOverview aggregates the actor info, balances, sealing pipeline,
+	// proving state, deals and alerts of the miner in one call, for
+	// dashboards polling many miners
+	MinerOverview(ctx context.Context) (*MinerOverview, error) //perm:read
 
 	// Temp api for testing
 	PledgeSector(context.Context) (abi.SectorID, error) //perm:write
+	// PledgeSectorFor pledges a sector for the primary or one of the additional
+	// miner actors
+	PledgeSectorFor(context.Context, address.Address) (abi.SectorID, error) //perm:write
+	// SectorAddPieceToAny adds a deal piece to a sector accepting deals,
+	// creating one if needed. Used by markets running in a separate process.
+	SectorAddPieceToAny(ctx context.Context, size abi.UnpaddedPieceSize, r storage.Data, d PieceDealInfo) (SectorOffset, error) //perm:admin
 
 	// Get the status of a given sector by ID
 	SectorsStatus(ctx context.Context, sid abi.SectorNumber, showOnChainInfo bool) (SectorInfo, error) //perm:read
+	// SectorLog returns the full history of a sector: its state transitions,
+	// the messages sent for it and the errors it hit, oldest first. Unlike
+	// the event log in SectorInfo it isn't truncated
+	SectorLog(ctx context.Context, sid abi.SectorNumber) ([]SectorLogEntry, error) //perm:read
+	// SectorReceipts returns the work receipts signed by the sealing service
+	// which produced the replica and proof of the sector, see
+	// storiface.WorkReceipt
+	SectorReceipts(ctx context.Context, sid abi.SectorNumber) ([]storiface.WorkReceipt, error) //perm:read
 
 	// List all staged sectors
 	SectorsList(context.Context) ([]abi.SectorNumber, error) //perm:read
@@ -64,6 +99,19 @@ type StorageMiner interface {
 	// List sectors in particular states
 	SectorsListInStates(context.Context, []SectorState) ([]abi.SectorNumber, error) //perm:read
 
+	// SectorsStateChanges streams the state transitions of the sectors
+	// matching the filter, of all miner actors of the node, as they happen.
+	// Transitions are dropped when the client doesn't keep up
+	SectorsStateChanges(ctx context.Context, filter SectorStateFilter) (<-chan SectorStateChange, error) //perm:read
+
+	// SectorsStateChangesWithPolicy is like SectorsStateChanges, but with the
+	// given buffering policy, which decides what happens to transitions when
+	// the client falls behind: 'drop-oldest' drops the oldest buffered
+	// transitions, 'coalesce' merges all buffered transitions into a single
+	// one per sector, from its first to its latest state, and 'disconnect'
+	// closes the channel.
+	SectorsStateChangesWithPolicy(ctx context.Context, filter SectorStateFilter, policy subscription.Policy) (<-chan SectorStateChange, error) //perm:read
+
 	SectorsRefs(context.Context) (map[string][]SealedRef, error) //perm:read
 
 	// SectorStartSealing can be called on sectors in Empty or WaitDeals states
@@ -83,6 +131,9 @@ type StorageMiner interface {
 	// SectorRemove removes the sector from storage. It doesn't terminate it on-chain, which can
 	// be done with SectorTerminate. Removing and not terminating live sectors will cause additional penalties.
 	SectorRemove(context.Context, abi.SectorNumber) error //perm:admin
+	// SectorSetLabels sets user-defined labels on the sector, labels with an
+	// empty value are removed
+	SectorSetLabels(context.Context, abi.SectorNumber, map[string]string) error //perm:write
 	// SectorTerminate terminates the sector on-chain (adding it to a termination batch first), then
 	// automatically removes it from storage
 	SectorTerminate(context.Context, abi.SectorNumber) error //perm:admin
@@ -92,6 +143,9 @@ type StorageMiner interface {
 	// SectorTerminatePending returns a list of pending sector terminations to be sent in the next batch message
 	SectorTerminatePending(ctx context.Context) ([]abi.SectorID, error)  //perm:admin
 	SectorMarkForUpgrade(ctx context.Context, id abi.SectorNumber) error //perm:admin
+	// SectorRegenerateProof recomputes the proof of a sector in the CommitFailed
+	// or ComputeProofFailed state, and commits it again
+	SectorRegenerateProof(ctx context.Context, id abi.SectorNumber) error //perm:admin
 	// SectorPreCommitFlush immediately sends a PreCommit message with sectors batched for PreCommit.
 	// Returns null if message wasn't sent
 	SectorPreCommitFlush(ctx context.Context) ([]sealiface.PreCommitBatchRes, error) //perm:admin
@@ -102,6 +156,51 @@ type StorageMiner interface {
 	SectorCommitFlush(ctx context.Context) ([]sealiface.CommitBatchRes, error) //perm:admin
 	// SectorCommitPending returns a list of pending Commit sectors to be sent in the next aggregate message
 	SectorCommitPending(ctx context.Context) ([]abi.SectorID, error) //perm:admin
+	// SectorBatchStatus returns the number of sectors queued for the next
+	// PreCommit and Commit batch messages, and when the batches get sent at
+	// the latest
+	SectorBatchStatus(ctx context.Context) (SectorBatchStatus, error) //perm:read
+	// SectorNumReservations returns the sector numbers reserved by name,
+	// which aren't assigned to new sectors
+	SectorNumReservations(ctx context.Context) (map[string]bitfield.BitField, error) //perm:read
+	// SectorNumReserve reserves sector numbers under a name, e.g. for another
+	// sealing cluster sealing sectors of the same miner. Unless forced, the
+	// numbers must not be reserved under another name, or used by sectors of
+	// this miner
+	SectorNumReserve(ctx context.Context, name string, numbers bitfield.BitField, force bool) error //perm:admin
+	// SectorNumFree drops the sector number reservation with the given name
+	SectorNumFree(ctx context.Context, name string) error //perm:admin
+	// SectorsUnsealQueue returns the running and queued unseals, with their
+	// estimated completion time
+	SectorsUnsealQueue(ctx context.Context) ([]storiface.UnsealJob, error) //perm:read
+	// SectorsUnsealPiece unseals a range of a sector, waiting for its turn
+	// in the unseal queue
+	SectorsUnsealPiece(ctx context.Context, sector storage.SectorRef, offset storiface.UnpaddedByteIndex, size abi.UnpaddedPieceSize, randomness abi.SealRandomness, commd *cid.Cid) error //perm:admin
+	// SectorsETA projects when sectors in the sealing pipeline will be
+	// proving, from the time sectors spent in each state since the miner
+	// started and the scheduler queue
+	SectorsETA(ctx context.Context) ([]SectorETA, error) //perm:read
+	// SealingForecast projects sealing throughput in sectors per day
+	SealingForecast(ctx context.Context) (SealingForecast, error) //perm:read
+	// SealingFSMGraph returns the sealing state machine graph, with the number
+	// of sectors currently in each state and the mean time sectors spent in
+	// them since the miner started
+	SealingFSMGraph(ctx context.Context) (SealingFSMGraph, error) //perm:read
+
+	// MaintenanceSet enables or disables maintenance mode, which pauses
+	// dispatching new sealing tasks to workers, accepting storage deals and
+	// sending batched messages. Window and winning PoSt aren't affected
+	MaintenanceSet(ctx context.Context, enabled bool) error //perm:admin
+	// MaintenanceMode returns whether the miner is in maintenance mode
+	MaintenanceMode(ctx context.Context) (bool, error) //perm:read
+	// FeeBreakerStatus returns the state of the base fee circuit breaker,
+	// which defers messages that aren't time-critical while the base fee is
+	// high
+	FeeBreakerStatus(ctx context.Context) (FeeBreakerStatus, error) //perm:read
+
+	// ConfigReload re-reads the sealing and fee settings from the config file,
+	// and makes the sealing pipelines apply them
+	ConfigReload(ctx context.Context) error //perm:admin
 
 	// WorkerConnect tells the node to connect to workers RPC
 	WorkerConnect(context.Context, string) error                              //perm:admin retry:true
@@ -140,6 +239,16 @@ type StorageMiner interface {
 	StorageLocal(ctx context.Context) (map[stores.ID]string, error)       //perm:admin
 	StorageStat(ctx context.Context, id stores.ID) (fsutil.FsStat, error) //perm:admin
 
+	// StorageMoveSector starts moving the sealed and cache files of a sector
+	// to another local storage path in the background. The sector stays
+	// provable while it's moved.
+	StorageMoveSector(ctx context.Context, sector abi.SectorNumber, dest stores.ID) error //perm:admin
+	// StorageMoveList lists sector storage moves since the node started
+	StorageMoveList(ctx context.Context) ([]stores.SectorMove, error) //perm:admin
+	// StorageTierList lists the tiering state of sectors stored in tiered
+	// storage groups
+	StorageTierList(ctx context.Context) ([]stores.SectorTier, error) //perm:admin
+
 	MarketImportDealData(ctx context.Context, propcid cid.Cid, path string) error                                                                                                        //perm:write
 	MarketListDeals(ctx context.Context) ([]MarketDeal, error)                                                                                                                           //perm:read
 	MarketListRetrievalDeals(ctx context.Context) ([]retrievalmarket.ProviderDealState, error)                                                                                           //perm:read
@@ -151,6 +260,12 @@ type StorageMiner interface {
 	MarketGetRetrievalAsk(ctx context.Context) (*retrievalmarket.Ask, error)                                                                                                             //perm:read
 	MarketListDataTransfers(ctx context.Context) ([]DataTransferChannel, error)                                                                                                          //perm:write
 	MarketDataTransferUpdates(ctx context.Context) (<-chan DataTransferChannel, error)                                                                                                   //perm:write
+	// MarketSetRetrievalPricingPolicy sets the policy pricing retrievals
+	// depending on the client and the retrieved piece. As the retrieval ask is
+	// quoted to all clients, it's set to the lowest price of the policy when
+	// the policy is enabled.
+	MarketSetRetrievalPricingPolicy(ctx context.Context, policy dtypes.RetrievalPricingPolicy) error //perm:admin
+	MarketGetRetrievalPricingPolicy(ctx context.Context) (dtypes.RetrievalPricingPolicy, error)      //perm:read
 	// MarketRestartDataTransfer attempts to restart a data transfer with the given transfer ID and other peer
 	MarketRestartDataTransfer(ctx context.Context, transferID datatransfer.TransferID, otherPeer peer.ID, isInitiator bool) error //perm:write
 	// MarketCancelDataTransfer cancels a data transfer with the given transfer ID and other peer
@@ -187,15 +302,379 @@ type StorageMiner interface {
 	// LOTUS_BACKUP_BASE_PATH environment variable set to some path, and that
 	// the path specified when calling CreateBackup is within the base path
 	CreateBackup(ctx context.Context, fpath string) error //perm:admin
+	// CreateBackupIncremental adds a snapshot of the node metadata and
+	// keystore to the backup directory dir, holding only the metadata changed
+	// since the last snapshot in the directory. The directory must be within
+	// LOTUS_BACKUP_BASE_PATH, like the file of CreateBackup
+	CreateBackupIncremental(ctx context.Context, dir string) (*BackupSnapshot, error) //perm:admin
 
 	CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, expensive bool) (map[abi.SectorNumber]string, error) //perm:admin
 
 	ComputeProof(ctx context.Context, ssi []builtin.SectorInfo, rand abi.PoStRandomness) ([]builtin.PoStProof, error) //perm:read
+
+	// AlertsList returns all alerts known to the miner, active or not
+	AlertsList(ctx context.Context) ([]alerting.Alert, error) //perm:read
+	// AlertsAck acknowledges an active alert; it stays active until the
+	// underlying condition is resolved
+	AlertsAck(ctx context.Context, at alerting.AlertType) error //perm:write
+
+	// PayoutSchedule returns the scheduled payout settings, when the next
+	// payout is due and the outcome of the recent payouts
+	PayoutSchedule(ctx context.Context) (*PayoutSchedule, error) //perm:read
+
+	// ActorWorkerRotate proposes changing the worker key of the miner to
+	// newWorker, after checking that the key is in the wallet and can sign.
+	// The miner then confirms the change once it can be, and checks that the
+	// new worker can sign blocks.
+	ActorWorkerRotate(ctx context.Context, newWorker address.Address) (*WorkerRotation, error) //perm:admin
+	// ActorWorkerRotation returns the state of the last worker key rotation,
+	// or nil if none was started
+	ActorWorkerRotation(ctx context.Context) (*WorkerRotation, error) //perm:read
+
+	// ActorOwnerChangePrepare checks that newOwner exists on chain and is an
+	// account or a multisig, and returns a challenge which one of its keys
+	// must sign for ActorOwnerChangeConfirm
+	ActorOwnerChangePrepare(ctx context.Context, newOwner address.Address) (*OwnerChange, error) //perm:admin
+	// ActorOwnerChangeConfirm checks the signature of the prepared challenge
+	// and proposes the new owner from the current owner. It must be called
+	// before the prepared change expires. The new owner then has to accept
+	// the change
+	ActorOwnerChangeConfirm(ctx context.Context, sig crypto.Signature) (cid.Cid, error) //perm:admin
+
+	// ActorControlSetPlan checks addrs as the full list of control addresses
+	// of the miner, and compares it to the current list. Addresses with a
+	// balance below minBalance are reported in the warnings
+	ActorControlSetPlan(ctx context.Context, addrs []address.Address, minBalance abi.TokenAmount) (*ControlAddressPlan, error) //perm:read
+	// ActorControlSet replaces the control addresses of the miner with addrs
+	// in a single message, unless ActorControlSetPlan reports errors for them
+	ActorControlSet(ctx context.Context, addrs []address.Address) (cid.Cid, error) //perm:admin
 }
 
 var _ storiface.WorkerReturn = *new(StorageMiner)
 var _ stores.SectorIndex = *new(StorageMiner)
 
+// MiningAttempt is the outcome of the miner trying to mine a block for an epoch
+type MiningAttempt struct {
+	Epoch abi.ChainEpoch
+	Base  types.TipSetKey
+
+	// LateStart is set when mining started after the propagation delay of the
+	// base, usually because the node was behind the chain
+	LateStart bool
+	Eligible  bool
+	Won       bool
+
+	// ProofMargin is the time left until the propagation deadline of the block
+	// once the winning PoSt was computed
+	ProofTook   time.Duration `json:",omitempty"`
+	ProofMargin time.Duration `json:",omitempty"`
+
+	Error string `json:",omitempty"`
+}
+
+// MinerReport summarizes the performance of a miner actor over a range of
+// epochs
+type MinerReport struct {
+	Miner address.Address
+	From  abi.ChainEpoch
+	To    abi.ChainEpoch
+
+	Blocks    MinerReportBlocks
+	PoSt      MinerReportPoSt
+	Faults    MinerReportFaults
+	Gas       []MinerReportGas // by method, most expensive first
+	Onboarded MinerReportOnboarding
+}
+
+type MinerReportBlocks struct {
+	// Won is the number of blocks mined by the miner on the canonical chain,
+	// and WinCount the number of elections won with them
+	Won      int
+	WinCount int64
+
+	// Expected is the number of elections the miner was expected to win given
+	// its share of the network power, in the epochs it was eligible to mine
+	Expected float64
+}
+
+type MinerReportPoSt struct {
+	// Submitted and Failed count the SubmitWindowedPoSt messages executed on
+	// chain
+	Submitted int
+	Failed    int
+
+	// AvgDelay and MaxDelay are the number of epochs between the opening of a
+	// deadline and the inclusion of its proofs
+	AvgDelay float64
+	MaxDelay abi.ChainEpoch
+
+	// Scheduler counts the window PoSt cycles recorded in the journal, by
+	// outcome (started, succeeded, faulted, aborted)
+	Scheduler map[string]int
+}
+
+type MinerReportFaults struct {
+	// faulty sectors at the start and the end of the range
+	FaultyStart uint64
+	FaultyEnd   uint64
+
+	// sectors declared faulty or recovered by this node, from the journal
+	DeclaredFaulty    uint64
+	DeclaredRecovered uint64
+
+	// Penalties are the funds burnt by the miner actor when executing the
+	// messages of the miner, e.g. termination fees and repaid fee debt. Fault
+	// fees charged at the end of deadlines are included once repaid
+	Penalties abi.TokenAmount
+	// FeeDebt is the debt of the miner actor at the end of the range
+	FeeDebt abi.TokenAmount
+}
+
+type MinerReportGas struct {
+	Method   string
+	Messages int
+	GasUsed  int64
+	// Cost includes the burnt base fee, the overestimation burn and the tip
+	// paid to the block miner
+	Cost abi.TokenAmount
+}
+
+type MinerReportOnboarding struct {
+	// sectors which were activated in the range, and are still on chain at
+	// its end
+	Sectors   int
+	WithDeals int
+	RawPower  abi.StoragePower
+}
+
+// MinerOverview is the state of a miner at a glance. Sections are loaded
+// independently, a section which failed to load is left empty with the
+// reason in Errors.
+type MinerOverview struct {
+	Miner  address.Address
+	Height abi.ChainEpoch // chain head the on-chain state was read at
+
+	Owner            address.Address
+	Worker           address.Address
+	ControlAddresses []address.Address
+	SectorSize       abi.SectorSize
+
+	Power    MinerOverviewPower
+	Balances MinerOverviewBalances
+	Sectors  MinerOverviewSectors
+	Proving  MinerOverviewProving
+	Deals    *MinerOverviewDeals // nil when the markets subsystem isn't enabled
+	Alerts   MinerOverviewAlerts
+
+	// Errors has the sections which failed to load, by name
+	Errors map[string]string `json:",omitempty"`
+}
+
+type MinerOverviewPower struct {
+	RawBytePower           abi.StoragePower
+	QualityAdjPower        abi.StoragePower
+	NetworkQualityAdjPower abi.StoragePower
+	HasMinPower            bool
+}
+
+type MinerOverviewBalances struct {
+	Miner             abi.TokenAmount
+	PreCommitDeposits abi.TokenAmount
+	InitialPledge     abi.TokenAmount
+	Vesting           abi.TokenAmount
+	Available         abi.TokenAmount
+	FeeDebt           abi.TokenAmount
+
+	MarketEscrow abi.TokenAmount
+	MarketLocked abi.TokenAmount
+
+	Owner  abi.TokenAmount
+	Worker abi.TokenAmount
+	// sum of the control address balances
+	Control abi.TokenAmount
+}
+
+type MinerOverviewSectors struct {
+	// on chain
+	Live       uint64
+	Active     uint64
+	Faulty     uint64
+	Recovering uint64
+
+	// Pipeline counts the sectors tracked by the sealing subsystem by state,
+	// it's nil when the sealing subsystem isn't enabled
+	Pipeline map[SectorState]int
+	// sectors in failed states, which need retries or operator intervention
+	Failed int
+}
+
+type MinerOverviewProving struct {
+	PeriodStart abi.ChainEpoch
+	// current deadline
+	Deadline uint64
+	Open     abi.ChainEpoch
+	Close    abi.ChainEpoch
+}
+
+type MinerOverviewDeals struct {
+	// deals not in an error state
+	Total       int
+	Active      int
+	Verified    int // active verified deals
+	Failed      int
+	ActiveBytes abi.PaddedPieceSize
+}
+
+type MinerOverviewAlerts struct {
+	Active int
+	// active alerts which weren't acknowledged
+	Unacked int
+	// Types are the types of the active alerts
+	Types []string
+}
+
+// Categories of the messages sent by miners, for spending accounting
+const (
+	SpendingPreCommit = "precommit"
+	SpendingCommit    = "commit"
+	SpendingPoSt      = "post"
+	SpendingPublish   = "publish"
+	SpendingOther     = "other"
+)
+
+type MinerSpending struct {
+	Miner address.Address
+	From  abi.ChainEpoch
+	To    abi.ChainEpoch
+
+	// Entries aggregate spending per day (UTC), sending address and category,
+	// oldest first
+	Entries []SpendingEntry
+	// Deadlines aggregate the spending on window PoSt submissions per
+	// deadline
+	Deadlines []DeadlineSpending
+}
+
+type SpendingEntry struct {
+	Day      string // YYYY-MM-DD
+	Address  address.Address
+	Category string
+	Messages int
+	// Spent includes the burnt base fee, the overestimation burn and the
+	// tip paid to the block miner
+	Spent abi.TokenAmount
+}
+
+type DeadlineSpending struct {
+	Deadline uint64
+	Messages int
+	Spent    abi.TokenAmount
+}
+
+type PayoutSchedule struct {
+	Interval  time.Duration
+	Float     abi.TokenAmount
+	MinAmount abi.TokenAmount
+	Shares    []PayoutShare
+	Next      time.Time
+
+	// History holds the recent payouts, most recent first
+	History []PayoutRecord
+}
+
+type PayoutShare struct {
+	Address address.Address
+	Percent uint64
+}
+
+type PayoutRecord struct {
+	Time      time.Time
+	Available abi.TokenAmount
+	Withdrawn abi.TokenAmount
+
+	WithdrawMessage *cid.Cid        `json:",omitempty"`
+	Payments        []PayoutPayment `json:",omitempty"`
+
+	// Skipped is set when not enough was available above the float
+	Skipped bool   `json:",omitempty"`
+	Error   string `json:",omitempty"`
+}
+
+type PayoutPayment struct {
+	To      address.Address
+	Amount  abi.TokenAmount
+	Message cid.Cid
+}
+
+// ControlAddressPlan is the outcome of checking a new list of control
+// addresses with ActorControlSetPlan
+type ControlAddressPlan struct {
+	// Addresses are the checked addresses, in the order of the new list
+	Addresses []ControlAddressCheck
+
+	// Keep, Add and Remove are the ID addresses kept from the current list,
+	// added to it and removed from it
+	Keep   []address.Address
+	Add    []address.Address
+	Remove []address.Address
+
+	// Errors prevent setting the list, warnings don't
+	Errors   []string
+	Warnings []string
+}
+
+type ControlAddressCheck struct {
+	ID       address.Address
+	Key      address.Address
+	Balance  abi.TokenAmount
+	InWallet bool
+}
+
+// OwnerChange is an owner change prepared with ActorOwnerChangePrepare
+type OwnerChange struct {
+	OldOwner address.Address
+	NewOwner address.Address // ID address
+
+	// Signers are the keys which can sign the challenge, the key of an
+	// account or the keys of the signers of a multisig
+	Signers   []address.Address
+	Challenge []byte
+	Expires   time.Time
+}
+
+type WorkerRotationPhase string
+
+const (
+	// WorkerRotationProposed is waiting for the change to become effective
+	WorkerRotationProposed WorkerRotationPhase = "proposed"
+	// WorkerRotationConfirming has the confirmation message sent
+	WorkerRotationConfirming WorkerRotationPhase = "confirming"
+	// WorkerRotationDone has the new worker active and able to sign blocks
+	WorkerRotationDone   WorkerRotationPhase = "done"
+	WorkerRotationFailed WorkerRotationPhase = "failed"
+)
+
+// WorkerRotation is the state of a worker key change started with
+// ActorWorkerRotate
+type WorkerRotation struct {
+	OldWorker address.Address
+	NewWorker address.Address // ID address
+	NewKey    address.Address
+
+	Phase   WorkerRotationPhase
+	Started time.Time
+
+	// EffectiveEpoch is the epoch from which the change can be confirmed
+	EffectiveEpoch abi.ChainEpoch
+
+	// ProposeMessage is unset when a change proposed before was picked up
+	ProposeMessage *cid.Cid `json:",omitempty"`
+	ConfirmMessage *cid.Cid `json:",omitempty"`
+
+	Finished time.Time
+	Error    string `json:",omitempty"`
+}
+
 type SealRes struct {
 	Err   string
 	GoErr error `json:"-"`
@@ -212,6 +691,25 @@ type SectorLog struct {
 	Message string
 }
 
+// SectorLogEntry is an entry of the persisted sector history
+type SectorLogEntry struct {
+	Time time.Time
+	// state, message or error
+	Kind string
+	// the state machine event the entry was recorded for
+	Event string
+
+	From SectorState `json:",omitempty"`
+	To   SectorState `json:",omitempty"`
+
+	// e.g. PreCommit or Commit
+	MessageType string   `json:",omitempty"`
+	Message     *cid.Cid `json:",omitempty"`
+
+	Error     string              `json:",omitempty"`
+	ErrorCode sealiface.ErrorCode `json:",omitempty"`
+}
+
 type SectorInfo struct {
 	SectorID     abi.SectorNumber
 	State        SectorState
@@ -228,7 +726,8 @@ type SectorInfo struct {
 
 	LastErr string
 
-	Log []SectorLog
+	Log    []SectorLog
+	Labels map[string]string
 
 	// On Chain Info
 	SealProof          abi.RegisteredSealProof // The seal proof type implies the PoSt proof/s
@@ -244,6 +743,127 @@ type SectorInfo struct {
 	Early abi.ChainEpoch
 }
 
+type SectorETA struct {
+	SectorID abi.SectorNumber
+	State    SectorState
+
+	// ETA is the projected time the sector will be proving. When Known is
+	// false, some of the remaining states have no duration history yet, and
+	// ETA is the earliest projected time
+	ETA   time.Time
+	Known bool
+
+	// set when the sector's task waits in the scheduler queue
+	QueuedTask   sealtasks.TaskType `json:",omitempty"`
+	QueuedBehind int                // tasks of the same type queued ahead of it
+}
+
+type SealingForecast struct {
+	// sectors in the sealing pipeline
+	InFlight int
+	// in-flight sectors projected to be proving within a day
+	ProjectedNextDay int
+	// rate at which sectors started proving over the last day, or since the
+	// miner started if that was more recent
+	ObservedPerDay float64
+	// moving average of the time sectors spent in each state, since the
+	// miner started
+	StateDurations map[SectorState]time.Duration
+}
+
+type FeeBreakerStatus struct {
+	// false when no trip threshold is configured
+	Enabled bool
+	Tripped bool
+	// when the breaker last tripped or reset
+	Since time.Time
+
+	// base fee last seen by the breaker
+	BaseFee      abi.TokenAmount
+	TripBaseFee  abi.TokenAmount
+	ResetBaseFee abi.TokenAmount
+
+	// sectors of the held precommit and commit batches, and the deferred
+	// withdrawals, by kind
+	Deferred map[string]int
+}
+
+type SealingFSMGraph struct {
+	States      []SealingFSMState
+	Transitions []SealingFSMTransition
+}
+
+type SealingFSMState struct {
+	State SectorState
+	// sectors currently in the state
+	Sectors int
+	// moving average of the time sectors spent in the state, computed from
+	// Samples sectors leaving it since the miner started
+	MeanDwell time.Duration
+	Samples   int
+}
+
+// SealingFSMTransition is an edge of the sealing state machine graph, taken
+// when a sector in the From state receives the Event. Global transitions can
+// be taken from any state.
+type SealingFSMTransition struct {
+	From   SectorState `json:",omitempty"`
+	Event  string
+	To     SectorState
+	Global bool `json:",omitempty"`
+}
+
+// SectorStateChange is a transition of the sealing state machine of a sector
+type SectorStateChange struct {
+	Miner        address.Address
+	SectorNumber abi.SectorNumber
+	From         SectorState
+	To           SectorState
+	// Error is the last error of the sector, e.g. the cause of a transition
+	// to a failure state
+	Error string `json:",omitempty"`
+	Time  time.Time
+}
+
+// SectorStateFilter selects sector state changes, empty fields match all
+// changes
+type SectorStateFilter struct {
+	Sectors []abi.SectorNumber
+	// States matches transitions to these states
+	States []SectorState
+}
+
+func (f SectorStateFilter) Matches(c SectorStateChange) bool {
+	if len(f.Sectors) > 0 {
+		var found bool
+		for _, sn := range f.Sectors {
+			if sn == c.SectorNumber {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.States) > 0 {
+		for _, st := range f.States {
+			if st == c.To {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+type SectorBatchStatus struct {
+	PreCommit sealiface.BatchStatus
+	Commit    sealiface.BatchStatus
+}
+
 type SealedRef struct {
 	SectorID abi.SectorNumber
 	Offset   abi.PaddedPieceSize
@@ -254,6 +874,40 @@ type SealedRefs struct {
 	Refs []SealedRef
 }
 
+// PieceDealInfo identifies the deal of a piece added to a sector, see
+// sealing.DealInfo
+type PieceDealInfo struct {
+	PublishCid   *cid.Cid
+	DealID       abi.DealID
+	DealProposal *market.DealProposal
+	DealSchedule DealSchedule
+	KeepUnsealed bool
+}
+
+// DealSchedule is the interval of a storage deal, see sealing.DealSchedule
+type DealSchedule struct {
+	StartEpoch abi.ChainEpoch
+	EndEpoch   abi.ChainEpoch
+}
+
+// SectorOffset is the location of a piece added to a sector
+type SectorOffset struct {
+	Sector abi.SectorNumber
+	Offset abi.PaddedPieceSize
+}
+
+// BackupSnapshot describes a snapshot added to an incremental backup directory
+type BackupSnapshot struct {
+	File string
+	// Full is set for the first snapshot of the directory, which holds all
+	// metadata
+	Full bool
+	// Entries is the number of metadata entries added or changed
+	Entries int
+	// Deleted is the number of metadata entries deleted
+	Deleted int
+}
+
 type SealTicket struct {
 	Value abi.SealRandomness
 	Epoch abi.ChainEpoch
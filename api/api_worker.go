@@ -10,6 +10,7 @@ import (
 	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
 	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
 	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
 	"github.com/filecoin-project/specs-storage/storage"
 )
 
@@ -32,6 +33,10 @@ type Worker interface {
 	Paths(context.Context) ([]stores.StoragePath, error)                //perm:admin
 	Info(context.Context) (storiface.WorkerInfo, error)                 //perm:admin
 
+	// GPUUsage returns the tasks holding each GPU of the worker, and the share
+	// of time each GPU was held
+	GPUUsage(context.Context) ([]storiface.GPUUsage, error) //perm:admin
+
 	// storiface.WorkerCalls
 	AddPiece(ctx context.Context, sector storage.SectorRef, pieceSizes []abi.UnpaddedPieceSize, newPieceSize abi.UnpaddedPieceSize, pieceData storage.Data) (storiface.CallID, error)                    //perm:admin
 	SealPreCommit1(ctx context.Context, sector storage.SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) (storiface.CallID, error)                                                           //perm:admin
@@ -44,6 +49,13 @@ type Worker interface {
 	UnsealPiece(context.Context, storage.SectorRef, storiface.UnpaddedByteIndex, abi.UnpaddedPieceSize, abi.SealRandomness, cid.Cid) (storiface.CallID, error)                                           //perm:admin
 	Fetch(context.Context, storage.SectorRef, storiface.SectorFileType, storiface.PathType, storiface.AcquireMode) (storiface.CallID, error)                                                             //perm:admin
 
+	// Proof verification, only available on workers with the verify task
+	// enabled, used by full nodes to offload verifying proofs in blocks
+	VerifySeal(ctx context.Context, info proof5.SealVerifyInfo) (bool, error)                                  //perm:admin
+	VerifyAggregateSeals(ctx context.Context, aggregate proof5.AggregateSealVerifyProofAndInfos) (bool, error) //perm:admin
+	VerifyWinningPoSt(ctx context.Context, info proof5.WinningPoStVerifyInfo) (bool, error)                    //perm:admin
+	VerifyWindowPoSt(ctx context.Context, info proof5.WindowPoStVerifyInfo) (bool, error)                      //perm:admin
+
 	TaskDisable(ctx context.Context, tt sealtasks.TaskType) error //perm:admin
 	TaskEnable(ctx context.Context, tt sealtasks.TaskType) error  //perm:admin
 
@@ -61,6 +73,14 @@ type Worker interface {
 	// WaitQuiet blocks until there are no tasks running
 	WaitQuiet(ctx context.Context) error //perm:admin
 
+	// RunningTasks returns the tasks the worker is processing, oldest first
+	RunningTasks(ctx context.Context) ([]storiface.WorkerJob, error) //perm:admin
+
+	// Shutdown deregisters the worker from the miner and stops the worker
+	// process. It fails when tasks are still running, disable the worker and
+	// wait for running tasks to finish first
+	Shutdown(ctx context.Context) error //perm:admin
+
 	// returns a random UUID of worker session, generated randomly when worker
 	// process starts
 	ProcessSession(context.Context) (uuid.UUID, error) //perm:admin
@@ -20,11 +20,13 @@ func _() {
 	_ = x[CheckStatusMessageNonce-10]
 	_ = x[CheckStatusMessageGetStateBalance-11]
 	_ = x[CheckStatusMessageBalance-12]
+	_ = x[CheckStatusMessageRecipient-13]
+	_ = x[CheckStatusMessageValue-14]
 }
 
-const _CheckStatusCode_name = "MessageSerializeMessageSizeMessageValidityMessageMinGasMessageMinBaseFeeMessageBaseFeeMessageBaseFeeLowerBoundMessageBaseFeeUpperBoundMessageGetStateNonceMessageNonceMessageGetStateBalanceMessageBalance"
+const _CheckStatusCode_name = "MessageSerializeMessageSizeMessageValidityMessageMinGasMessageMinBaseFeeMessageBaseFeeMessageBaseFeeLowerBoundMessageBaseFeeUpperBoundMessageGetStateNonceMessageNonceMessageGetStateBalanceMessageBalanceMessageRecipientMessageValue"
 
-var _CheckStatusCode_index = [...]uint8{0, 16, 27, 42, 55, 72, 86, 110, 134, 154, 166, 188, 202}
+var _CheckStatusCode_index = [...]uint8{0, 16, 27, 42, 55, 72, 86, 110, 134, 154, 166, 188, 202, 218, 230}
 
 func (i CheckStatusCode) String() string {
 	i -= 1
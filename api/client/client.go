@@ -135,3 +135,16 @@ func NewWalletRPCV0(ctx context.Context, addr string, requestHeader http.Header)
 
 	return &res, closer, err
 }
+
+// NewSealingServiceRPCV0 creates a new http jsonrpc client for a sealing service
+func NewSealingServiceRPCV0(ctx context.Context, addr string, requestHeader http.Header) (api.SealingService, jsonrpc.ClientCloser, error) {
+	var res api.SealingServiceStruct
+	closer, err := jsonrpc.NewMergeClient(ctx, addr, "Filecoin",
+		[]interface{}{
+			&res.Internal,
+		},
+		requestHeader,
+	)
+
+	return &res, closer, err
+}
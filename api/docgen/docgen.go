@@ -42,6 +42,8 @@ import (
 	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
 	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
 	sealing "github.com/filecoin-project/lotus/extern/storage-sealing"
+	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
+	"github.com/filecoin-project/lotus/lib/subscription"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 )
 
@@ -92,6 +94,7 @@ func init() {
 
 	addExample(bitfield.NewFromSet([]uint64{5}))
 	addExample(abi.RegisteredSealProof_StackedDrg32GiBV1_1)
+	addExample(abi.RegisteredAggregationProof_SnarkPackV1)
 	addExample(abi.RegisteredPoStProof_StackedDrgWindow32GiBV1)
 	addExample(abi.ChainEpoch(10101))
 	addExample(crypto.SigTypeBLS)
@@ -126,7 +129,10 @@ func init() {
 	addExample(network.ReachabilityPublic)
 	addExample(build.NewestNetworkVersion)
 	addExample(map[string]int{"name": 42})
+	addExample(map[string]bitfield.BitField{"name": bitfield.NewFromSet([]uint64{5})})
+	addExample(map[string]string{"datacenter": "fra1"})
 	addExample(map[string]time.Time{"name": time.Unix(1615243938, 0).UTC()})
+	addExample(map[string]cid.Cid{"storageminer": c})
 	addExample(&types.ExecutionTrace{
 		Msg:    ExampleValue("init", reflect.TypeOf(&types.Message{}), nil).(*types.Message),
 		MsgRct: ExampleValue("init", reflect.TypeOf(&types.MessageReceipt{}), nil).(*types.MessageReceipt),
@@ -189,6 +195,7 @@ func init() {
 		},
 	})
 	addExample(api.SectorState(sealing.Proving))
+	addExample(api.WorkerRotationProposed)
 	addExample(stores.ID("76f1988b-ef30-4d7e-b3ec-9a627f4ba5a8"))
 	addExample(storiface.FTUnsealed)
 	addExample(storiface.PathSealing)
@@ -241,9 +248,20 @@ func init() {
 	addExample(map[abi.SectorNumber]string{
 		123: "can't acquire read lock",
 	})
+	addExample(sealiface.ErrPreCommitExpired)
+	addExample(map[abi.SectorNumber]sealiface.ErrorInfo{
+		123: {
+			Code:    sealiface.ErrPreCommitExpired,
+			Message: "[precommit-expired] precommit expired at epoch 10101",
+			Meta:    map[string]string{"expiry": "10101"},
+		},
+	})
 	addExample(map[api.SectorState]int{
 		api.SectorState(sealing.Proving): 120,
 	})
+	addExample(map[api.SectorState]time.Duration{
+		api.SectorState(sealing.PreCommit1): 4 * time.Hour,
+	})
 	addExample([]abi.SectorNumber{123, 124})
 
 	// worker specific
@@ -253,6 +271,9 @@ func init() {
 		sealtasks.TTPreCommit2: {},
 	})
 	addExample(sealtasks.TTCommit2)
+	addExample(map[sealtasks.TaskType][]int{
+		sealtasks.TTCommit2: {0},
+	})
 	addExample(apitypes.OpenRPCDocument{
 		"openrpc": "1.2.6",
 		"info": map[string]interface{}{
@@ -263,7 +284,9 @@ func init() {
 	)
 
 	addExample(api.CheckStatusCode(0))
+	addExample(api.MsgExecuted)
 	addExample(map[string]interface{}{"abc": 123})
+	addExample(subscription.Disconnect)
 }
 
 func GetAPIType(name, pkg string) (i interface{}, t, permStruct, commonPermStruct reflect.Type) {
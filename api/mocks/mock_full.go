@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	address "github.com/filecoin-project/go-address"
 	bitfield "github.com/filecoin-project/go-bitfield"
@@ -23,11 +24,15 @@ import (
 	api "github.com/filecoin-project/lotus/api"
 	apitypes "github.com/filecoin-project/lotus/api/types"
 	miner "github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	beacon "github.com/filecoin-project/lotus/chain/beacon"
 	types "github.com/filecoin-project/lotus/chain/types"
+	journal "github.com/filecoin-project/lotus/journal"
+	subscription "github.com/filecoin-project/lotus/lib/subscription"
 	marketevents "github.com/filecoin-project/lotus/markets/loggers"
 	dtypes "github.com/filecoin-project/lotus/node/modules/dtypes"
 	miner0 "github.com/filecoin-project/specs-actors/actors/builtin/miner"
 	paych "github.com/filecoin-project/specs-actors/actors/builtin/paych"
+	proof "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
 	cid "github.com/ipfs/go-cid"
@@ -60,6 +65,64 @@ func (m *MockFullNode) EXPECT() *MockFullNodeMockRecorder {
 	return m.recorder
 }
 
+// AddressBookGet mocks base method.
+func (m *MockFullNode) AddressBookGet(arg0 context.Context, arg1 string) (*api.AddressBookEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddressBookGet", arg0, arg1)
+	ret0, _ := ret[0].(*api.AddressBookEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddressBookGet indicates an expected call of AddressBookGet.
+func (mr *MockFullNodeMockRecorder) AddressBookGet(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddressBookGet", reflect.TypeOf((*MockFullNode)(nil).AddressBookGet), arg0, arg1)
+}
+
+// AddressBookList mocks base method.
+func (m *MockFullNode) AddressBookList(arg0 context.Context) ([]api.AddressBookEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddressBookList", arg0)
+	ret0, _ := ret[0].([]api.AddressBookEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddressBookList indicates an expected call of AddressBookList.
+func (mr *MockFullNodeMockRecorder) AddressBookList(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddressBookList", reflect.TypeOf((*MockFullNode)(nil).AddressBookList), arg0)
+}
+
+// AddressBookRemove mocks base method.
+func (m *MockFullNode) AddressBookRemove(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddressBookRemove", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddressBookRemove indicates an expected call of AddressBookRemove.
+func (mr *MockFullNodeMockRecorder) AddressBookRemove(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddressBookRemove", reflect.TypeOf((*MockFullNode)(nil).AddressBookRemove), arg0, arg1)
+}
+
+// AddressBookSet mocks base method.
+func (m *MockFullNode) AddressBookSet(arg0 context.Context, arg1 api.AddressBookEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddressBookSet", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddressBookSet indicates an expected call of AddressBookSet.
+func (mr *MockFullNodeMockRecorder) AddressBookSet(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddressBookSet", reflect.TypeOf((*MockFullNode)(nil).AddressBookSet), arg0, arg1)
+}
+
 // AuthNew mocks base method.
 func (m *MockFullNode) AuthNew(arg0 context.Context, arg1 []auth.Permission) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -105,6 +168,36 @@ func (mr *MockFullNodeMockRecorder) BeaconGetEntry(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeaconGetEntry", reflect.TypeOf((*MockFullNode)(nil).BeaconGetEntry), arg0, arg1)
 }
 
+// BeaconHealth mocks base method.
+func (m *MockFullNode) BeaconHealth(arg0 context.Context) (*beacon.Health, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeaconHealth", arg0)
+	ret0, _ := ret[0].(*beacon.Health)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeaconHealth indicates an expected call of BeaconHealth.
+func (mr *MockFullNodeMockRecorder) BeaconHealth(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeaconHealth", reflect.TypeOf((*MockFullNode)(nil).BeaconHealth), arg0)
+}
+
+// ChainBlockstoreGC mocks base method.
+func (m *MockFullNode) ChainBlockstoreGC(arg0 context.Context, arg1 api.BlockstoreGCOpts) (<-chan api.BlockstoreGCProgress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreGC", arg0, arg1)
+	ret0, _ := ret[0].(<-chan api.BlockstoreGCProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainBlockstoreGC indicates an expected call of ChainBlockstoreGC.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreGC(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreGC", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreGC), arg0, arg1)
+}
+
 // ChainDeleteObj mocks base method.
 func (m *MockFullNode) ChainDeleteObj(arg0 context.Context, arg1 cid.Cid) error {
 	m.ctrl.T.Helper()
@@ -134,6 +227,21 @@ func (mr *MockFullNodeMockRecorder) ChainExport(arg0, arg1, arg2, arg3 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainExport", reflect.TypeOf((*MockFullNode)(nil).ChainExport), arg0, arg1, arg2, arg3)
 }
 
+// ChainGasUsage mocks base method.
+func (m *MockFullNode) ChainGasUsage(arg0 context.Context, arg1 abi.ChainEpoch) (*api.GasUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainGasUsage", arg0, arg1)
+	ret0, _ := ret[0].(*api.GasUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainGasUsage indicates an expected call of ChainGasUsage.
+func (mr *MockFullNodeMockRecorder) ChainGasUsage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGasUsage", reflect.TypeOf((*MockFullNode)(nil).ChainGasUsage), arg0, arg1)
+}
+
 // ChainGetBlock mocks base method.
 func (m *MockFullNode) ChainGetBlock(arg0 context.Context, arg1 cid.Cid) (*types.BlockHeader, error) {
 	m.ctrl.T.Helper()
@@ -344,6 +452,21 @@ func (mr *MockFullNodeMockRecorder) ChainHead(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainHead", reflect.TypeOf((*MockFullNode)(nil).ChainHead), arg0)
 }
 
+// ChainLatestSnapshot mocks base method.
+func (m *MockFullNode) ChainLatestSnapshot(arg0 context.Context) (*api.SnapshotInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainLatestSnapshot", arg0)
+	ret0, _ := ret[0].(*api.SnapshotInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainLatestSnapshot indicates an expected call of ChainLatestSnapshot.
+func (mr *MockFullNodeMockRecorder) ChainLatestSnapshot(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainLatestSnapshot", reflect.TypeOf((*MockFullNode)(nil).ChainLatestSnapshot), arg0)
+}
+
 // ChainNotify mocks base method.
 func (m *MockFullNode) ChainNotify(arg0 context.Context) (<-chan []*api.HeadChange, error) {
 	m.ctrl.T.Helper()
@@ -359,6 +482,21 @@ func (mr *MockFullNodeMockRecorder) ChainNotify(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainNotify", reflect.TypeOf((*MockFullNode)(nil).ChainNotify), arg0)
 }
 
+// ChainNotifyWithPolicy mocks base method.
+func (m *MockFullNode) ChainNotifyWithPolicy(arg0 context.Context, arg1 subscription.Policy) (<-chan []*api.HeadChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainNotifyWithPolicy", arg0, arg1)
+	ret0, _ := ret[0].(<-chan []*api.HeadChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainNotifyWithPolicy indicates an expected call of ChainNotifyWithPolicy.
+func (mr *MockFullNodeMockRecorder) ChainNotifyWithPolicy(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainNotifyWithPolicy", reflect.TypeOf((*MockFullNode)(nil).ChainNotifyWithPolicy), arg0, arg1)
+}
+
 // ChainReadObj mocks base method.
 func (m *MockFullNode) ChainReadObj(arg0 context.Context, arg1 cid.Cid) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -388,6 +526,64 @@ func (mr *MockFullNodeMockRecorder) ChainSetHead(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainSetHead", reflect.TypeOf((*MockFullNode)(nil).ChainSetHead), arg0, arg1)
 }
 
+// ChainSnapshotImportProgress mocks base method.
+func (m *MockFullNode) ChainSnapshotImportProgress(arg0 context.Context) (*api.SnapshotImportProgress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainSnapshotImportProgress", arg0)
+	ret0, _ := ret[0].(*api.SnapshotImportProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainSnapshotImportProgress indicates an expected call of ChainSnapshotImportProgress.
+func (mr *MockFullNodeMockRecorder) ChainSnapshotImportProgress(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainSnapshotImportProgress", reflect.TypeOf((*MockFullNode)(nil).ChainSnapshotImportProgress), arg0)
+}
+
+// ChainSplitstorePauseCompaction mocks base method.
+func (m *MockFullNode) ChainSplitstorePauseCompaction(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainSplitstorePauseCompaction", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChainSplitstorePauseCompaction indicates an expected call of ChainSplitstorePauseCompaction.
+func (mr *MockFullNodeMockRecorder) ChainSplitstorePauseCompaction(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainSplitstorePauseCompaction", reflect.TypeOf((*MockFullNode)(nil).ChainSplitstorePauseCompaction), arg0)
+}
+
+// ChainSplitstoreResumeCompaction mocks base method.
+func (m *MockFullNode) ChainSplitstoreResumeCompaction(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainSplitstoreResumeCompaction", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChainSplitstoreResumeCompaction indicates an expected call of ChainSplitstoreResumeCompaction.
+func (mr *MockFullNodeMockRecorder) ChainSplitstoreResumeCompaction(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainSplitstoreResumeCompaction", reflect.TypeOf((*MockFullNode)(nil).ChainSplitstoreResumeCompaction), arg0)
+}
+
+// ChainSplitstoreStatus mocks base method.
+func (m *MockFullNode) ChainSplitstoreStatus(arg0 context.Context) (*api.SplitstoreStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainSplitstoreStatus", arg0)
+	ret0, _ := ret[0].(*api.SplitstoreStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainSplitstoreStatus indicates an expected call of ChainSplitstoreStatus.
+func (mr *MockFullNodeMockRecorder) ChainSplitstoreStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainSplitstoreStatus", reflect.TypeOf((*MockFullNode)(nil).ChainSplitstoreStatus), arg0)
+}
+
 // ChainStatObj mocks base method.
 func (m *MockFullNode) ChainStatObj(arg0 context.Context, arg1, arg2 cid.Cid) (api.ObjStat, error) {
 	m.ctrl.T.Helper()
@@ -860,6 +1056,21 @@ func (mr *MockFullNodeMockRecorder) Discover(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Discover", reflect.TypeOf((*MockFullNode)(nil).Discover), arg0)
 }
 
+// GasAdvisorFees mocks base method.
+func (m *MockFullNode) GasAdvisorFees(arg0 context.Context, arg1 types.TipSetKey) (*api.GasAdvice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GasAdvisorFees", arg0, arg1)
+	ret0, _ := ret[0].(*api.GasAdvice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GasAdvisorFees indicates an expected call of GasAdvisorFees.
+func (mr *MockFullNodeMockRecorder) GasAdvisorFees(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GasAdvisorFees", reflect.TypeOf((*MockFullNode)(nil).GasAdvisorFees), arg0, arg1)
+}
+
 // GasEstimateFeeCap mocks base method.
 func (m *MockFullNode) GasEstimateFeeCap(arg0 context.Context, arg1 *types.Message, arg2 int64, arg3 types.TipSetKey) (big.Int, error) {
 	m.ctrl.T.Helper()
@@ -935,6 +1146,21 @@ func (mr *MockFullNodeMockRecorder) ID(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ID", reflect.TypeOf((*MockFullNode)(nil).ID), arg0)
 }
 
+// JournalQuery mocks base method.
+func (m *MockFullNode) JournalQuery(arg0 context.Context, arg1 []journal.EventType, arg2, arg3 time.Time, arg4 int) ([]*journal.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JournalQuery", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]*journal.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// JournalQuery indicates an expected call of JournalQuery.
+func (mr *MockFullNodeMockRecorder) JournalQuery(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JournalQuery", reflect.TypeOf((*MockFullNode)(nil).JournalQuery), arg0, arg1, arg2, arg3, arg4)
+}
+
 // LogList mocks base method.
 func (m *MockFullNode) LogList(arg0 context.Context) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -1038,6 +1264,21 @@ func (mr *MockFullNodeMockRecorder) MarketWithdraw(arg0, arg1, arg2, arg3 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarketWithdraw", reflect.TypeOf((*MockFullNode)(nil).MarketWithdraw), arg0, arg1, arg2, arg3)
 }
 
+// MinerComputeBlockTemplate mocks base method.
+func (m *MockFullNode) MinerComputeBlockTemplate(arg0 context.Context, arg1 address.Address, arg2 abi.ChainEpoch, arg3 types.TipSetKey) (*api.BlockTemplateReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MinerComputeBlockTemplate", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.BlockTemplateReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MinerComputeBlockTemplate indicates an expected call of MinerComputeBlockTemplate.
+func (mr *MockFullNodeMockRecorder) MinerComputeBlockTemplate(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MinerComputeBlockTemplate", reflect.TypeOf((*MockFullNode)(nil).MinerComputeBlockTemplate), arg0, arg1, arg2, arg3)
+}
+
 // MinerCreateBlock mocks base method.
 func (m *MockFullNode) MinerCreateBlock(arg0 context.Context, arg1 *api.BlockTemplate) (*types.BlockMsg, error) {
 	m.ctrl.T.Helper()
@@ -1202,6 +1443,21 @@ func (mr *MockFullNodeMockRecorder) MpoolGetNonce(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolGetNonce", reflect.TypeOf((*MockFullNode)(nil).MpoolGetNonce), arg0, arg1)
 }
 
+// MpoolNonceGaps mocks base method.
+func (m *MockFullNode) MpoolNonceGaps(arg0 context.Context) ([]api.NonceGapReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolNonceGaps", arg0)
+	ret0, _ := ret[0].([]api.NonceGapReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolNonceGaps indicates an expected call of MpoolNonceGaps.
+func (mr *MockFullNodeMockRecorder) MpoolNonceGaps(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolNonceGaps", reflect.TypeOf((*MockFullNode)(nil).MpoolNonceGaps), arg0)
+}
+
 // MpoolPending mocks base method.
 func (m *MockFullNode) MpoolPending(arg0 context.Context, arg1 types.TipSetKey) ([]*types.SignedMessage, error) {
 	m.ctrl.T.Helper()
@@ -1262,6 +1518,21 @@ func (mr *MockFullNodeMockRecorder) MpoolPushUntrusted(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPushUntrusted", reflect.TypeOf((*MockFullNode)(nil).MpoolPushUntrusted), arg0, arg1)
 }
 
+// MpoolReorgs mocks base method.
+func (m *MockFullNode) MpoolReorgs(arg0 context.Context) (<-chan api.MpoolReorg, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolReorgs", arg0)
+	ret0, _ := ret[0].(<-chan api.MpoolReorg)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolReorgs indicates an expected call of MpoolReorgs.
+func (mr *MockFullNodeMockRecorder) MpoolReorgs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolReorgs", reflect.TypeOf((*MockFullNode)(nil).MpoolReorgs), arg0)
+}
+
 // MpoolSelect mocks base method.
 func (m *MockFullNode) MpoolSelect(arg0 context.Context, arg1 types.TipSetKey, arg2 float64) ([]*types.SignedMessage, error) {
 	m.ctrl.T.Helper()
@@ -1679,6 +1950,21 @@ func (mr *MockFullNodeMockRecorder) NetBlockRemove(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetBlockRemove", reflect.TypeOf((*MockFullNode)(nil).NetBlockRemove), arg0, arg1)
 }
 
+// NetClassify mocks base method.
+func (m *MockFullNode) NetClassify(arg0 context.Context) ([]api.NetPeerClass, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetClassify", arg0)
+	ret0, _ := ret[0].([]api.NetPeerClass)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetClassify indicates an expected call of NetClassify.
+func (mr *MockFullNodeMockRecorder) NetClassify(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetClassify", reflect.TypeOf((*MockFullNode)(nil).NetClassify), arg0)
+}
+
 // NetConnect mocks base method.
 func (m *MockFullNode) NetConnect(arg0 context.Context, arg1 peer.AddrInfo) error {
 	m.ctrl.T.Helper()
@@ -1767,6 +2053,35 @@ func (mr *MockFullNodeMockRecorder) NetPeers(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPeers", reflect.TypeOf((*MockFullNode)(nil).NetPeers), arg0)
 }
 
+// NetPropagation mocks base method.
+func (m *MockFullNode) NetPropagation(arg0 context.Context) (*api.PropagationReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetPropagation", arg0)
+	ret0, _ := ret[0].(*api.PropagationReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetPropagation indicates an expected call of NetPropagation.
+func (mr *MockFullNodeMockRecorder) NetPropagation(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPropagation", reflect.TypeOf((*MockFullNode)(nil).NetPropagation), arg0)
+}
+
+// NetPropagationReset mocks base method.
+func (m *MockFullNode) NetPropagationReset(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetPropagationReset", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NetPropagationReset indicates an expected call of NetPropagationReset.
+func (mr *MockFullNodeMockRecorder) NetPropagationReset(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPropagationReset", reflect.TypeOf((*MockFullNode)(nil).NetPropagationReset), arg0)
+}
+
 // NetPubsubScores mocks base method.
 func (m *MockFullNode) NetPubsubScores(arg0 context.Context) ([]api.PubsubScore, error) {
 	m.ctrl.T.Helper()
@@ -2080,6 +2395,51 @@ func (mr *MockFullNodeMockRecorder) StateAccountKey(arg0, arg1, arg2 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateAccountKey", reflect.TypeOf((*MockFullNode)(nil).StateAccountKey), arg0, arg1, arg2)
 }
 
+// StateActorCodeCIDs mocks base method.
+func (m *MockFullNode) StateActorCodeCIDs(arg0 context.Context, arg1 network.Version) (map[string]cid.Cid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateActorCodeCIDs", arg0, arg1)
+	ret0, _ := ret[0].(map[string]cid.Cid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateActorCodeCIDs indicates an expected call of StateActorCodeCIDs.
+func (mr *MockFullNodeMockRecorder) StateActorCodeCIDs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateActorCodeCIDs", reflect.TypeOf((*MockFullNode)(nil).StateActorCodeCIDs), arg0, arg1)
+}
+
+// StateActorLifecycle mocks base method.
+func (m *MockFullNode) StateActorLifecycle(arg0 context.Context, arg1 address.Address) (*api.ActorLifecycle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateActorLifecycle", arg0, arg1)
+	ret0, _ := ret[0].(*api.ActorLifecycle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateActorLifecycle indicates an expected call of StateActorLifecycle.
+func (mr *MockFullNodeMockRecorder) StateActorLifecycle(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateActorLifecycle", reflect.TypeOf((*MockFullNode)(nil).StateActorLifecycle), arg0, arg1)
+}
+
+// StateActorMethods mocks base method.
+func (m *MockFullNode) StateActorMethods(arg0 context.Context, arg1 cid.Cid) ([]api.ActorMethod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateActorMethods", arg0, arg1)
+	ret0, _ := ret[0].([]api.ActorMethod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateActorMethods indicates an expected call of StateActorMethods.
+func (mr *MockFullNodeMockRecorder) StateActorMethods(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateActorMethods", reflect.TypeOf((*MockFullNode)(nil).StateActorMethods), arg0, arg1)
+}
+
 // StateAllMinerFaults mocks base method.
 func (m *MockFullNode) StateAllMinerFaults(arg0 context.Context, arg1 abi.ChainEpoch, arg2 types.TipSetKey) ([]*api.Fault, error) {
 	m.ctrl.T.Helper()
@@ -2140,6 +2500,21 @@ func (mr *MockFullNodeMockRecorder) StateCirculatingSupply(arg0, arg1 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateCirculatingSupply", reflect.TypeOf((*MockFullNode)(nil).StateCirculatingSupply), arg0, arg1)
 }
 
+// StateCirculatingSupplyBreakdown mocks base method.
+func (m *MockFullNode) StateCirculatingSupplyBreakdown(arg0 context.Context, arg1 types.TipSetKey) (*api.CirculatingSupplyBreakdown, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateCirculatingSupplyBreakdown", arg0, arg1)
+	ret0, _ := ret[0].(*api.CirculatingSupplyBreakdown)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateCirculatingSupplyBreakdown indicates an expected call of StateCirculatingSupplyBreakdown.
+func (mr *MockFullNodeMockRecorder) StateCirculatingSupplyBreakdown(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateCirculatingSupplyBreakdown", reflect.TypeOf((*MockFullNode)(nil).StateCirculatingSupplyBreakdown), arg0, arg1)
+}
+
 // StateCompute mocks base method.
 func (m *MockFullNode) StateCompute(arg0 context.Context, arg1 abi.ChainEpoch, arg2 []*types.Message, arg3 types.TipSetKey) (*api.ComputeStateOutput, error) {
 	m.ctrl.T.Helper()
@@ -2170,6 +2545,51 @@ func (mr *MockFullNodeMockRecorder) StateDealProviderCollateralBounds(arg0, arg1
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDealProviderCollateralBounds", reflect.TypeOf((*MockFullNode)(nil).StateDealProviderCollateralBounds), arg0, arg1, arg2, arg3)
 }
 
+// StateDecodeActorParams mocks base method.
+func (m *MockFullNode) StateDecodeActorParams(arg0 context.Context, arg1 cid.Cid, arg2 abi.MethodNum, arg3 []byte) (interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateDecodeActorParams", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateDecodeActorParams indicates an expected call of StateDecodeActorParams.
+func (mr *MockFullNodeMockRecorder) StateDecodeActorParams(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDecodeActorParams", reflect.TypeOf((*MockFullNode)(nil).StateDecodeActorParams), arg0, arg1, arg2, arg3)
+}
+
+// StateDecodeActorReturn mocks base method.
+func (m *MockFullNode) StateDecodeActorReturn(arg0 context.Context, arg1 cid.Cid, arg2 abi.MethodNum, arg3 []byte) (interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateDecodeActorReturn", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateDecodeActorReturn indicates an expected call of StateDecodeActorReturn.
+func (mr *MockFullNodeMockRecorder) StateDecodeActorReturn(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDecodeActorReturn", reflect.TypeOf((*MockFullNode)(nil).StateDecodeActorReturn), arg0, arg1, arg2, arg3)
+}
+
+// StateDecodeMessage mocks base method.
+func (m *MockFullNode) StateDecodeMessage(arg0 context.Context, arg1 cid.Cid) (*api.DecodedMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateDecodeMessage", arg0, arg1)
+	ret0, _ := ret[0].(*api.DecodedMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateDecodeMessage indicates an expected call of StateDecodeMessage.
+func (mr *MockFullNodeMockRecorder) StateDecodeMessage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDecodeMessage", reflect.TypeOf((*MockFullNode)(nil).StateDecodeMessage), arg0, arg1)
+}
+
 // StateDecodeParams mocks base method.
 func (m *MockFullNode) StateDecodeParams(arg0 context.Context, arg1 address.Address, arg2 abi.MethodNum, arg3 []byte, arg4 types.TipSetKey) (interface{}, error) {
 	m.ctrl.T.Helper()
@@ -2185,6 +2605,21 @@ func (mr *MockFullNodeMockRecorder) StateDecodeParams(arg0, arg1, arg2, arg3, ar
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDecodeParams", reflect.TypeOf((*MockFullNode)(nil).StateDecodeParams), arg0, arg1, arg2, arg3, arg4)
 }
 
+// StateDecodeReturn mocks base method.
+func (m *MockFullNode) StateDecodeReturn(arg0 context.Context, arg1 address.Address, arg2 abi.MethodNum, arg3 []byte, arg4 types.TipSetKey) (interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateDecodeReturn", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateDecodeReturn indicates an expected call of StateDecodeReturn.
+func (mr *MockFullNodeMockRecorder) StateDecodeReturn(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDecodeReturn", reflect.TypeOf((*MockFullNode)(nil).StateDecodeReturn), arg0, arg1, arg2, arg3, arg4)
+}
+
 // StateGetActor mocks base method.
 func (m *MockFullNode) StateGetActor(arg0 context.Context, arg1 address.Address, arg2 types.TipSetKey) (*types.Actor, error) {
 	m.ctrl.T.Helper()
@@ -2215,6 +2650,21 @@ func (mr *MockFullNodeMockRecorder) StateListActors(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateListActors", reflect.TypeOf((*MockFullNode)(nil).StateListActors), arg0, arg1)
 }
 
+// StateListCreatedActors mocks base method.
+func (m *MockFullNode) StateListCreatedActors(arg0 context.Context, arg1 abi.ChainEpoch, arg2 abi.ChainEpoch) ([]api.ActorLifecycle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateListCreatedActors", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]api.ActorLifecycle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateListCreatedActors indicates an expected call of StateListCreatedActors.
+func (mr *MockFullNodeMockRecorder) StateListCreatedActors(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateListCreatedActors", reflect.TypeOf((*MockFullNode)(nil).StateListCreatedActors), arg0, arg1, arg2)
+}
+
 // StateListMessages mocks base method.
 func (m *MockFullNode) StateListMessages(arg0 context.Context, arg1 *api.MessageMatch, arg2 types.TipSetKey, arg3 abi.ChainEpoch) ([]cid.Cid, error) {
 	m.ctrl.T.Helper()
@@ -2470,6 +2920,21 @@ func (mr *MockFullNodeMockRecorder) StateMinerProvingDeadline(arg0, arg1, arg2 i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateMinerProvingDeadline", reflect.TypeOf((*MockFullNode)(nil).StateMinerProvingDeadline), arg0, arg1, arg2)
 }
 
+// StateMinerProvingSummary mocks base method.
+func (m *MockFullNode) StateMinerProvingSummary(arg0 context.Context, arg1 address.Address, arg2 types.TipSetKey) (*api.MinerProvingSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateMinerProvingSummary", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.MinerProvingSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateMinerProvingSummary indicates an expected call of StateMinerProvingSummary.
+func (mr *MockFullNodeMockRecorder) StateMinerProvingSummary(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateMinerProvingSummary", reflect.TypeOf((*MockFullNode)(nil).StateMinerProvingSummary), arg0, arg1, arg2)
+}
+
 // StateMinerRecoveries mocks base method.
 func (m *MockFullNode) StateMinerRecoveries(arg0 context.Context, arg1 address.Address, arg2 types.TipSetKey) (bitfield.BitField, error) {
 	m.ctrl.T.Helper()
@@ -2530,6 +2995,36 @@ func (mr *MockFullNodeMockRecorder) StateMinerSectors(arg0, arg1, arg2, arg3 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateMinerSectors", reflect.TypeOf((*MockFullNode)(nil).StateMinerSectors), arg0, arg1, arg2, arg3)
 }
 
+// StateMinerSectorsPage mocks base method.
+func (m *MockFullNode) StateMinerSectorsPage(arg0 context.Context, arg1 address.Address, arg2 api.MinerSectorsQuery, arg3 types.TipSetKey) (*api.MinerSectorsPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateMinerSectorsPage", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.MinerSectorsPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateMinerSectorsPage indicates an expected call of StateMinerSectorsPage.
+func (mr *MockFullNodeMockRecorder) StateMinerSectorsPage(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateMinerSectorsPage", reflect.TypeOf((*MockFullNode)(nil).StateMinerSectorsPage), arg0, arg1, arg2, arg3)
+}
+
+// StateMinerTerminationPenalty mocks base method.
+func (m *MockFullNode) StateMinerTerminationPenalty(arg0 context.Context, arg1 address.Address, arg2 []abi.SectorNumber, arg3 types.TipSetKey) (big.Int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateMinerTerminationPenalty", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(big.Int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateMinerTerminationPenalty indicates an expected call of StateMinerTerminationPenalty.
+func (mr *MockFullNodeMockRecorder) StateMinerTerminationPenalty(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateMinerTerminationPenalty", reflect.TypeOf((*MockFullNode)(nil).StateMinerTerminationPenalty), arg0, arg1, arg2, arg3)
+}
+
 // StateNetworkName mocks base method.
 func (m *MockFullNode) StateNetworkName(arg0 context.Context) (dtypes.NetworkName, error) {
 	m.ctrl.T.Helper()
@@ -2560,6 +3055,21 @@ func (mr *MockFullNodeMockRecorder) StateNetworkVersion(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateNetworkVersion", reflect.TypeOf((*MockFullNode)(nil).StateNetworkVersion), arg0, arg1)
 }
 
+// StateQuery mocks base method.
+func (m *MockFullNode) StateQuery(arg0 context.Context, arg1 []api.StateFieldQuery, arg2 types.TipSetKey) (*api.StateQueryResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.StateQueryResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateQuery indicates an expected call of StateQuery.
+func (mr *MockFullNodeMockRecorder) StateQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateQuery", reflect.TypeOf((*MockFullNode)(nil).StateQuery), arg0, arg1, arg2)
+}
+
 // StateReadState mocks base method.
 func (m *MockFullNode) StateReadState(arg0 context.Context, arg1 address.Address, arg2 types.TipSetKey) (*api.ActorState, error) {
 	m.ctrl.T.Helper()
@@ -2590,6 +3100,21 @@ func (mr *MockFullNodeMockRecorder) StateReplay(arg0, arg1, arg2 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateReplay", reflect.TypeOf((*MockFullNode)(nil).StateReplay), arg0, arg1, arg2)
 }
 
+// StateReplayDetailed mocks base method.
+func (m *MockFullNode) StateReplayDetailed(arg0 context.Context, arg1 types.TipSetKey, arg2 cid.Cid) (*api.ReplayTrace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateReplayDetailed", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.ReplayTrace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateReplayDetailed indicates an expected call of StateReplayDetailed.
+func (mr *MockFullNodeMockRecorder) StateReplayDetailed(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateReplayDetailed", reflect.TypeOf((*MockFullNode)(nil).StateReplayDetailed), arg0, arg1, arg2)
+}
+
 // StateSearchMsg mocks base method.
 func (m *MockFullNode) StateSearchMsg(arg0 context.Context, arg1 types.TipSetKey, arg2 cid.Cid, arg3 abi.ChainEpoch, arg4 bool) (*api.MsgLookup, error) {
 	m.ctrl.T.Helper()
@@ -2650,6 +3175,21 @@ func (mr *MockFullNodeMockRecorder) StateSectorPartition(arg0, arg1, arg2, arg3
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateSectorPartition", reflect.TypeOf((*MockFullNode)(nil).StateSectorPartition), arg0, arg1, arg2, arg3)
 }
 
+// StateSectorPledgeProjection mocks base method.
+func (m *MockFullNode) StateSectorPledgeProjection(arg0 context.Context, arg1 abi.StoragePower, arg2 abi.ChainEpoch, arg3 types.TipSetKey) (*api.SectorPledgeProjection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateSectorPledgeProjection", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.SectorPledgeProjection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateSectorPledgeProjection indicates an expected call of StateSectorPledgeProjection.
+func (mr *MockFullNodeMockRecorder) StateSectorPledgeProjection(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateSectorPledgeProjection", reflect.TypeOf((*MockFullNode)(nil).StateSectorPledgeProjection), arg0, arg1, arg2, arg3)
+}
+
 // StateSectorPreCommitInfo mocks base method.
 func (m *MockFullNode) StateSectorPreCommitInfo(arg0 context.Context, arg1 address.Address, arg2 abi.SectorNumber, arg3 types.TipSetKey) (miner.SectorPreCommitOnChainInfo, error) {
 	m.ctrl.T.Helper()
@@ -2725,6 +3265,21 @@ func (mr *MockFullNodeMockRecorder) StateVerifierStatus(arg0, arg1, arg2 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateVerifierStatus", reflect.TypeOf((*MockFullNode)(nil).StateVerifierStatus), arg0, arg1, arg2)
 }
 
+// StateVerifyAggregateSeals mocks base method.
+func (m *MockFullNode) StateVerifyAggregateSeals(arg0 context.Context, arg1 proof.AggregateSealVerifyProofAndInfos) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateVerifyAggregateSeals", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateVerifyAggregateSeals indicates an expected call of StateVerifyAggregateSeals.
+func (mr *MockFullNodeMockRecorder) StateVerifyAggregateSeals(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateVerifyAggregateSeals", reflect.TypeOf((*MockFullNode)(nil).StateVerifyAggregateSeals), arg0, arg1)
+}
+
 // StateWaitMsg mocks base method.
 func (m *MockFullNode) StateWaitMsg(arg0 context.Context, arg1 cid.Cid, arg2 uint64, arg3 abi.ChainEpoch, arg4 bool) (*api.MsgLookup, error) {
 	m.ctrl.T.Helper()
@@ -2740,6 +3295,21 @@ func (mr *MockFullNodeMockRecorder) StateWaitMsg(arg0, arg1, arg2, arg3, arg4 in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateWaitMsg", reflect.TypeOf((*MockFullNode)(nil).StateWaitMsg), arg0, arg1, arg2, arg3, arg4)
 }
 
+// StateWatchMsg mocks base method.
+func (m *MockFullNode) StateWatchMsg(arg0 context.Context, arg1 cid.Cid, arg2 uint64, arg3 abi.ChainEpoch, arg4 bool) (<-chan api.MsgWatchEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateWatchMsg", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(<-chan api.MsgWatchEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateWatchMsg indicates an expected call of StateWatchMsg.
+func (mr *MockFullNodeMockRecorder) StateWatchMsg(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateWatchMsg", reflect.TypeOf((*MockFullNode)(nil).StateWatchMsg), arg0, arg1, arg2, arg3, arg4)
+}
+
 // SyncCheckBad mocks base method.
 func (m *MockFullNode) SyncCheckBad(arg0 context.Context, arg1 cid.Cid) (string, error) {
 	m.ctrl.T.Helper()
@@ -2769,6 +3339,21 @@ func (mr *MockFullNodeMockRecorder) SyncCheckpoint(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncCheckpoint", reflect.TypeOf((*MockFullNode)(nil).SyncCheckpoint), arg0, arg1)
 }
 
+// SyncConsensusFaults mocks base method.
+func (m *MockFullNode) SyncConsensusFaults(arg0 context.Context) ([]api.ConsensusFault, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncConsensusFaults", arg0)
+	ret0, _ := ret[0].([]api.ConsensusFault)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncConsensusFaults indicates an expected call of SyncConsensusFaults.
+func (mr *MockFullNodeMockRecorder) SyncConsensusFaults(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncConsensusFaults", reflect.TypeOf((*MockFullNode)(nil).SyncConsensusFaults), arg0)
+}
+
 // SyncIncomingBlocks mocks base method.
 func (m *MockFullNode) SyncIncomingBlocks(arg0 context.Context) (<-chan *types.BlockHeader, error) {
 	m.ctrl.T.Helper()
@@ -2870,6 +3455,21 @@ func (mr *MockFullNodeMockRecorder) SyncValidateTipset(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncValidateTipset", reflect.TypeOf((*MockFullNode)(nil).SyncValidateTipset), arg0, arg1)
 }
 
+// SyncValidationTiming mocks base method.
+func (m *MockFullNode) SyncValidationTiming(arg0 context.Context) (api.SyncValidationTiming, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncValidationTiming", arg0)
+	ret0, _ := ret[0].(api.SyncValidationTiming)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncValidationTiming indicates an expected call of SyncValidationTiming.
+func (mr *MockFullNodeMockRecorder) SyncValidationTiming(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncValidationTiming", reflect.TypeOf((*MockFullNode)(nil).SyncValidationTiming), arg0)
+}
+
 // Version mocks base method.
 func (m *MockFullNode) Version(arg0 context.Context) (api.APIVersion, error) {
 	m.ctrl.T.Helper()
@@ -2885,6 +3485,20 @@ func (mr *MockFullNodeMockRecorder) Version(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockFullNode)(nil).Version), arg0)
 }
 
+// WalletAddressLabelSet mocks base method.
+func (m *MockFullNode) WalletAddressLabelSet(arg0 context.Context, arg1 address.Address, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletAddressLabelSet", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WalletAddressLabelSet indicates an expected call of WalletAddressLabelSet.
+func (mr *MockFullNodeMockRecorder) WalletAddressLabelSet(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletAddressLabelSet", reflect.TypeOf((*MockFullNode)(nil).WalletAddressLabelSet), arg0, arg1, arg2)
+}
+
 // WalletBalance mocks base method.
 func (m *MockFullNode) WalletBalance(arg0 context.Context, arg1 address.Address) (big.Int, error) {
 	m.ctrl.T.Helper()
@@ -2974,6 +3588,21 @@ func (mr *MockFullNodeMockRecorder) WalletImport(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletImport", reflect.TypeOf((*MockFullNode)(nil).WalletImport), arg0, arg1)
 }
 
+// WalletLabels mocks base method.
+func (m *MockFullNode) WalletLabels(arg0 context.Context) (*api.WalletLabels, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletLabels", arg0)
+	ret0, _ := ret[0].(*api.WalletLabels)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WalletLabels indicates an expected call of WalletLabels.
+func (mr *MockFullNodeMockRecorder) WalletLabels(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletLabels", reflect.TypeOf((*MockFullNode)(nil).WalletLabels), arg0)
+}
+
 // WalletList mocks base method.
 func (m *MockFullNode) WalletList(arg0 context.Context) ([]address.Address, error) {
 	m.ctrl.T.Helper()
@@ -2989,6 +3618,20 @@ func (mr *MockFullNodeMockRecorder) WalletList(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletList", reflect.TypeOf((*MockFullNode)(nil).WalletList), arg0)
 }
 
+// WalletMessageLabelSet mocks base method.
+func (m *MockFullNode) WalletMessageLabelSet(arg0 context.Context, arg1 cid.Cid, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletMessageLabelSet", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WalletMessageLabelSet indicates an expected call of WalletMessageLabelSet.
+func (mr *MockFullNodeMockRecorder) WalletMessageLabelSet(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletMessageLabelSet", reflect.TypeOf((*MockFullNode)(nil).WalletMessageLabelSet), arg0, arg1, arg2)
+}
+
 // WalletNew mocks base method.
 func (m *MockFullNode) WalletNew(arg0 context.Context, arg1 types.KeyType) (address.Address, error) {
 	m.ctrl.T.Helper()
@@ -21,14 +21,19 @@ import (
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/paych"
+	"github.com/filecoin-project/lotus/chain/beacon"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/extern/sector-storage/fsutil"
 	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
 	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
 	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
 	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/lib/subscription"
 	marketevents "github.com/filecoin-project/lotus/markets/loggers"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
 	"github.com/filecoin-project/specs-storage/storage"
 	"github.com/google/uuid"
 	"github.com/ipfs/go-cid"
@@ -36,7 +41,7 @@ import (
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
 	protocol "github.com/libp2p/go-libp2p-core/protocol"
-	xerrors "golang.org/x/xerrors"
+	"golang.org/x/xerrors"
 )
 
 type ChainIOStruct struct {
@@ -62,6 +67,8 @@ type CommonStruct struct {
 
 		ID func(p0 context.Context) (peer.ID, error) `perm:"read"`
 
+		JournalQuery func(p0 context.Context, p1 []journal.EventType, p2 time.Time, p3 time.Time, p4 int) ([]*journal.Event, error) `perm:"read"`
+
 		LogList func(p0 context.Context) ([]string, error) `perm:"write"`
 
 		LogSetLevel func(p0 context.Context, p1 string, p2 string) error `perm:"write"`
@@ -84,6 +91,8 @@ type CommonStruct struct {
 
 		NetBlockRemove func(p0 context.Context, p1 NetBlockList) error `perm:"admin"`
 
+		NetClassify func(p0 context.Context) ([]NetPeerClass, error) `perm:"read"`
+
 		NetConnect func(p0 context.Context, p1 peer.AddrInfo) error `perm:"write"`
 
 		NetConnectedness func(p0 context.Context, p1 peer.ID) (network.Connectedness, error) `perm:"read"`
@@ -113,12 +122,26 @@ type FullNodeStruct struct {
 	CommonStruct
 
 	Internal struct {
+		AddressBookGet func(p0 context.Context, p1 string) (*AddressBookEntry, error) `perm:"read"`
+
+		AddressBookList func(p0 context.Context) ([]AddressBookEntry, error) `perm:"read"`
+
+		AddressBookRemove func(p0 context.Context, p1 string) error `perm:"write"`
+
+		AddressBookSet func(p0 context.Context, p1 AddressBookEntry) error `perm:"write"`
+
 		BeaconGetEntry func(p0 context.Context, p1 abi.ChainEpoch) (*types.BeaconEntry, error) `perm:"read"`
 
+		BeaconHealth func(p0 context.Context) (*beacon.Health, error) `perm:"read"`
+
+		ChainBlockstoreGC func(p0 context.Context, p1 BlockstoreGCOpts) (<-chan BlockstoreGCProgress, error) `perm:"admin"`
+
 		ChainDeleteObj func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
 
 		ChainExport func(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey) (<-chan []byte, error) `perm:"read"`
 
+		ChainGasUsage func(p0 context.Context, p1 abi.ChainEpoch) (*GasUsage, error) `perm:"read"`
+
 		ChainGetBlock func(p0 context.Context, p1 cid.Cid) (*types.BlockHeader, error) `perm:"read"`
 
 		ChainGetBlockMessages func(p0 context.Context, p1 cid.Cid) (*BlockMessages, error) `perm:"read"`
@@ -147,12 +170,24 @@ type FullNodeStruct struct {
 
 		ChainHead func(p0 context.Context) (*types.TipSet, error) `perm:"read"`
 
+		ChainLatestSnapshot func(p0 context.Context) (*SnapshotInfo, error) `perm:"read"`
+
 		ChainNotify func(p0 context.Context) (<-chan []*HeadChange, error) `perm:"read"`
 
+		ChainNotifyWithPolicy func(p0 context.Context, p1 subscription.Policy) (<-chan []*HeadChange, error) `perm:"read"`
+
 		ChainReadObj func(p0 context.Context, p1 cid.Cid) ([]byte, error) `perm:"read"`
 
 		ChainSetHead func(p0 context.Context, p1 types.TipSetKey) error `perm:"admin"`
 
+		ChainSnapshotImportProgress func(p0 context.Context) (*SnapshotImportProgress, error) `perm:"read"`
+
+		ChainSplitstorePauseCompaction func(p0 context.Context) error `perm:"admin"`
+
+		ChainSplitstoreResumeCompaction func(p0 context.Context) error `perm:"admin"`
+
+		ChainSplitstoreStatus func(p0 context.Context) (*SplitstoreStatus, error) `perm:"read"`
+
 		ChainStatObj func(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (ObjStat, error) `perm:"read"`
 
 		ChainTipSetWeight func(p0 context.Context, p1 types.TipSetKey) (types.BigInt, error) `perm:"read"`
@@ -213,6 +248,8 @@ type FullNodeStruct struct {
 
 		CreateBackup func(p0 context.Context, p1 string) error `perm:"admin"`
 
+		GasAdvisorFees func(p0 context.Context, p1 types.TipSetKey) (*GasAdvice, error) `perm:"read"`
+
 		GasEstimateFeeCap func(p0 context.Context, p1 *types.Message, p2 int64, p3 types.TipSetKey) (types.BigInt, error) `perm:"read"`
 
 		GasEstimateGasLimit func(p0 context.Context, p1 *types.Message, p2 types.TipSetKey) (int64, error) `perm:"read"`
@@ -231,6 +268,8 @@ type FullNodeStruct struct {
 
 		MarketWithdraw func(p0 context.Context, p1 address.Address, p2 address.Address, p3 types.BigInt) (cid.Cid, error) `perm:"sign"`
 
+		MinerComputeBlockTemplate func(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 types.TipSetKey) (*BlockTemplateReport, error) `perm:"sign"`
+
 		MinerCreateBlock func(p0 context.Context, p1 *BlockTemplate) (*types.BlockMsg, error) `perm:"write"`
 
 		MinerGetBaseInfo func(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 types.TipSetKey) (*MiningBaseInfo, error) `perm:"read"`
@@ -253,6 +292,8 @@ type FullNodeStruct struct {
 
 		MpoolGetNonce func(p0 context.Context, p1 address.Address) (uint64, error) `perm:"read"`
 
+		MpoolNonceGaps func(p0 context.Context) ([]NonceGapReport, error) `perm:"read"`
+
 		MpoolPending func(p0 context.Context, p1 types.TipSetKey) ([]*types.SignedMessage, error) `perm:"read"`
 
 		MpoolPush func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) `perm:"write"`
@@ -261,6 +302,8 @@ type FullNodeStruct struct {
 
 		MpoolPushUntrusted func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) `perm:"write"`
 
+		MpoolReorgs func(p0 context.Context) (<-chan MpoolReorg, error) `perm:"read"`
+
 		MpoolSelect func(p0 context.Context, p1 types.TipSetKey, p2 float64) ([]*types.SignedMessage, error) `perm:"read"`
 
 		MpoolSetConfig func(p0 context.Context, p1 *types.MpoolConfig) error `perm:"admin"`
@@ -299,6 +342,10 @@ type FullNodeStruct struct {
 
 		MsigSwapPropose func(p0 context.Context, p1 address.Address, p2 address.Address, p3 address.Address, p4 address.Address) (*MessagePrototype, error) `perm:"sign"`
 
+		NetPropagation func(p0 context.Context) (*PropagationReport, error) `perm:"read"`
+
+		NetPropagationReset func(p0 context.Context) error `perm:"admin"`
+
 		NodeStatus func(p0 context.Context, p1 bool) (NodeStatus, error) `perm:"read"`
 
 		PaychAllocateLane func(p0 context.Context, p1 address.Address) (uint64, error) `perm:"sign"`
@@ -335,6 +382,12 @@ type FullNodeStruct struct {
 
 		StateAccountKey func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (address.Address, error) `perm:"read"`
 
+		StateActorCodeCIDs func(p0 context.Context, p1 apitypes.NetworkVersion) (map[string]cid.Cid, error) `perm:"read"`
+
+		StateActorLifecycle func(p0 context.Context, p1 address.Address) (*ActorLifecycle, error) `perm:"read"`
+
+		StateActorMethods func(p0 context.Context, p1 cid.Cid) ([]ActorMethod, error) `perm:"read"`
+
 		StateAllMinerFaults func(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) ([]*Fault, error) `perm:"read"`
 
 		StateCall func(p0 context.Context, p1 *types.Message, p2 types.TipSetKey) (*InvocResult, error) `perm:"read"`
@@ -343,16 +396,28 @@ type FullNodeStruct struct {
 
 		StateCirculatingSupply func(p0 context.Context, p1 types.TipSetKey) (abi.TokenAmount, error) `perm:"read"`
 
+		StateCirculatingSupplyBreakdown func(p0 context.Context, p1 types.TipSetKey) (*CirculatingSupplyBreakdown, error) `perm:"read"`
+
 		StateCompute func(p0 context.Context, p1 abi.ChainEpoch, p2 []*types.Message, p3 types.TipSetKey) (*ComputeStateOutput, error) `perm:"read"`
 
 		StateDealProviderCollateralBounds func(p0 context.Context, p1 abi.PaddedPieceSize, p2 bool, p3 types.TipSetKey) (DealCollateralBounds, error) `perm:"read"`
 
+		StateDecodeActorParams func(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 []byte) (interface{}, error) `perm:"read"`
+
+		StateDecodeActorReturn func(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 []byte) (interface{}, error) `perm:"read"`
+
+		StateDecodeMessage func(p0 context.Context, p1 cid.Cid) (*DecodedMessage, error) `perm:"read"`
+
 		StateDecodeParams func(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) `perm:"read"`
 
+		StateDecodeReturn func(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) `perm:"read"`
+
 		StateGetActor func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*types.Actor, error) `perm:"read"`
 
 		StateListActors func(p0 context.Context, p1 types.TipSetKey) ([]address.Address, error) `perm:"read"`
 
+		StateListCreatedActors func(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch) ([]ActorLifecycle, error) `perm:"read"`
+
 		StateListMessages func(p0 context.Context, p1 *MessageMatch, p2 types.TipSetKey, p3 abi.ChainEpoch) ([]cid.Cid, error) `perm:"read"`
 
 		StateListMiners func(p0 context.Context, p1 types.TipSetKey) ([]address.Address, error) `perm:"read"`
@@ -387,6 +452,8 @@ type FullNodeStruct struct {
 
 		StateMinerProvingDeadline func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*dline.Info, error) `perm:"read"`
 
+		StateMinerProvingSummary func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*MinerProvingSummary, error) `perm:"read"`
+
 		StateMinerRecoveries func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (bitfield.BitField, error) `perm:"read"`
 
 		StateMinerSectorAllocated func(p0 context.Context, p1 address.Address, p2 abi.SectorNumber, p3 types.TipSetKey) (bool, error) `perm:"read"`
@@ -395,14 +462,22 @@ type FullNodeStruct struct {
 
 		StateMinerSectors func(p0 context.Context, p1 address.Address, p2 *bitfield.BitField, p3 types.TipSetKey) ([]*miner.SectorOnChainInfo, error) `perm:"read"`
 
+		StateMinerSectorsPage func(p0 context.Context, p1 address.Address, p2 MinerSectorsQuery, p3 types.TipSetKey) (*MinerSectorsPage, error) `perm:"read"`
+
+		StateMinerTerminationPenalty func(p0 context.Context, p1 address.Address, p2 []abi.SectorNumber, p3 types.TipSetKey) (abi.TokenAmount, error) `perm:"read"`
+
 		StateNetworkName func(p0 context.Context) (dtypes.NetworkName, error) `perm:"read"`
 
 		StateNetworkVersion func(p0 context.Context, p1 types.TipSetKey) (apitypes.NetworkVersion, error) `perm:"read"`
 
+		StateQuery func(p0 context.Context, p1 []StateFieldQuery, p2 types.TipSetKey) (*StateQueryResult, error) `perm:"read"`
+
 		StateReadState func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*ActorState, error) `perm:"read"`
 
 		StateReplay func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid) (*InvocResult, error) `perm:"read"`
 
+		StateReplayDetailed func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid) (*ReplayTrace, error) `perm:"read"`
+
 		StateSearchMsg func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 abi.ChainEpoch, p4 bool) (*MsgLookup, error) `perm:"read"`
 
 		StateSectorExpiration func(p0 context.Context, p1 address.Address, p2 abi.SectorNumber, p3 types.TipSetKey) (*miner.SectorExpiration, error) `perm:"read"`
@@ -411,6 +486,8 @@ type FullNodeStruct struct {
 
 		StateSectorPartition func(p0 context.Context, p1 address.Address, p2 abi.SectorNumber, p3 types.TipSetKey) (*miner.SectorLocation, error) `perm:"read"`
 
+		StateSectorPledgeProjection func(p0 context.Context, p1 abi.StoragePower, p2 abi.ChainEpoch, p3 types.TipSetKey) (*SectorPledgeProjection, error) `perm:"read"`
+
 		StateSectorPreCommitInfo func(p0 context.Context, p1 address.Address, p2 abi.SectorNumber, p3 types.TipSetKey) (miner.SectorPreCommitOnChainInfo, error) `perm:"read"`
 
 		StateVMCirculatingSupplyInternal func(p0 context.Context, p1 types.TipSetKey) (CirculatingSupply, error) `perm:"read"`
@@ -421,12 +498,18 @@ type FullNodeStruct struct {
 
 		StateVerifierStatus func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*abi.StoragePower, error) `perm:"read"`
 
+		StateVerifyAggregateSeals func(p0 context.Context, p1 proof5.AggregateSealVerifyProofAndInfos) (bool, error) `perm:"read"`
+
 		StateWaitMsg func(p0 context.Context, p1 cid.Cid, p2 uint64, p3 abi.ChainEpoch, p4 bool) (*MsgLookup, error) `perm:"read"`
 
+		StateWatchMsg func(p0 context.Context, p1 cid.Cid, p2 uint64, p3 abi.ChainEpoch, p4 bool) (<-chan MsgWatchEvent, error) `perm:"read"`
+
 		SyncCheckBad func(p0 context.Context, p1 cid.Cid) (string, error) `perm:"read"`
 
 		SyncCheckpoint func(p0 context.Context, p1 types.TipSetKey) error `perm:"admin"`
 
+		SyncConsensusFaults func(p0 context.Context) ([]ConsensusFault, error) `perm:"read"`
+
 		SyncIncomingBlocks func(p0 context.Context) (<-chan *types.BlockHeader, error) `perm:"read"`
 
 		SyncMarkBad func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
@@ -441,6 +524,10 @@ type FullNodeStruct struct {
 
 		SyncValidateTipset func(p0 context.Context, p1 types.TipSetKey) (bool, error) `perm:"read"`
 
+		SyncValidationTiming func(p0 context.Context) (SyncValidationTiming, error) `perm:"read"`
+
+		WalletAddressLabelSet func(p0 context.Context, p1 address.Address, p2 string) error `perm:"write"`
+
 		WalletBalance func(p0 context.Context, p1 address.Address) (types.BigInt, error) `perm:"read"`
 
 		WalletDefaultAddress func(p0 context.Context) (address.Address, error) `perm:"write"`
@@ -453,8 +540,12 @@ type FullNodeStruct struct {
 
 		WalletImport func(p0 context.Context, p1 *types.KeyInfo) (address.Address, error) `perm:"admin"`
 
+		WalletLabels func(p0 context.Context) (*WalletLabels, error) `perm:"read"`
+
 		WalletList func(p0 context.Context) ([]address.Address, error) `perm:"write"`
 
+		WalletMessageLabelSet func(p0 context.Context, p1 cid.Cid, p2 string) error `perm:"write"`
+
 		WalletNew func(p0 context.Context, p1 types.KeyType) (address.Address, error) `perm:"write"`
 
 		WalletSetDefault func(p0 context.Context, p1 address.Address) error `perm:"write"`
@@ -538,6 +629,23 @@ type GatewayStruct struct {
 type GatewayStub struct {
 }
 
+type SealingServiceStruct struct {
+	Internal struct {
+		SealCommit func(p0 context.Context, p1 abi.SectorID, p2 abi.InteractiveSealRandomness) error `perm:"write"`
+
+		SealPreCommit func(p0 context.Context, p1 storiface.SealPreCommitRequest) error `perm:"write"`
+
+		SealRelease func(p0 context.Context, p1 abi.SectorID) error `perm:"write"`
+
+		SealStatus func(p0 context.Context, p1 abi.SectorID) (storiface.SealJob, error) `perm:"read"`
+
+		Version func(p0 context.Context) (Version, error) `perm:"read"`
+	}
+}
+
+type SealingServiceStub struct {
+}
+
 type SignableStruct struct {
 	Internal struct {
 		Sign func(p0 context.Context, p1 SignFunc) error ``
@@ -555,14 +663,38 @@ type StorageMinerStruct struct {
 
 		ActorAddressConfig func(p0 context.Context) (AddressConfig, error) `perm:"read"`
 
+		ActorAddresses func(p0 context.Context) ([]address.Address, error) `perm:"read"`
+
+		ActorControlSet func(p0 context.Context, p1 []address.Address) (cid.Cid, error) `perm:"admin"`
+
+		ActorControlSetPlan func(p0 context.Context, p1 []address.Address, p2 abi.TokenAmount) (*ControlAddressPlan, error) `perm:"read"`
+
+		ActorOwnerChangeConfirm func(p0 context.Context, p1 crypto.Signature) (cid.Cid, error) `perm:"admin"`
+
+		ActorOwnerChangePrepare func(p0 context.Context, p1 address.Address) (*OwnerChange, error) `perm:"admin"`
+
 		ActorSectorSize func(p0 context.Context, p1 address.Address) (abi.SectorSize, error) `perm:"read"`
 
+		ActorSpending func(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 abi.ChainEpoch) (*MinerSpending, error) `perm:"read"`
+
+		ActorWorkerRotate func(p0 context.Context, p1 address.Address) (*WorkerRotation, error) `perm:"admin"`
+
+		ActorWorkerRotation func(p0 context.Context) (*WorkerRotation, error) `perm:"read"`
+
+		AlertsAck func(p0 context.Context, p1 alerting.AlertType) error `perm:"write"`
+
+		AlertsList func(p0 context.Context) ([]alerting.Alert, error) `perm:"read"`
+
 		CheckProvable func(p0 context.Context, p1 abi.RegisteredPoStProof, p2 []storage.SectorRef, p3 bool) (map[abi.SectorNumber]string, error) `perm:"admin"`
 
 		ComputeProof func(p0 context.Context, p1 []builtin.SectorInfo, p2 abi.PoStRandomness) ([]builtin.PoStProof, error) `perm:"read"`
 
+		ConfigReload func(p0 context.Context) error `perm:"admin"`
+
 		CreateBackup func(p0 context.Context, p1 string) error `perm:"admin"`
 
+		CreateBackupIncremental func(p0 context.Context, p1 string) (*BackupSnapshot, error) `perm:"admin"`
+
 		DealsConsiderOfflineRetrievalDeals func(p0 context.Context) (bool, error) `perm:"admin"`
 
 		DealsConsiderOfflineStorageDeals func(p0 context.Context) (bool, error) `perm:"admin"`
@@ -595,6 +727,12 @@ type StorageMinerStruct struct {
 
 		DealsSetPieceCidBlocklist func(p0 context.Context, p1 []cid.Cid) error `perm:"admin"`
 
+		FeeBreakerStatus func(p0 context.Context) (FeeBreakerStatus, error) `perm:"read"`
+
+		MaintenanceMode func(p0 context.Context) (bool, error) `perm:"read"`
+
+		MaintenanceSet func(p0 context.Context, p1 bool) error `perm:"admin"`
+
 		MarketCancelDataTransfer func(p0 context.Context, p1 datatransfer.TransferID, p2 peer.ID, p3 bool) error `perm:"write"`
 
 		MarketDataTransferUpdates func(p0 context.Context) (<-chan DataTransferChannel, error) `perm:"write"`
@@ -605,6 +743,8 @@ type StorageMinerStruct struct {
 
 		MarketGetRetrievalAsk func(p0 context.Context) (*retrievalmarket.Ask, error) `perm:"read"`
 
+		MarketGetRetrievalPricingPolicy func(p0 context.Context) (dtypes.RetrievalPricingPolicy, error) `perm:"read"`
+
 		MarketImportDealData func(p0 context.Context, p1 cid.Cid, p2 string) error `perm:"write"`
 
 		MarketListDataTransfers func(p0 context.Context) ([]DataTransferChannel, error) `perm:"write"`
@@ -625,8 +765,18 @@ type StorageMinerStruct struct {
 
 		MarketSetRetrievalAsk func(p0 context.Context, p1 *retrievalmarket.Ask) error `perm:"admin"`
 
+		MarketSetRetrievalPricingPolicy func(p0 context.Context, p1 dtypes.RetrievalPricingPolicy) error `perm:"admin"`
+
+		MinerOverview func(p0 context.Context) (*MinerOverview, error) `perm:"read"`
+
+		MinerReport func(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 abi.ChainEpoch) (*MinerReport, error) `perm:"read"`
+
+		MiningAttempts func(p0 context.Context) ([]MiningAttempt, error) `perm:"read"`
+
 		MiningBase func(p0 context.Context) (*types.TipSet, error) `perm:"read"`
 
+		PayoutSchedule func(p0 context.Context) (*PayoutSchedule, error) `perm:"read"`
+
 		PiecesGetCIDInfo func(p0 context.Context, p1 cid.Cid) (*piecestore.CIDInfo, error) `perm:"read"`
 
 		PiecesGetPieceInfo func(p0 context.Context, p1 cid.Cid) (*piecestore.PieceInfo, error) `perm:"read"`
@@ -637,6 +787,8 @@ type StorageMinerStruct struct {
 
 		PledgeSector func(p0 context.Context) (abi.SectorID, error) `perm:"write"`
 
+		PledgeSectorFor func(p0 context.Context, p1 address.Address) (abi.SectorID, error) `perm:"write"`
+
 		ReturnAddPiece func(p0 context.Context, p1 storiface.CallID, p2 abi.PieceInfo, p3 *storiface.CallError) error `perm:"admin"`
 
 		ReturnFetch func(p0 context.Context, p1 storiface.CallID, p2 *storiface.CallError) error `perm:"admin"`
@@ -661,8 +813,16 @@ type StorageMinerStruct struct {
 
 		SealingAbort func(p0 context.Context, p1 storiface.CallID) error `perm:"admin"`
 
+		SealingFSMGraph func(p0 context.Context) (SealingFSMGraph, error) `perm:"read"`
+
+		SealingForecast func(p0 context.Context) (SealingForecast, error) `perm:"read"`
+
 		SealingSchedDiag func(p0 context.Context, p1 bool) (interface{}, error) `perm:"admin"`
 
+		SectorAddPieceToAny func(p0 context.Context, p1 abi.UnpaddedPieceSize, p2 storage.Data, p3 PieceDealInfo) (SectorOffset, error) `perm:"admin"`
+
+		SectorBatchStatus func(p0 context.Context) (SectorBatchStatus, error) `perm:"read"`
+
 		SectorCommitFlush func(p0 context.Context) ([]sealiface.CommitBatchRes, error) `perm:"admin"`
 
 		SectorCommitPending func(p0 context.Context) ([]abi.SectorID, error) `perm:"admin"`
@@ -671,16 +831,30 @@ type StorageMinerStruct struct {
 
 		SectorGetSealDelay func(p0 context.Context) (time.Duration, error) `perm:"read"`
 
+		SectorLog func(p0 context.Context, p1 abi.SectorNumber) ([]SectorLogEntry, error) `perm:"read"`
+
 		SectorMarkForUpgrade func(p0 context.Context, p1 abi.SectorNumber) error `perm:"admin"`
 
+		SectorNumFree func(p0 context.Context, p1 string) error `perm:"admin"`
+
+		SectorNumReservations func(p0 context.Context) (map[string]bitfield.BitField, error) `perm:"read"`
+
+		SectorNumReserve func(p0 context.Context, p1 string, p2 bitfield.BitField, p3 bool) error `perm:"admin"`
+
 		SectorPreCommitFlush func(p0 context.Context) ([]sealiface.PreCommitBatchRes, error) `perm:"admin"`
 
 		SectorPreCommitPending func(p0 context.Context) ([]abi.SectorID, error) `perm:"admin"`
 
+		SectorReceipts func(p0 context.Context, p1 abi.SectorNumber) ([]storiface.WorkReceipt, error) `perm:"read"`
+
+		SectorRegenerateProof func(p0 context.Context, p1 abi.SectorNumber) error `perm:"admin"`
+
 		SectorRemove func(p0 context.Context, p1 abi.SectorNumber) error `perm:"admin"`
 
 		SectorSetExpectedSealDuration func(p0 context.Context, p1 time.Duration) error `perm:"write"`
 
+		SectorSetLabels func(p0 context.Context, p1 abi.SectorNumber, p2 map[string]string) error `perm:"write"`
+
 		SectorSetSealDelay func(p0 context.Context, p1 time.Duration) error `perm:"write"`
 
 		SectorStartSealing func(p0 context.Context, p1 abi.SectorNumber) error `perm:"write"`
@@ -691,16 +865,26 @@ type StorageMinerStruct struct {
 
 		SectorTerminatePending func(p0 context.Context) ([]abi.SectorID, error) `perm:"admin"`
 
+		SectorsETA func(p0 context.Context) ([]SectorETA, error) `perm:"read"`
+
 		SectorsList func(p0 context.Context) ([]abi.SectorNumber, error) `perm:"read"`
 
 		SectorsListInStates func(p0 context.Context, p1 []SectorState) ([]abi.SectorNumber, error) `perm:"read"`
 
 		SectorsRefs func(p0 context.Context) (map[string][]SealedRef, error) `perm:"read"`
 
+		SectorsStateChanges func(p0 context.Context, p1 SectorStateFilter) (<-chan SectorStateChange, error) `perm:"read"`
+
+		SectorsStateChangesWithPolicy func(p0 context.Context, p1 SectorStateFilter, p2 subscription.Policy) (<-chan SectorStateChange, error) `perm:"read"`
+
 		SectorsStatus func(p0 context.Context, p1 abi.SectorNumber, p2 bool) (SectorInfo, error) `perm:"read"`
 
 		SectorsSummary func(p0 context.Context) (map[SectorState]int, error) `perm:"read"`
 
+		SectorsUnsealPiece func(p0 context.Context, p1 storage.SectorRef, p2 storiface.UnpaddedByteIndex, p3 abi.UnpaddedPieceSize, p4 abi.SealRandomness, p5 *cid.Cid) error `perm:"admin"`
+
+		SectorsUnsealQueue func(p0 context.Context) ([]storiface.UnsealJob, error) `perm:"read"`
+
 		SectorsUpdate func(p0 context.Context, p1 abi.SectorNumber, p2 SectorState) error `perm:"admin"`
 
 		StorageAddLocal func(p0 context.Context, p1 string) error `perm:"admin"`
@@ -723,10 +907,16 @@ type StorageMinerStruct struct {
 
 		StorageLock func(p0 context.Context, p1 abi.SectorID, p2 storiface.SectorFileType, p3 storiface.SectorFileType) error `perm:"admin"`
 
+		StorageMoveList func(p0 context.Context) ([]stores.SectorMove, error) `perm:"admin"`
+
+		StorageMoveSector func(p0 context.Context, p1 abi.SectorNumber, p2 stores.ID) error `perm:"admin"`
+
 		StorageReportHealth func(p0 context.Context, p1 stores.ID, p2 stores.HealthReport) error `perm:"admin"`
 
 		StorageStat func(p0 context.Context, p1 stores.ID) (fsutil.FsStat, error) `perm:"admin"`
 
+		StorageTierList func(p0 context.Context) ([]stores.SectorTier, error) `perm:"admin"`
+
 		StorageTryLock func(p0 context.Context, p1 abi.SectorID, p2 storiface.SectorFileType, p3 storiface.SectorFileType) (bool, error) `perm:"admin"`
 
 		WorkerConnect func(p0 context.Context, p1 string) error `perm:"admin"`
@@ -772,6 +962,8 @@ type WorkerStruct struct {
 
 		FinalizeSector func(p0 context.Context, p1 storage.SectorRef, p2 []storage.Range) (storiface.CallID, error) `perm:"admin"`
 
+		GPUUsage func(p0 context.Context) ([]storiface.GPUUsage, error) `perm:"admin"`
+
 		Info func(p0 context.Context) (storiface.WorkerInfo, error) `perm:"admin"`
 
 		MoveStorage func(p0 context.Context, p1 storage.SectorRef, p2 storiface.SectorFileType) (storiface.CallID, error) `perm:"admin"`
@@ -784,6 +976,8 @@ type WorkerStruct struct {
 
 		Remove func(p0 context.Context, p1 abi.SectorID) error `perm:"admin"`
 
+		RunningTasks func(p0 context.Context) ([]storiface.WorkerJob, error) `perm:"admin"`
+
 		SealCommit1 func(p0 context.Context, p1 storage.SectorRef, p2 abi.SealRandomness, p3 abi.InteractiveSealRandomness, p4 []abi.PieceInfo, p5 storage.SectorCids) (storiface.CallID, error) `perm:"admin"`
 
 		SealCommit2 func(p0 context.Context, p1 storage.SectorRef, p2 storage.Commit1Out) (storiface.CallID, error) `perm:"admin"`
@@ -796,6 +990,8 @@ type WorkerStruct struct {
 
 		SetEnabled func(p0 context.Context, p1 bool) error `perm:"admin"`
 
+		Shutdown func(p0 context.Context) error `perm:"admin"`
+
 		StorageAddLocal func(p0 context.Context, p1 string) error `perm:"admin"`
 
 		TaskDisable func(p0 context.Context, p1 sealtasks.TaskType) error `perm:"admin"`
@@ -806,6 +1002,14 @@ type WorkerStruct struct {
 
 		UnsealPiece func(p0 context.Context, p1 storage.SectorRef, p2 storiface.UnpaddedByteIndex, p3 abi.UnpaddedPieceSize, p4 abi.SealRandomness, p5 cid.Cid) (storiface.CallID, error) `perm:"admin"`
 
+		VerifyAggregateSeals func(p0 context.Context, p1 proof5.AggregateSealVerifyProofAndInfos) (bool, error) `perm:"admin"`
+
+		VerifySeal func(p0 context.Context, p1 proof5.SealVerifyInfo) (bool, error) `perm:"admin"`
+
+		VerifyWindowPoSt func(p0 context.Context, p1 proof5.WindowPoStVerifyInfo) (bool, error) `perm:"admin"`
+
+		VerifyWinningPoSt func(p0 context.Context, p1 proof5.WinningPoStVerifyInfo) (bool, error) `perm:"admin"`
+
 		Version func(p0 context.Context) (Version, error) `perm:"admin"`
 
 		WaitQuiet func(p0 context.Context) error `perm:"admin"`
@@ -871,6 +1075,14 @@ func (s *CommonStub) ID(p0 context.Context) (peer.ID, error) {
 	return *new(peer.ID), xerrors.New("method not supported")
 }
 
+func (s *CommonStruct) JournalQuery(p0 context.Context, p1 []journal.EventType, p2 time.Time, p3 time.Time, p4 int) ([]*journal.Event, error) {
+	return s.Internal.JournalQuery(p0, p1, p2, p3, p4)
+}
+
+func (s *CommonStub) JournalQuery(p0 context.Context, p1 []journal.EventType, p2 time.Time, p3 time.Time, p4 int) ([]*journal.Event, error) {
+	return *new([]*journal.Event), xerrors.New("method not supported")
+}
+
 func (s *CommonStruct) LogList(p0 context.Context) ([]string, error) {
 	return s.Internal.LogList(p0)
 }
@@ -959,6 +1171,14 @@ func (s *CommonStub) NetBlockRemove(p0 context.Context, p1 NetBlockList) error {
 	return xerrors.New("method not supported")
 }
 
+func (s *CommonStruct) NetClassify(p0 context.Context) ([]NetPeerClass, error) {
+	return s.Internal.NetClassify(p0)
+}
+
+func (s *CommonStub) NetClassify(p0 context.Context) ([]NetPeerClass, error) {
+	return *new([]NetPeerClass), xerrors.New("method not supported")
+}
+
 func (s *CommonStruct) NetConnect(p0 context.Context, p1 peer.AddrInfo) error {
 	return s.Internal.NetConnect(p0, p1)
 }
@@ -1039,6 +1259,38 @@ func (s *CommonStub) Version(p0 context.Context) (APIVersion, error) {
 	return *new(APIVersion), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) AddressBookGet(p0 context.Context, p1 string) (*AddressBookEntry, error) {
+	return s.Internal.AddressBookGet(p0, p1)
+}
+
+func (s *FullNodeStub) AddressBookGet(p0 context.Context, p1 string) (*AddressBookEntry, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) AddressBookList(p0 context.Context) ([]AddressBookEntry, error) {
+	return s.Internal.AddressBookList(p0)
+}
+
+func (s *FullNodeStub) AddressBookList(p0 context.Context) ([]AddressBookEntry, error) {
+	return *new([]AddressBookEntry), xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) AddressBookRemove(p0 context.Context, p1 string) error {
+	return s.Internal.AddressBookRemove(p0, p1)
+}
+
+func (s *FullNodeStub) AddressBookRemove(p0 context.Context, p1 string) error {
+	return xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) AddressBookSet(p0 context.Context, p1 AddressBookEntry) error {
+	return s.Internal.AddressBookSet(p0, p1)
+}
+
+func (s *FullNodeStub) AddressBookSet(p0 context.Context, p1 AddressBookEntry) error {
+	return xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) BeaconGetEntry(p0 context.Context, p1 abi.ChainEpoch) (*types.BeaconEntry, error) {
 	return s.Internal.BeaconGetEntry(p0, p1)
 }
@@ -1047,6 +1299,22 @@ func (s *FullNodeStub) BeaconGetEntry(p0 context.Context, p1 abi.ChainEpoch) (*t
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) BeaconHealth(p0 context.Context) (*beacon.Health, error) {
+	return s.Internal.BeaconHealth(p0)
+}
+
+func (s *FullNodeStub) BeaconHealth(p0 context.Context) (*beacon.Health, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) ChainBlockstoreGC(p0 context.Context, p1 BlockstoreGCOpts) (<-chan BlockstoreGCProgress, error) {
+	return s.Internal.ChainBlockstoreGC(p0, p1)
+}
+
+func (s *FullNodeStub) ChainBlockstoreGC(p0 context.Context, p1 BlockstoreGCOpts) (<-chan BlockstoreGCProgress, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) ChainDeleteObj(p0 context.Context, p1 cid.Cid) error {
 	return s.Internal.ChainDeleteObj(p0, p1)
 }
@@ -1063,6 +1331,14 @@ func (s *FullNodeStub) ChainExport(p0 context.Context, p1 abi.ChainEpoch, p2 boo
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) ChainGasUsage(p0 context.Context, p1 abi.ChainEpoch) (*GasUsage, error) {
+	return s.Internal.ChainGasUsage(p0, p1)
+}
+
+func (s *FullNodeStub) ChainGasUsage(p0 context.Context, p1 abi.ChainEpoch) (*GasUsage, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) ChainGetBlock(p0 context.Context, p1 cid.Cid) (*types.BlockHeader, error) {
 	return s.Internal.ChainGetBlock(p0, p1)
 }
@@ -1175,6 +1451,14 @@ func (s *FullNodeStub) ChainHead(p0 context.Context) (*types.TipSet, error) {
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) ChainLatestSnapshot(p0 context.Context) (*SnapshotInfo, error) {
+	return s.Internal.ChainLatestSnapshot(p0)
+}
+
+func (s *FullNodeStub) ChainLatestSnapshot(p0 context.Context) (*SnapshotInfo, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) ChainNotify(p0 context.Context) (<-chan []*HeadChange, error) {
 	return s.Internal.ChainNotify(p0)
 }
@@ -1183,6 +1467,14 @@ func (s *FullNodeStub) ChainNotify(p0 context.Context) (<-chan []*HeadChange, er
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) ChainNotifyWithPolicy(p0 context.Context, p1 subscription.Policy) (<-chan []*HeadChange, error) {
+	return s.Internal.ChainNotifyWithPolicy(p0, p1)
+}
+
+func (s *FullNodeStub) ChainNotifyWithPolicy(p0 context.Context, p1 subscription.Policy) (<-chan []*HeadChange, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) ChainReadObj(p0 context.Context, p1 cid.Cid) ([]byte, error) {
 	return s.Internal.ChainReadObj(p0, p1)
 }
@@ -1199,6 +1491,38 @@ func (s *FullNodeStub) ChainSetHead(p0 context.Context, p1 types.TipSetKey) erro
 	return xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) ChainSnapshotImportProgress(p0 context.Context) (*SnapshotImportProgress, error) {
+	return s.Internal.ChainSnapshotImportProgress(p0)
+}
+
+func (s *FullNodeStub) ChainSnapshotImportProgress(p0 context.Context) (*SnapshotImportProgress, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) ChainSplitstorePauseCompaction(p0 context.Context) error {
+	return s.Internal.ChainSplitstorePauseCompaction(p0)
+}
+
+func (s *FullNodeStub) ChainSplitstorePauseCompaction(p0 context.Context) error {
+	return xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) ChainSplitstoreResumeCompaction(p0 context.Context) error {
+	return s.Internal.ChainSplitstoreResumeCompaction(p0)
+}
+
+func (s *FullNodeStub) ChainSplitstoreResumeCompaction(p0 context.Context) error {
+	return xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) ChainSplitstoreStatus(p0 context.Context) (*SplitstoreStatus, error) {
+	return s.Internal.ChainSplitstoreStatus(p0)
+}
+
+func (s *FullNodeStub) ChainSplitstoreStatus(p0 context.Context) (*SplitstoreStatus, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) ChainStatObj(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (ObjStat, error) {
 	return s.Internal.ChainStatObj(p0, p1, p2)
 }
@@ -1439,6 +1763,14 @@ func (s *FullNodeStub) CreateBackup(p0 context.Context, p1 string) error {
 	return xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) GasAdvisorFees(p0 context.Context, p1 types.TipSetKey) (*GasAdvice, error) {
+	return s.Internal.GasAdvisorFees(p0, p1)
+}
+
+func (s *FullNodeStub) GasAdvisorFees(p0 context.Context, p1 types.TipSetKey) (*GasAdvice, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) GasEstimateFeeCap(p0 context.Context, p1 *types.Message, p2 int64, p3 types.TipSetKey) (types.BigInt, error) {
 	return s.Internal.GasEstimateFeeCap(p0, p1, p2, p3)
 }
@@ -1511,6 +1843,14 @@ func (s *FullNodeStub) MarketWithdraw(p0 context.Context, p1 address.Address, p2
 	return *new(cid.Cid), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) MinerComputeBlockTemplate(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 types.TipSetKey) (*BlockTemplateReport, error) {
+	return s.Internal.MinerComputeBlockTemplate(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) MinerComputeBlockTemplate(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 types.TipSetKey) (*BlockTemplateReport, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) MinerCreateBlock(p0 context.Context, p1 *BlockTemplate) (*types.BlockMsg, error) {
 	return s.Internal.MinerCreateBlock(p0, p1)
 }
@@ -1599,6 +1939,14 @@ func (s *FullNodeStub) MpoolGetNonce(p0 context.Context, p1 address.Address) (ui
 	return 0, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) MpoolNonceGaps(p0 context.Context) ([]NonceGapReport, error) {
+	return s.Internal.MpoolNonceGaps(p0)
+}
+
+func (s *FullNodeStub) MpoolNonceGaps(p0 context.Context) ([]NonceGapReport, error) {
+	return *new([]NonceGapReport), xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) MpoolPending(p0 context.Context, p1 types.TipSetKey) ([]*types.SignedMessage, error) {
 	return s.Internal.MpoolPending(p0, p1)
 }
@@ -1631,6 +1979,14 @@ func (s *FullNodeStub) MpoolPushUntrusted(p0 context.Context, p1 *types.SignedMe
 	return *new(cid.Cid), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) MpoolReorgs(p0 context.Context) (<-chan MpoolReorg, error) {
+	return s.Internal.MpoolReorgs(p0)
+}
+
+func (s *FullNodeStub) MpoolReorgs(p0 context.Context) (<-chan MpoolReorg, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) MpoolSelect(p0 context.Context, p1 types.TipSetKey, p2 float64) ([]*types.SignedMessage, error) {
 	return s.Internal.MpoolSelect(p0, p1, p2)
 }
@@ -1783,6 +2139,22 @@ func (s *FullNodeStub) MsigSwapPropose(p0 context.Context, p1 address.Address, p
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) NetPropagation(p0 context.Context) (*PropagationReport, error) {
+	return s.Internal.NetPropagation(p0)
+}
+
+func (s *FullNodeStub) NetPropagation(p0 context.Context) (*PropagationReport, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) NetPropagationReset(p0 context.Context) error {
+	return s.Internal.NetPropagationReset(p0)
+}
+
+func (s *FullNodeStub) NetPropagationReset(p0 context.Context) error {
+	return xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) NodeStatus(p0 context.Context, p1 bool) (NodeStatus, error) {
 	return s.Internal.NodeStatus(p0, p1)
 }
@@ -1927,6 +2299,30 @@ func (s *FullNodeStub) StateAccountKey(p0 context.Context, p1 address.Address, p
 	return *new(address.Address), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateActorCodeCIDs(p0 context.Context, p1 apitypes.NetworkVersion) (map[string]cid.Cid, error) {
+	return s.Internal.StateActorCodeCIDs(p0, p1)
+}
+
+func (s *FullNodeStub) StateActorCodeCIDs(p0 context.Context, p1 apitypes.NetworkVersion) (map[string]cid.Cid, error) {
+	return *new(map[string]cid.Cid), xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) StateActorLifecycle(p0 context.Context, p1 address.Address) (*ActorLifecycle, error) {
+	return s.Internal.StateActorLifecycle(p0, p1)
+}
+
+func (s *FullNodeStub) StateActorLifecycle(p0 context.Context, p1 address.Address) (*ActorLifecycle, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) StateActorMethods(p0 context.Context, p1 cid.Cid) ([]ActorMethod, error) {
+	return s.Internal.StateActorMethods(p0, p1)
+}
+
+func (s *FullNodeStub) StateActorMethods(p0 context.Context, p1 cid.Cid) ([]ActorMethod, error) {
+	return *new([]ActorMethod), xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateAllMinerFaults(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) ([]*Fault, error) {
 	return s.Internal.StateAllMinerFaults(p0, p1, p2)
 }
@@ -1959,6 +2355,14 @@ func (s *FullNodeStub) StateCirculatingSupply(p0 context.Context, p1 types.TipSe
 	return *new(abi.TokenAmount), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateCirculatingSupplyBreakdown(p0 context.Context, p1 types.TipSetKey) (*CirculatingSupplyBreakdown, error) {
+	return s.Internal.StateCirculatingSupplyBreakdown(p0, p1)
+}
+
+func (s *FullNodeStub) StateCirculatingSupplyBreakdown(p0 context.Context, p1 types.TipSetKey) (*CirculatingSupplyBreakdown, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateCompute(p0 context.Context, p1 abi.ChainEpoch, p2 []*types.Message, p3 types.TipSetKey) (*ComputeStateOutput, error) {
 	return s.Internal.StateCompute(p0, p1, p2, p3)
 }
@@ -1975,6 +2379,30 @@ func (s *FullNodeStub) StateDealProviderCollateralBounds(p0 context.Context, p1
 	return *new(DealCollateralBounds), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateDecodeActorParams(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 []byte) (interface{}, error) {
+	return s.Internal.StateDecodeActorParams(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateDecodeActorParams(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 []byte) (interface{}, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) StateDecodeActorReturn(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 []byte) (interface{}, error) {
+	return s.Internal.StateDecodeActorReturn(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateDecodeActorReturn(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 []byte) (interface{}, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) StateDecodeMessage(p0 context.Context, p1 cid.Cid) (*DecodedMessage, error) {
+	return s.Internal.StateDecodeMessage(p0, p1)
+}
+
+func (s *FullNodeStub) StateDecodeMessage(p0 context.Context, p1 cid.Cid) (*DecodedMessage, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateDecodeParams(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) {
 	return s.Internal.StateDecodeParams(p0, p1, p2, p3, p4)
 }
@@ -1983,6 +2411,14 @@ func (s *FullNodeStub) StateDecodeParams(p0 context.Context, p1 address.Address,
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateDecodeReturn(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) {
+	return s.Internal.StateDecodeReturn(p0, p1, p2, p3, p4)
+}
+
+func (s *FullNodeStub) StateDecodeReturn(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateGetActor(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*types.Actor, error) {
 	return s.Internal.StateGetActor(p0, p1, p2)
 }
@@ -1999,6 +2435,14 @@ func (s *FullNodeStub) StateListActors(p0 context.Context, p1 types.TipSetKey) (
 	return *new([]address.Address), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateListCreatedActors(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch) ([]ActorLifecycle, error) {
+	return s.Internal.StateListCreatedActors(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateListCreatedActors(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch) ([]ActorLifecycle, error) {
+	return *new([]ActorLifecycle), xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateListMessages(p0 context.Context, p1 *MessageMatch, p2 types.TipSetKey, p3 abi.ChainEpoch) ([]cid.Cid, error) {
 	return s.Internal.StateListMessages(p0, p1, p2, p3)
 }
@@ -2135,6 +2579,14 @@ func (s *FullNodeStub) StateMinerProvingDeadline(p0 context.Context, p1 address.
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateMinerProvingSummary(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*MinerProvingSummary, error) {
+	return s.Internal.StateMinerProvingSummary(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateMinerProvingSummary(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*MinerProvingSummary, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateMinerRecoveries(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (bitfield.BitField, error) {
 	return s.Internal.StateMinerRecoveries(p0, p1, p2)
 }
@@ -2167,15 +2619,31 @@ func (s *FullNodeStub) StateMinerSectors(p0 context.Context, p1 address.Address,
 	return *new([]*miner.SectorOnChainInfo), xerrors.New("method not supported")
 }
 
-func (s *FullNodeStruct) StateNetworkName(p0 context.Context) (dtypes.NetworkName, error) {
-	return s.Internal.StateNetworkName(p0)
+func (s *FullNodeStruct) StateMinerSectorsPage(p0 context.Context, p1 address.Address, p2 MinerSectorsQuery, p3 types.TipSetKey) (*MinerSectorsPage, error) {
+	return s.Internal.StateMinerSectorsPage(p0, p1, p2, p3)
 }
 
-func (s *FullNodeStub) StateNetworkName(p0 context.Context) (dtypes.NetworkName, error) {
-	return *new(dtypes.NetworkName), xerrors.New("method not supported")
+func (s *FullNodeStub) StateMinerSectorsPage(p0 context.Context, p1 address.Address, p2 MinerSectorsQuery, p3 types.TipSetKey) (*MinerSectorsPage, error) {
+	return nil, xerrors.New("method not supported")
 }
 
-func (s *FullNodeStruct) StateNetworkVersion(p0 context.Context, p1 types.TipSetKey) (apitypes.NetworkVersion, error) {
+func (s *FullNodeStruct) StateMinerTerminationPenalty(p0 context.Context, p1 address.Address, p2 []abi.SectorNumber, p3 types.TipSetKey) (abi.TokenAmount, error) {
+	return s.Internal.StateMinerTerminationPenalty(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateMinerTerminationPenalty(p0 context.Context, p1 address.Address, p2 []abi.SectorNumber, p3 types.TipSetKey) (abi.TokenAmount, error) {
+	return *new(abi.TokenAmount), xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) StateNetworkName(p0 context.Context) (dtypes.NetworkName, error) {
+	return s.Internal.StateNetworkName(p0)
+}
+
+func (s *FullNodeStub) StateNetworkName(p0 context.Context) (dtypes.NetworkName, error) {
+	return *new(dtypes.NetworkName), xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) StateNetworkVersion(p0 context.Context, p1 types.TipSetKey) (apitypes.NetworkVersion, error) {
 	return s.Internal.StateNetworkVersion(p0, p1)
 }
 
@@ -2183,6 +2651,14 @@ func (s *FullNodeStub) StateNetworkVersion(p0 context.Context, p1 types.TipSetKe
 	return *new(apitypes.NetworkVersion), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateQuery(p0 context.Context, p1 []StateFieldQuery, p2 types.TipSetKey) (*StateQueryResult, error) {
+	return s.Internal.StateQuery(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateQuery(p0 context.Context, p1 []StateFieldQuery, p2 types.TipSetKey) (*StateQueryResult, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateReadState(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*ActorState, error) {
 	return s.Internal.StateReadState(p0, p1, p2)
 }
@@ -2199,6 +2675,14 @@ func (s *FullNodeStub) StateReplay(p0 context.Context, p1 types.TipSetKey, p2 ci
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateReplayDetailed(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid) (*ReplayTrace, error) {
+	return s.Internal.StateReplayDetailed(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateReplayDetailed(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid) (*ReplayTrace, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateSearchMsg(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 abi.ChainEpoch, p4 bool) (*MsgLookup, error) {
 	return s.Internal.StateSearchMsg(p0, p1, p2, p3, p4)
 }
@@ -2231,6 +2715,14 @@ func (s *FullNodeStub) StateSectorPartition(p0 context.Context, p1 address.Addre
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateSectorPledgeProjection(p0 context.Context, p1 abi.StoragePower, p2 abi.ChainEpoch, p3 types.TipSetKey) (*SectorPledgeProjection, error) {
+	return s.Internal.StateSectorPledgeProjection(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateSectorPledgeProjection(p0 context.Context, p1 abi.StoragePower, p2 abi.ChainEpoch, p3 types.TipSetKey) (*SectorPledgeProjection, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateSectorPreCommitInfo(p0 context.Context, p1 address.Address, p2 abi.SectorNumber, p3 types.TipSetKey) (miner.SectorPreCommitOnChainInfo, error) {
 	return s.Internal.StateSectorPreCommitInfo(p0, p1, p2, p3)
 }
@@ -2271,6 +2763,14 @@ func (s *FullNodeStub) StateVerifierStatus(p0 context.Context, p1 address.Addres
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateVerifyAggregateSeals(p0 context.Context, p1 proof5.AggregateSealVerifyProofAndInfos) (bool, error) {
+	return s.Internal.StateVerifyAggregateSeals(p0, p1)
+}
+
+func (s *FullNodeStub) StateVerifyAggregateSeals(p0 context.Context, p1 proof5.AggregateSealVerifyProofAndInfos) (bool, error) {
+	return *new(bool), xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateWaitMsg(p0 context.Context, p1 cid.Cid, p2 uint64, p3 abi.ChainEpoch, p4 bool) (*MsgLookup, error) {
 	return s.Internal.StateWaitMsg(p0, p1, p2, p3, p4)
 }
@@ -2279,6 +2779,14 @@ func (s *FullNodeStub) StateWaitMsg(p0 context.Context, p1 cid.Cid, p2 uint64, p
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateWatchMsg(p0 context.Context, p1 cid.Cid, p2 uint64, p3 abi.ChainEpoch, p4 bool) (<-chan MsgWatchEvent, error) {
+	return s.Internal.StateWatchMsg(p0, p1, p2, p3, p4)
+}
+
+func (s *FullNodeStub) StateWatchMsg(p0 context.Context, p1 cid.Cid, p2 uint64, p3 abi.ChainEpoch, p4 bool) (<-chan MsgWatchEvent, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) SyncCheckBad(p0 context.Context, p1 cid.Cid) (string, error) {
 	return s.Internal.SyncCheckBad(p0, p1)
 }
@@ -2295,6 +2803,14 @@ func (s *FullNodeStub) SyncCheckpoint(p0 context.Context, p1 types.TipSetKey) er
 	return xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) SyncConsensusFaults(p0 context.Context) ([]ConsensusFault, error) {
+	return s.Internal.SyncConsensusFaults(p0)
+}
+
+func (s *FullNodeStub) SyncConsensusFaults(p0 context.Context) ([]ConsensusFault, error) {
+	return *new([]ConsensusFault), xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) SyncIncomingBlocks(p0 context.Context) (<-chan *types.BlockHeader, error) {
 	return s.Internal.SyncIncomingBlocks(p0)
 }
@@ -2351,6 +2867,22 @@ func (s *FullNodeStub) SyncValidateTipset(p0 context.Context, p1 types.TipSetKey
 	return false, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) SyncValidationTiming(p0 context.Context) (SyncValidationTiming, error) {
+	return s.Internal.SyncValidationTiming(p0)
+}
+
+func (s *FullNodeStub) SyncValidationTiming(p0 context.Context) (SyncValidationTiming, error) {
+	return *new(SyncValidationTiming), xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) WalletAddressLabelSet(p0 context.Context, p1 address.Address, p2 string) error {
+	return s.Internal.WalletAddressLabelSet(p0, p1, p2)
+}
+
+func (s *FullNodeStub) WalletAddressLabelSet(p0 context.Context, p1 address.Address, p2 string) error {
+	return xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) WalletBalance(p0 context.Context, p1 address.Address) (types.BigInt, error) {
 	return s.Internal.WalletBalance(p0, p1)
 }
@@ -2399,6 +2931,14 @@ func (s *FullNodeStub) WalletImport(p0 context.Context, p1 *types.KeyInfo) (addr
 	return *new(address.Address), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) WalletLabels(p0 context.Context) (*WalletLabels, error) {
+	return s.Internal.WalletLabels(p0)
+}
+
+func (s *FullNodeStub) WalletLabels(p0 context.Context) (*WalletLabels, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) WalletList(p0 context.Context) ([]address.Address, error) {
 	return s.Internal.WalletList(p0)
 }
@@ -2407,6 +2947,14 @@ func (s *FullNodeStub) WalletList(p0 context.Context) ([]address.Address, error)
 	return *new([]address.Address), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) WalletMessageLabelSet(p0 context.Context, p1 cid.Cid, p2 string) error {
+	return s.Internal.WalletMessageLabelSet(p0, p1, p2)
+}
+
+func (s *FullNodeStub) WalletMessageLabelSet(p0 context.Context, p1 cid.Cid, p2 string) error {
+	return xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) WalletNew(p0 context.Context, p1 types.KeyType) (address.Address, error) {
 	return s.Internal.WalletNew(p0, p1)
 }
@@ -2687,6 +3235,46 @@ func (s *GatewayStub) WalletBalance(p0 context.Context, p1 address.Address) (typ
 	return *new(types.BigInt), xerrors.New("method not supported")
 }
 
+func (s *SealingServiceStruct) SealCommit(p0 context.Context, p1 abi.SectorID, p2 abi.InteractiveSealRandomness) error {
+	return s.Internal.SealCommit(p0, p1, p2)
+}
+
+func (s *SealingServiceStub) SealCommit(p0 context.Context, p1 abi.SectorID, p2 abi.InteractiveSealRandomness) error {
+	return xerrors.New("method not supported")
+}
+
+func (s *SealingServiceStruct) SealPreCommit(p0 context.Context, p1 storiface.SealPreCommitRequest) error {
+	return s.Internal.SealPreCommit(p0, p1)
+}
+
+func (s *SealingServiceStub) SealPreCommit(p0 context.Context, p1 storiface.SealPreCommitRequest) error {
+	return xerrors.New("method not supported")
+}
+
+func (s *SealingServiceStruct) SealRelease(p0 context.Context, p1 abi.SectorID) error {
+	return s.Internal.SealRelease(p0, p1)
+}
+
+func (s *SealingServiceStub) SealRelease(p0 context.Context, p1 abi.SectorID) error {
+	return xerrors.New("method not supported")
+}
+
+func (s *SealingServiceStruct) SealStatus(p0 context.Context, p1 abi.SectorID) (storiface.SealJob, error) {
+	return s.Internal.SealStatus(p0, p1)
+}
+
+func (s *SealingServiceStub) SealStatus(p0 context.Context, p1 abi.SectorID) (storiface.SealJob, error) {
+	return *new(storiface.SealJob), xerrors.New("method not supported")
+}
+
+func (s *SealingServiceStruct) Version(p0 context.Context) (Version, error) {
+	return s.Internal.Version(p0)
+}
+
+func (s *SealingServiceStub) Version(p0 context.Context) (Version, error) {
+	return *new(Version), xerrors.New("method not supported")
+}
+
 func (s *SignableStruct) Sign(p0 context.Context, p1 SignFunc) error {
 	return s.Internal.Sign(p0, p1)
 }
@@ -2711,6 +3299,46 @@ func (s *StorageMinerStub) ActorAddressConfig(p0 context.Context) (AddressConfig
 	return *new(AddressConfig), xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) ActorAddresses(p0 context.Context) ([]address.Address, error) {
+	return s.Internal.ActorAddresses(p0)
+}
+
+func (s *StorageMinerStub) ActorAddresses(p0 context.Context) ([]address.Address, error) {
+	return *new([]address.Address), xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) ActorControlSet(p0 context.Context, p1 []address.Address) (cid.Cid, error) {
+	return s.Internal.ActorControlSet(p0, p1)
+}
+
+func (s *StorageMinerStub) ActorControlSet(p0 context.Context, p1 []address.Address) (cid.Cid, error) {
+	return *new(cid.Cid), xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) ActorControlSetPlan(p0 context.Context, p1 []address.Address, p2 abi.TokenAmount) (*ControlAddressPlan, error) {
+	return s.Internal.ActorControlSetPlan(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) ActorControlSetPlan(p0 context.Context, p1 []address.Address, p2 abi.TokenAmount) (*ControlAddressPlan, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) ActorOwnerChangeConfirm(p0 context.Context, p1 crypto.Signature) (cid.Cid, error) {
+	return s.Internal.ActorOwnerChangeConfirm(p0, p1)
+}
+
+func (s *StorageMinerStub) ActorOwnerChangeConfirm(p0 context.Context, p1 crypto.Signature) (cid.Cid, error) {
+	return *new(cid.Cid), xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) ActorOwnerChangePrepare(p0 context.Context, p1 address.Address) (*OwnerChange, error) {
+	return s.Internal.ActorOwnerChangePrepare(p0, p1)
+}
+
+func (s *StorageMinerStub) ActorOwnerChangePrepare(p0 context.Context, p1 address.Address) (*OwnerChange, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) ActorSectorSize(p0 context.Context, p1 address.Address) (abi.SectorSize, error) {
 	return s.Internal.ActorSectorSize(p0, p1)
 }
@@ -2719,6 +3347,46 @@ func (s *StorageMinerStub) ActorSectorSize(p0 context.Context, p1 address.Addres
 	return *new(abi.SectorSize), xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) ActorSpending(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 abi.ChainEpoch) (*MinerSpending, error) {
+	return s.Internal.ActorSpending(p0, p1, p2, p3)
+}
+
+func (s *StorageMinerStub) ActorSpending(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 abi.ChainEpoch) (*MinerSpending, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) ActorWorkerRotate(p0 context.Context, p1 address.Address) (*WorkerRotation, error) {
+	return s.Internal.ActorWorkerRotate(p0, p1)
+}
+
+func (s *StorageMinerStub) ActorWorkerRotate(p0 context.Context, p1 address.Address) (*WorkerRotation, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) ActorWorkerRotation(p0 context.Context) (*WorkerRotation, error) {
+	return s.Internal.ActorWorkerRotation(p0)
+}
+
+func (s *StorageMinerStub) ActorWorkerRotation(p0 context.Context) (*WorkerRotation, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) AlertsAck(p0 context.Context, p1 alerting.AlertType) error {
+	return s.Internal.AlertsAck(p0, p1)
+}
+
+func (s *StorageMinerStub) AlertsAck(p0 context.Context, p1 alerting.AlertType) error {
+	return xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) AlertsList(p0 context.Context) ([]alerting.Alert, error) {
+	return s.Internal.AlertsList(p0)
+}
+
+func (s *StorageMinerStub) AlertsList(p0 context.Context) ([]alerting.Alert, error) {
+	return *new([]alerting.Alert), xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) CheckProvable(p0 context.Context, p1 abi.RegisteredPoStProof, p2 []storage.SectorRef, p3 bool) (map[abi.SectorNumber]string, error) {
 	return s.Internal.CheckProvable(p0, p1, p2, p3)
 }
@@ -2735,6 +3403,14 @@ func (s *StorageMinerStub) ComputeProof(p0 context.Context, p1 []builtin.SectorI
 	return *new([]builtin.PoStProof), xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) ConfigReload(p0 context.Context) error {
+	return s.Internal.ConfigReload(p0)
+}
+
+func (s *StorageMinerStub) ConfigReload(p0 context.Context) error {
+	return xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) CreateBackup(p0 context.Context, p1 string) error {
 	return s.Internal.CreateBackup(p0, p1)
 }
@@ -2743,6 +3419,14 @@ func (s *StorageMinerStub) CreateBackup(p0 context.Context, p1 string) error {
 	return xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) CreateBackupIncremental(p0 context.Context, p1 string) (*BackupSnapshot, error) {
+	return s.Internal.CreateBackupIncremental(p0, p1)
+}
+
+func (s *StorageMinerStub) CreateBackupIncremental(p0 context.Context, p1 string) (*BackupSnapshot, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) DealsConsiderOfflineRetrievalDeals(p0 context.Context) (bool, error) {
 	return s.Internal.DealsConsiderOfflineRetrievalDeals(p0)
 }
@@ -2871,6 +3555,30 @@ func (s *StorageMinerStub) DealsSetPieceCidBlocklist(p0 context.Context, p1 []ci
 	return xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) FeeBreakerStatus(p0 context.Context) (FeeBreakerStatus, error) {
+	return s.Internal.FeeBreakerStatus(p0)
+}
+
+func (s *StorageMinerStub) FeeBreakerStatus(p0 context.Context) (FeeBreakerStatus, error) {
+	return *new(FeeBreakerStatus), xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) MaintenanceMode(p0 context.Context) (bool, error) {
+	return s.Internal.MaintenanceMode(p0)
+}
+
+func (s *StorageMinerStub) MaintenanceMode(p0 context.Context) (bool, error) {
+	return *new(bool), xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) MaintenanceSet(p0 context.Context, p1 bool) error {
+	return s.Internal.MaintenanceSet(p0, p1)
+}
+
+func (s *StorageMinerStub) MaintenanceSet(p0 context.Context, p1 bool) error {
+	return xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) MarketCancelDataTransfer(p0 context.Context, p1 datatransfer.TransferID, p2 peer.ID, p3 bool) error {
 	return s.Internal.MarketCancelDataTransfer(p0, p1, p2, p3)
 }
@@ -2911,6 +3619,14 @@ func (s *StorageMinerStub) MarketGetRetrievalAsk(p0 context.Context) (*retrieval
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) MarketGetRetrievalPricingPolicy(p0 context.Context) (dtypes.RetrievalPricingPolicy, error) {
+	return s.Internal.MarketGetRetrievalPricingPolicy(p0)
+}
+
+func (s *StorageMinerStub) MarketGetRetrievalPricingPolicy(p0 context.Context) (dtypes.RetrievalPricingPolicy, error) {
+	return *new(dtypes.RetrievalPricingPolicy), xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) MarketImportDealData(p0 context.Context, p1 cid.Cid, p2 string) error {
 	return s.Internal.MarketImportDealData(p0, p1, p2)
 }
@@ -2991,6 +3707,38 @@ func (s *StorageMinerStub) MarketSetRetrievalAsk(p0 context.Context, p1 *retriev
 	return xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) MarketSetRetrievalPricingPolicy(p0 context.Context, p1 dtypes.RetrievalPricingPolicy) error {
+	return s.Internal.MarketSetRetrievalPricingPolicy(p0, p1)
+}
+
+func (s *StorageMinerStub) MarketSetRetrievalPricingPolicy(p0 context.Context, p1 dtypes.RetrievalPricingPolicy) error {
+	return xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) MinerOverview(p0 context.Context) (*MinerOverview, error) {
+	return s.Internal.MinerOverview(p0)
+}
+
+func (s *StorageMinerStub) MinerOverview(p0 context.Context) (*MinerOverview, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) MinerReport(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 abi.ChainEpoch) (*MinerReport, error) {
+	return s.Internal.MinerReport(p0, p1, p2, p3)
+}
+
+func (s *StorageMinerStub) MinerReport(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 abi.ChainEpoch) (*MinerReport, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) MiningAttempts(p0 context.Context) ([]MiningAttempt, error) {
+	return s.Internal.MiningAttempts(p0)
+}
+
+func (s *StorageMinerStub) MiningAttempts(p0 context.Context) ([]MiningAttempt, error) {
+	return *new([]MiningAttempt), xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) MiningBase(p0 context.Context) (*types.TipSet, error) {
 	return s.Internal.MiningBase(p0)
 }
@@ -2999,6 +3747,14 @@ func (s *StorageMinerStub) MiningBase(p0 context.Context) (*types.TipSet, error)
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) PayoutSchedule(p0 context.Context) (*PayoutSchedule, error) {
+	return s.Internal.PayoutSchedule(p0)
+}
+
+func (s *StorageMinerStub) PayoutSchedule(p0 context.Context) (*PayoutSchedule, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) PiecesGetCIDInfo(p0 context.Context, p1 cid.Cid) (*piecestore.CIDInfo, error) {
 	return s.Internal.PiecesGetCIDInfo(p0, p1)
 }
@@ -3039,6 +3795,14 @@ func (s *StorageMinerStub) PledgeSector(p0 context.Context) (abi.SectorID, error
 	return *new(abi.SectorID), xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) PledgeSectorFor(p0 context.Context, p1 address.Address) (abi.SectorID, error) {
+	return s.Internal.PledgeSectorFor(p0, p1)
+}
+
+func (s *StorageMinerStub) PledgeSectorFor(p0 context.Context, p1 address.Address) (abi.SectorID, error) {
+	return *new(abi.SectorID), xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) ReturnAddPiece(p0 context.Context, p1 storiface.CallID, p2 abi.PieceInfo, p3 *storiface.CallError) error {
 	return s.Internal.ReturnAddPiece(p0, p1, p2, p3)
 }
@@ -3135,6 +3899,22 @@ func (s *StorageMinerStub) SealingAbort(p0 context.Context, p1 storiface.CallID)
 	return xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) SealingFSMGraph(p0 context.Context) (SealingFSMGraph, error) {
+	return s.Internal.SealingFSMGraph(p0)
+}
+
+func (s *StorageMinerStub) SealingFSMGraph(p0 context.Context) (SealingFSMGraph, error) {
+	return *new(SealingFSMGraph), xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) SealingForecast(p0 context.Context) (SealingForecast, error) {
+	return s.Internal.SealingForecast(p0)
+}
+
+func (s *StorageMinerStub) SealingForecast(p0 context.Context) (SealingForecast, error) {
+	return *new(SealingForecast), xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) SealingSchedDiag(p0 context.Context, p1 bool) (interface{}, error) {
 	return s.Internal.SealingSchedDiag(p0, p1)
 }
@@ -3143,6 +3923,22 @@ func (s *StorageMinerStub) SealingSchedDiag(p0 context.Context, p1 bool) (interf
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) SectorAddPieceToAny(p0 context.Context, p1 abi.UnpaddedPieceSize, p2 storage.Data, p3 PieceDealInfo) (SectorOffset, error) {
+	return s.Internal.SectorAddPieceToAny(p0, p1, p2, p3)
+}
+
+func (s *StorageMinerStub) SectorAddPieceToAny(p0 context.Context, p1 abi.UnpaddedPieceSize, p2 storage.Data, p3 PieceDealInfo) (SectorOffset, error) {
+	return *new(SectorOffset), xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) SectorBatchStatus(p0 context.Context) (SectorBatchStatus, error) {
+	return s.Internal.SectorBatchStatus(p0)
+}
+
+func (s *StorageMinerStub) SectorBatchStatus(p0 context.Context) (SectorBatchStatus, error) {
+	return *new(SectorBatchStatus), xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) SectorCommitFlush(p0 context.Context) ([]sealiface.CommitBatchRes, error) {
 	return s.Internal.SectorCommitFlush(p0)
 }
@@ -3175,6 +3971,14 @@ func (s *StorageMinerStub) SectorGetSealDelay(p0 context.Context) (time.Duration
 	return *new(time.Duration), xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) SectorLog(p0 context.Context, p1 abi.SectorNumber) ([]SectorLogEntry, error) {
+	return s.Internal.SectorLog(p0, p1)
+}
+
+func (s *StorageMinerStub) SectorLog(p0 context.Context, p1 abi.SectorNumber) ([]SectorLogEntry, error) {
+	return *new([]SectorLogEntry), xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) SectorMarkForUpgrade(p0 context.Context, p1 abi.SectorNumber) error {
 	return s.Internal.SectorMarkForUpgrade(p0, p1)
 }
@@ -3183,6 +3987,30 @@ func (s *StorageMinerStub) SectorMarkForUpgrade(p0 context.Context, p1 abi.Secto
 	return xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) SectorNumFree(p0 context.Context, p1 string) error {
+	return s.Internal.SectorNumFree(p0, p1)
+}
+
+func (s *StorageMinerStub) SectorNumFree(p0 context.Context, p1 string) error {
+	return xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) SectorNumReservations(p0 context.Context) (map[string]bitfield.BitField, error) {
+	return s.Internal.SectorNumReservations(p0)
+}
+
+func (s *StorageMinerStub) SectorNumReservations(p0 context.Context) (map[string]bitfield.BitField, error) {
+	return *new(map[string]bitfield.BitField), xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) SectorNumReserve(p0 context.Context, p1 string, p2 bitfield.BitField, p3 bool) error {
+	return s.Internal.SectorNumReserve(p0, p1, p2, p3)
+}
+
+func (s *StorageMinerStub) SectorNumReserve(p0 context.Context, p1 string, p2 bitfield.BitField, p3 bool) error {
+	return xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) SectorPreCommitFlush(p0 context.Context) ([]sealiface.PreCommitBatchRes, error) {
 	return s.Internal.SectorPreCommitFlush(p0)
 }
@@ -3199,6 +4027,22 @@ func (s *StorageMinerStub) SectorPreCommitPending(p0 context.Context) ([]abi.Sec
 	return *new([]abi.SectorID), xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) SectorReceipts(p0 context.Context, p1 abi.SectorNumber) ([]storiface.WorkReceipt, error) {
+	return s.Internal.SectorReceipts(p0, p1)
+}
+
+func (s *StorageMinerStub) SectorReceipts(p0 context.Context, p1 abi.SectorNumber) ([]storiface.WorkReceipt, error) {
+	return *new([]storiface.WorkReceipt), xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) SectorRegenerateProof(p0 context.Context, p1 abi.SectorNumber) error {
+	return s.Internal.SectorRegenerateProof(p0, p1)
+}
+
+func (s *StorageMinerStub) SectorRegenerateProof(p0 context.Context, p1 abi.SectorNumber) error {
+	return xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) SectorRemove(p0 context.Context, p1 abi.SectorNumber) error {
 	return s.Internal.SectorRemove(p0, p1)
 }
@@ -3215,6 +4059,14 @@ func (s *StorageMinerStub) SectorSetExpectedSealDuration(p0 context.Context, p1
 	return xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) SectorSetLabels(p0 context.Context, p1 abi.SectorNumber, p2 map[string]string) error {
+	return s.Internal.SectorSetLabels(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) SectorSetLabels(p0 context.Context, p1 abi.SectorNumber, p2 map[string]string) error {
+	return xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) SectorSetSealDelay(p0 context.Context, p1 time.Duration) error {
 	return s.Internal.SectorSetSealDelay(p0, p1)
 }
@@ -3255,6 +4107,14 @@ func (s *StorageMinerStub) SectorTerminatePending(p0 context.Context) ([]abi.Sec
 	return *new([]abi.SectorID), xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) SectorsETA(p0 context.Context) ([]SectorETA, error) {
+	return s.Internal.SectorsETA(p0)
+}
+
+func (s *StorageMinerStub) SectorsETA(p0 context.Context) ([]SectorETA, error) {
+	return *new([]SectorETA), xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) SectorsList(p0 context.Context) ([]abi.SectorNumber, error) {
 	return s.Internal.SectorsList(p0)
 }
@@ -3279,6 +4139,22 @@ func (s *StorageMinerStub) SectorsRefs(p0 context.Context) (map[string][]SealedR
 	return *new(map[string][]SealedRef), xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) SectorsStateChanges(p0 context.Context, p1 SectorStateFilter) (<-chan SectorStateChange, error) {
+	return s.Internal.SectorsStateChanges(p0, p1)
+}
+
+func (s *StorageMinerStub) SectorsStateChanges(p0 context.Context, p1 SectorStateFilter) (<-chan SectorStateChange, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) SectorsStateChangesWithPolicy(p0 context.Context, p1 SectorStateFilter, p2 subscription.Policy) (<-chan SectorStateChange, error) {
+	return s.Internal.SectorsStateChangesWithPolicy(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) SectorsStateChangesWithPolicy(p0 context.Context, p1 SectorStateFilter, p2 subscription.Policy) (<-chan SectorStateChange, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) SectorsStatus(p0 context.Context, p1 abi.SectorNumber, p2 bool) (SectorInfo, error) {
 	return s.Internal.SectorsStatus(p0, p1, p2)
 }
@@ -3295,6 +4171,22 @@ func (s *StorageMinerStub) SectorsSummary(p0 context.Context) (map[SectorState]i
 	return *new(map[SectorState]int), xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) SectorsUnsealPiece(p0 context.Context, p1 storage.SectorRef, p2 storiface.UnpaddedByteIndex, p3 abi.UnpaddedPieceSize, p4 abi.SealRandomness, p5 *cid.Cid) error {
+	return s.Internal.SectorsUnsealPiece(p0, p1, p2, p3, p4, p5)
+}
+
+func (s *StorageMinerStub) SectorsUnsealPiece(p0 context.Context, p1 storage.SectorRef, p2 storiface.UnpaddedByteIndex, p3 abi.UnpaddedPieceSize, p4 abi.SealRandomness, p5 *cid.Cid) error {
+	return xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) SectorsUnsealQueue(p0 context.Context) ([]storiface.UnsealJob, error) {
+	return s.Internal.SectorsUnsealQueue(p0)
+}
+
+func (s *StorageMinerStub) SectorsUnsealQueue(p0 context.Context) ([]storiface.UnsealJob, error) {
+	return *new([]storiface.UnsealJob), xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) SectorsUpdate(p0 context.Context, p1 abi.SectorNumber, p2 SectorState) error {
 	return s.Internal.SectorsUpdate(p0, p1, p2)
 }
@@ -3383,6 +4275,22 @@ func (s *StorageMinerStub) StorageLock(p0 context.Context, p1 abi.SectorID, p2 s
 	return xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) StorageMoveList(p0 context.Context) ([]stores.SectorMove, error) {
+	return s.Internal.StorageMoveList(p0)
+}
+
+func (s *StorageMinerStub) StorageMoveList(p0 context.Context) ([]stores.SectorMove, error) {
+	return *new([]stores.SectorMove), xerrors.New("method not supported")
+}
+
+func (s *StorageMinerStruct) StorageMoveSector(p0 context.Context, p1 abi.SectorNumber, p2 stores.ID) error {
+	return s.Internal.StorageMoveSector(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) StorageMoveSector(p0 context.Context, p1 abi.SectorNumber, p2 stores.ID) error {
+	return xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) StorageReportHealth(p0 context.Context, p1 stores.ID, p2 stores.HealthReport) error {
 	return s.Internal.StorageReportHealth(p0, p1, p2)
 }
@@ -3399,6 +4307,14 @@ func (s *StorageMinerStub) StorageStat(p0 context.Context, p1 stores.ID) (fsutil
 	return *new(fsutil.FsStat), xerrors.New("method not supported")
 }
 
+func (s *StorageMinerStruct) StorageTierList(p0 context.Context) ([]stores.SectorTier, error) {
+	return s.Internal.StorageTierList(p0)
+}
+
+func (s *StorageMinerStub) StorageTierList(p0 context.Context) ([]stores.SectorTier, error) {
+	return *new([]stores.SectorTier), xerrors.New("method not supported")
+}
+
 func (s *StorageMinerStruct) StorageTryLock(p0 context.Context, p1 abi.SectorID, p2 storiface.SectorFileType, p3 storiface.SectorFileType) (bool, error) {
 	return s.Internal.StorageTryLock(p0, p1, p2, p3)
 }
@@ -3519,6 +4435,14 @@ func (s *WorkerStub) FinalizeSector(p0 context.Context, p1 storage.SectorRef, p2
 	return *new(storiface.CallID), xerrors.New("method not supported")
 }
 
+func (s *WorkerStruct) GPUUsage(p0 context.Context) ([]storiface.GPUUsage, error) {
+	return s.Internal.GPUUsage(p0)
+}
+
+func (s *WorkerStub) GPUUsage(p0 context.Context) ([]storiface.GPUUsage, error) {
+	return *new([]storiface.GPUUsage), xerrors.New("method not supported")
+}
+
 func (s *WorkerStruct) Info(p0 context.Context) (storiface.WorkerInfo, error) {
 	return s.Internal.Info(p0)
 }
@@ -3567,6 +4491,14 @@ func (s *WorkerStub) Remove(p0 context.Context, p1 abi.SectorID) error {
 	return xerrors.New("method not supported")
 }
 
+func (s *WorkerStruct) RunningTasks(p0 context.Context) ([]storiface.WorkerJob, error) {
+	return s.Internal.RunningTasks(p0)
+}
+
+func (s *WorkerStub) RunningTasks(p0 context.Context) ([]storiface.WorkerJob, error) {
+	return *new([]storiface.WorkerJob), xerrors.New("method not supported")
+}
+
 func (s *WorkerStruct) SealCommit1(p0 context.Context, p1 storage.SectorRef, p2 abi.SealRandomness, p3 abi.InteractiveSealRandomness, p4 []abi.PieceInfo, p5 storage.SectorCids) (storiface.CallID, error) {
 	return s.Internal.SealCommit1(p0, p1, p2, p3, p4, p5)
 }
@@ -3615,6 +4547,14 @@ func (s *WorkerStub) SetEnabled(p0 context.Context, p1 bool) error {
 	return xerrors.New("method not supported")
 }
 
+func (s *WorkerStruct) Shutdown(p0 context.Context) error {
+	return s.Internal.Shutdown(p0)
+}
+
+func (s *WorkerStub) Shutdown(p0 context.Context) error {
+	return xerrors.New("method not supported")
+}
+
 func (s *WorkerStruct) StorageAddLocal(p0 context.Context, p1 string) error {
 	return s.Internal.StorageAddLocal(p0, p1)
 }
@@ -3655,6 +4595,38 @@ func (s *WorkerStub) UnsealPiece(p0 context.Context, p1 storage.SectorRef, p2 st
 	return *new(storiface.CallID), xerrors.New("method not supported")
 }
 
+func (s *WorkerStruct) VerifyAggregateSeals(p0 context.Context, p1 proof5.AggregateSealVerifyProofAndInfos) (bool, error) {
+	return s.Internal.VerifyAggregateSeals(p0, p1)
+}
+
+func (s *WorkerStub) VerifyAggregateSeals(p0 context.Context, p1 proof5.AggregateSealVerifyProofAndInfos) (bool, error) {
+	return *new(bool), xerrors.New("method not supported")
+}
+
+func (s *WorkerStruct) VerifySeal(p0 context.Context, p1 proof5.SealVerifyInfo) (bool, error) {
+	return s.Internal.VerifySeal(p0, p1)
+}
+
+func (s *WorkerStub) VerifySeal(p0 context.Context, p1 proof5.SealVerifyInfo) (bool, error) {
+	return *new(bool), xerrors.New("method not supported")
+}
+
+func (s *WorkerStruct) VerifyWindowPoSt(p0 context.Context, p1 proof5.WindowPoStVerifyInfo) (bool, error) {
+	return s.Internal.VerifyWindowPoSt(p0, p1)
+}
+
+func (s *WorkerStub) VerifyWindowPoSt(p0 context.Context, p1 proof5.WindowPoStVerifyInfo) (bool, error) {
+	return *new(bool), xerrors.New("method not supported")
+}
+
+func (s *WorkerStruct) VerifyWinningPoSt(p0 context.Context, p1 proof5.WinningPoStVerifyInfo) (bool, error) {
+	return s.Internal.VerifyWinningPoSt(p0, p1)
+}
+
+func (s *WorkerStub) VerifyWinningPoSt(p0 context.Context, p1 proof5.WinningPoStVerifyInfo) (bool, error) {
+	return *new(bool), xerrors.New("method not supported")
+}
+
 func (s *WorkerStruct) Version(p0 context.Context) (Version, error) {
 	return s.Internal.Version(p0)
 }
@@ -3675,6 +4647,7 @@ var _ ChainIO = new(ChainIOStruct)
 var _ Common = new(CommonStruct)
 var _ FullNode = new(FullNodeStruct)
 var _ Gateway = new(GatewayStruct)
+var _ SealingService = new(SealingServiceStruct)
 var _ Signable = new(SignableStruct)
 var _ StorageMiner = new(StorageMinerStruct)
 var _ Wallet = new(WalletStruct)
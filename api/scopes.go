@@ -0,0 +1,308 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-jsonrpc/auth"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// Scoped tokens
+//
+// Besides the four global permissions, tokens can carry scopes, which grant a
+// permission level on a single area of the API only:
+//
+//   sealing:read            read only access to the sealing pipeline
+//   deals:write             manage deals, without access to any other area
+//   wallet:sign:<address>   use the wallet, but only for the given address
+//
+// The area of a method is derived from its method group (see scopeAreas), and
+// a scope with a given level also grants all lower levels, so `deals:write`
+// implies `deals:read`.
+//
+// Address restricted scopes only grant access to calls on the given address:
+// the first address parameter of the call, or the sender of the first message
+// parameter, must match. Calls without such parameters aren't granted.
+// Addresses are compared as-is, so a scope on a key address doesn't match
+// calls made with the matching ID address.
+//
+// Methods which sign with the wallet key of the sender (see walletSigners)
+// are also granted by wallet scopes restricted to the sender, so that
+// `wallet:sign:<address>` allows sending messages from that address.
+//
+// The `remote:read` scope isn't an API area: it grants reading sector files
+// from the /remote endpoints of the miner and its workers, see HasRemotePerm.
+// Markets-only nodes fetch sector data with such a token.
+//
+// Scopes are stored in the token alongside regular permissions, so tokens
+// created before scopes were introduced keep working unchanged.
+
+// scopeAreas maps method groups to API areas. Groups not listed here are
+// their own area, lowercased.
+var scopeAreas = map[string]string{
+	// common
+	"Auth":     "auth",
+	"Net":      "net",
+	"Log":      "node",
+	"Journal":  "node",
+	"Alerts":   "node",
+	"Create":   "node",
+	"Closing":  "node",
+	"Discover": "node",
+	"ID":       "node",
+	"Session":  "node",
+	"Shutdown": "node",
+	"Version":  "node",
+
+	// full node
+	"Beacon": "chain",
+	"Chain":  "chain",
+	"Gas":    "chain",
+	"State":  "chain",
+	"Sync":   "chain",
+	"Mpool":  "mpool",
+	"Wallet": "wallet",
+	"Msig":   "msig",
+	"Paych":  "paych",
+	"Client": "deals",
+
+	// miner
+	"Actor":   "actor",
+	"Deals":   "deals",
+	"Market":  "deals",
+	"Pieces":  "deals",
+	"Mining":  "mining",
+	"Miner":   "mining",
+	"Check":   "proving",
+	"Compute": "proving",
+	"Pledge":  "sealing",
+	"Sealing": "sealing",
+	"Sector":  "sealing",
+	"Sectors": "sealing",
+	"Storage": "storage",
+	"Return":  "workers",
+	"Worker":  "workers",
+}
+
+// walletSigners are the methods outside of the wallet area which have the
+// wallet sign for the subject address of the call.
+var walletSigners = map[string]struct{}{
+	"MpoolPushMessage": {},
+}
+
+// MethodScopeArea returns the scope area of the named API method.
+func MethodScopeArea(method string) string {
+	if area, ok := scopeAreas[method]; ok {
+		return area
+	}
+
+	group := method
+	if i := strings.IndexFunc(method[1:], unicode.IsUpper); i >= 0 {
+		group = method[:i+1]
+	}
+
+	if area, ok := scopeAreas[group]; ok {
+		return area
+	}
+	return strings.ToLower(group)
+}
+
+// ScopeAreas returns all known scope areas.
+func ScopeAreas() []string {
+	seen := map[string]struct{}{}
+	var out []string
+	for _, area := range scopeAreas {
+		if _, ok := seen[area]; ok {
+			continue
+		}
+		seen[area] = struct{}{}
+		out = append(out, area)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// RemoteReadScope grants reading sector files from the /remote endpoints,
+// without access to the API itself
+var RemoteReadScope = Scope{Area: "remote", Perm: PermRead}
+
+type Scope struct {
+	Area string
+	Perm auth.Permission
+
+	// Address, when set, restricts the scope to calls on this address
+	Address *address.Address
+}
+
+func ParseScope(s string) (Scope, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) < 2 || parts[0] == "" {
+		return Scope{}, xerrors.Errorf("scope %q must be in area:perm[:address] form", s)
+	}
+
+	sc := Scope{
+		Area: parts[0],
+		Perm: auth.Permission(parts[1]),
+	}
+	if permLevel(sc.Perm) < 0 {
+		return Scope{}, xerrors.Errorf("scope %q: unknown permission %q, must be one of %s", s, parts[1], AllPermissions)
+	}
+
+	if len(parts) == 3 {
+		addr, err := address.NewFromString(parts[2])
+		if err != nil {
+			return Scope{}, xerrors.Errorf("scope %q: parsing address: %w", s, err)
+		}
+		sc.Address = &addr
+	}
+
+	return sc, nil
+}
+
+func (s Scope) String() string {
+	out := s.Area + ":" + string(s.Perm)
+	if s.Address != nil {
+		out += ":" + s.Address.String()
+	}
+	return out
+}
+
+// ValidPermission returns whether p can be assigned to a token, either as a
+// global permission, or as a scope.
+func ValidPermission(p auth.Permission) error {
+	if permLevel(p) >= 0 {
+		return nil
+	}
+	_, err := ParseScope(string(p))
+	return err
+}
+
+func permLevel(p auth.Permission) int {
+	for i, ap := range AllPermissions {
+		if ap == p {
+			return i
+		}
+	}
+	return -1
+}
+
+// HasRemotePerm returns whether the caller may make the request to the sector
+// file endpoints: admin tokens may make any request, tokens with a remote
+// scope may only read files.
+func HasRemotePerm(r *http.Request) bool {
+	if auth.HasPerm(r.Context(), nil, PermAdmin) {
+		return true
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	default:
+		return false
+	}
+
+	for _, level := range AllPermissions[permLevel(RemoteReadScope.Perm):] {
+		scope := Scope{Area: RemoteReadScope.Area, Perm: level}
+		if auth.HasPerm(r.Context(), nil, auth.Permission(scope.String())) {
+			return true
+		}
+	}
+	return false
+}
+
+// subjectAddress returns the address a call operates on, if any.
+func subjectAddress(args []reflect.Value) (address.Address, bool) {
+	for _, arg := range args {
+		switch v := arg.Interface().(type) {
+		case address.Address:
+			return v, true
+		case *types.Message:
+			if v != nil {
+				return v.From, true
+			}
+		}
+	}
+	return address.Undef, false
+}
+
+func hasScopedPerm(ctx context.Context, method, area string, perm auth.Permission, args []reflect.Value) bool {
+	if auth.HasPerm(ctx, DefaultPerms, perm) {
+		return true
+	}
+
+	subject, hasSubject := subjectAddress(args)
+	_, signer := walletSigners[method]
+	for _, level := range AllPermissions[permLevel(perm):] {
+		scope := Scope{Area: area, Perm: level}
+		if auth.HasPerm(ctx, nil, auth.Permission(scope.String())) {
+			return true
+		}
+
+		if !hasSubject {
+			continue
+		}
+
+		scope.Address = &subject
+		if auth.HasPerm(ctx, nil, auth.Permission(scope.String())) {
+			return true
+		}
+
+		if signer {
+			scope.Area = "wallet"
+			if auth.HasPerm(ctx, nil, auth.Permission(scope.String())) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// PermissionedProxy fills the out struct of API methods with calls to in,
+// checking that the caller has the permission required by the perm tag of
+// each method, either globally or through a scope on the method area.
+func PermissionedProxy(in interface{}, out interface{}) {
+	rint := reflect.ValueOf(out).Elem()
+	ra := reflect.ValueOf(in)
+
+	for f := 0; f < rint.NumField(); f++ {
+		field := rint.Type().Field(f)
+		requiredPerm := auth.Permission(field.Tag.Get("perm"))
+		if requiredPerm == "" {
+			panic("missing 'perm' tag on " + field.Name)
+		}
+		if permLevel(requiredPerm) < 0 {
+			panic("unknown 'perm' tag on " + field.Name)
+		}
+
+		fn := ra.MethodByName(field.Name)
+		name := field.Name
+		area := MethodScopeArea(name)
+
+		rint.Field(f).Set(reflect.MakeFunc(field.Type, func(args []reflect.Value) (results []reflect.Value) {
+			ctx := args[0].Interface().(context.Context)
+			if hasScopedPerm(ctx, name, area, requiredPerm, args[1:]) {
+				return fn.Call(args)
+			}
+
+			err := xerrors.Errorf("missing permission to invoke '%s' (need '%s' or '%s:%s')", name, requiredPerm, area, requiredPerm)
+			rerr := reflect.ValueOf(&err).Elem()
+
+			if field.Type.NumOut() == 2 {
+				return []reflect.Value{
+					reflect.Zero(field.Type.Out(0)),
+					rerr,
+				}
+			}
+			return []reflect.Value{rerr}
+		}))
+	}
+}
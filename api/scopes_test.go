@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-jsonrpc/auth"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+type scopeTestAPI struct{}
+
+func (scopeTestAPI) SealingSchedDiag(ctx context.Context) (int, error) {
+	return 1, nil
+}
+
+func (scopeTestAPI) SectorRemove(ctx context.Context) error {
+	return nil
+}
+
+func (scopeTestAPI) WalletSign(ctx context.Context, a address.Address) (int, error) {
+	return 2, nil
+}
+
+func (scopeTestAPI) MpoolPushMessage(ctx context.Context, msg *types.Message) (int, error) {
+	return 3, nil
+}
+
+type scopeTestStruct struct {
+	Internal struct {
+		SealingSchedDiag func(ctx context.Context) (int, error)                     `perm:"read"`
+		SectorRemove     func(ctx context.Context) error                            `perm:"admin"`
+		WalletSign       func(ctx context.Context, a address.Address) (int, error)  `perm:"sign"`
+		MpoolPushMessage func(ctx context.Context, msg *types.Message) (int, error) `perm:"sign"`
+	}
+}
+
+func TestParseScope(t *testing.T) {
+	addr, err := address.NewIDAddress(100)
+	require.NoError(t, err)
+
+	sc, err := ParseScope("wallet:sign:" + addr.String())
+	require.NoError(t, err)
+	require.Equal(t, "wallet", sc.Area)
+	require.Equal(t, PermSign, sc.Perm)
+	require.Equal(t, addr, *sc.Address)
+	require.Equal(t, "wallet:sign:"+addr.String(), sc.String())
+
+	_, err = ParseScope("sealing")
+	require.Error(t, err)
+	_, err = ParseScope("sealing:root")
+	require.Error(t, err)
+	_, err = ParseScope("wallet:sign:notanaddress")
+	require.Error(t, err)
+
+	require.NoError(t, ValidPermission(PermAdmin))
+	require.NoError(t, ValidPermission("deals:write"))
+	require.Error(t, ValidPermission("superuser"))
+}
+
+func TestMethodScopeArea(t *testing.T) {
+	require.Equal(t, "sealing", MethodScopeArea("SectorsList"))
+	require.Equal(t, "sealing", MethodScopeArea("SealingSchedDiag"))
+	require.Equal(t, "deals", MethodScopeArea("MarketListDeals"))
+	require.Equal(t, "chain", MethodScopeArea("StateMinerInfo"))
+	require.Equal(t, "node", MethodScopeArea("Version"))
+	require.Equal(t, "node", MethodScopeArea("ID"))
+	require.Equal(t, "net", MethodScopeArea("NetPeers"))
+	require.Equal(t, "unknown", MethodScopeArea("UnknownMethod"))
+}
+
+func TestPermissionedProxyScopes(t *testing.T) {
+	var out scopeTestStruct
+	PermissionedProxy(scopeTestAPI{}, &out.Internal)
+
+	a100, err := address.NewIDAddress(100)
+	require.NoError(t, err)
+	a101, err := address.NewIDAddress(101)
+	require.NoError(t, err)
+
+	withPerms := func(perms ...auth.Permission) context.Context {
+		return auth.WithPerm(context.Background(), perms)
+	}
+
+	// no token, default perms
+	_, err = out.Internal.SealingSchedDiag(context.Background())
+	require.NoError(t, err)
+	require.Error(t, out.Internal.SectorRemove(context.Background()))
+
+	// legacy permissions
+	ctx := withPerms(AllPermissions...)
+	_, err = out.Internal.SealingSchedDiag(ctx)
+	require.NoError(t, err)
+	require.NoError(t, out.Internal.SectorRemove(ctx))
+	_, err = out.Internal.WalletSign(ctx, a101)
+	require.NoError(t, err)
+
+	// area scopes
+	ctx = withPerms("sealing:read")
+	_, err = out.Internal.SealingSchedDiag(ctx)
+	require.NoError(t, err)
+	require.Error(t, out.Internal.SectorRemove(ctx))
+	_, err = out.Internal.WalletSign(ctx, a100)
+	require.Error(t, err)
+
+	ctx = withPerms("sealing:admin")
+	_, err = out.Internal.SealingSchedDiag(ctx)
+	require.NoError(t, err)
+	require.NoError(t, out.Internal.SectorRemove(ctx))
+
+	// address restricted scopes
+	ctx = withPerms(auth.Permission(Scope{Area: "wallet", Perm: PermSign, Address: &a100}.String()))
+	v, err := out.Internal.WalletSign(ctx, a100)
+	require.NoError(t, err)
+	require.Equal(t, 2, v)
+	_, err = out.Internal.WalletSign(ctx, a101)
+	require.Error(t, err)
+
+	// wallet scopes restricted to the sender allow pushing its messages
+	v, err = out.Internal.MpoolPushMessage(ctx, &types.Message{From: a100})
+	require.NoError(t, err)
+	require.Equal(t, 3, v)
+	_, err = out.Internal.MpoolPushMessage(ctx, &types.Message{From: a101})
+	require.Error(t, err)
+
+	// but not unrestricted ones on other areas
+	ctx = withPerms("wallet:sign")
+	_, err = out.Internal.MpoolPushMessage(ctx, &types.Message{From: a100})
+	require.Error(t, err)
+}
+
+func TestHasRemotePerm(t *testing.T) {
+	req := func(method string, perms ...auth.Permission) bool {
+		r := httptest.NewRequest(method, "/remote/sealed/s-t01000-1", nil)
+		if perms != nil {
+			r = r.WithContext(auth.WithPerm(r.Context(), perms))
+		}
+		return HasRemotePerm(r)
+	}
+
+	require.False(t, req("GET"))
+	require.False(t, req("GET", PermRead, PermWrite))
+
+	require.True(t, req("GET", PermAdmin))
+	require.True(t, req("DELETE", PermAdmin))
+
+	remoteRead := auth.Permission(RemoteReadScope.String())
+	require.True(t, req("GET", remoteRead))
+	require.True(t, req("HEAD", remoteRead))
+	require.False(t, req("DELETE", remoteRead))
+	require.False(t, req("PUT", remoteRead))
+	require.True(t, req("GET", "remote:admin"))
+
+	// other scopes don't grant access to sector files
+	require.False(t, req("GET", "sealing:admin"))
+}
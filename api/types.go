@@ -52,6 +52,10 @@ type PubsubScore struct {
 
 type MessageSendSpec struct {
 	MaxFee abi.TokenAmount
+
+	// SafetyChecks makes MpoolPushMessage refuse messages which fail the
+	// recipient and value checks of MpoolCheckMessages
+	SafetyChecks bool `json:",omitempty"`
 }
 
 type DataTransferChannel struct {
@@ -113,6 +117,45 @@ type ExtendedPeerInfo struct {
 	ConnMgrMeta *ConnMgrInfo
 }
 
+// NetPeerClass is the class a connected peer is put in by the connection
+// manager policies, see NetClassify
+type NetPeerClass struct {
+	ID        peer.ID
+	Class     string
+	Protected bool
+}
+
+// PropagationReport describes when blocks and messages arrive over pubsub,
+// and which peers deliver them first
+type PropagationReport struct {
+	Since time.Time
+
+	// Blocks holds the delays of blocks since the start of their epoch
+	Blocks ArrivalStats
+	// Messages holds the times since the start of the current epoch at
+	// which messages arrive
+	Messages ArrivalStats
+
+	Peers []PeerDeliveries
+}
+
+type ArrivalStats struct {
+	Count uint64
+
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+}
+
+// PeerDeliveries is the number of blocks and messages a peer delivered first
+type PeerDeliveries struct {
+	ID       peer.ID
+	Blocks   uint64
+	Messages uint64
+}
+
 type ConnMgrInfo struct {
 	FirstSeen time.Time
 	Value     int
@@ -159,6 +202,10 @@ const (
 	CheckStatusMessageNonce
 	CheckStatusMessageGetStateBalance
 	CheckStatusMessageBalance
+
+	// Safety Checks
+	CheckStatusMessageRecipient
+	CheckStatusMessageValue
 )
 
 type CheckStatus struct {
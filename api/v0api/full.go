@@ -12,6 +12,7 @@ import (
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/crypto"
 	"github.com/filecoin-project/go-state-types/dline"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
 	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p-core/peer"
 
@@ -19,7 +20,9 @@ import (
 	apitypes "github.com/filecoin-project/lotus/api/types"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/paych"
+	"github.com/filecoin-project/lotus/chain/beacon"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/subscription"
 	marketevents "github.com/filecoin-project/lotus/markets/loggers"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 )
@@ -53,8 +56,18 @@ type FullNode interface {
 
 	// ChainNotify returns channel with chain head updates.
 	// First message is guaranteed to be of len == 1, and type == 'current'.
+	// Head changes are never dropped, so a subscriber which doesn't keep up
+	// delays notifications to all subscribers; see ChainNotifyWithPolicy to
+	// subscribe with a buffering policy instead.
 	ChainNotify(context.Context) (<-chan []*api.HeadChange, error) //perm:read
 
+	// ChainNotifyWithPolicy is like ChainNotify, but with the given buffering
+	// policy, which decides what happens to head changes when the subscriber
+	// falls behind: 'drop-oldest' drops the oldest buffered head changes,
+	// 'coalesce' replaces all buffered head changes with a single 'current'
+	// head change for the latest head, and 'disconnect' closes the channel.
+	ChainNotifyWithPolicy(ctx context.Context, policy subscription.Policy) (<-chan []*api.HeadChange, error) //perm:read
+
 	// ChainHead returns the current head of the chain.
 	ChainHead(context.Context) (*types.TipSet, error) //perm:read
 
@@ -92,6 +105,13 @@ type FullNode interface {
 	// specified block.
 	ChainGetParentMessages(ctx context.Context, blockCid cid.Cid) ([]api.Message, error) //perm:read
 
+	// ChainGasUsage returns how the gas used by messages in the last epochs
+	// is distributed by recipient actor code and method, e.g. the share of
+	// block space used by window PoSts. Usage is tracked for a window of
+	// recent epochs, see Fees.GasUsageEpochs in the config; 0 returns the
+	// whole window.
+	ChainGasUsage(ctx context.Context, epochs abi.ChainEpoch) (*api.GasUsage, error) //perm:read
+
 	// ChainGetTipSetByHeight looks back for a tipset at the specified epoch.
 	// If there are no blocks at the specified epoch, a tipset at an earlier epoch
 	// will be returned.
@@ -148,6 +168,33 @@ type FullNode interface {
 	// If oldmsgskip is set, messages from before the requested roots are also not included.
 	ChainExport(ctx context.Context, nroots abi.ChainEpoch, oldmsgskip bool, tsk types.TipSetKey) (<-chan []byte, error) //perm:read
 
+	// ChainSnapshotImportProgress returns the progress of the snapshot being
+	// imported in the background, including the height ranges for which
+	// headers and state can already be queried.
+	ChainSnapshotImportProgress(ctx context.Context) (*api.SnapshotImportProgress, error) //perm:read
+
+	// ChainLatestSnapshot returns the metadata of the latest chain snapshot
+	// exported by the node, see Chainstore.EnableSnapshots in the config.
+	// Other nodes can bootstrap from the snapshot at the returned URL.
+	ChainLatestSnapshot(ctx context.Context) (*api.SnapshotInfo, error) //perm:read
+
+	// ChainSplitstoreStatus returns the progress of the ongoing splitstore
+	// compaction, and the outcome of the last one.
+	ChainSplitstoreStatus(ctx context.Context) (*api.SplitstoreStatus, error) //perm:read
+
+	// ChainSplitstorePauseCompaction pauses the ongoing splitstore compaction,
+	// and prevents new compactions from starting until it's resumed.
+	ChainSplitstorePauseCompaction(ctx context.Context) error //perm:admin
+
+	// ChainSplitstoreResumeCompaction resumes splitstore compaction.
+	ChainSplitstoreResumeCompaction(ctx context.Context) error //perm:admin
+
+	// ChainBlockstoreGC runs online garbage collection on the chain
+	// blockstore, reclaiming disk space without stopping the node. The
+	// returned channel reports the progress of the collection, and is closed
+	// once it finishes.
+	ChainBlockstoreGC(ctx context.Context, opts api.BlockstoreGCOpts) (<-chan api.BlockstoreGCProgress, error) //perm:admin
+
 	// MethodGroup: Beacon
 	// The Beacon method group contains methods for interacting with the random beacon (DRAND)
 
@@ -155,6 +202,10 @@ type FullNode interface {
 	// the entry has not yet been produced, the call will block until the entry
 	// becomes available
 	BeaconGetEntry(ctx context.Context, epoch abi.ChainEpoch) (*types.BeaconEntry, error) //perm:read
+	// BeaconHealth reports how the beacon of the current epoch is fetching
+	// entries: the health and latency of its endpoints, and the rounds which
+	// couldn't be fetched
+	BeaconHealth(context.Context) (*beacon.Health, error) //perm:read
 
 	// GasEstimateFeeCap estimates gas fee cap
 	GasEstimateFeeCap(context.Context, *types.Message, int64, types.TipSetKey) (types.BigInt, error) //perm:read
@@ -172,6 +223,12 @@ type FullNode interface {
 	// GasEstimateMessageGas estimates gas values for unset message gas fields
 	GasEstimateMessageGas(context.Context, *types.Message, *api.MessageSendSpec, types.TipSetKey) (*types.Message, error) //perm:read
 
+	// GasAdvisorFees returns recommended fee caps and premiums for messages
+	// to be included in the next epoch, within 5 epochs, or on a best effort
+	// basis. The advice is based on the base fee at the given tipset, premiums
+	// of recently included messages and the current mpool contents.
+	GasAdvisorFees(context.Context, types.TipSetKey) (*api.GasAdvice, error) //perm:read
+
 	// MethodGroup: Sync
 	// The Sync method group contains methods for interacting with and
 	// observing the lotus sync service.
@@ -187,6 +244,15 @@ type FullNode interface {
 	// yet synced block headers.
 	SyncIncomingBlocks(ctx context.Context) (<-chan *types.BlockHeader, error) //perm:read
 
+	// SyncConsensusFaults returns the consensus faults detected in incoming
+	// blocks, when the consensus fault detector is enabled.
+	SyncConsensusFaults(ctx context.Context) ([]api.ConsensusFault, error) //perm:read
+
+	// SyncValidationTiming returns a histogram of the time taken to validate
+	// recently validated tipsets, broken down by signature checks, state
+	// execution and proof verification.
+	SyncValidationTiming(ctx context.Context) (api.SyncValidationTiming, error) //perm:read
+
 	// SyncCheckpoint marks a blocks as checkpointed, meaning that it won't ever fork away from it.
 	SyncCheckpoint(ctx context.Context, tsk types.TipSetKey) error //perm:admin
 
@@ -244,6 +310,14 @@ type FullNode interface {
 	// Note that this method may not be atomic. Use MpoolPushMessage instead.
 	MpoolGetNonce(context.Context, address.Address) (uint64, error) //perm:read
 	MpoolSub(context.Context) (<-chan api.MpoolUpdate, error)       //perm:read
+	// MpoolReorgs notifies about the messages from local addresses which
+	// were included in tipsets reverted by chain re-orgs, whether they were
+	// re-included in the new chain, and their execution results there
+	MpoolReorgs(context.Context) (<-chan api.MpoolReorg, error) //perm:read
+	// MpoolNonceGaps reports the local addresses with pending messages which
+	// are stuck, either behind gaps in their nonces, or behind a message which
+	// can't be included
+	MpoolNonceGaps(context.Context) ([]api.NonceGapReport, error) //perm:read
 
 	// MpoolClear clears pending messages from the mpool
 	MpoolClear(context.Context, bool) error //perm:write
@@ -257,6 +331,17 @@ type FullNode interface {
 
 	MinerGetBaseInfo(context.Context, address.Address, abi.ChainEpoch, types.TipSetKey) (*api.MiningBaseInfo, error) //perm:read
 	MinerCreateBlock(context.Context, *api.BlockTemplate) (*types.BlockMsg, error)                                   //perm:write
+	// MinerComputeBlockTemplate is a dry run of mining a block for the epoch on
+	// top of the base tipset. It reports whether the miner would win, the
+	// messages it would select, and the expected rewards and penalties, without
+	// creating a block. Computing the ticket and the election proof requires
+	// the worker key of the miner in the wallet of the node.
+	//
+	// Messages are selected from the current message pool when the base is the
+	// chain head. For other bases the messages are the ones included in the
+	// chain at the epoch on top of the base, if any, and epochs past the chain
+	// head are rejected.
+	MinerComputeBlockTemplate(ctx context.Context, maddr address.Address, epoch abi.ChainEpoch, base types.TipSetKey) (*api.BlockTemplateReport, error) //perm:sign
 
 	// // UX ?
 
@@ -291,6 +376,25 @@ type FullNode interface {
 	WalletDelete(context.Context, address.Address) error //perm:admin
 	// WalletValidateAddress validates whether a given string can be decoded as a well-formed address
 	WalletValidateAddress(context.Context, string) (address.Address, error) //perm:read
+	// WalletAddressLabelSet sets a label on an address, e.g. the name of a
+	// counterparty, shown in the wallet history. An empty label removes it.
+	WalletAddressLabelSet(ctx context.Context, addr address.Address, label string) error //perm:write
+	// WalletMessageLabelSet sets a label (memo) on a message. An empty label
+	// removes it.
+	WalletMessageLabelSet(ctx context.Context, msg cid.Cid, label string) error //perm:write
+	// WalletLabels returns the labels of addresses and messages
+	WalletLabels(context.Context) (*api.WalletLabels, error) //perm:read
+
+	// AddressBookSet adds a named address to the address book of the node, or
+	// replaces the entry of the name. Names can be used in place of addresses
+	// by the CLI.
+	AddressBookSet(ctx context.Context, entry api.AddressBookEntry) error //perm:write
+	// AddressBookRemove removes a name from the address book
+	AddressBookRemove(ctx context.Context, name string) error //perm:write
+	// AddressBookGet returns the address book entry of a name
+	AddressBookGet(ctx context.Context, name string) (*api.AddressBookEntry, error) //perm:read
+	// AddressBookList returns all address book entries, sorted by name
+	AddressBookList(context.Context) ([]api.AddressBookEntry, error) //perm:read
 
 	// Other
 
@@ -390,24 +494,73 @@ type FullNode interface {
 	// different signature, but with all other parameters matching (source/destination,
 	// nonce, params, etc.)
 	StateReplay(context.Context, types.TipSetKey, cid.Cid) (*api.InvocResult, error) //perm:read
+	// StateReplayDetailed replays a message like StateReplay, with an
+	// instrumented VM recording every step of its execution: the actor calls
+	// and their outcome, the state objects read and written, and the gas
+	// charged per class of operation.
+	StateReplayDetailed(context.Context, types.TipSetKey, cid.Cid) (*api.ReplayTrace, error) //perm:read
 	// StateGetActor returns the indicated actor's nonce and balance.
 	StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*types.Actor, error) //perm:read
 	// StateReadState returns the indicated actor's state.
 	StateReadState(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*api.ActorState, error) //perm:read
+	// StateQuery resolves the requested fields of the state of the given actors
+	// at a single tipset, in one call. The fields available depend on the
+	// actor type: all actors have Actor, Balance and ID; miners additionally
+	// have Info, Power, AvailableBalance, ProvingDeadline, Deadlines,
+	// DeadlineSummaries, SectorCount, Faults and Recoveries; multisigs have
+	// AvailableBalance, Pending and VestingSchedule. Fields which can't be
+	// resolved, including all fields of actors which can't be loaded, are
+	// reported in the Errors of the actor result, without failing the whole
+	// query.
+	StateQuery(ctx context.Context, query []api.StateFieldQuery, tsk types.TipSetKey) (*api.StateQueryResult, error) //perm:read
 	// StateListMessages looks back and returns all messages with a matching to or from address, stopping at the given height.
 	StateListMessages(ctx context.Context, match *api.MessageMatch, tsk types.TipSetKey, toht abi.ChainEpoch) ([]cid.Cid, error) //perm:read
 	// StateDecodeParams attempts to decode the provided params, based on the recipient actor address and method number.
 	StateDecodeParams(ctx context.Context, toAddr address.Address, method abi.MethodNum, params []byte, tsk types.TipSetKey) (interface{}, error) //perm:read
+	// StateDecodeReturn attempts to decode the provided return value, based on the recipient actor address and method number.
+	StateDecodeReturn(ctx context.Context, toAddr address.Address, method abi.MethodNum, ret []byte, tsk types.TipSetKey) (interface{}, error) //perm:read
+	// StateDecodeMessage looks up a message, and decodes its params and, if it
+	// was executed, its return value, based on the recipient actor at the time
+	// of execution (or at the head for pending messages).
+	StateDecodeMessage(ctx context.Context, msg cid.Cid) (*api.DecodedMessage, error) //perm:read
+	// StateActorCodeCIDs returns the code CIDs of the builtin actors at the
+	// network version, by actor name, e.g. storageminer.
+	StateActorCodeCIDs(ctx context.Context, nv apitypes.NetworkVersion) (map[string]cid.Cid, error) //perm:read
+	// StateActorMethods returns the number, name, and params and return types
+	// of the methods of the actor with the given code.
+	StateActorMethods(ctx context.Context, code cid.Cid) ([]api.ActorMethod, error) //perm:read
+	// StateDecodeActorParams decodes the params of a method of the actor with
+	// the given code, without looking up a recipient address.
+	StateDecodeActorParams(ctx context.Context, code cid.Cid, method abi.MethodNum, params []byte) (interface{}, error) //perm:read
+	// StateDecodeActorReturn decodes the return value of a method of the
+	// actor with the given code.
+	StateDecodeActorReturn(ctx context.Context, code cid.Cid, method abi.MethodNum, ret []byte) (interface{}, error) //perm:read
+	// StateActorLifecycle returns when the actor, given by its ID or robust
+	// address, was created and deleted, from the actor index enabled by
+	// Chainstore.EnableActorIndex.
+	StateActorLifecycle(ctx context.Context, addr address.Address) (*api.ActorLifecycle, error) //perm:read
+	// StateListCreatedActors returns the actors created between the epochs,
+	// inclusive, from the actor index enabled by Chainstore.EnableActorIndex.
+	StateListCreatedActors(ctx context.Context, from, to abi.ChainEpoch) ([]api.ActorLifecycle, error) //perm:read
 
 	// StateNetworkName returns the name of the network the node is synced to
 	StateNetworkName(context.Context) (dtypes.NetworkName, error) //perm:read
 	// StateMinerSectors returns info about the given miner's sectors. If the filter bitfield is nil, all sectors are included.
 	StateMinerSectors(context.Context, address.Address, *bitfield.BitField, types.TipSetKey) ([]*miner.SectorOnChainInfo, error) //perm:read
+	// StateMinerSectorsPage returns a page of the given miner's sectors
+	// matching the query, in sector number order. Pages are requested with
+	// the NextCursor of the previous page, until it is nil. Pages hold fewer
+	// sectors than the limit when the query skips many sectors.
+	StateMinerSectorsPage(context.Context, address.Address, api.MinerSectorsQuery, types.TipSetKey) (*api.MinerSectorsPage, error) //perm:read
 	// StateMinerActiveSectors returns info about sectors that a given miner is actively proving.
 	StateMinerActiveSectors(context.Context, address.Address, types.TipSetKey) ([]*miner.SectorOnChainInfo, error) //perm:read
 	// StateMinerProvingDeadline calculates the deadline at some epoch for a proving period
 	// and returns the deadline-related calculations.
 	StateMinerProvingDeadline(context.Context, address.Address, types.TipSetKey) (*dline.Info, error) //perm:read
+	// StateMinerProvingSummary returns the current proving deadline of the
+	// miner, with the partition and sector counts of each of its deadlines,
+	// in one call. Summaries are cached until the deadlines change.
+	StateMinerProvingSummary(context.Context, address.Address, types.TipSetKey) (*api.MinerProvingSummary, error) //perm:read
 	// StateMinerPower returns the power of the indicated miner
 	StateMinerPower(context.Context, address.Address, types.TipSetKey) (*api.MinerPower, error) //perm:read
 	// StateMinerInfo returns info about the indicated miner
@@ -428,6 +581,10 @@ type FullNode interface {
 	StateMinerInitialPledgeCollateral(context.Context, address.Address, miner.SectorPreCommitInfo, types.TipSetKey) (types.BigInt, error) //perm:read
 	// StateMinerAvailableBalance returns the portion of a miner's balance that can be withdrawn or spent
 	StateMinerAvailableBalance(context.Context, address.Address, types.TipSetKey) (types.BigInt, error) //perm:read
+	// StateMinerTerminationPenalty returns the fee the miner actor would charge
+	// for terminating the given live sectors in the specified tipset, computed
+	// with the actor's own math from the reward and network power estimates
+	StateMinerTerminationPenalty(ctx context.Context, maddr address.Address, sectors []abi.SectorNumber, tsk types.TipSetKey) (abi.TokenAmount, error) //perm:read
 	// StateMinerSectorAllocated checks if a sector is allocated
 	StateMinerSectorAllocated(context.Context, address.Address, abi.SectorNumber, types.TipSetKey) (bool, error) //perm:read
 	// StateSectorPreCommitInfo returns the PreCommit info for the specified miner's sector
@@ -440,6 +597,10 @@ type FullNode interface {
 	StateSectorExpiration(context.Context, address.Address, abi.SectorNumber, types.TipSetKey) (*miner.SectorExpiration, error) //perm:read
 	// StateSectorPartition finds deadline/partition with the specified sector
 	StateSectorPartition(ctx context.Context, maddr address.Address, sectorNumber abi.SectorNumber, tok types.TipSetKey) (*miner.SectorLocation, error) //perm:read
+	// StateVerifyAggregateSeals verifies an aggregated ProveCommit proof against
+	// the given seal infos with the proof verifier of the node, without sending
+	// any message, e.g. to check an aggregate built by external tooling
+	StateVerifyAggregateSeals(context.Context, proof5.AggregateSealVerifyProofAndInfos) (bool, error) //perm:read
 	// StateSearchMsg searches for a message in the chain, and returns its receipt and the tipset where it was executed
 	//
 	// NOTE: If a replacing message is found on chain, this method will return
@@ -507,6 +668,11 @@ type FullNode interface {
 	// different signature, but with all other parameters matching (source/destination,
 	// nonce, params, etc.)
 	StateWaitMsgLimited(ctx context.Context, cid cid.Cid, confidence uint64, limit abi.ChainEpoch) (*api.MsgLookup, error) //perm:read
+	// StateWatchMsg waits for a message like StateWaitMsg, but reports every stage
+	// the message goes through: execution, the requested confidence depth and
+	// finality. The channel is closed after the finality event, or after an event
+	// carrying an error.
+	StateWatchMsg(ctx context.Context, cid cid.Cid, confidence uint64, limit abi.ChainEpoch, allowReplaced bool) (<-chan api.MsgWatchEvent, error) //perm:read
 	// StateListMiners returns the addresses of every miner that has claimed power in the Power Actor
 	StateListMiners(context.Context, types.TipSetKey) ([]address.Address, error) //perm:read
 	// StateListActors returns the addresses of every actor in the state
@@ -591,6 +757,14 @@ type FullNode interface {
 	// StateVMCirculatingSupplyInternal returns an approximation of the circulating supply of Filecoin at the given tipset.
 	// This is the value reported by the runtime interface to actors code.
 	StateVMCirculatingSupplyInternal(context.Context, types.TipSetKey) (api.CirculatingSupply, error) //perm:read
+	// StateCirculatingSupplyBreakdown returns the components of the circulating supply at the given
+	// tipset, with the locked funds split between pledge collateral and funds locked in deals.
+	StateCirculatingSupplyBreakdown(context.Context, types.TipSetKey) (*api.CirculatingSupplyBreakdown, error) //perm:read
+	// StateSectorPledgeProjection estimates the initial pledge for a sector with the given
+	// quality-adjusted power committed at a future epoch. Network power and circulating supply
+	// are extrapolated linearly from their change over the day before the given tipset; the
+	// reward and baseline inputs are taken from the tipset as-is.
+	StateSectorPledgeProjection(ctx context.Context, qaPower abi.StoragePower, epoch abi.ChainEpoch, tsk types.TipSetKey) (*api.SectorPledgeProjection, error) //perm:read
 	// StateNetworkVersion returns the network version at the given tipset
 	StateNetworkVersion(context.Context, types.TipSetKey) (apitypes.NetworkVersion, error) //perm:read
 
@@ -699,6 +873,14 @@ type FullNode interface {
 	PaychVoucherList(context.Context, address.Address) ([]*paych.SignedVoucher, error)                                   //perm:write
 	PaychVoucherSubmit(context.Context, address.Address, *paych.SignedVoucher, []byte, []byte) (cid.Cid, error)          //perm:sign
 
+	// NetPropagation returns when recent blocks and messages arrived over
+	// pubsub, relative to the start of their epoch, and how many of them each
+	// peer delivered first.
+	NetPropagation(ctx context.Context) (*api.PropagationReport, error) //perm:read
+
+	// NetPropagationReset drops the recorded arrivals, starting a new report
+	NetPropagationReset(ctx context.Context) error //perm:admin
+
 	// CreateBackup creates node backup onder the specified file name. The
 	// method requires that the lotus daemon is running with the
 	// LOTUS_BACKUP_BASE_PATH environment variable set to some path, and that
@@ -1,13 +1,12 @@
 package v0api
 
 import (
-	"github.com/filecoin-project/go-jsonrpc/auth"
 	"github.com/filecoin-project/lotus/api"
 )
 
 func PermissionedFullAPI(a FullNode) FullNode {
 	var out FullNodeStruct
-	auth.PermissionedProxy(api.AllPermissions, api.DefaultPerms, a, &out.Internal)
-	auth.PermissionedProxy(api.AllPermissions, api.DefaultPerms, a, &out.CommonStruct.Internal)
+	api.PermissionedProxy(a, &out.Internal)
+	api.PermissionedProxy(a, &out.CommonStruct.Internal)
 	return &out
 }
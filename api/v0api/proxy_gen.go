@@ -19,9 +19,12 @@ import (
 	apitypes "github.com/filecoin-project/lotus/api/types"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/paych"
+	"github.com/filecoin-project/lotus/chain/beacon"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/subscription"
 	marketevents "github.com/filecoin-project/lotus/markets/loggers"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
 	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p-core/peer"
 	"golang.org/x/xerrors"
@@ -31,12 +34,26 @@ type FullNodeStruct struct {
 	CommonStruct
 
 	Internal struct {
+		AddressBookGet func(p0 context.Context, p1 string) (*api.AddressBookEntry, error) `perm:"read"`
+
+		AddressBookList func(p0 context.Context) ([]api.AddressBookEntry, error) `perm:"read"`
+
+		AddressBookRemove func(p0 context.Context, p1 string) error `perm:"write"`
+
+		AddressBookSet func(p0 context.Context, p1 api.AddressBookEntry) error `perm:"write"`
+
 		BeaconGetEntry func(p0 context.Context, p1 abi.ChainEpoch) (*types.BeaconEntry, error) `perm:"read"`
 
+		BeaconHealth func(p0 context.Context) (*beacon.Health, error) `perm:"read"`
+
+		ChainBlockstoreGC func(p0 context.Context, p1 api.BlockstoreGCOpts) (<-chan api.BlockstoreGCProgress, error) `perm:"admin"`
+
 		ChainDeleteObj func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
 
 		ChainExport func(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey) (<-chan []byte, error) `perm:"read"`
 
+		ChainGasUsage func(p0 context.Context, p1 abi.ChainEpoch) (*api.GasUsage, error) `perm:"read"`
+
 		ChainGetBlock func(p0 context.Context, p1 cid.Cid) (*types.BlockHeader, error) `perm:"read"`
 
 		ChainGetBlockMessages func(p0 context.Context, p1 cid.Cid) (*api.BlockMessages, error) `perm:"read"`
@@ -65,12 +82,24 @@ type FullNodeStruct struct {
 
 		ChainHead func(p0 context.Context) (*types.TipSet, error) `perm:"read"`
 
+		ChainLatestSnapshot func(p0 context.Context) (*api.SnapshotInfo, error) `perm:"read"`
+
 		ChainNotify func(p0 context.Context) (<-chan []*api.HeadChange, error) `perm:"read"`
 
+		ChainNotifyWithPolicy func(p0 context.Context, p1 subscription.Policy) (<-chan []*api.HeadChange, error) `perm:"read"`
+
 		ChainReadObj func(p0 context.Context, p1 cid.Cid) ([]byte, error) `perm:"read"`
 
 		ChainSetHead func(p0 context.Context, p1 types.TipSetKey) error `perm:"admin"`
 
+		ChainSnapshotImportProgress func(p0 context.Context) (*api.SnapshotImportProgress, error) `perm:"read"`
+
+		ChainSplitstorePauseCompaction func(p0 context.Context) error `perm:"admin"`
+
+		ChainSplitstoreResumeCompaction func(p0 context.Context) error `perm:"admin"`
+
+		ChainSplitstoreStatus func(p0 context.Context) (*api.SplitstoreStatus, error) `perm:"read"`
+
 		ChainStatObj func(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (api.ObjStat, error) `perm:"read"`
 
 		ChainTipSetWeight func(p0 context.Context, p1 types.TipSetKey) (types.BigInt, error) `perm:"read"`
@@ -131,6 +160,8 @@ type FullNodeStruct struct {
 
 		CreateBackup func(p0 context.Context, p1 string) error `perm:"admin"`
 
+		GasAdvisorFees func(p0 context.Context, p1 types.TipSetKey) (*api.GasAdvice, error) `perm:"read"`
+
 		GasEstimateFeeCap func(p0 context.Context, p1 *types.Message, p2 int64, p3 types.TipSetKey) (types.BigInt, error) `perm:"read"`
 
 		GasEstimateGasLimit func(p0 context.Context, p1 *types.Message, p2 types.TipSetKey) (int64, error) `perm:"read"`
@@ -149,6 +180,8 @@ type FullNodeStruct struct {
 
 		MarketWithdraw func(p0 context.Context, p1 address.Address, p2 address.Address, p3 types.BigInt) (cid.Cid, error) `perm:"sign"`
 
+		MinerComputeBlockTemplate func(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 types.TipSetKey) (*api.BlockTemplateReport, error) `perm:"sign"`
+
 		MinerCreateBlock func(p0 context.Context, p1 *api.BlockTemplate) (*types.BlockMsg, error) `perm:"write"`
 
 		MinerGetBaseInfo func(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 types.TipSetKey) (*api.MiningBaseInfo, error) `perm:"read"`
@@ -165,6 +198,8 @@ type FullNodeStruct struct {
 
 		MpoolGetNonce func(p0 context.Context, p1 address.Address) (uint64, error) `perm:"read"`
 
+		MpoolNonceGaps func(p0 context.Context) ([]api.NonceGapReport, error) `perm:"read"`
+
 		MpoolPending func(p0 context.Context, p1 types.TipSetKey) ([]*types.SignedMessage, error) `perm:"read"`
 
 		MpoolPush func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) `perm:"write"`
@@ -173,6 +208,8 @@ type FullNodeStruct struct {
 
 		MpoolPushUntrusted func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) `perm:"write"`
 
+		MpoolReorgs func(p0 context.Context) (<-chan api.MpoolReorg, error) `perm:"read"`
+
 		MpoolSelect func(p0 context.Context, p1 types.TipSetKey, p2 float64) ([]*types.SignedMessage, error) `perm:"read"`
 
 		MpoolSetConfig func(p0 context.Context, p1 *types.MpoolConfig) error `perm:"admin"`
@@ -211,6 +248,10 @@ type FullNodeStruct struct {
 
 		MsigSwapPropose func(p0 context.Context, p1 address.Address, p2 address.Address, p3 address.Address, p4 address.Address) (cid.Cid, error) `perm:"sign"`
 
+		NetPropagation func(p0 context.Context) (*api.PropagationReport, error) `perm:"read"`
+
+		NetPropagationReset func(p0 context.Context) error `perm:"admin"`
+
 		PaychAllocateLane func(p0 context.Context, p1 address.Address) (uint64, error) `perm:"sign"`
 
 		PaychAvailableFunds func(p0 context.Context, p1 address.Address) (*api.ChannelAvailableFunds, error) `perm:"sign"`
@@ -245,6 +286,12 @@ type FullNodeStruct struct {
 
 		StateAccountKey func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (address.Address, error) `perm:"read"`
 
+		StateActorCodeCIDs func(p0 context.Context, p1 apitypes.NetworkVersion) (map[string]cid.Cid, error) `perm:"read"`
+
+		StateActorLifecycle func(p0 context.Context, p1 address.Address) (*api.ActorLifecycle, error) `perm:"read"`
+
+		StateActorMethods func(p0 context.Context, p1 cid.Cid) ([]api.ActorMethod, error) `perm:"read"`
+
 		StateAllMinerFaults func(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) ([]*api.Fault, error) `perm:"read"`
 
 		StateCall func(p0 context.Context, p1 *types.Message, p2 types.TipSetKey) (*api.InvocResult, error) `perm:"read"`
@@ -253,18 +300,30 @@ type FullNodeStruct struct {
 
 		StateCirculatingSupply func(p0 context.Context, p1 types.TipSetKey) (abi.TokenAmount, error) `perm:"read"`
 
+		StateCirculatingSupplyBreakdown func(p0 context.Context, p1 types.TipSetKey) (*api.CirculatingSupplyBreakdown, error) `perm:"read"`
+
 		StateCompute func(p0 context.Context, p1 abi.ChainEpoch, p2 []*types.Message, p3 types.TipSetKey) (*api.ComputeStateOutput, error) `perm:"read"`
 
 		StateDealProviderCollateralBounds func(p0 context.Context, p1 abi.PaddedPieceSize, p2 bool, p3 types.TipSetKey) (api.DealCollateralBounds, error) `perm:"read"`
 
+		StateDecodeActorParams func(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 []byte) (interface{}, error) `perm:"read"`
+
+		StateDecodeActorReturn func(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 []byte) (interface{}, error) `perm:"read"`
+
+		StateDecodeMessage func(p0 context.Context, p1 cid.Cid) (*api.DecodedMessage, error) `perm:"read"`
+
 		StateDecodeParams func(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) `perm:"read"`
 
+		StateDecodeReturn func(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) `perm:"read"`
+
 		StateGetActor func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*types.Actor, error) `perm:"read"`
 
 		StateGetReceipt func(p0 context.Context, p1 cid.Cid, p2 types.TipSetKey) (*types.MessageReceipt, error) `perm:"read"`
 
 		StateListActors func(p0 context.Context, p1 types.TipSetKey) ([]address.Address, error) `perm:"read"`
 
+		StateListCreatedActors func(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch) ([]api.ActorLifecycle, error) `perm:"read"`
+
 		StateListMessages func(p0 context.Context, p1 *api.MessageMatch, p2 types.TipSetKey, p3 abi.ChainEpoch) ([]cid.Cid, error) `perm:"read"`
 
 		StateListMiners func(p0 context.Context, p1 types.TipSetKey) ([]address.Address, error) `perm:"read"`
@@ -299,6 +358,8 @@ type FullNodeStruct struct {
 
 		StateMinerProvingDeadline func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*dline.Info, error) `perm:"read"`
 
+		StateMinerProvingSummary func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*api.MinerProvingSummary, error) `perm:"read"`
+
 		StateMinerRecoveries func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (bitfield.BitField, error) `perm:"read"`
 
 		StateMinerSectorAllocated func(p0 context.Context, p1 address.Address, p2 abi.SectorNumber, p3 types.TipSetKey) (bool, error) `perm:"read"`
@@ -307,14 +368,22 @@ type FullNodeStruct struct {
 
 		StateMinerSectors func(p0 context.Context, p1 address.Address, p2 *bitfield.BitField, p3 types.TipSetKey) ([]*miner.SectorOnChainInfo, error) `perm:"read"`
 
+		StateMinerSectorsPage func(p0 context.Context, p1 address.Address, p2 api.MinerSectorsQuery, p3 types.TipSetKey) (*api.MinerSectorsPage, error) `perm:"read"`
+
+		StateMinerTerminationPenalty func(p0 context.Context, p1 address.Address, p2 []abi.SectorNumber, p3 types.TipSetKey) (abi.TokenAmount, error) `perm:"read"`
+
 		StateNetworkName func(p0 context.Context) (dtypes.NetworkName, error) `perm:"read"`
 
 		StateNetworkVersion func(p0 context.Context, p1 types.TipSetKey) (apitypes.NetworkVersion, error) `perm:"read"`
 
+		StateQuery func(p0 context.Context, p1 []api.StateFieldQuery, p2 types.TipSetKey) (*api.StateQueryResult, error) `perm:"read"`
+
 		StateReadState func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*api.ActorState, error) `perm:"read"`
 
 		StateReplay func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid) (*api.InvocResult, error) `perm:"read"`
 
+		StateReplayDetailed func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid) (*api.ReplayTrace, error) `perm:"read"`
+
 		StateSearchMsg func(p0 context.Context, p1 cid.Cid) (*api.MsgLookup, error) `perm:"read"`
 
 		StateSearchMsgLimited func(p0 context.Context, p1 cid.Cid, p2 abi.ChainEpoch) (*api.MsgLookup, error) `perm:"read"`
@@ -325,6 +394,8 @@ type FullNodeStruct struct {
 
 		StateSectorPartition func(p0 context.Context, p1 address.Address, p2 abi.SectorNumber, p3 types.TipSetKey) (*miner.SectorLocation, error) `perm:"read"`
 
+		StateSectorPledgeProjection func(p0 context.Context, p1 abi.StoragePower, p2 abi.ChainEpoch, p3 types.TipSetKey) (*api.SectorPledgeProjection, error) `perm:"read"`
+
 		StateSectorPreCommitInfo func(p0 context.Context, p1 address.Address, p2 abi.SectorNumber, p3 types.TipSetKey) (miner.SectorPreCommitOnChainInfo, error) `perm:"read"`
 
 		StateVMCirculatingSupplyInternal func(p0 context.Context, p1 types.TipSetKey) (api.CirculatingSupply, error) `perm:"read"`
@@ -335,14 +406,20 @@ type FullNodeStruct struct {
 
 		StateVerifierStatus func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*abi.StoragePower, error) `perm:"read"`
 
+		StateVerifyAggregateSeals func(p0 context.Context, p1 proof5.AggregateSealVerifyProofAndInfos) (bool, error) `perm:"read"`
+
 		StateWaitMsg func(p0 context.Context, p1 cid.Cid, p2 uint64) (*api.MsgLookup, error) `perm:"read"`
 
 		StateWaitMsgLimited func(p0 context.Context, p1 cid.Cid, p2 uint64, p3 abi.ChainEpoch) (*api.MsgLookup, error) `perm:"read"`
 
+		StateWatchMsg func(p0 context.Context, p1 cid.Cid, p2 uint64, p3 abi.ChainEpoch, p4 bool) (<-chan api.MsgWatchEvent, error) `perm:"read"`
+
 		SyncCheckBad func(p0 context.Context, p1 cid.Cid) (string, error) `perm:"read"`
 
 		SyncCheckpoint func(p0 context.Context, p1 types.TipSetKey) error `perm:"admin"`
 
+		SyncConsensusFaults func(p0 context.Context) ([]api.ConsensusFault, error) `perm:"read"`
+
 		SyncIncomingBlocks func(p0 context.Context) (<-chan *types.BlockHeader, error) `perm:"read"`
 
 		SyncMarkBad func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
@@ -357,6 +434,10 @@ type FullNodeStruct struct {
 
 		SyncValidateTipset func(p0 context.Context, p1 types.TipSetKey) (bool, error) `perm:"read"`
 
+		SyncValidationTiming func(p0 context.Context) (api.SyncValidationTiming, error) `perm:"read"`
+
+		WalletAddressLabelSet func(p0 context.Context, p1 address.Address, p2 string) error `perm:"write"`
+
 		WalletBalance func(p0 context.Context, p1 address.Address) (types.BigInt, error) `perm:"read"`
 
 		WalletDefaultAddress func(p0 context.Context) (address.Address, error) `perm:"write"`
@@ -369,8 +450,12 @@ type FullNodeStruct struct {
 
 		WalletImport func(p0 context.Context, p1 *types.KeyInfo) (address.Address, error) `perm:"admin"`
 
+		WalletLabels func(p0 context.Context) (*api.WalletLabels, error) `perm:"read"`
+
 		WalletList func(p0 context.Context) ([]address.Address, error) `perm:"write"`
 
+		WalletMessageLabelSet func(p0 context.Context, p1 cid.Cid, p2 string) error `perm:"write"`
+
 		WalletNew func(p0 context.Context, p1 types.KeyType) (address.Address, error) `perm:"write"`
 
 		WalletSetDefault func(p0 context.Context, p1 address.Address) error `perm:"write"`
@@ -456,6 +541,38 @@ type GatewayStruct struct {
 type GatewayStub struct {
 }
 
+func (s *FullNodeStruct) AddressBookGet(p0 context.Context, p1 string) (*api.AddressBookEntry, error) {
+	return s.Internal.AddressBookGet(p0, p1)
+}
+
+func (s *FullNodeStub) AddressBookGet(p0 context.Context, p1 string) (*api.AddressBookEntry, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) AddressBookList(p0 context.Context) ([]api.AddressBookEntry, error) {
+	return s.Internal.AddressBookList(p0)
+}
+
+func (s *FullNodeStub) AddressBookList(p0 context.Context) ([]api.AddressBookEntry, error) {
+	return *new([]api.AddressBookEntry), xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) AddressBookRemove(p0 context.Context, p1 string) error {
+	return s.Internal.AddressBookRemove(p0, p1)
+}
+
+func (s *FullNodeStub) AddressBookRemove(p0 context.Context, p1 string) error {
+	return xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) AddressBookSet(p0 context.Context, p1 api.AddressBookEntry) error {
+	return s.Internal.AddressBookSet(p0, p1)
+}
+
+func (s *FullNodeStub) AddressBookSet(p0 context.Context, p1 api.AddressBookEntry) error {
+	return xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) BeaconGetEntry(p0 context.Context, p1 abi.ChainEpoch) (*types.BeaconEntry, error) {
 	return s.Internal.BeaconGetEntry(p0, p1)
 }
@@ -464,6 +581,22 @@ func (s *FullNodeStub) BeaconGetEntry(p0 context.Context, p1 abi.ChainEpoch) (*t
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) BeaconHealth(p0 context.Context) (*beacon.Health, error) {
+	return s.Internal.BeaconHealth(p0)
+}
+
+func (s *FullNodeStub) BeaconHealth(p0 context.Context) (*beacon.Health, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) ChainBlockstoreGC(p0 context.Context, p1 api.BlockstoreGCOpts) (<-chan api.BlockstoreGCProgress, error) {
+	return s.Internal.ChainBlockstoreGC(p0, p1)
+}
+
+func (s *FullNodeStub) ChainBlockstoreGC(p0 context.Context, p1 api.BlockstoreGCOpts) (<-chan api.BlockstoreGCProgress, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) ChainDeleteObj(p0 context.Context, p1 cid.Cid) error {
 	return s.Internal.ChainDeleteObj(p0, p1)
 }
@@ -480,6 +613,14 @@ func (s *FullNodeStub) ChainExport(p0 context.Context, p1 abi.ChainEpoch, p2 boo
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) ChainGasUsage(p0 context.Context, p1 abi.ChainEpoch) (*api.GasUsage, error) {
+	return s.Internal.ChainGasUsage(p0, p1)
+}
+
+func (s *FullNodeStub) ChainGasUsage(p0 context.Context, p1 abi.ChainEpoch) (*api.GasUsage, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) ChainGetBlock(p0 context.Context, p1 cid.Cid) (*types.BlockHeader, error) {
 	return s.Internal.ChainGetBlock(p0, p1)
 }
@@ -592,6 +733,14 @@ func (s *FullNodeStub) ChainHead(p0 context.Context) (*types.TipSet, error) {
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) ChainLatestSnapshot(p0 context.Context) (*api.SnapshotInfo, error) {
+	return s.Internal.ChainLatestSnapshot(p0)
+}
+
+func (s *FullNodeStub) ChainLatestSnapshot(p0 context.Context) (*api.SnapshotInfo, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) ChainNotify(p0 context.Context) (<-chan []*api.HeadChange, error) {
 	return s.Internal.ChainNotify(p0)
 }
@@ -600,6 +749,14 @@ func (s *FullNodeStub) ChainNotify(p0 context.Context) (<-chan []*api.HeadChange
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) ChainNotifyWithPolicy(p0 context.Context, p1 subscription.Policy) (<-chan []*api.HeadChange, error) {
+	return s.Internal.ChainNotifyWithPolicy(p0, p1)
+}
+
+func (s *FullNodeStub) ChainNotifyWithPolicy(p0 context.Context, p1 subscription.Policy) (<-chan []*api.HeadChange, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) ChainReadObj(p0 context.Context, p1 cid.Cid) ([]byte, error) {
 	return s.Internal.ChainReadObj(p0, p1)
 }
@@ -616,6 +773,38 @@ func (s *FullNodeStub) ChainSetHead(p0 context.Context, p1 types.TipSetKey) erro
 	return xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) ChainSnapshotImportProgress(p0 context.Context) (*api.SnapshotImportProgress, error) {
+	return s.Internal.ChainSnapshotImportProgress(p0)
+}
+
+func (s *FullNodeStub) ChainSnapshotImportProgress(p0 context.Context) (*api.SnapshotImportProgress, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) ChainSplitstorePauseCompaction(p0 context.Context) error {
+	return s.Internal.ChainSplitstorePauseCompaction(p0)
+}
+
+func (s *FullNodeStub) ChainSplitstorePauseCompaction(p0 context.Context) error {
+	return xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) ChainSplitstoreResumeCompaction(p0 context.Context) error {
+	return s.Internal.ChainSplitstoreResumeCompaction(p0)
+}
+
+func (s *FullNodeStub) ChainSplitstoreResumeCompaction(p0 context.Context) error {
+	return xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) ChainSplitstoreStatus(p0 context.Context) (*api.SplitstoreStatus, error) {
+	return s.Internal.ChainSplitstoreStatus(p0)
+}
+
+func (s *FullNodeStub) ChainSplitstoreStatus(p0 context.Context) (*api.SplitstoreStatus, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) ChainStatObj(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (api.ObjStat, error) {
 	return s.Internal.ChainStatObj(p0, p1, p2)
 }
@@ -856,6 +1045,14 @@ func (s *FullNodeStub) CreateBackup(p0 context.Context, p1 string) error {
 	return xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) GasAdvisorFees(p0 context.Context, p1 types.TipSetKey) (*api.GasAdvice, error) {
+	return s.Internal.GasAdvisorFees(p0, p1)
+}
+
+func (s *FullNodeStub) GasAdvisorFees(p0 context.Context, p1 types.TipSetKey) (*api.GasAdvice, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) GasEstimateFeeCap(p0 context.Context, p1 *types.Message, p2 int64, p3 types.TipSetKey) (types.BigInt, error) {
 	return s.Internal.GasEstimateFeeCap(p0, p1, p2, p3)
 }
@@ -928,6 +1125,14 @@ func (s *FullNodeStub) MarketWithdraw(p0 context.Context, p1 address.Address, p2
 	return *new(cid.Cid), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) MinerComputeBlockTemplate(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 types.TipSetKey) (*api.BlockTemplateReport, error) {
+	return s.Internal.MinerComputeBlockTemplate(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) MinerComputeBlockTemplate(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 types.TipSetKey) (*api.BlockTemplateReport, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) MinerCreateBlock(p0 context.Context, p1 *api.BlockTemplate) (*types.BlockMsg, error) {
 	return s.Internal.MinerCreateBlock(p0, p1)
 }
@@ -992,6 +1197,14 @@ func (s *FullNodeStub) MpoolGetNonce(p0 context.Context, p1 address.Address) (ui
 	return 0, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) MpoolNonceGaps(p0 context.Context) ([]api.NonceGapReport, error) {
+	return s.Internal.MpoolNonceGaps(p0)
+}
+
+func (s *FullNodeStub) MpoolNonceGaps(p0 context.Context) ([]api.NonceGapReport, error) {
+	return *new([]api.NonceGapReport), xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) MpoolPending(p0 context.Context, p1 types.TipSetKey) ([]*types.SignedMessage, error) {
 	return s.Internal.MpoolPending(p0, p1)
 }
@@ -1024,6 +1237,14 @@ func (s *FullNodeStub) MpoolPushUntrusted(p0 context.Context, p1 *types.SignedMe
 	return *new(cid.Cid), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) MpoolReorgs(p0 context.Context) (<-chan api.MpoolReorg, error) {
+	return s.Internal.MpoolReorgs(p0)
+}
+
+func (s *FullNodeStub) MpoolReorgs(p0 context.Context) (<-chan api.MpoolReorg, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) MpoolSelect(p0 context.Context, p1 types.TipSetKey, p2 float64) ([]*types.SignedMessage, error) {
 	return s.Internal.MpoolSelect(p0, p1, p2)
 }
@@ -1176,6 +1397,22 @@ func (s *FullNodeStub) MsigSwapPropose(p0 context.Context, p1 address.Address, p
 	return *new(cid.Cid), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) NetPropagation(p0 context.Context) (*api.PropagationReport, error) {
+	return s.Internal.NetPropagation(p0)
+}
+
+func (s *FullNodeStub) NetPropagation(p0 context.Context) (*api.PropagationReport, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) NetPropagationReset(p0 context.Context) error {
+	return s.Internal.NetPropagationReset(p0)
+}
+
+func (s *FullNodeStub) NetPropagationReset(p0 context.Context) error {
+	return xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) PaychAllocateLane(p0 context.Context, p1 address.Address) (uint64, error) {
 	return s.Internal.PaychAllocateLane(p0, p1)
 }
@@ -1312,6 +1549,30 @@ func (s *FullNodeStub) StateAccountKey(p0 context.Context, p1 address.Address, p
 	return *new(address.Address), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateActorCodeCIDs(p0 context.Context, p1 apitypes.NetworkVersion) (map[string]cid.Cid, error) {
+	return s.Internal.StateActorCodeCIDs(p0, p1)
+}
+
+func (s *FullNodeStub) StateActorCodeCIDs(p0 context.Context, p1 apitypes.NetworkVersion) (map[string]cid.Cid, error) {
+	return *new(map[string]cid.Cid), xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) StateActorLifecycle(p0 context.Context, p1 address.Address) (*api.ActorLifecycle, error) {
+	return s.Internal.StateActorLifecycle(p0, p1)
+}
+
+func (s *FullNodeStub) StateActorLifecycle(p0 context.Context, p1 address.Address) (*api.ActorLifecycle, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) StateActorMethods(p0 context.Context, p1 cid.Cid) ([]api.ActorMethod, error) {
+	return s.Internal.StateActorMethods(p0, p1)
+}
+
+func (s *FullNodeStub) StateActorMethods(p0 context.Context, p1 cid.Cid) ([]api.ActorMethod, error) {
+	return *new([]api.ActorMethod), xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateAllMinerFaults(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) ([]*api.Fault, error) {
 	return s.Internal.StateAllMinerFaults(p0, p1, p2)
 }
@@ -1344,6 +1605,14 @@ func (s *FullNodeStub) StateCirculatingSupply(p0 context.Context, p1 types.TipSe
 	return *new(abi.TokenAmount), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateCirculatingSupplyBreakdown(p0 context.Context, p1 types.TipSetKey) (*api.CirculatingSupplyBreakdown, error) {
+	return s.Internal.StateCirculatingSupplyBreakdown(p0, p1)
+}
+
+func (s *FullNodeStub) StateCirculatingSupplyBreakdown(p0 context.Context, p1 types.TipSetKey) (*api.CirculatingSupplyBreakdown, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateCompute(p0 context.Context, p1 abi.ChainEpoch, p2 []*types.Message, p3 types.TipSetKey) (*api.ComputeStateOutput, error) {
 	return s.Internal.StateCompute(p0, p1, p2, p3)
 }
@@ -1360,6 +1629,30 @@ func (s *FullNodeStub) StateDealProviderCollateralBounds(p0 context.Context, p1
 	return *new(api.DealCollateralBounds), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateDecodeActorParams(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 []byte) (interface{}, error) {
+	return s.Internal.StateDecodeActorParams(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateDecodeActorParams(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 []byte) (interface{}, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) StateDecodeActorReturn(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 []byte) (interface{}, error) {
+	return s.Internal.StateDecodeActorReturn(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateDecodeActorReturn(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 []byte) (interface{}, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) StateDecodeMessage(p0 context.Context, p1 cid.Cid) (*api.DecodedMessage, error) {
+	return s.Internal.StateDecodeMessage(p0, p1)
+}
+
+func (s *FullNodeStub) StateDecodeMessage(p0 context.Context, p1 cid.Cid) (*api.DecodedMessage, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateDecodeParams(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) {
 	return s.Internal.StateDecodeParams(p0, p1, p2, p3, p4)
 }
@@ -1368,6 +1661,14 @@ func (s *FullNodeStub) StateDecodeParams(p0 context.Context, p1 address.Address,
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateDecodeReturn(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) {
+	return s.Internal.StateDecodeReturn(p0, p1, p2, p3, p4)
+}
+
+func (s *FullNodeStub) StateDecodeReturn(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateGetActor(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*types.Actor, error) {
 	return s.Internal.StateGetActor(p0, p1, p2)
 }
@@ -1392,6 +1693,14 @@ func (s *FullNodeStub) StateListActors(p0 context.Context, p1 types.TipSetKey) (
 	return *new([]address.Address), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateListCreatedActors(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch) ([]api.ActorLifecycle, error) {
+	return s.Internal.StateListCreatedActors(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateListCreatedActors(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch) ([]api.ActorLifecycle, error) {
+	return *new([]api.ActorLifecycle), xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateListMessages(p0 context.Context, p1 *api.MessageMatch, p2 types.TipSetKey, p3 abi.ChainEpoch) ([]cid.Cid, error) {
 	return s.Internal.StateListMessages(p0, p1, p2, p3)
 }
@@ -1528,6 +1837,14 @@ func (s *FullNodeStub) StateMinerProvingDeadline(p0 context.Context, p1 address.
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateMinerProvingSummary(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*api.MinerProvingSummary, error) {
+	return s.Internal.StateMinerProvingSummary(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateMinerProvingSummary(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*api.MinerProvingSummary, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateMinerRecoveries(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (bitfield.BitField, error) {
 	return s.Internal.StateMinerRecoveries(p0, p1, p2)
 }
@@ -1560,6 +1877,22 @@ func (s *FullNodeStub) StateMinerSectors(p0 context.Context, p1 address.Address,
 	return *new([]*miner.SectorOnChainInfo), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateMinerSectorsPage(p0 context.Context, p1 address.Address, p2 api.MinerSectorsQuery, p3 types.TipSetKey) (*api.MinerSectorsPage, error) {
+	return s.Internal.StateMinerSectorsPage(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateMinerSectorsPage(p0 context.Context, p1 address.Address, p2 api.MinerSectorsQuery, p3 types.TipSetKey) (*api.MinerSectorsPage, error) {
+	return nil, xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) StateMinerTerminationPenalty(p0 context.Context, p1 address.Address, p2 []abi.SectorNumber, p3 types.TipSetKey) (abi.TokenAmount, error) {
+	return s.Internal.StateMinerTerminationPenalty(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateMinerTerminationPenalty(p0 context.Context, p1 address.Address, p2 []abi.SectorNumber, p3 types.TipSetKey) (abi.TokenAmount, error) {
+	return *new(abi.TokenAmount), xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateNetworkName(p0 context.Context) (dtypes.NetworkName, error) {
 	return s.Internal.StateNetworkName(p0)
 }
@@ -1576,6 +1909,14 @@ func (s *FullNodeStub) StateNetworkVersion(p0 context.Context, p1 types.TipSetKe
 	return *new(apitypes.NetworkVersion), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateQuery(p0 context.Context, p1 []api.StateFieldQuery, p2 types.TipSetKey) (*api.StateQueryResult, error) {
+	return s.Internal.StateQuery(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateQuery(p0 context.Context, p1 []api.StateFieldQuery, p2 types.TipSetKey) (*api.StateQueryResult, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateReadState(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*api.ActorState, error) {
 	return s.Internal.StateReadState(p0, p1, p2)
 }
@@ -1592,6 +1933,14 @@ func (s *FullNodeStub) StateReplay(p0 context.Context, p1 types.TipSetKey, p2 ci
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateReplayDetailed(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid) (*api.ReplayTrace, error) {
+	return s.Internal.StateReplayDetailed(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateReplayDetailed(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid) (*api.ReplayTrace, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateSearchMsg(p0 context.Context, p1 cid.Cid) (*api.MsgLookup, error) {
 	return s.Internal.StateSearchMsg(p0, p1)
 }
@@ -1632,6 +1981,14 @@ func (s *FullNodeStub) StateSectorPartition(p0 context.Context, p1 address.Addre
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateSectorPledgeProjection(p0 context.Context, p1 abi.StoragePower, p2 abi.ChainEpoch, p3 types.TipSetKey) (*api.SectorPledgeProjection, error) {
+	return s.Internal.StateSectorPledgeProjection(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateSectorPledgeProjection(p0 context.Context, p1 abi.StoragePower, p2 abi.ChainEpoch, p3 types.TipSetKey) (*api.SectorPledgeProjection, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateSectorPreCommitInfo(p0 context.Context, p1 address.Address, p2 abi.SectorNumber, p3 types.TipSetKey) (miner.SectorPreCommitOnChainInfo, error) {
 	return s.Internal.StateSectorPreCommitInfo(p0, p1, p2, p3)
 }
@@ -1672,6 +2029,14 @@ func (s *FullNodeStub) StateVerifierStatus(p0 context.Context, p1 address.Addres
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateVerifyAggregateSeals(p0 context.Context, p1 proof5.AggregateSealVerifyProofAndInfos) (bool, error) {
+	return s.Internal.StateVerifyAggregateSeals(p0, p1)
+}
+
+func (s *FullNodeStub) StateVerifyAggregateSeals(p0 context.Context, p1 proof5.AggregateSealVerifyProofAndInfos) (bool, error) {
+	return *new(bool), xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) StateWaitMsg(p0 context.Context, p1 cid.Cid, p2 uint64) (*api.MsgLookup, error) {
 	return s.Internal.StateWaitMsg(p0, p1, p2)
 }
@@ -1688,6 +2053,14 @@ func (s *FullNodeStub) StateWaitMsgLimited(p0 context.Context, p1 cid.Cid, p2 ui
 	return nil, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) StateWatchMsg(p0 context.Context, p1 cid.Cid, p2 uint64, p3 abi.ChainEpoch, p4 bool) (<-chan api.MsgWatchEvent, error) {
+	return s.Internal.StateWatchMsg(p0, p1, p2, p3, p4)
+}
+
+func (s *FullNodeStub) StateWatchMsg(p0 context.Context, p1 cid.Cid, p2 uint64, p3 abi.ChainEpoch, p4 bool) (<-chan api.MsgWatchEvent, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) SyncCheckBad(p0 context.Context, p1 cid.Cid) (string, error) {
 	return s.Internal.SyncCheckBad(p0, p1)
 }
@@ -1704,6 +2077,14 @@ func (s *FullNodeStub) SyncCheckpoint(p0 context.Context, p1 types.TipSetKey) er
 	return xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) SyncConsensusFaults(p0 context.Context) ([]api.ConsensusFault, error) {
+	return s.Internal.SyncConsensusFaults(p0)
+}
+
+func (s *FullNodeStub) SyncConsensusFaults(p0 context.Context) ([]api.ConsensusFault, error) {
+	return *new([]api.ConsensusFault), xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) SyncIncomingBlocks(p0 context.Context) (<-chan *types.BlockHeader, error) {
 	return s.Internal.SyncIncomingBlocks(p0)
 }
@@ -1760,6 +2141,22 @@ func (s *FullNodeStub) SyncValidateTipset(p0 context.Context, p1 types.TipSetKey
 	return false, xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) SyncValidationTiming(p0 context.Context) (api.SyncValidationTiming, error) {
+	return s.Internal.SyncValidationTiming(p0)
+}
+
+func (s *FullNodeStub) SyncValidationTiming(p0 context.Context) (api.SyncValidationTiming, error) {
+	return *new(api.SyncValidationTiming), xerrors.New("method not supported")
+}
+
+func (s *FullNodeStruct) WalletAddressLabelSet(p0 context.Context, p1 address.Address, p2 string) error {
+	return s.Internal.WalletAddressLabelSet(p0, p1, p2)
+}
+
+func (s *FullNodeStub) WalletAddressLabelSet(p0 context.Context, p1 address.Address, p2 string) error {
+	return xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) WalletBalance(p0 context.Context, p1 address.Address) (types.BigInt, error) {
 	return s.Internal.WalletBalance(p0, p1)
 }
@@ -1808,6 +2205,14 @@ func (s *FullNodeStub) WalletImport(p0 context.Context, p1 *types.KeyInfo) (addr
 	return *new(address.Address), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) WalletLabels(p0 context.Context) (*api.WalletLabels, error) {
+	return s.Internal.WalletLabels(p0)
+}
+
+func (s *FullNodeStub) WalletLabels(p0 context.Context) (*api.WalletLabels, error) {
+	return nil, xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) WalletList(p0 context.Context) ([]address.Address, error) {
 	return s.Internal.WalletList(p0)
 }
@@ -1816,6 +2221,14 @@ func (s *FullNodeStub) WalletList(p0 context.Context) ([]address.Address, error)
 	return *new([]address.Address), xerrors.New("method not supported")
 }
 
+func (s *FullNodeStruct) WalletMessageLabelSet(p0 context.Context, p1 cid.Cid, p2 string) error {
+	return s.Internal.WalletMessageLabelSet(p0, p1, p2)
+}
+
+func (s *FullNodeStub) WalletMessageLabelSet(p0 context.Context, p1 cid.Cid, p2 string) error {
+	return xerrors.New("method not supported")
+}
+
 func (s *FullNodeStruct) WalletNew(p0 context.Context, p1 types.KeyType) (address.Address, error) {
 	return s.Internal.WalletNew(p0, p1)
 }
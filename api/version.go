@@ -59,6 +59,8 @@ var (
 
 	MinerAPIVersion0  = newVer(1, 1, 0)
 	WorkerAPIVersion0 = newVer(1, 1, 0)
+
+	SealingServiceAPIVersion0 = newVer(1, 0, 0)
 )
 
 //nolint:varcheck,deadcode
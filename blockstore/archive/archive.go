@@ -0,0 +1,389 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	blocks "github.com/ipfs/go-block-format"
+	cid "github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	car "github.com/ipld/go-car"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+var log = logging.Logger("archive")
+
+const (
+	carExt   = ".car"
+	indexExt = ".idx"
+	tmpExt   = ".tmp"
+)
+
+// location is the position of a block's data in an archive
+type location struct {
+	offset int64
+	size   int
+}
+
+type archiveFile struct {
+	name     string
+	from, to abi.ChainEpoch
+	size     int64
+
+	f   *os.File
+	idx *index
+}
+
+// Info describes an archive file
+type Info struct {
+	Path   string
+	From   abi.ChainEpoch
+	To     abi.ChainEpoch
+	Blocks int
+	Size   int64
+}
+
+// Store is a read-only set of CAR archives, each holding the chain data of a
+// range of epochs.
+//
+// Archives are named after the epoch range they cover, and come with a
+// sidecar index file mapping each block to the position of its data in the
+// CAR. Indexes are read from disk on lookup, see index. Archives are only
+// added through Writer, which writes the index before moving the CAR in
+// place, so a CAR without an index is never visible.
+type Store struct {
+	dir string
+
+	lk       sync.RWMutex
+	archives []*archiveFile // sorted by epoch, newest first
+}
+
+// Open opens the archives in dir, creating the directory if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, xerrors.Errorf("creating archive directory: %w", err)
+	}
+
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, xerrors.Errorf("reading archive directory: %w", err)
+	}
+
+	s := &Store{dir: dir}
+	for _, ent := range ents {
+		name := ent.Name()
+		if strings.HasSuffix(name, tmpExt) {
+			// leftover of an interrupted archiving run
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				log.Warnf("removing incomplete archive file %s: %s", name, err)
+			}
+			continue
+		}
+		if !strings.HasSuffix(name, carExt) {
+			continue
+		}
+
+		a, err := openArchive(dir, strings.TrimSuffix(name, carExt))
+		if err != nil {
+			_ = s.Close()
+			return nil, xerrors.Errorf("opening archive %s: %w", name, err)
+		}
+		s.archives = append(s.archives, a)
+	}
+
+	s.sort()
+	return s, nil
+}
+
+func openArchive(dir, name string) (*archiveFile, error) {
+	a := &archiveFile{name: name}
+	if _, err := fmt.Sscanf(name, "chain-%d-%d", &a.from, &a.to); err != nil {
+		return nil, xerrors.Errorf("parsing archive name: %w", err)
+	}
+
+	var err error
+	a.idx, err = openIndex(filepath.Join(dir, name+indexExt))
+	if err != nil {
+		return nil, xerrors.Errorf("opening index: %w", err)
+	}
+
+	a.f, err = os.Open(filepath.Join(dir, name+carExt))
+	if err != nil {
+		_ = a.idx.close()
+		return nil, err
+	}
+
+	st, err := a.f.Stat()
+	if err != nil {
+		_ = a.idx.close()
+		_ = a.f.Close()
+		return nil, err
+	}
+	a.size = st.Size()
+
+	return a, nil
+}
+
+func (s *Store) sort() {
+	sort.Slice(s.archives, func(i, j int) bool {
+		return s.archives[i].to > s.archives[j].to
+	})
+}
+
+// Close closes the archive files
+func (s *Store) Close() error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	var err error
+	for _, a := range s.archives {
+		if cerr := a.f.Close(); cerr != nil {
+			err = cerr
+		}
+		if cerr := a.idx.close(); cerr != nil {
+			err = cerr
+		}
+	}
+	s.archives = nil
+	return err
+}
+
+// MaxEpoch returns the highest archived epoch, or 0 if nothing is archived
+func (s *Store) MaxEpoch() abi.ChainEpoch {
+	s.lk.RLock()
+	defer s.lk.RUnlock()
+
+	if len(s.archives) == 0 {
+		return 0
+	}
+	return s.archives[0].to
+}
+
+// Archives returns the archives in the store, newest first
+func (s *Store) Archives() []Info {
+	s.lk.RLock()
+	defer s.lk.RUnlock()
+
+	out := make([]Info, 0, len(s.archives))
+	for _, a := range s.archives {
+		out = append(out, Info{
+			Path:   filepath.Join(s.dir, a.name+carExt),
+			From:   a.from,
+			To:     a.to,
+			Blocks: a.idx.len(),
+			Size:   a.size,
+		})
+	}
+	return out
+}
+
+func (s *Store) find(c cid.Cid) (*archiveFile, location, bool, error) {
+	s.lk.RLock()
+	defer s.lk.RUnlock()
+
+	for _, a := range s.archives {
+		loc, ok, err := a.idx.find(c)
+		if err != nil {
+			return nil, location{}, false, xerrors.Errorf("looking up %s in archive %s: %w", c, a.name, err)
+		}
+		if ok {
+			return a, loc, true, nil
+		}
+	}
+	return nil, location{}, false, nil
+}
+
+func (s *Store) Has(c cid.Cid) (bool, error) {
+	_, _, ok, err := s.find(c)
+	return ok, err
+}
+
+func (s *Store) GetSize(c cid.Cid) (int, error) {
+	_, loc, ok, err := s.find(c)
+	if err != nil {
+		return -1, err
+	}
+	if !ok {
+		return -1, blockstore.ErrNotFound
+	}
+	return loc.size, nil
+}
+
+func (s *Store) View(c cid.Cid, callback func([]byte) error) error {
+	a, loc, ok, err := s.find(c)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return blockstore.ErrNotFound
+	}
+
+	buf := make([]byte, loc.size)
+	if _, err := a.f.ReadAt(buf, loc.offset); err != nil {
+		return xerrors.Errorf("reading %s from archive %s: %w", c, a.name, err)
+	}
+	return callback(buf)
+}
+
+func (s *Store) Get(c cid.Cid) (blocks.Block, error) {
+	var blk blocks.Block
+	err := s.View(c, func(data []byte) error {
+		var err error
+		blk, err = blocks.NewBlockWithCid(data, c)
+		return err
+	})
+	return blk, err
+}
+
+// Writer writes a new archive to the store. Blocks are only visible in the
+// store once the writer is committed.
+type Writer struct {
+	s    *Store
+	name string
+
+	f       *os.File
+	w       *bufio.Writer
+	offset  int64
+	records []indexRecord
+}
+
+// NewWriter starts writing a new archive, whose CAR header lists roots.
+func (s *Store) NewWriter(roots []cid.Cid) (*Writer, error) {
+	f, err := os.CreateTemp(s.dir, "chain-*"+carExt+tmpExt)
+	if err != nil {
+		return nil, xerrors.Errorf("creating archive file: %w", err)
+	}
+
+	aw := &Writer{
+		s:    s,
+		name: strings.TrimSuffix(filepath.Base(f.Name()), carExt+tmpExt),
+		f:    f,
+		w:    bufio.NewWriterSize(f, 1<<20),
+	}
+
+	var hdr bytes.Buffer
+	if err := car.WriteHeader(&car.CarHeader{Roots: roots, Version: 1}, &hdr); err != nil {
+		aw.Abort()
+		return nil, xerrors.Errorf("encoding car header: %w", err)
+	}
+	if err := aw.write(hdr.Bytes()); err != nil {
+		aw.Abort()
+		return nil, err
+	}
+
+	return aw, nil
+}
+
+func (aw *Writer) write(b []byte) error {
+	n, err := aw.w.Write(b)
+	aw.offset += int64(n)
+	if err != nil {
+		return xerrors.Errorf("writing archive: %w", err)
+	}
+	return nil
+}
+
+// Put appends a block to the archive. Blocks put more than once are only
+// indexed once.
+func (aw *Writer) Put(c cid.Cid, data []byte) error {
+	cb := c.Bytes()
+
+	var lbuf [binary.MaxVarintLen64]byte
+	ln := binary.PutUvarint(lbuf[:], uint64(len(cb)+len(data)))
+	if err := aw.write(lbuf[:ln]); err != nil {
+		return err
+	}
+	if err := aw.write(cb); err != nil {
+		return err
+	}
+
+	aw.records = append(aw.records, indexRecord{
+		key: indexKey(c),
+		loc: location{offset: aw.offset, size: len(data)},
+	})
+	return aw.write(data)
+}
+
+// Commit syncs the archive and its index to disk, and adds the archive,
+// covering epochs from to to, to the store.
+func (aw *Writer) Commit(from, to abi.ChainEpoch) (Info, error) {
+	name := fmt.Sprintf("chain-%d-%d", from, to)
+	dir := aw.s.dir
+
+	if err := aw.w.Flush(); err != nil {
+		aw.Abort()
+		return Info{}, xerrors.Errorf("flushing archive: %w", err)
+	}
+	if err := aw.f.Sync(); err != nil {
+		aw.Abort()
+		return Info{}, xerrors.Errorf("syncing archive: %w", err)
+	}
+
+	idxTmp := filepath.Join(dir, aw.name+indexExt+tmpExt)
+	if err := writeIndex(idxTmp, aw.records); err != nil {
+		_ = os.Remove(idxTmp)
+		aw.Abort()
+		return Info{}, err
+	}
+
+	// the index goes in place first, so that the car is only ever visible
+	// with its index
+	if err := os.Rename(idxTmp, filepath.Join(dir, name+indexExt)); err != nil {
+		_ = os.Remove(idxTmp)
+		aw.Abort()
+		return Info{}, xerrors.Errorf("moving index in place: %w", err)
+	}
+	if err := os.Rename(aw.f.Name(), filepath.Join(dir, name+carExt)); err != nil {
+		_ = os.Remove(filepath.Join(dir, name+indexExt))
+		aw.Abort()
+		return Info{}, xerrors.Errorf("moving archive in place: %w", err)
+	}
+
+	idx, err := openIndex(filepath.Join(dir, name+indexExt))
+	if err != nil {
+		// the archive is complete, it's picked up on the next start
+		_ = aw.f.Close()
+		return Info{}, xerrors.Errorf("opening index: %w", err)
+	}
+
+	a := &archiveFile{
+		name: name,
+		from: from,
+		to:   to,
+		size: aw.offset,
+		f:    aw.f,
+		idx:  idx,
+	}
+
+	aw.s.lk.Lock()
+	aw.s.archives = append(aw.s.archives, a)
+	aw.s.sort()
+	aw.s.lk.Unlock()
+
+	return Info{
+		Path:   filepath.Join(dir, name+carExt),
+		From:   from,
+		To:     to,
+		Blocks: idx.len(),
+		Size:   a.size,
+	}, nil
+}
+
+// Abort discards the archive
+func (aw *Writer) Abort() {
+	_ = aw.f.Close()
+	if err := os.Remove(aw.f.Name()); err != nil && !os.IsNotExist(err) {
+		log.Warnf("removing aborted archive %s: %s", aw.f.Name(), err)
+	}
+}
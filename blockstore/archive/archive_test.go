@@ -0,0 +1,151 @@
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	car "github.com/ipld/go-car"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+func TestArchiveStore(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, s.MaxEpoch())
+
+	var blks []blocks.Block
+	for i := 0; i < 10; i++ {
+		blks = append(blks, blocks.NewBlock([]byte(fmt.Sprintf("block %d", i))))
+	}
+
+	w, err := s.NewWriter([]cid.Cid{blks[0].Cid()})
+	require.NoError(t, err)
+	for _, blk := range blks[:5] {
+		require.NoError(t, w.Put(blk.Cid(), blk.RawData()))
+	}
+
+	// nothing is visible until the archive is committed
+	has, err := s.Has(blks[0].Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	info, err := w.Commit(1, 100)
+	require.NoError(t, err)
+	require.Equal(t, 5, info.Blocks)
+	require.EqualValues(t, 100, s.MaxEpoch())
+
+	// a second, aborted archive leaves nothing behind
+	w, err = s.NewWriter(nil)
+	require.NoError(t, err)
+	require.NoError(t, w.Put(blks[5].Cid(), blks[5].RawData()))
+	w.Abort()
+
+	hot := blockstore.NewMemory()
+	require.NoError(t, hot.PutMany(blks[5:]))
+
+	check := func(bs blockstore.Blockstore) {
+		for _, blk := range blks {
+			has, err := bs.Has(blk.Cid())
+			require.NoError(t, err)
+			require.True(t, has)
+
+			got, err := bs.Get(blk.Cid())
+			require.NoError(t, err)
+			require.Equal(t, blk.RawData(), got.RawData())
+
+			size, err := bs.GetSize(blk.Cid())
+			require.NoError(t, err)
+			require.Equal(t, len(blk.RawData()), size)
+		}
+
+		_, err := bs.Get(blocks.NewBlock([]byte("missing")).Cid())
+		require.Equal(t, blockstore.ErrNotFound, err)
+	}
+	check(Wrap(hot, s))
+	require.NoError(t, s.Close())
+
+	// the archive can be reopened, and is a valid car
+	s, err = Open(dir)
+	require.NoError(t, err)
+	require.EqualValues(t, 100, s.MaxEpoch())
+	require.Len(t, s.Archives(), 1)
+	check(Wrap(hot, s))
+	require.NoError(t, s.Close())
+
+	ents, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, ents, 2)
+
+	f, err := os.Open(filepath.Join(dir, "chain-1-100.car"))
+	require.NoError(t, err)
+	defer f.Close() //nolint:errcheck
+
+	cr, err := car.NewCarReader(f)
+	require.NoError(t, err)
+	require.Equal(t, []cid.Cid{blks[0].Cid()}, cr.Header.Roots)
+	for _, blk := range blks[:5] {
+		got, err := cr.Next()
+		require.NoError(t, err)
+		require.Equal(t, blk.Cid(), got.Cid())
+		require.True(t, bytes.Equal(blk.RawData(), got.RawData()))
+	}
+}
+
+func TestArchiveIndex(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir)
+	require.NoError(t, err)
+
+	// enough blocks to fill all the fanout buckets, some of them put twice
+	var blks []blocks.Block
+	for i := 0; i < 2000; i++ {
+		blks = append(blks, blocks.NewBlock([]byte(fmt.Sprintf("block %d", i))))
+	}
+
+	w, err := s.NewWriter(nil)
+	require.NoError(t, err)
+	for _, blk := range blks {
+		require.NoError(t, w.Put(blk.Cid(), blk.RawData()))
+	}
+	for _, blk := range blks[:10] {
+		require.NoError(t, w.Put(blk.Cid(), blk.RawData()))
+	}
+
+	info, err := w.Commit(1, 10)
+	require.NoError(t, err)
+	require.Equal(t, len(blks), info.Blocks)
+	require.NoError(t, s.Close())
+
+	s, err = Open(dir)
+	require.NoError(t, err)
+	for _, blk := range blks {
+		got, err := s.Get(blk.Cid())
+		require.NoError(t, err)
+		require.Equal(t, blk.RawData(), got.RawData())
+	}
+	for i := 0; i < 100; i++ {
+		has, err := s.Has(blocks.NewBlock([]byte(fmt.Sprintf("missing %d", i))).Cid())
+		require.NoError(t, err)
+		require.False(t, has)
+	}
+	require.NoError(t, s.Close())
+
+	// archives with a truncated index aren't opened
+	idx := filepath.Join(dir, "chain-1-10"+indexExt)
+	st, err := os.Stat(idx)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(idx, st.Size()-1))
+
+	_, err = Open(dir)
+	require.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package archive
+
+import (
+	"context"
+
+	blocks "github.com/ipfs/go-block-format"
+	cid "github.com/ipfs/go-cid"
+	"go.uber.org/multierr"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+// ArchivedBlockstore serves reads from the wrapped blockstore, falling back
+// to the archives for blocks that were moved out of it. Writes and deletes go
+// to the wrapped blockstore only, and AllKeysChan only lists its keys.
+type ArchivedBlockstore struct {
+	blockstore.Blockstore
+
+	archive *Store
+}
+
+var _ blockstore.Blockstore = (*ArchivedBlockstore)(nil)
+var _ blockstore.BlockstoreGC = (*ArchivedBlockstore)(nil)
+var _ blockstore.BlockstoreSize = (*ArchivedBlockstore)(nil)
+
+// Wrap wraps bs, falling back to the archives in s for reads.
+func Wrap(bs blockstore.Blockstore, s *Store) *ArchivedBlockstore {
+	return &ArchivedBlockstore{Blockstore: bs, archive: s}
+}
+
+// Archive returns the archives backing the blockstore
+func (b *ArchivedBlockstore) Archive() *Store {
+	return b.archive
+}
+
+func (b *ArchivedBlockstore) Has(c cid.Cid) (bool, error) {
+	has, err := b.Blockstore.Has(c)
+	if has || err != nil {
+		return has, err
+	}
+	return b.archive.Has(c)
+}
+
+func (b *ArchivedBlockstore) Get(c cid.Cid) (blocks.Block, error) {
+	blk, err := b.Blockstore.Get(c)
+	if err == blockstore.ErrNotFound {
+		return b.archive.Get(c)
+	}
+	return blk, err
+}
+
+func (b *ArchivedBlockstore) View(c cid.Cid, callback func([]byte) error) error {
+	err := b.Blockstore.View(c, callback)
+	if err == blockstore.ErrNotFound {
+		return b.archive.View(c, callback)
+	}
+	return err
+}
+
+func (b *ArchivedBlockstore) GetSize(c cid.Cid) (int, error) {
+	size, err := b.Blockstore.GetSize(c)
+	if err == blockstore.ErrNotFound {
+		return b.archive.GetSize(c)
+	}
+	return size, err
+}
+
+func (b *ArchivedBlockstore) CollectGarbageOnline(ctx context.Context, opts blockstore.GCOptions, progress func(blockstore.GCProgress)) error {
+	gc, ok := b.Blockstore.(blockstore.BlockstoreGC)
+	if !ok {
+		return xerrors.Errorf("underlying blockstore doesn't support online garbage collection")
+	}
+	return gc.CollectGarbageOnline(ctx, opts, progress)
+}
+
+// Size returns the size of the wrapped blockstore, excluding the archives
+func (b *ArchivedBlockstore) Size() (int64, error) {
+	sz, ok := b.Blockstore.(blockstore.BlockstoreSize)
+	if !ok {
+		return 0, xerrors.Errorf("underlying blockstore doesn't report its size")
+	}
+	return sz.Size()
+}
+
+func (b *ArchivedBlockstore) Close() error {
+	var err error
+	if c, ok := b.Blockstore.(interface{ Close() error }); ok {
+		err = multierr.Append(err, c.Close())
+	}
+	return multierr.Append(err, b.archive.Close())
+}
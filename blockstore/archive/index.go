@@ -0,0 +1,194 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"os"
+	"sort"
+
+	cid "github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+// The index of an archive is kept on disk, and only its fanout table is
+// loaded in memory, so that the archives of the whole chain history can be
+// served without holding an entry per block in memory.
+//
+// The index file starts with indexMagic, followed by the fanout table, 256
+// big endian uint32s, the i-th of which is the number of records whose key
+// starts with a byte <= i. It is followed by the records, sorted by key, each
+// made of the key, the sha256 of the CID bytes, the offset of the block data
+// in the CAR as a big endian uint64, and its size as a big endian uint32.
+//
+// Lookups binary search the records between the fanout bounds of the first
+// byte of the key, reading them from the file.
+var indexMagic = []byte("lotusai1")
+
+const (
+	keySize         = sha256.Size
+	recordSize      = keySize + 8 + 4
+	fanoutSize      = 256 * 4
+	indexHeaderSize = 8 + fanoutSize
+)
+
+type indexRecord struct {
+	key [keySize]byte
+	loc location
+}
+
+func indexKey(c cid.Cid) [keySize]byte {
+	return sha256.Sum256(c.Bytes())
+}
+
+type index struct {
+	f      *os.File
+	fanout [256]uint32
+}
+
+func openIndex(path string) (*index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var hdr [indexHeaderSize]byte
+	if _, err := io.ReadFull(f, hdr[:]); err != nil {
+		_ = f.Close()
+		return nil, xerrors.Errorf("reading index header: %w", err)
+	}
+	if !bytes.Equal(hdr[:len(indexMagic)], indexMagic) {
+		_ = f.Close()
+		return nil, xerrors.Errorf("unknown index format")
+	}
+
+	idx := &index{f: f}
+	for i := range idx.fanout {
+		idx.fanout[i] = binary.BigEndian.Uint32(hdr[len(indexMagic)+i*4:])
+	}
+
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if st.Size() != indexHeaderSize+int64(idx.len())*recordSize {
+		_ = f.Close()
+		return nil, xerrors.Errorf("index size %d doesn't match its %d records", st.Size(), idx.len())
+	}
+
+	return idx, nil
+}
+
+func (idx *index) len() int {
+	return int(idx.fanout[255])
+}
+
+func (idx *index) close() error {
+	return idx.f.Close()
+}
+
+func (idx *index) record(i int) (indexRecord, error) {
+	var buf [recordSize]byte
+	if _, err := idx.f.ReadAt(buf[:], indexHeaderSize+int64(i)*recordSize); err != nil {
+		return indexRecord{}, xerrors.Errorf("reading index record %d: %w", i, err)
+	}
+
+	var r indexRecord
+	copy(r.key[:], buf[:keySize])
+	r.loc.offset = int64(binary.BigEndian.Uint64(buf[keySize:]))
+	r.loc.size = int(binary.BigEndian.Uint32(buf[keySize+8:]))
+	return r, nil
+}
+
+func (idx *index) find(c cid.Cid) (location, bool, error) {
+	key := indexKey(c)
+
+	lo := 0
+	if key[0] > 0 {
+		lo = int(idx.fanout[key[0]-1])
+	}
+	hi := int(idx.fanout[key[0]])
+
+	var rerr error
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		if rerr != nil {
+			return true
+		}
+		r, err := idx.record(lo + i)
+		if err != nil {
+			rerr = err
+			return true
+		}
+		return bytes.Compare(r.key[:], key[:]) >= 0
+	})
+	if rerr != nil {
+		return location{}, false, rerr
+	}
+	if i == hi {
+		return location{}, false, nil
+	}
+
+	r, err := idx.record(i)
+	if err != nil {
+		return location{}, false, err
+	}
+	if r.key != key {
+		return location{}, false, nil
+	}
+	return r.loc, true, nil
+}
+
+// writeIndex sorts the records, dropping the duplicates of blocks put more
+// than once, and writes them to a new index file
+func writeIndex(path string, records []indexRecord) error {
+	sort.Slice(records, func(i, j int) bool {
+		return bytes.Compare(records[i].key[:], records[j].key[:]) < 0
+	})
+
+	var fanout [256]uint32
+	n := 0
+	for i, r := range records {
+		if i > 0 && r.key == records[n-1].key {
+			continue
+		}
+		records[n] = r
+		n++
+		fanout[r.key[0]]++
+	}
+	records = records[:n]
+	for i := 1; i < len(fanout); i++ {
+		fanout[i] += fanout[i-1]
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return xerrors.Errorf("creating index: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+	_, _ = w.Write(indexMagic)
+	var buf [recordSize]byte
+	for _, cnt := range fanout {
+		binary.BigEndian.PutUint32(buf[:4], cnt)
+		_, _ = w.Write(buf[:4])
+	}
+	for _, r := range records {
+		copy(buf[:keySize], r.key[:])
+		binary.BigEndian.PutUint64(buf[keySize:], uint64(r.loc.offset))
+		binary.BigEndian.PutUint32(buf[keySize+8:], uint32(r.loc.size))
+		_, _ = w.Write(buf[:])
+	}
+
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return xerrors.Errorf("writing index: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return xerrors.Errorf("syncing index: %w", err)
+	}
+	return f.Close()
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"runtime"
 	"sync/atomic"
 
@@ -98,6 +100,8 @@ type Blockstore struct {
 var _ blockstore.Blockstore = (*Blockstore)(nil)
 var _ blockstore.Viewer = (*Blockstore)(nil)
 var _ io.Closer = (*Blockstore)(nil)
+var _ blockstore.BlockstoreGC = (*Blockstore)(nil)
+var _ blockstore.BlockstoreSize = (*Blockstore)(nil)
 
 // Open creates a new badger-backed blockstore, with the supplied options.
 func Open(opts Options) (*Blockstore, error) {
@@ -151,6 +155,61 @@ func (b *Blockstore) CollectGarbage() error {
 	return err
 }
 
+// CollectGarbageOnline runs garbage collection on the value log, one file at
+// a time, reporting the initial size of the store and the progress after each
+// file is rewritten.
+func (b *Blockstore) CollectGarbageOnline(ctx context.Context, opts blockstore.GCOptions, progress func(blockstore.GCProgress)) error {
+	if atomic.LoadInt64(&b.state) != stateOpen {
+		return ErrBlockstoreClosed
+	}
+
+	threshold := opts.Threshold
+	if threshold <= 0 {
+		threshold = 0.125
+	}
+	if threshold >= 1 {
+		return fmt.Errorf("invalid gc threshold %f, must be below 1", threshold)
+	}
+
+	sizeBefore, err := b.Size()
+	if err != nil {
+		return err
+	}
+	p := blockstore.GCProgress{SizeBefore: sizeBefore, Size: sizeBefore}
+	progress(p)
+
+	if opts.Compact {
+		if err := b.Compact(); err != nil {
+			return fmt.Errorf("compacting: %w", err)
+		}
+
+		if p.Size, err = b.Size(); err != nil {
+			return err
+		}
+		progress(p)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := b.DB.RunValueLogGC(threshold)
+		if err == badger.ErrNoRewrite {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		p.Rounds++
+		if p.Size, err = b.Size(); err != nil {
+			return err
+		}
+		progress(p)
+	}
+}
+
 // Compact runs a synchronous compaction
 func (b *Blockstore) Compact() error {
 	if atomic.LoadInt64(&b.state) != stateOpen {
@@ -165,6 +224,55 @@ func (b *Blockstore) Compact() error {
 	return b.DB.Flatten(nworkers)
 }
 
+// Size returns the size of the LSM tree and value log files of the store
+func (b *Blockstore) Size() (int64, error) {
+	if atomic.LoadInt64(&b.state) != stateOpen {
+		return 0, ErrBlockstoreClosed
+	}
+
+	opts := b.DB.Opts()
+	if opts.InMemory {
+		lsm, vlog := b.DB.Size()
+		return lsm + vlog, nil
+	}
+
+	// badger only refreshes the sizes returned by DB.Size periodically, so
+	// stat the files instead
+	size, err := dirSize(opts.Dir)
+	if err != nil {
+		return 0, err
+	}
+	if opts.ValueDir != opts.Dir {
+		vsize, err := dirSize(opts.ValueDir)
+		if err != nil {
+			return 0, err
+		}
+		size += vsize
+	}
+	return size, nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				// files are removed while walking, e.g. during gc
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("getting size of %s: %w", dir, err)
+	}
+	return size, nil
+}
+
 // View implements blockstore.Viewer, which leverages zero-copy read-only
 // access to values.
 func (b *Blockstore) View(cid cid.Cid, fn func([]byte) error) error {
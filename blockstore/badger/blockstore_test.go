@@ -1,6 +1,8 @@
 package badgerbs
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -61,6 +63,46 @@ func TestStorageKey(t *testing.T) {
 	require.Equal(t, k3, k2)
 }
 
+func TestCollectGarbageOnline(t *testing.T) {
+	bs, _ := newBlockstore(DefaultOptions)(t)
+	bbs := bs.(*Blockstore)
+	defer bbs.Close() //nolint:errcheck
+
+	var blks []blocks.Block
+	for i := 0; i < 100; i++ {
+		blks = append(blks, blocks.NewBlock([]byte(fmt.Sprintf("block %d", i))))
+	}
+	require.NoError(t, bbs.PutMany(blks))
+	for _, blk := range blks[:50] {
+		require.NoError(t, bbs.DeleteBlock(blk.Cid()))
+	}
+
+	var progress []blockstore.GCProgress
+	err := bbs.CollectGarbageOnline(context.Background(), blockstore.GCOptions{Compact: true}, func(p blockstore.GCProgress) {
+		progress = append(progress, p)
+	})
+	require.NoError(t, err)
+
+	// the initial size and the size after compaction are always reported
+	require.GreaterOrEqual(t, len(progress), 2)
+	require.Greater(t, progress[0].SizeBefore, int64(0))
+	require.Equal(t, progress[0].SizeBefore, progress[0].Size)
+
+	for _, blk := range blks[50:] {
+		has, err := bbs.Has(blk.Cid())
+		require.NoError(t, err)
+		require.True(t, has)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = bbs.CollectGarbageOnline(ctx, blockstore.GCOptions{}, func(blockstore.GCProgress) {})
+	require.ErrorIs(t, err, context.Canceled)
+
+	err = bbs.CollectGarbageOnline(context.Background(), blockstore.GCOptions{Threshold: 1}, func(blockstore.GCProgress) {})
+	require.Error(t, err)
+}
+
 func newBlockstore(optsSupplier func(path string) Options) func(tb testing.TB) (bs blockstore.BasicBlockstore, path string) {
 	return func(tb testing.TB) (bs blockstore.BasicBlockstore, path string) {
 		tb.Helper()
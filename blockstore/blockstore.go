@@ -1,6 +1,8 @@
 package blockstore
 
 import (
+	"context"
+
 	cid "github.com/ipfs/go-cid"
 	ds "github.com/ipfs/go-datastore"
 	logging "github.com/ipfs/go-log/v2"
@@ -30,6 +32,35 @@ type BatchDeleter interface {
 	DeleteMany(cids []cid.Cid) error
 }
 
+// BlockstoreGC is a trait for blockstores that support online garbage
+// collection.
+type BlockstoreGC interface {
+	// CollectGarbageOnline collects garbage while the blockstore is in use,
+	// calling progress before starting and after each step
+	CollectGarbageOnline(ctx context.Context, opts GCOptions, progress func(GCProgress)) error
+}
+
+// BlockstoreSize is a trait for blockstores that can report their size on
+// disk.
+type BlockstoreSize interface {
+	Size() (int64, error)
+}
+
+type GCOptions struct {
+	// Threshold is the fraction of garbage a file must contain for it to be
+	// rewritten; a store specific default is used when zero
+	Threshold float64
+	// Compact compacts the store before collecting garbage
+	Compact bool
+}
+
+type GCProgress struct {
+	// Rounds is the number of files rewritten so far
+	Rounds     int
+	SizeBefore int64
+	Size       int64
+}
+
 // WrapIDStore wraps the underlying blockstore in an "identity" blockstore.
 // The ID store filters out all puts for blocks with CIDs using the "identity"
 // hash function. It also extracts inlined blocks from CIDs using the identity
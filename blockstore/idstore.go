@@ -172,3 +172,19 @@ func (b *idstore) Close() error {
 	}
 	return nil
 }
+
+func (b *idstore) CollectGarbageOnline(ctx context.Context, opts GCOptions, progress func(GCProgress)) error {
+	gc, ok := b.bs.(BlockstoreGC)
+	if !ok {
+		return xerrors.Errorf("underlying blockstore doesn't support online garbage collection")
+	}
+	return gc.CollectGarbageOnline(ctx, opts, progress)
+}
+
+func (b *idstore) Size() (int64, error) {
+	sz, ok := b.bs.(BlockstoreSize)
+	if !ok {
+		return 0, xerrors.Errorf("underlying blockstore doesn't report its size")
+	}
+	return sz.Size()
+}
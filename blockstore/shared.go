@@ -0,0 +1,112 @@
+package blockstore
+
+import (
+	"context"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+// SharedStore is a read-through blockstore for deployments in which several
+// nodes share the chain history kept by one trusted, read-only, blockstore
+// (e.g. another lotus node or an IPFS cluster). Blocks missing locally are
+// read from the shared blockstore without being copied locally, while writes
+// and deletes only go to the local blockstore.
+type SharedStore struct {
+	Blockstore
+
+	shared Blockstore
+}
+
+var _ Blockstore = (*SharedStore)(nil)
+var _ BlockstoreGC = (*SharedStore)(nil)
+var _ BlockstoreSize = (*SharedStore)(nil)
+
+// NewSharedStore wraps local, reading the blocks missing in it from shared.
+func NewSharedStore(local, shared Blockstore) *SharedStore {
+	return &SharedStore{Blockstore: local, shared: shared}
+}
+
+func (s *SharedStore) Has(c cid.Cid) (bool, error) {
+	has, err := s.Blockstore.Has(c)
+	if has || err != nil {
+		return has, err
+	}
+
+	has, err = s.shared.Has(c)
+	if err != nil {
+		// a miss is always safe for Has, the caller will fetch the block
+		log.Warnf("shared blockstore: checking for %s: %s", c, err)
+		return false, nil
+	}
+	return has, nil
+}
+
+func (s *SharedStore) Get(c cid.Cid) (blocks.Block, error) {
+	blk, err := s.Blockstore.Get(c)
+	if err != ErrNotFound {
+		return blk, err
+	}
+
+	blk, err = s.shared.Get(c)
+	if err != nil {
+		return nil, s.sharedErr(c, err)
+	}
+	return blk, nil
+}
+
+func (s *SharedStore) View(c cid.Cid, callback func([]byte) error) error {
+	err := s.Blockstore.View(c, callback)
+	if err != ErrNotFound {
+		return err
+	}
+
+	blk, err := s.shared.Get(c)
+	if err != nil {
+		return s.sharedErr(c, err)
+	}
+	return callback(blk.RawData())
+}
+
+func (s *SharedStore) GetSize(c cid.Cid) (int, error) {
+	size, err := s.Blockstore.GetSize(c)
+	if err != ErrNotFound {
+		return size, err
+	}
+
+	size, err = s.shared.GetSize(c)
+	if err != nil {
+		return 0, s.sharedErr(c, err)
+	}
+	return size, nil
+}
+
+// sharedErr turns the errors of remote blockstores, which can't return
+// ErrNotFound, back into ErrNotFound when the block is missing
+func (s *SharedStore) sharedErr(c cid.Cid, err error) error {
+	if err == ErrNotFound {
+		return err
+	}
+	if has, herr := s.shared.Has(c); herr == nil && !has {
+		return ErrNotFound
+	}
+	return xerrors.Errorf("getting %s from the shared blockstore: %w", c, err)
+}
+
+func (s *SharedStore) CollectGarbageOnline(ctx context.Context, opts GCOptions, progress func(GCProgress)) error {
+	gc, ok := s.Blockstore.(BlockstoreGC)
+	if !ok {
+		return xerrors.Errorf("local blockstore doesn't support online garbage collection")
+	}
+	return gc.CollectGarbageOnline(ctx, opts, progress)
+}
+
+// Size returns the size of the local blockstore
+func (s *SharedStore) Size() (int64, error) {
+	sz, ok := s.Blockstore.(BlockstoreSize)
+	if !ok {
+		return 0, xerrors.Errorf("local blockstore doesn't report its size")
+	}
+	return sz.Size()
+}
@@ -0,0 +1,72 @@
+package blockstore
+
+import (
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+func TestSharedStore(t *testing.T) {
+	local := NewMemory()
+	shared := NewMemory()
+
+	_ = local.Put(b0)
+	_ = shared.Put(b1)
+
+	s := NewSharedStore(local, shared)
+
+	for _, b := range []blocks.Block{b0, b1} {
+		has, err := s.Has(b.Cid())
+		require.NoError(t, err)
+		require.True(t, has)
+
+		v, err := s.Get(b.Cid())
+		require.NoError(t, err)
+		require.Equal(t, b.RawData(), v.RawData())
+
+		size, err := s.GetSize(b.Cid())
+		require.NoError(t, err)
+		require.Equal(t, len(b.RawData()), size)
+	}
+
+	// blocks read from the shared store aren't copied locally
+	has, err := local.Has(b1.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// writes only go to the local store
+	require.NoError(t, s.Put(b2))
+	has, err = shared.Has(b2.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	missing := blocks.NewBlock([]byte("missing"))
+	_, err = s.Get(missing.Cid())
+	require.Equal(t, ErrNotFound, err)
+}
+
+// remoteStore fails reads of missing blocks like remote blockstores do
+type remoteStore struct {
+	MemBlockstore
+}
+
+func (r remoteStore) Get(c cid.Cid) (blocks.Block, error) {
+	blk, err := r.MemBlockstore.Get(c)
+	if err != nil {
+		return nil, xerrors.Errorf("blockstore get: %s", err)
+	}
+	return blk, nil
+}
+
+func TestSharedStoreRemoteNotFound(t *testing.T) {
+	s := NewSharedStore(NewMemory(), remoteStore{NewMemory()})
+
+	_, err := s.Get(b0.Cid())
+	require.Equal(t, ErrNotFound, err)
+
+	err = s.View(b0.Cid(), func([]byte) error { return nil })
+	require.Equal(t, ErrNotFound, err)
+}
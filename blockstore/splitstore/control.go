@@ -0,0 +1,216 @@
+package splitstore
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// compaction phases, as reported by CompactionStatus
+const (
+	PhaseWarmup  = "warmup"
+	PhaseMarking = "marking"
+	PhaseCollect = "collecting"
+	PhaseMoving  = "moving"
+	PhasePurging = "purging"
+	PhaseGC      = "gc"
+)
+
+var errCompactionAborted = xerrors.New("compaction aborted")
+
+// CompactionStatus reports the progress of the ongoing compaction, and the
+// outcome of the last one.
+type CompactionStatus struct {
+	Compacting bool
+	Paused     bool
+	Phase      string
+	Started    time.Time
+
+	BaseEpoch abi.ChainEpoch
+
+	// Marked is the number of objects marked as reachable so far
+	Marked int64
+	// Hot, Cold and Dead are the number of objects found in each category
+	// by the collection phase
+	Hot  int64
+	Cold int64
+	Dead int64
+	// Moved and Purged count the objects moved to the coldstore, and the
+	// objects deleted from the hotstore
+	Moved  int64
+	Purged int64
+
+	LastCompaction time.Time
+	LastDuration   time.Duration
+	LastError      string
+	// LastReclaimed is the size by which the last compaction shrank the
+	// hotstore, if the hotstore can report its size
+	LastReclaimed int64
+}
+
+// PauseCompaction pauses the ongoing compaction at the next opportunity, and
+// prevents new compactions from starting until ResumeCompaction is called.
+func (s *SplitStore) PauseCompaction() {
+	s.ctlMx.Lock()
+	defer s.ctlMx.Unlock()
+
+	if s.resume == nil {
+		s.resume = make(chan struct{})
+		atomic.StoreInt32(&s.paused, 1)
+	}
+}
+
+// ResumeCompaction resumes a paused compaction
+func (s *SplitStore) ResumeCompaction() {
+	s.ctlMx.Lock()
+	defer s.ctlMx.Unlock()
+
+	if s.resume != nil {
+		close(s.resume)
+		s.resume = nil
+		atomic.StoreInt32(&s.paused, 0)
+	}
+}
+
+// CompactionStatus returns the status of the ongoing, or the last, compaction
+func (s *SplitStore) CompactionStatus() CompactionStatus {
+	s.ctlMx.Lock()
+	defer s.ctlMx.Unlock()
+
+	st := s.status
+	st.Compacting = atomic.LoadInt32(&s.compacting) == 1
+	st.Paused = s.resume != nil
+	st.BaseEpoch = s.baseEpoch
+	st.Marked = atomic.LoadInt64(&s.progress.marked)
+	st.Moved = atomic.LoadInt64(&s.progress.moved)
+	st.Purged = atomic.LoadInt64(&s.progress.purged)
+	return st
+}
+
+// checkpoint blocks while compaction is paused, and aborts the compaction if
+// the splitstore is closing
+func (s *SplitStore) checkpoint() error {
+	if atomic.LoadInt32(&s.paused) == 1 {
+		s.ctlMx.Lock()
+		resume := s.resume
+		s.ctlMx.Unlock()
+
+		if resume != nil {
+			log.Info("compaction paused")
+			<-resume
+			log.Info("compaction resumed")
+		}
+	}
+
+	if atomic.LoadInt32(&s.closing) == 1 && atomic.LoadInt32(&s.critsection) == 0 {
+		return errCompactionAborted
+	}
+
+	return nil
+}
+
+// canCompact checks whether a new compaction can start now
+func (s *SplitStore) canCompact(now time.Time) bool {
+	if atomic.LoadInt32(&s.paused) == 1 {
+		return false
+	}
+
+	if len(s.compactionHours) == 0 {
+		return true
+	}
+	for _, h := range s.compactionHours {
+		if now.Hour() == h {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SplitStore) beginCompaction(phase string) {
+	atomic.StoreInt64(&s.progress.marked, 0)
+	atomic.StoreInt64(&s.progress.moved, 0)
+	atomic.StoreInt64(&s.progress.purged, 0)
+
+	s.ctlMx.Lock()
+	defer s.ctlMx.Unlock()
+
+	s.status.Phase = phase
+	s.status.Started = time.Now()
+	s.status.Hot, s.status.Cold, s.status.Dead = 0, 0, 0
+}
+
+func (s *SplitStore) setPhase(phase string) {
+	s.ctlMx.Lock()
+	defer s.ctlMx.Unlock()
+
+	s.status.Phase = phase
+}
+
+func (s *SplitStore) setCollected(hot, cold, dead int) {
+	s.ctlMx.Lock()
+	defer s.ctlMx.Unlock()
+
+	s.status.Hot, s.status.Cold, s.status.Dead = int64(hot), int64(cold), int64(dead)
+}
+
+func (s *SplitStore) endCompaction(took time.Duration, reclaimed int64, err error) {
+	s.ctlMx.Lock()
+	defer s.ctlMx.Unlock()
+
+	s.status.Phase = ""
+	s.status.LastCompaction = time.Now()
+	s.status.LastDuration = took
+	s.status.LastReclaimed = reclaimed
+	s.status.LastError = ""
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+}
+
+// hotSize returns the size of the hotstore, or -1 if it can't report it
+func (s *SplitStore) hotSize() int64 {
+	sz, ok := s.hot.(interface{ Size() (int64, error) })
+	if !ok {
+		return -1
+	}
+
+	n, err := sz.Size()
+	if err != nil {
+		log.Warnf("error getting hotstore size: %s", err)
+		return -1
+	}
+	return n
+}
+
+// throttle limits the bandwidth used for copying blocks between the stores
+func (s *SplitStore) throttle(n int) {
+	if s.limiter == nil {
+		return
+	}
+
+	for n > 0 {
+		c := n
+		if b := s.limiter.Burst(); c > b {
+			c = b
+		}
+		_ = s.limiter.WaitN(context.Background(), c)
+		n -= c
+	}
+}
+
+func newBandwidthLimiter(bps int64) *rate.Limiter {
+	if bps <= 0 {
+		return nil
+	}
+
+	burst := int(bps)
+	if burst < 1<<20 {
+		burst = 1 << 20
+	}
+	return rate.NewLimiter(rate.Limit(bps), burst)
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"go.uber.org/multierr"
+	"golang.org/x/time/rate"
 	"golang.org/x/xerrors"
 
 	blocks "github.com/ipfs/go-block-format"
@@ -97,6 +98,15 @@ type Config struct {
 	// do NOT enable this if you synced from a snapshot.
 	// Only applies if you enabled full compaction
 	Archival bool
+
+	// CompactionHours are the hours of the day, in local time, during which
+	// compaction is allowed to start. Compaction can start at any time if
+	// empty.
+	CompactionHours []int
+	// CompactionMaxBandwidth limits the rate, in bytes per second, at which
+	// blocks are copied between the hotstore and the coldstore. Unlimited
+	// if zero.
+	CompactionMaxBandwidth int64
 }
 
 // ChainAccessor allows the Splitstore to access the chain. It will most likely
@@ -109,9 +119,16 @@ type ChainAccessor interface {
 }
 
 type SplitStore struct {
+	progress struct {
+		marked int64
+		moved  int64
+		purged int64
+	}
+
 	compacting  int32 // compaction (or warmp up) in progress
 	critsection int32 // compaction critical section
 	closing     int32 // the split store is closing
+	paused      int32 // compaction is paused
 
 	fullCompaction  bool
 	enableGC        bool
@@ -136,6 +153,13 @@ type SplitStore struct {
 	env MarkSetEnv
 
 	markSetSize int64
+
+	compactionHours []int
+	limiter         *rate.Limiter
+
+	ctlMx  sync.Mutex
+	resume chan struct{} // closed when a paused compaction is resumed
+	status CompactionStatus
 }
 
 var _ bstore.Blockstore = (*SplitStore)(nil)
@@ -171,6 +195,17 @@ func Open(path string, ds dstore.Datastore, hot, cold bstore.Blockstore, cfg *Co
 		skipMsgReceipts: !(cfg.EnableFullCompaction && cfg.Archival),
 
 		coldPurgeSize: defaultColdPurgeSize,
+
+		compactionHours: cfg.CompactionHours,
+		limiter:         newBandwidthLimiter(cfg.CompactionMaxBandwidth),
+	}
+
+	for _, h := range cfg.CompactionHours {
+		if h < 0 || h > 23 {
+			_ = tracker.Close()
+			_ = env.Close()
+			return nil, xerrors.Errorf("invalid compaction hour %d", h)
+		}
 	}
 
 	if cfg.EnableGC {
@@ -394,6 +429,8 @@ func (s *SplitStore) Start(chain ChainAccessor) error {
 
 func (s *SplitStore) Close() error {
 	atomic.StoreInt32(&s.closing, 1)
+	// a paused compaction needs to either abort or leave its critical section
+	s.ResumeCompaction()
 
 	if atomic.LoadInt32(&s.critsection) == 1 {
 		log.Warn("ongoing compaction in critical section; waiting for it to finish...")
@@ -430,7 +467,9 @@ func (s *SplitStore) HeadChange(_, apply []*types.TipSet) error {
 			log.Info("warming up hotstore")
 			start := time.Now()
 
-			s.warmup(curTs)
+			s.beginCompaction(PhaseWarmup)
+			err := s.warmup(curTs)
+			s.endCompaction(time.Since(start), 0, err)
 
 			log.Infow("warm up done", "took", time.Since(start))
 		}()
@@ -438,7 +477,7 @@ func (s *SplitStore) HeadChange(_, apply []*types.TipSet) error {
 		return nil
 	}
 
-	if epoch-s.baseEpoch > CompactionThreshold {
+	if epoch-s.baseEpoch > CompactionThreshold && s.canCompact(time.Now()) {
 		// it's time to compact
 		go func() {
 			defer atomic.StoreInt32(&s.compacting, 0)
@@ -458,7 +497,7 @@ func (s *SplitStore) HeadChange(_, apply []*types.TipSet) error {
 	return nil
 }
 
-func (s *SplitStore) warmup(curTs *types.TipSet) {
+func (s *SplitStore) warmup(curTs *types.TipSet) error {
 	epoch := curTs.Height()
 
 	batchHot := make([]blocks.Block, 0, batchSize)
@@ -468,6 +507,11 @@ func (s *SplitStore) warmup(curTs *types.TipSet) {
 	err := s.chain.WalkSnapshot(context.Background(), curTs, 1, s.skipOldMsgs, s.skipMsgReceipts,
 		func(cid cid.Cid) error {
 			count++
+			atomic.AddInt64(&s.progress.marked, 1)
+
+			if err := s.checkpoint(); err != nil {
+				return err
+			}
 
 			has, err := s.hot.Has(cid)
 			if err != nil {
@@ -482,6 +526,7 @@ func (s *SplitStore) warmup(curTs *types.TipSet) {
 			if err != nil {
 				return err
 			}
+			s.throttle(len(blk.RawData()))
 
 			batchHot = append(batchHot, blk)
 			batchSnoop = append(batchSnoop, cid)
@@ -505,20 +550,20 @@ func (s *SplitStore) warmup(curTs *types.TipSet) {
 
 	if err != nil {
 		log.Errorf("error warming up splitstore: %s", err)
-		return
+		return err
 	}
 
 	if len(batchHot) > 0 {
 		err = s.tracker.PutBatch(batchSnoop, epoch)
 		if err != nil {
 			log.Errorf("error warming up splitstore: %s", err)
-			return
+			return err
 		}
 
 		err = s.hot.PutMany(batchHot)
 		if err != nil {
 			log.Errorf("error warming up splitstore: %s", err)
-			return
+			return err
 		}
 	}
 
@@ -537,10 +582,16 @@ func (s *SplitStore) warmup(curTs *types.TipSet) {
 	if err != nil {
 		log.Errorf("error saving mark set size: %s", err)
 	}
+
+	return nil
 }
 
 // Compaction/GC Algorithm
 func (s *SplitStore) compact(curTs *types.TipSet) {
+	s.beginCompaction(PhaseMarking)
+	begin := time.Now()
+	sizeBefore := s.hotSize()
+
 	var err error
 	if s.markSetSize == 0 {
 		start := time.Now()
@@ -548,6 +599,7 @@ func (s *SplitStore) compact(curTs *types.TipSet) {
 		err = s.estimateMarkSetSize(curTs)
 		if err != nil {
 			log.Errorf("error estimating mark set size: %s; aborting compaction", err)
+			s.endCompaction(time.Since(begin), 0, err)
 			return
 		}
 		log.Infow("estimating mark set size done", "took", time.Since(start), "size", s.markSetSize)
@@ -567,6 +619,13 @@ func (s *SplitStore) compact(curTs *types.TipSet) {
 	if err != nil {
 		log.Errorf("COMPACTION ERROR: %s", err)
 	}
+
+	var reclaimed int64
+	if sizeAfter := s.hotSize(); sizeBefore >= 0 && sizeAfter >= 0 {
+		reclaimed = sizeBefore - sizeAfter
+		log.Infow("hotstore size after compaction", "before", sizeBefore, "after", sizeAfter)
+	}
+	s.endCompaction(time.Since(begin), reclaimed, err)
 }
 
 func (s *SplitStore) estimateMarkSetSize(curTs *types.TipSet) error {
@@ -574,7 +633,7 @@ func (s *SplitStore) estimateMarkSetSize(curTs *types.TipSet) error {
 	err := s.chain.WalkSnapshot(context.Background(), curTs, 1, s.skipOldMsgs, s.skipMsgReceipts,
 		func(cid cid.Cid) error {
 			count++
-			return nil
+			return s.checkpoint()
 		})
 
 	if err != nil {
@@ -611,6 +670,10 @@ func (s *SplitStore) compactSimple(curTs *types.TipSet) error {
 	err = s.chain.WalkSnapshot(context.Background(), boundaryTs, 1, s.skipOldMsgs, s.skipMsgReceipts,
 		func(cid cid.Cid) error {
 			count++
+			atomic.AddInt64(&s.progress.marked, 1)
+			if err := s.checkpoint(); err != nil {
+				return err
+			}
 			return coldSet.Mark(cid)
 		})
 
@@ -626,6 +689,7 @@ func (s *SplitStore) compactSimple(curTs *types.TipSet) error {
 
 	// 2. move cold unreachable objects to the coldstore
 	log.Info("collecting cold objects")
+	s.setPhase(PhaseCollect)
 	startCollect := time.Now()
 
 	cold := make([]cid.Cid, 0, s.coldPurgeSize)
@@ -635,6 +699,10 @@ func (s *SplitStore) compactSimple(curTs *types.TipSet) error {
 
 	// 2.1 iterate through the tracking store and collect unreachable cold objects
 	err = s.tracker.ForEach(func(cid cid.Cid, writeEpoch abi.ChainEpoch) error {
+		if err := s.checkpoint(); err != nil {
+			return err
+		}
+
 		// is the object still hot?
 		if writeEpoch > coldEpoch {
 			// yes, stay in the hotstore
@@ -669,6 +737,7 @@ func (s *SplitStore) compactSimple(curTs *types.TipSet) error {
 
 	log.Infow("collection done", "took", time.Since(startCollect))
 	log.Infow("compaction stats", "hot", hotCnt, "cold", coldCnt)
+	s.setCollected(hotCnt, coldCnt, 0)
 	stats.Record(context.Background(), metrics.SplitstoreCompactionHot.M(int64(hotCnt)))
 	stats.Record(context.Background(), metrics.SplitstoreCompactionCold.M(int64(coldCnt)))
 
@@ -679,11 +748,12 @@ func (s *SplitStore) compactSimple(curTs *types.TipSet) error {
 	// check to see if we are closing first; if that's the case just return
 	if atomic.LoadInt32(&s.closing) == 1 {
 		log.Info("splitstore is closing; aborting compaction")
-		return xerrors.Errorf("compaction aborted")
+		return errCompactionAborted
 	}
 
 	// 2.2 copy the cold objects to the coldstore
 	log.Info("moving cold blocks to the coldstore")
+	s.setPhase(PhaseMoving)
 	startMove := time.Now()
 	err = s.moveColdBlocks(cold)
 	if err != nil {
@@ -693,6 +763,7 @@ func (s *SplitStore) compactSimple(curTs *types.TipSet) error {
 
 	// 2.3 delete cold objects from the hotstore
 	log.Info("purging cold objects from the hotstore")
+	s.setPhase(PhasePurging)
 	startPurge := time.Now()
 	err = s.purgeBlocks(cold)
 	if err != nil {
@@ -715,6 +786,7 @@ func (s *SplitStore) compactSimple(curTs *types.TipSet) error {
 		return xerrors.Errorf("error syncing tracker: %w", err)
 	}
 
+	s.setPhase(PhaseGC)
 	s.gcHotstore()
 
 	err = s.setBaseEpoch(coldEpoch)
@@ -734,6 +806,10 @@ func (s *SplitStore) moveColdBlocks(cold []cid.Cid) error {
 	batch := make([]blocks.Block, 0, batchSize)
 
 	for _, cid := range cold {
+		if err := s.checkpoint(); err != nil {
+			return err
+		}
+
 		blk, err := s.hot.Get(cid)
 		if err != nil {
 			if err == dstore.ErrNotFound {
@@ -750,6 +826,9 @@ func (s *SplitStore) moveColdBlocks(cold []cid.Cid) error {
 			continue
 		}
 
+		s.throttle(len(blk.RawData()))
+		atomic.AddInt64(&s.progress.moved, 1)
+
 		batch = append(batch, blk)
 		if len(batch) == batchSize {
 			err = s.cold.PutMany(batch)
@@ -785,6 +864,10 @@ func (s *SplitStore) purgeBatch(cids []cid.Cid, deleteBatch func([]cid.Cid) erro
 			done = true
 		}
 
+		if err := s.checkpoint(); err != nil {
+			return err
+		}
+
 		err := deleteBatch(cids[start:end])
 		if err != nil {
 			return xerrors.Errorf("error deleting batch: %w", err)
@@ -795,7 +878,13 @@ func (s *SplitStore) purgeBatch(cids []cid.Cid, deleteBatch func([]cid.Cid) erro
 }
 
 func (s *SplitStore) purgeBlocks(cids []cid.Cid) error {
-	return s.purgeBatch(cids, s.hot.DeleteMany)
+	return s.purgeBatch(cids, func(cids []cid.Cid) error {
+		if err := s.hot.DeleteMany(cids); err != nil {
+			return err
+		}
+		atomic.AddInt64(&s.progress.purged, int64(len(cids)))
+		return nil
+	})
 }
 
 func (s *SplitStore) purgeTracking(cids []cid.Cid) error {
@@ -861,6 +950,10 @@ func (s *SplitStore) compactFull(curTs *types.TipSet) error {
 	err = s.chain.WalkSnapshot(context.Background(), boundaryTs, boundaryEpoch-coldEpoch, s.skipOldMsgs, s.skipMsgReceipts,
 		func(cid cid.Cid) error {
 			count++
+			atomic.AddInt64(&s.progress.marked, 1)
+			if err := s.checkpoint(); err != nil {
+				return err
+			}
 			return hotSet.Mark(cid)
 		})
 
@@ -882,6 +975,10 @@ func (s *SplitStore) compactFull(curTs *types.TipSet) error {
 	err = s.chain.WalkSnapshot(context.Background(), coldTs, CompactionCold, s.skipOldMsgs, s.skipMsgReceipts,
 		func(cid cid.Cid) error {
 			count++
+			atomic.AddInt64(&s.progress.marked, 1)
+			if err := s.checkpoint(); err != nil {
+				return err
+			}
 			return coldSet.Mark(cid)
 		})
 
@@ -900,6 +997,7 @@ func (s *SplitStore) compactFull(curTs *types.TipSet) error {
 	// - If a cold object is reachable in the cold range, it is moved to the coldstore.
 	// - If a cold object is unreachable, it is deleted if GC is enabled, otherwise moved to the coldstore.
 	log.Info("collecting cold objects")
+	s.setPhase(PhaseCollect)
 	startCollect := time.Now()
 
 	// some stats for logging
@@ -910,6 +1008,10 @@ func (s *SplitStore) compactFull(curTs *types.TipSet) error {
 
 	// 2.1 iterate through the tracker and collect cold and dead objects
 	err = s.tracker.ForEach(func(cid cid.Cid, wrEpoch abi.ChainEpoch) error {
+		if err := s.checkpoint(); err != nil {
+			return err
+		}
+
 		// is the object stil hot?
 		if wrEpoch > coldEpoch {
 			// yes, stay in the hotstore
@@ -971,6 +1073,7 @@ func (s *SplitStore) compactFull(curTs *types.TipSet) error {
 
 	log.Infow("collection done", "took", time.Since(startCollect))
 	log.Infow("compaction stats", "hot", hotCnt, "cold", coldCnt, "dead", deadCnt)
+	s.setCollected(hotCnt, coldCnt, deadCnt)
 	stats.Record(context.Background(), metrics.SplitstoreCompactionHot.M(int64(hotCnt)))
 	stats.Record(context.Background(), metrics.SplitstoreCompactionCold.M(int64(coldCnt)))
 	stats.Record(context.Background(), metrics.SplitstoreCompactionDead.M(int64(deadCnt)))
@@ -982,11 +1085,12 @@ func (s *SplitStore) compactFull(curTs *types.TipSet) error {
 	// check to see if we are closing first; if that's the case just return
 	if atomic.LoadInt32(&s.closing) == 1 {
 		log.Info("splitstore is closing; aborting compaction")
-		return xerrors.Errorf("compaction aborted")
+		return errCompactionAborted
 	}
 
 	// 2.2 copy the cold objects to the coldstore
 	log.Info("moving cold objects to the coldstore")
+	s.setPhase(PhaseMoving)
 	startMove := time.Now()
 	err = s.moveColdBlocks(cold)
 	if err != nil {
@@ -996,6 +1100,7 @@ func (s *SplitStore) compactFull(curTs *types.TipSet) error {
 
 	// 2.3 delete cold objects from the hotstore
 	log.Info("purging cold objects from the hotstore")
+	s.setPhase(PhasePurging)
 	startPurge := time.Now()
 	err = s.purgeBlocks(cold)
 	if err != nil {
@@ -1036,6 +1141,7 @@ func (s *SplitStore) compactFull(curTs *types.TipSet) error {
 		return xerrors.Errorf("error syncing tracker: %w", err)
 	}
 
+	s.setPhase(PhaseGC)
 	s.gcHotstore()
 
 	err = s.setBaseEpoch(coldEpoch)
@@ -1052,7 +1158,10 @@ func (s *SplitStore) compactFull(curTs *types.TipSet) error {
 }
 
 func (s *SplitStore) setBaseEpoch(epoch abi.ChainEpoch) error {
+	s.ctlMx.Lock()
 	s.baseEpoch = epoch
+	s.ctlMx.Unlock()
+
 	// write to datastore
 	return s.ds.Put(baseEpochKey, epochToBytes(epoch))
 }
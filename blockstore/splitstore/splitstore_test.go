@@ -193,6 +193,59 @@ func TestSplitStoreFullCompactionWithGC(t *testing.T) {
 	})
 }
 
+func TestSplitStoreCompactionControls(t *testing.T) {
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	hot := blockstore.NewMemorySync()
+	cold := blockstore.NewMemorySync()
+
+	_, err := Open("", ds, hot, cold, &Config{TrackingStoreType: "mem", CompactionHours: []int{24}})
+	if err == nil {
+		t.Fatal("expected an error for an invalid compaction hour")
+	}
+
+	ss, err := Open("", ds, hot, cold, &Config{TrackingStoreType: "mem", CompactionHours: []int{3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint
+
+	at3 := time.Date(2021, 6, 1, 3, 30, 0, 0, time.Local)
+	at4 := time.Date(2021, 6, 1, 4, 30, 0, 0, time.Local)
+	if !ss.canCompact(at3) {
+		t.Error("expected compaction to be allowed at 3")
+	}
+	if ss.canCompact(at4) {
+		t.Error("expected compaction not to be allowed at 4")
+	}
+
+	ss.PauseCompaction()
+	if ss.canCompact(at3) {
+		t.Error("expected compaction not to be allowed while paused")
+	}
+	if !ss.CompactionStatus().Paused {
+		t.Error("expected the status to report the compaction as paused")
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- ss.checkpoint()
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("checkpoint returned while paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	ss.ResumeCompaction()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if ss.CompactionStatus().Paused {
+		t.Error("expected the status to report the compaction as resumed")
+	}
+}
+
 type mockChain struct {
 	t testing.TB
 
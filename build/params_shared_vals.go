@@ -38,6 +38,11 @@ var BlocksPerEpoch = uint64(builtin2.ExpectedLeadersPerEpoch)
 const Finality = policy.ChainFinality
 const MessageConfidence = uint64(5)
 
+// Confidence for callers which only need the message to be executed, and for
+// ones which can't tolerate it being re-orged out
+const SoftMessageConfidence = uint64(1)
+const FinalMessageConfidence = uint64(Finality)
+
 // constants for Weight calculation
 // The ratio of weight contributed by short-term vs long-term factors in a given round
 const WRatioNum = int64(1)
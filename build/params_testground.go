@@ -41,7 +41,9 @@ var (
 	SlashablePowerDelay        = 20
 	InteractivePoRepConfidence = 6
 
-	MessageConfidence uint64 = 5
+	MessageConfidence      uint64 = 5
+	SoftMessageConfidence  uint64 = 1
+	FinalMessageConfidence uint64 = uint64(Finality)
 
 	WRatioNum = int64(1)
 	WRatioDen = uint64(2)
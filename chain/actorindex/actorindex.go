@@ -0,0 +1,701 @@
+// Package actorindex records when actors are created and deleted, so that
+// these questions can be answered without replaying the chain.
+//
+// Created actors are found by comparing the next actor ID of the init actor
+// before and after each tipset is executed. Deleted actors (e.g. collected
+// payment channels) are found among the recipients of the executed
+// messages.
+package actorindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	init0 "github.com/filecoin-project/specs-actors/actors/builtin/init"
+	power0 "github.com/filecoin-project/specs-actors/actors/builtin/power"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/account"
+	init_ "github.com/filecoin-project/lotus/chain/actors/builtin/init"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/power"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("actorindex")
+
+// Prefix is the datastore namespace of the index
+var Prefix = datastore.NewKey("/actorindex")
+
+// ErrNotIndexed is returned for actors the index doesn't know about, either
+// because they were created before the indexed range, or don't exist
+var ErrNotIndexed = xerrors.New("actor not indexed")
+
+var headKey = datastore.NewKey("/head")
+
+func actorKey(id uint64) datastore.Key {
+	return datastore.NewKey(fmt.Sprintf("/actors/%d", id))
+}
+
+func robustKey(addr address.Address) datastore.Key {
+	return datastore.NewKey("/robust/" + addr.String())
+}
+
+func epochPrefix(kind string, epoch abi.ChainEpoch) string {
+	return fmt.Sprintf("/%s/%020d", kind, epoch)
+}
+
+func epochKey(kind string, epoch abi.ChainEpoch, id uint64) datastore.Key {
+	return datastore.NewKey(fmt.Sprintf("%s/%d", epochPrefix(kind, epoch), id))
+}
+
+type indexHead struct {
+	Height abi.ChainEpoch
+	Key    types.TipSetKey
+}
+
+// Index follows the chain and records the creation and deletion epochs of
+// actors.
+type Index struct {
+	cs       *store.ChainStore
+	ds       datastore.Batching
+	backfill abi.ChainEpoch
+
+	lk   sync.Mutex
+	head *indexHead
+
+	notify chan struct{}
+}
+
+// New returns an index stored in ds. When the index is empty, it starts
+// backfill epochs before the head, as far as the state is available.
+func New(cs *store.ChainStore, ds datastore.Batching, backfill abi.ChainEpoch) (*Index, error) {
+	ix := &Index{
+		cs:       cs,
+		ds:       ds,
+		backfill: backfill,
+		notify:   make(chan struct{}, 1),
+	}
+
+	b, err := ds.Get(headKey)
+	switch err {
+	case nil:
+		var h indexHead
+		if err := json.Unmarshal(b, &h); err != nil {
+			return nil, xerrors.Errorf("decoding index head: %w", err)
+		}
+		ix.head = &h
+	case datastore.ErrNotFound:
+	default:
+		return nil, xerrors.Errorf("loading index head: %w", err)
+	}
+
+	return ix, nil
+}
+
+// Run indexes the chain as the head changes, until the context is done
+func (ix *Index) Run(ctx context.Context) {
+	ix.cs.SubscribeHeadChanges(func(_, _ []*types.TipSet) error {
+		if ctx.Err() != nil {
+			return store.ErrNotifeeDone
+		}
+		select {
+		case ix.notify <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+
+	for {
+		if err := ix.Update(ctx, ix.cs.GetHeaviestTipSet()); err != nil {
+			log.Errorw("updating actor index", "error", err)
+		}
+
+		select {
+		case <-ix.notify:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Height returns the epoch up to which the chain is indexed, -1 when
+// nothing was indexed yet
+func (ix *Index) Height() abi.ChainEpoch {
+	ix.lk.Lock()
+	defer ix.lk.Unlock()
+
+	if ix.head == nil {
+		return -1
+	}
+	return ix.head.Height
+}
+
+// Update indexes the chain up to the tipset, reverting the tipsets indexed
+// before a reorg
+func (ix *Index) Update(ctx context.Context, head *types.TipSet) error {
+	ix.lk.Lock()
+	defer ix.lk.Unlock()
+
+	if head == nil {
+		return nil
+	}
+
+	if ix.head == nil {
+		start, err := ix.start(ctx, head)
+		if err != nil {
+			return err
+		}
+		if err := ix.setHead(start); err != nil {
+			return err
+		}
+	}
+
+	indexed, err := ix.cs.LoadTipSet(ix.head.Key)
+	if err != nil {
+		return xerrors.Errorf("loading indexed tipset: %w", err)
+	}
+
+	// walk back from the head to the common ancestor with the indexed chain
+	var apply []*types.TipSet
+	ts := head
+	for !ts.Equals(indexed) {
+		if ts.Height() > indexed.Height() {
+			apply = append(apply, ts)
+			if ts, err = ix.cs.LoadTipSet(ts.Parents()); err != nil {
+				return xerrors.Errorf("loading tipset: %w", err)
+			}
+			continue
+		}
+		if indexed, err = ix.cs.LoadTipSet(indexed.Parents()); err != nil {
+			return xerrors.Errorf("loading indexed tipset: %w", err)
+		}
+	}
+
+	if indexed.Height() < ix.head.Height {
+		log.Infow("reverting actor index", "from", ix.head.Height, "to", indexed.Height())
+		if err := ix.revertAbove(indexed.Height()); err != nil {
+			return xerrors.Errorf("reverting index above %d: %w", indexed.Height(), err)
+		}
+		if err := ix.setHead(indexed); err != nil {
+			return err
+		}
+	}
+
+	for i := len(apply) - 1; i >= 0; i-- {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		parent := indexed
+		if i < len(apply)-1 {
+			parent = apply[i+1]
+		}
+		if err := ix.apply(ctx, parent, apply[i]); err != nil {
+			return xerrors.Errorf("indexing tipset at %d: %w", apply[i].Height(), err)
+		}
+	}
+
+	return nil
+}
+
+// start returns the tipset the index starts from
+func (ix *Index) start(ctx context.Context, head *types.TipSet) (*types.TipSet, error) {
+	h := head.Height() - ix.backfill
+	if h <= 0 {
+		h = 0
+	}
+
+	ts, err := ix.cs.GetTipsetByHeight(ctx, h, head, true)
+	if err != nil {
+		return nil, xerrors.Errorf("getting tipset at %d: %w", h, err)
+	}
+
+	if _, err := state.LoadStateTree(ix.cs.ActorStore(ctx), ts.ParentState()); err != nil {
+		log.Warnw("state not available to backfill the actor index, starting at the head", "height", h, "error", err)
+		return head, nil
+	}
+
+	log.Infow("starting actor index", "height", ts.Height())
+	return ts, nil
+}
+
+func (ix *Index) setHead(ts *types.TipSet) error {
+	h := &indexHead{Height: ts.Height(), Key: ts.Key()}
+	b, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	if err := ix.ds.Put(headKey, b); err != nil {
+		return xerrors.Errorf("storing index head: %w", err)
+	}
+	ix.head = h
+	return nil
+}
+
+// apply indexes the actors created and deleted by executing the messages of
+// parent, which is reflected in the state of ts
+func (ix *Index) apply(ctx context.Context, parent, ts *types.TipSet) error {
+	as := ix.cs.ActorStore(ctx)
+
+	pre, err := state.LoadStateTree(as, parent.ParentState())
+	if err != nil {
+		return xerrors.Errorf("loading parent state: %w", err)
+	}
+	post, err := state.LoadStateTree(as, ts.ParentState())
+	if err != nil {
+		return xerrors.Errorf("loading state: %w", err)
+	}
+
+	preNext, err := nextID(as, pre)
+	if err != nil {
+		return err
+	}
+	postNext, err := nextID(as, post)
+	if err != nil {
+		return err
+	}
+
+	created := map[uint64]*api.ActorLifecycle{}
+	for id := uint64(preNext); id < uint64(postNext); id++ {
+		idAddr, err := address.NewIDAddress(id)
+		if err != nil {
+			return err
+		}
+
+		al := &api.ActorLifecycle{
+			ID:      idAddr,
+			Created: ts.Height(),
+			Deleted: -1,
+		}
+
+		act, err := post.GetActor(idAddr)
+		switch {
+		case xerrors.Is(err, types.ErrActorNotFound):
+			// created and deleted by the same tipset
+			al.Deleted = ts.Height()
+		case err != nil:
+			return xerrors.Errorf("getting actor %s: %w", idAddr, err)
+		default:
+			al.Code = act.Code
+			if builtin.IsAccountActor(act.Code) {
+				st, err := account.Load(as, act)
+				if err != nil {
+					return xerrors.Errorf("loading account %s: %w", idAddr, err)
+				}
+				if al.Robust, err = st.PubkeyAddress(); err != nil {
+					return xerrors.Errorf("getting account %s key: %w", idAddr, err)
+				}
+			}
+		}
+
+		created[id] = al
+	}
+
+	msgs, err := ix.cs.MessagesForTipset(parent)
+	if err != nil {
+		return xerrors.Errorf("loading messages: %w", err)
+	}
+
+	var deleted []*api.ActorLifecycle
+	seen := map[address.Address]struct{}{}
+	for i, cm := range msgs {
+		m := cm.VMMessage()
+
+		if len(created) > 0 {
+			rcpt, err := ix.cs.GetParentReceipt(ts.Blocks()[0], i)
+			if err != nil {
+				return xerrors.Errorf("loading receipt %d: %w", i, err)
+			}
+			if rcpt.ExitCode == exitcode.Ok {
+				id, robust, err := createdBy(post, m, rcpt)
+				if err != nil {
+					return xerrors.Errorf("message %s: %w", cm.Cid(), err)
+				}
+				if al, ok := created[id]; ok {
+					c := cm.Cid()
+					al.CreatedBy = &c
+					if robust != address.Undef {
+						al.Robust = robust
+					}
+				}
+			}
+		}
+
+		if _, ok := seen[m.To]; ok {
+			continue
+		}
+		seen[m.To] = struct{}{}
+
+		idAddr, err := pre.LookupID(m.To)
+		if xerrors.Is(err, types.ErrActorNotFound) {
+			continue
+		}
+		if err != nil {
+			return xerrors.Errorf("looking up %s: %w", m.To, err)
+		}
+
+		act, err := pre.GetActor(idAddr)
+		if xerrors.Is(err, types.ErrActorNotFound) {
+			continue
+		}
+		if err != nil {
+			return xerrors.Errorf("getting actor %s: %w", idAddr, err)
+		}
+		if _, err := post.GetActor(idAddr); !xerrors.Is(err, types.ErrActorNotFound) {
+			if err != nil {
+				return xerrors.Errorf("getting actor %s: %w", idAddr, err)
+			}
+			continue
+		}
+
+		al, err := ix.load(idAddr)
+		if xerrors.Is(err, ErrNotIndexed) {
+			al = &api.ActorLifecycle{ID: idAddr, Code: act.Code, Created: -1}
+			if m.To.Protocol() != address.ID {
+				al.Robust = m.To
+			}
+		} else if err != nil {
+			return err
+		}
+		al.Deleted = ts.Height()
+		deleted = append(deleted, al)
+	}
+
+	b, err := ix.ds.Batch()
+	if err != nil {
+		return err
+	}
+
+	for id, al := range created {
+		if err := put(b, al); err != nil {
+			return err
+		}
+		if err := b.Put(epochKey("created", al.Created, id), nil); err != nil {
+			return err
+		}
+		if al.Deleted >= 0 {
+			if err := b.Put(epochKey("deleted", al.Deleted, id), nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, al := range deleted {
+		if err := put(b, al); err != nil {
+			return err
+		}
+		id, err := address.IDFromAddress(al.ID)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(epochKey("deleted", al.Deleted, id), nil); err != nil {
+			return err
+		}
+	}
+
+	if err := b.Commit(); err != nil {
+		return xerrors.Errorf("storing actors: %w", err)
+	}
+
+	if len(created) > 0 || len(deleted) > 0 {
+		log.Debugw("indexed actors", "height", ts.Height(), "created", len(created), "deleted", len(deleted))
+	}
+
+	return ix.setHead(ts)
+}
+
+func put(b datastore.Batch, al *api.ActorLifecycle) error {
+	id, err := address.IDFromAddress(al.ID)
+	if err != nil {
+		return err
+	}
+
+	v, err := json.Marshal(al)
+	if err != nil {
+		return err
+	}
+	if err := b.Put(actorKey(id), v); err != nil {
+		return err
+	}
+
+	if al.Robust != address.Undef {
+		if err := b.Put(robustKey(al.Robust), al.ID.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revertAbove drops the creations and deletions after the epoch
+func (ix *Index) revertAbove(epoch abi.ChainEpoch) error {
+	b, err := ix.ds.Batch()
+	if err != nil {
+		return err
+	}
+
+	// undo deletions first, the actors also created above the epoch are
+	// dropped after
+	err = ix.forEachAbove("deleted", epoch, func(k datastore.Key, id uint64) error {
+		idAddr, err := address.NewIDAddress(id)
+		if err != nil {
+			return err
+		}
+		al, err := ix.load(idAddr)
+		if err != nil {
+			return err
+		}
+		if al.Created < 0 {
+			// only known because of the deletion
+			if al.Robust != address.Undef {
+				if err := b.Delete(robustKey(al.Robust)); err != nil {
+					return err
+				}
+			}
+			if err := b.Delete(actorKey(id)); err != nil {
+				return err
+			}
+		} else {
+			al.Deleted = -1
+			if err := put(b, al); err != nil {
+				return err
+			}
+		}
+		return b.Delete(k)
+	})
+	if err != nil {
+		return err
+	}
+	if err := b.Commit(); err != nil {
+		return err
+	}
+
+	if b, err = ix.ds.Batch(); err != nil {
+		return err
+	}
+	err = ix.forEachAbove("created", epoch, func(k datastore.Key, id uint64) error {
+		idAddr, err := address.NewIDAddress(id)
+		if err != nil {
+			return err
+		}
+		al, err := ix.load(idAddr)
+		if err != nil {
+			return err
+		}
+		if al.Robust != address.Undef {
+			if err := b.Delete(robustKey(al.Robust)); err != nil {
+				return err
+			}
+		}
+		if err := b.Delete(actorKey(id)); err != nil {
+			return err
+		}
+		return b.Delete(k)
+	})
+	if err != nil {
+		return err
+	}
+	return b.Commit()
+}
+
+func (ix *Index) forEachAbove(kind string, epoch abi.ChainEpoch, cb func(k datastore.Key, id uint64) error) error {
+	for e := epoch + 1; e <= ix.head.Height; e++ {
+		if err := ix.forEachAt(kind, e, cb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ix *Index) forEachAt(kind string, epoch abi.ChainEpoch, cb func(k datastore.Key, id uint64) error) error {
+	res, err := ix.ds.Query(query.Query{Prefix: epochPrefix(kind, epoch), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+
+	// collect the keys first, the callbacks may change the datastore
+	ents, err := res.Rest()
+	if err != nil {
+		return err
+	}
+
+	for _, e := range ents {
+		k := datastore.NewKey(e.Key)
+		var id uint64
+		if _, err := fmt.Sscan(k.Name(), &id); err != nil {
+			return xerrors.Errorf("parsing index key %s: %w", k, err)
+		}
+		if err := cb(k, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ix *Index) load(idAddr address.Address) (*api.ActorLifecycle, error) {
+	id, err := address.IDFromAddress(idAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ix.ds.Get(actorKey(id))
+	if err == datastore.ErrNotFound {
+		return nil, ErrNotIndexed
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("loading actor %s: %w", idAddr, err)
+	}
+
+	var al api.ActorLifecycle
+	if err := json.Unmarshal(b, &al); err != nil {
+		return nil, xerrors.Errorf("decoding actor %s: %w", idAddr, err)
+	}
+	return &al, nil
+}
+
+// Lookup returns when the actor, given by its ID or robust address, was
+// created and deleted
+func (ix *Index) Lookup(addr address.Address) (*api.ActorLifecycle, error) {
+	if addr.Protocol() != address.ID {
+		b, err := ix.ds.Get(robustKey(addr))
+		if err == datastore.ErrNotFound {
+			return nil, ErrNotIndexed
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("looking up %s: %w", addr, err)
+		}
+		if addr, err = address.NewFromBytes(b); err != nil {
+			return nil, err
+		}
+	}
+
+	return ix.load(addr)
+}
+
+// Created returns the actors created between the epochs, inclusive, ordered
+// by creation epoch and ID
+func (ix *Index) Created(from, to abi.ChainEpoch) ([]api.ActorLifecycle, error) {
+	if h := ix.Height(); to > h {
+		to = h
+	}
+
+	var out []api.ActorLifecycle
+	for e := from; e <= to; e++ {
+		var at []api.ActorLifecycle
+		err := ix.forEachAt("created", e, func(_ datastore.Key, id uint64) error {
+			idAddr, err := address.NewIDAddress(id)
+			if err != nil {
+				return err
+			}
+			al, err := ix.load(idAddr)
+			if err != nil {
+				return err
+			}
+			at = append(at, *al)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		sortByID(at)
+		out = append(out, at...)
+	}
+	return out, nil
+}
+
+// NotFoundError explains why the actor wasn't found at the epoch, when it
+// was created after it or deleted before it
+func (ix *Index) NotFoundError(addr address.Address, epoch abi.ChainEpoch, err error) error {
+	al, lerr := ix.Lookup(addr)
+	if lerr != nil {
+		return err
+	}
+
+	if al.Created > epoch {
+		return xerrors.Errorf("%s is created at epoch %d, after %d: %w", addr, al.Created, epoch, err)
+	}
+	if al.Deleted >= 0 && al.Deleted <= epoch {
+		return xerrors.Errorf("%s was deleted at epoch %d: %w", addr, al.Deleted, err)
+	}
+	return err
+}
+
+func nextID(as adt.Store, st *state.StateTree) (abi.ActorID, error) {
+	act, err := st.GetActor(init_.Address)
+	if err != nil {
+		return 0, xerrors.Errorf("getting init actor: %w", err)
+	}
+	ist, err := init_.Load(as, act)
+	if err != nil {
+		return 0, xerrors.Errorf("loading init actor state: %w", err)
+	}
+	return ist.NextID()
+}
+
+// createdBy returns the ID of the actor created by the message, with its
+// robust address when known, or 0
+func createdBy(post *state.StateTree, m *types.Message, rcpt *types.MessageReceipt) (uint64, address.Address, error) {
+	var idAddr, robust address.Address
+
+	switch {
+	case m.To == init_.Address && m.Method == init_.Methods.Exec:
+		// the return value didn't change across actor versions
+		var ret init0.ExecReturn
+		if err := ret.UnmarshalCBOR(bytes.NewReader(rcpt.Return)); err != nil {
+			return 0, address.Undef, xerrors.Errorf("decoding exec return: %w", err)
+		}
+		idAddr, robust = ret.IDAddress, ret.RobustAddress
+	case m.To == power.Address && m.Method == power.Methods.CreateMiner:
+		var ret power0.CreateMinerReturn
+		if err := ret.UnmarshalCBOR(bytes.NewReader(rcpt.Return)); err != nil {
+			return 0, address.Undef, xerrors.Errorf("decoding create miner return: %w", err)
+		}
+		idAddr, robust = ret.IDAddress, ret.RobustAddress
+	case m.To.Protocol() != address.ID:
+		// e.g. sending funds to a new key address creates an account
+		var err error
+		idAddr, err = post.LookupID(m.To)
+		if xerrors.Is(err, types.ErrActorNotFound) {
+			return 0, address.Undef, nil
+		}
+		if err != nil {
+			return 0, address.Undef, err
+		}
+		robust = m.To
+	default:
+		return 0, address.Undef, nil
+	}
+
+	id, err := address.IDFromAddress(idAddr)
+	if err != nil {
+		return 0, address.Undef, err
+	}
+	return id, robust, nil
+}
+
+func sortByID(als []api.ActorLifecycle) {
+	ids := make(map[address.Address]uint64, len(als))
+	for _, al := range als {
+		id, _ := address.IDFromAddress(al.ID)
+		ids[al.ID] = id
+	}
+	sort.Slice(als, func(i, j int) bool {
+		return ids[als[i].ID] < ids[als[j].ID]
+	})
+}
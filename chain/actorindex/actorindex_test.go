@@ -0,0 +1,78 @@
+package actorindex
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	builtin5 "github.com/filecoin-project/specs-actors/v5/actors/builtin"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+func TestIndexRevert(t *testing.T) {
+	ix := &Index{
+		ds:   dssync.MutexWrap(datastore.NewMapDatastore()),
+		head: &indexHead{Height: 20},
+	}
+
+	mkActor := func(id uint64, created, deleted abi.ChainEpoch, robust address.Address) {
+		idAddr, err := address.NewIDAddress(id)
+		require.NoError(t, err)
+
+		b, err := ix.ds.Batch()
+		require.NoError(t, err)
+		require.NoError(t, put(b, &api.ActorLifecycle{
+			ID:      idAddr,
+			Robust:  robust,
+			Code:    builtin5.AccountActorCodeID,
+			Created: created,
+			Deleted: deleted,
+		}))
+		if created >= 0 {
+			require.NoError(t, b.Put(epochKey("created", created, id), nil))
+		}
+		if deleted >= 0 {
+			require.NoError(t, b.Put(epochKey("deleted", deleted, id), nil))
+		}
+		require.NoError(t, b.Commit())
+	}
+
+	mkActor(1000, 5, -1, address.TestAddress)
+	mkActor(1001, 12, 18, address.Undef)
+	mkActor(1002, 16, -1, address.TestAddress2)
+	mkActor(900, -1, 15, address.Undef)
+
+	created, err := ix.Created(10, 100)
+	require.NoError(t, err)
+	require.Len(t, created, 2)
+	require.Equal(t, abi.ChainEpoch(12), created[0].Created)
+	require.Equal(t, address.TestAddress2, created[1].Robust)
+
+	al, err := ix.Lookup(address.TestAddress2)
+	require.NoError(t, err)
+	require.Equal(t, abi.ChainEpoch(16), al.Created)
+
+	require.NoError(t, ix.revertAbove(14))
+
+	// created above the epoch
+	_, err = ix.Lookup(address.TestAddress2)
+	require.ErrorIs(t, err, ErrNotIndexed)
+	created, err = ix.Created(0, 100)
+	require.NoError(t, err)
+	require.Len(t, created, 2)
+	require.Equal(t, abi.ChainEpoch(-1), created[1].Deleted)
+
+	// only known through a reverted deletion
+	id900, err := address.NewIDAddress(900)
+	require.NoError(t, err)
+	_, err = ix.Lookup(id900)
+	require.ErrorIs(t, err, ErrNotIndexed)
+
+	require.Contains(t, ix.NotFoundError(address.TestAddress, 3, xerrors.New("not found")).Error(), "created at epoch 5")
+}
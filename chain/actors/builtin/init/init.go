@@ -129,6 +129,9 @@ type State interface {
 
 	ForEachActor(func(id abi.ActorID, address address.Address) error) error
 
+	// NextID is the ID the next created actor will get
+	NextID() (abi.ActorID, error)
+
 	// Remove exists to support tooling that manipulates state for testing.
 	// It should not be used in production code, as init actor entries are
 	// immutable.
@@ -75,6 +75,10 @@ func (s *state2) SetNetworkName(name string) error {
 	return nil
 }
 
+func (s *state2) NextID() (abi.ActorID, error) {
+	return s.State.NextID, nil
+}
+
 func (s *state2) SetNextID(id abi.ActorID) error {
 	s.State.NextID = id
 	return nil
@@ -77,6 +77,10 @@ func (s *state5) SetNetworkName(name string) error {
 	return nil
 }
 
+func (s *state5) NextID() (abi.ActorID, error) {
+	return s.State.NextID, nil
+}
+
 func (s *state5) SetNextID(id abi.ActorID) error {
 	s.State.NextID = id
 	return nil
@@ -0,0 +1,298 @@
+package miner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/require"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	miner0 "github.com/filecoin-project/specs-actors/actors/builtin/miner"
+	adt0 "github.com/filecoin-project/specs-actors/actors/util/adt"
+	smoothing0 "github.com/filecoin-project/specs-actors/actors/util/smoothing"
+	miner2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/miner"
+	adt2 "github.com/filecoin-project/specs-actors/v2/actors/util/adt"
+	smoothing2 "github.com/filecoin-project/specs-actors/v2/actors/util/smoothing"
+	miner3 "github.com/filecoin-project/specs-actors/v3/actors/builtin/miner"
+	adt3 "github.com/filecoin-project/specs-actors/v3/actors/util/adt"
+	smoothing3 "github.com/filecoin-project/specs-actors/v3/actors/util/smoothing"
+	miner4 "github.com/filecoin-project/specs-actors/v4/actors/builtin/miner"
+	adt4 "github.com/filecoin-project/specs-actors/v4/actors/util/adt"
+	smoothing4 "github.com/filecoin-project/specs-actors/v4/actors/util/smoothing"
+	miner5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/miner"
+	adt5 "github.com/filecoin-project/specs-actors/v5/actors/util/adt"
+	smoothing5 "github.com/filecoin-project/specs-actors/v5/actors/util/smoothing"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// termSector holds the fields of a sector the termination penalty depends on
+type termSector struct {
+	Number            abi.SectorNumber
+	Activation        abi.ChainEpoch
+	DayReward         abi.TokenAmount
+	StoragePledge     abi.TokenAmount
+	ReplacedSectorAge abi.ChainEpoch
+	ReplacedDayReward abi.TokenAmount
+}
+
+type termVersion struct {
+	// replaced is set when the version accounts for the age of the sector
+	// replaced by a sector
+	replaced bool
+
+	// load stores the sectors in the state of a miner
+	load func(t *testing.T, store adt.Store, sectors []termSector) State
+	// penalty computes the penalty of a sector with the actors
+	penalty func(s termSector, epoch abi.ChainEpoch, reward, power builtin.FilterEstimate) abi.TokenAmount
+}
+
+var termSealProof = abi.RegisteredSealProof_StackedDrg32GiBV1_1
+
+func sealedCID(t *testing.T, n abi.SectorNumber) cid.Cid {
+	commR := make([]byte, 32)
+	commR[0] = byte(n)
+	c, err := commcid.ReplicaCommitmentV1ToCID(commR)
+	require.NoError(t, err)
+	return c
+}
+
+func termPower() abi.StoragePower {
+	ssize, _ := termSealProof.SectorSize()
+	return big.NewInt(int64(ssize))
+}
+
+// estimate returns a filter estimate of the value, in Q.128 format, not
+// changing over time
+func estimate(v big.Int) builtin.FilterEstimate {
+	return builtin.FilterEstimate{
+		PositionEstimate: big.Lsh(v, 128),
+		VelocityEstimate: big.Zero(),
+	}
+}
+
+var termVersions = map[string]termVersion{
+	"v0": {
+		load: func(t *testing.T, store adt.Store, sectors []termSector) State {
+			root, err := adt0.MakeEmptyArray(store).Root()
+			require.NoError(t, err)
+			st := &state0{State: miner0.State{Sectors: root}, store: store}
+
+			var infos []*miner0.SectorOnChainInfo
+			for _, s := range sectors {
+				infos = append(infos, &miner0.SectorOnChainInfo{
+					SectorNumber:          s.Number,
+					SealProof:             termSealProof,
+					SealedCID:             sealedCID(t, s.Number),
+					Activation:            s.Activation,
+					Expiration:            s.Activation + 540*builtin.EpochsInDay,
+					DealWeight:            big.Zero(),
+					VerifiedDealWeight:    big.Zero(),
+					InitialPledge:         s.StoragePledge,
+					ExpectedDayReward:     s.DayReward,
+					ExpectedStoragePledge: s.StoragePledge,
+				})
+			}
+			require.NoError(t, st.State.PutSectors(store, infos...))
+			return st
+		},
+		penalty: func(s termSector, epoch abi.ChainEpoch, reward, power builtin.FilterEstimate) abi.TokenAmount {
+			rewardSmoothed, powerSmoothed := smoothing0.FilterEstimate(reward), smoothing0.FilterEstimate(power)
+			return miner0.PledgePenaltyForTermination(s.DayReward, s.StoragePledge, epoch-s.Activation,
+				&rewardSmoothed, &powerSmoothed, termPower())
+		},
+	},
+	"v2": {
+		replaced: true,
+		load: func(t *testing.T, store adt.Store, sectors []termSector) State {
+			root, err := adt2.MakeEmptyArray(store).Root()
+			require.NoError(t, err)
+			st := &state2{State: miner2.State{Sectors: root}, store: store}
+
+			var infos []*miner2.SectorOnChainInfo
+			for _, s := range sectors {
+				infos = append(infos, &miner2.SectorOnChainInfo{
+					SectorNumber:          s.Number,
+					SealProof:             termSealProof,
+					SealedCID:             sealedCID(t, s.Number),
+					Activation:            s.Activation,
+					Expiration:            s.Activation + 540*builtin.EpochsInDay,
+					DealWeight:            big.Zero(),
+					VerifiedDealWeight:    big.Zero(),
+					InitialPledge:         s.StoragePledge,
+					ExpectedDayReward:     s.DayReward,
+					ExpectedStoragePledge: s.StoragePledge,
+					ReplacedSectorAge:     s.ReplacedSectorAge,
+					ReplacedDayReward:     s.ReplacedDayReward,
+				})
+			}
+			require.NoError(t, st.State.PutSectors(store, infos...))
+			return st
+		},
+		penalty: func(s termSector, epoch abi.ChainEpoch, reward, power builtin.FilterEstimate) abi.TokenAmount {
+			return miner2.PledgePenaltyForTermination(s.DayReward, epoch-s.Activation, s.StoragePledge,
+				smoothing2.FilterEstimate(power), termPower(), smoothing2.FilterEstimate(reward), s.ReplacedDayReward, s.ReplacedSectorAge)
+		},
+	},
+	"v3": {
+		replaced: true,
+		load: func(t *testing.T, store adt.Store, sectors []termSector) State {
+			root, err := adt3.StoreEmptyArray(store, miner3.SectorsAmtBitwidth)
+			require.NoError(t, err)
+			st := &state3{State: miner3.State{Sectors: root}, store: store}
+
+			var infos []*miner3.SectorOnChainInfo
+			for _, s := range sectors {
+				infos = append(infos, &miner3.SectorOnChainInfo{
+					SectorNumber:          s.Number,
+					SealProof:             termSealProof,
+					SealedCID:             sealedCID(t, s.Number),
+					Activation:            s.Activation,
+					Expiration:            s.Activation + 540*builtin.EpochsInDay,
+					DealWeight:            big.Zero(),
+					VerifiedDealWeight:    big.Zero(),
+					InitialPledge:         s.StoragePledge,
+					ExpectedDayReward:     s.DayReward,
+					ExpectedStoragePledge: s.StoragePledge,
+					ReplacedSectorAge:     s.ReplacedSectorAge,
+					ReplacedDayReward:     s.ReplacedDayReward,
+				})
+			}
+			require.NoError(t, st.State.PutSectors(store, infos...))
+			return st
+		},
+		penalty: func(s termSector, epoch abi.ChainEpoch, reward, power builtin.FilterEstimate) abi.TokenAmount {
+			return miner3.PledgePenaltyForTermination(s.DayReward, epoch-s.Activation, s.StoragePledge,
+				smoothing3.FilterEstimate(power), termPower(), smoothing3.FilterEstimate(reward), s.ReplacedDayReward, s.ReplacedSectorAge)
+		},
+	},
+	"v4": {
+		replaced: true,
+		load: func(t *testing.T, store adt.Store, sectors []termSector) State {
+			root, err := adt4.StoreEmptyArray(store, miner4.SectorsAmtBitwidth)
+			require.NoError(t, err)
+			st := &state4{State: miner4.State{Sectors: root}, store: store}
+
+			var infos []*miner4.SectorOnChainInfo
+			for _, s := range sectors {
+				infos = append(infos, &miner4.SectorOnChainInfo{
+					SectorNumber:          s.Number,
+					SealProof:             termSealProof,
+					SealedCID:             sealedCID(t, s.Number),
+					Activation:            s.Activation,
+					Expiration:            s.Activation + 540*builtin.EpochsInDay,
+					DealWeight:            big.Zero(),
+					VerifiedDealWeight:    big.Zero(),
+					InitialPledge:         s.StoragePledge,
+					ExpectedDayReward:     s.DayReward,
+					ExpectedStoragePledge: s.StoragePledge,
+					ReplacedSectorAge:     s.ReplacedSectorAge,
+					ReplacedDayReward:     s.ReplacedDayReward,
+				})
+			}
+			require.NoError(t, st.State.PutSectors(store, infos...))
+			return st
+		},
+		penalty: func(s termSector, epoch abi.ChainEpoch, reward, power builtin.FilterEstimate) abi.TokenAmount {
+			return miner4.PledgePenaltyForTermination(s.DayReward, epoch-s.Activation, s.StoragePledge,
+				smoothing4.FilterEstimate(power), termPower(), smoothing4.FilterEstimate(reward), s.ReplacedDayReward, s.ReplacedSectorAge)
+		},
+	},
+	"v5": {
+		replaced: true,
+		load: func(t *testing.T, store adt.Store, sectors []termSector) State {
+			root, err := adt5.StoreEmptyArray(store, miner5.SectorsAmtBitwidth)
+			require.NoError(t, err)
+			st := &state5{State: miner5.State{Sectors: root}, store: store}
+
+			var infos []*miner5.SectorOnChainInfo
+			for _, s := range sectors {
+				infos = append(infos, &miner5.SectorOnChainInfo{
+					SectorNumber:          s.Number,
+					SealProof:             termSealProof,
+					SealedCID:             sealedCID(t, s.Number),
+					Activation:            s.Activation,
+					Expiration:            s.Activation + 540*builtin.EpochsInDay,
+					DealWeight:            big.Zero(),
+					VerifiedDealWeight:    big.Zero(),
+					InitialPledge:         s.StoragePledge,
+					ExpectedDayReward:     s.DayReward,
+					ExpectedStoragePledge: s.StoragePledge,
+					ReplacedSectorAge:     s.ReplacedSectorAge,
+					ReplacedDayReward:     s.ReplacedDayReward,
+				})
+			}
+			require.NoError(t, st.State.PutSectors(store, infos...))
+			return st
+		},
+		penalty: func(s termSector, epoch abi.ChainEpoch, reward, power builtin.FilterEstimate) abi.TokenAmount {
+			return miner5.PledgePenaltyForTermination(s.DayReward, epoch-s.Activation, s.StoragePledge,
+				smoothing5.FilterEstimate(power), termPower(), smoothing5.FilterEstimate(reward), s.ReplacedDayReward, s.ReplacedSectorAge)
+		},
+	},
+}
+
+func TestTerminationPenalty(t *testing.T) {
+	dayReward := types.FromFil(1)
+	sectors := []termSector{
+		// active for 40 days at the termination epoch
+		{Number: 1, Activation: 10 * builtin.EpochsInDay, ReplacedDayReward: big.Zero()},
+		// active for 10 days
+		{Number: 2, Activation: 40 * builtin.EpochsInDay, ReplacedDayReward: big.Zero()},
+		// active for 10 days, replacing a sector active for 20 days
+		{Number: 3, Activation: 40 * builtin.EpochsInDay, ReplacedSectorAge: 20 * builtin.EpochsInDay, ReplacedDayReward: dayReward},
+	}
+	for i := range sectors {
+		sectors[i].DayReward = dayReward
+		sectors[i].StoragePledge = big.Mul(dayReward, big.NewInt(20))
+	}
+	epoch := 50 * builtin.EpochsInDay
+
+	// 1 FIL per epoch for a network of 1 EiB, the lower bound of the penalty
+	// is way below the one of the sector age
+	reward := estimate(types.FromFil(1))
+	power := estimate(big.NewInt(1 << 60))
+
+	for name, v := range termVersions {
+		v := v
+		t.Run(name, func(t *testing.T) {
+			store := adt.WrapStore(context.Background(), cbor.NewCborStore(blockstore.NewMemory()))
+			st := v.load(t, store, sectors)
+
+			penalty := func(nums ...abi.SectorNumber) abi.TokenAmount {
+				p, err := st.TerminationPenalty(nums, epoch, reward, power)
+				require.NoError(t, err)
+				return p
+			}
+
+			// the penalty is the one of the actors
+			for _, s := range sectors {
+				require.Equal(t, v.penalty(s, epoch, reward, power), penalty(s.Number), "sector %d", s.Number)
+			}
+
+			// older sectors pay more
+			require.True(t, penalty(1).GreaterThan(penalty(2)), "%s <= %s", penalty(1), penalty(2))
+
+			// the age of the replaced sector counts with actors v2 onwards
+			if v.replaced {
+				require.True(t, penalty(3).GreaterThan(penalty(2)), "%s <= %s", penalty(3), penalty(2))
+			} else {
+				require.Equal(t, penalty(2), penalty(3))
+			}
+
+			// the penalty of sectors is the sum of the ones of each sector
+			require.Equal(t, big.Sum(penalty(1), penalty(2), penalty(3)), penalty(1, 2, 3))
+
+			_, err := st.TerminationPenalty([]abi.SectorNumber{1, 4}, epoch, reward, power)
+			require.Error(t, err)
+		})
+	}
+}
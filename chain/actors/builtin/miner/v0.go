@@ -4,11 +4,10 @@ import (
 	"bytes"
 	"errors"
 
-	"github.com/filecoin-project/go-state-types/big"
-
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-bitfield"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-state-types/dline"
 	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p-core/peer"
@@ -16,9 +15,11 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
 
 	miner0 "github.com/filecoin-project/specs-actors/actors/builtin/miner"
 	adt0 "github.com/filecoin-project/specs-actors/actors/util/adt"
+	smoothing0 "github.com/filecoin-project/specs-actors/actors/util/smoothing"
 )
 
 var _ State = (*state0)(nil)
@@ -248,6 +249,41 @@ func (s *state0) IsAllocated(num abi.SectorNumber) (bool, error) {
 	return allocatedSectors.IsSet(uint64(num))
 }
 
+func (s *state0) TerminationPenalty(sectors []abi.SectorNumber, epoch abi.ChainEpoch, rewardEstimate, networkQAPowerEstimate builtin.FilterEstimate) (abi.TokenAmount, error) {
+	rewardSmoothed := smoothing0.FilterEstimate{
+		PositionEstimate: rewardEstimate.PositionEstimate,
+		VelocityEstimate: rewardEstimate.VelocityEstimate,
+	}
+	powerSmoothed := smoothing0.FilterEstimate{
+		PositionEstimate: networkQAPowerEstimate.PositionEstimate,
+		VelocityEstimate: networkQAPowerEstimate.VelocityEstimate,
+	}
+
+	total := big.Zero()
+	for _, num := range sectors {
+		info, ok, err := s.State.GetSector(s.store, num)
+		if err != nil {
+			return big.Zero(), xerrors.Errorf("loading sector %d: %w", num, err)
+		}
+		if !ok {
+			return big.Zero(), xerrors.Errorf("sector %d not found, or already terminated", num)
+		}
+
+		ssize, err := info.SealProof.SectorSize()
+		if err != nil {
+			return big.Zero(), err
+		}
+		power := miner0.QAPowerForSector(ssize, info)
+
+		fee := miner0.PledgePenaltyForTermination(info.ExpectedDayReward, info.ExpectedStoragePledge, epoch-info.Activation,
+			&rewardSmoothed, &powerSmoothed, power)
+
+		total = big.Add(total, fee)
+	}
+
+	return total, nil
+}
+
 func (s *state0) GetProvingPeriodStart() (abi.ChainEpoch, error) {
 	return s.State.ProvingPeriodStart, nil
 }
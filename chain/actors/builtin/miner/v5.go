@@ -7,6 +7,7 @@ import (
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-bitfield"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-state-types/dline"
 	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p-core/peer"
@@ -14,11 +15,13 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
 
 	builtin5 "github.com/filecoin-project/specs-actors/v5/actors/builtin"
 
 	miner5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/miner"
 	adt5 "github.com/filecoin-project/specs-actors/v5/actors/util/adt"
+	smoothing5 "github.com/filecoin-project/specs-actors/v5/actors/util/smoothing"
 )
 
 var _ State = (*state5)(nil)
@@ -248,6 +251,41 @@ func (s *state5) IsAllocated(num abi.SectorNumber) (bool, error) {
 	return allocatedSectors.IsSet(uint64(num))
 }
 
+func (s *state5) TerminationPenalty(sectors []abi.SectorNumber, epoch abi.ChainEpoch, rewardEstimate, networkQAPowerEstimate builtin.FilterEstimate) (abi.TokenAmount, error) {
+	rewardSmoothed := smoothing5.FilterEstimate{
+		PositionEstimate: rewardEstimate.PositionEstimate,
+		VelocityEstimate: rewardEstimate.VelocityEstimate,
+	}
+	powerSmoothed := smoothing5.FilterEstimate{
+		PositionEstimate: networkQAPowerEstimate.PositionEstimate,
+		VelocityEstimate: networkQAPowerEstimate.VelocityEstimate,
+	}
+
+	total := big.Zero()
+	for _, num := range sectors {
+		info, ok, err := s.State.GetSector(s.store, num)
+		if err != nil {
+			return big.Zero(), xerrors.Errorf("loading sector %d: %w", num, err)
+		}
+		if !ok {
+			return big.Zero(), xerrors.Errorf("sector %d not found, or already terminated", num)
+		}
+
+		ssize, err := info.SealProof.SectorSize()
+		if err != nil {
+			return big.Zero(), err
+		}
+		power := miner5.QAPowerForSector(ssize, info)
+
+		fee := miner5.PledgePenaltyForTermination(info.ExpectedDayReward, epoch-info.Activation, info.ExpectedStoragePledge,
+			powerSmoothed, power, rewardSmoothed, info.ReplacedDayReward, info.ReplacedSectorAge)
+
+		total = big.Add(total, fee)
+	}
+
+	return total, nil
+}
+
 func (s *state5) GetProvingPeriodStart() (abi.ChainEpoch, error) {
 	return s.State.ProvingPeriodStart, nil
 }
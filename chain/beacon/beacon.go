@@ -2,6 +2,7 @@ package beacon
 
 import (
 	"context"
+	"time"
 
 	"github.com/filecoin-project/go-state-types/abi"
 	logging "github.com/ipfs/go-log/v2"
@@ -45,6 +46,41 @@ type RandomBeacon interface {
 	MaxBeaconRoundForEpoch(abi.ChainEpoch) uint64
 }
 
+// HealthReporter is implemented by beacons which keep track of the health of
+// the endpoints they fetch entries from
+type HealthReporter interface {
+	Health() Health
+}
+
+// Health is a report of how a beacon is fetching entries
+type Health struct {
+	Endpoints []EndpointHealth
+
+	// LatestRound is the latest round fetched
+	LatestRound uint64
+	// LastFetchLatency is the time it took to fetch the latest round
+	LastFetchLatency time.Duration
+	// MissedRounds counts the rounds which couldn't be fetched from any
+	// endpoint
+	MissedRounds uint64
+}
+
+// EndpointHealth is the health of a single beacon endpoint. Endpoints are
+// tried healthiest first, the others are used when it fails.
+type EndpointHealth struct {
+	URL     string
+	Healthy bool
+
+	Requests            uint64
+	Failures            uint64
+	ConsecutiveFailures int
+
+	// Latency is the moving average of the latency of successful requests
+	Latency     time.Duration
+	LastSuccess time.Time
+	LastError   string `json:",omitempty"`
+}
+
 func ValidateBlockValues(bSchedule Schedule, h *types.BlockHeader, parentEpoch abi.ChainEpoch,
 	prevEntry types.BeaconEntry) error {
 	{
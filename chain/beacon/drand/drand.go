@@ -3,6 +3,7 @@ package drand
 import (
 	"bytes"
 	"context"
+	"sync"
 	"time"
 
 	dchain "github.com/drand/drand/chain"
@@ -50,7 +51,8 @@ func (dp *drandPeer) IsTLS() bool {
 //
 // The root trust for the Drand chain is configured from build.DrandChain.
 type DrandBeacon struct {
-	client dclient.Client
+	client    dclient.Client
+	endpoints *failoverClient
 
 	pubkey kyber.Point
 
@@ -62,6 +64,11 @@ type DrandBeacon struct {
 	filRoundTime uint64
 
 	localCache *lru.Cache
+
+	healthLk         sync.Mutex
+	latestRound      uint64
+	lastFetchLatency time.Duration
+	missedRounds     uint64
 }
 
 // DrandHTTPClient interface overrides the user agent used by drand
@@ -82,15 +89,18 @@ func NewDrandBeacon(genesisTs, interval uint64, ps *pubsub.PubSub, config dtypes
 	dlogger := dlog.NewKitLoggerFrom(kzap.NewZapSugarLogger(
 		log.SugaredLogger.Desugar(), zapcore.InfoLevel))
 
-	var clients []dclient.Client
+	if len(config.Servers) == 0 {
+		return nil, xerrors.Errorf("no drand servers configured")
+	}
+
+	endpoints := &failoverClient{}
 	for _, url := range config.Servers {
 		hc, err := hclient.NewWithInfo(url, drandChain, nil)
 		if err != nil {
 			return nil, xerrors.Errorf("could not create http drand client: %w", err)
 		}
 		hc.(DrandHTTPClient).SetUserAgent("drand-client-lotus/" + build.BuildVersion)
-		clients = append(clients, hc)
-
+		endpoints.endpoints = append(endpoints.endpoints, &endpoint{url: url, client: hc})
 	}
 
 	opts := []dclient.Option{
@@ -105,7 +115,7 @@ func NewDrandBeacon(genesisTs, interval uint64, ps *pubsub.PubSub, config dtypes
 		log.Info("drand beacon without pubsub")
 	}
 
-	client, err := dclient.Wrap(clients, opts...)
+	client, err := dclient.Wrap([]dclient.Client{endpoints}, opts...)
 	if err != nil {
 		return nil, xerrors.Errorf("creating drand client")
 	}
@@ -117,6 +127,7 @@ func NewDrandBeacon(genesisTs, interval uint64, ps *pubsub.PubSub, config dtypes
 
 	db := &DrandBeacon{
 		client:     client,
+		endpoints:  endpoints,
 		localCache: lc,
 	}
 
@@ -152,7 +163,18 @@ func (db *DrandBeacon) Entry(ctx context.Context, round uint64) <-chan beacon.Re
 			br.Entry.Round = resp.Round()
 			br.Entry.Data = resp.Signature()
 		}
-		log.Infow("done fetching randomness", "round", round, "took", build.Clock.Since(start))
+		took := build.Clock.Since(start)
+		log.Infow("done fetching randomness", "round", round, "took", took)
+
+		db.healthLk.Lock()
+		if err != nil {
+			db.missedRounds++
+		} else if br.Entry.Round >= db.latestRound {
+			db.latestRound = br.Entry.Round
+			db.lastFetchLatency = took
+		}
+		db.healthLk.Unlock()
+
 		out <- br
 		close(out)
 	}()
@@ -203,4 +225,20 @@ func (db *DrandBeacon) MaxBeaconRoundForEpoch(filEpoch abi.ChainEpoch) uint64 {
 	return dround
 }
 
+func (db *DrandBeacon) Health() beacon.Health {
+	db.healthLk.Lock()
+	h := beacon.Health{
+		LatestRound:      db.latestRound,
+		LastFetchLatency: db.lastFetchLatency,
+		MissedRounds:     db.missedRounds,
+	}
+	db.healthLk.Unlock()
+
+	for _, e := range db.endpoints.ordered() {
+		h.Endpoints = append(h.Endpoints, e.health())
+	}
+	return h
+}
+
 var _ beacon.RandomBeacon = (*DrandBeacon)(nil)
+var _ beacon.HealthReporter = (*DrandBeacon)(nil)
@@ -0,0 +1,192 @@
+package drand
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	dchain "github.com/drand/drand/chain"
+	dclient "github.com/drand/drand/client"
+	"go.uber.org/multierr"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/beacon"
+)
+
+const (
+	// endpointTimeout bounds requests to a single endpoint, before failing
+	// over to the next one
+	endpointTimeout = 5 * time.Second
+
+	// maxConsecutiveFailures is the number of failed requests in a row after
+	// which an endpoint is unhealthy, and only tried after the healthy ones
+	maxConsecutiveFailures = 3
+
+	// unhealthyRetryInterval is the time after which an unhealthy endpoint is
+	// tried again as if it was healthy
+	unhealthyRetryInterval = time.Minute
+
+	// latencyWeight is the weight of new requests in the latency average
+	latencyWeight = 0.2
+)
+
+// endpoint is a drand HTTP endpoint with its health
+type endpoint struct {
+	url    string
+	client dclient.Client
+
+	lk          sync.Mutex
+	requests    uint64
+	failures    uint64
+	consecutive int
+	latency     time.Duration
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastErr     error
+}
+
+func (e *endpoint) record(took time.Duration, err error) {
+	e.lk.Lock()
+	defer e.lk.Unlock()
+
+	e.requests++
+	if err != nil {
+		e.failures++
+		e.consecutive++
+		e.lastFailure = build.Clock.Now()
+		e.lastErr = err
+		return
+	}
+
+	e.consecutive = 0
+	e.lastSuccess = build.Clock.Now()
+	if e.latency == 0 {
+		e.latency = took
+	} else {
+		e.latency = time.Duration((1-latencyWeight)*float64(e.latency) + latencyWeight*float64(took))
+	}
+}
+
+// healthy must be called with the lock held
+func (e *endpoint) healthy() bool {
+	return e.consecutive < maxConsecutiveFailures || build.Clock.Since(e.lastFailure) > unhealthyRetryInterval
+}
+
+func (e *endpoint) health() beacon.EndpointHealth {
+	e.lk.Lock()
+	defer e.lk.Unlock()
+
+	h := beacon.EndpointHealth{
+		URL:                 e.url,
+		Healthy:             e.healthy(),
+		Requests:            e.requests,
+		Failures:            e.failures,
+		ConsecutiveFailures: e.consecutive,
+		Latency:             e.latency,
+		LastSuccess:         e.lastSuccess,
+	}
+	if e.lastErr != nil {
+		h.LastError = e.lastErr.Error()
+	}
+	return h
+}
+
+// failoverClient is a drand client trying its endpoints one at a time,
+// healthiest first
+type failoverClient struct {
+	endpoints []*endpoint
+}
+
+var _ dclient.Client = (*failoverClient)(nil)
+
+// ordered returns the endpoints healthy first, then by latency. Endpoints
+// without successful requests yet come after the ones with a known latency.
+func (fc *failoverClient) ordered() []*endpoint {
+	type rank struct {
+		e       *endpoint
+		healthy bool
+		latency time.Duration
+	}
+
+	ranks := make([]rank, len(fc.endpoints))
+	for i, e := range fc.endpoints {
+		e.lk.Lock()
+		ranks[i] = rank{e: e, healthy: e.healthy(), latency: e.latency}
+		e.lk.Unlock()
+	}
+
+	sort.SliceStable(ranks, func(i, j int) bool {
+		if ranks[i].healthy != ranks[j].healthy {
+			return ranks[i].healthy
+		}
+		if (ranks[i].latency == 0) != (ranks[j].latency == 0) {
+			return ranks[i].latency != 0
+		}
+		return ranks[i].latency < ranks[j].latency
+	})
+
+	out := make([]*endpoint, len(ranks))
+	for i, r := range ranks {
+		out[i] = r.e
+	}
+	return out
+}
+
+func (fc *failoverClient) Get(ctx context.Context, round uint64) (dclient.Result, error) {
+	var errs error
+	for _, e := range fc.ordered() {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		rctx, cancel := context.WithTimeout(ctx, endpointTimeout)
+		start := build.Clock.Now()
+		res, err := e.client.Get(rctx, round)
+		cancel()
+
+		if err != nil && ctx.Err() != nil {
+			// the caller gave up, the endpoint isn't at fault
+			return nil, ctx.Err()
+		}
+
+		e.record(build.Clock.Since(start), err)
+		if err == nil {
+			return res, nil
+		}
+
+		log.Warnw("drand endpoint failed, trying the next one", "url", e.url, "round", round, "error", err)
+		errs = multierr.Append(errs, xerrors.Errorf("%s: %w", e.url, err))
+	}
+
+	return nil, xerrors.Errorf("all drand endpoints failed: %w", errs)
+}
+
+func (fc *failoverClient) Watch(ctx context.Context) <-chan dclient.Result {
+	return fc.ordered()[0].client.Watch(ctx)
+}
+
+func (fc *failoverClient) Info(ctx context.Context) (*dchain.Info, error) {
+	var errs error
+	for _, e := range fc.ordered() {
+		info, err := e.client.Info(ctx)
+		if err == nil {
+			return info, nil
+		}
+		errs = multierr.Append(errs, err)
+	}
+	return nil, errs
+}
+
+func (fc *failoverClient) RoundAt(t time.Time) uint64 {
+	return fc.endpoints[0].client.RoundAt(t)
+}
+
+func (fc *failoverClient) Close() error {
+	var errs error
+	for _, e := range fc.endpoints {
+		errs = multierr.Append(errs, e.client.Close())
+	}
+	return errs
+}
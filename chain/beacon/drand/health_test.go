@@ -0,0 +1,67 @@
+package drand
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	dchain "github.com/drand/drand/chain"
+	dclient "github.com/drand/drand/client"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResult uint64
+
+func (r fakeResult) Round() uint64      { return uint64(r) }
+func (r fakeResult) Randomness() []byte { return nil }
+func (r fakeResult) Signature() []byte  { return nil }
+
+type fakeClient struct {
+	fail bool
+	gets int
+}
+
+func (c *fakeClient) Get(ctx context.Context, round uint64) (dclient.Result, error) {
+	c.gets++
+	if c.fail {
+		return nil, errors.New("unavailable")
+	}
+	return fakeResult(round), nil
+}
+
+func (c *fakeClient) Watch(ctx context.Context) <-chan dclient.Result { return nil }
+func (c *fakeClient) Info(ctx context.Context) (*dchain.Info, error)  { return nil, nil }
+func (c *fakeClient) RoundAt(time.Time) uint64                        { return 0 }
+func (c *fakeClient) Close() error                                    { return nil }
+
+func TestFailoverClient(t *testing.T) {
+	primary, backup := &fakeClient{fail: true}, &fakeClient{}
+	fc := &failoverClient{endpoints: []*endpoint{
+		{url: "primary", client: primary},
+		{url: "backup", client: backup},
+	}}
+	ctx := context.Background()
+
+	for round := uint64(1); round <= maxConsecutiveFailures; round++ {
+		res, err := fc.Get(ctx, round)
+		require.NoError(t, err)
+		require.Equal(t, round, res.Round())
+	}
+	require.Equal(t, maxConsecutiveFailures, primary.gets)
+
+	// the primary is unhealthy now, the backup is tried first
+	require.Equal(t, "backup", fc.ordered()[0].url)
+	_, err := fc.Get(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, maxConsecutiveFailures, primary.gets)
+
+	h := fc.endpoints[0].health()
+	require.False(t, h.Healthy)
+	require.Equal(t, uint64(maxConsecutiveFailures), h.Failures)
+	require.Equal(t, "unavailable", h.LastError)
+
+	backup.fail = true
+	_, err = fc.Get(ctx, 11)
+	require.Error(t, err)
+}
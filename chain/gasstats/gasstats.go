@@ -0,0 +1,327 @@
+// Package gasstats tracks how the gas used by the messages of recent tipsets
+// is distributed among the actors and methods they call, e.g. how much of
+// the block space goes to window PoSts compared to aggregated prove-commits
+// or plain transfers, for fee forecasting tools.
+//
+// Usage is computed from the receipts of each tipset as the chain head
+// changes, and kept in memory for a window of recent epochs.
+package gasstats
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	blockadt "github.com/filecoin-project/specs-actors/actors/util/adt"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("gasstats")
+
+// maxCachedCodes bounds the cache of recipient actor codes
+const maxCachedCodes = 100_000
+
+type methodKey struct {
+	code   cid.Cid
+	method abi.MethodNum
+}
+
+type methodUsage struct {
+	messages int64
+	gasUsed  int64
+	gasLimit int64
+}
+
+// tipsetUsage is the gas usage of the messages executed in a tipset, as
+// recorded in the receipts of its child
+type tipsetUsage struct {
+	// key and epoch of the child tipset holding the receipts
+	key   types.TipSetKey
+	epoch abi.ChainEpoch
+
+	height abi.ChainEpoch
+	blocks int
+	usage  map[methodKey]*methodUsage
+}
+
+// Tracker follows the chain and records the gas usage of the messages of
+// the last window epochs.
+type Tracker struct {
+	cs     *store.ChainStore
+	window abi.ChainEpoch
+
+	lk      sync.Mutex
+	tipsets []*tipsetUsage // by increasing height
+
+	codes map[address.Address]cid.Cid
+
+	notify chan struct{}
+}
+
+func New(cs *store.ChainStore, window abi.ChainEpoch) *Tracker {
+	return &Tracker{
+		cs:     cs,
+		window: window,
+		codes:  map[address.Address]cid.Cid{},
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// Run tracks gas usage as the head changes, until the context is done
+func (t *Tracker) Run(ctx context.Context) {
+	t.cs.SubscribeHeadChanges(func(_, _ []*types.TipSet) error {
+		if ctx.Err() != nil {
+			return store.ErrNotifeeDone
+		}
+		select {
+		case t.notify <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+
+	for {
+		if err := t.Update(ctx, t.cs.GetHeaviestTipSet()); err != nil {
+			log.Errorw("updating gas usage", "error", err)
+		}
+
+		select {
+		case <-t.notify:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Update records the usage of the tipsets up to head not tracked yet, and
+// drops the tipsets which were reverted or left the window.
+func (t *Tracker) Update(ctx context.Context, head *types.TipSet) error {
+	if head == nil {
+		return nil
+	}
+
+	t.lk.Lock()
+	known := make(map[types.TipSetKey]int, len(t.tipsets))
+	for i, tu := range t.tipsets {
+		known[tu.key] = i
+	}
+	t.lk.Unlock()
+
+	minEpoch := head.Height() - t.window
+
+	// walk back from the head to the last tracked tipset, only Run updates
+	// the tracked tipsets so they don't change in the meantime
+	fork := -1
+	var added []*tipsetUsage // newest first
+	for ts := head; ts.Height() > 0 && ts.Height() > minEpoch; {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if i, ok := known[ts.Key()]; ok {
+			fork = i
+			break
+		}
+
+		parent, err := t.cs.LoadTipSet(ts.Parents())
+		if err != nil {
+			return xerrors.Errorf("loading tipset: %w", err)
+		}
+
+		tu, err := t.tipsetUsage(ctx, parent, ts)
+		if err != nil {
+			// e.g. state pruned by the splitstore while backfilling
+			log.Warnw("computing gas usage, not going further back", "height", parent.Height(), "error", err)
+			break
+		}
+		added = append(added, tu)
+		ts = parent
+	}
+
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	// tracked tipsets past the fork were reverted; without a fork the added
+	// tipsets don't connect to the tracked ones
+	tracked := append([]*tipsetUsage{}, t.tipsets[:fork+1]...)
+	for i := len(added) - 1; i >= 0; i-- {
+		tracked = append(tracked, added[i])
+	}
+	for len(tracked) > 0 && tracked[0].epoch <= minEpoch {
+		tracked = tracked[1:]
+	}
+	t.tipsets = tracked
+
+	return nil
+}
+
+// tipsetUsage computes the usage of the messages of parent from the receipts
+// in ts.
+func (t *Tracker) tipsetUsage(ctx context.Context, parent, ts *types.TipSet) (*tipsetUsage, error) {
+	msgs, err := t.cs.MessagesForTipset(parent)
+	if err != nil {
+		return nil, xerrors.Errorf("loading messages: %w", err)
+	}
+
+	// block headers use adt0
+	receipts, err := blockadt.AsArray(t.cs.ActorStore(ctx), ts.Blocks()[0].ParentMessageReceipts)
+	if err != nil {
+		return nil, xerrors.Errorf("loading receipts: %w", err)
+	}
+
+	// recipients are looked up after execution, for actors created by the
+	// message, then before it, for actors deleted by it
+	var trees [2]*state.StateTree
+	roots := [2]cid.Cid{ts.ParentState(), parent.ParentState()}
+
+	tu := &tipsetUsage{
+		key:    ts.Key(),
+		epoch:  ts.Height(),
+		height: parent.Height(),
+		blocks: len(parent.Blocks()),
+		usage:  map[methodKey]*methodUsage{},
+	}
+	for i, cm := range msgs {
+		m := cm.VMMessage()
+
+		var r types.MessageReceipt
+		if found, err := receipts.Get(uint64(i), &r); err != nil {
+			return nil, xerrors.Errorf("loading receipt %d: %w", i, err)
+		} else if !found {
+			return nil, xerrors.Errorf("missing receipt %d", i)
+		}
+
+		code, ok := t.codes[m.To]
+		for j := 0; !ok && j < len(roots); j++ {
+			if trees[j] == nil {
+				if trees[j], err = state.LoadStateTree(t.cs.ActorStore(ctx), roots[j]); err != nil {
+					return nil, xerrors.Errorf("loading state tree: %w", err)
+				}
+			}
+
+			act, err := trees[j].GetActor(m.To)
+			if err != nil {
+				if xerrors.Is(err, types.ErrActorNotFound) {
+					continue
+				}
+				return nil, xerrors.Errorf("loading actor %s: %w", m.To, err)
+			}
+
+			code, ok = act.Code, true
+			if len(t.codes) >= maxCachedCodes {
+				t.codes = map[address.Address]cid.Cid{}
+			}
+			t.codes[m.To] = code
+		}
+
+		k := methodKey{code: code, method: m.Method}
+		mu, ok := tu.usage[k]
+		if !ok {
+			mu = &methodUsage{}
+			tu.usage[k] = mu
+		}
+		mu.messages++
+		mu.gasUsed += r.GasUsed
+		mu.gasLimit += m.GasLimit
+	}
+
+	return tu, nil
+}
+
+// Usage returns the gas usage over the last epochs tracked, or over the
+// whole window when epochs is 0.
+func (t *Tracker) Usage(epochs abi.ChainEpoch) (*api.GasUsage, error) {
+	if epochs < 0 {
+		return nil, xerrors.Errorf("negative number of epochs")
+	}
+
+	t.lk.Lock()
+	tracked := t.tipsets
+	t.lk.Unlock()
+
+	if len(tracked) == 0 {
+		return nil, xerrors.Errorf("no gas usage tracked yet")
+	}
+
+	return usage(tracked, epochs), nil
+}
+
+func usage(tracked []*tipsetUsage, epochs abi.ChainEpoch) *api.GasUsage {
+	out := &api.GasUsage{
+		From: tracked[0].height,
+		To:   tracked[len(tracked)-1].height,
+	}
+	if epochs > 0 && out.To-epochs+1 > out.From {
+		out.From = out.To - epochs + 1
+	}
+
+	total := map[methodKey]*methodUsage{}
+	for _, tu := range tracked {
+		if tu.height < out.From {
+			continue
+		}
+
+		out.Tipsets++
+		out.Blocks += tu.blocks
+		for k, mu := range tu.usage {
+			tot, ok := total[k]
+			if !ok {
+				tot = &methodUsage{}
+				total[k] = tot
+			}
+			tot.messages += mu.messages
+			tot.gasUsed += mu.gasUsed
+			tot.gasLimit += mu.gasLimit
+
+			out.Messages += mu.messages
+			out.GasUsed += mu.gasUsed
+			out.GasLimit += mu.gasLimit
+		}
+	}
+	out.BlockGasLimit = int64(out.Blocks) * build.BlockGasLimit
+
+	for k, mu := range total {
+		gm := api.GasUsageMethod{
+			ActorCode: k.code,
+			Actor:     "unknown",
+			Method:    k.method,
+			Messages:  mu.messages,
+			GasUsed:   mu.gasUsed,
+			GasLimit:  mu.gasLimit,
+		}
+		if k.code.Defined() {
+			gm.Actor = builtin.ActorNameByCode(k.code)
+		}
+		if meta, ok := stmgr.MethodsMap[k.code][k.method]; ok {
+			gm.MethodName = meta.Name
+		}
+		if out.GasUsed > 0 {
+			gm.GasUsedShare = float64(mu.gasUsed) / float64(out.GasUsed)
+		}
+		if out.BlockGasLimit > 0 {
+			gm.BlockSpaceShare = float64(mu.gasLimit) / float64(out.BlockGasLimit)
+		}
+		out.Methods = append(out.Methods, gm)
+	}
+
+	sort.Slice(out.Methods, func(i, j int) bool {
+		if out.Methods[i].GasUsed != out.Methods[j].GasUsed {
+			return out.Methods[i].GasUsed > out.Methods[j].GasUsed
+		}
+		return out.Methods[i].Actor < out.Methods[j].Actor || (out.Methods[i].Actor == out.Methods[j].Actor && out.Methods[i].Method < out.Methods[j].Method)
+	})
+
+	return out
+}
@@ -0,0 +1,55 @@
+package gasstats
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	builtin5 "github.com/filecoin-project/specs-actors/v5/actors/builtin"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+func TestUsage(t *testing.T) {
+	wpost := methodKey{code: builtin5.StorageMinerActorCodeID, method: builtin5.MethodsMiner.SubmitWindowedPoSt}
+	send := methodKey{code: builtin5.AccountActorCodeID, method: builtin5.MethodSend}
+	unknown := methodKey{code: cid.Undef, method: 5}
+
+	tracked := []*tipsetUsage{
+		{height: 10, blocks: 2, usage: map[methodKey]*methodUsage{
+			wpost: {messages: 2, gasUsed: 400, gasLimit: 500},
+			send:  {messages: 1, gasUsed: 100, gasLimit: 200},
+		}},
+		{height: 12, blocks: 1, usage: map[methodKey]*methodUsage{
+			send:    {messages: 3, gasUsed: 300, gasLimit: 600},
+			unknown: {messages: 1, gasUsed: 50, gasLimit: 50},
+		}},
+	}
+
+	gu := usage(tracked, 0)
+	require.Equal(t, abi.ChainEpoch(10), gu.From)
+	require.Equal(t, abi.ChainEpoch(12), gu.To)
+	require.Equal(t, 2, gu.Tipsets)
+	require.Equal(t, 3, gu.Blocks)
+	require.Equal(t, int64(7), gu.Messages)
+	require.Equal(t, int64(850), gu.GasUsed)
+	require.Equal(t, 3*build.BlockGasLimit, gu.BlockGasLimit)
+
+	require.Len(t, gu.Methods, 3)
+	require.Equal(t, "Send", gu.Methods[0].MethodName)
+	require.Equal(t, int64(400), gu.Methods[0].GasUsed)
+	require.Equal(t, "SubmitWindowedPoSt", gu.Methods[1].MethodName)
+	require.Equal(t, "fil/5/storageminer", gu.Methods[1].Actor)
+	require.InDelta(t, 400./850., gu.Methods[1].GasUsedShare, 1e-9)
+	require.InDelta(t, 500./float64(3*build.BlockGasLimit), gu.Methods[1].BlockSpaceShare, 1e-15)
+	require.Equal(t, "unknown", gu.Methods[2].Actor)
+
+	// only the last epochs
+	gu = usage(tracked, 1)
+	require.Equal(t, abi.ChainEpoch(12), gu.From)
+	require.Equal(t, 1, gu.Tipsets)
+	require.Equal(t, int64(350), gu.GasUsed)
+	require.Len(t, gu.Methods, 2)
+}
@@ -0,0 +1,334 @@
+package slashsvc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	cborutil "github.com/filecoin-project/go-cbor-util"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	builtin5 "github.com/filecoin-project/specs-actors/v5/actors/builtin"
+	miner5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/reward"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("slashsvc")
+
+// FaultsPrefix is the datastore namespace of detected consensus faults
+var FaultsPrefix = datastore.NewKey("/consensusfaults")
+
+// ReportAPI is used to submit ReportConsensusFault messages
+type ReportAPI interface {
+	GasEstimateMessageGas(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec, tsk types.TipSetKey) (*types.Message, error)
+	MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error)
+	WalletDefaultAddress(ctx context.Context) (address.Address, error)
+}
+
+type Config struct {
+	// AutoReport submits ReportConsensusFault messages for detected faults
+	// when the reporter reward exceeds the cost of the message
+	AutoReport bool
+
+	// From sends the reports; the wallet default address when undefined
+	From address.Address
+
+	MaxFee abi.TokenAmount
+}
+
+// Detector watches incoming blocks for blocks mined by the same miner at
+// the same height (double-fork mining consensus faults), and records the
+// block headers as evidence.
+type Detector struct {
+	cfg Config
+	api ReportAPI
+	sm  *stmgr.StateManager
+	ds  datastore.Batching
+
+	lk     sync.Mutex
+	seen   map[seenKey]*types.BlockHeader
+	pruned abi.ChainEpoch
+}
+
+type seenKey struct {
+	miner address.Address
+	epoch abi.ChainEpoch
+}
+
+func New(cfg Config, a ReportAPI, sm *stmgr.StateManager, ds datastore.Batching) *Detector {
+	return &Detector{
+		cfg:  cfg,
+		api:  a,
+		sm:   sm,
+		ds:   ds,
+		seen: map[seenKey]*types.BlockHeader{},
+	}
+}
+
+// Run processes incoming blocks until the context is done or the channel is
+// closed
+func (d *Detector) Run(ctx context.Context, blocks <-chan *types.BlockHeader) {
+	for {
+		var bh *types.BlockHeader
+		select {
+		case b, ok := <-blocks:
+			if !ok {
+				return
+			}
+			bh = b
+		case <-ctx.Done():
+			return
+		}
+
+		fault, err := d.observe(bh)
+		if err != nil {
+			log.Errorw("recording consensus fault", "miner", bh.Miner, "epoch", bh.Height, "error", err)
+			continue
+		}
+		if fault == nil {
+			continue
+		}
+
+		log.Errorw("detected consensus fault: multiple blocks mined at one epoch", "miner", fault.Miner, "epoch", fault.Epoch, "block1", fault.Block1.Cid(), "block2", fault.Block2.Cid())
+
+		if d.cfg.AutoReport {
+			go d.report(ctx, fault)
+		}
+	}
+}
+
+// Faults returns the detected consensus faults, ordered by epoch.
+func (d *Detector) Faults() ([]api.ConsensusFault, error) {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	res, err := d.ds.Query(query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying faults: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var out []api.ConsensusFault
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("iterating faults: %w", r.Error)
+		}
+
+		var f api.ConsensusFault
+		if err := json.Unmarshal(r.Value, &f); err != nil {
+			return nil, xerrors.Errorf("decoding fault %s: %w", r.Key, err)
+		}
+		out = append(out, f)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Epoch < out[j].Epoch
+	})
+	return out, nil
+}
+
+func (d *Detector) observe(bh *types.BlockHeader) (*api.ConsensusFault, error) {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	d.prune(bh.Height)
+
+	key := seenKey{miner: bh.Miner, epoch: bh.Height}
+	other, ok := d.seen[key]
+	if !ok {
+		d.seen[key] = bh
+		return nil, nil
+	}
+	if other.Cid() == bh.Cid() {
+		return nil, nil
+	}
+
+	has, err := d.ds.Has(faultKey(bh.Miner, bh.Height))
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		// already recorded with another pair of blocks
+		return nil, nil
+	}
+
+	fault := &api.ConsensusFault{
+		Miner:    bh.Miner,
+		Epoch:    bh.Height,
+		Block1:   other,
+		Block2:   bh,
+		Detected: build.Clock.Now(),
+	}
+	return fault, d.put(fault)
+}
+
+// prune drops blocks too old to report faults for
+func (d *Detector) prune(epoch abi.ChainEpoch) {
+	if epoch <= d.pruned {
+		return
+	}
+	d.pruned = epoch
+
+	for k := range d.seen {
+		if k.epoch < epoch-policy.ChainFinality {
+			delete(d.seen, k)
+		}
+	}
+}
+
+func (d *Detector) report(ctx context.Context, fault *api.ConsensusFault) {
+	mcid, err := d.submit(ctx, fault)
+
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	if err != nil {
+		log.Warnw("not reporting consensus fault", "miner", fault.Miner, "epoch", fault.Epoch, "error", err)
+		fault.ReportError = err.Error()
+	} else {
+		log.Infow("reported consensus fault", "miner", fault.Miner, "epoch", fault.Epoch, "message", mcid)
+		fault.Report = &mcid
+	}
+
+	if err := d.put(fault); err != nil {
+		log.Errorw("recording consensus fault report", "miner", fault.Miner, "epoch", fault.Epoch, "error", err)
+	}
+}
+
+func (d *Detector) submit(ctx context.Context, fault *api.ConsensusFault) (cid.Cid, error) {
+	// faults can only be reported once their epoch is in the chain
+	head, err := d.waitHead(ctx, fault.Epoch)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	act, err := d.sm.LoadActor(ctx, fault.Miner, head)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("loading miner actor: %w", err)
+	}
+	mas, err := miner.Load(d.sm.ChainStore().ActorStore(ctx), act)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("loading miner actor state: %w", err)
+	}
+	info, err := mas.Info()
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("getting miner info: %w", err)
+	}
+	if info.ConsensusFaultElapsed > head.Height() {
+		return cid.Undef, xerrors.Errorf("miner is already penalized for a consensus fault until epoch %d", info.ConsensusFaultElapsed)
+	}
+
+	bh1, err := cborutil.Dump(fault.Block1)
+	if err != nil {
+		return cid.Undef, err
+	}
+	bh2, err := cborutil.Dump(fault.Block2)
+	if err != nil {
+		return cid.Undef, err
+	}
+	params, err := actors.SerializeParams(&miner5.ReportConsensusFaultParams{
+		BlockHeader1: bh1,
+		BlockHeader2: bh2,
+	})
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	from := d.cfg.From
+	if from == address.Undef {
+		from, err = d.api.WalletDefaultAddress(ctx)
+		if err != nil {
+			return cid.Undef, xerrors.Errorf("getting default wallet address: %w", err)
+		}
+	}
+
+	msg := &types.Message{
+		To:     fault.Miner,
+		From:   from,
+		Value:  types.NewInt(0),
+		Method: builtin5.MethodsMiner.ReportConsensusFault,
+		Params: params,
+	}
+	spec := &api.MessageSendSpec{MaxFee: d.cfg.MaxFee}
+
+	emsg, err := d.api.GasEstimateMessageGas(ctx, msg, spec, head.Key())
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("estimating gas: %w", err)
+	}
+	cost := big.Mul(emsg.GasFeeCap, big.NewInt(emsg.GasLimit))
+
+	rew, err := d.reporterReward(ctx, head)
+	if err != nil {
+		return cid.Undef, err
+	}
+	if rew.LessThanEqual(cost) {
+		return cid.Undef, xerrors.Errorf("reporting isn't profitable: reward %s, max cost %s", types.FIL(rew), types.FIL(cost))
+	}
+
+	smsg, err := d.api.MpoolPushMessage(ctx, msg, spec)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("pushing message: %w", err)
+	}
+	return smsg.Cid(), nil
+}
+
+func (d *Detector) reporterReward(ctx context.Context, ts *types.TipSet) (abi.TokenAmount, error) {
+	ract, err := d.sm.LoadActor(ctx, reward.Address, ts)
+	if err != nil {
+		return big.Zero(), xerrors.Errorf("loading reward actor: %w", err)
+	}
+	rst, err := reward.Load(d.sm.ChainStore().ActorStore(ctx), ract)
+	if err != nil {
+		return big.Zero(), xerrors.Errorf("loading reward actor state: %w", err)
+	}
+	epochReward, err := rst.ThisEpochReward()
+	if err != nil {
+		return big.Zero(), err
+	}
+	return miner5.RewardForConsensusSlashReport(epochReward), nil
+}
+
+func (d *Detector) waitHead(ctx context.Context, epoch abi.ChainEpoch) (*types.TipSet, error) {
+	for {
+		head := d.sm.ChainStore().GetHeaviestTipSet()
+		if head.Height() > epoch {
+			return head, nil
+		}
+
+		select {
+		case <-build.Clock.After(time.Duration(build.BlockDelaySecs) * time.Second):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (d *Detector) put(fault *api.ConsensusFault) error {
+	b, err := json.Marshal(fault)
+	if err != nil {
+		return xerrors.Errorf("encoding fault: %w", err)
+	}
+	return d.ds.Put(faultKey(fault.Miner, fault.Epoch), b)
+}
+
+func faultKey(maddr address.Address, epoch abi.ChainEpoch) datastore.Key {
+	return datastore.NewKey(fmt.Sprintf("/%s/%d", maddr, epoch))
+}
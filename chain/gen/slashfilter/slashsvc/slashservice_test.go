@@ -0,0 +1,46 @@
+package slashsvc
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestDetectDoubleForkMining(t *testing.T) {
+	d := New(Config{}, nil, nil, dssync.MutexWrap(datastore.NewMapDatastore()))
+
+	b1 := mock.MkBlock(nil, 1, 1)
+	b2 := mock.MkBlock(nil, 1, 2)
+	b3 := mock.MkBlock(nil, 1, 3)
+
+	fault, err := d.observe(b1)
+	require.NoError(t, err)
+	require.Nil(t, fault)
+
+	// the same block again isn't a fault
+	fault, err = d.observe(b1)
+	require.NoError(t, err)
+	require.Nil(t, fault)
+
+	fault, err = d.observe(b2)
+	require.NoError(t, err)
+	require.NotNil(t, fault)
+	require.Equal(t, b1.Miner, fault.Miner)
+	require.Equal(t, b1.Height, fault.Epoch)
+	require.Equal(t, b1.Cid(), fault.Block1.Cid())
+	require.Equal(t, b2.Cid(), fault.Block2.Cid())
+
+	// only recorded once per miner and epoch
+	fault, err = d.observe(b3)
+	require.NoError(t, err)
+	require.Nil(t, fault)
+
+	faults, err := d.Faults()
+	require.NoError(t, err)
+	require.Len(t, faults, 1)
+	require.Equal(t, b2.Cid(), faults[0].Block2.Cid())
+}
@@ -85,6 +85,7 @@ const (
 	localMsgsDs = "/mpool/local"
 
 	localUpdates = "update"
+	localReorgs  = "reorg"
 )
 
 // Journal event types.
@@ -143,6 +144,9 @@ type MessagePool struct {
 	cfgLk sync.RWMutex
 	cfg   *types.MpoolConfig
 
+	// selPolicy customizes message selection for blocks, guarded by lk
+	selPolicy SelectionPolicy
+
 	api Provider
 
 	minGasPrice types.BigInt
@@ -1172,6 +1176,35 @@ func (mp *MessagePool) HeadChange(ctx context.Context, revert []*types.TipSet, a
 		}
 	}
 
+	isLocal := func(addr address.Address) bool {
+		mp.lk.Lock()
+		defer mp.lk.Unlock()
+
+		local, err := mp.isLocal(ctx, addr)
+		if err != nil {
+			log.Debugf("checking whether %s is local: %s", addr, err)
+			return false
+		}
+		return local
+	}
+
+	// local messages included in reverted tipsets, and the messages from the
+	// same senders included in applied tipsets, for reporting re-orgs
+	var reorged []api.ReorgedMessage
+	type inclusion struct {
+		cid cid.Cid
+		ts  *types.TipSet
+	}
+	included := make(map[address.Address]map[uint64]inclusion)
+	include := func(m *types.Message, mcid cid.Cid, ts *types.TipSet) {
+		s, ok := included[m.From]
+		if !ok {
+			s = make(map[uint64]inclusion)
+			included[m.From] = s
+		}
+		s[m.Nonce] = inclusion{cid: mcid, ts: ts}
+	}
+
 	var merr error
 
 	for _, ts := range revert {
@@ -1193,6 +1226,15 @@ func (mp *MessagePool) HeadChange(ctx context.Context, revert []*types.TipSet, a
 
 		for _, msg := range msgs {
 			add(msg)
+
+			if isLocal(msg.Message.From) {
+				m := msg.Message
+				reorged = append(reorged, api.ReorgedMessage{
+					Message:    &m,
+					Cid:        msg.Cid(),
+					RevertedIn: ts.Key(),
+				})
+			}
 		}
 	}
 
@@ -1211,11 +1253,13 @@ func (mp *MessagePool) HeadChange(ctx context.Context, revert []*types.TipSet, a
 			for _, msg := range smsgs {
 				rm(msg.Message.From, msg.Message.Nonce)
 				maybeRepub(msg.Cid())
+				include(&msg.Message, msg.Cid(), ts)
 			}
 
 			for _, msg := range bmsgs {
 				rm(msg.From, msg.Nonce)
 				maybeRepub(msg.Cid())
+				include(msg, msg.Cid(), ts)
 			}
 		}
 	}
@@ -1235,6 +1279,27 @@ func (mp *MessagePool) HeadChange(ctx context.Context, revert []*types.TipSet, a
 		}
 	}
 
+	if len(reorged) > 0 {
+		for i, r := range reorged {
+			if inc, ok := included[r.Message.From][r.Message.Nonce]; ok {
+				reorged[i].Reincluded = true
+				reorged[i].ReincludedCid = inc.cid
+				reorged[i].ReincludedIn = inc.ts.Key()
+			}
+		}
+
+		reorg := api.MpoolReorg{
+			Messages: reorged,
+		}
+		for _, ts := range revert {
+			reorg.Reverted = append(reorg.Reverted, ts.Key())
+		}
+		for _, ts := range apply {
+			reorg.Applied = append(reorg.Applied, ts.Key())
+		}
+		mp.changes.Pub(reorg, localReorgs)
+	}
+
 	if len(revert) > 0 && futureDebug {
 		mp.lk.Lock()
 		msgs, ts := mp.allPending(ctx)
@@ -1444,6 +1509,37 @@ func (mp *MessagePool) Updates(ctx context.Context) (<-chan api.MpoolUpdate, err
 	return out, nil
 }
 
+// Reorgs notifies about the messages from local addresses which were
+// included in tipsets reverted by re-orgs.
+func (mp *MessagePool) Reorgs(ctx context.Context) (<-chan api.MpoolReorg, error) {
+	out := make(chan api.MpoolReorg, 20)
+	sub := mp.changes.Sub(localReorgs)
+
+	go func() {
+		defer mp.changes.Unsub(sub, localReorgs)
+		defer close(out)
+
+		for {
+			select {
+			case r := <-sub:
+				select {
+				case out <- r.(api.MpoolReorg):
+				case <-ctx.Done():
+					return
+				case <-mp.closer:
+					return
+				}
+			case <-ctx.Done():
+				return
+			case <-mp.closer:
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
 func (mp *MessagePool) loadLocal(ctx context.Context) error {
 	res, err := mp.localMsgs.Query(query.Query{})
 	if err != nil {
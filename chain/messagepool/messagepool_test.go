@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
@@ -434,6 +435,141 @@ func TestRevertMessages(t *testing.T) {
 
 }
 
+func TestReorgNotifications(t *testing.T) {
+	tma := newTestMpoolAPI()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mp, err := New(tma, datastore.NewMapDatastore(), "mptest", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local, err := w.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := w.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tma.setBalance(local, 1)
+	tma.setBalance(remote, 1)
+
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+	m0 := makeTestMessage(w, local, remote, 0, gasLimit, 1)
+	m1 := makeTestMessage(w, local, remote, 1, gasLimit, 1)
+	r0 := makeTestMessage(w, remote, local, 0, gasLimit, 1)
+
+	for _, m := range []*types.SignedMessage{m0, m1} {
+		if _, err := mp.Push(context.TODO(), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustAdd(t, mp, r0)
+
+	a := tma.nextBlock()
+	tma.setBlockMessages(a, m0, m1, r0)
+	tma.applyBlock(t, a)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub, err := mp.Reorgs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// re-org to a block only including the first local message
+	b := tma.nextBlock()
+	tma.setBlockMessages(b, m0)
+	if err := tma.cb([]*types.TipSet{mock.TipSet(a)}, []*types.TipSet{mock.TipSet(b)}); err != nil {
+		t.Fatal(err)
+	}
+
+	var r api.MpoolReorg
+	select {
+	case r = <-sub:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no re-org notification")
+	}
+
+	assert.Equal(t, []types.TipSetKey{mock.TipSet(a).Key()}, r.Reverted)
+	assert.Equal(t, []types.TipSetKey{mock.TipSet(b).Key()}, r.Applied)
+	if !assert.Len(t, r.Messages, 2) {
+		return
+	}
+
+	sort.Slice(r.Messages, func(i, j int) bool {
+		return r.Messages[i].Message.Nonce < r.Messages[j].Message.Nonce
+	})
+
+	assert.Equal(t, m0.Cid(), r.Messages[0].Cid)
+	assert.True(t, r.Messages[0].Reincluded)
+	assert.Equal(t, m0.Cid(), r.Messages[0].ReincludedCid)
+	assert.Equal(t, mock.TipSet(b).Key(), r.Messages[0].ReincludedIn)
+
+	assert.Equal(t, m1.Cid(), r.Messages[1].Cid)
+	assert.Equal(t, mock.TipSet(a).Key(), r.Messages[1].RevertedIn)
+	assert.False(t, r.Messages[1].Reincluded)
+}
+
+func TestNonceGaps(t *testing.T) {
+	tma := newTestMpoolAPI()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mp, err := New(tma, datastore.NewMapDatastore(), "mptest", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local, err := w.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := w.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tma.setBalance(local, 1)
+
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+	for i := uint64(0); i < 5; i++ {
+		if _, err := mp.Push(context.TODO(), makeTestMessage(w, local, remote, i, gasLimit, 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reports, err := mp.NonceGaps(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range reports {
+		assert.Empty(t, r.Gaps)
+	}
+
+	mp.Remove(context.TODO(), local, 1, false)
+	mp.Remove(context.TODO(), local, 2, false)
+
+	reports, err = mp.NonceGaps(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, reports, 1) {
+		return
+	}
+	assert.Equal(t, local, reports[0].Address)
+	assert.Equal(t, uint64(0), reports[0].StateNonce)
+	assert.Equal(t, 3, reports[0].Pending)
+	assert.Equal(t, []api.NonceGap{{Start: 1, End: 3, Blocked: 2}}, reports[0].Gaps)
+}
+
 func TestPruningSimple(t *testing.T) {
 	oldMaxNonceGap := MaxNonceGap
 	MaxNonceGap = 1000
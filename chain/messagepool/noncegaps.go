@@ -0,0 +1,114 @@
+package messagepool
+
+import (
+	"context"
+	"sort"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// NonceGaps reports the local addresses with pending messages which can't be
+// included on chain, either because of missing nonces, or because the message
+// with the next nonce fails the checks required for its inclusion
+func (mp *MessagePool) NonceGaps(ctx context.Context) ([]api.NonceGapReport, error) {
+	mp.curTsLk.Lock()
+	curTs := mp.curTs
+
+	pending := map[address.Address][]*types.SignedMessage{}
+	mp.lk.Lock()
+	mp.forEachLocal(ctx, func(ctx context.Context, la address.Address) {
+		if msgs := mp.pendingFor(ctx, la); len(msgs) > 0 {
+			pending[la] = msgs
+		}
+	})
+	mp.lk.Unlock()
+	mp.curTsLk.Unlock()
+
+	var out []api.NonceGapReport
+	for addr, msgs := range pending {
+		stateNonce, err := mp.getStateNonce(ctx, addr, curTs)
+		if err != nil {
+			return nil, xerrors.Errorf("getting state nonce of %s: %w", addr, err)
+		}
+
+		rep := api.NonceGapReport{
+			Address:    addr,
+			StateNonce: stateNonce,
+			Pending:    len(msgs),
+			Gaps:       findNonceGaps(stateNonce, msgs),
+		}
+
+		if first := msgs[0]; first.Message.Nonce == stateNonce {
+			rep.Blocking, err = mp.blockingMessage(ctx, first)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rep.Gaps) == 0 && rep.Blocking == nil {
+			continue
+		}
+		out = append(out, rep)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Address.String() < out[j].Address.String()
+	})
+
+	return out, nil
+}
+
+// findNonceGaps lists the nonces missing from the pending messages, sorted by
+// nonce. Messages with nonces already used on chain are ignored.
+func findNonceGaps(stateNonce uint64, msgs []*types.SignedMessage) []api.NonceGap {
+	var gaps []api.NonceGap
+
+	next := stateNonce
+	for i, m := range msgs {
+		if m.Message.Nonce < next {
+			continue
+		}
+		if m.Message.Nonce > next {
+			gaps = append(gaps, api.NonceGap{
+				Start:   next,
+				End:     m.Message.Nonce,
+				Blocked: len(msgs) - i,
+			})
+		}
+		next = m.Message.Nonce + 1
+	}
+
+	return gaps
+}
+
+// blockingMessage explains why the message with the next nonce of its sender
+// can't be included, returns nil if nothing is wrong with it
+func (mp *MessagePool) blockingMessage(ctx context.Context, m *types.SignedMessage) (*api.BlockingMessage, error) {
+	checks, err := mp.checkMessages(ctx, []*types.Message{&m.Message}, true, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("checking message %s: %w", m.Cid(), err)
+	}
+
+	var reasons []string
+	for _, res := range checks {
+		for _, c := range res {
+			if !c.OK {
+				reasons = append(reasons, c.Err)
+			}
+		}
+	}
+	if len(reasons) == 0 {
+		return nil, nil
+	}
+
+	return &api.BlockingMessage{
+		Cid:     m.Cid(),
+		Nonce:   m.Message.Nonce,
+		Reasons: reasons,
+	}, nil
+}
@@ -79,6 +79,9 @@ func (mp *MessagePool) selectMessagesOptimal(ctx context.Context, curTs, ts *typ
 	if err != nil {
 		return nil, err
 	}
+	if err := mp.filterPending(ctx, ts, pending); err != nil {
+		return nil, xerrors.Errorf("filtering pending messages: %w", err)
+	}
 
 	if len(pending) == 0 {
 		return nil, nil
@@ -403,6 +406,9 @@ func (mp *MessagePool) selectMessagesGreedy(ctx context.Context, curTs, ts *type
 	if err != nil {
 		return nil, err
 	}
+	if err := mp.filterPending(ctx, ts, pending); err != nil {
+		return nil, xerrors.Errorf("filtering pending messages: %w", err)
+	}
 
 	if len(pending) == 0 {
 		return nil, nil
@@ -539,7 +545,7 @@ func (mp *MessagePool) selectPriorityMessages(ctx context.Context, pending map[a
 
 	// 1. Get priority actor chains
 	var chains []*msgChain
-	priority := mpCfg.PriorityAddrs
+	priority := mp.prioritySenders(ctx, ts, pending)
 	for _, actor := range priority {
 		pk, err := mp.resolveToKey(ctx, actor)
 		if err != nil {
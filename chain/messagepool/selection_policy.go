@@ -0,0 +1,160 @@
+package messagepool
+
+import (
+	"context"
+	"sort"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/chain/actors/builtin/market"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// SelectionPolicy customizes selecting messages for blocks. Messages of
+// priority senders are selected first, like messages of the PriorityAddrs in
+// the mpool config; the remaining messages are selected to maximize the gas
+// reward of the block.
+//
+// Policies are called with the pool locked, and mustn't call back into it.
+type SelectionPolicy interface {
+	// FilterPending may drop pending messages before selection. The nonce
+	// maps of senders can be shared with the pool, so they must be replaced
+	// instead of modified.
+	FilterPending(ctx context.Context, ts *types.TipSet, pending map[address.Address]map[uint64]*types.SignedMessage) error
+
+	// PrioritySenders returns the senders whose messages are selected first.
+	PrioritySenders(ctx context.Context, ts *types.TipSet, pending map[address.Address]map[uint64]*types.SignedMessage) ([]address.Address, error)
+}
+
+// SetSelectionPolicy sets the policy used to select messages for blocks; with
+// a nil policy, messages are only selected by gas reward.
+func (mp *MessagePool) SetSelectionPolicy(p SelectionPolicy) {
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	mp.selPolicy = p
+}
+
+func (mp *MessagePool) filterPending(ctx context.Context, ts *types.TipSet, pending map[address.Address]map[uint64]*types.SignedMessage) error {
+	if mp.selPolicy == nil {
+		return nil
+	}
+	return mp.selPolicy.FilterPending(ctx, ts, pending)
+}
+
+func (mp *MessagePool) prioritySenders(ctx context.Context, ts *types.TipSet, pending map[address.Address]map[uint64]*types.SignedMessage) []address.Address {
+	priority := mp.getConfig().PriorityAddrs
+	if mp.selPolicy == nil {
+		return priority
+	}
+
+	extra, err := mp.selPolicy.PrioritySenders(ctx, ts, pending)
+	if err != nil {
+		log.Warnw("selection policy failed to get priority senders", "error", err)
+		return priority
+	}
+	return append(append([]address.Address{}, priority...), extra...)
+}
+
+// SelectionPolicies applies multiple policies, in order.
+type SelectionPolicies []SelectionPolicy
+
+func (ps SelectionPolicies) FilterPending(ctx context.Context, ts *types.TipSet, pending map[address.Address]map[uint64]*types.SignedMessage) error {
+	for _, p := range ps {
+		if err := p.FilterPending(ctx, ts, pending); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ps SelectionPolicies) PrioritySenders(ctx context.Context, ts *types.TipSet, pending map[address.Address]map[uint64]*types.SignedMessage) ([]address.Address, error) {
+	var out []address.Address
+	for _, p := range ps {
+		senders, err := p.PrioritySenders(ctx, ts, pending)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, senders...)
+	}
+	return out, nil
+}
+
+// OwnMessagesPolicy prioritizes messages sent by the block producer, e.g. by
+// the worker and control addresses of the miner.
+type OwnMessagesPolicy struct {
+	Senders func(ctx context.Context, ts *types.TipSet) ([]address.Address, error)
+}
+
+func (p *OwnMessagesPolicy) FilterPending(context.Context, *types.TipSet, map[address.Address]map[uint64]*types.SignedMessage) error {
+	return nil
+}
+
+func (p *OwnMessagesPolicy) PrioritySenders(ctx context.Context, ts *types.TipSet, _ map[address.Address]map[uint64]*types.SignedMessage) ([]address.Address, error) {
+	senders, err := p.Senders(ctx, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("getting own senders: %w", err)
+	}
+	return senders, nil
+}
+
+// DealPublishPolicy prioritizes senders with pending PublishStorageDeals
+// messages, so that deals get on chain before their start epoch.
+type DealPublishPolicy struct{}
+
+func (DealPublishPolicy) FilterPending(context.Context, *types.TipSet, map[address.Address]map[uint64]*types.SignedMessage) error {
+	return nil
+}
+
+func (DealPublishPolicy) PrioritySenders(_ context.Context, _ *types.TipSet, pending map[address.Address]map[uint64]*types.SignedMessage) ([]address.Address, error) {
+	var out []address.Address
+	for sender, mset := range pending {
+		for _, m := range mset {
+			if m.Message.To == market.Address && m.Message.Method == market.Methods.PublishStorageDeals {
+				out = append(out, sender)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// SenderLimitPolicy selects at most Max messages, those with the lowest
+// nonces, of each sender into a block.
+type SenderLimitPolicy struct {
+	Max int
+}
+
+func (p SenderLimitPolicy) FilterPending(_ context.Context, _ *types.TipSet, pending map[address.Address]map[uint64]*types.SignedMessage) error {
+	for sender, mset := range pending {
+		if len(mset) <= p.Max {
+			continue
+		}
+
+		nonces := make([]uint64, 0, len(mset))
+		for nonce := range mset {
+			nonces = append(nonces, nonce)
+		}
+		sort.Slice(nonces, func(i, j int) bool {
+			return nonces[i] < nonces[j]
+		})
+
+		limited := make(map[uint64]*types.SignedMessage, p.Max)
+		for _, nonce := range nonces[:p.Max] {
+			limited[nonce] = mset[nonce]
+		}
+		pending[sender] = limited
+	}
+	return nil
+}
+
+func (p SenderLimitPolicy) PrioritySenders(context.Context, *types.TipSet, map[address.Address]map[uint64]*types.SignedMessage) ([]address.Address, error) {
+	return nil, nil
+}
+
+var _ SelectionPolicy = SelectionPolicies{}
+var _ SelectionPolicy = &OwnMessagesPolicy{}
+var _ SelectionPolicy = DealPublishPolicy{}
+var _ SelectionPolicy = SenderLimitPolicy{}
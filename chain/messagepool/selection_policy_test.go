@@ -0,0 +1,51 @@
+package messagepool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/chain/actors/builtin/market"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestSelectionPolicies(t *testing.T) {
+	a1, _ := address.NewIDAddress(1001)
+	a2, _ := address.NewIDAddress(1002)
+
+	pending := map[address.Address]map[uint64]*types.SignedMessage{
+		a1: {},
+		a2: {},
+	}
+	for i := uint64(0); i < 5; i++ {
+		pending[a1][i] = &types.SignedMessage{Message: types.Message{From: a1, Nonce: i}}
+	}
+	pending[a2][7] = &types.SignedMessage{Message: types.Message{
+		From:   a2,
+		To:     market.Address,
+		Method: market.Methods.PublishStorageDeals,
+		Nonce:  7,
+	}}
+	shared := pending[a1]
+
+	p := SelectionPolicies{DealPublishPolicy{}, SenderLimitPolicy{Max: 2}}
+	if err := p.FilterPending(context.TODO(), nil, pending); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pending[a1]) != 2 || pending[a1][0] == nil || pending[a1][1] == nil {
+		t.Fatalf("expected the two lowest nonces of a1, got %v", pending[a1])
+	}
+	if len(shared) != 5 {
+		t.Fatal("the nonce map of a1 was modified in place")
+	}
+
+	senders, err := p.PrioritySenders(context.TODO(), nil, pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(senders) != 1 || senders[0] != a2 {
+		t.Fatalf("expected a2 to be prioritized, got %v", senders)
+	}
+}
@@ -0,0 +1,193 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+// S3Config configures uploading snapshots to an S3-compatible endpoint.
+// Objects are addressed path-style, as <Endpoint>/<Bucket>/<Prefix><name>.
+type S3Config struct {
+	Endpoint string
+	Region   string
+	Bucket   string
+	Prefix   string
+
+	// AccessKey and SecretKey sign the requests with AWS signature v4; the
+	// requests are sent unsigned when they are empty
+	AccessKey string
+	SecretKey string
+}
+
+type s3Client struct {
+	cfg  S3Config
+	base *url.URL
+	http *http.Client
+}
+
+func newS3Client(cfg *S3Config) (*s3Client, error) {
+	base, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, xerrors.Errorf("parsing S3 endpoint: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, xerrors.Errorf("S3 endpoint must be an http(s) URL, got %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, xerrors.Errorf("S3 bucket not set")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	return &s3Client{
+		cfg:  *cfg,
+		base: base,
+		http: &http.Client{},
+	}, nil
+}
+
+func (c *s3Client) objectURL(name string) string {
+	u := *c.base
+	u.Path = u.Path + "/" + c.cfg.Bucket + "/" + c.cfg.Prefix + name
+	return u.String()
+}
+
+func (c *s3Client) put(ctx context.Context, name string, data []byte) error {
+	sum := sha256.Sum256(data)
+	return c.do(ctx, http.MethodPut, name, bytes.NewReader(data), int64(len(data)), hex.EncodeToString(sum[:]))
+}
+
+// putFile uploads the file at path; sum is the hex-encoded sha256 of the
+// file, computed here when empty.
+func (c *s3Client) putFile(ctx context.Context, name, path, sum string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck
+
+	if sum == "" {
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		sum = hex.EncodeToString(h.Sum(nil))
+	}
+
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, http.MethodPut, name, f, st.Size(), sum)
+}
+
+func (c *s3Client) delete(ctx context.Context, name string) error {
+	sum := sha256.Sum256(nil)
+	return c.do(ctx, http.MethodDelete, name, nil, 0, hex.EncodeToString(sum[:]))
+}
+
+func (c *s3Client) do(ctx context.Context, method, name string, body io.Reader, size int64, payloadHash string) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.objectURL(name), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if c.cfg.AccessKey != "" {
+		c.sign(req, payloadHash, build.Clock.Now())
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return xerrors.Errorf("%s %s: %w", method, name, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return xerrors.Errorf("%s %s: %s: %s", method, name, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// sign adds an AWS signature v4 Authorization header to the request, signing
+// the host, payload hash and date headers.
+func (c *s3Client) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	req.Header.Set("x-amz-date", amzDate)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.EscapedPath()),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + c.cfg.Region + "/s3/aws4_request"
+	crh := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(crh[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.cfg.SecretKey), date)
+	key = hmacSHA256(key, c.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.cfg.AccessKey, scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// uriEncode re-encodes an escaped path the way signature v4 expects: every
+// byte other than unreserved characters and '/' is percent-encoded.
+func uriEncode(escaped string) string {
+	s, err := url.PathUnescape(escaped)
+	if err != nil {
+		s = escaped
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case 'A' <= ch && ch <= 'Z', 'a' <= ch && ch <= 'z', '0' <= ch && ch <= '9',
+			ch == '-', ch == '_', ch == '.', ch == '~', ch == '/':
+			b.WriteByte(ch)
+		default:
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
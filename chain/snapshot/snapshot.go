@@ -0,0 +1,343 @@
+// Package snapshot periodically exports pruned chain snapshots, for other
+// nodes to bootstrap from.
+package snapshot
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("snapshot")
+
+const (
+	// lookback is how far behind the head snapshots are taken, so that they
+	// are unlikely to be on a fork
+	lookback = abi.ChainEpoch(10)
+
+	namePrefix = "snapshot-"
+	nameSuffix = ".car"
+	sumSuffix  = ".sha256"
+	tmpSuffix  = ".tmp"
+
+	// MetadataName is the name of the file describing the latest snapshot
+	MetadataName = "latest.json"
+)
+
+type Config struct {
+	// Path is the directory the snapshots are written to
+	Path string
+	// Interval is the time between snapshots
+	Interval time.Duration
+	// Retention is the number of snapshots kept; 0 keeps all of them
+	Retention int
+	// RecentStateroots is the number of epochs of state included
+	RecentStateroots abi.ChainEpoch
+	// PublicURL is the base URL the snapshot directory, or bucket, is served
+	// at; it is used to advertise where the latest snapshot can be fetched
+	PublicURL string
+
+	// S3, when set, is where snapshots are uploaded after being written
+	S3 *S3Config
+}
+
+// ExportFunc writes a snapshot of the chain at ts to w.
+type ExportFunc func(ctx context.Context, ts *types.TipSet, w io.Writer) error
+
+type Service struct {
+	cfg    Config
+	cs     *store.ChainStore
+	export ExportFunc
+	s3     *s3Client
+
+	lk     sync.Mutex
+	latest *api.SnapshotInfo
+}
+
+// New creates the snapshot directory, cleans up after interrupted snapshots
+// and loads the metadata of the latest snapshot taken.
+func New(cs *store.ChainStore, cfg Config) (*Service, error) {
+	s := &Service{
+		cfg: cfg,
+		cs:  cs,
+		export: func(ctx context.Context, ts *types.TipSet, w io.Writer) error {
+			return cs.Export(ctx, ts, cfg.RecentStateroots, true, w)
+		},
+	}
+	if cfg.S3 != nil {
+		c, err := newS3Client(cfg.S3)
+		if err != nil {
+			return nil, err
+		}
+		s.s3 = c
+	}
+
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Service) open() error {
+	if err := os.MkdirAll(s.cfg.Path, 0755); err != nil {
+		return xerrors.Errorf("creating snapshot directory: %w", err)
+	}
+
+	tmps, err := filepath.Glob(filepath.Join(s.cfg.Path, "*"+tmpSuffix))
+	if err != nil {
+		return err
+	}
+	for _, tmp := range tmps {
+		log.Infow("removing interrupted snapshot file", "path", tmp)
+		if err := os.Remove(tmp); err != nil {
+			return xerrors.Errorf("removing %s: %w", tmp, err)
+		}
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(s.cfg.Path, MetadataName))
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return xerrors.Errorf("reading snapshot metadata: %w", err)
+	}
+
+	var info api.SnapshotInfo
+	if err := json.Unmarshal(b, &info); err != nil {
+		return xerrors.Errorf("decoding snapshot metadata: %w", err)
+	}
+	s.latest = &info
+	return nil
+}
+
+// Latest returns the metadata of the latest snapshot, or nil if no snapshot
+// was taken yet.
+func (s *Service) Latest() *api.SnapshotInfo {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	return s.latest
+}
+
+// Run takes a snapshot every interval until the context is canceled. The
+// first snapshot is taken once an interval has passed since the latest one.
+func (s *Service) Run(ctx context.Context) {
+	wait := time.Duration(0)
+	if l := s.Latest(); l != nil {
+		wait = s.cfg.Interval - build.Clock.Since(l.Created)
+	}
+
+	for {
+		if wait > 0 {
+			select {
+			case <-build.Clock.After(wait):
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		start := build.Clock.Now()
+		info, err := s.Snapshot(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Errorf("taking chain snapshot: %s", err)
+		} else {
+			log.Infow("chain snapshot taken", "name", info.Name, "height", info.Height, "size", info.Size, "took", build.Clock.Since(start))
+		}
+
+		wait = s.cfg.Interval - build.Clock.Since(start)
+	}
+}
+
+// Snapshot exports a snapshot of the chain, a few epochs behind the head.
+func (s *Service) Snapshot(ctx context.Context) (*api.SnapshotInfo, error) {
+	head := s.cs.GetHeaviestTipSet()
+	if head == nil {
+		return nil, xerrors.Errorf("no chain head")
+	}
+
+	h := head.Height() - lookback
+	if h < 0 {
+		h = 0
+	}
+	ts, err := s.cs.GetTipsetByHeight(ctx, h, head, true)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset at %d: %w", h, err)
+	}
+
+	return s.snapshot(ctx, ts)
+}
+
+func (s *Service) snapshot(ctx context.Context, ts *types.TipSet) (*api.SnapshotInfo, error) {
+	name := fmt.Sprintf("%s%010d%s", namePrefix, ts.Height(), nameSuffix)
+	path := filepath.Join(s.cfg.Path, name)
+
+	h := sha256.New()
+	err := writeAtomic(path, func(w io.Writer) error {
+		bw := bufio.NewWriterSize(io.MultiWriter(w, h), 1<<20)
+		if err := s.export(ctx, ts, bw); err != nil {
+			return err
+		}
+		return bw.Flush()
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("exporting snapshot: %w", err)
+	}
+
+	st, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &api.SnapshotInfo{
+		Name:    name,
+		Height:  ts.Height(),
+		TipSet:  ts.Key(),
+		Size:    st.Size(),
+		SHA256:  hex.EncodeToString(h.Sum(nil)),
+		Created: build.Clock.Now(),
+		URL:     s.url(name),
+	}
+
+	// in the format of sha256sum, so that the download can be checked with `sha256sum -c`
+	sumPath := path + sumSuffix
+	err = writeAtomic(sumPath, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "%s  %s\n", info.SHA256, name)
+		return err
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("writing checksum: %w", err)
+	}
+
+	meta, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	// the metadata goes last, so that it never points at a missing snapshot
+	if s.s3 != nil {
+		if err := s.s3.putFile(ctx, name, path, info.SHA256); err != nil {
+			return nil, xerrors.Errorf("uploading snapshot: %w", err)
+		}
+		if err := s.s3.putFile(ctx, name+sumSuffix, sumPath, ""); err != nil {
+			return nil, xerrors.Errorf("uploading checksum: %w", err)
+		}
+		if err := s.s3.put(ctx, MetadataName, meta); err != nil {
+			return nil, xerrors.Errorf("uploading metadata: %w", err)
+		}
+	}
+
+	err = writeAtomic(filepath.Join(s.cfg.Path, MetadataName), func(w io.Writer) error {
+		_, err := w.Write(meta)
+		return err
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("writing metadata: %w", err)
+	}
+
+	s.lk.Lock()
+	s.latest = info
+	s.lk.Unlock()
+
+	if err := s.prune(ctx); err != nil {
+		log.Errorf("pruning old snapshots: %s", err)
+	}
+
+	return info, nil
+}
+
+func (s *Service) url(name string) string {
+	switch {
+	case s.cfg.PublicURL != "":
+		return strings.TrimSuffix(s.cfg.PublicURL, "/") + "/" + name
+	case s.s3 != nil:
+		return s.s3.objectURL(name)
+	default:
+		return ""
+	}
+}
+
+// prune removes the oldest snapshots beyond the retention.
+func (s *Service) prune(ctx context.Context) error {
+	if s.cfg.Retention <= 0 {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(s.cfg.Path, namePrefix+"*"+nameSuffix))
+	if err != nil {
+		return err
+	}
+	if len(paths) <= s.cfg.Retention {
+		return nil
+	}
+
+	// heights are zero-padded, so this sorts oldest first
+	sort.Strings(paths)
+	for _, path := range paths[:len(paths)-s.cfg.Retention] {
+		name := filepath.Base(path)
+		for _, n := range []string{name, name + sumSuffix} {
+			if err := os.Remove(filepath.Join(s.cfg.Path, n)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			if s.s3 != nil {
+				if err := s.s3.delete(ctx, n); err != nil {
+					return err
+				}
+			}
+		}
+		log.Infow("removed old snapshot", "name", name)
+	}
+
+	return nil
+}
+
+// writeAtomic writes a file through a temporary file, renamed into place
+// once synced to disk.
+func writeAtomic(path string, write func(w io.Writer) error) (err error) {
+	tmp := path + tmpSuffix
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	if err := write(f); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
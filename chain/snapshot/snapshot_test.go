@@ -0,0 +1,151 @@
+package snapshot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func testService(t *testing.T, cfg Config) *Service {
+	s := &Service{
+		cfg: cfg,
+		export: func(ctx context.Context, ts *types.TipSet, w io.Writer) error {
+			_, err := fmt.Fprintf(w, "snapshot at %d", ts.Height())
+			return err
+		},
+	}
+	if cfg.S3 != nil {
+		c, err := newS3Client(cfg.S3)
+		require.NoError(t, err)
+		s.s3 = c
+	}
+	require.NoError(t, s.open())
+	return s
+}
+
+func TestSnapshotRetention(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	s := testService(t, Config{Path: dir, Retention: 2, PublicURL: "https://snapshots.example.com/"})
+	require.Nil(t, s.Latest())
+
+	ts := mock.TipSet(mock.MkBlock(nil, 1, 1))
+	for i := 0; i < 3; i++ {
+		info, err := s.snapshot(ctx, ts)
+		require.NoError(t, err)
+
+		data := fmt.Sprintf("snapshot at %d", ts.Height())
+		sum := sha256.Sum256([]byte(data))
+		require.Equal(t, hex.EncodeToString(sum[:]), info.SHA256)
+		require.Equal(t, int64(len(data)), info.Size)
+		require.Equal(t, "https://snapshots.example.com/"+info.Name, info.URL)
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, info.Name+sumSuffix))
+		require.NoError(t, err)
+		require.Equal(t, info.SHA256+"  "+info.Name+"\n", string(b))
+
+		ts = mock.TipSet(mock.MkBlock(ts, 1, 1))
+	}
+
+	names, err := filepath.Glob(filepath.Join(dir, namePrefix+"*"))
+	require.NoError(t, err)
+	for i := range names {
+		names[i] = filepath.Base(names[i])
+	}
+	require.Equal(t, []string{
+		"snapshot-0000000001.car",
+		"snapshot-0000000001.car.sha256",
+		"snapshot-0000000002.car",
+		"snapshot-0000000002.car.sha256",
+	}, names)
+
+	// the latest snapshot is known after a restart, and leftovers of an
+	// interrupted snapshot are removed
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "snapshot-0000000003.car"+tmpSuffix), []byte("partial"), 0644))
+
+	s2 := testService(t, Config{Path: dir})
+	require.Equal(t, "snapshot-0000000002.car", s2.Latest().Name)
+	require.Equal(t, s.Latest().SHA256, s2.Latest().SHA256)
+
+	_, err = os.Stat(filepath.Join(dir, "snapshot-0000000003.car"+tmpSuffix))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestSnapshotS3(t *testing.T) {
+	var lk sync.Mutex
+	objects := map[string]string{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		lk.Lock()
+		defer lk.Unlock()
+
+		switch r.Method {
+		case http.MethodPut:
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			sum := sha256.Sum256(b)
+			if hex.EncodeToString(sum[:]) != r.Header.Get("x-amz-content-sha256") {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			objects[r.URL.Path] = string(b)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	s := testService(t, Config{
+		Path:      t.TempDir(),
+		Retention: 1,
+		S3: &S3Config{
+			Endpoint:  srv.URL,
+			Bucket:    "bucket",
+			Prefix:    "mainnet/",
+			AccessKey: "key",
+			SecretKey: "secret",
+		},
+	})
+
+	ts := mock.TipSet(mock.MkBlock(nil, 1, 1))
+	_, err := s.snapshot(context.Background(), ts)
+	require.NoError(t, err)
+
+	ts = mock.TipSet(mock.MkBlock(ts, 1, 1))
+	info, err := s.snapshot(context.Background(), ts)
+	require.NoError(t, err)
+	require.Equal(t, srv.URL+"/bucket/mainnet/snapshot-0000000001.car", info.URL)
+
+	lk.Lock()
+	defer lk.Unlock()
+
+	require.Len(t, objects, 3)
+	require.Equal(t, "snapshot at 1", objects["/bucket/mainnet/snapshot-0000000001.car"])
+	require.Contains(t, objects["/bucket/mainnet/snapshot-0000000001.car.sha256"], info.SHA256)
+	require.Contains(t, objects["/bucket/mainnet/"+MetadataName], info.SHA256)
+}
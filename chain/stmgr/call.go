@@ -24,6 +24,11 @@ func (sm *StateManager) Call(ctx context.Context, msg *types.Message, ts *types.
 	ctx, span := trace.StartSpan(ctx, "statemanager.Call")
 	defer span.End()
 
+	// the message is updated with the defaults and nonce it's executed with,
+	// leave the one of the caller alone
+	msgCopy := *msg
+	msg = &msgCopy
+
 	// If no tipset is provided, try to find one without a fork.
 	if ts == nil {
 		ts = sm.cs.GetHeaviestTipSet()
@@ -38,6 +43,20 @@ func (sm *StateManager) Call(ctx context.Context, msg *types.Message, ts *types.
 		}
 	}
 
+	if msg.GasLimit == 0 {
+		msg.GasLimit = build.BlockGasLimit
+	}
+	if msg.GasFeeCap == types.EmptyInt {
+		msg.GasFeeCap = types.NewInt(0)
+	}
+	if msg.GasPremium == types.EmptyInt {
+		msg.GasPremium = types.NewInt(0)
+	}
+
+	if msg.Value == types.EmptyInt {
+		msg.Value = types.NewInt(0)
+	}
+
 	bstate := ts.ParentState()
 	pts, err := sm.cs.LoadTipSet(ts.Parents())
 	if err != nil {
@@ -59,6 +78,17 @@ func (sm *StateManager) Call(ctx context.Context, msg *types.Message, ts *types.
 		return nil, fmt.Errorf("failed to handle fork: %w", err)
 	}
 
+	key := execKey(execInputs{
+		State:    bstate,
+		Epoch:    pheight + 1,
+		BaseFee:  types.NewInt(0),
+		Tipset:   ts.Key(),
+		Implicit: true,
+	}, nil, msg)
+	if res, ok := sm.execCache.get(key); ok {
+		return res, nil
+	}
+
 	vmopt := &vm.VMOpts{
 		StateBase:      bstate,
 		Epoch:          pheight + 1,
@@ -76,20 +106,6 @@ func (sm *StateManager) Call(ctx context.Context, msg *types.Message, ts *types.
 		return nil, xerrors.Errorf("failed to set up vm: %w", err)
 	}
 
-	if msg.GasLimit == 0 {
-		msg.GasLimit = build.BlockGasLimit
-	}
-	if msg.GasFeeCap == types.EmptyInt {
-		msg.GasFeeCap = types.NewInt(0)
-	}
-	if msg.GasPremium == types.EmptyInt {
-		msg.GasPremium = types.NewInt(0)
-	}
-
-	if msg.Value == types.EmptyInt {
-		msg.Value = types.NewInt(0)
-	}
-
 	if span.IsRecordingEvents() {
 		span.AddAttributes(
 			trace.Int64Attribute("gas_limit", msg.GasLimit),
@@ -117,21 +133,25 @@ func (sm *StateManager) Call(ctx context.Context, msg *types.Message, ts *types.
 		log.Warnf("chain call failed: %s", ret.ActorErr)
 	}
 
-	return &api.InvocResult{
+	res := &api.InvocResult{
 		MsgCid:         msg.Cid(),
 		Msg:            msg,
 		MsgRct:         &ret.MessageReceipt,
 		ExecutionTrace: ret.ExecutionTrace,
 		Error:          errs,
 		Duration:       ret.Duration,
-	}, nil
-
+	}
+	sm.execCache.put(key, res)
+	return res, nil
 }
 
 func (sm *StateManager) CallWithGas(ctx context.Context, msg *types.Message, priorMsgs []types.ChainMsg, ts *types.TipSet) (*api.InvocResult, error) {
 	ctx, span := trace.StartSpan(ctx, "statemanager.CallWithGas")
 	defer span.End()
 
+	msgCopy := *msg
+	msg = &msgCopy
+
 	if ts == nil {
 		ts = sm.cs.GetHeaviestTipSet()
 
@@ -164,6 +184,16 @@ func (sm *StateManager) CallWithGas(ctx context.Context, msg *types.Message, pri
 		return nil, fmt.Errorf("failed to handle fork: %w", err)
 	}
 
+	key := execKey(execInputs{
+		State:   state,
+		Epoch:   ts.Height() + 1,
+		BaseFee: ts.Blocks()[0].ParentBaseFee,
+		Tipset:  ts.Key(),
+	}, priorMsgs, msg)
+	if res, ok := sm.execCache.get(key); ok {
+		return res, nil
+	}
+
 	r := store.NewChainRand(sm.cs, ts.Cids())
 
 	if span.IsRecordingEvents() {
@@ -234,7 +264,7 @@ func (sm *StateManager) CallWithGas(ctx context.Context, msg *types.Message, pri
 		errs = ret.ActorErr.Error()
 	}
 
-	return &api.InvocResult{
+	res := &api.InvocResult{
 		MsgCid:         msg.Cid(),
 		Msg:            msg,
 		MsgRct:         &ret.MessageReceipt,
@@ -242,7 +272,9 @@ func (sm *StateManager) CallWithGas(ctx context.Context, msg *types.Message, pri
 		ExecutionTrace: ret.ExecutionTrace,
 		Error:          errs,
 		Duration:       ret.Duration,
-	}, nil
+	}
+	sm.execCache.put(key, res)
+	return res, nil
 }
 
 var errHaltExecution = fmt.Errorf("halt")
@@ -263,3 +295,24 @@ func (sm *StateManager) Replay(ctx context.Context, ts *types.TipSet, mcid cid.C
 
 	return finder.outm, finder.outr, nil
 }
+
+// ReplayDetailed replays a message like Replay, recording the steps of its
+// execution.
+func (sm *StateManager) ReplayDetailed(ctx context.Context, ts *types.TipSet, mcid cid.Cid) (*types.Message, *vm.ApplyRet, *api.ReplayTrace, error) {
+	var tracer replayTracer
+	tracer.mcid = mcid
+
+	_, _, err := sm.computeTipSetState(ctx, ts, &tracer)
+	if err != nil && !xerrors.Is(err, errHaltExecution) {
+		return nil, nil, nil, xerrors.Errorf("unexpected error during execution: %w", err)
+	}
+
+	if tracer.outr == nil {
+		return nil, nil, nil, xerrors.Errorf("given message not found in tipset")
+	}
+
+	return tracer.outm, tracer.outr, &api.ReplayTrace{
+		Steps:      tracer.steps,
+		GasByClass: tracer.gasByClass(),
+	}, nil
+}
@@ -0,0 +1,89 @@
+package stmgr
+
+import (
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// ExecCacheSize is the number of message executions kept by the state manager
+var ExecCacheSize = 256
+
+// execCache keeps the results of the explicit message executions done by the
+// state manager (e.g. for gas estimation or StateCall), so that executing the
+// same message again with the same inputs, as happens when a message is first
+// estimated and then estimated again before being pushed, doesn't have to run
+// the VM again.
+//
+// Entries are keyed by everything the execution depends on: the state the
+// message is applied on, the VM parameters, the messages applied before it and
+// the message itself, so that any two executions with the same key, whether
+// from Call or CallWithGas, are equivalent. The nonce of the message is read
+// from the state, so it isn't part of the key.
+//
+// In practice Call and CallWithGas don't share entries: Call applies messages
+// implicitly, without charging gas, on the parent state of the tipset. Block
+// validation doesn't use the cache either, as it applies messages on
+// intermediate states whose root isn't computed, and with the base fee of the
+// new tipset rather than the one estimation uses, so none of its executions
+// would be equivalent to a cached one.
+type execCache struct {
+	cache *lru.ARCCache
+}
+
+// execInputs are the inputs of a message execution besides the messages.
+type execInputs struct {
+	// State is the state the messages are applied on, after the forks at
+	// the epoch were handled
+	State    cid.Cid
+	Epoch    abi.ChainEpoch
+	BaseFee  abi.TokenAmount
+	Tipset   types.TipSetKey // randomness and lookback state
+	Implicit bool
+}
+
+func newExecCache(size int) *execCache {
+	cache, err := lru.NewARC(size)
+	if err != nil {
+		panic(err) // only errors for invalid sizes
+	}
+	return &execCache{cache: cache}
+}
+
+func execKey(in execInputs, priorMsgs []types.ChainMsg, msg *types.Message) string {
+	m := *msg
+	m.Nonce = 0
+
+	key := fmt.Sprintf("%s/%d/%s/%s/%t/", in.State, in.Epoch, in.BaseFee, in.Tipset, in.Implicit)
+	for _, pm := range priorMsgs {
+		key += pm.Cid().KeyString()
+	}
+	return key + m.Cid().KeyString()
+}
+
+// get returns a copy of the cached result for key. The message of the result
+// is the message as executed, with its nonce set from the state.
+func (c *execCache) get(key string) (*api.InvocResult, bool) {
+	v, ok := c.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	res := *v.(*api.InvocResult)
+	msg := *res.Msg
+	res.Msg = &msg
+	return &res, true
+}
+
+func (c *execCache) put(key string, res *api.InvocResult) {
+	cached := *res
+	msg := *res.Msg
+	cached.Msg = &msg
+	c.cache.Add(key, &cached)
+}
@@ -0,0 +1,61 @@
+package stmgr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestExecCache(t *testing.T) {
+	c := newExecCache(16)
+
+	ts := mock.TipSet(mock.MkBlock(nil, 1, 1))
+	in := execInputs{State: ts.ParentState(), Epoch: 2, BaseFee: types.NewInt(100), Tipset: ts.Key()}
+	msg := &types.Message{From: mock.Address(100), To: mock.Address(101), Method: 2, Value: types.NewInt(0), GasFeeCap: types.NewInt(0), GasPremium: types.NewInt(0)}
+
+	key := execKey(in, nil, msg)
+	_, ok := c.get(key)
+	require.False(t, ok)
+
+	// the execution sets the nonce from the state
+	executed := *msg
+	executed.Nonce = 5
+	c.put(key, &api.InvocResult{MsgCid: executed.Cid(), Msg: &executed, MsgRct: &types.MessageReceipt{GasUsed: 100}})
+
+	// the nonce of the message executed again doesn't matter, the result has
+	// the nonce it was executed with and the message isn't changed
+	again := &types.Message{From: mock.Address(100), To: mock.Address(101), Method: 2, Value: types.NewInt(0), GasFeeCap: types.NewInt(0), GasPremium: types.NewInt(0), Nonce: 1}
+	res, ok := c.get(execKey(in, nil, again))
+	require.True(t, ok)
+	require.EqualValues(t, 1, again.Nonce)
+	require.EqualValues(t, 5, res.Msg.Nonce)
+	require.Equal(t, executed.Cid(), res.MsgCid)
+	require.EqualValues(t, 100, res.MsgRct.GasUsed)
+
+	// results are copies
+	res.Msg.Nonce = 7
+	res, ok = c.get(key)
+	require.True(t, ok)
+	require.EqualValues(t, 5, res.Msg.Nonce)
+
+	// any execution input is part of the key
+	for _, other := range []execInputs{
+		{State: ts.ParentState(), Epoch: 3, BaseFee: in.BaseFee, Tipset: in.Tipset},
+		{State: ts.ParentState(), Epoch: 2, BaseFee: types.NewInt(0), Tipset: in.Tipset},
+		{State: ts.ParentState(), Epoch: 2, BaseFee: in.BaseFee, Tipset: in.Tipset, Implicit: true},
+		{State: ts.Cids()[0], Epoch: 2, BaseFee: in.BaseFee, Tipset: in.Tipset},
+		{State: ts.ParentState(), Epoch: 2, BaseFee: in.BaseFee, Tipset: mock.TipSet(mock.MkBlock(ts, 1, 2)).Key()},
+	} {
+		_, ok = c.get(execKey(other, nil, again))
+		require.False(t, ok)
+	}
+	_, ok = c.get(execKey(in, []types.ChainMsg{msg}, again))
+	require.False(t, ok)
+	again.Method = 3
+	_, ok = c.get(execKey(in, nil, again))
+	require.False(t, ok)
+}
@@ -105,6 +105,8 @@ type StateManager struct {
 	genesisMarketFunds abi.TokenAmount
 
 	tsExecMonitor ExecMonitor
+
+	execCache *execCache
 }
 
 // Caches a single state tree
@@ -169,7 +171,8 @@ func NewStateManagerWithUpgradeSchedule(cs *store.ChainStore, us UpgradeSchedule
 			root: cid.Undef,
 			tree: nil,
 		},
-		compWait: make(map[string]chan struct{}),
+		compWait:  make(map[string]chan struct{}),
+		execCache: newExecCache(ExecCacheSize),
 	}, nil
 }
 
@@ -309,6 +312,9 @@ func (sm *StateManager) ApplyBlocks(ctx context.Context, parentEpoch abi.ChainEp
 			BaseFee:        baseFee,
 			LookbackState:  LookbackStateGetterForTipset(sm, ts),
 		}
+		if t, ok := em.(vm.Tracer); ok {
+			vmopt.Tracer = t
+		}
 
 		return sm.newVM(ctx, vmopt)
 	}
@@ -1358,6 +1364,36 @@ func (sm *StateManager) GetVMCirculatingSupplyDetailed(ctx context.Context, heig
 	}, nil
 }
 
+// GetCirculatingSupplyBreakdown returns the same components as GetVMCirculatingSupplyDetailed,
+// with the locked funds split between pledge collateral and funds locked in deals.
+func (sm *StateManager) GetCirculatingSupplyBreakdown(ctx context.Context, height abi.ChainEpoch, st *state.StateTree) (*api.CirculatingSupplyBreakdown, error) {
+	cs, err := sm.GetVMCirculatingSupplyDetailed(ctx, height, st)
+	if err != nil {
+		return nil, err
+	}
+
+	filPowerLocked, err := getFilPowerLocked(ctx, st)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get filPowerLocked: %w", err)
+	}
+
+	filMarketLocked, err := getFilMarketLocked(ctx, st)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get filMarketLocked: %w", err)
+	}
+
+	return &api.CirculatingSupplyBreakdown{
+		Height:              height,
+		FilVested:           cs.FilVested,
+		FilMined:            cs.FilMined,
+		FilReserveDisbursed: cs.FilReserveDisbursed,
+		FilBurnt:            cs.FilBurnt,
+		FilLockedPledge:     filPowerLocked,
+		FilLockedDeals:      filMarketLocked,
+		FilCirculating:      cs.FilCirculating,
+	}, nil
+}
+
 func (sm *StateManager) GetCirculatingSupply(ctx context.Context, height abi.ChainEpoch, st *state.StateTree) (abi.TokenAmount, error) {
 	circ := big.Zero()
 	unCirc := big.Zero()
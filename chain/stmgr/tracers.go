@@ -2,8 +2,10 @@ package stmgr
 
 import (
 	"context"
+	"sort"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/aerrors"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/vm"
 	"github.com/ipfs/go-cid"
@@ -54,3 +56,88 @@ func (m *messageFinder) MessageApplied(ctx context.Context, ts *types.TipSet, mc
 	}
 	return nil
 }
+
+var _ ExecMonitor = (*replayTracer)(nil)
+var _ vm.Tracer = (*replayTracer)(nil)
+
+// replayTracer finds a message like messageFinder, recording the execution
+// steps of the message. Steps are discarded each time another message is
+// applied, so that only the steps of the message to find are kept.
+type replayTracer struct {
+	messageFinder
+
+	steps []api.ReplayStep
+	gas   map[string]*api.ReplayGasClass
+	// gas used by the message at the start of each call on the stack
+	calls []int64
+}
+
+func (t *replayTracer) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error {
+	if err := t.messageFinder.MessageApplied(ctx, ts, mcid, msg, ret, implicit); err != nil {
+		return err
+	}
+
+	t.steps = nil
+	t.gas = nil
+	t.calls = nil
+	return nil
+}
+
+func (t *replayTracer) OnCall(depth uint64, msg *types.Message, gasUsed int64) {
+	t.calls = append(t.calls, gasUsed)
+	t.steps = append(t.steps, api.ReplayStep{Op: "call", Depth: depth, Msg: msg})
+}
+
+func (t *replayTracer) OnReturn(depth uint64, rct *types.MessageReceipt, err aerrors.ActorError) {
+	step := api.ReplayStep{
+		Op:       "return",
+		Depth:    depth,
+		ExitCode: rct.ExitCode,
+		GasUsed:  rct.GasUsed,
+	}
+	if n := len(t.calls); n > 0 {
+		step.GasUsed -= t.calls[n-1]
+		t.calls = t.calls[:n-1]
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	t.steps = append(t.steps, step)
+}
+
+func (t *replayTracer) OnGas(depth uint64, gas vm.GasCharge) {
+	if t.gas == nil {
+		t.gas = map[string]*api.ReplayGasClass{}
+	}
+	gc, ok := t.gas[gas.Name]
+	if !ok {
+		gc = &api.ReplayGasClass{Name: gas.Name}
+		t.gas[gas.Name] = gc
+	}
+	gc.Count++
+	gc.TotalGas += gas.Total()
+	gc.ComputeGas += gas.ComputeGas
+	gc.StorageGas += gas.StorageGas
+}
+
+func (t *replayTracer) OnIpldGet(depth uint64, c cid.Cid, size int) {
+	t.steps = append(t.steps, api.ReplayStep{Op: "get", Depth: depth, Cid: c, Size: size})
+}
+
+func (t *replayTracer) OnIpldPut(depth uint64, c cid.Cid, size int) {
+	t.steps = append(t.steps, api.ReplayStep{Op: "put", Depth: depth, Cid: c, Size: size})
+}
+
+func (t *replayTracer) gasByClass() []api.ReplayGasClass {
+	out := make([]api.ReplayGasClass, 0, len(t.gas))
+	for _, gc := range t.gas {
+		out = append(out, *gc)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].TotalGas != out[j].TotalGas {
+			return out[i].TotalGas > out[j].TotalGas
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
@@ -0,0 +1,65 @@
+package stmgr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/exitcode"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+func TestReplayTracer(t *testing.T) {
+	ctx := context.Background()
+
+	other := &types.Message{Nonce: 1}
+	target := &types.Message{Nonce: 2}
+	sub := &types.Message{Nonce: 3}
+
+	var tr replayTracer
+	tr.mcid = target.Cid()
+
+	// steps of other messages are discarded
+	tr.OnCall(0, other, 0)
+	tr.OnIpldGet(0, other.Cid(), 10)
+	tr.OnGas(0, vm.GasCharge{Name: "OnIpldGet", ComputeGas: 100})
+	tr.OnReturn(0, &types.MessageReceipt{GasUsed: 100}, nil)
+	require.NoError(t, tr.MessageApplied(ctx, nil, other.Cid(), other, &vm.ApplyRet{}, false))
+	require.Empty(t, tr.steps)
+
+	tr.OnCall(0, target, 10)
+	tr.OnGas(0, vm.GasCharge{Name: "OnMethodInvocation", ComputeGas: 10})
+	tr.OnIpldGet(0, target.Cid(), 20)
+	tr.OnCall(1, sub, 30)
+	tr.OnGas(1, vm.GasCharge{Name: "OnIpldPut", ComputeGas: 5, StorageGas: 100})
+	tr.OnGas(1, vm.GasCharge{Name: "OnIpldPut", ComputeGas: 5, StorageGas: 100})
+	tr.OnIpldPut(1, sub.Cid(), 30)
+	tr.OnReturn(1, &types.MessageReceipt{ExitCode: exitcode.ErrForbidden, GasUsed: 240}, nil)
+	tr.OnReturn(0, &types.MessageReceipt{GasUsed: 250}, nil)
+
+	ret := &vm.ApplyRet{}
+	require.ErrorIs(t, tr.MessageApplied(ctx, nil, target.Cid(), target, ret, false), errHaltExecution)
+	require.Equal(t, ret, tr.outr)
+
+	ops := make([]string, 0, len(tr.steps))
+	for _, s := range tr.steps {
+		ops = append(ops, s.Op)
+	}
+	require.Equal(t, []string{"call", "get", "call", "put", "return", "return"}, ops)
+
+	// gas used by calls is relative to their start, and includes subcalls
+	require.EqualValues(t, 210, tr.steps[4].GasUsed)
+	require.Equal(t, exitcode.ErrForbidden, tr.steps[4].ExitCode)
+	require.EqualValues(t, 240, tr.steps[5].GasUsed)
+
+	gas := tr.gasByClass()
+	require.Len(t, gas, 2)
+	require.Equal(t, "OnIpldPut", gas[0].Name)
+	require.Equal(t, 2, gas[0].Count)
+	require.EqualValues(t, 210, gas[0].TotalGas)
+	require.EqualValues(t, 200, gas[0].StorageGas)
+	require.Equal(t, "OnMethodInvocation", gas[1].Name)
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 
 	exported5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/exported"
@@ -31,6 +32,7 @@ import (
 	exported4 "github.com/filecoin-project/specs-actors/v4/actors/builtin/exported"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors"
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	init_ "github.com/filecoin-project/lotus/chain/actors/builtin/init"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/market"
@@ -621,6 +623,106 @@ func GetParamType(actCode cid.Cid, method abi.MethodNum) (cbg.CBORUnmarshaler, e
 	return reflect.New(m.Params.Elem()).Interface().(cbg.CBORUnmarshaler), nil
 }
 
+// DecodeParams decodes the params of a method of the actor with the given
+// code, which also identifies the actors version
+func DecodeParams(actCode cid.Cid, method abi.MethodNum, params []byte) (cbg.CBORUnmarshaler, error) {
+	p, err := GetParamType(actCode, method)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.UnmarshalCBOR(bytes.NewReader(params)); err != nil {
+		return nil, xerrors.Errorf("decoding params of method %d: %w", method, err)
+	}
+	return p, nil
+}
+
+// DecodeReturn decodes the return value of a method of the actor with the
+// given code
+func DecodeReturn(actCode cid.Cid, method abi.MethodNum, ret []byte) (cbg.CBORUnmarshaler, error) {
+	m, found := MethodsMap[actCode][method]
+	if !found {
+		return nil, fmt.Errorf("unknown method %d for actor %s", method, actCode)
+	}
+	r := reflect.New(m.Ret.Elem()).Interface().(cbg.CBORUnmarshaler)
+	if err := r.UnmarshalCBOR(bytes.NewReader(ret)); err != nil {
+		return nil, xerrors.Errorf("decoding return of method %d: %w", method, err)
+	}
+	return r, nil
+}
+
+// BuiltinActorCodes returns the code CIDs of the builtin actors of an actors
+// version, by actor name, e.g. storageminer
+func BuiltinActorCodes(av actors.Version) (map[string]cid.Cid, error) {
+	var exp []rt.VMActor
+	switch av {
+	case actors.Version0:
+		exp = exported0.BuiltinActors()
+	case actors.Version2:
+		exp = exported2.BuiltinActors()
+	case actors.Version3:
+		exp = exported3.BuiltinActors()
+	case actors.Version4:
+		exp = exported4.BuiltinActors()
+	case actors.Version5:
+		exp = exported5.BuiltinActors()
+	default:
+		return nil, xerrors.Errorf("unknown actors version %d", av)
+	}
+
+	out := make(map[string]cid.Cid, len(exp))
+	for _, act := range exp {
+		// names are in the fil/<version>/<actor> form
+		name := builtin.ActorNameByCode(act.Code())
+		out[name[strings.LastIndexByte(name, '/')+1:]] = act.Code()
+	}
+	return out, nil
+}
+
+// ActorMethods returns the signatures of the methods of the actor with the
+// given code, by increasing method number
+func ActorMethods(actCode cid.Cid) ([]api.ActorMethod, error) {
+	methods, found := MethodsMap[actCode]
+	if !found {
+		return nil, xerrors.Errorf("unknown actor code %s", actCode)
+	}
+
+	out := make([]api.ActorMethod, 0, len(methods))
+	for num, m := range methods {
+		out = append(out, api.ActorMethod{
+			Num:          num,
+			Name:         m.Name,
+			Params:       m.Params.String(),
+			Return:       m.Ret.String(),
+			ParamsFields: typeFields(m.Params),
+			ReturnFields: typeFields(m.Ret),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Num < out[j].Num
+	})
+	return out, nil
+}
+
+// typeFields lists the fields of struct types, in their CBOR tuple order
+func typeFields(t reflect.Type) []api.ActorMethodField {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var out []api.ActorMethodField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		out = append(out, api.ActorMethodField{Name: f.Name, Type: f.Type.String()})
+	}
+	return out
+}
+
 func minerHasMinPower(ctx context.Context, sm *StateManager, addr address.Address, ts *types.TipSet) (bool, error) {
 	pact, err := sm.LoadActor(ctx, power.Address, ts)
 	if err != nil {
@@ -0,0 +1,50 @@
+package stmgr
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	builtin4 "github.com/filecoin-project/specs-actors/v4/actors/builtin"
+	builtin5 "github.com/filecoin-project/specs-actors/v5/actors/builtin"
+
+	"github.com/filecoin-project/lotus/chain/actors"
+)
+
+func TestActorMethods(t *testing.T) {
+	codes, err := BuiltinActorCodes(actors.Version5)
+	require.NoError(t, err)
+	require.Equal(t, builtin5.StorageMinerActorCodeID, codes["storageminer"])
+	require.Equal(t, builtin5.AccountActorCodeID, codes["account"])
+
+	codes, err = BuiltinActorCodes(actors.Version4)
+	require.NoError(t, err)
+	require.Equal(t, builtin4.StorageMinerActorCodeID, codes["storageminer"])
+
+	_, err = BuiltinActorCodes(actors.Version(1))
+	require.Error(t, err)
+
+	methods, err := ActorMethods(builtin5.StorageMinerActorCodeID)
+	require.NoError(t, err)
+	require.Equal(t, abi.MethodNum(0), methods[0].Num)
+	require.Equal(t, "Send", methods[0].Name)
+	require.Empty(t, methods[0].ParamsFields)
+
+	var found bool
+	for _, m := range methods {
+		if m.Num != builtin5.MethodsMiner.SubmitWindowedPoSt {
+			continue
+		}
+		found = true
+		require.Equal(t, "SubmitWindowedPoSt", m.Name)
+		require.Equal(t, "*miner.SubmitWindowedPoStParams", m.Params)
+		require.Equal(t, "Deadline", m.ParamsFields[0].Name)
+		require.Equal(t, "uint64", m.ParamsFields[0].Type)
+	}
+	require.True(t, found)
+
+	_, err = ActorMethods(cid.Undef)
+	require.Error(t, err)
+}
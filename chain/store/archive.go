@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/blockstore/archive"
+	"github.com/filecoin-project/lotus/build"
+)
+
+const archiveDeleteBatch = 4096
+
+// ArchiveChain moves the chain data of the tipsets below boundary, down to
+// the highest epoch already archived, into a new archive, and then deletes it
+// from bs. That is their block headers, messages, receipts and parent state
+// trees, but for the blocks also reachable from the state of the boundary
+// tipset, which stay in bs for the state of the recent chain. State trees
+// missing from the chain store, e.g. below an imported snapshot, are skipped.
+// The genesis block and its state are never archived.
+//
+// bs must fall back to the archives for reads, so that the archived chain
+// and states can still be loaded through the chain store.
+func (cs *ChainStore) ArchiveChain(ctx context.Context, as *archive.Store, boundary abi.ChainEpoch, bs blockstore.Blockstore) (*archive.Info, error) {
+	last := as.MaxEpoch()
+	if boundary <= last+1 {
+		return nil, nil
+	}
+
+	kept, err := cs.GetTipsetByHeight(ctx, boundary, cs.GetHeaviestTipSet(), true)
+	if err != nil {
+		return nil, xerrors.Errorf("getting boundary tipset: %w", err)
+	}
+	ts, err := cs.LoadTipSet(kept.Parents())
+	if err != nil {
+		return nil, xerrors.Errorf("loading parent of boundary tipset: %w", err)
+	}
+	if ts.Height() <= last || ts.Height() == 0 {
+		return nil, nil
+	}
+
+	log.Infow("archiving chain", "from", last+1, "to", ts.Height())
+	start := build.Clock.Now()
+
+	// blocks of the states kept in bs are marked as walked first, so that
+	// they aren't archived with the older states sharing them
+	walked := cid.NewSet()
+	genesis, err := cs.GetGenesis()
+	if err != nil {
+		return nil, xerrors.Errorf("getting genesis: %w", err)
+	}
+	for _, root := range []cid.Cid{kept.ParentState(), genesis.ParentStateRoot} {
+		if !walked.Visit(root) {
+			continue
+		}
+		has, err := cs.stateBlockstore.Has(root)
+		if err != nil {
+			return nil, xerrors.Errorf("checking for state %s: %w", root, err)
+		}
+		if !has {
+			continue
+		}
+		if _, err := recurseLinks(cs.stateBlockstore, walked, root, nil); err != nil {
+			return nil, xerrors.Errorf("walking kept state %s: %w", root, err)
+		}
+	}
+
+	w, err := as.NewWriter(ts.Cids())
+	if err != nil {
+		return nil, err
+	}
+
+	var archived []cid.Cid
+	put := func(src blockstore.Blockstore, c cid.Cid) error {
+		// state shared with older archives is only deleted
+		has, err := as.Has(c)
+		if err != nil {
+			return err
+		}
+		if has {
+			archived = append(archived, c)
+			return nil
+		}
+
+		blk, err := src.Get(c)
+		if err != nil {
+			return xerrors.Errorf("getting %s: %w", c, err)
+		}
+		archived = append(archived, c)
+		return w.Put(c, blk.RawData())
+	}
+	walk := func(src blockstore.Blockstore, root cid.Cid) error {
+		cids, err := recurseLinks(src, walked, root, []cid.Cid{root})
+		if err != nil {
+			return xerrors.Errorf("walking links of %s: %w", root, err)
+		}
+		for _, c := range cids {
+			if err := put(src, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	to := ts.Height()
+	from := to
+	for ts.Height() > last && ts.Height() > 0 {
+		if err := ctx.Err(); err != nil {
+			w.Abort()
+			return nil, err
+		}
+
+		for _, h := range ts.Blocks() {
+			if err := put(cs.chainBlockstore, h.Cid()); err != nil {
+				w.Abort()
+				return nil, err
+			}
+
+			for _, root := range []cid.Cid{h.Messages, h.ParentMessageReceipts} {
+				if !walked.Visit(root) {
+					continue
+				}
+				if err := walk(cs.chainBlockstore, root); err != nil {
+					w.Abort()
+					return nil, err
+				}
+			}
+
+			if !walked.Visit(h.ParentStateRoot) {
+				continue
+			}
+			has, err := cs.stateBlockstore.Has(h.ParentStateRoot)
+			if err != nil {
+				w.Abort()
+				return nil, xerrors.Errorf("checking for state %s: %w", h.ParentStateRoot, err)
+			}
+			if !has {
+				continue
+			}
+			if err := walk(cs.stateBlockstore, h.ParentStateRoot); err != nil {
+				w.Abort()
+				return nil, err
+			}
+		}
+
+		from = ts.Height()
+		if ts, err = cs.LoadTipSet(ts.Parents()); err != nil {
+			w.Abort()
+			return nil, xerrors.Errorf("loading parent tipset: %w", err)
+		}
+	}
+
+	info, err := w.Commit(from, to)
+	if err != nil {
+		return nil, xerrors.Errorf("committing archive: %w", err)
+	}
+
+	for len(archived) > 0 {
+		n := archiveDeleteBatch
+		if n > len(archived) {
+			n = len(archived)
+		}
+		if err := bs.DeleteMany(archived[:n]); err != nil {
+			return &info, xerrors.Errorf("deleting archived blocks: %w", err)
+		}
+		archived = archived[n:]
+	}
+
+	log.Infow("archived chain", "from", from, "to", to, "blocks", info.Blocks, "size", info.Size, "took", build.Clock.Since(start))
+	return &info, nil
+}
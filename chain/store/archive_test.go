@@ -0,0 +1,131 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	datastore "github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/blockstore/archive"
+	"github.com/filecoin-project/lotus/chain/gen"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// requireLinksIn checks that root and the blocks it links to are all in bs
+func requireLinksIn(t *testing.T, bs blockstore.Blockstore, root cid.Cid, seen *cid.Set) {
+	if root.Prefix().Codec != cid.DagCBOR || !seen.Visit(root) {
+		return
+	}
+
+	blk, err := bs.Get(root)
+	require.NoError(t, err, root)
+	require.NoError(t, cbg.ScanForLinks(bytes.NewReader(blk.RawData()), func(c cid.Cid) {
+		requireLinksIn(t, bs, c, seen)
+	}))
+}
+
+func TestArchiveChain(t *testing.T) {
+	ctx := context.Background()
+
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	var last *types.TipSet
+	for i := 0; i < 60; i++ {
+		ts, err := cg.NextTipSet()
+		require.NoError(t, err)
+		last = ts.TipSet.TipSet()
+	}
+
+	// a chain store with the full chain and all states, falling back to the
+	// archives
+	buf := new(bytes.Buffer)
+	require.NoError(t, cg.ChainStore().Export(ctx, last, last.Height(), false, buf))
+
+	as, err := archive.Open(t.TempDir())
+	require.NoError(t, err)
+	defer as.Close() //nolint:errcheck
+
+	hot := blockstore.NewMemory()
+	bs := archive.Wrap(hot, as)
+	cs := store.NewChainStore(bs, bs, datastore.NewMapDatastore(), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	head, err := cs.Import(buf)
+	require.NoError(t, err)
+	genesis, err := cs.GetTipsetByHeight(ctx, 0, head, true)
+	require.NoError(t, err)
+	require.NoError(t, cs.SetGenesis(genesis.Blocks()[0]))
+	require.NoError(t, cs.SetHead(head))
+
+	// the generated chain may have null rounds
+	boundaryAt := func(h abi.ChainEpoch) (kept, top *types.TipSet) {
+		kept, err := cs.GetTipsetByHeight(ctx, h, head, true)
+		require.NoError(t, err)
+		top, err = cs.LoadTipSet(kept.Parents())
+		require.NoError(t, err)
+		return kept, top
+	}
+	first, err := cs.GetTipsetByHeight(ctx, 1, head, false)
+	require.NoError(t, err)
+	kept, top := boundaryAt(30)
+
+	hotSize := len(hot)
+
+	info, err := cs.ArchiveChain(ctx, as, 30, bs)
+	require.NoError(t, err)
+	require.NotNil(t, info)
+	require.Equal(t, first.Height(), info.From)
+	require.Equal(t, top.Height(), info.To)
+	require.Less(t, len(hot), hotSize)
+
+	for h := info.From; h <= info.To; h++ {
+		ts, err := cs.GetTipsetByHeight(ctx, h, head, true)
+		require.NoError(t, err)
+		for _, b := range ts.Blocks() {
+			has, err := hot.Has(b.Cid())
+			require.NoError(t, err)
+			require.False(t, has, "header at %d still in the blockstore", h)
+
+			has, err = hot.Has(b.Messages)
+			require.NoError(t, err)
+			require.False(t, has, "messages at %d still in the blockstore", h)
+		}
+	}
+
+	// the state of the boundary tipset, and the genesis block and state, are
+	// left in place
+	requireLinksIn(t, hot, kept.ParentState(), cid.NewSet())
+	requireLinksIn(t, hot, genesis.ParentState(), cid.NewSet())
+	has, err := hot.Has(genesis.Blocks()[0].Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+
+	// the whole chain, with all states, is still readable
+	require.NoError(t, cs.Export(ctx, head, head.Height(), false, ioutil.Discard))
+
+	// nothing new to archive
+	info, err = cs.ArchiveChain(ctx, as, 30, bs)
+	require.NoError(t, err)
+	require.Nil(t, info)
+
+	// the next archive picks up where the last one stopped
+	_, top = boundaryAt(45)
+	info, err = cs.ArchiveChain(ctx, as, 45, bs)
+	require.NoError(t, err)
+	require.NotNil(t, info)
+	require.Equal(t, kept.Height(), info.From)
+	require.Equal(t, top.Height(), info.To)
+	require.Len(t, as.Archives(), 2)
+
+	require.NoError(t, cs.Export(ctx, head, head.Height(), false, ioutil.Discard))
+}
@@ -0,0 +1,258 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-car"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+const snapshotImportBatch = 4096
+
+// SnapshotImport streams a chain snapshot into the chain store while keeping
+// track of the height ranges whose headers and state are already imported.
+//
+// Snapshots are written by WalkSnapshot, which walks block headers from the
+// head back to genesis, emitting the messages and state of each header before
+// moving to the next one. This means that once a header at some height is
+// read, everything above it is imported, which allows the node to start
+// operating from the head while older history is still being imported.
+type SnapshotImport struct {
+	cs   *ChainStore
+	r    *countingReader
+	cr   *car.CarReader
+	root types.TipSetKey
+
+	rootHeight abi.ChainEpoch
+
+	// header cids we expect to read
+	headers map[cid.Cid]struct{}
+
+	// state root of the last header read, which WalkSnapshot emits before
+	// the next header if it's included in the snapshot
+	stateRoot    cid.Cid
+	stateHeight  abi.ChainEpoch
+	pendingState abi.ChainEpoch
+	lastState    cid.Cid
+
+	lk       sync.Mutex
+	progress api.SnapshotImportProgress
+}
+
+// NewSnapshotImport reads the header of the snapshot CAR in r. size is the
+// length of the snapshot, if known.
+func (cs *ChainStore) NewSnapshotImport(r io.Reader, size int64) (*SnapshotImport, error) {
+	cr := &countingReader{r: r}
+
+	rd, err := car.NewCarReader(cr)
+	if err != nil {
+		return nil, xerrors.Errorf("reading snapshot car header: %w", err)
+	}
+
+	si := &SnapshotImport{
+		cs:      cs,
+		r:       cr,
+		cr:      rd,
+		root:    types.NewTipSetKey(rd.Header.Roots...),
+		headers: map[cid.Cid]struct{}{},
+
+		pendingState: -1,
+	}
+	for _, c := range rd.Header.Roots {
+		si.headers[c] = struct{}{}
+	}
+
+	si.progress = api.SnapshotImportProgress{
+		Root:       si.root,
+		TotalBytes: size,
+		Headers:    api.EpochRange{From: -1, To: -1},
+		State:      api.EpochRange{From: -1, To: -1},
+	}
+
+	return si, nil
+}
+
+// Root returns the key of the tipset the snapshot was taken at
+func (si *SnapshotImport) Root() types.TipSetKey {
+	return si.root
+}
+
+// Import imports the snapshot until the headers and the state of the tipsets
+// less than lookback epochs below the snapshot root are imported, or until
+// the end of the snapshot if lookback is negative. Import can be called again
+// to continue importing the snapshot.
+func (si *SnapshotImport) Import(ctx context.Context, lookback abi.ChainEpoch) error {
+	err := si.importUntil(ctx, lookback)
+	if err != nil {
+		si.lk.Lock()
+		si.progress.Error = err.Error()
+		si.lk.Unlock()
+	}
+	return err
+}
+
+func (si *SnapshotImport) importUntil(ctx context.Context, lookback abi.ChainEpoch) error {
+	batch := make([]blocks.Block, 0, snapshotImportBatch)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := si.cs.StateBlockstore().PutMany(batch); err != nil {
+			return xerrors.Errorf("putting blocks: %w", err)
+		}
+		si.lk.Lock()
+		si.progress.Blocks += int64(len(batch))
+		si.progress.BytesRead = si.r.n
+		si.lk.Unlock()
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		blk, err := si.cr.Next()
+		if err == io.EOF {
+			if err := flush(); err != nil {
+				return err
+			}
+			si.finish()
+			return nil
+		}
+		if err != nil {
+			return xerrors.Errorf("reading snapshot: %w", err)
+		}
+
+		if blk.Cid() == si.stateRoot {
+			// the state is complete once the next header is read
+			si.pendingState = si.stateHeight
+			si.lastState = si.stateRoot
+			si.stateRoot = cid.Undef
+		}
+
+		if _, ok := si.headers[blk.Cid()]; !ok {
+			batch = append(batch, blk)
+			if len(batch) >= snapshotImportBatch {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		delete(si.headers, blk.Cid())
+
+		var bh types.BlockHeader
+		if err := bh.UnmarshalCBOR(bytes.NewReader(blk.RawData())); err != nil {
+			return xerrors.Errorf("decoding block header %s: %w", blk.Cid(), err)
+		}
+
+		// everything read before this header is complete, make it
+		// available before reporting it
+		batch = append(batch, blk)
+		if err := flush(); err != nil {
+			return err
+		}
+
+		si.commitState()
+		if err := si.readHeader(&bh); err != nil {
+			return err
+		}
+
+		if lookback >= 0 && bh.Height <= si.rootHeight-lookback {
+			return nil
+		}
+	}
+}
+
+func (si *SnapshotImport) readHeader(bh *types.BlockHeader) error {
+	if bh.Height == 0 {
+		gen, err := si.cs.GetGenesis()
+		if err == nil && gen.Cid() != bh.Cid() {
+			return xerrors.Errorf("snapshot genesis %s doesn't match the genesis of the node %s", bh.Cid(), gen.Cid())
+		}
+	}
+
+	if bh.Height > 0 {
+		// the parents of genesis aren't headers, and the genesis state is
+		// always available
+		for _, p := range bh.Parents {
+			si.headers[p] = struct{}{}
+		}
+		si.stateRoot = bh.ParentStateRoot
+		si.stateHeight = bh.Height
+
+		if bh.ParentStateRoot == si.lastState {
+			// the state didn't change, so it isn't in the snapshot again
+			si.pendingState = bh.Height
+		}
+	}
+
+	si.lk.Lock()
+	defer si.lk.Unlock()
+
+	if si.progress.Headers.To < 0 {
+		si.rootHeight = bh.Height
+		si.progress.Headers.To = bh.Height
+	}
+	si.progress.Headers.From = bh.Height
+	return nil
+}
+
+// commitState marks the state root read since the last header as complete
+func (si *SnapshotImport) commitState() {
+	if si.pendingState < 0 {
+		return
+	}
+
+	si.lk.Lock()
+	defer si.lk.Unlock()
+
+	if si.progress.State.To < 0 {
+		si.progress.State.To = si.pendingState
+	}
+	if si.progress.State.From < 0 || si.pendingState < si.progress.State.From {
+		si.progress.State.From = si.pendingState
+	}
+	si.pendingState = -1
+}
+
+func (si *SnapshotImport) finish() {
+	si.commitState()
+
+	si.lk.Lock()
+	defer si.lk.Unlock()
+
+	si.progress.BytesRead = si.r.n
+	si.progress.Done = true
+}
+
+// Progress returns the current progress of the import
+func (si *SnapshotImport) Progress() api.SnapshotImportProgress {
+	si.lk.Lock()
+	defer si.lk.Unlock()
+
+	return si.progress
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
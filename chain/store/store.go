@@ -31,6 +31,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/vm"
 	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/lib/subscription"
 	"github.com/filecoin-project/lotus/metrics"
 
 	"go.opencensus.io/stats"
@@ -338,6 +339,106 @@ func (cs *ChainStore) SubHeadChanges(ctx context.Context) chan []*api.HeadChange
 	return out
 }
 
+// SubHeadChangesWithPolicy is like SubHeadChanges, but never stalls the
+// publisher of head changes when the subscriber doesn't keep up; instead, the
+// policy decides what happens to the notifications buffered for it.
+//
+// Coalesced notifications are replaced by a single 'current' head change,
+// just like the first notification of a subscription, so subscribers can
+// resynchronise from it.
+func (cs *ChainStore) SubHeadChangesWithPolicy(ctx context.Context, p subscription.Policy) chan []*api.HeadChange {
+	cs.pubLk.Lock()
+	subch := cs.bestTips.Sub("headchange")
+	head := cs.GetHeaviestTipSet()
+	cs.pubLk.Unlock()
+
+	buf := subscription.NewBuffer("headchange", p, cs.coalesceHeadChanges)
+	buf.Push([]*api.HeadChange{{
+		Type: HCCurrent,
+		Val:  head,
+	}})
+
+	go func() {
+		defer buf.Close()
+		var unsubOnce sync.Once
+		unsub := func() {
+			unsubOnce.Do(func() {
+				go cs.bestTips.Unsub(subch)
+			})
+		}
+
+		// keep reading from subch until it's closed, so that the publisher
+		// never blocks on this subscription
+		done := ctx.Done()
+		for {
+			select {
+			case val, ok := <-subch:
+				if !ok {
+					return
+				}
+				if !buf.Push(val.([]*api.HeadChange)) {
+					// closed, possibly for lagging
+					unsub()
+				}
+			case <-done:
+				done = nil
+				buf.Close()
+				unsub()
+			}
+		}
+	}()
+
+	out := make(chan []*api.HeadChange)
+	go func() {
+		defer close(out)
+
+		for {
+			val, ok := buf.Next(ctx)
+			if !ok {
+				return
+			}
+
+			select {
+			case out <- val.([]*api.HeadChange):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+func (cs *ChainStore) coalesceHeadChanges(pending []interface{}) interface{} {
+	var last *api.HeadChange
+	for _, p := range pending {
+		if hcs := p.([]*api.HeadChange); len(hcs) > 0 {
+			last = hcs[len(hcs)-1]
+		}
+	}
+
+	var head *types.TipSet
+	switch {
+	case last == nil:
+		head = cs.GetHeaviestTipSet()
+	case last.Type == HCRevert:
+		// the chain was left at the parent of the reverted tipset
+		parent, err := cs.LoadTipSet(last.Val.Parents())
+		if err != nil {
+			log.Warnw("loading parent of reverted tipset", "tipset", last.Val.Key(), "error", err)
+			parent = cs.GetHeaviestTipSet()
+		}
+		head = parent
+	default:
+		head = last.Val
+	}
+
+	return []*api.HeadChange{{
+		Type: HCCurrent,
+		Val:  head,
+	}}
+}
+
 func (cs *ChainStore) SubscribeHeadChanges(f ReorgNotifee) {
 	cs.reorgNotifeeCh <- f
 }
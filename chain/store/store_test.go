@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	datastore "github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/crypto"
@@ -118,6 +119,58 @@ func TestChainExportImport(t *testing.T) {
 	}
 }
 
+func TestChainSnapshotImportBackground(t *testing.T) {
+	cg, err := gen.NewGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last *types.TipSet
+	for i := 0; i < 100; i++ {
+		ts, err := cg.NextTipSet()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		last = ts.TipSet.TipSet()
+	}
+
+	buf := new(bytes.Buffer)
+	if err := cg.ChainStore().Export(context.TODO(), last, 20, false, buf); err != nil {
+		t.Fatal(err)
+	}
+	size := int64(buf.Len())
+
+	nbs := blockstore.NewMemory()
+	cs := store.NewChainStore(nbs, nbs, datastore.NewMapDatastore(), nil, nil)
+	defer cs.Close() //nolint:errcheck
+
+	si, err := cs.NewSnapshotImport(buf, size)
+	require.NoError(t, err)
+	require.Equal(t, last.Key(), si.Root())
+
+	require.NoError(t, si.Import(context.TODO(), 10))
+
+	p := si.Progress()
+	require.False(t, p.Done)
+	require.Equal(t, last.Height(), p.Headers.To)
+	require.Equal(t, last.Height()-10, p.Headers.From)
+	require.Equal(t, last.Height(), p.State.To)
+	require.Equal(t, last.Height()-9, p.State.From)
+
+	root, err := cs.LoadTipSet(si.Root())
+	require.NoError(t, err)
+	require.True(t, root.Equals(last))
+
+	require.NoError(t, si.Import(context.TODO(), -1))
+
+	p = si.Progress()
+	require.True(t, p.Done)
+	require.Equal(t, size, p.BytesRead)
+	require.Equal(t, abi.ChainEpoch(0), p.Headers.From)
+	require.Equal(t, last.Height()-19, p.State.From)
+}
+
 func TestChainExportImportFull(t *testing.T) {
 	cg, err := gen.NewGenerator()
 	if err != nil {
@@ -13,6 +13,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/sub/propagation"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/lib/sigs"
 	"github.com/filecoin-project/lotus/metrics"
@@ -227,18 +228,21 @@ type BlockValidator struct {
 	// necessary for block validation
 	chain *store.ChainStore
 	stmgr *stmgr.StateManager
+
+	propagation *propagation.Tracker
 }
 
-func NewBlockValidator(self peer.ID, chain *store.ChainStore, stmgr *stmgr.StateManager, blacklist func(peer.ID)) *BlockValidator {
+func NewBlockValidator(self peer.ID, chain *store.ChainStore, stmgr *stmgr.StateManager, pt *propagation.Tracker, blacklist func(peer.ID)) *BlockValidator {
 	p, _ := lru.New2Q(4096)
 	return &BlockValidator{
-		self:       self,
-		peers:      p,
-		killThresh: 10,
-		blacklist:  blacklist,
-		recvBlocks: newBlockReceiptCache(),
-		chain:      chain,
-		stmgr:      stmgr,
+		self:        self,
+		peers:       p,
+		killThresh:  10,
+		blacklist:   blacklist,
+		recvBlocks:  newBlockReceiptCache(),
+		chain:       chain,
+		stmgr:       stmgr,
+		propagation: pt,
 	}
 }
 
@@ -333,6 +337,10 @@ func (bv *BlockValidator) Validate(ctx context.Context, pid peer.ID, msg *pubsub
 	}
 
 	// all good, accept the block
+	epochStart := time.Unix(int64(blk.Header.Timestamp), 0)
+	delay := bv.propagation.BlockArrived(pid, epochStart, begin)
+	stats.Record(ctx, metrics.BlockArrivalDelay.M(float64(delay.Milliseconds())))
+
 	msg.ValidatorData = blk
 	stats.Record(ctx, metrics.BlockValidationSuccess.M(1))
 	return pubsub.ValidationAccept
@@ -494,12 +502,13 @@ func (brc *blockReceiptCache) add(bcid cid.Cid) int {
 }
 
 type MessageValidator struct {
-	self  peer.ID
-	mpool *messagepool.MessagePool
+	self        peer.ID
+	mpool       *messagepool.MessagePool
+	propagation *propagation.Tracker
 }
 
-func NewMessageValidator(self peer.ID, mp *messagepool.MessagePool) *MessageValidator {
-	return &MessageValidator{self: self, mpool: mp}
+func NewMessageValidator(self peer.ID, mp *messagepool.MessagePool, pt *propagation.Tracker) *MessageValidator {
+	return &MessageValidator{self: self, mpool: mp, propagation: pt}
 }
 
 func (mv *MessageValidator) Validate(ctx context.Context, pid peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
@@ -545,6 +554,9 @@ func (mv *MessageValidator) Validate(ctx context.Context, pid peer.ID, msg *pubs
 		}
 	}
 
+	offset := mv.propagation.MessageArrived(pid, start)
+	stats.Record(ctx, metrics.MessageArrivalOffset.M(float64(offset.Milliseconds())))
+
 	ctx, _ = tag.New(
 		ctx,
 		tag.Upsert(metrics.MsgValid, "true"),
@@ -0,0 +1,173 @@
+// Package propagation records when blocks and messages arrive over pubsub,
+// relative to the start of their epoch, and which peers deliver them first.
+package propagation
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+)
+
+const (
+	// number of arrival times the statistics are computed over
+	blockSamples   = 2000
+	messageSamples = 20000
+)
+
+// Tracker records the arrival of blocks and messages accepted by the pubsub
+// validators. Validators only see the first delivery of a message, so the
+// peer passed to the tracker is the one which delivered it first.
+type Tracker struct {
+	genesis time.Time
+
+	lk       sync.Mutex
+	since    time.Time
+	blocks   *samples
+	messages *samples
+	peers    map[peer.ID]*api.PeerDeliveries
+}
+
+func NewTracker(genesis time.Time) *Tracker {
+	t := &Tracker{genesis: genesis}
+	t.reset()
+	return t
+}
+
+func (t *Tracker) reset() {
+	t.since = build.Clock.Now()
+	t.blocks = newSamples(blockSamples)
+	t.messages = newSamples(messageSamples)
+	t.peers = map[peer.ID]*api.PeerDeliveries{}
+}
+
+// BlockArrived records a block from the given epoch start, and returns its
+// delay
+func (t *Tracker) BlockArrived(from peer.ID, epochStart, at time.Time) time.Duration {
+	delay := at.Sub(epochStart)
+	if t == nil {
+		return delay
+	}
+
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	t.blocks.add(delay)
+	t.peer(from).Blocks++
+	return delay
+}
+
+// MessageArrived records a message, and returns the time since the start of
+// the epoch it arrived in
+func (t *Tracker) MessageArrived(from peer.ID, at time.Time) time.Duration {
+	if t == nil {
+		return 0
+	}
+	epoch := time.Duration(build.BlockDelaySecs) * time.Second
+	offset := at.Sub(t.genesis) % epoch
+
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	t.messages.add(offset)
+	t.peer(from).Messages++
+	return offset
+}
+
+// peer must be called with the lock held
+func (t *Tracker) peer(p peer.ID) *api.PeerDeliveries {
+	d, ok := t.peers[p]
+	if !ok {
+		d = &api.PeerDeliveries{ID: p}
+		t.peers[p] = d
+	}
+	return d
+}
+
+// Reset drops everything recorded so far
+func (t *Tracker) Reset() {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	t.reset()
+}
+
+// Report returns the arrival statistics, and the peers sorted by the number
+// of blocks, then messages, they delivered first
+func (t *Tracker) Report() *api.PropagationReport {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	r := &api.PropagationReport{
+		Since:    t.since,
+		Blocks:   t.blocks.stats(),
+		Messages: t.messages.stats(),
+		Peers:    make([]api.PeerDeliveries, 0, len(t.peers)),
+	}
+	for _, d := range t.peers {
+		r.Peers = append(r.Peers, *d)
+	}
+	sort.Slice(r.Peers, func(i, j int) bool {
+		if r.Peers[i].Blocks != r.Peers[j].Blocks {
+			return r.Peers[i].Blocks > r.Peers[j].Blocks
+		}
+		if r.Peers[i].Messages != r.Peers[j].Messages {
+			return r.Peers[i].Messages > r.Peers[j].Messages
+		}
+		return r.Peers[i].ID < r.Peers[j].ID
+	})
+	return r
+}
+
+// samples keeps the last arrival times in a ring
+type samples struct {
+	buf   []time.Duration
+	next  int
+	count uint64
+}
+
+func newSamples(n int) *samples {
+	return &samples{buf: make([]time.Duration, 0, n)}
+}
+
+func (s *samples) add(d time.Duration) {
+	s.count++
+	if len(s.buf) < cap(s.buf) {
+		s.buf = append(s.buf, d)
+		return
+	}
+	s.buf[s.next] = d
+	s.next = (s.next + 1) % len(s.buf)
+}
+
+func (s *samples) stats() api.ArrivalStats {
+	st := api.ArrivalStats{Count: s.count}
+	if len(s.buf) == 0 {
+		return st
+	}
+
+	sorted := make([]time.Duration, len(s.buf))
+	copy(sorted, s.buf)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+	percentile := func(p int) time.Duration {
+		return sorted[(len(sorted)-1)*p/100]
+	}
+
+	st.Mean = sum / time.Duration(len(sorted))
+	st.P50 = percentile(50)
+	st.P90 = percentile(90)
+	st.P99 = percentile(99)
+	st.Max = sorted[len(sorted)-1]
+	return st
+}
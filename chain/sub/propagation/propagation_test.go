@@ -0,0 +1,57 @@
+package propagation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+func TestTracker(t *testing.T) {
+	genesis := time.Unix(1000, 0)
+	tr := NewTracker(genesis)
+
+	fast, slow := peer.ID("fast"), peer.ID("slow")
+	for i := 1; i <= 10; i++ {
+		epochStart := genesis.Add(time.Duration(i*int(build.BlockDelaySecs)) * time.Second)
+		delay := tr.BlockArrived(fast, epochStart, epochStart.Add(time.Duration(i)*time.Second))
+		require.Equal(t, time.Duration(i)*time.Second, delay)
+	}
+	tr.BlockArrived(slow, genesis, genesis.Add(20*time.Second))
+
+	offset := tr.MessageArrived(slow, genesis.Add(time.Duration(build.BlockDelaySecs+3)*time.Second))
+	require.Equal(t, 3*time.Second, offset)
+
+	r := tr.Report()
+	require.Equal(t, uint64(11), r.Blocks.Count)
+	require.Equal(t, 6*time.Second, r.Blocks.P50)
+	require.Equal(t, 20*time.Second, r.Blocks.Max)
+	require.Equal(t, uint64(1), r.Messages.Count)
+
+	require.Len(t, r.Peers, 2)
+	require.Equal(t, fast, r.Peers[0].ID)
+	require.Equal(t, uint64(10), r.Peers[0].Blocks)
+	require.Equal(t, uint64(1), r.Peers[1].Messages)
+
+	tr.Reset()
+	r = tr.Report()
+	require.Zero(t, r.Blocks.Count)
+	require.Empty(t, r.Peers)
+}
+
+func TestSamplesRing(t *testing.T) {
+	s := newSamples(3)
+	for i := 1; i <= 5; i++ {
+		s.add(time.Duration(i))
+	}
+
+	st := s.stats()
+	require.Equal(t, uint64(5), st.Count)
+	// only the last 3 samples are kept
+	require.Equal(t, time.Duration(4), st.Mean)
+	require.Equal(t, time.Duration(4), st.P50)
+	require.Equal(t, time.Duration(5), st.Max)
+}
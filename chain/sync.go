@@ -129,6 +129,8 @@ type Syncer struct {
 
 	verifier ffiwrapper.Verifier
 
+	timing *validationTimer
+
 	tickerCtxCancel context.CancelFunc
 
 	ds dtypes.MetadataDS
@@ -160,6 +162,7 @@ func NewSyncer(ds dtypes.MetadataDS, sm *stmgr.StateManager, exchange exchange.C
 		receiptTracker: newBlockReceiptTracker(),
 		connmgr:        connmgr,
 		verifier:       verifier,
+		timing:         newValidationTimer(),
 
 		incoming: pubsub.New(50),
 	}
@@ -598,12 +601,17 @@ func (syncer *Syncer) ValidateTipSet(ctx context.Context, fts *store.FullTipSet,
 		return nil
 	}
 
+	start := build.Clock.Now()
+	timings := make([]*blockTiming, len(fts.Blocks))
+
 	var futures []async.ErrorFuture
-	for _, b := range fts.Blocks {
+	for i, b := range fts.Blocks {
 		b := b // rebind to a scoped variable
+		bt := &blockTiming{}
+		timings[i] = bt
 
 		futures = append(futures, async.Err(func() error {
-			if err := syncer.ValidateBlock(ctx, b, useCache); err != nil {
+			if err := syncer.validateBlock(ctx, b, useCache, bt); err != nil {
 				if isPermanent(err) {
 					syncer.bad.Add(b.Cid(), NewBadBlockReason([]cid.Cid{b.Cid()}, err.Error()))
 				}
@@ -621,6 +629,8 @@ func (syncer *Syncer) ValidateTipSet(ctx context.Context, fts *store.FullTipSet,
 			return err
 		}
 	}
+
+	syncer.timing.record(ts, build.Clock.Since(start), timings)
 	return nil
 }
 
@@ -675,6 +685,10 @@ func blockSanityChecks(h *types.BlockHeader) error {
 
 // ValidateBlock should match up with 'Semantical Validation' in validation.md in the spec
 func (syncer *Syncer) ValidateBlock(ctx context.Context, b *types.FullBlock, useCache bool) (err error) {
+	return syncer.validateBlock(ctx, b, useCache, nil)
+}
+
+func (syncer *Syncer) validateBlock(ctx context.Context, b *types.FullBlock, useCache bool, bt *blockTiming) (err error) {
 	defer func() {
 		// b.Cid() could panic for empty blocks that are used in tests.
 		if rerr := recover(); rerr != nil {
@@ -694,6 +708,10 @@ func (syncer *Syncer) ValidateBlock(ctx context.Context, b *types.FullBlock, use
 		}
 	}
 
+	if bt != nil {
+		bt.validated = true
+	}
+
 	validationStart := build.Clock.Now()
 	defer func() {
 		stats.Record(ctx, metrics.BlockValidationDurationMilliseconds.M(metrics.SinceInMilliseconds(validationStart)))
@@ -740,7 +758,7 @@ func (syncer *Syncer) ValidateBlock(ctx context.Context, b *types.FullBlock, use
 		log.Warn("Got block from the future, but within threshold", h.Timestamp, build.Clock.Now().Unix())
 	}
 
-	msgsCheck := async.Err(func() error {
+	msgsCheck := async.Err(bt.track(checkSignatures, func() error {
 		if b.Cid() == build.WhitelistedBlock {
 			return nil
 		}
@@ -749,7 +767,7 @@ func (syncer *Syncer) ValidateBlock(ctx context.Context, b *types.FullBlock, use
 			return xerrors.Errorf("block had invalid messages: %w", err)
 		}
 		return nil
-	})
+	}))
 
 	minerCheck := async.Err(func() error {
 		if err := syncer.minerIsValid(ctx, h.Miner, baseTs); err != nil {
@@ -779,8 +797,10 @@ func (syncer *Syncer) ValidateBlock(ctx context.Context, b *types.FullBlock, use
 			b.Header.ParentWeight, pweight)
 	}
 
-	stateRootCheck := async.Err(func() error {
-		stateroot, precp, err := syncer.sm.TipSetState(ctx, baseTs)
+	stateRootCheck := async.Err(bt.track(checkExecution, func() error {
+		var pt vm.ProofTimer
+		stateroot, precp, err := syncer.sm.TipSetState(vm.WithProofTimer(ctx, &pt), baseTs)
+		bt.addProofs(pt.Total())
 		if err != nil {
 			return xerrors.Errorf("get tipsetstate(%d, %s) failed: %w", h.Height, h.Parents, err)
 		}
@@ -805,7 +825,7 @@ func (syncer *Syncer) ValidateBlock(ctx context.Context, b *types.FullBlock, use
 		}
 
 		return nil
-	})
+	}))
 
 	// Stuff that needs worker address
 	waddr, err := stmgr.GetMinerWorkerRaw(ctx, syncer.sm, lbst, h.Miner)
@@ -867,12 +887,12 @@ func (syncer *Syncer) ValidateBlock(ctx context.Context, b *types.FullBlock, use
 		return nil
 	})
 
-	blockSigCheck := async.Err(func() error {
+	blockSigCheck := async.Err(bt.track(checkSignatures, func() error {
 		if err := sigs.CheckBlockSignature(ctx, h, waddr); err != nil {
 			return xerrors.Errorf("check block signature failed: %w", err)
 		}
 		return nil
-	})
+	}))
 
 	beaconValuesCheck := async.Err(func() error {
 		if os.Getenv("LOTUS_IGNORE_DRAND") == "_yes_" {
@@ -912,12 +932,12 @@ func (syncer *Syncer) ValidateBlock(ctx context.Context, b *types.FullBlock, use
 		return nil
 	})
 
-	wproofCheck := async.Err(func() error {
+	wproofCheck := async.Err(bt.track(checkProofs, func() error {
 		if err := syncer.VerifyWinningPoStProof(ctx, winPoStNv, h, *prevBeacon, lbst, waddr); err != nil {
 			return xerrors.Errorf("invalid election post: %w", err)
 		}
 		return nil
-	})
+	}))
 
 	await := []async.ErrorFuture{
 		minerCheck,
@@ -0,0 +1,235 @@
+package chain
+
+import (
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+// DefaultValidationTimingWindow is the number of recently validated tipsets
+// SyncValidationTiming reports on by default.
+const DefaultValidationTimingWindow = 120
+
+// ValidationTimingBounds are the upper bounds of the validation time histogram
+// buckets.
+var ValidationTimingBounds = func() []time.Duration {
+	bt := time.Duration(build.BlockDelaySecs) * time.Second
+	return []time.Duration{bt / 30, bt / 15, bt / 6, bt / 3, bt / 2, 2 * bt / 3, bt}
+}()
+
+// blockTiming collects how long the checks of a block took. It's nil when the
+// block validation isn't timed.
+type blockTiming struct {
+	lk        sync.Mutex
+	validated bool // false when the block was validated before
+
+	signatures, execution, proofs time.Duration
+}
+
+type timedCheck int
+
+const (
+	checkSignatures timedCheck = iota
+	checkExecution
+	checkProofs
+)
+
+// track returns f recording how long it took as part of the check
+func (bt *blockTiming) track(check timedCheck, f func() error) func() error {
+	if bt == nil {
+		return f
+	}
+	return func() error {
+		start := build.Clock.Now()
+		defer func() {
+			took := build.Clock.Since(start)
+
+			bt.lk.Lock()
+			defer bt.lk.Unlock()
+
+			switch check {
+			case checkSignatures:
+				bt.signatures += took
+			case checkExecution:
+				bt.execution += took
+			case checkProofs:
+				bt.proofs += took
+			}
+		}()
+		return f()
+	}
+}
+
+// addProofs records proof verification time spent outside of the proofs
+// check, e.g. in the verifier syscalls of the executed messages
+func (bt *blockTiming) addProofs(took time.Duration) {
+	if bt == nil {
+		return
+	}
+
+	bt.lk.Lock()
+	defer bt.lk.Unlock()
+
+	bt.proofs += took
+}
+
+// validationTimer keeps the validation timing of recent tipsets, and raises
+// an alert when validating a tipset takes longer than the budget.
+type validationTimer struct {
+	lk     sync.Mutex
+	recent []api.ValidationTiming
+	next   int
+	window int
+
+	budget time.Duration
+	al     *alerting.Alerting
+	at     alerting.AlertType
+}
+
+func newValidationTimer() *validationTimer {
+	return &validationTimer{
+		window: DefaultValidationTimingWindow,
+	}
+}
+
+// SetValidationBudget makes the syncer raise an alert when validating a
+// tipset takes longer than budget, and keep the timing of the last window
+// validated tipsets.
+func (syncer *Syncer) SetValidationBudget(al *alerting.Alerting, budget time.Duration, window int) {
+	vt := syncer.timing
+
+	vt.lk.Lock()
+	defer vt.lk.Unlock()
+
+	vt.budget = budget
+	vt.al = al
+	if al != nil {
+		vt.at = al.AddAlertType("sync", "validation-time")
+	}
+
+	if window > 0 && window != vt.window {
+		vt.window = window
+		vt.recent = nil
+		vt.next = 0
+	}
+}
+
+func (vt *validationTimer) record(ts *types.TipSet, total time.Duration, blocks []*blockTiming) {
+	t := api.ValidationTiming{
+		TipSet: ts.Key(),
+		Height: ts.Height(),
+		Total:  total,
+	}
+
+	validated := false
+	for _, bt := range blocks {
+		validated = validated || bt.validated
+		t.Signatures = maxDuration(t.Signatures, bt.signatures)
+		t.Execution = maxDuration(t.Execution, bt.execution)
+		t.Proofs = maxDuration(t.Proofs, bt.proofs)
+	}
+	if !validated {
+		return
+	}
+
+	vt.lk.Lock()
+	if len(vt.recent) < vt.window {
+		vt.recent = append(vt.recent, t)
+	} else {
+		vt.recent[vt.next] = t
+	}
+	vt.next = (vt.next + 1) % vt.window
+	budget, al, at := vt.budget, vt.al, vt.at
+	vt.lk.Unlock()
+
+	if budget <= 0 {
+		return
+	}
+
+	if total > budget {
+		log.Warnw("tipset validation took longer than the budget", "height", t.Height, "took", total, "budget", budget,
+			"signatures", t.Signatures, "execution", t.Execution, "proofs", t.Proofs)
+	}
+	if al == nil {
+		return
+	}
+
+	if total > budget {
+		al.Raise(at, map[string]interface{}{
+			"message":    "tipset validation took longer than the budget",
+			"height":     t.Height,
+			"took":       total.String(),
+			"budget":     budget.String(),
+			"signatures": t.Signatures.String(),
+			"execution":  t.Execution.String(),
+			"proofs":     t.Proofs.String(),
+		})
+	} else {
+		al.Resolve(at, map[string]interface{}{
+			"message": "tipset validated within the budget",
+			"height":  t.Height,
+		})
+	}
+}
+
+// ValidationTiming returns a histogram of the validation time of recently
+// validated tipsets.
+func (syncer *Syncer) ValidationTiming() api.SyncValidationTiming {
+	vt := syncer.timing
+
+	vt.lk.Lock()
+	defer vt.lk.Unlock()
+
+	out := api.SyncValidationTiming{
+		Budget:     vt.budget,
+		Bounds:     ValidationTimingBounds,
+		Tipsets:    len(vt.recent),
+		Total:      make([]int, len(ValidationTimingBounds)+1),
+		Signatures: make([]int, len(ValidationTimingBounds)+1),
+		Execution:  make([]int, len(ValidationTimingBounds)+1),
+		Proofs:     make([]int, len(ValidationTimingBounds)+1),
+	}
+
+	for i := range vt.recent {
+		t := vt.recent[i]
+		out.Total[bucket(t.Total)]++
+		out.Signatures[bucket(t.Signatures)]++
+		out.Execution[bucket(t.Execution)]++
+		out.Proofs[bucket(t.Proofs)]++
+
+		if out.Slowest == nil || t.Total > out.Slowest.Total {
+			out.Slowest = &vt.recent[i]
+		}
+	}
+
+	if len(vt.recent) > 0 {
+		latest := vt.recent[(vt.next+len(vt.recent)-1)%len(vt.recent)]
+		out.Latest = &latest
+	}
+	if out.Slowest != nil {
+		slowest := *out.Slowest
+		out.Slowest = &slowest
+	}
+
+	return out
+}
+
+func bucket(d time.Duration) int {
+	for i, b := range ValidationTimingBounds {
+		if d <= b {
+			return i
+		}
+	}
+	return len(ValidationTimingBounds)
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,56 @@
+package chain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+func TestValidationTiming(t *testing.T) {
+	syncer := &Syncer{timing: newValidationTimer()}
+	al := alerting.NewAlertingSystem(journal.NilJournal())
+	syncer.SetValidationBudget(al, ValidationTimingBounds[4], 3)
+	at := al.AddAlertType("sync", "validation-time")
+
+	ts := mock.TipSet(mock.MkBlock(nil, 1, 1))
+
+	// tipsets made of blocks validated before aren't recorded
+	syncer.timing.record(ts, time.Hour, []*blockTiming{{}})
+	require.Equal(t, 0, syncer.ValidationTiming().Tipsets)
+
+	fast := []*blockTiming{
+		{validated: true, signatures: 1, execution: 2, proofs: 1},
+		{validated: true, signatures: 2, execution: 1, proofs: 1},
+	}
+	syncer.timing.record(ts, ValidationTimingBounds[0], fast)
+	require.False(t, al.IsRaised(at))
+
+	vt := syncer.ValidationTiming()
+	require.Equal(t, 1, vt.Tipsets)
+	require.Equal(t, 1, vt.Total[0])
+	require.Equal(t, time.Duration(2), vt.Latest.Signatures)
+	require.Equal(t, time.Duration(2), vt.Latest.Execution)
+	require.Equal(t, time.Duration(1), vt.Latest.Proofs)
+
+	slow := []*blockTiming{{validated: true, execution: ValidationTimingBounds[5]}}
+	syncer.timing.record(ts, ValidationTimingBounds[5], slow)
+	require.True(t, al.IsRaised(at))
+
+	// only the last 3 tipsets are kept
+	for i := 0; i < 3; i++ {
+		syncer.timing.record(ts, ValidationTimingBounds[1], fast)
+	}
+	require.False(t, al.IsRaised(at))
+
+	vt = syncer.ValidationTiming()
+	require.Equal(t, 3, vt.Tipsets)
+	require.Equal(t, 3, vt.Total[1])
+	require.Equal(t, 0, vt.Total[5])
+	require.Equal(t, ValidationTimingBounds[1], vt.Slowest.Total)
+	require.Len(t, vt.Total, len(ValidationTimingBounds)+1)
+}
@@ -0,0 +1,52 @@
+package vm
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+// ProofTimer accumulates the time spent in the proof verification syscalls
+// (seal, aggregate seal and window PoSt verification) of the messages applied
+// with a context carrying it, see WithProofTimer.
+type ProofTimer struct {
+	lk    sync.Mutex
+	total time.Duration
+}
+
+type proofTimerKey struct{}
+
+// WithProofTimer returns a context making the VM record the time spent
+// verifying proofs in pt.
+func WithProofTimer(ctx context.Context, pt *ProofTimer) context.Context {
+	return context.WithValue(ctx, proofTimerKey{}, pt)
+}
+
+func proofTimerFrom(ctx context.Context) *ProofTimer {
+	pt, _ := ctx.Value(proofTimerKey{}).(*ProofTimer)
+	return pt
+}
+
+// Total returns the time spent verifying proofs so far.
+func (pt *ProofTimer) Total() time.Duration {
+	pt.lk.Lock()
+	defer pt.lk.Unlock()
+
+	return pt.total
+}
+
+// since records the time elapsed since start, pt may be nil.
+func (pt *ProofTimer) since(start time.Time) {
+	if pt == nil {
+		return
+	}
+
+	took := build.Clock.Since(start)
+
+	pt.lk.Lock()
+	defer pt.lk.Unlock()
+
+	pt.total += took
+}
@@ -550,6 +550,9 @@ func (rt *Runtime) chargeGasFunc(skip int) func(GasCharge) {
 
 func (rt *Runtime) chargeGasInternal(gas GasCharge, skip int) aerrors.ActorError {
 	toUse := gas.Total()
+	if rt.vm != nil && rt.vm.tracer != nil {
+		rt.vm.tracer.OnGas(rt.depth, gas)
+	}
 	if EnableGasTracing {
 		var callers [10]uintptr
 
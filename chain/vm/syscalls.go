@@ -244,6 +244,8 @@ func (ss *syscallShim) workerKeyAtLookback(height abi.ChainEpoch) (address.Addre
 }
 
 func (ss *syscallShim) VerifyPoSt(proof proof5.WindowPoStVerifyInfo) error {
+	defer proofTimerFrom(ss.ctx).since(build.Clock.Now())
+
 	ok, err := ss.verifier.VerifyWindowPoSt(context.TODO(), proof)
 	if err != nil {
 		return err
@@ -255,6 +257,12 @@ func (ss *syscallShim) VerifyPoSt(proof proof5.WindowPoStVerifyInfo) error {
 }
 
 func (ss *syscallShim) VerifySeal(info proof5.SealVerifyInfo) error {
+	defer proofTimerFrom(ss.ctx).since(build.Clock.Now())
+
+	return ss.verifySeal(info)
+}
+
+func (ss *syscallShim) verifySeal(info proof5.SealVerifyInfo) error {
 	//_, span := trace.StartSpan(ctx, "ValidatePoRep")
 	//defer span.End()
 
@@ -282,6 +290,8 @@ func (ss *syscallShim) VerifySeal(info proof5.SealVerifyInfo) error {
 }
 
 func (ss *syscallShim) VerifyAggregateSeals(aggregate proof5.AggregateSealVerifyProofAndInfos) error {
+	defer proofTimerFrom(ss.ctx).since(build.Clock.Now())
+
 	ok, err := ss.verifier.VerifyAggregateSeals(aggregate)
 	if err != nil {
 		return xerrors.Errorf("failed to verify aggregated PoRep: %w", err)
@@ -307,6 +317,9 @@ func (ss *syscallShim) VerifySignature(sig crypto.Signature, addr address.Addres
 var BatchSealVerifyParallelism = goruntime.NumCPU()
 
 func (ss *syscallShim) BatchVerifySeals(inp map[address.Address][]proof5.SealVerifyInfo) (map[address.Address][]bool, error) {
+	// seals are verified in parallel, so time the whole batch
+	defer proofTimerFrom(ss.ctx).since(build.Clock.Now())
+
 	out := make(map[address.Address][]bool)
 
 	sema := make(chan struct{}, BatchSealVerifyParallelism)
@@ -322,7 +335,7 @@ func (ss *syscallShim) BatchVerifySeals(inp map[address.Address][]proof5.SealVer
 				defer wg.Done()
 				sema <- struct{}{}
 
-				if err := ss.VerifySeal(svi); err != nil {
+				if err := ss.verifySeal(svi); err != nil {
 					log.Warnw("seal verify in batch failed", "miner", ma, "sectorNumber", svi.SectorID.Number, "err", err)
 					res[ix] = false
 				} else {
@@ -0,0 +1,24 @@
+package vm
+
+import (
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/lotus/chain/actors/aerrors"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// Tracer observes the execution of messages step by step, to debug them. It
+// is set through VMOpts, and is called synchronously during execution.
+type Tracer interface {
+	// OnCall is called when an actor method is invoked, with the gas used by
+	// the message before the call
+	OnCall(depth uint64, msg *types.Message, gasUsed int64)
+	// OnReturn is called when an actor method returns; the receipt holds the
+	// gas used by the message after the call
+	OnReturn(depth uint64, rct *types.MessageReceipt, err aerrors.ActorError)
+	// OnGas is called for each gas charge
+	OnGas(depth uint64, gas GasCharge)
+	// OnIpldGet and OnIpldPut are called when actors read and write state
+	OnIpldGet(depth uint64, c cid.Cid, size int)
+	OnIpldPut(depth uint64, c cid.Cid, size int)
+}
@@ -80,6 +80,9 @@ type gasChargingBlocks struct {
 	chargeGas func(GasCharge)
 	pricelist Pricelist
 	under     cbor.IpldBlockstore
+
+	tracer Tracer
+	depth  uint64
 }
 
 func (bs *gasChargingBlocks) View(c cid.Cid, cb func([]byte) error) error {
@@ -89,6 +92,9 @@ func (bs *gasChargingBlocks) View(c cid.Cid, cb func([]byte) error) error {
 			// we have successfully retrieved the value; charge for it, even if the user-provided function fails.
 			bs.chargeGas(newGasCharge("OnIpldViewEnd", 0, 0).WithExtra(len(b)))
 			bs.chargeGas(gasOnActorExec)
+			if bs.tracer != nil {
+				bs.tracer.OnIpldGet(bs.depth, c, len(b))
+			}
 			return cb(b)
 		})
 	}
@@ -108,6 +114,9 @@ func (bs *gasChargingBlocks) Get(c cid.Cid) (block.Block, error) {
 	}
 	bs.chargeGas(newGasCharge("OnIpldGetEnd", 0, 0).WithExtra(len(blk.RawData())))
 	bs.chargeGas(gasOnActorExec)
+	if bs.tracer != nil {
+		bs.tracer.OnIpldGet(bs.depth, c, len(blk.RawData()))
+	}
 
 	return blk, nil
 }
@@ -119,6 +128,9 @@ func (bs *gasChargingBlocks) Put(blk block.Block) error {
 		return aerrors.Escalate(err, "failed to write data to disk")
 	}
 	bs.chargeGas(gasOnActorExec)
+	if bs.tracer != nil {
+		bs.tracer.OnIpldPut(bs.depth, blk.Cid(), len(blk.RawData()))
+	}
 	return nil
 }
 
@@ -157,7 +169,7 @@ func (vm *VM) makeRuntime(ctx context.Context, msg *types.Message, parent *Runti
 		rt.Abortf(exitcode.SysErrForbidden, "message execution exceeds call depth")
 	}
 
-	cbb := &gasChargingBlocks{rt.chargeGasFunc(2), rt.pricelist, vm.cst.Blocks}
+	cbb := &gasChargingBlocks{rt.chargeGasFunc(2), rt.pricelist, vm.cst.Blocks, vm.tracer, rt.depth}
 	cst := cbor.NewCborStore(cbb)
 	cst.Atlas = vm.cst.Atlas // associate the atlas.
 	rt.cst = cst
@@ -214,6 +226,7 @@ type VM struct {
 	ntwkVersion    NtwkVersionGetter
 	baseFee        abi.TokenAmount
 	lbStateGet     LookbackStateGetter
+	tracer         Tracer
 
 	Syscalls SyscallBuilder
 }
@@ -228,6 +241,8 @@ type VMOpts struct {
 	NtwkVersion    NtwkVersionGetter // TODO: stebalien: In what cases do we actually need this? It seems like even when creating new networks we want to use the 'global'/build-default version getter
 	BaseFee        abi.TokenAmount
 	LookbackState  LookbackStateGetter
+	// Tracer, if set, observes message execution step by step
+	Tracer Tracer
 }
 
 func NewVM(ctx context.Context, opts *VMOpts) (*VM, error) {
@@ -251,6 +266,7 @@ func NewVM(ctx context.Context, opts *VMOpts) (*VM, error) {
 		Syscalls:       opts.Syscalls,
 		baseFee:        opts.BaseFee,
 		lbStateGet:     opts.LookbackState,
+		tracer:         opts.Tracer,
 	}, nil
 }
 
@@ -293,6 +309,9 @@ func (vm *VM) send(ctx context.Context, msg *types.Message, parent *Runtime,
 			parent.gasUsed = rt.gasUsed
 		}()
 	}
+	if vm.tracer != nil {
+		vm.tracer.OnCall(rt.depth, msg, rt.gasUsed)
+	}
 	if gasCharge != nil {
 		if err := rt.chargeGasSafe(*gasCharge); err != nil {
 			// this should never happen
@@ -361,6 +380,9 @@ func (vm *VM) send(ctx context.Context, msg *types.Message, parent *Runtime,
 	if err != nil {
 		rt.executionTrace.Error = err.Error()
 	}
+	if vm.tracer != nil {
+		vm.tracer.OnReturn(rt.depth, &mr, err)
+	}
 
 	return ret, err, rt
 }
@@ -8,7 +8,7 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/api/client"
-	cliutil "github.com/filecoin-project/lotus/cli/util"
+	"github.com/filecoin-project/lotus/lib/apiinfo"
 	"github.com/filecoin-project/lotus/node/modules/helpers"
 )
 
@@ -18,7 +18,7 @@ type RemoteWallet struct {
 
 func SetupRemoteWallet(info string) func(mctx helpers.MetricsCtx, lc fx.Lifecycle) (*RemoteWallet, error) {
 	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle) (*RemoteWallet, error) {
-		ai := cliutil.ParseApiInfo(info)
+		ai := apiinfo.Parse(info)
 
 		url, err := ai.DialArgs("v0")
 		if err != nil {
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	lapi "github.com/filecoin-project/lotus/api"
+)
+
+// AddressBook is the part of the node API used to resolve address book names
+type AddressBook interface {
+	AddressBookGet(ctx context.Context, name string) (*lapi.AddressBookEntry, error)
+}
+
+// ResolveAddress parses an address, or looks up a name in the address book of
+// the node. The address of a name is printed along with it, so that the user
+// can check where funds are going.
+func ResolveAddress(ctx context.Context, cctx *cli.Context, ab AddressBook, s string) (address.Address, error) {
+	if addr, err := address.NewFromString(s); err == nil {
+		return addr, nil
+	}
+
+	entry, err := ab.AddressBookGet(ctx, s)
+	if err != nil {
+		return address.Undef, xerrors.Errorf("%q is neither an address nor an address book name: %w", s, err)
+	}
+
+	_, _ = fmt.Fprintf(cctx.App.ErrWriter, "Using %s (%s)\n", entry.Name, entry.Address)
+	return entry.Address, nil
+}
+
+var walletAddressBook = &cli.Command{
+	Name:  "address-book",
+	Usage: "Manage named addresses",
+	Description: `Names can be used in place of addresses by the send, msig propose and
+   lotus-miner actor commands, which print the address a name resolves to.`,
+	Subcommands: []*cli.Command{
+		walletAddressBookSet,
+		walletAddressBookRemove,
+		walletAddressBookList,
+	},
+}
+
+var walletAddressBookSet = &cli.Command{
+	Name:      "set",
+	Usage:     "Add a named address, or replace the address of a name",
+	ArgsUsage: "[name] [address]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "note",
+			Usage: "note about the address, shown in the list",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 2 {
+			return ShowHelp(cctx, xerrors.Errorf("expected a name and an address"))
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		addr, err := address.NewFromString(cctx.Args().Get(1))
+		if err != nil {
+			return xerrors.Errorf("parsing address: %w", err)
+		}
+
+		return api.AddressBookSet(ctx, lapi.AddressBookEntry{
+			Name:    cctx.Args().Get(0),
+			Address: addr,
+			Note:    cctx.String("note"),
+		})
+	},
+}
+
+var walletAddressBookRemove = &cli.Command{
+	Name:      "remove",
+	Usage:     "Remove a named address",
+	ArgsUsage: "[name]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return ShowHelp(cctx, xerrors.Errorf("expected a name"))
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		return api.AddressBookRemove(ctx, cctx.Args().First())
+	},
+}
+
+var walletAddressBookList = &cli.Command{
+	Name:  "list",
+	Usage: "List named addresses",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		entries, err := api.AddressBookList(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Name\tAddress\tAdded\tNote")
+		for _, e := range entries {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Name, e.Address, e.Added.Format("2006-01-02"), e.Note)
+		}
+		return tw.Flush()
+	},
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
@@ -30,6 +31,10 @@ var AuthCreateAdminToken = &cli.Command{
 			Name:  "perm",
 			Usage: "permission to assign to the token, one of: read, write, sign, admin",
 		},
+		&cli.StringSliceFlag{
+			Name:  "scope",
+			Usage: "grant a permission on a single API area, as area:perm, or area:perm:address to limit it to calls on one address (e.g. sealing:read, wallet:sign:f1...); may be repeated",
+		},
 	},
 
 	Action: func(cctx *cli.Context) error {
@@ -41,30 +46,41 @@ var AuthCreateAdminToken = &cli.Command{
 
 		ctx := ReqContext(cctx)
 
-		if !cctx.IsSet("perm") {
-			return xerrors.New("--perm flag not set")
+		if !cctx.IsSet("perm") && !cctx.IsSet("scope") {
+			return xerrors.New("--perm or --scope flag not set")
 		}
 
-		perm := cctx.String("perm")
-		idx := 0
-		for i, p := range api.AllPermissions {
-			if auth.Permission(perm) == p {
-				idx = i + 1
+		var perms []auth.Permission
+		if cctx.IsSet("perm") {
+			perm := cctx.String("perm")
+			idx := 0
+			for i, p := range api.AllPermissions {
+				if auth.Permission(perm) == p {
+					idx = i + 1
+				}
+			}
+
+			if idx == 0 {
+				return fmt.Errorf("--perm flag has to be one of: %s", api.AllPermissions)
 			}
+
+			// slice on [:idx] so for example: 'sign' gives you [read, write, sign]
+			perms = append(perms, api.AllPermissions[:idx]...)
 		}
 
-		if idx == 0 {
-			return fmt.Errorf("--perm flag has to be one of: %s", api.AllPermissions)
+		for _, s := range cctx.StringSlice("scope") {
+			sc, err := api.ParseScope(s)
+			if err != nil {
+				return xerrors.Errorf("parsing --scope: %w (known areas: %s)", err, strings.Join(api.ScopeAreas(), ", "))
+			}
+			perms = append(perms, auth.Permission(sc.String()))
 		}
 
-		// slice on [:idx] so for example: 'sign' gives you [read, write, sign]
-		token, err := napi.AuthNew(ctx, api.AllPermissions[:idx])
+		token, err := napi.AuthNew(ctx, perms)
 		if err != nil {
 			return err
 		}
 
-		// TODO: Log in audit log when it is implemented
-
 		fmt.Println(string(token))
 		return nil
 	},
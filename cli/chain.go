@@ -14,6 +14,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/filecoin-project/go-address"
@@ -55,12 +56,51 @@ var ChainCmd = &cli.Command{
 		ChainGetCmd,
 		ChainBisectCmd,
 		ChainExportCmd,
+		ChainLatestSnapshotCmd,
 		SlashConsensusFault,
 		ChainGasPriceCmd,
+		ChainGasAdviceCmd,
+		ChainGasUsageCmd,
 		ChainInspectUsage,
 		ChainDecodeCmd,
 		ChainEncodeCmd,
 		ChainDisputeSetCmd,
+		ChainPruneCmd,
+		ChainBeaconHealthCmd,
+	},
+}
+
+var ChainBeaconHealthCmd = &cli.Command{
+	Name:  "beacon-health",
+	Usage: "Print the health of the drand endpoints, and the beacon rounds which couldn't be fetched",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		h, err := api.BeaconHealth(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Latest round: %d (fetched in %s)\n", h.LatestRound, h.LastFetchLatency.Truncate(time.Millisecond))
+		fmt.Printf("Missed rounds: %d\n", h.MissedRounds)
+		fmt.Println()
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Endpoint\tHealthy\tLatency\tRequests\tFailures\tLast Success\tLast Error")
+		for _, e := range h.Endpoints {
+			lastSuccess := "never"
+			if !e.LastSuccess.IsZero() {
+				lastSuccess = time.Since(e.LastSuccess).Truncate(time.Second).String() + " ago"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%t\t%s\t%d\t%d\t%s\t%s\n",
+				e.URL, e.Healthy, e.Latency.Truncate(time.Millisecond), e.Requests, e.Failures, lastSuccess, e.LastError)
+		}
+		return tw.Flush()
 	},
 }
 
@@ -293,6 +333,12 @@ var ChainGetMsgCmd = &cli.Command{
 	Name:      "getmessage",
 	Usage:     "Get and print a message by its cid",
 	ArgsUsage: "[messageCid]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "decode",
+			Usage: "print the params and, once executed, the return value decoded for the method of the recipient actor",
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		if !cctx.Args().Present() {
 			return fmt.Errorf("must pass a cid of a message to get")
@@ -310,6 +356,21 @@ var ChainGetMsgCmd = &cli.Command{
 			return xerrors.Errorf("failed to parse cid input: %w", err)
 		}
 
+		if cctx.Bool("decode") {
+			dm, err := api.StateDecodeMessage(ctx, c)
+			if err != nil {
+				return err
+			}
+
+			enc, err := json.MarshalIndent(dm, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(enc))
+			return nil
+		}
+
 		mb, err := api.ChainReadObj(ctx, c)
 		if err != nil {
 			return xerrors.Errorf("failed to read object: %w", err)
@@ -1102,6 +1163,35 @@ var ChainExportCmd = &cli.Command{
 	},
 }
 
+var ChainLatestSnapshotCmd = &cli.Command{
+	Name:  "latest-snapshot",
+	Usage: "Show the latest chain snapshot exported by the node",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		info, err := api.ChainLatestSnapshot(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Name:    %s\n", info.Name)
+		fmt.Printf("Height:  %d\n", info.Height)
+		fmt.Printf("TipSet:  %s\n", info.TipSet)
+		fmt.Printf("Size:    %s\n", types.SizeStr(types.NewInt(uint64(info.Size))))
+		fmt.Printf("SHA256:  %s\n", info.SHA256)
+		fmt.Printf("Created: %s\n", info.Created.Format(time.RFC3339))
+		if info.URL != "" {
+			fmt.Printf("URL:     %s\n", info.URL)
+		}
+		return nil
+	},
+}
+
 var SlashConsensusFault = &cli.Command{
 	Name:      "slash-consensus",
 	Usage:     "Report consensus fault",
@@ -1254,11 +1344,77 @@ var ChainGasPriceCmd = &cli.Command{
 	},
 }
 
+var ChainGasAdviceCmd = &cli.Command{
+	Name:  "gas-advice",
+	Usage: "Recommend gas fee caps and premiums for inclusion targets",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		advice, err := api.GasAdvisorFees(ctx, types.EmptyTSK)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Height: %d, base fee: %s\n", advice.Height, types.FIL(advice.BaseFee))
+		for _, t := range advice.Targets {
+			fmt.Printf("%s: fee cap %s, premium %s\n", t.Target, types.FIL(t.GasFeeCap), types.FIL(t.GasPremium))
+		}
+
+		return nil
+	},
+}
+
+var ChainGasUsageCmd = &cli.Command{
+	Name:  "gas-usage",
+	Usage: "Show the gas used by messages in recent epochs, by actor and method",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "epochs",
+			Usage: "number of recent epochs to show the usage of, 0 for all the tracked epochs",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		gu, err := api.ChainGasUsage(ctx, abi.ChainEpoch(cctx.Int64("epochs")))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Epochs %d-%d: %d tipsets, %d blocks, %d messages\n", gu.From, gu.To, gu.Tipsets, gu.Blocks, gu.Messages)
+		if gu.BlockGasLimit > 0 {
+			fmt.Printf("Gas used: %d (%.2f%% of the block gas limit)\n", gu.GasUsed, 100*float64(gu.GasUsed)/float64(gu.BlockGasLimit))
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "\nActor\tMethod\tMessages\tGas Used\tShare\tBlock Space")
+		for _, m := range gu.Methods {
+			method := m.MethodName
+			if method == "" {
+				method = fmt.Sprint(m.Method)
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.2f%%\t%.2f%%\n", m.Actor, method, m.Messages, m.GasUsed, 100*m.GasUsedShare, 100*m.BlockSpaceShare)
+		}
+		return tw.Flush()
+	},
+}
+
 var ChainDecodeCmd = &cli.Command{
 	Name:  "decode",
 	Usage: "decode various types",
 	Subcommands: []*cli.Command{
 		chainDecodeParamsCmd,
+		chainDecodeReturnCmd,
 	},
 }
 
@@ -1334,6 +1490,78 @@ var chainDecodeParamsCmd = &cli.Command{
 	},
 }
 
+var chainDecodeReturnCmd = &cli.Command{
+	Name:      "return",
+	Usage:     "Decode the return value of a message",
+	ArgsUsage: "[toAddr method return]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name: "tipset",
+		},
+		&cli.StringFlag{
+			Name:  "encoding",
+			Value: "base64",
+			Usage: "specify input encoding to parse",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if cctx.Args().Len() != 3 {
+			return ShowHelp(cctx, fmt.Errorf("incorrect number of arguments"))
+		}
+
+		to, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing toAddr: %w", err)
+		}
+
+		method, err := strconv.ParseInt(cctx.Args().Get(1), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("parsing method id: %w", err)
+		}
+
+		var ret []byte
+		switch cctx.String("encoding") {
+		case "base64":
+			ret, err = base64.StdEncoding.DecodeString(cctx.Args().Get(2))
+			if err != nil {
+				return xerrors.Errorf("decoding base64 value: %w", err)
+			}
+		case "hex":
+			ret, err = hex.DecodeString(cctx.Args().Get(2))
+			if err != nil {
+				return xerrors.Errorf("decoding hex value: %w", err)
+			}
+		default:
+			return xerrors.Errorf("unrecognized encoding: %s", cctx.String("encoding"))
+		}
+		ts, err := LoadTipSet(ctx, cctx, api)
+		if err != nil {
+			return err
+		}
+
+		act, err := api.StateGetActor(ctx, to, ts.Key())
+		if err != nil {
+			return xerrors.Errorf("getting actor: %w", err)
+		}
+
+		rstr, err := jsonReturn(act.Code, abi.MethodNum(method), ret)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(rstr)
+
+		return nil
+	},
+}
+
 var ChainEncodeCmd = &cli.Command{
 	Name:  "encode",
 	Usage: "encode various types",
@@ -1416,3 +1644,62 @@ var chainEncodeParamsCmd = &cli.Command{
 		return nil
 	},
 }
+
+var ChainPruneCmd = &cli.Command{
+	Name:  "prune",
+	Usage: "Reclaim disk space by garbage collecting the chain blockstore while the node is running",
+	Flags: []cli.Flag{
+		&cli.Float64Flag{
+			Name:  "threshold",
+			Usage: "fraction of garbage a value log file must contain to be rewritten; lower values reclaim more space but take longer",
+		},
+		&cli.BoolFlag{
+			Name:  "compact",
+			Usage: "compact the blockstore index before collecting garbage",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if t := cctx.Float64("threshold"); t < 0 || t >= 1 {
+			return xerrors.Errorf("threshold must be between 0 and 1")
+		}
+
+		progress, err := api.ChainBlockstoreGC(ctx, lapi.BlockstoreGCOpts{
+			Threshold: cctx.Float64("threshold"),
+			Compact:   cctx.Bool("compact"),
+		})
+		if err != nil {
+			return err
+		}
+
+		size := func(n int64) string {
+			if n < 0 {
+				n = 0
+			}
+			return types.SizeStr(types.NewInt(uint64(n)))
+		}
+
+		for p := range progress {
+			if p.Error != "" {
+				return xerrors.Errorf("garbage collection failed after %d rounds: %s", p.Rounds, p.Error)
+			}
+			if p.Done {
+				fmt.Printf("done: rewrote %d value log files, %s -> %s (reclaimed %s)\n", p.Rounds, size(p.SizeBefore), size(p.Size), size(p.SizeBefore-p.Size))
+				return nil
+			}
+			if p.Rounds == 0 && p.Size == p.SizeBefore {
+				fmt.Printf("blockstore size: %s\n", size(p.SizeBefore))
+				continue
+			}
+			fmt.Printf("round %d: %s (reclaimed %s so far)\n", p.Rounds, size(p.Size), size(p.SizeBefore-p.Size))
+		}
+
+		return xerrors.Errorf("garbage collection interrupted")
+	},
+}
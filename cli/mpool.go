@@ -31,6 +31,7 @@ var MpoolCmd = &cli.Command{
 		MpoolSub,
 		MpoolStat,
 		MpoolReplaceCmd,
+		MpoolFixNonceCmd,
 		MpoolFindCmd,
 		MpoolConfig,
 		MpoolGasPerfCmd,
@@ -513,6 +514,152 @@ var MpoolReplaceCmd = &cli.Command{
 	},
 }
 
+var MpoolFixNonceCmd = &cli.Command{
+	Name:  "fix-nonce",
+	Usage: "Find stuck local messages, and fill nonce gaps with self-sends",
+	Description: `Lists the local addresses with pending messages which can't be included,
+either because of gaps in their nonces, or because the message with the next
+nonce fails the checks required for its inclusion.
+
+With --really-do-it, every gap is filled with messages sending nothing from
+the address to itself. Messages failing the checks have to be replaced with
+'lotus mpool replace'.`,
+	ArgsUsage: "[address]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "gas-feecap",
+			Usage: "gas feecap for filler messages (attoFIL/GasUnit); defaults to twice the base fee",
+		},
+		&cli.StringFlag{
+			Name:  "gas-premium",
+			Usage: "gas premium for filler messages (attoFIL/GasUnit); defaults to an estimate",
+		},
+		&cli.Int64Flag{
+			Name:  "gas-limit",
+			Usage: "gas limit for filler messages (GasUnit)",
+			Value: 1000000,
+		},
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "push the filler messages, only report the gaps otherwise",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		var only address.Address
+		if cctx.Args().Present() {
+			only, err = address.NewFromString(cctx.Args().First())
+			if err != nil {
+				return err
+			}
+			only, err = api.StateAccountKey(ctx, only, types.EmptyTSK)
+			if err != nil {
+				return xerrors.Errorf("resolving address: %w", err)
+			}
+		}
+
+		reports, err := api.MpoolNonceGaps(ctx)
+		if err != nil {
+			return err
+		}
+
+		var stuck []lapi.NonceGapReport
+		for _, r := range reports {
+			if only != address.Undef && r.Address != only {
+				continue
+			}
+			stuck = append(stuck, r)
+		}
+		if len(stuck) == 0 {
+			fmt.Println("No stuck messages found")
+			return nil
+		}
+
+		for _, r := range stuck {
+			fmt.Printf("%s: state nonce %d, %d pending\n", r.Address, r.StateNonce, r.Pending)
+			if b := r.Blocking; b != nil {
+				fmt.Printf("  message %s with nonce %d can't be included:\n", b.Cid, b.Nonce)
+				for _, reason := range b.Reasons {
+					fmt.Printf("    %s\n", reason)
+				}
+			}
+			for _, g := range r.Gaps {
+				fmt.Printf("  nonces %d to %d missing, %d messages blocked\n", g.Start, g.End-1, g.Blocked)
+			}
+		}
+
+		if !cctx.Bool("really-do-it") {
+			return nil
+		}
+
+		ts, err := api.ChainHead(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting chain head: %w", err)
+		}
+
+		gasLimit := cctx.Int64("gas-limit")
+		for _, r := range stuck {
+			if len(r.Gaps) == 0 {
+				continue
+			}
+
+			var premium abi.TokenAmount
+			if cctx.IsSet("gas-premium") {
+				premium, err = types.BigFromString(cctx.String("gas-premium"))
+				if err != nil {
+					return fmt.Errorf("parsing gas-premium: %w", err)
+				}
+			} else {
+				premium, err = api.GasEstimateGasPremium(ctx, 10, r.Address, gasLimit, ts.Key())
+				if err != nil {
+					return xerrors.Errorf("estimating gas premium: %w", err)
+				}
+			}
+
+			feeCap := big.Mul(ts.Blocks()[0].ParentBaseFee, big.NewInt(2))
+			if cctx.IsSet("gas-feecap") {
+				feeCap, err = types.BigFromString(cctx.String("gas-feecap"))
+				if err != nil {
+					return fmt.Errorf("parsing gas-feecap: %w", err)
+				}
+			}
+			feeCap = big.Max(feeCap, premium)
+
+			for _, g := range r.Gaps {
+				for nonce := g.Start; nonce < g.End; nonce++ {
+					smsg, err := api.WalletSignMessage(ctx, r.Address, &types.Message{
+						From:       r.Address,
+						To:         r.Address,
+						Value:      abi.NewTokenAmount(0),
+						Nonce:      nonce,
+						GasLimit:   gasLimit,
+						GasFeeCap:  feeCap,
+						GasPremium: premium,
+					})
+					if err != nil {
+						return xerrors.Errorf("signing filler message: %w", err)
+					}
+
+					c, err := api.MpoolPush(ctx, smsg)
+					if err != nil {
+						return xerrors.Errorf("pushing filler message: %w", err)
+					}
+					fmt.Printf("%s nonce %d: %s\n", r.Address, nonce, c)
+				}
+			}
+		}
+
+		return nil
+	},
+}
+
 var MpoolFindCmd = &cli.Command{
 	Name:  "find",
 	Usage: "find a message in the mempool",
@@ -382,12 +382,12 @@ var msigProposeCmd = &cli.Command{
 		api := srv.FullNodeAPI()
 		ctx := ReqContext(cctx)
 
-		msig, err := address.NewFromString(cctx.Args().Get(0))
+		msig, err := ResolveAddress(ctx, cctx, api, cctx.Args().Get(0))
 		if err != nil {
 			return err
 		}
 
-		dest, err := address.NewFromString(cctx.Args().Get(1))
+		dest, err := ResolveAddress(ctx, cctx, api, cctx.Args().Get(1))
 		if err != nil {
 			return err
 		}
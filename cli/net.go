@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/urfave/cli/v2"
@@ -35,6 +36,8 @@ var NetCmd = &cli.Command{
 		NetScores,
 		NetReachability,
 		NetBandwidthCmd,
+		NetClassify,
+		NetPropagation,
 		NetBlockCmd,
 	},
 }
@@ -323,6 +326,123 @@ var NetReachability = &cli.Command{
 	},
 }
 
+var NetClassify = &cli.Command{
+	Name:  "classify",
+	Usage: "Print the class of connected peers used by the connection manager policies",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "class",
+			Usage: "only print peers of the class (bootstrap, protected, market, graphsync, pubsub, other)",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		peers, err := api.NetClassify(ctx)
+		if err != nil {
+			return err
+		}
+
+		counts := map[string]int{}
+		tw := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+		fmt.Fprintf(tw, "Peer\tClass\tProtected\n")
+		for _, p := range peers {
+			counts[p.Class]++
+			if c := cctx.String("class"); c != "" && c != p.Class {
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%t\n", p.ID, p.Class, p.Protected)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		var summary []string
+		for _, c := range []string{"bootstrap", "protected", "market", "graphsync", "pubsub", "other"} {
+			summary = append(summary, fmt.Sprintf("%s: %d", c, counts[c]))
+		}
+		fmt.Printf("\n%d peers (%s)\n", len(peers), strings.Join(summary, ", "))
+		return nil
+	},
+}
+
+var NetPropagation = &cli.Command{
+	Name:  "propagation",
+	Usage: "Print when blocks and messages arrive over pubsub, and which peers deliver them first",
+	Description: `Arrival times are relative to the start of the epoch, for blocks the epoch
+   they were mined in. Peers are listed by the number of blocks, then messages,
+   they delivered before any other peer. When run against a miner, the report
+   is the one of its full node.`,
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "peers",
+			Usage: "number of peers to print",
+			Value: 20,
+		},
+		&cli.BoolFlag{
+			Name:  "reset",
+			Usage: "start a new report after printing this one",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		r, err := api.NetPropagation(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Since %s (%s)\n\n", r.Since.Format(time.RFC3339), time.Since(r.Since).Truncate(time.Second))
+
+		tw := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+		fmt.Fprintf(tw, "\tCount\tMean\tP50\tP90\tP99\tMax\n")
+		for _, st := range []struct {
+			name string
+			s    atypes.ArrivalStats
+		}{{"Blocks", r.Blocks}, {"Messages", r.Messages}} {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", st.name, st.s.Count,
+				st.s.Mean.Truncate(time.Millisecond), st.s.P50.Truncate(time.Millisecond), st.s.P90.Truncate(time.Millisecond),
+				st.s.P99.Truncate(time.Millisecond), st.s.Max.Truncate(time.Millisecond))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		peers := r.Peers
+		if n := cctx.Int("peers"); n >= 0 && len(peers) > n {
+			peers = peers[:n]
+		}
+		if len(peers) > 0 {
+			fmt.Println()
+			tw = tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+			fmt.Fprintf(tw, "Peer\tBlocks\tMessages\n")
+			for _, p := range peers {
+				fmt.Fprintf(tw, "%s\t%d\t%d\n", p.ID, p.Blocks, p.Messages)
+			}
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+		}
+
+		if cctx.Bool("reset") {
+			return api.NetPropagationReset(ctx)
+		}
+		return nil
+	},
+}
+
 var NetBandwidthCmd = &cli.Command{
 	Name:  "bandwidth",
 	Usage: "Print bandwidth usage information",
@@ -1,18 +1,39 @@
 package cli
 
 import (
+	"fmt"
+
 	"github.com/docker/go-units"
-	paramfetch "github.com/filecoin-project/go-paramfetch"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/lib/proofparams"
 )
 
 var FetchParamCmd = &cli.Command{
 	Name:      "fetch-params",
 	Usage:     "Fetch proving parameters",
 	ArgsUsage: "[sectorSize]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "verify",
+			Usage: "only check the parameter files against their digests, without fetching them",
+		},
+		&cli.BoolFlag{
+			Name:  "prune",
+			Usage: "remove the parameter files of other sector sizes and older parameter versions",
+		},
+		&cli.StringSliceFlag{
+			Name:  "mirror",
+			Usage: "URL prefix which the CIDs of the files are appended to, can be repeated to fall back to other mirrors (default: IPFS_GATEWAY or " + proofparams.DefaultGateway + ")",
+		},
+		&cli.IntFlag{
+			Name:  "segments",
+			Usage: "number of parallel requests downloading each file",
+			Value: proofparams.DefaultSegments,
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		if !cctx.Args().Present() {
 			return xerrors.Errorf("must pass sector size to fetch params for (specify as \"32GiB\", for instance)")
@@ -23,9 +44,51 @@ var FetchParamCmd = &cli.Command{
 		}
 		sectorSize := uint64(sectorSizeInt)
 
-		err = paramfetch.GetParams(ReqContext(cctx), build.ParametersJSON(), build.SrsJSON(), sectorSize)
+		ctx := ReqContext(cctx)
+
+		m, err := proofparams.ParseManifest(build.ParametersJSON(), build.SrsJSON())
 		if err != nil {
-			return xerrors.Errorf("fetching proof parameters: %w", err)
+			return err
+		}
+		files := m.For(sectorSize)
+		dir := proofparams.Dir()
+
+		if cctx.Bool("verify") {
+			var bad int
+			for _, f := range files {
+				err := proofparams.Check(ctx, dir, f)
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				status := "ok"
+				if err != nil {
+					status = err.Error()
+					bad++
+				}
+				fmt.Printf("%s: %s\n", f.Name, status)
+			}
+			if bad > 0 {
+				return xerrors.Errorf("%d of %d parameter files failed verification", bad, len(files))
+			}
+		} else {
+			f := &proofparams.Fetcher{
+				Mirrors:  cctx.StringSlice("mirror"),
+				Segments: cctx.Int("segments"),
+			}
+			if err := f.Fetch(ctx, dir, files); err != nil {
+				return xerrors.Errorf("fetching proof parameters: %w", err)
+			}
+		}
+
+		if cctx.Bool("prune") {
+			removed, freed, err := proofparams.Prune(dir, files)
+			for _, name := range removed {
+				fmt.Printf("removed %s\n", name)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Printf("freed %s\n", units.BytesSize(float64(freed)))
 		}
 
 		return nil
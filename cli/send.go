@@ -56,10 +56,18 @@ var sendCmd = &cli.Command{
 			Name:  "params-hex",
 			Usage: "specify invocation parameters in hex",
 		},
+		&cli.StringFlag{
+			Name:  "label",
+			Usage: "label the message in the wallet history",
+		},
 		&cli.BoolFlag{
 			Name:  "force",
 			Usage: "Deprecated: use global 'force-send'",
 		},
+		&cli.BoolFlag{
+			Name:  "preview",
+			Usage: "print the type of the recipient actor and the failed safety checks, without sending",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		if cctx.IsSet("force") {
@@ -79,7 +87,7 @@ var sendCmd = &cli.Command{
 		ctx := ReqContext(cctx)
 		var params SendParams
 
-		params.To, err = address.NewFromString(cctx.Args().Get(0))
+		params.To, err = ResolveAddress(ctx, cctx, srv, cctx.Args().Get(0))
 		if err != nil {
 			return ShowHelp(cctx, fmt.Errorf("failed to parse target address: %w", err))
 		}
@@ -150,12 +158,22 @@ var sendCmd = &cli.Command{
 			return xerrors.Errorf("creating message prototype: %w", err)
 		}
 
+		if cctx.Bool("preview") {
+			return previewSend(ctx, cctx, srv, proto)
+		}
+
 		sm, err := InteractiveSend(ctx, cctx, srv, proto)
 		if err != nil {
 			return err
 		}
 
 		fmt.Fprintf(cctx.App.Writer, "%s\n", sm.Cid())
+
+		if cctx.String("label") != "" {
+			if err := srv.FullNodeAPI().WalletMessageLabelSet(ctx, sm.Cid(), cctx.String("label")); err != nil {
+				return xerrors.Errorf("labeling message: %w", err)
+			}
+		}
 		return nil
 	},
 }
@@ -64,4 +64,50 @@ func TestSendCLI(t *testing.T) {
 		assert.NoError(t, err)
 		assert.EqualValues(t, sigMsg.Cid().String()+"\n", buf.String())
 	})
+
+	t.Run("preview", func(t *testing.T) {
+		app, mockSrvcs, buf, done := newMockApp(t, sendCmd)
+		defer done()
+
+		arbtProto := &api.MessagePrototype{
+			Message: types.Message{
+				From:  mustAddr(address.NewIDAddress(1)),
+				To:    mustAddr(address.NewIDAddress(1000)),
+				Value: oneFil,
+			},
+		}
+		checks := [][]api.MessageCheckStatus{{
+			{
+				Cid: arbtProto.Message.Cid(),
+				CheckStatus: api.CheckStatus{
+					Code: api.CheckStatusMessageRecipient,
+					Err:  "recipient is a miner actor, funds sent to it can only be withdrawn by its owner",
+					Hint: map[string]interface{}{"actorType": "fil/5/storageminer"},
+				},
+			},
+			{
+				Cid: arbtProto.Message.Cid(),
+				CheckStatus: api.CheckStatus{
+					Code: api.CheckStatusMessageMinGas,
+					Err:  "not enough gas",
+				},
+			},
+		}}
+
+		gomock.InOrder(
+			mockSrvcs.EXPECT().MessageForSend(gomock.Any(), SendParams{
+				To:  mustAddr(address.NewIDAddress(1000)),
+				Val: oneFil,
+			}).Return(arbtProto, nil),
+			mockSrvcs.EXPECT().RunChecksForPrototype(gomock.Any(), arbtProto).
+				Return(checks, nil),
+			mockSrvcs.EXPECT().Close(),
+		)
+		err := app.Run([]string{"lotus", "send", "--preview", "t01000", "1"})
+		assert.NoError(t, err)
+		assert.EqualValues(t, "Recipient: t01000 (fil/5/storageminer)\n"+
+			"Value: 1 FIL\n"+
+			"current message failed a check MessageRecipient: recipient is a miner actor, funds sent to it can only be withdrawn by its owner\n",
+			buf.String())
+	})
 }
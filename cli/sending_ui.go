@@ -44,6 +44,47 @@ func InteractiveSend(ctx context.Context, cctx *cli.Context, srv ServicesAPI,
 	return msg, nil
 }
 
+// safetyChecks are the checks shown in send previews, other checks depend on
+// the gas estimation done when publishing the message
+var safetyChecks = map[api.CheckStatusCode]bool{
+	api.CheckStatusMessageRecipient: true,
+	api.CheckStatusMessageValue:     true,
+}
+
+func previewSend(ctx context.Context, cctx *cli.Context, srv ServicesAPI, proto *api.MessagePrototype) error {
+	checkGroups, err := srv.RunChecksForPrototype(ctx, proto)
+	if err != nil {
+		return xerrors.Errorf("running checks: %w", err)
+	}
+	printer := cctx.App.Writer
+	protoCid := proto.Message.Cid()
+
+	recipient := "no actor yet"
+	var failed []api.MessageCheckStatus
+	for _, checks := range checkGroups {
+		for _, c := range checks {
+			if !c.Cid.Equals(protoCid) || !safetyChecks[c.Code] {
+				continue
+			}
+			if t, ok := c.Hint["actorType"].(string); ok && c.Code == api.CheckStatusMessageRecipient {
+				recipient = t
+			}
+			if !c.OK {
+				failed = append(failed, c)
+			}
+		}
+	}
+
+	fmt.Fprintf(printer, "Recipient: %s (%s)\n", proto.Message.To, recipient)
+	fmt.Fprintf(printer, "Value: %s\n", types.FIL(proto.Message.Value))
+	if len(failed) == 0 {
+		fmt.Fprintf(printer, "Safety checks passed\n")
+		return nil
+	}
+	printChecks(printer, [][]api.MessageCheckStatus{failed}, protoCid)
+	return nil
+}
+
 var interactiveSolves = map[api.CheckStatusCode]bool{
 	api.CheckStatusMessageMinBaseFee:        true,
 	api.CheckStatusMessageBaseFee:           true,
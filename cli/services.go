@@ -46,6 +46,9 @@ type ServicesAPI interface {
 	MpoolPendingFilter(ctx context.Context, filter func(*types.SignedMessage) bool, tsk types.TipSetKey) ([]*types.SignedMessage, error)
 	MpoolCheckPendingMessages(ctx context.Context, a address.Address) ([][]api.MessageCheckStatus, error)
 
+	// AddressBookGet returns the address book entry of a name, see ResolveAddress
+	AddressBookGet(ctx context.Context, name string) (*api.AddressBookEntry, error)
+
 	// Close ends the session of services and disconnects from RPC, using Services after Close is called
 	// most likely will result in an error
 	// Should not be called concurrently
@@ -61,6 +64,10 @@ func (s *ServicesImpl) FullNodeAPI() api.FullNode {
 	return s.api
 }
 
+func (s *ServicesImpl) AddressBookGet(ctx context.Context, name string) (*api.AddressBookEntry, error) {
+	return s.api.AddressBookGet(ctx, name)
+}
+
 func (s *ServicesImpl) Close() error {
 	if s.closer == nil {
 		return xerrors.Errorf("Services already closed")
@@ -39,6 +39,21 @@ func (m *MockServicesAPI) EXPECT() *MockServicesAPIMockRecorder {
 	return m.recorder
 }
 
+// AddressBookGet mocks base method.
+func (m *MockServicesAPI) AddressBookGet(arg0 context.Context, arg1 string) (*api.AddressBookEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddressBookGet", arg0, arg1)
+	ret0, _ := ret[0].(*api.AddressBookEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddressBookGet indicates an expected call of AddressBookGet.
+func (mr *MockServicesAPIMockRecorder) AddressBookGet(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddressBookGet", reflect.TypeOf((*MockServicesAPI)(nil).AddressBookGet), arg0, arg1)
+}
+
 // Close mocks base method.
 func (m *MockServicesAPI) Close() error {
 	m.ctrl.T.Helper()
@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/hex"
@@ -11,14 +10,15 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
-	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/filecoin-project/lotus/api/v0api"
 
+	"github.com/docker/go-units"
 	"github.com/fatih/color"
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
 
@@ -27,17 +27,17 @@ import (
 	"github.com/multiformats/go-multiaddr"
 	"github.com/multiformats/go-multihash"
 	"github.com/urfave/cli/v2"
-	cbg "github.com/whyrusleeping/cbor-gen"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/go-state-types/network"
 
 	"github.com/filecoin-project/lotus/api"
 	lapi "github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
-	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/state"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -59,12 +59,16 @@ var StateCmd = &cli.Command{
 		StateListActorsCmd,
 		StateListMinersCmd,
 		StateCircSupplyCmd,
+		StatePledgeProjectionCmd,
 		StateSectorCmd,
 		StateGetActorCmd,
 		StateLookupIDCmd,
+		StateActorLifecycleCmd,
+		StateCreatedActorsCmd,
 		StateReplayCmd,
 		StateSectorSizeCmd,
 		StateReadStateCmd,
+		StateQueryCmd,
 		StateListMessagesCmd,
 		StateComputeStateCmd,
 		StateCallCmd,
@@ -75,6 +79,8 @@ var StateCmd = &cli.Command{
 		StateMarketCmd,
 		StateExecTraceCmd,
 		StateNtwkVersionCmd,
+		StateActorCIDsCmd,
+		StateActorMethodsCmd,
 		StateMinerProvingDeadlineCmd,
 	},
 }
@@ -325,6 +331,36 @@ var StateSectorsCmd = &cli.Command{
 	Name:      "sectors",
 	Usage:     "Query the sector set of a miner",
 	ArgsUsage: "[minerAddress]",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "activation-min",
+			Usage: "only list sectors activated at or after the epoch",
+		},
+		&cli.Int64Flag{
+			Name:  "activation-max",
+			Usage: "only list sectors activated at or before the epoch",
+		},
+		&cli.Int64Flag{
+			Name:  "expiration-min",
+			Usage: "only list sectors expiring at or after the epoch",
+		},
+		&cli.Int64Flag{
+			Name:  "expiration-max",
+			Usage: "only list sectors expiring at or before the epoch",
+		},
+		&cli.BoolFlag{
+			Name:  "with-deals",
+			Usage: "only list sectors holding deals",
+		},
+		&cli.Uint64Flag{
+			Name:  "deadline",
+			Usage: "only list the sectors of the deadline",
+		},
+		&cli.Uint64Flag{
+			Name:  "partition",
+			Usage: "only list the sectors of the partition of --deadline",
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -348,16 +384,37 @@ var StateSectorsCmd = &cli.Command{
 			return err
 		}
 
-		sectors, err := api.StateMinerSectors(ctx, maddr, nil, ts.Key())
-		if err != nil {
-			return err
+		q := lapi.MinerSectorsQuery{
+			ActivationMin: abi.ChainEpoch(cctx.Int64("activation-min")),
+			ActivationMax: abi.ChainEpoch(cctx.Int64("activation-max")),
+			ExpirationMin: abi.ChainEpoch(cctx.Int64("expiration-min")),
+			ExpirationMax: abi.ChainEpoch(cctx.Int64("expiration-max")),
+			WithDeals:     cctx.Bool("with-deals"),
 		}
-
-		for _, s := range sectors {
-			fmt.Printf("%d: %s\n", s.SectorNumber, s.SealedCID)
+		if cctx.IsSet("deadline") {
+			dl := cctx.Uint64("deadline")
+			q.Deadline = &dl
+		}
+		if cctx.IsSet("partition") {
+			part := cctx.Uint64("partition")
+			q.Partition = &part
 		}
 
-		return nil
+		for {
+			page, err := api.StateMinerSectorsPage(ctx, maddr, q, ts.Key())
+			if err != nil {
+				return err
+			}
+
+			for _, s := range page.Sectors {
+				fmt.Printf("%d: %s\n", s.SectorNumber, s.SealedCID)
+			}
+
+			if page.NextCursor == nil {
+				return nil
+			}
+			q.Cursor = *page.NextCursor
+		}
 	},
 }
 
@@ -852,6 +909,62 @@ var StateReadStateCmd = &cli.Command{
 	},
 }
 
+var StateQueryCmd = &cli.Command{
+	Name:      "query",
+	Usage:     "Query selected fields of the state of one or more actors",
+	ArgsUsage: "[actorAddress...]",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:     "field",
+			Usage:    "state field to query, e.g. AvailableBalance or DeadlineSummaries for miners; can be repeated",
+			Required: true,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must pass address of at least one actor to query")
+		}
+
+		var query []lapi.StateFieldQuery
+		for _, a := range cctx.Args().Slice() {
+			addr, err := address.NewFromString(a)
+			if err != nil {
+				return xerrors.Errorf("parsing address %q: %w", a, err)
+			}
+			query = append(query, lapi.StateFieldQuery{
+				Actor:  addr,
+				Fields: cctx.StringSlice("field"),
+			})
+		}
+
+		ts, err := LoadTipSet(ctx, cctx, api)
+		if err != nil {
+			return err
+		}
+
+		res, err := api.StateQuery(ctx, query, ts.Key())
+		if err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+
+		return nil
+	},
+}
+
 var StateListMessagesCmd = &cli.Command{
 	Name:  "list-messages",
 	Usage: "list messages on chain matching given criteria",
@@ -1380,31 +1493,22 @@ func sumGas(changes []*types.GasTrace) types.GasTrace {
 }
 
 func JsonParams(code cid.Cid, method abi.MethodNum, params []byte) (string, error) {
-	p, err := stmgr.GetParamType(code, method)
+	p, err := stmgr.DecodeParams(code, method, params)
 	if err != nil {
 		return "", err
 	}
 
-	if err := p.UnmarshalCBOR(bytes.NewReader(params)); err != nil {
-		return "", err
-	}
-
 	b, err := json.MarshalIndent(p, "", "  ")
 	return string(b), err
 }
 
 func jsonReturn(code cid.Cid, method abi.MethodNum, ret []byte) (string, error) {
-	methodMeta, found := stmgr.MethodsMap[code][method]
-	if !found {
-		return "", fmt.Errorf("method %d not found on actor %s", method, code)
-	}
-	re := reflect.New(methodMeta.Ret.Elem())
-	p := re.Interface().(cbg.CBORUnmarshaler)
-	if err := p.UnmarshalCBOR(bytes.NewReader(ret)); err != nil {
+	r, err := stmgr.DecodeReturn(code, method, ret)
+	if err != nil {
 		return "", err
 	}
 
-	b, err := json.MarshalIndent(p, "", "  ")
+	b, err := json.MarshalIndent(r, "", "  ")
 	return string(b), err
 }
 
@@ -1417,12 +1521,26 @@ var StateWaitMsgCmd = &cli.Command{
 			Name:  "timeout",
 			Value: "10m",
 		},
+		&cli.StringFlag{
+			Name:  "confidence",
+			Usage: "depth to wait for: soft, default, final or a number of epochs",
+			Value: string(lapi.ConfidenceDefault),
+		},
+		&cli.BoolFlag{
+			Name:  "watch",
+			Usage: "report execution, the confidence depth and finality as they happen",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		if !cctx.Args().Present() {
 			return fmt.Errorf("must specify message cid to wait for")
 		}
 
+		confidence, err := lapi.ParseConfidence(cctx.String("confidence"))
+		if err != nil {
+			return err
+		}
+
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
 			return err
@@ -1436,9 +1554,28 @@ var StateWaitMsgCmd = &cli.Command{
 			return err
 		}
 
-		mw, err := api.StateWaitMsg(ctx, msg, build.MessageConfidence)
-		if err != nil {
-			return err
+		var mw *lapi.MsgLookup
+		if cctx.Bool("watch") {
+			events, err := api.StateWatchMsg(ctx, msg, confidence, lapi.LookbackNoLimit, true)
+			if err != nil {
+				return err
+			}
+
+			for ev := range events {
+				if ev.Error != "" {
+					return xerrors.Errorf("waiting for %s message: %s", ev.Type, ev.Error)
+				}
+				fmt.Printf("%s: height %d, confidence %d\n", ev.Type, ev.Lookup.Height, ev.Confidence)
+				mw = ev.Lookup
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		} else {
+			mw, err = api.StateWaitMsg(ctx, msg, confidence)
+			if err != nil {
+				return err
+			}
 		}
 
 		m, err := api.ChainGetMessage(ctx, msg)
@@ -1663,6 +1800,10 @@ var StateCircSupplyCmd = &cli.Command{
 			Usage: "calculates the approximation of the circulating supply used internally by the VM (instead of the exact amount)",
 			Value: false,
 		},
+		&cli.BoolFlag{
+			Name:  "breakdown",
+			Usage: "show the components of the circulating supply, with locked funds split between pledge and deals",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
@@ -1678,6 +1819,22 @@ var StateCircSupplyCmd = &cli.Command{
 			return err
 		}
 
+		if cctx.Bool("breakdown") {
+			circ, err := api.StateCirculatingSupplyBreakdown(ctx, ts.Key())
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Circulating supply: ", types.FIL(circ.FilCirculating))
+			fmt.Println("Vested: ", types.FIL(circ.FilVested))
+			fmt.Println("Mined: ", types.FIL(circ.FilMined))
+			fmt.Println("Reserve disbursed: ", types.FIL(circ.FilReserveDisbursed))
+			fmt.Println("Burnt: ", types.FIL(circ.FilBurnt))
+			fmt.Println("Locked in pledge: ", types.FIL(circ.FilLockedPledge))
+			fmt.Println("Locked in deals: ", types.FIL(circ.FilLockedDeals))
+			return nil
+		}
+
 		if cctx.IsSet("vm-supply") {
 			circ, err := api.StateVMCirculatingSupplyInternal(ctx, ts.Key())
 			if err != nil {
@@ -1703,6 +1860,79 @@ var StateCircSupplyCmd = &cli.Command{
 	},
 }
 
+var StatePledgeProjectionCmd = &cli.Command{
+	Name:  "pledge-projection",
+	Usage: "Estimate the initial pledge for a sector committed at a future epoch",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "sector-size",
+			Usage: "size of the sector",
+			Value: "32GiB",
+		},
+		&cli.BoolFlag{
+			Name:  "verified",
+			Usage: "project the pledge for a sector filled with verified deals",
+		},
+		&cli.Int64Flag{
+			Name:  "epoch",
+			Usage: "epoch to project the pledge at (default: the current epoch)",
+		},
+		&cli.Int64Flag{
+			Name:  "days",
+			Usage: "project the pledge this many days from now, instead of at a given epoch",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		ts, err := LoadTipSet(ctx, cctx, api)
+		if err != nil {
+			return err
+		}
+
+		sectorSizeInt, err := units.RAMInBytes(cctx.String("sector-size"))
+		if err != nil {
+			return xerrors.Errorf("error parsing sector size (specify as \"32GiB\", for instance): %w", err)
+		}
+		ssize := abi.SectorSize(sectorSizeInt)
+
+		verifiedWeight := big.Zero()
+		if cctx.Bool("verified") {
+			verifiedWeight = big.NewInt(int64(ssize))
+		}
+		qaPower := builtin.QAPowerForWeight(ssize, 1, big.Zero(), verifiedWeight)
+
+		epoch := ts.Height()
+		switch {
+		case cctx.IsSet("epoch") && cctx.IsSet("days"):
+			return xerrors.Errorf("only one of --epoch and --days can be set")
+		case cctx.IsSet("epoch"):
+			epoch = abi.ChainEpoch(cctx.Int64("epoch"))
+		case cctx.IsSet("days"):
+			epoch += abi.ChainEpoch(cctx.Int64("days")) * builtin.EpochsInDay
+		}
+
+		proj, err := api.StateSectorPledgeProjection(ctx, qaPower, epoch, ts.Key())
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Epoch: %d (%d epochs from now)\n", proj.Epoch, proj.Epoch-ts.Height())
+		fmt.Printf("Sector QA power: %s\n", types.SizeStr(proj.QAPower))
+		fmt.Printf("Initial pledge: %s\n", types.FIL(proj.InitialPledge))
+		fmt.Printf("Network QA power: %s\n", types.SizeStr(proj.NetworkQAPower))
+		fmt.Printf("Circulating supply: %s\n", types.FIL(proj.FilCirculating))
+		fmt.Printf("Trend sampled over: %d epochs\n", proj.SampleEpochs)
+		return nil
+	},
+}
+
 var StateSectorCmd = &cli.Command{
 	Name:      "sector",
 	Usage:     "Get miner sector info",
@@ -1847,3 +2077,241 @@ var StateNtwkVersionCmd = &cli.Command{
 		return nil
 	},
 }
+
+var StateActorCIDsCmd = &cli.Command{
+	Name:  "actor-cids",
+	Usage: "Print the code CIDs of the builtin actors",
+	Flags: []cli.Flag{
+		&cli.UintFlag{
+			Name:  "network-version",
+			Usage: "network version to print the actors of, defaults to the one of the tipset",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		nv, err := cliNetworkVersion(ctx, cctx, api)
+		if err != nil {
+			return err
+		}
+
+		codes, err := api.StateActorCodeCIDs(ctx, nv)
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(codes))
+		for name := range codes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Printf("Network Version: %d\n", nv)
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		for _, name := range names {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\n", name, codes[name])
+		}
+		return tw.Flush()
+	},
+}
+
+var StateActorMethodsCmd = &cli.Command{
+	Name:      "actor-methods",
+	Usage:     "List the methods of an actor, with their params and return types",
+	ArgsUsage: "[actor name or code CID]",
+	Flags: []cli.Flag{
+		&cli.UintFlag{
+			Name:  "network-version",
+			Usage: "network version to resolve actor names at, defaults to the one of the tipset",
+		},
+		&cli.BoolFlag{
+			Name:  "fields",
+			Usage: "print the fields of struct params and return values",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return ShowHelp(cctx, fmt.Errorf("must pass an actor name or code CID"))
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		code, err := cid.Decode(cctx.Args().First())
+		if err != nil {
+			nv, err := cliNetworkVersion(ctx, cctx, api)
+			if err != nil {
+				return err
+			}
+
+			codes, err := api.StateActorCodeCIDs(ctx, nv)
+			if err != nil {
+				return err
+			}
+
+			var ok bool
+			if code, ok = codes[cctx.Args().First()]; !ok {
+				return xerrors.Errorf("no actor named %q at network version %d", cctx.Args().First(), nv)
+			}
+		}
+
+		methods, err := api.StateActorMethods(ctx, code)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Num\tName\tParams\tReturn")
+		for _, m := range methods {
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", m.Num, m.Name, m.Params, m.Return)
+			if cctx.Bool("fields") {
+				for _, f := range m.ParamsFields {
+					_, _ = fmt.Fprintf(tw, "\t\t  %s %s\t\n", f.Name, f.Type)
+				}
+				for _, f := range m.ReturnFields {
+					_, _ = fmt.Fprintf(tw, "\t\t\t  %s %s\n", f.Name, f.Type)
+				}
+			}
+		}
+		return tw.Flush()
+	},
+}
+
+// cliNetworkVersion returns the --network-version flag, or the network
+// version at the tipset
+func cliNetworkVersion(ctx context.Context, cctx *cli.Context, api v0api.FullNode) (network.Version, error) {
+	if cctx.IsSet("network-version") {
+		return network.Version(cctx.Uint("network-version")), nil
+	}
+
+	ts, err := LoadTipSet(ctx, cctx, api)
+	if err != nil {
+		return 0, err
+	}
+	return api.StateNetworkVersion(ctx, ts.Key())
+}
+
+var StateActorLifecycleCmd = &cli.Command{
+	Name:      "actor-lifecycle",
+	Usage:     "Show when an actor was created and deleted, from the actor index",
+	ArgsUsage: "[address]",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		if cctx.Args().Len() != 1 {
+			return fmt.Errorf("must pass the address of the actor")
+		}
+
+		addr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		al, err := api.StateActorLifecycle(ctx, addr)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("ID:\t\t%s\n", al.ID)
+		if al.Robust != address.Undef {
+			fmt.Printf("Robust:\t\t%s\n", al.Robust)
+		}
+		if al.Code.Defined() {
+			fmt.Printf("Type:\t\t%s\n", builtin.ActorNameByCode(al.Code))
+		}
+
+		switch {
+		case al.Created < 0:
+			fmt.Printf("Created:\tbefore the indexed range\n")
+		case al.CreatedBy != nil:
+			fmt.Printf("Created:\t%d (message %s)\n", al.Created, *al.CreatedBy)
+		default:
+			fmt.Printf("Created:\t%d\n", al.Created)
+		}
+		if al.Deleted >= 0 {
+			fmt.Printf("Deleted:\t%d\n", al.Deleted)
+		}
+
+		return nil
+	},
+}
+
+var StateCreatedActorsCmd = &cli.Command{
+	Name:  "created-actors",
+	Usage: "List the actors created in a range of epochs, from the actor index",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:     "from",
+			Usage:    "first epoch of the range",
+			Required: true,
+		},
+		&cli.Int64Flag{
+			Name:  "to",
+			Usage: "last epoch of the range, the head by default",
+		},
+		&cli.StringFlag{
+			Name:  "type",
+			Usage: "only list actors of the type, e.g. storageminer, paymentchannel, multisig",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		to := abi.ChainEpoch(cctx.Int64("to"))
+		if !cctx.IsSet("to") {
+			head, err := api.ChainHead(ctx)
+			if err != nil {
+				return err
+			}
+			to = head.Height()
+		}
+
+		actors, err := api.StateListCreatedActors(ctx, abi.ChainEpoch(cctx.Int64("from")), to)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "ID\tRobust\tType\tCreated\tDeleted")
+		for _, al := range actors {
+			var name string
+			if al.Code.Defined() {
+				name = builtin.ActorNameByCode(al.Code)
+			}
+			if t := cctx.String("type"); t != "" && !strings.HasSuffix(name, "/"+t) {
+				continue
+			}
+
+			robust, deleted := "-", "-"
+			if al.Robust != address.Undef {
+				robust = al.Robust.String()
+			}
+			if al.Deleted >= 0 {
+				deleted = fmt.Sprint(al.Deleted)
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", al.ID, robust, name, al.Created, deleted)
+		}
+		return tw.Flush()
+	},
+}
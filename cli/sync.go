@@ -3,6 +3,8 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
+	"text/tabwriter"
 	"time"
 
 	"github.com/filecoin-project/lotus/chain/types"
@@ -26,6 +28,56 @@ var SyncCmd = &cli.Command{
 		SyncUnmarkBadCmd,
 		SyncCheckBadCmd,
 		SyncCheckpointCmd,
+		SyncTimingCmd,
+	},
+}
+
+var SyncTimingCmd = &cli.Command{
+	Name:  "timing",
+	Usage: "show how long validating recently synced tipsets took",
+	Action: func(cctx *cli.Context) error {
+		apic, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		vt, err := apic.SyncValidationTiming(ctx)
+		if err != nil {
+			return err
+		}
+
+		if vt.Budget > 0 {
+			fmt.Printf("validation time of the last %d tipsets (budget %s):\n", vt.Tipsets, vt.Budget)
+		} else {
+			fmt.Printf("validation time of the last %d tipsets:\n", vt.Tipsets)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "\tTotal\tSignatures\tExecution\tProofs\n")
+		for i := range vt.Total {
+			bucket := fmt.Sprintf("> %s", vt.Bounds[len(vt.Bounds)-1])
+			if i < len(vt.Bounds) {
+				bucket = fmt.Sprintf("<= %s", vt.Bounds[i])
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", bucket, vt.Total[i], vt.Signatures[i], vt.Execution[i], vt.Proofs[i])
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		printTiming := func(name string, t *api.ValidationTiming) {
+			if t == nil {
+				return
+			}
+			fmt.Printf("%s: height %d took %s (signatures %s, execution %s, proofs %s)\n",
+				name, t.Height, t.Total, t.Signatures, t.Execution, t.Proofs)
+		}
+		printTiming("latest", vt.Latest)
+		printTiming("slowest", vt.Slowest)
+
+		return nil
 	},
 }
 
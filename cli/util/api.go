@@ -203,6 +203,10 @@ func GetFullNodeAPIV1(ctx *cli.Context) (v1api.FullNode, jsonrpc.ClientCloser, e
 
 type GetStorageMinerOptions struct {
 	PreferHttp bool
+
+	// DialURL returns the URL to dial the miner API at, see
+	// rpctls.Certs.ClientURL
+	DialURL func(string) (string, error)
 }
 
 type GetStorageMinerOption func(*GetStorageMinerOptions)
@@ -211,6 +215,12 @@ func StorageMinerUseHttp(opts *GetStorageMinerOptions) {
 	opts.PreferHttp = true
 }
 
+func StorageMinerDialURL(f func(string) (string, error)) GetStorageMinerOption {
+	return func(opts *GetStorageMinerOptions) {
+		opts.DialURL = f
+	}
+}
+
 func GetStorageMinerAPI(ctx *cli.Context, opts ...GetStorageMinerOption) (api.StorageMiner, jsonrpc.ClientCloser, error) {
 	var options GetStorageMinerOptions
 	for _, opt := range opts {
@@ -242,6 +252,13 @@ func GetStorageMinerAPI(ctx *cli.Context, opts ...GetStorageMinerOption) (api.St
 		addr = u.String()
 	}
 
+	if options.DialURL != nil {
+		addr, err = options.DialURL(addr)
+		if err != nil {
+			return nil, nil, xerrors.Errorf("miner api URL: %w", err)
+		}
+	}
+
 	return client.NewStorageMinerRPCV0(ctx.Context, addr, headers)
 }
 
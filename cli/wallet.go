@@ -36,6 +36,9 @@ var walletCmd = &cli.Command{
 		walletVerify,
 		walletDelete,
 		walletMarket,
+		walletLabel,
+		walletExportHistory,
+		walletAddressBook,
 	},
 }
 
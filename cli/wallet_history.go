@@ -0,0 +1,299 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var walletLabel = &cli.Command{
+	Name:      "label",
+	Usage:     "Label an address or a message, or list the labels",
+	ArgsUsage: "[address or message CID] [label]",
+	Description: `Labels are stored by the node, and shown in the wallet history. Without
+   arguments, all labels are listed.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "remove",
+			Usage: "remove the label of the address or message",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if !cctx.Args().Present() {
+			labels, err := api.WalletLabels(ctx)
+			if err != nil {
+				return err
+			}
+
+			tw := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+			for _, l := range []map[string]string{labels.Addresses, labels.Messages} {
+				keys := make([]string, 0, len(l))
+				for k := range l {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+
+				for _, k := range keys {
+					_, _ = fmt.Fprintf(tw, "%s\t%s\n", k, l[k])
+				}
+			}
+			return tw.Flush()
+		}
+
+		var label string
+		switch {
+		case cctx.Bool("remove") && cctx.Args().Len() == 1:
+		case !cctx.Bool("remove") && cctx.Args().Len() == 2:
+			label = cctx.Args().Get(1)
+			if label == "" {
+				return xerrors.Errorf("label can't be empty, use --remove to remove it")
+			}
+		default:
+			return ShowHelp(cctx, xerrors.Errorf("expected an address or message CID, and a label unless removing"))
+		}
+
+		if addr, err := address.NewFromString(cctx.Args().First()); err == nil {
+			return api.WalletAddressLabelSet(ctx, addr, label)
+		}
+
+		msg, err := cid.Parse(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("%q is neither an address nor a message CID", cctx.Args().First())
+		}
+		return api.WalletMessageLabelSet(ctx, msg, label)
+	},
+}
+
+// historyEntry is a message sent or received by the wallet
+type historyEntry struct {
+	Height    abi.ChainEpoch
+	Time      time.Time
+	Cid       cid.Cid
+	Direction string
+	From, To  address.Address
+	Method    string
+	Value     abi.TokenAmount
+	// GasFee is the total gas cost of messages sent by the wallet
+	GasFee   abi.TokenAmount
+	ExitCode string
+}
+
+var walletExportHistory = &cli.Command{
+	Name:      "export-history",
+	Usage:     "Export the messages sent and received by wallet addresses",
+	ArgsUsage: "[addresses (default: all wallet addresses)]",
+	Description: `The history is built by walking the chain back from the current head, down to
+   the --from epoch, which the node must have the messages of.
+
+   Value is positive for received funds, negative for sent funds. Gas fees are
+   the total cost of the gas of sent messages, including burnt funds.`,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "from",
+			Usage: "earliest epoch to export",
+		},
+		&cli.BoolFlag{
+			Name:  "csv",
+			Usage: "print the history in the CSV format",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		var addrs []address.Address
+		for _, s := range cctx.Args().Slice() {
+			a, err := address.NewFromString(s)
+			if err != nil {
+				return xerrors.Errorf("parsing address %q: %w", s, err)
+			}
+			addrs = append(addrs, a)
+		}
+		if len(addrs) == 0 {
+			addrs, err = api.WalletList(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		head, err := api.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+
+		// messages reference both key and ID addresses
+		own := map[address.Address]struct{}{}
+		for _, a := range addrs {
+			own[a] = struct{}{}
+			if id, err := api.StateLookupID(ctx, a, head.Key()); err == nil {
+				own[id] = struct{}{}
+			}
+		}
+
+		msgs := map[cid.Cid]struct{}{}
+		for _, a := range addrs {
+			for _, match := range []*lapi.MessageMatch{{From: a}, {To: a}} {
+				found, err := api.StateListMessages(ctx, match, head.Key(), abi.ChainEpoch(cctx.Int64("from")))
+				if err != nil {
+					return xerrors.Errorf("listing messages of %s: %w", a, err)
+				}
+				for _, c := range found {
+					msgs[c] = struct{}{}
+				}
+			}
+		}
+
+		codes := map[address.Address]cid.Cid{}
+		times := map[types.TipSetKey]time.Time{}
+
+		entries := make([]historyEntry, 0, len(msgs))
+		for c := range msgs {
+			m, err := api.ChainGetMessage(ctx, c)
+			if err != nil {
+				return xerrors.Errorf("getting message %s: %w", c, err)
+			}
+
+			lookup, err := api.StateSearchMsg(ctx, c)
+			if err != nil {
+				return xerrors.Errorf("searching message %s: %w", c, err)
+			}
+			if lookup == nil {
+				continue // not executed yet
+			}
+
+			ts, ok := times[lookup.TipSet]
+			if !ok {
+				t, err := api.ChainGetTipSet(ctx, lookup.TipSet)
+				if err != nil {
+					return xerrors.Errorf("getting tipset of message %s: %w", c, err)
+				}
+				ts = time.Unix(int64(t.MinTimestamp()), 0)
+				times[lookup.TipSet] = ts
+			}
+
+			_, out := own[m.From]
+			_, in := own[m.To]
+
+			e := historyEntry{
+				Height:   lookup.Height,
+				Time:     ts,
+				Cid:      c,
+				From:     m.From,
+				To:       m.To,
+				Method:   fmt.Sprint(m.Method),
+				Value:    m.Value,
+				GasFee:   big.Zero(),
+				ExitCode: lookup.Receipt.ExitCode.String(),
+			}
+			switch {
+			case out && in:
+				e.Direction = "self"
+				e.Value = big.Zero()
+			case out:
+				e.Direction = "out"
+				e.Value = big.Neg(m.Value)
+			default:
+				e.Direction = "in"
+			}
+			if lookup.Receipt.ExitCode != 0 {
+				e.Value = big.Zero() // failed messages don't transfer funds
+			}
+
+			if out {
+				res, err := api.StateReplay(ctx, types.EmptyTSK, c)
+				if err != nil {
+					return xerrors.Errorf("replaying message %s: %w", c, err)
+				}
+				e.GasFee = res.GasCost.TotalCost
+			}
+
+			code, ok := codes[m.To]
+			if !ok {
+				if act, err := api.StateGetActor(ctx, m.To, head.Key()); err == nil {
+					code = act.Code
+				}
+				codes[m.To] = code
+			}
+			if name := getMethod(code, m.Method); name != "" {
+				e.Method = name
+			}
+
+			entries = append(entries, e)
+		}
+
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].Height != entries[j].Height {
+				return entries[i].Height < entries[j].Height
+			}
+			return entries[i].Cid.String() < entries[j].Cid.String()
+		})
+
+		labels, err := api.WalletLabels(ctx)
+		if err != nil {
+			return err
+		}
+
+		header := []string{"Height", "Time", "Message", "Direction", "From", "From Label", "To", "To Label", "Method", "Value", "Gas Fee", "Exit Code", "Label"}
+		rows := make([][]string, 0, len(entries))
+		for _, e := range entries {
+			rows = append(rows, []string{
+				fmt.Sprint(e.Height),
+				e.Time.UTC().Format(time.RFC3339),
+				e.Cid.String(),
+				e.Direction,
+				e.From.String(),
+				labels.Addresses[e.From.String()],
+				e.To.String(),
+				labels.Addresses[e.To.String()],
+				e.Method,
+				types.FIL(e.Value).Unitless(),
+				types.FIL(e.GasFee).Unitless(),
+				e.ExitCode,
+				labels.Messages[e.Cid.String()],
+			})
+		}
+
+		if cctx.Bool("csv") {
+			w := csv.NewWriter(cctx.App.Writer)
+			if err := w.Write(header); err != nil {
+				return err
+			}
+			if err := w.WriteAll(rows); err != nil {
+				return err
+			}
+			return w.Error()
+		}
+
+		tw := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, r := range rows {
+			_, _ = fmt.Fprintln(tw, strings.Join(r, "\t"))
+		}
+		return tw.Flush()
+	},
+}
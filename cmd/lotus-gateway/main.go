@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/urfave/cli/v2"
 	"go.opencensus.io/stats/view"
@@ -133,6 +135,33 @@ var runCmd = &cli.Command{
 			Usage: "maximum number of blocks to search back through for message inclusion",
 			Value: int64(gateway.DefaultStateWaitLookbackLimit),
 		},
+		&cli.Float64Flag{
+			Name:  "rate-limit",
+			Usage: "maximum request cost per second per client (valid token or IP); 0 disables rate limiting",
+		},
+		&cli.IntFlag{
+			Name:  "rate-limit-burst",
+			Usage: "maximum request cost a client can spend at once",
+			Value: 100,
+		},
+		&cli.StringSliceFlag{
+			Name:  "rate-limit-method-cost",
+			Usage: "override the rate limiting cost of a method, as Method=cost",
+		},
+		&cli.BoolFlag{
+			Name:  "rate-limit-trust-forwarded-for",
+			Usage: "identify clients by the X-Forwarded-For header, when running behind a reverse proxy",
+		},
+		&cli.IntFlag{
+			Name:  "cache-size",
+			Usage: "number of cached responses of hot read-only methods; 0 disables caching",
+			Value: gateway.DefaultCacheSize,
+		},
+		&cli.DurationFlag{
+			Name:  "cache-ttl",
+			Usage: "how long responses depending on the chain head are cached",
+			Value: gateway.DefaultCacheTTL,
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		log.Info("Starting lotus gateway")
@@ -173,8 +202,32 @@ var runCmd = &cli.Command{
 			return xerrors.Errorf("failed to convert endpoint address to multiaddr: %w", err)
 		}
 
-		gwapi := gateway.NewNode(api, lookbackCap, waitLookback)
-		h, err := gateway.Handler(gwapi, serverOptions...)
+		var rl *gateway.RateLimiter
+		if rate := cctx.Float64("rate-limit"); rate > 0 {
+			costs := map[string]int{}
+			for _, mc := range cctx.StringSlice("rate-limit-method-cost") {
+				parts := strings.SplitN(mc, "=", 2)
+				if len(parts) != 2 {
+					return xerrors.Errorf("method cost %q must be in Method=cost form", mc)
+				}
+				cost, err := strconv.Atoi(parts[1])
+				if err != nil || cost < 0 {
+					return xerrors.Errorf("method cost %q: invalid cost", mc)
+				}
+				costs[parts[0]] = cost
+			}
+
+			rl = gateway.NewRateLimiter(gateway.RateLimitConfig{
+				Rate:              rate,
+				Burst:             cctx.Int("rate-limit-burst"),
+				MethodCosts:       costs,
+				TrustForwardedFor: cctx.Bool("rate-limit-trust-forwarded-for"),
+				Verify:            api.AuthVerify,
+			})
+		}
+
+		gwapi := gateway.NewNode(api, lookbackCap, waitLookback, gateway.WithCache(cctx.Int("cache-size"), cctx.Duration("cache-ttl")))
+		h, err := gateway.Handler(gwapi, rl, serverOptions...)
 		if err != nil {
 			return xerrors.Errorf("failed to set up gateway HTTP handler")
 		}
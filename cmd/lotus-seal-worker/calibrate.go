@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/mitchellh/go-homedir"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	paramfetch "github.com/filecoin-project/go-paramfetch"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper"
+	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper/basicfs"
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+const calibrationFile = "calibration.json"
+
+var calibrateCmd = &cli.Command{
+	Name:  "calibrate",
+	Usage: "Measure task durations and memory usage on this machine",
+	Description: `Seals a small sector, measuring each step, and saves the measurements in
+the worker repo. Once restarted, the worker reports them to the miner, and the
+scheduler uses them instead of static resource estimates.
+
+Calibrate while the worker isn't running tasks, as they would skew the results.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "sector-size",
+			Usage: "size of the sector to calibrate with",
+			Value: "512MiB",
+		},
+		&cli.StringFlag{
+			Name:  "tmpdir",
+			Usage: "directory for sector data; defaults to a temporary directory in the worker repo",
+		},
+		&cli.BoolFlag{
+			Name:  "skip-commit2",
+			Usage: "skip commit2, e.g. on workers which don't run it",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.ReqContext(cctx)
+
+		if !cctx.Bool("enable-gpu-proving") {
+			if err := os.Setenv("BELLMAN_NO_GPU", "true"); err != nil {
+				return xerrors.Errorf("could not set no-gpu env: %+v", err)
+			}
+		}
+
+		repoPath, err := homedir.Expand(cctx.String(FlagWorkerRepo))
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(repoPath); err != nil {
+			return xerrors.Errorf("worker repo %s: %w", repoPath, err)
+		}
+
+		sectorSizeInt, err := units.RAMInBytes(cctx.String("sector-size"))
+		if err != nil {
+			return err
+		}
+		ssize := abi.SectorSize(sectorSizeInt)
+
+		spt, err := miner.SealProofTypeFromSectorSize(ssize, build.NewestNetworkVersion)
+		if err != nil {
+			return err
+		}
+
+		if err := paramfetch.GetParams(ctx, build.ParametersJSON(), build.SrsJSON(), uint64(ssize)); err != nil {
+			return xerrors.Errorf("get params: %w", err)
+		}
+
+		tmpdir := cctx.String("tmpdir")
+		if tmpdir == "" {
+			tmpdir = repoPath
+		}
+		sbdir, err := ioutil.TempDir(tmpdir, "calibrate")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := os.RemoveAll(sbdir); err != nil {
+				log.Warnf("removing calibration data: %s", err)
+			}
+		}()
+
+		sb, err := ffiwrapper.New(&basicfs.Provider{Root: sbdir})
+		if err != nil {
+			return err
+		}
+
+		res, err := calibrate(ctx, sb, spt, ssize, cctx.Bool("skip-commit2"))
+		if err != nil {
+			return xerrors.Errorf("calibrating: %w", err)
+		}
+
+		for _, c := range res {
+			mem := "unknown"
+			if c.PeakMemory > 0 {
+				mem = types.SizeStr(types.NewInt(c.PeakMemory))
+			}
+			fmt.Printf("%s:\t%s\tpeak memory: %s\n", c.Task.Short(), c.Duration.Truncate(time.Millisecond), mem)
+		}
+
+		b, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(repoPath, calibrationFile), b, 0644); err != nil {
+			return xerrors.Errorf("saving calibration: %w", err)
+		}
+
+		fmt.Println("Saved; restart the worker to report the calibration to the miner")
+		return nil
+	},
+}
+
+func calibrate(ctx context.Context, sb *ffiwrapper.Sealer, spt abi.RegisteredSealProof, ssize abi.SectorSize, skipc2 bool) ([]storiface.TaskCalibration, error) {
+	sid := storage.SectorRef{
+		ID:        abi.SectorID{Miner: 1000, Number: 1},
+		ProofType: spt,
+	}
+
+	var out []storiface.TaskCalibration
+	measure := func(tt sealtasks.TaskType, cb func() error) error {
+		log.Infof("calibrating %s", tt.Short())
+
+		before := resetPeakMemory()
+		start := time.Now()
+		if err := cb(); err != nil {
+			return xerrors.Errorf("%s: %w", tt.Short(), err)
+		}
+
+		c := storiface.TaskCalibration{
+			Task:      tt,
+			SealProof: spt,
+			Duration:  time.Since(start),
+			Measured:  time.Now(),
+		}
+		if peak := peakMemory(); before > 0 && peak > before {
+			c.PeakMemory = peak - before
+		}
+		out = append(out, c)
+		return nil
+	}
+
+	var piece abi.PieceInfo
+	err := measure(sealtasks.TTAddPiece, func() (err error) {
+		r := rand.New(rand.NewSource(100))
+		piece, err = sb.AddPiece(ctx, sid, nil, abi.PaddedPieceSize(ssize).Unpadded(), r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ticket := abi.SealRandomness(make([]byte, 32))
+	pieces := []abi.PieceInfo{piece}
+
+	var pc1o storage.PreCommit1Out
+	err = measure(sealtasks.TTPreCommit1, func() (err error) {
+		pc1o, err = sb.SealPreCommit1(ctx, sid, ticket, pieces)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var cids storage.SectorCids
+	err = measure(sealtasks.TTPreCommit2, func() (err error) {
+		cids, err = sb.SealPreCommit2(ctx, sid, pc1o)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seed := abi.InteractiveSealRandomness(make([]byte, 32))
+
+	var c1o storage.Commit1Out
+	err = measure(sealtasks.TTCommit1, func() (err error) {
+		c1o, err = sb.SealCommit1(ctx, sid, ticket, seed, pieces, cids)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !skipc2 {
+		err = measure(sealtasks.TTCommit2, func() error {
+			_, err := sb.SealCommit2(ctx, sid, c1o)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// resetPeakMemory resets the peak resident memory of the process, and returns
+// the current resident memory; 0 when memory can't be measured, e.g. on
+// systems other than Linux.
+func resetPeakMemory() uint64 {
+	if err := ioutil.WriteFile("/proc/self/clear_refs", []byte("5"), 0); err != nil {
+		return 0
+	}
+	return procStatusMemory("VmRSS")
+}
+
+func peakMemory() uint64 {
+	return procStatusMemory("VmHWM")
+}
+
+func procStatusMemory(field string) uint64 {
+	b, err := ioutil.ReadFile("/proc/self/status")
+	if err != nil {
+		return 0
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		// e.g. "VmHWM:     1234 kB"
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 3 || fields[0] != field+":" {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0
+		}
+		return kb << 10
+	}
+	return 0
+}
+
+// loadCalibration reads calibration saved in the worker repo, if any.
+func loadCalibration(repoPath string) ([]storiface.TaskCalibration, error) {
+	b, err := ioutil.ReadFile(filepath.Join(repoPath, calibrationFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var out []storiface.TaskCalibration
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, xerrors.Errorf("decoding %s: %w", calibrationFile, err)
+	}
+	return out, nil
+}
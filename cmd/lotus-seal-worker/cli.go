@@ -1,10 +1,16 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 
 	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
 )
 
 var setCmd = &cli.Command{
@@ -49,3 +55,95 @@ var waitQuietCmd = &cli.Command{
 		return api.WaitQuiet(ctx)
 	},
 }
+
+var drainCmd = &cli.Command{
+	Name:  "drain",
+	Usage: "Stop taking new tasks, wait for running tasks to finish and shut the worker down",
+	Description: `Drain disables the worker, so that the miner doesn't schedule new tasks on it,
+then waits for the tasks already running to finish. Once the worker is idle it
+deregisters from the miner and the worker process exits, releasing its repo.
+
+Use this before restarting workers, so that long running tasks like PreCommit1
+aren't aborted.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "shutdown",
+			Usage: "stop the worker process once drained",
+			Value: true,
+		},
+		&cli.DurationFlag{
+			Name:  "interval",
+			Usage: "how often to print the running tasks",
+			Value: 30 * time.Second,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetWorkerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		if err := api.SetEnabled(ctx, false); err != nil {
+			return xerrors.Errorf("SetEnabled: %w", err)
+		}
+
+		// tasks can still be assigned until the scheduler checks the worker
+		// session on the next heartbeat
+		fmt.Println("Worker disabled, waiting for the scheduler to notice")
+		select {
+		case <-time.After(stores.HeartbeatInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		for {
+			running, err := api.RunningTasks(ctx)
+			if err != nil {
+				return xerrors.Errorf("getting running tasks: %w", err)
+			}
+			if len(running) == 0 {
+				break
+			}
+
+			fmt.Printf("%s: waiting for %d running tasks\n", time.Now().Format(time.Stamp), len(running))
+
+			tw := tablewriter.New(
+				tablewriter.Col("Sector"),
+				tablewriter.Col("Task"),
+				tablewriter.Col("Time"))
+			for _, job := range running {
+				tw.Write(map[string]interface{}{
+					"Sector": job.Sector.Number,
+					"Task":   job.Task.Short(),
+					"Time":   time.Since(job.Start).Truncate(time.Second),
+				})
+			}
+			if err := tw.Flush(os.Stdout); err != nil {
+				return err
+			}
+
+			select {
+			case <-time.After(cctx.Duration("interval")):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		fmt.Println("No tasks running")
+
+		if !cctx.Bool("shutdown") {
+			fmt.Println("Worker drained; it stays disabled until re-enabled with 'lotus-worker set --enabled'")
+			return nil
+		}
+
+		if err := api.Shutdown(ctx); err != nil {
+			return xerrors.Errorf("shutting down worker: %w", err)
+		}
+
+		fmt.Println("Worker shutting down")
+		return nil
+	},
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
@@ -67,6 +69,32 @@ var infoCmd = &cli.Command{
 		}
 		fmt.Println()
 
+		gpus, err := api.GPUUsage(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting GPU usage: %w", err)
+		}
+
+		for _, gpu := range gpus {
+			assigned := "any"
+			if len(gpu.Tasks) > 0 {
+				var names []string
+				for _, t := range gpu.Tasks {
+					names = append(names, t.Short())
+				}
+				assigned = strings.Join(names, " ")
+			}
+
+			running := "idle"
+			if gpu.Task != "" {
+				running = fmt.Sprintf("running %s for %s", gpu.Task.Short(), time.Since(gpu.Since).Truncate(time.Second))
+				if gpu.Task != sealtasks.TTGenerateWindowPoSt && gpu.Task != sealtasks.TTGenerateWinningPoSt {
+					running += fmt.Sprintf(" (sector %d)", gpu.Sector.Number)
+				}
+			}
+
+			fmt.Printf("GPU %d: %s; tasks: %s; %s; utilization: %.1f%%\n", gpu.Index, gpu.Device, assigned, running, gpu.Utilization*100)
+		}
+
 		fmt.Println()
 
 		paths, err := api.Paths(ctx)
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,10 +34,13 @@ import (
 	lcli "github.com/filecoin-project/lotus/cli"
 	cliutil "github.com/filecoin-project/lotus/cli/util"
 	sectorstorage "github.com/filecoin-project/lotus/extern/sector-storage"
+	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper/proverrpc"
 	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
 	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
 	"github.com/filecoin-project/lotus/lib/lotuslog"
 	"github.com/filecoin-project/lotus/lib/rpcenc"
+	"github.com/filecoin-project/lotus/lib/rpctls"
+	"github.com/filecoin-project/lotus/lib/tunnel"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/node/modules"
 	"github.com/filecoin-project/lotus/node/repo"
@@ -59,7 +64,10 @@ func main() {
 		storageCmd,
 		setCmd,
 		waitQuietCmd,
+		drainCmd,
 		tasksCmd,
+		calibrateCmd,
+		proverCmd,
 	}
 
 	app := &cli.App{
@@ -113,6 +121,29 @@ var runCmd = &cli.Command{
 			Name:   "address",
 			Hidden: true,
 		},
+		&cli.StringFlag{
+			Name:  "tls-listen",
+			Usage: "host address and port the worker api will listen on with mutual TLS, used by the miner when set; --listen must then be a loopback address",
+		},
+		&cli.StringFlag{
+			Name:    "tls-cert",
+			Usage:   "certificate of the worker for mutual TLS with the miner, reloaded when it changes",
+			EnvVars: []string{"LOTUS_WORKER_TLS_CERT"},
+		},
+		&cli.StringFlag{
+			Name:    "tls-key",
+			Usage:   "key of the worker TLS certificate",
+			EnvVars: []string{"LOTUS_WORKER_TLS_KEY"},
+		},
+		&cli.StringFlag{
+			Name:    "tls-ca",
+			Usage:   "certificates of the CAs issuing the miner and external prover TLS certificates",
+			EnvVars: []string{"LOTUS_WORKER_TLS_CA"},
+		},
+		&cli.BoolFlag{
+			Name:  "connect-out",
+			Usage: "connect to the miner and serve its calls over that connection, for workers the miner can't dial, e.g. behind NAT; the miner serves the sector files of the worker to other workers",
+		},
 		&cli.BoolFlag{
 			Name:  "no-local-storage",
 			Usage: "don't use storageminer repo for sector storage",
@@ -147,6 +178,24 @@ var runCmd = &cli.Command{
 			Usage: "enable commit (32G sectors: all cores or GPUs, 128GiB Memory + 64GiB swap)",
 			Value: true,
 		},
+		&cli.BoolFlag{
+			Name:  "verify",
+			Usage: "enable verifying proofs for full nodes",
+			Value: false,
+		},
+		&cli.StringSliceFlag{
+			Name:  "gpu-assign",
+			Usage: "reserve a GPU for a task type, as TASK=GPU_INDEX (e.g. C2=0), running one task of the type at a time on it; proofs may still use any visible GPU, set CUDA_VISIBLE_DEVICES to restrict them; can be repeated",
+		},
+		&cli.StringSliceFlag{
+			Name:  "external-prover",
+			Usage: "compute proofs of a kind with an external prover daemon, as KIND=HOST:PORT (e.g. commit2=gpufarm:9900); can be repeated. Requires --tls-cert, --tls-key, --tls-ca and --external-prover-token",
+		},
+		&cli.StringFlag{
+			Name:    "external-prover-token",
+			Usage:   "token the external provers are started with",
+			EnvVars: []string{"LOTUS_PROVER_TOKEN"},
+		},
 		&cli.IntFlag{
 			Name:  "parallel-fetch-limit",
 			Usage: "maximum fetch operations to run in parallel",
@@ -177,6 +226,28 @@ var runCmd = &cli.Command{
 			}
 		}
 
+		var certs *rpctls.Certs
+		if cctx.IsSet("tls-cert") {
+			c, err := rpctls.Load(rpctls.Files{
+				CertFile: cctx.String("tls-cert"),
+				KeyFile:  cctx.String("tls-key"),
+				CAFile:   cctx.String("tls-ca"),
+			})
+			if err != nil {
+				return xerrors.Errorf("loading TLS certificates: %w", err)
+			}
+			certs = c
+		} else if cctx.IsSet("tls-listen") {
+			return xerrors.Errorf("--tls-listen requires --tls-cert, --tls-key and --tls-ca")
+		}
+
+		// other hosts must go through the TLS API
+		if cctx.IsSet("tls-listen") {
+			if err := loopbackOnly(cctx.String("listen")); err != nil {
+				return err
+			}
+		}
+
 		// Connect to storage-miner
 		ctx := lcli.ReqContext(cctx)
 
@@ -184,7 +255,9 @@ var runCmd = &cli.Command{
 		var closer func()
 		var err error
 		for {
-			nodeApi, closer, err = lcli.GetStorageMinerAPI(cctx, cliutil.StorageMinerUseHttp)
+			// the miner API is reached with mutual TLS when its API info is
+			// a wss:// or https:// URL
+			nodeApi, closer, err = lcli.GetStorageMinerAPI(cctx, cliutil.StorageMinerUseHttp, cliutil.StorageMinerDialURL(certs.ClientURL))
 			if err == nil {
 				_, err = nodeApi.Version(ctx)
 				if err == nil {
@@ -252,6 +325,40 @@ var runCmd = &cli.Command{
 		if cctx.Bool("commit") {
 			taskTypes = append(taskTypes, sealtasks.TTCommit2)
 		}
+		if cctx.Bool("verify") {
+			taskTypes = append(taskTypes, sealtasks.TTVerify)
+		}
+
+		gpuCfg := map[string][]int{}
+		for _, a := range cctx.StringSlice("gpu-assign") {
+			parts := strings.SplitN(a, "=", 2)
+			if len(parts) != 2 {
+				return xerrors.Errorf("invalid GPU assignment %q, expected TASK=GPU_INDEX", a)
+			}
+			idx, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return xerrors.Errorf("invalid GPU index in %q: %w", a, err)
+			}
+			gpuCfg[parts[0]] = append(gpuCfg[parts[0]], idx)
+		}
+		gpus, err := sectorstorage.ParseGPUAssignment(gpuCfg)
+		if err != nil {
+			return xerrors.Errorf("parsing GPU assignment: %w", err)
+		}
+
+		proverCfg := map[string]string{}
+		for _, p := range cctx.StringSlice("external-prover") {
+			parts := strings.SplitN(p, "=", 2)
+			if len(parts) != 2 {
+				return xerrors.Errorf("invalid external prover %q, expected KIND=HOST:PORT", p)
+			}
+			proverCfg[parts[0]] = parts[1]
+		}
+		backends, err := proverrpc.Backends(proverCfg, proverrpc.Credentials{Certs: certs, Token: cctx.String("external-prover-token")})
+		if err != nil {
+			return xerrors.Errorf("connecting external provers: %w", err)
+		}
+		defer proverrpc.Close(backends) // nolint:errcheck
 
 		if len(taskTypes) == 0 {
 			return xerrors.Errorf("no task types specified")
@@ -333,43 +440,70 @@ var runCmd = &cli.Command{
 			return err
 		}
 
-		log.Info("Opening local storage; connecting to master")
-		const unspecifiedAddress = "0.0.0.0"
-		address := cctx.String("listen")
-		addressSlice := strings.Split(address, ":")
-		if ip := net.ParseIP(addressSlice[0]); ip != nil {
-			if ip.String() == unspecifiedAddress {
-				timeout, err := time.ParseDuration(cctx.String("timeout"))
-				if err != nil {
-					return err
-				}
-				rip, err := extractRoutableIP(timeout)
-				if err != nil {
-					return err
-				}
-				address = rip + ":" + addressSlice[1]
-			}
+		calibration, err := loadCalibration(lr.Path())
+		if err != nil {
+			return xerrors.Errorf("loading calibration: %w", err)
 		}
 
-		localStore, err := stores.NewLocal(ctx, lr, nodeApi, []string{"http://" + address + "/remote"})
+		log.Info("Opening local storage; connecting to master")
+		address, err := advertisedAddress(cctx, cctx.String("listen"))
 		if err != nil {
 			return err
 		}
 
-		// Setup remote sector store
+		// the URL the miner and other workers reach the worker at
+		workerURL := "http://" + address
+		if cctx.IsSet("tls-listen") {
+			tlsAddress, err := advertisedAddress(cctx, cctx.String("tls-listen"))
+			if err != nil {
+				return err
+			}
+			workerURL = "https://" + tlsAddress
+		}
+
 		sminfo, err := lcli.GetAPIInfo(cctx, repo.StorageMiner)
 		if err != nil {
 			return xerrors.Errorf("could not get api info: %w", err)
 		}
 
+		remoteURL := workerURL + "/remote"
+
+		var tunnelURL, tunnelID string
+		var dialTLS tunnel.DialFunc
+		if cctx.Bool("connect-out") {
+			tunnelURL, err = sminfo.DialArgs("worker-tunnel/v0")
+			if err != nil {
+				return xerrors.Errorf("getting miner tunnel url: %w", err)
+			}
+			if certs != nil {
+				dialTLS = certs.DialTLSContext
+			}
+
+			// the miner and other workers can't dial this worker, the miner
+			// serves its sector files through the tunnel
+			tunnelID = uuid.New().String()
+			remoteURL, err = tunnel.RemoteURL(tunnelURL, tunnelID)
+			if err != nil {
+				return err
+			}
+		}
+
+		localStore, err := stores.NewLocal(ctx, lr, nodeApi, []string{remoteURL})
+		if err != nil {
+			return err
+		}
+
+		// Setup remote sector store
+
 		remote := stores.NewRemote(localStore, nodeApi, sminfo.AuthHeader(), cctx.Int("parallel-fetch-limit"),
 			&stores.DefaultPartialFileHandler{})
+		remote.SetHTTPClient(certs.HTTPClient())
 
 		fh := &stores.FetchHandler{Local: localStore, PfHandler: &stores.DefaultPartialFileHandler{}}
 		remoteHandler := func(w http.ResponseWriter, r *http.Request) {
-			if !auth.HasPerm(r.Context(), nil, api.PermAdmin) {
+			if !api.HasRemotePerm(r) {
 				w.WriteHeader(401)
-				_ = json.NewEncoder(w).Encode(struct{ Error string }{"unauthorized: missing admin permission"})
+				_ = json.NewEncoder(w).Encode(struct{ Error string }{"unauthorized: missing admin or remote:read permission"})
 				return
 			}
 
@@ -382,11 +516,16 @@ var runCmd = &cli.Command{
 
 		workerApi := &worker{
 			LocalWorker: sectorstorage.NewLocalWorker(sectorstorage.WorkerConfig{
-				TaskTypes: taskTypes,
-				NoSwap:    cctx.Bool("no-swap"),
+				TaskTypes:     taskTypes,
+				NoSwap:        cctx.Bool("no-swap"),
+				GPUAssignment: gpus,
+				Calibration:   calibration,
+				ResultCache:   namespace.Wrap(ds, modules.WorkerResultsPrefix),
+				ProofBackends: backends,
 			}, remote, localStore, nodeApi, nodeApi, wsts),
 			localStore: localStore,
 			ls:         lr,
+			shutdown:   make(chan struct{}),
 		}
 
 		mux := mux.NewRouter()
@@ -429,6 +568,19 @@ var runCmd = &cli.Command{
 			return err
 		}
 
+		if cctx.IsSet("tls-listen") {
+			tnl, err := net.Listen("tcp", cctx.String("tls-listen"))
+			if err != nil {
+				return err
+			}
+
+			go func() {
+				if err := srv.Serve(tls.NewListener(tnl, certs.ServerConfig())); err != http.ErrServerClosed {
+					log.Errorf("serving TLS API: %s", err)
+				}
+			}()
+		}
+
 		{
 			a, err := net.ResolveTCPAddr("tcp", address)
 			if err != nil {
@@ -473,7 +625,7 @@ var runCmd = &cli.Command{
 			heartbeats := time.NewTicker(stores.HeartbeatInterval)
 			defer heartbeats.Stop()
 
-			var redeclareStorage bool
+			var redeclareStorage, tunnelStarted bool
 			var readyCh chan struct{}
 			for {
 				// If we're reconnecting, redeclare storage first
@@ -511,14 +663,28 @@ var runCmd = &cli.Command{
 
 					select {
 					case <-readyCh:
-						if err := nodeApi.WorkerConnect(ctx, "http://"+address+"/rpc/v0"); err != nil {
-							log.Errorf("Registering worker failed: %+v", err)
-							cancel()
-							return
+						if tunnelURL != "" {
+							// the tunnel reconnects by itself, and the miner
+							// registers the worker on each connection
+							if !tunnelStarted {
+								header := sminfo.AuthHeader()
+								if header == nil {
+									header = http.Header{}
+								}
+								header.Set(tunnel.IDHeader, tunnelID)
+								go runTunnel(ctx, tunnelURL, header, dialTLS, srv, localStore.Redeclare)
+								tunnelStarted = true
+							}
+						} else {
+							if err := nodeApi.WorkerConnect(ctx, workerURL+"/rpc/v0"); err != nil {
+								log.Errorf("Registering worker failed: %+v", err)
+								cancel()
+								return
+							}
+
+							log.Info("Worker registered successfully, waiting for tasks")
 						}
 
-						log.Info("Worker registered successfully, waiting for tasks")
-
 						readyCh = nil
 					case <-heartbeats.C:
 					case <-ctx.Done():
@@ -532,10 +698,134 @@ var runCmd = &cli.Command{
 			}
 		}()
 
-		return srv.Serve(nl)
+		go func() {
+			select {
+			case <-workerApi.shutdown:
+			case <-ctx.Done():
+				return
+			}
+
+			log.Warn("Shutdown requested, deregistering from the miner")
+			if err := deregisterWorker(ctx, nodeApi, workerApi); err != nil {
+				log.Errorf("deregistering worker: %+v", err)
+			}
+			cancel()
+		}()
+
+		if err := srv.Serve(nl); err != http.ErrServerClosed {
+			return err
+		}
+		return nil
 	},
 }
 
+// runTunnel keeps a tunnel to the miner open, and serves the calls of the
+// miner over it. The miner registers the worker each time the tunnel opens.
+func runTunnel(ctx context.Context, url string, header http.Header, dialTLS tunnel.DialFunc, srv *http.Server, redeclare func(context.Context) error) {
+	backoff := time.Second
+	for reconnect := false; ; reconnect = true {
+		if reconnect {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return // graceful shutdown
+			}
+			if backoff < time.Minute {
+				backoff *= 2
+			}
+
+			// the miner may have restarted while the tunnel was down
+			if err := redeclare(ctx); err != nil {
+				log.Errorf("Redeclaring local storage failed: %+v", err)
+				continue
+			}
+		}
+
+		sess, err := tunnel.Dial(ctx, url, header, dialTLS)
+		if err != nil {
+			log.Errorf("Opening tunnel to the miner failed: %+v", err)
+			continue
+		}
+		backoff = time.Second
+
+		log.Info("Tunnel to the miner open, waiting for tasks")
+		if err := srv.Serve(sess); err != nil && err != http.ErrServerClosed {
+			log.Warnf("Tunnel to the miner closed: %s", err)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// deregisterWorker closes the local worker, and waits for the miner to drop
+// it from the scheduler
+func deregisterWorker(ctx context.Context, nodeApi api.StorageMiner, w *worker) error {
+	sess, err := w.LocalWorker.Session(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting worker session: %w", err)
+	}
+
+	if err := w.LocalWorker.Close(); err != nil {
+		return xerrors.Errorf("closing worker: %w", err)
+	}
+
+	// the miner checks worker sessions on every heartbeat
+	timeout := time.After(3 * stores.HeartbeatInterval)
+	for {
+		stats, err := nodeApi.WorkerStats(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting worker stats: %w", err)
+		}
+		if _, ok := stats[sess]; !ok {
+			log.Info("Worker deregistered")
+			return nil
+		}
+
+		select {
+		case <-time.After(time.Second):
+		case <-timeout:
+			return xerrors.Errorf("miner didn't drop the worker in %s", 3*stores.HeartbeatInterval)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// loopbackOnly refuses plain API listen addresses reachable from other hosts
+func loopbackOnly(listen string) error {
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return xerrors.Errorf("parsing listen address: %w", err)
+	}
+
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return xerrors.Errorf("--listen %s must be a loopback address when --tls-listen is set", listen)
+	}
+	return nil
+}
+
+// advertisedAddress replaces the unspecified IP of a listen address with the
+// IP routing to the miner
+func advertisedAddress(cctx *cli.Context, listen string) (string, error) {
+	const unspecifiedAddress = "0.0.0.0"
+	addressSlice := strings.Split(listen, ":")
+	if ip := net.ParseIP(addressSlice[0]); ip != nil {
+		if ip.String() == unspecifiedAddress {
+			timeout, err := time.ParseDuration(cctx.String("timeout"))
+			if err != nil {
+				return "", err
+			}
+			rip, err := extractRoutableIP(timeout)
+			if err != nil {
+				return "", err
+			}
+			return rip + ":" + addressSlice[1], nil
+		}
+	}
+	return listen, nil
+}
+
 func extractRoutableIP(timeout time.Duration) (string, error) {
 	minerMultiAddrKey := "MINER_API_INFO"
 	deprecatedMinerMultiAddrKey := "STORAGE_API_INFO"
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	yamux "github.com/libp2p/go-yamux/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/lib/tunnel"
+)
+
+func TestRunTunnelReconnects(t *testing.T) {
+	// the miner side, accepting tunnels
+	sessions := make(chan *yamux.Session, 1)
+	miner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		sess, err := tunnel.Accept(w, r)
+		if err != nil {
+			return
+		}
+		sessions <- sess
+	}))
+	defer miner.Close()
+
+	nextSession := func() *yamux.Session {
+		select {
+		case sess := <-sessions:
+			return sess
+		case <-time.After(10 * time.Second):
+			t.Fatal("worker didn't open a tunnel")
+			return nil
+		}
+	}
+
+	callWorker := func(sess *yamux.Session) string {
+		addr, err := tunnel.Forward(sess)
+		require.NoError(t, err)
+
+		resp, err := http.Get("http://" + addr + "/rpc/v0")
+		require.NoError(t, err)
+		defer resp.Body.Close() // nolint:errcheck
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	// the worker side, serving its API over the tunnel
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("worker " + r.URL.Path))
+	})}
+
+	var redeclared int64
+	redeclare := func(context.Context) error {
+		atomic.AddInt64(&redeclared, 1)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runTunnel(ctx, miner.URL, http.Header{"Authorization": []string{"Bearer token"}}, nil, srv, redeclare)
+	}()
+
+	first := nextSession()
+	require.Equal(t, "worker /rpc/v0", callWorker(first))
+	require.EqualValues(t, 0, atomic.LoadInt64(&redeclared))
+
+	// the miner drops the tunnel, the worker redeclares its storage and opens
+	// a new one
+	require.NoError(t, first.Close())
+
+	second := nextSession()
+	require.Equal(t, "worker /rpc/v0", callWorker(second))
+	require.EqualValues(t, 1, atomic.LoadInt64(&redeclared))
+
+	// no reconnection once the worker shuts down
+	cancel()
+	require.NoError(t, second.Close())
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("runTunnel didn't return after shutdown")
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper"
+	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper/proverrpc"
+	"github.com/filecoin-project/lotus/lib/rpctls"
+)
+
+var proverCmd = &cli.Command{
+	Name:  "prover",
+	Usage: "Serve Commit2, aggregation and PoSt proofs to miners and workers over gRPC",
+	Description: `Computes proofs with the GPUs of this machine for the miners and workers
+configured to use it as an external prover, with Storage.ExternalProvers in the
+miner config or --external-prover on workers.
+
+Clients connect with mutual TLS, presenting a certificate issued by the CA in
+--tls-ca, and must send the token in --token (Storage.ExternalProverToken in
+the miner config, --external-prover-token on workers).
+
+PoSt proofs read the sector files at the paths they have on the miner, which
+must be mounted at the same paths on this machine. A prover shared by several
+miners must see the sectors of all of them at their paths, e.g. by mounting
+the storage of each miner at the path it has there; sector data isn't sent
+over the connection.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "listen",
+			Usage: "host address and port the prover will listen on",
+			Value: "127.0.0.1:9900",
+		},
+		&cli.StringFlag{
+			Name:     "tls-cert",
+			Usage:    "certificate of the prover, reloaded when it changes",
+			EnvVars:  []string{"LOTUS_PROVER_TLS_CERT"},
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "tls-key",
+			Usage:    "key of the prover TLS certificate",
+			EnvVars:  []string{"LOTUS_PROVER_TLS_KEY"},
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "tls-ca",
+			Usage:    "certificates of the CAs issuing the client TLS certificates",
+			EnvVars:  []string{"LOTUS_PROVER_TLS_CA"},
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "token",
+			Usage:    "token clients must send",
+			EnvVars:  []string{"LOTUS_PROVER_TOKEN"},
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "no-gpu",
+			Usage: "compute proofs on the CPU",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Bool("no-gpu") {
+			if err := os.Setenv("BELLMAN_NO_GPU", "true"); err != nil {
+				return xerrors.Errorf("could not set no-gpu env: %+v", err)
+			}
+		}
+
+		certs, err := rpctls.Load(rpctls.Files{
+			CertFile: cctx.String("tls-cert"),
+			KeyFile:  cctx.String("tls-key"),
+			CAFile:   cctx.String("tls-ca"),
+		})
+		if err != nil {
+			return xerrors.Errorf("loading TLS certificates: %w", err)
+		}
+
+		srv, err := proverrpc.NewServer(ffiwrapper.LocalProofBackend, proverrpc.Credentials{Certs: certs, Token: cctx.String("token")})
+		if err != nil {
+			return err
+		}
+
+		nl, err := net.Listen("tcp", cctx.String("listen"))
+		if err != nil {
+			return err
+		}
+
+		ctx := lcli.ReqContext(cctx)
+		go func() {
+			<-ctx.Done()
+			log.Warn("Shutting down...")
+			srv.GracefulStop()
+		}()
+
+		log.Infof("Serving proofs on %s", nl.Addr())
+		return srv.Serve(nl)
+	},
+}
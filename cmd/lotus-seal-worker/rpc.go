@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"sync"
 	"sync/atomic"
 
 	"github.com/google/uuid"
@@ -12,8 +13,12 @@ import (
 	apitypes "github.com/filecoin-project/lotus/api/types"
 	"github.com/filecoin-project/lotus/build"
 	sectorstorage "github.com/filecoin-project/lotus/extern/sector-storage"
+	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper"
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
 	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
 	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
 )
 
 type worker struct {
@@ -23,6 +28,9 @@ type worker struct {
 	ls         stores.LocalStorage
 
 	disabled int64
+
+	shutdownOnce sync.Once
+	shutdown     chan struct{} // closed when the worker process should exit
 }
 
 func (w *worker) Version(context.Context) (api.Version, error) {
@@ -66,16 +74,81 @@ func (w *worker) WaitQuiet(ctx context.Context) error {
 	return nil
 }
 
+func (w *worker) Shutdown(ctx context.Context) error {
+	running, err := w.RunningTasks(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting running tasks: %w", err)
+	}
+	if len(running) > 0 {
+		return xerrors.Errorf("%d tasks still running, drain the worker first", len(running))
+	}
+
+	w.shutdownOnce.Do(func() {
+		close(w.shutdown)
+	})
+	return nil
+}
+
 func (w *worker) ProcessSession(ctx context.Context) (uuid.UUID, error) {
 	return w.LocalWorker.Session(ctx)
 }
 
 func (w *worker) Session(ctx context.Context) (uuid.UUID, error) {
+	sess, err := w.LocalWorker.Session(ctx)
+	if err != nil || sess == sectorstorage.ClosedWorkerID {
+		// report closed workers even when disabled, so that the miner drops
+		// them right away
+		return sess, err
+	}
+
 	if atomic.LoadInt64(&w.disabled) == 1 {
 		return uuid.UUID{}, xerrors.Errorf("worker disabled")
 	}
 
-	return w.LocalWorker.Session(ctx)
+	return sess, nil
+}
+
+func (w *worker) verifier(ctx context.Context) (ffiwrapper.Verifier, error) {
+	tts, err := w.TaskTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := tts[sealtasks.TTVerify]; !ok {
+		return nil, xerrors.Errorf("proof verification not enabled on this worker")
+	}
+	return ffiwrapper.ProofVerifier, nil
+}
+
+func (w *worker) VerifySeal(ctx context.Context, info proof5.SealVerifyInfo) (bool, error) {
+	v, err := w.verifier(ctx)
+	if err != nil {
+		return false, err
+	}
+	return v.VerifySeal(info)
+}
+
+func (w *worker) VerifyAggregateSeals(ctx context.Context, aggregate proof5.AggregateSealVerifyProofAndInfos) (bool, error) {
+	v, err := w.verifier(ctx)
+	if err != nil {
+		return false, err
+	}
+	return v.VerifyAggregateSeals(aggregate)
+}
+
+func (w *worker) VerifyWinningPoSt(ctx context.Context, info proof5.WinningPoStVerifyInfo) (bool, error) {
+	v, err := w.verifier(ctx)
+	if err != nil {
+		return false, err
+	}
+	return v.VerifyWinningPoSt(ctx, info)
+}
+
+func (w *worker) VerifyWindowPoSt(ctx context.Context, info proof5.WindowPoStVerifyInfo) (bool, error) {
+	v, err := w.verifier(ctx)
+	if err != nil {
+		return false, err
+	}
+	return v.VerifyWindowPoSt(ctx, info)
 }
 
 func (w *worker) Discover(ctx context.Context) (apitypes.OpenRPCDocument, error) {
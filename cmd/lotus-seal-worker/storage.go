@@ -51,6 +51,26 @@ var storageAttachCmd = &cli.Command{
 			Name:  "max-storage",
 			Usage: "(for init) limit storage space for sectors (expensive for very large paths!)",
 		},
+		&cli.StringSliceFlag{
+			Name:  "group",
+			Usage: "(for init) path group names",
+		},
+		&cli.StringSliceFlag{
+			Name:  "allow-task",
+			Usage: "(for init) only allow the given task types (e.g. PC1) to allocate space in this path",
+		},
+		&cli.StringSliceFlag{
+			Name:  "deny-task",
+			Usage: "(for init) don't allow the given task types to allocate space in this path",
+		},
+		&cli.StringSliceFlag{
+			Name:  "allow-type",
+			Usage: "(for init) only allow the given sector file types (unsealed, sealed, cache) in this path",
+		},
+		&cli.StringSliceFlag{
+			Name:  "deny-type",
+			Usage: "(for init) don't allow the given sector file types in this path",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		nodeApi, closer, err := lcli.GetWorkerAPI(cctx)
@@ -98,12 +118,21 @@ var storageAttachCmd = &cli.Command{
 				CanSeal:    cctx.Bool("seal"),
 				CanStore:   cctx.Bool("store"),
 				MaxStorage: uint64(maxStor),
+				Groups:     cctx.StringSlice("group"),
+				AllowTasks: cctx.StringSlice("allow-task"),
+				DenyTasks:  cctx.StringSlice("deny-task"),
+				AllowTypes: cctx.StringSlice("allow-type"),
+				DenyTypes:  cctx.StringSlice("deny-type"),
 			}
 
 			if !(cfg.CanStore || cfg.CanSeal) {
 				return xerrors.Errorf("must specify at least one of --store of --seal")
 			}
 
+			if err := cfg.ValidateRules(); err != nil {
+				return xerrors.Errorf("invalid path rules: %w", err)
+			}
+
 			b, err := json.MarshalIndent(cfg, "", "  ")
 			if err != nil {
 				return xerrors.Errorf("marshaling storage config: %w", err)
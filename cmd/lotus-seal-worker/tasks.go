@@ -27,6 +27,7 @@ var allowSetting = map[sealtasks.TaskType]struct{}{
 	sealtasks.TTPreCommit2: {},
 	sealtasks.TTCommit2:    {},
 	sealtasks.TTUnseal:     {},
+	sealtasks.TTVerify:     {},
 }
 
 var settableStr = func() string {
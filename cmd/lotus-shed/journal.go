@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/audit"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
+)
+
+var journalCmd = &cli.Command{
+	Name:        "journal",
+	Description: "inspect node journals",
+	Subcommands: []*cli.Command{
+		journalQueryCmd,
+		journalAuditCmd,
+	},
+}
+
+var journalSourceFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:  "miner",
+		Usage: "query the journal of the miner node instead of the daemon",
+	},
+	&cli.StringFlag{
+		Name:  "dir",
+		Usage: "read journal files from this directory directly, without connecting to a node",
+	},
+	&cli.StringFlag{
+		Name:  "from",
+		Usage: "select entries recorded at or after this time (RFC3339, or a duration like '2h' meaning 2 hours ago)",
+	},
+	&cli.StringFlag{
+		Name:  "to",
+		Usage: "select entries recorded at or before this time (RFC3339, or a duration like '30m' meaning 30 minutes ago)",
+	},
+	&cli.IntFlag{
+		Name:  "limit",
+		Usage: "maximum number of entries to print (0 for no limit)",
+		Value: 1000,
+	},
+}
+
+var journalQueryCmd = &cli.Command{
+	Name:        "query",
+	Description: "print journal entries matching the given filters, oldest first",
+	Flags: append([]cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "type",
+			Usage: "event types to select, as 'system' or 'system:event' (e.g. 'wdpost', 'storage:sealing_states'); may be repeated",
+		},
+	}, journalSourceFlags...),
+	Action: func(cctx *cli.Context) error {
+		q := journal.Query{Limit: cctx.Int("limit")}
+		for _, s := range cctx.StringSlice("type") {
+			parts := strings.SplitN(s, ":", 2)
+			et := journal.EventType{System: parts[0]}
+			if len(parts) == 2 {
+				et.Event = parts[1]
+			}
+			q.Types = append(q.Types, et)
+		}
+
+		evts, err := queryJournal(cctx, q)
+		if err != nil {
+			return err
+		}
+
+		for _, evt := range evts {
+			data, err := json.Marshal(evt.Data)
+			if err != nil {
+				return xerrors.Errorf("marshaling event data: %w", err)
+			}
+			fmt.Printf("%s %s %s\n", evt.Timestamp.Format(time.RFC3339Nano), evt.EventType, data)
+		}
+
+		return nil
+	},
+}
+
+var journalAuditCmd = &cli.Command{
+	Name:        "audit",
+	Description: "print the API audit log: calls to non-read API methods, and the tokens they were made with",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "token",
+			Usage: "only show calls made with this token (either the token itself, or its ID as printed in the log)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "method",
+			Usage: "only show calls to this method; may be repeated",
+		},
+	}, journalSourceFlags...),
+	Action: func(cctx *cli.Context) error {
+		tokenID := cctx.String("token")
+		if len(tokenID) > 16 {
+			tokenID = audit.TokenID(tokenID)
+		}
+		methods := map[string]bool{}
+		for _, m := range cctx.StringSlice("method") {
+			methods[m] = true
+		}
+
+		// filter locally, limiting only the printed entries
+		limit := cctx.Int("limit")
+		evts, err := queryJournal(cctx, journal.Query{
+			Types: []journal.EventType{{System: audit.EventSystem, Event: audit.EventName}},
+		})
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Time"),
+			tablewriter.Col("Token"),
+			tablewriter.Col("Method"),
+			tablewriter.Col("Perm"),
+			tablewriter.Col("Params"),
+			tablewriter.NewLineCol("Error"))
+
+		var n int
+		for _, evt := range evts {
+			// events decoded from the journal carry generic data; round-trip
+			// it to get a typed value
+			b, err := json.Marshal(evt.Data)
+			if err != nil {
+				return xerrors.Errorf("marshaling event data: %w", err)
+			}
+			var ae audit.Evt
+			if err := json.Unmarshal(b, &ae); err != nil {
+				return xerrors.Errorf("decoding audit event: %w", err)
+			}
+
+			if tokenID != "" && ae.Token != tokenID {
+				continue
+			}
+			if len(methods) > 0 && !methods[ae.Method] {
+				continue
+			}
+
+			params, err := json.Marshal(ae.Params)
+			if err != nil {
+				return err
+			}
+			tw.Write(map[string]interface{}{
+				"Time":   evt.Timestamp.Format(time.RFC3339),
+				"Token":  ae.Token,
+				"Method": ae.Method,
+				"Perm":   ae.Perm,
+				"Params": string(params),
+				"Error":  ae.Error,
+			})
+
+			n++
+			if limit > 0 && n >= limit {
+				break
+			}
+		}
+
+		return tw.Flush(os.Stdout)
+	},
+}
+
+// queryJournal runs q against the journal selected by journalSourceFlags, in
+// the time range given by the flags.
+func queryJournal(cctx *cli.Context, q journal.Query) ([]*journal.Event, error) {
+	ctx := lcli.ReqContext(cctx)
+
+	var err error
+	if q.From, err = parseJournalTime(cctx.String("from")); err != nil {
+		return nil, xerrors.Errorf("parsing --from: %w", err)
+	}
+	if q.To, err = parseJournalTime(cctx.String("to")); err != nil {
+		return nil, xerrors.Errorf("parsing --to: %w", err)
+	}
+
+	if cctx.IsSet("dir") {
+		dir, err := homedir.Expand(cctx.String("dir"))
+		if err != nil {
+			return nil, err
+		}
+		return journal.QueryDir(ctx, dir, q)
+	}
+
+	var (
+		napi   api.Common
+		closer func()
+	)
+	if cctx.Bool("miner") {
+		napi, closer, err = lcli.GetStorageMinerAPI(cctx)
+	} else {
+		napi, closer, err = lcli.GetFullNodeAPI(cctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+
+	return napi.JournalQuery(ctx, q.Types, q.From, q.To, q.Limit)
+}
+
+func parseJournalTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -24,6 +24,7 @@ func main() {
 		frozenMinersCmd,
 		keyinfoCmd,
 		jwtCmd,
+		journalCmd,
 		noncefix,
 		bigIntParseCmd,
 		staterootCmd,
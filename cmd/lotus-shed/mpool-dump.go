@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+// mpoolDump is a snapshot of the pending messages of a node
+type mpoolDump struct {
+	Time          time.Time
+	Head          types.TipSetKey
+	Height        abi.ChainEpoch
+	ParentBaseFee types.BigInt
+
+	Messages []*types.SignedMessage
+
+	// Selected are the messages the node selected for the next block, in
+	// order, with the given ticket quality
+	Selected      []cid.Cid
+	TicketQuality float64
+}
+
+var mpoolDumpCmd = &cli.Command{
+	Name:      "dump",
+	Usage:     "Save the pending messages of the node, and its message selection, to a file",
+	ArgsUsage: "[file]",
+	Flags: []cli.Flag{
+		&cli.Float64Flag{
+			Name:  "ticket-quality",
+			Value: 1,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return xerrors.Errorf("expected 1 argument")
+		}
+
+		api, closer, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		head, err := api.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+
+		msgs, err := api.MpoolPending(ctx, head.Key())
+		if err != nil {
+			return xerrors.Errorf("getting pending messages: %w", err)
+		}
+
+		sel, err := api.MpoolSelect(ctx, head.Key(), cctx.Float64("ticket-quality"))
+		if err != nil {
+			return xerrors.Errorf("selecting messages: %w", err)
+		}
+
+		dump := mpoolDump{
+			Time:          time.Now(),
+			Head:          head.Key(),
+			Height:        head.Height(),
+			ParentBaseFee: head.Blocks()[0].ParentBaseFee,
+			Messages:      msgs,
+			Selected:      make([]cid.Cid, 0, len(sel)),
+			TicketQuality: cctx.Float64("ticket-quality"),
+		}
+		for _, sm := range sel {
+			dump.Selected = append(dump.Selected, sm.Cid())
+		}
+
+		b, err := json.MarshalIndent(&dump, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(cctx.Args().First(), b, 0644); err != nil {
+			return xerrors.Errorf("writing dump: %w", err)
+		}
+
+		fmt.Printf("saved %d pending and %d selected messages at height %d\n", len(msgs), len(sel), head.Height())
+		return nil
+	},
+}
+
+var mpoolReplayCmd = &cli.Command{
+	Name:  "replay",
+	Usage: "Replay the messages of an mpool dump against a node",
+	Description: `By default the gas of each message is estimated, and the message is called, on
+   top of the tipset of the dump, which the node must still have the state of.
+
+   --compute applies the selected messages of the dump, in order, to the state of
+   the tipset, like a block would, and prints the receipts.
+
+   --push pushes the messages to the mpool of the node, which publishes them to
+   the network, this is meant for devnets.`,
+	ArgsUsage: "[file]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "current",
+			Usage: "replay on top of the current head instead of the tipset of the dump",
+		},
+		&cli.BoolFlag{
+			Name:  "compute",
+			Usage: "apply the selected messages to the state, instead of estimating each message",
+		},
+		&cli.BoolFlag{
+			Name:  "push",
+			Usage: "push the messages to the mpool of the node",
+		},
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "must be specified for --push to take effect",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return xerrors.Errorf("expected 1 argument")
+		}
+
+		b, err := ioutil.ReadFile(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("reading dump: %w", err)
+		}
+		var dump mpoolDump
+		if err := json.Unmarshal(b, &dump); err != nil {
+			return xerrors.Errorf("decoding dump: %w", err)
+		}
+
+		api, closer, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		if cctx.Bool("push") {
+			if !cctx.Bool("really-do-it") {
+				//nolint:golint
+				return fmt.Errorf("--really-do-it must be specified for this action to have an effect; you have been warned")
+			}
+
+			// push in nonce order, so that the mpool doesn't reject gaps
+			msgs := append([]*types.SignedMessage{}, dump.Messages...)
+			sort.SliceStable(msgs, func(i, j int) bool {
+				if msgs[i].Message.From != msgs[j].Message.From {
+					return msgs[i].Message.From.String() < msgs[j].Message.From.String()
+				}
+				return msgs[i].Message.Nonce < msgs[j].Message.Nonce
+			})
+
+			var pushed int
+			for _, sm := range msgs {
+				if _, err := api.MpoolPush(ctx, sm); err != nil {
+					fmt.Printf("%s: push failed: %s\n", sm.Cid(), err)
+					continue
+				}
+				pushed++
+			}
+
+			fmt.Printf("pushed %d of %d messages\n", pushed, len(msgs))
+			return nil
+		}
+
+		ts, err := api.ChainGetTipSet(ctx, dump.Head)
+		if err != nil {
+			return xerrors.Errorf("getting tipset of the dump: %w", err)
+		}
+		if cctx.Bool("current") {
+			ts, err = api.ChainHead(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		if cctx.Bool("compute") {
+			byCid := map[cid.Cid]*types.SignedMessage{}
+			for _, sm := range dump.Messages {
+				byCid[sm.Cid()] = sm
+			}
+
+			msgs := make([]*types.Message, 0, len(dump.Selected))
+			for _, c := range dump.Selected {
+				sm, ok := byCid[c]
+				if !ok {
+					return xerrors.Errorf("selected message %s isn't in the dump", c)
+				}
+				msgs = append(msgs, &sm.Message)
+			}
+
+			out, err := api.StateCompute(ctx, ts.Height(), msgs, ts.Key())
+			if err != nil {
+				return xerrors.Errorf("computing state: %w", err)
+			}
+
+			var gasUsed int64
+			tip := big.Zero()
+			for _, r := range out.Trace {
+				if r.MsgRct == nil {
+					fmt.Printf("%s: %s\n", r.MsgCid, r.Error)
+					continue
+				}
+
+				fmt.Printf("%s: exit %d, gasUsed %d / %d, tip %s, total cost %s\n", r.MsgCid, r.MsgRct.ExitCode, r.MsgRct.GasUsed, r.Msg.GasLimit, types.FIL(r.GasCost.MinerTip), types.FIL(r.GasCost.TotalCost))
+				gasUsed += r.MsgRct.GasUsed
+				tip = big.Add(tip, r.GasCost.MinerTip)
+			}
+
+			fmt.Println("applied messages: ", len(msgs))
+			fmt.Printf("total gas used: %d / %d (%0.2f%%)\n", gasUsed, build.BlockGasLimit, 100*float64(gasUsed)/float64(build.BlockGasLimit))
+			fmt.Printf("total miner tip: %s\n", types.FIL(tip))
+			return nil
+		}
+
+		for _, sm := range dump.Messages {
+			m := sm.Message
+
+			res, err := api.StateCall(ctx, &m, ts.Key())
+			if err != nil {
+				fmt.Printf("%s: call failed: %s\n", sm.Cid(), err)
+				continue
+			}
+			if res.MsgRct == nil {
+				fmt.Printf("%s: call failed: %s\n", sm.Cid(), res.Error)
+				continue
+			}
+
+			est := m
+			est.GasLimit = 0
+			est.GasFeeCap = big.Zero()
+			est.GasPremium = big.Zero()
+
+			var estStr string
+			if e, err := api.GasEstimateMessageGas(ctx, &est, nil, ts.Key()); err != nil {
+				estStr = err.Error()
+			} else {
+				estStr = fmt.Sprintf("gasLimit %d, gasFeecap %s, gasPremium %s", e.GasLimit, e.GasFeeCap, e.GasPremium)
+			}
+
+			fmt.Printf("%s: exit %d, gasUsed %d; sent gasLimit %d, gasFeecap %s, gasPremium %s; estimated %s\n", sm.Cid(), res.MsgRct.ExitCode, res.MsgRct.GasUsed, m.GasLimit, m.GasFeeCap, m.GasPremium, estStr)
+		}
+
+		return nil
+	},
+}
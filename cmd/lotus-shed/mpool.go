@@ -16,6 +16,8 @@ var mpoolCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		minerSelectMsgsCmd,
 		mpoolClear,
+		mpoolDumpCmd,
+		mpoolReplayCmd,
 	},
 }
 
@@ -26,6 +26,9 @@ var msgCmd = &cli.Command{
 	Name:      "msg",
 	Usage:     "Translate message between various formats",
 	ArgsUsage: "Message in any form",
+	Subcommands: []*cli.Command{
+		msgDebugCmd,
+	},
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() != 1 {
 			return xerrors.Errorf("expected 1 argument")
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
+)
+
+var msgDebugCmd = &cli.Command{
+	Name:      "debug",
+	Usage:     "Replay a message, printing every step of its execution",
+	ArgsUsage: "[messageCid]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "tipset",
+			Usage: "tipset to replay the message on, by default the parent of the tipset it was executed in",
+		},
+		&cli.BoolFlag{
+			Name:  "no-state",
+			Usage: "don't print state reads and writes",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the raw trace as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return xerrors.Errorf("expected 1 argument")
+		}
+
+		mc, err := cid.Parse(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing message cid: %w", err)
+		}
+
+		fapi, closer, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		tsk := types.EmptyTSK
+		if cctx.IsSet("tipset") {
+			ts, err := lcli.ParseTipSetRef(ctx, fapi, cctx.String("tipset"))
+			if err != nil {
+				return err
+			}
+			tsk = ts.Key()
+		}
+
+		trace, err := fapi.StateReplayDetailed(ctx, tsk, mc)
+		if err != nil {
+			return xerrors.Errorf("replaying message: %w", err)
+		}
+
+		if cctx.Bool("json") {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(trace)
+		}
+
+		codes := map[address.Address]cid.Cid{}
+		methodName := func(msg *types.Message) string {
+			code, ok := codes[msg.To]
+			if !ok {
+				act, err := fapi.StateGetActor(ctx, msg.To, types.EmptyTSK)
+				if err == nil {
+					code = act.Code
+				}
+				codes[msg.To] = code
+			}
+
+			if m, ok := stmgr.MethodsMap[code][msg.Method]; ok {
+				return fmt.Sprintf("%s (%d)", m.Name, msg.Method)
+			}
+			return fmt.Sprint(msg.Method)
+		}
+
+		ir := trace.InvocResult
+		color.Green("Message %s", ir.MsgCid)
+		fmt.Printf("Exit code: %d\n", ir.MsgRct.ExitCode)
+		fmt.Printf("Gas used: %d of %d\n", ir.MsgRct.GasUsed, ir.Msg.GasLimit)
+		if ir.Error != "" {
+			color.Red("Error: %s", ir.Error)
+		}
+		fmt.Println()
+
+		color.Green("Steps:")
+		for _, step := range trace.Steps {
+			indent := strings.Repeat("  ", int(step.Depth)+1)
+			switch step.Op {
+			case "call":
+				fmt.Printf("%s%s %s -> %s, method %s, value %s\n", indent, color.BlueString("call"), step.Msg.From, step.Msg.To, methodName(step.Msg), types.FIL(step.Msg.Value))
+			case "return":
+				res := color.GreenString("return")
+				if step.ExitCode != 0 {
+					res = color.RedString("return")
+				}
+				fmt.Printf("%s%s exit code %d, gas used %d\n", indent, res, step.ExitCode, step.GasUsed)
+				if step.Error != "" {
+					fmt.Printf("%s  %s\n", indent, color.RedString(step.Error))
+				}
+			case "get", "put":
+				if cctx.Bool("no-state") {
+					continue
+				}
+				fmt.Printf("%s  %s %s (%d bytes)\n", indent, step.Op, step.Cid, step.Size)
+			}
+		}
+		fmt.Println()
+
+		color.Green("Gas by class:")
+		tw := tablewriter.New(
+			tablewriter.Col("Class"),
+			tablewriter.Col("Count"),
+			tablewriter.Col("Total"),
+			tablewriter.Col("Compute"),
+			tablewriter.Col("Storage"),
+		)
+		for _, gc := range trace.GasByClass {
+			tw.Write(map[string]interface{}{
+				"Class":   gc.Name,
+				"Count":   gc.Count,
+				"Total":   gc.TotalGas,
+				"Compute": gc.ComputeGas,
+				"Storage": gc.StorageGas,
+			})
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
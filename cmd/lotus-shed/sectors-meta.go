@@ -0,0 +1,453 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dsq "github.com/ipfs/go-datastore/query"
+	dssync "github.com/ipfs/go-datastore/sync"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	sealing "github.com/filecoin-project/lotus/extern/storage-sealing"
+	"github.com/filecoin-project/lotus/lib/backupds"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+var sectorsMetaCmd = &cli.Command{
+	Name:  "meta",
+	Usage: "Inspect and repair the sealing metadata of an offline miner",
+	Description: `These commands access the metadata datastore of the miner repo directly, so
+   the miner must not be running.
+
+   Records are saved to a backup file before they are modified or removed, use
+   'lotus-shed sectors meta restore' to put them back.`,
+	Subcommands: []*cli.Command{
+		sectorsMetaDumpCmd,
+		sectorsMetaSetStateCmd,
+		sectorsMetaSetMsgCmd,
+		sectorsMetaRemoveOrphansCmd,
+		sectorsMetaRestoreCmd,
+	},
+}
+
+var sectorsMetaEditFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "only print the changes",
+	},
+	&cli.StringFlag{
+		Name:  "backup-file",
+		Usage: "file to save the records to before changing them (default: sectors-meta-[unix time].cbor)",
+	},
+}
+
+var sectorsMetaDumpCmd = &cli.Command{
+	Name:      "dump",
+	Usage:     "Print the sealing state machine record of a sector as JSON",
+	ArgsUsage: "[sectorNum]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "log",
+			Usage: "include the sector event log",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return xerrors.Errorf("expected 1 argument")
+		}
+
+		sn, err := parseSectorNumber(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		mds, closer, err := openMinerMetadata(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		info, err := getSectorMeta(mds, sn)
+		if err != nil {
+			return err
+		}
+		if !cctx.Bool("log") {
+			info.Log = nil
+		}
+
+		out, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	},
+}
+
+var sectorsMetaSetStateCmd = &cli.Command{
+	Name:      "set-state",
+	Usage:     "Rewrite the state of a sector record",
+	ArgsUsage: "[sectorNum] [state]",
+	Flags:     sectorsMetaEditFlags,
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 2 {
+			return xerrors.Errorf("expected 2 arguments")
+		}
+
+		sn, err := parseSectorNumber(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		state := sealing.SectorState(cctx.Args().Get(1))
+		if _, ok := sealing.ExistSectorStateList[state]; !ok {
+			return xerrors.Errorf("unknown sector state %q", state)
+		}
+
+		mds, closer, err := openMinerMetadata(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		info, err := getSectorMeta(mds, sn)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("sector %d: state %s -> %s\n", sn, info.State, state)
+		info.State = state
+
+		return putSectorMeta(cctx, mds, info)
+	},
+}
+
+var sectorsMetaSetMsgCmd = &cli.Command{
+	Name:      "set-msg",
+	Usage:     "Re-link the precommit or commit message of a sector record",
+	ArgsUsage: "[sectorNum]",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "precommit",
+			Usage: "precommit message CID, 'none' to unset",
+		},
+		&cli.StringFlag{
+			Name:  "commit",
+			Usage: "commit message CID, 'none' to unset",
+		},
+	}, sectorsMetaEditFlags...),
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return xerrors.Errorf("expected 1 argument")
+		}
+		if !cctx.IsSet("precommit") && !cctx.IsSet("commit") {
+			return xerrors.Errorf("expected --precommit or --commit")
+		}
+
+		sn, err := parseSectorNumber(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		mds, closer, err := openMinerMetadata(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		info, err := getSectorMeta(mds, sn)
+		if err != nil {
+			return err
+		}
+
+		if cctx.IsSet("precommit") {
+			c, err := parseMsgCid(cctx.String("precommit"))
+			if err != nil {
+				return xerrors.Errorf("parsing precommit message: %w", err)
+			}
+			fmt.Printf("sector %d: precommit message %s -> %s\n", sn, fmtMsgCid(info.PreCommitMessage), fmtMsgCid(c))
+			info.PreCommitMessage = c
+		}
+
+		if cctx.IsSet("commit") {
+			c, err := parseMsgCid(cctx.String("commit"))
+			if err != nil {
+				return xerrors.Errorf("parsing commit message: %w", err)
+			}
+			fmt.Printf("sector %d: commit message %s -> %s\n", sn, fmtMsgCid(info.CommitMessage), fmtMsgCid(c))
+			info.CommitMessage = c
+		}
+
+		return putSectorMeta(cctx, mds, info)
+	},
+}
+
+var sectorsMetaRemoveOrphansCmd = &cli.Command{
+	Name:  "remove-orphans",
+	Usage: "Remove sector records which can't be decoded, or are stored under the key of another sector",
+	Flags: append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:  "removed",
+			Usage: "also remove the records of sectors in the Removed state",
+		},
+	}, sectorsMetaEditFlags...),
+	Action: func(cctx *cli.Context) error {
+		mds, closer, err := openMinerMetadata(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		res, err := mds.Query(dsq.Query{Prefix: sealing.SectorStorePrefix})
+		if err != nil {
+			return xerrors.Errorf("querying sector records: %w", err)
+		}
+
+		entries, err := res.Rest()
+		if err != nil {
+			return xerrors.Errorf("iterating sector records: %w", err)
+		}
+
+		var orphans []datastore.Key
+		for _, r := range entries {
+			k := datastore.NewKey(r.Key)
+
+			var info sealing.SectorInfo
+			if err := info.UnmarshalCBOR(bytes.NewReader(r.Value)); err != nil {
+				fmt.Printf("%s: can't decode record: %s\n", k, err)
+				orphans = append(orphans, k)
+				continue
+			}
+
+			if !k.Equal(sectorMetaKey(info.SectorNumber)) {
+				fmt.Printf("%s: record of sector %d\n", k, info.SectorNumber)
+				orphans = append(orphans, k)
+				continue
+			}
+
+			if cctx.Bool("removed") && info.State == sealing.Removed {
+				fmt.Printf("%s: sector removed\n", k)
+				orphans = append(orphans, k)
+			}
+		}
+
+		if len(orphans) == 0 {
+			fmt.Println("no orphaned records")
+			return nil
+		}
+
+		return editSectorMeta(cctx, mds, nil, orphans)
+	},
+}
+
+var sectorsMetaRestoreCmd = &cli.Command{
+	Name:      "restore",
+	Usage:     "Put back the records saved to a backup file",
+	ArgsUsage: "[backupFile]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return xerrors.Errorf("expected 1 argument")
+		}
+
+		f, err := os.Open(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("opening backup file: %w", err)
+		}
+		defer f.Close() //nolint:errcheck
+
+		mds, closer, err := openMinerMetadata(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if err := backupds.RestoreInto(f, mds); err != nil {
+			return xerrors.Errorf("restoring records: %w", err)
+		}
+
+		fmt.Println("restored records from", cctx.Args().First())
+		return nil
+	},
+}
+
+func openMinerMetadata(cctx *cli.Context) (datastore.Batching, func(), error) {
+	logging.SetLogLevel("badger", "ERROR") // nolint:errcheck
+
+	r, err := repo.NewFS(cctx.String("miner-repo"))
+	if err != nil {
+		return nil, nil, xerrors.Errorf("opening fs repo: %w", err)
+	}
+
+	exists, err := r.Exists()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !exists {
+		return nil, nil, xerrors.Errorf("miner repo doesn't exist")
+	}
+
+	lr, err := r.Lock(repo.StorageMiner)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("locking miner repo (is the miner running?): %w", err)
+	}
+
+	mds, err := lr.Datastore(context.Background(), "/metadata")
+	if err != nil {
+		_ = lr.Close()
+		return nil, nil, err
+	}
+
+	return mds, func() {
+		_ = lr.Close()
+	}, nil
+}
+
+// sectorMetaKey is the key of the state machine record of a sector
+func sectorMetaKey(sn abi.SectorNumber) datastore.Key {
+	return datastore.NewKey(sealing.SectorStorePrefix).ChildString(fmt.Sprint(uint64(sn)))
+}
+
+func getSectorMeta(mds datastore.Batching, sn abi.SectorNumber) (*sealing.SectorInfo, error) {
+	b, err := mds.Get(sectorMetaKey(sn))
+	if err != nil {
+		return nil, xerrors.Errorf("getting record of sector %d: %w", sn, err)
+	}
+
+	var info sealing.SectorInfo
+	if err := info.UnmarshalCBOR(bytes.NewReader(b)); err != nil {
+		return nil, xerrors.Errorf("decoding record of sector %d: %w", sn, err)
+	}
+	return &info, nil
+}
+
+func putSectorMeta(cctx *cli.Context, mds datastore.Batching, info *sealing.SectorInfo) error {
+	buf := new(bytes.Buffer)
+	if err := info.MarshalCBOR(buf); err != nil {
+		return xerrors.Errorf("encoding record of sector %d: %w", info.SectorNumber, err)
+	}
+
+	return editSectorMeta(cctx, mds, map[datastore.Key][]byte{
+		sectorMetaKey(info.SectorNumber): buf.Bytes(),
+	}, nil)
+}
+
+// editSectorMeta saves the current values of the keys to put or delete to the
+// backup file, then applies the changes in one batch
+func editSectorMeta(cctx *cli.Context, mds datastore.Batching, put map[datastore.Key][]byte, del []datastore.Key) error {
+	if cctx.Bool("dry-run") {
+		fmt.Println("dry run, not changing anything")
+		return nil
+	}
+
+	keys := append([]datastore.Key{}, del...)
+	for k := range put {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Less(keys[j])
+	})
+
+	bpath := cctx.String("backup-file")
+	if bpath == "" {
+		bpath = fmt.Sprintf("sectors-meta-%d.cbor", time.Now().Unix())
+	}
+	if err := backupSectorMeta(bpath, mds, keys); err != nil {
+		return xerrors.Errorf("backing up records: %w", err)
+	}
+	fmt.Println("saved the previous records to", bpath)
+
+	batch, err := mds.Batch()
+	if err != nil {
+		return xerrors.Errorf("creating batch: %w", err)
+	}
+	for k, v := range put {
+		if err := batch.Put(k, v); err != nil {
+			return xerrors.Errorf("putting %s: %w", k, err)
+		}
+	}
+	for _, k := range del {
+		if err := batch.Delete(k); err != nil {
+			return xerrors.Errorf("deleting %s: %w", k, err)
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		return xerrors.Errorf("committing changes: %w", err)
+	}
+
+	fmt.Printf("updated %d and removed %d records\n", len(put), len(del))
+	return nil
+}
+
+// backupSectorMeta writes the existing keys to a new file, in the format of
+// datastore backups
+func backupSectorMeta(path string, mds datastore.Batching, keys []datastore.Key) error {
+	bds := dssync.MutexWrap(datastore.NewMapDatastore())
+	for _, k := range keys {
+		v, err := mds.Get(k)
+		switch {
+		case err == datastore.ErrNotFound:
+			continue
+		case err != nil:
+			return xerrors.Errorf("getting %s: %w", k, err)
+		}
+
+		if err := bds.Put(k, v); err != nil {
+			return err
+		}
+	}
+
+	wds, err := backupds.Wrap(bds, backupds.NoLogdir)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if err := wds.Backup(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func parseSectorNumber(s string) (abi.SectorNumber, error) {
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, xerrors.Errorf("parsing sector number: %w", err)
+	}
+	return abi.SectorNumber(n), nil
+}
+
+func parseMsgCid(s string) (*cid.Cid, error) {
+	if s == "none" {
+		return nil, nil
+	}
+
+	c, err := cid.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func fmtMsgCid(c *cid.Cid) string {
+	if c == nil {
+		return "none"
+	}
+	return c.String()
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	builtin5 "github.com/filecoin-project/specs-actors/v5/actors/builtin"
+	init5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/init"
+	market5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/market"
+	miner5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/miner"
+	multisig5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/multisig"
+	paych5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/paych"
+	power5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/power"
+	reward5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/reward"
+	verifreg5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/verifreg"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+// actorStateTypes are the state types of the v5 actors, used to name the
+// structures of their states
+var actorStateTypes = map[string]reflect.Type{
+	"init":             reflect.TypeOf(init5.State{}),
+	"storagemarket":    reflect.TypeOf(market5.State{}),
+	"storageminer":     reflect.TypeOf(miner5.State{}),
+	"multisig":         reflect.TypeOf(multisig5.State{}),
+	"paymentchannel":   reflect.TypeOf(paych5.State{}),
+	"storagepower":     reflect.TypeOf(power5.State{}),
+	"reward":           reflect.TypeOf(reward5.State{}),
+	"verifiedregistry": reflect.TypeOf(verifreg5.State{}),
+}
+
+type stateProfile struct {
+	Total  api.ObjStat
+	Types  map[string]*actorTypeProfile
+	Actors map[address.Address]actorProfile
+}
+
+type actorTypeProfile struct {
+	Actors int
+	Stat   api.ObjStat
+	// Structures holds the stats of the state structures linked from the
+	// actor state root, e.g. the sectors AMT of miners
+	Structures map[string]api.ObjStat
+}
+
+type actorProfile struct {
+	Type string
+	Stat api.ObjStat
+}
+
+var staterootProfileCmd = &cli.Command{
+	Name:  "profile",
+	Usage: "print the state tree size by actor type, and by state structure",
+	Description: `Sizes are in bytes, nodes are the number of IPLD blocks. Nodes shared by
+   several actors are counted for each of them.`,
+	ArgsUsage: "[actor addresses (default: all actors)]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "tipset",
+			Usage: "specify tipset to profile",
+		},
+		&cli.StringFlag{
+			Name:  "diff",
+			Usage: "compare with the state at this earlier tipset, e.g. @1000000",
+		},
+		&cli.StringFlag{
+			Name:  "actor-type",
+			Usage: "only profile actors of this type, e.g. storageminer",
+		},
+		&cli.IntFlag{
+			Name:  "top",
+			Usage: "number of largest (or fastest growing) actors to list",
+			Value: 10,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		ts, err := lcli.LoadTipSet(ctx, cctx, api)
+		if err != nil {
+			return err
+		}
+
+		var addrs []address.Address
+		for _, inp := range cctx.Args().Slice() {
+			a, err := address.NewFromString(inp)
+			if err != nil {
+				return err
+			}
+			addrs = append(addrs, a)
+		}
+
+		cur, err := profileState(ctx, api, ts, addrs, cctx.String("actor-type"))
+		if err != nil {
+			return err
+		}
+
+		base := &stateProfile{
+			Types:  map[string]*actorTypeProfile{},
+			Actors: map[address.Address]actorProfile{},
+		}
+		if cctx.IsSet("diff") {
+			bts, err := lcli.ParseTipSetRef(ctx, api, cctx.String("diff"))
+			if err != nil {
+				return xerrors.Errorf("parsing diff tipset: %w", err)
+			}
+
+			base, err = profileState(ctx, api, bts, addrs, cctx.String("actor-type"))
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Changes from height %d to %d\n\n", bts.Height(), ts.Height())
+		}
+
+		printProfile(cur, base, cctx.Int("top"))
+		return nil
+	},
+}
+
+func profileState(ctx context.Context, fapi v0api.FullNode, ts *types.TipSet, addrs []address.Address, actorType string) (*stateProfile, error) {
+	out := &stateProfile{
+		Types:  map[string]*actorTypeProfile{},
+		Actors: map[address.Address]actorProfile{},
+	}
+
+	var err error
+	out.Total, err = fapi.ChainStatObj(ctx, ts.ParentState(), cid.Undef)
+	if err != nil {
+		return nil, xerrors.Errorf("stat state root: %w", err)
+	}
+
+	if len(addrs) == 0 {
+		addrs, err = fapi.StateListActors(ctx, ts.Key())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, a := range addrs {
+		act, err := fapi.StateGetActor(ctx, a, ts.Key())
+		if err != nil {
+			return nil, xerrors.Errorf("getting actor %s: %w", a, err)
+		}
+
+		name := builtin.ActorNameByCode(act.Code)
+		typ := name[strings.LastIndex(name, "/")+1:]
+		if actorType != "" && typ != actorType {
+			continue
+		}
+
+		stat, err := fapi.ChainStatObj(ctx, act.Head, cid.Undef)
+		if err != nil {
+			return nil, xerrors.Errorf("stat actor %s state: %w", a, err)
+		}
+
+		tp, ok := out.Types[typ]
+		if !ok {
+			tp = &actorTypeProfile{Structures: map[string]api.ObjStat{}}
+			out.Types[typ] = tp
+		}
+		tp.Actors++
+		tp.Stat = addObjStat(tp.Stat, stat)
+		out.Actors[a] = actorProfile{Type: typ, Stat: stat}
+
+		structs, err := stateStructures(ctx, fapi, act, typ)
+		if err != nil {
+			return nil, xerrors.Errorf("actor %s: %w", a, err)
+		}
+		for field, c := range structs {
+			s, err := fapi.ChainStatObj(ctx, c, cid.Undef)
+			if err != nil {
+				return nil, xerrors.Errorf("stat actor %s %s: %w", a, field, err)
+			}
+			tp.Structures[field] = addObjStat(tp.Structures[field], s)
+		}
+	}
+
+	return out, nil
+}
+
+// stateStructures returns the links of the actor state root by field name
+func stateStructures(ctx context.Context, fapi v0api.FullNode, act *types.Actor, typ string) (map[string]cid.Cid, error) {
+	b, err := fapi.ChainReadObj(ctx, act.Head)
+	if err != nil {
+		return nil, xerrors.Errorf("reading state root: %w", err)
+	}
+
+	var root interface{}
+	if err := cbor.DecodeInto(b, &root); err != nil {
+		return nil, xerrors.Errorf("decoding state root: %w", err)
+	}
+
+	fields, ok := root.([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	// the field names are only known for the current actors version
+	st, named := actorStateTypes[typ]
+	named = named && builtin5.IsBuiltinActor(act.Code) && st.NumField() == len(fields)
+
+	out := map[string]cid.Cid{}
+	for i, f := range fields {
+		c, ok := f.(cid.Cid)
+		if !ok {
+			continue
+		}
+
+		name := fmt.Sprintf("field %d", i)
+		if named {
+			name = st.Field(i).Name
+		}
+		out[name] = c
+	}
+	return out, nil
+}
+
+func addObjStat(a, b api.ObjStat) api.ObjStat {
+	return api.ObjStat{Size: a.Size + b.Size, Links: a.Links + b.Links}
+}
+
+func printProfile(cur, base *stateProfile, top int) {
+	tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Total state tree\t%d bytes\t%d nodes\t%s\n", cur.Total.Size, cur.Total.Links, fmtObjDelta(cur.Total, base.Total))
+	_ = tw.Flush()
+	fmt.Println()
+
+	typs := make([]string, 0, len(cur.Types))
+	for t := range cur.Types {
+		typs = append(typs, t)
+	}
+	for t := range base.Types {
+		if _, ok := cur.Types[t]; !ok {
+			typs = append(typs, t)
+		}
+	}
+	sort.Slice(typs, func(i, j int) bool {
+		return profileOf(cur, typs[i]).Stat.Size > profileOf(cur, typs[j]).Stat.Size
+	})
+
+	fmt.Fprintf(tw, "Type / Structure\tActors\tSize\tNodes\tChange\n")
+	for _, t := range typs {
+		ctp, btp := profileOf(cur, t), profileOf(base, t)
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\n", t, ctp.Actors, ctp.Stat.Size, ctp.Stat.Links, fmtObjDelta(ctp.Stat, btp.Stat))
+
+		fields := make([]string, 0, len(ctp.Structures))
+		for f := range ctp.Structures {
+			fields = append(fields, f)
+		}
+		sort.Slice(fields, func(i, j int) bool {
+			return ctp.Structures[fields[i]].Size > ctp.Structures[fields[j]].Size
+		})
+		for _, f := range fields {
+			s := ctp.Structures[f]
+			fmt.Fprintf(tw, "  %s\t\t%d\t%d\t%s\n", f, s.Size, s.Links, fmtObjDelta(s, btp.Structures[f]))
+		}
+	}
+	_ = tw.Flush()
+	fmt.Println()
+
+	// list the fastest growing actors in diff mode, which are the largest
+	// ones otherwise
+	addrs := make([]address.Address, 0, len(cur.Actors))
+	for a := range cur.Actors {
+		addrs = append(addrs, a)
+	}
+	growth := func(a address.Address) int64 {
+		return int64(cur.Actors[a].Stat.Size) - int64(base.Actors[a].Stat.Size)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return growth(addrs[i]) > growth(addrs[j])
+	})
+	if len(addrs) > top {
+		addrs = addrs[:top]
+	}
+
+	fmt.Fprintf(tw, "Actor\tType\tSize\tNodes\tChange\n")
+	for _, a := range addrs {
+		ap := cur.Actors[a]
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", a, ap.Type, ap.Stat.Size, ap.Stat.Links, fmtObjDelta(ap.Stat, base.Actors[a].Stat))
+	}
+	_ = tw.Flush()
+}
+
+func profileOf(p *stateProfile, typ string) *actorTypeProfile {
+	if tp, ok := p.Types[typ]; ok {
+		return tp
+	}
+	return &actorTypeProfile{Structures: map[string]api.ObjStat{}}
+}
+
+func fmtObjDelta(cur, base api.ObjStat) string {
+	if base.Size == 0 && base.Links == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%+d bytes, %+d nodes", int64(cur.Size)-int64(base.Size), int64(cur.Links)-int64(base.Links))
+}
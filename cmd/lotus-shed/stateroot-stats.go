@@ -20,6 +20,7 @@ var staterootCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		staterootDiffsCmd,
 		staterootStatCmd,
+		staterootProfileCmd,
 	},
 }
 
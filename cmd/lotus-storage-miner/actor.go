@@ -1,9 +1,14 @@
 package main
 
 import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
+	"time"
 
 	cbor "github.com/ipfs/go-ipld-cbor"
 
@@ -16,13 +21,16 @@ import (
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/crypto"
 
 	miner2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/miner"
 
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors"
 	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/types"
 	lcli "github.com/filecoin-project/lotus/cli"
@@ -38,9 +46,13 @@ var actorCmd = &cli.Command{
 		actorRepayDebtCmd,
 		actorSetPeeridCmd,
 		actorSetOwnerCmd,
+		actorChangeOwnerCmd,
 		actorControl,
 		actorProposeChangeWorker,
 		actorConfirmChangeWorker,
+		actorSetWorkerCmd,
+		actorSpendingCmd,
+		actorPayoutScheduleCmd,
 	},
 }
 
@@ -371,6 +383,120 @@ var actorRepayDebtCmd = &cli.Command{
 	},
 }
 
+var actorChangeOwnerCmd = &cli.Command{
+	Name:  "change-owner",
+	Usage: "Propose a new owner address after checking the new owner can sign",
+	Description: `A guarded alternative to the first step of set-owner. 'prepare' checks that the
+new owner exists on chain and is an account or a multisig, and returns a
+challenge to sign with its key, or the key of one of its signers. 'confirm'
+checks the signature and proposes the new owner from the current owner, within
+10 minutes of 'prepare'. The new owner then accepts the change with set-owner.`,
+	Subcommands: []*cli.Command{
+		actorChangeOwnerPrepareCmd,
+		actorChangeOwnerConfirmCmd,
+	},
+}
+
+var actorChangeOwnerPrepareCmd = &cli.Command{
+	Name:      "prepare",
+	Usage:     "Check the new owner address and get the challenge to sign",
+	ArgsUsage: "[newOwnerAddress]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return fmt.Errorf("must pass new owner address")
+		}
+
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		api, acloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer acloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		na, err := lcli.ResolveAddress(ctx, cctx, api, cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		oc, err := nodeApi.ActorOwnerChangePrepare(ctx, na)
+		if err != nil {
+			return err
+		}
+
+		if outputJSON(cctx) {
+			return printJSON(oc)
+		}
+
+		fmt.Printf("Current owner:\t%s\n", oc.OldOwner)
+		fmt.Printf("New owner:\t%s\n", oc.NewOwner)
+		fmt.Printf("Expires:\t%s\n", oc.Expires.Format(time.RFC3339))
+		fmt.Println()
+		fmt.Println("Sign the challenge with one of the keys of the new owner:")
+		for _, key := range oc.Signers {
+			fmt.Printf("  lotus wallet sign %s %x\n", key, oc.Challenge)
+		}
+		fmt.Println("then propose the new owner with:")
+		fmt.Println("  lotus-miner actor change-owner confirm --really-do-it <signature>")
+		return nil
+	},
+}
+
+var actorChangeOwnerConfirmCmd = &cli.Command{
+	Name:      "confirm",
+	Usage:     "Propose the prepared new owner, given the signed challenge",
+	ArgsUsage: "[signature]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "Actually send transaction performing the action",
+			Value: false,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return fmt.Errorf("must pass the signature of the challenge")
+		}
+
+		sigBytes, err := hex.DecodeString(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("decoding signature: %w", err)
+		}
+		var sig crypto.Signature
+		if err := sig.UnmarshalBinary(sigBytes); err != nil {
+			return xerrors.Errorf("decoding signature: %w", err)
+		}
+
+		if !cctx.Bool("really-do-it") {
+			fmt.Fprintln(cctx.App.Writer, "Pass --really-do-it to actually execute this action")
+			return nil
+		}
+
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		mc, err := nodeApi.ActorOwnerChangeConfirm(ctx, sig)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cctx.App.Writer, "Propose Message CID:", mc)
+		fmt.Fprintln(cctx.App.Writer, "Once it lands on chain, the new owner must accept the change, e.g. with 'lotus-miner actor set-owner <newOwner> <newOwner>'")
+		return nil
+	},
+}
+
 var actorControl = &cli.Command{
 	Name:  "control",
 	Usage: "Manage control addresses",
@@ -539,7 +665,23 @@ var actorControlSet = &cli.Command{
 	Name:      "set",
 	Usage:     "Set control address(-es)",
 	ArgsUsage: "[...address]",
+	Description: `Replaces the full list of control addresses in a single message. The addresses
+are checked first: they must exist on chain and be accounts, and addresses not
+in the wallet of the node, with a low balance, or used in the Addresses section
+of the config but removed from the list are reported.
+
+With --from-file, the addresses are read from the file, one per line; empty
+lines and lines starting with # are ignored.`,
 	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "from-file",
+			Usage: "read the addresses from a file",
+		},
+		&cli.StringFlag{
+			Name:  "min-balance",
+			Usage: "warn about addresses with a balance below this amount",
+			Value: "5",
+		},
 		&cli.BoolFlag{
 			Name:  "really-do-it",
 			Usage: "Actually send transaction performing the action",
@@ -561,93 +703,112 @@ var actorControlSet = &cli.Command{
 
 		ctx := lcli.ReqContext(cctx)
 
-		maddr, err := nodeApi.ActorAddress(ctx)
-		if err != nil {
-			return err
+		args := cctx.Args().Slice()
+		if cctx.IsSet("from-file") {
+			if len(args) > 0 {
+				return xerrors.Errorf("pass the addresses either as arguments or with --from-file")
+			}
+			if args, err = readAddressFile(cctx.String("from-file")); err != nil {
+				return err
+			}
 		}
 
-		mi, err := api.StateMinerInfo(ctx, maddr, types.EmptyTSK)
+		minBalance, err := types.ParseFIL(cctx.String("min-balance"))
 		if err != nil {
-			return err
+			return xerrors.Errorf("parsing min-balance: %w", err)
 		}
 
-		del := map[address.Address]struct{}{}
-		existing := map[address.Address]struct{}{}
-		for _, controlAddress := range mi.ControlAddresses {
-			ka, err := api.StateAccountKey(ctx, controlAddress, types.EmptyTSK)
+		var addrs []address.Address
+		for i, as := range args {
+			a, err := lcli.ResolveAddress(ctx, cctx, api, as)
 			if err != nil {
-				return err
+				return xerrors.Errorf("parsing address %d: %w", i, err)
 			}
-
-			del[ka] = struct{}{}
-			existing[ka] = struct{}{}
+			addrs = append(addrs, a)
 		}
 
-		var toSet []address.Address
-
-		for i, as := range cctx.Args().Slice() {
-			a, err := address.NewFromString(as)
-			if err != nil {
-				return xerrors.Errorf("parsing address %d: %w", i, err)
-			}
+		plan, err := nodeApi.ActorControlSetPlan(ctx, addrs, abi.TokenAmount(minBalance))
+		if err != nil {
+			return err
+		}
 
-			ka, err := api.StateAccountKey(ctx, a, types.EmptyTSK)
-			if err != nil {
+		if outputJSON(cctx) {
+			if err := printJSON(plan); err != nil {
 				return err
 			}
-
-			// make sure the address exists on chain
-			_, err = api.StateLookupID(ctx, ka, types.EmptyTSK)
-			if err != nil {
-				return xerrors.Errorf("looking up %s: %w", ka, err)
+		} else {
+			keys := map[address.Address]address.Address{}
+			for _, c := range plan.Addresses {
+				keys[c.ID] = c.Key
 			}
 
-			delete(del, ka)
-			toSet = append(toSet, ka)
+			for _, id := range plan.Remove {
+				key, err := api.StateAccountKey(ctx, id, types.EmptyTSK)
+				if err != nil {
+					key = id
+				}
+				fmt.Println("Remove", key)
+			}
+			for _, id := range plan.Add {
+				fmt.Println("Add", keys[id])
+			}
+			for _, id := range plan.Keep {
+				fmt.Println("Keep", keys[id])
+			}
+			for _, w := range plan.Warnings {
+				fmt.Println(color.YellowString("Warning: %s", w))
+			}
+			for _, e := range plan.Errors {
+				fmt.Println(color.RedString("Error: %s", e))
+			}
 		}
 
-		for a := range del {
-			fmt.Println("Remove", a)
+		if len(plan.Errors) > 0 {
+			return xerrors.Errorf("invalid control addresses")
 		}
-		for _, a := range toSet {
-			if _, exists := existing[a]; !exists {
-				fmt.Println("Add", a)
+		if len(plan.Add) == 0 && len(plan.Remove) == 0 {
+			if !outputJSON(cctx) {
+				fmt.Println("Control addresses already set")
 			}
+			return nil
 		}
 
 		if !cctx.Bool("really-do-it") {
-			fmt.Println("Pass --really-do-it to actually execute this action")
+			if !outputJSON(cctx) {
+				fmt.Println("Pass --really-do-it to actually execute this action")
+			}
 			return nil
 		}
 
-		cwp := &miner2.ChangeWorkerAddressParams{
-			NewWorker:       mi.Worker,
-			NewControlAddrs: toSet,
-		}
-
-		sp, err := actors.SerializeParams(cwp)
+		mc, err := nodeApi.ActorControlSet(ctx, addrs)
 		if err != nil {
-			return xerrors.Errorf("serializing params: %w", err)
+			return err
 		}
 
-		smsg, err := api.MpoolPushMessage(ctx, &types.Message{
-			From:   mi.Owner,
-			To:     maddr,
-			Method: miner.Methods.ChangeWorkerAddress,
-
-			Value:  big.Zero(),
-			Params: sp,
-		}, nil)
-		if err != nil {
-			return xerrors.Errorf("mpool push: %w", err)
-		}
-
-		fmt.Println("Message CID:", smsg.Cid())
+		fmt.Println("Message CID:", mc)
 
 		return nil
 	},
 }
 
+// readAddressFile reads the addresses in a file, one per line
+func readAddressFile(path string) ([]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("reading address file: %w", err)
+	}
+
+	var out []string
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return out, nil
+}
+
 var actorSetOwnerCmd = &cli.Command{
 	Name:      "set-owner",
 	Usage:     "Set owner address (this command should be invoked twice, first with the old owner as the senderAddress, and then with the new owner)",
@@ -683,7 +844,7 @@ var actorSetOwnerCmd = &cli.Command{
 
 		ctx := lcli.ReqContext(cctx)
 
-		na, err := address.NewFromString(cctx.Args().First())
+		na, err := lcli.ResolveAddress(ctx, cctx, api, cctx.Args().First())
 		if err != nil {
 			return err
 		}
@@ -693,7 +854,7 @@ var actorSetOwnerCmd = &cli.Command{
 			return err
 		}
 
-		fa, err := address.NewFromString(cctx.Args().Get(1))
+		fa, err := lcli.ResolveAddress(ctx, cctx, api, cctx.Args().Get(1))
 		if err != nil {
 			return err
 		}
@@ -783,7 +944,7 @@ var actorProposeChangeWorker = &cli.Command{
 
 		ctx := lcli.ReqContext(cctx)
 
-		na, err := address.NewFromString(cctx.Args().First())
+		na, err := lcli.ResolveAddress(ctx, cctx, api, cctx.Args().First())
 		if err != nil {
 			return err
 		}
@@ -898,7 +1059,7 @@ var actorConfirmChangeWorker = &cli.Command{
 
 		ctx := lcli.ReqContext(cctx)
 
-		na, err := address.NewFromString(cctx.Args().First())
+		na, err := lcli.ResolveAddress(ctx, cctx, api, cctx.Args().First())
 		if err != nil {
 			return err
 		}
@@ -970,3 +1131,308 @@ var actorConfirmChangeWorker = &cli.Command{
 		return nil
 	},
 }
+
+var actorSetWorkerCmd = &cli.Command{
+	Name:      "set-worker",
+	Usage:     "Change the worker address, or show the state of the last change",
+	ArgsUsage: "[address]",
+	Description: `With --rotate, the miner checks that the new worker key is in the wallet and
+can sign, proposes the change, raises an alert shortly before it becomes
+effective, confirms it once it can be, and checks that the new worker can sign
+blocks. The miner must keep running until the change is done.
+
+Without an address, shows the state of the last worker change started this way.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "rotate",
+			Usage: "have the miner propose and confirm the change",
+		},
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "Actually send transaction performing the action",
+			Value: false,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		if !cctx.Args().Present() {
+			r, err := nodeApi.ActorWorkerRotation(ctx)
+			if err != nil {
+				return err
+			}
+			if r == nil {
+				fmt.Println("No worker change started")
+				return nil
+			}
+			return printWorkerRotation(cctx, r)
+		}
+
+		if !cctx.Bool("rotate") {
+			return xerrors.Errorf("pass --rotate to have the miner change the worker, or use propose-change-worker and confirm-change-worker")
+		}
+
+		api, acloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer acloser()
+
+		na, err := lcli.ResolveAddress(ctx, cctx, api, cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		if !cctx.Bool("really-do-it") {
+			fmt.Fprintln(cctx.App.Writer, "Pass --really-do-it to actually execute this action")
+			return nil
+		}
+
+		r, err := nodeApi.ActorWorkerRotate(ctx, na)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cctx.App.Writer, "Worker key change to %s proposed, the miner will confirm it at or after height %d\n", r.NewWorker, r.EffectiveEpoch)
+		fmt.Fprintln(cctx.App.Writer, "Keep the miner running until then, and check progress with 'lotus-miner actor set-worker'")
+		return nil
+	},
+}
+
+func printWorkerRotation(cctx *cli.Context, r *api.WorkerRotation) error {
+	if outputJSON(cctx) {
+		return printJSON(r)
+	}
+
+	phase := string(r.Phase)
+	switch r.Phase {
+	case api.WorkerRotationDone:
+		phase = color.GreenString(phase)
+	case api.WorkerRotationFailed:
+		phase = color.RedString(phase)
+	}
+
+	fmt.Printf("Phase:\t\t%s\n", phase)
+	fmt.Printf("Old worker:\t%s\n", r.OldWorker)
+	fmt.Printf("New worker:\t%s (%s)\n", r.NewWorker, r.NewKey)
+	fmt.Printf("Started:\t%s\n", r.Started.Format(time.RFC3339))
+	fmt.Printf("Effective at:\t%d\n", r.EffectiveEpoch)
+	if r.ProposeMessage != nil {
+		fmt.Printf("Proposed in:\t%s\n", r.ProposeMessage)
+	}
+	if r.ConfirmMessage != nil {
+		fmt.Printf("Confirmed in:\t%s\n", r.ConfirmMessage)
+	}
+	if !r.Finished.IsZero() {
+		fmt.Printf("Finished:\t%s\n", r.Finished.Format(time.RFC3339))
+	}
+	if r.Error != "" {
+		fmt.Printf("Error:\t\t%s\n", color.RedString(r.Error))
+	}
+	return nil
+}
+
+var actorSpendingCmd = &cli.Command{
+	Name:  "spending",
+	Usage: "Report the funds spent on gas by the miner addresses, per day and category of messages",
+	Description: `Messages sent by the owner, worker and control addresses are categorized as
+precommit, commit, post (window PoSt and fault declarations), publish (deal
+publishing) or other. Window PoSt spending is also reported per deadline.`,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "from",
+			Usage: "first epoch of the range; defaults to a week before its end",
+		},
+		&cli.Int64Flag{
+			Name:  "to",
+			Usage: "last epoch of the range; defaults to the chain head",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "output format: table, json or csv",
+			Value: "table",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		api, acloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer acloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		format := cctx.String("output")
+		if !cctx.IsSet("output") && outputJSON(cctx) {
+			format = "json"
+		}
+		switch format {
+		case "table", "json", "csv":
+		default:
+			return xerrors.Errorf("unknown output format %q", format)
+		}
+
+		maddr, err := getActorAddress(ctx, cctx)
+		if err != nil {
+			return err
+		}
+
+		from, to, err := epochRange(cctx, api, 7*builtin.EpochsInDay)
+		if err != nil {
+			return err
+		}
+
+		sp, err := nodeApi.ActorSpending(ctx, maddr, from, to)
+		if err != nil {
+			return err
+		}
+
+		switch format {
+		case "json":
+			out, err := json.MarshalIndent(sp, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			if err := w.Write([]string{"day", "address", "category", "messages", "spent"}); err != nil {
+				return err
+			}
+			for _, e := range sp.Entries {
+				if err := w.Write([]string{e.Day, e.Address.String(), e.Category, fmt.Sprint(e.Messages), e.Spent.String()}); err != nil {
+					return err
+				}
+			}
+			w.Flush()
+			return w.Error()
+		}
+
+		total := big.Zero()
+		tw := tablewriter.New(
+			tablewriter.Col("Day"),
+			tablewriter.Col("Address"),
+			tablewriter.Col("Category"),
+			tablewriter.Col("Messages"),
+			tablewriter.Col("Spent"),
+		)
+		for _, e := range sp.Entries {
+			total = big.Add(total, e.Spent)
+			tw.Write(map[string]interface{}{
+				"Day":      e.Day,
+				"Address":  e.Address,
+				"Category": e.Category,
+				"Messages": e.Messages,
+				"Spent":    types.FIL(e.Spent),
+			})
+		}
+		if err := tw.Flush(os.Stdout); err != nil {
+			return err
+		}
+		fmt.Printf("Total spent over epochs %d to %d: %s\n", sp.From, sp.To, types.FIL(total))
+
+		if len(sp.Deadlines) == 0 {
+			return nil
+		}
+
+		fmt.Println("\nWindow PoSt by deadline:")
+		tw = tablewriter.New(
+			tablewriter.Col("Deadline"),
+			tablewriter.Col("Messages"),
+			tablewriter.Col("Spent"),
+		)
+		for _, d := range sp.Deadlines {
+			tw.Write(map[string]interface{}{
+				"Deadline": d.Deadline,
+				"Messages": d.Messages,
+				"Spent":    types.FIL(d.Spent),
+			})
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
+
+var actorPayoutScheduleCmd = &cli.Command{
+	Name:  "payout-schedule",
+	Usage: "Review the scheduled payouts from the miner actor",
+	Description: `Scheduled payouts periodically withdraw the available balance above the
+configured float to the owner address, and send the configured shares of it to
+the payout addresses. They are configured in the Payout section of the config.`,
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		ps, err := nodeApi.PayoutSchedule(ctx)
+		if err != nil {
+			return err
+		}
+
+		if outputJSON(cctx) {
+			return printJSON(ps)
+		}
+
+		fmt.Printf("Interval:\t%s\n", ps.Interval)
+		fmt.Printf("Float:\t\t%s\n", types.FIL(ps.Float))
+		fmt.Printf("Min amount:\t%s\n", types.FIL(ps.MinAmount))
+		fmt.Printf("Next payout:\t%s (in %s)\n", ps.Next.Format(time.RFC3339), time.Until(ps.Next).Truncate(time.Second))
+
+		fmt.Println("Shares:")
+		left := uint64(100)
+		for _, s := range ps.Shares {
+			fmt.Printf("  %s: %d%%\n", s.Address, s.Percent)
+			left -= s.Percent
+		}
+		if left > 0 {
+			fmt.Printf("  owner: %d%%\n", left)
+		}
+
+		if len(ps.History) == 0 {
+			return nil
+		}
+
+		fmt.Println("\nRecent payouts:")
+		tw := tablewriter.New(
+			tablewriter.Col("Time"),
+			tablewriter.Col("Available"),
+			tablewriter.Col("Withdrawn"),
+			tablewriter.Col("Payments"),
+			tablewriter.NewLineCol("Status"),
+		)
+		for _, r := range ps.History {
+			status := "ok"
+			switch {
+			case r.Error != "":
+				status = color.RedString(r.Error)
+			case r.Skipped:
+				status = "skipped, not enough above the float"
+			}
+
+			tw.Write(map[string]interface{}{
+				"Time":      r.Time.Format(time.RFC3339),
+				"Available": types.FIL(r.Available),
+				"Withdrawn": types.FIL(r.Withdrawn),
+				"Payments":  len(r.Payments),
+				"Status":    status,
+			})
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
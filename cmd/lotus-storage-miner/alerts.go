@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
+)
+
+var alertsCmd = &cli.Command{
+	Name:  "alerts",
+	Usage: "Manage miner alerts",
+	Subcommands: []*cli.Command{
+		alertsListCmd,
+		alertsAckCmd,
+	},
+}
+
+var alertsListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List alerts",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "also show inactive alerts",
+		},
+		&cli.BoolFlag{
+			Name:  "color",
+			Usage: "use color in display output",
+			Value: true,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		color.NoColor = !cctx.Bool("color")
+
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		alerts, err := nodeApi.AlertsList(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting alerts: %w", err)
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Type"),
+			tablewriter.Col("State"),
+			tablewriter.Col("Since"),
+			tablewriter.NewLineCol("Message"))
+
+		for _, alert := range alerts {
+			if !alert.Active && !cctx.Bool("all") {
+				continue
+			}
+
+			m := map[string]interface{}{
+				"Type": alert.Type.String(),
+			}
+
+			var evt *alerting.AlertEvent
+			switch {
+			case alert.Active && alert.Acked:
+				m["State"] = color.YellowString("acked")
+				evt = alert.LastActive
+			case alert.Active:
+				m["State"] = color.RedString("active")
+				evt = alert.LastActive
+			default:
+				m["State"] = color.GreenString("resolved")
+				evt = alert.LastResolved
+			}
+
+			if evt != nil {
+				m["Since"] = evt.Time.Format(time.Stamp)
+				m["Message"] = string(evt.Message)
+			}
+
+			tw.Write(m)
+		}
+
+		return tw.Flush(os.Stdout)
+	},
+}
+
+var alertsAckCmd = &cli.Command{
+	Name:      "ack",
+	Usage:     "Acknowledge an active alert",
+	ArgsUsage: "[system:subsystem]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return lcli.ShowHelp(cctx, fmt.Errorf("must specify the alert type"))
+		}
+
+		parts := strings.SplitN(cctx.Args().First(), ":", 2)
+		if len(parts) != 2 {
+			return xerrors.Errorf("alert type must be in system:subsystem form")
+		}
+
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		return nodeApi.AlertsAck(ctx, alerting.AlertType{
+			System:    parts[0],
+			Subsystem: parts[1],
+		})
+	},
+}
@@ -1,14 +1,123 @@
 package main
 
 import (
+	"context"
+	"fmt"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/mitchellh/go-homedir"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-jsonrpc"
 
 	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/backupds"
 	"github.com/filecoin-project/lotus/node/repo"
 )
 
-var backupCmd = lcli.BackupCmd(FlagMinerRepo, repo.StorageMiner, func(cctx *cli.Context) (lcli.BackupAPI, jsonrpc.ClientCloser, error) {
+var backupCmd = withIncrementalBackup(lcli.BackupCmd(FlagMinerRepo, repo.StorageMiner, func(cctx *cli.Context) (lcli.BackupAPI, jsonrpc.ClientCloser, error) {
 	return lcli.GetStorageMinerAPI(cctx)
-})
+}))
+
+// withIncrementalBackup adds the --incremental flag to the backup command,
+// which takes snapshots of the metadata and keystore into a directory
+func withIncrementalBackup(cmd *cli.Command) *cli.Command {
+	cmd.Description += `
+
+Incremental backups:
+With --incremental, the path is a directory holding snapshots of the metadata
+datastore and of the keystore. The first snapshot in the directory holds all
+metadata, the following ones only the metadata changed since the previous
+snapshot. Restore with 'lotus-miner init restore [directory]'.`
+	cmd.Flags = append(cmd.Flags, &cli.BoolFlag{
+		Name:  "incremental",
+		Usage: "add an incremental snapshot to the backup directory at the specified path",
+	})
+
+	action := cmd.Action
+	cmd.Action = func(cctx *cli.Context) error {
+		if !cctx.Bool("incremental") {
+			return action(cctx)
+		}
+		if cctx.Args().Len() != 1 {
+			return xerrors.Errorf("expected 1 argument")
+		}
+
+		if cctx.Bool("offline") {
+			return offlineIncrementalBackup(cctx)
+		}
+
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return xerrors.Errorf("getting api: %w (if the node isn't running you can use the --offline flag)", err)
+		}
+		defer closer()
+
+		snap, err := nodeApi.CreateBackupIncremental(lcli.ReqContext(cctx), cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Wrote %s: %d changed entries, %d deleted (full: %t)\n", snap.File, snap.Entries, snap.Deleted, snap.Full)
+		return nil
+	}
+
+	return cmd
+}
+
+func offlineIncrementalBackup(cctx *cli.Context) error {
+	logging.SetLogLevel("badger", "ERROR") // nolint:errcheck
+
+	r, err := repo.NewFS(cctx.String(FlagMinerRepo))
+	if err != nil {
+		return err
+	}
+
+	ok, err := r.Exists()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return xerrors.Errorf("repo at '%s' is not initialized", cctx.String(FlagMinerRepo))
+	}
+
+	lr, err := r.LockRO(repo.StorageMiner)
+	if err != nil {
+		return xerrors.Errorf("locking repo: %w", err)
+	}
+	defer lr.Close() // nolint:errcheck
+
+	mds, err := lr.Datastore(context.TODO(), "/metadata")
+	if err != nil {
+		return xerrors.Errorf("getting metadata datastore: %w", err)
+	}
+
+	bds, err := backupds.Wrap(mds, backupds.NoLogdir)
+	if err != nil {
+		return err
+	}
+
+	ks, err := lr.KeyStore()
+	if err != nil {
+		return err
+	}
+
+	keys, err := backupds.KeystoreEntries(ks)
+	if err != nil {
+		return xerrors.Errorf("reading keystore: %w", err)
+	}
+
+	dir, err := homedir.Expand(cctx.Args().First())
+	if err != nil {
+		return xerrors.Errorf("expanding backup path: %w", err)
+	}
+
+	snap, err := bds.BackupIncremental(dir, keys)
+	if err != nil {
+		return xerrors.Errorf("backup error: %w", err)
+	}
+
+	fmt.Printf("Wrote %s: %d changed entries, %d deleted (full: %t)\n", snap.File, snap.Entries, len(snap.Deleted), snap.Full)
+	return nil
+}
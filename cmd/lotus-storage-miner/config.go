@@ -2,15 +2,26 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
+	"github.com/mitchellh/go-homedir"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
 
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
 	"github.com/filecoin-project/lotus/node/config"
 )
 
 var configCmd = &cli.Command{
 	Name:  "config",
 	Usage: "Output default configuration",
+	Subcommands: []*cli.Command{
+		configReloadCmd,
+		configValidateCmd,
+		configDiffCmd,
+	},
 	Action: func(cctx *cli.Context) error {
 		comm, err := config.ConfigComment(config.DefaultStorageMiner())
 		if err != nil {
@@ -20,3 +31,117 @@ var configCmd = &cli.Command{
 		return nil
 	},
 }
+
+var configReloadCmd = &cli.Command{
+	Name:  "reload",
+	Usage: "Apply changes of the sealing and fee config without restarting the miner",
+	Description: `Reloads the Sealing and Fees sections of config.toml. Other settings still
+require a restart. Sending SIGHUP to the miner process has the same effect.`,
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		if err := nodeApi.ConfigReload(ctx); err != nil {
+			return err
+		}
+
+		fmt.Println("Config reloaded")
+		return nil
+	},
+}
+
+var configFileFlag = &cli.StringFlag{
+	Name:  "config",
+	Usage: "config file to check, defaults to the config.toml of the miner repo",
+}
+
+var configValidateCmd = &cli.Command{
+	Name:  "validate",
+	Usage: "Check the config file for mistakes",
+	Description: `Loads the config file the way the miner does, including the LOTUS_*
+environment variable overrides, and reports unknown or deprecated settings,
+and settings contradicting each other or the network limits. Exits with an
+error if the miner would fail to start, or to work, with the config.`,
+	Flags: []cli.Flag{
+		configFileFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		l, err := loadMinerConfigFile(cctx)
+		if err != nil {
+			return err
+		}
+
+		for _, i := range l.Issues {
+			if i.Key == "" {
+				fmt.Printf("%s: %s\n", i.Severity, i.Message)
+				continue
+			}
+			fmt.Printf("%s: %s: %s\n", i.Severity, i.Key, i.Message)
+		}
+
+		if l.HasErrors() {
+			return xerrors.Errorf("config is invalid")
+		}
+		if len(l.Issues) == 0 {
+			fmt.Println("Config is valid")
+		}
+		return nil
+	},
+}
+
+var configDiffCmd = &cli.Command{
+	Name:  "diff",
+	Usage: "Show the settings changed from their defaults",
+	Description: `Lists the settings set in the config file, or overridden by LOTUS_*
+environment variables, with their default, file and effective values.`,
+	Flags: []cli.Flag{
+		configFileFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		l, err := loadMinerConfigFile(cctx)
+		if err != nil {
+			return err
+		}
+		if l.File == nil || l.Effective == nil {
+			return xerrors.Errorf("loading config: %s", l.Issues[len(l.Issues)-1].Message)
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Setting"),
+			tablewriter.Col("Default"),
+			tablewriter.Col("File"),
+			tablewriter.Col("Effective"),
+		)
+		for _, e := range l.Diff() {
+			file := "-"
+			if e.InFile {
+				file = e.File
+			}
+			tw.Write(map[string]interface{}{
+				"Setting":   e.Key,
+				"Default":   e.Default,
+				"File":      file,
+				"Effective": e.Effective,
+			})
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
+
+func loadMinerConfigFile(cctx *cli.Context) (*config.LoadedMiner, error) {
+	path := cctx.String("config")
+	if path == "" {
+		repoPath, err := homedir.Expand(cctx.String(FlagMinerRepo))
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(repoPath, "config.toml")
+	}
+
+	return config.LoadStorageMiner(path)
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/gdamore/tcell/v2"
+	"github.com/google/uuid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/dline"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+	sealing "github.com/filecoin-project/lotus/extern/storage-sealing"
+	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+var dashboardCmd = &cli.Command{
+	Name:  "dashboard",
+	Usage: "Live view of the sealing pipeline, workers, batches, proving and alerts",
+	Description: `The dashboard polls the miner and its full node every interval, and redraws
+   countdowns every second. Press q or Esc to quit.`,
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "interval",
+			Usage: "how often to poll the miner",
+			Value: 5 * time.Second,
+		},
+		&cli.IntFlag{
+			Name:  "alerts",
+			Usage: "number of recent alerts to show",
+			Value: 5,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		fullApi, acloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer acloser()
+
+		ctx, cancel := context.WithCancel(lcli.ReqContext(cctx))
+		defer cancel()
+
+		maddr, err := getActorAddress(ctx, cctx)
+		if err != nil {
+			return err
+		}
+
+		screen, err := tcell.NewScreen()
+		if err != nil {
+			return xerrors.Errorf("creating screen: %w", err)
+		}
+		if err := screen.Init(); err != nil {
+			return xerrors.Errorf("initializing screen: %w", err)
+		}
+		defer screen.Fini()
+
+		events := make(chan tcell.Event)
+		go func() {
+			for {
+				ev := screen.PollEvent()
+				if ev == nil {
+					return // screen finalized
+				}
+				select {
+				case events <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		updates := make(chan *dashboardState)
+		go func() {
+			for {
+				st := fetchDashboard(ctx, nodeApi, fullApi, maddr)
+				select {
+				case updates <- st:
+				case <-ctx.Done():
+					return
+				}
+
+				select {
+				case <-time.After(cctx.Duration("interval")):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		redraw := time.NewTicker(time.Second)
+		defer redraw.Stop()
+
+		st := &dashboardState{maddr: maddr}
+		for {
+			drawDashboard(screen, st, cctx.Int("alerts"))
+
+			select {
+			case ev := <-events:
+				switch ev := ev.(type) {
+				case *tcell.EventKey:
+					if ev.Key() == tcell.KeyEscape || ev.Key() == tcell.KeyCtrlC || ev.Rune() == 'q' {
+						return nil
+					}
+				case *tcell.EventResize:
+					screen.Sync()
+				}
+			case st = <-updates:
+			case <-redraw.C:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	},
+}
+
+// dashboardState is a snapshot of the miner, fetch errors are shown in the
+// sections they affect
+type dashboardState struct {
+	at    time.Time
+	maddr address.Address
+
+	summary    map[api.SectorState]int
+	summaryErr error
+
+	workers    map[uuid.UUID]storiface.WorkerStats
+	jobs       map[uuid.UUID][]storiface.WorkerJob
+	workersErr error
+
+	batches    api.SectorBatchStatus
+	batchesErr error
+
+	deadline           *dline.Info
+	faults, recoveries uint64
+	provingErr         error
+
+	alerts    []alerting.Alert
+	alertsErr error
+}
+
+func fetchDashboard(ctx context.Context, nodeApi api.StorageMiner, fullApi v0api.FullNode, maddr address.Address) *dashboardState {
+	st := &dashboardState{
+		at:    time.Now(),
+		maddr: maddr,
+	}
+
+	st.summary, st.summaryErr = nodeApi.SectorsSummary(ctx)
+
+	st.workers, st.workersErr = nodeApi.WorkerStats(ctx)
+	if st.workersErr == nil {
+		st.jobs, st.workersErr = nodeApi.WorkerJobs(ctx)
+	}
+
+	st.batches, st.batchesErr = nodeApi.SectorBatchStatus(ctx)
+
+	st.provingErr = func() error {
+		di, err := fullApi.StateMinerProvingDeadline(ctx, maddr, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting proving deadline: %w", err)
+		}
+		st.deadline = di
+
+		faults, err := fullApi.StateMinerFaults(ctx, maddr, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting faults: %w", err)
+		}
+		if st.faults, err = faults.Count(); err != nil {
+			return err
+		}
+
+		recoveries, err := fullApi.StateMinerRecoveries(ctx, maddr, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting recoveries: %w", err)
+		}
+		st.recoveries, err = recoveries.Count()
+		return err
+	}()
+
+	st.alerts, st.alertsErr = nodeApi.AlertsList(ctx)
+
+	return st
+}
+
+type dashboardView struct {
+	screen tcell.Screen
+	row    int
+}
+
+func (v *dashboardView) line(style tcell.Style, f string, args ...interface{}) {
+	for x, r := range []rune(fmt.Sprintf(f, args...)) {
+		v.screen.SetContent(x, v.row, r, nil, style)
+	}
+	v.row++
+}
+
+func (v *dashboardView) section(title string, err error) bool {
+	v.row++
+	v.line(tcell.StyleDefault.Bold(true), "%s", title)
+	if err != nil {
+		v.line(tcell.StyleDefault.Foreground(tcell.ColorRed), "  %s", err)
+		return false
+	}
+	return true
+}
+
+func drawDashboard(screen tcell.Screen, st *dashboardState, maxAlerts int) {
+	screen.Clear()
+	v := &dashboardView{screen: screen}
+	def := tcell.StyleDefault
+
+	if st.at.IsZero() {
+		v.line(def, "Miner %s, loading...", st.maddr)
+		screen.Show()
+		return
+	}
+	v.line(def, "Miner %s, updated %s ago (q to quit)", st.maddr, time.Since(st.at).Truncate(time.Second))
+
+	if v.section("Sealing pipeline", st.summaryErr) {
+		states := make([]sealing.SectorState, 0, len(st.summary))
+		for s := range st.summary {
+			states = append(states, sealing.SectorState(s))
+		}
+		sort.Slice(states, func(i, j int) bool {
+			return stateOrder[states[i]].i < stateOrder[states[j]].i
+		})
+
+		for _, s := range states {
+			v.line(def.Foreground(tcellColor(stateOrder[s].col)), "  %-22s %d", s, st.summary[api.SectorState(s)])
+		}
+	}
+
+	if v.section("Workers", st.workersErr) {
+		wids := make([]uuid.UUID, 0, len(st.workers))
+		for wid := range st.workers {
+			wids = append(wids, wid)
+		}
+		sort.Slice(wids, func(i, j int) bool {
+			return st.workers[wids[i]].Info.Hostname < st.workers[wids[j]].Info.Hostname
+		})
+
+		for _, wid := range wids {
+			stat := st.workers[wid]
+
+			var running, assigned int
+			for _, job := range st.jobs[wid] {
+				switch {
+				case job.RunWait == 0:
+					running++
+				case job.RunWait > 0:
+					assigned++
+				}
+			}
+
+			gpu := "idle"
+			if stat.GpuUsed {
+				gpu = "used"
+			}
+			if len(stat.Info.Resources.GPUs) == 0 {
+				gpu = "none"
+			}
+
+			var memUse uint64
+			if stat.Info.Resources.MemPhysical > 0 {
+				memUse = (stat.Info.Resources.MemReserved + stat.MemUsedMin) * 100 / stat.Info.Resources.MemPhysical
+			}
+
+			style := def
+			if !stat.Enabled {
+				style = def.Foreground(tcell.ColorGray)
+			}
+			v.line(style, "  %-22s CPU %d/%d  GPU %s  RAM %d%%  %d running, %d assigned",
+				stat.Info.Hostname, stat.CpuUse, stat.Info.Resources.CPUs, gpu, memUse, running, assigned)
+		}
+	}
+
+	if v.section("Batches", st.batchesErr) {
+		for _, b := range []struct {
+			name   string
+			status sealiface.BatchStatus
+		}{
+			{"PreCommit", st.batches.PreCommit},
+			{"Commit", st.batches.Commit},
+		} {
+			switch {
+			case b.status.Pending == 0:
+				v.line(def, "  %-22s empty", b.name)
+			case b.status.SendBy.IsZero():
+				v.line(def, "  %-22s %d sectors, no cutoff", b.name, b.status.Pending)
+			default:
+				sendIn := time.Until(b.status.SendBy).Truncate(time.Second)
+				style := def
+				if sendIn < time.Hour {
+					style = def.Foreground(tcell.ColorYellow)
+				}
+				v.line(style, "  %-22s %d sectors, sent in %s at the latest (cutoff in %s)",
+					b.name, b.status.Pending, sendIn, time.Until(b.status.Cutoff).Truncate(time.Second))
+			}
+		}
+	}
+
+	if v.section("Proving", st.provingErr) {
+		di := st.deadline
+		v.line(def, "  Deadline %d/%d, closes at epoch %s", di.Index, di.WPoStPeriodDeadlines, lcli.EpochTime(di.CurrentEpoch, di.Close))
+
+		style := def
+		if st.faults > 0 {
+			style = def.Foreground(tcell.ColorRed)
+		}
+		v.line(style, "  Faults: %d, recovering: %d", st.faults, st.recoveries)
+	}
+
+	if v.section("Alerts", st.alertsErr) {
+		type alertLine struct {
+			alerting.Alert
+			evt *alerting.AlertEvent
+		}
+
+		var lines []alertLine
+		for _, a := range st.alerts {
+			evt := a.LastResolved
+			if a.Active {
+				evt = a.LastActive
+			}
+			if evt == nil {
+				continue
+			}
+			lines = append(lines, alertLine{a, evt})
+		}
+
+		// active first, then most recent
+		sort.Slice(lines, func(i, j int) bool {
+			if lines[i].Active != lines[j].Active {
+				return lines[i].Active
+			}
+			return lines[i].evt.Time.After(lines[j].evt.Time)
+		})
+		if len(lines) > maxAlerts {
+			lines = lines[:maxAlerts]
+		}
+
+		if len(lines) == 0 {
+			v.line(def, "  none")
+		}
+		for _, l := range lines {
+			state, style := "resolved", def.Foreground(tcell.ColorGreen)
+			switch {
+			case l.Active && l.Acked:
+				state, style = "acked", def.Foreground(tcell.ColorYellow)
+			case l.Active:
+				state, style = "active", def.Foreground(tcell.ColorRed)
+			}
+			v.line(style, "  %-22s %-8s %s  %s", l.Type, state, l.evt.Time.Format(time.Stamp), string(l.evt.Message))
+		}
+	}
+
+	screen.Show()
+}
+
+// tcellColor maps the colors of the sector states list to the terminal
+func tcellColor(c color.Attribute) tcell.Color {
+	switch c {
+	case color.FgGreen:
+		return tcell.ColorGreen
+	case color.FgBlue:
+		return tcell.ColorBlue
+	case color.FgYellow:
+		return tcell.ColorYellow
+	case color.FgCyan:
+		return tcell.ColorTeal
+	case color.FgRed:
+		return tcell.ColorRed
+	default:
+		return tcell.ColorDefault
+	}
+}
@@ -90,6 +90,14 @@ func infoCmdAct(cctx *cli.Context) error {
 
 	fmt.Println()
 
+	fb, err := nodeApi.FeeBreakerStatus(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting fee breaker status: %w", err)
+	}
+	if fb.Enabled {
+		printFeeBreaker(fb)
+	}
+
 	maddr, err := getActorAddress(ctx, cctx)
 	if err != nil {
 		return err
@@ -295,6 +303,7 @@ var stateList = []stateMeta{
 	{col: color.FgYellow, state: sealing.PreCommitBatchWait},
 	{col: color.FgYellow, state: sealing.WaitSeed},
 	{col: color.FgYellow, state: sealing.Committing},
+	{col: color.FgYellow, state: sealing.RegenerateProof},
 	{col: color.FgYellow, state: sealing.CommitFinalize},
 	{col: color.FgYellow, state: sealing.SubmitCommit},
 	{col: color.FgYellow, state: sealing.CommitWait},
@@ -336,6 +345,24 @@ func init() {
 	}
 }
 
+func printFeeBreaker(fb api.FeeBreakerStatus) {
+	if !fb.Tripped {
+		fmt.Printf("Fee Breaker: [%s] (trips over %s)\n", color.GreenString("ok"), types.FIL(fb.TripBaseFee).Short())
+		return
+	}
+
+	fmt.Printf("Fee Breaker: [%s] since %s, resets under %s\n", color.RedString("tripped"), fb.Since.Format(time.Stamp), types.FIL(fb.ResetBaseFee).Short())
+
+	kinds := make([]string, 0, len(fb.Deferred))
+	for k := range fb.Deferred {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	for _, k := range kinds {
+		fmt.Printf("\tDeferred %s: %d\n", k, fb.Deferred[k])
+	}
+}
+
 func sectorsInfo(ctx context.Context, napi api.StorageMiner) error {
 	summary, err := napi.SectorsSummary(ctx)
 	if err != nil {
@@ -122,6 +122,7 @@ var initCmd = &cli.Command{
 	},
 	Subcommands: []*cli.Command{
 		initRestoreCmd,
+		initMarketsCmd,
 	},
 	Action: func(cctx *cli.Context) error {
 		log.Info("Initializing lotus miner")
@@ -469,7 +470,7 @@ func storageMinerInit(ctx context.Context, cctx *cli.Context, api v1api.FullNode
 				AllowPreCommit2:    true,
 				AllowCommit:        true,
 				AllowUnseal:        true,
-			}, wsts, smsts)
+			}, wsts, smsts, nil)
 
 			if err != nil {
 				return err
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"github.com/ipfs/go-datastore"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/client"
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	cliutil "github.com/filecoin-project/lotus/cli/util"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+var initMarketsCmd = &cli.Command{
+	Name:  "markets",
+	Usage: "Initialize a lotus miner repo running only the markets subsystem",
+	Description: `Creates a repo for a node making storage and retrieval deals for the miner
+served by the sealing node at --sealer-api-info. Pieces of the deals are sent
+to the sealing node, which shares its sector index with the markets node.
+
+The peer ID of the miner actor is changed to the one of the new node, so
+clients connect to it. The sealing node should then run with
+Subsystems.EnableMarkets set to false.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "sealer-api-info",
+			Usage:    "api info of the sealing node, with an admin token: TOKEN:MULTIADDR",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "nosync",
+			Usage: "don't check full-node sync status",
+		},
+		&cli.StringFlag{
+			Name:  "gas-premium",
+			Usage: "set gas premium for the peer ID change message",
+			Value: "0",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		log.Info("Initializing lotus miner markets node")
+
+		ctx := lcli.ReqContext(cctx)
+
+		gasPrice, err := types.BigFromString(cctx.String("gas-premium"))
+		if err != nil {
+			return xerrors.Errorf("failed to parse gas-price flag: %s", err)
+		}
+
+		if err := checkV1ApiSupport(ctx, cctx); err != nil {
+			return err
+		}
+
+		api, closer, err := lcli.GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if !cctx.Bool("nosync") {
+			if err := lcli.SyncWait(ctx, &v0api.WrapperV1Full{FullNode: api}, false); err != nil {
+				return xerrors.Errorf("sync wait: %w", err)
+			}
+		}
+
+		log.Info("Connecting to the sealing node")
+
+		sealerInfo := cctx.String("sealer-api-info")
+		ai := cliutil.ParseApiInfo(sealerInfo)
+		addr, err := ai.DialArgs("v0")
+		if err != nil {
+			return xerrors.Errorf("parsing sealer api info: %w", err)
+		}
+
+		sealer, scloser, err := client.NewStorageMinerRPCV0(ctx, addr, ai.AuthHeader())
+		if err != nil {
+			return xerrors.Errorf("connecting to the sealing node: %w", err)
+		}
+		defer scloser()
+
+		v, err := sealer.Version(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting sealer version: %w", err)
+		}
+		if !v.APIVersion.EqMajorMinor(lapi.MinerAPIVersion0) {
+			return xerrors.Errorf("sealer API version didn't match (expected %s, remote %s)", lapi.MinerAPIVersion0, v.APIVersion)
+		}
+
+		maddr, err := sealer.ActorAddress(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting actor address: %w", err)
+		}
+
+		log.Info("ACTOR ADDRESS: ", maddr.String())
+
+		repoPath := cctx.String(FlagMinerRepo)
+		r, err := repo.NewFS(repoPath)
+		if err != nil {
+			return err
+		}
+
+		ok, err := r.Exists()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return xerrors.Errorf("repo at '%s' is already initialized", repoPath)
+		}
+
+		log.Info("Initializing repo")
+
+		if err := r.Init(repo.StorageMiner); err != nil {
+			return err
+		}
+
+		lr, err := r.Lock(repo.StorageMiner)
+		if err != nil {
+			return err
+		}
+		defer lr.Close() //nolint:errcheck
+
+		var cerr error
+		err = lr.SetConfig(func(raw interface{}) {
+			rcfg, ok := raw.(*config.StorageMiner)
+			if !ok {
+				cerr = xerrors.New("expected miner config")
+				return
+			}
+
+			rcfg.Subsystems.EnableMarkets = true
+			rcfg.Subsystems.EnableSealing = false
+			rcfg.Subsystems.SealerApiInfo = sealerInfo
+		})
+		if cerr != nil {
+			return cerr
+		}
+		if err != nil {
+			return xerrors.Errorf("setting config: %w", err)
+		}
+
+		mds, err := lr.Datastore(ctx, "/metadata")
+		if err != nil {
+			return err
+		}
+
+		if err := mds.Put(datastore.NewKey("miner-address"), maddr.Bytes()); err != nil {
+			return err
+		}
+
+		log.Info("Initializing libp2p identity")
+
+		p2pSk, err := makeHostKey(lr)
+		if err != nil {
+			return xerrors.Errorf("make host key: %w", err)
+		}
+
+		peerid, err := peer.IDFromPrivateKey(p2pSk)
+		if err != nil {
+			return xerrors.Errorf("peer ID from private key: %w", err)
+		}
+
+		log.Info("Configuring miner actor")
+
+		if err := configureStorageMiner(ctx, api, maddr, peerid, gasPrice); err != nil {
+			return err
+		}
+
+		log.Warn("Set Subsystems.EnableMarkets to false in the config of the sealing node, and restart it")
+
+		return nil
+	},
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/docker/go-units"
 	"github.com/ipfs/go-datastore"
+	"github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/mitchellh/go-homedir"
 	"github.com/urfave/cli/v2"
@@ -47,7 +48,11 @@ var initRestoreCmd = &cli.Command{
 			Usage: "storage paths config (storage.json)",
 		},
 	},
-	ArgsUsage: "[backupFile]",
+	Description: `The backup is either a file written by 'lotus-miner backup', or a directory
+written by 'lotus-miner backup --incremental'. All snapshots in the directory
+are verified before anything is restored. The keystore, including the libp2p
+identity of the node, is restored from the directory too.`,
+	ArgsUsage: "[backupFile|backupDir]",
 	Action: func(cctx *cli.Context) error {
 		log.Info("Initializing lotus miner using a backup")
 		if cctx.Args().Len() != 1 {
@@ -95,11 +100,13 @@ var initRestoreCmd = &cli.Command{
 			return xerrors.Errorf("stat backup file (%s): %w", bf, err)
 		}
 
-		f, err := os.Open(bf)
-		if err != nil {
-			return xerrors.Errorf("opening backup file: %w", err)
+		if st.IsDir() {
+			log.Info("Verifying incremental backup")
+
+			if _, _, err := backupds.ReadIncremental(bf); err != nil {
+				return xerrors.Errorf("verifying backup: %w", err)
+			}
 		}
-		defer f.Close() // nolint:errcheck
 
 		log.Info("Checking if repo exists")
 
@@ -203,19 +210,43 @@ var initRestoreCmd = &cli.Command{
 			return err
 		}
 
-		bar := pb.New64(st.Size())
-		br := bar.NewProxyReader(f)
-		bar.ShowTimeLeft = true
-		bar.ShowPercent = true
-		bar.ShowSpeed = true
-		bar.Units = pb.U_BYTES
+		if st.IsDir() {
+			keys, err := backupds.RestoreIncrementalInto(bf, mds)
+			if err != nil {
+				return xerrors.Errorf("restoring metadata: %w", err)
+			}
 
-		bar.Start()
-		err = backupds.RestoreInto(br, mds)
-		bar.Finish()
+			log.Info("Restoring keystore")
 
-		if err != nil {
-			return xerrors.Errorf("restoring metadata: %w", err)
+			ks, err := lr.KeyStore()
+			if err != nil {
+				return err
+			}
+
+			if err := backupds.RestoreKeystore(keys, ks); err != nil {
+				return xerrors.Errorf("restoring keystore: %w", err)
+			}
+		} else {
+			f, err := os.Open(bf)
+			if err != nil {
+				return xerrors.Errorf("opening backup file: %w", err)
+			}
+			defer f.Close() // nolint:errcheck
+
+			bar := pb.New64(st.Size())
+			br := bar.NewProxyReader(f)
+			bar.ShowTimeLeft = true
+			bar.ShowPercent = true
+			bar.ShowSpeed = true
+			bar.Units = pb.U_BYTES
+
+			bar.Start()
+			err = backupds.RestoreInto(br, mds)
+			bar.Finish()
+
+			if err != nil {
+				return xerrors.Errorf("restoring metadata: %w", err)
+			}
 		}
 
 		log.Info("Checking actor metadata")
@@ -261,9 +292,15 @@ var initRestoreCmd = &cli.Command{
 
 		log.Info("Initializing libp2p identity")
 
-		p2pSk, err := makeHostKey(lr)
+		p2pSk, err := restoredHostKey(lr)
 		if err != nil {
-			return xerrors.Errorf("make host key: %w", err)
+			return xerrors.Errorf("reading restored host key: %w", err)
+		}
+		if p2pSk == nil {
+			p2pSk, err = makeHostKey(lr)
+			if err != nil {
+				return xerrors.Errorf("make host key: %w", err)
+			}
 		}
 
 		peerid, err := peer.IDFromPrivateKey(p2pSk)
@@ -271,6 +308,11 @@ var initRestoreCmd = &cli.Command{
 			return xerrors.Errorf("peer ID from private key: %w", err)
 		}
 
+		if mi.PeerId != nil && *mi.PeerId == peerid {
+			log.Info("Restored libp2p identity matches the miner actor")
+			return nil
+		}
+
 		log.Info("Configuring miner actor")
 
 		if err := configureStorageMiner(ctx, api, maddr, peerid, big.Zero()); err != nil {
@@ -280,3 +322,22 @@ var initRestoreCmd = &cli.Command{
 		return nil
 	},
 }
+
+// restoredHostKey returns the libp2p identity restored with the keystore, nil
+// if there is none
+func restoredHostKey(lr repo.LockedRepo) (crypto.PrivKey, error) {
+	ks, err := lr.KeyStore()
+	if err != nil {
+		return nil, err
+	}
+
+	ki, err := ks.Get("libp2p-host")
+	if xerrors.Is(err, types.ErrKeyInfoNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.UnmarshalPrivateKey(ki.PrivateKey)
+}
@@ -36,8 +36,13 @@ func main() {
 		stopCmd,
 		configCmd,
 		backupCmd,
+		alertsCmd,
+		maintenanceCmd,
+		dashboardCmd,
 		lcli.WithCategory("chain", actorCmd),
 		lcli.WithCategory("chain", infoCmd),
+		lcli.WithCategory("chain", miningCmd),
+		lcli.WithCategory("chain", reportCmd),
 		lcli.WithCategory("market", storageDealsCmd),
 		lcli.WithCategory("market", retrievalDealsCmd),
 		lcli.WithCategory("market", dataTransfersCmd),
@@ -96,6 +101,20 @@ func main() {
 				Value:   "~/.lotusminer", // TODO: Consider XDG_DATA_HOME
 				Usage:   fmt.Sprintf("Specify miner repo path. flag(%s) and env(LOTUS_STORAGE_PATH) are DEPRECATION, will REMOVE SOON", FlagMinerRepoDeprecation),
 			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "output format of the sectors list, proving deadlines, storage list, sealing jobs and sectors batching commands: text or json",
+				Value: "text",
+			},
+		},
+		Before: func(cctx *cli.Context) error {
+			switch out := cctx.String("output"); out {
+			case "text", "json":
+				cctx.App.Metadata["output"] = out
+				return nil
+			default:
+				return xerrors.Errorf("unknown output format %q, expected text or json", out)
+			}
 		},
 
 		Commands: append(local, lcli.CommonCommands...),
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+var maintenanceCmd = &cli.Command{
+	Name:  "maintenance",
+	Usage: "Manage miner maintenance mode",
+	Description: `In maintenance mode the miner stops dispatching new sealing tasks to workers,
+rejects new storage deals and holds batched PreCommit, Commit and Terminate
+messages. Window and winning PoSt keep running, as do tasks already running
+on workers. Held batches can still be sent manually with
+'sectors batching [commit|precommit] --publish-now' and 'sectors terminate flush'.`,
+	Subcommands: []*cli.Command{
+		maintenanceEnableCmd,
+		maintenanceDisableCmd,
+		maintenanceStatusCmd,
+	},
+}
+
+var maintenanceEnableCmd = &cli.Command{
+	Name:  "enable",
+	Usage: "Enter maintenance mode",
+	Action: func(cctx *cli.Context) error {
+		return setMaintenance(cctx, true)
+	},
+}
+
+var maintenanceDisableCmd = &cli.Command{
+	Name:  "disable",
+	Usage: "Leave maintenance mode",
+	Action: func(cctx *cli.Context) error {
+		return setMaintenance(cctx, false)
+	},
+}
+
+var maintenanceStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "Print whether the miner is in maintenance mode",
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		enabled, err := nodeApi.MaintenanceMode(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting maintenance mode: %w", err)
+		}
+
+		if enabled {
+			fmt.Println("Maintenance mode: enabled")
+		} else {
+			fmt.Println("Maintenance mode: disabled")
+		}
+		return nil
+	},
+}
+
+func setMaintenance(cctx *cli.Context, enabled bool) error {
+	nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	ctx := lcli.ReqContext(cctx)
+
+	if err := nodeApi.MaintenanceSet(ctx, enabled); err != nil {
+		return xerrors.Errorf("setting maintenance mode: %w", err)
+	}
+
+	if enabled {
+		fmt.Println("Maintenance mode enabled; sealing dispatch, deal acceptance and batch sends are paused")
+	} else {
+		fmt.Println("Maintenance mode disabled")
+	}
+	return nil
+}
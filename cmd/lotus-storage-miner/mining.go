@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
+)
+
+var miningCmd = &cli.Command{
+	Name:  "mining",
+	Usage: "Inspect block production",
+	Subcommands: []*cli.Command{
+		miningTemplateCmd,
+		miningReportCmd,
+	},
+}
+
+var miningTemplateCmd = &cli.Command{
+	Name:  "template",
+	Usage: "Dry run mining a block, reporting whether the miner would win and the expected rewards",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "epoch",
+			Usage: "epoch to mine at; defaults to the epoch after the chain head",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		fullApi, acloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer acloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		maddr, err := getActorAddress(ctx, cctx)
+		if err != nil {
+			return err
+		}
+
+		head, err := fullApi.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+
+		epoch := head.Height() + 1
+		if cctx.IsSet("epoch") {
+			epoch = abi.ChainEpoch(cctx.Int64("epoch"))
+		}
+
+		base, err := miningBase(ctx, fullApi, head, epoch)
+		if err != nil {
+			return err
+		}
+
+		tpl, err := fullApi.MinerComputeBlockTemplate(ctx, maddr, epoch, base.Key())
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Epoch:    %d\n", tpl.Epoch)
+		fmt.Printf("Base:     %s (height %d)\n", tpl.Base, base.Height())
+
+		switch {
+		case !tpl.Eligible:
+			fmt.Printf("Outcome:  %s\n", color.YellowString("not eligible to mine"))
+			return nil
+		case !tpl.Won:
+			fmt.Printf("Outcome:  not a winner\n")
+			return nil
+		}
+
+		fmt.Printf("Outcome:  %s (win count %d)\n", color.GreenString("winner"), tpl.WinCount)
+		fmt.Printf("Messages: %d\n", len(tpl.Messages))
+		fmt.Printf("Reward:   %s\n", types.FIL(tpl.BlockReward))
+		fmt.Printf("Tips:     %s\n", types.FIL(tpl.MinerTips))
+		fmt.Printf("Penalty:  %s\n", types.FIL(tpl.MinerPenalties))
+		return nil
+	},
+}
+
+var miningReportCmd = &cli.Command{
+	Name:  "report",
+	Usage: "Replay recent epochs, reporting won blocks which didn't make it into the chain",
+	Description: `For each epoch, computes whether the miner would have won on top of the
+canonical chain, and checks whether its block was included.
+
+Missed wins are attributed using the mining attempts recorded by the miner
+since it was started:
+ - bad sync: the miner didn't mine on the canonical base, or started late
+ - slow PoSt: the winning PoSt was computed after the propagation deadline
+ - error: mining failed
+ - not mining: the miner has no record of the epoch
+ - not included: the block was mined in time, but didn't make it into the chain`,
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "epochs",
+			Usage: "number of epochs to replay",
+			Value: 120,
+		},
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "list all won epochs, not only missed ones",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		color.NoColor = !cctx.Bool("color")
+
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		fullApi, acloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer acloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		maddr, err := getActorAddress(ctx, cctx)
+		if err != nil {
+			return err
+		}
+
+		attempts, err := nodeApi.MiningAttempts(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting mining attempts: %w", err)
+		}
+		byEpoch := map[abi.ChainEpoch]api.MiningAttempt{}
+		for _, at := range attempts {
+			byEpoch[at.Epoch] = at
+		}
+
+		head, err := fullApi.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Epoch"),
+			tablewriter.Col("WinCount"),
+			tablewriter.Col("Reward"),
+			tablewriter.Col("Outcome"),
+		)
+
+		var won, included int
+		missed := map[string]int{}
+		missedReward := big.Zero()
+
+		for epoch := head.Height() - abi.ChainEpoch(cctx.Int("epochs")) + 1; epoch <= head.Height(); epoch++ {
+			base, err := miningBase(ctx, fullApi, head, epoch)
+			if err != nil {
+				return err
+			}
+
+			tpl, err := fullApi.MinerComputeBlockTemplate(ctx, maddr, epoch, base.Key())
+			if err != nil {
+				return xerrors.Errorf("computing block template at epoch %d: %w", epoch, err)
+			}
+			if !tpl.Won {
+				continue
+			}
+			won++
+
+			ts, err := fullApi.ChainGetTipSetByHeight(ctx, epoch, head.Key())
+			if err != nil {
+				return err
+			}
+
+			outcome := "included"
+			if ts.Height() == epoch && hasBlockFrom(ts, maddr) {
+				included++
+			} else {
+				outcome = missCause(byEpoch, epoch, base)
+				missed[outcome]++
+				missedReward = big.Add(missedReward, tpl.BlockReward)
+			}
+
+			if outcome == "included" && !cctx.Bool("all") {
+				continue
+			}
+
+			col := color.RedString
+			if outcome == "included" {
+				col = color.GreenString
+			}
+			tw.Write(map[string]interface{}{
+				"Epoch":    epoch,
+				"WinCount": tpl.WinCount,
+				"Reward":   types.FIL(tpl.BlockReward).Short(),
+				"Outcome":  col(outcome),
+			})
+		}
+
+		if err := tw.Flush(os.Stdout); err != nil {
+			return err
+		}
+
+		fmt.Printf("\nWon %d blocks in %d epochs, %d included\n", won, cctx.Int("epochs"), included)
+		if len(missed) == 0 {
+			return nil
+		}
+
+		var causes []string
+		for cause := range missed {
+			causes = append(causes, cause)
+		}
+		sort.Strings(causes)
+
+		fmt.Printf("Missed %s in block rewards:\n", types.FIL(missedReward).Short())
+		for _, cause := range causes {
+			fmt.Printf("  %s: %d\n", cause, missed[cause])
+		}
+		return nil
+	},
+}
+
+// miningBase returns the canonical tipset a block for the epoch is mined on.
+func miningBase(ctx context.Context, fullApi v0api.FullNode, head *types.TipSet, epoch abi.ChainEpoch) (*types.TipSet, error) {
+	if epoch > head.Height() {
+		return head, nil
+	}
+	base, err := fullApi.ChainGetTipSetByHeight(ctx, epoch-1, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting tipset at %d: %w", epoch-1, err)
+	}
+	return base, nil
+}
+
+func hasBlockFrom(ts *types.TipSet, maddr address.Address) bool {
+	for _, b := range ts.Blocks() {
+		if b.Miner == maddr {
+			return true
+		}
+	}
+	return false
+}
+
+func missCause(attempts map[abi.ChainEpoch]api.MiningAttempt, epoch abi.ChainEpoch, base *types.TipSet) string {
+	at, ok := attempts[epoch]
+	switch {
+	case !ok:
+		return "not mining"
+	case at.Base != base.Key() || at.LateStart:
+		return "bad sync"
+	case at.Error != "":
+		return "error"
+	case at.Won && at.ProofMargin < 0:
+		return "slow PoSt"
+	default:
+		return "not included"
+	}
+}
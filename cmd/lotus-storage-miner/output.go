@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+// outputJSON returns whether the global --output flag asks for JSON output
+func outputJSON(cctx *cli.Context) bool {
+	return cctx.App.Metadata["output"] == "json"
+}
+
+// printJSON prints the output of a command in the JSON output mode
+func printJSON(v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
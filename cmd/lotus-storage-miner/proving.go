@@ -212,62 +212,64 @@ var provingDeadlinesCmd = &cli.Command{
 			return err
 		}
 
-		deadlines, err := api.StateMinerDeadlines(ctx, maddr, types.EmptyTSK)
+		summary, err := api.StateMinerProvingSummary(ctx, maddr, types.EmptyTSK)
 		if err != nil {
 			return xerrors.Errorf("getting deadlines: %w", err)
 		}
+		di := summary.ProvingDeadline
 
-		di, err := api.StateMinerProvingDeadline(ctx, maddr, types.EmptyTSK)
-		if err != nil {
-			return xerrors.Errorf("getting deadlines: %w", err)
+		jsonOut := outputJSON(cctx)
+		if !jsonOut {
+			fmt.Printf("Miner: %s\n", color.BlueString("%s", maddr))
+		}
+		out := provingDeadlinesOutput{
+			Miner:     maddr,
+			Current:   di.Index,
+			Deadlines: make([]provingDeadline, 0, len(summary.Deadlines)),
 		}
-
-		fmt.Printf("Miner: %s\n", color.BlueString("%s", maddr))
 
 		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
 		_, _ = fmt.Fprintln(tw, "deadline\tpartitions\tsectors (faults)\tproven partitions")
 
-		for dlIdx, deadline := range deadlines {
-			partitions, err := api.StateMinerPartitions(ctx, maddr, uint64(dlIdx), types.EmptyTSK)
-			if err != nil {
-				return xerrors.Errorf("getting partitions for deadline %d: %w", dlIdx, err)
-			}
-
-			provenPartitions, err := deadline.PostSubmissions.Count()
-			if err != nil {
-				return err
-			}
-
-			sectors := uint64(0)
-			faults := uint64(0)
-
-			for _, partition := range partitions {
-				sc, err := partition.AllSectors.Count()
-				if err != nil {
-					return err
-				}
-
-				sectors += sc
-
-				fc, err := partition.FaultySectors.Count()
-				if err != nil {
-					return err
-				}
-
-				faults += fc
-			}
+		for _, dl := range summary.Deadlines {
+			out.Deadlines = append(out.Deadlines, provingDeadline{
+				Index:            dl.Index,
+				Partitions:       int(dl.Partitions),
+				Sectors:          dl.LiveSectors,
+				Faults:           dl.FaultySectors,
+				ProvenPartitions: dl.PostedPartitions,
+			})
 
 			var cur string
-			if di.Index == uint64(dlIdx) {
+			if di.Index == dl.Index {
 				cur += "\t(current)"
 			}
-			_, _ = fmt.Fprintf(tw, "%d\t%d\t%d (%d)\t%d%s\n", dlIdx, len(partitions), sectors, faults, provenPartitions, cur)
+			_, _ = fmt.Fprintf(tw, "%d\t%d\t%d (%d)\t%d%s\n", dl.Index, dl.Partitions, dl.LiveSectors, dl.FaultySectors, dl.PostedPartitions, cur)
 		}
 
+		if jsonOut {
+			return printJSON(out)
+		}
 		return tw.Flush()
 	},
 }
 
+// provingDeadlinesOutput is the JSON output of proving deadlines
+type provingDeadlinesOutput struct {
+	Miner address.Address
+	// Current is the index of the current deadline
+	Current   uint64
+	Deadlines []provingDeadline
+}
+
+type provingDeadline struct {
+	Index            uint64
+	Partitions       int
+	Sectors          uint64
+	Faults           uint64
+	ProvenPartitions uint64
+}
+
 var provingDeadlineInfoCmd = &cli.Command{
 	Name:      "deadline",
 	Usage:     "View the current proving period deadline information by its index ",
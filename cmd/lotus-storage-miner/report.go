@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
+)
+
+var reportCmd = &cli.Command{
+	Name:  "report",
+	Usage: "Generate a report of the miner performance over a range of epochs",
+	Description: `Reports blocks won against the expected number of wins, window PoSt
+punctuality, faults and penalties, gas spent per method and onboarded sectors.
+
+Chain data covers the whole range. Window PoSt scheduler runs and fault
+declarations come from the journal of the miner, and only cover the time it
+was running.`,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "from",
+			Usage: "first epoch of the range; defaults to a day before its end",
+		},
+		&cli.Int64Flag{
+			Name:  "to",
+			Usage: "last epoch of the range; defaults to the chain head",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "output format: table, json or csv",
+			Value: "table",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		fullApi, acloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer acloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		format := cctx.String("output")
+		if !cctx.IsSet("output") && outputJSON(cctx) {
+			format = "json"
+		}
+		switch format {
+		case "table", "json", "csv":
+		default:
+			return xerrors.Errorf("unknown output format %q", format)
+		}
+
+		maddr, err := getActorAddress(ctx, cctx)
+		if err != nil {
+			return err
+		}
+
+		from, to, err := epochRange(cctx, fullApi, builtin.EpochsInDay)
+		if err != nil {
+			return err
+		}
+
+		rep, err := nodeApi.MinerReport(ctx, maddr, from, to)
+		if err != nil {
+			return err
+		}
+
+		switch format {
+		case "json":
+			out, err := json.MarshalIndent(rep, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			if err := w.Write([]string{"section", "metric", "value"}); err != nil {
+				return err
+			}
+			if err := w.WriteAll(reportRows(rep)); err != nil {
+				return err
+			}
+			return nil
+		}
+
+		fmt.Printf("Miner %s, epochs %d to %d\n", rep.Miner, rep.From, rep.To)
+
+		fmt.Println("\nBlocks:")
+		fmt.Printf("  Won: %d (win count %d)\n", rep.Blocks.Won, rep.Blocks.WinCount)
+		fmt.Printf("  Expected: %.2f\n", rep.Blocks.Expected)
+
+		fmt.Println("\nWindow PoSt:")
+		fmt.Printf("  Submitted: %d (%d failed)\n", rep.PoSt.Submitted, rep.PoSt.Failed)
+		fmt.Printf("  Delay: %.1f epochs on average, %d at most\n", rep.PoSt.AvgDelay, rep.PoSt.MaxDelay)
+		if len(rep.PoSt.Scheduler) > 0 {
+			var states []string
+			for st := range rep.PoSt.Scheduler {
+				states = append(states, st)
+			}
+			sort.Strings(states)
+			fmt.Print("  Scheduler:")
+			for _, st := range states {
+				fmt.Printf(" %s: %d", st, rep.PoSt.Scheduler[st])
+			}
+			fmt.Println()
+		}
+
+		fmt.Println("\nFaults:")
+		fmt.Printf("  Faulty sectors: %d -> %d\n", rep.Faults.FaultyStart, rep.Faults.FaultyEnd)
+		fmt.Printf("  Declared: %d faulty, %d recovered\n", rep.Faults.DeclaredFaulty, rep.Faults.DeclaredRecovered)
+		fmt.Printf("  Penalties: %s\n", types.FIL(rep.Faults.Penalties))
+		fmt.Printf("  Fee debt: %s\n", types.FIL(rep.Faults.FeeDebt))
+
+		fmt.Println("\nOnboarded:")
+		fmt.Printf("  Sectors: %d (%d with deals)\n", rep.Onboarded.Sectors, rep.Onboarded.WithDeals)
+		fmt.Printf("  Raw power: %s\n", types.SizeStr(rep.Onboarded.RawPower))
+
+		fmt.Println("\nGas:")
+		tw := tablewriter.New(
+			tablewriter.Col("Method"),
+			tablewriter.Col("Messages"),
+			tablewriter.Col("GasUsed"),
+			tablewriter.Col("Cost"),
+		)
+		for _, g := range rep.Gas {
+			tw.Write(map[string]interface{}{
+				"Method":   g.Method,
+				"Messages": g.Messages,
+				"GasUsed":  g.GasUsed,
+				"Cost":     types.FIL(g.Cost),
+			})
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
+
+// epochRange reads the range set with the --from and --to flags. It defaults
+// to the given number of epochs up to the chain head.
+func epochRange(cctx *cli.Context, fullApi v0api.FullNode, epochs abi.ChainEpoch) (abi.ChainEpoch, abi.ChainEpoch, error) {
+	to := abi.ChainEpoch(cctx.Int64("to"))
+	if !cctx.IsSet("to") {
+		head, err := fullApi.ChainHead(lcli.ReqContext(cctx))
+		if err != nil {
+			return 0, 0, err
+		}
+		to = head.Height()
+	}
+
+	from := to - epochs + 1
+	if cctx.IsSet("from") {
+		from = abi.ChainEpoch(cctx.Int64("from"))
+	}
+
+	return from, to, nil
+}
+
+// reportRows flattens the report for csv output
+func reportRows(rep *api.MinerReport) [][]string {
+	rows := [][]string{
+		{"range", "miner", rep.Miner.String()},
+		{"range", "from", fmt.Sprint(rep.From)},
+		{"range", "to", fmt.Sprint(rep.To)},
+		{"blocks", "won", fmt.Sprint(rep.Blocks.Won)},
+		{"blocks", "win_count", fmt.Sprint(rep.Blocks.WinCount)},
+		{"blocks", "expected", fmt.Sprintf("%.4f", rep.Blocks.Expected)},
+		{"post", "submitted", fmt.Sprint(rep.PoSt.Submitted)},
+		{"post", "failed", fmt.Sprint(rep.PoSt.Failed)},
+		{"post", "avg_delay", fmt.Sprintf("%.2f", rep.PoSt.AvgDelay)},
+		{"post", "max_delay", fmt.Sprint(rep.PoSt.MaxDelay)},
+	}
+
+	var states []string
+	for st := range rep.PoSt.Scheduler {
+		states = append(states, st)
+	}
+	sort.Strings(states)
+	for _, st := range states {
+		rows = append(rows, []string{"post", "scheduler_" + st, fmt.Sprint(rep.PoSt.Scheduler[st])})
+	}
+
+	rows = append(rows,
+		[]string{"faults", "faulty_start", fmt.Sprint(rep.Faults.FaultyStart)},
+		[]string{"faults", "faulty_end", fmt.Sprint(rep.Faults.FaultyEnd)},
+		[]string{"faults", "declared_faulty", fmt.Sprint(rep.Faults.DeclaredFaulty)},
+		[]string{"faults", "declared_recovered", fmt.Sprint(rep.Faults.DeclaredRecovered)},
+		[]string{"faults", "penalties", rep.Faults.Penalties.String()},
+		[]string{"faults", "fee_debt", rep.Faults.FeeDebt.String()},
+		[]string{"onboarded", "sectors", fmt.Sprint(rep.Onboarded.Sectors)},
+		[]string{"onboarded", "with_deals", fmt.Sprint(rep.Onboarded.WithDeals)},
+		[]string{"onboarded", "raw_power", rep.Onboarded.RawPower.String()},
+	)
+
+	for _, g := range rep.Gas {
+		rows = append(rows,
+			[]string{"gas", g.Method + "_messages", fmt.Sprint(g.Messages)},
+			[]string{"gas", g.Method + "_gas_used", fmt.Sprint(g.GasUsed)},
+			[]string{"gas", g.Method + "_cost", g.Cost.String()},
+		)
+	}
+
+	return rows
+}
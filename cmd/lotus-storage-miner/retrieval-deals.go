@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"text/tabwriter"
+	"time"
 
 	"github.com/docker/go-units"
 	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
@@ -22,6 +23,8 @@ var retrievalDealsCmd = &cli.Command{
 		retrievalDealsListCmd,
 		retrievalSetAskCmd,
 		retrievalGetAskCmd,
+		retrievalPolicyCmd,
+		retrievalUnsealQueueCmd,
 	},
 }
 
@@ -268,3 +271,40 @@ var retrievalGetAskCmd = &cli.Command{
 
 	},
 }
+
+var retrievalUnsealQueueCmd = &cli.Command{
+	Name:  "unseal-queue",
+	Usage: "List running and queued unseals, with their estimated completion time",
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.DaemonContext(cctx)
+
+		api, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		jobs, err := api.SectorsUnsealQueue(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Sector\tStorage\tState\tQueued\tETA\n")
+		for _, j := range jobs {
+			state := "running"
+			if j.Position > 0 {
+				state = fmt.Sprintf("queued (%d)", j.Position)
+			}
+
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+				j.Sector.Number,
+				j.Storage,
+				state,
+				time.Since(j.Queued).Truncate(time.Second),
+				time.Until(j.ETA).Truncate(time.Second),
+			)
+		}
+		return w.Flush()
+	},
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+var retrievalPolicyCmd = &cli.Command{
+	Name:  "policy",
+	Usage: "Configure retrieval pricing depending on the client and the retrieved piece",
+	Description: `When a pricing policy is enabled, the retrieval ask, which is quoted to all
+   clients, is set to the lowest price of the policy, and retrieval deal proposals
+   priced below the price for their client and piece are rejected.`,
+	Subcommands: []*cli.Command{
+		retrievalPolicyListCmd,
+		retrievalPolicySetCmd,
+		retrievalPolicyAddRuleCmd,
+		retrievalPolicyResetCmd,
+	},
+}
+
+var retrievalPolicyListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List the retrieval pricing policy",
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.DaemonContext(cctx)
+
+		api, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		policy, err := getRetrievalPolicy(ctx, api)
+		if err != nil {
+			return err
+		}
+
+		enabled := policy.FreeForDealClients || len(policy.Rules) > 0
+		fmt.Printf("enabled: %t\n", enabled)
+		fmt.Printf("free for clients with active storage deals: %t\n", policy.FreeForDealClients)
+		fmt.Printf("default price: %s, unseal price %s\n", pricePerGiB(policy.Default.PricePerByte), types.FIL(policy.Default.UnsealPrice))
+
+		if len(policy.Rules) == 0 {
+			return nil
+		}
+
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "#\tClient\tPiece\tPrice\tUnseal Price\n")
+		for i, r := range policy.Rules {
+			client := "*"
+			if r.Client != "" {
+				client = r.Client.String()
+			}
+			piece := "*"
+			if r.PieceCID != nil {
+				piece = r.PieceCID.String()
+			}
+
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i, client, piece, pricePerGiB(r.Price.PricePerByte), types.FIL(r.Price.UnsealPrice))
+		}
+		return w.Flush()
+	},
+}
+
+var retrievalPolicySetCmd = &cli.Command{
+	Name:  "set",
+	Usage: "Configure the retrieval pricing policy",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "free-for-deal-clients",
+			Usage: "make retrievals of pieces for which the client has an active storage deal free",
+		},
+		&cli.StringFlag{
+			Name:  "price",
+			Usage: "set the price of retrievals matching no rule (FIL/GiB)",
+		},
+		&cli.StringFlag{
+			Name:  "unseal-price",
+			Usage: "set the unseal price of retrievals matching no rule",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.DaemonContext(cctx)
+
+		api, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		policy, err := getRetrievalPolicy(ctx, api)
+		if err != nil {
+			return err
+		}
+
+		if cctx.IsSet("free-for-deal-clients") {
+			policy.FreeForDealClients = cctx.Bool("free-for-deal-clients")
+		}
+		if err := parsePrices(cctx, &policy.Default); err != nil {
+			return err
+		}
+
+		return api.MarketSetRetrievalPricingPolicy(ctx, policy)
+	},
+}
+
+var retrievalPolicyAddRuleCmd = &cli.Command{
+	Name:  "add-rule",
+	Usage: "Add a rule pricing retrievals by a client and/or of a piece",
+	Description: `Rules are matched in the order they were added in, the first rule matching
+   the client and piece of a retrieval sets its price.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "client",
+			Usage: "peer ID of the client the rule applies to, all clients by default",
+		},
+		&cli.StringFlag{
+			Name:  "piece",
+			Usage: "CID of the piece the rule applies to, all pieces by default",
+		},
+		&cli.StringFlag{
+			Name:     "price",
+			Usage:    "price of the retrievals matching the rule (FIL/GiB)",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "unseal-price",
+			Usage: "unseal price of the retrievals matching the rule",
+			Value: "0",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.DaemonContext(cctx)
+
+		api, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		var rule dtypes.RetrievalPricingRule
+		if cctx.IsSet("client") {
+			rule.Client, err = peer.Decode(cctx.String("client"))
+			if err != nil {
+				return xerrors.Errorf("parsing client peer ID: %w", err)
+			}
+		}
+		if cctx.IsSet("piece") {
+			piece, err := cid.Parse(cctx.String("piece"))
+			if err != nil {
+				return xerrors.Errorf("parsing piece CID: %w", err)
+			}
+			rule.PieceCID = &piece
+		}
+		if err := parsePrices(cctx, &rule.Price); err != nil {
+			return err
+		}
+
+		policy, err := getRetrievalPolicy(ctx, api)
+		if err != nil {
+			return err
+		}
+		policy.Rules = append(policy.Rules, rule)
+
+		return api.MarketSetRetrievalPricingPolicy(ctx, policy)
+	},
+}
+
+var retrievalPolicyResetCmd = &cli.Command{
+	Name:  "reset",
+	Usage: "Remove all rules and disable the retrieval pricing policy",
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.DaemonContext(cctx)
+
+		api, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		policy, err := getRetrievalPolicy(ctx, api)
+		if err != nil {
+			return err
+		}
+
+		return api.MarketSetRetrievalPricingPolicy(ctx, dtypes.RetrievalPricingPolicy{Default: policy.Default})
+	},
+}
+
+// getRetrievalPolicy returns the retrieval pricing policy of the miner, with
+// the default price taken from the retrieval ask if it was never set
+func getRetrievalPolicy(ctx context.Context, mapi api.StorageMiner) (dtypes.RetrievalPricingPolicy, error) {
+	policy, err := mapi.MarketGetRetrievalPricingPolicy(ctx)
+	if err != nil {
+		return dtypes.RetrievalPricingPolicy{}, err
+	}
+
+	if policy.Default.PricePerByte.Int == nil {
+		ask, err := mapi.MarketGetRetrievalAsk(ctx)
+		if err != nil {
+			return dtypes.RetrievalPricingPolicy{}, err
+		}
+		policy.Default = dtypes.RetrievalPrice{PricePerByte: big.Zero(), UnsealPrice: big.Zero()}
+		if ask != nil {
+			policy.Default = dtypes.RetrievalPrice{PricePerByte: ask.PricePerByte, UnsealPrice: ask.UnsealPrice}
+		}
+	}
+
+	return policy, nil
+}
+
+func parsePrices(cctx *cli.Context, price *dtypes.RetrievalPrice) error {
+	if cctx.IsSet("price") || price.PricePerByte.Int == nil {
+		v, err := types.ParseFIL(cctx.String("price"))
+		if err != nil {
+			return xerrors.Errorf("parsing price: %w", err)
+		}
+		price.PricePerByte = types.BigDiv(types.BigInt(v), types.NewInt(1<<30))
+	}
+
+	if cctx.IsSet("unseal-price") || price.UnsealPrice.Int == nil {
+		v, err := types.ParseFIL(cctx.String("unseal-price"))
+		if err != nil {
+			return xerrors.Errorf("parsing unseal price: %w", err)
+		}
+		price.UnsealPrice = abi.TokenAmount(v)
+	}
+
+	return nil
+}
+
+func pricePerGiB(pricePerByte abi.TokenAmount) string {
+	return types.FIL(types.BigMul(pricePerByte, types.NewInt(1<<30))).String() + "/GiB"
+}
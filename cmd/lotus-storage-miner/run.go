@@ -4,12 +4,15 @@ import (
 	"fmt"
 	_ "net/http/pprof"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/filecoin-project/lotus/api/v1api"
 
 	"github.com/filecoin-project/lotus/api/v0api"
 
 	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
 	"github.com/urfave/cli/v2"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
@@ -19,9 +22,11 @@ import (
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
 	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/rpctls"
 	"github.com/filecoin-project/lotus/lib/ulimit"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/node"
+	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/repo"
 )
@@ -118,6 +123,25 @@ var runCmd = &cli.Command{
 			return xerrors.Errorf("repo at '%s' is not initialized, run 'lotus-miner init' to set it up", minerRepoPath)
 		}
 
+		tlsCfg, err := minerTLSConfig(r)
+		if err != nil {
+			return err
+		}
+
+		var certs *rpctls.Certs
+		if tlsCfg.CertFile != "" {
+			certs, err = rpctls.Load(rpctls.Files{
+				CertFile: tlsCfg.CertFile,
+				KeyFile:  tlsCfg.KeyFile,
+				CAFile:   tlsCfg.CAFile,
+			})
+			if err != nil {
+				return xerrors.Errorf("loading API TLS certificates: %w", err)
+			}
+		} else if tlsCfg.ListenAddress != "" {
+			return xerrors.Errorf("API.TLS.ListenAddress is set without the TLS certificate files")
+		}
+
 		shutdownChan := make(chan struct{})
 
 		var minerapi api.StorageMiner
@@ -132,6 +156,10 @@ var runCmd = &cli.Command{
 					return multiaddr.NewMultiaddr("/ip4/127.0.0.1/tcp/" + cctx.String("miner-api"))
 				})),
 			node.Override(new(v1api.FullNode), nodeApi),
+
+			// connections to the sealer, and to workers with a TLS API
+			node.ApplyIf(func(s *node.Settings) bool { return certs != nil },
+				node.Override(new(*rpctls.Certs), certs)),
 		)
 		if err != nil {
 			return xerrors.Errorf("creating node: %w", err)
@@ -142,6 +170,11 @@ var runCmd = &cli.Command{
 			return xerrors.Errorf("getting API endpoint: %w", err)
 		}
 
+		// other hosts must go through the TLS API
+		if tlsCfg.ListenAddress != "" && !manet.IsIPLoopback(endpoint) {
+			return xerrors.Errorf("API.ListenAddress %s must be a loopback address when API.TLS.ListenAddress is set", endpoint)
+		}
+
 		// Bootstrap with full node
 		remoteAddrs, err := nodeApi.NetAddrsListen(ctx)
 		if err != nil {
@@ -166,13 +199,58 @@ var runCmd = &cli.Command{
 			return fmt.Errorf("failed to start json-rpc endpoint: %s", err)
 		}
 
+		shutdownHandlers := []node.ShutdownHandler{{Component: "rpc server", StopFunc: rpcStopper}}
+		if tlsCfg.ListenAddress != "" {
+			tlsEndpoint, err := multiaddr.NewMultiaddr(tlsCfg.ListenAddress)
+			if err != nil {
+				return xerrors.Errorf("parsing API.TLS.ListenAddress: %w", err)
+			}
+
+			tlsStopper, err := node.ServeTLSRPC(handler, "lotus-miner", tlsEndpoint, certs.ServerConfig())
+			if err != nil {
+				return fmt.Errorf("failed to start TLS json-rpc endpoint: %s", err)
+			}
+			shutdownHandlers = append(shutdownHandlers, node.ShutdownHandler{Component: "tls rpc server", StopFunc: tlsStopper})
+		}
+
+		// Reload the sealing and fee config on SIGHUP.
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				if err := minerapi.ConfigReload(ctx); err != nil {
+					log.Errorf("reloading config: %s", err)
+				}
+			}
+		}()
+
 		// Monitor for shutdown.
 		finishCh := node.MonitorShutdown(shutdownChan,
-			node.ShutdownHandler{Component: "rpc server", StopFunc: rpcStopper},
-			node.ShutdownHandler{Component: "miner", StopFunc: stop},
+			append(shutdownHandlers, node.ShutdownHandler{Component: "miner", StopFunc: stop})...,
 		)
 
 		<-finishCh
 		return nil
 	},
 }
+
+// minerTLSConfig reads the API TLS config before the node is started, so that
+// the certificates are in place for the connections made while starting
+func minerTLSConfig(r *repo.FsRepo) (config.APITLS, error) {
+	lr, err := r.LockRO(repo.StorageMiner)
+	if err != nil {
+		return config.APITLS{}, xerrors.Errorf("locking repo: %w", err)
+	}
+	defer lr.Close() // nolint:errcheck
+
+	c, err := lr.Config()
+	if err != nil {
+		return config.APITLS{}, xerrors.Errorf("reading config: %w", err)
+	}
+
+	cfg, ok := c.(*config.StorageMiner)
+	if !ok {
+		return config.APITLS{}, xerrors.Errorf("invalid config for repo, got: %T", c)
+	}
+	return cfg.API.TLS, nil
+}
@@ -15,6 +15,9 @@ import (
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
 	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
 
 	"github.com/filecoin-project/lotus/chain/types"
@@ -29,6 +32,7 @@ var sealingCmd = &cli.Command{
 		sealingWorkersCmd,
 		sealingSchedDiagCmd,
 		sealingAbortCmd,
+		sealingSimulateCmd,
 	},
 }
 
@@ -114,7 +118,15 @@ var sealingWorkersCmd = &cli.Command{
 				types.SizeStr(types.NewInt(stat.Info.Resources.MemReserved+stat.MemUsedMax)),
 				types.SizeStr(types.NewInt(vmem)))
 
-			for _, gpu := range stat.Info.Resources.GPUs {
+			for i, gpu := range stat.Info.Resources.GPUs {
+				if len(stat.Info.Resources.GPUAssignment) > 0 {
+					gpuUse, gpuCol = "not used", color.FgBlue
+					if i < len(stat.GPUTasks) && stat.GPUTasks[i] != "" {
+						gpuUse, gpuCol = "used by "+stat.GPUTasks[i].Short(), color.FgGreen
+					}
+					fmt.Printf("\tGPU: %s\n", color.New(gpuCol).Sprintf("%s, %s", gpu, gpuUse))
+					continue
+				}
 				fmt.Printf("\tGPU: %s\n", color.New(gpuCol).Sprintf("%s, %sused", gpu, gpuUse))
 			}
 		}
@@ -187,6 +199,9 @@ var sealingJobsCmd = &cli.Command{
 			workerHostnames[wid] = st.Info.Hostname
 		}
 
+		jsonOut := outputJSON(cctx)
+		out := make([]sealingJobItem, 0, len(lines))
+
 		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
 		_, _ = fmt.Fprintf(tw, "ID\tSector\tWorker\tHostname\tTask\tState\tTime\n")
 
@@ -215,6 +230,19 @@ var sealingJobsCmd = &cli.Command{
 				hostname = l.Hostname
 			}
 
+			if jsonOut {
+				out = append(out, sealingJobItem{
+					ID:       l.ID,
+					Sector:   l.Sector,
+					Worker:   l.wid,
+					Hostname: hostname,
+					Task:     l.Task,
+					State:    state,
+					Start:    l.Start,
+				})
+				continue
+			}
+
 			_, _ = fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
 				hex.EncodeToString(l.ID.ID[:4]),
 				l.Sector.Number,
@@ -225,10 +253,25 @@ var sealingJobsCmd = &cli.Command{
 				dur)
 		}
 
+		if jsonOut {
+			return printJSON(out)
+		}
 		return tw.Flush()
 	},
 }
 
+// sealingJobItem is the JSON output of sealing jobs for a job
+type sealingJobItem struct {
+	ID       storiface.CallID
+	Sector   abi.SectorID
+	Worker   uuid.UUID
+	Hostname string
+	Task     sealtasks.TaskType
+	State    string
+	// Start is zero for jobs which aren't running yet
+	Start time.Time
+}
+
 var sealingSchedDiagCmd = &cli.Command{
 	Name:  "sched-diag",
 	Usage: "Dump internal scheduler state",
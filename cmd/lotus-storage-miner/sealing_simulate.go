@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	sectorstorage "github.com/filecoin-project/lotus/extern/sector-storage"
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+var sealingSimulateCmd = &cli.Command{
+	Name:  "simulate",
+	Usage: "Predict the throughput of a planned set of workers by running the scheduler with synthetic workers",
+	Description: `Workers are described by comma separated key=value pairs:
+
+   name=<name>          name of the worker in the output
+   count=<n>            number of identical workers (default 1)
+   tasks=<AP+PC1+...>   tasks the worker runs
+   cpus=<n>             logical cores (default 32)
+   mem=<size>           physical memory (default 128GiB)
+   swap=<size>          swap (default 0)
+   gpus=<n>             number of GPUs (default 0)
+   <TASK>=<duration>    duration of a task on this worker, e.g. PC1=4h
+
+   Example: --workers name=pc,count=4,tasks=AP+PC1+PC2,cpus=64,mem=512GiB,gpus=1
+            --workers name=c2,tasks=C1+C2+FIN,gpus=1
+
+   No real sectors are sealed, the tasks sleep for their durations in
+   simulated time. Task durations default to rough 32GiB figures, which
+   --duration overrides for all workers.`,
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "sectors",
+			Usage: "number of sectors to seal",
+			Value: 100,
+		},
+		&cli.StringFlag{
+			Name:  "sector-size",
+			Usage: "size of the sectors",
+			Value: "32GiB",
+		},
+		&cli.StringSliceFlag{
+			Name:  "workers",
+			Usage: "worker spec, can be repeated",
+		},
+		&cli.StringSliceFlag{
+			Name:  "duration",
+			Usage: "duration of a task on all workers, e.g. PC1=4h, can be repeated",
+		},
+		&cli.Float64Flag{
+			Name:  "speedup",
+			Usage: "how many times faster than real time the simulation runs",
+			Value: 3600,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.ReqContext(cctx)
+
+		sectorSizeInt, err := units.RAMInBytes(cctx.String("sector-size"))
+		if err != nil {
+			return err
+		}
+		spt, err := miner.SealProofTypeFromSectorSize(abi.SectorSize(sectorSizeInt), build.NewestNetworkVersion)
+		if err != nil {
+			return err
+		}
+
+		durations := map[sealtasks.TaskType]time.Duration{}
+		for _, d := range cctx.StringSlice("duration") {
+			kv := strings.SplitN(d, "=", 2)
+			if len(kv) != 2 {
+				return xerrors.Errorf("duration %q: expected <TASK>=<duration>", d)
+			}
+			if err := parseSimDuration(durations, kv[0], kv[1]); err != nil {
+				return xerrors.Errorf("duration %q: %w", d, err)
+			}
+		}
+
+		var workers []sectorstorage.SimWorker
+		for _, spec := range cctx.StringSlice("workers") {
+			ws, err := parseSimWorkers(spec)
+			if err != nil {
+				return xerrors.Errorf("worker %q: %w", spec, err)
+			}
+			workers = append(workers, ws...)
+		}
+		if len(workers) == 0 {
+			return xerrors.Errorf("no workers, see --workers")
+		}
+
+		speedup := cctx.Float64("speedup")
+		fmt.Printf("Simulating %d sectors on %d workers, %.0fx faster than real time\n", cctx.Int("sectors"), len(workers), speedup)
+
+		res, err := sectorstorage.Simulate(ctx, sectorstorage.SimConfig{
+			Sectors:   cctx.Int("sectors"),
+			SealProof: spt,
+			Workers:   workers,
+			Durations: durations,
+			Speedup:   speedup,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Elapsed:     %s\n", res.Elapsed.Truncate(time.Minute))
+		fmt.Printf("Throughput:  %.2f sectors/day\n", res.SectorsPerDay)
+		fmt.Printf("Bottleneck:  %s\n\n", res.Bottleneck.Short())
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "Task\tCount\tAvg Wait\tMax Wait\tAvg Run\n")
+		for _, tt := range sectorstorage.SimPipeline {
+			st := res.Tasks[tt]
+			if st.Count == 0 {
+				continue
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", tt.Short(), st.Count,
+				(st.Wait / time.Duration(st.Count)).Truncate(time.Second),
+				st.MaxWait.Truncate(time.Second),
+				(st.Run / time.Duration(st.Count)).Truncate(time.Second))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Println()
+
+		tw = tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "Worker\tBusy\tLoad\tTasks\n")
+		for _, w := range res.Workers {
+			var tasks []string
+			for _, tt := range sectorstorage.SimPipeline {
+				if n := w.Tasks[tt]; n > 0 {
+					tasks = append(tasks, fmt.Sprintf("%s:%d", tt.Short(), n))
+				}
+			}
+			// average number of tasks running at the same time
+			load := float64(w.Busy) / float64(res.Elapsed)
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%.2f\t%s\n", w.Name, w.Busy.Truncate(time.Minute), load, strings.Join(tasks, " "))
+		}
+		return tw.Flush()
+	},
+}
+
+func parseSimDuration(durations map[sealtasks.TaskType]time.Duration, task, value string) error {
+	tt, ok := sealtasks.ParseTaskType(task)
+	if !ok {
+		return xerrors.Errorf("unknown task %q", task)
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return xerrors.Errorf("parsing duration: %w", err)
+	}
+	durations[tt] = d
+	return nil
+}
+
+func parseSimWorkers(spec string) ([]sectorstorage.SimWorker, error) {
+	w := sectorstorage.SimWorker{
+		Name: "worker",
+		Resources: storiface.WorkerResources{
+			MemPhysical: 128 << 30,
+			CPUs:        32,
+		},
+		Durations: map[sealtasks.TaskType]time.Duration{},
+	}
+	count := 1
+
+	for _, kv := range strings.Split(spec, ",") {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			return nil, xerrors.Errorf("expected key=value, got %q", kv)
+		}
+		k, v := parts[0], parts[1]
+
+		var err error
+		switch k {
+		case "name":
+			w.Name = v
+		case "count":
+			count, err = strconv.Atoi(v)
+		case "tasks":
+			for _, t := range strings.Split(v, "+") {
+				tt, ok := sealtasks.ParseTaskType(t)
+				if !ok {
+					return nil, xerrors.Errorf("unknown task %q", t)
+				}
+				w.TaskTypes = append(w.TaskTypes, tt)
+			}
+		case "cpus":
+			w.Resources.CPUs, err = strconv.ParseUint(v, 10, 64)
+		case "mem", "swap":
+			var size int64
+			size, err = units.RAMInBytes(v)
+			if k == "mem" {
+				w.Resources.MemPhysical = uint64(size)
+			} else {
+				w.Resources.MemSwap = uint64(size)
+			}
+		case "gpus":
+			var n int
+			n, err = strconv.Atoi(v)
+			w.Resources.GPUs = nil
+			for i := 0; i < n; i++ {
+				w.Resources.GPUs = append(w.Resources.GPUs, fmt.Sprintf("simulated GPU %d", i))
+			}
+		default:
+			err = parseSimDuration(w.Durations, k, v)
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("%s: %w", k, err)
+		}
+	}
+
+	if len(w.TaskTypes) == 0 {
+		return nil, xerrors.Errorf("no tasks")
+	}
+
+	out := make([]sectorstorage.SimWorker, count)
+	for i := range out {
+		out[i] = w
+		if count > 1 {
+			out[i].Name = fmt.Sprintf("%s-%d", w.Name, i)
+		}
+	}
+	return out, nil
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-bitfield"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
@@ -42,16 +43,32 @@ var sectorsCmd = &cli.Command{
 		sectorsTerminateCmd,
 		sectorsRemoveCmd,
 		sectorsMarkForUpgradeCmd,
+		sectorsRegenerateProofCmd,
+		sectorsReceiptsCmd,
+		sectorsLabelCmd,
 		sectorsStartSealCmd,
 		sectorsSealDelayCmd,
 		sectorsCapacityCollateralCmd,
 		sectorsBatching,
+		sectorsNumbersCmd,
+		sectorsWatchCmd,
+		sectorsFSMGraphCmd,
 	},
 }
 
 var sectorsPledgeCmd = &cli.Command{
 	Name:  "pledge",
 	Usage: "store random data in a sector",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "label",
+			Usage: "label the new sector, in the key=value format (can be repeated)",
+		},
+		&cli.StringFlag{
+			Name:  "actor",
+			Usage: "pledge a sector for one of the additional miner actors served by this node",
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
 		if err != nil {
@@ -60,13 +77,41 @@ var sectorsPledgeCmd = &cli.Command{
 		defer closer()
 		ctx := lcli.ReqContext(cctx)
 
-		id, err := nodeApi.PledgeSector(ctx)
+		labels, err := parseSectorLabels(cctx.StringSlice("label"))
 		if err != nil {
 			return err
 		}
 
+		var id abi.SectorID
+		if cctx.IsSet("actor") {
+			if len(labels) > 0 {
+				return xerrors.Errorf("labels can only be set on sectors of the primary miner")
+			}
+
+			maddr, err := address.NewFromString(cctx.String("actor"))
+			if err != nil {
+				return xerrors.Errorf("parsing miner address: %w", err)
+			}
+
+			id, err = nodeApi.PledgeSectorFor(ctx, maddr)
+			if err != nil {
+				return err
+			}
+		} else {
+			id, err = nodeApi.PledgeSector(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
 		fmt.Println("Created CC sector: ", id.Number)
 
+		if len(labels) > 0 {
+			if err := nodeApi.SectorSetLabels(ctx, id.Number, labels); err != nil {
+				return xerrors.Errorf("setting sector labels: %w", err)
+			}
+		}
+
 		return nil
 	},
 }
@@ -78,7 +123,7 @@ var sectorsStatusCmd = &cli.Command{
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
 			Name:  "log",
-			Usage: "display event log",
+			Usage: "display the sector history",
 		},
 		&cli.BoolFlag{
 			Name:  "on-chain-info",
@@ -124,6 +169,9 @@ var sectorsStatusCmd = &cli.Command{
 		if status.LastErr != "" {
 			fmt.Printf("Last Error:\t\t%s\n", status.LastErr)
 		}
+		if len(status.Labels) > 0 {
+			fmt.Printf("Labels:\t\t%s\n", formatSectorLabels(status.Labels))
+		}
 
 		if onChainInfo {
 			fmt.Printf("\nSector On Chain Info\n")
@@ -139,13 +187,38 @@ var sectorsStatusCmd = &cli.Command{
 		}
 
 		if cctx.Bool("log") {
-			fmt.Printf("--------\nEvent Log:\n")
+			history, err := nodeApi.SectorLog(ctx, abi.SectorNumber(id))
+			if err != nil {
+				return xerrors.Errorf("getting sector history: %w", err)
+			}
+
+			// sectors which didn't change since the history was introduced
+			// only have the event log
+			if len(history) == 0 {
+				fmt.Printf("--------\nEvent Log:\n")
+
+				for i, l := range status.Log {
+					fmt.Printf("%d.\t%s:\t[%s]\t%s\n", i, time.Unix(int64(l.Timestamp), 0), l.Kind, l.Message)
+					if l.Trace != "" {
+						fmt.Printf("\t%s\n", l.Trace)
+					}
+				}
+				return nil
+			}
 
-			for i, l := range status.Log {
-				fmt.Printf("%d.\t%s:\t[%s]\t%s\n", i, time.Unix(int64(l.Timestamp), 0), l.Kind, l.Message)
-				if l.Trace != "" {
-					fmt.Printf("\t%s\n", l.Trace)
+			fmt.Printf("--------\nHistory:\n")
+
+			for i, e := range history {
+				var desc string
+				switch e.Kind {
+				case "state":
+					desc = fmt.Sprintf("%s -> %s", e.From, e.To)
+				case "message":
+					desc = fmt.Sprintf("%s message %s", e.MessageType, e.Message)
+				case "error":
+					desc = e.Error
 				}
+				fmt.Printf("%d.\t%s:\t[%s]\t%s\t(%s)\n", i, e.Time.Format("2006-01-02 15:04:05"), e.Kind, desc, e.Event)
 			}
 		}
 		return nil
@@ -177,10 +250,18 @@ var sectorsListCmd = &cli.Command{
 			Name:  "seal-time",
 			Usage: "display how long it took for the sector to be sealed",
 		},
+		&cli.BoolFlag{
+			Name:  "eta",
+			Usage: "display the projected time until sealing sectors are proving",
+		},
 		&cli.StringFlag{
 			Name:  "states",
 			Usage: "filter sectors by a comma-separated list of states",
 		},
+		&cli.StringSliceFlag{
+			Name:  "label",
+			Usage: "filter sectors by label, in the key=value format (can be repeated)",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		color.NoColor = !cctx.Bool("color")
@@ -201,6 +282,11 @@ var sectorsListCmd = &cli.Command{
 
 		var list []abi.SectorNumber
 
+		labels, err := parseSectorLabels(cctx.StringSlice("label"))
+		if err != nil {
+			return err
+		}
+
 		showRemoved := cctx.Bool("show-removed")
 		states := cctx.String("states")
 		if len(states) == 0 {
@@ -258,6 +344,7 @@ var sectorsListCmd = &cli.Command{
 			tablewriter.Col("Active"),
 			tablewriter.Col("Expiration"),
 			tablewriter.Col("SealTime"),
+			tablewriter.Col("ETA"),
 			tablewriter.Col("Events"),
 			tablewriter.Col("Deals"),
 			tablewriter.Col("DealWeight"),
@@ -266,10 +353,24 @@ var sectorsListCmd = &cli.Command{
 			tablewriter.NewLineCol("RecoveryTimeout"))
 
 		fast := cctx.Bool("fast")
+		jsonOut := outputJSON(cctx)
+		items := make([]sectorListItem, 0, len(list))
+
+		etas := map[abi.SectorNumber]api.SectorETA{}
+		if cctx.Bool("eta") {
+			el, err := nodeApi.SectorsETA(ctx)
+			if err != nil {
+				return xerrors.Errorf("getting sector ETAs: %w", err)
+			}
+			for _, eta := range el {
+				etas[eta.SectorID] = eta
+			}
+		}
 
 		for _, s := range list {
 			st, err := nodeApi.SectorsStatus(ctx, s, !fast)
 			if err != nil {
+				items = append(items, sectorListItem{ID: s, Error: err.Error()})
 				tw.Write(map[string]interface{}{
 					"ID":    s,
 					"Error": err,
@@ -277,6 +378,10 @@ var sectorsListCmd = &cli.Command{
 				continue
 			}
 
+			if !hasSectorLabels(st.Labels, labels) {
+				continue
+			}
+
 			if showRemoved || st.State != api.SectorState(sealing.Removed) {
 				_, inSSet := commitedIDs[s]
 				_, inASet := activeIDs[s]
@@ -306,6 +411,14 @@ var sectorsListCmd = &cli.Command{
 					"OnChain": yesno(inSSet),
 					"Active":  yesno(inASet),
 				}
+				item := sectorListItem{
+					ID:        s,
+					State:     st.State,
+					OnChain:   inSSet,
+					Active:    inASet,
+					Deals:     deals,
+					ToUpgrade: st.ToUpgrade,
+				}
 
 				if deals > 0 {
 					m["Deals"] = color.GreenString("%d", deals)
@@ -321,16 +434,20 @@ var sectorsListCmd = &cli.Command{
 						m["Expiration"] = "n/a"
 					} else {
 						m["Expiration"] = lcli.EpochTime(head.Height(), exp)
+						item.Expiration = exp
 
 						if !fast && deals > 0 {
 							m["DealWeight"] = units.BytesSize(dw)
+							item.DealWeight = dw
 							if vp > 0 {
 								m["VerifiedPower"] = color.GreenString(units.BytesSize(vp))
+								item.VerifiedPower = vp
 							}
 						}
 
 						if st.Early > 0 {
 							m["RecoveryTimeout"] = color.YellowString(lcli.EpochTime(head.Height(), st.Early))
+							item.RecoveryTimeout = st.Early
 						}
 					}
 				}
@@ -348,6 +465,7 @@ var sectorsListCmd = &cli.Command{
 					}
 
 					pieces := len(st.Deals)
+					item.Events = &events
 
 					switch {
 					case events < 12+pieces:
@@ -366,6 +484,7 @@ var sectorsListCmd = &cli.Command{
 						if sectorLog.Kind == "event;sealing.SectorProving" {
 							end := time.Unix(int64(sectorLog.Timestamp), 0)
 							dur := end.Sub(start)
+							item.SealTime = dur.String()
 
 							switch {
 							case dur < 12*time.Hour:
@@ -381,14 +500,47 @@ var sectorsListCmd = &cli.Command{
 					}
 				}
 
+				if eta, ok := etas[s]; ok {
+					m["ETA"] = formatSectorETA(eta)
+					item.ETA = &eta
+				}
+
+				items = append(items, item)
 				tw.Write(m)
 			}
 		}
 
+		if jsonOut {
+			return printJSON(items)
+		}
 		return tw.Flush(os.Stdout)
 	},
 }
 
+// sectorListItem is a sector in the JSON output of sectors list
+type sectorListItem struct {
+	ID      abi.SectorNumber
+	State   api.SectorState `json:",omitempty"`
+	OnChain bool
+	Active  bool
+
+	// on-chain info, not set with --fast
+	Expiration      abi.ChainEpoch `json:",omitempty"`
+	RecoveryTimeout abi.ChainEpoch `json:",omitempty"`
+	DealWeight      float64        `json:",omitempty"`
+	VerifiedPower   float64        `json:",omitempty"`
+
+	Deals     int
+	ToUpgrade bool
+
+	// set with --events, --seal-time and --eta
+	Events   *int           `json:",omitempty"`
+	SealTime string         `json:",omitempty"`
+	ETA      *api.SectorETA `json:",omitempty"`
+
+	Error string `json:",omitempty"`
+}
+
 var sectorsRefsCmd = &cli.Command{
 	Name:  "refs",
 	Usage: "List References to sectors",
@@ -647,20 +799,27 @@ var sectorsTerminateCmd = &cli.Command{
 			Name:  "really-do-it",
 			Usage: "pass this flag if you know what you are doing",
 		},
+		&cli.StringFlag{
+			Name:  "max-penalty",
+			Usage: "refuse to terminate the sector if the termination penalty is higher than this (FIL)",
+			Value: "1",
+		},
 	},
 	Subcommands: []*cli.Command{
 		sectorsTerminateFlushCmd,
 		sectorsTerminatePendingCmd,
 	},
 	Action: func(cctx *cli.Context) error {
-		if !cctx.Bool("really-do-it") {
-			return xerrors.Errorf("pass --really-do-it to confirm this action")
-		}
 		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
 		if err != nil {
 			return err
 		}
 		defer closer()
+		api, nCloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer nCloser()
 		ctx := lcli.ReqContext(cctx)
 		if cctx.Args().Len() != 1 {
 			return xerrors.Errorf("must pass sector number")
@@ -671,6 +830,29 @@ var sectorsTerminateCmd = &cli.Command{
 			return xerrors.Errorf("could not parse sector number: %w", err)
 		}
 
+		maxPenalty, err := types.ParseFIL(cctx.String("max-penalty"))
+		if err != nil {
+			return xerrors.Errorf("parsing max-penalty: %w", err)
+		}
+
+		maddr, err := nodeApi.ActorAddress(ctx)
+		if err != nil {
+			return err
+		}
+
+		penalty, err := api.StateMinerTerminationPenalty(ctx, maddr, []abi.SectorNumber{abi.SectorNumber(id)}, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("computing termination penalty: %w", err)
+		}
+		fmt.Printf("Termination penalty: %s\n", types.FIL(penalty))
+
+		if penalty.GreaterThan(abi.TokenAmount(maxPenalty)) {
+			return xerrors.Errorf("termination penalty is higher than --max-penalty %s", maxPenalty)
+		}
+		if !cctx.Bool("really-do-it") {
+			return xerrors.Errorf("pass --really-do-it to confirm this action")
+		}
+
 		return nodeApi.SectorTerminate(ctx, abi.SectorNumber(id))
 	},
 }
@@ -785,6 +967,84 @@ var sectorsRemoveCmd = &cli.Command{
 	},
 }
 
+var sectorsLabelCmd = &cli.Command{
+	Name:      "label",
+	Usage:     "Set labels on a sector, use key= to remove a label",
+	ArgsUsage: "<sectorNum> <key=value>...",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() < 2 {
+			return lcli.ShowHelp(cctx, xerrors.Errorf("must pass sector number and labels"))
+		}
+
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		id, err := strconv.ParseUint(cctx.Args().First(), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("could not parse sector number: %w", err)
+		}
+
+		labels, err := parseSectorLabels(cctx.Args().Tail())
+		if err != nil {
+			return err
+		}
+
+		return nodeApi.SectorSetLabels(ctx, abi.SectorNumber(id), labels)
+	},
+}
+
+// formatSectorETA prints the time left until the sector is proving, as a
+// lower bound when some states have no duration history yet
+func formatSectorETA(eta api.SectorETA) string {
+	left := time.Until(eta.ETA).Truncate(time.Minute)
+	if left < 0 {
+		left = 0
+	}
+
+	out := left.String()
+	if !eta.Known {
+		out = ">" + out
+	}
+	if eta.QueuedTask != "" {
+		out += fmt.Sprintf(" (%s queued, %d ahead)", eta.QueuedTask.Short(), eta.QueuedBehind)
+	}
+	return out
+}
+
+func parseSectorLabels(args []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, xerrors.Errorf("malformed label %q, expected key=value", arg)
+		}
+		labels[kv[0]] = kv[1]
+	}
+	return labels, nil
+}
+
+func hasSectorLabels(labels, filter map[string]string) bool {
+	for k, v := range filter {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func formatSectorLabels(labels map[string]string) string {
+	out := make([]string, 0, len(labels))
+	for k, v := range labels {
+		out = append(out, k+"="+v)
+	}
+	sort.Strings(out)
+	return strings.Join(out, ", ")
+}
+
 var sectorsMarkForUpgradeCmd = &cli.Command{
 	Name:      "mark-for-upgrade",
 	Usage:     "Mark a committed capacity sector for replacement by a sector with deals",
@@ -810,6 +1070,93 @@ var sectorsMarkForUpgradeCmd = &cli.Command{
 	},
 }
 
+var sectorsRegenerateProofCmd = &cli.Command{
+	Name:      "regenerate-proof",
+	Usage:     "Recompute the proof of a sector which failed to commit",
+	ArgsUsage: "<sectorNum>",
+	Description: `Recomputes the commit proof of a sector in the CommitFailed or
+ComputeProofFailed state, and commits the sector again. Sectors whose commit
+failed because of an invalid proof are regenerated automatically.`,
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return lcli.ShowHelp(cctx, xerrors.Errorf("must pass sector number"))
+		}
+
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		id, err := strconv.ParseUint(cctx.Args().Get(0), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("could not parse sector number: %w", err)
+		}
+
+		return nodeApi.SectorRegenerateProof(ctx, abi.SectorNumber(id))
+	},
+}
+
+var sectorsReceiptsCmd = &cli.Command{
+	Name:      "receipts",
+	Usage:     "Show the work receipts of a sector sealed by the sealing service",
+	ArgsUsage: "<sectorNum>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return lcli.ShowHelp(cctx, xerrors.Errorf("must pass sector number"))
+		}
+
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		id, err := strconv.ParseUint(cctx.Args().Get(0), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("could not parse sector number: %w", err)
+		}
+
+		receipts, err := nodeApi.SectorReceipts(ctx, abi.SectorNumber(id))
+		if err != nil {
+			return err
+		}
+		if len(receipts) == 0 {
+			fmt.Println("No receipts for the sector")
+			return nil
+		}
+
+		for i, r := range receipts {
+			if i > 0 {
+				fmt.Println()
+			}
+
+			valid := color.GreenString("valid")
+			if err := r.Verify(); err != nil {
+				valid = color.RedString("invalid: %s", err)
+			}
+
+			fmt.Printf("Task:\t\t%s\n", r.Task.Short())
+			fmt.Printf("Producer:\t%s\n", r.Producer)
+			fmt.Printf("Key:\t\t%x\n", r.PublicKey)
+			fmt.Printf("Time:\t\t%s\n", r.Time.Format(time.RFC3339))
+			if r.CommR != nil {
+				fmt.Printf("CommR:\t\t%s\n", r.CommR)
+			}
+			if r.CommD != nil {
+				fmt.Printf("CommD:\t\t%s\n", r.CommD)
+			}
+			if len(r.ProofHash) > 0 {
+				fmt.Printf("ProofHash:\t%x\n", r.ProofHash)
+			}
+			fmt.Printf("Signature:\t%s\n", valid)
+		}
+		return nil
+	},
+}
+
 var sectorsStartSealCmd = &cli.Command{
 	Name:      "seal",
 	Usage:     "Manually start sealing a sector (filling any unused space with junk)",
@@ -1005,12 +1352,18 @@ var sectorsBatchingPendingCommit = &cli.Command{
 				return xerrors.Errorf("no sectors to publish")
 			}
 
+			if outputJSON(cctx) {
+				return printJSON(res)
+			}
+
 			for i, re := range res {
 				fmt.Printf("Batch %d:\n", i)
 				if re.Error != "" {
 					fmt.Printf("\tError: %s\n", re.Error)
-				} else {
+				} else if re.Msg != nil {
 					fmt.Printf("\tMessage: %s\n", re.Msg)
+				} else {
+					fmt.Printf("\tMessage: none, sectors failed pre-flight checks\n")
 				}
 				fmt.Printf("\tSectors:\n")
 				for _, sector := range re.Sectors {
@@ -1029,6 +1382,14 @@ var sectorsBatchingPendingCommit = &cli.Command{
 			return xerrors.Errorf("getting pending deals: %w", err)
 		}
 
+		if outputJSON(cctx) {
+			nums := make([]abi.SectorNumber, 0, len(pending))
+			for _, sector := range pending {
+				nums = append(nums, sector.Number)
+			}
+			return printJSON(nums)
+		}
+
 		if len(pending) > 0 {
 			for _, sector := range pending {
 				fmt.Println(sector.Number)
@@ -1067,6 +1428,10 @@ var sectorsBatchingPendingPreCommit = &cli.Command{
 				return xerrors.Errorf("no sectors to publish")
 			}
 
+			if outputJSON(cctx) {
+				return printJSON(res)
+			}
+
 			for i, re := range res {
 				fmt.Printf("Batch %d:\n", i)
 				if re.Error != "" {
@@ -1087,6 +1452,14 @@ var sectorsBatchingPendingPreCommit = &cli.Command{
 			return xerrors.Errorf("getting pending deals: %w", err)
 		}
 
+		if outputJSON(cctx) {
+			nums := make([]abi.SectorNumber, 0, len(pending))
+			for _, sector := range pending {
+				nums = append(nums, sector.Number)
+			}
+			return printJSON(nums)
+		}
+
 		if len(pending) > 0 {
 			for _, sector := range pending {
 				fmt.Println(sector.Number)
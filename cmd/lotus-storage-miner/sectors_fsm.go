@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+var sectorsFSMGraphCmd = &cli.Command{
+	Name:  "fsm-graph",
+	Usage: "print the sealing state machine graph, with the sectors in each state",
+	Description: `Prints the states and transitions of the sealing state machine, with the
+number of sectors currently in each state and the mean time sectors spent in
+it since the miner started. The DOT output can be rendered with graphviz, e.g.
+
+   lotus-miner sectors fsm-graph | dot -Tsvg > sealing.svg`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "output format: dot or json",
+			Value: "dot",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		format := cctx.String("format")
+		if !cctx.IsSet("format") && outputJSON(cctx) {
+			format = "json"
+		}
+		switch format {
+		case "dot", "json":
+		default:
+			return xerrors.Errorf("unknown output format %q", format)
+		}
+
+		g, err := nodeApi.SealingFSMGraph(ctx)
+		if err != nil {
+			return err
+		}
+
+		if format == "json" {
+			return printJSON(g)
+		}
+		return writeFSMDot(os.Stdout, g)
+	},
+}
+
+const fsmAnyState = "any state"
+
+func fsmNodeName(st api.SectorState) string {
+	if st == "" {
+		return "Undefined"
+	}
+	return string(st)
+}
+
+func writeFSMDot(w io.Writer, g api.SealingFSMGraph) error {
+	var b strings.Builder
+
+	b.WriteString("digraph sealing {\n")
+	b.WriteString("  node [shape=box];\n")
+
+	for _, st := range g.States {
+		label := fmt.Sprintf("%s\\n%d sectors", fsmNodeName(st.State), st.Sectors)
+		if st.Samples > 0 {
+			label += fmt.Sprintf("\\nmean %s", st.MeanDwell.Round(time.Second))
+		}
+
+		attrs := fmt.Sprintf("label=%q", label)
+		if st.Sectors > 0 {
+			attrs += ", style=filled, fillcolor=lightblue"
+		}
+		fmt.Fprintf(&b, "  %q [%s];\n", fsmNodeName(st.State), attrs)
+	}
+
+	var global bool
+	for _, t := range g.Transitions {
+		if t.Global {
+			if !global {
+				fmt.Fprintf(&b, "  %q [shape=plaintext];\n", fsmAnyState)
+				global = true
+			}
+			fmt.Fprintf(&b, "  %q -> %q [label=%q, style=dashed];\n", fsmAnyState, fsmNodeName(t.To), t.Event)
+			continue
+		}
+		fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", fsmNodeName(t.From), fsmNodeName(t.To), t.Event)
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
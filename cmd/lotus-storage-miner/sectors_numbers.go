@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-bitfield"
+	rlepluslazy "github.com/filecoin-project/go-bitfield/rle"
+
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+var sectorsNumbersCmd = &cli.Command{
+	Name:  "numbers",
+	Usage: "manage sector number reservations",
+	Description: `Reserved sector numbers are never assigned to new sectors of this miner, e.g.
+so that another sealing cluster can seal sectors of the same miner actor.`,
+	Subcommands: []*cli.Command{
+		sectorsNumbersListCmd,
+		sectorsNumbersReserveCmd,
+		sectorsNumbersFreeCmd,
+	},
+}
+
+var sectorsNumbersListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list sector number reservations",
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		res, err := nodeApi.SectorNumReservations(ctx)
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(res))
+		for name := range res {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			bf := res[name]
+			cnt, err := bf.Count()
+			if err != nil {
+				return err
+			}
+
+			ranges, err := formatNumberRanges(bf)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%s: %s (%d numbers)\n", name, ranges, cnt)
+		}
+
+		return nil
+	},
+}
+
+var sectorsNumbersReserveCmd = &cli.Command{
+	Name:      "reserve",
+	Usage:     "reserve sector numbers under a name",
+	ArgsUsage: "[name] [ranges, e.g. 1000-1999,2500]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "reserve numbers reserved under another name, or used by sectors of this miner",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 2 {
+			return xerrors.Errorf("expected 2 arguments")
+		}
+
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		numbers, err := parseNumberRanges(cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		return nodeApi.SectorNumReserve(ctx, cctx.Args().First(), numbers, cctx.Bool("force"))
+	},
+}
+
+var sectorsNumbersFreeCmd = &cli.Command{
+	Name:      "free",
+	Usage:     "drop a sector number reservation",
+	ArgsUsage: "[name]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return xerrors.Errorf("expected 1 argument")
+		}
+
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return nodeApi.SectorNumFree(lcli.ReqContext(cctx), cctx.Args().First())
+	},
+}
+
+// parseNumberRanges parses comma separated numbers and inclusive ranges of
+// numbers, e.g. 1000-1999,2500
+func parseNumberRanges(s string) (bitfield.BitField, error) {
+	var parts []bitfield.BitField
+	for _, r := range strings.Split(s, ",") {
+		r = strings.TrimSpace(r)
+
+		start, end := r, r
+		if i := strings.Index(r, "-"); i >= 0 {
+			start, end = r[:i], r[i+1:]
+		}
+
+		from, err := strconv.ParseUint(start, 10, 64)
+		if err != nil {
+			return bitfield.BitField{}, xerrors.Errorf("parsing range %q: %w", r, err)
+		}
+		to, err := strconv.ParseUint(end, 10, 64)
+		if err != nil {
+			return bitfield.BitField{}, xerrors.Errorf("parsing range %q: %w", r, err)
+		}
+		if to < from {
+			return bitfield.BitField{}, xerrors.Errorf("range %q ends before it starts", r)
+		}
+
+		var runs []rlepluslazy.Run
+		if from > 0 {
+			runs = append(runs, rlepluslazy.Run{Val: false, Len: from})
+		}
+		runs = append(runs, rlepluslazy.Run{Val: true, Len: to - from + 1})
+
+		bf, err := bitfield.NewFromIter(&rlepluslazy.RunSliceIterator{Runs: runs})
+		if err != nil {
+			return bitfield.BitField{}, err
+		}
+		parts = append(parts, bf)
+	}
+
+	return bitfield.MultiMerge(parts...)
+}
+
+// formatNumberRanges formats a bitfield the way parseNumberRanges parses it
+func formatNumberRanges(bf bitfield.BitField) (string, error) {
+	rit, err := bf.RunIterator()
+	if err != nil {
+		return "", err
+	}
+
+	var out []string
+	var pos uint64
+	for rit.HasNext() {
+		r, err := rit.NextRun()
+		if err != nil {
+			return "", err
+		}
+
+		if r.Val {
+			if r.Len == 1 {
+				out = append(out, fmt.Sprint(pos))
+			} else {
+				out = append(out, fmt.Sprintf("%d-%d", pos, pos+r.Len-1))
+			}
+		}
+		pos += r.Len
+	}
+
+	return strings.Join(out, ","), nil
+}
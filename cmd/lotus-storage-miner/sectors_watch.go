@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+var sectorsWatchCmd = &cli.Command{
+	Name:      "watch",
+	Usage:     "print sector state transitions as they happen",
+	ArgsUsage: "[sector numbers (default: all sectors)]",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "state",
+			Usage: "only print transitions to this state, e.g. Proving or CommitFailed (can be repeated)",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		var filter api.SectorStateFilter
+		for _, s := range cctx.Args().Slice() {
+			sn, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				return xerrors.Errorf("parsing sector number %q: %w", s, err)
+			}
+			filter.Sectors = append(filter.Sectors, abi.SectorNumber(sn))
+		}
+		for _, st := range cctx.StringSlice("state") {
+			filter.States = append(filter.States, api.SectorState(st))
+		}
+
+		changes, err := nodeApi.SectorsStateChanges(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		jsonOut := outputJSON(cctx)
+		for change := range changes {
+			if jsonOut {
+				b, err := json.Marshal(change)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(b))
+				continue
+			}
+
+			fmt.Printf("%s %s sector %d: %s -> %s", change.Time.Format(time.Stamp), change.Miner, change.SectorNumber, change.From, change.To)
+			if change.Error != "" {
+				fmt.Printf(" (%s)", change.Error)
+			}
+			fmt.Println()
+		}
+
+		return nil
+	},
+}
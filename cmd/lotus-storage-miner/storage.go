@@ -47,6 +47,8 @@ stored while moving through the sealing pipeline (references as 'seal').`,
 		storageAttachCmd,
 		storageListCmd,
 		storageFindCmd,
+		storageMoveCmd,
+		storageTiersCmd,
 		storageCleanupCmd,
 	},
 }
@@ -72,6 +74,15 @@ Data for the sealing process will be stored here
 Store
 Finalized sectors that will be moved here for long term storage and be proven
 over time
+
+Groups
+Names of the groups the path belongs to, e.g. 'rack-A' or 'nvme-scratch'
+
+Allow / Deny rules
+Restrict which task types (AP, PC1, PC2, C1, C2, FIN, GET, UNS) can allocate
+space in the path, and which sector file types (unsealed, sealed, cache) it
+can hold. For example, to keep PC1 scratch space on a local NVMe path:
+  --seal --group nvme-scratch --allow-task PC1 --allow-task PC2
    `,
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
@@ -95,6 +106,26 @@ over time
 			Name:  "max-storage",
 			Usage: "(for init) limit storage space for sectors (expensive for very large paths!)",
 		},
+		&cli.StringSliceFlag{
+			Name:  "group",
+			Usage: "(for init) path group names",
+		},
+		&cli.StringSliceFlag{
+			Name:  "allow-task",
+			Usage: "(for init) only allow the given task types (e.g. PC1) to allocate space in this path",
+		},
+		&cli.StringSliceFlag{
+			Name:  "deny-task",
+			Usage: "(for init) don't allow the given task types to allocate space in this path",
+		},
+		&cli.StringSliceFlag{
+			Name:  "allow-type",
+			Usage: "(for init) only allow the given sector file types (unsealed, sealed, cache) in this path",
+		},
+		&cli.StringSliceFlag{
+			Name:  "deny-type",
+			Usage: "(for init) don't allow the given sector file types in this path",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
@@ -142,12 +173,21 @@ over time
 				CanSeal:    cctx.Bool("seal"),
 				CanStore:   cctx.Bool("store"),
 				MaxStorage: uint64(maxStor),
+				Groups:     cctx.StringSlice("group"),
+				AllowTasks: cctx.StringSlice("allow-task"),
+				DenyTasks:  cctx.StringSlice("deny-task"),
+				AllowTypes: cctx.StringSlice("allow-type"),
+				DenyTypes:  cctx.StringSlice("deny-type"),
 			}
 
 			if !(cfg.CanStore || cfg.CanSeal) {
 				return xerrors.Errorf("must specify at least one of --store of --seal")
 			}
 
+			if err := cfg.ValidateRules(); err != nil {
+				return xerrors.Errorf("invalid path rules: %w", err)
+			}
+
 			b, err := json.MarshalIndent(cfg, "", "  ")
 			if err != nil {
 				return xerrors.Errorf("marshaling storage config: %w", err)
@@ -167,6 +207,10 @@ var storageListCmd = &cli.Command{
 	Usage: "list local storage paths",
 	Flags: []cli.Flag{
 		&cli.BoolFlag{Name: "color"},
+		&cli.StringFlag{
+			Name:  "group",
+			Usage: "only list paths in the given group",
+		},
 	},
 	Subcommands: []*cli.Command{
 		storageListSectorsCmd,
@@ -215,7 +259,19 @@ var storageListCmd = &cli.Command{
 			return sorted[i].ID < sorted[j].ID
 		})
 
+		jsonOut := outputJSON(cctx)
+		var out []storagePathItem
+
 		for _, s := range sorted {
+			if cctx.IsSet("group") {
+				si, err := nodeApi.StorageInfo(ctx, s.ID)
+				if err != nil {
+					return err
+				}
+				if !si.InGroup(cctx.String("group")) {
+					continue
+				}
+			}
 
 			var cnt [3]int
 			for _, decl := range s.sectors {
@@ -226,6 +282,32 @@ var storageListCmd = &cli.Command{
 				}
 			}
 
+			if jsonOut {
+				item := storagePathItem{
+					ID:       s.ID,
+					Unsealed: cnt[0],
+					Sealed:   cnt[1],
+					Caches:   cnt[2],
+				}
+
+				st, err := nodeApi.StorageStat(ctx, s.ID)
+				if err != nil {
+					item.Error = err.Error()
+				} else {
+					item.Stat = &st
+				}
+
+				si, err := nodeApi.StorageInfo(ctx, s.ID)
+				if err != nil {
+					return err
+				}
+				item.Info = si
+				item.LocalPath = local[s.ID]
+
+				out = append(out, item)
+				continue
+			}
+
 			fmt.Printf("%s:\n", s.ID)
 
 			pingStart := time.Now()
@@ -321,6 +403,23 @@ var storageListCmd = &cli.Command{
 				fmt.Print(color.HiYellowString("Use: ReadOnly"))
 			}
 
+			if len(si.Groups) > 0 {
+				fmt.Printf("\tGroups: %s\n", strings.Join(si.Groups, ", "))
+			}
+			for _, rule := range []struct {
+				name string
+				list []string
+			}{
+				{"Allow tasks", si.AllowTasks},
+				{"Deny tasks", si.DenyTasks},
+				{"Allow types", si.AllowTypes},
+				{"Deny types", si.DenyTypes},
+			} {
+				if len(rule.list) > 0 {
+					fmt.Printf("\t%s: %s\n", rule.name, strings.Join(rule.list, ", "))
+				}
+			}
+
 			if localPath, ok := local[s.ID]; ok {
 				fmt.Printf("\tLocal: %s\n", color.GreenString(localPath))
 			}
@@ -335,10 +434,29 @@ var storageListCmd = &cli.Command{
 			fmt.Println()
 		}
 
+		if jsonOut {
+			return printJSON(out)
+		}
 		return nil
 	},
 }
 
+// storagePathItem is the JSON output of storage list for a path
+type storagePathItem struct {
+	ID stores.ID
+
+	Stat  *fsutil.FsStat `json:",omitempty"`
+	Error string         `json:",omitempty"`
+
+	// sector file counts
+	Unsealed int
+	Sealed   int
+	Caches   int
+
+	Info      stores.StorageInfo
+	LocalPath string `json:",omitempty"`
+}
+
 type storedSector struct {
 	id    stores.ID
 	store stores.SectorStorageInfo
@@ -633,6 +751,170 @@ func maybeStr(c bool, col color.Attribute, s string) string {
 	return color.New(col).Sprint(s)
 }
 
+var storageMoveCmd = &cli.Command{
+	Name:      "move",
+	Usage:     "move a sector to another storage path",
+	ArgsUsage: "[sector number] [target path or storage ID]",
+	Description: `Copies the sealed and cache files of a sector to another local storage path
+in the background, verifies the copy, switches the sector index to the new
+copy and removes the old one. The sector stays provable while it's moved.
+
+Use 'lotus-miner storage move list' to follow the progress of moves.`,
+	Subcommands: []*cli.Command{
+		storageMoveListCmd,
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		if cctx.Args().Len() != 2 {
+			return xerrors.New("Usage: lotus-miner storage move [sector number] [target path or storage ID]")
+		}
+
+		snum, err := strconv.ParseUint(cctx.Args().First(), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("parsing sector number: %w", err)
+		}
+
+		local, err := nodeApi.StorageLocal(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting local storage paths: %w", err)
+		}
+
+		target := cctx.Args().Get(1)
+		dest := stores.ID(target)
+		if _, ok := local[dest]; !ok {
+			p, err := homedir.Expand(target)
+			if err != nil {
+				return xerrors.Errorf("expanding target path: %w", err)
+			}
+			p, err = filepath.Abs(p)
+			if err != nil {
+				return xerrors.Errorf("getting absolute target path: %w", err)
+			}
+
+			dest = ""
+			for id, lp := range local {
+				if filepath.Clean(lp) == p {
+					dest = id
+					break
+				}
+			}
+			if dest == "" {
+				return xerrors.Errorf("%s is not an attached local storage path", target)
+			}
+		}
+
+		if err := nodeApi.StorageMoveSector(ctx, abi.SectorNumber(snum), dest); err != nil {
+			return err
+		}
+
+		fmt.Printf("Moving sector %d to %s (%s)\n", snum, dest, local[dest])
+		return nil
+	},
+}
+
+var storageMoveListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list sector storage moves",
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		moves, err := nodeApi.StorageMoveList(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Sector"),
+			tablewriter.Col("From"),
+			tablewriter.Col("To"),
+			tablewriter.Col("State"),
+			tablewriter.Col("Progress"),
+			tablewriter.Col("Queued"),
+			tablewriter.NewLineCol("Error"),
+		)
+
+		for _, mv := range moves {
+			progress := "-"
+			if mv.BytesTotal > 0 {
+				progress = fmt.Sprintf("%s/%s", types.SizeStr(types.NewInt(uint64(mv.BytesCopied))), types.SizeStr(types.NewInt(uint64(mv.BytesTotal))))
+			}
+
+			row := map[string]interface{}{
+				"Sector":   mv.Sector.Number,
+				"From":     mv.From,
+				"To":       mv.To,
+				"State":    mv.State,
+				"Progress": progress,
+				"Queued":   mv.Queued.Format(time.Stamp),
+			}
+			if mv.Err != "" {
+				row["Error"] = mv.Err
+			}
+			tw.Write(row)
+		}
+
+		return tw.Flush(os.Stdout)
+	},
+}
+
+var storageTiersCmd = &cli.Command{
+	Name:  "tiers",
+	Usage: "list the storage tiers of sectors",
+	Description: `With tiering enabled in the config, sealed sectors without active retrieval
+deals which weren't retrieved for a while are moved from paths in the hot
+storage group to paths in the cold storage group, and moved back when
+retrieved.`,
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		tiers, err := nodeApi.StorageTierList(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Sector"),
+			tablewriter.Col("Tier"),
+			tablewriter.Col("Migration"),
+			tablewriter.Col("Since"),
+			tablewriter.NewLineCol("Error"),
+		)
+
+		for _, st := range tiers {
+			row := map[string]interface{}{
+				"Sector": st.Sector.Number,
+				"Tier":   st.Tier,
+				"Since":  st.Since.Format(time.Stamp),
+			}
+			if st.Migration != stores.TierSettled {
+				row["Migration"] = st.Migration
+			}
+			if st.LastErr != "" {
+				row["Error"] = st.LastErr
+			}
+			tw.Write(row)
+		}
+
+		return tw.Flush(os.Stdout)
+	},
+}
+
 var storageCleanupCmd = &cli.Command{
 	Name:  "cleanup",
 	Usage: "trigger cleanup actions",
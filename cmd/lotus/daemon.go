@@ -111,6 +111,10 @@ var DaemonCmd = &cli.Command{
 			Name:  "import-snapshot",
 			Usage: "import chain state from a given chain export file or url",
 		},
+		&cli.BoolFlag{
+			Name:  "import-snapshot-background",
+			Usage: "start the node once the recent chain from the snapshot is imported, and import the rest of the snapshot in the background",
+		},
 		&cli.BoolFlag{
 			Name:  "halt-after-import",
 			Usage: "halt the process after importing chain from file",
@@ -256,6 +260,7 @@ var DaemonCmd = &cli.Command{
 			}
 		}
 
+		snapshotImport := node.Options()
 		chainfile := cctx.String("import-chain")
 		snapshot := cctx.String("import-snapshot")
 		if chainfile != "" || snapshot != "" {
@@ -268,12 +273,33 @@ var DaemonCmd = &cli.Command{
 				issnapshot = true
 			}
 
-			if err := ImportChain(ctx, r, chainfile, issnapshot); err != nil {
-				return err
-			}
-			if cctx.Bool("halt-after-import") {
-				fmt.Println("Chain import complete, halting as requested...")
-				return nil
+			if cctx.Bool("import-snapshot-background") {
+				if !issnapshot {
+					return xerrors.Errorf("only snapshots can be imported in the background")
+				}
+				if cctx.Bool("halt-after-import") {
+					return xerrors.Errorf("cannot halt after a background import")
+				}
+
+				rd, l, err := openChainFile(chainfile)
+				if err != nil {
+					return err
+				}
+				defer rd.Close() //nolint:errcheck
+
+				log.Infof("importing chain from %s in the background...", chainfile)
+				snapshotImport = node.Options(
+					node.Override(new(*store.SnapshotImport), modules.SnapshotImport(rd, l)),
+					node.Override(node.RunSnapshotImportKey, modules.RunSnapshotImport),
+				)
+			} else {
+				if err := ImportChain(ctx, r, chainfile, issnapshot); err != nil {
+					return err
+				}
+				if cctx.Bool("halt-after-import") {
+					fmt.Println("Chain import complete, halting as requested...")
+					return nil
+				}
 			}
 		}
 
@@ -322,6 +348,7 @@ var DaemonCmd = &cli.Command{
 
 			genesis,
 			liteModeDeps,
+			snapshotImport,
 
 			node.ApplyIf(func(s *node.Settings) bool { return cctx.IsSet("api") },
 				node.Override(node.SetApiEndpointKey, func(lr repo.LockedRepo) error {
@@ -423,42 +450,47 @@ func importKey(ctx context.Context, api api.FullNode, f string) error {
 	return nil
 }
 
-func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool) (err error) {
-	var rd io.Reader
-	var l int64
+// openChainFile opens a chain CAR from a file or an url, returning its length
+func openChainFile(fname string) (io.ReadCloser, int64, error) {
 	if strings.HasPrefix(fname, "http://") || strings.HasPrefix(fname, "https://") {
 		resp, err := http.Get(fname) //nolint:gosec
 		if err != nil {
-			return err
+			return nil, 0, err
 		}
-		defer resp.Body.Close() //nolint:errcheck
 
 		if resp.StatusCode != http.StatusOK {
-			return xerrors.Errorf("fetching chain CAR failed with non-200 response: %d", resp.StatusCode)
+			resp.Body.Close() //nolint:errcheck
+			return nil, 0, xerrors.Errorf("fetching chain CAR failed with non-200 response: %d", resp.StatusCode)
 		}
 
-		rd = resp.Body
-		l = resp.ContentLength
-	} else {
-		fname, err = homedir.Expand(fname)
-		if err != nil {
-			return err
-		}
+		return resp.Body, resp.ContentLength, nil
+	}
 
-		fi, err := os.Open(fname)
-		if err != nil {
-			return err
-		}
-		defer fi.Close() //nolint:errcheck
+	fname, err := homedir.Expand(fname)
+	if err != nil {
+		return nil, 0, err
+	}
 
-		st, err := os.Stat(fname)
-		if err != nil {
-			return err
-		}
+	fi, err := os.Open(fname)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	st, err := fi.Stat()
+	if err != nil {
+		fi.Close() //nolint:errcheck
+		return nil, 0, err
+	}
+
+	return fi, st.Size(), nil
+}
 
-		rd = fi
-		l = st.Size()
+func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool) (err error) {
+	rd, l, err := openChainFile(fname)
+	if err != nil {
+		return err
 	}
+	defer rd.Close() //nolint:errcheck
 
 	lr, err := r.Lock(repo.FullNode)
 	if err != nil {
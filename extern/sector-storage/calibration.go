@@ -0,0 +1,108 @@
+package sectorstorage
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+// calibration returns the measurement of a task on the worker, preferring one
+// taken with the same seal proof.
+func (wh *workerHandle) calibration(tt sealtasks.TaskType, spt abi.RegisteredSealProof) (storiface.TaskCalibration, bool) {
+	var out storiface.TaskCalibration
+	var found bool
+	for _, c := range wh.info.Calibration {
+		if c.Task != tt {
+			continue
+		}
+		if c.SealProof == spt {
+			return c, true
+		}
+		out, found = c, true
+	}
+	return out, found
+}
+
+// taskResources returns the resources needed to run a task on the worker. The
+// memory measured by calibrating the worker with the same seal proof replaces
+// the estimate from the resource table.
+func (wh *workerHandle) taskResources(tt sealtasks.TaskType, spt abi.RegisteredSealProof) Resources {
+	res := ResourceTable[tt][spt]
+
+	c, ok := wh.calibration(tt, spt)
+	if !ok || c.SealProof != spt || c.PeakMemory == 0 {
+		return res
+	}
+
+	// the peak includes memory shared between threads
+	mem := c.PeakMemory
+	if mem > res.BaseMinMemory {
+		mem -= res.BaseMinMemory
+	}
+	res.MinMemory = mem
+	if res.MaxMemory < mem {
+		res.MaxMemory = mem
+	}
+	return res
+}
+
+// predictDuration predicts how long a task takes on the worker. Measurements
+// with other seal proofs are scaled by sector size.
+func (wh *workerHandle) predictDuration(tt sealtasks.TaskType, spt abi.RegisteredSealProof) (time.Duration, bool) {
+	c, ok := wh.calibration(tt, spt)
+	if !ok {
+		return 0, false
+	}
+	if c.SealProof == spt {
+		return c.Duration, true
+	}
+
+	have, err := c.SealProof.SectorSize()
+	if err != nil || have == 0 {
+		return 0, false
+	}
+	want, err := spt.SectorSize()
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(float64(c.Duration) * float64(want) / float64(have)), true
+}
+
+// predictFinish predicts when the worker would finish a new task, after the
+// tasks it's running and the tasks assigned to its windows. Tasks the worker
+// wasn't calibrated for are assumed to be short. It returns false when the
+// worker wasn't calibrated for the new task.
+func (sh *scheduler) predictFinish(wid WorkerID, wh *workerHandle, tt sealtasks.TaskType, spt abi.RegisteredSealProof) (time.Duration, bool) {
+	if len(wh.info.Calibration) == 0 {
+		return 0, false
+	}
+
+	total, ok := wh.predictDuration(tt, spt)
+	if !ok {
+		return 0, false
+	}
+
+	for _, t := range sh.workTracker.Running() {
+		if t.worker != wid {
+			continue
+		}
+		d, _ := wh.predictDuration(t.job.Task, spt)
+		if remaining := d - time.Since(t.job.Start); remaining > 0 {
+			total += remaining
+		}
+	}
+
+	wh.wndLk.Lock()
+	defer wh.wndLk.Unlock()
+	for _, window := range wh.activeWindows {
+		for _, req := range window.todo {
+			d, _ := wh.predictDuration(req.taskType, req.sector.ProofType)
+			total += d
+		}
+	}
+
+	return total, true
+}
@@ -0,0 +1,84 @@
+package ffiwrapper
+
+import (
+	"context"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+)
+
+// ProofKind is a kind of proof which can be computed by another backend than
+// the in-process FFI
+type ProofKind string
+
+const (
+	ProofCommit2     ProofKind = "commit2"
+	ProofAggregate   ProofKind = "aggregate"
+	ProofWinningPoSt ProofKind = "winningpost"
+	ProofWindowPoSt  ProofKind = "windowpost"
+)
+
+var ProofKinds = []ProofKind{ProofCommit2, ProofAggregate, ProofWinningPoSt, ProofWindowPoSt}
+
+// PoStSector is a sector to prove, with the paths of its files. External
+// backends must be able to read the files at the same paths.
+type PoStSector struct {
+	SectorInfo    proof5.SectorInfo
+	PoStProofType abi.RegisteredPoStProof
+
+	CacheDirPath     string
+	SealedSectorPath string
+}
+
+// ProofBackend computes the GPU-heavy proofs
+type ProofBackend interface {
+	SealCommit2(ctx context.Context, sector abi.SectorID, phase1Out []byte) ([]byte, error)
+	AggregateSealProofs(ctx context.Context, aggregateInfo proof5.AggregateSealVerifyProofAndInfos, proofs [][]byte) ([]byte, error)
+
+	// Randomness is expected to be already masked
+	GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectors []PoStSector, randomness abi.PoStRandomness) ([]proof5.PoStProof, error)
+	GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectors []PoStSector, randomness abi.PoStRandomness) ([]proof5.PoStProof, []abi.SectorNumber, error)
+}
+
+// ProofBackends selects the backend of each kind of proof, proofs of kinds
+// without one are computed in process
+type ProofBackends map[ProofKind]ProofBackend
+
+type SealerOption func(*Sealer)
+
+// WithProofBackends makes the sealer compute proofs with the backends
+func WithProofBackends(b ProofBackends) SealerOption {
+	return func(sb *Sealer) {
+		sb.backends = b
+	}
+}
+
+// BackendProver returns a Prover aggregating proofs with the backend. The
+// aggregates are checked with the verifier before they are returned.
+func BackendProver(b ProofBackend, v Verifier) Prover {
+	return backendProver{b: b, v: v}
+}
+
+type backendProver struct {
+	b ProofBackend
+	v Verifier
+}
+
+func (p backendProver) AggregateSealProofs(aggregateInfo proof5.AggregateSealVerifyProofAndInfos, proofs [][]byte) ([]byte, error) {
+	aggregate, err := p.b.AggregateSealProofs(context.TODO(), aggregateInfo, proofs)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregateInfo.AggregateProof = aggregate
+	ok, err := p.v.VerifyAggregateSeals(aggregateInfo)
+	if err != nil {
+		return nil, xerrors.Errorf("verifying aggregate of the proof backend: %w", err)
+	}
+	if !ok {
+		return nil, xerrors.Errorf("proof backend returned an invalid aggregate")
+	}
+	return aggregate, nil
+}
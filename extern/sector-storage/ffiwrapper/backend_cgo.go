@@ -0,0 +1,74 @@
+//+build cgo
+
+package ffiwrapper
+
+import (
+	"context"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+)
+
+// LocalProofBackend computes the proofs in process with the FFI
+var LocalProofBackend ProofBackend = localBackend{}
+
+type localBackend struct{}
+
+func (localBackend) SealCommit2(ctx context.Context, sector abi.SectorID, phase1Out []byte) ([]byte, error) {
+	return ffi.SealCommitPhase2(phase1Out, sector.Number, sector.Miner)
+}
+
+func (localBackend) AggregateSealProofs(ctx context.Context, aggregateInfo proof5.AggregateSealVerifyProofAndInfos, proofs [][]byte) ([]byte, error) {
+	return ffi.AggregateSealProofs(aggregateInfo, proofs)
+}
+
+func (localBackend) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectors []PoStSector, randomness abi.PoStRandomness) ([]proof5.PoStProof, error) {
+	return ffi.GenerateWinningPoSt(minerID, privateSectors(sectors), randomness)
+}
+
+func (localBackend) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectors []PoStSector, randomness abi.PoStRandomness) ([]proof5.PoStProof, []abi.SectorNumber, error) {
+	return ffi.GenerateWindowPoSt(minerID, privateSectors(sectors), randomness)
+}
+
+func privateSectors(sectors []PoStSector) ffi.SortedPrivateSectorInfo {
+	out := make([]ffi.PrivateSectorInfo, len(sectors))
+	for i, s := range sectors {
+		out[i] = ffi.PrivateSectorInfo{
+			SectorInfo:       s.SectorInfo,
+			CacheDirPath:     s.CacheDirPath,
+			PoStProofType:    s.PoStProofType,
+			SealedSectorPath: s.SealedSectorPath,
+		}
+	}
+	return ffi.NewSortedPrivateSectorInfo(out...)
+}
+
+func postSectors(sectors ffi.SortedPrivateSectorInfo) []PoStSector {
+	var out []PoStSector
+	for _, s := range sectors.Values() {
+		out = append(out, PoStSector{
+			SectorInfo:       s.SectorInfo,
+			PoStProofType:    s.PoStProofType,
+			CacheDirPath:     s.CacheDirPath,
+			SealedSectorPath: s.SealedSectorPath,
+		})
+	}
+	return out
+}
+
+func (sb *Sealer) backend(kind ProofKind) ProofBackend {
+	if b, ok := sb.backends[kind]; ok {
+		return b
+	}
+	return LocalProofBackend
+}
+
+// external returns whether proofs of the kind are computed by another
+// backend, whose results are verified before they are used. Commit2 proofs
+// are verified by the sealing state machine before they are submitted, see
+// checkCommit.
+func (sb *Sealer) external(kind ProofKind) bool {
+	_, ok := sb.backends[kind]
+	return ok
+}
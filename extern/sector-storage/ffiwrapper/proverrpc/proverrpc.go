@@ -0,0 +1,384 @@
+// Package proverrpc serves proof backends over gRPC, so that Commit2,
+// aggregation and PoSt proofs can be computed by an external prover daemon,
+// e.g. a GPU farm shared by several miners.
+//
+// Messages are JSON encoded, the service is described by hand instead of
+// being generated from protobuf definitions.
+//
+// Connections use mutual TLS with the certificates of lib/rpctls, and calls
+// carry a token shared by the prover and its clients.
+package proverrpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"strings"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper"
+	"github.com/filecoin-project/lotus/lib/rpctls"
+)
+
+const serviceName = "lotus.prover.v1.Prover"
+
+// maxMessageSize fits Commit1 outputs and aggregates of many proofs
+const maxMessageSize = 512 << 20
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+type commit2Request struct {
+	Sector    abi.SectorID
+	Phase1Out []byte
+}
+
+type aggregateRequest struct {
+	Info   proof5.AggregateSealVerifyProofAndInfos
+	Proofs [][]byte
+}
+
+type proofResponse struct {
+	Proof []byte
+}
+
+type postRequest struct {
+	Miner      abi.ActorID
+	Sectors    []ffiwrapper.PoStSector
+	Randomness abi.PoStRandomness
+}
+
+type postResponse struct {
+	Proofs []proof5.PoStProof
+	Faulty []abi.SectorNumber
+
+	// gRPC drops the response of failed calls, window PoSt errors are
+	// returned here to keep the faulty sectors
+	Error string
+}
+
+// Credentials authenticate the provers and their clients to each other
+type Credentials struct {
+	// Certs are the TLS certificates of the process, the peer must present a
+	// certificate issued by their CA
+	Certs *rpctls.Certs
+
+	// Token is shared by the prover and its clients
+	Token string
+}
+
+func (c Credentials) check() error {
+	if c.Certs == nil || c.Token == "" {
+		return xerrors.Errorf("external provers need TLS certificates and a token")
+	}
+	return nil
+}
+
+// transportCreds performs TLS handshakes with the current certificates, which
+// may be rotated while connections are open
+type transportCreds struct {
+	certs *rpctls.Certs
+}
+
+func (t transportCreds) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	host, _, err := net.SplitHostPort(authority)
+	if err != nil {
+		host = authority
+	}
+	cfg, err := t.certs.ClientConfig(host)
+	if err != nil {
+		return nil, nil, err
+	}
+	return credentials.NewTLS(cfg).ClientHandshake(ctx, authority, conn)
+}
+
+func (t transportCreds) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	cfg := t.certs.ServerConfig()
+	getConfig := cfg.GetConfigForClient
+	cfg.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		c, err := getConfig(hello)
+		if err != nil {
+			return nil, err
+		}
+		c.NextProtos = []string{"h2"}
+		return c, nil
+	}
+	return credentials.NewTLS(cfg).ServerHandshake(conn)
+}
+
+func (t transportCreds) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "tls", SecurityVersion: "1.2"}
+}
+
+func (t transportCreds) Clone() credentials.TransportCredentials {
+	return t
+}
+
+func (t transportCreds) OverrideServerName(string) error {
+	return nil
+}
+
+// tokenCreds adds the token to the calls
+type tokenCreds string
+
+func (t tokenCreds) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (t tokenCreds) RequireTransportSecurity() bool {
+	return true
+}
+
+// Client computes proofs with a remote prover
+type Client struct {
+	conn *grpc.ClientConn
+}
+
+var _ ffiwrapper.ProofBackend = &Client{}
+
+// Dial returns a client of the prover at the address. Connections are made
+// when needed, so the prover doesn't have to be up yet.
+func Dial(addr string, creds Credentials) (*Client, error) {
+	if err := creds.check(); err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.Dial(addr,
+		grpc.WithTransportCredentials(transportCreds{certs: creds.Certs}),
+		grpc.WithPerRPCCredentials(tokenCreds(creds.Token)),
+		grpc.WithDefaultCallOptions(
+			grpc.ForceCodec(jsonCodec{}),
+			grpc.MaxCallRecvMsgSize(maxMessageSize),
+			grpc.MaxCallSendMsgSize(maxMessageSize),
+		),
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("dialing prover %s: %w", addr, err)
+	}
+	return &Client{conn: conn}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) invoke(ctx context.Context, method string, req, resp interface{}) error {
+	if err := c.conn.Invoke(ctx, "/"+serviceName+"/"+method, req, resp); err != nil {
+		return xerrors.Errorf("remote prover %s: %w", method, err)
+	}
+	return nil
+}
+
+func (c *Client) SealCommit2(ctx context.Context, sector abi.SectorID, phase1Out []byte) ([]byte, error) {
+	var resp proofResponse
+	err := c.invoke(ctx, "SealCommit2", &commit2Request{Sector: sector, Phase1Out: phase1Out}, &resp)
+	return resp.Proof, err
+}
+
+func (c *Client) AggregateSealProofs(ctx context.Context, aggregateInfo proof5.AggregateSealVerifyProofAndInfos, proofs [][]byte) ([]byte, error) {
+	var resp proofResponse
+	err := c.invoke(ctx, "AggregateSealProofs", &aggregateRequest{Info: aggregateInfo, Proofs: proofs}, &resp)
+	return resp.Proof, err
+}
+
+func (c *Client) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectors []ffiwrapper.PoStSector, randomness abi.PoStRandomness) ([]proof5.PoStProof, error) {
+	var resp postResponse
+	err := c.invoke(ctx, "GenerateWinningPoSt", &postRequest{Miner: minerID, Sectors: sectors, Randomness: randomness}, &resp)
+	return resp.Proofs, err
+}
+
+func (c *Client) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectors []ffiwrapper.PoStSector, randomness abi.PoStRandomness) ([]proof5.PoStProof, []abi.SectorNumber, error) {
+	var resp postResponse
+	if err := c.invoke(ctx, "GenerateWindowPoSt", &postRequest{Miner: minerID, Sectors: sectors, Randomness: randomness}, &resp); err != nil {
+		return nil, nil, err
+	}
+	if resp.Error != "" {
+		return nil, resp.Faulty, xerrors.Errorf("remote prover GenerateWindowPoSt: %s", resp.Error)
+	}
+	return resp.Proofs, resp.Faulty, nil
+}
+
+// Backends dials the provers configured by proof kind, e.g.
+// {"commit2": "gpufarm:9900"}. Kinds sharing an address share a connection.
+// The backends must be closed with Close.
+func Backends(provers map[string]string, creds Credentials) (ffiwrapper.ProofBackends, error) {
+	known := map[ffiwrapper.ProofKind]struct{}{}
+	for _, k := range ffiwrapper.ProofKinds {
+		known[k] = struct{}{}
+	}
+
+	out := ffiwrapper.ProofBackends{}
+	clients := map[string]*Client{}
+	for kind, addr := range provers {
+		k := ffiwrapper.ProofKind(kind)
+		if _, ok := known[k]; !ok {
+			_ = Close(out)
+			return nil, xerrors.Errorf("unknown proof kind %q, expected one of %v", kind, ffiwrapper.ProofKinds)
+		}
+
+		c, ok := clients[addr]
+		if !ok {
+			var err error
+			c, err = Dial(addr, creds)
+			if err != nil {
+				_ = Close(out)
+				return nil, err
+			}
+			clients[addr] = c
+		}
+		out[k] = c
+	}
+	return out, nil
+}
+
+// Close closes the connections of the backends returned by Backends
+func Close(backends ffiwrapper.ProofBackends) error {
+	closed := map[*Client]struct{}{}
+	var err error
+	for _, b := range backends {
+		c, ok := b.(*Client)
+		if !ok {
+			continue
+		}
+		if _, ok := closed[c]; ok {
+			continue
+		}
+		closed[c] = struct{}{}
+
+		if cerr := c.Close(); cerr != nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// NewServer returns a gRPC server computing proofs with the backend, for
+// clients with the credentials
+func NewServer(b ffiwrapper.ProofBackend, creds Credentials) (*grpc.Server, error) {
+	if err := creds.check(); err != nil {
+		return nil, err
+	}
+
+	s := grpc.NewServer(
+		grpc.Creds(transportCreds{certs: creds.Certs}),
+		grpc.UnaryInterceptor(authInterceptor(creds.Token)),
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.MaxSendMsgSize(maxMessageSize),
+	)
+	s.RegisterService(&serviceDesc, b)
+	return s, nil
+}
+
+// authInterceptor rejects calls without the token
+func authInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		var got string
+		if auth := md.Get("authorization"); len(auth) == 1 {
+			got = strings.TrimPrefix(auth[0], "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func handler(call func(ctx context.Context, b ffiwrapper.ProofBackend, dec func(interface{}) error) (interface{}, error)) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		if interceptor == nil {
+			return call(ctx, srv.(ffiwrapper.ProofBackend), dec)
+		}
+
+		// the interceptor runs before the request is decoded, requests
+		// without the token aren't decoded at all
+		return interceptor(ctx, nil, &grpc.UnaryServerInfo{Server: srv}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			return call(ctx, srv.(ffiwrapper.ProofBackend), dec)
+		})
+	}
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*ffiwrapper.ProofBackend)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SealCommit2",
+			Handler: handler(func(ctx context.Context, b ffiwrapper.ProofBackend, dec func(interface{}) error) (interface{}, error) {
+				var req commit2Request
+				if err := dec(&req); err != nil {
+					return nil, err
+				}
+				p, err := b.SealCommit2(ctx, req.Sector, req.Phase1Out)
+				return &proofResponse{Proof: p}, err
+			}),
+		},
+		{
+			MethodName: "AggregateSealProofs",
+			Handler: handler(func(ctx context.Context, b ffiwrapper.ProofBackend, dec func(interface{}) error) (interface{}, error) {
+				var req aggregateRequest
+				if err := dec(&req); err != nil {
+					return nil, err
+				}
+				p, err := b.AggregateSealProofs(ctx, req.Info, req.Proofs)
+				return &proofResponse{Proof: p}, err
+			}),
+		},
+		{
+			MethodName: "GenerateWinningPoSt",
+			Handler: handler(func(ctx context.Context, b ffiwrapper.ProofBackend, dec func(interface{}) error) (interface{}, error) {
+				var req postRequest
+				if err := dec(&req); err != nil {
+					return nil, err
+				}
+				p, err := b.GenerateWinningPoSt(ctx, req.Miner, req.Sectors, req.Randomness)
+				return &postResponse{Proofs: p}, err
+			}),
+		},
+		{
+			MethodName: "GenerateWindowPoSt",
+			Handler: handler(func(ctx context.Context, b ffiwrapper.ProofBackend, dec func(interface{}) error) (interface{}, error) {
+				var req postRequest
+				if err := dec(&req); err != nil {
+					return nil, err
+				}
+				p, faulty, err := b.GenerateWindowPoSt(ctx, req.Miner, req.Sectors, req.Randomness)
+				resp := &postResponse{Proofs: p, Faulty: faulty}
+				if err != nil {
+					resp.Error = err.Error()
+				}
+				return resp, nil
+			}),
+		},
+	},
+	Metadata: "proverrpc.go",
+}
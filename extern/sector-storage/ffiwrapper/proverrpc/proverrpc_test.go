@@ -0,0 +1,159 @@
+package proverrpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper"
+	"github.com/filecoin-project/lotus/lib/rpctls"
+)
+
+type testBackend struct{}
+
+func (testBackend) SealCommit2(ctx context.Context, sector abi.SectorID, phase1Out []byte) ([]byte, error) {
+	return append([]byte{byte(sector.Number)}, phase1Out...), nil
+}
+
+func (testBackend) AggregateSealProofs(ctx context.Context, aggregateInfo proof5.AggregateSealVerifyProofAndInfos, proofs [][]byte) ([]byte, error) {
+	return nil, xerrors.New("no aggregation")
+}
+
+func (testBackend) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectors []ffiwrapper.PoStSector, randomness abi.PoStRandomness) ([]proof5.PoStProof, error) {
+	return []proof5.PoStProof{{PoStProof: abi.RegisteredPoStProof_StackedDrgWinning2KiBV1, ProofBytes: randomness}}, nil
+}
+
+func (testBackend) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectors []ffiwrapper.PoStSector, randomness abi.PoStRandomness) ([]proof5.PoStProof, []abi.SectorNumber, error) {
+	return nil, []abi.SectorNumber{sectors[0].SectorInfo.SectorNumber}, xerrors.New("faulty sectors")
+}
+
+// testCerts returns certificates for 127.0.0.1, issued by a new CA which
+// they trust
+func testCerts(t *testing.T) *rpctls.Certs {
+	dir := t.TempDir()
+	write := func(name, typ string, der []byte) string {
+		f := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(f, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0600))
+		return f
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDer)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	kb, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	c, err := rpctls.Load(rpctls.Files{
+		CertFile: write("cert.pem", "CERTIFICATE", der),
+		KeyFile:  write("key.pem", "EC PRIVATE KEY", kb),
+		CAFile:   write("ca.pem", "CERTIFICATE", caDer),
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func TestProverRPC(t *testing.T) {
+	ctx := context.Background()
+
+	nl, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	creds := Credentials{Certs: testCerts(t), Token: "token"}
+
+	srv, err := NewServer(testBackend{}, creds)
+	require.NoError(t, err)
+	go srv.Serve(nl) // nolint:errcheck
+	defer srv.Stop()
+
+	backends, err := Backends(map[string]string{
+		"commit2":    nl.Addr().String(),
+		"windowpost": nl.Addr().String(),
+	}, creds)
+	require.NoError(t, err)
+	defer Close(backends) // nolint:errcheck
+	require.Len(t, backends, 2)
+	require.Equal(t, backends[ffiwrapper.ProofCommit2], backends[ffiwrapper.ProofWindowPoSt])
+
+	c := backends[ffiwrapper.ProofCommit2]
+
+	p, err := c.SealCommit2(ctx, abi.SectorID{Miner: 1000, Number: 7}, []byte{1, 2})
+	require.NoError(t, err)
+	require.Equal(t, []byte{7, 1, 2}, p)
+
+	_, err = c.AggregateSealProofs(ctx, proof5.AggregateSealVerifyProofAndInfos{}, nil)
+	require.Error(t, err)
+
+	wp, err := c.GenerateWinningPoSt(ctx, 1000, nil, abi.PoStRandomness{3})
+	require.NoError(t, err)
+	require.Equal(t, []byte{3}, wp[0].ProofBytes)
+
+	_, faulty, err := c.GenerateWindowPoSt(ctx, 1000, []ffiwrapper.PoStSector{{SectorInfo: proof5.SectorInfo{SectorNumber: 5}}}, nil)
+	require.Error(t, err)
+	require.Equal(t, []abi.SectorNumber{5}, faulty)
+
+	_, err = Backends(map[string]string{"unseal": "localhost:1"}, creds)
+	require.Error(t, err)
+
+	// the prover isn't reachable without credentials
+	_, err = Backends(map[string]string{"commit2": nl.Addr().String()}, Credentials{})
+	require.Error(t, err)
+
+	wrong, err := Dial(nl.Addr().String(), Credentials{Certs: creds.Certs, Token: "wrong"})
+	require.NoError(t, err)
+	defer wrong.Close() // nolint:errcheck
+
+	_, err = wrong.SealCommit2(ctx, abi.SectorID{Miner: 1000, Number: 7}, []byte{1, 2})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid token")
+
+	// nor to clients whose certificate isn't issued by its CA
+	other, err := Dial(nl.Addr().String(), Credentials{Certs: testCerts(t), Token: "token"})
+	require.NoError(t, err)
+	defer other.Close() // nolint:errcheck
+
+	tctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	_, err = other.SealCommit2(tctx, abi.SectorID{Miner: 1000, Number: 7}, []byte{1, 2})
+	require.Error(t, err)
+}
@@ -8,6 +8,7 @@ var log = logging.Logger("ffiwrapper")
 
 type Sealer struct {
 	sectors  SectorProvider
+	backends ProofBackends
 	stopping chan struct{}
 }
 
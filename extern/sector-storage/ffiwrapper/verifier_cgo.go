@@ -27,7 +27,24 @@ func (sb *Sealer) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID,
 		return nil, xerrors.Errorf("pubSectorToPriv skipped sectors: %+v", skipped)
 	}
 
-	return ffi.GenerateWinningPoSt(minerID, privsectors, randomness)
+	proofs, err := sb.backend(ProofWinningPoSt).GenerateWinningPoSt(ctx, minerID, postSectors(privsectors), randomness)
+	if err != nil || !sb.external(ProofWinningPoSt) {
+		return proofs, err
+	}
+
+	ok, err := ProofVerifier.VerifyWinningPoSt(ctx, proof5.WinningPoStVerifyInfo{
+		Randomness:        randomness,
+		Proofs:            proofs,
+		ChallengedSectors: sectorInfo,
+		Prover:            minerID,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("verifying winning PoSt of the external prover: %w", err)
+	}
+	if !ok {
+		return nil, xerrors.Errorf("external prover returned an invalid winning PoSt")
+	}
+	return proofs, nil
 }
 
 func (sb *Sealer) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof5.SectorInfo, randomness abi.PoStRandomness) ([]proof5.PoStProof, []abi.SectorID, error) {
@@ -42,7 +59,7 @@ func (sb *Sealer) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, s
 		return nil, skipped, xerrors.Errorf("pubSectorToPriv skipped some sectors")
 	}
 
-	proof, faulty, err := ffi.GenerateWindowPoSt(minerID, privsectors, randomness)
+	proof, faulty, err := sb.backend(ProofWindowPoSt).GenerateWindowPoSt(ctx, minerID, postSectors(privsectors), randomness)
 
 	var faultyIDs []abi.SectorID
 	for _, f := range faulty {
@@ -52,6 +69,21 @@ func (sb *Sealer) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, s
 		})
 	}
 
+	if err == nil && sb.external(ProofWindowPoSt) {
+		ok, verr := ProofVerifier.VerifyWindowPoSt(ctx, proof5.WindowPoStVerifyInfo{
+			Randomness:        randomness,
+			Proofs:            proof,
+			ChallengedSectors: sectorInfo,
+			Prover:            minerID,
+		})
+		if verr != nil {
+			return nil, faultyIDs, xerrors.Errorf("verifying window PoSt of the external prover: %w", verr)
+		}
+		if !ok {
+			return nil, faultyIDs, xerrors.Errorf("external prover returned an invalid window PoSt")
+		}
+	}
+
 	return proof, faultyIDs, err
 }
 
@@ -0,0 +1,220 @@
+package sectorstorage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+// ParseGPUAssignment parses a GPU assignment keyed by full or short task type
+// names, e.g. {"C2": [0], "WDP": [1]}.
+func ParseGPUAssignment(cfg map[string][]int) (map[sealtasks.TaskType][]int, error) {
+	if len(cfg) == 0 {
+		return nil, nil
+	}
+
+	out := map[sealtasks.TaskType][]int{}
+	for name, idxs := range cfg {
+		tt, ok := sealtasks.ParseTaskType(name)
+		if !ok {
+			return nil, xerrors.Errorf("unknown task type %q", name)
+		}
+		for _, idx := range idxs {
+			if idx < 0 {
+				return nil, xerrors.Errorf("invalid GPU index %d for %s", idx, name)
+			}
+		}
+		out[tt] = append(out[tt], idxs...)
+	}
+	return out, nil
+}
+
+type gpuState struct {
+	device string
+
+	task   sealtasks.TaskType
+	sector abi.SectorID
+	since  time.Time
+	busy   time.Duration
+}
+
+// gpuAllocator hands out GPUs of a worker process to running tasks. When GPUs
+// are assigned to task types, tasks wait for a free GPU assigned to them;
+// otherwise GPU usage is only tracked, and the scheduler keeps GPU tasks from
+// running in parallel.
+//
+// Proofs are computed in process, on the devices the proving library picks
+// when it starts, so an assignment limits how many tasks of each type run at
+// once and how usage is reported, it doesn't bind a task to its device. To
+// keep task types on separate devices, run a worker per device, started with
+// CUDA_VISIBLE_DEVICES (or BELLMAN_CUSTOM_GPU) selecting it.
+type gpuAllocator struct {
+	assignment map[sealtasks.TaskType][]int
+	devices    func() ([]string, error)
+
+	once  sync.Once
+	start time.Time
+
+	lk       sync.Mutex
+	gpus     []*gpuState
+	released chan struct{} // closed when a GPU is released
+}
+
+func newGPUAllocator(assignment map[sealtasks.TaskType][]int) *gpuAllocator {
+	return &gpuAllocator{
+		assignment: assignment,
+		devices:    ffi.GetGPUDevices,
+
+		start:    time.Now(),
+		released: make(chan struct{}),
+	}
+}
+
+func (g *gpuAllocator) init() {
+	g.once.Do(func() {
+		devices, err := g.devices()
+		if err != nil {
+			log.Errorf("getting gpu devices failed: %+v", err)
+		}
+
+		g.lk.Lock()
+		defer g.lk.Unlock()
+		for _, d := range devices {
+			g.gpus = append(g.gpus, &gpuState{device: d, since: g.start})
+		}
+	})
+}
+
+func (g *gpuAllocator) resources() storiface.WorkerResources {
+	wr := storiface.WorkerResources{GPUAssignment: g.assignment}
+	for _, gs := range g.gpus {
+		wr.GPUs = append(wr.GPUs, gs.device)
+	}
+	return wr
+}
+
+// acquire a GPU for a task; the returned function releases it.
+func (g *gpuAllocator) acquire(ctx context.Context, tt sealtasks.TaskType, sector abi.SectorID) (func(), error) {
+	g.init()
+
+	// winning PoSt is never delayed, that could cost a block
+	wait := len(g.assignment) > 0 && tt != sealtasks.TTGenerateWinningPoSt
+
+	for {
+		g.lk.Lock()
+		idxs := g.resources().GPUsFor(tt)
+		if len(idxs) == 0 {
+			g.lk.Unlock()
+			return func() {}, nil
+		}
+
+		for _, idx := range idxs {
+			gs := g.gpus[idx]
+			if gs.task != "" {
+				continue
+			}
+
+			now := time.Now()
+			gs.task, gs.sector, gs.since = tt, sector, now
+			g.lk.Unlock()
+
+			return func() {
+				g.release(gs)
+			}, nil
+		}
+
+		released := g.released
+		g.lk.Unlock()
+
+		if !wait {
+			return func() {}, nil
+		}
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, xerrors.Errorf("waiting for a GPU for %s: %w", tt.Short(), ctx.Err())
+		}
+	}
+}
+
+func (g *gpuAllocator) release(gs *gpuState) {
+	g.lk.Lock()
+	defer g.lk.Unlock()
+
+	now := time.Now()
+	gs.busy += now.Sub(gs.since)
+	gs.task, gs.sector, gs.since = "", abi.SectorID{}, now
+
+	close(g.released)
+	g.released = make(chan struct{})
+}
+
+func (g *gpuAllocator) usage() []storiface.GPUUsage {
+	g.init()
+
+	g.lk.Lock()
+	defer g.lk.Unlock()
+
+	now := time.Now()
+	uptime := now.Sub(g.start)
+
+	out := make([]storiface.GPUUsage, len(g.gpus))
+	for idx, gs := range g.gpus {
+		busy := gs.busy
+		if gs.task != "" {
+			busy += now.Sub(gs.since)
+		}
+
+		out[idx] = storiface.GPUUsage{
+			Index:  idx,
+			Device: gs.device,
+
+			Task:   gs.task,
+			Sector: gs.sector,
+			Since:  gs.since,
+		}
+		if uptime > 0 {
+			out[idx].Utilization = float64(busy) / float64(uptime)
+		}
+
+		for tt, idxs := range g.assignment {
+			for _, i := range idxs {
+				if i == idx {
+					out[idx].Tasks = append(out[idx].Tasks, tt)
+					break
+				}
+			}
+		}
+		sort.Slice(out[idx].Tasks, func(i, j int) bool {
+			return out[idx].Tasks[i] < out[idx].Tasks[j]
+		})
+	}
+	return out
+}
+
+// gpuWork wraps work so that it runs with a GPU assigned to the task type.
+func (l *LocalWorker) gpuWork(tt sealtasks.TaskType, sector abi.SectorID, work func(ctx context.Context, ci storiface.CallID) (interface{}, error)) func(ctx context.Context, ci storiface.CallID) (interface{}, error) {
+	return func(ctx context.Context, ci storiface.CallID) (interface{}, error) {
+		release, err := l.gpus.acquire(ctx, tt, sector)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		return work(ctx, ci)
+	}
+}
+
+func (l *LocalWorker) GPUUsage(context.Context) ([]storiface.GPUUsage, error) {
+	return l.gpus.usage(), nil
+}
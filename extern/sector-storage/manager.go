@@ -1,6 +1,7 @@
 package sectorstorage
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
@@ -16,13 +17,16 @@ import (
 
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-statestore"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
 	"github.com/filecoin-project/specs-storage/storage"
 
 	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper"
+	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper/proverrpc"
 	"github.com/filecoin-project/lotus/extern/sector-storage/fsutil"
 	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
 	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
 	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+	"github.com/filecoin-project/lotus/lib/rpctls"
 )
 
 var log = logging.Logger("advmgr")
@@ -70,6 +74,13 @@ type Manager struct {
 	sched *scheduler
 
 	storage.Prover
+	gpus     *gpuAllocator // of the local worker, shared with proving
+	backends ffiwrapper.ProofBackends
+
+	svcLk sync.Mutex
+	svc   *serviceSealer
+
+	quotas *minerQuotas
 
 	workLk sync.Mutex
 	work   *statestore.StateStore
@@ -90,12 +101,39 @@ type result struct {
 type SealerConfig struct {
 	ParallelFetchLimit int
 
+	// ParallelUnsealsPerPath limits the number of sectors unsealed at once
+	// from sealed sectors stored in the same path, 0 for no limit. Further
+	// unseals wait in a queue.
+	ParallelUnsealsPerPath int
+
 	// Local worker config
 	AllowAddPiece   bool
 	AllowPreCommit1 bool
 	AllowPreCommit2 bool
 	AllowCommit     bool
 	AllowUnseal     bool
+
+	// GPUAssignment reserves GPUs of the miner process for task types, keyed
+	// by full or short task type names, e.g. {"WDP": [0], "C2": [1]}. Tasks of
+	// a type run one per reserved GPU at a time, but proofs aren't bound to
+	// the device; run separate processes with CUDA_VISIBLE_DEVICES set for
+	// that.
+	GPUAssignment map[string][]int
+
+	// ExternalProvers routes proofs to external prover daemons over gRPC,
+	// keyed by proof kind: "commit2", "aggregate", "winningpost" or
+	// "windowpost". Values are the addresses of the provers, e.g.
+	// {"commit2": "gpufarm:9900"}. Other kinds are computed in process.
+	// Provers are reached with the TLS certificates of the API, and
+	// ExternalProverToken.
+	//
+	// PoSt provers read the sector files at the paths they have on the miner,
+	// a prover shared by several miners must see the sectors of all of them
+	// at these paths.
+	ExternalProvers map[string]string
+
+	// ExternalProverToken is the token the external provers are started with
+	ExternalProverToken string
 }
 
 type StorageAuth http.Header
@@ -103,10 +141,24 @@ type StorageAuth http.Header
 type WorkerStateStore *statestore.StateStore
 type ManagerStateStore *statestore.StateStore
 
-func New(ctx context.Context, lstor *stores.Local, stor *stores.Remote, ls stores.LocalStorage, si stores.SectorIndex, sc SealerConfig, wss WorkerStateStore, mss ManagerStateStore) (*Manager, error) {
+// New returns the sector manager. certs, which may be nil, are presented to
+// the external provers.
+func New(ctx context.Context, lstor *stores.Local, stor *stores.Remote, ls stores.LocalStorage, si stores.SectorIndex, sc SealerConfig, wss WorkerStateStore, mss ManagerStateStore, certs *rpctls.Certs) (*Manager, error) {
+	gpus, err := ParseGPUAssignment(sc.GPUAssignment)
+	if err != nil {
+		return nil, xerrors.Errorf("parsing GPU assignment: %w", err)
+	}
 
-	prover, err := ffiwrapper.New(&readonlyProvider{stor: lstor, index: si})
+	backends, err := proverrpc.Backends(sc.ExternalProvers, proverrpc.Credentials{Certs: certs, Token: sc.ExternalProverToken})
 	if err != nil {
+		return nil, xerrors.Errorf("connecting external provers: %w", err)
+	}
+
+	// the store wrapped by remote storage; sectors are only proven from local
+	// storage, or the read cache of an object store
+	prover, err := ffiwrapper.New(&readonlyProvider{stor: stor.LocalStore(), index: si}, ffiwrapper.WithProofBackends(backends))
+	if err != nil {
+		_ = proverrpc.Close(backends)
 		return nil, xerrors.Errorf("creating prover instance: %w", err)
 	}
 
@@ -119,7 +171,10 @@ func New(ctx context.Context, lstor *stores.Local, stor *stores.Remote, ls store
 
 		sched: newScheduler(),
 
-		Prover: prover,
+		Prover:   prover,
+		backends: backends,
+
+		quotas: newMinerQuotas(),
 
 		work:       mss,
 		callToWork: map[storiface.CallID]WorkID{},
@@ -151,16 +206,32 @@ func New(ctx context.Context, lstor *stores.Local, stor *stores.Remote, ls store
 		localTasks = append(localTasks, sealtasks.TTUnseal)
 	}
 
-	err = m.AddWorker(ctx, NewLocalWorker(WorkerConfig{
-		TaskTypes: localTasks,
-	}, stor, lstor, si, m, wss))
+	lw := NewLocalWorker(WorkerConfig{
+		TaskTypes:     localTasks,
+		GPUAssignment: gpus,
+		ProofBackends: backends,
+	}, stor, lstor, si, m, wss)
+	m.gpus = lw.gpus
+
+	err = m.AddWorker(ctx, lw)
 	if err != nil {
+		_ = proverrpc.Close(backends)
 		return nil, xerrors.Errorf("adding local worker: %w", err)
 	}
 
 	return m, nil
 }
 
+// ProofProver returns the prover aggregating proofs with the external prover
+// configured for aggregation, or in process. The connection is closed with
+// the manager.
+func (m *Manager) ProofProver() ffiwrapper.Prover {
+	if b, ok := m.backends[ffiwrapper.ProofAggregate]; ok {
+		return ffiwrapper.BackendProver(b, ffiwrapper.ProofVerifier)
+	}
+	return ffiwrapper.ProofProver
+}
+
 func (m *Manager) AddLocalStorage(ctx context.Context, path string) error {
 	path, err := homedir.Expand(path)
 	if err != nil {
@@ -296,6 +367,19 @@ func (m *Manager) SealPreCommit1(ctx context.Context, sector storage.SectorRef,
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	svc := m.sealingService()
+	if ok, err := svc.delegated(sector.ID, true); err != nil {
+		return nil, xerrors.Errorf("checking sealing service: %w", err)
+	} else if ok {
+		return svc.preCommit1(ctx, m.index, sector, ticket, pieces)
+	}
+
+	release, err := m.quotas.acquire(ctx, sector.ID.Miner)
+	if err != nil {
+		return nil, xerrors.Errorf("waiting for miner sealing quota: %w", err)
+	}
+	defer release()
+
 	wk, wait, cancel, err := m.getWork(ctx, sealtasks.TTPreCommit1, sector, ticket, pieces)
 	if err != nil {
 		return nil, xerrors.Errorf("getWork: %w", err)
@@ -347,6 +431,23 @@ func (m *Manager) SealPreCommit2(ctx context.Context, sector storage.SectorRef,
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	svc := m.sealingService()
+	if ok, err := svc.delegated(sector.ID, false); err != nil {
+		return storage.SectorCids{}, xerrors.Errorf("checking sealing service: %w", err)
+	} else if ok {
+		return svc.preCommit2(ctx, sector)
+	}
+
+	if bytes.Equal(phase1Out, serviceSealedOut) {
+		return storage.SectorCids{}, xerrors.Errorf("sealing service failed sector %d after precommit1, precommit1 must be redone locally", sector.ID.Number)
+	}
+
+	release, err := m.quotas.acquire(ctx, sector.ID.Miner)
+	if err != nil {
+		return storage.SectorCids{}, xerrors.Errorf("waiting for miner sealing quota: %w", err)
+	}
+	defer release()
+
 	wk, wait, cancel, err := m.getWork(ctx, sealtasks.TTPreCommit2, sector, phase1Out)
 	if err != nil {
 		return storage.SectorCids{}, xerrors.Errorf("getWork: %w", err)
@@ -396,6 +497,13 @@ func (m *Manager) SealCommit1(ctx context.Context, sector storage.SectorRef, tic
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	svc := m.sealingService()
+	if ok, err := svc.delegated(sector.ID, false); err != nil {
+		return storage.Commit1Out{}, xerrors.Errorf("checking sealing service: %w", err)
+	} else if ok {
+		return svc.commit1(ctx, sector, seed)
+	}
+
 	wk, wait, cancel, err := m.getWork(ctx, sealtasks.TTCommit1, sector, ticket, seed, pieces, cids)
 	if err != nil {
 		return storage.Commit1Out{}, xerrors.Errorf("getWork: %w", err)
@@ -445,6 +553,19 @@ func (m *Manager) SealCommit1(ctx context.Context, sector storage.SectorRef, tic
 }
 
 func (m *Manager) SealCommit2(ctx context.Context, sector storage.SectorRef, phase1Out storage.Commit1Out) (out storage.Proof, err error) {
+	svc := m.sealingService()
+	if ok, err := svc.delegated(sector.ID, false); err != nil {
+		return storage.Proof{}, xerrors.Errorf("checking sealing service: %w", err)
+	} else if ok {
+		return svc.commit2(ctx, sector)
+	}
+
+	release, err := m.quotas.acquire(ctx, sector.ID.Miner)
+	if err != nil {
+		return storage.Proof{}, xerrors.Errorf("waiting for miner sealing quota: %w", err)
+	}
+	defer release()
+
 	wk, wait, cancel, err := m.getWork(ctx, sealtasks.TTCommit2, sector, phase1Out)
 	if err != nil {
 		return storage.Proof{}, xerrors.Errorf("getWork: %w", err)
@@ -495,6 +616,16 @@ func (m *Manager) FinalizeSector(ctx context.Context, sector storage.SectorRef,
 		return xerrors.Errorf("acquiring sector lock: %w", err)
 	}
 
+	// sectors sealed by the sealing service are finalized once fetched
+	svc := m.sealingService()
+	if ok, err := svc.delegated(sector.ID, false); err != nil {
+		return xerrors.Errorf("checking sealing service: %w", err)
+	} else if ok {
+		if err := svc.fetch(ctx, m, sector); err != nil {
+			return xerrors.Errorf("fetching sector from the sealing service: %w", err)
+		}
+	}
+
 	unsealed := storiface.FTUnsealed
 	{
 		unsealedStores, err := m.index.StorageFindSector(ctx, sector.ID, storiface.FTUnsealed, 0, false)
@@ -555,6 +686,9 @@ func (m *Manager) Remove(ctx context.Context, sector storage.SectorRef) error {
 
 	var err error
 
+	if rerr := m.sealingService().release(ctx, sector.ID); rerr != nil {
+		err = multierror.Append(err, xerrors.Errorf("removing sector (sealing service): %w", rerr))
+	}
 	if rerr := m.storage.Remove(ctx, sector.ID, storiface.FTSealed, true); rerr != nil {
 		err = multierror.Append(err, xerrors.Errorf("removing sector (sealed): %w", rerr))
 	}
@@ -626,10 +760,37 @@ func (m *Manager) StorageLocal(ctx context.Context) (map[stores.ID]string, error
 	return out, nil
 }
 
+func (m *Manager) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof5.SectorInfo, randomness abi.PoStRandomness) ([]proof5.PoStProof, error) {
+	release, err := m.gpus.acquire(ctx, sealtasks.TTGenerateWinningPoSt, abi.SectorID{Miner: minerID})
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return m.Prover.GenerateWinningPoSt(ctx, minerID, sectorInfo, randomness)
+}
+
+func (m *Manager) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof5.SectorInfo, randomness abi.PoStRandomness) ([]proof5.PoStProof, []abi.SectorID, error) {
+	release, err := m.gpus.acquire(ctx, sealtasks.TTGenerateWindowPoSt, abi.SectorID{Miner: minerID})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	return m.Prover.GenerateWindowPoSt(ctx, minerID, sectorInfo, randomness)
+}
+
 func (m *Manager) FsStat(ctx context.Context, id stores.ID) (fsutil.FsStat, error) {
 	return m.storage.FsStat(ctx, id)
 }
 
+// SetDispatchPaused stops or resumes dispatching sealing tasks to workers.
+// Tasks already running on workers aren't affected, new tasks stay queued
+// until scheduling is resumed.
+func (m *Manager) SetDispatchPaused(paused bool) {
+	m.sched.setPaused(paused)
+}
+
 func (m *Manager) SchedDiag(ctx context.Context, doSched bool) (interface{}, error) {
 	if doSched {
 		select {
@@ -684,6 +845,9 @@ func (m *Manager) SchedDiag(ctx context.Context, doSched bool) (interface{}, err
 }
 
 func (m *Manager) Close(ctx context.Context) error {
+	if err := proverrpc.Close(m.backends); err != nil {
+		log.Warnf("closing external prover connections: %+v", err)
+	}
 	return m.sched.Close(ctx)
 }
 
@@ -381,6 +381,64 @@ func TestRestartWorker(t *testing.T) {
 	require.Empty(t, uf)
 }
 
+func TestWorkerRunningTasks(t *testing.T) {
+	logging.SetAllLoggers(logging.LevelDebug)
+
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+
+	ds := datastore.NewMapDatastore()
+
+	m, lstor, stor, idx, cleanup := newTestMgr(ctx, t, ds)
+	defer cleanup()
+
+	localTasks := []sealtasks.TaskType{
+		sealtasks.TTAddPiece, sealtasks.TTPreCommit1, sealtasks.TTCommit1, sealtasks.TTFinalize, sealtasks.TTFetch,
+	}
+
+	wds := datastore.NewMapDatastore()
+
+	arch := make(chan chan apres)
+	w := newLocalWorker(func() (ffiwrapper.Storage, error) {
+		return &testExec{apch: arch}, nil
+	}, WorkerConfig{
+		TaskTypes: localTasks,
+	}, stor, lstor, idx, m, statestore.New(wds))
+
+	err := m.AddWorker(ctx, w)
+	require.NoError(t, err)
+
+	sid := storage.SectorRef{
+		ID:        abi.SectorID{Miner: 1000, Number: 1},
+		ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1,
+	}
+
+	apDone := make(chan struct{})
+
+	go func() {
+		defer close(apDone)
+
+		_, err := m.AddPiece(ctx, sid, nil, 1016, strings.NewReader(strings.Repeat("testthis", 127)))
+		require.Error(t, err)
+	}()
+
+	resp := <-arch
+
+	running, err := w.RunningTasks(ctx)
+	require.NoError(t, err)
+	require.Len(t, running, 1)
+	require.Equal(t, sid.ID, running[0].Sector)
+	require.Equal(t, sealtasks.TTAddPiece, running[0].Task)
+
+	resp <- apres{err: fmt.Errorf("test error")}
+	<-apDone
+
+	w.WaitQuiet()
+	running, err = w.RunningTasks(ctx)
+	require.NoError(t, err)
+	require.Empty(t, running)
+}
+
 func TestReenableWorker(t *testing.T) {
 	logging.SetAllLoggers(logging.LevelDebug)
 	stores.HeartbeatInterval = 5 * time.Millisecond
@@ -0,0 +1,92 @@
+package sectorstorage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// minerQuotas limits the number of sealing tasks each miner actor can have
+// in the shared worker pool at once, so that miners sealing with the same
+// workers can't starve each other.
+type minerQuotas struct {
+	lk      sync.Mutex
+	limits  map[abi.ActorID]int // miners without a limit aren't limited
+	running map[abi.ActorID]int
+
+	// closed and replaced every time a task finishes
+	freed chan struct{}
+}
+
+func newMinerQuotas() *minerQuotas {
+	return &minerQuotas{
+		limits:  map[abi.ActorID]int{},
+		running: map[abi.ActorID]int{},
+		freed:   make(chan struct{}),
+	}
+}
+
+func (q *minerQuotas) setLimit(miner abi.ActorID, limit int) {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+
+	if limit <= 0 {
+		delete(q.limits, miner)
+	} else {
+		q.limits[miner] = limit
+	}
+
+	// let waiters re-check against the new limit
+	close(q.freed)
+	q.freed = make(chan struct{})
+}
+
+// acquire waits until the miner is under its quota, and counts a task
+// against it until release is called
+func (q *minerQuotas) acquire(ctx context.Context, miner abi.ActorID) (release func(), err error) {
+	if q == nil {
+		return func() {}, nil
+	}
+
+	for {
+		q.lk.Lock()
+		limit, limited := q.limits[miner]
+		if !limited || q.running[miner] < limit {
+			q.running[miner]++
+			q.lk.Unlock()
+			break
+		}
+		freed := q.freed
+		q.lk.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.lk.Lock()
+			defer q.lk.Unlock()
+
+			q.running[miner]--
+			if q.running[miner] == 0 {
+				delete(q.running, miner)
+			}
+
+			close(q.freed)
+			q.freed = make(chan struct{})
+		})
+	}, nil
+}
+
+// SetMinerQuota limits the number of PreCommit1, PreCommit2 and Commit2 tasks
+// of the miner scheduled or running at once; 0 removes the limit. Sectors
+// sealed by a sealing service don't count against the quota.
+func (m *Manager) SetMinerQuota(miner abi.ActorID, tasks int) {
+	m.quotas.setLimit(miner, tasks)
+}
@@ -0,0 +1,74 @@
+package sectorstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinerQuotas(t *testing.T) {
+	ctx := context.Background()
+	q := newMinerQuotas()
+	q.setLimit(1000, 2)
+
+	r1, err := q.acquire(ctx, 1000)
+	require.NoError(t, err)
+	r2, err := q.acquire(ctx, 1000)
+	require.NoError(t, err)
+
+	// other miners aren't limited by the quota
+	for i := 0; i < 5; i++ {
+		_, err := q.acquire(ctx, 1001)
+		require.NoError(t, err)
+	}
+
+	// over quota
+	tctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	_, err = q.acquire(tctx, 1000)
+	cancel()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	acquired := make(chan struct{})
+	go func() {
+		r3, err := q.acquire(ctx, 1000)
+		require.NoError(t, err)
+		close(acquired)
+		r3()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired over quota")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	r1()
+	r1() // releasing twice doesn't free more than one task
+	<-acquired
+
+	r2()
+
+	q.lk.Lock()
+	require.Equal(t, 0, q.running[1000])
+	q.lk.Unlock()
+
+	// raising the limit wakes waiters
+	r1, err = q.acquire(ctx, 1000)
+	require.NoError(t, err)
+	r2, err = q.acquire(ctx, 1000)
+	require.NoError(t, err)
+
+	acquired = make(chan struct{})
+	go func() {
+		_, err := q.acquire(ctx, 1000)
+		require.NoError(t, err)
+		close(acquired)
+	}()
+
+	q.setLimit(1000, 3)
+	<-acquired
+	r1()
+	r2()
+}
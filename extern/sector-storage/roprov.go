@@ -13,7 +13,7 @@ import (
 
 type readonlyProvider struct {
 	index stores.SectorIndex
-	stor  *stores.Local
+	stor  stores.Store
 }
 
 func (l *readonlyProvider) AcquireSector(ctx context.Context, id storage.SectorRef, existing storiface.SectorFileType, allocate storiface.SectorFileType, sealing storiface.PathType) (storiface.SectorPaths, func(), error) {
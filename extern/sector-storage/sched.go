@@ -5,6 +5,7 @@ import (
 	"math/rand"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -28,6 +29,17 @@ var (
 	SchedWindows = 2
 )
 
+// pausableTasks aren't dispatched to workers while scheduling is paused;
+// finalize, fetch and unseal keep running so that in-flight sectors can be
+// stored and retrievals served
+var pausableTasks = map[sealtasks.TaskType]struct{}{
+	sealtasks.TTAddPiece:   {},
+	sealtasks.TTPreCommit1: {},
+	sealtasks.TTPreCommit2: {},
+	sealtasks.TTCommit1:    {},
+	sealtasks.TTCommit2:    {},
+}
+
 func getPriority(ctx context.Context) int {
 	sp := ctx.Value(SchedPriorityKey)
 	if p, ok := sp.(int); ok {
@@ -66,6 +78,8 @@ type scheduler struct {
 
 	workTracker *workTracker
 
+	paused int32 // atomic; see pausableTasks
+
 	info chan func(interface{})
 
 	closing  chan struct{}
@@ -117,6 +131,9 @@ type activeResources struct {
 	gpuUsed    bool
 	cpuUse     uint64
 
+	// task types using each GPU, when GPUs are assigned to tasks
+	gpus []sealtasks.TaskType
+
 	cond *sync.Cond
 }
 
@@ -328,6 +345,19 @@ func (sh *scheduler) diag() SchedDiagInfo {
 	return out
 }
 
+func (sh *scheduler) setPaused(paused bool) {
+	var v int32
+	if paused {
+		v = 1
+	}
+	atomic.StoreInt32(&sh.paused, v)
+
+	select {
+	case sh.workerChange <- struct{}{}:
+	default: // workerChange is buffered, and scheduling is global, so it's ok if we don't send here
+	}
+}
+
 func (sh *scheduler) trySched() {
 	/*
 		This assigns tasks to workers based on:
@@ -376,9 +406,13 @@ func (sh *scheduler) trySched() {
 			}()
 
 			task := (*sh.schedQueue)[sqi]
-			needRes := ResourceTable[task.taskType][task.sector.ProofType]
 
 			task.indexHeap = sqi
+
+			if _, ok := pausableTasks[task.taskType]; ok && atomic.LoadInt32(&sh.paused) == 1 {
+				return
+			}
+
 			for wnd, windowRequest := range sh.openWindows {
 				worker, ok := sh.workers[windowRequest.worker]
 				if !ok {
@@ -392,8 +426,10 @@ func (sh *scheduler) trySched() {
 					continue
 				}
 
+				needRes := worker.taskResources(task.taskType, task.sector.ProofType)
+
 				// TODO: allow bigger windows
-				if !windows[wnd].allocated.canHandleRequest(needRes, windowRequest.worker, "schedAcceptable", worker.info.Resources) {
+				if !windows[wnd].allocated.canHandleRequest(task.taskType, needRes, windowRequest.worker, "schedAcceptable", worker.info.Resources) {
 					continue
 				}
 
@@ -416,6 +452,18 @@ func (sh *scheduler) trySched() {
 				return
 			}
 
+			// predicted finish times of calibrated workers
+			finish := map[WorkerID]time.Duration{}
+			for _, wnd := range acceptableWindows[sqi] {
+				wid := sh.openWindows[wnd].worker
+				if _, ok := finish[wid]; ok {
+					continue
+				}
+				if d, ok := sh.predictFinish(wid, sh.workers[wid], task.taskType, task.sector.ProofType); ok {
+					finish[wid] = d
+				}
+			}
+
 			// Pick best worker (shuffle in case some workers are equally as good)
 			rand.Shuffle(len(acceptableWindows[sqi]), func(i, j int) {
 				acceptableWindows[sqi][i], acceptableWindows[sqi][j] = acceptableWindows[sqi][j], acceptableWindows[sqi][i] // nolint:scopelint
@@ -429,6 +477,14 @@ func (sh *scheduler) trySched() {
 					return acceptableWindows[sqi][i] < acceptableWindows[sqi][j] // nolint:scopelint
 				}
 
+				// prefer the worker predicted to finish first when both were
+				// calibrated for the task
+				fi, iok := finish[wii]
+				fj, jok := finish[wji]
+				if iok && jok && fi != fj {
+					return fi < fj
+				}
+
 				wi := sh.workers[wii]
 				wj := sh.workers[wji]
 
@@ -455,23 +511,23 @@ func (sh *scheduler) trySched() {
 
 	for sqi := 0; sqi < queuneLen; sqi++ {
 		task := (*sh.schedQueue)[sqi]
-		needRes := ResourceTable[task.taskType][task.sector.ProofType]
 
 		selectedWindow := -1
 		for _, wnd := range acceptableWindows[task.indexHeap] {
 			wid := sh.openWindows[wnd].worker
 			wr := sh.workers[wid].info.Resources
+			needRes := sh.workers[wid].taskResources(task.taskType, task.sector.ProofType)
 
 			log.Debugf("SCHED try assign sqi:%d sector %d to window %d", sqi, task.sector.ID.Number, wnd)
 
 			// TODO: allow bigger windows
-			if !windows[wnd].allocated.canHandleRequest(needRes, wid, "schedAssign", wr) {
+			if !windows[wnd].allocated.canHandleRequest(task.taskType, needRes, wid, "schedAssign", wr) {
 				continue
 			}
 
 			log.Debugf("SCHED ASSIGNED sqi:%d sector %d task %s to window %d", sqi, task.sector.ID.Number, task.taskType, wnd)
 
-			windows[wnd].allocated.add(wr, needRes)
+			windows[wnd].allocated.add(task.taskType, wr, needRes)
 			// TODO: We probably want to re-sort acceptableWindows here based on new
 			//  workerHandle.utilization + windows[wnd].allocated.utilization (workerHandle.utilization is used in all
 			//  task selectors, but not in the same way, so need to figure out how to do that in a non-O(n^2 way), and
@@ -3,22 +3,23 @@ package sectorstorage
 import (
 	"sync"
 
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
 	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
 )
 
-func (a *activeResources) withResources(id WorkerID, wr storiface.WorkerResources, r Resources, locker sync.Locker, cb func() error) error {
-	for !a.canHandleRequest(r, id, "withResources", wr) {
+func (a *activeResources) withResources(tt sealtasks.TaskType, id WorkerID, wr storiface.WorkerResources, r Resources, locker sync.Locker, cb func() error) error {
+	for !a.canHandleRequest(tt, r, id, "withResources", wr) {
 		if a.cond == nil {
 			a.cond = sync.NewCond(locker)
 		}
 		a.cond.Wait()
 	}
 
-	a.add(wr, r)
+	a.add(tt, wr, r)
 
 	err := cb()
 
-	a.free(wr, r)
+	a.free(tt, wr, r)
 	if a.cond != nil {
 		a.cond.Broadcast()
 	}
@@ -26,25 +27,74 @@ func (a *activeResources) withResources(id WorkerID, wr storiface.WorkerResource
 	return err
 }
 
-func (a *activeResources) add(wr storiface.WorkerResources, r Resources) {
+func (a *activeResources) add(tt sealtasks.TaskType, wr storiface.WorkerResources, r Resources) {
 	if r.CanGPU {
-		a.gpuUsed = true
+		a.useGPU(tt, wr)
 	}
 	a.cpuUse += r.Threads(wr.CPUs)
 	a.memUsedMin += r.MinMemory
 	a.memUsedMax += r.MaxMemory
 }
 
-func (a *activeResources) free(wr storiface.WorkerResources, r Resources) {
+func (a *activeResources) free(tt sealtasks.TaskType, wr storiface.WorkerResources, r Resources) {
 	if r.CanGPU {
-		a.gpuUsed = false
+		a.releaseGPU(tt, wr)
 	}
 	a.cpuUse -= r.Threads(wr.CPUs)
 	a.memUsedMin -= r.MinMemory
 	a.memUsedMax -= r.MaxMemory
 }
 
-func (a *activeResources) canHandleRequest(needRes Resources, wid WorkerID, caller string, res storiface.WorkerResources) bool {
+// findGPU returns the index of a free GPU which can run the task type, -1 when
+// all of them are in use.
+func (a *activeResources) findGPU(tt sealtasks.TaskType, wr storiface.WorkerResources) int {
+	for _, idx := range wr.GPUsFor(tt) {
+		if idx >= len(a.gpus) || a.gpus[idx] == "" {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (a *activeResources) useGPU(tt sealtasks.TaskType, wr storiface.WorkerResources) {
+	if len(wr.GPUAssignment) == 0 {
+		// all GPUs are shared by all tasks
+		a.gpuUsed = true
+		return
+	}
+
+	idx := a.findGPU(tt, wr)
+	if idx < 0 {
+		return
+	}
+	for len(a.gpus) <= idx {
+		a.gpus = append(a.gpus, "")
+	}
+	a.gpus[idx] = tt
+	a.gpuUsed = true
+}
+
+func (a *activeResources) releaseGPU(tt sealtasks.TaskType, wr storiface.WorkerResources) {
+	if len(wr.GPUAssignment) == 0 {
+		a.gpuUsed = false
+		return
+	}
+
+	idxs := wr.GPUsFor(tt)
+	for i := len(idxs) - 1; i >= 0; i-- {
+		if idx := idxs[i]; idx < len(a.gpus) && a.gpus[idx] == tt {
+			a.gpus[idx] = ""
+			break
+		}
+	}
+
+	a.gpuUsed = false
+	for _, t := range a.gpus {
+		a.gpuUsed = a.gpuUsed || t != ""
+	}
+}
+
+func (a *activeResources) canHandleRequest(tt sealtasks.TaskType, needRes Resources, wid WorkerID, caller string, res storiface.WorkerResources) bool {
 
 	// TODO: dedupe needRes.BaseMinMemory per task type (don't add if that task is already running)
 	minNeedMem := res.MemReserved + a.memUsedMin + needRes.MinMemory + needRes.BaseMinMemory
@@ -66,7 +116,13 @@ func (a *activeResources) canHandleRequest(needRes Resources, wid WorkerID, call
 	}
 
 	if len(res.GPUs) > 0 && needRes.CanGPU {
-		if a.gpuUsed {
+		if len(res.GPUAssignment) > 0 {
+			// tasks without GPUs they can use run on the CPU
+			if len(res.GPUsFor(tt)) > 0 && a.findGPU(tt, res) < 0 {
+				log.Debugf("sched: not scheduling on worker %s for %s; GPUs assigned to %s in use", wid, caller, tt.Short())
+				return false
+			}
+		} else if a.gpuUsed {
 			log.Debugf("sched: not scheduling on worker %s for %s; GPU in use", wid, caller)
 			return false
 		}
@@ -400,6 +400,24 @@ func TestSched(t *testing.T) {
 		taskDone("t4"),
 	}))
 
+	setPaused := func(paused bool) task {
+		return func(t *testing.T, sched *scheduler, index *stores.Index, rm *runMeta) {
+			sched.setPaused(paused)
+		}
+	}
+
+	t.Run("paused-pc1", testFunc([]workerSpec{
+		{name: "fred", taskTypes: map[sealtasks.TaskType]struct{}{sealtasks.TTPreCommit1: {}}},
+	}, []task{
+		setPaused(true),
+
+		sched("pc1", "fred", 8, sealtasks.TTPreCommit1),
+		taskNotScheduled("pc1"),
+
+		setPaused(false),
+		taskDone("pc1"),
+	}))
+
 	twoPC1 := func(prefix string, sid abi.SectorNumber, schedAssert func(name string) task) task {
 		return multTask(
 			sched(prefix+"-a", "fred", sid, sealtasks.TTPreCommit1),
@@ -589,7 +607,7 @@ func TestWindowCompact(t *testing.T) {
 						taskType: task,
 						sector:   storage.SectorRef{ProofType: spt},
 					})
-					window.allocated.add(wh.info.Resources, ResourceTable[task][spt])
+					window.allocated.add(task, wh.info.Resources, ResourceTable[task][spt])
 				}
 
 				wh.activeWindows = append(wh.activeWindows, window)
@@ -608,7 +626,7 @@ func TestWindowCompact(t *testing.T) {
 
 				for ti, task := range tasks {
 					require.Equal(t, task, wh.activeWindows[wi].todo[ti].taskType, "%d, %d", wi, ti)
-					expectRes.add(wh.info.Resources, ResourceTable[task][spt])
+					expectRes.add(task, wh.info.Resources, ResourceTable[task][spt])
 				}
 
 				require.Equal(t, expectRes.cpuUse, wh.activeWindows[wi].allocated.cpuUse, "%d", wi)
@@ -645,3 +663,66 @@ func TestWindowCompact(t *testing.T) {
 		[][]sealtasks.TaskType{{sealtasks.TTPreCommit1, sealtasks.TTPreCommit1, sealtasks.TTAddPiece}, {sealtasks.TTPreCommit1, sealtasks.TTPreCommit2}}),
 	)
 }
+
+func TestSchedGPUAssignment(t *testing.T) {
+	spt := abi.RegisteredSealProof_StackedDrg2KiBV1
+
+	wr := decentWorkerResources
+	wr.GPUs = []string{"gpu0", "gpu1", "gpu2"}
+	wr.GPUAssignment = map[sealtasks.TaskType][]int{
+		sealtasks.TTCommit2:            {0, 1},
+		sealtasks.TTGenerateWindowPoSt: {2},
+	}
+
+	require.Equal(t, []int{0, 1}, wr.GPUsFor(sealtasks.TTCommit2))
+	require.Empty(t, wr.GPUsFor(sealtasks.TTPreCommit2))
+
+	var a activeResources
+	c2 := ResourceTable[sealtasks.TTCommit2][spt]
+
+	require.True(t, a.canHandleRequest(sealtasks.TTCommit2, c2, WorkerID{}, "test", wr))
+	a.add(sealtasks.TTCommit2, wr, c2)
+	require.True(t, a.canHandleRequest(sealtasks.TTCommit2, c2, WorkerID{}, "test", wr))
+	a.add(sealtasks.TTCommit2, wr, c2)
+
+	// both GPUs assigned to C2 are in use
+	require.False(t, a.canHandleRequest(sealtasks.TTCommit2, c2, WorkerID{}, "test", wr))
+	require.Equal(t, []sealtasks.TaskType{sealtasks.TTCommit2, sealtasks.TTCommit2}, a.gpus)
+
+	// PC2 has no GPUs to use, it runs on the CPU
+	require.True(t, a.canHandleRequest(sealtasks.TTPreCommit2, ResourceTable[sealtasks.TTPreCommit2][spt], WorkerID{}, "test", wr))
+
+	a.free(sealtasks.TTCommit2, wr, c2)
+	require.True(t, a.gpuUsed)
+	require.True(t, a.canHandleRequest(sealtasks.TTCommit2, c2, WorkerID{}, "test", wr))
+
+	a.free(sealtasks.TTCommit2, wr, c2)
+	require.False(t, a.gpuUsed)
+}
+
+func TestCalibratedResources(t *testing.T) {
+	wh := &workerHandle{
+		info: storiface.WorkerInfo{
+			Calibration: []storiface.TaskCalibration{{
+				Task:       sealtasks.TTPreCommit2,
+				SealProof:  abi.RegisteredSealProof_StackedDrg512MiBV1,
+				Duration:   time.Minute,
+				PeakMemory: 3 << 30,
+			}},
+		},
+	}
+
+	res := wh.taskResources(sealtasks.TTPreCommit2, abi.RegisteredSealProof_StackedDrg512MiBV1)
+	require.Equal(t, uint64(3<<30)-res.BaseMinMemory, res.MinMemory)
+
+	// measured with another seal proof, only durations are used
+	require.Equal(t, ResourceTable[sealtasks.TTPreCommit2][abi.RegisteredSealProof_StackedDrg32GiBV1],
+		wh.taskResources(sealtasks.TTPreCommit2, abi.RegisteredSealProof_StackedDrg32GiBV1))
+
+	d, ok := wh.predictDuration(sealtasks.TTPreCommit2, abi.RegisteredSealProof_StackedDrg32GiBV1)
+	require.True(t, ok)
+	require.Equal(t, 64*time.Minute, d)
+
+	_, ok = wh.predictDuration(sealtasks.TTCommit2, abi.RegisteredSealProof_StackedDrg32GiBV1)
+	require.False(t, ok)
+}
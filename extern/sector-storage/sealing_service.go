@@ -0,0 +1,481 @@
+package sectorstorage
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-statestore"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
+	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+	"github.com/filecoin-project/lotus/extern/sector-storage/tarutil"
+)
+
+// SealingService is an external provider sealing whole sectors, PC1 through
+// C2. Sealing is split at the commit seed: SealPreCommit runs PC1 and PC2,
+// SealCommit runs C1 and C2 once the seed is known. The miner then fetches the
+// replica and releases the sector.
+//
+// Requests for sectors which are already being sealed must be no-ops, so that
+// the miner can retry them safely.
+type SealingService interface {
+	SealPreCommit(ctx context.Context, req storiface.SealPreCommitRequest) error
+	SealCommit(ctx context.Context, sector abi.SectorID, seed abi.InteractiveSealRandomness) error
+	SealStatus(ctx context.Context, sector abi.SectorID) (storiface.SealJob, error)
+	SealRelease(ctx context.Context, sector abi.SectorID) error
+}
+
+type SealingServiceOpts struct {
+	// MaxSectors is how many sectors the service seals at once, 0 for no limit
+	MaxSectors int
+
+	// URLSigner signs the URLs the service fetches unsealed sectors from.
+	// Signed URLs only grant reading the sector they point to, until they
+	// expire after SealingServiceURLExpiry.
+	URLSigner *stores.URLSigner
+
+	// ServiceAuth is sent by the miner to fetch replicas from the service
+	ServiceAuth http.Header
+
+	// ReceiptKeys are the keys the service signs work receipts with. When
+	// set, the service must return a receipt signed by one of them for each
+	// sector it seals; otherwise receipts are checked and kept when the
+	// service returns them.
+	ReceiptKeys []ed25519.PublicKey
+}
+
+var SealingServicePollInterval = 30 * time.Second
+
+// SealingServiceURLExpiry is how long the service can fetch the unsealed
+// sector for after it's delegated.
+var SealingServiceURLExpiry = 48 * time.Hour
+
+var errSealJobFailed = xerrors.New("sealing job failed")
+
+// Outputs of PC1 and C1 stay with the service; the placeholder is passed
+// between sealing steps instead.
+var serviceSealedOut = []byte("sealing-service")
+
+type serviceSealer struct {
+	svc  SealingService
+	opts SealingServiceOpts
+
+	lk      sync.Mutex
+	sectors *statestore.StateStore // abi.SectorID of delegated sectors
+	local   *statestore.StateStore // abi.SectorID of sectors the service failed to precommit
+
+	receipts datastore.Batching
+}
+
+// SetSealingService makes the manager delegate sealing of new sectors to a
+// sealing service. Delegated sectors are tracked in ds, so that they are
+// still sealed by the service after a restart. Sectors the service fails
+// before they are precommitted are released and sealed locally from then on,
+// they are tracked in local. Work receipts returned by the service are kept
+// in receipts, after the sectors are released.
+//
+// Once the service precommitted a sector, only it has the replica, so the
+// sector stays with the service when its commit fails, and is retried there.
+func (m *Manager) SetSealingService(svc SealingService, opts SealingServiceOpts, ds, local, receipts datastore.Batching) {
+	m.svcLk.Lock()
+	defer m.svcLk.Unlock()
+
+	m.svc = &serviceSealer{
+		svc:      svc,
+		opts:     opts,
+		sectors:  statestore.New(ds),
+		local:    statestore.New(local),
+		receipts: receipts,
+	}
+}
+
+func (m *Manager) sealingService() *serviceSealer {
+	m.svcLk.Lock()
+	defer m.svcLk.Unlock()
+
+	return m.svc
+}
+
+// delegated returns whether the service seals the sector; with assign set,
+// new sectors are assigned to the service when it has capacity.
+func (s *serviceSealer) delegated(sid abi.SectorID, assign bool) (bool, error) {
+	if s == nil {
+		return false, nil
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	has, err := s.sectors.Has(storiface.SectorName(sid))
+	if err != nil || has || !assign {
+		return has, err
+	}
+
+	if local, err := s.local.Has(storiface.SectorName(sid)); err != nil || local {
+		return false, err
+	}
+
+	if s.opts.MaxSectors > 0 {
+		var all []abi.SectorID
+		if err := s.sectors.List(&all); err != nil {
+			return false, err
+		}
+		if len(all) >= s.opts.MaxSectors {
+			return false, nil
+		}
+	}
+
+	log.Infow("delegating sector sealing to the sealing service", "sector", sid)
+	return true, s.sectors.Begin(storiface.SectorName(sid), &sid)
+}
+
+func (s *serviceSealer) forget(sid abi.SectorID) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	return s.sectors.Get(storiface.SectorName(sid)).End()
+}
+
+// abandon releases a sector the service failed to precommit, and keeps it
+// from being delegated again, so that it's sealed locally when retried.
+func (s *serviceSealer) abandon(ctx context.Context, sid abi.SectorID) error {
+	log.Warnw("sealing service failed sector, sealing it locally", "sector", sid)
+
+	if err := s.svc.SealRelease(ctx, sid); err != nil {
+		log.Warnf("releasing sector %d on the sealing service: %+v", sid.Number, err)
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if err := s.local.Begin(storiface.SectorName(sid), &sid); err != nil {
+		return xerrors.Errorf("tracking locally sealed sector: %w", err)
+	}
+	return s.sectors.Get(storiface.SectorName(sid)).End()
+}
+
+// precommitFailed abandons the sector when the service reports err as a
+// failure of the sealing job.
+func (s *serviceSealer) precommitFailed(ctx context.Context, sid abi.SectorID, err error) error {
+	if xerrors.Is(err, errSealJobFailed) {
+		if aerr := s.abandon(ctx, sid); aerr != nil {
+			log.Errorf("abandoning sector %d: %+v", sid.Number, aerr)
+		}
+	}
+	return err
+}
+
+// wait polls the service until the sector sealing reaches the given state.
+func (s *serviceSealer) wait(ctx context.Context, sid abi.SectorID, state storiface.SealJobState) (storiface.SealJob, error) {
+	for {
+		job, err := s.svc.SealStatus(ctx, sid)
+		switch {
+		case err != nil:
+			log.Warnf("getting sealing service status of sector %d: %+v", sid.Number, err)
+		case job.State == storiface.SealJobFailed:
+			return job, xerrors.Errorf("sealing service: %s: %w", job.Err, errSealJobFailed)
+		case job.State.Reached(state):
+			return job, nil
+		}
+
+		select {
+		case <-time.After(SealingServicePollInterval):
+		case <-ctx.Done():
+			return storiface.SealJob{}, ctx.Err()
+		}
+	}
+}
+
+func (s *serviceSealer) preCommit1(ctx context.Context, index stores.SectorIndex, sector storage.SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) (storage.PreCommit1Out, error) {
+	ssize, err := sector.ProofType.SectorSize()
+	if err != nil {
+		return nil, err
+	}
+
+	unsealed, err := index.StorageFindSector(ctx, sector.ID, storiface.FTUnsealed, ssize, false)
+	if err != nil {
+		return nil, xerrors.Errorf("finding unsealed sector: %w", err)
+	}
+
+	if s.opts.URLSigner == nil {
+		return nil, xerrors.Errorf("no URL signer for the sealing service")
+	}
+
+	req := storiface.SealPreCommitRequest{
+		Sector: sector,
+		Ticket: ticket,
+		Pieces: pieces,
+	}
+	expires := time.Now().Add(SealingServiceURLExpiry)
+	for _, info := range unsealed {
+		for _, u := range info.URLs {
+			su, err := s.opts.URLSigner.Sign(u, expires)
+			if err != nil {
+				return nil, xerrors.Errorf("signing unsealed sector URL: %w", err)
+			}
+			req.UnsealedURLs = append(req.UnsealedURLs, su)
+		}
+	}
+	if len(req.UnsealedURLs) == 0 {
+		return nil, xerrors.Errorf("no URLs to fetch the unsealed sector from")
+	}
+
+	if err := s.svc.SealPreCommit(ctx, req); err != nil {
+		return nil, xerrors.Errorf("sealing service precommit: %w", err)
+	}
+
+	if _, err := s.wait(ctx, sector.ID, storiface.SealJobPreCommit2); err != nil {
+		return nil, s.precommitFailed(ctx, sector.ID, err)
+	}
+	return serviceSealedOut, nil
+}
+
+func (s *serviceSealer) preCommit2(ctx context.Context, sector storage.SectorRef) (storage.SectorCids, error) {
+	job, err := s.wait(ctx, sector.ID, storiface.SealJobPreCommitted)
+	if err != nil {
+		return storage.SectorCids{}, s.precommitFailed(ctx, sector.ID, err)
+	}
+	if job.Cids == nil {
+		return storage.SectorCids{}, xerrors.Errorf("sealing service didn't return sector CIDs")
+	}
+	if err := s.checkReceipt(job, sealtasks.TTPreCommit2); err != nil {
+		return storage.SectorCids{}, err
+	}
+	return *job.Cids, nil
+}
+
+func (s *serviceSealer) commit1(ctx context.Context, sector storage.SectorRef, seed abi.InteractiveSealRandomness) (storage.Commit1Out, error) {
+	if err := s.svc.SealCommit(ctx, sector.ID, seed); err != nil {
+		return nil, xerrors.Errorf("sealing service commit: %w", err)
+	}
+
+	if _, err := s.wait(ctx, sector.ID, storiface.SealJobCommit2); err != nil {
+		return nil, err
+	}
+	return serviceSealedOut, nil
+}
+
+func (s *serviceSealer) commit2(ctx context.Context, sector storage.SectorRef) (storage.Proof, error) {
+	job, err := s.wait(ctx, sector.ID, storiface.SealJobCommitted)
+	if err != nil {
+		return nil, err
+	}
+	if len(job.Proof) == 0 {
+		return nil, xerrors.Errorf("sealing service didn't return a proof")
+	}
+	if err := s.checkReceipt(job, sealtasks.TTCommit2); err != nil {
+		return nil, err
+	}
+	return job.Proof, nil
+}
+
+// checkReceipt verifies the receipt of the job for the task and stores it.
+// Outputs with an invalid receipt, or without a receipt when receipt keys are
+// configured, are rejected.
+func (s *serviceSealer) checkReceipt(job storiface.SealJob, task sealtasks.TaskType) error {
+	var commr, commd *cid.Cid
+	if job.Cids != nil {
+		commr, commd = &job.Cids.Sealed, &job.Cids.Unsealed
+	}
+
+	var receipt *storiface.WorkReceipt
+	for i := range job.Receipts {
+		if job.Receipts[i].Task == task {
+			receipt = &job.Receipts[i]
+			break
+		}
+	}
+
+	if receipt == nil {
+		if len(s.opts.ReceiptKeys) > 0 {
+			return xerrors.Errorf("sealing service didn't return a %s receipt", task)
+		}
+		return nil
+	}
+
+	if err := receipt.Covers(job.Sector, task, commr, commd, job.Proof); err != nil {
+		return xerrors.Errorf("sealing service %s receipt: %w", task, err)
+	}
+	if err := receipt.Verify(); err != nil {
+		return xerrors.Errorf("sealing service %s receipt: %w", task, err)
+	}
+	if len(s.opts.ReceiptKeys) > 0 {
+		var trusted bool
+		for _, k := range s.opts.ReceiptKeys {
+			if k.Equal(ed25519.PublicKey(receipt.PublicKey)) {
+				trusted = true
+				break
+			}
+		}
+		if !trusted {
+			return xerrors.Errorf("sealing service %s receipt is signed with an untrusted key %x", task, receipt.PublicKey)
+		}
+	}
+
+	if s.receipts == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(receipt)
+	if err != nil {
+		return xerrors.Errorf("encoding receipt: %w", err)
+	}
+	if err := s.receipts.Put(receiptKey(job.Sector, task), b); err != nil {
+		return xerrors.Errorf("storing receipt: %w", err)
+	}
+	return nil
+}
+
+func receiptKey(sid abi.SectorID, task sealtasks.TaskType) datastore.Key {
+	return datastore.NewKey(storiface.SectorName(sid)).ChildString(string(task))
+}
+
+// SectorReceipts returns the work receipts kept for a sector sealed by the
+// sealing service.
+func (m *Manager) SectorReceipts(ctx context.Context, sid abi.SectorID) ([]storiface.WorkReceipt, error) {
+	svc := m.sealingService()
+	if svc == nil || svc.receipts == nil {
+		return nil, nil
+	}
+
+	res, err := svc.receipts.Query(query.Query{Prefix: datastore.NewKey(storiface.SectorName(sid)).String() + "/"})
+	if err != nil {
+		return nil, xerrors.Errorf("querying receipts: %w", err)
+	}
+	defer res.Close() // nolint
+
+	var out []storiface.WorkReceipt
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("reading receipts: %w", r.Error)
+		}
+
+		var receipt storiface.WorkReceipt
+		if err := json.Unmarshal(r.Value, &receipt); err != nil {
+			return nil, xerrors.Errorf("decoding receipt %s: %w", r.Key, err)
+		}
+		out = append(out, receipt)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Time.Before(out[j].Time)
+	})
+	return out, nil
+}
+
+// fetch downloads the replica sealed by the service into local sealing
+// storage, from where it's finalized like other sectors, and releases the
+// sector on the service.
+func (s *serviceSealer) fetch(ctx context.Context, m *Manager, sector storage.SectorRef) error {
+	job, err := s.wait(ctx, sector.ID, storiface.SealJobCommitted)
+	if err != nil {
+		return err
+	}
+	if job.SealedURL == "" || job.CacheURL == "" {
+		return xerrors.Errorf("sealing service didn't return replica URLs")
+	}
+
+	ft := storiface.FTSealed | storiface.FTCache
+	paths, ids, err := m.localStore.AcquireSector(ctx, sector, storiface.FTNone, ft, storiface.PathSealing, storiface.AcquireMove)
+	if err != nil {
+		return xerrors.Errorf("allocating local storage: %w", err)
+	}
+
+	release, err := m.localStore.Reserve(ctx, sector, ft, ids, storiface.FSOverheadSeal)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if err := s.download(ctx, job.SealedURL, paths.Sealed, false); err != nil {
+		return xerrors.Errorf("fetching sealed replica: %w", err)
+	}
+	if err := s.download(ctx, job.CacheURL, paths.Cache, true); err != nil {
+		return xerrors.Errorf("fetching sector cache: %w", err)
+	}
+
+	for _, t := range []storiface.SectorFileType{storiface.FTSealed, storiface.FTCache} {
+		sid := stores.ID(storiface.PathByType(ids, t))
+		if err := m.index.StorageDeclareSector(ctx, sid, sector.ID, t, true); err != nil {
+			return xerrors.Errorf("declaring sector: %w", err)
+		}
+	}
+
+	if err := s.svc.SealRelease(ctx, sector.ID); err != nil {
+		log.Warnf("releasing sector %d on the sealing service: %+v", sector.ID.Number, err)
+	}
+	return s.forget(sector.ID)
+}
+
+func (s *serviceSealer) download(ctx context.Context, url string, dest string, tar bool) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return xerrors.Errorf("request: %w", err)
+	}
+	req.Header = s.opts.ServiceAuth.Clone()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return xerrors.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close() // nolint
+
+	if resp.StatusCode != 200 {
+		return xerrors.Errorf("non-200 code: %d", resp.StatusCode)
+	}
+
+	if tar {
+		return tarutil.ExtractTar(resp.Body, dest)
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyBuffer(f, resp.Body, make([]byte, stores.CopyBuf)); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// release drops a removed sector from the service.
+func (s *serviceSealer) release(ctx context.Context, sid abi.SectorID) error {
+	if s == nil {
+		return nil
+	}
+
+	s.lk.Lock()
+	local, err := s.local.Has(storiface.SectorName(sid))
+	if err == nil && local {
+		err = s.local.Get(storiface.SectorName(sid)).End()
+	}
+	s.lk.Unlock()
+	if err != nil {
+		return xerrors.Errorf("forgetting locally sealed sector: %w", err)
+	}
+
+	if ok, err := s.delegated(sid, false); err != nil || !ok {
+		return err
+	}
+
+	if err := s.svc.SealRelease(ctx, sid); err != nil {
+		return xerrors.Errorf("releasing sector on the sealing service: %w", err)
+	}
+	return s.forget(sid)
+}
@@ -0,0 +1,268 @@
+package sectorstorage
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/fsutil"
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
+	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+func TestServiceReceipts(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	_, other, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	commr, err := cid.Parse("bagboea4b5abcatlxechwbp7kjpjguna6r6q7ejrhe6mdp3lf34pmswn27pkkiekz")
+	require.NoError(t, err)
+	commd, err := cid.Parse("baga6ea4seaqhyticusemlcrjhvulpfng4nint6bu3wpe5s3x4bnuj2rs47hfacy")
+	require.NoError(t, err)
+
+	sid := abi.SectorID{Miner: 1000, Number: 1}
+	job := storiface.SealJob{
+		Sector: sid,
+		State:  storiface.SealJobPreCommitted,
+		Cids:   &storage.SectorCids{Sealed: commr, Unsealed: commd},
+	}
+	receipt := func(key ed25519.PrivateKey) storiface.WorkReceipt {
+		r := storiface.WorkReceipt{
+			Sector:   sid,
+			Task:     sealtasks.TTPreCommit2,
+			CommR:    &commr,
+			CommD:    &commd,
+			Producer: "sealer-1",
+			Time:     time.Unix(1600000000, 0),
+		}
+		r.Sign(key)
+		return r
+	}
+
+	s := &serviceSealer{receipts: dssync.MutexWrap(datastore.NewMapDatastore())}
+	m := &Manager{svc: s}
+
+	// receipts aren't required without receipt keys
+	require.NoError(t, s.checkReceipt(job, sealtasks.TTPreCommit2))
+
+	s.opts.ReceiptKeys = []ed25519.PublicKey{pub}
+	require.Error(t, s.checkReceipt(job, sealtasks.TTPreCommit2))
+
+	job.Receipts = []storiface.WorkReceipt{receipt(other)}
+	require.Error(t, s.checkReceipt(job, sealtasks.TTPreCommit2))
+
+	tampered := receipt(priv)
+	tampered.Producer = "sealer-2"
+	job.Receipts = []storiface.WorkReceipt{tampered}
+	require.Error(t, s.checkReceipt(job, sealtasks.TTPreCommit2))
+
+	job.Receipts = []storiface.WorkReceipt{receipt(priv)}
+	require.NoError(t, s.checkReceipt(job, sealtasks.TTPreCommit2))
+
+	// the receipt must cover the returned outputs
+	job.Cids = &storage.SectorCids{Sealed: commd, Unsealed: commd}
+	require.Error(t, s.checkReceipt(job, sealtasks.TTPreCommit2))
+
+	stored, err := m.SectorReceipts(context.Background(), sid)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	require.Equal(t, "sealer-1", stored[0].Producer)
+	require.NoError(t, stored[0].Verify())
+
+	stored, err = m.SectorReceipts(context.Background(), abi.SectorID{Miner: 1000, Number: 10})
+	require.NoError(t, err)
+	require.Empty(t, stored)
+}
+
+type testSealingService struct {
+	lk       sync.Mutex
+	fail     bool
+	requests []storiface.SealPreCommitRequest
+	jobs     map[abi.SectorID]storiface.SealJob
+	released []abi.SectorID
+}
+
+func (s *testSealingService) SealPreCommit(ctx context.Context, req storiface.SealPreCommitRequest) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.requests = append(s.requests, req)
+
+	job := storiface.SealJob{Sector: req.Sector.ID, State: storiface.SealJobPreCommitted}
+	if s.fail {
+		job.State, job.Err = storiface.SealJobFailed, "no space left"
+	} else {
+		commr, _ := cid.Parse("bagboea4b5abcatlxechwbp7kjpjguna6r6q7ejrhe6mdp3lf34pmswn27pkkiekz")
+		commd, _ := cid.Parse("baga6ea4seaqhyticusemlcrjhvulpfng4nint6bu3wpe5s3x4bnuj2rs47hfacy")
+		job.Cids = &storage.SectorCids{Sealed: commr, Unsealed: commd}
+	}
+	s.jobs[req.Sector.ID] = job
+	return nil
+}
+
+func (s *testSealingService) SealCommit(ctx context.Context, sector abi.SectorID, seed abi.InteractiveSealRandomness) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	job := s.jobs[sector]
+	job.State, job.Proof = storiface.SealJobCommitted, []byte("proof")
+	s.jobs[sector] = job
+	return nil
+}
+
+func (s *testSealingService) SealStatus(ctx context.Context, sector abi.SectorID) (storiface.SealJob, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	return s.jobs[sector], nil
+}
+
+func (s *testSealingService) SealRelease(ctx context.Context, sector abi.SectorID) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.released = append(s.released, sector)
+	delete(s.jobs, sector)
+	return nil
+}
+
+func newServiceTestMgr(t *testing.T, svc SealingService, opts SealingServiceOpts, sectors ...abi.SectorID) *Manager {
+	ctx := context.Background()
+
+	si := stores.NewIndex()
+	require.NoError(t, si.StorageAttach(ctx, stores.StorageInfo{
+		ID:      "unsealed",
+		URLs:    []string{"http://miner:2345/remote"},
+		CanSeal: true,
+	}, fsutil.FsStat{Capacity: 1 << 30, Available: 1 << 30}))
+	for _, sid := range sectors {
+		require.NoError(t, si.StorageDeclareSector(ctx, "unsealed", sid, storiface.FTUnsealed, true))
+	}
+
+	m := &Manager{index: si}
+	m.SetSealingService(svc, opts, datastore.NewMapDatastore(), datastore.NewMapDatastore(), nil)
+	return m
+}
+
+func TestServiceDelegated(t *testing.T) {
+	m := newServiceTestMgr(t, &testSealingService{}, SealingServiceOpts{MaxSectors: 2})
+	s := m.sealingService()
+
+	sector := func(n abi.SectorNumber) abi.SectorID {
+		return abi.SectorID{Miner: 1000, Number: n}
+	}
+
+	// sectors are only assigned on request
+	ok, err := s.delegated(sector(1), false)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	for _, n := range []abi.SectorNumber{1, 2} {
+		ok, err := s.delegated(sector(n), true)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	// and stay assigned
+	ok, err = s.delegated(sector(1), false)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = s.delegated(sector(1), true)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// up to MaxSectors
+	ok, err = s.delegated(sector(3), true)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, s.forget(sector(1)))
+	ok, err = s.delegated(sector(3), true)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// without a service nothing is delegated
+	var none *serviceSealer
+	ok, err = none.delegated(sector(4), true)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestServiceHandoff(t *testing.T) {
+	SealingServicePollInterval = time.Millisecond
+
+	ctx := context.Background()
+	signer := stores.NewURLSigner([]byte("secret"))
+	sector := storage.SectorRef{
+		ID:        abi.SectorID{Miner: 1000, Number: 1},
+		ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1,
+	}
+
+	t.Run("sealed", func(t *testing.T) {
+		svc := &testSealingService{jobs: map[abi.SectorID]storiface.SealJob{}}
+		m := newServiceTestMgr(t, svc, SealingServiceOpts{URLSigner: signer}, sector.ID)
+
+		p1o, err := m.SealPreCommit1(ctx, sector, abi.SealRandomness{1}, nil)
+		require.NoError(t, err)
+		require.Equal(t, serviceSealedOut, []byte(p1o))
+
+		// the service gets signed URLs of the unsealed sector, and no token
+		require.Len(t, svc.requests, 1)
+		require.Len(t, svc.requests[0].UnsealedURLs, 1)
+		u := svc.requests[0].UnsealedURLs[0]
+		require.True(t, strings.HasPrefix(u, "http://miner:2345/remote/unsealed/s-t01000-1?"))
+		require.True(t, signer.Verify(httptest.NewRequest("GET", u, nil)))
+
+		cids, err := m.SealPreCommit2(ctx, sector, p1o)
+		require.NoError(t, err)
+		require.Equal(t, svc.jobs[sector.ID].Cids.Sealed, cids.Sealed)
+
+		c1o, err := m.SealCommit1(ctx, sector, abi.SealRandomness{1}, abi.InteractiveSealRandomness{2}, nil, cids)
+		require.NoError(t, err)
+
+		proof, err := m.SealCommit2(ctx, sector, c1o)
+		require.NoError(t, err)
+		require.Equal(t, []byte("proof"), []byte(proof))
+		require.Empty(t, svc.released)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		svc := &testSealingService{fail: true, jobs: map[abi.SectorID]storiface.SealJob{}}
+		m := newServiceTestMgr(t, svc, SealingServiceOpts{URLSigner: signer}, sector.ID)
+
+		_, err := m.SealPreCommit1(ctx, sector, abi.SealRandomness{1}, nil)
+		require.Error(t, err)
+
+		// the sector is released on the service, and sealed locally from now on
+		require.Equal(t, []abi.SectorID{sector.ID}, svc.released)
+
+		ok, err := m.sealingService().delegated(sector.ID, true)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		// outputs of the service can't be used by local workers
+		_, err = m.SealPreCommit2(ctx, sector, serviceSealedOut)
+		require.Error(t, err)
+
+		// removing the sector forgets it, and doesn't release it again
+		require.NoError(t, m.sealingService().release(ctx, sector.ID))
+		require.Len(t, svc.released, 1)
+		has, err := m.sealingService().local.Has(storiface.SectorName(sector.ID))
+		require.NoError(t, err)
+		require.False(t, has)
+	})
+}
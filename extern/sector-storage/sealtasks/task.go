@@ -1,5 +1,7 @@
 package sealtasks
 
+import "strings"
+
 type TaskType string
 
 const (
@@ -13,6 +15,15 @@ const (
 
 	TTFetch  TaskType = "seal/v0/fetch"
 	TTUnseal TaskType = "seal/v0/unseal"
+
+	// PoSt tasks aren't scheduled on workers, they are only used to assign
+	// GPUs to proving
+	TTGenerateWindowPoSt  TaskType = "post/v0/windowproof"
+	TTGenerateWinningPoSt TaskType = "post/v0/winningproof"
+
+	// Verify tasks aren't scheduled by the miner either, workers with them
+	// enabled verify proofs for full nodes syncing the chain
+	TTVerify TaskType = "verify/v0/proof"
 )
 
 var order = map[TaskType]int{
@@ -24,6 +35,11 @@ var order = map[TaskType]int{
 	TTUnseal:     1,
 	TTFetch:      -1,
 	TTFinalize:   -2, // most priority
+
+	TTGenerateWindowPoSt:  -3,
+	TTGenerateWinningPoSt: -4,
+
+	TTVerify: -5,
 }
 
 var shortNames = map[TaskType]string{
@@ -38,6 +54,11 @@ var shortNames = map[TaskType]string{
 
 	TTFetch:  "GET",
 	TTUnseal: "UNS",
+
+	TTGenerateWindowPoSt:  "WDP",
+	TTGenerateWinningPoSt: "WNP",
+
+	TTVerify: "VRF",
 }
 
 func (a TaskType) MuchLess(b TaskType) (bool, bool) {
@@ -58,3 +79,13 @@ func (a TaskType) Short() string {
 
 	return n
 }
+
+// ParseTaskType parses a task type from either its full or short name.
+func ParseTaskType(s string) (TaskType, bool) {
+	for tt, short := range shortNames {
+		if strings.EqualFold(s, short) || s == string(tt) {
+			return tt, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,310 @@
+package sectorstorage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
+	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+// SimPipeline are the tasks sealing a sector, in order
+var SimPipeline = []sealtasks.TaskType{
+	sealtasks.TTAddPiece,
+	sealtasks.TTPreCommit1,
+	sealtasks.TTPreCommit2,
+	sealtasks.TTCommit1,
+	sealtasks.TTCommit2,
+	sealtasks.TTFinalize,
+}
+
+// DefaultSimDurations are rough durations of the sealing tasks of 32GiB
+// sectors on current hardware
+var DefaultSimDurations = map[sealtasks.TaskType]time.Duration{
+	sealtasks.TTAddPiece:   10 * time.Minute,
+	sealtasks.TTPreCommit1: 3*time.Hour + 30*time.Minute,
+	sealtasks.TTPreCommit2: 15 * time.Minute,
+	sealtasks.TTCommit1:    time.Minute,
+	sealtasks.TTCommit2:    30 * time.Minute,
+	sealtasks.TTFinalize:   5 * time.Minute,
+}
+
+// SimWorker is a synthetic worker of a simulation
+type SimWorker struct {
+	Name      string
+	Resources storiface.WorkerResources
+	TaskTypes []sealtasks.TaskType
+
+	// Durations of tasks on this worker, overriding SimConfig.Durations
+	Durations map[sealtasks.TaskType]time.Duration
+}
+
+// SimConfig describes a simulation of the sealing pipeline
+type SimConfig struct {
+	Sectors   int
+	SealProof abi.RegisteredSealProof
+	Workers   []SimWorker
+
+	// Durations of tasks on workers not overriding them, DefaultSimDurations
+	// are used for missing tasks
+	Durations map[sealtasks.TaskType]time.Duration
+
+	// Speedup is how many times faster than real time the simulation runs.
+	// High values make the scheduling overhead significant.
+	Speedup float64
+}
+
+// SimTaskStats are the times spent by the tasks of a type
+type SimTaskStats struct {
+	Count   int
+	Wait    time.Duration // total time spent waiting for a worker
+	MaxWait time.Duration
+	Run     time.Duration // total time spent running
+}
+
+// SimWorkerStats is how much a worker was used
+type SimWorkerStats struct {
+	Name  string
+	Busy  time.Duration // total running time of the tasks, may exceed the elapsed time
+	Tasks map[sealtasks.TaskType]int
+}
+
+// SimResult is the outcome of a simulation, in simulated time
+type SimResult struct {
+	Elapsed       time.Duration
+	SectorsPerDay float64
+
+	Tasks   map[sealtasks.TaskType]*SimTaskStats
+	Workers []SimWorkerStats
+
+	// Bottleneck is the task which sectors waited the longest for
+	Bottleneck sealtasks.TaskType
+}
+
+// Simulate runs the scheduler with synthetic workers, which sleep instead of
+// running tasks, and returns the predicted throughput of the pipeline
+func Simulate(ctx context.Context, cfg SimConfig) (*SimResult, error) {
+	if cfg.Sectors <= 0 {
+		return nil, xerrors.Errorf("no sectors to simulate")
+	}
+	if cfg.Speedup <= 0 {
+		return nil, xerrors.Errorf("speedup must be positive")
+	}
+
+	for _, tt := range SimPipeline {
+		if _, ok := ResourceTable[tt][cfg.SealProof]; !ok {
+			return nil, xerrors.Errorf("no resource table entry for %s with proof %d", tt.Short(), cfg.SealProof)
+		}
+
+		found := false
+		for _, w := range cfg.Workers {
+			for _, wtt := range w.TaskTypes {
+				found = found || wtt == tt
+			}
+		}
+		if !found {
+			return nil, xerrors.Errorf("no worker can run %s", tt.Short())
+		}
+	}
+
+	sim := &simulation{
+		cfg:   cfg,
+		start: time.Now(),
+		tasks: map[sealtasks.TaskType]*SimTaskStats{},
+	}
+	for _, tt := range SimPipeline {
+		sim.tasks[tt] = &SimTaskStats{}
+	}
+
+	sched := newScheduler()
+	go sched.runSched()
+	defer sched.Close(context.TODO()) // nolint:errcheck
+
+	workers := make([]*simWorker, len(cfg.Workers))
+	for i, w := range cfg.Workers {
+		sw := &simWorker{
+			spec:    w,
+			session: uuid.New(),
+			tasks:   map[sealtasks.TaskType]int{},
+		}
+		if err := sched.runWorker(ctx, sw); err != nil {
+			return nil, xerrors.Errorf("adding worker %s: %w", w.Name, err)
+		}
+		workers[i] = sw
+	}
+
+	sel := newTaskSelector()
+	noop := func(context.Context, Worker) error { return nil }
+
+	var wg sync.WaitGroup
+	errs := make(chan error, cfg.Sectors)
+	for n := 0; n < cfg.Sectors; n++ {
+		sector := storage.SectorRef{
+			ID:        abi.SectorID{Miner: 1000, Number: abi.SectorNumber(n)},
+			ProofType: cfg.SealProof,
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for _, tt := range SimPipeline {
+				tt := tt
+				queued := sim.now()
+				err := sched.Schedule(ctx, sector, tt, sel, noop, func(ctx context.Context, w Worker) error {
+					return sim.run(ctx, tt, queued, w)
+				})
+				if err != nil {
+					errs <- xerrors.Errorf("sector %d, %s: %w", sector.ID.Number, tt.Short(), err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return nil, err
+	default:
+	}
+
+	res := &SimResult{
+		Elapsed: sim.now(),
+		Tasks:   sim.tasks,
+	}
+	res.SectorsPerDay = float64(cfg.Sectors) / res.Elapsed.Hours() * 24
+
+	var maxWait time.Duration
+	for _, tt := range SimPipeline {
+		if st := sim.tasks[tt]; st.Wait > maxWait {
+			maxWait = st.Wait
+			res.Bottleneck = tt
+		}
+	}
+
+	for _, w := range workers {
+		w.lk.Lock()
+		res.Workers = append(res.Workers, SimWorkerStats{
+			Name:  w.spec.Name,
+			Busy:  w.busy,
+			Tasks: w.tasks,
+		})
+		w.lk.Unlock()
+	}
+	sort.SliceStable(res.Workers, func(i, j int) bool {
+		return res.Workers[i].Name < res.Workers[j].Name
+	})
+
+	return res, nil
+}
+
+type simulation struct {
+	cfg   SimConfig
+	start time.Time
+
+	lk    sync.Mutex
+	tasks map[sealtasks.TaskType]*SimTaskStats
+}
+
+// now returns the simulated time since the start of the simulation
+func (s *simulation) now() time.Duration {
+	return time.Duration(float64(time.Since(s.start)) * s.cfg.Speedup)
+}
+
+func (s *simulation) duration(w *simWorker, tt sealtasks.TaskType) time.Duration {
+	if d, ok := w.spec.Durations[tt]; ok {
+		return d
+	}
+	if d, ok := s.cfg.Durations[tt]; ok {
+		return d
+	}
+	return DefaultSimDurations[tt]
+}
+
+func (s *simulation) run(ctx context.Context, tt sealtasks.TaskType, queued time.Duration, w Worker) error {
+	if tw, ok := w.(*trackedWorker); ok {
+		w = tw.Worker
+	}
+	sw, ok := w.(*simWorker)
+	if !ok {
+		return xerrors.Errorf("unexpected worker type %T", w)
+	}
+
+	wait := s.now() - queued
+	d := s.duration(sw, tt)
+
+	select {
+	case <-time.After(time.Duration(float64(d) / s.cfg.Speedup)):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	s.lk.Lock()
+	st := s.tasks[tt]
+	st.Count++
+	st.Wait += wait
+	st.Run += d
+	if wait > st.MaxWait {
+		st.MaxWait = wait
+	}
+	s.lk.Unlock()
+
+	sw.lk.Lock()
+	sw.busy += d
+	sw.tasks[tt]++
+	sw.lk.Unlock()
+
+	return nil
+}
+
+// simWorker is scheduled like other workers, its calls are never made as
+// the simulated tasks don't call the worker
+type simWorker struct {
+	storiface.WorkerCalls
+
+	spec    SimWorker
+	session uuid.UUID
+
+	lk    sync.Mutex
+	busy  time.Duration
+	tasks map[sealtasks.TaskType]int
+}
+
+func (s *simWorker) TaskTypes(context.Context) (map[sealtasks.TaskType]struct{}, error) {
+	out := map[sealtasks.TaskType]struct{}{}
+	for _, tt := range s.spec.TaskTypes {
+		out[tt] = struct{}{}
+	}
+	return out, nil
+}
+
+func (s *simWorker) Paths(context.Context) ([]stores.StoragePath, error) {
+	return nil, nil
+}
+
+func (s *simWorker) Info(context.Context) (storiface.WorkerInfo, error) {
+	return storiface.WorkerInfo{
+		Hostname:  s.spec.Name,
+		Resources: s.spec.Resources,
+	}, nil
+}
+
+func (s *simWorker) Session(context.Context) (uuid.UUID, error) {
+	return s.session, nil
+}
+
+func (s *simWorker) Close() error {
+	return nil
+}
+
+var _ Worker = &simWorker{}
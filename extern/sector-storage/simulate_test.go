@@ -0,0 +1,67 @@
+package sectorstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
+)
+
+func TestSimulate(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), 30*time.Second)
+	defer done()
+
+	res, err := Simulate(ctx, SimConfig{
+		Sectors:   4,
+		SealProof: abi.RegisteredSealProof_StackedDrg2KiBV1,
+		Workers: []SimWorker{
+			{
+				Name:      "sealer",
+				Resources: decentWorkerResources,
+				TaskTypes: []sealtasks.TaskType{sealtasks.TTAddPiece, sealtasks.TTPreCommit1, sealtasks.TTPreCommit2},
+			},
+			{
+				Name:      "prover",
+				Resources: decentWorkerResources,
+				TaskTypes: []sealtasks.TaskType{sealtasks.TTCommit1, sealtasks.TTCommit2, sealtasks.TTFinalize},
+				Durations: map[sealtasks.TaskType]time.Duration{
+					sealtasks.TTCommit2: time.Hour,
+				},
+			},
+		},
+		Speedup: 100000,
+	})
+	require.NoError(t, err)
+
+	for _, tt := range SimPipeline {
+		require.Equal(t, 4, res.Tasks[tt].Count, tt.Short())
+	}
+	require.Equal(t, 4*time.Hour, res.Tasks[sealtasks.TTCommit2].Run)
+
+	require.Len(t, res.Workers, 2)
+	require.Equal(t, "prover", res.Workers[0].Name)
+	require.Equal(t, 4, res.Workers[0].Tasks[sealtasks.TTCommit2])
+	require.Zero(t, res.Workers[0].Tasks[sealtasks.TTPreCommit1])
+
+	require.True(t, res.Elapsed > 0)
+	require.True(t, res.SectorsPerDay > 0)
+}
+
+func TestSimulateMissingTask(t *testing.T) {
+	_, err := Simulate(context.Background(), SimConfig{
+		Sectors:   1,
+		SealProof: abi.RegisteredSealProof_StackedDrg2KiBV1,
+		Workers: []SimWorker{{
+			Name:      "sealer",
+			Resources: decentWorkerResources,
+			TaskTypes: []sealtasks.TaskType{sealtasks.TTAddPiece},
+		}},
+		Speedup: 1000,
+	})
+	require.Error(t, err)
+}
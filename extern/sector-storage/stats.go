@@ -1,10 +1,16 @@
 package sectorstorage
 
 import (
+	"context"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
 	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
 )
 
@@ -23,6 +29,8 @@ func (m *Manager) WorkerStats() map[uuid.UUID]storiface.WorkerStats {
 			MemUsedMax: handle.active.memUsedMax,
 			GpuUsed:    handle.active.gpuUsed,
 			CpuUse:     handle.active.cpuUse,
+
+			GPUTasks: append([]sealtasks.TaskType(nil), handle.active.gpus...),
 		}
 	}
 
@@ -92,3 +100,55 @@ func (m *Manager) WorkerJobs() map[uuid.UUID][]storiface.WorkerJob {
 
 	return out
 }
+
+// QueuePosition is the place of a sector's task waiting in the scheduler queue
+type QueuePosition struct {
+	Task sealtasks.TaskType
+
+	Ahead   int // tasks of the same type which will be scheduled before it
+	Running int // tasks of the same type running on workers
+}
+
+// QueuePositions returns the queue positions of sectors with tasks waiting
+// to be scheduled on workers
+func (m *Manager) QueuePositions(ctx context.Context) (map[abi.SectorID]QueuePosition, error) {
+	si, err := m.sched.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	diag, ok := si.(SchedDiagInfo)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected scheduler info type %T", si)
+	}
+
+	running := map[sealtasks.TaskType]int{}
+	for _, t := range m.sched.workTracker.Running() {
+		running[t.job.Task]++
+	}
+
+	byType := map[sealtasks.TaskType][]SchedDiagRequestInfo{}
+	for _, r := range diag.Requests {
+		byType[r.TaskType] = append(byType[r.TaskType], r)
+	}
+
+	out := map[abi.SectorID]QueuePosition{}
+	for tt, reqs := range byType {
+		// same order as the request queue uses for tasks of one type
+		sort.Slice(reqs, func(i, j int) bool {
+			if reqs[i].Priority != reqs[j].Priority {
+				return reqs[i].Priority > reqs[j].Priority
+			}
+			return reqs[i].Sector.Number < reqs[j].Sector.Number
+		})
+
+		for i, r := range reqs {
+			out[r.Sector] = QueuePosition{
+				Task:    tt,
+				Ahead:   i,
+				Running: running[tt],
+			}
+		}
+	}
+
+	return out, nil
+}
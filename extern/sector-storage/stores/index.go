@@ -34,6 +34,12 @@ type StorageInfo struct {
 
 	CanSeal  bool
 	CanStore bool
+
+	Groups     []string
+	AllowTasks []string
+	DenyTasks  []string
+	AllowTypes []string
+	DenyTypes  []string
 }
 
 type HealthReport struct {
@@ -92,6 +98,7 @@ type Index struct {
 
 	sectors map[Decl][]*declMeta
 	stores  map[ID]*storageEntry
+	tiers   map[abi.SectorID]*SectorTier
 }
 
 func NewIndex() *Index {
@@ -101,6 +108,7 @@ func NewIndex() *Index {
 		},
 		sectors: map[Decl][]*declMeta{},
 		stores:  map[ID]*storageEntry{},
+		tiers:   map[abi.SectorID]*SectorTier{},
 	}
 }
 
@@ -133,6 +141,33 @@ func (i *Index) StorageList(ctx context.Context) (map[ID][]Decl, error) {
 	return out, nil
 }
 
+// StorageHealthInfo describes the last heartbeat received from a storage path.
+type StorageHealthInfo struct {
+	LastHeartbeat time.Time
+	Err           string
+}
+
+// Online returns whether the path reported recently, without errors.
+func (hi StorageHealthInfo) Online() bool {
+	return hi.Err == "" && time.Since(hi.LastHeartbeat) <= SkippedHeartbeatThresh
+}
+
+func (i *Index) StorageHealth(ctx context.Context) (map[ID]StorageHealthInfo, error) {
+	i.lk.RLock()
+	defer i.lk.RUnlock()
+
+	out := make(map[ID]StorageHealthInfo, len(i.stores))
+	for id, ent := range i.stores {
+		hi := StorageHealthInfo{LastHeartbeat: ent.lastHeartbeat}
+		if ent.heartbeatErr != nil {
+			hi.Err = ent.heartbeatErr.Error()
+		}
+		out[id] = hi
+	}
+
+	return out, nil
+}
+
 func (i *Index) StorageAttach(ctx context.Context, si StorageInfo, st fsutil.FsStat) error {
 	i.lk.Lock()
 	defer i.lk.Unlock()
@@ -161,6 +196,11 @@ func (i *Index) StorageAttach(ctx context.Context, si StorageInfo, st fsutil.FsS
 		i.stores[si.ID].info.MaxStorage = si.MaxStorage
 		i.stores[si.ID].info.CanSeal = si.CanSeal
 		i.stores[si.ID].info.CanStore = si.CanStore
+		i.stores[si.ID].info.Groups = si.Groups
+		i.stores[si.ID].info.AllowTasks = si.AllowTasks
+		i.stores[si.ID].info.DenyTasks = si.DenyTasks
+		i.stores[si.ID].info.AllowTypes = si.AllowTypes
+		i.stores[si.ID].info.DenyTypes = si.DenyTypes
 
 		return nil
 	}
@@ -250,6 +290,9 @@ func (i *Index) StorageDropSector(ctx context.Context, storageID ID, s abi.Secto
 		}
 		if len(rewritten) == 0 {
 			delete(i.sectors, d)
+			if fileType == storiface.FTSealed {
+				delete(i.tiers, s)
+			}
 			continue
 		}
 
@@ -259,6 +302,41 @@ func (i *Index) StorageDropSector(ctx context.Context, storageID ID, s abi.Secto
 	return nil
 }
 
+// StorageMoveDecl atomically moves the declarations of sector files from one
+// storage path to another, so that the files are never seen as missing, and
+// never declared in both paths at once. The files are declared as primary in
+// the new path.
+func (i *Index) StorageMoveDecl(ctx context.Context, s abi.SectorID, ft storiface.SectorFileType, from, to ID) error {
+	i.lk.Lock()
+	defer i.lk.Unlock()
+
+	if _, ok := i.stores[to]; !ok {
+		return xerrors.Errorf("destination storage %s not found", to)
+	}
+
+	for _, fileType := range storiface.PathTypes {
+		if fileType&ft == 0 {
+			continue
+		}
+
+		d := Decl{s, fileType}
+
+		rewritten := make([]*declMeta, 0, len(i.sectors[d]))
+		for _, dm := range i.sectors[d] {
+			if dm.storage == from || dm.storage == to {
+				continue
+			}
+			rewritten = append(rewritten, dm)
+		}
+		i.sectors[d] = append(rewritten, &declMeta{
+			storage: to,
+			primary: true,
+		})
+	}
+
+	return nil
+}
+
 func (i *Index) StorageFindSector(ctx context.Context, s abi.SectorID, ft storiface.SectorFileType, ssize abi.SectorSize, allowFetch bool) ([]SectorStorageInfo, error) {
 	i.lk.RLock()
 	defer i.lk.RUnlock()
@@ -406,6 +484,11 @@ func (i *Index) StorageBestAlloc(ctx context.Context, allocate storiface.SectorF
 			continue
 		}
 
+		if !p.info.canAllocateCtx(ctx, allocate) {
+			log.Debugf("not allocating on %s, not allowed by path rules", p.info.ID)
+			continue
+		}
+
 		if spaceReq > uint64(p.fsi.Available) {
 			log.Debugf("not allocating on %s, out of space (available: %d, need: %d)", p.info.ID, p.fsi.Available, spaceReq)
 			continue
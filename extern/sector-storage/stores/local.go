@@ -46,6 +46,19 @@ type LocalStorageMeta struct {
 	// MaxStorage specifies the maximum number of bytes to use for sector storage
 	// (0 = unlimited)
 	MaxStorage uint64
+
+	// Groups this path belongs to, e.g. "rack-A" or "nvme-scratch"
+	Groups []string
+
+	// Task types (short names, e.g. PC1) allowed / denied to allocate space
+	// in this path; empty AllowTasks allows all tasks
+	AllowTasks []string
+	DenyTasks  []string
+
+	// Sector file types (unsealed, sealed, cache) allowed / denied to be
+	// allocated in this path; empty AllowTypes allows all types
+	AllowTypes []string
+	DenyTypes  []string
 }
 
 // StorageConfig .lotusstorage/storage.json
@@ -205,6 +218,11 @@ func (st *Local) OpenPath(ctx context.Context, p string) error {
 		MaxStorage: meta.MaxStorage,
 		CanSeal:    meta.CanSeal,
 		CanStore:   meta.CanStore,
+		Groups:     meta.Groups,
+		AllowTasks: meta.AllowTasks,
+		DenyTasks:  meta.DenyTasks,
+		AllowTypes: meta.AllowTypes,
+		DenyTypes:  meta.DenyTypes,
 	}, fst)
 	if err != nil {
 		return xerrors.Errorf("declaring storage in index: %w", err)
@@ -269,6 +287,11 @@ func (st *Local) Redeclare(ctx context.Context) error {
 			MaxStorage: meta.MaxStorage,
 			CanSeal:    meta.CanSeal,
 			CanStore:   meta.CanStore,
+			Groups:     meta.Groups,
+			AllowTasks: meta.AllowTasks,
+			DenyTasks:  meta.DenyTasks,
+			AllowTypes: meta.AllowTypes,
+			DenyTypes:  meta.DenyTypes,
 		}, fst)
 		if err != nil {
 			return xerrors.Errorf("redeclaring storage in index: %w", err)
@@ -491,6 +514,12 @@ func (st *Local) AcquireSector(ctx context.Context, sid storage.SectorRef, exist
 				continue
 			}
 
+			// the index only applies task rules for allocations made in the
+			// same process, so check them again here
+			if !si.canAllocateCtx(ctx, fileType) {
+				continue
+			}
+
 			// TODO: Check free space
 
 			best = p.sectorPath(sid.ID, fileType)
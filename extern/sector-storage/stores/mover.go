@@ -0,0 +1,566 @@
+package stores
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/raulk/clock"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+// DefaultMoveRemoveDelay is how long the source copy of a moved sector is
+// kept after the index switched to the new copy, so that proofs which looked
+// up the old location just before the switch can still read it.
+const DefaultMoveRemoveDelay = 10 * time.Minute
+
+// moveTypes are the sector files needed to prove a sector.
+const moveTypes = storiface.FTSealed | storiface.FTCache
+
+type MoveState string
+
+const (
+	MoveQueued    MoveState = "queued"
+	MoveCopying   MoveState = "copying"
+	MoveVerifying MoveState = "verifying"
+	MoveRemoving  MoveState = "removing-source"
+	MoveDone      MoveState = "done"
+	MoveFailed    MoveState = "failed"
+)
+
+// SectorMove describes a sector storage move.
+type SectorMove struct {
+	Sector abi.SectorID
+	From   ID
+	To     ID
+
+	State       MoveState
+	BytesTotal  int64
+	BytesCopied int64
+
+	Queued   time.Time
+	Finished time.Time `json:",omitempty"`
+	Err      string    `json:",omitempty"`
+}
+
+// Mover moves sealed and cache files of sectors between local storage paths
+// in the background. Sectors stay provable while they are moved: files are
+// copied and checksummed while the sector is only locked for reading, and the
+// index is switched to the new copy only once it's complete.
+//
+// Moves switched to their new copy are kept in the datastore until their
+// source copy is removed, so that the removal is finished after a restart,
+// see Resume.
+type Mover struct {
+	ctx   context.Context
+	local *Local
+	index *Index
+	ds    datastore.Batching
+	clock clock.Clock
+
+	RemoveDelay time.Duration
+
+	throttle chan struct{}
+
+	lk       sync.Mutex
+	moves    map[abi.SectorID]*SectorMove
+	finished []func(SectorMove)
+}
+
+// pendingRemoval is a move switched to its new copy, whose source copy is
+// removed at RemoveAt
+type pendingRemoval struct {
+	Move     SectorMove
+	RemoveAt time.Time
+}
+
+func NewMover(ctx context.Context, local *Local, index *Index, ds datastore.Batching) *Mover {
+	return &Mover{
+		ctx:   ctx,
+		local: local,
+		index: index,
+		ds:    ds,
+		clock: clock.New(),
+
+		RemoveDelay: DefaultMoveRemoveDelay,
+
+		// moves are IO bound, running them in parallel only slows all of them
+		throttle: make(chan struct{}, 1),
+
+		moves: map[abi.SectorID]*SectorMove{},
+	}
+}
+
+func pendingKey(sid abi.SectorID) datastore.Key {
+	return datastore.NewKey(storiface.SectorName(sid))
+}
+
+// Resume finishes the moves interrupted by a restart after their index
+// switch: the source copies, declared again when their path was opened, are
+// dropped from the index, and removed once their removal delay passed.
+func (m *Mover) Resume() error {
+	res, err := m.ds.Query(query.Query{})
+	if err != nil {
+		return err
+	}
+	defer res.Close() // nolint
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return r.Error
+		}
+
+		var p pendingRemoval
+		if err := json.Unmarshal(r.Value, &p); err != nil {
+			return xerrors.Errorf("unmarshaling pending removal %s: %w", r.Key, err)
+		}
+
+		mv := p.Move
+		mv.State = MoveRemoving
+		m.lk.Lock()
+		m.moves[mv.Sector] = &mv
+		m.lk.Unlock()
+
+		log.Infow("resuming sector move", "sector", mv.Sector, "from", mv.From, "to", mv.To, "removeAt", p.RemoveAt)
+		go func(removeAt time.Time) {
+			err := m.index.StorageDropSector(m.ctx, mv.From, mv.Sector, moveTypes)
+			if err != nil {
+				err = xerrors.Errorf("dropping source copy from the index: %w", err)
+			} else {
+				err = m.removeSource(&mv, removeAt)
+			}
+			m.finish(&mv, err)
+		}(p.RemoveAt)
+	}
+
+	return nil
+}
+
+// Move queues moving the sector to the given local storage path.
+func (m *Mover) Move(ctx context.Context, sid abi.SectorID, to ID) error {
+	if _, ok := m.local.localPath(to); !ok {
+		return xerrors.Errorf("destination %s is not a local storage path", to)
+	}
+	dst, err := m.index.StorageInfo(ctx, to)
+	if err != nil {
+		return xerrors.Errorf("getting destination storage info: %w", err)
+	}
+	if !dst.CanStore {
+		return xerrors.Errorf("destination %s can't be used for long-term storage", to)
+	}
+
+	from, err := m.findSource(ctx, sid)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return xerrors.Errorf("sector %d is already stored in %s", sid.Number, to)
+	}
+
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	if mv, ok := m.moves[sid]; ok && mv.State != MoveDone && mv.State != MoveFailed {
+		return xerrors.Errorf("sector %d is already being moved to %s", sid.Number, mv.To)
+	}
+
+	mv := &SectorMove{
+		Sector: sid,
+		From:   from,
+		To:     to,
+		State:  MoveQueued,
+		Queued: m.clock.Now(),
+	}
+	m.moves[sid] = mv
+
+	go m.run(mv)
+
+	return nil
+}
+
+// OnFinished registers a callback called when a move completes or fails.
+func (m *Mover) OnFinished(cb func(SectorMove)) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	m.finished = append(m.finished, cb)
+}
+
+// List returns all moves since the node started.
+func (m *Mover) List() []SectorMove {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	out := make([]SectorMove, 0, len(m.moves))
+	for _, mv := range m.moves {
+		out = append(out, *mv)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Queued.Before(out[j].Queued)
+	})
+	return out
+}
+
+// findSource finds the local path all files needed to prove the sector are
+// stored in.
+func (m *Mover) findSource(ctx context.Context, sid abi.SectorID) (ID, error) {
+	var from ID
+	for _, ft := range storiface.PathTypes {
+		if ft&moveTypes == 0 {
+			continue
+		}
+
+		si, err := m.index.StorageFindSector(ctx, sid, ft, 0, false)
+		if err != nil {
+			return "", xerrors.Errorf("finding sector %d (%s): %w", sid.Number, ft, err)
+		}
+
+		var found ID
+		for _, info := range si {
+			if _, ok := m.local.localPath(info.ID); ok {
+				found = info.ID
+				if info.Primary {
+					break
+				}
+			}
+		}
+
+		switch {
+		case found == "":
+			return "", xerrors.Errorf("sector %d (%s) not found in local storage", sid.Number, ft)
+		case from != "" && from != found:
+			return "", xerrors.Errorf("sector %d files are split between paths %s and %s", sid.Number, from, found)
+		}
+		from = found
+	}
+
+	return from, nil
+}
+
+func (m *Mover) update(mv *SectorMove, cb func(mv *SectorMove)) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	cb(mv)
+}
+
+func (m *Mover) run(mv *SectorMove) {
+	select {
+	case m.throttle <- struct{}{}:
+	case <-m.ctx.Done():
+		return
+	}
+	defer func() {
+		<-m.throttle
+	}()
+
+	m.finish(mv, m.move(mv))
+}
+
+func (m *Mover) finish(mv *SectorMove, err error) {
+	m.lk.Lock()
+	mv.Finished = m.clock.Now()
+	if err != nil {
+		log.Errorf("moving sector %d from %s to %s: %+v", mv.Sector.Number, mv.From, mv.To, err)
+		mv.State = MoveFailed
+		mv.Err = err.Error()
+	} else {
+		mv.State = MoveDone
+	}
+	res, cbs := *mv, m.finished
+	m.lk.Unlock()
+
+	for _, cb := range cbs {
+		cb(res)
+	}
+}
+
+type moveFile struct {
+	src, tmp, dst string
+}
+
+func (m *Mover) moveFiles(mv *SectorMove) ([]moveFile, error) {
+	srcRoot, ok := m.local.localPath(mv.From)
+	if !ok {
+		return nil, xerrors.Errorf("source path %s not found", mv.From)
+	}
+	dstRoot, ok := m.local.localPath(mv.To)
+	if !ok {
+		return nil, xerrors.Errorf("destination path %s not found", mv.To)
+	}
+
+	var files []moveFile
+	for _, ft := range storiface.PathTypes {
+		if ft&moveTypes == 0 {
+			continue
+		}
+
+		name := storiface.SectorName(mv.Sector)
+		files = append(files, moveFile{
+			src: filepath.Join(srcRoot, ft.String(), name),
+			tmp: filepath.Join(dstRoot, ft.String(), FetchTempSubdir, name+".move"),
+			dst: filepath.Join(dstRoot, ft.String(), name),
+		})
+	}
+	return files, nil
+}
+
+func (m *Mover) move(mv *SectorMove) error {
+	files, err := m.moveFiles(mv)
+	if err != nil {
+		return err
+	}
+
+	// readers, like PoSt, can keep using the sector while it's copied; the
+	// read lock only keeps writers, e.g. sector removal, out
+	lctx, unlock := context.WithCancel(m.ctx)
+	defer unlock()
+	if err := m.index.StorageLock(lctx, mv.Sector, moveTypes, storiface.FTNone); err != nil {
+		return xerrors.Errorf("locking sector: %w", err)
+	}
+
+	var total int64
+	for _, f := range files {
+		size, err := pathSize(f.src)
+		if err != nil {
+			return xerrors.Errorf("getting size of %s: %w", f.src, err)
+		}
+		total += size
+	}
+
+	stat, err := m.local.FsStat(m.ctx, mv.To)
+	if err != nil {
+		return xerrors.Errorf("getting destination fs stat: %w", err)
+	}
+	if stat.Available < total {
+		return xerrors.Errorf("not enough space in destination (available: %d, need: %d)", stat.Available, total)
+	}
+
+	m.update(mv, func(mv *SectorMove) {
+		mv.State = MoveCopying
+		mv.BytesTotal = total
+	})
+
+	cleanup := func() {
+		for _, f := range files {
+			if err := os.RemoveAll(f.tmp); err != nil {
+				log.Warnf("removing temporary move files %s: %s", f.tmp, err)
+			}
+		}
+	}
+
+	progress := func(n int64) {
+		m.update(mv, func(mv *SectorMove) {
+			mv.BytesCopied += n
+		})
+	}
+	for _, f := range files {
+		if err := copyTree(m.ctx, f.src, f.tmp, progress); err != nil {
+			cleanup()
+			return xerrors.Errorf("copying %s: %w", f.src, err)
+		}
+	}
+
+	m.update(mv, func(mv *SectorMove) {
+		mv.State = MoveVerifying
+	})
+	for _, f := range files {
+		if err := verifyTree(f.src, f.tmp); err != nil {
+			cleanup()
+			return xerrors.Errorf("verifying copy of %s: %w", f.src, err)
+		}
+	}
+
+	for _, f := range files {
+		if err := os.Rename(f.tmp, f.dst); err != nil {
+			cleanup()
+			return xerrors.Errorf("renaming %s: %w", f.tmp, err)
+		}
+	}
+
+	if err := m.index.StorageMoveDecl(m.ctx, mv.Sector, moveTypes, mv.From, mv.To); err != nil {
+		return xerrors.Errorf("updating sector index: %w", err)
+	}
+	m.local.reportStorage(m.ctx)
+	unlock()
+
+	removeAt := m.clock.Now().Add(m.RemoveDelay)
+	b, err := json.Marshal(pendingRemoval{Move: *mv, RemoveAt: removeAt})
+	if err == nil {
+		err = m.ds.Put(pendingKey(mv.Sector), b)
+	}
+	if err != nil {
+		log.Errorf("saving pending removal of sector %d: %+v", mv.Sector.Number, err)
+	}
+
+	return m.removeSource(mv, removeAt)
+}
+
+// removeSource removes the source copy of a move switched to its new copy at
+// removeAt
+func (m *Mover) removeSource(mv *SectorMove, removeAt time.Time) error {
+	m.update(mv, func(mv *SectorMove) {
+		mv.State = MoveRemoving
+	})
+
+	select {
+	case <-m.clock.After(removeAt.Sub(m.clock.Now())):
+	case <-m.ctx.Done():
+		// the removal is finished on restart
+		return xerrors.Errorf("node shutting down, source copy not removed yet")
+	}
+
+	files, err := m.moveFiles(mv)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.RemoveAll(f.src); err != nil {
+			return xerrors.Errorf("removing source copy %s: %w", f.src, err)
+		}
+	}
+	m.local.reportStorage(m.ctx)
+
+	if err := m.ds.Delete(pendingKey(mv.Sector)); err != nil {
+		return xerrors.Errorf("deleting pending removal: %w", err)
+	}
+	return nil
+}
+
+func (st *Local) localPath(id ID) (string, bool) {
+	st.localLk.RLock()
+	defer st.localLk.RUnlock()
+
+	p, ok := st.paths[id]
+	if !ok || p.local == "" {
+		return "", false
+	}
+	return p.local, true
+}
+
+func pathSize(p string) (int64, error) {
+	var size int64
+	err := filepath.Walk(p, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// copyTree copies a file, or a directory of files, reporting the progress
+// of the copy.
+func copyTree(ctx context.Context, src, dst string, progress func(int64)) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, 0755)
+		case info.Mode().IsRegular():
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			return copyFile(p, target, progress)
+		default:
+			return xerrors.Errorf("unexpected file type of %s: %s", p, info.Mode())
+		}
+	})
+}
+
+type progressWriter func(int64)
+
+func (pw progressWriter) Write(b []byte) (int, error) {
+	pw(int64(len(b)))
+	return len(b), nil
+}
+
+func copyFile(src, dst string, progress func(int64)) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close() // nolint
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(io.MultiWriter(out, progressWriter(progress)), in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// verifyTree compares the checksums of all files of a copy with the original.
+func verifyTree(src, dst string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+
+		sh, err := fileChecksum(p)
+		if err != nil {
+			return err
+		}
+		dh, err := fileChecksum(filepath.Join(dst, rel))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(sh, dh) {
+			return xerrors.Errorf("checksum mismatch for %s", rel)
+		}
+		return nil
+	})
+}
+
+func fileChecksum(p string) ([]byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,195 @@
+package stores
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+// reopenStorage opens the paths of the local storage again, in a new index,
+// like on a restart
+func reopenStorage(t *testing.T, old *Local) (*Local, *Index) {
+	ctx := context.Background()
+	index := NewIndex()
+
+	st, err := NewLocal(ctx, &TestingLocalStorage{root: t.TempDir()}, index, nil)
+	require.NoError(t, err)
+
+	old.localLk.RLock()
+	var paths []string
+	for _, p := range old.paths {
+		paths = append(paths, p.local)
+	}
+	old.localLk.RUnlock()
+
+	for _, p := range paths {
+		require.NoError(t, st.OpenPath(ctx, p))
+	}
+	return st, index
+}
+
+// sectorPaths returns the paths the sealed file of the sector is declared in
+func sectorPaths(t *testing.T, index *Index, sid abi.SectorID) []ID {
+	si, err := index.StorageFindSector(context.Background(), sid, storiface.FTSealed, 0, false)
+	require.NoError(t, err)
+
+	var out []ID
+	for _, info := range si {
+		out = append(out, info.ID)
+	}
+	return out
+}
+
+func moveState(m *Mover, sid abi.SectorID) (SectorMove, bool) {
+	for _, mv := range m.List() {
+		if mv.Sector == sid {
+			return mv, true
+		}
+	}
+	return SectorMove{}, false
+}
+
+// requireMoveState waits for the move of the sector to reach the state,
+// advancing the clock by step on each check
+func requireMoveState(t *testing.T, m *Mover, clk *clock.Mock, step time.Duration, sid abi.SectorID, state MoveState) SectorMove {
+	var mv SectorMove
+	require.Eventually(t, func() bool {
+		clk.Add(step)
+		mv, _ = moveState(m, sid)
+		return mv.State == state
+	}, 5*time.Second, 10*time.Millisecond)
+	return mv
+}
+
+func TestMoverRemovesSource(t *testing.T) {
+	ctx := context.Background()
+	sid := abi.SectorID{Miner: 1000, Number: 1}
+
+	st, index, ids := testStorage(t, []string{"a", "b"}, sid)
+	from, to := ids[0], ids[1]
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	m := NewMover(ctx, st, index, ds)
+	clk := clock.NewMock()
+	m.clock = clk
+	m.RemoveDelay = time.Hour
+
+	require.NoError(t, m.Move(ctx, sid, to))
+	require.Error(t, m.Move(ctx, sid, to))
+
+	// the index switches to the new copy right away, the source copy is kept
+	// for RemoveDelay
+	mv := requireMoveState(t, m, clk, 0, sid, MoveRemoving)
+	require.EqualValues(t, len("sealed s-t01000-1")+len("aux s-t01000-1"), mv.BytesTotal)
+	require.Equal(t, mv.BytesTotal, mv.BytesCopied)
+	require.Equal(t, []ID{to}, sectorPaths(t, index, sid))
+	require.True(t, sectorIn(t, st, from, sid))
+	require.True(t, sectorIn(t, st, to, sid))
+
+	has, err := ds.Has(pendingKey(sid))
+	require.NoError(t, err)
+	require.True(t, has)
+
+	requireMoveState(t, m, clk, time.Minute, sid, MoveDone)
+	require.False(t, sectorIn(t, st, from, sid))
+	require.True(t, sectorIn(t, st, to, sid))
+
+	dst, ok := st.localPath(to)
+	require.True(t, ok)
+	b, err := ioutil.ReadFile(filepath.Join(dst, storiface.FTCache.String(), "s-t01000-1", "p_aux"))
+	require.NoError(t, err)
+	require.Equal(t, "aux s-t01000-1", string(b))
+
+	has, err = ds.Has(pendingKey(sid))
+	require.NoError(t, err)
+	require.False(t, has)
+}
+
+func TestMoverResume(t *testing.T) {
+	sid := abi.SectorID{Miner: 1000, Number: 1}
+
+	st, index, ids := testStorage(t, []string{"a", "b"}, sid)
+	from, to := ids[0], ids[1]
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	ctx, shutdown := context.WithCancel(context.Background())
+	m := NewMover(ctx, st, index, ds)
+	clk := clock.NewMock()
+	m.clock = clk
+	m.RemoveDelay = time.Hour
+
+	require.NoError(t, m.Move(ctx, sid, to))
+	requireMoveState(t, m, clk, 0, sid, MoveRemoving)
+
+	// the node shuts down before the source copy is removed
+	clk.Add(30 * time.Minute)
+	shutdown()
+	requireMoveState(t, m, clk, 0, sid, MoveFailed)
+	require.True(t, sectorIn(t, st, from, sid))
+
+	// on restart both copies are declared again, until the move is resumed
+	st, index = reopenStorage(t, st)
+	require.ElementsMatch(t, []ID{from, to}, sectorPaths(t, index, sid))
+
+	m = NewMover(context.Background(), st, index, ds)
+	m.clock = clk
+	require.NoError(t, m.Resume())
+
+	// the source copy is dropped from the index right away, and removed
+	// once the rest of the delay passed
+	require.Eventually(t, func() bool {
+		paths := sectorPaths(t, index, sid)
+		return len(paths) == 1 && paths[0] == to
+	}, 5*time.Second, 10*time.Millisecond)
+	require.True(t, sectorIn(t, st, from, sid))
+
+	mv := requireMoveState(t, m, clk, time.Minute, sid, MoveDone)
+	require.Equal(t, from, mv.From)
+	require.Equal(t, to, mv.To)
+	require.False(t, sectorIn(t, st, from, sid))
+	require.True(t, sectorIn(t, st, to, sid))
+
+	has, err := ds.Has(pendingKey(sid))
+	require.NoError(t, err)
+	require.False(t, has)
+}
+
+func TestVerifyTree(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "s-t01000-1")
+	writeTree := func(root string, files map[string]string) {
+		for name, content := range files {
+			p := filepath.Join(root, name)
+			require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+			require.NoError(t, ioutil.WriteFile(p, []byte(content), 0644))
+		}
+	}
+	writeTree(src, map[string]string{"p_aux": "aux", "sc-02-data-tree-r-last.dat": "tree"})
+
+	var copied int64
+	dst := filepath.Join(t.TempDir(), "s-t01000-1.move")
+	require.NoError(t, copyTree(context.Background(), src, dst, func(n int64) {
+		copied += n
+	}))
+	require.EqualValues(t, len("aux")+len("tree"), copied)
+	require.NoError(t, verifyTree(src, dst))
+
+	// a corrupted copy doesn't pass verification
+	writeTree(dst, map[string]string{"p_aux": "axu"})
+	require.Error(t, verifyTree(src, dst))
+
+	// and neither does an incomplete one
+	require.NoError(t, os.Remove(filepath.Join(dst, "p_aux")))
+	require.Error(t, verifyTree(src, dst))
+}
@@ -0,0 +1,702 @@
+package stores
+
+import (
+	"context"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/fsutil"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+type ObjectStoreConfig struct {
+	// Endpoint of the S3-compatible API, e.g. https://s3.us-east-1.amazonaws.com;
+	// object storage is disabled when empty
+	Endpoint string
+	Region   string
+	Bucket   string
+	// Prefix of the keys of sector objects in the bucket
+	Prefix string
+	// Use path-style (endpoint/bucket/key) instead of virtual-hosted style
+	// (bucket.endpoint/key) requests, which most self-hosted stores need
+	PathStyle bool
+
+	// Credentials, AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY are used when empty
+	AccessKey string
+	SecretKey string
+
+	// Weight of the object store when choosing where to fetch sectors from
+	Weight uint64
+
+	// Local directory sector files are cached in when they are read, e.g. for
+	// PoSt; the miner repo 'objcache' directory when empty
+	CacheDir string
+	// Maximum size of the read cache in bytes, unlimited when zero. The cache
+	// should be large enough to hold all sectors of a proving deadline.
+	CacheMaxSize int64
+}
+
+// objectStoreTypes are the sector file types kept in the object store; unsealed
+// files stay in local storage, as they are read in small, random pieces.
+const objectStoreTypes = storiface.FTSealed | storiface.FTCache
+
+// ObjectStore keeps sealed sectors in an S3-compatible object store for
+// long-term retention. It wraps local storage: sectors are sealed locally, and
+// uploaded when moved to long-term storage. When sector files stored only in
+// the object store are acquired, they are downloaded into a local read-through
+// cache.
+//
+// In the sector index the object store is a storage path with an s3:// URL,
+// which workers can't fetch from; sector files are only read from the object
+// store by the node it's configured on.
+type ObjectStore struct {
+	local Store
+	index SectorIndex
+
+	id     ID
+	prefix string
+	client *s3Client
+
+	cache *objectCache
+
+	fetchLk  sync.Mutex
+	fetching map[string]chan struct{}
+}
+
+func NewObjectStore(ctx context.Context, local Store, index SectorIndex, cfg ObjectStoreConfig) (*ObjectStore, error) {
+	if cfg.AccessKey == "" {
+		cfg.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if cfg.SecretKey == "" {
+		cfg.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+
+	client, err := newS3Client(cfg.Endpoint, cfg.Region, cfg.Bucket, cfg.PathStyle, cfg.AccessKey, cfg.SecretKey)
+	if err != nil {
+		return nil, xerrors.Errorf("creating s3 client: %w", err)
+	}
+
+	cache, err := openObjectCache(cfg.CacheDir, cfg.CacheMaxSize)
+	if err != nil {
+		return nil, xerrors.Errorf("opening read cache: %w", err)
+	}
+
+	prefix := strings.Trim(cfg.Prefix, "/")
+	bucketURL := "s3://" + cfg.Bucket + "/" + prefix
+
+	o := &ObjectStore{
+		local: local,
+		index: index,
+
+		// the same bucket and prefix always get the same ID
+		id:     ID(uuid.NewSHA1(uuid.NameSpaceURL, []byte(bucketURL)).String()),
+		prefix: prefix,
+		client: client,
+
+		cache: cache,
+
+		fetching: map[string]chan struct{}{},
+	}
+
+	if err := index.StorageAttach(ctx, StorageInfo{
+		ID:         o.id,
+		URLs:       []string{bucketURL},
+		Weight:     cfg.Weight,
+		CanStore:   true,
+		AllowTypes: []string{storiface.FTSealed.String(), storiface.FTCache.String()},
+	}, o.stat()); err != nil {
+		return nil, xerrors.Errorf("attaching object store: %w", err)
+	}
+
+	if err := o.declareSectors(ctx); err != nil {
+		return nil, xerrors.Errorf("declaring sectors: %w", err)
+	}
+
+	go o.reportHealth(ctx)
+
+	return o, nil
+}
+
+func (o *ObjectStore) stat() fsutil.FsStat {
+	// object stores don't run out of space
+	const unlimited = 1 << 62
+	return fsutil.FsStat{
+		Capacity:    unlimited,
+		Available:   unlimited,
+		FSAvailable: unlimited,
+	}
+}
+
+func (o *ObjectStore) key(elems ...string) string {
+	if o.prefix != "" {
+		elems = append([]string{o.prefix}, elems...)
+	}
+	return strings.Join(elems, "/")
+}
+
+// declareSectors declares all sectors found in the object store in the index.
+func (o *ObjectStore) declareSectors(ctx context.Context) error {
+	for _, ft := range storiface.PathTypes {
+		if ft&objectStoreTypes == 0 {
+			continue
+		}
+
+		tprefix := o.key(ft.String()) + "/"
+		objs, err := o.client.listObjects(ctx, tprefix, 0)
+		if err != nil {
+			return xerrors.Errorf("listing %s objects: %w", ft, err)
+		}
+
+		declared := map[string]struct{}{}
+		for _, obj := range objs {
+			// cache directories are stored as one object per file
+			name := strings.SplitN(strings.TrimPrefix(obj.Key, tprefix), "/", 2)[0]
+			if _, ok := declared[name]; ok {
+				continue
+			}
+			declared[name] = struct{}{}
+
+			sid, err := storiface.ParseSectorID(name)
+			if err != nil {
+				log.Warnf("unexpected object %s in the object store: %s", obj.Key, err)
+				continue
+			}
+
+			if err := o.index.StorageDeclareSector(ctx, o.id, sid, ft, true); err != nil {
+				return xerrors.Errorf("declaring sector %s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (o *ObjectStore) reportHealth(ctx context.Context) {
+	// the object store is checked less often than local paths, requests cost money
+	interval := 6 * HeartbeatInterval
+
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
+
+		report := HealthReport{Stat: o.stat()}
+		if _, err := o.client.listObjects(ctx, o.key(), 1); err != nil {
+			report.Err = err.Error()
+		}
+
+		if err := o.index.StorageReportHealth(ctx, o.id, report); err != nil {
+			log.Warnf("error reporting object store health: %+v", err)
+		}
+	}
+}
+
+// storedTypes returns the types of sector files stored in the object store.
+func (o *ObjectStore) storedTypes(ctx context.Context, s abi.SectorID, types storiface.SectorFileType) (storiface.SectorFileType, error) {
+	var out storiface.SectorFileType
+	for _, ft := range storiface.PathTypes {
+		if ft&types&objectStoreTypes == 0 {
+			continue
+		}
+
+		si, err := o.index.StorageFindSector(ctx, s, ft, 0, false)
+		if err != nil {
+			return 0, err
+		}
+		for _, info := range si {
+			if info.ID == o.id {
+				out |= ft
+			}
+		}
+	}
+	return out, nil
+}
+
+func (o *ObjectStore) AcquireSector(ctx context.Context, s storage.SectorRef, existing storiface.SectorFileType, allocate storiface.SectorFileType, pathType storiface.PathType, op storiface.AcquireMode) (storiface.SectorPaths, storiface.SectorPaths, error) {
+	paths, ids, err := o.local.AcquireSector(ctx, s, existing, allocate, pathType, op)
+	if err != nil {
+		return storiface.SectorPaths{}, storiface.SectorPaths{}, err
+	}
+
+	var missing storiface.SectorFileType
+	for _, ft := range storiface.PathTypes {
+		if ft&existing != 0 && storiface.PathByType(paths, ft) == "" {
+			missing |= ft
+		}
+	}
+
+	stored, err := o.storedTypes(ctx, s.ID, missing)
+	if err != nil {
+		return storiface.SectorPaths{}, storiface.SectorPaths{}, xerrors.Errorf("finding sector in object store: %w", err)
+	}
+
+	for _, ft := range storiface.PathTypes {
+		if ft&stored == 0 {
+			continue
+		}
+
+		// the file stays in the cache until ctx is done, path providers
+		// cancel it when the paths are released
+		p, err := o.fetch(ctx, s.ID, ft)
+		if err != nil {
+			return storiface.SectorPaths{}, storiface.SectorPaths{}, xerrors.Errorf("fetching %s of sector %d from object store: %w", ft, s.ID.Number, err)
+		}
+
+		storiface.SetPathByType(&paths, ft, p)
+		storiface.SetPathByType(&ids, ft, string(o.id))
+	}
+
+	return paths, ids, nil
+}
+
+// fetch returns the path of the sector file in the read cache, downloading it
+// first if it isn't cached. The file is pinned in the cache until ctx is done.
+func (o *ObjectStore) fetch(ctx context.Context, s abi.SectorID, ft storiface.SectorFileType) (string, error) {
+	name := storiface.SectorName(s)
+	ckey := filepath.Join(ft.String(), name)
+
+	for {
+		o.fetchLk.Lock()
+		c, fetching := o.fetching[ckey]
+		if !fetching {
+			o.fetching[ckey] = make(chan struct{})
+			o.fetchLk.Unlock()
+			break
+		}
+		o.fetchLk.Unlock()
+
+		select {
+		case <-c:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	defer func() {
+		o.fetchLk.Lock()
+		close(o.fetching[ckey])
+		delete(o.fetching, ckey)
+		o.fetchLk.Unlock()
+	}()
+
+	if p, ok := o.cache.pin(ckey); ok {
+		o.unpinWhenDone(ctx, ckey)
+		return p, nil
+	}
+
+	tprefix := o.key(ft.String(), name)
+	objs, err := o.client.listObjects(ctx, tprefix, 0)
+	if err != nil {
+		return "", xerrors.Errorf("listing objects: %w", err)
+	}
+	if len(objs) == 0 {
+		return "", xerrors.Errorf("no objects found: %w", storiface.ErrSectorNotFound)
+	}
+
+	tmp, err := o.cache.tempPath(ckey)
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp) // nolint
+
+	log.Infof("fetching %s from object store", tprefix)
+
+	var size int64
+	for _, obj := range objs {
+		var dest string
+		switch {
+		case obj.Key == tprefix:
+			dest = tmp
+		case strings.HasPrefix(obj.Key, tprefix+"/"):
+			dest = filepath.Join(tmp, filepath.FromSlash(strings.TrimPrefix(obj.Key, tprefix+"/")))
+		default:
+			// another sector with the same name prefix
+			continue
+		}
+
+		if err := o.download(ctx, obj.Key, dest); err != nil {
+			return "", xerrors.Errorf("downloading %s: %w", obj.Key, err)
+		}
+		size += obj.Size
+	}
+
+	p, err := o.cache.add(ckey, tmp, size, true)
+	if err != nil {
+		return "", err
+	}
+	o.unpinWhenDone(ctx, ckey)
+	return p, nil
+}
+
+func (o *ObjectStore) unpinWhenDone(ctx context.Context, key string) {
+	go func() {
+		<-ctx.Done()
+		o.cache.unpin(key)
+	}()
+}
+
+func (o *ObjectStore) download(ctx context.Context, key string, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if err := o.client.getObject(ctx, key, f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// upload uploads a sector file, or all files of a sector directory.
+func (o *ObjectStore) upload(ctx context.Context, src string, key string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+
+		okey := key
+		if p != src {
+			rel, err := filepath.Rel(src, p)
+			if err != nil {
+				return err
+			}
+			okey += "/" + filepath.ToSlash(rel)
+		}
+
+		if err := o.client.uploadFile(ctx, okey, p); err != nil {
+			return xerrors.Errorf("uploading %s: %w", p, err)
+		}
+		return nil
+	})
+}
+
+func (o *ObjectStore) remove(ctx context.Context, s abi.SectorID, ft storiface.SectorFileType) error {
+	tprefix := o.key(ft.String(), storiface.SectorName(s))
+	objs, err := o.client.listObjects(ctx, tprefix, 0)
+	if err != nil {
+		return xerrors.Errorf("listing objects: %w", err)
+	}
+
+	for _, obj := range objs {
+		if obj.Key != tprefix && !strings.HasPrefix(obj.Key, tprefix+"/") {
+			continue
+		}
+		if err := o.client.deleteObject(ctx, obj.Key); err != nil {
+			return xerrors.Errorf("deleting %s: %w", obj.Key, err)
+		}
+	}
+
+	if err := o.index.StorageDropSector(ctx, o.id, s, ft); err != nil {
+		return xerrors.Errorf("dropping sector from index: %w", err)
+	}
+
+	o.cache.remove(filepath.Join(ft.String(), storiface.SectorName(s)))
+	return nil
+}
+
+func (o *ObjectStore) Remove(ctx context.Context, s abi.SectorID, types storiface.SectorFileType, force bool) error {
+	if bits.OnesCount(uint(types)) != 1 {
+		return xerrors.New("delete expects one file type")
+	}
+
+	stored, err := o.storedTypes(ctx, s, types)
+	if err != nil {
+		return xerrors.Errorf("finding sector in object store: %w", err)
+	}
+
+	if err := o.local.Remove(ctx, s, types, force); err != nil {
+		return err
+	}
+
+	if stored != 0 {
+		return o.remove(ctx, s, types)
+	}
+	return nil
+}
+
+func (o *ObjectStore) RemoveCopies(ctx context.Context, s abi.SectorID, types storiface.SectorFileType) error {
+	return o.local.RemoveCopies(ctx, s, types)
+}
+
+// MoveStorage uploads sealed and cache files to the object store; other file
+// types are moved to local long-term storage. Uploaded files are kept in the
+// read cache, sectors are usually proven soon after they are sealed.
+func (o *ObjectStore) MoveStorage(ctx context.Context, s storage.SectorRef, types storiface.SectorFileType) error {
+	upload := types & objectStoreTypes
+	if rest := types &^ upload; rest != 0 {
+		if err := o.local.MoveStorage(ctx, s, rest); err != nil {
+			return err
+		}
+	}
+	if upload == 0 {
+		return nil
+	}
+
+	stored, err := o.storedTypes(ctx, s.ID, upload)
+	if err != nil {
+		return xerrors.Errorf("finding sector in object store: %w", err)
+	}
+	upload &^= stored
+
+	src, _, err := o.local.AcquireSector(ctx, s, upload, storiface.FTNone, storiface.PathStorage, storiface.AcquireMove)
+	if err != nil {
+		return xerrors.Errorf("acquire src storage: %w", err)
+	}
+
+	for _, ft := range storiface.PathTypes {
+		if ft&upload == 0 {
+			continue
+		}
+
+		p := storiface.PathByType(src, ft)
+		if p == "" {
+			return xerrors.Errorf("sector %d %s not found in local storage", s.ID.Number, ft)
+		}
+
+		size, err := pathSize(p)
+		if err != nil {
+			return xerrors.Errorf("getting size of %s: %w", p, err)
+		}
+
+		log.Infof("uploading %s to object store", p)
+		if err := o.upload(ctx, p, o.key(ft.String(), storiface.SectorName(s.ID))); err != nil {
+			return xerrors.Errorf("uploading sector %d %s: %w", s.ID.Number, ft, err)
+		}
+
+		if err := o.index.StorageDeclareSector(ctx, o.id, s.ID, ft, true); err != nil {
+			return xerrors.Errorf("declaring sector %d %s: %w", s.ID.Number, ft, err)
+		}
+
+		// keep the local copy in the read cache, then drop all local copies
+		if _, err := o.cache.add(filepath.Join(ft.String(), storiface.SectorName(s.ID)), p, size, false); err != nil {
+			log.Warnf("caching uploaded %s: %s", p, err)
+		}
+		if err := o.local.Remove(ctx, s.ID, ft, true); err != nil {
+			return xerrors.Errorf("removing local copies of sector %d %s: %w", s.ID.Number, ft, err)
+		}
+	}
+
+	return nil
+}
+
+func (o *ObjectStore) FsStat(ctx context.Context, id ID) (fsutil.FsStat, error) {
+	if id == o.id {
+		return o.stat(), nil
+	}
+	return o.local.FsStat(ctx, id)
+}
+
+func (o *ObjectStore) Reserve(ctx context.Context, sid storage.SectorRef, ft storiface.SectorFileType, storageIDs storiface.SectorPaths, overheadTab map[storiface.SectorFileType]int) (func(), error) {
+	// nothing to reserve in the object store
+	for _, t := range storiface.PathTypes {
+		if t&ft != 0 && ID(storiface.PathByType(storageIDs, t)) == o.id {
+			ft &^= t
+		}
+	}
+	return o.local.Reserve(ctx, sid, ft, storageIDs, overheadTab)
+}
+
+var _ Store = &ObjectStore{}
+
+type objectCacheEntry struct {
+	size       int64
+	lastAccess time.Time
+
+	// pins counts the users of the file, pinned files aren't evicted
+	pins int
+}
+
+// objectCache is a size-limited local cache of sector files, evicting the
+// least recently used files first. Files being read are pinned, the cache may
+// grow over its size limit while they are.
+type objectCache struct {
+	dir     string
+	maxSize int64
+
+	lk      sync.Mutex
+	size    int64
+	entries map[string]*objectCacheEntry
+}
+
+const objectCacheTempDir = "fetching"
+
+func openObjectCache(dir string, maxSize int64) (*objectCache, error) {
+	if dir == "" {
+		return nil, xerrors.New("no cache directory configured")
+	}
+
+	c := &objectCache{
+		dir:     dir,
+		maxSize: maxSize,
+		entries: map[string]*objectCacheEntry{},
+	}
+
+	// leftovers of interrupted fetches
+	if err := os.RemoveAll(filepath.Join(dir, objectCacheTempDir)); err != nil {
+		return nil, err
+	}
+
+	for _, ft := range storiface.PathTypes {
+		tdir := filepath.Join(dir, ft.String())
+		if err := os.MkdirAll(tdir, 0755); err != nil {
+			return nil, err
+		}
+
+		ents, err := os.ReadDir(tdir)
+		if err != nil {
+			return nil, err
+		}
+		for _, ent := range ents {
+			info, err := ent.Info()
+			if err != nil {
+				return nil, err
+			}
+			size, err := pathSize(filepath.Join(tdir, ent.Name()))
+			if err != nil {
+				return nil, err
+			}
+
+			c.entries[filepath.Join(ft.String(), ent.Name())] = &objectCacheEntry{
+				size:       size,
+				lastAccess: info.ModTime(),
+			}
+			c.size += size
+		}
+	}
+
+	return c, nil
+}
+
+// pin returns the path of a cached file, pinning it until unpin is called
+func (c *objectCache) pin(key string) (string, bool) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	ent, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	ent.lastAccess = time.Now()
+	ent.pins++
+	return filepath.Join(c.dir, key), true
+}
+
+func (c *objectCache) unpin(key string) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	ent, ok := c.entries[key]
+	if !ok || ent.pins == 0 {
+		return
+	}
+	ent.pins--
+
+	// files added while this one was pinned may have been kept over the limit
+	c.evict("")
+}
+
+func (c *objectCache) tempPath(key string) (string, error) {
+	tmp := filepath.Join(c.dir, objectCacheTempDir, key)
+	if err := os.MkdirAll(filepath.Dir(tmp), 0755); err != nil {
+		return "", err
+	}
+	return tmp, os.RemoveAll(tmp)
+}
+
+// add moves the file at src into the cache, evicting other files if the cache
+// grows too large. With pin, the file is pinned until unpin is called.
+func (c *objectCache) add(key string, src string, size int64, pin bool) (string, error) {
+	dest := filepath.Join(c.dir, key)
+	if err := os.RemoveAll(dest); err != nil {
+		return "", err
+	}
+	if err := move(src, dest); err != nil {
+		return "", xerrors.Errorf("moving into cache: %w", err)
+	}
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	ent := &objectCacheEntry{size: size, lastAccess: time.Now()}
+	if old, ok := c.entries[key]; ok {
+		c.size -= old.size
+		ent.pins = old.pins
+	}
+	if pin {
+		ent.pins++
+	}
+	c.entries[key] = ent
+	c.size += size
+
+	c.evict(key)
+	return dest, nil
+}
+
+// evict must be called with lk held.
+func (c *objectCache) evict(keep string) {
+	if c.maxSize <= 0 || c.size <= c.maxSize {
+		return
+	}
+
+	keys := make([]string, 0, len(c.entries))
+	for k := range c.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.entries[keys[i]].lastAccess.Before(c.entries[keys[j]].lastAccess)
+	})
+
+	for _, k := range keys {
+		if c.size <= c.maxSize {
+			return
+		}
+		if k == keep || c.entries[k].pins > 0 {
+			continue
+		}
+
+		log.Debugf("evicting %s from object store cache", k)
+		if err := os.RemoveAll(filepath.Join(c.dir, k)); err != nil {
+			log.Warnf("evicting %s from object store cache: %s", k, err)
+			continue
+		}
+		c.size -= c.entries[k].size
+		delete(c.entries, k)
+	}
+}
+
+func (c *objectCache) remove(key string) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	ent, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	if err := os.RemoveAll(filepath.Join(c.dir, key)); err != nil {
+		log.Warnf("removing %s from object store cache: %s", key, err)
+		return
+	}
+	c.size -= ent.size
+	delete(c.entries, key)
+}
@@ -0,0 +1,234 @@
+package stores
+
+import (
+	"context"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+// addTestFile adds a file of the size to the cache
+func addTestFile(t *testing.T, c *objectCache, key string, size int, pin bool) {
+	src := filepath.Join(t.TempDir(), filepath.Base(key))
+	require.NoError(t, ioutil.WriteFile(src, make([]byte, size), 0644))
+
+	_, err := c.add(key, src, int64(size), pin)
+	require.NoError(t, err)
+}
+
+// requireCached checks that the file is cached, or isn't, both in the cache
+// entries and on disk
+func requireCached(t *testing.T, c *objectCache, key string, want bool) {
+	c.lk.Lock()
+	_, ok := c.entries[key]
+	c.lk.Unlock()
+	require.Equal(t, want, ok, key)
+
+	_, err := os.Stat(filepath.Join(c.dir, key))
+	require.Equal(t, want, err == nil, key)
+}
+
+func TestObjectCacheEvict(t *testing.T) {
+	c, err := openObjectCache(t.TempDir(), 20)
+	require.NoError(t, err)
+
+	addTestFile(t, c, "sealed/s-t01000-1", 8, false)
+	time.Sleep(time.Millisecond)
+	addTestFile(t, c, "sealed/s-t01000-2", 8, false)
+	time.Sleep(time.Millisecond)
+
+	// reading the first file makes the second the least recently used
+	_, ok := c.pin("sealed/s-t01000-1")
+	require.True(t, ok)
+	c.unpin("sealed/s-t01000-1")
+
+	addTestFile(t, c, "sealed/s-t01000-3", 8, false)
+	requireCached(t, c, "sealed/s-t01000-1", true)
+	requireCached(t, c, "sealed/s-t01000-2", false)
+	requireCached(t, c, "sealed/s-t01000-3", true)
+	require.EqualValues(t, 16, c.size)
+
+	// files too large for the cache are kept until the next one is added
+	addTestFile(t, c, "cache/s-t01000-4", 30, false)
+	requireCached(t, c, "cache/s-t01000-4", true)
+	require.EqualValues(t, 30, c.size)
+
+	c.remove("cache/s-t01000-4")
+	requireCached(t, c, "cache/s-t01000-4", false)
+	require.EqualValues(t, 0, c.size)
+
+	// the cache is loaded from disk on restart
+	addTestFile(t, c, "sealed/s-t01000-5", 8, false)
+	reopened, err := openObjectCache(c.dir, 20)
+	require.NoError(t, err)
+	requireCached(t, reopened, "sealed/s-t01000-5", true)
+	require.EqualValues(t, 8, reopened.size)
+}
+
+func TestObjectCachePinned(t *testing.T) {
+	c, err := openObjectCache(t.TempDir(), 20)
+	require.NoError(t, err)
+
+	// a file being read isn't evicted, even when it's the least recently used
+	addTestFile(t, c, "sealed/s-t01000-1", 8, true)
+	time.Sleep(time.Millisecond)
+	addTestFile(t, c, "sealed/s-t01000-2", 8, false)
+	time.Sleep(time.Millisecond)
+	addTestFile(t, c, "sealed/s-t01000-3", 8, false)
+
+	requireCached(t, c, "sealed/s-t01000-1", true)
+	requireCached(t, c, "sealed/s-t01000-2", false)
+	requireCached(t, c, "sealed/s-t01000-3", true)
+
+	// with all files pinned, the cache grows over its limit
+	_, ok := c.pin("sealed/s-t01000-3")
+	require.True(t, ok)
+	addTestFile(t, c, "sealed/s-t01000-4", 8, true)
+	require.EqualValues(t, 24, c.size)
+
+	// and shrinks back once they are released, by the time the last user of
+	// a file is done
+	_, ok = c.pin("sealed/s-t01000-1")
+	require.True(t, ok)
+	c.unpin("sealed/s-t01000-1")
+	requireCached(t, c, "sealed/s-t01000-1", true)
+
+	c.unpin("sealed/s-t01000-1")
+	requireCached(t, c, "sealed/s-t01000-1", false)
+	require.EqualValues(t, 16, c.size)
+
+	// unpinning files which aren't cached or pinned is harmless
+	c.unpin("sealed/s-t01000-1")
+	c.unpin("sealed/s-t01000-3")
+	c.unpin("sealed/s-t01000-3")
+	requireCached(t, c, "sealed/s-t01000-3", true)
+}
+
+// testS3 serves the objects with the parts of the S3 API used by the object
+// store, counting object downloads
+func testS3(t *testing.T, objects map[string]string) (*s3Client, *int64) {
+	var gets int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+
+		if r.URL.Query().Get("list-type") == "2" {
+			type content struct {
+				Key  string
+				Size int64
+			}
+			var res struct {
+				XMLName  xml.Name `xml:"ListBucketResult"`
+				Contents []content
+			}
+			prefix := r.URL.Query().Get("prefix")
+			for k, v := range objects {
+				if strings.HasPrefix(k, prefix) {
+					res.Contents = append(res.Contents, content{Key: k, Size: int64(len(v))})
+				}
+			}
+			_ = xml.NewEncoder(w).Encode(res)
+			return
+		}
+
+		v, ok := objects[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt64(&gets, 1)
+		_, _ = w.Write([]byte(v))
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := newS3Client(srv.URL, "", "bucket", true, "key", "secret")
+	require.NoError(t, err)
+	return c, &gets
+}
+
+func TestObjectStoreFetch(t *testing.T) {
+	client, gets := testS3(t, map[string]string{
+		"p/sealed/s-t01000-1":         "sealed 1",
+		"p/sealed/s-t01000-2":         "sealed 2",
+		"p/cache/s-t01000-1/p_aux":    "aux",
+		"p/cache/s-t01000-1/t_aux":    "taux",
+		"p/cache/s-t01000-10/p_aux":   "other sector",
+		"p/sealed/s-t01000-3-ignored": "unrelated",
+	})
+
+	cache, err := openObjectCache(t.TempDir(), 10)
+	require.NoError(t, err)
+
+	o := &ObjectStore{
+		prefix:   "p",
+		client:   client,
+		cache:    cache,
+		fetching: map[string]chan struct{}{},
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	defer cancel1()
+
+	s1 := abi.SectorID{Miner: 1000, Number: 1}
+	p, err := o.fetch(ctx1, s1, storiface.FTSealed)
+	require.NoError(t, err)
+	b, err := ioutil.ReadFile(p)
+	require.NoError(t, err)
+	require.Equal(t, "sealed 1", string(b))
+	require.EqualValues(t, 1, atomic.LoadInt64(gets))
+
+	// cached files aren't downloaded again
+	again, err := o.fetch(ctx1, s1, storiface.FTSealed)
+	require.NoError(t, err)
+	require.Equal(t, p, again)
+	require.EqualValues(t, 1, atomic.LoadInt64(gets))
+
+	// cache directories are downloaded with all their files, and only theirs
+	cp, err := o.fetch(ctx1, s1, storiface.FTCache)
+	require.NoError(t, err)
+	for name, content := range map[string]string{"p_aux": "aux", "t_aux": "taux"} {
+		b, err := ioutil.ReadFile(filepath.Join(cp, name))
+		require.NoError(t, err)
+		require.Equal(t, content, string(b))
+	}
+	files, err := ioutil.ReadDir(cp)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	// the files of the first sector are still read, the second one goes over
+	// the cache limit
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+
+	p2, err := o.fetch(ctx2, abi.SectorID{Miner: 1000, Number: 2}, storiface.FTSealed)
+	require.NoError(t, err)
+	_, err = os.Stat(p)
+	require.NoError(t, err)
+	_, err = os.Stat(p2)
+	require.NoError(t, err)
+
+	// once done, they can be evicted
+	cancel1()
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(p)
+		return os.IsNotExist(err)
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = os.Stat(p2)
+	require.NoError(t, err)
+
+	_, err = o.fetch(ctx2, abi.SectorID{Miner: 1000, Number: 4}, storiface.FTSealed)
+	require.True(t, xerrors.Is(err, storiface.ErrSectorNotFound), err)
+}
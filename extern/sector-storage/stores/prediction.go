@@ -0,0 +1,399 @@
+package stores
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+// Fault prediction watches the health of the devices backing the local
+// storage paths. Telemetry collectors sample health indicators of each path,
+// such as SMART attributes and IO latency; paths with indicators over the
+// policy thresholds are flagged as at risk, and the sealed sectors stored in
+// them can be moved to healthy paths, or declared faulty before a window PoSt
+// fails to read them.
+
+// PathTelemetry is a sample of the health indicators of a storage path.
+type PathTelemetry struct {
+	// SMART attributes of the device, -1 when no collector reports them
+	ReallocatedSectors  int64
+	PendingSectors      int64
+	UncorrectableErrors int64
+
+	// Latency of a small synced write to the path, read back; zero when not
+	// measured
+	Latency time.Duration
+}
+
+// TelemetryCollector samples the telemetry of a local storage path, setting
+// the fields of the sample it knows about.
+type TelemetryCollector interface {
+	Collect(ctx context.Context, path string, t *PathTelemetry) error
+}
+
+type PredictionAction string
+
+const (
+	// PredictAlert only flags the paths at risk
+	PredictAlert PredictionAction = "alert"
+	// PredictMove moves the sealed sectors of paths at risk to healthy paths
+	PredictMove PredictionAction = "move"
+	// PredictDeclare declares the sectors of paths at risk faulty
+	PredictDeclare PredictionAction = "declare"
+)
+
+type PredictionPolicy struct {
+	CheckInterval time.Duration
+
+	// A path is at risk when any of its SMART attributes is over the maximum
+	MaxReallocatedSectors  int64
+	MaxPendingSectors      int64
+	MaxUncorrectableErrors int64
+
+	// A path is at risk after LatencySamples consecutive samples with a
+	// latency over MaxLatency; 0 disables the latency check
+	MaxLatency     time.Duration
+	LatencySamples int
+
+	Action PredictionAction
+}
+
+// PathRisk is the health of a local storage path, as last predicted.
+type PathRisk struct {
+	ID ID
+
+	AtRisk  bool
+	Reasons []string `json:",omitempty"`
+
+	Telemetry PathTelemetry
+	Checked   time.Time
+	// Err is set when collecting the telemetry failed
+	Err string `json:",omitempty"`
+}
+
+// FaultPredictor periodically collects the telemetry of the local storage
+// paths, and acts on the sectors stored in paths at risk as the policy says.
+type FaultPredictor struct {
+	policy     PredictionPolicy
+	collectors []TelemetryCollector
+
+	local *Local
+	index *Index
+	mover *Mover
+
+	lk    sync.Mutex
+	paths map[ID]*PathRisk
+	slow  map[ID]int // consecutive samples over the latency threshold
+}
+
+func NewFaultPredictor(policy PredictionPolicy, local *Local, index *Index, mover *Mover, collectors ...TelemetryCollector) (*FaultPredictor, error) {
+	switch policy.Action {
+	case PredictAlert, PredictMove, PredictDeclare:
+	default:
+		return nil, xerrors.Errorf("unknown fault prediction action %q", policy.Action)
+	}
+	if policy.CheckInterval <= 0 {
+		return nil, xerrors.Errorf("fault prediction check interval must be positive")
+	}
+	if len(collectors) == 0 {
+		return nil, xerrors.Errorf("no telemetry collectors")
+	}
+
+	return &FaultPredictor{
+		policy:     policy,
+		collectors: collectors,
+		local:      local,
+		index:      index,
+		mover:      mover,
+		paths:      map[ID]*PathRisk{},
+		slow:       map[ID]int{},
+	}, nil
+}
+
+func (p *FaultPredictor) Run(ctx context.Context) {
+	tick := time.NewTicker(p.policy.CheckInterval)
+	defer tick.Stop()
+
+	for {
+		if err := p.check(ctx); err != nil {
+			log.Errorf("predicting storage path faults: %+v", err)
+		}
+
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// PathRisks returns the last prediction for each local storage path.
+func (p *FaultPredictor) PathRisks() []PathRisk {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	out := make([]PathRisk, 0, len(p.paths))
+	for _, pr := range p.paths {
+		out = append(out, *pr)
+	}
+	sort.Slice(out, func(a, b int) bool {
+		return out[a].ID < out[b].ID
+	})
+	return out
+}
+
+// FaultySectors returns the sectors of the miner to declare faulty, which are
+// those with sealed files in paths at risk when the policy action is to
+// declare them. They are only considered recovered once their path is
+// healthy again, or they were moved out of it.
+func (p *FaultPredictor) FaultySectors(miner abi.ActorID) map[abi.SectorNumber]struct{} {
+	if p.policy.Action != PredictDeclare {
+		return nil
+	}
+
+	out := map[abi.SectorNumber]struct{}{}
+	for _, id := range p.atRisk() {
+		for _, sid := range p.index.pathSectors(id, storiface.FTSealed) {
+			if sid.Miner == miner {
+				out[sid.Number] = struct{}{}
+			}
+		}
+	}
+	return out
+}
+
+func (p *FaultPredictor) atRisk() []ID {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	var out []ID
+	for id, pr := range p.paths {
+		if pr.AtRisk {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func (p *FaultPredictor) check(ctx context.Context) error {
+	paths, err := p.local.Local(ctx)
+	if err != nil {
+		return xerrors.Errorf("listing local paths: %w", err)
+	}
+
+	seen := map[ID]struct{}{}
+	for _, path := range paths {
+		seen[path.ID] = struct{}{}
+
+		pr := PathRisk{
+			ID:      path.ID,
+			Checked: time.Now(),
+			Telemetry: PathTelemetry{
+				ReallocatedSectors:  -1,
+				PendingSectors:      -1,
+				UncorrectableErrors: -1,
+			},
+		}
+		for _, c := range p.collectors {
+			if err := c.Collect(ctx, path.LocalPath, &pr.Telemetry); err != nil {
+				pr.Err = err.Error()
+			}
+		}
+
+		p.lk.Lock()
+		if p.policy.MaxLatency > 0 && pr.Telemetry.Latency > p.policy.MaxLatency {
+			p.slow[path.ID]++
+		} else {
+			p.slow[path.ID] = 0
+		}
+		pr.Reasons = p.policy.riskReasons(pr.Telemetry, p.slow[path.ID])
+		pr.AtRisk = len(pr.Reasons) > 0
+
+		prev, known := p.paths[path.ID]
+		p.paths[path.ID] = &pr
+		p.lk.Unlock()
+
+		switch {
+		case pr.AtRisk && (!known || !prev.AtRisk):
+			log.Warnw("storage path at risk of failing", "id", path.ID, "path", path.LocalPath, "reasons", pr.Reasons)
+		case !pr.AtRisk && known && prev.AtRisk:
+			log.Infow("storage path healthy again", "id", path.ID, "path", path.LocalPath)
+		}
+	}
+
+	p.lk.Lock()
+	for id := range p.paths {
+		if _, ok := seen[id]; !ok {
+			delete(p.paths, id)
+			delete(p.slow, id)
+		}
+	}
+	p.lk.Unlock()
+
+	if p.policy.Action == PredictMove {
+		p.evacuate(ctx, paths)
+	}
+	return nil
+}
+
+func (pp PredictionPolicy) riskReasons(t PathTelemetry, slowSamples int) []string {
+	var out []string
+	if t.ReallocatedSectors > pp.MaxReallocatedSectors {
+		out = append(out, fmt.Sprintf("%d reallocated sectors", t.ReallocatedSectors))
+	}
+	if t.PendingSectors > pp.MaxPendingSectors {
+		out = append(out, fmt.Sprintf("%d pending sectors", t.PendingSectors))
+	}
+	if t.UncorrectableErrors > pp.MaxUncorrectableErrors {
+		out = append(out, fmt.Sprintf("%d uncorrectable errors", t.UncorrectableErrors))
+	}
+	if pp.MaxLatency > 0 && slowSamples >= pp.LatencySamples && slowSamples > 0 {
+		out = append(out, fmt.Sprintf("latency over %s for %d checks", pp.MaxLatency, slowSamples))
+	}
+	return out
+}
+
+// evacuate moves the sealed sectors out of the local paths at risk, to the
+// healthy local paths with the most available space.
+func (p *FaultPredictor) evacuate(ctx context.Context, paths []StoragePath) {
+	atRisk := map[ID]struct{}{}
+	for _, id := range p.atRisk() {
+		atRisk[id] = struct{}{}
+	}
+	if len(atRisk) == 0 {
+		return
+	}
+
+	local := map[ID]struct{}{}
+	for _, path := range paths {
+		local[path.ID] = struct{}{}
+	}
+	healthy := p.index.storeCandidates(func(si StorageInfo) bool {
+		_, isLocal := local[si.ID]
+		_, risky := atRisk[si.ID]
+		return isLocal && !risky
+	})
+	if len(healthy) == 0 {
+		log.Warnw("no healthy local storage paths to move sectors at risk to")
+		return
+	}
+
+	for id := range atRisk {
+		for _, sid := range p.index.pathSectors(id, storiface.FTSealed) {
+			var err error
+			for _, dst := range healthy {
+				if err = p.mover.Move(ctx, sid, dst.ID); err == nil {
+					log.Infow("moving sector off storage path at risk", "sector", sid, "from", id, "to", dst.ID)
+					break
+				}
+			}
+			if err != nil {
+				log.Debugw("not moving sector off storage path at risk", "sector", sid, "from", id, "error", err)
+			}
+		}
+	}
+}
+
+// LatencyProbe measures the latency of writing a small file to the path,
+// syncing it and reading it back.
+type LatencyProbe struct{}
+
+const latencyProbeFile = ".latency-probe"
+
+func (LatencyProbe) Collect(ctx context.Context, path string, t *PathTelemetry) error {
+	data := make([]byte, 4<<10)
+	if _, err := rand.Read(data); err != nil {
+		return err
+	}
+
+	p := filepath.Join(path, latencyProbeFile)
+	defer os.Remove(p) // nolint
+
+	start := time.Now()
+
+	f, err := os.Create(p)
+	if err != nil {
+		return xerrors.Errorf("creating latency probe: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return xerrors.Errorf("writing latency probe: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return xerrors.Errorf("syncing latency probe: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	read, err := ioutil.ReadFile(p)
+	if err != nil {
+		return xerrors.Errorf("reading latency probe: %w", err)
+	}
+	if !bytes.Equal(read, data) {
+		return xerrors.Errorf("latency probe read back different data")
+	}
+
+	t.Latency = time.Since(start)
+	return nil
+}
+
+// CommandCollector runs a command with the local path as its argument, which
+// prints the SMART attributes of the device backing the path as a JSON
+// object, with any of the ReallocatedSectors, PendingSectors and
+// UncorrectableErrors fields. This is usually a script mapping the path to
+// its device and wrapping `smartctl -j -A`.
+type CommandCollector struct {
+	Command string
+}
+
+func (c CommandCollector) Collect(ctx context.Context, path string, t *PathTelemetry) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Command, path)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return xerrors.Errorf("running %s: %w: %s", c.Command, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return parseCommandTelemetry(out, t)
+}
+
+func parseCommandTelemetry(out []byte, t *PathTelemetry) error {
+	var attrs struct {
+		ReallocatedSectors  *int64
+		PendingSectors      *int64
+		UncorrectableErrors *int64
+	}
+	if err := json.Unmarshal(out, &attrs); err != nil {
+		return xerrors.Errorf("decoding telemetry: %w", err)
+	}
+
+	if attrs.ReallocatedSectors != nil {
+		t.ReallocatedSectors = *attrs.ReallocatedSectors
+	}
+	if attrs.PendingSectors != nil {
+		t.PendingSectors = *attrs.PendingSectors
+	}
+	if attrs.UncorrectableErrors != nil {
+		t.UncorrectableErrors = *attrs.UncorrectableErrors
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package stores
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiskReasons(t *testing.T) {
+	pp := PredictionPolicy{
+		MaxReallocatedSectors:  10,
+		MaxPendingSectors:      0,
+		MaxUncorrectableErrors: 0,
+		MaxLatency:             time.Second,
+		LatencySamples:         3,
+	}
+
+	healthy := PathTelemetry{ReallocatedSectors: 10, Latency: 2 * time.Second}
+	require.Empty(t, pp.riskReasons(healthy, 2))
+
+	// attributes not reported by any collector are ignored
+	require.Empty(t, pp.riskReasons(PathTelemetry{ReallocatedSectors: -1, PendingSectors: -1, UncorrectableErrors: -1}, 0))
+
+	require.Equal(t, []string{"11 reallocated sectors", "1 pending sectors"},
+		pp.riskReasons(PathTelemetry{ReallocatedSectors: 11, PendingSectors: 1}, 0))
+	require.Equal(t, []string{"latency over 1s for 3 checks"}, pp.riskReasons(healthy, 3))
+
+	pp.MaxLatency = 0
+	require.Empty(t, pp.riskReasons(healthy, 3))
+}
+
+func TestCommandTelemetry(t *testing.T) {
+	tm := PathTelemetry{ReallocatedSectors: -1, PendingSectors: -1, UncorrectableErrors: -1}
+	require.NoError(t, parseCommandTelemetry([]byte(`{"ReallocatedSectors": 8, "PendingSectors": 0}`), &tm))
+	require.Equal(t, PathTelemetry{ReallocatedSectors: 8, PendingSectors: 0, UncorrectableErrors: -1}, tm)
+
+	require.Error(t, parseCommandTelemetry([]byte("not json"), &tm))
+}
+
+func TestLatencyProbe(t *testing.T) {
+	dir := t.TempDir()
+
+	var tm PathTelemetry
+	require.NoError(t, LatencyProbe{}.Collect(context.Background(), dir, &tm))
+	require.NotZero(t, tm.Latency)
+
+	// the probe file is cleaned up
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	require.Error(t, LatencyProbe{}.Collect(context.Background(), filepath.Join(dir, "missing"), &tm))
+}
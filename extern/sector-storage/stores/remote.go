@@ -32,9 +32,10 @@ var FetchTempSubdir = "fetching"
 var CopyBuf = 1 << 20
 
 type Remote struct {
-	local Store
-	index SectorIndex
-	auth  http.Header
+	local  Store
+	index  SectorIndex
+	auth   http.Header
+	client *http.Client
 
 	limit chan struct{}
 
@@ -54,9 +55,10 @@ func (r *Remote) RemoveCopies(ctx context.Context, s abi.SectorID, types storifa
 
 func NewRemote(local Store, index SectorIndex, auth http.Header, fetchLimit int, pfHandler partialFileHandler) *Remote {
 	return &Remote{
-		local: local,
-		index: index,
-		auth:  auth,
+		local:  local,
+		index:  index,
+		auth:   auth,
+		client: http.DefaultClient,
 
 		limit: make(chan struct{}, fetchLimit),
 
@@ -65,6 +67,17 @@ func NewRemote(local Store, index SectorIndex, auth http.Header, fetchLimit int,
 	}
 }
 
+// SetHTTPClient sets the client sectors are fetched from other nodes with,
+// e.g. to present a TLS client certificate.
+func (r *Remote) SetHTTPClient(c *http.Client) {
+	r.client = c
+}
+
+// LocalStore returns the store remote storage fetches sectors into.
+func (r *Remote) LocalStore() Store {
+	return r.local
+}
+
 func (r *Remote) AcquireSector(ctx context.Context, s storage.SectorRef, existing storiface.SectorFileType, allocate storiface.SectorFileType, pathType storiface.PathType, op storiface.AcquireMode) (storiface.SectorPaths, storiface.SectorPaths, error) {
 	if existing|allocate != existing^allocate {
 		return storiface.SectorPaths{}, storiface.SectorPaths{}, xerrors.New("can't both find and allocate a sector")
@@ -249,7 +262,7 @@ func (r *Remote) fetch(ctx context.Context, url, outname string) error {
 	req.Header = r.auth
 	req = req.WithContext(ctx)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := r.client.Do(req)
 	if err != nil {
 		return xerrors.Errorf("do request: %w", err)
 	}
@@ -344,7 +357,7 @@ func (r *Remote) deleteFromRemote(ctx context.Context, url string) error {
 	req.Header = r.auth
 	req = req.WithContext(ctx)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := r.client.Do(req)
 	if err != nil {
 		return xerrors.Errorf("do request: %w", err)
 	}
@@ -391,7 +404,7 @@ func (r *Remote) FsStat(ctx context.Context, id ID) (fsutil.FsStat, error) {
 	req.Header = r.auth
 	req = req.WithContext(ctx)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := r.client.Do(req)
 	if err != nil {
 		return fsutil.FsStat{}, xerrors.Errorf("do request: %w", err)
 	}
@@ -428,7 +441,7 @@ func (r *Remote) checkAllocated(ctx context.Context, url string, spt abi.Registe
 	req.Header = r.auth.Clone()
 	req = req.WithContext(ctx)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := r.client.Do(req)
 	if err != nil {
 		return false, xerrors.Errorf("do request: %w", err)
 	}
@@ -471,7 +484,7 @@ func (r *Remote) readRemote(ctx context.Context, url string, offset, size abi.Pa
 	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+size-1))
 	req = req.WithContext(ctx)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := r.client.Do(req)
 	if err != nil {
 		return nil, xerrors.Errorf("do request: %w", err)
 	}
@@ -0,0 +1,105 @@
+package stores
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+// Storage paths can restrict which tasks allocate space on them, and which
+// sector file types they hold, e.g. so that PC1 scratch space always ends up
+// on local NVMe, while sealed sectors go to a NAS:
+//
+//   AllowTasks / DenyTasks   task types, as short names (PC1, FIN, ...)
+//   AllowTypes / DenyTypes   sector file types (unsealed, sealed, cache)
+//
+// Empty allow lists allow everything. Rules only apply to allocating new
+// files; existing files are always found regardless of the rules.
+
+type taskTypeKey struct{}
+
+// WithTaskType marks allocations made with the returned context as made for
+// the given task.
+func WithTaskType(ctx context.Context, task sealtasks.TaskType) context.Context {
+	return context.WithValue(ctx, taskTypeKey{}, task)
+}
+
+func taskTypeFromContext(ctx context.Context) (sealtasks.TaskType, bool) {
+	task, ok := ctx.Value(taskTypeKey{}).(sealtasks.TaskType)
+	return task, ok
+}
+
+// CanAllocate returns whether the rules of the path allow allocating files of
+// the given types for the given task. Allocations made without a known task
+// are only subject to file type rules.
+func (si StorageInfo) CanAllocate(task sealtasks.TaskType, knownTask bool, ft storiface.SectorFileType) bool {
+	if knownTask && !ruleAllows(si.AllowTasks, si.DenyTasks, task.Short(), string(task)) {
+		return false
+	}
+
+	for _, t := range storiface.PathTypes {
+		if ft&t != 0 && !ruleAllows(si.AllowTypes, si.DenyTypes, t.String()) {
+			return false
+		}
+	}
+	return true
+}
+
+func (si StorageInfo) canAllocateCtx(ctx context.Context, ft storiface.SectorFileType) bool {
+	task, ok := taskTypeFromContext(ctx)
+	return si.CanAllocate(task, ok, ft)
+}
+
+func ruleAllows(allow, deny []string, names ...string) bool {
+	matches := func(list []string) bool {
+		for _, l := range list {
+			for _, n := range names {
+				if strings.EqualFold(l, n) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if matches(deny) {
+		return false
+	}
+	return len(allow) == 0 || matches(allow)
+}
+
+// InGroup returns whether the path belongs to the given storage group.
+func (si StorageInfo) InGroup(group string) bool {
+	for _, g := range si.Groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRules checks that the path rules only name known task and sector
+// file types.
+func (m *LocalStorageMeta) ValidateRules() error {
+	for _, t := range append(append([]string{}, m.AllowTasks...), m.DenyTasks...) {
+		if _, ok := sealtasks.ParseTaskType(t); !ok {
+			return xerrors.Errorf("unknown task type %q", t)
+		}
+	}
+
+	for _, t := range append(append([]string{}, m.AllowTypes...), m.DenyTypes...) {
+		known := false
+		for _, ft := range storiface.PathTypes {
+			known = known || strings.EqualFold(t, ft.String())
+		}
+		if !known {
+			return xerrors.Errorf("unknown sector file type %q", t)
+		}
+	}
+
+	return nil
+}
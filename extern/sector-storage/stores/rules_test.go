@@ -0,0 +1,40 @@
+package stores
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+func TestPathRules(t *testing.T) {
+	scratch := StorageInfo{
+		Groups:     []string{"nvme-scratch"},
+		AllowTasks: []string{"PC1", "pc2"},
+		DenyTypes:  []string{"unsealed"},
+	}
+
+	require.True(t, scratch.InGroup("nvme-scratch"))
+	require.False(t, scratch.InGroup("rack-A"))
+
+	require.True(t, scratch.CanAllocate(sealtasks.TTPreCommit1, true, storiface.FTSealed|storiface.FTCache))
+	require.True(t, scratch.CanAllocate(sealtasks.TTPreCommit2, true, storiface.FTCache))
+	require.False(t, scratch.CanAllocate(sealtasks.TTFinalize, true, storiface.FTSealed))
+	require.False(t, scratch.CanAllocate(sealtasks.TTPreCommit1, true, storiface.FTUnsealed|storiface.FTSealed))
+
+	// without a known task only file type rules apply
+	require.True(t, scratch.CanAllocate("", false, storiface.FTSealed))
+	require.False(t, scratch.CanAllocate("", false, storiface.FTUnsealed))
+
+	nas := StorageInfo{DenyTasks: []string{string(sealtasks.TTPreCommit1)}}
+	require.False(t, nas.canAllocateCtx(WithTaskType(context.Background(), sealtasks.TTPreCommit1), storiface.FTCache))
+	require.True(t, nas.canAllocateCtx(WithTaskType(context.Background(), sealtasks.TTFetch), storiface.FTSealed))
+	require.True(t, nas.canAllocateCtx(context.Background(), storiface.FTSealed))
+
+	require.NoError(t, (&LocalStorageMeta{AllowTasks: []string{"PC1"}, DenyTypes: []string{"Cache"}}).ValidateRules())
+	require.Error(t, (&LocalStorageMeta{AllowTasks: []string{"PC3"}}).ValidateRules())
+	require.Error(t, (&LocalStorageMeta{AllowTypes: []string{"staged"}}).ValidateRules())
+}
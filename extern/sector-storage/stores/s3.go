@@ -0,0 +1,397 @@
+package stores
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// s3PartSize is the size of the parts of multipart uploads. Sealed sectors are
+// too large for single part uploads, which are limited to 5GiB.
+const s3PartSize = 64 << 20
+
+const s3PartRetries = 3
+
+// emptyPayloadHash is the sha256 of an empty request body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// s3Client is a minimal client of the S3 API, implementing only the calls
+// needed to store sector files, signed with AWS signature version 4.
+type s3Client struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	pathStyle bool
+
+	accessKey string
+	secretKey string
+
+	client *http.Client
+	now    func() time.Time
+}
+
+type s3Object struct {
+	Key  string
+	Size int64
+}
+
+func newS3Client(endpoint, region, bucket string, pathStyle bool, accessKey, secretKey string) (*s3Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, xerrors.Errorf("parsing endpoint: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, xerrors.Errorf("unsupported endpoint scheme %q", u.Scheme)
+	}
+	if bucket == "" {
+		return nil, xerrors.New("no bucket configured")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &s3Client{
+		endpoint:  u,
+		region:    region,
+		bucket:    bucket,
+		pathStyle: pathStyle,
+
+		accessKey: accessKey,
+		secretKey: secretKey,
+
+		client: http.DefaultClient,
+		now:    time.Now,
+	}, nil
+}
+
+func (c *s3Client) objectURL(key string, query url.Values) *url.URL {
+	u := *c.endpoint
+	if c.pathStyle {
+		u.Path = "/" + c.bucket
+	} else {
+		u.Host = c.bucket + "." + u.Host
+		u.Path = ""
+	}
+	if key != "" {
+		u.Path += "/" + key
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	// send the path encoded the same way as in the signature
+	u.RawPath = s3Escape(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+func (c *s3Client) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		h := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(h[:])
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.objectURL(key, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, xerrors.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	c.sign(req, payloadHash)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close() // nolint
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return nil, xerrors.Errorf("%s %s: %s: %s", method, key, resp.Status, bytes.TrimSpace(msg))
+	}
+	return resp, nil
+}
+
+// sign adds an AWS signature version 4 Authorization header to the request.
+func (c *s3Client) sign(req *http.Request, payloadHash string) {
+	now := c.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if lk == "x-amz-date" || lk == "x-amz-content-sha256" || lk == "range" || lk == "content-md5" {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonRequest := strings.Join([]string{
+		req.Method,
+		s3Escape(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + c.region + "/s3/aws4_request"
+	crh := sha256.Sum256([]byte(canonRequest))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(crh[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+c.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+sig)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		vals := append([]string{}, q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape URI-encodes all but unreserved characters, as required for
+// canonical requests.
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case 'A' <= ch && ch <= 'Z', 'a' <= ch && ch <= 'z', '0' <= ch && ch <= '9',
+			ch == '-', ch == '_', ch == '.', ch == '~':
+			b.WriteByte(ch)
+		case ch == '/' && !encodeSlash:
+			b.WriteByte(ch)
+		default:
+			b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{ch})))
+		}
+	}
+	return b.String()
+}
+
+func (c *s3Client) getObject(ctx context.Context, key string, w io.Writer) error {
+	resp, err := c.do(ctx, "GET", key, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint
+
+	n, err := io.CopyBuffer(w, resp.Body, make([]byte, CopyBuf))
+	if err != nil {
+		return xerrors.Errorf("reading object: %w", err)
+	}
+	if resp.ContentLength >= 0 && n != resp.ContentLength {
+		return xerrors.Errorf("object truncated: got %d of %d bytes", n, resp.ContentLength)
+	}
+	return nil
+}
+
+func (c *s3Client) deleteObject(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, "DELETE", key, nil, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (c *s3Client) listObjects(ctx context.Context, prefix string, maxKeys int) ([]s3Object, error) {
+	var out []s3Object
+	var token string
+	for {
+		q := url.Values{
+			"list-type": {"2"},
+			"prefix":    {prefix},
+		}
+		if maxKeys > 0 {
+			q.Set("max-keys", strconv.Itoa(maxKeys))
+		}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+
+		resp, err := c.do(ctx, "GET", "", q, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var res struct {
+			Contents              []s3Object
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&res)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, xerrors.Errorf("decoding object list: %w", err)
+		}
+
+		out = append(out, res.Contents...)
+		if !res.IsTruncated || (maxKeys > 0 && len(out) >= maxKeys) {
+			return out, nil
+		}
+		token = res.NextContinuationToken
+	}
+}
+
+// uploadFile uploads a local file, with a multipart upload for large files.
+// The signed payload hash of each request makes the object store reject data
+// corrupted in transit.
+func (c *s3Client) uploadFile(ctx context.Context, key string, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close() // nolint
+
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if st.Size() < s3PartSize {
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			return xerrors.Errorf("reading %s: %w", path, err)
+		}
+		resp, err := c.do(ctx, "PUT", key, nil, data)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	resp, err := c.do(ctx, "POST", key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return xerrors.Errorf("creating multipart upload: %w", err)
+	}
+	var initRes struct {
+		UploadId string
+	}
+	err = xml.NewDecoder(resp.Body).Decode(&initRes)
+	_ = resp.Body.Close()
+	if err != nil {
+		return xerrors.Errorf("decoding multipart upload: %w", err)
+	}
+
+	type part struct {
+		PartNumber int
+		ETag       string
+	}
+	var complete struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []part   `xml:"Part"`
+	}
+
+	abort := func(cause error) error {
+		// use a fresh context, ctx may be the reason of the failure
+		actx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if resp, err := c.do(actx, "DELETE", key, url.Values{"uploadId": {initRes.UploadId}}, nil); err != nil {
+			log.Warnf("aborting multipart upload of %s: %s", key, err)
+		} else {
+			_ = resp.Body.Close()
+		}
+		return cause
+	}
+
+	buf := make([]byte, s3PartSize)
+	for pn := 1; ; pn++ {
+		n, err := io.ReadFull(f, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return abort(xerrors.Errorf("reading %s: %w", path, err))
+		}
+
+		q := url.Values{
+			"partNumber": {strconv.Itoa(pn)},
+			"uploadId":   {initRes.UploadId},
+		}
+
+		var resp *http.Response
+		for try := 0; try < s3PartRetries; try++ {
+			resp, err = c.do(ctx, "PUT", key, q, buf[:n])
+			if err == nil || ctx.Err() != nil {
+				break
+			}
+			log.Warnf("uploading part %d of %s (try %d): %s", pn, key, try+1, err)
+		}
+		if err != nil {
+			return abort(xerrors.Errorf("uploading part %d: %w", pn, err))
+		}
+		_ = resp.Body.Close()
+
+		complete.Parts = append(complete.Parts, part{pn, resp.Header.Get("ETag")})
+
+		if n < len(buf) {
+			break
+		}
+	}
+
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return abort(err)
+	}
+	resp, err = c.do(ctx, "POST", key, url.Values{"uploadId": {initRes.UploadId}}, body)
+	if err != nil {
+		return abort(xerrors.Errorf("completing multipart upload: %w", err))
+	}
+	defer resp.Body.Close() // nolint
+
+	// errors completing the upload may be returned with a 200 status
+	res, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return abort(xerrors.Errorf("reading complete upload response: %w", err))
+	}
+	if bytes.Contains(res, []byte("<Error>")) {
+		return abort(xerrors.Errorf("completing multipart upload: %s", res))
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package stores
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLSigner(t *testing.T) {
+	s := NewURLSigner([]byte("secret"))
+
+	signed, err := s.Sign("http://miner:2345/remote/unsealed/s-t01000-1", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	require.True(t, s.Verify(httptest.NewRequest("GET", signed, nil)))
+	require.True(t, s.Verify(httptest.NewRequest("HEAD", signed, nil)))
+
+	// signed URLs are read-only
+	require.False(t, s.Verify(httptest.NewRequest("DELETE", signed, nil)))
+
+	// and only grant access to the signed path
+	other, err := url.Parse(signed)
+	require.NoError(t, err)
+	other.Path = "/remote/sealed/s-t01000-1"
+	require.False(t, s.Verify(httptest.NewRequest("GET", other.String(), nil)))
+
+	// with the expiry they were signed with
+	extended, err := url.Parse(signed)
+	require.NoError(t, err)
+	q := extended.Query()
+	q.Set(signedURLExpires, "99999999999")
+	extended.RawQuery = q.Encode()
+	require.False(t, s.Verify(httptest.NewRequest("GET", extended.String(), nil)))
+
+	// by the same key
+	require.False(t, NewURLSigner([]byte("other")).Verify(httptest.NewRequest("GET", signed, nil)))
+
+	require.False(t, s.Verify(httptest.NewRequest("GET", "http://miner:2345/remote/unsealed/s-t01000-1", nil)))
+
+	expired, err := s.Sign("http://miner:2345/remote/unsealed/s-t01000-1", time.Now().Add(-time.Second))
+	require.NoError(t, err)
+	require.False(t, s.Verify(httptest.NewRequest("GET", expired, nil)))
+}
@@ -0,0 +1,447 @@
+package stores
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/raulk/clock"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+// Sealed sectors can be tiered between storage path groups: sectors which
+// weren't retrieved for a while are demoted from paths in the hot group to
+// paths in the cold group, and promoted back when a retrieval needs them.
+// Sectors stored outside of both groups aren't tiered.
+
+type Tier string
+
+const (
+	TierHot  Tier = "hot"
+	TierCold Tier = "cold"
+)
+
+type TierMigration string
+
+const (
+	TierSettled   TierMigration = ""
+	TierDemoting  TierMigration = "demoting"
+	TierPromoting TierMigration = "promoting"
+)
+
+// SectorTier is the tiering state of a sector tracked by the index.
+type SectorTier struct {
+	Sector abi.SectorID
+
+	Tier      Tier
+	Migration TierMigration `json:",omitempty"`
+
+	// Since is when the sector entered its tier, or was last retrieved
+	Since time.Time
+	// LastErr is the error of the last failed migration
+	LastErr string `json:",omitempty"`
+}
+
+// SectorTiers returns the tiering state of all tiered sectors.
+func (i *Index) SectorTiers() []SectorTier {
+	i.lk.RLock()
+	defer i.lk.RUnlock()
+
+	out := make([]SectorTier, 0, len(i.tiers))
+	for _, st := range i.tiers {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(a, b int) bool {
+		return out[a].Sector.Number < out[b].Sector.Number
+	})
+	return out
+}
+
+func (i *Index) sectorTier(s abi.SectorID) (SectorTier, bool) {
+	i.lk.RLock()
+	defer i.lk.RUnlock()
+
+	st, ok := i.tiers[s]
+	if !ok {
+		return SectorTier{}, false
+	}
+	return *st, true
+}
+
+func (i *Index) updateSectorTier(s abi.SectorID, cb func(st *SectorTier)) SectorTier {
+	i.lk.Lock()
+	defer i.lk.Unlock()
+
+	st, ok := i.tiers[s]
+	if !ok {
+		st = &SectorTier{Sector: s}
+		i.tiers[s] = st
+	}
+	cb(st)
+	return *st
+}
+
+func (i *Index) dropSectorTier(s abi.SectorID) {
+	i.lk.Lock()
+	defer i.lk.Unlock()
+
+	delete(i.tiers, s)
+}
+
+// sealedSectorPaths returns the paths storing sealed files of each sector of
+// the given miner.
+func (i *Index) sealedSectorPaths(miner abi.ActorID) map[abi.SectorID][]StorageInfo {
+	i.lk.RLock()
+	defer i.lk.RUnlock()
+
+	out := map[abi.SectorID][]StorageInfo{}
+	for d, metas := range i.sectors {
+		if d.SectorFileType != storiface.FTSealed || d.Miner != miner {
+			continue
+		}
+		for _, dm := range metas {
+			if st, ok := i.stores[dm.storage]; ok {
+				out[d.SectorID] = append(out[d.SectorID], *st.info)
+			}
+		}
+	}
+	return out
+}
+
+// pathSectors returns the sectors with files of the given type stored in the
+// path.
+func (i *Index) pathSectors(id ID, ft storiface.SectorFileType) []abi.SectorID {
+	i.lk.RLock()
+	defer i.lk.RUnlock()
+
+	var out []abi.SectorID
+	for d, metas := range i.sectors {
+		if d.SectorFileType != ft {
+			continue
+		}
+		for _, dm := range metas {
+			if dm.storage == id {
+				out = append(out, d.SectorID)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// groupStores returns the long-term storage paths in a group, with the most
+// available space first.
+func (i *Index) groupStores(group string) []StorageInfo {
+	return i.storeCandidates(func(si StorageInfo) bool {
+		return si.InGroup(group)
+	})
+}
+
+// storeCandidates returns the online long-term storage paths accepted by the
+// filter, with the most available space first.
+func (i *Index) storeCandidates(filter func(StorageInfo) bool) []StorageInfo {
+	i.lk.RLock()
+	defer i.lk.RUnlock()
+
+	type candidate struct {
+		info  StorageInfo
+		avail int64
+	}
+	var candidates []candidate
+	for _, st := range i.stores {
+		if st.info.CanStore && st.heartbeatErr == nil && filter(*st.info) {
+			candidates = append(candidates, candidate{*st.info, st.fsi.Available})
+		}
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		return candidates[a].avail > candidates[b].avail
+	})
+
+	out := make([]StorageInfo, len(candidates))
+	for n, c := range candidates {
+		out[n] = c.info
+	}
+	return out
+}
+
+type TieringPolicy struct {
+	HotGroup  string
+	ColdGroup string
+
+	// DemoteAfter is how long a sector has to stay unretrieved in the hot tier
+	// before it's demoted
+	DemoteAfter time.Duration
+	// CheckInterval is how often sectors are checked for demotion
+	CheckInterval time.Duration
+}
+
+// ActiveDealSectors returns the sector numbers of sectors with ongoing
+// retrieval deals, which are never demoted.
+type ActiveDealSectors func(ctx context.Context) (map[abi.SectorNumber]struct{}, error)
+
+// Tierer migrates sealed sectors between tiers according to a tiering policy,
+// using the Mover.
+//
+// The tiering state of sectors is kept in the datastore, so that the time
+// sectors entered their tier, or were last retrieved, survives restarts.
+// Migrations don't: sectors are settled in the tier their files are found in
+// on the first check after a restart.
+type Tierer struct {
+	policy TieringPolicy
+	miner  abi.ActorID
+
+	index *Index
+	mover *Mover
+	ds    datastore.Batching
+	clock clock.Clock
+}
+
+func NewTierer(policy TieringPolicy, miner abi.ActorID, index *Index, mover *Mover, ds datastore.Batching) (*Tierer, error) {
+	if policy.HotGroup == "" || policy.ColdGroup == "" || policy.HotGroup == policy.ColdGroup {
+		return nil, xerrors.Errorf("tiering needs distinct hot and cold storage groups")
+	}
+
+	t := &Tierer{
+		policy: policy,
+		miner:  miner,
+		index:  index,
+		mover:  mover,
+		ds:     ds,
+		clock:  clock.New(),
+	}
+	if err := t.load(); err != nil {
+		return nil, xerrors.Errorf("loading sector tiers: %w", err)
+	}
+	mover.OnFinished(t.moveFinished)
+	return t, nil
+}
+
+func tierKey(sid abi.SectorID) datastore.Key {
+	return datastore.NewKey(fmt.Sprint(sid.Number))
+}
+
+func (t *Tierer) load() error {
+	res, err := t.ds.Query(query.Query{})
+	if err != nil {
+		return err
+	}
+	defer res.Close() // nolint
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return r.Error
+		}
+
+		var st SectorTier
+		if err := json.Unmarshal(r.Value, &st); err != nil {
+			return xerrors.Errorf("unmarshaling tier of %s: %w", r.Key, err)
+		}
+		if st.Sector.Miner != t.miner {
+			continue
+		}
+
+		st.Migration = TierSettled
+		t.index.updateSectorTier(st.Sector, func(cur *SectorTier) {
+			*cur = st
+		})
+	}
+
+	return nil
+}
+
+// update updates the tiering state of the sector in the index, and saves it
+func (t *Tierer) update(sid abi.SectorID, cb func(st *SectorTier)) {
+	st := t.index.updateSectorTier(sid, cb)
+
+	b, err := json.Marshal(st)
+	if err == nil {
+		err = t.ds.Put(tierKey(sid), b)
+	}
+	if err != nil {
+		log.Errorf("saving tier of sector %d: %+v", sid.Number, err)
+	}
+}
+
+// dropRemoved forgets the tiering state of sectors whose sealed files aren't
+// stored anymore
+func (t *Tierer) dropRemoved(stored map[abi.SectorID][]StorageInfo) error {
+	for _, st := range t.index.SectorTiers() {
+		if _, ok := stored[st.Sector]; !ok {
+			t.index.dropSectorTier(st.Sector)
+		}
+	}
+
+	res, err := t.ds.Query(query.Query{KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	defer res.Close() // nolint
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return r.Error
+		}
+
+		var num abi.SectorNumber
+		if _, err := fmt.Sscan(datastore.NewKey(r.Key).BaseNamespace(), &num); err != nil {
+			log.Warnf("unexpected sector tier key %s", r.Key)
+			continue
+		}
+		sid := abi.SectorID{Miner: t.miner, Number: num}
+		if _, ok := stored[sid]; ok {
+			continue
+		}
+		if err := t.ds.Delete(tierKey(sid)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Tierer) Run(ctx context.Context, active ActiveDealSectors) {
+	tick := t.clock.Ticker(t.policy.CheckInterval)
+	defer tick.Stop()
+
+	for {
+		if err := t.check(ctx, active); err != nil {
+			log.Errorf("checking sector tiers: %+v", err)
+		}
+
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *Tierer) tierOf(paths []StorageInfo) (Tier, bool) {
+	var cold bool
+	for _, p := range paths {
+		if p.InGroup(t.policy.HotGroup) {
+			return TierHot, true
+		}
+		cold = cold || p.InGroup(t.policy.ColdGroup)
+	}
+	if cold {
+		return TierCold, true
+	}
+	return "", false
+}
+
+// check records the current tier of all sectors, and demotes hot sectors
+// which weren't retrieved for longer than the policy allows.
+func (t *Tierer) check(ctx context.Context, active ActiveDealSectors) error {
+	withDeals, err := active(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting sectors with active retrieval deals: %w", err)
+	}
+
+	now := t.clock.Now()
+	stored := t.index.sealedSectorPaths(t.miner)
+	for sid, paths := range stored {
+		tier, ok := t.tierOf(paths)
+		if !ok {
+			continue
+		}
+
+		var demote bool
+		t.update(sid, func(st *SectorTier) {
+			if st.Tier != tier && st.Migration == TierSettled {
+				// new sector, or moved manually
+				st.Tier = tier
+				st.Since = now
+			}
+
+			_, hasDeals := withDeals[sid.Number]
+			demote = st.Tier == TierHot && st.Migration == TierSettled && !hasDeals &&
+				now.Sub(st.Since) > t.policy.DemoteAfter
+			if demote {
+				st.Migration = TierDemoting
+			}
+		})
+
+		if demote {
+			t.migrate(ctx, sid, t.policy.ColdGroup)
+		}
+	}
+
+	if err := t.dropRemoved(stored); err != nil {
+		return xerrors.Errorf("dropping tiers of removed sectors: %w", err)
+	}
+
+	return nil
+}
+
+// Demand is called before a sector is read for a retrieval; sectors in the
+// cold tier are promoted back to the hot tier.
+func (t *Tierer) Demand(ctx context.Context, sid abi.SectorID) {
+	if _, ok := t.index.sectorTier(sid); !ok {
+		return
+	}
+
+	var promote bool
+	t.update(sid, func(st *SectorTier) {
+		switch {
+		case st.Tier == TierHot && st.Migration == TierSettled:
+			st.Since = t.clock.Now()
+		case st.Tier == TierCold && st.Migration == TierSettled:
+			st.Migration = TierPromoting
+			promote = true
+		}
+	})
+
+	if promote {
+		log.Infow("promoting sector on retrieval", "sector", sid)
+		t.migrate(ctx, sid, t.policy.HotGroup)
+	}
+}
+
+func (t *Tierer) migrate(ctx context.Context, sid abi.SectorID, group string) {
+	var err error
+	for _, p := range t.index.groupStores(group) {
+		if err = t.mover.Move(ctx, sid, p.ID); err == nil {
+			return
+		}
+	}
+	if err == nil {
+		err = xerrors.Errorf("no storage paths available in group %s", group)
+	}
+
+	log.Warnf("migrating sector %d to %s: %s", sid.Number, group, err)
+	t.update(sid, func(st *SectorTier) {
+		st.Migration = TierSettled
+		st.LastErr = err.Error()
+	})
+}
+
+func (t *Tierer) moveFinished(mv SectorMove) {
+	st, ok := t.index.sectorTier(mv.Sector)
+	if !ok || st.Migration == TierSettled {
+		return
+	}
+
+	t.update(mv.Sector, func(st *SectorTier) {
+		if mv.State == MoveFailed {
+			st.LastErr = mv.Err
+		} else {
+			if st.Migration == TierDemoting {
+				st.Tier = TierCold
+			} else {
+				st.Tier = TierHot
+			}
+			st.Since = t.clock.Now()
+			st.LastErr = ""
+		}
+		st.Migration = TierSettled
+	})
+}
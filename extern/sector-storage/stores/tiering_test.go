@@ -0,0 +1,168 @@
+package stores
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+// testStorage opens a local storage path in each of the groups, storing the
+// sealed and cache files of the sectors in the first path
+func testStorage(t *testing.T, groups []string, sectors ...abi.SectorID) (*Local, *Index, []ID) {
+	ctx := context.Background()
+	tstor := &TestingLocalStorage{root: t.TempDir()}
+	index := NewIndex()
+
+	st, err := NewLocal(ctx, tstor, index, nil)
+	require.NoError(t, err)
+
+	var ids []ID
+	for n, group := range groups {
+		p := filepath.Join(tstor.root, group)
+		require.NoError(t, os.Mkdir(p, 0755))
+
+		meta := &LocalStorageMeta{
+			ID:       ID(uuid.New().String()),
+			Weight:   1,
+			CanStore: true,
+			Groups:   []string{group},
+		}
+		mb, err := json.Marshal(meta)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(p, MetaFile), mb, 0644))
+
+		for _, ft := range storiface.PathTypes {
+			require.NoError(t, os.MkdirAll(filepath.Join(p, ft.String()), 0755))
+		}
+		if n == 0 {
+			for _, sid := range sectors {
+				writeSectorFiles(t, p, sid)
+			}
+		}
+
+		require.NoError(t, st.OpenPath(ctx, p))
+		ids = append(ids, meta.ID)
+	}
+
+	return st, index, ids
+}
+
+func writeSectorFiles(t *testing.T, root string, sid abi.SectorID) {
+	name := storiface.SectorName(sid)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, storiface.FTSealed.String(), name), []byte("sealed "+name), 0644))
+
+	cache := filepath.Join(root, storiface.FTCache.String(), name)
+	require.NoError(t, os.MkdirAll(cache, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cache, "p_aux"), []byte("aux "+name), 0644))
+}
+
+// sectorIn returns whether the sealed file of the sector is stored in the path
+func sectorIn(t *testing.T, st *Local, id ID, sid abi.SectorID) bool {
+	p, ok := st.localPath(id)
+	require.True(t, ok)
+	_, err := os.Stat(filepath.Join(p, storiface.FTSealed.String(), storiface.SectorName(sid)))
+	return err == nil
+}
+
+func TestTiering(t *testing.T) {
+	ctx := context.Background()
+	sid := abi.SectorID{Miner: 1000, Number: 1}
+
+	st, index, ids := testStorage(t, []string{"hot", "cold"}, sid)
+	hot, cold := ids[0], ids[1]
+
+	mover := NewMover(ctx, st, index, datastore.NewMapDatastore())
+	mover.RemoveDelay = 0
+
+	policy := TieringPolicy{
+		HotGroup:      "hot",
+		ColdGroup:     "cold",
+		DemoteAfter:   time.Hour,
+		CheckInterval: time.Minute,
+	}
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	tr, err := NewTierer(policy, 1000, index, mover, ds)
+	require.NoError(t, err)
+	clk := clock.NewMock()
+	tr.clock = clk
+
+	noDeals := func(context.Context) (map[abi.SectorNumber]struct{}, error) {
+		return map[abi.SectorNumber]struct{}{}, nil
+	}
+	withDeal := func(context.Context) (map[abi.SectorNumber]struct{}, error) {
+		return map[abi.SectorNumber]struct{}{sid.Number: {}}, nil
+	}
+	requireTier := func(tier Tier) SectorTier {
+		var cur SectorTier
+		require.Eventually(t, func() bool {
+			cur, _ = index.sectorTier(sid)
+			return cur.Tier == tier && cur.Migration == TierSettled
+		}, 5*time.Second, 10*time.Millisecond)
+		return cur
+	}
+
+	// the sector is recorded in the hot tier
+	require.NoError(t, tr.check(ctx, noDeals))
+	require.Equal(t, clk.Now(), requireTier(TierHot).Since)
+
+	// retrievals keep the sector hot
+	clk.Add(50 * time.Minute)
+	tr.Demand(ctx, sid)
+	clk.Add(50 * time.Minute)
+	require.NoError(t, tr.check(ctx, noDeals))
+	require.Equal(t, clk.Now().Add(-50*time.Minute), requireTier(TierHot).Since)
+
+	// and so do active retrieval deals
+	clk.Add(time.Hour)
+	require.NoError(t, tr.check(ctx, withDeal))
+	requireTier(TierHot)
+
+	// the tiering state survives restarts
+	restartedIndex := NewIndex()
+	_, err = NewTierer(policy, 1000, restartedIndex, NewMover(ctx, st, restartedIndex, datastore.NewMapDatastore()), ds)
+	require.NoError(t, err)
+	saved, ok := restartedIndex.sectorTier(sid)
+	require.True(t, ok)
+	require.Equal(t, TierHot, saved.Tier)
+	require.True(t, clk.Now().Add(-110*time.Minute).Equal(saved.Since), saved.Since)
+
+	// sectors not retrieved for longer than DemoteAfter are demoted
+	require.NoError(t, tr.check(ctx, noDeals))
+	demoted := requireTier(TierCold)
+	require.Equal(t, clk.Now(), demoted.Since)
+	require.Empty(t, demoted.LastErr)
+	require.True(t, sectorIn(t, st, cold, sid))
+	require.False(t, sectorIn(t, st, hot, sid))
+
+	// cold sectors aren't demoted again, and are promoted back on retrieval
+	clk.Add(2 * time.Hour)
+	require.NoError(t, tr.check(ctx, noDeals))
+	requireTier(TierCold)
+
+	tr.Demand(ctx, sid)
+	requireTier(TierHot)
+	require.True(t, sectorIn(t, st, hot, sid))
+	require.False(t, sectorIn(t, st, cold, sid))
+
+	// the state of removed sectors is dropped
+	require.NoError(t, index.StorageDropSector(ctx, hot, sid, storiface.FTSealed|storiface.FTCache))
+	require.NoError(t, tr.check(ctx, noDeals))
+	_, ok = index.sectorTier(sid)
+	require.False(t, ok)
+	_, err = ds.Get(tierKey(sid))
+	require.Equal(t, datastore.ErrNotFound, err)
+}
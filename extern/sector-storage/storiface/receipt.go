@@ -0,0 +1,100 @@
+package storiface
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
+)
+
+// WorkReceipt is a signed acknowledgment by whoever sealed a sector outside of
+// the miner, e.g. a sealing service, that it produced the sector replica
+// (TTPreCommit2, covering CommR and CommD) or its proof (TTCommit2, covering
+// the proof hash). Receipts are kept by the miner as an audit trail of who
+// produced each replica.
+type WorkReceipt struct {
+	Sector abi.SectorID
+	Task   sealtasks.TaskType
+
+	CommR     *cid.Cid `json:",omitempty"` // TTPreCommit2
+	CommD     *cid.Cid `json:",omitempty"` // TTPreCommit2
+	ProofHash []byte   `json:",omitempty"` // TTCommit2, sha256 of the proof
+
+	// Producer is a free-form name of the machine or service which did the
+	// work, PublicKey the ed25519 key the receipt is signed with
+	Producer  string
+	PublicKey []byte
+	Time      time.Time
+
+	Signature []byte
+}
+
+// ProofHash is the hash of a proof covered by a commit receipt.
+func ProofHash(proof []byte) []byte {
+	h := sha256.Sum256(proof)
+	return h[:]
+}
+
+func cidString(c *cid.Cid) string {
+	if c == nil {
+		return ""
+	}
+	return c.String()
+}
+
+// SigningBytes returns the message the receipt signature is over. It's a plain
+// text encoding, so that services not written in Go can produce it.
+func (r *WorkReceipt) SigningBytes() []byte {
+	return []byte(fmt.Sprintf("lotus-work-receipt/v1\nminer:%d\nsector:%d\ntask:%s\ncommr:%s\ncommd:%s\nproof:%x\nproducer:%s\ntime:%d\n",
+		r.Sector.Miner, r.Sector.Number, r.Task, cidString(r.CommR), cidString(r.CommD), r.ProofHash, r.Producer, r.Time.Unix()))
+}
+
+// Sign sets the public key and signature of the receipt.
+func (r *WorkReceipt) Sign(key ed25519.PrivateKey) {
+	r.PublicKey = key.Public().(ed25519.PublicKey)
+	r.Signature = ed25519.Sign(key, r.SigningBytes())
+}
+
+// Verify checks the receipt signature. It doesn't check whether the key is
+// trusted.
+func (r *WorkReceipt) Verify() error {
+	if len(r.PublicKey) != ed25519.PublicKeySize {
+		return xerrors.Errorf("bad receipt public key length %d", len(r.PublicKey))
+	}
+	if !ed25519.Verify(r.PublicKey, r.SigningBytes(), r.Signature) {
+		return xerrors.Errorf("invalid receipt signature")
+	}
+	return nil
+}
+
+// Covers checks that the receipt is for the given sector task and outputs.
+func (r *WorkReceipt) Covers(sector abi.SectorID, task sealtasks.TaskType, commr *cid.Cid, commd *cid.Cid, proof []byte) error {
+	if r.Sector != sector || r.Task != task {
+		return xerrors.Errorf("receipt is for %s of sector %d, expected %s of sector %d", r.Task, r.Sector.Number, task, sector.Number)
+	}
+
+	switch task {
+	case sealtasks.TTPreCommit2:
+		if r.CommR == nil || commr == nil || !r.CommR.Equals(*commr) {
+			return xerrors.Errorf("receipt CommR %s doesn't match %s", cidString(r.CommR), cidString(commr))
+		}
+		if r.CommD == nil || commd == nil || !r.CommD.Equals(*commd) {
+			return xerrors.Errorf("receipt CommD %s doesn't match %s", cidString(r.CommD), cidString(commd))
+		}
+	case sealtasks.TTCommit2:
+		if !bytes.Equal(r.ProofHash, ProofHash(proof)) {
+			return xerrors.Errorf("receipt proof hash %x doesn't match %x", r.ProofHash, ProofHash(proof))
+		}
+	default:
+		return xerrors.Errorf("no receipts for task %s", task)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package storiface
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+)
+
+type SealJobState string
+
+const (
+	SealJobQueued       SealJobState = "queued"
+	SealJobPreCommit1   SealJobState = "precommit1"
+	SealJobPreCommit2   SealJobState = "precommit2"
+	SealJobPreCommitted SealJobState = "precommitted" // waiting for the commit seed
+	SealJobCommit1      SealJobState = "commit1"
+	SealJobCommit2      SealJobState = "commit2"
+	SealJobCommitted    SealJobState = "committed" // replica and proof ready to fetch
+	SealJobFailed       SealJobState = "failed"
+)
+
+var sealJobOrder = map[SealJobState]int{
+	SealJobQueued:       0,
+	SealJobPreCommit1:   1,
+	SealJobPreCommit2:   2,
+	SealJobPreCommitted: 3,
+	SealJobCommit1:      4,
+	SealJobCommit2:      5,
+	SealJobCommitted:    6,
+}
+
+// Reached returns whether a job in this state went through state s.
+func (st SealJobState) Reached(s SealJobState) bool {
+	a, ok := sealJobOrder[st]
+	if !ok {
+		return false
+	}
+	return a >= sealJobOrder[s]
+}
+
+// SealPreCommitRequest asks a sealing service to run PC1 and PC2 of a sector.
+type SealPreCommitRequest struct {
+	Sector storage.SectorRef
+	Ticket abi.SealRandomness
+	Pieces []abi.PieceInfo
+
+	// UnsealedURLs are where the unsealed sector can be fetched from. The URLs
+	// are signed, they are fetched without credentials until they expire.
+	UnsealedURLs []string
+}
+
+// SealJob is the progress of a sector sealed by a sealing service.
+type SealJob struct {
+	Sector  abi.SectorID
+	State   SealJobState
+	Updated time.Time
+
+	Cids  *storage.SectorCids `json:",omitempty"` // set once precommitted
+	Proof storage.Proof       `json:",omitempty"` // set once committed
+
+	// where the replica can be fetched from once committed; the cache is
+	// served as a tar archive
+	SealedURL string `json:",omitempty"`
+	CacheURL  string `json:",omitempty"`
+
+	// Receipts for the work done so far, one for TTPreCommit2 once
+	// precommitted and one for TTCommit2 once committed
+	Receipts []WorkReceipt `json:",omitempty"`
+
+	Err string `json:",omitempty"`
+}
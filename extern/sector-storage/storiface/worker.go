@@ -19,6 +19,21 @@ type WorkerInfo struct {
 	Hostname string
 
 	Resources WorkerResources
+
+	// Calibration contains measurements of tasks on the worker, used by the
+	// scheduler instead of static resource estimates
+	Calibration []TaskCalibration `json:",omitempty"`
+}
+
+// TaskCalibration is the measured cost of running a task on a worker.
+type TaskCalibration struct {
+	Task      sealtasks.TaskType
+	SealProof abi.RegisteredSealProof
+
+	Duration   time.Duration
+	PeakMemory uint64 // 0 if it couldn't be measured
+
+	Measured time.Time
 }
 
 type WorkerResources struct {
@@ -29,6 +44,57 @@ type WorkerResources struct {
 
 	CPUs uint64 // Logical cores
 	GPUs []string
+
+	// GPUAssignment maps task types to indexes of GPUs reserved for them, see
+	// GPUsFor
+	GPUAssignment map[sealtasks.TaskType][]int `json:",omitempty"`
+}
+
+// GPUsFor returns the indexes of GPUs which can run tasks of the given type.
+// GPUs assigned to task types only run tasks of those types; tasks without an
+// assignment run on the remaining GPUs. A task holds its GPU while it runs,
+// the computation itself may use any device of the worker process.
+func (wr WorkerResources) GPUsFor(tt sealtasks.TaskType) []int {
+	var out []int
+	if idxs, ok := wr.GPUAssignment[tt]; ok {
+		for _, idx := range idxs {
+			if idx >= 0 && idx < len(wr.GPUs) {
+				out = append(out, idx)
+			}
+		}
+		return out
+	}
+
+	assigned := map[int]struct{}{}
+	for _, idxs := range wr.GPUAssignment {
+		for _, idx := range idxs {
+			assigned[idx] = struct{}{}
+		}
+	}
+	for idx := range wr.GPUs {
+		if _, ok := assigned[idx]; !ok {
+			out = append(out, idx)
+		}
+	}
+	return out
+}
+
+// GPUUsage is the usage of a GPU by tasks running on a worker.
+type GPUUsage struct {
+	Index  int
+	Device string
+
+	// Tasks are the task types the GPU is assigned to; empty when the GPU runs
+	// any tasks without an assignment
+	Tasks []sealtasks.TaskType
+
+	Task   sealtasks.TaskType `json:",omitempty"` // running task; empty when idle
+	Sector abi.SectorID
+	Since  time.Time // when the running task started, or when the GPU became idle
+
+	// Utilization is the fraction of time the GPU was running tasks since the
+	// worker started
+	Utilization float64
 }
 
 type WorkerStats struct {
@@ -39,6 +105,9 @@ type WorkerStats struct {
 	MemUsedMax uint64
 	GpuUsed    bool   // nolint
 	CpuUse     uint64 // nolint
+
+	// task types scheduled on each GPU, when GPUs are assigned to tasks
+	GPUTasks []sealtasks.TaskType `json:",omitempty"`
 }
 
 const (
@@ -63,6 +132,23 @@ type WorkerJob struct {
 	Hostname string `json:",omitempty"` // optional, set for ret-wait jobs
 }
 
+// UnsealJob is an unseal running or waiting in the unseal queue of the miner
+type UnsealJob struct {
+	Sector abi.SectorID
+	Offset UnpaddedByteIndex
+	Size   abi.UnpaddedPieceSize
+
+	// Storage is the ID of the storage path holding the sealed sector, the
+	// unseal queue is per path
+	Storage string
+
+	// 0 for running unseals, 1+ for the position in the path queue
+	Position int
+	Queued   time.Time
+	Started  time.Time // zero while queued
+	ETA      time.Time // estimated completion time
+}
+
 type CallID struct {
 	Sector abi.SectorID
 	ID     uuid.UUID
@@ -0,0 +1,206 @@
+package sectorstorage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+// DefaultUnsealDuration is how long unseals are estimated to take until one
+// completes.
+var DefaultUnsealDuration = time.Hour
+
+// unsealDurationDecay is the weight of the last unseal in the estimated unseal
+// duration.
+const unsealDurationDecay = 0.2
+
+// UnsealQueue is an Unsealer queueing unseals per storage path holding the
+// sealed sector, so that at most a limited number of unseals read from a path
+// at once, and estimating when queued unseals will complete.
+type UnsealQueue struct {
+	index stores.SectorIndex
+	uns   Unsealer
+	limit int // 0 for no limit
+
+	lk       sync.Mutex
+	paths    map[stores.ID]*unsealPath
+	estimate time.Duration
+}
+
+type unsealPath struct {
+	running []*unsealJob
+	queued  []*unsealJob
+}
+
+type unsealJob struct {
+	job   storiface.UnsealJob
+	ready chan struct{}
+}
+
+func NewUnsealQueue(index stores.SectorIndex, uns Unsealer, limitPerPath int) *UnsealQueue {
+	return &UnsealQueue{
+		index: index,
+		uns:   uns,
+		limit: limitPerPath,
+
+		paths:    map[stores.ID]*unsealPath{},
+		estimate: DefaultUnsealDuration,
+	}
+}
+
+func (q *UnsealQueue) SectorsUnsealPiece(ctx context.Context, sector storage.SectorRef, offset storiface.UnpaddedByteIndex, size abi.UnpaddedPieceSize, randomness abi.SealRandomness, commd *cid.Cid) error {
+	path := q.sealedPath(ctx, sector.ID)
+
+	j := &unsealJob{
+		job: storiface.UnsealJob{
+			Sector:  sector.ID,
+			Offset:  offset,
+			Size:    size,
+			Storage: string(path),
+			Queued:  time.Now(),
+		},
+		ready: make(chan struct{}),
+	}
+
+	q.lk.Lock()
+	p, ok := q.paths[path]
+	if !ok {
+		p = &unsealPath{}
+		q.paths[path] = p
+	}
+	if q.limit <= 0 || len(p.running) < q.limit {
+		q.start(p, j)
+	} else {
+		log.Infow("queueing unseal", "sector", sector.ID, "storage", path, "position", len(p.queued)+1)
+		p.queued = append(p.queued, j)
+	}
+	q.lk.Unlock()
+
+	select {
+	case <-j.ready:
+	case <-ctx.Done():
+		q.lk.Lock()
+		select {
+		case <-j.ready: // started just now, release the slot
+			q.finish(path, j, false)
+		default:
+			p.queued = removeUnsealJob(p.queued, j)
+			q.cleanup(path)
+		}
+		q.lk.Unlock()
+		return ctx.Err()
+	}
+
+	err := q.uns.SectorsUnsealPiece(ctx, sector, offset, size, randomness, commd)
+
+	q.lk.Lock()
+	q.finish(path, j, err == nil)
+	q.lk.Unlock()
+
+	return err
+}
+
+// sealedPath returns the ID of the storage path holding the sealed sector, or
+// an empty ID if it can't be found.
+func (q *UnsealQueue) sealedPath(ctx context.Context, sector abi.SectorID) stores.ID {
+	si, err := q.index.StorageFindSector(ctx, sector, storiface.FTSealed, 0, false)
+	if err != nil || len(si) == 0 {
+		log.Warnw("finding sealed sector storage for unsealing", "sector", sector, "error", err)
+		return ""
+	}
+	return si[0].ID
+}
+
+// must be called with the lock held
+func (q *UnsealQueue) start(p *unsealPath, j *unsealJob) {
+	j.job.Started = time.Now()
+	p.running = append(p.running, j)
+	close(j.ready)
+}
+
+// must be called with the lock held
+func (q *UnsealQueue) finish(path stores.ID, j *unsealJob, measure bool) {
+	if measure {
+		took := time.Since(j.job.Started)
+		q.estimate = time.Duration(unsealDurationDecay*float64(took) + (1-unsealDurationDecay)*float64(q.estimate))
+	}
+
+	p := q.paths[path]
+	p.running = removeUnsealJob(p.running, j)
+	if len(p.queued) > 0 && (q.limit <= 0 || len(p.running) < q.limit) {
+		next := p.queued[0]
+		p.queued = p.queued[1:]
+		q.start(p, next)
+	}
+	q.cleanup(path)
+}
+
+// must be called with the lock held
+func (q *UnsealQueue) cleanup(path stores.ID) {
+	if p := q.paths[path]; len(p.running) == 0 && len(p.queued) == 0 {
+		delete(q.paths, path)
+	}
+}
+
+func removeUnsealJob(jobs []*unsealJob, j *unsealJob) []*unsealJob {
+	for i, cj := range jobs {
+		if cj == j {
+			return append(jobs[:i], jobs[i+1:]...)
+		}
+	}
+	return jobs
+}
+
+// Jobs returns the running and queued unseals, with the estimated time at which
+// they will complete.
+func (q *UnsealQueue) Jobs() []storiface.UnsealJob {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+
+	now := time.Now()
+	var out []storiface.UnsealJob
+
+	for _, p := range q.paths {
+		// times at which each unseal slot of the path becomes free
+		var free []time.Time
+
+		for _, j := range p.running {
+			job := j.job
+			job.ETA = job.Started.Add(q.estimate)
+			if job.ETA.Before(now) {
+				job.ETA = now
+			}
+			free = append(free, job.ETA)
+			out = append(out, job)
+		}
+
+		for i, j := range p.queued {
+			sort.Slice(free, func(a, b int) bool {
+				return free[a].Before(free[b])
+			})
+
+			job := j.job
+			job.Position = i + 1
+			job.ETA = free[0].Add(q.estimate)
+			free[0] = job.ETA
+			out = append(out, job)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ETA.Before(out[j].ETA)
+	})
+
+	return out
+}
+
+var _ Unsealer = &UnsealQueue{}
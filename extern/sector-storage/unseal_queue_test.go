@@ -0,0 +1,105 @@
+package sectorstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+type sealedPathIndex struct {
+	stores.SectorIndex
+}
+
+func (sealedPathIndex) StorageFindSector(ctx context.Context, sector abi.SectorID, ft storiface.SectorFileType, ssize abi.SectorSize, allowFetch bool) ([]stores.SectorStorageInfo, error) {
+	// even sectors on path "a", odd on "b"
+	if sector.Number%2 == 0 {
+		return []stores.SectorStorageInfo{{ID: "a"}}, nil
+	}
+	return []stores.SectorStorageInfo{{ID: "b"}}, nil
+}
+
+type blockingUnsealer struct {
+	started chan abi.SectorNumber
+	release map[abi.SectorNumber]chan struct{}
+}
+
+func (u *blockingUnsealer) SectorsUnsealPiece(ctx context.Context, sector storage.SectorRef, offset storiface.UnpaddedByteIndex, size abi.UnpaddedPieceSize, randomness abi.SealRandomness, commd *cid.Cid) error {
+	u.started <- sector.ID.Number
+	<-u.release[sector.ID.Number]
+	return nil
+}
+
+func TestUnsealQueue(t *testing.T) {
+	uns := &blockingUnsealer{
+		started: make(chan abi.SectorNumber, 10),
+		release: map[abi.SectorNumber]chan struct{}{},
+	}
+	for n := abi.SectorNumber(0); n < 5; n++ {
+		uns.release[n] = make(chan struct{})
+	}
+	q := NewUnsealQueue(sealedPathIndex{}, uns, 1)
+
+	unseal := func(ctx context.Context, n abi.SectorNumber) chan error {
+		done := make(chan error, 1)
+		go func() {
+			done <- q.SectorsUnsealPiece(ctx, storage.SectorRef{ID: abi.SectorID{Miner: 1000, Number: n}}, 0, 1016, nil, nil)
+		}()
+		return done
+	}
+
+	waitJobs := func(n int) []storiface.UnsealJob {
+		require.Eventually(t, func() bool {
+			return len(q.Jobs()) == n
+		}, time.Second, time.Millisecond)
+		return q.Jobs()
+	}
+
+	// sectors 0 and 1 are on different paths, both start
+	done0 := unseal(context.Background(), 0)
+	done1 := unseal(context.Background(), 1)
+	require.ElementsMatch(t, []abi.SectorNumber{0, 1}, []abi.SectorNumber{<-uns.started, <-uns.started})
+
+	// sectors 2 and 4 queue behind 0
+	done2 := unseal(context.Background(), 2)
+	waitJobs(3)
+	ctx4, cancel4 := context.WithCancel(context.Background())
+	done4 := unseal(ctx4, 4)
+	jobs := waitJobs(4)
+
+	queued := map[abi.SectorNumber]storiface.UnsealJob{}
+	for _, j := range jobs {
+		if j.Position > 0 {
+			queued[j.Sector.Number] = j
+		}
+	}
+	require.Len(t, queued, 2)
+	require.Equal(t, 1, queued[2].Position)
+	require.Equal(t, 2, queued[4].Position)
+	require.Equal(t, "a", queued[4].Storage)
+	require.True(t, queued[4].ETA.After(queued[2].ETA))
+
+	// cancelling a queued unseal removes it from the queue
+	cancel4()
+	require.ErrorIs(t, <-done4, context.Canceled)
+	waitJobs(3)
+
+	// completing 0 starts 2
+	close(uns.release[0])
+	require.NoError(t, <-done0)
+	require.Equal(t, abi.SectorNumber(2), <-uns.started)
+
+	close(uns.release[1])
+	close(uns.release[2])
+	require.NoError(t, <-done1)
+	require.NoError(t, <-done2)
+	waitJobs(0)
+}
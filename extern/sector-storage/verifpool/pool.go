@@ -0,0 +1,160 @@
+package verifpool
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper"
+)
+
+var log = logging.Logger("verifpool")
+
+// Worker verifies proofs remotely, it's implemented by the API of workers with
+// the verify task enabled.
+type Worker interface {
+	VerifySeal(ctx context.Context, info proof5.SealVerifyInfo) (bool, error)
+	VerifyAggregateSeals(ctx context.Context, aggregate proof5.AggregateSealVerifyProofAndInfos) (bool, error)
+	VerifyWinningPoSt(ctx context.Context, info proof5.WinningPoStVerifyInfo) (bool, error)
+	VerifyWindowPoSt(ctx context.Context, info proof5.WindowPoStVerifyInfo) (bool, error)
+}
+
+// Pool is a Verifier dispatching proof verifications to workers in turn,
+// trying the next worker when one fails or doesn't answer in time. Proofs are
+// verified locally when there are no workers, or when all of them failed and
+// local fallback is enabled.
+type Pool struct {
+	local    ffiwrapper.Verifier
+	throttle chan struct{} // nil for no limit
+	fallback bool
+	timeout  time.Duration
+
+	workers []Worker
+	next    uint64
+}
+
+// New returns a pool verifying at most parallel proofs locally at once, 0
+// for no limit, and giving workers timeout to verify a proof, 0 for no limit.
+func New(local ffiwrapper.Verifier, parallel int, fallback bool, timeout time.Duration, workers ...Worker) *Pool {
+	p := &Pool{
+		local:    local,
+		fallback: fallback,
+		timeout:  timeout,
+		workers:  workers,
+	}
+	if parallel > 0 {
+		p.throttle = make(chan struct{}, parallel)
+	}
+	return p
+}
+
+func (p *Pool) verify(ctx context.Context, kind string, remote func(context.Context, Worker) (bool, error), local func() (bool, error)) (bool, error) {
+	if len(p.workers) > 0 {
+		start := atomic.AddUint64(&p.next, 1)
+		for i := 0; i < len(p.workers); i++ {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+
+			ok, err := p.verifyRemote(ctx, p.workers[(start+uint64(i))%uint64(len(p.workers))], remote)
+			if err == nil {
+				return ok, nil
+			}
+			log.Warnw("verifying proof on worker", "proof", kind, "error", err)
+		}
+
+		if !p.fallback {
+			return false, xerrors.Errorf("no worker could verify the %s proof", kind)
+		}
+	}
+
+	if p.throttle != nil {
+		select {
+		case p.throttle <- struct{}{}:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+		defer func() {
+			<-p.throttle
+		}()
+	}
+
+	return local()
+}
+
+// verifyRemote runs the verification on the worker, giving up after the pool
+// timeout. The RPC client doesn't always return when the context is done,
+// e.g. while the connection is being established, so the call is left behind
+// rather than waited for.
+func (p *Pool) verifyRemote(ctx context.Context, w Worker, remote func(context.Context, Worker) (bool, error)) (bool, error) {
+	if p.timeout <= 0 {
+		return remote(ctx, w)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	type result struct {
+		ok  bool
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		ok, err := remote(ctx, w)
+		done <- result{ok, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.ok, r.err
+	case <-ctx.Done():
+		return false, xerrors.Errorf("worker didn't verify the proof in %s: %w", p.timeout, ctx.Err())
+	}
+}
+
+func (p *Pool) VerifySeal(info proof5.SealVerifyInfo) (bool, error) {
+	ctx := context.TODO()
+	return p.verify(ctx, "seal", func(ctx context.Context, w Worker) (bool, error) {
+		return w.VerifySeal(ctx, info)
+	}, func() (bool, error) {
+		return p.local.VerifySeal(info)
+	})
+}
+
+func (p *Pool) VerifyAggregateSeals(aggregate proof5.AggregateSealVerifyProofAndInfos) (bool, error) {
+	ctx := context.TODO()
+	return p.verify(ctx, "aggregate seal", func(ctx context.Context, w Worker) (bool, error) {
+		return w.VerifyAggregateSeals(ctx, aggregate)
+	}, func() (bool, error) {
+		return p.local.VerifyAggregateSeals(aggregate)
+	})
+}
+
+func (p *Pool) VerifyWinningPoSt(ctx context.Context, info proof5.WinningPoStVerifyInfo) (bool, error) {
+	return p.verify(ctx, "winning PoSt", func(ctx context.Context, w Worker) (bool, error) {
+		return w.VerifyWinningPoSt(ctx, info)
+	}, func() (bool, error) {
+		return p.local.VerifyWinningPoSt(ctx, info)
+	})
+}
+
+func (p *Pool) VerifyWindowPoSt(ctx context.Context, info proof5.WindowPoStVerifyInfo) (bool, error) {
+	return p.verify(ctx, "window PoSt", func(ctx context.Context, w Worker) (bool, error) {
+		return w.VerifyWindowPoSt(ctx, info)
+	}, func() (bool, error) {
+		return p.local.VerifyWindowPoSt(ctx, info)
+	})
+}
+
+// GenerateWinningPoStSectorChallenge is cheap, it's always computed locally.
+func (p *Pool) GenerateWinningPoStSectorChallenge(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, eligibleSectorCount uint64) ([]uint64, error) {
+	return p.local.GenerateWinningPoStSectorChallenge(ctx, proofType, minerID, randomness, eligibleSectorCount)
+}
+
+var _ ffiwrapper.Verifier = &Pool{}
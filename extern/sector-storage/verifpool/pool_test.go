@@ -0,0 +1,129 @@
+package verifpool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+)
+
+type testVerifier struct {
+	err   error
+	calls int
+}
+
+func (v *testVerifier) VerifySeal(proof5.SealVerifyInfo) (bool, error) {
+	v.calls++
+	return v.err == nil, v.err
+}
+
+func (v *testVerifier) VerifyAggregateSeals(proof5.AggregateSealVerifyProofAndInfos) (bool, error) {
+	v.calls++
+	return v.err == nil, v.err
+}
+
+func (v *testVerifier) VerifyWinningPoSt(context.Context, proof5.WinningPoStVerifyInfo) (bool, error) {
+	v.calls++
+	return v.err == nil, v.err
+}
+
+func (v *testVerifier) VerifyWindowPoSt(context.Context, proof5.WindowPoStVerifyInfo) (bool, error) {
+	v.calls++
+	return v.err == nil, v.err
+}
+
+func (v *testVerifier) GenerateWinningPoStSectorChallenge(context.Context, abi.RegisteredPoStProof, abi.ActorID, abi.PoStRandomness, uint64) ([]uint64, error) {
+	return nil, nil
+}
+
+type testWorker struct {
+	testVerifier
+}
+
+func (w *testWorker) VerifySeal(_ context.Context, info proof5.SealVerifyInfo) (bool, error) {
+	return w.testVerifier.VerifySeal(info)
+}
+
+func (w *testWorker) VerifyAggregateSeals(_ context.Context, aggregate proof5.AggregateSealVerifyProofAndInfos) (bool, error) {
+	return w.testVerifier.VerifyAggregateSeals(aggregate)
+}
+
+func TestPoolDispatch(t *testing.T) {
+	local := &testVerifier{}
+	w1, w2 := &testWorker{}, &testWorker{}
+	p := New(local, 1, true, 0, w1, w2)
+
+	for i := 0; i < 4; i++ {
+		ok, err := p.VerifySeal(proof5.SealVerifyInfo{})
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	require.Equal(t, 2, w1.calls)
+	require.Equal(t, 2, w2.calls)
+	require.Equal(t, 0, local.calls)
+
+	// a failing worker is skipped
+	w1.err = xerrors.New("verify task disabled")
+	ok, err := p.VerifyAggregateSeals(proof5.AggregateSealVerifyProofAndInfos{})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 3, w2.calls)
+	require.Equal(t, 0, local.calls)
+
+	// all workers failing falls back to local verification
+	w2.err = xerrors.New("connection refused")
+	ok, err = p.VerifyWindowPoSt(context.Background(), proof5.WindowPoStVerifyInfo{})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, local.calls)
+
+	// unless fallback is disabled
+	p = New(local, 0, false, 0, w1, w2)
+	_, err = p.VerifyWinningPoSt(context.Background(), proof5.WinningPoStVerifyInfo{})
+	require.Error(t, err)
+	require.Equal(t, 1, local.calls)
+}
+
+type hungWorker struct {
+	testWorker
+	hung int64
+}
+
+func (w *hungWorker) VerifySeal(ctx context.Context, _ proof5.SealVerifyInfo) (bool, error) {
+	atomic.AddInt64(&w.hung, 1)
+	<-ctx.Done()
+	return false, ctx.Err()
+}
+
+func (w *hungWorker) VerifyWinningPoSt(context.Context, proof5.WinningPoStVerifyInfo) (bool, error) {
+	atomic.AddInt64(&w.hung, 1)
+	select {} // ignores the context
+}
+
+func TestPoolTimeout(t *testing.T) {
+	local := &testVerifier{}
+	hung, w := &hungWorker{}, &testWorker{}
+	p := New(local, 0, true, 50*time.Millisecond, hung, w)
+
+	// the hung worker is given up on, whichever is tried first
+	for i := 0; i < 2; i++ {
+		ok, err := p.VerifySeal(proof5.SealVerifyInfo{})
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	require.EqualValues(t, 1, atomic.LoadInt64(&hung.hung))
+	require.Equal(t, 2, w.calls)
+
+	// falls back to local verification when no worker answers
+	p = New(local, 0, true, 50*time.Millisecond, hung)
+	ok, err := p.VerifyWinningPoSt(context.Background(), proof5.WinningPoStVerifyInfo{})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, local.calls)
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"reflect"
 	"runtime"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/hashicorp/go-multierror"
 	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
 	"golang.org/x/xerrors"
 
 	ffi "github.com/filecoin-project/filecoin-ffi"
@@ -33,6 +35,21 @@ var pathTypes = []storiface.SectorFileType{storiface.FTUnsealed, storiface.FTSea
 type WorkerConfig struct {
 	TaskTypes []sealtasks.TaskType
 	NoSwap    bool
+
+	// GPUAssignment reserves GPUs, by index, for task types. This limits the
+	// tasks running at once, proofs aren't bound to the device.
+	GPUAssignment map[sealtasks.TaskType][]int
+
+	// Calibration is reported to the scheduler in worker info
+	Calibration []storiface.TaskCalibration
+
+	// ResultCache stores results of PC2/C2 tasks, so that they can be returned
+	// without recomputing when re-issued after a miner restart. Disabled when nil.
+	ResultCache datastore.Datastore
+
+	// ProofBackends compute the proofs of the kinds they are set for instead
+	// of the FFI
+	ProofBackends ffiwrapper.ProofBackends
 }
 
 // used do provide custom proofs impl (mostly used in testing)
@@ -45,12 +62,19 @@ type LocalWorker struct {
 	ret        storiface.WorkerReturn
 	executor   ExecutorFunc
 	noSwap     bool
+	calibrated []storiface.TaskCalibration
+	backends   ffiwrapper.ProofBackends
 
 	ct          *workerCallTracker
+	results     *workerResultCache
+	gpus        *gpuAllocator
 	acceptTasks map[sealtasks.TaskType]struct{}
 	running     sync.WaitGroup
 	taskLk      sync.Mutex
 
+	runningLk    sync.Mutex
+	runningCalls map[storiface.CallID]storiface.WorkerJob
+
 	session     uuid.UUID
 	testDisable int64
 	closing     chan struct{}
@@ -74,6 +98,11 @@ func newLocalWorker(executor ExecutorFunc, wcfg WorkerConfig, store stores.Store
 		acceptTasks: acceptTasks,
 		executor:    executor,
 		noSwap:      wcfg.NoSwap,
+		calibrated:  wcfg.Calibration,
+		backends:    wcfg.ProofBackends,
+		gpus:        newGPUAllocator(wcfg.GPUAssignment),
+
+		runningCalls: map[storiface.CallID]storiface.WorkerJob{},
 
 		session: uuid.New(),
 		closing: make(chan struct{}),
@@ -83,6 +112,14 @@ func newLocalWorker(executor ExecutorFunc, wcfg WorkerConfig, store stores.Store
 		w.executor = w.ffiExec
 	}
 
+	if wcfg.ResultCache != nil {
+		w.results = &workerResultCache{ds: wcfg.ResultCache}
+		if err := w.results.gc(); err != nil {
+			log.Errorf("removing expired task results: %+v", err)
+		}
+		go w.results.runGC(w.closing)
+	}
+
 	unfinished, err := w.ct.unfinished()
 	if err != nil {
 		log.Errorf("reading unfinished tasks: %+v", err)
@@ -116,13 +153,19 @@ type localWorkerPathProvider struct {
 }
 
 func (l *localWorkerPathProvider) AcquireSector(ctx context.Context, sector storage.SectorRef, existing storiface.SectorFileType, allocate storiface.SectorFileType, sealing storiface.PathType) (storiface.SectorPaths, func(), error) {
-	paths, storageIDs, err := l.w.storage.AcquireSector(ctx, sector, existing, allocate, sealing, l.op)
+	// files read from the object store cache stay there until the paths are
+	// released
+	actx, release := context.WithCancel(ctx)
+
+	paths, storageIDs, err := l.w.storage.AcquireSector(actx, sector, existing, allocate, sealing, l.op)
 	if err != nil {
+		release()
 		return storiface.SectorPaths{}, nil, err
 	}
 
 	releaseStorage, err := l.w.localStore.Reserve(ctx, sector, allocate, storageIDs, storiface.FSOverheadSeal)
 	if err != nil {
+		release()
 		return storiface.SectorPaths{}, nil, xerrors.Errorf("reserving storage space: %w", err)
 	}
 
@@ -130,6 +173,7 @@ func (l *localWorkerPathProvider) AcquireSector(ctx context.Context, sector stor
 
 	return paths, func() {
 		releaseStorage()
+		release()
 
 		for _, fileType := range pathTypes {
 			if fileType&allocate == 0 {
@@ -146,7 +190,7 @@ func (l *localWorkerPathProvider) AcquireSector(ctx context.Context, sector stor
 }
 
 func (l *LocalWorker) ffiExec() (ffiwrapper.Storage, error) {
-	return ffiwrapper.New(&localWorkerPathProvider{w: l})
+	return ffiwrapper.New(&localWorkerPathProvider{w: l}, ffiwrapper.WithProofBackends(l.backends))
 }
 
 type ReturnType string
@@ -211,20 +255,53 @@ var returnFunc = map[ReturnType]func(context.Context, storiface.CallID, storifac
 	Fetch:           rfunc(storiface.WorkerReturn.ReturnFetch),
 }
 
+// returnTaskType maps calls to the task types they are scheduled as, for
+// applying storage path rules
+var returnTaskType = map[ReturnType]sealtasks.TaskType{
+	AddPiece:        sealtasks.TTAddPiece,
+	SealPreCommit1:  sealtasks.TTPreCommit1,
+	SealPreCommit2:  sealtasks.TTPreCommit2,
+	SealCommit1:     sealtasks.TTCommit1,
+	SealCommit2:     sealtasks.TTCommit2,
+	FinalizeSector:  sealtasks.TTFinalize,
+	ReleaseUnsealed: sealtasks.TTFinalize,
+	MoveStorage:     sealtasks.TTFetch,
+	UnsealPiece:     sealtasks.TTUnseal,
+	Fetch:           sealtasks.TTFetch,
+}
+
 func (l *LocalWorker) asyncCall(ctx context.Context, sector storage.SectorRef, rt ReturnType, work func(ctx context.Context, ci storiface.CallID) (interface{}, error)) (storiface.CallID, error) {
 	ci := storiface.CallID{
 		Sector: sector.ID,
 		ID:     uuid.New(),
 	}
 
+	if task, ok := returnTaskType[rt]; ok {
+		ctx = stores.WithTaskType(ctx, task)
+	}
+
 	if err := l.ct.onStart(ci, rt); err != nil {
 		log.Errorf("tracking call (start): %+v", err)
 	}
 
 	l.running.Add(1)
 
+	l.runningLk.Lock()
+	l.runningCalls[ci] = storiface.WorkerJob{
+		ID:     ci,
+		Sector: sector.ID,
+		Task:   returnTaskType[rt],
+		Start:  time.Now(),
+	}
+	l.runningLk.Unlock()
+
 	go func() {
 		defer l.running.Done()
+		defer func() {
+			l.runningLk.Lock()
+			delete(l.runningCalls, ci)
+			l.runningLk.Unlock()
+		}()
 
 		ctx := &wctx{
 			vals:    ctx,
@@ -347,9 +424,9 @@ func (l *LocalWorker) SealPreCommit2(ctx context.Context, sector storage.SectorR
 		return storiface.UndefCall, err
 	}
 
-	return l.asyncCall(ctx, sector, SealPreCommit2, func(ctx context.Context, ci storiface.CallID) (interface{}, error) {
+	return l.asyncCall(ctx, sector, SealPreCommit2, l.cachedWork(sealtasks.TTPreCommit2, new(storage.SectorCids), l.gpuWork(sealtasks.TTPreCommit2, sector.ID, func(ctx context.Context, ci storiface.CallID) (interface{}, error) {
 		return sb.SealPreCommit2(ctx, sector, phase1Out)
-	})
+	}), sector, phase1Out))
 }
 
 func (l *LocalWorker) SealCommit1(ctx context.Context, sector storage.SectorRef, ticket abi.SealRandomness, seed abi.InteractiveSealRandomness, pieces []abi.PieceInfo, cids storage.SectorCids) (storiface.CallID, error) {
@@ -369,9 +446,9 @@ func (l *LocalWorker) SealCommit2(ctx context.Context, sector storage.SectorRef,
 		return storiface.UndefCall, err
 	}
 
-	return l.asyncCall(ctx, sector, SealCommit2, func(ctx context.Context, ci storiface.CallID) (interface{}, error) {
+	return l.asyncCall(ctx, sector, SealCommit2, l.cachedWork(sealtasks.TTCommit2, new(storage.Proof), l.gpuWork(sealtasks.TTCommit2, sector.ID, func(ctx context.Context, ci storiface.CallID) (interface{}, error) {
 		return sb.SealCommit2(ctx, sector, phase1Out)
-	})
+	}), sector, phase1Out))
 }
 
 func (l *LocalWorker) FinalizeSector(ctx context.Context, sector storage.SectorRef, keepUnsealed []storage.Range) (storiface.CallID, error) {
@@ -508,7 +585,10 @@ func (l *LocalWorker) Info(context.Context) (storiface.WorkerInfo, error) {
 			MemReserved: mem.VirtualUsed + mem.Total - mem.Available, // TODO: sub this process
 			CPUs:        uint64(runtime.NumCPU()),
 			GPUs:        gpus,
+
+			GPUAssignment: l.gpus.assignment,
 		},
+		Calibration: l.calibrated,
 	}, nil
 }
 
@@ -535,6 +615,22 @@ func (l *LocalWorker) WaitQuiet() {
 	l.running.Wait()
 }
 
+// RunningTasks returns the tasks the worker is processing, oldest first
+func (l *LocalWorker) RunningTasks(ctx context.Context) ([]storiface.WorkerJob, error) {
+	l.runningLk.Lock()
+	out := make([]storiface.WorkerJob, 0, len(l.runningCalls))
+	for _, job := range l.runningCalls {
+		out = append(out, job)
+	}
+	l.runningLk.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Start.Before(out[j].Start)
+	})
+
+	return out, nil
+}
+
 type wctx struct {
 	vals    context.Context
 	closing chan struct{}
@@ -0,0 +1,164 @@
+package sectorstorage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+// ResultCacheTTL is how long workers keep results of expensive tasks
+var ResultCacheTTL = 24 * time.Hour
+
+// ResultCacheGCInterval is how often workers remove expired results
+var ResultCacheGCInterval = time.Hour
+
+type cachedResult struct {
+	ID     WorkID
+	Result json.RawMessage
+	Done   time.Time
+}
+
+// workerResultCache keeps results of expensive tasks by work ID. When the
+// miner re-issues a task which already finished, e.g. because it restarted
+// before the result was returned, the cached result is returned instead of
+// computing it again.
+type workerResultCache struct {
+	ds datastore.Datastore
+}
+
+func resultKey(wid WorkID) datastore.Key {
+	h := sha256.Sum256([]byte(wid.String()))
+	return datastore.NewKey(hex.EncodeToString(h[:]))
+}
+
+// get decodes the cached result of the work into out, a pointer to the result
+// type.
+func (c *workerResultCache) get(wid WorkID, out interface{}) (bool, error) {
+	b, err := c.ds.Get(resultKey(wid))
+	if err == datastore.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var cr cachedResult
+	if err := json.Unmarshal(b, &cr); err != nil {
+		return false, xerrors.Errorf("unmarshaling cached result: %w", err)
+	}
+	if cr.ID != wid || time.Since(cr.Done) > ResultCacheTTL {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(cr.Result, out); err != nil {
+		return false, xerrors.Errorf("unmarshaling result: %w", err)
+	}
+	return true, nil
+}
+
+func (c *workerResultCache) put(wid WorkID, res interface{}) error {
+	rb, err := json.Marshal(res)
+	if err != nil {
+		return xerrors.Errorf("marshaling result: %w", err)
+	}
+
+	b, err := json.Marshal(cachedResult{
+		ID:     wid,
+		Result: rb,
+		Done:   time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.ds.Put(resultKey(wid), b)
+}
+
+// gc removes expired results.
+func (c *workerResultCache) gc() error {
+	res, err := c.ds.Query(query.Query{})
+	if err != nil {
+		return err
+	}
+	defer res.Close() // nolint
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return r.Error
+		}
+
+		var cr cachedResult
+		if err := json.Unmarshal(r.Value, &cr); err == nil && time.Since(cr.Done) <= ResultCacheTTL {
+			continue
+		}
+
+		if err := c.ds.Delete(datastore.NewKey(r.Key)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runGC removes expired results periodically, until stop is closed
+func (c *workerResultCache) runGC(stop <-chan struct{}) {
+	t := time.NewTicker(ResultCacheGCInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if err := c.gc(); err != nil {
+				log.Errorf("removing expired task results: %+v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// cachedWork wraps work producing a result of the type out points to,
+// returning the cached result of the same work if there is one.
+func (l *LocalWorker) cachedWork(task sealtasks.TaskType, out interface{}, work func(ctx context.Context, ci storiface.CallID) (interface{}, error), params ...interface{}) func(ctx context.Context, ci storiface.CallID) (interface{}, error) {
+	if l.results == nil {
+		return work
+	}
+
+	return func(ctx context.Context, ci storiface.CallID) (interface{}, error) {
+		wid, err := newWorkID(task, params...)
+		if err != nil {
+			return nil, err
+		}
+
+		// calls run concurrently, decode into a new value each time
+		cached := reflect.New(reflect.TypeOf(out).Elem())
+		ok, err := l.results.get(wid, cached.Interface())
+		if err != nil {
+			log.Warnf("getting cached result of %s: %+v", task, err)
+		}
+		if ok {
+			log.Infow("returning cached result", "task", task, "sector", ci.Sector)
+			return cached.Elem().Interface(), nil
+		}
+
+		res, err := work(ctx, ci)
+		if err != nil {
+			return res, err
+		}
+
+		if err := l.results.put(wid, res); err != nil {
+			log.Warnf("caching result of %s: %+v", task, err)
+		}
+		return res, nil
+	}
+}
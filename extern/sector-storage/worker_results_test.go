@@ -0,0 +1,130 @@
+package sectorstorage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+func testWorkID(t *testing.T, n abi.SectorNumber) WorkID {
+	wid, err := newWorkID(sealtasks.TTCommit2, storage.SectorRef{ID: abi.SectorID{Miner: 1000, Number: n}})
+	require.NoError(t, err)
+	return wid
+}
+
+// putResultDone stores a result as if it was computed at done
+func putResultDone(t *testing.T, ds datastore.Datastore, wid WorkID, res interface{}, done time.Time) {
+	rb, err := json.Marshal(res)
+	require.NoError(t, err)
+	b, err := json.Marshal(cachedResult{ID: wid, Result: rb, Done: done})
+	require.NoError(t, err)
+	require.NoError(t, ds.Put(resultKey(wid), b))
+}
+
+func TestWorkerResultCache(t *testing.T) {
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	c := &workerResultCache{ds: ds}
+
+	hit, miss, expired, corrupt, other := testWorkID(t, 1), testWorkID(t, 2), testWorkID(t, 3), testWorkID(t, 4), testWorkID(t, 5)
+
+	require.NoError(t, c.put(hit, storage.Proof("proof 1")))
+	putResultDone(t, ds, expired, storage.Proof("proof 3"), time.Now().Add(-ResultCacheTTL-time.Minute))
+	require.NoError(t, ds.Put(resultKey(corrupt), []byte("not json")))
+	// an entry of another work stored under the key of the work
+	putResultDone(t, ds, other, storage.Proof("proof 5"), time.Now())
+	b, err := ds.Get(resultKey(other))
+	require.NoError(t, err)
+	require.NoError(t, ds.Put(resultKey(testWorkID(t, 6)), b))
+
+	var p storage.Proof
+	ok, err := c.get(hit, &p)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, storage.Proof("proof 1"), p)
+
+	ok, err = c.get(miss, &p)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = c.get(expired, &p)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = c.get(corrupt, &p)
+	require.Error(t, err)
+
+	ok, err = c.get(testWorkID(t, 6), &p)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// gc removes the expired and corrupt entries
+	require.NoError(t, c.gc())
+	for wid, keep := range map[WorkID]bool{hit: true, expired: false, corrupt: false, other: true} {
+		has, err := ds.Has(resultKey(wid))
+		require.NoError(t, err)
+		require.Equal(t, keep, has, wid.String())
+	}
+}
+
+func TestWorkerResultCacheGC(t *testing.T) {
+	defer func(interval time.Duration) {
+		ResultCacheGCInterval = interval
+	}(ResultCacheGCInterval)
+	ResultCacheGCInterval = 10 * time.Millisecond
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	c := &workerResultCache{ds: ds}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go c.runGC(stop)
+
+	// results expiring while the worker runs are removed
+	wid := testWorkID(t, 1)
+	putResultDone(t, ds, wid, storage.Proof("proof"), time.Now().Add(-ResultCacheTTL-time.Minute))
+	require.Eventually(t, func() bool {
+		has, err := ds.Has(resultKey(wid))
+		require.NoError(t, err)
+		return !has
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestCachedWork(t *testing.T) {
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	l := &LocalWorker{results: &workerResultCache{ds: ds}}
+
+	calls := 0
+	work := func(ctx context.Context, ci storiface.CallID) (interface{}, error) {
+		calls++
+		return storage.Proof("proof"), nil
+	}
+
+	sector := storage.SectorRef{ID: abi.SectorID{Miner: 1000, Number: 1}}
+	ci := storiface.CallID{Sector: sector.ID}
+	cw := l.cachedWork(sealtasks.TTCommit2, new(storage.Proof), work, sector)
+
+	// computed once, then returned from the cache
+	for i := 0; i < 2; i++ {
+		res, err := cw(context.Background(), ci)
+		require.NoError(t, err)
+		require.Equal(t, storage.Proof("proof"), res)
+	}
+	require.Equal(t, 1, calls)
+
+	// other work isn't
+	other := storage.SectorRef{ID: abi.SectorID{Miner: 1000, Number: 2}}
+	_, err := l.cachedWork(sealtasks.TTCommit2, new(storage.Proof), work, other)(context.Background(), storiface.CallID{Sector: other.ID})
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+}
@@ -30,6 +30,10 @@ type ErrBadTicket struct{ error }
 type ErrPrecommitOnChain struct{ error }
 type ErrSectorNumberAllocated struct{ error }
 
+// ErrSectorNumberCollision means the sector number is used on chain by a
+// different sector, e.g. one sealed before the datastore was restored
+type ErrSectorNumberCollision struct{ error }
+
 type ErrBadSeed struct{ error }
 type ErrInvalidProof struct{ error }
 type ErrNoPrecommit struct{ error }
@@ -108,6 +112,9 @@ func checkPrecommit(ctx context.Context, maddr address.Address, si SectorInfo, t
 	}
 
 	if pci != nil {
+		if si.CommR != nil && pci.Info.SealedCID != *si.CommR {
+			return &ErrSectorNumberCollision{xerrors.Errorf("sector number precommitted on chain with a different CommR: %s != %s", pci.Info.SealedCID, si.CommR)}
+		}
 		if pci.Info.SealRandEpoch != si.TicketEpoch {
 			return &ErrBadTicket{xerrors.Errorf("bad ticket epoch: %d != %d", pci.Info.SealRandEpoch, si.TicketEpoch)}
 		}
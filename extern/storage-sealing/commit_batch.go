@@ -12,6 +12,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/actors"
 
 	"github.com/ipfs/go-cid"
+	"go.opencensus.io/stats"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
@@ -25,9 +26,10 @@ import (
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper"
 	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
-	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/metrics"
 )
 
 const arp = abi.RegisteredAggregationProof_SnarkPackV1
@@ -39,8 +41,11 @@ type CommitBatcherApi interface {
 	ChainBaseFee(context.Context, TipSetToken) (abi.TokenAmount, error)
 
 	StateSectorPreCommitInfo(ctx context.Context, maddr address.Address, sectorNumber abi.SectorNumber, tok TipSetToken) (*miner.SectorPreCommitOnChainInfo, error)
+	StateSectorGetInfo(ctx context.Context, maddr address.Address, sectorNumber abi.SectorNumber, tok TipSetToken) (*miner.SectorOnChainInfo, error)
 	StateMinerInitialPledgeCollateral(context.Context, address.Address, miner.SectorPreCommitInfo, TipSetToken) (big.Int, error)
+	StateMarketStorageDeal(context.Context, abi.DealID, TipSetToken) (*api.MarketDeal, error)
 	StateNetworkVersion(ctx context.Context, tok TipSetToken) (network.Version, error)
+	StateSearchMsg(context.Context, cid.Cid) (*MsgLookup, error)
 }
 
 type AggregateInput struct {
@@ -54,34 +59,53 @@ type CommitBatcher struct {
 	maddr     address.Address
 	mctx      context.Context
 	addrSel   AddrSel
-	feeCfg    config.MinerFeeConfig
+	getFeeCfg GetFeeConfigFunc
 	getConfig GetSealingConfigFunc
 	prover    ffiwrapper.Prover
 
 	cutoffs map[abi.SectorNumber]time.Time
 	todo    map[abi.SectorNumber]AggregateInput
 	waiting map[abi.SectorNumber][]chan sealiface.CommitBatchRes
+	added   map[abi.SectorNumber]time.Time
 
-	notify, stop, stopped chan struct{}
-	force                 chan chan []sealiface.CommitBatchRes
-	lk                    sync.Mutex
+	// commit messages sent for sectors, which may still be in flight
+	sent map[abi.SectorNumber]sentCommit
+
+	notify, reload, stop, stopped chan struct{}
+	force                         chan chan []sealiface.CommitBatchRes
+	lk                            sync.Mutex
+
+	maintenance bool // batches held in maintenance mode
+
+	fb      *FeeBreaker
+	feeHeld bool // batches held by the base fee circuit breaker
+}
+
+type sentCommit struct {
+	msg   cid.Cid
+	epoch abi.ChainEpoch
+	spt   abi.RegisteredSealProof
 }
 
-func NewCommitBatcher(mctx context.Context, maddr address.Address, api CommitBatcherApi, addrSel AddrSel, feeCfg config.MinerFeeConfig, getConfig GetSealingConfigFunc, prov ffiwrapper.Prover) *CommitBatcher {
+func NewCommitBatcher(mctx context.Context, maddr address.Address, api CommitBatcherApi, addrSel AddrSel, getFeeCfg GetFeeConfigFunc, getConfig GetSealingConfigFunc, prov ffiwrapper.Prover, fb *FeeBreaker) *CommitBatcher {
 	b := &CommitBatcher{
 		api:       api,
 		maddr:     maddr,
 		mctx:      mctx,
 		addrSel:   addrSel,
-		feeCfg:    feeCfg,
+		getFeeCfg: getFeeCfg,
 		getConfig: getConfig,
 		prover:    prov,
+		fb:        fb,
 
 		cutoffs: map[abi.SectorNumber]time.Time{},
 		todo:    map[abi.SectorNumber]AggregateInput{},
 		waiting: map[abi.SectorNumber][]chan sealiface.CommitBatchRes{},
+		added:   map[abi.SectorNumber]time.Time{},
+		sent:    map[abi.SectorNumber]sentCommit{},
 
 		notify:  make(chan struct{}, 1),
+		reload:  make(chan struct{}, 1),
 		force:   make(chan chan []sealiface.CommitBatchRes),
 		stop:    make(chan struct{}),
 		stopped: make(chan struct{}),
@@ -101,6 +125,10 @@ func (b *CommitBatcher) run() {
 		panic(err)
 	}
 
+	// the age of the oldest queued commit keeps growing between batches
+	metricsTicker := time.NewTicker(BatcherMetricsInterval)
+	defer metricsTicker.Stop()
+
 	for {
 		if forceRes != nil {
 			forceRes <- lastMsg
@@ -119,6 +147,19 @@ func (b *CommitBatcher) run() {
 			sendAboveMin = true
 		case fr := <-b.force: // user triggered
 			forceRes = fr
+		case <-b.reload:
+			// batch waits may have changed
+			if ncfg, err := b.getConfig(); err != nil {
+				log.Warnw("CommitBatcher getconfig error", "error", err)
+			} else {
+				cfg = ncfg
+			}
+			continue
+		case <-metricsTicker.C:
+			b.lk.Lock()
+			b.recordQueueMetricsLocked()
+			b.lk.Unlock()
+			continue
 		}
 
 		var err error
@@ -139,6 +180,10 @@ func (b *CommitBatcher) batchWait(maxWait, slack time.Duration) <-chan time.Time
 		return nil
 	}
 
+	if b.maintenance || b.feeHeld {
+		return time.After(MaintenanceRecheckInterval)
+	}
+
 	var cutoff time.Time
 	for sn := range b.todo {
 		sectorCutoff := b.cutoffs[sn]
@@ -173,6 +218,7 @@ func (b *CommitBatcher) batchWait(maxWait, slack time.Duration) <-chan time.Time
 func (b *CommitBatcher) maybeStartBatch(notif, after bool) ([]sealiface.CommitBatchRes, error) {
 	b.lk.Lock()
 	defer b.lk.Unlock()
+	defer b.recordQueueMetricsLocked()
 
 	total := len(b.todo)
 	if total == 0 {
@@ -184,6 +230,12 @@ func (b *CommitBatcher) maybeStartBatch(notif, after bool) ([]sealiface.CommitBa
 		return nil, xerrors.Errorf("getting config: %w", err)
 	}
 
+	b.maintenance = cfg.MaintenanceMode
+	if cfg.MaintenanceMode && (notif || after) {
+		log.Infow("holding commit batch in maintenance mode", "sectors", total)
+		return nil, nil
+	}
+
 	if notif && total < cfg.MaxCommitBatch {
 		return nil, nil
 	}
@@ -192,72 +244,313 @@ func (b *CommitBatcher) maybeStartBatch(notif, after bool) ([]sealiface.CommitBa
 		return nil, nil
 	}
 
+	if notif || after {
+		hold, err := b.fb.hold(b.mctx, b.api, b.cutoffs, cfg.CommitBatchSlack)
+		if err != nil {
+			return nil, xerrors.Errorf("checking base fee circuit breaker: %w", err)
+		}
+		b.feeHeld = hold
+		if hold {
+			b.fb.Defer("commit", total)
+			log.Infow("holding commit batch, base fee over the circuit breaker threshold", "sectors", total)
+			return nil, nil
+		}
+	}
+	b.fb.Defer("commit", 0)
+
+	// drop the sectors which would fail the commit message before
+	// deciding whether they are still enough to aggregate
+	var failed []sealiface.CommitBatchRes
+	if fr, err := b.preflightLocked(); err != nil {
+		return nil, xerrors.Errorf("commit pre-flight checks: %w", err)
+	} else if len(fr.Sectors) > 0 {
+		b.doneLocked(fr)
+		failed = append(failed, fr)
+
+		total = len(b.todo)
+		if total == 0 {
+			return failed, nil
+		}
+	}
+
 	var res []sealiface.CommitBatchRes
 
 	if total < cfg.MinCommitBatch || total < miner5.MinAggregatedSectors {
-		res, err = b.processIndividually()
+		res, err = b.processIndividually(b.todoSectorsLocked())
 	} else {
 		res, err = b.processBatch(cfg)
 	}
 	if err != nil && len(res) == 0 {
-		return nil, err
+		return failed, err
 	}
 
-	for _, r := range res {
+	for i := range res {
 		if err != nil {
-			r.Error = err.Error()
+			res[i].SetError(err)
 		}
 
-		for _, sn := range r.Sectors {
-			for _, ch := range b.waiting[sn] {
-				ch <- r // buffered
-			}
+		b.doneLocked(res[i])
+	}
+
+	return append(failed, res...), nil
+}
+
+// doneLocked passes the result to the sectors waiting for it, and removes
+// them from the batcher
+func (b *CommitBatcher) doneLocked(r sealiface.CommitBatchRes) {
+	for _, sn := range r.Sectors {
+		for _, ch := range b.waiting[sn] {
+			ch <- r // buffered
+		}
+
+		delete(b.waiting, sn)
+		delete(b.todo, sn)
+		delete(b.cutoffs, sn)
+		delete(b.added, sn)
+	}
+}
+
+// preflightLocked checks each pending sector against the chain state, and
+// returns the sectors which would fail the commit message, and so the whole
+// aggregate, with the reasons in FailedSectors
+func (b *CommitBatcher) preflightLocked() (sealiface.CommitBatchRes, error) {
+	res := sealiface.CommitBatchRes{
+		FailedSectors: map[abi.SectorNumber]string{},
+	}
+
+	tok, curEpoch, err := b.api.ChainHead(b.mctx)
+	if err != nil {
+		return res, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	nv, err := b.api.StateNetworkVersion(b.mctx, tok)
+	if err != nil {
+		return res, xerrors.Errorf("getting network version: %w", err)
+	}
+
+	// commits sent longer than the prove commit duration ago can't land
+	// anymore
+	for sn, sc := range b.sent {
+		if sc.epoch+policy.GetMaxProveCommitDuration(actors.VersionForNetwork(nv), sc.spt) < curEpoch {
+			delete(b.sent, sn)
+		}
+	}
 
-			delete(b.waiting, sn)
-			delete(b.todo, sn)
-			delete(b.cutoffs, sn)
+	for sn, in := range b.todo {
+		if err := b.preflightSector(sn, in, tok, curEpoch, nv); err != nil {
+			log.Warnw("dropping sector from commit batch", "sector", sn, "error", err)
+			res.Sectors = append(res.Sectors, sn)
+			res.SetFailed(sn, err)
 		}
 	}
 
 	return res, nil
 }
 
-func (b *CommitBatcher) processBatch(cfg sealiface.Config) ([]sealiface.CommitBatchRes, error) {
-	tok, _, err := b.api.ChainHead(b.mctx)
+func (b *CommitBatcher) preflightSector(sn abi.SectorNumber, in AggregateInput, tok TipSetToken, curEpoch abi.ChainEpoch, nv network.Version) error {
+	pci, err := b.api.StateSectorPreCommitInfo(b.mctx, b.maddr, sn, tok)
 	if err != nil {
-		return nil, err
+		return xerrors.Errorf("getting precommit info: %w", err)
+	}
+	if pci == nil {
+		si, err := b.api.StateSectorGetInfo(b.mctx, b.maddr, sn, tok)
+		if err != nil {
+			return xerrors.Errorf("getting sector info: %w", err)
+		}
+		if si != nil {
+			return sealiface.WithCode(sealiface.ErrSectorCommitted, xerrors.Errorf("sector already committed on chain"))
+		}
+		return sealiface.WithCode(sealiface.ErrPreCommitNotFound, xerrors.Errorf("precommit info not found on chain"))
 	}
 
-	total := len(b.todo)
+	expiry := pci.PreCommitEpoch + policy.GetMaxProveCommitDuration(actors.VersionForNetwork(nv), in.spt)
+	if curEpoch > expiry {
+		return sealiface.WithCode(sealiface.ErrPreCommitExpired, xerrors.Errorf("precommit expired at epoch %d", expiry), "expiry", expiry)
+	}
+
+	if sc, ok := b.sent[sn]; ok {
+		lookup, err := b.api.StateSearchMsg(b.mctx, sc.msg)
+		if err != nil {
+			return xerrors.Errorf("searching previous commit message %s: %w", sc.msg, err)
+		}
+		if lookup == nil {
+			return sealiface.WithCode(sealiface.ErrCommitInFlight, xerrors.Errorf("previous commit message %s still in flight", sc.msg), "message", sc.msg)
+		}
+		delete(b.sent, sn)
+	}
+
+	if _, err := b.sectorCollateral(pci, tok); err != nil {
+		return err
+	}
+
+	for _, did := range pci.Info.DealIDs {
+		deal, err := b.api.StateMarketStorageDeal(b.mctx, did, tok)
+		if err != nil {
+			return xerrors.Errorf("getting deal %d: %w", did, err)
+		}
+		if deal.Proposal.Provider != b.maddr {
+			return sealiface.WithCode(sealiface.ErrDealInvalid, xerrors.Errorf("deal %d is for another provider %s", did, deal.Proposal.Provider), "deal", did)
+		}
+		if deal.Proposal.StartEpoch <= curEpoch {
+			return sealiface.WithCode(sealiface.ErrDealInvalid, xerrors.Errorf("deal %d should have started at epoch %d", did, deal.Proposal.StartEpoch), "deal", did)
+		}
+		if deal.State.SlashEpoch != -1 {
+			return sealiface.WithCode(sealiface.ErrDealInvalid, xerrors.Errorf("deal %d was slashed at epoch %d", did, deal.State.SlashEpoch), "deal", did)
+		}
+	}
+
+	return nil
+}
+
+// maxAggregateParamsSize keeps ProveCommitAggregate messages under the 64KiB
+// message pool limit, leaving room for the other message fields
+const maxAggregateParamsSize = 62 << 10
+
+func (b *CommitBatcher) todoSectorsLocked() []abi.SectorNumber {
+	out := make([]abi.SectorNumber, 0, len(b.todo))
+	for sn := range b.todo {
+		out = append(out, sn)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i] < out[j]
+	})
+	return out
+}
 
-	var res sealiface.CommitBatchRes
+// commitBatchChunks splits the pending sectors into aggregates of at most max
+// sectors. An aggregate only holds sectors of one seal proof type, and the
+// sectors of each type are split evenly between its aggregates, so that the
+// last one isn't left with too few sectors.
+func commitBatchChunks(todo map[abi.SectorNumber]AggregateInput, max int) [][]abi.SectorNumber {
+	if max < 1 {
+		max = 1
+	}
+
+	bySpt := map[abi.RegisteredSealProof][]abi.SectorNumber{}
+	for sn, in := range todo {
+		bySpt[in.spt] = append(bySpt[in.spt], sn)
+	}
+
+	spts := make([]abi.RegisteredSealProof, 0, len(bySpt))
+	for spt := range bySpt {
+		spts = append(spts, spt)
+	}
+	sort.Slice(spts, func(i, j int) bool {
+		return spts[i] < spts[j]
+	})
+
+	var out [][]abi.SectorNumber
+	for _, spt := range spts {
+		sectors := bySpt[spt]
+		sort.Slice(sectors, func(i, j int) bool {
+			return sectors[i] < sectors[j]
+		})
+
+		n := (len(sectors) + max - 1) / max
+		size := (len(sectors) + n - 1) / n
+		for len(sectors) > 0 {
+			if size > len(sectors) {
+				size = len(sectors)
+			}
+			out = append(out, sectors[:size])
+			sectors = sectors[size:]
+		}
+	}
+
+	return out
+}
+
+// processBatch sends all pending sectors, in as many ProveCommitAggregate
+// messages as needed to stay within the aggregation and message size limits
+func (b *CommitBatcher) processBatch(cfg sealiface.Config) ([]sealiface.CommitBatchRes, error) {
+	max := cfg.MaxCommitBatch
+	if max > miner5.MaxAggregatedSectors {
+		max = miner5.MaxAggregatedSectors
+	}
+
+	chunks := commitBatchChunks(b.todo, max)
+	if len(chunks) > 1 {
+		log.Infow("splitting commit batch", "sectors", len(b.todo), "messages", len(chunks))
+	}
+
+	var res []sealiface.CommitBatchRes
+	for _, sectors := range chunks {
+		if len(sectors) < miner5.MinAggregatedSectors {
+			r, err := b.processIndividually(sectors)
+			if err != nil {
+				return res, err
+			}
+			res = append(res, r...)
+			continue
+		}
+
+		res = append(res, b.processAggregate(sectors)...)
+	}
+
+	return res, nil
+}
+
+// processAggregate sends the sectors in a ProveCommitAggregate message,
+// splitting it in two when the aggregate is over the message size limit
+func (b *CommitBatcher) processAggregate(sectors []abi.SectorNumber) []sealiface.CommitBatchRes {
+	res := sealiface.CommitBatchRes{
+		FailedSectors: map[abi.SectorNumber]string{},
+	}
+
+	fail := func(err error) []sealiface.CommitBatchRes {
+		log.Errorw("sending commit aggregate", "sectors", len(sectors), "error", err)
+		res.SetError(err)
+		return []sealiface.CommitBatchRes{res}
+	}
+
+	tok, curEpoch, err := b.api.ChainHead(b.mctx)
+	if err != nil {
+		res.Sectors = sectors
+		return fail(sealiface.WithCode(sealiface.ErrChainAccess, xerrors.Errorf("getting chain head: %w", err)))
+	}
 
 	params := miner5.ProveCommitAggregateParams{
 		SectorNumbers: bitfield.New(),
 	}
 
-	proofs := make([][]byte, 0, total)
-	infos := make([]proof5.AggregateSealVerifyInfo, 0, total)
+	proofs := make([][]byte, 0, len(sectors))
+	infos := make([]proof5.AggregateSealVerifyInfo, 0, len(sectors))
 	collateral := big.Zero()
 
-	for id, p := range b.todo {
-		if len(infos) >= cfg.MaxCommitBatch {
-			log.Infow("commit batch full")
-			break
-		}
-
+	for _, id := range sectors {
 		res.Sectors = append(res.Sectors, id)
 
 		sc, err := b.getSectorCollateral(id, tok)
 		if err != nil {
-			res.FailedSectors[id] = err.Error()
+			res.SetFailed(id, err)
 			continue
 		}
 
 		collateral = big.Add(collateral, sc)
 
 		params.SectorNumbers.Set(uint64(id))
-		infos = append(infos, p.info)
+		infos = append(infos, b.todo[id].info)
+	}
+
+	if len(infos) < miner5.MinAggregatedSectors {
+		// too many sectors failed to aggregate the rest
+		var good []abi.SectorNumber
+		for _, info := range infos {
+			good = append(good, info.Number)
+		}
+		if len(good) > 0 {
+			res.Sectors = res.Sectors[:0]
+			for id := range res.FailedSectors {
+				res.Sectors = append(res.Sectors, id)
+			}
+
+			single, err := b.processIndividually(good)
+			if err != nil {
+				return fail(err)
+			}
+			return append([]sealiface.CommitBatchRes{res}, single...)
+		}
+		return []sealiface.CommitBatchRes{res}
 	}
 
 	sort.Slice(infos, func(i, j int) bool {
@@ -270,7 +563,7 @@ func (b *CommitBatcher) processBatch(cfg sealiface.Config) ([]sealiface.CommitBa
 
 	mid, err := address.IDFromAddress(b.maddr)
 	if err != nil {
-		return []sealiface.CommitBatchRes{res}, xerrors.Errorf("getting miner id: %w", err)
+		return fail(xerrors.Errorf("getting miner id: %w", err))
 	}
 
 	params.AggregateProof, err = b.prover.AggregateSealProofs(proof5.AggregateSealVerifyProofAndInfos{
@@ -280,30 +573,41 @@ func (b *CommitBatcher) processBatch(cfg sealiface.Config) ([]sealiface.CommitBa
 		Infos:          infos,
 	}, proofs)
 	if err != nil {
-		return []sealiface.CommitBatchRes{res}, xerrors.Errorf("aggregating proofs: %w", err)
+		return fail(sealiface.WithCode(sealiface.ErrAggregationFailed, xerrors.Errorf("aggregating proofs: %w", err)))
 	}
 
 	enc := new(bytes.Buffer)
 	if err := params.MarshalCBOR(enc); err != nil {
-		return []sealiface.CommitBatchRes{res}, xerrors.Errorf("couldn't serialize ProveCommitAggregateParams: %w", err)
+		return fail(xerrors.Errorf("couldn't serialize ProveCommitAggregateParams: %w", err))
+	}
+
+	if enc.Len() > maxAggregateParamsSize && len(sectors) >= 2*miner5.MinAggregatedSectors {
+		log.Infow("commit aggregate over the message size limit, splitting it", "sectors", len(sectors), "size", enc.Len())
+
+		half := len(sectors) / 2
+		return append(b.processAggregate(sectors[:half]), b.processAggregate(sectors[half:])...)
 	}
 
 	mi, err := b.api.StateMinerInfo(b.mctx, b.maddr, nil)
 	if err != nil {
-		return []sealiface.CommitBatchRes{res}, xerrors.Errorf("couldn't get miner info: %w", err)
+		return fail(xerrors.Errorf("couldn't get miner info: %w", err))
+	}
+
+	feeCfg, err := b.getFeeCfg()
+	if err != nil {
+		return fail(xerrors.Errorf("getting fee config: %w", err))
 	}
 
-	maxFee := b.feeCfg.MaxCommitBatchGasFee.FeeForSectors(len(infos))
+	maxFee := feeCfg.MaxCommitBatchGasFee.FeeForSectors(len(infos))
 
 	bf, err := b.api.ChainBaseFee(b.mctx, tok)
 	if err != nil {
-		return []sealiface.CommitBatchRes{res}, xerrors.Errorf("couldn't get base fee: %w", err)
+		return fail(xerrors.Errorf("couldn't get base fee: %w", err))
 	}
 
 	nv, err := b.api.StateNetworkVersion(b.mctx, tok)
 	if err != nil {
-		log.Errorf("getting network version: %s", err)
-		return []sealiface.CommitBatchRes{res}, xerrors.Errorf("getting network version: %s", err)
+		return fail(xerrors.Errorf("getting network version: %s", err))
 	}
 
 	aggFee := policy.AggregateNetworkFee(nv, len(infos), bf)
@@ -312,45 +616,53 @@ func (b *CommitBatcher) processBatch(cfg sealiface.Config) ([]sealiface.CommitBa
 
 	from, _, err := b.addrSel(b.mctx, mi, api.CommitAddr, goodFunds, collateral)
 	if err != nil {
-		return []sealiface.CommitBatchRes{res}, xerrors.Errorf("no good address found: %w", err)
+		return fail(sealiface.WithCode(sealiface.ErrInsufficientFunds, xerrors.Errorf("no good address found: %w", err), "collateral", types.FIL(collateral)))
 	}
 
 	mcid, err := b.api.SendMsg(b.mctx, from, b.maddr, miner.Methods.ProveCommitAggregate, collateral, maxFee, enc.Bytes())
 	if err != nil {
-		return []sealiface.CommitBatchRes{res}, xerrors.Errorf("sending message failed: %w", err)
+		recordMsgFailed(b.mctx, msgTypeCommitAggregate, "send")
+		return fail(sealiface.WithCode(sealiface.ErrMessageSend, xerrors.Errorf("sending message failed: %w", err)))
 	}
+	recordMsgSent(b.mctx, msgTypeCommitAggregate)
 
 	res.Msg = &mcid
+	for _, info := range infos {
+		b.sent[info.Number] = sentCommit{msg: mcid, epoch: curEpoch, spt: b.todo[info.Number].spt}
+	}
 
-	log.Infow("Sent ProveCommitAggregate message", "cid", mcid, "from", from, "todo", total, "sectors", len(infos))
+	log.Infow("Sent ProveCommitAggregate message", "cid", mcid, "from", from, "todo", len(b.todo), "sectors", len(infos))
 
-	return []sealiface.CommitBatchRes{res}, nil
+	return []sealiface.CommitBatchRes{res}
 }
 
-func (b *CommitBatcher) processIndividually() ([]sealiface.CommitBatchRes, error) {
+func (b *CommitBatcher) processIndividually(sectors []abi.SectorNumber) ([]sealiface.CommitBatchRes, error) {
 	mi, err := b.api.StateMinerInfo(b.mctx, b.maddr, nil)
 	if err != nil {
 		return nil, xerrors.Errorf("couldn't get miner info: %w", err)
 	}
 
-	tok, _, err := b.api.ChainHead(b.mctx)
+	tok, curEpoch, err := b.api.ChainHead(b.mctx)
 	if err != nil {
 		return nil, err
 	}
 
 	var res []sealiface.CommitBatchRes
 
-	for sn, info := range b.todo {
+	for _, sn := range sectors {
+		info := b.todo[sn]
 		r := sealiface.CommitBatchRes{
-			Sectors: []abi.SectorNumber{sn},
+			Sectors:       []abi.SectorNumber{sn},
+			FailedSectors: map[abi.SectorNumber]string{},
 		}
 
 		mcid, err := b.processSingle(mi, sn, info, tok)
 		if err != nil {
 			log.Errorf("process single error: %+v", err) // todo: return to user
-			r.FailedSectors[sn] = err.Error()
+			r.SetFailed(sn, err)
 		} else {
 			r.Msg = &mcid
+			b.sent[sn] = sentCommit{msg: mcid, epoch: curEpoch, spt: info.spt}
 		}
 
 		res = append(res, r)
@@ -375,17 +687,24 @@ func (b *CommitBatcher) processSingle(mi miner.MinerInfo, sn abi.SectorNumber, i
 		return cid.Undef, err
 	}
 
-	goodFunds := big.Add(collateral, big.Int(b.feeCfg.MaxCommitGasFee))
+	feeCfg, err := b.getFeeCfg()
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("getting fee config: %w", err)
+	}
+
+	goodFunds := big.Add(collateral, big.Int(feeCfg.MaxCommitGasFee))
 
 	from, _, err := b.addrSel(b.mctx, mi, api.CommitAddr, goodFunds, collateral)
 	if err != nil {
-		return cid.Undef, xerrors.Errorf("no good address to send commit message from: %w", err)
+		return cid.Undef, sealiface.WithCode(sealiface.ErrInsufficientFunds, xerrors.Errorf("no good address to send commit message from: %w", err), "collateral", types.FIL(collateral))
 	}
 
-	mcid, err := b.api.SendMsg(b.mctx, from, b.maddr, miner.Methods.ProveCommitSector, collateral, big.Int(b.feeCfg.MaxCommitGasFee), enc.Bytes())
+	mcid, err := b.api.SendMsg(b.mctx, from, b.maddr, miner.Methods.ProveCommitSector, collateral, big.Int(feeCfg.MaxCommitGasFee), enc.Bytes())
 	if err != nil {
-		return cid.Undef, xerrors.Errorf("pushing message to mpool: %w", err)
+		recordMsgFailed(b.mctx, msgTypeCommit, "send")
+		return cid.Undef, sealiface.WithCode(sealiface.ErrMessageSend, xerrors.Errorf("pushing message to mpool: %w", err))
 	}
+	recordMsgSent(b.mctx, msgTypeCommit)
 
 	return mcid, nil
 }
@@ -400,8 +719,26 @@ func (b *CommitBatcher) AddCommit(ctx context.Context, s SectorInfo, in Aggregat
 	}
 
 	b.lk.Lock()
+	if r, inFlight, err := b.inFlightLocked(sn); err != nil {
+		b.lk.Unlock()
+		return sealiface.CommitBatchRes{}, err
+	} else if inFlight {
+		b.lk.Unlock()
+		log.Infow("sector commit message already in flight, not adding it again", "sector", sn, "message", r.Msg)
+		return r, nil
+	}
+
 	b.cutoffs[sn] = cu
-	b.todo[sn] = in
+	if queued, found := b.todo[sn]; !found || !bytes.Equal(queued.proof, in.proof) {
+		// a retry with a recomputed proof replaces the queued one
+		b.todo[sn] = in
+	} else {
+		log.Debugw("sector already queued for commit, waiting for the same batch", "sector", sn)
+	}
+	if _, found := b.added[sn]; !found {
+		b.added[sn] = time.Now()
+	}
+	b.recordQueueMetricsLocked()
 
 	sent := make(chan sealiface.CommitBatchRes, 1)
 	b.waiting[sn] = append(b.waiting[sn], sent)
@@ -420,6 +757,40 @@ func (b *CommitBatcher) AddCommit(ctx context.Context, s SectorInfo, in Aggregat
 	}
 }
 
+// inFlightLocked returns the result for a sector whose commit message sent by
+// the batcher hasn't landed yet, so that FSM retries wait for that message
+// instead of committing the sector a second time. Messages which landed are
+// forgotten, a retry after a failed commit gets the sector committed again.
+func (b *CommitBatcher) inFlightLocked(sn abi.SectorNumber) (sealiface.CommitBatchRes, bool, error) {
+	sc, ok := b.sent[sn]
+	if !ok {
+		return sealiface.CommitBatchRes{}, false, nil
+	}
+
+	lookup, err := b.api.StateSearchMsg(b.mctx, sc.msg)
+	if err != nil {
+		return sealiface.CommitBatchRes{}, false, xerrors.Errorf("searching previous commit message %s: %w", sc.msg, err)
+	}
+	if lookup != nil {
+		delete(b.sent, sn)
+		return sealiface.CommitBatchRes{}, false, nil
+	}
+
+	return sealiface.CommitBatchRes{
+		Sectors:       []abi.SectorNumber{sn},
+		FailedSectors: map[abi.SectorNumber]string{},
+		Msg:           &sc.msg,
+	}, true, nil
+}
+
+// ReloadConfig makes the batcher pick up changes of the batch wait
+func (b *CommitBatcher) ReloadConfig() {
+	select {
+	case b.reload <- struct{}{}:
+	default:
+	}
+}
+
 func (b *CommitBatcher) Flush(ctx context.Context) ([]sealiface.CommitBatchRes, error) {
 	resCh := make(chan []sealiface.CommitBatchRes, 1)
 	select {
@@ -463,6 +834,49 @@ func (b *CommitBatcher) Pending(ctx context.Context) ([]abi.SectorID, error) {
 	return res, nil
 }
 
+func (b *CommitBatcher) recordQueueMetricsLocked() {
+	var oldest time.Time
+	for sn := range b.todo {
+		if t, found := b.added[sn]; found && (oldest.IsZero() || t.Before(oldest)) {
+			oldest = t
+		}
+	}
+
+	var age float64
+	if !oldest.IsZero() {
+		age = time.Since(oldest).Seconds()
+	}
+
+	stats.Record(b.mctx, metrics.CommitBatcherPending.M(int64(len(b.todo))), metrics.CommitBatcherOldestAge.M(age))
+}
+
+// Status returns the number of queued sectors, and when the batch gets sent
+// at the latest
+func (b *CommitBatcher) Status(ctx context.Context) (sealiface.BatchStatus, error) {
+	cfg, err := b.getConfig()
+	if err != nil {
+		return sealiface.BatchStatus{}, err
+	}
+
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	out := sealiface.BatchStatus{
+		Pending: len(b.todo),
+	}
+	for sn := range b.todo {
+		sectorCutoff := b.cutoffs[sn]
+		if out.Cutoff.IsZero() || (!sectorCutoff.IsZero() && sectorCutoff.Before(out.Cutoff)) {
+			out.Cutoff = sectorCutoff
+		}
+	}
+	if !out.Cutoff.IsZero() {
+		out.SendBy = out.Cutoff.Add(-cfg.CommitBatchSlack)
+	}
+
+	return out, nil
+}
+
 func (b *CommitBatcher) Stop(ctx context.Context) error {
 	close(b.stop)
 
@@ -522,6 +936,10 @@ func (b *CommitBatcher) getSectorCollateral(sn abi.SectorNumber, tok TipSetToken
 		return big.Zero(), xerrors.Errorf("precommit info not found on chain")
 	}
 
+	return b.sectorCollateral(pci, tok)
+}
+
+func (b *CommitBatcher) sectorCollateral(pci *miner.SectorPreCommitOnChainInfo, tok TipSetToken) (abi.TokenAmount, error) {
 	collateral, err := b.api.StateMinerInitialPledgeCollateral(b.mctx, b.maddr, pci.Info, tok)
 	if err != nil {
 		return big.Zero(), xerrors.Errorf("getting initial pledge collateral: %w", err)
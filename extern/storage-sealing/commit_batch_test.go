@@ -0,0 +1,375 @@
+package sealing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/network"
+	miner5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/miner"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+func TestCommitBatchChunks(t *testing.T) {
+	todo := map[abi.SectorNumber]AggregateInput{}
+	for sn := abi.SectorNumber(0); sn < 850; sn++ {
+		todo[sn] = AggregateInput{spt: abi.RegisteredSealProof_StackedDrg32GiBV1_1}
+	}
+
+	// split evenly instead of leaving a 31 sector remainder
+	chunks := commitBatchChunks(todo, 819)
+	require.Len(t, chunks, 2)
+	require.Len(t, chunks[0], 425)
+	require.Len(t, chunks[1], 425)
+	require.Equal(t, abi.SectorNumber(0), chunks[0][0])
+	require.Equal(t, abi.SectorNumber(425), chunks[1][0])
+
+	// aggregates hold a single proof type
+	todo[1000] = AggregateInput{spt: abi.RegisteredSealProof_StackedDrg64GiBV1_1}
+	chunks = commitBatchChunks(todo, 819)
+	require.Len(t, chunks, 3)
+	require.Equal(t, []abi.SectorNumber{1000}, chunks[2])
+
+	chunks = commitBatchChunks(map[abi.SectorNumber]AggregateInput{1: {}, 2: {}, 3: {}}, 2)
+	require.Equal(t, [][]abi.SectorNumber{{1, 2}, {3}}, chunks)
+}
+
+type searchMsgAPI struct {
+	CommitBatcherApi
+	landed map[cid.Cid]bool
+}
+
+func (a *searchMsgAPI) StateSearchMsg(_ context.Context, c cid.Cid) (*MsgLookup, error) {
+	if a.landed[c] {
+		return &MsgLookup{}, nil
+	}
+	return nil, nil
+}
+
+func TestCommitInFlight(t *testing.T) {
+	msg, err := cid.Parse("bafy2bzacea3wsdh6y3a36tb3skempjoxqpuyompjbmfeyf34fi3uy6uue42v4")
+	require.NoError(t, err)
+
+	api := &searchMsgAPI{landed: map[cid.Cid]bool{}}
+	b := &CommitBatcher{
+		api:  api,
+		mctx: context.Background(),
+		sent: map[abi.SectorNumber]sentCommit{1: {msg: msg}},
+	}
+
+	_, inFlight, err := b.inFlightLocked(2)
+	require.NoError(t, err)
+	require.False(t, inFlight)
+
+	// retries wait for the pending message
+	res, inFlight, err := b.inFlightLocked(1)
+	require.NoError(t, err)
+	require.True(t, inFlight)
+	require.Equal(t, &msg, res.Msg)
+	require.Equal(t, []abi.SectorNumber{1}, res.Sectors)
+
+	// once it landed the sector can be committed again
+	api.landed[msg] = true
+	_, inFlight, err = b.inFlightLocked(1)
+	require.NoError(t, err)
+	require.False(t, inFlight)
+	require.Empty(t, b.sent)
+}
+
+// preflightAPI is the chain as seen by the commit batcher
+type preflightAPI struct {
+	CommitBatcherApi
+	head abi.ChainEpoch
+
+	precommits map[abi.SectorNumber]*miner.SectorPreCommitOnChainInfo
+	committed  map[abi.SectorNumber]bool
+	deals      map[abi.DealID]*api.MarketDeal
+	landed     map[cid.Cid]bool
+
+	sent []*types.Message
+}
+
+func (a *preflightAPI) ChainHead(context.Context) (TipSetToken, abi.ChainEpoch, error) {
+	return TipSetToken("head"), a.head, nil
+}
+
+func (a *preflightAPI) ChainBaseFee(context.Context, TipSetToken) (abi.TokenAmount, error) {
+	return big.NewInt(100), nil
+}
+
+func (a *preflightAPI) StateNetworkVersion(context.Context, TipSetToken) (network.Version, error) {
+	return network.Version13, nil
+}
+
+func (a *preflightAPI) StateMinerInfo(context.Context, address.Address, TipSetToken) (miner.MinerInfo, error) {
+	return miner.MinerInfo{Worker: address.TestAddress}, nil
+}
+
+func (a *preflightAPI) StateSectorPreCommitInfo(_ context.Context, _ address.Address, sn abi.SectorNumber, _ TipSetToken) (*miner.SectorPreCommitOnChainInfo, error) {
+	return a.precommits[sn], nil
+}
+
+func (a *preflightAPI) StateSectorGetInfo(_ context.Context, _ address.Address, sn abi.SectorNumber, _ TipSetToken) (*miner.SectorOnChainInfo, error) {
+	if a.committed[sn] {
+		return &miner.SectorOnChainInfo{SectorNumber: sn}, nil
+	}
+	return nil, nil
+}
+
+func (a *preflightAPI) StateMinerInitialPledgeCollateral(context.Context, address.Address, miner.SectorPreCommitInfo, TipSetToken) (big.Int, error) {
+	return types.FromFil(1), nil
+}
+
+func (a *preflightAPI) StateMarketStorageDeal(_ context.Context, did abi.DealID, _ TipSetToken) (*api.MarketDeal, error) {
+	deal, ok := a.deals[did]
+	if !ok {
+		return nil, fmt.Errorf("deal %d not found", did)
+	}
+	return deal, nil
+}
+
+func (a *preflightAPI) StateSearchMsg(_ context.Context, c cid.Cid) (*MsgLookup, error) {
+	if a.landed[c] {
+		return &MsgLookup{}, nil
+	}
+	return nil, nil
+}
+
+func (a *preflightAPI) SendMsg(_ context.Context, from, to address.Address, method abi.MethodNum, value, _ abi.TokenAmount, params []byte) (cid.Cid, error) {
+	a.sent = append(a.sent, &types.Message{From: from, To: to, Method: method, Value: value, Params: params})
+	return testMsgCid(fmt.Sprintf("msg %d", len(a.sent)))
+}
+
+func testMsgCid(s string) (cid.Cid, error) {
+	return cid.V1Builder{Codec: cid.DagCBOR, MhType: multihash.BLAKE2B_MIN + 31}.Sum([]byte(s))
+}
+
+type aggregateProver struct{}
+
+func (aggregateProver) AggregateSealProofs(proof5.AggregateSealVerifyProofAndInfos, [][]byte) ([]byte, error) {
+	return []byte("aggregate"), nil
+}
+
+// preflightBatcher returns a batcher with the sectors queued, each sector
+// with a precommit landed one day before the head of the chain
+func preflightBatcher(t *testing.T, sectors ...abi.SectorNumber) (*CommitBatcher, *preflightAPI, map[abi.SectorNumber]chan sealiface.CommitBatchRes) {
+	maddr, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+
+	a := &preflightAPI{
+		head:       100000,
+		precommits: map[abi.SectorNumber]*miner.SectorPreCommitOnChainInfo{},
+		committed:  map[abi.SectorNumber]bool{},
+		deals:      map[abi.DealID]*api.MarketDeal{},
+		landed:     map[cid.Cid]bool{},
+	}
+
+	b := &CommitBatcher{
+		api:   a,
+		maddr: maddr,
+		mctx:  context.Background(),
+		addrSel: func(_ context.Context, mi miner.MinerInfo, _ api.AddrUse, _, _ abi.TokenAmount) (address.Address, abi.TokenAmount, error) {
+			return mi.Worker, big.Zero(), nil
+		},
+		getFeeCfg: func() (config.MinerFeeConfig, error) {
+			return config.DefaultStorageMiner().Fees, nil
+		},
+		getConfig: func() (sealiface.Config, error) {
+			return sealiface.Config{MinCommitBatch: 1, MaxCommitBatch: miner5.MaxAggregatedSectors}, nil
+		},
+		prover: aggregateProver{},
+
+		todo:    map[abi.SectorNumber]AggregateInput{},
+		waiting: map[abi.SectorNumber][]chan sealiface.CommitBatchRes{},
+		sent:    map[abi.SectorNumber]sentCommit{},
+	}
+
+	waiting := map[abi.SectorNumber]chan sealiface.CommitBatchRes{}
+	for _, sn := range sectors {
+		a.precommits[sn] = &miner.SectorPreCommitOnChainInfo{
+			Info:             miner.SectorPreCommitInfo{SectorNumber: sn},
+			PreCommitDeposit: big.Zero(),
+			PreCommitEpoch:   a.head - 2880,
+		}
+
+		b.todo[sn] = AggregateInput{
+			spt:   abi.RegisteredSealProof_StackedDrg32GiBV1_1,
+			info:  proof5.AggregateSealVerifyInfo{Number: sn},
+			proof: []byte{byte(sn)},
+		}
+
+		waiting[sn] = make(chan sealiface.CommitBatchRes, 1)
+		b.waiting[sn] = []chan sealiface.CommitBatchRes{waiting[sn]}
+	}
+
+	return b, a, waiting
+}
+
+func failedCodes(r sealiface.CommitBatchRes) map[abi.SectorNumber]sealiface.ErrorCode {
+	out := map[abi.SectorNumber]sealiface.ErrorCode{}
+	for sn, ei := range r.FailedErrors {
+		out[sn] = ei.Code
+	}
+	return out
+}
+
+func TestCommitPreflight(t *testing.T) {
+	b, a, _ := preflightBatcher(t, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+
+	// 1 has a valid deal
+	a.deals[1] = &api.MarketDeal{}
+	a.deals[1].Proposal.Provider = b.maddr
+	a.deals[1].Proposal.StartEpoch = a.head + 1000
+	a.deals[1].State.SlashEpoch = -1
+	a.precommits[1].Info.DealIDs = []abi.DealID{1}
+
+	// 2 wasn't precommitted, 3 is already committed
+	delete(a.precommits, 2)
+	delete(a.precommits, 3)
+	a.committed[3] = true
+
+	// the precommit of 4 expired
+	a.precommits[4].PreCommitEpoch = 1000
+
+	// a commit message of 5 is in flight, the one of 6 landed
+	inFlight, err := testMsgCid("in flight")
+	require.NoError(t, err)
+	landed, err := testMsgCid("landed")
+	require.NoError(t, err)
+	a.landed[landed] = true
+	b.sent[5] = sentCommit{msg: inFlight, epoch: a.head - 10, spt: abi.RegisteredSealProof_StackedDrg32GiBV1_1}
+	b.sent[6] = sentCommit{msg: landed, epoch: a.head - 10, spt: abi.RegisteredSealProof_StackedDrg32GiBV1_1}
+
+	// the deal of 7 is for another provider, the one of 8 started and the one
+	// of 9 was slashed
+	for sn, deal := range map[abi.SectorNumber]api.MarketDeal{7: *a.deals[1], 8: *a.deals[1], 9: *a.deals[1]} {
+		deal := deal
+		did := abi.DealID(sn)
+		switch sn {
+		case 7:
+			deal.Proposal.Provider = address.TestAddress
+		case 8:
+			deal.Proposal.StartEpoch = a.head
+		case 9:
+			deal.State.SlashEpoch = a.head - 1
+		}
+		a.deals[did] = &deal
+		a.precommits[sn].Info.DealIDs = []abi.DealID{1, did}
+	}
+
+	fr, err := b.preflightLocked()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []abi.SectorNumber{2, 3, 4, 5, 7, 8, 9}, fr.Sectors)
+	require.Equal(t, map[abi.SectorNumber]sealiface.ErrorCode{
+		2: sealiface.ErrPreCommitNotFound,
+		3: sealiface.ErrSectorCommitted,
+		4: sealiface.ErrPreCommitExpired,
+		5: sealiface.ErrCommitInFlight,
+		7: sealiface.ErrDealInvalid,
+		8: sealiface.ErrDealInvalid,
+		9: sealiface.ErrDealInvalid,
+	}, failedCodes(fr))
+	require.Len(t, fr.FailedSectors, 7)
+
+	// pre-flight doesn't change the queue, landed commits are forgotten
+	require.Len(t, b.todo, 9)
+	require.Contains(t, b.sent, abi.SectorNumber(5))
+	require.NotContains(t, b.sent, abi.SectorNumber(6))
+}
+
+func TestCommitBatchPartial(t *testing.T) {
+	t.Run("aggregate", func(t *testing.T) {
+		b, a, waiting := preflightBatcher(t, 1, 2, 3, 4, 5, 6)
+		delete(a.precommits, 5)
+		a.precommits[6].PreCommitEpoch = 1000
+
+		res, err := b.maybeStartBatch(false, false)
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+
+		// the failing sectors are reported first, without a message
+		require.ElementsMatch(t, []abi.SectorNumber{5, 6}, res[0].Sectors)
+		require.Nil(t, res[0].Msg)
+		require.Equal(t, map[abi.SectorNumber]sealiface.ErrorCode{
+			5: sealiface.ErrPreCommitNotFound,
+			6: sealiface.ErrPreCommitExpired,
+		}, failedCodes(res[0]))
+
+		// the other ones are still enough to aggregate
+		require.Equal(t, []abi.SectorNumber{1, 2, 3, 4}, res[1].Sectors)
+		require.NotNil(t, res[1].Msg)
+		require.Empty(t, res[1].FailedSectors)
+
+		require.Len(t, a.sent, 1)
+		require.Equal(t, miner.Methods.ProveCommitAggregate, a.sent[0].Method)
+		var params miner5.ProveCommitAggregateParams
+		require.NoError(t, params.UnmarshalCBOR(bytes.NewReader(a.sent[0].Params)))
+		committed, err := params.SectorNumbers.All(10)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1, 2, 3, 4}, committed)
+		// the collateral of the dropped sectors isn't sent
+		require.Equal(t, types.FromFil(4), a.sent[0].Value)
+
+		// each sector gets the result it's part of
+		for sn, ch := range waiting {
+			r := <-ch
+			require.Contains(t, r.Sectors, sn)
+		}
+		require.Empty(t, b.todo)
+		require.Len(t, b.sent, 4)
+	})
+
+	t.Run("individual", func(t *testing.T) {
+		b, a, waiting := preflightBatcher(t, 1, 2, 3, 4)
+		a.committed[4] = true
+		delete(a.precommits, 4)
+
+		res, err := b.maybeStartBatch(false, false)
+		require.NoError(t, err)
+
+		// too few sectors left to aggregate, they are committed one by one
+		require.Len(t, res, 4)
+		require.Equal(t, []abi.SectorNumber{4}, res[0].Sectors)
+		require.Equal(t, sealiface.ErrSectorCommitted, res[0].FailedErrors[4].Code)
+
+		require.Len(t, a.sent, 3)
+		for i, r := range res[1:] {
+			require.Equal(t, []abi.SectorNumber{abi.SectorNumber(i + 1)}, r.Sectors)
+			require.NotNil(t, r.Msg)
+			require.Equal(t, miner.Methods.ProveCommitSector, a.sent[i].Method)
+		}
+
+		require.Nil(t, (<-waiting[4]).Msg)
+		require.NotNil(t, (<-waiting[1]).Msg)
+		require.Empty(t, b.todo)
+	})
+
+	t.Run("all-failed", func(t *testing.T) {
+		b, a, waiting := preflightBatcher(t, 1, 2)
+		delete(a.precommits, 1)
+		delete(a.precommits, 2)
+
+		res, err := b.maybeStartBatch(false, false)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.ElementsMatch(t, []abi.SectorNumber{1, 2}, res[0].Sectors)
+		require.Empty(t, a.sent)
+
+		require.Len(t, (<-waiting[1]).FailedSectors, 2)
+		require.Empty(t, b.todo)
+	})
+}
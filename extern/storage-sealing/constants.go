@@ -1,4 +1,14 @@
 package sealing
 
+import "time"
+
 // Epochs
 const InteractivePoRepConfidence = 6
+
+// MaintenanceRecheckInterval is how often batchers holding messages in
+// maintenance mode check whether it was disabled
+const MaintenanceRecheckInterval = time.Minute
+
+// BatcherMetricsInterval is how often the commit batcher refreshes its queue
+// metrics while nothing is added to or sent from the queue
+var BatcherMetricsInterval = 30 * time.Second
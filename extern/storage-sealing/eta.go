@@ -0,0 +1,229 @@
+package sealing
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	sectorstorage "github.com/filecoin-project/lotus/extern/sector-storage"
+)
+
+type etaStep struct {
+	// alternative states of the step, e.g. for batched and single messages
+	states []SectorState
+
+	// sectors may skip optional steps, which don't make ETAs unknown when
+	// there is no history for them
+	optional bool
+}
+
+// etaPath is the sealing happy path, which sectors are expected to follow
+// until they are proving
+var etaPath = []etaStep{
+	{states: []SectorState{WaitDeals}, optional: true},
+	{states: []SectorState{AddPiece}, optional: true},
+	{states: []SectorState{Packing}},
+	{states: []SectorState{GetTicket}},
+	{states: []SectorState{PreCommit1}},
+	{states: []SectorState{PreCommit2}},
+	{states: []SectorState{PreCommitting, SubmitPreCommitBatch}},
+	{states: []SectorState{PreCommitWait, PreCommitBatchWait}},
+	{states: []SectorState{WaitSeed}},
+	{states: []SectorState{Committing, RegenerateProof}},
+	{states: []SectorState{CommitFinalize}, optional: true},
+	{states: []SectorState{SubmitCommit, SubmitCommitAggregate}},
+	{states: []SectorState{CommitWait, CommitAggregateWait}},
+	{states: []SectorState{FinalizeSector}},
+}
+
+type SectorETA struct {
+	Number abi.SectorNumber
+	State  SectorState
+
+	// Remaining is the projected time until the sector is proving. When Known
+	// is false, some of the remaining states have no duration history yet, and
+	// Remaining is a lower bound
+	Remaining time.Duration
+	Known     bool
+
+	// set when the sector's task is waiting in the scheduler queue
+	Queue *sectorstorage.QueuePosition
+}
+
+type SealingForecast struct {
+	InFlight int // sectors in the sealing pipeline
+
+	// in-flight sectors projected to be proving within a day
+	ProjectedNextDay int
+
+	// rate at which sectors started proving over the last day, or since the
+	// miner started if that was more recent
+	ObservedPerDay float64
+
+	StateDurations map[SectorState]time.Duration
+}
+
+// SectorsETA projects when the sectors in the sealing pipeline will be
+// proving, from the time sectors spent in each state since the miner was
+// started, and the position of their tasks in the scheduler queue
+func (m *Sealing) SectorsETA(queue map[abi.SectorID]sectorstorage.QueuePosition) ([]SectorETA, error) {
+	sectors, err := m.ListSectors()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	m.stats.lk.Lock()
+	defer m.stats.lk.Unlock()
+
+	var out []SectorETA
+	for _, sector := range sectors {
+		if toStatState(sector.State) != sstStaging && toStatState(sector.State) != sstSealing {
+			continue
+		}
+		if sector.State == UndefinedSectorState {
+			continue
+		}
+
+		sid := m.minerSectorID(sector.SectorNumber)
+
+		// sectors which were in the state before a restart don't have an
+		// accurate entry time, use the last event instead
+		since := now
+		if entry, ok := m.stats.states[sid]; ok && !entry.partial {
+			since = entry.since
+		} else if len(sector.Log) > 0 {
+			since = time.Unix(int64(sector.Log[len(sector.Log)-1].Timestamp), 0)
+		}
+
+		var qp *sectorstorage.QueuePosition
+		if p, ok := queue[sid]; ok {
+			qp = &p
+		}
+
+		remaining, known := estimateRemaining(sector.State, now.Sub(since), m.stats.durations, qp)
+		out = append(out, SectorETA{
+			Number:    sector.SectorNumber,
+			State:     sector.State,
+			Remaining: remaining,
+			Known:     known,
+			Queue:     qp,
+		})
+	}
+
+	return out, nil
+}
+
+// Forecast projects sealing throughput from sector ETAs
+func (m *Sealing) Forecast(etas []SectorETA) SealingForecast {
+	now := time.Now()
+
+	out := SealingForecast{
+		InFlight:       len(etas),
+		StateDurations: map[SectorState]time.Duration{},
+	}
+
+	for _, eta := range etas {
+		if eta.Known && eta.Remaining <= 24*time.Hour {
+			out.ProjectedNextDay++
+		}
+	}
+
+	m.stats.lk.Lock()
+	defer m.stats.lk.Unlock()
+
+	for st, sd := range m.stats.durations {
+		out.StateDurations[st] = sd.avg
+	}
+
+	window := now.Sub(m.stats.started)
+	if window > 24*time.Hour {
+		window = 24 * time.Hour
+	}
+
+	var completed int
+	for _, t := range m.stats.completions {
+		if now.Sub(t) <= window {
+			completed++
+		}
+	}
+	if window > 0 {
+		out.ObservedPerDay = float64(completed) * float64(24*time.Hour) / float64(window)
+	}
+
+	return out
+}
+
+func estimateRemaining(st SectorState, inState time.Duration, durations map[SectorState]stateDuration, queue *sectorstorage.QueuePosition) (time.Duration, bool) {
+	cur := -1
+	for i, step := range etaPath {
+		for _, s := range step.states {
+			if s == st {
+				cur = i
+			}
+		}
+	}
+	if cur < 0 {
+		return 0, false // not on the happy path
+	}
+
+	known := true
+
+	// the current state, minus the time already spent in it
+	var remaining time.Duration
+	d, ok := durations[st]
+	if ok && d.samples > 0 {
+		remaining = d.avg - inState
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		if queue != nil {
+			// the task hasn't started yet, and waits for the tasks queued
+			// ahead of it to be processed by running workers
+			running := queue.Running
+			if running < 1 {
+				running = 1
+			}
+			queued := d.avg + time.Duration(float64(d.avg)*float64(queue.Ahead)/float64(running))
+			if queued > remaining {
+				remaining = queued
+			}
+		}
+	} else if !etaPath[cur].optional {
+		known = false
+	}
+
+	for _, step := range etaPath[cur+1:] {
+		d, ok := stepDuration(step, durations)
+		if !ok {
+			if !step.optional {
+				known = false
+			}
+			continue
+		}
+		remaining += d
+	}
+
+	return remaining, known
+}
+
+// stepDuration averages durations of the step's states, weighted by the
+// number of samples, so that the states sectors usually go through dominate
+func stepDuration(step etaStep, durations map[SectorState]stateDuration) (time.Duration, bool) {
+	var total float64
+	var samples int
+	for _, st := range step.states {
+		d, ok := durations[st]
+		if !ok || d.samples == 0 {
+			continue
+		}
+		total += float64(d.avg) * float64(d.samples)
+		samples += d.samples
+	}
+	if samples == 0 {
+		return 0, false
+	}
+	return time.Duration(total / float64(samples)), true
+}
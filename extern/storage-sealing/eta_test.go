@@ -0,0 +1,54 @@
+package sealing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	sectorstorage "github.com/filecoin-project/lotus/extern/sector-storage"
+)
+
+func TestEstimateRemaining(t *testing.T) {
+	durations := map[SectorState]stateDuration{}
+	for _, step := range etaPath {
+		if step.optional {
+			continue
+		}
+		durations[step.states[0]] = stateDuration{avg: time.Hour, samples: 1}
+	}
+
+	// PreCommit1 and the 9 states after it
+	rem, known := estimateRemaining(PreCommit1, 20*time.Minute, durations, nil)
+	require.True(t, known)
+	require.Equal(t, 40*time.Minute+9*time.Hour, rem)
+
+	// overdue sectors aren't projected to finish the current state in the past
+	rem, known = estimateRemaining(PreCommit1, 3*time.Hour, durations, nil)
+	require.True(t, known)
+	require.Equal(t, 9*time.Hour, rem)
+
+	// queued behind 4 tasks with 2 running
+	rem, known = estimateRemaining(PreCommit1, 0, durations, &sectorstorage.QueuePosition{Ahead: 4, Running: 2})
+	require.True(t, known)
+	require.Equal(t, 3*time.Hour+9*time.Hour, rem)
+
+	rem, known = estimateRemaining(FinalizeSector, 0, durations, nil)
+	require.True(t, known)
+	require.Equal(t, time.Hour, rem)
+
+	// alternative states are averaged by samples
+	durations[SubmitCommitAggregate] = stateDuration{avg: 5 * time.Hour, samples: 3}
+	rem, known = estimateRemaining(Committing, 0, durations, nil)
+	require.True(t, known)
+	require.Equal(t, time.Hour+4*time.Hour+2*time.Hour, rem)
+
+	// no history for a required state
+	delete(durations, WaitSeed)
+	rem, known = estimateRemaining(PreCommit2, 0, durations, nil)
+	require.False(t, known)
+	require.Equal(t, 6*time.Hour+4*time.Hour, rem)
+
+	_, known = estimateRemaining(SealPreCommit1Failed, 0, durations, nil)
+	require.False(t, known)
+}
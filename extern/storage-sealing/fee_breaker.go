@@ -0,0 +1,183 @@
+package sealing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// FeeBreaker is the base fee circuit breaker. It trips when the base fee goes
+// over the configured threshold, and resets once it's back under the reset
+// threshold. While it's tripped, messages which aren't time-critical, like
+// precommit batches and scheduled withdrawals, are deferred. Window PoSts and
+// batches with sectors close to their deadline are always sent.
+type FeeBreaker struct {
+	getFeeCfg GetFeeConfigFunc
+
+	lk       sync.Mutex
+	tripped  bool
+	since    time.Time
+	baseFee  abi.TokenAmount
+	deferred map[string]int
+}
+
+type FeeBreakerStatus struct {
+	Enabled bool
+	Tripped bool
+	// when the breaker last tripped or reset
+	Since time.Time
+
+	BaseFee      abi.TokenAmount // last seen
+	TripBaseFee  abi.TokenAmount
+	ResetBaseFee abi.TokenAmount
+
+	// number of messages, or sectors for batches, deferred by kind
+	Deferred map[string]int
+}
+
+func NewFeeBreaker(getFeeCfg GetFeeConfigFunc) *FeeBreaker {
+	return &FeeBreaker{
+		getFeeCfg: getFeeCfg,
+		baseFee:   big.Zero(),
+		deferred:  map[string]int{},
+	}
+}
+
+func (fb *FeeBreaker) thresholds() (trip, reset abi.TokenAmount, err error) {
+	cfg, err := fb.getFeeCfg()
+	if err != nil {
+		return big.Zero(), big.Zero(), err
+	}
+
+	trip, reset = big.Zero(), big.Zero()
+	if cfg.BreakerTripBaseFee.Int != nil {
+		trip = abi.TokenAmount(cfg.BreakerTripBaseFee)
+	}
+	if cfg.BreakerResetBaseFee.Int != nil {
+		reset = abi.TokenAmount(cfg.BreakerResetBaseFee)
+	}
+	if reset.IsZero() || reset.GreaterThan(trip) {
+		reset = trip
+	}
+	return trip, reset, nil
+}
+
+// Tripped updates the breaker with the current base fee, and returns whether
+// non time-critical messages should be deferred.
+func (fb *FeeBreaker) Tripped(baseFee abi.TokenAmount) bool {
+	if fb == nil {
+		return false
+	}
+
+	trip, reset, err := fb.thresholds()
+	if err != nil {
+		log.Errorw("getting fee breaker config", "error", err)
+		return false
+	}
+
+	fb.lk.Lock()
+	defer fb.lk.Unlock()
+
+	fb.baseFee = baseFee
+
+	switch {
+	case !fb.tripped && !trip.IsZero() && baseFee.GreaterThan(trip):
+		log.Warnw("base fee over the circuit breaker threshold, deferring non time-critical messages", "basefee", types.FIL(baseFee), "threshold", types.FIL(trip))
+		fb.tripped = true
+		fb.since = time.Now()
+	case fb.tripped && (trip.IsZero() || !baseFee.GreaterThan(reset)):
+		log.Infow("base fee circuit breaker reset, sending deferred messages", "basefee", types.FIL(baseFee))
+		fb.tripped = false
+		fb.since = time.Now()
+		fb.deferred = map[string]int{}
+	}
+
+	return fb.tripped
+}
+
+// Defer records the number of messages of the kind currently deferred by the
+// breaker.
+func (fb *FeeBreaker) Defer(kind string, n int) {
+	if fb == nil {
+		return
+	}
+
+	fb.lk.Lock()
+	defer fb.lk.Unlock()
+
+	if n == 0 {
+		delete(fb.deferred, kind)
+		return
+	}
+	fb.deferred[kind] = n
+}
+
+func (fb *FeeBreaker) Status() (FeeBreakerStatus, error) {
+	trip, reset, err := fb.thresholds()
+	if err != nil {
+		return FeeBreakerStatus{}, err
+	}
+
+	fb.lk.Lock()
+	defer fb.lk.Unlock()
+
+	deferred := make(map[string]int, len(fb.deferred))
+	for k, n := range fb.deferred {
+		deferred[k] = n
+	}
+
+	return FeeBreakerStatus{
+		Enabled:      !trip.IsZero(),
+		Tripped:      fb.tripped,
+		Since:        fb.since,
+		BaseFee:      fb.baseFee,
+		TripBaseFee:  trip,
+		ResetBaseFee: reset,
+		Deferred:     deferred,
+	}, nil
+}
+
+type baseFeeAPI interface {
+	ChainHead(ctx context.Context) (TipSetToken, abi.ChainEpoch, error)
+	ChainBaseFee(context.Context, TipSetToken) (abi.TokenAmount, error)
+}
+
+// hold returns whether the breaker holds a batch with the given sector
+// cutoffs. Batches with a sector within the slack of its cutoff are sent as
+// a whole regardless of the base fee.
+func (fb *FeeBreaker) hold(ctx context.Context, api baseFeeAPI, cutoffs map[abi.SectorNumber]time.Time, slack time.Duration) (bool, error) {
+	if fb == nil || imminent(cutoffs, slack) {
+		return false, nil
+	}
+
+	tok, _, err := api.ChainHead(ctx)
+	if err != nil {
+		return false, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	bf, err := api.ChainBaseFee(ctx, tok)
+	if err != nil {
+		return false, xerrors.Errorf("getting base fee: %w", err)
+	}
+
+	return fb.Tripped(bf), nil
+}
+
+// imminent returns whether the earliest cutoff is within the slack, meaning
+// the batch has to be sent regardless of the base fee
+func imminent(cutoffs map[abi.SectorNumber]time.Time, slack time.Duration) bool {
+	now := time.Now()
+	for _, cutoff := range cutoffs {
+		if !cutoff.IsZero() && cutoff.Add(-slack).Before(now) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package sealing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+func TestFeeBreaker(t *testing.T) {
+	cfg := config.MinerFeeConfig{
+		BreakerTripBaseFee:  types.FIL(big.NewInt(100)),
+		BreakerResetBaseFee: types.FIL(big.NewInt(50)),
+	}
+	fb := NewFeeBreaker(func() (config.MinerFeeConfig, error) {
+		return cfg, nil
+	})
+
+	require.False(t, fb.Tripped(big.NewInt(100)))
+	require.True(t, fb.Tripped(big.NewInt(101)))
+
+	// stays tripped until the base fee is under the reset threshold
+	require.True(t, fb.Tripped(big.NewInt(80)))
+	fb.Defer("commit", 3)
+
+	st, err := fb.Status()
+	require.NoError(t, err)
+	require.True(t, st.Enabled)
+	require.True(t, st.Tripped)
+	require.Equal(t, map[string]int{"commit": 3}, st.Deferred)
+
+	require.False(t, fb.Tripped(big.NewInt(50)))
+	st, err = fb.Status()
+	require.NoError(t, err)
+	require.Empty(t, st.Deferred)
+
+	// disabling the breaker resets it
+	require.True(t, fb.Tripped(big.NewInt(200)))
+	cfg = config.MinerFeeConfig{}
+	require.False(t, fb.Tripped(big.NewInt(200)))
+
+	var nilfb *FeeBreaker
+	require.False(t, nilfb.Tripped(big.NewInt(200)))
+}
+
+func TestImminent(t *testing.T) {
+	now := time.Now()
+	cutoffs := map[abi.SectorNumber]time.Time{
+		1: now.Add(time.Hour),
+		2: {},
+	}
+
+	require.False(t, imminent(cutoffs, 30*time.Minute))
+	require.True(t, imminent(cutoffs, 2*time.Hour))
+}
@@ -79,6 +79,7 @@ var fsmPlanners = map[SectorState]func(events []statemachine.Event, state *Secto
 		on(SectorPreCommitLanded{}, WaitSeed),
 		on(SectorDealsExpired{}, DealsExpired),
 		on(SectorInvalidDealIDs{}, RecoverDealIDs),
+		on(SectorProving{}, FinalizeSector),
 	),
 	SubmitPreCommitBatch: planOne(
 		on(SectorPreCommitBatchSent{}, PreCommitBatchWait),
@@ -102,7 +103,8 @@ var fsmPlanners = map[SectorState]func(events []statemachine.Event, state *Secto
 		on(SectorSeedReady{}, Committing),
 		on(SectorChainPreCommitFailed{}, PreCommitFailed),
 	),
-	Committing: planCommitting,
+	Committing:      planCommitting,
+	RegenerateProof: planCommitting,
 	CommitFinalize: planOne(
 		on(SectorFinalized{}, SubmitCommit),
 		on(SectorFinalizeFailed{}, CommitFinalizeFailed),
@@ -150,9 +152,11 @@ var fsmPlanners = map[SectorState]func(events []statemachine.Event, state *Secto
 		on(SectorPreCommitLanded{}, WaitSeed),
 		on(SectorDealsExpired{}, DealsExpired),
 		on(SectorInvalidDealIDs{}, RecoverDealIDs),
+		on(SectorProving{}, FinalizeSector),
 	),
 	ComputeProofFailed: planOne(
 		on(SectorRetryComputeProof{}, Committing),
+		on(SectorRegenerateProof{}, RegenerateProof),
 		on(SectorSealPreCommit1Failed{}, SealPreCommit1Failed),
 	),
 	CommitFinalizeFailed: planOne(
@@ -162,7 +166,8 @@ var fsmPlanners = map[SectorState]func(events []statemachine.Event, state *Secto
 		on(SectorSealPreCommit1Failed{}, SealPreCommit1Failed),
 		on(SectorRetryWaitSeed{}, WaitSeed),
 		on(SectorRetryComputeProof{}, Committing),
-		on(SectorRetryInvalidProof{}, Committing),
+		on(SectorRetryInvalidProof{}, RegenerateProof),
+		on(SectorRegenerateProof{}, RegenerateProof),
 		on(SectorRetryPreCommitWait{}, PreCommitWait),
 		on(SectorChainPreCommitFailed{}, PreCommitFailed),
 		on(SectorRetryPreCommit{}, PreCommitting),
@@ -251,6 +256,10 @@ func (m *Sealing) logEvents(events []statemachine.Event, state *SectorInfo) {
 			l.Trace = fmt.Sprintf("%+v", err)
 		}
 
+		if err, iserr := event.User.(error); iserr {
+			log.Warnf("sector %d got error event %T: %+v", state.SectorNumber, event.User, err)
+		}
+
 		if len(state.Log) > 8000 {
 			log.Warnw("truncating sector log", "sector", state.SectorNumber)
 			state.Log[2000] = Log{
@@ -291,10 +300,12 @@ func (m *Sealing) plan(events []statemachine.Event, state *SectorInfo) (func(sta
 		}
 	}
 
+	before := *state
 	processed, err := p(events, state)
 	if err != nil {
 		return nil, 0, xerrors.Errorf("running planner for state %s failed: %w", state.State, err)
 	}
+	m.recordSectorLog(events[:processed], &before, state)
 
 	/////
 	// Now decide what to do next
@@ -378,6 +389,8 @@ func (m *Sealing) plan(events []statemachine.Event, state *SectorInfo) (func(sta
 		return m.handleWaitSeed, processed, nil
 	case Committing:
 		return m.handleCommitting, processed, nil
+	case RegenerateProof:
+		return m.handleRegenerateProof, processed, nil
 	case SubmitCommit:
 		return m.handleSubmitCommit, processed, nil
 	case SubmitCommitAggregate:
@@ -601,10 +614,6 @@ func planOne(ts ...func() (mut mutator, next func(*SectorInfo) (more bool, err e
 					continue
 				}
 
-				if err, iserr := event.User.(error); iserr {
-					log.Warnf("sector %d got error event %T: %+v", state.SectorNumber, event.User, err)
-				}
-
 				event.User.(mutator).apply(state)
 				more, err := next(state)
 				if err != nil || !more {
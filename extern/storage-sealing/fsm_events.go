@@ -1,6 +1,7 @@
 package sealing
 
 import (
+	"sort"
 	"time"
 
 	"github.com/ipfs/go-cid"
@@ -325,6 +326,12 @@ func (evt SectorRetryInvalidProof) apply(state *SectorInfo) {
 	state.InvalidProofs++
 }
 
+// SectorRegenerateProof recomputes the proof of a sector whose commit failed
+// for proof-related reasons, see RegenerateProof
+type SectorRegenerateProof struct{}
+
+func (evt SectorRegenerateProof) apply(state *SectorInfo) {}
+
 type SectorRetryCommitWait struct{}
 
 func (evt SectorRetryCommitWait) apply(state *SectorInfo) {}
@@ -396,6 +403,35 @@ func (evt SectorRemove) applyGlobal(state *SectorInfo) bool {
 	return true
 }
 
+type SectorSetLabels struct {
+	// Labels to set, an empty value removes the label
+	Labels map[string]string
+}
+
+func (evt SectorSetLabels) applyGlobal(state *SectorInfo) bool {
+	labels := map[string]string{}
+	for _, l := range state.Labels {
+		labels[l.Key] = l.Value
+	}
+	for k, v := range evt.Labels {
+		if v == "" {
+			delete(labels, k)
+			continue
+		}
+		labels[k] = v
+	}
+
+	state.Labels = make([]SectorLabel, 0, len(labels))
+	for k, v := range labels {
+		state.Labels = append(state.Labels, SectorLabel{Key: k, Value: v})
+	}
+	sort.Slice(state.Labels, func(i, j int) bool {
+		return state.Labels[i].Key < state.Labels[j].Key
+	})
+
+	return false
+}
+
 type SectorRemoved struct{}
 
 func (evt SectorRemoved) apply(state *SectorInfo) {}
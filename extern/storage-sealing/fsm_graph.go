@@ -0,0 +1,211 @@
+package sealing
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	statemachine "github.com/filecoin-project/go-statemachine"
+)
+
+// FSMTransition is an edge of the sealing state machine graph. Global
+// transitions can happen from any state, and have no From state.
+type FSMTransition struct {
+	From   SectorState
+	Event  string
+	To     SectorState
+	Global bool
+}
+
+type FSMState struct {
+	State SectorState
+
+	// sectors currently in the state
+	Sectors int
+
+	// moving average of the time sectors spent in the state since the miner
+	// started, zero when no sector left the state yet
+	MeanDwell time.Duration
+	Samples   int
+}
+
+type FSMGraph struct {
+	States      []FSMState
+	Transitions []FSMTransition
+}
+
+// errFSMProbe is carried by the error events used to discover the graph
+var errFSMProbe = xerrors.New("fsm graph probe")
+
+// fsmEvents are the events planners may handle, used to discover the graph
+// transitions. TestFSMEventList makes sure no event is missing.
+var fsmEvents = []mutator{
+	SectorStart{},
+	SectorStartCC{},
+	SectorAddPiece{},
+	SectorPieceAdded{},
+	SectorAddPieceFailed{errFSMProbe},
+	SectorStartPacking{},
+	SectorPacked{},
+	SectorTicket{},
+	SectorOldTicket{},
+	SectorPreCommit1{},
+	SectorPreCommit2{},
+	SectorPreCommitBatch{},
+	SectorPreCommitBatchSent{},
+	SectorPreCommitLanded{},
+	SectorSealPreCommit1Failed{errFSMProbe},
+	SectorSealPreCommit2Failed{errFSMProbe},
+	SectorChainPreCommitFailed{errFSMProbe},
+	SectorPreCommitted{},
+	SectorSeedReady{SeedEpoch: 1}, // differs from the probed sector's seed
+	SectorComputeProofFailed{errFSMProbe},
+	SectorCommitFailed{errFSMProbe},
+	SectorRetrySubmitCommit{},
+	SectorDealsExpired{errFSMProbe},
+	SectorTicketExpired{errFSMProbe},
+	SectorCommitted{},
+	SectorProofReady{},
+	SectorSubmitCommitAggregate{},
+	SectorCommitSubmitted{},
+	SectorCommitAggregateSent{},
+	SectorProving{},
+	SectorFinalized{},
+	SectorRetryFinalize{},
+	SectorFinalizeFailed{errFSMProbe},
+	SectorRetrySealPreCommit1{},
+	SectorRetrySealPreCommit2{},
+	SectorRetryPreCommit{},
+	SectorRetryWaitSeed{},
+	SectorRetryPreCommitWait{},
+	SectorRetryComputeProof{},
+	SectorRetryInvalidProof{},
+	SectorRegenerateProof{},
+	SectorRetryCommitWait{},
+	SectorInvalidDealIDs{},
+	SectorUpdateDealIDs{},
+	SectorFaulty{},
+	SectorFaultReported{},
+	SectorTerminating{},
+	SectorTerminated{},
+	SectorTerminateFailed{errFSMProbe},
+	SectorRemoved{},
+	SectorRemoveFailed{errFSMProbe},
+}
+
+// fsmGlobalEvents are the global events moving sectors to a fixed state.
+// SectorForceState can move sectors to any state, and isn't in the graph.
+var fsmGlobalEvents = []globalMutator{
+	SectorTerminate{},
+	SectorRemove{},
+}
+
+// fsmReturnStates are the states sectors can return to after recovering
+var fsmReturnStates = []ReturnState{
+	RetPreCommit1,
+	RetPreCommitting,
+	RetPreCommitFailed,
+	RetCommitFailed,
+}
+
+var (
+	fsmTransitionsOnce sync.Once
+	fsmTransitions     []FSMTransition
+)
+
+// planTransitions discovers the transitions of the state machine by feeding
+// each event to the planner of each state.
+func planTransitions() []FSMTransition {
+	fsmTransitionsOnce.Do(func() {
+		seen := map[FSMTransition]struct{}{}
+
+		for from, planner := range fsmPlanners {
+			for _, evt := range fsmEvents {
+				for _, ret := range fsmReturnStates {
+					probe := &SectorInfo{State: from, Return: ret}
+					if _, err := planner([]statemachine.Event{{User: evt}}, probe); err != nil {
+						continue
+					}
+
+					if probe.State == from {
+						// ignored events don't change the state
+						if _, ignored := evt.(Ignorable); ignored {
+							continue
+						}
+					}
+
+					seen[FSMTransition{From: from, Event: eventName(evt), To: probe.State}] = struct{}{}
+				}
+			}
+		}
+
+		for _, evt := range fsmGlobalEvents {
+			var probe SectorInfo
+			evt.applyGlobal(&probe)
+			seen[FSMTransition{Event: eventName(evt), To: probe.State, Global: true}] = struct{}{}
+		}
+
+		for t := range seen {
+			fsmTransitions = append(fsmTransitions, t)
+		}
+		sort.Slice(fsmTransitions, func(i, j int) bool {
+			a, b := fsmTransitions[i], fsmTransitions[j]
+			if a.Global != b.Global {
+				return b.Global
+			}
+			if a.From != b.From {
+				return a.From < b.From
+			}
+			if a.To != b.To {
+				return a.To < b.To
+			}
+			return a.Event < b.Event
+		})
+	})
+
+	return fsmTransitions
+}
+
+func eventName(evt interface{}) string {
+	return reflect.TypeOf(evt).Name()
+}
+
+// FSMGraph returns the sealing state machine graph, with the number of
+// sectors in each state and the time sectors spend in them.
+func (m *Sealing) FSMGraph() (FSMGraph, error) {
+	sectors, err := m.ListSectors()
+	if err != nil {
+		return FSMGraph{}, err
+	}
+
+	counts := map[SectorState]int{}
+	for _, sector := range sectors {
+		counts[sector.State]++
+	}
+
+	out := FSMGraph{
+		States:      make([]FSMState, 0, len(fsmPlanners)),
+		Transitions: append([]FSMTransition{}, planTransitions()...),
+	}
+
+	m.stats.lk.Lock()
+	for st := range fsmPlanners {
+		d := m.stats.durations[st]
+		out.States = append(out.States, FSMState{
+			State:     st,
+			Sectors:   counts[st],
+			MeanDwell: d.avg,
+			Samples:   d.samples,
+		})
+	}
+	m.stats.lk.Unlock()
+
+	sort.Slice(out.States, func(i, j int) bool {
+		return out.States[i].State < out.States[j].State
+	})
+
+	return out, nil
+}
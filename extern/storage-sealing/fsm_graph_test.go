@@ -0,0 +1,49 @@
+package sealing
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSMEventList(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "fsm_events.go", nil, 0)
+	require.NoError(t, err)
+
+	var events []string
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || fn.Name.Name != "apply" {
+			continue
+		}
+		events = append(events, fn.Recv.List[0].Type.(*ast.Ident).Name)
+	}
+
+	var listed []string
+	for _, evt := range fsmEvents {
+		listed = append(listed, eventName(evt))
+	}
+
+	require.ElementsMatch(t, events, listed)
+}
+
+func TestPlanTransitions(t *testing.T) {
+	transitions := planTransitions()
+
+	require.Contains(t, transitions, FSMTransition{From: PreCommit1, Event: "SectorPreCommit1", To: PreCommit2})
+	require.Contains(t, transitions, FSMTransition{From: Committing, Event: "SectorProofReady", To: CommitFinalize})
+	require.Contains(t, transitions, FSMTransition{From: RecoverDealIDs, Event: "SectorUpdateDealIDs", To: CommitFailed})
+	require.Contains(t, transitions, FSMTransition{Event: "SectorRemove", To: Removing, Global: true})
+
+	// ignored events aren't transitions
+	require.NotContains(t, transitions, FSMTransition{From: Packing, Event: "SectorStartPacking", To: Packing})
+
+	// every state the machine goes to has a planner
+	for _, tr := range transitions {
+		_, ok := fsmPlanners[tr.To]
+		require.True(t, ok, "state %s", tr.To)
+	}
+}
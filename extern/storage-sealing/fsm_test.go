@@ -228,6 +228,34 @@ func TestPlanCommittingHandlesSectorCommitFailed(t *testing.T) {
 	require.Equal(t, CommitFailed, m.state.State)
 }
 
+func TestRegenerateProof(t *testing.T) {
+	ma, _ := address.NewIDAddress(55151)
+	m := test{
+		s: &Sealing{
+			maddr: ma,
+			stats: SectorStats{
+				bySector: map[abi.SectorID]statSectorState{},
+			},
+		},
+		t:     t,
+		state: &SectorInfo{State: CommitFailed},
+	}
+
+	m.planSingle(SectorRetryInvalidProof{})
+	require.Equal(m.t, RegenerateProof, m.state.State)
+	require.Equal(m.t, uint64(1), m.state.InvalidProofs)
+
+	m.planSingle(SectorCommitted{})
+	require.Equal(m.t, SubmitCommit, m.state.State)
+
+	m.state.State = ComputeProofFailed
+	m.planSingle(SectorRegenerateProof{})
+	require.Equal(m.t, RegenerateProof, m.state.State)
+
+	m.planSingle(SectorComputeProofFailed{})
+	require.Equal(m.t, ComputeProofFailed, m.state.State)
+}
+
 func TestPlannerList(t *testing.T) {
 	for state := range ExistSectorStateList {
 		_, ok := fsmPlanners[state]
@@ -317,3 +345,53 @@ func TestTicketExpired(t *testing.T) {
 		}
 	}
 }
+
+func TestAlreadyCommitted(t *testing.T) {
+	ma, _ := address.NewIDAddress(55151)
+	for _, state := range []SectorState{PreCommitting, PreCommitFailed} {
+		m := test{
+			s: &Sealing{
+				maddr: ma,
+				stats: SectorStats{
+					bySector: map[abi.SectorID]statSectorState{},
+				},
+				notifee: func(before, after SectorInfo) {},
+			},
+			t:     t,
+			state: &SectorInfo{State: state},
+		}
+
+		m.planSingle(SectorProving{})
+		require.Equal(m.t, FinalizeSector, m.state.State, "from %s", state)
+
+		m.planSingle(SectorFinalized{})
+		require.Equal(m.t, Proving, m.state.State, "from %s", state)
+	}
+}
+
+func TestSetLabels(t *testing.T) {
+	ma, _ := address.NewIDAddress(55151)
+	m := test{
+		s: &Sealing{
+			maddr: ma,
+			stats: SectorStats{
+				bySector: map[abi.SectorID]statSectorState{},
+			},
+		},
+		t:     t,
+		state: &SectorInfo{State: PreCommit1},
+	}
+
+	m.planSingle(SectorSetLabels{Labels: map[string]string{"datacenter": "fra1", "owner": "a"}})
+	require.Equal(m.t, m.state.State, PreCommit1)
+	require.Equal(m.t, []SectorLabel{{"datacenter", "fra1"}, {"owner", "a"}}, m.state.Labels)
+
+	m.planSingle(SectorSetLabels{Labels: map[string]string{"owner": "", "rack": "3"}})
+	require.Equal(m.t, []SectorLabel{{"datacenter", "fra1"}, {"rack", "3"}}, m.state.Labels)
+
+	// labels can still be changed on proving sectors
+	m.state.State = Proving
+	m.planSingle(SectorSetLabels{Labels: map[string]string{"datacenter": "ams2"}})
+	require.Equal(m.t, m.state.State, Proving)
+	require.Equal(m.t, []SectorLabel{{"datacenter", "ams2"}, {"rack", "3"}}, m.state.Labels)
+}
@@ -16,6 +16,7 @@ func main() {
 		sealing.DealSchedule{},
 		sealing.SectorInfo{},
 		sealing.Log{},
+		sealing.SectorLabel{},
 	)
 	if err != nil {
 		fmt.Println(err)
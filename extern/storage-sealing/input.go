@@ -171,7 +171,7 @@ func (m *Sealing) handleAddPiece(ctx statemachine.Context, sector SectorInfo) er
 
 		if len(sector.dealIDs())+(i+1) > maxDeals {
 			// todo: this is rather unlikely to happen, but in case it does, return the deal to waiting queue instead of failing it
-			deal.accepted(sector.SectorNumber, offset, xerrors.Errorf("too many deals assigned to sector %d, dropping deal", sector.SectorNumber))
+			deal.accepted(sector.SectorNumber, offset, sealiface.WithCode(sealiface.ErrTooManyDeals, xerrors.Errorf("too many deals assigned to sector %d, dropping deal", sector.SectorNumber)))
 			continue
 		}
 
@@ -179,7 +179,7 @@ func (m *Sealing) handleAddPiece(ctx statemachine.Context, sector SectorInfo) er
 
 		if offset.Padded()+padLength+deal.size.Padded() > abi.PaddedPieceSize(ssize) {
 			// todo: this is rather unlikely to happen, but in case it does, return the deal to waiting queue instead of failing it
-			deal.accepted(sector.SectorNumber, offset, xerrors.Errorf("piece %s assigned to sector %d with not enough space", piece, sector.SectorNumber))
+			deal.accepted(sector.SectorNumber, offset, sealiface.WithCode(sealiface.ErrSectorNoSpace, xerrors.Errorf("piece %s assigned to sector %d with not enough space", piece, sector.SectorNumber)))
 			continue
 		}
 
@@ -239,7 +239,7 @@ func (m *Sealing) handleAddPieceFailed(ctx statemachine.Context, sector SectorIn
 func (m *Sealing) AddPieceToAnySector(ctx context.Context, size abi.UnpaddedPieceSize, data storage.Data, deal DealInfo) (abi.SectorNumber, abi.PaddedPieceSize, error) {
 	log.Infof("Adding piece for deal %d (publish msg: %s)", deal.DealID, deal.PublishCid)
 	if (padreader.PaddedSize(uint64(size))) != size {
-		return 0, 0, xerrors.Errorf("cannot allocate unpadded piece")
+		return 0, 0, sealiface.WithCode(sealiface.ErrPieceUnpadded, xerrors.Errorf("cannot allocate unpadded piece"))
 	}
 
 	sp, err := m.currentSealProof(ctx)
@@ -253,17 +253,17 @@ func (m *Sealing) AddPieceToAnySector(ctx context.Context, size abi.UnpaddedPiec
 	}
 
 	if size > abi.PaddedPieceSize(ssize).Unpadded() {
-		return 0, 0, xerrors.Errorf("piece cannot fit into a sector")
+		return 0, 0, sealiface.WithCode(sealiface.ErrPieceTooLarge, xerrors.Errorf("piece cannot fit into a sector"), "size", size)
 	}
 
 	if _, err := deal.DealProposal.Cid(); err != nil {
-		return 0, 0, xerrors.Errorf("getting proposal CID: %w", err)
+		return 0, 0, sealiface.WithCode(sealiface.ErrDealInvalid, xerrors.Errorf("getting proposal CID: %w", err))
 	}
 
 	m.inputLk.Lock()
 	if _, exist := m.pendingPieces[proposalCID(deal)]; exist {
 		m.inputLk.Unlock()
-		return 0, 0, xerrors.Errorf("piece for deal %s already pending", proposalCID(deal))
+		return 0, 0, sealiface.WithCode(sealiface.ErrDealPending, xerrors.Errorf("piece for deal %s already pending", proposalCID(deal)), "proposal", proposalCID(deal))
 	}
 
 	resCh := make(chan struct {
@@ -429,7 +429,7 @@ func (m *Sealing) tryCreateDealSector(ctx context.Context, sp abi.RegisteredSeal
 func (m *Sealing) createSector(ctx context.Context, cfg sealiface.Config, sp abi.RegisteredSealProof) (abi.SectorNumber, error) {
 	// Now actually create a new sector
 
-	sid, err := m.sc.Next()
+	sid, err := m.nextSectorNumber(ctx)
 	if err != nil {
 		return 0, xerrors.Errorf("getting sector number: %w", err)
 	}
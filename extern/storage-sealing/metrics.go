@@ -0,0 +1,50 @@
+package sealing
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// Message types used to tag sealing message metrics
+const (
+	msgTypePreCommit       = "precommit"
+	msgTypePreCommitBatch  = "precommit_batch"
+	msgTypeCommit          = "commit"
+	msgTypeCommitAggregate = "commit_aggregate"
+)
+
+func recordMsgSent(ctx context.Context, msgType string) {
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(metrics.SealingMsgType, msgType)}, metrics.SealingMessagesSent.M(1))
+}
+
+// recordMsgFailed records a message which failed to be sent ("send"), or
+// which landed on chain with a non-zero exit code ("exec"). Execution
+// failures of batch messages are recorded once per affected sector.
+func recordMsgFailed(ctx context.Context, msgType string, failure string) {
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{
+		tag.Upsert(metrics.SealingMsgType, msgType),
+		tag.Upsert(metrics.FailureType, failure),
+	}, metrics.SealingMessagesFailed.M(1))
+}
+
+// commitMsgType returns the message type of the commit message of a sector.
+// Sectors wait for single and aggregate commit messages in the same state, so
+// the method of the message tells them apart.
+func (m *Sealing) commitMsgType(ctx context.Context, mcid cid.Cid) string {
+	msg, err := m.api.ChainGetMessage(ctx, mcid)
+	if err != nil {
+		log.Warnw("getting commit message", "message", mcid, "error", err)
+		return msgTypeCommit
+	}
+
+	if msg.Method == miner.Methods.ProveCommitAggregate {
+		return msgTypeCommitAggregate
+	}
+	return msgTypeCommit
+}
@@ -21,14 +21,15 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
-	"github.com/filecoin-project/lotus/node/config"
 )
 
 type PreCommitBatcherApi interface {
 	SendMsg(ctx context.Context, from, to address.Address, method abi.MethodNum, value, maxFee abi.TokenAmount, params []byte) (cid.Cid, error)
 	StateMinerInfo(context.Context, address.Address, TipSetToken) (miner.MinerInfo, error)
 	ChainHead(ctx context.Context) (TipSetToken, abi.ChainEpoch, error)
+	ChainBaseFee(context.Context, TipSetToken) (abi.TokenAmount, error)
 }
 
 type preCommitEntry struct {
@@ -41,32 +42,39 @@ type PreCommitBatcher struct {
 	maddr     address.Address
 	mctx      context.Context
 	addrSel   AddrSel
-	feeCfg    config.MinerFeeConfig
+	getFeeCfg GetFeeConfigFunc
 	getConfig GetSealingConfigFunc
 
 	cutoffs map[abi.SectorNumber]time.Time
 	todo    map[abi.SectorNumber]*preCommitEntry
 	waiting map[abi.SectorNumber][]chan sealiface.PreCommitBatchRes
 
-	notify, stop, stopped chan struct{}
-	force                 chan chan []sealiface.PreCommitBatchRes
-	lk                    sync.Mutex
+	notify, reload, stop, stopped chan struct{}
+	force                         chan chan []sealiface.PreCommitBatchRes
+	lk                            sync.Mutex
+
+	maintenance bool // batches held in maintenance mode
+
+	fb      *FeeBreaker
+	feeHeld bool // batches held by the base fee circuit breaker
 }
 
-func NewPreCommitBatcher(mctx context.Context, maddr address.Address, api PreCommitBatcherApi, addrSel AddrSel, feeCfg config.MinerFeeConfig, getConfig GetSealingConfigFunc) *PreCommitBatcher {
+func NewPreCommitBatcher(mctx context.Context, maddr address.Address, api PreCommitBatcherApi, addrSel AddrSel, getFeeCfg GetFeeConfigFunc, getConfig GetSealingConfigFunc, fb *FeeBreaker) *PreCommitBatcher {
 	b := &PreCommitBatcher{
 		api:       api,
 		maddr:     maddr,
 		mctx:      mctx,
 		addrSel:   addrSel,
-		feeCfg:    feeCfg,
+		getFeeCfg: getFeeCfg,
 		getConfig: getConfig,
+		fb:        fb,
 
 		cutoffs: map[abi.SectorNumber]time.Time{},
 		todo:    map[abi.SectorNumber]*preCommitEntry{},
 		waiting: map[abi.SectorNumber][]chan sealiface.PreCommitBatchRes{},
 
 		notify:  make(chan struct{}, 1),
+		reload:  make(chan struct{}, 1),
 		force:   make(chan chan []sealiface.PreCommitBatchRes),
 		stop:    make(chan struct{}),
 		stopped: make(chan struct{}),
@@ -104,6 +112,14 @@ func (b *PreCommitBatcher) run() {
 			sendAboveMin = true
 		case fr := <-b.force: // user triggered
 			forceRes = fr
+		case <-b.reload:
+			// batch waits may have changed
+			if ncfg, err := b.getConfig(); err != nil {
+				log.Warnw("PreCommitBatcher getconfig error", "error", err)
+			} else {
+				cfg = ncfg
+			}
+			continue
 		}
 
 		var err error
@@ -124,6 +140,10 @@ func (b *PreCommitBatcher) batchWait(maxWait, slack time.Duration) <-chan time.T
 		return nil
 	}
 
+	if b.maintenance || b.feeHeld {
+		return time.After(MaintenanceRecheckInterval)
+	}
+
 	var cutoff time.Time
 	for sn := range b.todo {
 		sectorCutoff := b.cutoffs[sn]
@@ -169,6 +189,12 @@ func (b *PreCommitBatcher) maybeStartBatch(notif, after bool) ([]sealiface.PreCo
 		return nil, xerrors.Errorf("getting config: %w", err)
 	}
 
+	b.maintenance = cfg.MaintenanceMode
+	if cfg.MaintenanceMode && (notif || after) {
+		log.Infow("holding precommit batch in maintenance mode", "sectors", total)
+		return nil, nil
+	}
+
 	if notif && total < cfg.MaxPreCommitBatch {
 		return nil, nil
 	}
@@ -177,15 +203,30 @@ func (b *PreCommitBatcher) maybeStartBatch(notif, after bool) ([]sealiface.PreCo
 		return nil, nil
 	}
 
+	if notif || after {
+		hold, err := b.fb.hold(b.mctx, b.api, b.cutoffs, cfg.PreCommitBatchSlack)
+		if err != nil {
+			return nil, xerrors.Errorf("checking base fee circuit breaker: %w", err)
+		}
+		b.feeHeld = hold
+		if hold {
+			b.fb.Defer("precommit", total)
+			log.Infow("holding precommit batch, base fee over the circuit breaker threshold", "sectors", total)
+			return nil, nil
+		}
+	}
+	b.fb.Defer("precommit", 0)
+
 	// todo support multiple batches
 	res, err := b.processBatch(cfg)
 	if err != nil && len(res) == 0 {
 		return nil, err
 	}
 
-	for _, r := range res {
+	for i, r := range res {
 		if err != nil {
-			r.Error = err.Error()
+			res[i].SetError(err)
+			r = res[i]
 		}
 
 		for _, sn := range r.Sectors {
@@ -228,18 +269,25 @@ func (b *PreCommitBatcher) processBatch(cfg sealiface.Config) ([]sealiface.PreCo
 		return []sealiface.PreCommitBatchRes{res}, xerrors.Errorf("couldn't get miner info: %w", err)
 	}
 
-	maxFee := b.feeCfg.MaxPreCommitBatchGasFee.FeeForSectors(len(params.Sectors))
+	feeCfg, err := b.getFeeCfg()
+	if err != nil {
+		return []sealiface.PreCommitBatchRes{res}, xerrors.Errorf("getting fee config: %w", err)
+	}
+
+	maxFee := feeCfg.MaxPreCommitBatchGasFee.FeeForSectors(len(params.Sectors))
 	goodFunds := big.Add(deposit, maxFee)
 
 	from, _, err := b.addrSel(b.mctx, mi, api.PreCommitAddr, goodFunds, deposit)
 	if err != nil {
-		return []sealiface.PreCommitBatchRes{res}, xerrors.Errorf("no good address found: %w", err)
+		return []sealiface.PreCommitBatchRes{res}, sealiface.WithCode(sealiface.ErrInsufficientFunds, xerrors.Errorf("no good address found: %w", err), "deposit", types.FIL(deposit))
 	}
 
 	mcid, err := b.api.SendMsg(b.mctx, from, b.maddr, miner.Methods.PreCommitSectorBatch, deposit, maxFee, enc.Bytes())
 	if err != nil {
-		return []sealiface.PreCommitBatchRes{res}, xerrors.Errorf("sending message failed: %w", err)
+		recordMsgFailed(b.mctx, msgTypePreCommitBatch, "send")
+		return []sealiface.PreCommitBatchRes{res}, sealiface.WithCode(sealiface.ErrMessageSend, xerrors.Errorf("sending message failed: %w", err))
 	}
+	recordMsgSent(b.mctx, msgTypePreCommitBatch)
 
 	res.Msg = &mcid
 
@@ -282,6 +330,14 @@ func (b *PreCommitBatcher) AddPreCommit(ctx context.Context, s SectorInfo, depos
 	}
 }
 
+// ReloadConfig makes the batcher pick up changes of the batch wait
+func (b *PreCommitBatcher) ReloadConfig() {
+	select {
+	case b.reload <- struct{}{}:
+	default:
+	}
+}
+
 func (b *PreCommitBatcher) Flush(ctx context.Context) ([]sealiface.PreCommitBatchRes, error) {
 	resCh := make(chan []sealiface.PreCommitBatchRes, 1)
 	select {
@@ -325,6 +381,33 @@ func (b *PreCommitBatcher) Pending(ctx context.Context) ([]abi.SectorID, error)
 	return res, nil
 }
 
+// Status returns the number of queued sectors, and when the batch gets sent
+// at the latest
+func (b *PreCommitBatcher) Status(ctx context.Context) (sealiface.BatchStatus, error) {
+	cfg, err := b.getConfig()
+	if err != nil {
+		return sealiface.BatchStatus{}, err
+	}
+
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	out := sealiface.BatchStatus{
+		Pending: len(b.todo),
+	}
+	for sn := range b.todo {
+		sectorCutoff := b.cutoffs[sn]
+		if out.Cutoff.IsZero() || (!sectorCutoff.IsZero() && sectorCutoff.Before(out.Cutoff)) {
+			out.Cutoff = sectorCutoff
+		}
+	}
+	if !out.Cutoff.IsZero() {
+		out.SendBy = out.Cutoff.Add(-cfg.PreCommitBatchSlack)
+	}
+
+	return out, nil
+}
+
 func (b *PreCommitBatcher) Stop(ctx context.Context) error {
 	close(b.stop)
 
@@ -2,16 +2,20 @@ package sealing
 
 import (
 	"context"
+	"time"
 
-	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/network"
 
-	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
 )
 
 type PreCommitPolicy interface {
-	Expiration(ctx context.Context, ps ...Piece) (abi.ChainEpoch, error)
+	Expiration(ctx context.Context, sn abi.SectorNumber, ps ...Piece) (abi.ChainEpoch, error)
 }
 
 type Chain interface {
@@ -29,12 +33,22 @@ type Chain interface {
 // the first or second mode.
 //
 // If we're in Mode 1: The pre-commit expiration epoch will be the maximum
-// deal end epoch of a piece in the sector.
+// deal end epoch of a piece in the sector. With the "max" deal sector
+// expiration policy, it will be the later of the maximum deal end epoch and
+// the Mode 2 expiration.
 //
 // If we're in Mode 2: The pre-commit expiration epoch will be set to the
-// current epoch + the provided default duration.
+// current epoch + the committed capacity sector lifetime from the sealing
+// config, capped at the provided maximum duration. When an expiration ladder
+// is configured, the lifetime is shortened by a number of ladder steps picked
+// by sector number, spreading the expirations of sectors sealed together over
+// time, so that they don't all need to be extended or re-pledged at once. The
+// lifetime is never shorter than the minimum sector lifetime of the network.
+//
+// In both modes the expiration is then aligned to the end of a proving period.
 type BasicPreCommitPolicy struct {
-	api Chain
+	api       Chain
+	getConfig GetSealingConfigFunc
 
 	provingBoundary abi.ChainEpoch
 	duration        abi.ChainEpoch
@@ -42,11 +56,13 @@ type BasicPreCommitPolicy struct {
 
 // NewBasicPreCommitPolicy produces a BasicPreCommitPolicy.
 //
-// The provided duration is used as the default sector expiry when the sector
-// contains no deals. The proving boundary is used to adjust/align the sector's expiration.
-func NewBasicPreCommitPolicy(api Chain, duration abi.ChainEpoch, provingBoundary abi.ChainEpoch) BasicPreCommitPolicy {
+// The provided duration is the maximum sector lifetime, used as the default
+// expiry when the sector contains no deals. The proving boundary is used to
+// adjust/align the sector's expiration.
+func NewBasicPreCommitPolicy(api Chain, cfgGetter GetSealingConfigFunc, duration abi.ChainEpoch, provingBoundary abi.ChainEpoch) BasicPreCommitPolicy {
 	return BasicPreCommitPolicy{
 		api:             api,
+		getConfig:       cfgGetter,
 		provingBoundary: provingBoundary,
 		duration:        duration,
 	}
@@ -54,12 +70,23 @@ func NewBasicPreCommitPolicy(api Chain, duration abi.ChainEpoch, provingBoundary
 
 // Expiration produces the pre-commit sector expiration epoch for an encoded
 // replica containing the provided enumeration of pieces and deals.
-func (p *BasicPreCommitPolicy) Expiration(ctx context.Context, ps ...Piece) (abi.ChainEpoch, error) {
+func (p *BasicPreCommitPolicy) Expiration(ctx context.Context, sn abi.SectorNumber, ps ...Piece) (abi.ChainEpoch, error) {
 	_, epoch, err := p.api.ChainHead(ctx)
 	if err != nil {
 		return 0, err
 	}
 
+	cfg, err := p.getConfig()
+	if err != nil {
+		return 0, xerrors.Errorf("getting sealing config: %w", err)
+	}
+
+	switch cfg.DealSectorExpiration {
+	case "", sealiface.DealSectorExpirationDealEnd, sealiface.DealSectorExpirationMax:
+	default:
+		return 0, xerrors.Errorf("unknown deal sector expiration policy %q", cfg.DealSectorExpiration)
+	}
+
 	var end *abi.ChainEpoch
 
 	for _, p := range ps {
@@ -78,12 +105,48 @@ func (p *BasicPreCommitPolicy) Expiration(ctx context.Context, ps ...Piece) (abi
 		}
 	}
 
-	if end == nil {
-		tmp := epoch + p.duration
-		end = &tmp
+	if end == nil || cfg.DealSectorExpiration == sealiface.DealSectorExpirationMax {
+		tmp := epoch + p.ccLifetime(cfg, sn)
+		if end == nil || *end < tmp {
+			end = &tmp
+		}
 	}
 
 	*end += miner.WPoStProvingPeriod - (*end % miner.WPoStProvingPeriod) + p.provingBoundary - 1
 
 	return *end, nil
 }
+
+func (p *BasicPreCommitPolicy) ccLifetime(cfg sealiface.Config, sn abi.SectorNumber) abi.ChainEpoch {
+	lifetime := p.duration
+	if l := durationEpochs(cfg.CommittedCapacitySectorLifetime); l > 0 && l < lifetime {
+		lifetime = l
+	}
+
+	if cfg.ExpirationLadderSteps > 1 {
+		step := durationEpochs(cfg.ExpirationLadderStep)
+		// always move by at least a whole proving period, so that each step
+		// of the ladder expires in a different proving period
+		if step < miner.WPoStProvingPeriod {
+			step = miner.WPoStProvingPeriod
+		}
+
+		lifetime -= abi.ChainEpoch(uint64(sn)%uint64(cfg.ExpirationLadderSteps)) * step
+	}
+
+	// never go below the minimum sector lifetime, however long the ladder is;
+	// the maximum duration can only be shorter on test networks
+	floor := abi.ChainEpoch(miner.MinSectorExpiration)
+	if floor > p.duration {
+		floor = p.duration
+	}
+	if lifetime < floor {
+		lifetime = floor
+	}
+
+	return lifetime
+}
+
+func durationEpochs(d time.Duration) abi.ChainEpoch {
+	return abi.ChainEpoch(d / (time.Duration(build.BlockDelaySecs) * time.Second))
+}
@@ -3,6 +3,7 @@ package sealing_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/filecoin-project/go-state-types/network"
 	"github.com/filecoin-project/lotus/build"
@@ -15,6 +16,7 @@ import (
 	"github.com/filecoin-project/go-state-types/abi"
 
 	sealing "github.com/filecoin-project/lotus/extern/storage-sealing"
+	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
 )
 
 type fakeChain struct {
@@ -29,6 +31,16 @@ func (f *fakeChain) ChainHead(ctx context.Context) (sealing.TipSetToken, abi.Cha
 	return []byte{1, 2, 3}, f.h, nil
 }
 
+func noConfig() (sealiface.Config, error) {
+	return sealiface.Config{}, nil
+}
+
+func withConfig(cfg sealiface.Config) sealing.GetSealingConfigFunc {
+	return func() (sealiface.Config, error) {
+		return cfg, nil
+	}
+}
+
 func fakePieceCid(t *testing.T) cid.Cid {
 	comm := [32]byte{1, 2, 3}
 	fakePieceCid, err := commcid.ReplicaCommitmentV1ToCID(comm[:])
@@ -39,9 +51,9 @@ func fakePieceCid(t *testing.T) cid.Cid {
 func TestBasicPolicyEmptySector(t *testing.T) {
 	policy := sealing.NewBasicPreCommitPolicy(&fakeChain{
 		h: abi.ChainEpoch(55),
-	}, 10, 0)
+	}, noConfig, 10, 0)
 
-	exp, err := policy.Expiration(context.Background())
+	exp, err := policy.Expiration(context.Background(), 0)
 	require.NoError(t, err)
 
 	assert.Equal(t, 2879, int(exp))
@@ -50,7 +62,7 @@ func TestBasicPolicyEmptySector(t *testing.T) {
 func TestBasicPolicyMostConstrictiveSchedule(t *testing.T) {
 	policy := sealing.NewBasicPreCommitPolicy(&fakeChain{
 		h: abi.ChainEpoch(55),
-	}, 100, 11)
+	}, noConfig, 100, 11)
 
 	pieces := []sealing.Piece{
 		{
@@ -81,7 +93,7 @@ func TestBasicPolicyMostConstrictiveSchedule(t *testing.T) {
 		},
 	}
 
-	exp, err := policy.Expiration(context.Background(), pieces...)
+	exp, err := policy.Expiration(context.Background(), 0, pieces...)
 	require.NoError(t, err)
 
 	assert.Equal(t, 2890, int(exp))
@@ -90,7 +102,7 @@ func TestBasicPolicyMostConstrictiveSchedule(t *testing.T) {
 func TestBasicPolicyIgnoresExistingScheduleIfExpired(t *testing.T) {
 	policy := sealing.NewBasicPreCommitPolicy(&fakeChain{
 		h: abi.ChainEpoch(55),
-	}, 100, 0)
+	}, noConfig, 100, 0)
 
 	pieces := []sealing.Piece{
 		{
@@ -108,7 +120,7 @@ func TestBasicPolicyIgnoresExistingScheduleIfExpired(t *testing.T) {
 		},
 	}
 
-	exp, err := policy.Expiration(context.Background(), pieces...)
+	exp, err := policy.Expiration(context.Background(), 0, pieces...)
 	require.NoError(t, err)
 
 	assert.Equal(t, 2879, int(exp))
@@ -117,7 +129,7 @@ func TestBasicPolicyIgnoresExistingScheduleIfExpired(t *testing.T) {
 func TestMissingDealIsIgnored(t *testing.T) {
 	policy := sealing.NewBasicPreCommitPolicy(&fakeChain{
 		h: abi.ChainEpoch(55),
-	}, 100, 11)
+	}, noConfig, 100, 11)
 
 	pieces := []sealing.Piece{
 		{
@@ -142,8 +154,111 @@ func TestMissingDealIsIgnored(t *testing.T) {
 		},
 	}
 
-	exp, err := policy.Expiration(context.Background(), pieces...)
+	exp, err := policy.Expiration(context.Background(), 0, pieces...)
 	require.NoError(t, err)
 
 	assert.Equal(t, 2890, int(exp))
 }
+
+func epochs(n int) time.Duration {
+	return time.Duration(n) * time.Duration(build.BlockDelaySecs) * time.Second
+}
+
+func TestPolicyCommittedCapacityLifetime(t *testing.T) {
+	// shorter than the maximum duration
+	policy := sealing.NewBasicPreCommitPolicy(&fakeChain{
+		h: abi.ChainEpoch(55),
+	}, withConfig(sealiface.Config{CommittedCapacitySectorLifetime: epochs(576000)}), 1555200, 0)
+
+	exp, err := policy.Expiration(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 578879, int(exp))
+
+	// capped at the maximum duration
+	policy = sealing.NewBasicPreCommitPolicy(&fakeChain{
+		h: abi.ChainEpoch(55),
+	}, withConfig(sealiface.Config{CommittedCapacitySectorLifetime: epochs(1728000)}), 1555200, 0)
+
+	exp, err = policy.Expiration(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1558079, int(exp))
+
+	// raised to the minimum sector lifetime
+	policy = sealing.NewBasicPreCommitPolicy(&fakeChain{
+		h: abi.ChainEpoch(55),
+	}, withConfig(sealiface.Config{CommittedCapacitySectorLifetime: epochs(288000)}), 1555200, 0)
+
+	exp, err = policy.Expiration(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 521279, int(exp))
+}
+
+func TestPolicyExpirationLadder(t *testing.T) {
+	policy := sealing.NewBasicPreCommitPolicy(&fakeChain{
+		h: abi.ChainEpoch(55),
+	}, withConfig(sealiface.Config{ExpirationLadderSteps: 3}), 1555200, 0)
+
+	// steps are at least one proving period long
+	for sn, expect := range []int{1558079, 1555199, 1552319, 1558079} {
+		exp, err := policy.Expiration(context.Background(), abi.SectorNumber(sn))
+		require.NoError(t, err)
+		assert.Equal(t, expect, int(exp), "sector %d", sn)
+	}
+
+	// steps don't go below the minimum sector lifetime
+	policy = sealing.NewBasicPreCommitPolicy(&fakeChain{
+		h: abi.ChainEpoch(55),
+	}, withConfig(sealiface.Config{
+		CommittedCapacitySectorLifetime: epochs(576000),
+		ExpirationLadderSteps:           3,
+		ExpirationLadderStep:            epochs(115200),
+	}), 1555200, 0)
+
+	for sn, expect := range []int{578879, 521279, 521279} {
+		exp, err := policy.Expiration(context.Background(), abi.SectorNumber(sn))
+		require.NoError(t, err)
+		assert.Equal(t, expect, int(exp), "sector %d", sn)
+	}
+}
+
+func TestPolicyDealSectorMaxExpiration(t *testing.T) {
+	pieces := []sealing.Piece{
+		{
+			Piece: abi.PieceInfo{
+				Size:     abi.PaddedPieceSize(1024),
+				PieceCID: fakePieceCid(t),
+			},
+			DealInfo: &sealing.DealInfo{
+				DealID: abi.DealID(42),
+				DealSchedule: sealing.DealSchedule{
+					StartEpoch: abi.ChainEpoch(70),
+					EndEpoch:   abi.ChainEpoch(6000),
+				},
+			},
+		},
+	}
+
+	policy := sealing.NewBasicPreCommitPolicy(&fakeChain{
+		h: abi.ChainEpoch(55),
+	}, withConfig(sealiface.Config{DealSectorExpiration: sealiface.DealSectorExpirationMax}), 10000, 0)
+
+	exp, err := policy.Expiration(context.Background(), 0, pieces...)
+	require.NoError(t, err)
+	assert.Equal(t, 11519, int(exp))
+
+	// deals ending after the committed capacity lifetime still set the expiration
+	policy = sealing.NewBasicPreCommitPolicy(&fakeChain{
+		h: abi.ChainEpoch(55),
+	}, withConfig(sealiface.Config{DealSectorExpiration: sealiface.DealSectorExpirationMax}), 1000, 0)
+
+	exp, err = policy.Expiration(context.Background(), 0, pieces...)
+	require.NoError(t, err)
+	assert.Equal(t, 8639, int(exp))
+
+	policy = sealing.NewBasicPreCommitPolicy(&fakeChain{
+		h: abi.ChainEpoch(55),
+	}, withConfig(sealiface.Config{DealSectorExpiration: "forever"}), 1000, 0)
+
+	_, err = policy.Expiration(context.Background(), 0, pieces...)
+	require.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package sealing
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-statemachine"
+
+	sectorstorage "github.com/filecoin-project/lotus/extern/sector-storage"
+)
+
+// RegenerateProofPriority is the scheduling priority of proof regenerations.
+// The sectors are precommitted with their deposit at stake, so their proofs
+// go ahead of the sealing work of new sectors, MaxProofRegenerations keeps
+// them from taking over the workers.
+var RegenerateProofPriority = 2 * DealSectorPriority
+
+// regenRecheck is how often regenerations waiting for a slot check the limit
+// again
+var regenRecheck = time.Minute
+
+// RegenerateProof recomputes the proof of a sector which failed to commit,
+// and commits it again.
+func (m *Sealing) RegenerateProof(id abi.SectorNumber) error {
+	si, err := m.GetSectorInfo(id)
+	if err != nil {
+		return xerrors.Errorf("getting sector info: %w", err)
+	}
+
+	switch si.State {
+	case CommitFailed, ComputeProofFailed:
+	default:
+		return xerrors.Errorf("can't regenerate the proof of a sector in the %s state, expected %s or %s", si.State, CommitFailed, ComputeProofFailed)
+	}
+
+	return m.sectors.Send(uint64(id), SectorRegenerateProof{})
+}
+
+func (m *Sealing) handleRegenerateProof(ctx statemachine.Context, sector SectorInfo) error {
+	release, err := m.acquireRegen(ctx.Context())
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	log.Infow("regenerating sector proof", "sector", sector.SectorNumber, "invalidProofs", sector.InvalidProofs)
+
+	return m.computeProof(sectorstorage.WithPriority(ctx.Context(), RegenerateProofPriority), ctx, sector)
+}
+
+// acquireRegen waits until less than MaxProofRegenerations proofs are being
+// regenerated, and returns the function releasing the slot
+func (m *Sealing) acquireRegen(ctx context.Context) (func(), error) {
+	for {
+		cfg, err := m.getConfig()
+		if err != nil {
+			return nil, xerrors.Errorf("getting config: %w", err)
+		}
+
+		m.regenLk.Lock()
+		if cfg.MaxProofRegenerations == 0 || m.regenerating < cfg.MaxProofRegenerations {
+			m.regenerating++
+			m.regenLk.Unlock()
+
+			return func() {
+				m.regenLk.Lock()
+				m.regenerating--
+				m.regenLk.Unlock()
+			}, nil
+		}
+		m.regenLk.Unlock()
+
+		select {
+		case <-time.After(regenRecheck):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package sealing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
+)
+
+func TestAcquireRegen(t *testing.T) {
+	m := &Sealing{
+		getConfig: func() (sealiface.Config, error) {
+			return sealiface.Config{MaxProofRegenerations: 1}, nil
+		},
+	}
+
+	release, err := m.acquireRegen(context.Background())
+	require.NoError(t, err)
+
+	// the only slot is taken
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = m.acquireRegen(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release()
+
+	release, err = m.acquireRegen(context.Background())
+	require.NoError(t, err)
+	release()
+	require.Zero(t, m.regenerating)
+}
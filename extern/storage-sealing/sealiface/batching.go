@@ -1,6 +1,8 @@
 package sealiface
 
 import (
+	"time"
+
 	"github.com/ipfs/go-cid"
 
 	"github.com/filecoin-project/go-state-types/abi"
@@ -10,14 +12,52 @@ type CommitBatchRes struct {
 	Sectors []abi.SectorNumber
 
 	FailedSectors map[abi.SectorNumber]string
+	// FailedErrors has the error code and metadata of each failed sector
+	FailedErrors map[abi.SectorNumber]ErrorInfo `json:",omitempty"`
 
-	Msg   *cid.Cid
-	Error string // if set, means that all sectors are failed, implies Msg==nil
+	Msg       *cid.Cid
+	Error     string     // if set, means that all sectors are failed, implies Msg==nil
+	ErrorInfo *ErrorInfo `json:",omitempty"`
 }
 
 type PreCommitBatchRes struct {
 	Sectors []abi.SectorNumber
 
-	Msg   *cid.Cid
-	Error string // if set, means that all sectors are failed, implies Msg==nil
+	Msg       *cid.Cid
+	Error     string     // if set, means that all sectors are failed, implies Msg==nil
+	ErrorInfo *ErrorInfo `json:",omitempty"`
+}
+
+// BatchStatus describes the sectors queued in a batcher
+type BatchStatus struct {
+	Pending int
+
+	// Cutoff is the earliest cutoff of the queued sectors, zero if there is
+	// none. The batch is sent at the latest at Cutoff minus the batch slack.
+	Cutoff time.Time
+	SendBy time.Time
+}
+
+// SetError sets the error of the whole batch.
+func (r *CommitBatchRes) SetError(err error) {
+	r.Error = err.Error()
+	r.ErrorInfo = NewErrorInfo(err)
+}
+
+// SetFailed marks the sector as failed.
+func (r *CommitBatchRes) SetFailed(sn abi.SectorNumber, err error) {
+	if r.FailedSectors == nil {
+		r.FailedSectors = map[abi.SectorNumber]string{}
+	}
+	if r.FailedErrors == nil {
+		r.FailedErrors = map[abi.SectorNumber]ErrorInfo{}
+	}
+	r.FailedSectors[sn] = err.Error()
+	r.FailedErrors[sn] = *NewErrorInfo(err)
+}
+
+// SetError sets the error of the whole batch.
+func (r *PreCommitBatchRes) SetError(err error) {
+	r.Error = err.Error()
+	r.ErrorInfo = NewErrorInfo(err)
 }
@@ -32,7 +32,33 @@ type Config struct {
 	CommitBatchWait  time.Duration
 	CommitBatchSlack time.Duration
 
+	// proofs regenerated at once after proof-related commit failures,
+	// 0 = no limit
+	MaxProofRegenerations uint64
+
 	TerminateBatchMax  uint64
 	TerminateBatchMin  uint64
 	TerminateBatchWait time.Duration
+
+	CommittedCapacitySectorLifetime time.Duration
+	DealSectorExpiration            string
+	ExpirationLadderSteps           int
+	ExpirationLadderStep            time.Duration
+
+	// MaintenanceMode holds batched messages until it's disabled, or the
+	// batches are flushed manually
+	MaintenanceMode bool
+
+	// StateWebhookURLs receive the sector state transitions, only the
+	// transitions to StateWebhookStates when set
+	StateWebhookURLs   []string
+	StateWebhookStates []string
 }
+
+const (
+	// DealSectorExpirationDealEnd ends sectors with deals with their last deal
+	DealSectorExpirationDealEnd = "deal-end"
+	// DealSectorExpirationMax keeps sectors with deals for the committed
+	// capacity sector lifetime when it is longer than their deals
+	DealSectorExpirationMax = "max"
+)
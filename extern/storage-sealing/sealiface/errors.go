@@ -0,0 +1,131 @@
+package sealiface
+
+import (
+	"fmt"
+	"regexp"
+
+	"golang.org/x/xerrors"
+)
+
+// ErrorCode identifies the cause of a sealing, batching or deal error, so that
+// callers can act on it without matching error messages.
+type ErrorCode string
+
+const (
+	// ErrUnknown is returned by CodeOf for errors without a code
+	ErrUnknown ErrorCode = ""
+
+	ErrInsufficientFunds ErrorCode = "insufficient-funds"
+	ErrMessageSend       ErrorCode = "message-send-failed"
+	ErrChainAccess       ErrorCode = "chain-access"
+
+	ErrPreCommitExpired  ErrorCode = "precommit-expired"
+	ErrPreCommitNotFound ErrorCode = "precommit-not-found"
+	ErrSectorCommitted   ErrorCode = "sector-committed"
+	ErrCommitInFlight    ErrorCode = "commit-in-flight"
+	ErrAggregationFailed ErrorCode = "proof-aggregation-failed"
+
+	ErrDealInvalid   ErrorCode = "deal-invalid"
+	ErrDealPending   ErrorCode = "deal-pending"
+	ErrPieceTooLarge ErrorCode = "piece-too-large"
+	ErrPieceUnpadded ErrorCode = "piece-unpadded"
+	ErrSectorNoSpace ErrorCode = "sector-no-space"
+	ErrTooManyDeals  ErrorCode = "too-many-deals"
+)
+
+var knownCodes = map[ErrorCode]struct{}{
+	ErrInsufficientFunds: {},
+	ErrMessageSend:       {},
+	ErrChainAccess:       {},
+	ErrPreCommitExpired:  {},
+	ErrPreCommitNotFound: {},
+	ErrSectorCommitted:   {},
+	ErrCommitInFlight:    {},
+	ErrAggregationFailed: {},
+	ErrDealInvalid:       {},
+	ErrDealPending:       {},
+	ErrPieceTooLarge:     {},
+	ErrPieceUnpadded:     {},
+	ErrSectorNoSpace:     {},
+	ErrTooManyDeals:      {},
+}
+
+// CodedError is an error with a code and optional metadata, e.g. the epoch a
+// precommit expired at.
+//
+// The code is part of the message, as in "[precommit-expired] ...", because
+// errors returned over the API only keep their message; ParseErrorCode gets it
+// back on the client side.
+type CodedError struct {
+	Code ErrorCode
+	Meta map[string]string
+	Err  error
+}
+
+// WithCode wraps err with the code. Metadata is given as key/value pairs.
+func WithCode(code ErrorCode, err error, meta ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+
+	ce := &CodedError{Code: code, Err: err}
+	if len(meta) > 0 {
+		ce.Meta = map[string]string{}
+		for i := 0; i+1 < len(meta); i += 2 {
+			ce.Meta[fmt.Sprint(meta[i])] = fmt.Sprint(meta[i+1])
+		}
+	}
+	return ce
+}
+
+func (e *CodedError) Error() string {
+	return fmt.Sprintf("[%s] %s", e.Code, e.Err)
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// CodeOf returns the code of the outermost CodedError in the chain of err.
+func CodeOf(err error) ErrorCode {
+	var ce *CodedError
+	if xerrors.As(err, &ce) {
+		return ce.Code
+	}
+	return ErrUnknown
+}
+
+var codeRe = regexp.MustCompile(`\[([a-z-]+)\]`)
+
+// ParseErrorCode returns the first known error code in an error message, for
+// errors which went through the API and lost their type.
+func ParseErrorCode(msg string) ErrorCode {
+	for _, m := range codeRe.FindAllStringSubmatch(msg, -1) {
+		if _, ok := knownCodes[ErrorCode(m[1])]; ok {
+			return ErrorCode(m[1])
+		}
+	}
+	return ErrUnknown
+}
+
+// ErrorInfo is the serializable form of an error, returned in API results.
+type ErrorInfo struct {
+	Code    ErrorCode `json:",omitempty"`
+	Message string
+	Meta    map[string]string `json:",omitempty"`
+}
+
+// NewErrorInfo returns the info for err, or nil if err is nil.
+func NewErrorInfo(err error) *ErrorInfo {
+	if err == nil {
+		return nil
+	}
+
+	ei := &ErrorInfo{Message: err.Error()}
+	var ce *CodedError
+	if xerrors.As(err, &ce) {
+		ei.Code = ce.Code
+		ei.Meta = ce.Meta
+	}
+	return ei
+}
@@ -0,0 +1,29 @@
+package sealiface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+func TestCodedError(t *testing.T) {
+	require.Nil(t, WithCode(ErrMessageSend, nil))
+
+	err := WithCode(ErrPreCommitExpired, xerrors.New("precommit expired at epoch 10"), "expiry", 10)
+	wrapped := xerrors.Errorf("commit pre-flight checks: %w", err)
+
+	require.Equal(t, ErrPreCommitExpired, CodeOf(wrapped))
+	require.Equal(t, ErrUnknown, CodeOf(xerrors.New("boom")))
+
+	// the code survives the error being sent as a string
+	require.Equal(t, ErrPreCommitExpired, ParseErrorCode(wrapped.Error()))
+	require.Equal(t, ErrUnknown, ParseErrorCode("[not-a-code] boom"))
+
+	ei := NewErrorInfo(wrapped)
+	require.Equal(t, ErrPreCommitExpired, ei.Code)
+	require.Equal(t, "commit pre-flight checks: [precommit-expired] precommit expired at epoch 10", ei.Message)
+	require.Equal(t, map[string]string{"expiry": "10"}, ei.Meta)
+
+	require.Nil(t, NewErrorInfo(nil))
+}
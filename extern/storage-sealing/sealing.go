@@ -28,7 +28,6 @@ import (
 	sectorstorage "github.com/filecoin-project/lotus/extern/sector-storage"
 	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper"
 	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
-	"github.com/filecoin-project/lotus/node/config"
 )
 
 const SectorStorePrefix = "/sectors"
@@ -79,11 +78,12 @@ type SectorStateNotifee func(before, after SectorInfo)
 type AddrSel func(ctx context.Context, mi miner.MinerInfo, use api.AddrUse, goodFunds, minFunds abi.TokenAmount) (address.Address, abi.TokenAmount, error)
 
 type Sealing struct {
-	api    SealingAPI
-	feeCfg config.MinerFeeConfig
-	events Events
+	api       SealingAPI
+	getFeeCfg GetFeeConfigFunc
+	events    Events
 
 	maddr address.Address
+	ds    datastore.Batching
 
 	sealer  sectorstorage.SectorManager
 	sectors *statemachine.StateGroup
@@ -101,6 +101,9 @@ type Sealing struct {
 	upgradeLk sync.Mutex
 	toUpgrade map[abi.SectorNumber]struct{}
 
+	regenLk      sync.Mutex
+	regenerating uint64 // proof regenerations running
+
 	notifee SectorStateNotifee
 	addrSel AddrSel
 
@@ -130,13 +133,14 @@ type pendingPiece struct {
 	accepted func(abi.SectorNumber, abi.UnpaddedPieceSize, error)
 }
 
-func New(api SealingAPI, fc config.MinerFeeConfig, events Events, maddr address.Address, ds datastore.Batching, sealer sectorstorage.SectorManager, sc SectorIDCounter, verif ffiwrapper.Verifier, prov ffiwrapper.Prover, pcp PreCommitPolicy, gc GetSealingConfigFunc, notifee SectorStateNotifee, as AddrSel) *Sealing {
+func New(api SealingAPI, fc GetFeeConfigFunc, events Events, maddr address.Address, ds datastore.Batching, sealer sectorstorage.SectorManager, sc SectorIDCounter, verif ffiwrapper.Verifier, prov ffiwrapper.Prover, pcp PreCommitPolicy, gc GetSealingConfigFunc, notifee SectorStateNotifee, as AddrSel, fb *FeeBreaker) *Sealing {
 	s := &Sealing{
-		api:    api,
-		feeCfg: fc,
-		events: events,
+		api:       api,
+		getFeeCfg: fc,
+		events:    events,
 
 		maddr:  maddr,
+		ds:     ds,
 		sealer: sealer,
 		sc:     sc,
 		verif:  verif,
@@ -152,14 +156,15 @@ func New(api SealingAPI, fc config.MinerFeeConfig, events Events, maddr address.
 		addrSel: as,
 
 		terminator:  NewTerminationBatcher(context.TODO(), maddr, api, as, fc, gc),
-		precommiter: NewPreCommitBatcher(context.TODO(), maddr, api, as, fc, gc),
-		commiter:    NewCommitBatcher(context.TODO(), maddr, api, as, fc, gc, prov),
+		precommiter: NewPreCommitBatcher(context.TODO(), maddr, api, as, fc, gc, fb),
+		commiter:    NewCommitBatcher(context.TODO(), maddr, api, as, fc, gc, prov, fb),
 
 		getConfig: gc,
 		dealInfo:  &CurrentDealInfoManager{api},
 
 		stats: SectorStats{
 			bySector: map[abi.SectorID]statSectorState{},
+			started:  time.Now(),
 		},
 	}
 
@@ -192,6 +197,16 @@ func (m *Sealing) Remove(ctx context.Context, sid abi.SectorNumber) error {
 	return m.sectors.Send(uint64(sid), SectorRemove{})
 }
 
+func (m *Sealing) SetLabels(ctx context.Context, sid abi.SectorNumber, labels map[string]string) error {
+	for k := range labels {
+		if k == "" {
+			return xerrors.Errorf("label keys can't be empty")
+		}
+	}
+
+	return m.sectors.Send(uint64(sid), SectorSetLabels{Labels: labels})
+}
+
 func (m *Sealing) Terminate(ctx context.Context, sid abi.SectorNumber) error {
 	return m.sectors.Send(uint64(sid), SectorTerminate{})
 }
@@ -220,6 +235,22 @@ func (m *Sealing) CommitPending(ctx context.Context) ([]abi.SectorID, error) {
 	return m.commiter.Pending(ctx)
 }
 
+func (m *Sealing) PreCommitBatchStatus(ctx context.Context) (sealiface.BatchStatus, error) {
+	return m.precommiter.Status(ctx)
+}
+
+func (m *Sealing) CommitBatchStatus(ctx context.Context) (sealiface.BatchStatus, error) {
+	return m.commiter.Status(ctx)
+}
+
+// ReloadConfig makes the batchers pick up changes of the sealing config which
+// they don't read for each batch
+func (m *Sealing) ReloadConfig() {
+	m.terminator.ReloadConfig()
+	m.precommiter.ReloadConfig()
+	m.commiter.ReloadConfig()
+}
+
 func (m *Sealing) currentSealProof(ctx context.Context) (abi.RegisteredSealProof, error) {
 	mi, err := m.api.StateMinerInfo(ctx, m.maddr, nil)
 	if err != nil {
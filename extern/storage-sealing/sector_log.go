@@ -0,0 +1,187 @@
+package sealing
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	statemachine "github.com/filecoin-project/go-statemachine"
+
+	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
+)
+
+// SectorLogPrefix holds the persisted event history of each sector. Unlike the
+// log kept in SectorInfo, it is never truncated, and only records what
+// matters to follow a sector: state transitions, messages sent and errors.
+const SectorLogPrefix = "/storage/sectorlog"
+
+type SectorEventKind string
+
+const (
+	SectorEventState   SectorEventKind = "state"
+	SectorEventMessage SectorEventKind = "message"
+	SectorEventError   SectorEventKind = "error"
+)
+
+type SectorEvent struct {
+	Time time.Time
+	Kind SectorEventKind
+
+	// Event is the state machine event the entry was recorded for
+	Event string
+
+	// state transitions
+	From SectorState `json:",omitempty"`
+	To   SectorState `json:",omitempty"`
+
+	// messages, MessageType is e.g. PreCommit or Commit
+	MessageType string   `json:",omitempty"`
+	Message     *cid.Cid `json:",omitempty"`
+
+	Error     string              `json:",omitempty"`
+	ErrorCode sealiface.ErrorCode `json:",omitempty"`
+}
+
+func sectorLogKey(sid abi.SectorNumber) datastore.Key {
+	return datastore.NewKey(SectorLogPrefix).ChildString(fmt.Sprint(sid))
+}
+
+// SectorLog returns the event history of the sector, oldest first.
+func (m *Sealing) SectorLog(sid abi.SectorNumber) ([]SectorEvent, error) {
+	// the trailing slash keeps sector 1 from matching sector 10
+	res, err := m.ds.Query(query.Query{Prefix: sectorLogKey(sid).String() + "/"})
+	if err != nil {
+		return nil, xerrors.Errorf("querying sector log: %w", err)
+	}
+	defer res.Close() // nolint
+
+	type keyed struct {
+		key string
+		evt SectorEvent
+	}
+	var entries []keyed
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("reading sector log: %w", r.Error)
+		}
+
+		var evt SectorEvent
+		if err := json.Unmarshal(r.Value, &evt); err != nil {
+			return nil, xerrors.Errorf("decoding sector log entry %s: %w", r.Key, err)
+		}
+		entries = append(entries, keyed{key: r.Key, evt: evt})
+	}
+
+	// keys are zero-padded and ordered by the time entries were recorded
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+
+	out := make([]SectorEvent, len(entries))
+	for i, e := range entries {
+		out[i] = e.evt
+	}
+	return out, nil
+}
+
+// recordSectorLog persists the history entries for the events processed by
+// the planner, given the sector state before and after processing them.
+func (m *Sealing) recordSectorLog(events []statemachine.Event, before, after *SectorInfo) {
+	if m.ds == nil {
+		return // tests
+	}
+
+	entries := sectorLogEntries(events, before, after, time.Now())
+	if len(entries) == 0 {
+		return
+	}
+
+	b, err := m.ds.Batch()
+	if err != nil {
+		log.Errorw("recording sector log", "sector", after.SectorNumber, "error", err)
+		return
+	}
+
+	base := sectorLogKey(after.SectorNumber)
+	for i, e := range entries {
+		v, err := json.Marshal(e)
+		if err != nil {
+			log.Errorw("encoding sector log entry", "sector", after.SectorNumber, "error", err)
+			return
+		}
+
+		k := base.ChildString(fmt.Sprintf("%020d-%03d", e.Time.UnixNano(), i))
+		if err := b.Put(k, v); err != nil {
+			log.Errorw("recording sector log", "sector", after.SectorNumber, "error", err)
+			return
+		}
+	}
+
+	if err := b.Commit(); err != nil {
+		log.Errorw("recording sector log", "sector", after.SectorNumber, "error", err)
+	}
+}
+
+func sectorLogEntries(events []statemachine.Event, before, after *SectorInfo, now time.Time) []SectorEvent {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var out []SectorEvent
+	for _, event := range events {
+		if err, iserr := event.User.(error); iserr {
+			out = append(out, SectorEvent{
+				Time:  now,
+				Kind:  SectorEventError,
+				Event: eventName(event.User),
+				Error: err.Error(),
+				// events don't unwrap to the error they hold
+				ErrorCode: sealiface.ParseErrorCode(err.Error()),
+			})
+		}
+	}
+
+	// the planner applies events in order, attribute the changes to the last
+	// one processed
+	last := eventName(events[len(events)-1].User)
+
+	for _, msg := range []struct {
+		typ           string
+		before, after *cid.Cid
+	}{
+		{"PreCommit", before.PreCommitMessage, after.PreCommitMessage},
+		{"Commit", before.CommitMessage, after.CommitMessage},
+		{"FaultReport", before.FaultReportMsg, after.FaultReportMsg},
+		{"Terminate", before.TerminateMessage, after.TerminateMessage},
+	} {
+		if msg.after == nil || (msg.before != nil && *msg.before == *msg.after) {
+			continue
+		}
+		out = append(out, SectorEvent{
+			Time:        now,
+			Kind:        SectorEventMessage,
+			Event:       last,
+			MessageType: msg.typ,
+			Message:     msg.after,
+		})
+	}
+
+	if before.State != after.State {
+		out = append(out, SectorEvent{
+			Time:  now,
+			Kind:  SectorEventState,
+			Event: last,
+			From:  before.State,
+			To:    after.State,
+		})
+	}
+
+	return out
+}
@@ -0,0 +1,59 @@
+package sealing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	statemachine "github.com/filecoin-project/go-statemachine"
+)
+
+func TestSectorLogEntries(t *testing.T) {
+	msg, err := cid.Parse("bafy2bzacea3wsdh6y3a36tb3skempjoxqpuyompjbmfeyf34fi3uy6uue42v4")
+	require.NoError(t, err)
+
+	now := time.Unix(1600000000, 0)
+	before := &SectorInfo{SectorNumber: 1, State: PreCommitting}
+	after := &SectorInfo{SectorNumber: 1, State: PreCommitWait, PreCommitMessage: &msg}
+
+	entries := sectorLogEntries([]statemachine.Event{{User: SectorPreCommitted{Message: msg}}}, before, after, now)
+	require.Len(t, entries, 2)
+	require.Equal(t, SectorEventMessage, entries[0].Kind)
+	require.Equal(t, "PreCommit", entries[0].MessageType)
+	require.Equal(t, &msg, entries[0].Message)
+	require.Equal(t, SectorEvent{Time: now, Kind: SectorEventState, Event: "SectorPreCommitted", From: PreCommitting, To: PreCommitWait}, entries[1])
+
+	// unchanged messages aren't recorded again
+	entries = sectorLogEntries([]statemachine.Event{{User: SectorRestart{}}}, after, after, now)
+	require.Empty(t, entries)
+
+	failed := &SectorInfo{SectorNumber: 1, State: PreCommitFailed, PreCommitMessage: &msg}
+	entries = sectorLogEntries([]statemachine.Event{{User: SectorChainPreCommitFailed{xerrors.New("boom")}}}, after, failed, now)
+	require.Len(t, entries, 2)
+	require.Equal(t, SectorEventError, entries[0].Kind)
+	require.Equal(t, "boom", entries[0].Error)
+	require.Equal(t, PreCommitFailed, entries[1].To)
+}
+
+func TestSectorLog(t *testing.T) {
+	m := &Sealing{ds: dssync.MutexWrap(datastore.NewMapDatastore())}
+
+	m.recordSectorLog([]statemachine.Event{{User: SectorStartCC{}}}, &SectorInfo{SectorNumber: 1}, &SectorInfo{SectorNumber: 1, State: Packing})
+	m.recordSectorLog([]statemachine.Event{{User: SectorPacked{}}}, &SectorInfo{SectorNumber: 1, State: Packing}, &SectorInfo{SectorNumber: 1, State: GetTicket})
+	m.recordSectorLog([]statemachine.Event{{User: SectorStartCC{}}}, &SectorInfo{SectorNumber: 10}, &SectorInfo{SectorNumber: 10, State: Packing})
+
+	entries, err := m.SectorLog(1)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, Packing, entries[0].To)
+	require.Equal(t, GetTicket, entries[1].To)
+
+	entries, err = m.SectorLog(2)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
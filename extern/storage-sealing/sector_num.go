@@ -0,0 +1,253 @@
+package sealing
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// SectorNumReservationsKey holds the sector numbers reserved by name, e.g. for
+// another sealing cluster sealing sectors of the same miner
+var SectorNumReservationsKey = datastore.NewKey("/storage/sectornum/reservations")
+
+// NumReservations returns the sector number reservations by name
+func (m *Sealing) NumReservations() (map[string]bitfield.BitField, error) {
+	m.inputLk.Lock()
+	defer m.inputLk.Unlock()
+
+	return m.numReservations()
+}
+
+// NumReserve reserves the sector numbers under the given name, so that they
+// aren't assigned to new sectors. Unless forced, the numbers must not be
+// reserved under another name, or used by sectors of this miner.
+func (m *Sealing) NumReserve(name string, numbers bitfield.BitField, force bool) error {
+	m.inputLk.Lock()
+	defer m.inputLk.Unlock()
+
+	if name == "" {
+		return xerrors.Errorf("reservation name can't be empty")
+	}
+
+	res, err := m.numReservations()
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		for other, bf := range res {
+			if other == name {
+				continue
+			}
+
+			overlap, err := bitfield.IntersectBitField(bf, numbers)
+			if err != nil {
+				return xerrors.Errorf("intersecting with reservation %s: %w", other, err)
+			}
+			if empty, err := overlap.IsEmpty(); err != nil {
+				return err
+			} else if !empty {
+				return xerrors.Errorf("sector numbers already reserved under %q", other)
+			}
+		}
+
+		sectors, err := m.ListSectors()
+		if err != nil {
+			return xerrors.Errorf("listing sectors: %w", err)
+		}
+		for _, s := range sectors {
+			used, err := numbers.IsSet(uint64(s.SectorNumber))
+			if err != nil {
+				return err
+			}
+			if used {
+				return xerrors.Errorf("sector number %d is used by a sector of this miner", s.SectorNumber)
+			}
+		}
+	}
+
+	if prev, ok := res[name]; ok {
+		numbers, err = bitfield.MergeBitFields(prev, numbers)
+		if err != nil {
+			return xerrors.Errorf("merging with existing reservation: %w", err)
+		}
+	}
+	res[name] = numbers
+
+	return m.putNumReservations(res)
+}
+
+// NumFree drops the sector number reservation with the given name
+func (m *Sealing) NumFree(name string) error {
+	m.inputLk.Lock()
+	defer m.inputLk.Unlock()
+
+	res, err := m.numReservations()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := res[name]; !ok {
+		return xerrors.Errorf("no sector number reservation named %q", name)
+	}
+	delete(res, name)
+
+	return m.putNumReservations(res)
+}
+
+// call with m.inputLk
+func (m *Sealing) numReservations() (map[string]bitfield.BitField, error) {
+	out := map[string]bitfield.BitField{}
+
+	b, err := m.ds.Get(SectorNumReservationsKey)
+	switch {
+	case err == datastore.ErrNotFound:
+		return out, nil
+	case err != nil:
+		return nil, xerrors.Errorf("getting sector number reservations: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, xerrors.Errorf("decoding sector number reservations: %w", err)
+	}
+	return out, nil
+}
+
+// call with m.inputLk
+func (m *Sealing) putNumReservations(res map[string]bitfield.BitField) error {
+	b, err := json.Marshal(res)
+	if err != nil {
+		return xerrors.Errorf("encoding sector number reservations: %w", err)
+	}
+
+	if err := m.ds.Put(SectorNumReservationsKey, b); err != nil {
+		return xerrors.Errorf("storing sector number reservations: %w", err)
+	}
+	return nil
+}
+
+// nextSectorNumber assigns the next sector number which isn't reserved, or
+// already allocated on chain, e.g. because the counter went back with a
+// datastore restore
+//
+// call with m.inputLk
+func (m *Sealing) nextSectorNumber(ctx context.Context) (abi.SectorNumber, error) {
+	res, err := m.numReservations()
+	if err != nil {
+		return 0, err
+	}
+
+	reserved := make([]bitfield.BitField, 0, len(res))
+	for _, bf := range res {
+		reserved = append(reserved, bf)
+	}
+	all, err := bitfield.MultiMerge(reserved...)
+	if err != nil {
+		return 0, xerrors.Errorf("merging sector number reservations: %w", err)
+	}
+
+	sid, err := m.sc.Next()
+	if err != nil {
+		return 0, err
+	}
+
+	allocated := func(n uint64) (bool, error) {
+		return m.api.StateMinerSectorAllocated(ctx, m.maddr, abi.SectorNumber(n), nil)
+	}
+
+	free := uint64(sid)
+	for {
+		unreserved, err := nextFreeNumber(all, free)
+		if err != nil {
+			return 0, err
+		}
+		free, err = firstUnallocated(unreserved, allocated)
+		if err != nil {
+			return 0, xerrors.Errorf("checking on-chain sector number allocation: %w", err)
+		}
+		if free == unreserved {
+			break
+		}
+		log.Warnw("skipping sector numbers allocated on chain", "from", unreserved, "to", free)
+	}
+	if free == uint64(sid) {
+		return sid, nil
+	}
+
+	log.Infow("skipping unavailable sector numbers", "from", sid, "to", free)
+
+	if err := m.sc.SkipTo(abi.SectorNumber(free)); err != nil {
+		return 0, xerrors.Errorf("skipping unavailable sector numbers: %w", err)
+	}
+	return m.sc.Next()
+}
+
+// firstUnallocated returns the first number from n which isn't allocated.
+// Numbers allocated ahead of the counter are expected to form a run, so the
+// end of the run is found with an exponential search.
+func firstUnallocated(n uint64, allocated func(uint64) (bool, error)) (uint64, error) {
+	if a, err := allocated(n); err != nil || !a {
+		return n, err
+	}
+
+	// lo is allocated, hi isn't
+	lo, step := n, uint64(1)
+	hi := n + step
+	for {
+		a, err := allocated(hi)
+		if err != nil {
+			return 0, err
+		}
+		if !a {
+			break
+		}
+		lo = hi
+		step *= 2
+		hi = n + step
+	}
+
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		a, err := allocated(mid)
+		if err != nil {
+			return 0, err
+		}
+		if a {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return hi, nil
+}
+
+// nextFreeNumber returns the first number from n which isn't set in reserved
+func nextFreeNumber(reserved bitfield.BitField, n uint64) (uint64, error) {
+	rit, err := reserved.RunIterator()
+	if err != nil {
+		return 0, err
+	}
+
+	var pos uint64
+	for rit.HasNext() && pos <= n {
+		r, err := rit.NextRun()
+		if err != nil {
+			return 0, err
+		}
+
+		// set and unset runs alternate, the number following a set run is
+		// free
+		if r.Val && n < pos+r.Len {
+			return pos + r.Len, nil
+		}
+		pos += r.Len
+	}
+
+	return n, nil
+}
@@ -0,0 +1,62 @@
+package sealing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-bitfield"
+)
+
+func TestNextFreeNumber(t *testing.T) {
+	reserved := bitfield.NewFromSet([]uint64{3, 4, 5, 8})
+
+	for n, exp := range map[uint64]uint64{
+		0:  0,
+		2:  2,
+		3:  6,
+		5:  6,
+		6:  6,
+		8:  9,
+		20: 20,
+	} {
+		free, err := nextFreeNumber(reserved, n)
+		require.NoError(t, err)
+		require.Equal(t, exp, free, "from %d", n)
+	}
+
+	free, err := nextFreeNumber(bitfield.New(), 7)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), free)
+}
+
+func TestFirstUnallocated(t *testing.T) {
+	onChain := bitfield.NewFromSet([]uint64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 20})
+
+	allocated := func(n uint64) (bool, error) {
+		return onChain.IsSet(n)
+	}
+
+	for n, exp := range map[uint64]uint64{
+		0:  13,
+		5:  13,
+		12: 13,
+		13: 13,
+		20: 21,
+		30: 30,
+	} {
+		free, err := firstUnallocated(n, allocated)
+		require.NoError(t, err)
+		require.Equal(t, exp, free, "from %d", n)
+	}
+
+	// a long run is skipped with few lookups
+	var calls int
+	free, err := firstUnallocated(0, func(n uint64) (bool, error) {
+		calls++
+		return n < 100000, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(100000), free)
+	require.Less(t, calls, 40)
+}
@@ -17,6 +17,7 @@ var ExistSectorStateList = map[SectorState]struct{}{
 	PreCommitBatchWait:    {},
 	WaitSeed:              {},
 	Committing:            {},
+	RegenerateProof:       {},
 	CommitFinalize:        {},
 	CommitFinalizeFailed:  {},
 	SubmitCommit:          {},
@@ -69,6 +70,7 @@ const (
 	Committing           SectorState = "Committing"     // compute PoRep
 	CommitFinalize       SectorState = "CommitFinalize" // cleanup sector metadata before submitting the proof (early finalize)
 	CommitFinalizeFailed SectorState = "CommitFinalizeFailed"
+	RegenerateProof      SectorState = "RegenerateProof" // recompute PoRep after a proof-related commit failure
 
 	// single commit
 	SubmitCommit SectorState = "SubmitCommit" // send commit message to the chain
@@ -106,11 +108,17 @@ const (
 	Removed      SectorState = "Removed"
 )
 
+// IsFailedState returns whether st is one of the sealing or proving failure
+// states, which need either automatic retries or operator intervention.
+func IsFailedState(st SectorState) bool {
+	return toStatState(st) == sstFailed
+}
+
 func toStatState(st SectorState) statSectorState {
 	switch st {
 	case UndefinedSectorState, Empty, WaitDeals, AddPiece:
 		return sstStaging
-	case Packing, GetTicket, PreCommit1, PreCommit2, PreCommitting, PreCommitWait, SubmitPreCommitBatch, PreCommitBatchWait, WaitSeed, Committing, CommitFinalize, SubmitCommit, CommitWait, SubmitCommitAggregate, CommitAggregateWait, FinalizeSector:
+	case Packing, GetTicket, PreCommit1, PreCommit2, PreCommitting, PreCommitWait, SubmitPreCommitBatch, PreCommitBatchWait, WaitSeed, Committing, RegenerateProof, CommitFinalize, SubmitCommit, CommitWait, SubmitCommitAggregate, CommitAggregateWait, FinalizeSector:
 		return sstSealing
 	case Proving, Removed, Removing, Terminating, TerminateWait, TerminateFinality, TerminateFailed:
 		return sstProving
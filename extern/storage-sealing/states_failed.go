@@ -50,6 +50,26 @@ func (m *Sealing) checkPreCommitted(ctx statemachine.Context, sector SectorInfo)
 	return info, true
 }
 
+// handleNumberAllocated reconciles a sector whose number is allocated on chain
+// without a precommit. A sector committed by a previous run, whose state was
+// lost e.g. in a datastore restore, moves on to finalization; otherwise the
+// number is used by another sector, and nothing is sent.
+func (m *Sealing) handleNumberAllocated(ctx statemachine.Context, sector SectorInfo, tok TipSetToken) error {
+	onChain, err := m.api.StateSectorGetInfo(ctx.Context(), m.maddr, sector.SectorNumber, tok)
+	if err != nil {
+		log.Errorf("sector %d: getting on-chain sector info, not proceeding: %+v", sector.SectorNumber, err)
+		return nil
+	}
+
+	if onChain != nil && sector.CommR != nil && onChain.SealedCID == *sector.CommR {
+		log.Warnf("sector %d is already committed on chain, finalizing", sector.SectorNumber)
+		return ctx.Send(SectorProving{})
+	}
+
+	log.Errorf("sector %d: the sector number is used on chain by a different sector, not precommitting; the sector needs to be removed", sector.SectorNumber)
+	return nil
+}
+
 func (m *Sealing) handleSealPrecommit1Failed(ctx statemachine.Context, sector SectorInfo) error {
 	if err := failedCooldown(ctx, sector); err != nil {
 		return err
@@ -126,9 +146,9 @@ func (m *Sealing) handlePreCommitFailed(ctx statemachine.Context, sector SectorI
 		case *ErrPrecommitOnChain:
 			// noop
 		case *ErrSectorNumberAllocated:
-			log.Errorf("handlePreCommitFailed: sector number already allocated, not proceeding: %+v", err)
-			// TODO: check if the sector is committed (not sure how we'd end up here)
-			// TODO: check on-chain state, adjust local sector number counter to not give out allocated numbers
+			return m.handleNumberAllocated(ctx, sector, tok)
+		case *ErrSectorNumberCollision:
+			log.Errorf("handlePreCommitFailed: sector %d can't be precommitted, it needs to be removed: %+v", sector.SectorNumber, err)
 			return nil
 		default:
 			return xerrors.Errorf("checkPrecommit sanity check error: %w", err)
@@ -238,6 +258,8 @@ func (m *Sealing) handleCommitFailed(ctx statemachine.Context, sector SectorInfo
 			// noop, this is expected
 		case *ErrSectorNumberAllocated:
 			// noop, already committed?
+		case *ErrSectorNumberCollision:
+			return ctx.Send(SectorChainPreCommitFailed{err})
 		default:
 			return xerrors.Errorf("checkPrecommit sanity check error (%T): %w", err, err)
 		}
@@ -20,6 +20,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/actors"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
 )
 
 var DealSectorPriority = 1024
@@ -252,15 +253,16 @@ func (m *Sealing) preCommitParams(ctx statemachine.Context, sector SectorInfo) (
 		case *ErrPrecommitOnChain:
 			return nil, big.Zero(), nil, ctx.Send(SectorPreCommitLanded{TipSet: tok}) // we re-did precommit
 		case *ErrSectorNumberAllocated:
-			log.Errorf("handlePreCommitFailed: sector number already allocated, not proceeding: %+v", err)
-			// TODO: check if the sector is committed (not sure how we'd end up here)
-			return nil, big.Zero(), nil, nil
+			return nil, big.Zero(), nil, m.handleNumberAllocated(ctx, sector, tok)
+		case *ErrSectorNumberCollision:
+			// don't burn a precommit deposit on a message which can't land
+			return nil, big.Zero(), nil, ctx.Send(SectorChainPreCommitFailed{err})
 		default:
 			return nil, big.Zero(), nil, xerrors.Errorf("checkPrecommit sanity check error: %w", err)
 		}
 	}
 
-	expiration, err := m.pcp.Expiration(ctx.Context(), sector.Pieces...)
+	expiration, err := m.pcp.Expiration(ctx.Context(), sector.SectorNumber, sector.Pieces...)
 	if err != nil {
 		return nil, big.Zero(), nil, ctx.Send(SectorSealPreCommit1Failed{xerrors.Errorf("handlePreCommitting: failed to compute pre-commit expiry: %w", err)})
 	}
@@ -334,21 +336,28 @@ func (m *Sealing) handlePreCommitting(ctx statemachine.Context, sector SectorInf
 		return nil
 	}
 
-	goodFunds := big.Add(deposit, big.Int(m.feeCfg.MaxPreCommitGasFee))
+	feeCfg, err := m.getFeeCfg()
+	if err != nil {
+		return xerrors.Errorf("getting fee config: %w", err)
+	}
+
+	goodFunds := big.Add(deposit, big.Int(feeCfg.MaxPreCommitGasFee))
 
 	from, _, err := m.addrSel(ctx.Context(), mi, api.PreCommitAddr, goodFunds, deposit)
 	if err != nil {
-		return ctx.Send(SectorChainPreCommitFailed{xerrors.Errorf("no good address to send precommit message from: %w", err)})
+		return ctx.Send(SectorChainPreCommitFailed{sealiface.WithCode(sealiface.ErrInsufficientFunds, xerrors.Errorf("no good address to send precommit message from: %w", err))})
 	}
 
 	log.Infof("submitting precommit for sector %d (deposit: %s): ", sector.SectorNumber, deposit)
-	mcid, err := m.api.SendMsg(ctx.Context(), from, m.maddr, miner.Methods.PreCommitSector, deposit, big.Int(m.feeCfg.MaxPreCommitGasFee), enc.Bytes())
+	mcid, err := m.api.SendMsg(ctx.Context(), from, m.maddr, miner.Methods.PreCommitSector, deposit, big.Int(feeCfg.MaxPreCommitGasFee), enc.Bytes())
 	if err != nil {
+		recordMsgFailed(ctx.Context(), msgTypePreCommit, "send")
 		if params.ReplaceCapacity {
 			m.remarkForUpgrade(params.ReplaceSectorNumber)
 		}
-		return ctx.Send(SectorChainPreCommitFailed{xerrors.Errorf("pushing message to mpool: %w", err)})
+		return ctx.Send(SectorChainPreCommitFailed{sealiface.WithCode(sealiface.ErrMessageSend, xerrors.Errorf("pushing message to mpool: %w", err))})
 	}
+	recordMsgSent(ctx.Context(), msgTypePreCommit)
 
 	return ctx.Send(SectorPreCommitted{Message: mcid, PreCommitDeposit: deposit, PreCommitInfo: *params})
 }
@@ -400,6 +409,11 @@ func (m *Sealing) handlePreCommitWait(ctx statemachine.Context, sector SectorInf
 		// gas estimator guessed a wrong number / out of funds:
 		return ctx.Send(SectorRetryPreCommit{})
 	default:
+		if sector.State == PreCommitBatchWait {
+			recordMsgFailed(ctx.Context(), msgTypePreCommitBatch, "exec")
+		} else {
+			recordMsgFailed(ctx.Context(), msgTypePreCommit, "exec")
+		}
 		log.Error("sector precommit failed: ", mw.Receipt.ExitCode)
 		err := xerrors.Errorf("sector precommit failed: %d", mw.Receipt.ExitCode)
 		return ctx.Send(SectorChainPreCommitFailed{err})
@@ -478,6 +492,12 @@ func (m *Sealing) handleCommitting(ctx statemachine.Context, sector SectorInfo)
 		}
 	}
 
+	return m.computeProof(sector.sealingCtx(ctx.Context()), ctx, sector)
+}
+
+// computeProof runs the commit phases of the sector with the scheduling
+// context sctx, and sends the resulting proof to the state machine
+func (m *Sealing) computeProof(sctx context.Context, ctx statemachine.Context, sector SectorInfo) error {
 	cfg, err := m.getConfig()
 	if err != nil {
 		return xerrors.Errorf("getting config: %w", err)
@@ -495,12 +515,12 @@ func (m *Sealing) handleCommitting(ctx statemachine.Context, sector SectorInfo)
 		Unsealed: *sector.CommD,
 		Sealed:   *sector.CommR,
 	}
-	c2in, err := m.sealer.SealCommit1(sector.sealingCtx(ctx.Context()), m.minerSector(sector.SectorType, sector.SectorNumber), sector.TicketValue, sector.SeedValue, sector.pieceInfos(), cids)
+	c2in, err := m.sealer.SealCommit1(sctx, m.minerSector(sector.SectorType, sector.SectorNumber), sector.TicketValue, sector.SeedValue, sector.pieceInfos(), cids)
 	if err != nil {
 		return ctx.Send(SectorComputeProofFailed{xerrors.Errorf("computing seal proof failed(1): %w", err)})
 	}
 
-	proof, err := m.sealer.SealCommit2(sector.sealingCtx(ctx.Context()), m.minerSector(sector.SectorType, sector.SectorNumber), c2in)
+	proof, err := m.sealer.SealCommit2(sctx, m.minerSector(sector.SectorType, sector.SectorNumber), c2in)
 	if err != nil {
 		return ctx.Send(SectorComputeProofFailed{xerrors.Errorf("computing seal proof failed(2): %w", err)})
 	}
@@ -589,18 +609,25 @@ func (m *Sealing) handleSubmitCommit(ctx statemachine.Context, sector SectorInfo
 		collateral = big.Zero()
 	}
 
-	goodFunds := big.Add(collateral, big.Int(m.feeCfg.MaxCommitGasFee))
+	feeCfg, err := m.getFeeCfg()
+	if err != nil {
+		return xerrors.Errorf("getting fee config: %w", err)
+	}
+
+	goodFunds := big.Add(collateral, big.Int(feeCfg.MaxCommitGasFee))
 
 	from, _, err := m.addrSel(ctx.Context(), mi, api.CommitAddr, goodFunds, collateral)
 	if err != nil {
-		return ctx.Send(SectorCommitFailed{xerrors.Errorf("no good address to send commit message from: %w", err)})
+		return ctx.Send(SectorCommitFailed{sealiface.WithCode(sealiface.ErrInsufficientFunds, xerrors.Errorf("no good address to send commit message from: %w", err))})
 	}
 
 	// TODO: check seed / ticket / deals are up to date
-	mcid, err := m.api.SendMsg(ctx.Context(), from, m.maddr, miner.Methods.ProveCommitSector, collateral, big.Int(m.feeCfg.MaxCommitGasFee), enc.Bytes())
+	mcid, err := m.api.SendMsg(ctx.Context(), from, m.maddr, miner.Methods.ProveCommitSector, collateral, big.Int(feeCfg.MaxCommitGasFee), enc.Bytes())
 	if err != nil {
-		return ctx.Send(SectorCommitFailed{xerrors.Errorf("pushing message to mpool: %w", err)})
+		recordMsgFailed(ctx.Context(), msgTypeCommit, "send")
+		return ctx.Send(SectorCommitFailed{sealiface.WithCode(sealiface.ErrMessageSend, xerrors.Errorf("pushing message to mpool: %w", err))})
 	}
+	recordMsgSent(ctx.Context(), msgTypeCommit)
 
 	return ctx.Send(SectorCommitSubmitted{
 		Message: mcid,
@@ -662,6 +689,7 @@ func (m *Sealing) handleCommitWait(ctx statemachine.Context, sector SectorInfo)
 		// gas estimator guessed a wrong number / out of funds
 		return ctx.Send(SectorRetrySubmitCommit{})
 	default:
+		recordMsgFailed(ctx.Context(), m.commitMsgType(ctx.Context(), *sector.CommitMessage), "exec")
 		return ctx.Send(SectorCommitFailed{xerrors.Errorf("submitting sector proof failed (exit=%d, msg=%s) (t:%x; s:%x(%d); p:%x)", mw.Receipt.ExitCode, sector.CommitMessage, sector.TicketValue, sector.SeedValue, sector.SeedEpoch, sector.Proof)})
 	}
 
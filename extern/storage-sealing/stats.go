@@ -1,10 +1,16 @@
 package sealing
 
 import (
+	"context"
 	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
 
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
+	"github.com/filecoin-project/lotus/metrics"
 )
 
 type statSectorState int
@@ -22,6 +28,33 @@ type SectorStats struct {
 
 	bySector map[abi.SectorID]statSectorState
 	totals   [nsst]uint64
+
+	// fine-grained state tracking, exported as metrics
+	states  map[abi.SectorID]sectorStateEntry
+	byState map[SectorState]int64
+
+	// time sectors spent in each state, and when sectors started proving,
+	// used to project sealing ETAs and throughput
+	started     time.Time
+	durations   map[SectorState]stateDuration
+	completions []time.Time
+}
+
+// weight of the latest sample in the moving average of state durations
+const stateDurationWeight = 0.2
+
+type stateDuration struct {
+	avg     time.Duration
+	samples int
+}
+
+type sectorStateEntry struct {
+	state SectorState
+	since time.Time
+
+	// set when the sector was first seen in this state (e.g. after a restart),
+	// meaning we don't know when it actually entered it
+	partial bool
 }
 
 func (ss *SectorStats) updateSector(cfg sealiface.Config, id abi.SectorID, st SectorState) (updateInput bool) {
@@ -41,6 +74,8 @@ func (ss *SectorStats) updateSector(cfg sealiface.Config, id abi.SectorID, st Se
 	ss.bySector[id] = sst
 	ss.totals[sst]++
 
+	ss.updateStateMetricsLocked(id, st)
+
 	// check if we may need be able to process more deals
 	sealing := ss.curSealingLocked()
 	staging := ss.curStagingLocked()
@@ -62,6 +97,81 @@ func (ss *SectorStats) updateSector(cfg sealiface.Config, id abi.SectorID, st Se
 	return updateInput
 }
 
+func (ss *SectorStats) updateStateMetricsLocked(id abi.SectorID, st SectorState) {
+	if ss.states == nil {
+		ss.states = map[abi.SectorID]sectorStateEntry{}
+		ss.byState = map[SectorState]int64{}
+	}
+
+	now := time.Now()
+	ctx := context.TODO()
+
+	prev, found := ss.states[id]
+	if found {
+		if prev.state == st {
+			return
+		}
+
+		ss.byState[prev.state]--
+		recordSectorsInState(ctx, prev.state, ss.byState[prev.state])
+
+		if !prev.partial {
+			_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(metrics.SectorState, string(prev.state))},
+				metrics.SealingStateDuration.M(now.Sub(prev.since).Seconds()))
+
+			ss.recordDurationLocked(prev.state, now.Sub(prev.since))
+		}
+
+		if st == Proving && toStatState(prev.state) == sstSealing {
+			ss.recordCompletionLocked(now)
+		}
+	}
+
+	// sectors are tracked until they are sealed or removed, so that entries
+	// don't pile up over the lifetime of the miner. Sectors leaving Proving
+	// again (e.g. on termination) start a new, partial entry.
+	if st == Proving || st == Removed {
+		delete(ss.states, id)
+		return
+	}
+
+	ss.states[id] = sectorStateEntry{
+		state:   st,
+		since:   now,
+		partial: !found && st != UndefinedSectorState,
+	}
+	ss.byState[st]++
+	recordSectorsInState(ctx, st, ss.byState[st])
+}
+
+func (ss *SectorStats) recordDurationLocked(st SectorState, d time.Duration) {
+	if ss.durations == nil {
+		ss.durations = map[SectorState]stateDuration{}
+	}
+
+	sd := ss.durations[st]
+	if sd.samples == 0 {
+		sd.avg = d
+	} else {
+		sd.avg = time.Duration(stateDurationWeight*float64(d) + (1-stateDurationWeight)*float64(sd.avg))
+	}
+	sd.samples++
+	ss.durations[st] = sd
+}
+
+func (ss *SectorStats) recordCompletionLocked(now time.Time) {
+	ss.completions = append(ss.completions, now)
+
+	cutoff := now.Add(-24 * time.Hour)
+	for len(ss.completions) > 0 && ss.completions[0].Before(cutoff) {
+		ss.completions = ss.completions[1:]
+	}
+}
+
+func recordSectorsInState(ctx context.Context, st SectorState, n int64) {
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(metrics.SectorState, string(st))}, metrics.SealingSectorsInState.M(n))
+}
+
 func (ss *SectorStats) curSealingLocked() uint64 {
 	return ss.totals[sstStaging] + ss.totals[sstSealing] + ss.totals[sstFailed]
 }
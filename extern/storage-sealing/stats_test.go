@@ -0,0 +1,211 @@
+package sealing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// viewRows registers the views for the duration of the test, and returns a
+// function reading the rows of one of them
+func viewRows(t *testing.T, views ...*view.View) func(v *view.View) []*view.Row {
+	require.NoError(t, view.Register(views...))
+	t.Cleanup(func() { view.Unregister(views...) })
+
+	return func(v *view.View) []*view.Row {
+		rows, err := view.RetrieveData(v.Name)
+		require.NoError(t, err)
+		return rows
+	}
+}
+
+// rowFor returns the row of the view with the given tags
+func rowFor(rows []*view.Row, tags ...tag.Tag) *view.Row {
+	for _, r := range rows {
+		if len(r.Tags) == len(tags) && hasTags(r.Tags, tags) {
+			return r
+		}
+	}
+	return nil
+}
+
+func hasTags(have, want []tag.Tag) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h == w {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSectorStatsStates(t *testing.T) {
+	rows := viewRows(t, metrics.SealingSectorsInStateView)
+	inState := func(st SectorState) float64 {
+		r := rowFor(rows(metrics.SealingSectorsInStateView), tag.Tag{Key: metrics.SectorState, Value: string(st)})
+		if r == nil {
+			return -1
+		}
+		return r.Data.(*view.LastValueData).Value
+	}
+
+	ss := &SectorStats{bySector: map[abi.SectorID]statSectorState{}}
+	cfg := sealiface.Config{}
+	s1 := abi.SectorID{Miner: 1000, Number: 1}
+	s2 := abi.SectorID{Miner: 1000, Number: 2}
+
+	ss.updateSector(cfg, s1, UndefinedSectorState)
+	ss.updateSector(cfg, s1, Packing)
+	ss.updateSector(cfg, s2, Packing)
+	require.Equal(t, float64(2), inState(Packing))
+	require.Len(t, ss.states, 2)
+
+	// the first update of a sector past the start of the pipeline (e.g. after
+	// a restart) doesn't tell when it entered the state
+	require.False(t, ss.states[s1].partial)
+	require.True(t, ss.states[s2].partial)
+
+	ss.updateSector(cfg, s1, PreCommit1)
+	require.Equal(t, float64(1), inState(Packing))
+	require.Equal(t, float64(1), inState(PreCommit1))
+	require.False(t, ss.states[s1].partial)
+	require.Equal(t, 1, ss.durations[Packing].samples)
+
+	// sealed sectors aren't tracked any longer
+	ss.updateSector(cfg, s1, Proving)
+	require.Equal(t, float64(0), inState(PreCommit1))
+	require.Len(t, ss.states, 1)
+	require.Len(t, ss.completions, 1)
+
+	// and neither are removed ones
+	ss.updateSector(cfg, s2, Removing)
+	require.Equal(t, float64(0), inState(Packing))
+	require.Equal(t, float64(1), inState(Removing))
+	ss.updateSector(cfg, s2, Removed)
+	require.Equal(t, float64(0), inState(Removing))
+	require.Empty(t, ss.states)
+
+	// proving sectors leaving the state are tracked again
+	ss.updateSector(cfg, s1, Terminating)
+	require.Equal(t, float64(1), inState(Terminating))
+	require.True(t, ss.states[s1].partial)
+}
+
+func TestCommitBatcherMetricsRefresh(t *testing.T) {
+	rows := viewRows(t, metrics.CommitBatcherPendingView, metrics.CommitBatcherOldestAgeView)
+	lastValue := func(v *view.View) float64 {
+		r := rowFor(rows(v))
+		if r == nil {
+			return -1
+		}
+		return r.Data.(*view.LastValueData).Value
+	}
+
+	defer func(interval time.Duration) {
+		BatcherMetricsInterval = interval
+	}(BatcherMetricsInterval)
+	BatcherMetricsInterval = 10 * time.Millisecond
+
+	b := &CommitBatcher{
+		mctx: context.Background(),
+		getConfig: func() (sealiface.Config, error) {
+			return sealiface.Config{CommitBatchWait: time.Hour}, nil
+		},
+
+		cutoffs: map[abi.SectorNumber]time.Time{1: time.Now().Add(24 * time.Hour)},
+		todo:    map[abi.SectorNumber]AggregateInput{1: {}},
+		added:   map[abi.SectorNumber]time.Time{1: time.Now().Add(-time.Hour)},
+
+		notify:  make(chan struct{}, 1),
+		reload:  make(chan struct{}, 1),
+		force:   make(chan chan []sealiface.CommitBatchRes),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go b.run()
+	defer func() {
+		close(b.stop)
+		<-b.stopped
+	}()
+
+	// nothing is added to or sent from the queue, the gauges are refreshed
+	// by the batcher anyway
+	require.Eventually(t, func() bool {
+		return lastValue(metrics.CommitBatcherPendingView) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	first := lastValue(metrics.CommitBatcherOldestAgeView)
+	require.True(t, first >= time.Hour.Seconds(), "oldest age %f", first)
+	require.Eventually(t, func() bool {
+		return lastValue(metrics.CommitBatcherOldestAgeView) > first
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+type commitMsgAPI struct {
+	SealingAPI
+	msgs map[cid.Cid]*types.Message
+}
+
+func (a *commitMsgAPI) ChainGetMessage(_ context.Context, c cid.Cid) (*types.Message, error) {
+	msg, ok := a.msgs[c]
+	if !ok {
+		return nil, xerrors.Errorf("message %s not found", c)
+	}
+	return msg, nil
+}
+
+func TestCommitFailureLabels(t *testing.T) {
+	rows := viewRows(t, metrics.SealingMessagesFailedView)
+	failures := func(msgType string) int64 {
+		r := rowFor(rows(metrics.SealingMessagesFailedView),
+			tag.Tag{Key: metrics.FailureType, Value: "exec"},
+			tag.Tag{Key: metrics.SealingMsgType, Value: msgType})
+		if r == nil {
+			return 0
+		}
+		return r.Data.(*view.CountData).Value
+	}
+
+	msgCid := func(s string) cid.Cid {
+		c, err := cid.V1Builder{Codec: cid.DagCBOR, MhType: multihash.BLAKE2B_MIN + 31}.Sum([]byte(s))
+		require.NoError(t, err)
+		return c
+	}
+	single, aggregate, unknown := msgCid("single"), msgCid("aggregate"), msgCid("unknown")
+
+	m := &Sealing{api: &commitMsgAPI{msgs: map[cid.Cid]*types.Message{
+		single:    {Method: miner.Methods.ProveCommitSector},
+		aggregate: {Method: miner.Methods.ProveCommitAggregate},
+	}}}
+
+	ctx := context.Background()
+	require.Equal(t, msgTypeCommit, m.commitMsgType(ctx, single))
+	require.Equal(t, msgTypeCommitAggregate, m.commitMsgType(ctx, aggregate))
+	// messages which can't be loaded are counted as single commits
+	require.Equal(t, msgTypeCommit, m.commitMsgType(ctx, unknown))
+
+	for _, c := range []cid.Cid{single, aggregate, aggregate} {
+		recordMsgFailed(ctx, m.commitMsgType(ctx, c), "exec")
+	}
+	require.EqualValues(t, 1, failures(msgTypeCommit))
+	require.EqualValues(t, 2, failures(msgTypeCommitAggregate))
+}
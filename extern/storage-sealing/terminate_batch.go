@@ -19,7 +19,7 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
-	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
 )
 
 type TerminateBatcherApi interface {
@@ -35,31 +35,32 @@ type TerminateBatcher struct {
 	maddr     address.Address
 	mctx      context.Context
 	addrSel   AddrSel
-	feeCfg    config.MinerFeeConfig
+	getFeeCfg GetFeeConfigFunc
 	getConfig GetSealingConfigFunc
 
 	todo map[SectorLocation]*bitfield.BitField // MinerSectorLocation -> BitField
 
 	waiting map[abi.SectorNumber][]chan cid.Cid
 
-	notify, stop, stopped chan struct{}
-	force                 chan chan *cid.Cid
-	lk                    sync.Mutex
+	notify, reload, stop, stopped chan struct{}
+	force                         chan chan *cid.Cid
+	lk                            sync.Mutex
 }
 
-func NewTerminationBatcher(mctx context.Context, maddr address.Address, api TerminateBatcherApi, addrSel AddrSel, feeCfg config.MinerFeeConfig, getConfig GetSealingConfigFunc) *TerminateBatcher {
+func NewTerminationBatcher(mctx context.Context, maddr address.Address, api TerminateBatcherApi, addrSel AddrSel, getFeeCfg GetFeeConfigFunc, getConfig GetSealingConfigFunc) *TerminateBatcher {
 	b := &TerminateBatcher{
 		api:       api,
 		maddr:     maddr,
 		mctx:      mctx,
 		addrSel:   addrSel,
-		feeCfg:    feeCfg,
+		getFeeCfg: getFeeCfg,
 		getConfig: getConfig,
 
 		todo:    map[SectorLocation]*bitfield.BitField{},
 		waiting: map[abi.SectorNumber][]chan cid.Cid{},
 
 		notify:  make(chan struct{}, 1),
+		reload:  make(chan struct{}, 1),
 		force:   make(chan chan *cid.Cid),
 		stop:    make(chan struct{}),
 		stopped: make(chan struct{}),
@@ -97,6 +98,8 @@ func (b *TerminateBatcher) run() {
 			sendAboveMin = true
 		case fr := <-b.force: // user triggered
 			forceRes = fr
+		case <-b.reload: // re-read the batch wait
+			continue
 		}
 
 		lastMsg, err = b.processBatch(sendAboveMax, sendAboveMin)
@@ -117,6 +120,10 @@ func (b *TerminateBatcher) processBatch(notif, after bool) (*cid.Cid, error) {
 		return nil, xerrors.Errorf("getting sealing config: %W", err)
 	}
 
+	if cfg.MaintenanceMode && (notif || after) {
+		return nil, nil
+	}
+
 	b.lk.Lock()
 	defer b.lk.Unlock()
 	params := miner2.TerminateSectorsParams{}
@@ -215,14 +222,19 @@ func (b *TerminateBatcher) processBatch(notif, after bool) (*cid.Cid, error) {
 		return nil, xerrors.Errorf("couldn't get miner info: %w", err)
 	}
 
-	from, _, err := b.addrSel(b.mctx, mi, api.TerminateSectorsAddr, big.Int(b.feeCfg.MaxTerminateGasFee), big.Int(b.feeCfg.MaxTerminateGasFee))
+	feeCfg, err := b.getFeeCfg()
 	if err != nil {
-		return nil, xerrors.Errorf("no good address found: %w", err)
+		return nil, xerrors.Errorf("getting fee config: %w", err)
 	}
 
-	mcid, err := b.api.SendMsg(b.mctx, from, b.maddr, miner.Methods.TerminateSectors, big.Zero(), big.Int(b.feeCfg.MaxTerminateGasFee), enc.Bytes())
+	from, _, err := b.addrSel(b.mctx, mi, api.TerminateSectorsAddr, big.Int(feeCfg.MaxTerminateGasFee), big.Int(feeCfg.MaxTerminateGasFee))
 	if err != nil {
-		return nil, xerrors.Errorf("sending message failed: %w", err)
+		return nil, sealiface.WithCode(sealiface.ErrInsufficientFunds, xerrors.Errorf("no good address found: %w", err))
+	}
+
+	mcid, err := b.api.SendMsg(b.mctx, from, b.maddr, miner.Methods.TerminateSectors, big.Zero(), big.Int(feeCfg.MaxTerminateGasFee), enc.Bytes())
+	if err != nil {
+		return nil, sealiface.WithCode(sealiface.ErrMessageSend, xerrors.Errorf("sending message failed: %w", err))
 	}
 	log.Infow("Sent TerminateSectors message", "cid", mcid, "from", from, "terminations", len(params.Terminations))
 
@@ -306,6 +318,14 @@ func (b *TerminateBatcher) AddTermination(ctx context.Context, s abi.SectorID) (
 	}
 }
 
+// ReloadConfig makes the batcher pick up changes of the batch wait
+func (b *TerminateBatcher) ReloadConfig() {
+	select {
+	case b.reload <- struct{}{}:
+	default:
+	}
+}
+
 func (b *TerminateBatcher) Flush(ctx context.Context) (*cid.Cid, error) {
 	resCh := make(chan *cid.Cid, 1)
 	select {
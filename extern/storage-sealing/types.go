@@ -14,6 +14,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	sectorstorage "github.com/filecoin-project/lotus/extern/sector-storage"
 	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
+	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/specs-actors/v2/actors/builtin/market"
 )
 
@@ -56,6 +57,11 @@ type Log struct {
 	Kind string
 }
 
+type SectorLabel struct {
+	Key   string
+	Value string
+}
+
 type ReturnState string
 
 const (
@@ -110,6 +116,9 @@ type SectorInfo struct {
 	TerminateMessage *cid.Cid
 	TerminatedAt     abi.ChainEpoch
 
+	// User-defined labels, sorted by key
+	Labels []SectorLabel
+
 	// Debug
 	LastErr string
 
@@ -195,6 +204,9 @@ func (t *SectorInfo) keepUnsealedRanges(invert, alwaysKeep bool) []storage.Range
 
 type SectorIDCounter interface {
 	Next() (abi.SectorNumber, error)
+	// SkipTo makes the following call of Next return n, which must be greater
+	// than the last returned number
+	SkipTo(n abi.SectorNumber) error
 }
 
 type TipSetToken []byte
@@ -213,6 +225,10 @@ type MessageReceipt struct {
 
 type GetSealingConfigFunc func() (sealiface.Config, error)
 
+// GetFeeConfigFunc returns the current fee limits of the messages sent by the
+// sealing subsystem. They can change while the miner is running.
+type GetFeeConfigFunc func() (config.MinerFeeConfig, error)
+
 func (mr *MessageReceipt) Equals(o *MessageReceipt) bool {
 	return mr.ExitCode == o.ExitCode && bytes.Equal(mr.Return, o.Return) && mr.GasUsed == o.GasUsed
 }
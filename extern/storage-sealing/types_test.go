@@ -59,6 +59,7 @@ func TestSectorInfoSerialization(t *testing.T) {
 		SeedEpoch:        0,
 		CommitMessage:    nil,
 		FaultReportMsg:   nil,
+		Labels:           []SectorLabel{{Key: "datacenter", Value: "fra1"}},
 		LastErr:          "hi",
 	}
 
@@ -82,4 +83,5 @@ func TestSectorInfoSerialization(t *testing.T) {
 	assert.DeepEqual(t, si.TicketValue, si2.TicketValue)
 	assert.Equal(t, si.TicketEpoch, si2.TicketEpoch)
 	assert.Equal(t, si.TicketEpoch, si2.TicketEpoch)
+	assert.DeepEqual(t, si.Labels, si2.Labels)
 }
@@ -0,0 +1,93 @@
+package gateway
+
+import (
+	"context"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+const (
+	DefaultCacheSize = 1024
+	DefaultCacheTTL  = 5 * time.Second
+)
+
+type headKey struct{}
+
+type minerInfoKey struct {
+	miner address.Address
+	tsk   types.TipSetKey
+}
+
+type cacheEntry struct {
+	val     interface{}
+	expires time.Time // zero for responses which can't change
+}
+
+// responseCache caches responses of hot read-only methods. Responses for an
+// explicit tipset never change, and are kept until evicted; responses
+// depending on the chain head expire after the configured TTL.
+type responseCache struct {
+	ttl   time.Duration
+	cache *lru.ARCCache
+}
+
+// newResponseCache returns nil, which disables caching, when size isn't
+// positive.
+func newResponseCache(size int, ttl time.Duration) *responseCache {
+	if size <= 0 {
+		return nil
+	}
+
+	c, err := lru.NewARC(size)
+	if err != nil {
+		// only returned for invalid sizes
+		panic(err)
+	}
+	return &responseCache{
+		ttl:   ttl,
+		cache: c,
+	}
+}
+
+func (rc *responseCache) get(ctx context.Context, method string, key interface{}) (interface{}, bool) {
+	if rc == nil {
+		return nil, false
+	}
+
+	v, ok := rc.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	e := v.(cacheEntry)
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		rc.cache.Remove(key)
+		return nil, false
+	}
+
+	ctx, _ = tag.New(ctx, tag.Upsert(metrics.Endpoint, method))
+	stats.Record(ctx, metrics.APICacheHit.M(1))
+	return e.val, true
+}
+
+func (rc *responseCache) put(key interface{}, val interface{}, atHead bool) {
+	if rc == nil {
+		return
+	}
+
+	e := cacheEntry{val: val}
+	if atHead {
+		if rc.ttl <= 0 {
+			return
+		}
+		e.expires = time.Now().Add(rc.ttl)
+	}
+	rc.cache.Add(key, e)
+}
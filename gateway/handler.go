@@ -14,15 +14,23 @@ import (
 )
 
 // Handler returns a gateway http.Handler, to be mounted as-is on the server.
-func Handler(a api.Gateway, opts ...jsonrpc.ServerOption) (http.Handler, error) {
+// rl may be nil to disable rate limiting.
+func Handler(a api.Gateway, rl *RateLimiter, opts ...jsonrpc.ServerOption) (http.Handler, error) {
 	m := mux.NewRouter()
 
 	serveRpc := func(path string, hnd interface{}) {
 		rpcServer := jsonrpc.NewServer(opts...)
 		rpcServer.Register("Filecoin", hnd)
+		if rl != nil {
+			m.Handle(path, rl.Handler(rpcServer))
+			return
+		}
 		m.Handle(path, rpcServer)
 	}
 
+	if rl != nil {
+		a = RateLimitedGatewayAPI(a, rl)
+	}
 	ma := metrics.MetricedGatewayAPI(a)
 
 	serveRpc("/rpc/v1", ma)
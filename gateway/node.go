@@ -77,6 +77,7 @@ type Node struct {
 	lookbackCap            time.Duration
 	stateWaitLookbackLimit abi.ChainEpoch
 	errLookback            error
+	cache                  *responseCache
 }
 
 var (
@@ -87,14 +88,29 @@ var (
 	_ full.StateModuleAPI = (*Node)(nil)
 )
 
+type NodeOption func(*Node)
+
+// WithCache enables caching of the responses of hot read-only methods
+// (ChainHead, StateMinerInfo). Responses depending on the chain head are
+// cached for ttl.
+func WithCache(size int, ttl time.Duration) NodeOption {
+	return func(gw *Node) {
+		gw.cache = newResponseCache(size, ttl)
+	}
+}
+
 // NewNode creates a new gateway node.
-func NewNode(api TargetAPI, lookbackCap time.Duration, stateWaitLookbackLimit abi.ChainEpoch) *Node {
-	return &Node{
+func NewNode(api TargetAPI, lookbackCap time.Duration, stateWaitLookbackLimit abi.ChainEpoch, opts ...NodeOption) *Node {
+	gw := &Node{
 		target:                 api,
 		lookbackCap:            lookbackCap,
 		stateWaitLookbackLimit: stateWaitLookbackLimit,
 		errLookback:            fmt.Errorf("lookbacks of more than %s are disallowed", lookbackCap),
 	}
+	for _, opt := range opts {
+		opt(gw)
+	}
+	return gw
 }
 
 func (gw *Node) checkTipsetKey(ctx context.Context, tsk types.TipSetKey) error {
@@ -149,9 +165,16 @@ func (gw *Node) ChainHasObj(ctx context.Context, c cid.Cid) (bool, error) {
 }
 
 func (gw *Node) ChainHead(ctx context.Context) (*types.TipSet, error) {
-	// TODO: cache and invalidate cache when timestamp is up (or have internal ChainNotify)
+	if v, ok := gw.cache.get(ctx, "ChainHead", headKey{}); ok {
+		return v.(*types.TipSet), nil
+	}
 
-	return gw.target.ChainHead(ctx)
+	head, err := gw.target.ChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gw.cache.put(headKey{}, head, true)
+	return head, nil
 }
 
 func (gw *Node) ChainGetMessage(ctx context.Context, mc cid.Cid) (*types.Message, error) {
@@ -363,7 +386,18 @@ func (gw *Node) StateMinerInfo(ctx context.Context, m address.Address, tsk types
 	if err := gw.checkTipsetKey(ctx, tsk); err != nil {
 		return miner.MinerInfo{}, err
 	}
-	return gw.target.StateMinerInfo(ctx, m, tsk)
+
+	key := minerInfoKey{miner: m, tsk: tsk}
+	if v, ok := gw.cache.get(ctx, "StateMinerInfo", key); ok {
+		return v.(miner.MinerInfo), nil
+	}
+
+	mi, err := gw.target.StateMinerInfo(ctx, m, tsk)
+	if err != nil {
+		return miner.MinerInfo{}, err
+	}
+	gw.cache.put(key, mi, tsk.IsEmpty())
+	return mi, nil
 }
 
 func (gw *Node) StateMinerDeadlines(ctx context.Context, m address.Address, tsk types.TipSetKey) ([]api.Deadline, error) {
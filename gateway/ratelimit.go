@@ -0,0 +1,241 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/journal/audit"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// DefaultMethodCosts are the rate limiting costs of gateway methods which are
+// noticeably more expensive to serve than a simple lookup. Methods not listed
+// cost 1.
+var DefaultMethodCosts = map[string]int{
+	"ChainGetTipSetByHeight": 5,
+	"GasEstimateMessageGas":  10,
+	"MpoolPush":              5,
+	"MsigGetPending":         5,
+	"MsigGetVested":          5,
+	"StateListMiners":        20,
+	"StateMinerDeadlines":    5,
+	"StateReadState":         5,
+	"StateSearchMsg":         10,
+	"StateWaitMsg":           10,
+}
+
+type RateLimitConfig struct {
+	// Rate is the number of cost units per second each client may spend;
+	// zero disables rate limiting
+	Rate float64
+	// Burst is the number of cost units a client may spend at once
+	Burst int
+
+	// MethodCosts overrides the cost of single methods
+	MethodCosts map[string]int
+
+	// TrustForwardedFor identifies clients without a token by the first
+	// X-Forwarded-For address, for gateways running behind a reverse proxy
+	TrustForwardedFor bool
+
+	// Verify checks the API tokens sent by clients, typically with the
+	// AuthVerify method of the node. Only clients with a valid token are
+	// identified by it, others are identified by their address. Without it,
+	// all clients are identified by their address.
+	Verify func(ctx context.Context, token string) ([]auth.Permission, error)
+}
+
+// maxClients bounds the number of limiters kept, the least recently seen
+// clients are dropped first. Once dropped, a client starts again with a full
+// burst.
+const maxClients = 100000
+
+// maxTokens bounds the number of token verification results kept
+const maxTokens = 10000
+
+// tokenCheckInterval is how long a token verification result is kept
+const tokenCheckInterval = 10 * time.Minute
+
+type tokenCheck struct {
+	valid   bool
+	checked time.Time
+}
+
+// RateLimiter limits the rate of gateway API calls per client, where clients
+// are identified by their API token if they send a valid one, and by their IP
+// address otherwise.
+type RateLimiter struct {
+	cfg   RateLimitConfig
+	costs map[string]int
+
+	lk      sync.Mutex
+	clients *lru.Cache // client -> *rate.Limiter
+	tokens  *lru.Cache // token id -> tokenCheck
+}
+
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	costs := make(map[string]int, len(DefaultMethodCosts)+len(cfg.MethodCosts))
+	for m, c := range DefaultMethodCosts {
+		costs[m] = c
+	}
+	for m, c := range cfg.MethodCosts {
+		costs[m] = c
+	}
+
+	// a call costing more than the burst could never be made
+	for _, c := range costs {
+		if c > cfg.Burst {
+			cfg.Burst = c
+		}
+	}
+
+	clients, _ := lru.New(maxClients)
+	tokens, _ := lru.New(maxTokens)
+
+	return &RateLimiter{
+		cfg:     cfg,
+		costs:   costs,
+		clients: clients,
+		tokens:  tokens,
+	}
+}
+
+func (rl *RateLimiter) cost(method string) int {
+	if c, ok := rl.costs[method]; ok {
+		return c
+	}
+	return 1
+}
+
+func (rl *RateLimiter) allow(client string, cost int) bool {
+	rl.lk.Lock()
+	defer rl.lk.Unlock()
+
+	var l *rate.Limiter
+	if v, ok := rl.clients.Get(client); ok {
+		l = v.(*rate.Limiter)
+	} else {
+		l = rate.NewLimiter(rate.Limit(rl.cfg.Rate), rl.cfg.Burst)
+		rl.clients.Add(client, l)
+	}
+
+	return l.AllowN(time.Now(), cost)
+}
+
+type clientKey struct{}
+
+// Handler identifies the client of each request for rate limiting.
+func (rl *RateLimiter) Handler(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), clientKey{}, rl.clientID(r)))
+		next.ServeHTTP(w, r)
+	}
+}
+
+func (rl *RateLimiter) clientID(r *http.Request) string {
+	addr := "ip:" + rl.remoteIP(r)
+
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		token = r.FormValue("token")
+	}
+	token = strings.TrimPrefix(token, "Bearer ")
+	if token != "" && rl.validToken(r.Context(), addr, token) {
+		return "token:" + audit.TokenID(token)
+	}
+
+	return addr
+}
+
+func (rl *RateLimiter) remoteIP(r *http.Request) string {
+	if rl.cfg.TrustForwardedFor {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			return strings.TrimSpace(strings.Split(fwd, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return host
+}
+
+// validToken returns whether the token is accepted by Verify. Checking a new
+// token costs a call to the address of the client, so that clients can't
+// flood the node with verifications of made up tokens.
+func (rl *RateLimiter) validToken(ctx context.Context, addr, token string) bool {
+	if rl.cfg.Verify == nil {
+		return false
+	}
+
+	id := audit.TokenID(token)
+	if v, ok := rl.tokens.Get(id); ok {
+		if c := v.(tokenCheck); time.Since(c.checked) < tokenCheckInterval {
+			return c.valid
+		}
+	}
+
+	if !rl.allow(addr, 1) {
+		return false
+	}
+
+	_, err := rl.cfg.Verify(ctx, token)
+	rl.tokens.Add(id, tokenCheck{valid: err == nil, checked: time.Now()})
+	return err == nil
+}
+
+// RateLimitedGatewayAPI wraps the gateway API, rejecting calls of clients
+// which exceeded their rate limit.
+func RateLimitedGatewayAPI(a api.Gateway, rl *RateLimiter) api.Gateway {
+	var out api.GatewayStruct
+
+	rint := reflect.ValueOf(&out.Internal).Elem()
+	ra := reflect.ValueOf(a)
+
+	for f := 0; f < rint.NumField(); f++ {
+		field := rint.Type().Field(f)
+		fn := ra.MethodByName(field.Name)
+		method := field.Name
+		cost := rl.cost(method)
+
+		rint.Field(f).Set(reflect.MakeFunc(field.Type, func(args []reflect.Value) (results []reflect.Value) {
+			ctx := args[0].Interface().(context.Context)
+
+			client, ok := ctx.Value(clientKey{}).(string)
+			if !ok || rl.allow(client, cost) {
+				return fn.Call(args)
+			}
+
+			ctx, _ = tag.New(ctx, tag.Upsert(metrics.Endpoint, method))
+			stats.Record(ctx, metrics.APIRateLimited.M(1))
+
+			err := xerrors.Errorf("rate limit exceeded for %s", method)
+			rerr := reflect.ValueOf(&err).Elem()
+
+			if field.Type.NumOut() == 2 {
+				return []reflect.Value{
+					reflect.Zero(field.Type.Out(0)),
+					rerr,
+				}
+			}
+			return []reflect.Value{rerr}
+		}))
+	}
+
+	return &out
+}
@@ -0,0 +1,92 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+func TestRateLimiterTokens(t *testing.T) {
+	var verified int64
+	verify := func(ctx context.Context, token string) ([]auth.Permission, error) {
+		atomic.AddInt64(&verified, 1)
+		if token != "valid" {
+			return nil, xerrors.New("invalid token")
+		}
+		return api.AllPermissions, nil
+	}
+
+	request := func(rl *RateLimiter, token string) string {
+		r := httptest.NewRequest("POST", "/rpc/v1", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		return rl.clientID(r)
+	}
+
+	t.Run("random tokens", func(t *testing.T) {
+		rl := NewRateLimiter(RateLimitConfig{Rate: 0.001, Burst: 20, Verify: verify})
+
+		// made up tokens don't get their own bucket, calls from the address
+		// are limited together, verifications included
+		allowed := 0
+		for i := 0; i < 100; i++ {
+			if rl.allow(request(rl, fmt.Sprintf("random-%d", i)), 1) {
+				allowed++
+			}
+		}
+		require.Less(t, allowed, 20)
+		require.LessOrEqual(t, atomic.LoadInt64(&verified), int64(20))
+
+		// once the address is limited, new tokens aren't verified anymore
+		before := atomic.LoadInt64(&verified)
+		require.Equal(t, "ip:10.0.0.1", request(rl, "another"))
+		require.Equal(t, before, atomic.LoadInt64(&verified))
+	})
+
+	t.Run("valid token", func(t *testing.T) {
+		rl := NewRateLimiter(RateLimitConfig{Rate: 0.001, Burst: 20, Verify: verify})
+
+		id := request(rl, "valid")
+		require.Regexp(t, "^token:", id)
+
+		// the result of the verification is kept
+		before := atomic.LoadInt64(&verified)
+		for i := 0; i < 5; i++ {
+			require.Equal(t, id, request(rl, "valid"))
+		}
+		require.Equal(t, before, atomic.LoadInt64(&verified))
+
+		// and the token doesn't share the bucket of the address
+		require.False(t, rl.allow("ip:10.0.0.1", 20))
+		require.True(t, rl.allow(id, 20))
+	})
+}
+
+func TestRateLimiterNoVerify(t *testing.T) {
+	rl := NewRateLimiter(RateLimitConfig{Rate: 1, Burst: 1})
+
+	r := httptest.NewRequest("POST", "/rpc/v1", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("Authorization", "Bearer anything")
+	require.Equal(t, "ip:10.0.0.1", rl.clientID(r))
+}
+
+func TestRateLimiterBoundsClients(t *testing.T) {
+	rl := NewRateLimiter(RateLimitConfig{Rate: 1, Burst: 1})
+
+	for i := 0; i < maxClients+10; i++ {
+		rl.allow(fmt.Sprintf("ip:%d", i), 1)
+	}
+	require.Equal(t, maxClients, rl.clients.Len())
+}
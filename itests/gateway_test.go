@@ -276,7 +276,7 @@ func startNodes(
 
 				// Create a gateway server in front of the full node
 				gwapi := gateway.NewNode(fullNode, lookbackCap, stateWaitLookbackLimit)
-				handler, err := gateway.Handler(gwapi)
+				handler, err := gateway.Handler(gwapi, nil)
 				require.NoError(t, err)
 
 				srv, _ := kit.CreateRPCServer(t, handler)
@@ -38,6 +38,7 @@ import (
 	"github.com/filecoin-project/lotus/genesis"
 	lotusminer "github.com/filecoin-project/lotus/miner"
 	"github.com/filecoin-project/lotus/node"
+	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	testing2 "github.com/filecoin-project/lotus/node/modules/testing"
@@ -62,7 +63,24 @@ func init() {
 }
 
 func CreateTestStorageNode(ctx context.Context, t *testing.T, waddr address.Address, act address.Address, pk crypto.PrivKey, tnd TestFullNode, mn mocknet.Mocknet, opts node.Option) TestMiner {
-	r := repo.NewMemory(nil)
+	return CreateTestStorageNodeWithConfig(ctx, t, waddr, act, pk, tnd, mn, nil, opts)
+}
+
+// CreateTestStorageNodeWithConfig creates a storage node with the default
+// miner config, modified by cfg when set
+func CreateTestStorageNodeWithConfig(ctx context.Context, t *testing.T, waddr address.Address, act address.Address, pk crypto.PrivKey, tnd TestFullNode, mn mocknet.Mocknet, cfg func(*config.StorageMiner), opts node.Option) TestMiner {
+	var r *repo.MemRepo
+	if cfg == nil {
+		r = repo.NewMemory(nil)
+	} else {
+		r = repo.NewMemory(&repo.MemRepoOptions{
+			ConfigF: func(repo.RepoType) interface{} {
+				c := config.DefaultStorageMiner()
+				cfg(c)
+				return c
+			},
+		})
+	}
 
 	lr, err := r.Lock(repo.StorageMiner)
 	require.NoError(t, err)
@@ -572,7 +590,7 @@ func mockMinerBuilderOpts(t *testing.T, fullOpts []FullNodeOpts, storage []Stora
 		if opts == nil {
 			opts = node.Options()
 		}
-		miners[i] = CreateTestStorageNode(ctx, t, genms[i].Worker, maddrs[i], pidKeys[i], f, mn, node.Options(
+		miners[i] = CreateTestStorageNodeWithConfig(ctx, t, genms[i].Worker, maddrs[i], pidKeys[i], f, mn, def.Config, node.Options(
 			node.Override(new(*mock.SectorMgr), func() (*mock.SectorMgr, error) {
 				return mock.NewMockSectorMgr(sectors), nil
 			}),
@@ -15,6 +15,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/miner"
 	"github.com/filecoin-project/lotus/node"
+	"github.com/filecoin-project/lotus/node/config"
 )
 
 type MinerBuilder func(context.Context, *testing.T, abi.RegisteredSealProof, address.Address) TestMiner
@@ -49,6 +50,10 @@ type StorageMiner struct {
 	Full    int
 	Opts    node.Option
 	Preseal int
+
+	// Config modifies the default miner config of the node, only used by
+	// the mock miner builders
+	Config func(*config.StorageMiner)
 }
 
 type OptionGenerator func([]TestFullNode) node.Option
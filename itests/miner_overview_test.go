@@ -0,0 +1,65 @@
+package itests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/itests/kit"
+)
+
+func TestMinerOverview(t *testing.T) {
+	kit.QuietMiningLogs()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	n, sn := kit.MockMinerBuilder(t, kit.OneFull, kit.OneMiner)
+	client, miner := n[0].FullNode, sn[0]
+
+	maddr, err := miner.ActorAddress(ctx)
+	require.NoError(t, err)
+
+	// no blocks are mined, so that the head doesn't move under the overview.
+	// All sections load against a healthy node
+	ov, err := miner.MinerOverview(ctx)
+	require.NoError(t, err)
+	require.Empty(t, ov.Errors)
+	require.Equal(t, maddr, ov.Miner)
+
+	ts, err := client.ChainHead(ctx)
+	require.NoError(t, err)
+	require.Equal(t, ts.Height(), ov.Height)
+
+	mi, err := client.StateMinerInfo(ctx, maddr, ts.Key())
+	require.NoError(t, err)
+	require.Equal(t, mi.Owner, ov.Owner)
+	require.Equal(t, mi.Worker, ov.Worker)
+	require.Equal(t, mi.SectorSize, ov.SectorSize)
+
+	pow, err := client.StateMinerPower(ctx, maddr, ts.Key())
+	require.NoError(t, err)
+	require.Equal(t, pow.MinerPower.QualityAdjPower, ov.Power.QualityAdjPower)
+	require.Equal(t, pow.TotalPower.QualityAdjPower, ov.Power.NetworkQualityAdjPower)
+
+	act, err := client.StateGetActor(ctx, maddr, ts.Key())
+	require.NoError(t, err)
+	require.Equal(t, act.Balance, ov.Balances.Miner)
+
+	counts, err := client.StateMinerSectorCount(ctx, maddr, ts.Key())
+	require.NoError(t, err)
+	require.Equal(t, counts.Live, ov.Sectors.Live)
+	require.Equal(t, counts.Active, ov.Sectors.Active)
+	require.NotNil(t, ov.Sectors.Pipeline)
+	require.Zero(t, ov.Sectors.Failed)
+
+	dl, err := client.StateMinerProvingDeadline(ctx, maddr, ts.Key())
+	require.NoError(t, err)
+	require.Equal(t, dl.Index, ov.Proving.Deadline)
+	require.Equal(t, dl.Open, ov.Proving.Open)
+
+	// the markets subsystem is enabled, without any deals yet
+	require.NotNil(t, ov.Deals)
+	require.Zero(t, ov.Deals.Total)
+}
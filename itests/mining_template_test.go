@@ -0,0 +1,85 @@
+package itests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/itests/kit"
+)
+
+func TestMinerComputeBlockTemplate(t *testing.T) {
+	kit.QuietMiningLogs()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	n, sn := kit.MockMinerBuilder(t, kit.OneFull, kit.OneMiner)
+	client, miner := n[0].FullNode, sn[0]
+
+	maddr, err := miner.ActorAddress(ctx)
+	require.NoError(t, err)
+
+	bm := kit.NewBlockMiner(t, miner)
+	bm.MineBlocks(ctx, 50*time.Millisecond)
+
+	from, err := client.WalletDefaultAddress(ctx)
+	require.NoError(t, err)
+	sm, err := client.MpoolPushMessage(ctx, &types.Message{From: from, To: from, Value: big.Zero()}, nil)
+	require.NoError(t, err)
+	lookup, err := client.StateWaitMsg(ctx, sm.Cid(), 1, api.LookbackNoLimit, true)
+	require.NoError(t, err)
+
+	bm.Stop()
+
+	head, err := client.ChainHead(ctx)
+	require.NoError(t, err)
+
+	t.Run("head", func(t *testing.T) {
+		tpl, err := client.MinerComputeBlockTemplate(ctx, maddr, head.Height()+1, types.EmptyTSK)
+		require.NoError(t, err)
+		require.Equal(t, head.Key(), tpl.Base)
+		require.True(t, tpl.Eligible)
+	})
+
+	t.Run("past", func(t *testing.T) {
+		// the message was included in the parent of the tipset it was executed
+		// in, replaying that epoch uses the messages in the chain
+		execTs, err := client.ChainGetTipSet(ctx, lookup.TipSet)
+		require.NoError(t, err)
+		inclTs, err := client.ChainGetTipSet(ctx, execTs.Parents())
+		require.NoError(t, err)
+
+		tpl, err := client.MinerComputeBlockTemplate(ctx, maddr, inclTs.Height(), inclTs.Parents())
+		require.NoError(t, err)
+		require.True(t, tpl.Eligible)
+		if !tpl.Won {
+			t.Skip("the miner doesn't win the epoch, messages aren't selected")
+		}
+
+		var found bool
+		for _, m := range tpl.Messages {
+			found = found || m.Cid() == sm.Cid()
+		}
+		require.True(t, found, "message %s not in the template", sm.Cid())
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		parent, err := client.ChainGetTipSet(ctx, head.Parents())
+		require.NoError(t, err)
+
+		// the base must be before the epoch
+		_, err = client.MinerComputeBlockTemplate(ctx, maddr, parent.Height(), parent.Key())
+		require.Error(t, err)
+
+		// and the head, to mine past it
+		_, err = client.MinerComputeBlockTemplate(ctx, maddr, head.Height()+1, parent.Key())
+		require.Error(t, err)
+	})
+}
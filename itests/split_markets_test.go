@@ -0,0 +1,155 @@
+package itests
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/filecoin-project/go-commp-utils/zerocomm"
+	"github.com/filecoin-project/go-jsonrpc/auth"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	market2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/market"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper"
+	"github.com/filecoin-project/lotus/extern/sector-storage/mock"
+	"github.com/filecoin-project/lotus/itests/kit"
+	"github.com/filecoin-project/lotus/node"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+// TestSplitMarketsSealing runs the markets and the sealing subsystems of a
+// miner in two nodes, the markets-only one connected to the sealing-only one
+// through its authenticated API endpoint.
+func TestSplitMarketsSealing(t *testing.T) {
+	kit.QuietMiningLogs()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	n, sn := kit.MockMinerBuilder(t, kit.OneFull, []kit.StorageMiner{{
+		Full:    0,
+		Preseal: kit.PresealGenesis,
+		Config: func(cfg *config.StorageMiner) {
+			cfg.Subsystems.EnableMarkets = false
+		},
+	}})
+	full, sealer := n[0], sn[0]
+
+	maddr, err := sealer.ActorAddress(ctx)
+	require.NoError(t, err)
+
+	// the sealing-only node mines blocks, the builder mined the first ones
+	// with it
+	head, err := full.ChainHead(ctx)
+	require.NoError(t, err)
+	require.True(t, head.Height() >= 2, "head at %d", head.Height())
+
+	handler, err := node.MinerHandler(sealer.StorageMiner, true)
+	require.NoError(t, err)
+	srv, srvAddr := kit.CreateRPCServer(t, handler)
+
+	adminToken, err := sealer.AuthNew(ctx, api.AllPermissions)
+	require.NoError(t, err)
+	sealerInfo := string(adminToken) + ":" + srvAddr.String()
+
+	// the markets-only node is constructed with the sealer api
+	worker, err := full.WalletDefaultAddress(ctx)
+	require.NoError(t, err)
+	pk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+
+	markets := kit.CreateTestStorageNodeWithConfig(ctx, t, worker, maddr, pk, full, mocknet.New(ctx), func(cfg *config.StorageMiner) {
+		cfg.Subsystems.EnableSealing = false
+		cfg.Subsystems.SealerApiInfo = sealerInfo
+	}, node.Options(
+		node.Override(new(ffiwrapper.Verifier), mock.MockVerifier),
+		node.Override(new(ffiwrapper.Prover), mock.MockProver),
+	))
+
+	marketsAddr, err := markets.ActorAddress(ctx)
+	require.NoError(t, err)
+	require.Equal(t, maddr, marketsAddr)
+
+	deals, err := markets.MarketListIncompleteDeals(ctx)
+	require.NoError(t, err)
+	require.Empty(t, deals)
+
+	lc := fxtest.NewLifecycle(t)
+	mctx := helpers.MetricsCtx(ctx)
+	sealerAPI, err := modules.ConnectSealerAPI(sealerInfo)(mctx, lc, nil)
+	require.NoError(t, err)
+	lc.RequireStart()
+	defer lc.RequireStop()
+
+	t.Run("storage-auth", func(t *testing.T) {
+		sa, err := modules.SealerStorageAuth(mctx, lc, sealerAPI)
+		require.NoError(t, err)
+
+		remote := func(method string, hdr http.Header) int {
+			req, err := http.NewRequest(method, srv.URL+"/remote/stat/sealed/s-t01000-0", nil)
+			require.NoError(t, err)
+			req.Header = hdr.Clone()
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			return resp.StatusCode
+		}
+
+		// the token minted for the markets node only reads sector files, the
+		// sealer serves them through its (mocked) sector manager
+		require.Equal(t, http.StatusNotFound, remote("GET", http.Header(sa)))
+		require.Equal(t, http.StatusUnauthorized, remote("DELETE", http.Header(sa)))
+		require.Equal(t, http.StatusUnauthorized, remote("GET", nil))
+
+		// and doesn't grant access to the api
+		perms, err := sealerAPI.AuthVerify(ctx, strings.TrimPrefix(http.Header(sa).Get("Authorization"), "Bearer "))
+		require.NoError(t, err)
+		require.Equal(t, []auth.Permission{auth.Permission(api.RemoteReadScope.String())}, perms)
+	})
+
+	t.Run("push-piece", func(t *testing.T) {
+		// the piece data is streamed to /rpc/streams/v0/push of the sealer
+		size := abi.PaddedPieceSize(128).Unpadded()
+		data := make([]byte, size)
+
+		proposal := &market2.DealProposal{
+			PieceCID:             zerocomm.ZeroPieceCommitment(size),
+			PieceSize:            size.Padded(),
+			Client:               worker,
+			Provider:             maddr,
+			StartEpoch:           1000,
+			EndEpoch:             2000,
+			StoragePricePerEpoch: big.Zero(),
+			ProviderCollateral:   big.Zero(),
+			ClientCollateral:     big.Zero(),
+		}
+
+		so, err := sealerAPI.SectorAddPieceToAny(ctx, size, bytes.NewReader(data), api.PieceDealInfo{
+			DealID:       1,
+			DealProposal: proposal,
+			DealSchedule: api.DealSchedule{
+				StartEpoch: proposal.StartEpoch,
+				EndEpoch:   proposal.EndEpoch,
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, abi.PaddedPieceSize(0), so.Offset)
+
+		si, err := sealer.SectorsStatus(ctx, so.Sector, false)
+		require.NoError(t, err)
+		require.Equal(t, []abi.DealID{1}, si.Deals)
+	})
+}
@@ -0,0 +1,149 @@
+package itests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/itests/kit"
+)
+
+func TestStateQuery(t *testing.T) {
+	kit.QuietMiningLogs()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	n, sn := kit.MockMinerBuilder(t, kit.OneFull, kit.OneMiner)
+	client := n[0].FullNode
+
+	bm := kit.NewBlockMiner(t, sn[0])
+	bm.MineBlocks(ctx, 50*time.Millisecond)
+	defer bm.Stop()
+
+	maddr, err := sn[0].ActorAddress(ctx)
+	require.NoError(t, err)
+	wallet, err := client.WalletDefaultAddress(ctx)
+	require.NoError(t, err)
+	// never sent funds, so it doesn't exist on chain
+	missing, err := address.NewIDAddress(999999)
+	require.NoError(t, err)
+
+	head, err := client.ChainHead(ctx)
+	require.NoError(t, err)
+
+	res, err := client.StateQuery(ctx, []api.StateFieldQuery{
+		{Actor: maddr, Fields: []string{"Balance", "Info", "SectorCount", "Info"}},
+		{Actor: missing, Fields: []string{"Balance", "ID"}},
+		{Actor: wallet, Fields: []string{"Balance", "SectorCount"}},
+	}, head.Key())
+	require.NoError(t, err)
+
+	// all fields are resolved at the requested tipset
+	require.Equal(t, head.Key(), res.TipSet)
+	require.Equal(t, head.Height(), res.Height)
+	require.Len(t, res.Actors, 3)
+
+	t.Run("miner", func(t *testing.T) {
+		mres := res.Actors[0]
+		require.Equal(t, maddr, mres.Actor)
+		require.Empty(t, mres.Errors)
+
+		// only the selected fields are returned, duplicates once
+		require.Len(t, mres.Fields, 3)
+
+		var info miner.MinerInfo
+		require.NoError(t, json.Unmarshal(mres.Fields["Info"], &info))
+		expInfo, err := client.StateMinerInfo(ctx, maddr, head.Key())
+		require.NoError(t, err)
+		require.Equal(t, expInfo.Worker, info.Worker)
+
+		var balance types.BigInt
+		require.NoError(t, json.Unmarshal(mres.Fields["Balance"], &balance))
+		act, err := client.StateGetActor(ctx, maddr, head.Key())
+		require.NoError(t, err)
+		require.Equal(t, act.Balance, balance)
+
+		var sc api.MinerSectors
+		require.NoError(t, json.Unmarshal(mres.Fields["SectorCount"], &sc))
+		require.NotZero(t, sc.Live)
+	})
+
+	t.Run("load-failure", func(t *testing.T) {
+		// an actor failing to load fails its fields, not the query
+		mres := res.Actors[1]
+		require.Equal(t, missing, mres.Actor)
+		require.Empty(t, mres.Fields)
+		require.Len(t, mres.Errors, 2)
+		require.Contains(t, mres.Errors["Balance"], "loading actor")
+		require.Contains(t, mres.Errors["ID"], "loading actor")
+	})
+
+	t.Run("unknown-field", func(t *testing.T) {
+		// miner fields aren't available for accounts
+		ares := res.Actors[2]
+		require.Contains(t, ares.Fields, "Balance")
+		require.NotContains(t, ares.Fields, "SectorCount")
+		require.Contains(t, ares.Errors["SectorCount"], "unknown field")
+		require.Contains(t, ares.Errors["SectorCount"], "Balance")
+		require.NotContains(t, ares.Errors["SectorCount"], "SectorCount")
+	})
+
+	t.Run("past-tipset", func(t *testing.T) {
+		past, err := client.ChainGetTipSet(ctx, head.Parents())
+		require.NoError(t, err)
+
+		res, err := client.StateQuery(ctx, []api.StateFieldQuery{{Actor: maddr, Fields: []string{"ProvingDeadline"}}}, past.Key())
+		require.NoError(t, err)
+		require.Equal(t, past.Key(), res.TipSet)
+		require.Equal(t, past.Height(), res.Height)
+		require.Contains(t, res.Actors[0].Fields, "ProvingDeadline")
+	})
+	t.Run("sectors-page", func(t *testing.T) {
+		all, err := client.StateMinerSectors(ctx, maddr, nil, head.Key())
+		require.NoError(t, err)
+		require.True(t, len(all) > 1, "%d sectors", len(all))
+
+		// a page per sector, following the cursors
+		var paged []abi.SectorNumber
+		q := api.MinerSectorsQuery{Limit: 1}
+		for {
+			page, err := client.StateMinerSectorsPage(ctx, maddr, q, head.Key())
+			require.NoError(t, err)
+			require.Len(t, page.Sectors, 1)
+			paged = append(paged, page.Sectors[0].SectorNumber)
+
+			if page.NextCursor == nil {
+				break
+			}
+			q.Cursor = *page.NextCursor
+		}
+		require.Len(t, paged, len(all))
+		for i, si := range all {
+			require.Equal(t, si.SectorNumber, paged[i])
+		}
+
+		// the genesis sectors don't hold deals, and expire after they were
+		// activated
+		page, err := client.StateMinerSectorsPage(ctx, maddr, api.MinerSectorsQuery{WithDeals: true}, head.Key())
+		require.NoError(t, err)
+		require.Empty(t, page.Sectors)
+		require.Nil(t, page.NextCursor)
+
+		page, err = client.StateMinerSectorsPage(ctx, maddr, api.MinerSectorsQuery{ExpirationMin: all[0].Expiration, ExpirationMax: all[0].Expiration}, head.Key())
+		require.NoError(t, err)
+		require.NotEmpty(t, page.Sectors)
+		for _, si := range page.Sectors {
+			require.Equal(t, all[0].Expiration, si.Expiration)
+		}
+	})
+}
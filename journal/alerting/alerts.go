@@ -0,0 +1,224 @@
+package alerting
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/journal"
+)
+
+var log = logging.Logger("alerting")
+
+// Alerting provides simple stateful alert system. Consumers can register alerts,
+// which can be raised and resolved.
+//
+// When an alert is raised or resolved, a related journal entry is recorded,
+// and the configured sinks are notified.
+type Alerting struct {
+	j     journal.Journal
+	sinks []Sink
+
+	lk     sync.Mutex
+	alerts map[AlertType]*Alert
+}
+
+// AlertType is a unique alert identifier
+type AlertType struct {
+	System, Subsystem string
+}
+
+func (at AlertType) String() string {
+	return at.System + ":" + at.Subsystem
+}
+
+// AlertEvent contains information about alert state transition
+type AlertEvent struct {
+	Type    string // either 'raised' or 'resolved'
+	Message json.RawMessage
+	Time    time.Time
+}
+
+type Alert struct {
+	Type   AlertType
+	Active bool
+
+	// Acked is set when an operator acknowledges an active alert. It is cleared
+	// when the alert gets raised again after being resolved.
+	Acked bool
+
+	LastActive   *AlertEvent // NOTE: pointer for nullability, don't mutate the referenced object!
+	LastResolved *AlertEvent
+
+	journalType journal.EventType
+}
+
+func NewAlertingSystem(j journal.Journal, sinks ...Sink) *Alerting {
+	return &Alerting{
+		j:     j,
+		sinks: sinks,
+
+		alerts: map[AlertType]*Alert{},
+	}
+}
+
+// AddAlertType registers an alert type, and returns its identifier. Calling
+// it multiple times with the same arguments is allowed.
+func (a *Alerting) AddAlertType(system, subsystem string) AlertType {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+
+	at := AlertType{
+		System:    system,
+		Subsystem: subsystem,
+	}
+
+	if _, exists := a.alerts[at]; exists {
+		return at
+	}
+
+	et := a.j.RegisterEventType(system, subsystem)
+	a.alerts[at] = &Alert{
+		Type:        at,
+		Active:      false,
+		journalType: et,
+	}
+
+	return at
+}
+
+func (a *Alerting) update(at AlertType, message interface{}, upd func(*Alert, json.RawMessage) bool) {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+
+	alert, ok := a.alerts[at]
+	if !ok {
+		log.Errorw("unknown alert", "type", at, "message", message)
+		return
+	}
+
+	rawMsg, err := json.Marshal(message)
+	if err != nil {
+		log.Errorw("marshaling alert message failed", "type", at, "error", err)
+		rawMsg, _ = json.Marshal(&struct {
+			AlertError string
+		}{
+			AlertError: err.Error(),
+		})
+	}
+
+	if !upd(alert, rawMsg) {
+		return
+	}
+
+	snap := *alert
+	for _, s := range a.sinks {
+		go func(s Sink) {
+			if err := s.Notify(snap); err != nil {
+				log.Errorw("delivering alert notification", "sink", s.Name(), "type", at, "error", err)
+			}
+		}(s)
+	}
+}
+
+// Raise marks the alert condition as active and records related event in the journal.
+// Sinks are only notified when an inactive alert becomes active.
+func (a *Alerting) Raise(at AlertType, message interface{}) {
+	a.update(at, message, func(alert *Alert, rawMsg json.RawMessage) bool {
+		wasActive := alert.Active
+		if !wasActive {
+			log.Errorw("alert raised", "type", at, "message", message)
+		}
+
+		alert.Active = true
+		alert.LastActive = &AlertEvent{
+			Type:    "raised",
+			Message: rawMsg,
+			Time:    time.Now(),
+		}
+		if !wasActive {
+			alert.Acked = false
+		}
+
+		a.j.RecordEvent(alert.journalType, func() interface{} {
+			return alert.LastActive
+		})
+
+		return !wasActive
+	})
+}
+
+// Resolve marks the alert condition as resolved and records related event in the journal.
+// Sinks are only notified when an active alert gets resolved.
+func (a *Alerting) Resolve(at AlertType, message interface{}) {
+	a.update(at, message, func(alert *Alert, rawMsg json.RawMessage) bool {
+		if !alert.Active {
+			return false
+		}
+
+		log.Warnw("alert resolved", "type", at, "message", message)
+
+		alert.Active = false
+		alert.LastResolved = &AlertEvent{
+			Type:    "resolved",
+			Message: rawMsg,
+			Time:    time.Now(),
+		}
+
+		a.j.RecordEvent(alert.journalType, func() interface{} {
+			return alert.LastResolved
+		})
+
+		return true
+	})
+}
+
+// Ack acknowledges an active alert. Acknowledged alerts stay active until the
+// underlying condition is resolved.
+func (a *Alerting) Ack(at AlertType) error {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+
+	alert, ok := a.alerts[at]
+	if !ok {
+		return xerrors.Errorf("unknown alert: %s", at)
+	}
+	if !alert.Active {
+		return xerrors.Errorf("alert %s is not active", at)
+	}
+
+	alert.Acked = true
+	return nil
+}
+
+// GetAlerts returns all registered (active and inactive) alerts
+func (a *Alerting) GetAlerts() []Alert {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+
+	out := make([]Alert, 0, len(a.alerts))
+	for _, alert := range a.alerts {
+		out = append(out, *alert)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Type.System != out[j].Type.System {
+			return out[i].Type.System < out[j].Type.System
+		}
+
+		return out[i].Type.Subsystem < out[j].Type.Subsystem
+	})
+
+	return out
+}
+
+func (a *Alerting) IsRaised(at AlertType) bool {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+
+	alert, ok := a.alerts[at]
+	return ok && alert.Active
+}
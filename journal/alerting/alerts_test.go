@@ -0,0 +1,70 @@
+package alerting
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/journal"
+)
+
+type testSink struct {
+	lk   sync.Mutex
+	seen []Alert
+}
+
+func (s *testSink) Name() string {
+	return "test"
+}
+
+func (s *testSink) Notify(alert Alert) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.seen = append(s.seen, alert)
+	return nil
+}
+
+func (s *testSink) notified() []Alert {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	return append([]Alert{}, s.seen...)
+}
+
+func TestAlerting(t *testing.T) {
+	sink := &testSink{}
+	a := NewAlertingSystem(journal.NilJournal(), sink)
+
+	at := a.AddAlertType("wdpost", "deadline-3")
+	require.Equal(t, at, a.AddAlertType("wdpost", "deadline-3"))
+	require.False(t, a.IsRaised(at))
+	require.Error(t, a.Ack(at))
+
+	a.Raise(at, map[string]string{"missed": "1"})
+	a.Raise(at, map[string]string{"missed": "2"})
+	require.True(t, a.IsRaised(at))
+
+	require.NoError(t, a.Ack(at))
+	alerts := a.GetAlerts()
+	require.Len(t, alerts, 1)
+	require.True(t, alerts[0].Active)
+	require.True(t, alerts[0].Acked)
+	require.Equal(t, `{"missed":"2"}`, string(alerts[0].LastActive.Message))
+
+	a.Resolve(at, "ok")
+	a.Resolve(at, "still ok")
+	require.False(t, a.IsRaised(at))
+
+	a.Raise(at, "again")
+	require.False(t, a.GetAlerts()[0].Acked)
+
+	// sinks only hear about transitions
+	require.Eventually(t, func() bool {
+		return len(sink.notified()) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	require.Error(t, a.Ack(AlertType{System: "unknown"}))
+}
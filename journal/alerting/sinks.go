@@ -0,0 +1,169 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const sinkTimeout = 30 * time.Second
+
+// Sink delivers alert state transitions to an external system.
+type Sink interface {
+	Name() string
+
+	// Notify is called when an alert gets raised or resolved. It's called from
+	// a separate goroutine, so implementations are free to block.
+	Notify(alert Alert) error
+}
+
+// PostJSON POSTs the JSON-encoded body to url, failing on non-2xx responses
+func PostJSON(url string, body interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return xerrors.Errorf("marshaling body: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return xerrors.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return xerrors.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return xerrors.Errorf("non-2xx response status %d: %s", resp.StatusCode, string(msg))
+	}
+
+	return nil
+}
+
+// WebhookSink POSTs the JSON-encoded alert to an HTTP endpoint.
+type WebhookSink struct {
+	URL string
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) Notify(alert Alert) error {
+	return PostJSON(s.URL, alert)
+}
+
+// SMTPSink emails alert state transitions.
+type SMTPSink struct {
+	// Server is the SMTP server address, in host:port form.
+	Server   string
+	Username string
+	Password string
+
+	From string
+	To   []string
+}
+
+func (s *SMTPSink) Name() string {
+	return "smtp"
+}
+
+func (s *SMTPSink) Notify(alert Alert) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Server)
+		if err != nil {
+			return xerrors.Errorf("parsing smtp server address: %w", err)
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	state, evt := "RESOLVED", alert.LastResolved
+	if alert.Active {
+		state, evt = "ALERT", alert.LastActive
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", s.From)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&body, "Subject: [lotus] %s %s\r\n", state, alert.Type)
+	fmt.Fprintf(&body, "\r\n")
+	if evt != nil {
+		fmt.Fprintf(&body, "%s at %s\r\n\r\n%s\r\n", evt.Type, evt.Time.Format(time.RFC3339), string(evt.Message))
+	}
+
+	if err := smtp.SendMail(s.Server, auth, s.From, s.To, body.Bytes()); err != nil {
+		return xerrors.Errorf("sending mail: %w", err)
+	}
+
+	return nil
+}
+
+// PagerDutySink delivers alerts to a PagerDuty Events API v2 compatible
+// endpoint. Alerts are deduplicated by type, so that a resolved alert closes
+// the incident opened when it was raised.
+type PagerDutySink struct {
+	URL        string
+	RoutingKey string
+}
+
+func (s *PagerDutySink) Name() string {
+	return "pagerduty"
+}
+
+type pdPayload struct {
+	Summary       string          `json:"summary"`
+	Source        string          `json:"source"`
+	Severity      string          `json:"severity"`
+	Component     string          `json:"component"`
+	Group         string          `json:"group"`
+	CustomDetails json.RawMessage `json:"custom_details,omitempty"`
+}
+
+type pdEvent struct {
+	RoutingKey  string     `json:"routing_key"`
+	EventAction string     `json:"event_action"`
+	DedupKey    string     `json:"dedup_key"`
+	Payload     *pdPayload `json:"payload,omitempty"`
+}
+
+func (s *PagerDutySink) Notify(alert Alert) error {
+	evt := pdEvent{
+		RoutingKey:  s.RoutingKey,
+		EventAction: "resolve",
+		DedupKey:    alert.Type.String(),
+	}
+
+	if alert.Active {
+		evt.EventAction = "trigger"
+		evt.Payload = &pdPayload{
+			Summary:   "lotus alert: " + alert.Type.String(),
+			Source:    "lotus-miner",
+			Severity:  "error",
+			Component: alert.Type.System,
+			Group:     alert.Type.Subsystem,
+		}
+		if alert.LastActive != nil {
+			evt.Payload.CustomDetails = alert.LastActive.Message
+		}
+	}
+
+	return PostJSON(s.URL, evt)
+}
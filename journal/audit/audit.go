@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal"
+)
+
+// The audit log records calls to API methods requiring more than read
+// permissions, along with the token they were made with. Entries are stored
+// in the node journal under the api:audit event type, and can be retrieved
+// with JournalQuery, e.g. using `lotus-shed journal audit`.
+//
+// The audit log can be turned off by disabling the api:audit journal event
+// type (LOTUS_JOURNAL_DISABLED).
+const (
+	EventSystem = "api"
+	EventName   = "audit"
+)
+
+// maxParamSize caps the size of a single recorded call parameter, so that
+// calls carrying large payloads don't bloat the journal.
+const maxParamSize = 1 << 10
+
+// redactedTypes are parameter types which carry secrets, e.g. the private
+// keys passed to WalletImport; they are never written to the audit log.
+var redactedTypes = map[reflect.Type]struct{}{
+	reflect.TypeOf(types.KeyInfo{}):  {},
+	reflect.TypeOf(&types.KeyInfo{}): {},
+}
+
+// redactedParams are the positions of the parameters of methods which are
+// sensitive even though their type isn't, like the payloads signed with
+// WalletSign. Call results, e.g. the tokens created by AuthNew, are never
+// recorded.
+var redactedParams = map[string][]int{
+	"WalletSign": {1},
+}
+
+var (
+	redactedParam  = json.RawMessage(`"<redacted>"`)
+	truncatedParam = json.RawMessage(`"<truncated>"`)
+)
+
+// Evt is the journal event recorded for each audited API call.
+type Evt struct {
+	// Token identifies the JWT token used for the call; see TokenID.
+	Token  string
+	Method string
+	Perm   string
+	Params []json.RawMessage
+
+	Duration time.Duration
+	Error    string `json:",omitempty"`
+}
+
+type tokenKey struct{}
+
+// TokenID returns the identifier recorded in the audit log for calls made
+// with the given token. The token itself is never recorded.
+func TokenID(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:8])
+}
+
+func tokenFromContext(ctx context.Context) string {
+	id, ok := ctx.Value(tokenKey{}).(string)
+	if !ok {
+		return "unauthenticated"
+	}
+	return id
+}
+
+// TokenHandler extracts the request JWT token the same way auth.Handler does,
+// and makes its identifier available to the audited API.
+func TokenHandler(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("Authorization")
+		if token == "" {
+			token = r.FormValue("token")
+		}
+		token = strings.TrimPrefix(token, "Bearer ")
+
+		if token != "" {
+			r = r.WithContext(context.WithValue(r.Context(), tokenKey{}, TokenID(token)))
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+func AuditedStorMinerAPI(a api.StorageMiner, j journal.Journal) api.StorageMiner {
+	var out api.StorageMinerStruct
+	et := j.RegisterEventType(EventSystem, EventName)
+	proxy(a, &out.Internal, j, et)
+	proxy(a, &out.CommonStruct.Internal, j, et)
+	return &out
+}
+
+func AuditedFullAPI(a api.FullNode, j journal.Journal) api.FullNode {
+	var out api.FullNodeStruct
+	et := j.RegisterEventType(EventSystem, EventName)
+	proxy(a, &out.Internal, j, et)
+	proxy(a, &out.CommonStruct.Internal, j, et)
+	return &out
+}
+
+func proxy(in interface{}, out interface{}, j journal.Journal, et journal.EventType) {
+	rint := reflect.ValueOf(out).Elem()
+	ra := reflect.ValueOf(in)
+
+	for f := 0; f < rint.NumField(); f++ {
+		field := rint.Type().Field(f)
+		fn := ra.MethodByName(field.Name)
+
+		perm := field.Tag.Get("perm")
+		if perm == "" || perm == string(api.PermRead) {
+			rint.Field(f).Set(fn)
+			continue
+		}
+
+		method := field.Name
+		rint.Field(f).Set(reflect.MakeFunc(field.Type, func(args []reflect.Value) (results []reflect.Value) {
+			ctx := args[0].Interface().(context.Context)
+
+			start := time.Now()
+			results = fn.Call(args)
+
+			j.RecordEvent(et, func() interface{} {
+				evt := &Evt{
+					Token:    tokenFromContext(ctx),
+					Method:   method,
+					Perm:     perm,
+					Params:   encodeParams(method, args[1:]),
+					Duration: time.Since(start),
+				}
+				if err, ok := results[len(results)-1].Interface().(error); ok && err != nil {
+					evt.Error = err.Error()
+				}
+				return evt
+			})
+
+			return results
+		}))
+	}
+}
+
+func encodeParams(method string, args []reflect.Value) []json.RawMessage {
+	out := make([]json.RawMessage, len(args))
+	for _, i := range redactedParams[method] {
+		if i < len(out) {
+			out[i] = redactedParam
+		}
+	}
+
+	for i, arg := range args {
+		if out[i] != nil {
+			continue
+		}
+		if _, redact := redactedTypes[arg.Type()]; redact {
+			out[i] = redactedParam
+			continue
+		}
+
+		b, err := json.Marshal(arg.Interface())
+		switch {
+		case err != nil:
+			b, _ = json.Marshal("<unencodable: " + err.Error() + ">")
+		case len(b) > maxParamSize:
+			b = truncatedParam
+		}
+		out[i] = b
+	}
+	return out
+}
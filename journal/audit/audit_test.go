@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-jsonrpc/auth"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal"
+)
+
+type recordingJournal struct {
+	journal.EventTypeRegistry
+
+	lk   sync.Mutex
+	evts []*Evt
+}
+
+func (j *recordingJournal) RecordEvent(_ journal.EventType, supplier func() interface{}) {
+	j.lk.Lock()
+	defer j.lk.Unlock()
+
+	j.evts = append(j.evts, supplier().(*Evt))
+}
+
+func (j *recordingJournal) Close() error {
+	return nil
+}
+
+type walletAPI struct {
+	api.FullNode
+}
+
+func (walletAPI) WalletSign(context.Context, address.Address, []byte) (*crypto.Signature, error) {
+	return &crypto.Signature{Type: crypto.SigTypeSecp256k1, Data: []byte("sig")}, nil
+}
+
+func (walletAPI) WalletImport(context.Context, *types.KeyInfo) (address.Address, error) {
+	return address.NewIDAddress(1000)
+}
+
+func (walletAPI) AuthNew(context.Context, []auth.Permission) ([]byte, error) {
+	return []byte("secret-token"), nil
+}
+
+func (walletAPI) WalletBalance(context.Context, address.Address) (types.BigInt, error) {
+	return types.NewInt(1), nil
+}
+
+func TestAuditRedaction(t *testing.T) {
+	j := &recordingJournal{EventTypeRegistry: journal.NewEventTypeRegistry(nil)}
+	a := AuditedFullAPI(walletAPI{}, j)
+	ctx := context.Background()
+
+	addr, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+
+	_, err = a.WalletSign(ctx, addr, []byte("secret-payload"))
+	require.NoError(t, err)
+	_, err = a.WalletImport(ctx, &types.KeyInfo{Type: types.KTSecp256k1, PrivateKey: []byte("secret-key")})
+	require.NoError(t, err)
+	_, err = a.AuthNew(ctx, []auth.Permission{api.PermAdmin})
+	require.NoError(t, err)
+	// read calls aren't audited
+	_, err = a.WalletBalance(ctx, addr)
+	require.NoError(t, err)
+
+	require.Len(t, j.evts, 3)
+
+	sign := j.evts[0]
+	require.Equal(t, "WalletSign", sign.Method)
+	require.Equal(t, "unauthenticated", sign.Token)
+	addrParam, err := json.Marshal(addr)
+	require.NoError(t, err)
+	require.Equal(t, []json.RawMessage{addrParam, redactedParam}, sign.Params)
+
+	imp := j.evts[1]
+	require.Equal(t, "WalletImport", imp.Method)
+	require.Equal(t, []json.RawMessage{redactedParam}, imp.Params)
+
+	// only the parameters are recorded, not the token created
+	authNew := j.evts[2]
+	require.Equal(t, "AuthNew", authNew.Method)
+	require.Equal(t, []json.RawMessage{json.RawMessage(`["admin"]`)}, authNew.Params)
+}
@@ -1,11 +1,14 @@
 package journal
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/lotus/build"
@@ -24,6 +27,9 @@ type fsJournal struct {
 	fi    *os.File
 	fSize int64
 
+	// index holds the entries for queries, see dsIndex
+	index *dsIndex
+
 	incoming chan *Event
 
 	closing chan struct{}
@@ -31,17 +37,24 @@ type fsJournal struct {
 }
 
 // OpenFSJournal constructs a rolling filesystem journal, with a default
-// per-file size limit of 1GiB.
+// per-file size limit of 1GiB. Entries are also indexed in the metadata
+// datastore of the repo, which serves queries.
 func OpenFSJournal(lr repo.LockedRepo, disabled DisabledEvents) (Journal, error) {
 	dir := filepath.Join(lr.Path(), "journal")
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to mk directory %s for file journal: %w", dir, err)
 	}
 
+	mds, err := lr.Datastore(context.TODO(), "/metadata")
+	if err != nil {
+		return nil, xerrors.Errorf("opening journal index datastore: %w", err)
+	}
+
 	f := &fsJournal{
 		EventTypeRegistry: NewEventTypeRegistry(disabled),
 		dir:               dir,
 		sizeLimit:         1 << 30,
+		index:             newDSIndex(namespace.Wrap(mds, datastore.NewKey("/journal"))),
 		incoming:          make(chan *Event, 32),
 		closing:           make(chan struct{}),
 		closed:            make(chan struct{}),
@@ -97,6 +110,12 @@ func (f *fsJournal) putEvent(evt *Event) error {
 
 	f.fSize += int64(n)
 
+	if f.index != nil {
+		if err := f.index.put(evt); err != nil {
+			log.Errorw("failed to index journal event", "event", evt, "err", err)
+		}
+	}
+
 	if f.fSize >= f.sizeLimit {
 		_ = f.rollJournalFile()
 	}
@@ -109,7 +128,7 @@ func (f *fsJournal) rollJournalFile() error {
 		_ = f.fi.Close()
 	}
 
-	nfi, err := os.Create(filepath.Join(f.dir, fmt.Sprintf("lotus-journal-%s.ndjson", build.Clock.Now().Format(RFC3339nocolon))))
+	nfi, err := os.Create(filepath.Join(f.dir, journalFilePrefix+build.Clock.Now().Format(RFC3339nocolon)+journalFileSuffix))
 	if err != nil {
 		return xerrors.Errorf("failed to open journal file: %w", err)
 	}
@@ -0,0 +1,159 @@
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+)
+
+// dsIndex keeps a copy of the journal entries in a datastore, keyed by
+// /<system>/<event>/<timestamp>/<seq>, so that queries only read the entries
+// of the requested event types, in time order.
+//
+// The timestamp is in nanoseconds since the epoch, zero padded, so that keys
+// sort in time order; seq orders entries recorded at the same time.
+type dsIndex struct {
+	ds  datastore.Batching
+	seq uint64
+}
+
+func newDSIndex(ds datastore.Batching) *dsIndex {
+	return &dsIndex{ds: ds}
+}
+
+func indexEntryKey(evt *Event, seq uint64) datastore.Key {
+	return datastore.KeyWithNamespaces([]string{
+		evt.System,
+		evt.Event,
+		fmt.Sprintf("%019d", evt.Timestamp.UnixNano()),
+		fmt.Sprintf("%010d", seq),
+	})
+}
+
+// indexEntryTime returns the timestamp encoded in the key of an entry
+func indexEntryTime(k string) (time.Time, error) {
+	ns := datastore.RawKey(k).Namespaces()
+	if len(ns) != 4 {
+		return time.Time{}, xerrors.Errorf("malformed journal index key %s", k)
+	}
+	ts, err := strconv.ParseInt(ns[2], 10, 64)
+	if err != nil {
+		return time.Time{}, xerrors.Errorf("malformed journal index key %s: %w", k, err)
+	}
+	return time.Unix(0, ts), nil
+}
+
+// indexPrefix returns the key prefix of the entries of the event type, and
+// whether the entries under it are in time order, that is when the prefix
+// selects a single event type.
+func indexPrefix(et EventType) (string, bool) {
+	switch {
+	case et.System == "":
+		return "/", false
+	case et.Event == "":
+		return datastore.NewKey(et.System).String(), false
+	default:
+		return datastore.KeyWithNamespaces([]string{et.System, et.Event}).String(), true
+	}
+}
+
+func (ix *dsIndex) put(evt *Event) error {
+	b, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+
+	ix.seq++
+	return ix.ds.Put(indexEntryKey(evt, ix.seq), b)
+}
+
+func (ix *dsIndex) query(ctx context.Context, q *Query) ([]*Event, error) {
+	types := q.Types
+	if len(types) == 0 {
+		types = []EventType{{}}
+	}
+
+	var out []*Event
+	seen := map[string]struct{}{}
+	for _, et := range types {
+		var err error
+		out, err = ix.queryPrefix(ctx, et, q, seen, out)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Timestamp.Before(out[j].Timestamp)
+	})
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[:q.Limit]
+	}
+
+	return out, nil
+}
+
+func (ix *dsIndex) queryPrefix(ctx context.Context, et EventType, q *Query, seen map[string]struct{}, out []*Event) ([]*Event, error) {
+	prefix, ordered := indexPrefix(et)
+	res, err := ix.ds.Query(query.Query{
+		Prefix: prefix,
+		Orders: []query.Order{query.OrderByKey{}},
+	})
+	if err != nil {
+		return out, xerrors.Errorf("querying journal index: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	n := 0
+	for r := range res.Next() {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		if r.Error != nil {
+			return out, xerrors.Errorf("reading journal index: %w", r.Error)
+		}
+		if _, ok := seen[r.Key]; ok {
+			// selected by an earlier, overlapping event type
+			continue
+		}
+
+		ts, err := indexEntryTime(r.Key)
+		if err != nil {
+			return out, err
+		}
+		if !q.To.IsZero() && ts.After(q.To) {
+			if ordered {
+				break
+			}
+			continue
+		}
+		if !q.From.IsZero() && ts.Before(q.From) {
+			continue
+		}
+
+		var evt Event
+		if err := json.Unmarshal(r.Value, &evt); err != nil {
+			return out, xerrors.Errorf("decoding journal entry %s: %w", r.Key, err)
+		}
+		if !q.matches(&evt) {
+			continue
+		}
+
+		seen[r.Key] = struct{}{}
+		out = append(out, &evt)
+
+		n++
+		if ordered && q.Limit > 0 && n >= q.Limit {
+			break
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,97 @@
+package journal
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+func TestIndexQuery(t *testing.T) {
+	base := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(m int) time.Time { return base.Add(time.Duration(m) * time.Minute) }
+	evt := func(system, event string, m int) *Event {
+		return &Event{
+			EventType: EventType{System: system, Event: event},
+			Timestamp: at(m),
+			Data:      map[string]interface{}{"minute": m},
+		}
+	}
+
+	ix := newDSIndex(datastore.NewMapDatastore())
+	for _, e := range []*Event{
+		evt("wdpost", "scheduler", 1),
+		evt("storage", "sealing_states", 2),
+		evt("wdpost", "proofs_processed", 3),
+		evt("wdpost", "scheduler", 11),
+		evt("storage", "sealing_states", 12),
+		evt("storage", "sealing_states", 12),
+	} {
+		require.NoError(t, ix.put(e))
+	}
+
+	minutes := func(evts []*Event) []int {
+		var out []int
+		for _, e := range evts {
+			out = append(out, int(e.Data.(map[string]interface{})["minute"].(float64)))
+		}
+		return out
+	}
+	query := func(q Query) []int {
+		res, err := ix.query(context.Background(), &q)
+		require.NoError(t, err)
+		return minutes(res)
+	}
+
+	require.Equal(t, []int{1, 2, 3, 11, 12, 12}, query(Query{}))
+	require.Equal(t, []int{1, 3, 11}, query(Query{Types: []EventType{{System: "wdpost"}}}))
+	require.Equal(t, []int{1, 11}, query(Query{Types: []EventType{{System: "wdpost", Event: "scheduler"}}}))
+	require.Equal(t, []int{1, 11}, query(Query{Types: []EventType{{Event: "scheduler"}}}))
+	require.Equal(t, []int{3, 11}, query(Query{From: at(3), To: at(11)}))
+	require.Equal(t, []int{11, 12, 12}, query(Query{From: at(11)}))
+	require.Equal(t, []int{1, 2}, query(Query{Limit: 2}))
+
+	// overlapping types select entries once, the limit applies to the merged
+	// results
+	require.Equal(t, []int{1, 2, 3, 11, 12, 12}, query(Query{Types: []EventType{{System: "wdpost"}, {System: "wdpost", Event: "scheduler"}, {System: "storage"}}}))
+	require.Equal(t, []int{1, 2, 3}, query(Query{Types: []EventType{{System: "wdpost", Event: "scheduler"}, {System: "storage"}, {System: "wdpost"}}, Limit: 3}))
+	require.Equal(t, []int{2, 11}, query(Query{Types: []EventType{{System: "wdpost", Event: "scheduler"}, {System: "storage"}}, From: at(2), Limit: 2}))
+
+	require.Empty(t, query(Query{Types: []EventType{{System: "unknown"}}}))
+}
+
+func TestFSJournalQuery(t *testing.T) {
+	lr, err := repo.NewMemory(nil).Lock(repo.FullNode)
+	require.NoError(t, err)
+	defer lr.Close() //nolint:errcheck
+
+	j, err := OpenFSJournal(lr, nil)
+	require.NoError(t, err)
+
+	scheduler := j.RegisterEventType("wdpost", "scheduler")
+	sealing := j.RegisterEventType("storage", "sealing_states")
+	for i := 0; i < 3; i++ {
+		n := i
+		j.RecordEvent(scheduler, func() interface{} { return n })
+		j.RecordEvent(sealing, func() interface{} { return n })
+	}
+	require.NoError(t, j.Close())
+
+	res, err := j.(Reader).Query(context.Background(), Query{Types: []EventType{{System: "wdpost"}}})
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	for i, e := range res {
+		require.Equal(t, "scheduler", e.Event)
+		require.EqualValues(t, i, e.Data)
+	}
+
+	// the journal files hold the same entries
+	all, err := QueryDir(context.Background(), filepath.Join(lr.Path(), "journal"), Query{})
+	require.NoError(t, err)
+	require.Len(t, all, 6)
+}
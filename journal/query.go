@@ -0,0 +1,179 @@
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	journalFilePrefix = "lotus-journal-"
+	journalFileSuffix = ".ndjson"
+)
+
+// Query selects journal entries for replay.
+type Query struct {
+	// Types restricts the results to the given event types. An empty System
+	// or Event acts as a wildcard, so {System: "wdpost"} selects every window
+	// PoSt event. An empty list selects all event types.
+	Types []EventType
+
+	// From and To bound the timestamps of returned entries (inclusive). Zero
+	// values leave the corresponding side of the range open.
+	From time.Time
+	To   time.Time
+
+	// Limit caps the number of returned entries; 0 means no limit.
+	Limit int
+}
+
+func (q *Query) matches(evt *Event) bool {
+	if !q.From.IsZero() && evt.Timestamp.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && evt.Timestamp.After(q.To) {
+		return false
+	}
+	if len(q.Types) == 0 {
+		return true
+	}
+	for _, et := range q.Types {
+		if (et.System == "" || et.System == evt.System) && (et.Event == "" || et.Event == evt.Event) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reader is implemented by journals whose entries can be read back.
+type Reader interface {
+	// Query returns the journal entries matching the query, in the order in
+	// which they were recorded.
+	Query(ctx context.Context, q Query) ([]*Event, error)
+}
+
+// Query reads the entries from the index of the journal. Entries recorded
+// before the index was introduced are only found by QueryDir.
+func (f *fsJournal) Query(ctx context.Context, q Query) ([]*Event, error) {
+	if f.index == nil {
+		return QueryDir(ctx, f.dir, q)
+	}
+	return f.index.query(ctx, &q)
+}
+
+type journalFile struct {
+	path  string
+	start time.Time
+}
+
+// listJournalFiles returns the journal files in dir, ordered by the time
+// they were opened. The opening time is encoded in the file name, and acts
+// as the index used to skip files that can't hold entries in a given range.
+func listJournalFiles(dir string) ([]journalFile, error) {
+	ents, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, xerrors.Errorf("reading journal directory: %w", err)
+	}
+
+	var out []journalFile
+	for _, ent := range ents {
+		name := ent.Name()
+		if ent.IsDir() || !strings.HasPrefix(name, journalFilePrefix) || !strings.HasSuffix(name, journalFileSuffix) {
+			continue
+		}
+
+		ts := strings.TrimSuffix(strings.TrimPrefix(name, journalFilePrefix), journalFileSuffix)
+		start, err := time.Parse(RFC3339nocolon, ts)
+		if err != nil {
+			log.Warnw("skipping journal file with malformed name", "file", name, "error", err)
+			continue
+		}
+
+		out = append(out, journalFile{path: filepath.Join(dir, name), start: start})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].start.Before(out[j].start)
+	})
+
+	return out, nil
+}
+
+// QueryDir runs a query against the filesystem journal stored in dir, reading
+// the journal files. It can be used on the journal of a node that isn't
+// running.
+func QueryDir(ctx context.Context, dir string, q Query) ([]*Event, error) {
+	files, err := listJournalFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*Event
+	for i, jf := range files {
+		if !q.To.IsZero() && jf.start.After(q.To) {
+			// this file, and all following ones, were opened after the end of
+			// the range.
+			break
+		}
+		if !q.From.IsZero() && i+1 < len(files) && files[i+1].start.Before(q.From) {
+			// the journal rolled to the next file before the start of the range.
+			continue
+		}
+
+		out, err = readJournalFile(ctx, jf.path, &q, out)
+		if err != nil {
+			return nil, err
+		}
+		if q.Limit > 0 && len(out) >= q.Limit {
+			break
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Timestamp.Before(out[j].Timestamp)
+	})
+
+	return out, nil
+}
+
+func readJournalFile(ctx context.Context, path string, q *Query, out []*Event) ([]*Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return out, xerrors.Errorf("opening journal file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	dec := json.NewDecoder(f)
+	for {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+
+		var evt Event
+		if err := dec.Decode(&evt); err != nil {
+			if err != io.EOF {
+				// most likely an entry that is still being written; the rest of
+				// the file can't be decoded reliably.
+				log.Debugw("stopped reading journal file", "file", path, "error", err)
+			}
+			return out, nil
+		}
+
+		if !q.matches(&evt) {
+			continue
+		}
+
+		out = append(out, &evt)
+		if q.Limit > 0 && len(out) >= q.Limit {
+			return out, nil
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeJournalFile(t *testing.T, dir string, start time.Time, evts ...*Event) {
+	f, err := os.Create(filepath.Join(dir, journalFilePrefix+start.Format(RFC3339nocolon)+journalFileSuffix))
+	require.NoError(t, err)
+	defer f.Close() //nolint:errcheck
+
+	for _, evt := range evts {
+		b, err := json.Marshal(evt)
+		require.NoError(t, err)
+		_, err = f.Write(append(b, '\n'))
+		require.NoError(t, err)
+	}
+}
+
+func TestQueryDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal-query")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	base := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(m int) time.Time { return base.Add(time.Duration(m) * time.Minute) }
+	evt := func(system, event string, m int) *Event {
+		return &Event{
+			EventType: EventType{System: system, Event: event},
+			Timestamp: at(m),
+			Data:      map[string]interface{}{"minute": m},
+		}
+	}
+
+	writeJournalFile(t, dir, at(0),
+		evt("wdpost", "scheduler", 1),
+		evt("storage", "sealing_states", 2),
+		evt("wdpost", "proofs_processed", 3),
+	)
+	writeJournalFile(t, dir, at(10),
+		evt("wdpost", "scheduler", 11),
+		evt("storage", "sealing_states", 12),
+	)
+
+	minutes := func(evts []*Event) []int {
+		var out []int
+		for _, e := range evts {
+			out = append(out, int(e.Data.(map[string]interface{})["minute"].(float64)))
+		}
+		return out
+	}
+
+	ctx := context.Background()
+
+	res, err := QueryDir(ctx, dir, Query{})
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3, 11, 12}, minutes(res))
+
+	res, err = QueryDir(ctx, dir, Query{Types: []EventType{{System: "wdpost"}}})
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 3, 11}, minutes(res))
+
+	res, err = QueryDir(ctx, dir, Query{Types: []EventType{{System: "wdpost", Event: "scheduler"}}})
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 11}, minutes(res))
+
+	res, err = QueryDir(ctx, dir, Query{From: at(3), To: at(11)})
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 11}, minutes(res))
+
+	res, err = QueryDir(ctx, dir, Query{From: at(11)})
+	require.NoError(t, err)
+	require.Equal(t, []int{11, 12}, minutes(res))
+
+	res, err = QueryDir(ctx, dir, Query{Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2}, minutes(res))
+}
@@ -83,3 +83,48 @@ func TestLogRestore(t *testing.T) {
 
 	checkVals(t, ds2, 0, 20, true)
 }
+
+func TestIncrementalRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backupds-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	ds1 := datastore.NewMapDatastore()
+	putVals(t, ds1, 0, 10)
+
+	bds, err := Wrap(ds1, NoLogdir)
+	require.NoError(t, err)
+
+	snap, err := bds.BackupIncremental(dir, map[string][]byte{"libp2p-host": []byte("key")})
+	require.NoError(t, err)
+	require.True(t, snap.Full)
+	require.Equal(t, 10, snap.Entries)
+
+	putVals(t, ds1, 10, 15)
+	require.NoError(t, ds1.Delete(datastore.NewKey("3")))
+
+	snap, err = bds.BackupIncremental(dir, nil)
+	require.NoError(t, err)
+	require.False(t, snap.Full)
+	require.Equal(t, 5, snap.Entries)
+	require.Equal(t, []string{"/3"}, snap.Deleted)
+
+	ds2 := datastore.NewMapDatastore()
+	ks, err := RestoreIncrementalInto(dir, ds2)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"libp2p-host": []byte("key")}, ks)
+
+	checkVals(t, ds2, 0, 3, true)
+	checkVals(t, ds2, 3, 4, false)
+	checkVals(t, ds2, 4, 15, true)
+
+	// a corrupted snapshot fails the restore
+	f, err := os.OpenFile(filepath.Join(dir, snap.File), os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = RestoreIncrementalInto(dir, datastore.NewMapDatastore())
+	require.Error(t, err)
+}
@@ -53,12 +53,23 @@ func Wrap(child datastore.Batching, logdir string) (*Datastore, error) {
 // Writes a datastore dump into the provided writer as
 // [array(*) of [key, value] tuples, checksum]
 func (d *Datastore) Backup(out io.Writer) error {
+	_, err := d.backup(out, nil)
+	return err
+}
+
+// Summary maps the keys of a datastore to the hashes of their values
+type Summary map[string][sha256.Size]byte
+
+// backup writes the entries which changed since the state summarized by prev,
+// all of them if prev is nil, and returns the summary of the current state
+func (d *Datastore) backup(out io.Writer, prev Summary) (Summary, error) {
 	scratch := make([]byte, 9)
 
 	if err := cbg.WriteMajorTypeHeaderBuf(scratch, out, cbg.MajArray, 2); err != nil {
-		return xerrors.Errorf("writing tuple header: %w", err)
+		return nil, xerrors.Errorf("writing tuple header: %w", err)
 	}
 
+	cur := Summary{}
 	hasher := sha256.New()
 	hout := io.MultiWriter(hasher, out)
 
@@ -66,7 +77,7 @@ func (d *Datastore) Backup(out io.Writer) error {
 	{
 		// write indefinite length array header
 		if _, err := hout.Write([]byte{0x9f}); err != nil {
-			return xerrors.Errorf("writing header: %w", err)
+			return nil, xerrors.Errorf("writing header: %w", err)
 		}
 
 		d.backupLk.Lock()
@@ -77,7 +88,7 @@ func (d *Datastore) Backup(out io.Writer) error {
 
 		qr, err := d.child.Query(query.Query{})
 		if err != nil {
-			return xerrors.Errorf("query: %w", err)
+			return nil, xerrors.Errorf("query: %w", err)
 		}
 		defer func() {
 			if err := qr.Close(); err != nil {
@@ -87,30 +98,40 @@ func (d *Datastore) Backup(out io.Writer) error {
 		}()
 
 		for result := range qr.Next() {
+			if result.Error != nil {
+				return nil, xerrors.Errorf("query result: %w", result.Error)
+			}
+
+			h := sha256.Sum256(result.Value)
+			cur[result.Key] = h
+			if ph, ok := prev[result.Key]; ok && ph == h {
+				continue
+			}
+
 			if err := cbg.WriteMajorTypeHeaderBuf(scratch, hout, cbg.MajArray, 2); err != nil {
-				return xerrors.Errorf("writing tuple header: %w", err)
+				return nil, xerrors.Errorf("writing tuple header: %w", err)
 			}
 
 			if err := cbg.WriteMajorTypeHeaderBuf(scratch, hout, cbg.MajByteString, uint64(len([]byte(result.Key)))); err != nil {
-				return xerrors.Errorf("writing key header: %w", err)
+				return nil, xerrors.Errorf("writing key header: %w", err)
 			}
 
 			if _, err := hout.Write([]byte(result.Key)[:]); err != nil {
-				return xerrors.Errorf("writing key: %w", err)
+				return nil, xerrors.Errorf("writing key: %w", err)
 			}
 
 			if err := cbg.WriteMajorTypeHeaderBuf(scratch, hout, cbg.MajByteString, uint64(len(result.Value))); err != nil {
-				return xerrors.Errorf("writing value header: %w", err)
+				return nil, xerrors.Errorf("writing value header: %w", err)
 			}
 
 			if _, err := hout.Write(result.Value[:]); err != nil {
-				return xerrors.Errorf("writing value: %w", err)
+				return nil, xerrors.Errorf("writing value: %w", err)
 			}
 		}
 
 		// array break
 		if _, err := hout.Write([]byte{0xff}); err != nil {
-			return xerrors.Errorf("writing array 'break': %w", err)
+			return nil, xerrors.Errorf("writing array 'break': %w", err)
 		}
 	}
 
@@ -119,15 +140,15 @@ func (d *Datastore) Backup(out io.Writer) error {
 		sum := hasher.Sum(nil)
 
 		if err := cbg.WriteMajorTypeHeaderBuf(scratch, hout, cbg.MajByteString, uint64(len(sum))); err != nil {
-			return xerrors.Errorf("writing checksum header: %w", err)
+			return nil, xerrors.Errorf("writing checksum header: %w", err)
 		}
 
 		if _, err := hout.Write(sum[:]); err != nil {
-			return xerrors.Errorf("writing checksum: %w", err)
+			return nil, xerrors.Errorf("writing checksum: %w", err)
 		}
 	}
 
-	return nil
+	return cur, nil
 }
 
 // proxy
@@ -0,0 +1,299 @@
+package backupds
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"golang.org/x/xerrors"
+)
+
+const manifestName = "manifest.json"
+
+// Manifest lists the snapshots of an incremental backup directory, oldest
+// first. The first snapshot holds all entries of the datastore, the following
+// ones the entries changed since the previous snapshot.
+type Manifest struct {
+	Snapshots []Snapshot
+}
+
+// Snapshot is a backup of the datastore, and of the keystore, taken at once
+type Snapshot struct {
+	Time int64
+	Full bool
+
+	// File with the datastore entries, in the format of Datastore.Backup
+	File   string
+	Sha256 string
+	// Entries is the number of entries in File
+	Entries int
+	// Deleted lists the keys deleted since the previous snapshot
+	Deleted []string `json:",omitempty"`
+
+	// KeystoreFile holds all the keystore entries, in the same format, empty
+	// if no keystore was backed up
+	KeystoreFile   string `json:",omitempty"`
+	KeystoreSha256 string `json:",omitempty"`
+}
+
+// BackupIncremental adds a snapshot of the datastore and of the given
+// keystore entries to the backup directory, holding only the datastore
+// entries changed since the last snapshot. The existing snapshots are verified
+// first; a new directory starts with a full snapshot.
+func (d *Datastore) BackupIncremental(dir string, keystore map[string][]byte) (*Snapshot, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, xerrors.Errorf("creating backup dir: %w", err)
+	}
+
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var prev Summary
+	if len(m.Snapshots) > 0 {
+		entries, _, err := readSnapshots(dir, m)
+		if err != nil {
+			return nil, xerrors.Errorf("verifying previous snapshots: %w", err)
+		}
+
+		prev = Summary{}
+		for k, v := range entries {
+			prev[k] = sha256.Sum256(v)
+		}
+	}
+
+	seq := len(m.Snapshots)
+	snap := Snapshot{
+		Time: time.Now().Unix(),
+		Full: prev == nil,
+		File: fmt.Sprintf("%06d-metadata.cbor", seq),
+	}
+
+	var cur Summary
+	snap.Sha256, err = writeSnapshotFile(filepath.Join(dir, snap.File), func(w io.Writer) error {
+		var err error
+		cur, err = d.backup(w, prev)
+		return err
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("writing metadata snapshot: %w", err)
+	}
+
+	for k, h := range cur {
+		if ph, ok := prev[k]; !ok || ph != h {
+			snap.Entries++
+		}
+	}
+	for k := range prev {
+		if _, ok := cur[k]; !ok {
+			snap.Deleted = append(snap.Deleted, k)
+		}
+	}
+	sort.Strings(snap.Deleted)
+
+	if keystore != nil {
+		kds := dssync.MutexWrap(datastore.NewMapDatastore())
+		for name, v := range keystore {
+			if err := kds.Put(datastore.NewKey(name), v); err != nil {
+				return nil, xerrors.Errorf("putting keystore entry: %w", err)
+			}
+		}
+		kbds, err := Wrap(kds, NoLogdir)
+		if err != nil {
+			return nil, err
+		}
+
+		snap.KeystoreFile = fmt.Sprintf("%06d-keystore.cbor", seq)
+		snap.KeystoreSha256, err = writeSnapshotFile(filepath.Join(dir, snap.KeystoreFile), kbds.Backup)
+		if err != nil {
+			return nil, xerrors.Errorf("writing keystore snapshot: %w", err)
+		}
+	}
+
+	m.Snapshots = append(m.Snapshots, snap)
+	if err := writeManifest(dir, m); err != nil {
+		return nil, err
+	}
+
+	return &snap, nil
+}
+
+// ReadManifest reads the manifest of a backup directory, which is empty if
+// the directory has no snapshots yet
+func ReadManifest(dir string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, manifestName))
+	switch {
+	case os.IsNotExist(err):
+		return &Manifest{}, nil
+	case err != nil:
+		return nil, xerrors.Errorf("reading manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, xerrors.Errorf("decoding manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// ReadIncremental verifies all snapshots of a backup directory, and returns
+// the datastore entries at the time of the last one, and the keystore entries
+// of the last snapshot with a keystore
+func ReadIncremental(dir string) (entries map[string][]byte, keystore map[string][]byte, err error) {
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(m.Snapshots) == 0 {
+		return nil, nil, xerrors.Errorf("no snapshots in %s", dir)
+	}
+
+	return readSnapshots(dir, m)
+}
+
+// RestoreIncrementalInto writes the datastore entries of a backup directory
+// into dest, after verifying all snapshots, and returns the keystore entries
+func RestoreIncrementalInto(dir string, dest datastore.Batching) (map[string][]byte, error) {
+	entries, keystore, err := ReadIncremental(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := dest.Batch()
+	if err != nil {
+		return nil, xerrors.Errorf("creating batch: %w", err)
+	}
+
+	for k, v := range entries {
+		if err := batch.Put(datastore.NewKey(k), v); err != nil {
+			return nil, xerrors.Errorf("put key: %w", err)
+		}
+	}
+
+	if err := batch.Commit(); err != nil {
+		return nil, xerrors.Errorf("committing batch: %w", err)
+	}
+
+	return keystore, nil
+}
+
+func readSnapshots(dir string, m *Manifest) (map[string][]byte, map[string][]byte, error) {
+	if !m.Snapshots[0].Full {
+		return nil, nil, xerrors.Errorf("first snapshot %s isn't a full snapshot", m.Snapshots[0].File)
+	}
+
+	entries := map[string][]byte{}
+	var keystore map[string][]byte
+
+	for _, snap := range m.Snapshots {
+		if snap.Full {
+			entries = map[string][]byte{}
+		}
+
+		err := readSnapshotFile(filepath.Join(dir, snap.File), snap.Sha256, func(key datastore.Key, value []byte) {
+			entries[key.String()] = value
+		})
+		if err != nil {
+			return nil, nil, xerrors.Errorf("snapshot %s: %w", snap.File, err)
+		}
+
+		for _, k := range snap.Deleted {
+			delete(entries, k)
+		}
+
+		if snap.KeystoreFile == "" {
+			continue
+		}
+		keystore = map[string][]byte{}
+		err = readSnapshotFile(filepath.Join(dir, snap.KeystoreFile), snap.KeystoreSha256, func(key datastore.Key, value []byte) {
+			keystore[key.BaseNamespace()] = value
+		})
+		if err != nil {
+			return nil, nil, xerrors.Errorf("keystore snapshot %s: %w", snap.KeystoreFile, err)
+		}
+	}
+
+	return entries, keystore, nil
+}
+
+func readSnapshotFile(path string, expSum string, cb func(key datastore.Key, value []byte)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck // The file is RO
+
+	hasher := sha256.New()
+	_, err = ReadBackup(io.TeeReader(f, hasher), func(key datastore.Key, value []byte, log bool) error {
+		if log {
+			return xerrors.Errorf("unexpected log entry")
+		}
+		cb(key, value)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if sum := hex.EncodeToString(hasher.Sum(nil)); sum != expSum {
+		return xerrors.Errorf("file checksum didn't match; expected %s, got %s", expSum, sum)
+	}
+	return nil
+}
+
+// writeSnapshotFile writes a snapshot file in place once it's complete, and
+// returns its checksum
+func writeSnapshotFile(path string, write func(io.Writer) error) (string, error) {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	hasher := sha256.New()
+	if err := write(io.MultiWriter(f, hasher)); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return "", err
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func writeManifest(dir string, m *Manifest) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("encoding manifest: %w", err)
+	}
+
+	_, err = writeSnapshotFile(filepath.Join(dir, manifestName), func(w io.Writer) error {
+		_, err := w.Write(b)
+		return err
+	})
+	if err != nil {
+		return xerrors.Errorf("writing manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package backupds
+
+import (
+	"encoding/json"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// KeystoreEntries encodes all keys of a keystore for BackupIncremental
+func KeystoreEntries(ks types.KeyStore) (map[string][]byte, error) {
+	names, err := ks.List()
+	if err != nil {
+		return nil, xerrors.Errorf("listing keys: %w", err)
+	}
+
+	out := map[string][]byte{}
+	for _, name := range names {
+		ki, err := ks.Get(name)
+		if err != nil {
+			return nil, xerrors.Errorf("getting key %s: %w", name, err)
+		}
+
+		out[name], err = json.Marshal(ki)
+		if err != nil {
+			return nil, xerrors.Errorf("encoding key %s: %w", name, err)
+		}
+	}
+
+	return out, nil
+}
+
+// RestoreKeystore puts the keys returned by RestoreIncrementalInto into the
+// keystore
+func RestoreKeystore(entries map[string][]byte, ks types.KeyStore) error {
+	for name, v := range entries {
+		var ki types.KeyInfo
+		if err := json.Unmarshal(v, &ki); err != nil {
+			return xerrors.Errorf("decoding key %s: %w", name, err)
+		}
+
+		if err := ks.Put(name, ki); err != nil {
+			return xerrors.Errorf("putting key %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
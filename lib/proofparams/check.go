@@ -0,0 +1,110 @@
+package proofparams
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/blake2b-simd"
+	"golang.org/x/xerrors"
+)
+
+// ErrMissing is returned by Check for files which haven't been fetched
+var ErrMissing = xerrors.New("file missing")
+
+// Check hashes the file and compares it with the digest of the manifest
+func Check(ctx context.Context, dir string, f File) error {
+	fd, err := os.Open(f.path(dir))
+	if os.IsNotExist(err) {
+		return ErrMissing
+	}
+	if err != nil {
+		return err
+	}
+	defer fd.Close() // nolint:errcheck
+
+	h := blake2b.New512()
+	if _, err := io.Copy(h, &ctxReader{ctx: ctx, r: fd}); err != nil {
+		return xerrors.Errorf("hashing %s: %w", f.Name, err)
+	}
+
+	// the manifests only have the first half of the digest
+	if sum := hex.EncodeToString(h.Sum(nil)[:16]); sum != f.Digest {
+		return xerrors.Errorf("digest of %s is %s, expected %s", f.Name, sum, f.Digest)
+	}
+	return nil
+}
+
+// Verify checks the files, and returns the missing and corrupted ones
+func Verify(ctx context.Context, dir string, files []File) ([]File, error) {
+	var bad []File
+	for _, f := range files {
+		err := Check(ctx, dir, f)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			log.Warnw("parameter file check failed", "file", f.Name, "error", err)
+			bad = append(bad, f)
+		}
+	}
+	return bad, nil
+}
+
+// Prune removes the parameter files in the directory which aren't in keep:
+// files for other sector sizes and files of older parameter versions, along
+// with their unfinished downloads. It returns the removed files and the
+// number of bytes freed.
+func Prune(dir string, keep []File) ([]string, int64, error) {
+	keepNames := map[string]struct{}{}
+	for _, f := range keep {
+		keepNames[f.Name] = struct{}{}
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, 0, xerrors.Errorf("listing parameter dir: %w", err)
+	}
+
+	var removed []string
+	var freed int64
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimSuffix(e.Name(), stateSuffix), partSuffix)
+		switch filepath.Ext(name) {
+		case ".params", ".vk", ".srs":
+		default:
+			// not a parameter file, e.g. a lock file
+			continue
+		}
+		if _, ok := keepNames[name]; ok {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			return removed, freed, xerrors.Errorf("removing %s: %w", e.Name(), err)
+		}
+		removed = append(removed, e.Name())
+		freed += e.Size()
+	}
+	return removed, freed, nil
+}
+
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,388 @@
+package proofparams
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// DefaultGateway is the mirror used when none are configured and IPFS_GATEWAY
+// isn't set
+const DefaultGateway = "https://proofs.filecoin.io/ipfs/"
+
+// DefaultSegments is the number of parallel requests downloading a file
+const DefaultSegments = 4
+
+const (
+	partSuffix  = ".part"
+	stateSuffix = ".json"
+)
+
+// how often the progress of downloads is saved
+var saveInterval = 5 * time.Second
+
+// Fetcher downloads parameter files. Files are downloaded in segments with
+// parallel ranged requests, into a partial file whose progress is saved next
+// to it, so that interrupted downloads resume where they stopped.
+type Fetcher struct {
+	// Mirrors are URL prefixes which the CIDs of the files are appended to,
+	// tried in order
+	Mirrors []string
+
+	// Segments is the number of parallel requests downloading a file
+	Segments int
+
+	Client *http.Client
+}
+
+func (f *Fetcher) mirrors() []string {
+	if len(f.Mirrors) > 0 {
+		return f.Mirrors
+	}
+	if gw := os.Getenv("IPFS_GATEWAY"); gw != "" {
+		return []string{gw}
+	}
+	return []string{DefaultGateway}
+}
+
+func (f *Fetcher) segments() int {
+	if f.Segments > 0 {
+		return f.Segments
+	}
+	return DefaultSegments
+}
+
+func (f *Fetcher) client() *http.Client {
+	if f.Client != nil {
+		return f.Client
+	}
+	return http.DefaultClient
+}
+
+// Fetch downloads the files which are missing or fail their check
+func (f *Fetcher) Fetch(ctx context.Context, dir string, files []File) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return xerrors.Errorf("creating parameter dir: %w", err)
+	}
+
+	for _, file := range files {
+		err := Check(ctx, dir, file)
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != ErrMissing {
+			log.Warnw("fetching corrupted parameter file again", "file", file.Name, "error", err)
+			if err := os.Remove(file.path(dir)); err != nil {
+				return xerrors.Errorf("removing corrupted file: %w", err)
+			}
+		}
+
+		if err := f.fetchFile(ctx, dir, file); err != nil {
+			return xerrors.Errorf("fetching %s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
+
+func (f *Fetcher) fetchFile(ctx context.Context, dir string, file File) error {
+	var errs []string
+	for _, m := range f.mirrors() {
+		u := strings.TrimSuffix(m, "/") + "/" + file.Cid
+		log.Infow("fetching parameter file", "file", file.Name, "url", u)
+
+		if err := f.download(ctx, u, file.path(dir)); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Warnw("parameter download failed", "file", file.Name, "url", u, "error", err)
+			errs = append(errs, fmt.Sprintf("%s: %s", m, err))
+			continue
+		}
+
+		if err := Check(ctx, dir, file); err != nil {
+			_ = os.Remove(file.path(dir))
+			errs = append(errs, fmt.Sprintf("%s: %s", m, err))
+			continue
+		}
+		log.Infow("fetched parameter file", "file", file.Name)
+		return nil
+	}
+	return xerrors.Errorf("all mirrors failed: %s", strings.Join(errs, "; "))
+}
+
+// download fetches the URL into dst, resuming the partial file if there is one
+func (f *Fetcher) download(ctx context.Context, u, dst string) error {
+	part, statePath := dst+partSuffix, dst+partSuffix+stateSuffix
+
+	size, ranges := f.probe(ctx, u)
+	if !ranges || size <= 0 {
+		// can't resume or split without ranges
+		_ = os.Remove(statePath)
+		if err := f.downloadWhole(ctx, u, part); err != nil {
+			return err
+		}
+		return os.Rename(part, dst)
+	}
+
+	st, err := loadState(statePath)
+	if err != nil || st.Size != size {
+		st = newState(size, f.segments())
+	} else {
+		log.Infow("resuming parameter download", "file", dst, "done", st.done(), "size", size)
+	}
+
+	fd, err := os.OpenFile(part, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer fd.Close() // nolint:errcheck
+	if err := fd.Truncate(size); err != nil {
+		return err
+	}
+
+	var lk sync.Mutex
+	save := func() error {
+		lk.Lock()
+		b, err := json.Marshal(st)
+		lk.Unlock()
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(statePath, b, 0644)
+	}
+
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(st.Segments))
+	for i := range st.Segments {
+		s := &st.Segments[i]
+		go func() {
+			errs <- f.fetchSegment(sctx, u, fd, s, &lk)
+		}()
+	}
+
+	ticker := time.NewTicker(saveInterval)
+	defer ticker.Stop()
+
+	var dlErr error
+	for remaining := len(st.Segments); remaining > 0; {
+		select {
+		case err := <-errs:
+			remaining--
+			if err != nil && dlErr == nil {
+				dlErr = err
+				cancel()
+			}
+		case <-ticker.C:
+			if err := save(); err != nil {
+				log.Warnw("saving download progress", "error", err)
+			}
+		}
+	}
+
+	if dlErr != nil {
+		// keep the progress for the next attempt
+		if err := save(); err != nil {
+			log.Warnw("saving download progress", "error", err)
+		}
+		return dlErr
+	}
+
+	if err := fd.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(part, dst); err != nil {
+		return err
+	}
+	_ = os.Remove(statePath)
+	return nil
+}
+
+// probe returns the size of the file, and whether the server accepts ranged
+// requests
+func (f *Fetcher) probe(ctx context.Context, u string) (int64, bool) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", u, nil)
+	if err != nil {
+		return 0, false
+	}
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return 0, false
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, false
+	}
+	return resp.ContentLength, resp.Header.Get("Accept-Ranges") == "bytes"
+}
+
+func (f *Fetcher) downloadWhole(ctx context.Context, u, dst string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return xerrors.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	fd, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(fd, resp.Body); err != nil {
+		_ = fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+func (f *Fetcher) fetchSegment(ctx context.Context, u string, w io.WriterAt, s *segment, lk sync.Locker) error {
+	lk.Lock()
+	from := s.Start + s.Done
+	lk.Unlock()
+	if from >= s.End {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", from, s.End-1))
+
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return xerrors.Errorf("unexpected status for ranged request: %s", resp.Status)
+	}
+
+	buf := make([]byte, 1<<20)
+	for from < s.End {
+		n, err := resp.Body.Read(buf)
+		if int64(n) > s.End-from {
+			n = int(s.End - from)
+		}
+		if n > 0 {
+			if _, err := w.WriteAt(buf[:n], from); err != nil {
+				return err
+			}
+			from += int64(n)
+
+			lk.Lock()
+			s.Done += int64(n)
+			lk.Unlock()
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if from < s.End {
+		return xerrors.Errorf("segment ended early at %d, expected %d", from, s.End)
+	}
+	return nil
+}
+
+// downloadState is the progress of a download, saved next to the partial file
+type downloadState struct {
+	Size     int64
+	Segments []segment
+}
+
+type segment struct {
+	Start, End int64 // End is exclusive
+	Done       int64
+}
+
+func newState(size int64, n int) *downloadState {
+	if int64(n) > size {
+		n = int(size)
+	}
+
+	st := &downloadState{Size: size}
+	step := size / int64(n)
+	for i := 0; i < n; i++ {
+		s := segment{Start: int64(i) * step, End: int64(i+1) * step}
+		if i == n-1 {
+			s.End = size
+		}
+		st.Segments = append(st.Segments, s)
+	}
+	return st
+}
+
+func loadState(path string) (*downloadState, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var st downloadState
+	if err := json.Unmarshal(b, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (st *downloadState) done() int64 {
+	var d int64
+	for _, s := range st.Segments {
+		d += s.Done
+	}
+	return d
+}
+
+// RunVerifier checks the files at the interval until the context is done,
+// and fetches the ones failing their check again
+func (f *Fetcher) RunVerifier(ctx context.Context, dir string, files []File, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		bad, err := Verify(ctx, dir, files)
+		if err != nil {
+			return
+		}
+		if len(bad) == 0 {
+			log.Debugw("parameter files verified", "files", len(files))
+			continue
+		}
+
+		log.Errorw("parameter files failed verification, fetching them again", "files", len(bad))
+		if err := f.Fetch(ctx, dir, bad); err != nil {
+			log.Errorw("fetching parameter files", "error", err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Package proofparams manages the proof parameter and SRS files: it checks
+// them against the digests of the manifests, prunes the files a node doesn't
+// need, and fetches them from mirrors with parallel, resumable downloads.
+package proofparams
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("proofparams")
+
+// DefaultDir is where the proofs look for the parameters when
+// FIL_PROOFS_PARAMETER_CACHE isn't set
+const DefaultDir = "/var/tmp/filecoin-proof-parameters"
+
+const dirEnv = "FIL_PROOFS_PARAMETER_CACHE"
+
+// Dir returns the directory the proofs load the parameters from
+func Dir() string {
+	if d := os.Getenv(dirEnv); d != "" {
+		return d
+	}
+	return DefaultDir
+}
+
+// File is a manifest entry
+type File struct {
+	Name string `json:"-"`
+
+	Cid        string `json:"cid"`
+	Digest     string `json:"digest"`
+	SectorSize uint64 `json:"sector_size"`
+
+	// SRS files are used by all sector sizes
+	SRS bool `json:"-"`
+}
+
+// Manifest is the set of the parameter and SRS files, by name
+type Manifest map[string]File
+
+// ParseManifest parses the parameter and SRS manifests built into lotus
+func ParseManifest(params, srs []byte) (Manifest, error) {
+	m := Manifest{}
+	for _, src := range []struct {
+		data []byte
+		srs  bool
+	}{{params, false}, {srs, true}} {
+		var files map[string]File
+		if err := json.Unmarshal(src.data, &files); err != nil {
+			return nil, xerrors.Errorf("parsing manifest: %w", err)
+		}
+		for name, f := range files {
+			f.Name = name
+			f.SRS = src.srs
+			m[name] = f
+		}
+	}
+	return m, nil
+}
+
+// For returns the files needed to seal and prove sectors of the given size,
+// sorted by name. Size 0 only returns the SRS files.
+func (m Manifest) For(sectorSize uint64) []File {
+	var out []File
+	for _, f := range m {
+		if f.SRS || (sectorSize != 0 && f.SectorSize == sectorSize) {
+			out = append(out, f)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func (f File) path(dir string) string {
+	return filepath.Join(dir, f.Name)
+}
@@ -0,0 +1,142 @@
+package proofparams
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/blake2b-simd"
+	"github.com/stretchr/testify/require"
+)
+
+func testFile(t *testing.T, name string, size int) (File, []byte) {
+	data := make([]byte, size)
+	_, err := rand.New(rand.NewSource(int64(size))).Read(data)
+	require.NoError(t, err)
+
+	sum := blake2b.Sum512(data)
+	return File{
+		Name:       name,
+		Cid:        "cid-" + name,
+		Digest:     hex.EncodeToString(sum[:16]),
+		SectorSize: 2048,
+	}, data
+}
+
+type testServer struct {
+	lk     sync.Mutex
+	ranges []string
+}
+
+func (s *testServer) handler(files map[string][]byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == "GET" {
+			s.lk.Lock()
+			s.ranges = append(s.ranges, r.Header.Get("Range"))
+			s.lk.Unlock()
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	})
+}
+
+func TestFetch(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	f, data := testFile(t, "a.params", 3<<20+17)
+	ts := &testServer{}
+	srv := httptest.NewServer(ts.handler(map[string][]byte{f.Cid: data}))
+	defer srv.Close()
+
+	fetcher := &Fetcher{Mirrors: []string{"http://127.0.0.1:1/down", srv.URL}, Segments: 3}
+	require.NoError(t, fetcher.Fetch(ctx, dir, []File{f}))
+	require.NoError(t, Check(ctx, dir, f))
+	require.Len(t, ts.ranges, 3)
+
+	// corrupted files are fetched again
+	require.NoError(t, ioutil.WriteFile(f.path(dir), []byte("garbage"), 0644))
+	bad, err := Verify(ctx, dir, []File{f})
+	require.NoError(t, err)
+	require.Len(t, bad, 1)
+
+	require.NoError(t, fetcher.Fetch(ctx, dir, bad))
+	require.NoError(t, Check(ctx, dir, f))
+}
+
+func TestFetchResume(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	f, data := testFile(t, "b.params", 1<<20)
+	ts := &testServer{}
+	srv := httptest.NewServer(ts.handler(map[string][]byte{f.Cid: data}))
+	defer srv.Close()
+
+	// the first of two segments and half of the second are done
+	part := f.path(dir) + partSuffix
+	require.NoError(t, ioutil.WriteFile(part, data[:3<<18], 0644))
+	st := newState(int64(len(data)), 2)
+	st.Segments[0].Done = 1 << 19
+	st.Segments[1].Done = 1 << 18
+	b, err := json.Marshal(st)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(part+stateSuffix, b, 0644))
+
+	fetcher := &Fetcher{Mirrors: []string{srv.URL}, Segments: 2}
+	require.NoError(t, fetcher.Fetch(ctx, dir, []File{f}))
+	require.NoError(t, Check(ctx, dir, f))
+	require.Equal(t, []string{"bytes=786432-1048575"}, ts.ranges)
+
+	_, err = os.Stat(part + stateSuffix)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"keep.params", "keep.vk", "other.params", "old.vk.part", "old.vk.part.json", "lock"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte("x"), 0644))
+	}
+
+	removed, freed, err := Prune(dir, []File{{Name: "keep.params"}, {Name: "keep.vk"}})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"other.params", "old.vk.part", "old.vk.part.json"}, removed)
+	require.Equal(t, int64(3), freed)
+
+	left, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, left, 3)
+}
+
+func TestManifestFor(t *testing.T) {
+	m, err := ParseManifest(
+		[]byte(`{"a.params": {"cid": "a", "digest": "d", "sector_size": 2048}, "b.params": {"cid": "b", "digest": "d", "sector_size": 8388608}}`),
+		[]byte(`{"c.srs": {"cid": "c", "digest": "d", "sector_size": 0}}`),
+	)
+	require.NoError(t, err)
+
+	names := func(fs []File) []string {
+		var out []string
+		for _, f := range fs {
+			out = append(out, f.Name)
+		}
+		return out
+	}
+	require.Equal(t, []string{"a.params", "c.srs"}, names(m.For(2048)))
+	require.Equal(t, []string{"c.srs"}, names(m.For(0)))
+}
@@ -0,0 +1,267 @@
+// Package rpctls sets up mutual TLS for the RPC links between the miner, its
+// workers and markets processes. Certificates are reloaded from disk when
+// they change, so they can be rotated without restarting the processes.
+package rpctls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("rpctls")
+
+// reloadInterval is how often the files are checked for changes
+var reloadInterval = 10 * time.Second
+
+// Files are the paths of the certificate and key of a process, and of the
+// certificates of the CAs issuing the certificates of its peers
+type Files struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+}
+
+// Certs holds the current certificates loaded from Files
+type Certs struct {
+	files Files
+
+	lk      sync.Mutex
+	checked time.Time
+	mods    [3]time.Time
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+
+	fwdLk      sync.Mutex
+	forwarders map[string]string // server address -> forwarder address
+}
+
+// Load loads the certificates, failing if any of the files is missing or
+// invalid
+func Load(files Files) (*Certs, error) {
+	if files.CertFile == "" || files.KeyFile == "" || files.CAFile == "" {
+		return nil, xerrors.Errorf("TLS needs a certificate, a key and a CA file")
+	}
+
+	c := &Certs{files: files}
+	if _, _, err := c.current(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// current returns the loaded certificates, reloading them if the files
+// changed. Failing reloads keep the previous certificates.
+func (c *Certs) current() (*tls.Certificate, *x509.CertPool, error) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	if c.cert != nil && time.Since(c.checked) < reloadInterval {
+		return c.cert, c.pool, nil
+	}
+	c.checked = time.Now()
+
+	var mods [3]time.Time
+	for i, f := range []string{c.files.CertFile, c.files.KeyFile, c.files.CAFile} {
+		st, err := os.Stat(f)
+		if err != nil {
+			return c.keep(xerrors.Errorf("checking %s: %w", f, err))
+		}
+		mods[i] = st.ModTime()
+	}
+	if c.cert != nil && mods == c.mods {
+		return c.cert, c.pool, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.files.CertFile, c.files.KeyFile)
+	if err != nil {
+		return c.keep(xerrors.Errorf("loading certificate: %w", err))
+	}
+
+	ca, err := ioutil.ReadFile(c.files.CAFile)
+	if err != nil {
+		return c.keep(xerrors.Errorf("reading CA file: %w", err))
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return c.keep(xerrors.Errorf("no certificates in CA file %s", c.files.CAFile))
+	}
+
+	if c.cert != nil {
+		log.Infow("reloaded TLS certificates", "cert", c.files.CertFile, "ca", c.files.CAFile)
+	}
+	c.cert, c.pool, c.mods = &cert, pool, mods
+	return c.cert, c.pool, nil
+}
+
+// keep must be called with the lock held
+func (c *Certs) keep(err error) (*tls.Certificate, *x509.CertPool, error) {
+	if c.cert == nil {
+		return nil, nil, err
+	}
+	log.Errorw("reloading TLS certificates failed, keeping the previous ones", "error", err)
+	return c.cert, c.pool, nil
+}
+
+// ServerConfig returns the config of servers requiring clients to present
+// a certificate issued by the CA
+func (c *Certs) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool, err := c.current()
+			if err != nil {
+				return nil, err
+			}
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+				ClientCAs:    pool,
+			}, nil
+		},
+	}
+}
+
+// ClientConfig returns the config of clients connecting to the server at
+// host, presenting the certificate of the process. The server must present a
+// certificate issued by the CA, valid for host, which may be an IP address.
+func (c *Certs) ClientConfig(host string) (*tls.Config, error) {
+	cert, pool, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{*cert},
+		RootCAs:      pool,
+		ServerName:   host,
+	}, nil
+}
+
+// DialTLSContext opens a mutual TLS connection to addr, it can be used as the
+// DialTLSContext of HTTP transports
+func (c *Certs) DialTLSContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, xerrors.Errorf("parsing address: %w", err)
+	}
+
+	cfg, err := c.ClientConfig(host)
+	if err != nil {
+		return nil, err
+	}
+	return (&tls.Dialer{Config: cfg}).DialContext(ctx, network, addr)
+}
+
+// HTTPClient returns the client to use for HTTP requests to the servers
+// requiring mutual TLS, like sector fetches. Without certificates, it's the
+// default client.
+func (c *Certs) HTTPClient() *http.Client {
+	if c == nil {
+		return http.DefaultClient
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialTLSContext = c.DialTLSContext
+	return &http.Client{Transport: t}
+}
+
+// ClientURL returns the URL RPC clients must dial to reach the API at u. RPC
+// clients connect with the HTTP and websocket clients of the process, which
+// don't present the certificate; https:// and wss:// URLs are reached through
+// a forwarder listening on the loopback interface instead, which opens a
+// mutual TLS connection to the server for each connection it accepts.
+// Forwarders are kept for the life of the process, one per server. Without
+// certificates, u is returned unchanged.
+func (c *Certs) ClientURL(u string) (string, error) {
+	if c == nil {
+		return u, nil
+	}
+
+	pu, err := url.Parse(u)
+	if err != nil {
+		return "", xerrors.Errorf("parsing url: %w", err)
+	}
+
+	var scheme string
+	switch pu.Scheme {
+	case "https":
+		scheme = "http"
+	case "wss":
+		scheme = "ws"
+	default:
+		return u, nil
+	}
+
+	port := pu.Port()
+	if port == "" {
+		port = "443"
+	}
+	target := net.JoinHostPort(pu.Hostname(), port)
+
+	c.fwdLk.Lock()
+	defer c.fwdLk.Unlock()
+
+	local, ok := c.forwarders[target]
+	if !ok {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return "", xerrors.Errorf("listening for %s: %w", target, err)
+		}
+		go c.forward(l, target)
+
+		if c.forwarders == nil {
+			c.forwarders = map[string]string{}
+		}
+		local = l.Addr().String()
+		c.forwarders[target] = local
+	}
+
+	pu.Scheme, pu.Host = scheme, local
+	return pu.String(), nil
+}
+
+func (c *Certs) forward(l net.Listener, target string) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			log.Errorw("forwarder to TLS server stopped", "target", target, "error", err)
+			return
+		}
+
+		go func() {
+			defer conn.Close() // nolint:errcheck
+
+			rc, err := c.DialTLSContext(context.TODO(), "tcp", target)
+			if err != nil {
+				log.Warnw("connecting to TLS server", "target", target, "error", err)
+				return
+			}
+			defer rc.Close() // nolint:errcheck
+
+			done := make(chan struct{}, 2)
+			go func() {
+				_, _ = io.Copy(rc, conn)
+				done <- struct{}{}
+			}()
+			go func() {
+				_, _ = io.Copy(conn, rc)
+				done <- struct{}{}
+			}()
+			<-done
+		}()
+	}
+}
@@ -0,0 +1,161 @@
+package rpctls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue writes a certificate for the IPs, and its key, to dir
+func (ca *testCA) issue(t *testing.T, dir string, ips ...net.IP) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	kb, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kb}), 0600))
+	return certFile, keyFile
+}
+
+// load loads a certificate issued by issuer, for peers with certificates
+// issued by ca
+func load(t *testing.T, ca, issuer *testCA, ips ...net.IP) *Certs {
+	dir := t.TempDir()
+	certFile, keyFile := issuer.issue(t, dir, ips...)
+
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caFile, ca.pem, 0600))
+
+	c, err := Load(Files{CertFile: certFile, KeyFile: keyFile, CAFile: caFile})
+	require.NoError(t, err)
+	return c
+}
+
+func TestClientVerifiesServer(t *testing.T) {
+	ca, other := newTestCA(t), newTestCA(t)
+	client := load(t, ca, ca)
+
+	for _, tc := range []struct {
+		name   string
+		server *Certs
+		ok     bool
+	}{
+		{name: "good", server: load(t, ca, ca, net.IPv4(127, 0, 0, 1)), ok: true},
+		{name: "wrong ca", server: load(t, ca, other, net.IPv4(127, 0, 0, 1))},
+		{name: "ip mismatch", server: load(t, ca, ca, net.IPv4(10, 0, 0, 1))},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("ok"))
+			}))
+			srv.TLS = tc.server.ServerConfig()
+			srv.StartTLS()
+			defer srv.Close()
+
+			get := func(u string) error {
+				resp, err := client.HTTPClient().Get(u)
+				if err != nil {
+					return err
+				}
+				return resp.Body.Close()
+			}
+
+			err := get(srv.URL)
+			if !tc.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			// RPC clients go through the forwarder
+			fwd, err := client.ClientURL(srv.URL)
+			require.NoError(t, err)
+			require.Regexp(t, "^http://127.0.0.1:", fwd)
+			require.NoError(t, get(fwd))
+
+			same, err := client.ClientURL(srv.URL)
+			require.NoError(t, err)
+			require.Equal(t, fwd, same)
+		})
+	}
+}
+
+func TestServerVerifiesClient(t *testing.T) {
+	ca, other := newTestCA(t), newTestCA(t)
+	server := load(t, ca, ca, net.IPv4(127, 0, 0, 1))
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = server.ServerConfig()
+	srv.StartTLS()
+	defer srv.Close()
+
+	// the client trusts the server, but its certificate isn't issued by the
+	// CA the server trusts
+	_, err := load(t, ca, other).HTTPClient().Get(srv.URL)
+	require.Error(t, err)
+
+	resp, err := load(t, ca, ca).HTTPClient().Get(srv.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	// without certificates, URLs aren't forwarded
+	var none *Certs
+	u, err := none.ClientURL(srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, srv.URL, u)
+}
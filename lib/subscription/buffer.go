@@ -0,0 +1,168 @@
+package subscription
+
+import (
+	"context"
+	"sync"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("subscription")
+
+// Mode selects what happens when a subscriber doesn't keep up with the
+// notifications sent to it, and its buffer fills up.
+type Mode string
+
+const (
+	// DropOldest discards the oldest buffered notification to make room for
+	// the new one.
+	DropOldest Mode = "drop-oldest"
+	// Coalesce merges all buffered notifications into a single one; how
+	// notifications are merged depends on the subscription type.
+	Coalesce Mode = "coalesce"
+	// Disconnect closes the subscription. Subscribers are expected to notice
+	// the closed channel, and resubscribe.
+	Disconnect Mode = "disconnect"
+)
+
+// Policy configures the buffering of a single subscription.
+type Policy struct {
+	Mode Mode
+	// Buffer is the maximum number of notifications queued for the
+	// subscriber; values <= 0 select the default.
+	Buffer int
+}
+
+const DefaultBuffer = 64
+
+// Validate checks the policy, and fills in the default buffer size.
+func (p Policy) Validate() (Policy, error) {
+	switch p.Mode {
+	case DropOldest, Coalesce, Disconnect:
+	default:
+		return p, xerrors.Errorf("unknown subscription mode %q", p.Mode)
+	}
+	if p.Buffer <= 0 {
+		p.Buffer = DefaultBuffer
+	}
+	return p, nil
+}
+
+// CoalesceFunc merges pending notifications, oldest first, into one.
+type CoalesceFunc func(pending []interface{}) interface{}
+
+// Buffer queues notifications for a single subscriber, so that the
+// publisher never blocks on it. All methods are safe for concurrent use.
+type Buffer struct {
+	name     string
+	policy   Policy
+	coalesce CoalesceFunc
+
+	lk     sync.Mutex
+	queue  []interface{}
+	closed bool
+	notify chan struct{}
+}
+
+// NewBuffer creates a buffer for a subscription. The name is used in logs.
+// coalesce may be nil, in which case coalescing keeps only the newest
+// notification.
+func NewBuffer(name string, p Policy, coalesce CoalesceFunc) *Buffer {
+	if p.Buffer <= 0 {
+		p.Buffer = DefaultBuffer
+	}
+	if coalesce == nil {
+		coalesce = func(pending []interface{}) interface{} {
+			return pending[len(pending)-1]
+		}
+	}
+
+	return &Buffer{
+		name:     name,
+		policy:   p,
+		coalesce: coalesce,
+		notify:   make(chan struct{}, 1),
+	}
+}
+
+// Push queues a notification, applying the policy when the buffer is full.
+// It never blocks, and returns false once the subscription is closed.
+func (b *Buffer) Push(v interface{}) bool {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	if b.closed {
+		return false
+	}
+
+	if len(b.queue) >= b.policy.Buffer {
+		log.Warnw("subscriber is lagging", "subscription", b.name, "mode", b.policy.Mode, "buffered", len(b.queue))
+
+		switch b.policy.Mode {
+		case DropOldest:
+			b.queue = append(b.queue[:0], b.queue[1:]...)
+		case Coalesce:
+			b.queue = []interface{}{b.coalesce(append(b.queue, v))}
+			b.signal()
+			return true
+		case Disconnect:
+			b.closeLocked()
+			return false
+		}
+	}
+
+	b.queue = append(b.queue, v)
+	b.signal()
+	return true
+}
+
+// Next waits for the next notification. It returns false when the
+// subscription is closed, or the context is cancelled.
+func (b *Buffer) Next(ctx context.Context) (interface{}, bool) {
+	for {
+		b.lk.Lock()
+		if b.closed {
+			b.lk.Unlock()
+			return nil, false
+		}
+		if len(b.queue) > 0 {
+			v := b.queue[0]
+			b.queue[0] = nil
+			b.queue = b.queue[1:]
+			b.lk.Unlock()
+			return v, true
+		}
+		b.lk.Unlock()
+
+		select {
+		case <-b.notify:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// Close closes the subscription, dropping pending notifications.
+func (b *Buffer) Close() {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	b.closeLocked()
+}
+
+func (b *Buffer) closeLocked() {
+	if b.closed {
+		return
+	}
+	b.closed = true
+	b.queue = nil
+	b.signal()
+}
+
+func (b *Buffer) signal() {
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,80 @@
+package subscription
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func drain(ctx context.Context, b *Buffer) []interface{} {
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	var out []interface{}
+	for {
+		v, ok := b.Next(ctx)
+		if !ok {
+			return out
+		}
+		out = append(out, v)
+	}
+}
+
+func TestBufferPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	b := NewBuffer("test", Policy{Mode: DropOldest, Buffer: 2}, nil)
+	for i := 0; i < 4; i++ {
+		require.True(t, b.Push(i))
+	}
+	require.Equal(t, []interface{}{2, 3}, drain(ctx, b))
+
+	sum := func(pending []interface{}) interface{} {
+		var s int
+		for _, v := range pending {
+			s += v.(int)
+		}
+		return s
+	}
+	b = NewBuffer("test", Policy{Mode: Coalesce, Buffer: 2}, sum)
+	for i := 1; i <= 4; i++ {
+		require.True(t, b.Push(i))
+	}
+	// the buffer is coalesced when 3 arrives, 4 is queued after that
+	require.Equal(t, []interface{}{6, 4}, drain(ctx, b))
+
+	b = NewBuffer("test", Policy{Mode: Disconnect, Buffer: 2}, nil)
+	require.True(t, b.Push(1))
+	require.True(t, b.Push(2))
+	require.False(t, b.Push(3))
+	require.False(t, b.Push(4))
+	_, ok := b.Next(ctx)
+	require.False(t, ok)
+}
+
+func TestBufferNextWaits(t *testing.T) {
+	b := NewBuffer("test", Policy{Mode: Disconnect}, nil)
+
+	done := make(chan interface{})
+	go func() {
+		v, _ := b.Next(context.Background())
+		done <- v
+	}()
+
+	require.True(t, b.Push("x"))
+	require.Equal(t, "x", <-done)
+
+	go b.Close()
+	_, ok := b.Next(context.Background())
+	require.False(t, ok)
+}
+
+func TestPolicyValidate(t *testing.T) {
+	p, err := Policy{Mode: Coalesce}.Validate()
+	require.NoError(t, err)
+	require.Equal(t, DefaultBuffer, p.Buffer)
+
+	_, err = Policy{Mode: "block"}.Validate()
+	require.Error(t, err)
+}
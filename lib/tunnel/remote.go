@@ -0,0 +1,99 @@
+package tunnel
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+// IDHeader carries the ID of a tunnel, under which the miner serves the
+// sector files of the worker, see RemoteURL
+const IDHeader = "Lotus-Tunnel-Id"
+
+// RemotePrefix is the path under which the miner serves the sector files of
+// workers behind tunnels
+const RemotePrefix = "/worker-remote/"
+
+// RemoteURL returns the URL the sector files of the worker with the tunnel
+// ID are served at by the miner, given the URL of the tunnel. Workers behind
+// tunnels declare it as the URL of their storage, so that the miner and
+// other workers can fetch sectors from them.
+func RemoteURL(tunnelURL string, id string) (string, error) {
+	u, err := url.Parse(tunnelURL)
+	if err != nil {
+		return "", xerrors.Errorf("parsing tunnel url: %w", err)
+	}
+
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	}
+	u.Path, u.RawPath, u.RawQuery = RemotePrefix+id, "", ""
+	return u.String(), nil
+}
+
+// Remotes serves the sector files of workers through their tunnels, while
+// they are open
+type Remotes struct {
+	lk    sync.Mutex
+	addrs map[string]string // tunnel ID -> forwarded address
+}
+
+func NewRemotes() *Remotes {
+	return &Remotes{addrs: map[string]string{}}
+}
+
+// Add serves the files of the worker with the tunnel ID from the address
+// returned by Forward, until the returned function is called
+func (rs *Remotes) Add(id, addr string) func() {
+	rs.lk.Lock()
+	defer rs.lk.Unlock()
+
+	rs.addrs[id] = addr
+	return func() {
+		rs.lk.Lock()
+		defer rs.lk.Unlock()
+
+		// a reconnected tunnel may have replaced this one already
+		if rs.addrs[id] == addr {
+			delete(rs.addrs, id)
+		}
+	}
+}
+
+// ServeHTTP proxies requests under RemotePrefix to the /remote endpoint of
+// the worker. Requests keep their headers, the worker checks their
+// authorization itself.
+func (rs *Remotes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, RemotePrefix)
+	parts := strings.SplitN(rest, "/", 2)
+
+	rs.lk.Lock()
+	addr, ok := rs.addrs[parts[0]]
+	rs.lk.Unlock()
+	if !ok {
+		http.Error(w, "no open tunnel to the worker", http.StatusServiceUnavailable)
+		return
+	}
+
+	path := "/remote"
+	if len(parts) == 2 {
+		path += "/" + parts[1]
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = "http"
+			req.URL.Host = addr
+			req.URL.Path, req.URL.RawPath = path, ""
+			req.Host = addr
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
@@ -0,0 +1,83 @@
+package tunnel
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteURL(t *testing.T) {
+	for tunnelURL, expect := range map[string]string{
+		"ws://miner:2345/rpc/worker-tunnel/v0":   "http://miner:2345/worker-remote/abc",
+		"wss://miner:2345/rpc/worker-tunnel/v0":  "https://miner:2345/worker-remote/abc",
+		"http://miner:2345/rpc/worker-tunnel/v0": "http://miner:2345/worker-remote/abc",
+	} {
+		u, err := RemoteURL(tunnelURL, "abc")
+		require.NoError(t, err)
+		require.Equal(t, expect, u)
+	}
+}
+
+func TestRemotes(t *testing.T) {
+	// the forwarded address of the worker, serving its sector files
+	worker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.RequestURI() + " " + r.Header.Get("Authorization")))
+	}))
+	defer worker.Close()
+	addr := strings.TrimPrefix(worker.URL, "http://")
+
+	rs := NewRemotes()
+	miner := httptest.NewServer(rs)
+	defer miner.Close()
+
+	base, err := RemoteURL(miner.URL+"/rpc/worker-tunnel/v0", "w1")
+	require.NoError(t, err)
+
+	do := func(method, u string) (int, string) {
+		req, err := http.NewRequest(method, u, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer token")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close() // nolint:errcheck
+
+		b, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(b)
+	}
+
+	// no tunnel yet
+	code, _ := do("GET", base+"/sealed/s-t01000-1")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+
+	remove := rs.Add("w1", addr)
+
+	code, body := do("GET", base+"/sealed/s-t01000-1?offset=0")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "GET /remote/sealed/s-t01000-1?offset=0 Bearer token", body)
+
+	code, body = do("DELETE", base+"/unsealed/s-t01000-1")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "DELETE /remote/unsealed/s-t01000-1 Bearer token", body)
+
+	// other workers aren't reachable through the tunnel
+	code, _ = do("GET", miner.URL+RemotePrefix+"w2/sealed/s-t01000-1")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+
+	// a reconnected tunnel replaces the previous one, which doesn't remove it
+	// when it closes
+	removeNew := rs.Add("w1", addr+"0")
+	remove()
+	rs.lk.Lock()
+	require.Equal(t, addr+"0", rs.addrs["w1"])
+	rs.lk.Unlock()
+
+	removeNew()
+	code, _ = do("GET", base+"/sealed/s-t01000-1")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+}
@@ -0,0 +1,208 @@
+// Package tunnel lets a worker which the miner can't dial serve the miner's
+// calls over a connection the worker opens. The HTTP connection to the miner
+// API is upgraded into a yamux session: the miner opens streams, the worker
+// serves HTTP on them as on any listener.
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	yamux "github.com/libp2p/go-yamux/v2"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("tunnel")
+
+// Protocol is the value of the Upgrade header of tunnel requests
+const Protocol = "lotus-worker-tunnel/1"
+
+// KeepAliveInterval is the interval of the pings keeping the session, and
+// the NAT mappings of the connection, alive. Sessions which miss pings are
+// closed.
+var KeepAliveInterval = 10 * time.Second
+
+func sessionConfig() *yamux.Config {
+	cfg := yamux.DefaultConfig()
+	cfg.EnableKeepAlive = true
+	cfg.KeepAliveInterval = KeepAliveInterval
+	cfg.LogOutput = io.Discard
+	return cfg
+}
+
+// DialFunc opens connections, see rpctls.Certs.DialTLSContext
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Dial opens a tunnel to the given HTTP(S) URL. The returned session accepts
+// the streams opened by the other side, and can be served like a listener.
+// HTTPS connections are opened with dialTLS when set, e.g. to present a
+// client certificate.
+func Dial(ctx context.Context, u string, header http.Header, dialTLS DialFunc) (*yamux.Session, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, xerrors.Errorf("parsing tunnel url: %w", err)
+	}
+
+	var d net.Dialer
+	var conn net.Conn
+	switch parsed.Scheme {
+	case "http", "ws":
+		conn, err = d.DialContext(ctx, "tcp", hostPort(parsed, "80"))
+	case "https", "wss":
+		if dialTLS == nil {
+			dialTLS = (&tls.Dialer{NetDialer: &d}).DialContext
+		}
+		conn, err = dialTLS(ctx, "tcp", hostPort(parsed, "443"))
+	default:
+		return nil, xerrors.Errorf("unsupported tunnel url scheme %q", parsed.Scheme)
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("dialing %s: %w", parsed.Host, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", Protocol)
+
+	if err := req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, xerrors.Errorf("sending tunnel request: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, xerrors.Errorf("reading tunnel response: %w", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		_ = conn.Close()
+		return nil, xerrors.Errorf("tunnel request refused: %s", resp.Status)
+	}
+
+	sess, err := yamux.Server(&bufConn{Conn: conn, r: br}, sessionConfig())
+	if err != nil {
+		_ = conn.Close()
+		return nil, xerrors.Errorf("starting tunnel session: %w", err)
+	}
+	return sess, nil
+}
+
+// Accept upgrades a tunnel request into a session, in which the caller opens
+// streams to the other side
+func Accept(w http.ResponseWriter, r *http.Request) (*yamux.Session, error) {
+	if r.Header.Get("Upgrade") != Protocol {
+		http.Error(w, "expected an upgrade to "+Protocol, http.StatusUpgradeRequired)
+		return nil, xerrors.Errorf("not a tunnel request")
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection can't be upgraded", http.StatusInternalServerError)
+		return nil, xerrors.Errorf("response writer doesn't support hijacking")
+	}
+
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, xerrors.Errorf("hijacking connection: %w", err)
+	}
+
+	_, err = brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: " + Protocol + "\r\n\r\n")
+	if err == nil {
+		err = brw.Flush()
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, xerrors.Errorf("writing tunnel response: %w", err)
+	}
+
+	sess, err := yamux.Client(&bufConn{Conn: conn, r: brw.Reader}, sessionConfig())
+	if err != nil {
+		_ = conn.Close()
+		return nil, xerrors.Errorf("starting tunnel session: %w", err)
+	}
+	return sess, nil
+}
+
+// Forward listens on a local address, and forwards the connections to it
+// through the session until the session is closed. The returned address can
+// be dialed by clients which don't know about the tunnel.
+func Forward(sess *yamux.Session) (string, error) {
+	nl, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", xerrors.Errorf("listening for tunnel connections: %w", err)
+	}
+
+	go func() {
+		<-sess.CloseChan()
+		_ = nl.Close()
+	}()
+
+	go func() {
+		for {
+			c, err := nl.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer c.Close() // nolint:errcheck
+
+				s, err := sess.Open(context.TODO())
+				if err != nil {
+					log.Warnw("opening tunnel stream", "error", err)
+					return
+				}
+				defer s.Close() // nolint:errcheck
+
+				pipe(c, s)
+			}()
+		}
+	}()
+
+	return nl.Addr().String(), nil
+}
+
+// pipe copies data both ways until one side is done
+func pipe(a, b io.ReadWriter) {
+	done := make(chan struct{}, 2)
+	cp := func(dst io.Writer, src io.Reader) {
+		_, _ = io.Copy(dst, src)
+		done <- struct{}{}
+	}
+	go cp(a, b)
+	go cp(b, a)
+	<-done
+}
+
+func hostPort(u *url.URL, defPort string) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), defPort)
+}
+
+// bufConn reads the bytes buffered while reading the upgrade handshake before
+// reading from the connection
+type bufConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -0,0 +1,103 @@
+package tunnel
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	yamux "github.com/libp2p/go-yamux/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// tunnelServer accepts tunnels, and sends the sessions to the returned channel
+func tunnelServer(t *testing.T) (*httptest.Server, <-chan *yamux.Session) {
+	sessions := make(chan *yamux.Session, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sess, err := Accept(w, r)
+		if err != nil {
+			return
+		}
+		sessions <- sess
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, sessions
+}
+
+func TestTunnelLoopback(t *testing.T) {
+	srv, sessions := tunnelServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the dialing side serves HTTP on the streams of the session
+	wsess, err := Dial(ctx, srv.URL, http.Header{"Authorization": []string{"Bearer token"}}, nil)
+	require.NoError(t, err)
+
+	go http.Serve(wsess, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { // nolint:errcheck
+		_, _ = w.Write([]byte("hello " + r.URL.Path))
+	}))
+
+	var msess *yamux.Session
+	select {
+	case msess = <-sessions:
+	case <-ctx.Done():
+		t.Fatal("tunnel not accepted")
+	}
+
+	// the accepting side reaches it through the forwarded address
+	addr, err := Forward(msess)
+	require.NoError(t, err)
+
+	for _, path := range []string{"/a", "/b"} {
+		resp, err := http.Get("http://" + addr + path)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, "hello "+path, string(body))
+	}
+
+	// dropping the tunnel closes the session on the other side, and the
+	// forwarded listener with it
+	require.NoError(t, wsess.Close())
+
+	select {
+	case <-msess.CloseChan():
+	case <-ctx.Done():
+		t.Fatal("session not closed after the other side dropped")
+	}
+
+	require.Eventually(t, func() bool {
+		c, err := net.Dial("tcp", addr)
+		if err != nil {
+			return true
+		}
+		_ = c.Close()
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestTunnelRefused(t *testing.T) {
+	srv, _ := tunnelServer(t)
+
+	// plain requests aren't upgraded
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusUpgradeRequired, resp.StatusCode)
+
+	// servers which don't upgrade the request are reported
+	plain := httptest.NewServer(http.NotFoundHandler())
+	defer plain.Close()
+
+	_, err = Dial(context.Background(), plain.URL, nil, nil)
+	require.Error(t, err)
+
+	_, err = Dial(context.Background(), "ftp://127.0.0.1/", nil, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,162 @@
+package retrievaladapter
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+var pricingPolicyKey = datastore.NewKey("/retrievals/pricing-policy")
+
+// RetrievalPricing keeps the retrieval pricing policy of the miner, and
+// prices retrievals with it.
+type RetrievalPricing struct {
+	ds        datastore.Datastore
+	listDeals func() ([]storagemarket.MinerDeal, error)
+
+	lk     sync.Mutex
+	policy dtypes.RetrievalPricingPolicy
+}
+
+// NewRetrievalPricing loads the pricing policy stored in ds. listDeals lists
+// the storage deals of the miner, to find the clients with active deals.
+func NewRetrievalPricing(ds datastore.Datastore, listDeals func() ([]storagemarket.MinerDeal, error)) (*RetrievalPricing, error) {
+	p := &RetrievalPricing{
+		ds:        ds,
+		listDeals: listDeals,
+	}
+
+	b, err := ds.Get(pricingPolicyKey)
+	switch err {
+	case nil:
+		if err := json.Unmarshal(b, &p.policy); err != nil {
+			return nil, xerrors.Errorf("decoding retrieval pricing policy: %w", err)
+		}
+	case datastore.ErrNotFound:
+	default:
+		return nil, xerrors.Errorf("loading retrieval pricing policy: %w", err)
+	}
+
+	return p, nil
+}
+
+func (p *RetrievalPricing) Policy() dtypes.RetrievalPricingPolicy {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	return p.policy
+}
+
+func (p *RetrievalPricing) SetPolicy(policy dtypes.RetrievalPricingPolicy) error {
+	if err := validatePrice(policy.Default); err != nil {
+		return xerrors.Errorf("default price: %w", err)
+	}
+	for i, r := range policy.Rules {
+		if err := validatePrice(r.Price); err != nil {
+			return xerrors.Errorf("rule %d: %w", i, err)
+		}
+	}
+
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return xerrors.Errorf("encoding retrieval pricing policy: %w", err)
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	if err := p.ds.Put(pricingPolicyKey, b); err != nil {
+		return xerrors.Errorf("storing retrieval pricing policy: %w", err)
+	}
+	p.policy = policy
+	return nil
+}
+
+func validatePrice(price dtypes.RetrievalPrice) error {
+	if price.PricePerByte.Int == nil || price.UnsealPrice.Int == nil {
+		return xerrors.Errorf("price not set")
+	}
+	if price.PricePerByte.Sign() < 0 || price.UnsealPrice.Sign() < 0 {
+		return xerrors.Errorf("negative price")
+	}
+	return nil
+}
+
+// Enabled returns whether retrievals are priced by the policy
+func (p *RetrievalPricing) Enabled() bool {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	return enabled(p.policy)
+}
+
+func enabled(policy dtypes.RetrievalPricingPolicy) bool {
+	return policy.FreeForDealClients || len(policy.Rules) > 0
+}
+
+// LowestPrice returns the lowest price any retrieval can get with the policy
+func (p *RetrievalPricing) LowestPrice() dtypes.RetrievalPrice {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	if p.policy.FreeForDealClients {
+		return dtypes.RetrievalPrice{PricePerByte: big.Zero(), UnsealPrice: big.Zero()}
+	}
+
+	lowest := p.policy.Default
+	for _, r := range p.policy.Rules {
+		lowest.PricePerByte = big.Min(lowest.PricePerByte, r.Price.PricePerByte)
+		lowest.UnsealPrice = big.Min(lowest.UnsealPrice, r.Price.UnsealPrice)
+	}
+	return lowest
+}
+
+// Price returns the price of retrieving the piece for the client
+func (p *RetrievalPricing) Price(client peer.ID, piece cid.Cid) (dtypes.RetrievalPrice, error) {
+	policy := p.Policy()
+
+	if policy.FreeForDealClients {
+		active, err := p.hasActiveDeal(client, piece)
+		if err != nil {
+			return dtypes.RetrievalPrice{}, err
+		}
+		if active {
+			return dtypes.RetrievalPrice{PricePerByte: big.Zero(), UnsealPrice: big.Zero()}, nil
+		}
+	}
+
+	for _, r := range policy.Rules {
+		if r.Client != "" && r.Client != client {
+			continue
+		}
+		if r.PieceCID != nil && !r.PieceCID.Equals(piece) {
+			continue
+		}
+		return r.Price, nil
+	}
+
+	return policy.Default, nil
+}
+
+func (p *RetrievalPricing) hasActiveDeal(client peer.ID, piece cid.Cid) (bool, error) {
+	deals, err := p.listDeals()
+	if err != nil {
+		return false, xerrors.Errorf("listing storage deals: %w", err)
+	}
+
+	for _, d := range deals {
+		if d.Client == client && d.State == storagemarket.StorageDealActive && d.Proposal.PieceCID.Equals(piece) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
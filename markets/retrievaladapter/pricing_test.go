@@ -0,0 +1,93 @@
+package retrievaladapter
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+	market2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/market"
+	tutils "github.com/filecoin-project/specs-actors/v2/support/testing"
+
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+func price(perByte, unseal int64) dtypes.RetrievalPrice {
+	return dtypes.RetrievalPrice{PricePerByte: abi.NewTokenAmount(perByte), UnsealPrice: abi.NewTokenAmount(unseal)}
+}
+
+func requirePrice(t *testing.T, expect, actual dtypes.RetrievalPrice) {
+	require.True(t, expect.PricePerByte.Equals(actual.PricePerByte), "price per byte: expected %s, got %s", expect.PricePerByte, actual.PricePerByte)
+	require.True(t, expect.UnsealPrice.Equals(actual.UnsealPrice), "unseal price: expected %s, got %s", expect.UnsealPrice, actual.UnsealPrice)
+}
+
+func TestRetrievalPricing(t *testing.T) {
+	dealClient := peer.ID("deal-client")
+	vipClient := peer.ID("vip-client")
+	otherClient := peer.ID("other-client")
+
+	piece := tutils.MakeCID("piece", nil)
+	rarePiece := tutils.MakeCID("rare-piece", nil)
+
+	deals := []storagemarket.MinerDeal{{
+		ClientDealProposal: market2.ClientDealProposal{Proposal: market2.DealProposal{PieceCID: piece}},
+		Client:             dealClient,
+		State:              storagemarket.StorageDealActive,
+	}, {
+		ClientDealProposal: market2.ClientDealProposal{Proposal: market2.DealProposal{PieceCID: rarePiece}},
+		Client:             dealClient,
+		State:              storagemarket.StorageDealExpired,
+	}}
+
+	ds := datastore.NewMapDatastore()
+	p, err := NewRetrievalPricing(ds, func() ([]storagemarket.MinerDeal, error) { return deals, nil })
+	require.NoError(t, err)
+	require.False(t, p.Enabled())
+
+	err = p.SetPolicy(dtypes.RetrievalPricingPolicy{
+		FreeForDealClients: true,
+		Rules: []dtypes.RetrievalPricingRule{
+			{Client: vipClient, Price: price(1, 0)},
+			{PieceCID: &rarePiece, Price: price(100, 10)},
+		},
+		Default: price(10, 5),
+	})
+	require.NoError(t, err)
+	require.True(t, p.Enabled())
+	requirePrice(t, price(0, 0), p.LowestPrice())
+
+	for _, tc := range []struct {
+		name   string
+		client peer.ID
+		piece  cid.Cid
+		expect dtypes.RetrievalPrice
+	}{
+		{"active deal", dealClient, piece, price(0, 0)},
+		{"expired deal", dealClient, rarePiece, price(100, 10)},
+		{"client rule", vipClient, rarePiece, price(1, 0)},
+		{"piece rule", otherClient, rarePiece, price(100, 10)},
+		{"default", otherClient, piece, price(10, 5)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pr, err := p.Price(tc.client, tc.piece)
+			require.NoError(t, err)
+			requirePrice(t, tc.expect, pr)
+		})
+	}
+
+	// the policy is persisted
+	p2, err := NewRetrievalPricing(ds, nil)
+	require.NoError(t, err)
+	require.True(t, p2.Enabled())
+	require.Len(t, p2.Policy().Rules, 2)
+	pr, err := p2.Price(otherClient, rarePiece)
+	require.NoError(t, err)
+	requirePrice(t, price(100, 10), pr)
+
+	err = p.SetPolicy(dtypes.RetrievalPricingPolicy{Default: price(-1, 0)})
+	require.Error(t, err)
+}
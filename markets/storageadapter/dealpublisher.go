@@ -10,7 +10,11 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/lotus/api"
@@ -28,6 +32,7 @@ type dealPublisherAPI interface {
 	ChainHead(context.Context) (*types.TipSet, error)
 	MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error)
 	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (miner.MinerInfo, error)
+	StateCall(context.Context, *types.Message, types.TipSetKey) (*api.InvocResult, error)
 }
 
 // DealPublisher batches deal publishing so that many deals can be included in
@@ -37,7 +42,14 @@ type dealPublisherAPI interface {
 // time for other deals to be submitted before sending the publish message.
 // There is a configurable maximum number of deals that can be included in one
 // message. When the limit is reached the DealPublisher immediately submits a
-// publish message with all deals in the queue.
+// publish message with all deals in the queue. The queue is also published
+// early when waiting for the publish period to elapse would leave less than
+// the configured slack before the start epoch of one of the deals in it.
+// Deals which can't be sealed before their start epoch anymore are rejected
+// instead of being published.
+// Before sending a publish message, its execution is simulated, and if it
+// would fail, the deals causing the failure are found and rejected, so that
+// the other deals can still be published.
 type DealPublisher struct {
 	api dealPublisherAPI
 
@@ -46,7 +58,10 @@ type DealPublisher struct {
 
 	maxDealsPerPublishMsg uint64
 	publishPeriod         time.Duration
+	publishSlack          time.Duration
 	publishSpec           *api.MessageSendSpec
+	maxFeePerDeal         abi.TokenAmount
+	expectedSealDuration  dtypes.GetExpectedSealDurationFunc
 
 	lk                     sync.Mutex
 	pending                []*pendingDeal
@@ -82,19 +97,27 @@ type PublishMsgConfig struct {
 	// The maximum number of deals to include in a single PublishStorageDeals
 	// message
 	MaxDealsPerMsg uint64
+	// Time buffer before the start epoch of a deal in the queue at which the
+	// queue is published without waiting for the publish period to
+	// elapse. 0 = disabled
+	Slack time.Duration
 }
 
 func NewDealPublisher(
 	feeConfig *config.MinerFeeConfig,
 	publishMsgCfg PublishMsgConfig,
-) func(lc fx.Lifecycle, full api.FullNode) *DealPublisher {
-	return func(lc fx.Lifecycle, full api.FullNode) *DealPublisher {
+) func(lc fx.Lifecycle, full api.FullNode, sealDuration dtypes.GetExpectedSealDurationFunc) *DealPublisher {
+	return func(lc fx.Lifecycle, full api.FullNode, sealDuration dtypes.GetExpectedSealDurationFunc) *DealPublisher {
 		maxFee := abi.NewTokenAmount(0)
 		if feeConfig != nil {
 			maxFee = abi.TokenAmount(feeConfig.MaxPublishDealsFee)
 		}
 		publishSpec := &api.MessageSendSpec{MaxFee: maxFee}
 		dp := newDealPublisher(full, publishMsgCfg, publishSpec)
+		if feeConfig != nil && feeConfig.MaxPublishDealsFeePerDeal.Int != nil {
+			dp.maxFeePerDeal = abi.TokenAmount(feeConfig.MaxPublishDealsFeePerDeal)
+		}
+		dp.expectedSealDuration = sealDuration
 		lc.Append(fx.Hook{
 			OnStop: func(ctx context.Context) error {
 				dp.Shutdown()
@@ -117,7 +140,9 @@ func newDealPublisher(
 		Shutdown:              cancel,
 		maxDealsPerPublishMsg: publishMsgCfg.MaxDealsPerMsg,
 		publishPeriod:         publishMsgCfg.Period,
+		publishSlack:          publishMsgCfg.Slack,
 		publishSpec:           publishSpec,
+		maxFeePerDeal:         big.Zero(),
 	}
 }
 
@@ -202,10 +227,40 @@ func (p *DealPublisher) processNewDeal(pdeal *pendingDeal) {
 		return
 	}
 
+	// If waiting for the publish period to elapse would get the deal too
+	// close to its start epoch, send a publish message now
+	if p.publishSlack > 0 {
+		periodEnd := time.Now().Add(p.publishPeriod)
+		if !p.publishPeriodStart.IsZero() {
+			periodEnd = p.publishPeriodStart.Add(p.publishPeriod)
+		}
+
+		deadline, err := p.publishDeadline(pdeal.deal)
+		if err != nil {
+			log.Warnf("getting publish deadline of deal with piece CID %s: %s", pdeal.deal.Proposal.PieceCID, err)
+		} else if deadline.Before(periodEnd) {
+			log.Infof("deal with piece CID %s starts within publish slack of %s, publishing deals", pdeal.deal.Proposal.PieceCID, p.publishSlack)
+			p.publishAllDeals()
+			return
+		}
+	}
+
 	// Otherwise wait for more deals to arrive or the timeout to be reached
 	p.waitForMoreDeals()
 }
 
+// publishDeadline returns the time after which publishing the deal would leave
+// less than the publish slack before its start epoch
+func (p *DealPublisher) publishDeadline(deal market2.ClientDealProposal) (time.Time, error) {
+	head, err := p.api.ChainHead(p.ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	untilStart := time.Duration(deal.Proposal.StartEpoch-head.Height()) * time.Duration(build.BlockDelaySecs) * time.Second
+	return time.Now().Add(untilStart - p.publishSlack), nil
+}
+
 func (p *DealPublisher) waitForMoreDeals() {
 	// Check if we're already waiting for deals
 	if !p.publishPeriodStart.IsZero() {
@@ -290,33 +345,79 @@ func (p *DealPublisher) publishReady(ready []*pendingDeal) {
 	}
 
 	// Send the publish message
-	msgCid, err := p.publishDealProposals(deals)
+	msgCid, failed, err := p.publishDealProposals(deals)
 
 	// Signal that each deal has been published
-	for _, pd := range validated {
+	for i, pd := range validated {
+		if ferr, ok := failed[i]; ok {
+			go onComplete(pd, cid.Undef, ferr)
+			continue
+		}
 		go onComplete(pd, msgCid, err)
 	}
 }
 
+// ErrDealStartTooSoon is returned for deals which can't be published because
+// their sector couldn't be sealed before the start epoch of the deal, so that
+// the provider would lose its collateral.
+//
+// Deals can't be renegotiated with a later start epoch, as the start epoch is
+// part of the deal proposal signed by the client, so rejecting the deal
+// before it's published, while no collateral is locked yet, is the only way to
+// avoid being slashed for it.
+type ErrDealStartTooSoon struct {
+	PieceCID   cid.Cid
+	StartEpoch abi.ChainEpoch
+	// The epoch of the chain head the deal was checked at
+	Epoch abi.ChainEpoch
+	// The earliest epoch at which the sector of the deal is expected to be
+	// sealed
+	EarliestStartEpoch abi.ChainEpoch
+}
+
+func (e *ErrDealStartTooSoon) Error() string {
+	if e.Epoch > e.StartEpoch {
+		return fmt.Sprintf("cannot publish deal with piece CID %s: current epoch %d has passed deal proposal start epoch %d",
+			e.PieceCID, e.Epoch, e.StartEpoch)
+	}
+	return fmt.Sprintf("cannot publish deal with piece CID %s: deal proposal start epoch %d is before the earliest epoch the sector of the deal is expected to be sealed at %d",
+		e.PieceCID, e.StartEpoch, e.EarliestStartEpoch)
+}
+
 // validateDeal checks that the deal proposal start epoch hasn't already
-// elapsed
+// elapsed, and that there is still enough time to seal the sector of the
+// deal before its start epoch
 func (p *DealPublisher) validateDeal(deal market2.ClientDealProposal) error {
 	head, err := p.api.ChainHead(p.ctx)
 	if err != nil {
 		return err
 	}
-	if head.Height() > deal.Proposal.StartEpoch {
-		return xerrors.Errorf(
-			"cannot publish deal with piece CID %s: current epoch %d has passed deal proposal start epoch %d",
-			deal.Proposal.PieceCID, head.Height(), deal.Proposal.StartEpoch)
+
+	earliest := head.Height()
+	if p.expectedSealDuration != nil {
+		sealDuration, err := p.expectedSealDuration()
+		if err != nil {
+			return xerrors.Errorf("getting expected seal duration: %w", err)
+		}
+		earliest += abi.ChainEpoch(sealDuration / (time.Duration(build.BlockDelaySecs) * time.Second))
+	}
+
+	if earliest > deal.Proposal.StartEpoch {
+		return &ErrDealStartTooSoon{
+			PieceCID:           deal.Proposal.PieceCID,
+			StartEpoch:         deal.Proposal.StartEpoch,
+			Epoch:              head.Height(),
+			EarliestStartEpoch: earliest,
+		}
 	}
 	return nil
 }
 
-// Sends the publish message
-func (p *DealPublisher) publishDealProposals(deals []market2.ClientDealProposal) (cid.Cid, error) {
+// Sends the publish message. Deals which would make the message fail are left
+// out of it, and returned by index along with the reason for the failure.
+func (p *DealPublisher) publishDealProposals(deals []market2.ClientDealProposal) (cid.Cid, map[int]error, error) {
 	if len(deals) == 0 {
-		return cid.Undef, nil
+		return cid.Undef, nil, nil
 	}
 
 	log.Infof("publishing %d deals in publish deals queue with piece CIDs: %s", len(deals), pieceCids(deals))
@@ -328,35 +429,106 @@ func (p *DealPublisher) publishDealProposals(deals []market2.ClientDealProposal)
 				"not all deals are for same provider: " +
 				fmt.Sprintf("deal with piece CID %s is for provider %s ", deals[0].Proposal.PieceCID, deals[0].Proposal.Provider) +
 				fmt.Sprintf("but deal with piece CID %s is for provider %s", dl.Proposal.PieceCID, dl.Proposal.Provider)
-			return cid.Undef, xerrors.Errorf(msg)
+			return cid.Undef, nil, xerrors.Errorf(msg)
 		}
 	}
 
 	mi, err := p.api.StateMinerInfo(p.ctx, provider, types.EmptyTSK)
 	if err != nil {
-		return cid.Undef, err
+		return cid.Undef, nil, err
+	}
+
+	failed := p.checkDealProposals(mi.Worker, deals)
+	if len(failed) > 0 {
+		ok := make([]market2.ClientDealProposal, 0, len(deals)-len(failed))
+		for i, dl := range deals {
+			if _, f := failed[i]; !f {
+				ok = append(ok, dl)
+			}
+		}
+		deals = ok
+
+		if len(deals) == 0 {
+			return cid.Undef, failed, nil
+		}
+	}
+
+	msg, err := publishDealsMsg(mi.Worker, deals)
+	if err != nil {
+		return cid.Undef, failed, err
 	}
 
+	// maxFee = maxBase + maxPerDeal * nDeals
+	spec := *p.publishSpec
+	spec.MaxFee = big.Add(spec.MaxFee, big.Mul(p.maxFeePerDeal, big.NewInt(int64(len(deals)))))
+
+	smsg, err := p.api.MpoolPushMessage(p.ctx, msg, &spec)
+	if err != nil {
+		return cid.Undef, failed, err
+	}
+	return smsg.Cid(), failed, nil
+}
+
+// checkDealProposals simulates publishing the deals, and if that would fail,
+// simulates publishing each deal on its own to find which deals cause the
+// failure. Deals which would fail are returned by index, along with the reason
+// for the failure.
+func (p *DealPublisher) checkDealProposals(worker address.Address, deals []market2.ClientDealProposal) map[int]error {
+	err := p.callPublishDeals(worker, deals)
+	if err == nil {
+		return nil
+	}
+	if len(deals) == 1 {
+		return map[int]error{0: err}
+	}
+	log.Warnf("publishing %d deals would fail, checking deals one by one: %s", len(deals), err)
+
+	failed := map[int]error{}
+	for i, dl := range deals {
+		if err := p.callPublishDeals(worker, []market2.ClientDealProposal{dl}); err != nil {
+			log.Warnf("rejecting deal with piece CID %s: %s", dl.Proposal.PieceCID, err)
+			failed[i] = err
+		}
+	}
+	return failed
+}
+
+// callPublishDeals returns an error if publishing the deals would fail.
+// Errors of the call itself are only logged, so that the deals still get
+// published when the call can't be made.
+func (p *DealPublisher) callPublishDeals(worker address.Address, deals []market2.ClientDealProposal) error {
+	msg, err := publishDealsMsg(worker, deals)
+	if err != nil {
+		return err
+	}
+
+	res, err := p.api.StateCall(p.ctx, msg, types.EmptyTSK)
+	if err != nil {
+		log.Warnf("simulating publishing of %d deals: %s", len(deals), err)
+		return nil
+	}
+	if res.MsgRct.ExitCode != exitcode.Ok {
+		return xerrors.Errorf("publishing deals would fail with exit code %d: %s", res.MsgRct.ExitCode, res.Error)
+	}
+	return nil
+}
+
+func publishDealsMsg(worker address.Address, deals []market2.ClientDealProposal) (*types.Message, error) {
 	params, err := actors.SerializeParams(&market2.PublishStorageDealsParams{
 		Deals: deals,
 	})
 
 	if err != nil {
-		return cid.Undef, xerrors.Errorf("serializing PublishStorageDeals params failed: %w", err)
+		return nil, xerrors.Errorf("serializing PublishStorageDeals params failed: %w", err)
 	}
 
-	smsg, err := p.api.MpoolPushMessage(p.ctx, &types.Message{
+	return &types.Message{
 		To:     market.Address,
-		From:   mi.Worker,
+		From:   worker,
 		Value:  types.NewInt(0),
 		Method: market.Methods.PublishStorageDeals,
 		Params: params,
-	}, p.publishSpec)
-
-	if err != nil {
-		return cid.Undef, err
-	}
-	return smsg.Cid(), nil
+	}, nil
 }
 
 func pieceCids(deals []market2.ClientDealProposal) string {
@@ -3,6 +3,7 @@ package storageadapter
 import (
 	"bytes"
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -21,7 +22,9 @@ import (
 	market0 "github.com/filecoin-project/specs-actors/actors/builtin/market"
 
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/exitcode"
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
 )
 
 func TestDealPublisher(t *testing.T) {
@@ -172,21 +175,78 @@ func TestForcePublish(t *testing.T) {
 	checkPublishedDeals(t, dpapi, dealsToPublish, []int{2})
 }
 
-func publishDeal(t *testing.T, dp *DealPublisher, ctxCancelled bool, expired bool) market.ClientDealProposal {
-	ctx, cancel := context.WithCancel(context.Background())
-	t.Cleanup(cancel)
+func TestPublishSlack(t *testing.T) {
+	dpapi := newDPAPI(t)
 
-	pctx := ctx
-	if ctxCancelled {
-		pctx, cancel = context.WithCancel(ctx)
-		cancel()
-	}
+	// Deals start 10 epochs after the chain head, so with a slack of more
+	// than 10 epochs they're published without waiting for the publish period
+	dp := newDealPublisher(dpapi, PublishMsgConfig{
+		Period:         time.Hour,
+		MaxDealsPerMsg: 10,
+		Slack:          time.Duration(20*build.BlockDelaySecs) * time.Second,
+	}, &api.MessageSendSpec{MaxFee: abi.NewTokenAmount(1)})
 
-	startEpoch := abi.ChainEpoch(20)
-	if expired {
-		startEpoch = abi.ChainEpoch(5)
+	deal := publishDeal(t, dp, false, false)
+	checkPublishedDeals(t, dpapi, []market.ClientDealProposal{deal}, []int{1})
+}
+
+func TestRejectFailingDeals(t *testing.T) {
+	dpapi := newDPAPI(t)
+
+	dp := newDealPublisher(dpapi, PublishMsgConfig{
+		Period:         time.Hour,
+		MaxDealsPerMsg: 3,
+	}, &api.MessageSendSpec{MaxFee: abi.NewTokenAmount(1)})
+
+	var dealsToPublish []market.ClientDealProposal
+	dealsToPublish = append(dealsToPublish, publishDeal(t, dp, false, false))
+
+	// The failing deal is rejected, the others are published
+	failing := newDeal(t, 20)
+	failing.Proposal.EndEpoch = failingDealEndEpoch
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := dp.Publish(context.Background(), failing)
+		errCh <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	dealsToPublish = append(dealsToPublish, publishDeal(t, dp, false, false))
+
+	checkPublishedDeals(t, dpapi, dealsToPublish, []int{2})
+	require.Error(t, <-errCh)
+}
+
+func TestRejectDealStartingTooSoon(t *testing.T) {
+	dpapi := newDPAPI(t)
+
+	dp := newDealPublisher(dpapi, PublishMsgConfig{
+		Period:         time.Millisecond,
+		MaxDealsPerMsg: 5,
+	}, &api.MessageSendSpec{MaxFee: abi.NewTokenAmount(1)})
+
+	// Deals start 10 epochs after the chain head, sealing takes 20
+	dp.expectedSealDuration = func() (time.Duration, error) {
+		return time.Duration(20*build.BlockDelaySecs) * time.Second, nil
 	}
-	deal := market.ClientDealProposal{
+
+	_, err := dp.Publish(context.Background(), newDeal(t, 20))
+	var tooSoon *ErrDealStartTooSoon
+	require.True(t, errors.As(err, &tooSoon))
+	require.EqualValues(t, 20, tooSoon.StartEpoch)
+	require.EqualValues(t, 30, tooSoon.EarliestStartEpoch)
+
+	// Deals starting after the sector is sealed are published
+	deal := newDeal(t, 40)
+	go func() {
+		_, err := dp.Publish(context.Background(), deal)
+		require.NoError(t, err)
+	}()
+	checkPublishedDeals(t, dpapi, []market.ClientDealProposal{deal}, []int{1})
+}
+
+func newDeal(t *testing.T, startEpoch abi.ChainEpoch) market.ClientDealProposal {
+	return market.ClientDealProposal{
 		Proposal: market0.DealProposal{
 			PieceCID:   generateCids(1)[0],
 			Client:     getClientActor(t),
@@ -199,6 +259,23 @@ func publishDeal(t *testing.T, dp *DealPublisher, ctxCancelled bool, expired boo
 			Data: []byte("signature data"),
 		},
 	}
+}
+
+func publishDeal(t *testing.T, dp *DealPublisher, ctxCancelled bool, expired bool) market.ClientDealProposal {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	pctx := ctx
+	if ctxCancelled {
+		pctx, cancel = context.WithCancel(ctx)
+		cancel()
+	}
+
+	startEpoch := abi.ChainEpoch(20)
+	if expired {
+		startEpoch = abi.ChainEpoch(5)
+	}
+	deal := newDeal(t, startEpoch)
 
 	go func() {
 		_, err := dp.Publish(pctx, deal)
@@ -279,6 +356,8 @@ func dealPieceCids(deals []market2.ClientDealProposal) []cid.Cid {
 	return cids
 }
 
+const failingDealEndEpoch = abi.ChainEpoch(666)
+
 type dpAPI struct {
 	t      *testing.T
 	worker address.Address
@@ -315,6 +394,23 @@ func (d *dpAPI) StateMinerInfo(ctx context.Context, address address.Address, key
 	return miner.MinerInfo{Worker: d.worker}, nil
 }
 
+// StateCall fails publishing deals that end at failingDealEndEpoch
+func (d *dpAPI) StateCall(ctx context.Context, msg *types.Message, tsk types.TipSetKey) (*api.InvocResult, error) {
+	var params market2.PublishStorageDealsParams
+	err := params.UnmarshalCBOR(bytes.NewReader(msg.Params))
+	require.NoError(d.t, err)
+
+	for _, dl := range params.Deals {
+		if dl.Proposal.EndEpoch == failingDealEndEpoch {
+			return &api.InvocResult{
+				MsgRct: &types.MessageReceipt{ExitCode: exitcode.ErrIllegalArgument},
+				Error:  "invalid deal",
+			}, nil
+		}
+	}
+	return &api.InvocResult{MsgRct: &types.MessageReceipt{ExitCode: exitcode.Ok}}, nil
+}
+
 func (d *dpAPI) MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error) {
 	d.pushedMsgs <- msg
 	return &types.SignedMessage{Message: *msg}, nil
@@ -5,6 +5,7 @@ package storageadapter
 import (
 	"context"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/ipfs/go-cid"
@@ -95,11 +96,11 @@ func (n *ProviderNodeAdapter) OnDealComplete(ctx context.Context, deal storagema
 		return nil, xerrors.Errorf("deal.PublishCid can't be nil")
 	}
 
-	sdInfo := sealing.DealInfo{
+	sdInfo := api.PieceDealInfo{
 		DealID:       deal.DealID,
 		DealProposal: &deal.Proposal,
 		PublishCid:   deal.PublishCid,
-		DealSchedule: sealing.DealSchedule{
+		DealSchedule: api.DealSchedule{
 			StartEpoch: deal.ClientDealProposal.Proposal.StartEpoch,
 			EndEpoch:   deal.ClientDealProposal.Proposal.EndEpoch,
 		},
@@ -109,7 +110,7 @@ func (n *ProviderNodeAdapter) OnDealComplete(ctx context.Context, deal storagema
 	p, offset, err := n.secb.AddPiece(ctx, pieceSize, pieceData, sdInfo)
 	curTime := time.Now()
 	for time.Since(curTime) < addPieceRetryTimeout {
-		if !xerrors.Is(err, sealing.ErrTooManySectorsSealing) {
+		if !isTooManySectorsSealing(err) {
 			if err != nil {
 				log.Errorf("failed to addPiece for deal %d, err: %v", deal.DealID, err)
 			}
@@ -135,6 +136,15 @@ func (n *ProviderNodeAdapter) OnDealComplete(ctx context.Context, deal storagema
 	}, nil
 }
 
+// isTooManySectorsSealing also matches the error message, as the error loses
+// its identity when the sealing subsystem is accessed over RPC
+func isTooManySectorsSealing(err error) bool {
+	if err == nil {
+		return false
+	}
+	return xerrors.Is(err, sealing.ErrTooManySectorsSealing) || strings.Contains(err.Error(), sealing.ErrTooManySectorsSealing.Error())
+}
+
 func (n *ProviderNodeAdapter) VerifySignature(ctx context.Context, sig crypto.Signature, addr address.Address, input []byte, encodedTs shared.TipSetToken) (bool, error) {
 	addr, err := n.StateAccountKey(ctx, addr, types.EmptyTSK)
 	if err != nil {
@@ -239,23 +249,16 @@ func (n *ProviderNodeAdapter) LocatePieceForDealWithinSector(ctx context.Context
 	}
 
 	// TODO: better strategy (e.g. look for already unsealed)
-	var best api.SealedRef
-	var bestSi sealing.SectorInfo
 	for _, r := range refs {
-		si, err := n.secb.Miner.GetSectorInfo(r.SectorID)
+		si, err := n.secb.SectorBuilder.SectorsStatus(ctx, r.SectorID, false)
 		if err != nil {
 			return 0, 0, 0, xerrors.Errorf("getting sector info: %w", err)
 		}
-		if si.State == sealing.Proving {
-			best = r
-			bestSi = si
-			break
+		if si.State == api.SectorState(sealing.Proving) {
+			return r.SectorID, r.Offset, r.Size.Padded(), nil
 		}
 	}
-	if bestSi.State == sealing.UndefinedSectorState {
-		return 0, 0, 0, xerrors.New("no sealed sector found")
-	}
-	return best.SectorID, best.Offset, best.Size.Padded(), nil
+	return 0, 0, 0, xerrors.New("no sealed sector found")
 }
 
 func (n *ProviderNodeAdapter) DealProviderCollateralBounds(ctx context.Context, size abi.PaddedPieceSize, isVerified bool) (abi.TokenAmount, abi.TokenAmount, error) {
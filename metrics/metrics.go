@@ -15,6 +15,11 @@ import (
 
 // Distribution
 var defaultMillisecondsDistribution = view.Distribution(0.01, 0.05, 0.1, 0.3, 0.6, 0.8, 1, 2, 3, 4, 5, 6, 8, 10, 13, 16, 20, 25, 30, 40, 50, 65, 80, 100, 130, 160, 200, 250, 300, 400, 500, 650, 800, 1000, 2000, 3000, 4000, 5000, 7500, 10000, 20000, 50000, 100000)
+var sealingStateSecondsDistribution = view.Distribution(
+	1, 5, 15, 30, 60, 2*60, 5*60, 10*60, 20*60, 30*60, 45*60, // short states, batching
+	60*60, 75*60, 90*60, 2*60*60, 3*60*60, 4*60*60, 5*60*60, 6*60*60, 8*60*60, // WaitSeed, PC1, C2
+	12*60*60, 24*60*60, 2*24*60*60, 7*24*60*60, // waiting for deals, stuck sectors
+)
 var workMillisecondsDistribution = view.Distribution(
 	250, 500, 1000, 2000, 5000, 10_000, 30_000, 60_000, 2*60_000, 5*60_000, 10*60_000, 15*60_000, 30*60_000, // short sealing tasks
 	40*60_000, 45*60_000, 50*60_000, 55*60_000, 60*60_000, 65*60_000, 70*60_000, 75*60_000, 80*60_000, 85*60_000, 100*60_000, 120*60_000, // PC2 / C2 range
@@ -45,6 +50,8 @@ var (
 	// miner
 	TaskType, _       = tag.NewKey("task_type")
 	WorkerHostname, _ = tag.NewKey("worker_hostname")
+	SectorState, _    = tag.NewKey("sector_state")
+	SealingMsgType, _ = tag.NewKey("msg_type")
 )
 
 // Measures
@@ -53,6 +60,8 @@ var (
 	LotusInfo          = stats.Int64("info", "Arbitrary counter to tag lotus info to", stats.UnitDimensionless)
 	PeerCount          = stats.Int64("peer/count", "Current number of FIL peers", stats.UnitDimensionless)
 	APIRequestDuration = stats.Float64("api/request_duration_ms", "Duration of API requests", stats.UnitMilliseconds)
+	APIRateLimited     = stats.Int64("api/rate_limited", "Counter for API requests rejected by rate limiting", stats.UnitDimensionless)
+	APICacheHit        = stats.Int64("api/cache_hit", "Counter for API requests served from cache", stats.UnitDimensionless)
 
 	// chain
 	ChainNodeHeight                     = stats.Int64("chain/node_height", "Current Height of the node", stats.UnitDimensionless)
@@ -63,6 +72,7 @@ var (
 	MessageValidationFailure            = stats.Int64("message/failure", "Counter for message validation failures", stats.UnitDimensionless)
 	MessageValidationSuccess            = stats.Int64("message/success", "Counter for message validation successes", stats.UnitDimensionless)
 	MessageValidationDuration           = stats.Float64("message/validation_ms", "Duration of message validation", stats.UnitMilliseconds)
+	MessageArrivalOffset                = stats.Float64("message/arrival_offset_ms", "Time from the start of the epoch to the arrival of accepted messages", stats.UnitMilliseconds)
 	MpoolGetNonceDuration               = stats.Float64("mpool/getnonce_ms", "Duration of getStateNonce in mpool", stats.UnitMilliseconds)
 	MpoolGetBalanceDuration             = stats.Float64("mpool/getbalance_ms", "Duration of getStateBalance in mpool", stats.UnitMilliseconds)
 	MpoolAddTsDuration                  = stats.Float64("mpool/addts_ms", "Duration of addTs in mpool", stats.UnitMilliseconds)
@@ -74,6 +84,7 @@ var (
 	BlockValidationSuccess              = stats.Int64("block/success", "Counter for block validation successes", stats.UnitDimensionless)
 	BlockValidationDurationMilliseconds = stats.Float64("block/validation_ms", "Duration for Block Validation in ms", stats.UnitMilliseconds)
 	BlockDelay                          = stats.Int64("block/delay", "Delay of accepted blocks, where delay is >5s", stats.UnitMilliseconds)
+	BlockArrivalDelay                   = stats.Float64("block/arrival_ms", "Time from the start of the epoch to the arrival of accepted blocks", stats.UnitMilliseconds)
 	PubsubPublishMessage                = stats.Int64("pubsub/published", "Counter for total published messages", stats.UnitDimensionless)
 	PubsubDeliverMessage                = stats.Int64("pubsub/delivered", "Counter for total delivered messages", stats.UnitDimensionless)
 	PubsubRejectMessage                 = stats.Int64("pubsub/rejected", "Counter for total rejected messages", stats.UnitDimensionless)
@@ -96,6 +107,12 @@ var (
 	WorkerCallsReturnedCount     = stats.Int64("sealing/worker_calls_returned_count", "Counter of returned worker tasks", stats.UnitDimensionless)
 	WorkerCallsReturnedDuration  = stats.Float64("sealing/worker_calls_returned_ms", "Counter of returned worker tasks", stats.UnitMilliseconds)
 	WorkerUntrackedCallsReturned = stats.Int64("sealing/worker_untracked_calls_returned", "Counter of returned untracked worker tasks", stats.UnitDimensionless)
+	SealingSectorsInState        = stats.Int64("sealing/sectors_in_state", "Number of sectors in each sealing state", stats.UnitDimensionless)
+	SealingStateDuration         = stats.Float64("sealing/state_duration_s", "Time spent by sectors in a sealing state", stats.UnitSeconds)
+	SealingMessagesSent          = stats.Int64("sealing/messages_sent", "Counter of precommit and commit messages sent", stats.UnitDimensionless)
+	SealingMessagesFailed        = stats.Int64("sealing/messages_failed", "Counter of precommit and commit messages which failed to send or execute", stats.UnitDimensionless)
+	CommitBatcherPending         = stats.Int64("sealing/commit_batcher_pending", "Number of sectors waiting in the commit batcher", stats.UnitDimensionless)
+	CommitBatcherOldestAge       = stats.Float64("sealing/commit_batcher_oldest_age_s", "Age of the oldest sector waiting in the commit batcher", stats.UnitSeconds)
 
 	// splitstore
 	SplitstoreMiss                  = stats.Int64("splitstore/miss", "Number of misses in hotstre access", stats.UnitDimensionless)
@@ -160,6 +177,10 @@ var (
 			return view.Distribution(bounds...)
 		}(),
 	}
+	BlockArrivalDelayView = &view.View{
+		Measure:     BlockArrivalDelay,
+		Aggregation: defaultMillisecondsDistribution,
+	}
 	MessagePublishedView = &view.View{
 		Measure:     MessagePublished,
 		Aggregation: view.Count(),
@@ -182,6 +203,10 @@ var (
 		Aggregation: defaultMillisecondsDistribution,
 		TagKeys:     []tag.Key{MsgValid, Local},
 	}
+	MessageArrivalOffsetView = &view.View{
+		Measure:     MessageArrivalOffset,
+		Aggregation: defaultMillisecondsDistribution,
+	}
 	MpoolGetNonceDurationView = &view.View{
 		Measure:     MpoolGetNonceDuration,
 		Aggregation: defaultMillisecondsDistribution,
@@ -239,6 +264,16 @@ var (
 		Aggregation: defaultMillisecondsDistribution,
 		TagKeys:     []tag.Key{APIInterface, Endpoint},
 	}
+	APIRateLimitedView = &view.View{
+		Measure:     APIRateLimited,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{Endpoint},
+	}
+	APICacheHitView = &view.View{
+		Measure:     APICacheHit,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{Endpoint},
+	}
 	VMFlushCopyDurationView = &view.View{
 		Measure:     VMFlushCopyDuration,
 		Aggregation: view.Sum(),
@@ -296,6 +331,34 @@ var (
 		Aggregation: workMillisecondsDistribution,
 		TagKeys:     []tag.Key{TaskType, WorkerHostname},
 	}
+	SealingSectorsInStateView = &view.View{
+		Measure:     SealingSectorsInState,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{SectorState},
+	}
+	SealingStateDurationView = &view.View{
+		Measure:     SealingStateDuration,
+		Aggregation: sealingStateSecondsDistribution,
+		TagKeys:     []tag.Key{SectorState},
+	}
+	SealingMessagesSentView = &view.View{
+		Measure:     SealingMessagesSent,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{SealingMsgType},
+	}
+	SealingMessagesFailedView = &view.View{
+		Measure:     SealingMessagesFailed,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{SealingMsgType, FailureType},
+	}
+	CommitBatcherPendingView = &view.View{
+		Measure:     CommitBatcherPending,
+		Aggregation: view.LastValue(),
+	}
+	CommitBatcherOldestAgeView = &view.View{
+		Measure:     CommitBatcherOldestAge,
+		Aggregation: view.LastValue(),
+	}
 
 	// splitstore
 	SplitstoreMissView = &view.View{
@@ -326,6 +389,8 @@ var DefaultViews = func() []*view.View {
 		InfoView,
 		PeerCountView,
 		APIRequestDurationView,
+		APIRateLimitedView,
+		APICacheHitView,
 	}
 	views = append(views, blockstore.DefaultViews...)
 	views = append(views, rpcmetrics.DefaultViews...)
@@ -341,11 +406,13 @@ var ChainNodeViews = append([]*view.View{
 	BlockValidationSuccessView,
 	BlockValidationDurationView,
 	BlockDelayView,
+	BlockArrivalDelayView,
 	MessagePublishedView,
 	MessageReceivedView,
 	MessageValidationFailureView,
 	MessageValidationSuccessView,
 	MessageValidationDurationView,
+	MessageArrivalOffsetView,
 	MpoolGetNonceDurationView,
 	MpoolGetBalanceDurationView,
 	MpoolAddTsDurationView,
@@ -379,6 +446,12 @@ var MinerNodeViews = append([]*view.View{
 	WorkerCallsReturnedCountView,
 	WorkerUntrackedCallsReturnedView,
 	WorkerCallsReturnedDurationView,
+	SealingSectorsInStateView,
+	SealingStateDurationView,
+	SealingMessagesSentView,
+	SealingMessagesFailedView,
+	CommitBatcherPendingView,
+	CommitBatcherOldestAgeView,
 }, DefaultViews...)
 
 // SinceInMilliseconds returns the duration of time since the provide time as a float64.
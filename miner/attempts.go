@@ -0,0 +1,124 @@
+package miner
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+)
+
+// miningAttemptHistory is how many mining attempts are kept
+const miningAttemptHistory = int(builtin.EpochsInDay)
+
+func attemptKey(epoch abi.ChainEpoch) datastore.Key {
+	// zero padded so that attempts are listed in epoch order
+	return datastore.NewKey(fmt.Sprintf("%019d", epoch))
+}
+
+// SetAttemptStore makes the miner persist its mining attempts in the
+// datastore, so that they survive restarts, and loads the ones recorded
+// before.
+func (m *Miner) SetAttemptStore(ds datastore.Batching) error {
+	ads := namespace.Wrap(ds, datastore.NewKey("/miner/attempts"))
+
+	res, err := ads.Query(query.Query{Orders: []query.Order{query.OrderByKey{}}})
+	if err != nil {
+		return xerrors.Errorf("querying mining attempts: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var attempts []api.MiningAttempt
+	var malformed []datastore.Key
+	for r := range res.Next() {
+		if r.Error != nil {
+			return xerrors.Errorf("reading mining attempts: %w", r.Error)
+		}
+
+		var at api.MiningAttempt
+		if err := json.Unmarshal(r.Value, &at); err != nil {
+			log.Warnw("dropping malformed mining attempt", "key", r.Key, "error", err)
+			malformed = append(malformed, datastore.RawKey(r.Key))
+			continue
+		}
+		attempts = append(attempts, at)
+	}
+
+	for _, k := range malformed {
+		if err := ads.Delete(k); err != nil {
+			return xerrors.Errorf("deleting mining attempt %s: %w", k, err)
+		}
+	}
+
+	m.attemptsLk.Lock()
+	defer m.attemptsLk.Unlock()
+
+	m.attemptsDs = ads
+	// attempts recorded before the store was set come last
+	for _, at := range m.attempts {
+		m.putAttemptLocked(at)
+	}
+	m.attempts = append(attempts, m.attempts...)
+	m.pruneAttemptsLocked()
+
+	return nil
+}
+
+func (m *Miner) recordAttempt(at api.MiningAttempt) {
+	m.attemptsLk.Lock()
+	defer m.attemptsLk.Unlock()
+
+	m.attempts = append(m.attempts, at)
+	m.pruneAttemptsLocked()
+	m.putAttemptLocked(at)
+}
+
+func (m *Miner) putAttemptLocked(at api.MiningAttempt) {
+	if m.attemptsDs == nil {
+		return
+	}
+
+	b, err := json.Marshal(at)
+	if err != nil {
+		log.Errorw("marshaling mining attempt", "epoch", at.Epoch, "error", err)
+		return
+	}
+	if err := m.attemptsDs.Put(attemptKey(at.Epoch), b); err != nil {
+		log.Errorw("persisting mining attempt", "epoch", at.Epoch, "error", err)
+	}
+}
+
+// pruneAttemptsLocked drops the attempts past the history, also from the
+// datastore
+func (m *Miner) pruneAttemptsLocked() {
+	if len(m.attempts) <= miningAttemptHistory {
+		return
+	}
+
+	drop := m.attempts[:len(m.attempts)-miningAttemptHistory]
+	m.attempts = m.attempts[len(m.attempts)-miningAttemptHistory:]
+
+	if m.attemptsDs == nil {
+		return
+	}
+	for _, at := range drop {
+		if err := m.attemptsDs.Delete(attemptKey(at.Epoch)); err != nil {
+			log.Errorw("deleting old mining attempt", "epoch", at.Epoch, "error", err)
+		}
+	}
+}
+
+// Attempts returns the recent attempts to mine blocks, oldest first.
+func (m *Miner) Attempts() []api.MiningAttempt {
+	m.attemptsLk.Lock()
+	defer m.attemptsLk.Unlock()
+
+	return append([]api.MiningAttempt{}, m.attempts...)
+}
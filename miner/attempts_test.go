@@ -0,0 +1,69 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+func TestAttemptsPersisted(t *testing.T) {
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+
+	epochs := func(ats []api.MiningAttempt) []abi.ChainEpoch {
+		var out []abi.ChainEpoch
+		for _, at := range ats {
+			out = append(out, at.Epoch)
+		}
+		return out
+	}
+
+	m := &Miner{}
+	// attempts made before the store is set are persisted too
+	m.recordAttempt(api.MiningAttempt{Epoch: 12})
+	require.NoError(t, m.SetAttemptStore(ds))
+	m.recordAttempt(api.MiningAttempt{Epoch: 13, Eligible: true, Won: true})
+	m.recordAttempt(api.MiningAttempt{Epoch: 14, Error: "failed"})
+
+	// a restarted miner loads the attempts in epoch order
+	m = &Miner{}
+	require.NoError(t, m.SetAttemptStore(ds))
+	ats := m.Attempts()
+	require.Equal(t, []abi.ChainEpoch{12, 13, 14}, epochs(ats))
+	require.True(t, ats[1].Won)
+	require.Equal(t, "failed", ats[2].Error)
+
+	// malformed entries are dropped
+	ads := namespace.Wrap(ds, datastore.NewKey("/miner/attempts"))
+	require.NoError(t, ads.Put(attemptKey(15), []byte("not json")))
+	m = &Miner{}
+	require.NoError(t, m.SetAttemptStore(ds))
+	require.Equal(t, []abi.ChainEpoch{12, 13, 14}, epochs(m.Attempts()))
+	has, err := ads.Has(attemptKey(15))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// only the recent attempts are kept, also in the datastore
+	for e := 100; e < 100+miningAttemptHistory; e++ {
+		m.recordAttempt(api.MiningAttempt{Epoch: abi.ChainEpoch(e)})
+	}
+	ats = m.Attempts()
+	require.Len(t, ats, miningAttemptHistory)
+	require.Equal(t, abi.ChainEpoch(100), ats[0].Epoch)
+
+	res, err := ads.Query(query.Query{KeysOnly: true})
+	require.NoError(t, err)
+	keys, err := res.Rest()
+	require.NoError(t, err)
+	require.Len(t, keys, miningAttemptHistory)
+	has, err = ads.Has(attemptKey(13))
+	require.NoError(t, err)
+	require.False(t, has)
+}
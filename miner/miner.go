@@ -22,6 +22,7 @@ import (
 	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-state-types/crypto"
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/ipfs/go-datastore"
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
@@ -29,6 +30,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
 
 	logging "github.com/ipfs/go-log/v2"
 	"go.opencensus.io/trace"
@@ -40,6 +42,7 @@ var log = logging.Logger("miner")
 // Journal event types.
 const (
 	evtTypeBlockMined = iota
+	evtTypeWinningPoSt
 )
 
 // waitFunc is expected to pace block mining at the configured network rate.
@@ -95,7 +98,8 @@ func NewMiner(api v1api.FullNode, epp gen.WinningPoStProver, addr address.Addres
 		sf:                sf,
 		minedBlockHeights: arc,
 		evtTypes: [...]journal.EventType{
-			evtTypeBlockMined: j.RegisterEventType("miner", "block_mined"),
+			evtTypeBlockMined:  j.RegisterEventType("miner", "block_mined"),
+			evtTypeWinningPoSt: j.RegisterEventType("miner", "winning_post"),
 		},
 		journal: j,
 	}
@@ -125,8 +129,16 @@ type Miner struct {
 	// intended to avoid slashings in case of a bug.
 	minedBlockHeights *lru.ARCCache
 
-	evtTypes [1]journal.EventType
+	evtTypes [2]journal.EventType
 	journal  journal.Journal
+
+	alerting      *alerting.Alerting
+	latencyAlert  alerting.AlertType
+	latencyMargin time.Duration
+
+	attemptsLk sync.Mutex
+	attempts   []api.MiningAttempt
+	attemptsDs datastore.Datastore
 }
 
 // Address returns the address of the miner.
@@ -427,6 +439,7 @@ func (m *Miner) mineOne(ctx context.Context, base *MiningBase) (minedBlock *type
 	var winner *types.ElectionProof
 	var mbi *api.MiningBaseInfo
 	var rbase types.BeaconEntry
+	var proofLat *WinningPoStLatency
 	defer func() {
 
 		var hasMinPower bool
@@ -467,6 +480,22 @@ func (m *Miner) mineOne(ctx context.Context, base *MiningBase) (minedBlock *type
 			"error", err,
 		}
 
+		attempt := api.MiningAttempt{
+			Epoch:     round,
+			Base:      base.TipSet.Key(),
+			LateStart: isLate,
+			Eligible:  mbi.EligibleForMining,
+			Won:       winner != nil,
+		}
+		if proofLat != nil {
+			attempt.ProofTook = proofLat.Took
+			attempt.ProofMargin = proofLat.Margin
+		}
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+		m.recordAttempt(attempt)
+
 		if err != nil {
 			log.Errorw("completed mineOne", logStruct...)
 		} else if isLate || (hasMinPower && !mbi.EligibleForMining) {
@@ -539,6 +568,8 @@ func (m *Miner) mineOne(ctx context.Context, base *MiningBase) (minedBlock *type
 	}
 
 	tProof := build.Clock.Now()
+	lat := m.recordProofLatency(base, round, tSeed, tProof)
+	proofLat = &lat
 
 	// get pending messages early,
 	msgs, err := m.api.MpoolSelect(context.TODO(), base.TipSet.Key(), ticket.Quality())
@@ -577,8 +608,14 @@ func (m *Miner) mineOne(ctx context.Context, base *MiningBase) (minedBlock *type
 }
 
 func (m *Miner) computeTicket(ctx context.Context, brand *types.BeaconEntry, base *MiningBase, mbi *api.MiningBaseInfo) (*types.Ticket, error) {
+	return ComputeTicket(ctx, m.api.WalletSign, m.address, brand, base, mbi.WorkerKey)
+}
+
+// ComputeTicket computes the ticket of a block mined by maddr on top of base,
+// signing with the worker key of the miner.
+func ComputeTicket(ctx context.Context, sign gen.SignFunc, maddr address.Address, brand *types.BeaconEntry, base *MiningBase, worker address.Address) (*types.Ticket, error) {
 	buf := new(bytes.Buffer)
-	if err := m.address.MarshalCBOR(buf); err != nil {
+	if err := maddr.MarshalCBOR(buf); err != nil {
 		return nil, xerrors.Errorf("failed to marshal address to cbor: %w", err)
 	}
 
@@ -592,7 +629,7 @@ func (m *Miner) computeTicket(ctx context.Context, brand *types.BeaconEntry, bas
 		return nil, err
 	}
 
-	vrfOut, err := gen.ComputeVRF(ctx, m.api.WalletSign, mbi.WorkerKey, input)
+	vrfOut, err := gen.ComputeVRF(ctx, sign, worker, input)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,78 @@
+package miner
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+// WinningPoStLatency is recorded in the journal for each winning PoSt
+// computed for a block.
+type WinningPoStLatency struct {
+	Epoch abi.ChainEpoch
+	Took  time.Duration
+
+	// Margin is the time left until the propagation deadline of the block
+	// once the proof was computed, negative when the deadline was missed
+	Margin time.Duration
+}
+
+// SetLatencyAlert makes the miner raise an alert when less than margin is
+// left until the propagation deadline of a block after computing its winning
+// PoSt. The alert is resolved by the next proof computed in time.
+func (m *Miner) SetLatencyAlert(al *alerting.Alerting, margin time.Duration) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	m.alerting = al
+	m.latencyMargin = margin
+	m.latencyAlert = al.AddAlertType("winningpost", "latency")
+}
+
+// propagationDeadline returns when a block mined on base has to be sent out
+// for other miners to include it.
+func propagationDeadline(base *MiningBase) time.Time {
+	ts := base.TipSet.MinTimestamp() + build.BlockDelaySecs*(uint64(base.NullRounds)+1)
+	return time.Unix(int64(ts+build.PropagationDelaySecs), 0)
+}
+
+func (m *Miner) recordProofLatency(base *MiningBase, round abi.ChainEpoch, start, done time.Time) WinningPoStLatency {
+	lat := WinningPoStLatency{
+		Epoch:  round,
+		Took:   done.Sub(start),
+		Margin: propagationDeadline(base).Sub(done),
+	}
+
+	m.journal.RecordEvent(m.evtTypes[evtTypeWinningPoSt], func() interface{} {
+		return lat
+	})
+
+	m.lk.Lock()
+	al, at, margin := m.alerting, m.latencyAlert, m.latencyMargin
+	m.lk.Unlock()
+
+	if lat.Margin < margin {
+		log.Warnw("winning PoSt computed close to the block propagation deadline", "epoch", round, "took", lat.Took, "margin", lat.Margin)
+	}
+	if al == nil {
+		return lat
+	}
+
+	if lat.Margin < margin {
+		al.Raise(at, map[string]interface{}{
+			"message": "winning PoSt computed close to the block propagation deadline",
+			"epoch":   round,
+			"took":    lat.Took.String(),
+			"margin":  lat.Margin.String(),
+		})
+	} else {
+		al.Resolve(at, map[string]interface{}{
+			"message": "winning PoSt computed in time",
+			"epoch":   round,
+		})
+	}
+	return lat
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/exchange"
 	rpcstmgr "github.com/filecoin-project/lotus/chain/stmgr/rpc"
 	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/sub/propagation"
 	"github.com/filecoin-project/lotus/chain/vm"
 	"github.com/filecoin-project/lotus/chain/wallet"
 	"github.com/filecoin-project/lotus/node/hello"
@@ -42,13 +43,18 @@ import (
 	storage2 "github.com/filecoin-project/specs-storage/storage"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore/archive"
+	"github.com/filecoin-project/lotus/chain/actorindex"
 	"github.com/filecoin-project/lotus/chain/beacon"
+	"github.com/filecoin-project/lotus/chain/gasstats"
 	"github.com/filecoin-project/lotus/chain/gen"
 	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
+	"github.com/filecoin-project/lotus/chain/gen/slashfilter/slashsvc"
 	"github.com/filecoin-project/lotus/chain/market"
 	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/messagesigner"
 	"github.com/filecoin-project/lotus/chain/metrics"
+	"github.com/filecoin-project/lotus/chain/snapshot"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/types"
 	ledgerwallet "github.com/filecoin-project/lotus/chain/wallet/ledger"
@@ -59,10 +65,14 @@ import (
 	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
 	sealing "github.com/filecoin-project/lotus/extern/storage-sealing"
 	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
 	"github.com/filecoin-project/lotus/lib/peermgr"
+	"github.com/filecoin-project/lotus/lib/rpctls"
 	_ "github.com/filecoin-project/lotus/lib/sigs/bls"
 	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
+	"github.com/filecoin-project/lotus/lib/tunnel"
 	"github.com/filecoin-project/lotus/markets/dealfilter"
+	"github.com/filecoin-project/lotus/markets/retrievaladapter"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
 	"github.com/filecoin-project/lotus/miner"
 	"github.com/filecoin-project/lotus/node/config"
@@ -120,9 +130,11 @@ const (
 	PstoreAddSelfKeysKey
 	StartListeningKey
 	BootstrapKey
+	RunPeerClassifierKey
 
 	// filecoin
 	SetGenesisKey
+	RunSnapshotImportKey
 
 	RunHelloKey
 	RunChainExchangeKey
@@ -133,13 +145,26 @@ const (
 	HandleIncomingMessagesKey
 	HandleMigrateClientFundsKey
 	HandlePaymentChannelManagerKey
+	SetMessageSelectionPolicyKey
+	RunConsensusFaultDetectorKey
+	RunActorIndexKey
+	RunGasStatsKey
+	RunChainSnapshotsKey
+	SetValidationBudgetKey
 
 	// miner
 	GetParamsKey
+	RunParamsVerifierKey
 	HandleMigrateProviderFundsKey
 	HandleDealsKey
 	HandleRetrievalKey
 	RunSectorServiceKey
+	RunAlertsKey
+	RunSectorTieringKey
+	RunFaultPredictorKey
+	RunPayoutsKey
+	RunWorkerRotationKey
+	ConnectSealingServiceKey
 
 	// daemon
 	ExtractApiKey
@@ -147,6 +172,7 @@ const (
 	SettlePaymentChannelsKey
 	RunPeerTaggerKey
 	SetupFallbackBlockstoresKey
+	RunChainArchiverKey
 
 	SetApiEndpointKey
 
@@ -289,12 +315,14 @@ var ChainNode = Options(
 	Override(new(*hello.Service), hello.NewHelloService),
 	Override(new(exchange.Server), exchange.NewServer),
 	Override(new(*peermgr.PeerMgr), peermgr.NewPeerMgr),
+	Override(new(*propagation.Tracker), modules.NewPropagationTracker),
 
 	// Chain mining API dependencies
 	Override(new(*slashfilter.SlashFilter), modules.NewSlashFilter),
 
 	// Service: Message Pool
 	Override(new(dtypes.DefaultMaxFeeFunc), modules.NewDefaultMaxFeeFunc),
+	Override(new(dtypes.SafeSendThresholdFunc), modules.NewSafeSendThresholdFunc),
 	Override(new(*messagepool.MessagePool), modules.MessagePool),
 	Override(new(*dtypes.MpoolLocker), new(dtypes.MpoolLocker)),
 
@@ -330,6 +358,7 @@ var ChainNode = Options(
 	Override(HandleMigrateClientFundsKey, modules.HandleMigrateClientFunds),
 
 	Override(new(*full.GasPriceCache), full.NewGasPriceCache),
+	Override(new(*full.DeadlineSummaryCache), full.NewDeadlineSummaryCache),
 
 	// Lite node API
 	ApplyIf(isLiteNode,
@@ -364,6 +393,7 @@ var MinerNode = Options(
 	// API dependencies
 	Override(new(api.Common), From(new(common.CommonAPI))),
 	Override(new(sectorstorage.StorageAuth), modules.StorageAuth),
+	Override(new(*rpctls.Certs), func() *rpctls.Certs { return nil }), // set by the run command with API.TLS
 
 	// Actor config
 	Override(new(dtypes.MinerAddress), modules.MinerAddress),
@@ -376,11 +406,20 @@ var MinerNode = Options(
 	Override(new(stores.SectorIndex), From(new(*stores.Index))),
 	Override(new(stores.LocalStorage), From(new(repo.LockedRepo))),
 	Override(new(*stores.Local), modules.LocalStorage),
+	Override(new(*stores.ObjectStore), modules.ObjectStorage(config.DefaultStorageMiner().ObjectStore)),
 	Override(new(*stores.Remote), modules.RemoteStorage),
+	Override(new(*stores.Mover), modules.SectorMover),
+	Override(new(*stores.Tierer), modules.SectorTierer(config.DefaultStorageMiner().Tiering)),
+	Override(RunSectorTieringKey, modules.RunSectorTiering),
+	Override(new(*stores.FaultPredictor), modules.SectorFaultPredictor(config.DefaultStorageMiner().FaultPrediction)),
+	Override(RunFaultPredictorKey, modules.RunFaultPredictor),
 	Override(new(*sectorstorage.Manager), modules.SectorStorage),
+	Override(new(*stores.URLSigner), modules.SectorURLSigner),
+	Override(new(*tunnel.Remotes), tunnel.NewRemotes),
 	Override(new(sectorstorage.SectorManager), From(new(*sectorstorage.Manager))),
 	Override(new(storiface.WorkerReturn), From(new(sectorstorage.SectorManager))),
-	Override(new(sectorstorage.Unsealer), From(new(*sectorstorage.Manager))),
+	Override(new(*sectorstorage.UnsealQueue), modules.UnsealQueue),
+	Override(new(sectorstorage.Unsealer), From(new(*sectorstorage.UnsealQueue))),
 
 	// Sector storage: Proofs
 	Override(new(ffiwrapper.Verifier), ffiwrapper.ProofVerifier),
@@ -393,24 +432,31 @@ var MinerNode = Options(
 
 	// Mining / proving
 	Override(new(*slashfilter.SlashFilter), modules.NewSlashFilter),
-	Override(new(*storage.Miner), modules.StorageMiner(config.DefaultStorageMiner().Fees)),
-	Override(new(*miner.Miner), modules.SetupBlockProducer),
+	Override(new(*storage.Miner), modules.StorageMiner),
+	Override(new(storage.AdditionalMiners), modules.AdditionalMiners(config.DefaultStorageMiner().MultiMiner)),
+	Override(new(*miner.Miner), modules.SetupBlockProducer(config.DefaultStorageMiner().WinningPoSt)),
 	Override(new(gen.WinningPoStProver), storage.NewWinningPoStProver),
 
 	Override(new(*storage.AddressSelector), modules.AddressSelector(nil)),
 
+	// Alerts
+	Override(new(*alerting.Alerting), modules.NewAlerting(config.DefaultStorageMiner().Alerting)),
+	Override(RunAlertsKey, modules.RunAlertChecker(config.DefaultStorageMiner().Alerting)),
+
 	// Markets
 	Override(new(dtypes.StagingMultiDstore), modules.StagingMultiDatastore),
 	Override(new(dtypes.StagingBlockstore), modules.StagingBlockstore),
 	Override(new(dtypes.StagingDAG), modules.StagingDAG),
 	Override(new(dtypes.StagingGraphsync), modules.StagingGraphsync),
 	Override(new(dtypes.ProviderPieceStore), modules.NewProviderPieceStore),
+	Override(new(sectorblocks.SectorBuilder), From(new(*storage.Miner))),
 	Override(new(*sectorblocks.SectorBlocks), sectorblocks.NewSectorBlocks),
 
 	// Markets (retrieval)
 	Override(new(sectorstorage.PieceProvider), sectorstorage.NewPieceProvider),
 	Override(new(retrievalmarket.RetrievalProvider), modules.RetrievalProvider),
 	Override(new(dtypes.RetrievalDealFilter), modules.RetrievalDealFilter(nil)),
+	Override(new(*retrievaladapter.RetrievalPricing), modules.RetrievalPricing),
 	Override(HandleRetrievalKey, modules.HandleRetrieval),
 
 	// Markets (storage)
@@ -440,6 +486,7 @@ var MinerNode = Options(
 	Override(new(dtypes.SetConsiderUnverifiedStorageDealsConfigFunc), modules.NewSetConsideringUnverifiedStorageDealsFunc),
 	Override(new(dtypes.SetSealingConfigFunc), modules.NewSetSealConfigFunc),
 	Override(new(dtypes.GetSealingConfigFunc), modules.NewGetSealConfigFunc),
+	Override(new(sealing.GetFeeConfigFunc), modules.NewGetFeeConfigFunc),
 	Override(new(dtypes.SetExpectedSealDurationFunc), modules.NewSetExpectedSealDurationFunc),
 	Override(new(dtypes.GetExpectedSealDurationFunc), modules.NewGetExpectedSealDurationFunc),
 )
@@ -509,6 +556,8 @@ func ConfigCommon(cfg *config.Common) Option {
 				cfg.Libp2p.ConnMgrHigh,
 				time.Duration(cfg.Libp2p.ConnMgrGrace),
 				cfg.Libp2p.ProtectedPeers)),
+			Override(new(*lp2p.PeerClassifier), lp2p.NewPeerClassifier(cfg.Libp2p)),
+			Override(RunPeerClassifierKey, lp2p.RunPeerClassifier),
 			Override(new(*pubsub.PubSub), lp2p.GossipSub),
 			Override(new(*config.Pubsub), &cfg.Pubsub),
 
@@ -540,6 +589,38 @@ func ConfigFullNode(c interface{}) Option {
 			),
 		),
 		Override(new(dtypes.Graphsync), modules.Graphsync(cfg.Client.SimultaneousTransfers)),
+		Override(SetMessageSelectionPolicyKey, modules.MessageSelectionPolicy(cfg.MessageSelection)),
+
+		If(cfg.FaultReporter.EnableConsensusFaultDetector,
+			Override(new(*slashsvc.Detector), modules.ConsensusFaultDetector(cfg.FaultReporter)),
+			Override(RunConsensusFaultDetectorKey, modules.RunConsensusFaultDetector),
+		),
+
+		If(cfg.Chainstore.EnableActorIndex,
+			Override(new(*actorindex.Index), modules.ActorIndex(cfg.Chainstore.ActorIndex)),
+			Override(RunActorIndexKey, modules.RunActorIndex),
+		),
+
+		If(cfg.Fees.GasUsageEpochs > 0,
+			Override(new(*gasstats.Tracker), modules.GasStats(cfg.Fees)),
+			Override(RunGasStatsKey, modules.RunGasStats),
+		),
+
+		If(cfg.Chainstore.EnableSnapshots,
+			Override(new(*snapshot.Service), modules.ChainSnapshots(cfg.Chainstore.Snapshots)),
+			Override(RunChainSnapshotsKey, modules.RunChainSnapshots),
+		),
+
+		Override(new(*alerting.Alerting), modules.NewAlerting(cfg.Alerting)),
+		Override(SetValidationBudgetKey, modules.SetValidationBudget(cfg.Sync)),
+
+		If(len(cfg.Beacon.ExtraDrandServers) > 0,
+			Override(new(dtypes.DrandSchedule), modules.DrandConfigWithServers(cfg.Beacon.ExtraDrandServers)),
+		),
+
+		If(len(cfg.ProofVerification.Workers) > 0 || cfg.ProofVerification.ParallelLocal > 0,
+			Override(new(ffiwrapper.Verifier), modules.ProofVerifier(cfg.ProofVerification)),
+		),
 
 		If(cfg.Metrics.HeadNotifs,
 			Override(HeadMetricsKey, metrics.SendHeadNotifs(cfg.Metrics.Nickname)),
@@ -564,6 +645,13 @@ func ConfigStorageMiner(c interface{}) Option {
 		return Error(xerrors.Errorf("invalid config from repo, got: %T", c))
 	}
 
+	if !cfg.Subsystems.EnableMarkets && !cfg.Subsystems.EnableSealing {
+		return Error(xerrors.New("at least one of the markets and sealing subsystems must be enabled"))
+	}
+	if !cfg.Subsystems.EnableSealing && cfg.Subsystems.SealerApiInfo == "" {
+		return Error(xerrors.New("Subsystems.SealerApiInfo must be set when the sealing subsystem is disabled"))
+	}
+
 	return Options(
 		ConfigCommon(&cfg.Common),
 
@@ -578,12 +666,96 @@ func ConfigStorageMiner(c interface{}) Option {
 		Override(new(*storageadapter.DealPublisher), storageadapter.NewDealPublisher(&cfg.Fees, storageadapter.PublishMsgConfig{
 			Period:         time.Duration(cfg.Dealmaking.PublishMsgPeriod),
 			MaxDealsPerMsg: cfg.Dealmaking.MaxDealsPerPublishMsg,
+			Slack:          time.Duration(cfg.Dealmaking.PublishMsgSlack),
 		})),
 		Override(new(storagemarket.StorageProviderNode), storageadapter.NewProviderNodeAdapter(&cfg.Fees, &cfg.Dealmaking)),
 
 		Override(new(sectorstorage.SealerConfig), cfg.Storage),
+		Override(new(ffiwrapper.Prover), modules.ProofProver),
+		Override(new(*stores.ObjectStore), modules.ObjectStorage(cfg.ObjectStore)),
 		Override(new(*storage.AddressSelector), modules.AddressSelector(&cfg.Addresses)),
-		Override(new(*storage.Miner), modules.StorageMiner(cfg.Fees)),
+		Override(new(storage.AdditionalMiners), modules.AdditionalMiners(cfg.MultiMiner)),
+		Override(new(*storage.PoStCoordinator), modules.PoStCoordinator(cfg.MultiMiner)),
+
+		Override(new(*alerting.Alerting), modules.NewAlerting(cfg.Alerting)),
+		Override(RunAlertsKey, modules.RunAlertChecker(cfg.Alerting)),
+
+		Override(new(*stores.Tierer), modules.SectorTierer(cfg.Tiering)),
+		Override(new(*stores.FaultPredictor), modules.SectorFaultPredictor(cfg.FaultPrediction)),
+
+		If(cfg.Payout.Enable,
+			Override(new(*storage.PayoutScheduler), modules.PayoutScheduler(cfg.Payout)),
+			Override(RunPayoutsKey, modules.RunPayoutScheduler),
+		),
+		Override(new(*storage.WorkerRotator), modules.WorkerRotator),
+		Override(RunWorkerRotationKey, modules.RunWorkerRotator),
+		Override(new(*storage.OwnerChangeGuard), modules.OwnerChangeGuard),
+
+		Override(GetParamsKey, modules.GetParamsFrom(cfg.ProofParams)),
+		Override(RunParamsVerifierKey, modules.RunParamsVerifier(cfg.ProofParams)),
+
+		Override(new(*miner.Miner), modules.SetupBlockProducer(cfg.WinningPoSt)),
+		If(cfg.WinningPoSt.FallbackAPIInfo != "",
+			Override(new(gen.WinningPoStProver), modules.WinningPoStProverWithFallback(cfg.WinningPoSt)),
+		),
+
+		If(cfg.SealingService.APIInfo != "",
+			Override(ConnectSealingServiceKey, modules.ConnectSealingService(cfg.SealingService)),
+		),
+
+		// markets-only node, sectors are sealed by the process at SealerApiInfo
+		If(!cfg.Subsystems.EnableSealing,
+			Override(new(modules.MinerSealingService), modules.ConnectSealerAPI(cfg.Subsystems.SealerApiInfo)),
+			Override(new(sectorblocks.SectorBuilder), From(new(modules.MinerSealingService))),
+			Override(new(stores.SectorIndex), From(new(modules.MinerSealingService))),
+			Override(new(sectorstorage.Unsealer), From(new(modules.MinerSealingService))),
+			Override(new(sectorstorage.StorageAuth), modules.SealerStorageAuth),
+			Override(new(*stores.Tierer), func() *stores.Tierer { return nil }),
+			Override(new(*stores.FaultPredictor), func() *stores.FaultPredictor { return nil }),
+
+			Unset(new(*storage.Miner)),
+			Unset(new(storage.AdditionalMiners)),
+			Unset(new(*storage.PoStCoordinator)),
+			Unset(new(*storage.AddressSelector)),
+			Unset(new(*miner.Miner)),
+			Unset(new(gen.WinningPoStProver)),
+			Unset(new(*sectorstorage.Manager)),
+			Unset(new(sectorstorage.SectorManager)),
+			Unset(new(storiface.WorkerReturn)),
+			Unset(new(*sectorstorage.UnsealQueue)),
+			Unset(new(*stores.Index)),
+			Unset(new(*stores.Mover)),
+			Unset(new(*stores.URLSigner)),
+			Unset(new(*tunnel.Remotes)),
+			Unset(GetParamsKey),
+			Unset(RunParamsVerifierKey),
+			Unset(RunAlertsKey),
+			Unset(RunSectorTieringKey),
+			Unset(RunFaultPredictorKey),
+			Unset(new(*storage.PayoutScheduler)),
+			Unset(RunPayoutsKey),
+			Unset(new(*storage.WorkerRotator)),
+			Unset(RunWorkerRotationKey),
+			Unset(new(*storage.OwnerChangeGuard)),
+			Unset(ConnectSealingServiceKey),
+		),
+
+		// sealing-only node, deals are made by a separate markets process
+		If(!cfg.Subsystems.EnableMarkets,
+			Unset(new(dtypes.ProviderPieceStore)),
+			Unset(new(*sectorblocks.SectorBlocks)),
+			Unset(new(sectorstorage.PieceProvider)),
+			Unset(new(retrievalmarket.RetrievalProvider)),
+			Unset(new(*retrievaladapter.RetrievalPricing)),
+			Unset(new(dtypes.ProviderDataTransfer)),
+			Unset(new(*storedask.StoredAsk)),
+			Unset(new(storagemarket.StorageProvider)),
+			Unset(new(storagemarket.StorageProviderNode)),
+			Unset(new(*storageadapter.DealPublisher)),
+			Unset(HandleRetrievalKey),
+			Unset(HandleMigrateProviderFundsKey),
+			Unset(HandleDealsKey),
+		),
 	)
 }
 
@@ -615,6 +787,17 @@ func Repo(r repo.Repo) Option {
 
 			Override(new(dtypes.UniversalBlockstore), modules.UniversalBlockstore),
 
+			If(cfg.EnableArchive,
+				Override(new(*archive.Store), modules.ChainArchive(cfg)),
+				Override(RunChainArchiverKey, modules.RunChainArchiver(cfg)),
+			),
+			If(cfg.EnableSharedBlockstore,
+				Override(new(dtypes.SharedBlockstore), modules.SharedBlockstore(cfg)),
+			),
+			If(cfg.EnableArchive || cfg.EnableSharedBlockstore,
+				Override(new(dtypes.UniversalBlockstore), modules.ReadThroughUniversalBlockstore),
+			),
+
 			If(cfg.EnableSplitstore,
 				If(cfg.Splitstore.HotStoreType == "badger",
 					Override(new(dtypes.HotBlockstore), modules.BadgerHotBlockstore)),
@@ -10,8 +10,10 @@ import (
 	"github.com/filecoin-project/go-state-types/big"
 	miner5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/miner"
 
+	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/types"
 	sectorstorage "github.com/filecoin-project/lotus/extern/sector-storage"
+	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
 )
 
 // Common is common config between full node and miner
@@ -30,6 +32,16 @@ type FullNode struct {
 	Wallet     Wallet
 	Fees       FeeConfig
 	Chainstore Chainstore
+
+	MessageSelection  MessageSelectionConfig
+	FaultReporter     FaultReporterConfig
+	ProofVerification ProofVerificationConfig
+	Sync              SyncConfig
+	Beacon            BeaconConfig
+
+	// Alerting configures where alerts raised by the node are sent, the
+	// checks configured by it only apply to miners
+	Alerting AlertingConfig
 }
 
 // // Common
@@ -42,11 +54,42 @@ type Backup struct {
 type StorageMiner struct {
 	Common
 
+	Subsystems MinerSubsystemConfig
 	Dealmaking DealmakingConfig
 	Sealing    SealingConfig
 	Storage    sectorstorage.SealerConfig
 	Fees       MinerFeeConfig
 	Addresses  MinerAddressConfig
+	Alerting   AlertingConfig
+	Tiering    TieringConfig
+	Payout     PayoutConfig
+
+	FaultPrediction FaultPredictionConfig
+
+	// S3-compatible object storage for sealed sectors
+	ObjectStore stores.ObjectStoreConfig
+
+	SealingService SealingServiceConfig
+	WinningPoSt    WinningPoStConfig
+	MultiMiner     MultiMinerConfig
+	ProofParams    ProofParamsConfig
+}
+
+// MinerSubsystemConfig selects the subsystems run by the miner process. The
+// markets can run in a separate process, connected to the one sealing and
+// proving sectors.
+type MinerSubsystemConfig struct {
+	// Run the storage and retrieval markets
+	EnableMarkets bool
+	// Seal, store and prove sectors, and mine blocks
+	EnableSealing bool
+
+	// API info of the miner process sealing sectors, in the form of
+	// `token:multiaddr`. Required when sealing is disabled; pieces of new
+	// deals are added to sectors through it, and the sector index and
+	// sector data are read from it. The token must have admin permission,
+	// sector data is read with a `remote:read` token minted through it.
+	SealerApiInfo string
 }
 
 type DealmakingConfig struct {
@@ -64,6 +107,10 @@ type DealmakingConfig struct {
 	// The maximum number of deals to include in a single PublishStorageDeals
 	// message
 	MaxDealsPerPublishMsg uint64
+	// Time buffer before the start epoch of a deal at which deals waiting to
+	// be published are published without waiting for PublishMsgPeriod to
+	// elapse. 0 = disabled
+	PublishMsgSlack Duration
 	// The maximum collateral that the provider will put up against a deal,
 	// as a multiplier of the minimum collateral bound
 	MaxProviderCollateralMultiplier uint64
@@ -103,6 +150,7 @@ type SealingConfig struct {
 	AggregateCommits bool
 	// maximum batched commit size - batches will be sent immediately above this size
 	MinCommitBatch int
+	// larger batches are split between several aggregate messages
 	MaxCommitBatch int
 	// how long to wait before submitting a batch after crossing the minimum batch size
 	CommitBatchWait Duration
@@ -113,6 +161,36 @@ type SealingConfig struct {
 	TerminateBatchMin  uint64
 	TerminateBatchWait Duration
 
+	// Lifetime of committed capacity sectors, capped at the maximum sector
+	// lifetime allowed by the network. 0 = maximum lifetime
+	CommittedCapacitySectorLifetime Duration
+	// How the expiration of sectors with deals is chosen: "deal-end" ends the
+	// sector with its last deal, "max" keeps the sector for the committed
+	// capacity sector lifetime if it's longer than its deals
+	DealSectorExpiration string
+	// Spread the expirations of sectors over this many steps of
+	// ExpirationLadderStep, picked by sector number, so that sectors sealed
+	// together don't all expire together. 0 = disabled
+	ExpirationLadderSteps int
+	// The length of each expiration ladder step, at least one proving period
+	ExpirationLadderStep Duration
+
+	// Maximum number of sector proofs regenerated at the same time after
+	// proof-related commit failures. Regenerations are scheduled ahead of
+	// the sealing work of new sectors, 0 = no limit
+	MaxProofRegenerations uint64
+
+	// Maintenance mode pauses dispatching new sealing tasks to workers,
+	// accepting storage deals and sending batched messages. Window and
+	// winning PoSt keep running. Toggled with `lotus-miner maintenance`
+	MaintenanceMode bool
+
+	// Sector state transitions are POSTed as JSON to each of these URLs
+	StateWebhookURLs []string
+	// When set, only transitions to these states, e.g. Proving or
+	// CommitFailed, are POSTed to the webhooks
+	StateWebhookStates []string
+
 	// Keep this many sectors in sealing pipeline, start CC if needed
 	// todo TargetSealingSectors uint64
 
@@ -140,6 +218,18 @@ type MinerFeeConfig struct {
 	MaxWindowPoStGasFee    types.FIL
 	MaxPublishDealsFee     types.FIL
 	MaxMarketBalanceAddFee types.FIL
+
+	// maxPublishDealsFee = MaxPublishDealsFee + MaxPublishDealsFeePerDeal * nDeals
+	MaxPublishDealsFeePerDeal types.FIL
+
+	// While the base fee is over BreakerTripBaseFee, precommit and commit
+	// batches are held unless some of their sectors are close to their
+	// deadline, and scheduled withdrawals are deferred. Window PoSts are
+	// always sent. Deferred messages are sent once the base fee is back under
+	// BreakerResetBaseFee, which defaults to the trip threshold. Zero disables
+	// the breaker.
+	BreakerTripBaseFee  types.FIL
+	BreakerResetBaseFee types.FIL
 }
 
 type MinerAddressConfig struct {
@@ -157,11 +247,211 @@ type MinerAddressConfig struct {
 	DisableWorkerFallback bool
 }
 
+type AlertingConfig struct {
+	// How often the alert conditions are checked
+	CheckInterval Duration
+
+	// Raise an alert when the worker or a control address balance drops below
+	// this amount
+	MinAddressBalance types.FIL
+	// Raise an alert when a sector stays in a failed state for longer than this
+	FailedSectorTimeout Duration
+
+	// Alert state transitions are POSTed as JSON to each of these URLs
+	WebhookURLs []string
+	SMTP        SMTPAlertsConfig
+	PagerDuty   PagerDutyAlertsConfig
+}
+
+type SMTPAlertsConfig struct {
+	// host:port of the SMTP server; alert emails are disabled when empty
+	Server   string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+type PagerDutyAlertsConfig struct {
+	// Events API v2 compatible endpoint
+	URL string
+	// Integration key of the service; PagerDuty alerts are disabled when empty
+	RoutingKey string
+}
+
+// TieringConfig configures moving sealed sectors between storage path groups
+// depending on how recently they were retrieved
+type TieringConfig struct {
+	Enable bool
+
+	// Storage groups of the hot and cold tier paths, see
+	// `lotus-miner storage attach --group`
+	HotGroup  string
+	ColdGroup string
+
+	// Sectors without active retrieval deals which weren't retrieved for this
+	// long are moved from the hot to the cold tier
+	DemoteAfter Duration
+	// How often sectors are checked for demotion
+	CheckInterval Duration
+}
+
+// FaultPredictionConfig configures flagging the local storage paths at risk of
+// failing from the telemetry of their devices
+type FaultPredictionConfig struct {
+	Enable bool
+
+	// How often the telemetry of the paths is collected
+	CheckInterval Duration
+	// Command run with each local storage path as its argument, printing the
+	// SMART attributes of the device backing it as a JSON object with any of
+	// the ReallocatedSectors, PendingSectors and UncorrectableErrors fields.
+	// Only the latency of the paths is checked when empty
+	TelemetryCommand string
+
+	// A path is at risk when any of the SMART attributes of its device is
+	// over these
+	MaxReallocatedSectors  int64
+	MaxPendingSectors      int64
+	MaxUncorrectableErrors int64
+	// A path is at risk when writing and reading back a small file takes
+	// longer than MaxLatency for LatencySamples checks in a row
+	MaxLatency     Duration
+	LatencySamples int
+
+	// What is done with the sectors stored in paths at risk, besides raising
+	// an alert: "alert" does nothing else, "move" moves them to healthy local
+	// paths, "declare" declares them faulty until the path is healthy again
+	Action string
+}
+
+// PayoutConfig configures periodically withdrawing the miner actor balance
+type PayoutConfig struct {
+	Enable bool
+
+	// Time between payouts
+	Interval Duration
+	// Available balance left in the miner actor after each payout
+	Float types.FIL
+	// Payouts are skipped while less than this is available above the float
+	MinAmount types.FIL
+	// Max fee of each of the payout messages
+	MaxFee types.FIL
+
+	// The withdrawn funds go to the owner address, which sends each of these
+	// addresses its share. Shares can add up to at most 100 percent, the
+	// remainder stays with the owner
+	Recipients []PayoutRecipient
+}
+
+type PayoutRecipient struct {
+	Address string
+	Percent uint64
+}
+
+// SealingServiceConfig configures delegating sealing of whole sectors to an
+// external sealing service
+type SealingServiceConfig struct {
+	// API info of the service, in the form of `token:multiaddr`; sectors are
+	// sealed by the miner's own workers when empty
+	APIInfo string
+	// How many sectors the service seals at once; new sectors over the limit
+	// are sealed by the miner's workers. 0 for no limit
+	MaxSectors int
+	// Hex-encoded ed25519 public keys the service signs work receipts with.
+	// When set, replicas and proofs without a receipt signed by one of these
+	// keys are rejected
+	ReceiptKeys []string
+}
+
+type WinningPoStConfig struct {
+	// Raise an alert when less than this is left until the propagation
+	// deadline of a block after computing its winning PoSt
+	AlertMargin Duration
+
+	// API info of a second node computing winning PoSts for this miner, in
+	// the form of `token:multiaddr`, usually a standby lotus-miner with its own
+	// GPU and access to the sealed sectors. The second node is raced against
+	// the local prover, and the first proof is used
+	FallbackAPIInfo string
+}
+
+// MultiMinerConfig configures sealing and proving the sectors of additional
+// miner actors with the storage and workers of this miner
+type MultiMinerConfig struct {
+	// Maximum number of PreCommit1, PreCommit2 and Commit2 tasks of the
+	// primary miner scheduled on the shared workers at once, 0 for no limit
+	PrimaryQuota int
+
+	// Maximum number of window PoSt proofs generated at once across the
+	// primary and additional miners, 0 for no limit. When deadlines of
+	// several miners overlap, the others wait for their turn.
+	PoStParallel int
+	// Window PoSt priority of the primary miner, miners with a higher
+	// priority prove first when their deadlines overlap
+	PrimaryPoStPriority int
+
+	Miners []AdditionalMinerConfig
+}
+
+type AdditionalMinerConfig struct {
+	// Address of the miner actor, its worker key must be in the wallet of
+	// the full node
+	Address string
+	// Maximum number of PreCommit1, PreCommit2 and Commit2 tasks of this
+	// miner scheduled on the shared workers at once, 0 for no limit
+	Quota int
+	// Window PoSt priority of this miner, see PrimaryPoStPriority
+	PoStPriority int
+
+	// Control addresses of this miner
+	Addresses MinerAddressConfig
+}
+
+// ProofParamsConfig configures how the miner fetches and checks the proof
+// parameter files
+type ProofParamsConfig struct {
+	// URL prefixes which the CIDs of the parameter files are appended to,
+	// tried in order. When empty, the parameters are fetched from the default
+	// gateway, or IPFS_GATEWAY.
+	Mirrors []string
+	// Number of parallel ranged requests downloading each file from the
+	// mirrors
+	Segments int
+	// How often the parameter files are checked against their digests, files
+	// failing the check are fetched again. 0 disables the checks.
+	VerifyInterval Duration
+}
+
 // API contains configs for API endpoint
 type API struct {
 	ListenAddress       string
 	RemoteListenAddress string
 	Timeout             Duration
+
+	// TLS serves the API with mutual TLS on a second address, for the RPC
+	// links between the miner, workers and markets processes
+	TLS APITLS
+}
+
+// APITLS configures mutual TLS for the API. With the certificate files set,
+// the process also presents its certificate when connecting to the TLS APIs
+// of the other processes, which are given as wss:// or https:// API infos.
+type APITLS struct {
+	// ListenAddress is the multiaddress of the TLS API, e.g.
+	// /ip4/0.0.0.0/tcp/2346. Workers fetch sector data from the miner over
+	// RemoteListenAddress, which should be the TLS address when it is set.
+	// With the TLS API, the plain API only listens on loopback addresses, for
+	// the local CLI.
+	ListenAddress string
+
+	// CertFile and KeyFile are the certificate of the process, CAFile holds
+	// the certificates of the CAs issuing the certificates of the other
+	// processes. The files are reloaded when they change, so certificates
+	// can be rotated in place.
+	CertFile string
+	KeyFile  string
+	CAFile   string
 }
 
 // Libp2p contains configs for libp2p
@@ -175,6 +465,16 @@ type Libp2p struct {
 	ConnMgrLow   uint
 	ConnMgrHigh  uint
 	ConnMgrGrace Duration
+
+	// PubsubPeersHigh and GraphsyncPeersHigh limit the number of connected
+	// peers only used for pubsub and graphsync, the excess unprotected peers
+	// are disconnected. 0 disables the limit.
+	PubsubPeersHigh    uint
+	GraphsyncPeersHigh uint
+
+	// ProtectMarketPeers keeps the connections to the peers using the storage
+	// and retrieval market protocols, such as the deal clients of a miner
+	ProtectMarketPeers bool
 }
 
 type Pubsub struct {
@@ -187,6 +487,27 @@ type Pubsub struct {
 type Chainstore struct {
 	EnableSplitstore bool
 	Splitstore       Splitstore
+
+	// EnableArchive moves old chain headers, messages, receipts and state
+	// trees out of the blockstore into CAR archives, which keep serving reads
+	EnableArchive bool
+	Archive       ChainArchive
+
+	// EnableSharedBlockstore serves the blocks missing locally from a shared,
+	// read-only, blockstore, so that the nodes of a deployment don't each
+	// need to keep the full chain history
+	EnableSharedBlockstore bool
+	SharedBlockstore       SharedBlockstore
+
+	// EnableActorIndex records the epochs at which actors are created and
+	// deleted, for StateActorLifecycle and StateListCreatedActors
+	EnableActorIndex bool
+	ActorIndex       ActorIndex
+
+	// EnableSnapshots periodically exports pruned chain snapshots, for other
+	// nodes to bootstrap from; see ChainLatestSnapshot
+	EnableSnapshots bool
+	Snapshots       ChainSnapshots
 }
 
 type Splitstore struct {
@@ -196,6 +517,69 @@ type Splitstore struct {
 	EnableFullCompaction bool
 	EnableGC             bool // EXPERIMENTAL
 	Archival             bool
+
+	// CompactionHours are the hours of the day, in local time, during which
+	// compaction can start, e.g. to avoid proving windows on shared disks.
+	// Compaction can start at any time when empty.
+	CompactionHours []int
+	// CompactionMaxBandwidth limits the bytes per second copied between the
+	// hot and cold stores during compaction; 0 for no limit
+	CompactionMaxBandwidth int64
+}
+
+type ChainArchive struct {
+	// Path is the directory holding the archives, which can be on cheaper
+	// storage; defaults to the "archive" directory in the repo
+	Path string
+	// KeepEpochs is the number of recent epochs whose chain data is kept in
+	// the blockstore; must be at least the chain finality
+	KeepEpochs int64
+	// MinEpochs is the minimum number of epochs in a new archive
+	MinEpochs int64
+	// Interval is how often to check for chain data to archive
+	Interval Duration
+}
+
+type SharedBlockstore struct {
+	// Type is "lotus" to use the blockstore of another lotus node, or "ipfs"
+	Type string
+	// Endpoint is the API info of the lotus node, in the token:multiaddr
+	// format, or the multiaddr of the IPFS API. The link to the shared
+	// blockstore must be trusted, as blocks aren't verified
+	Endpoint string
+}
+
+type ActorIndex struct {
+	// BackfillEpochs is how many epochs before the head are indexed when the
+	// index is first enabled, as far as the state is available
+	BackfillEpochs int64
+}
+
+type ChainSnapshots struct {
+	// Path is the directory snapshots are written to; defaults to the
+	// "snapshots" directory in the repo
+	Path string
+	// Interval is the time between snapshots
+	Interval Duration
+	// Retention is the number of snapshots kept, locally and in S3; 0 keeps
+	// all of them
+	Retention int
+	// RecentStateroots is the number of epochs of state in the snapshots
+	RecentStateroots int64
+	// PublicURL is the base URL the snapshots are served at, advertised in
+	// the snapshot metadata; defaults to the S3 object URL when uploading
+	PublicURL string
+
+	// S3Endpoint, when set, is an S3-compatible endpoint snapshots are
+	// uploaded to, e.g. https://s3.us-east-1.amazonaws.com. Objects are
+	// addressed path-style, and uploaded in a single request, so the
+	// endpoint must accept objects as large as the snapshots
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3Prefix    string
+	S3AccessKey string
+	S3SecretKey string
 }
 
 // // Full Node
@@ -217,10 +601,91 @@ type Wallet struct {
 	RemoteBackend string
 	EnableLedger  bool
 	DisableLocal  bool
+
+	// SafeSendThreshold is the value above which messages checked for safety,
+	// e.g. by lotus send, need to be forced. Zero disables the check.
+	SafeSendThreshold types.FIL
 }
 
 type FeeConfig struct {
 	DefaultMaxFee types.FIL
+
+	// GasUsageEpochs is how many recent epochs the gas usage by actor and
+	// method is tracked for, see ChainGasUsage; 0 disables tracking
+	GasUsageEpochs int64
+}
+
+// BeaconConfig configures fetching the drand randomness beacon
+type BeaconConfig struct {
+	// ExtraDrandServers are HTTP endpoints of the current drand network, used
+	// along with the built-in ones, e.g. a relay close to the node. Endpoints
+	// are tried healthiest first, and the next one is used when a request
+	// fails.
+	ExtraDrandServers []string
+}
+
+// MessageSelectionConfig configures selecting messages for the blocks mined
+// on this node
+type MessageSelectionConfig struct {
+	// Policies applied when selecting messages, in order:
+	//  - own-messages: select messages sent by the OwnMiners first
+	//  - deal-publishes: select PublishStorageDeals messages first
+	//  - sender-limit: select at most MaxMessagesPerSender messages of each sender
+	Policies []string
+
+	// OwnMiners are the miner actors whose owner, worker and control
+	// addresses are prioritized by the own-messages policy
+	OwnMiners []string
+
+	MaxMessagesPerSender int
+}
+
+// FaultReporterConfig configures detecting consensus faults in the blocks
+// received from the network
+type FaultReporterConfig struct {
+	// EnableConsensusFaultDetector records the blocks of miners mining
+	// multiple blocks at one epoch
+	EnableConsensusFaultDetector bool
+
+	// ConsensusFaultReporterAutoReport submits ReportConsensusFault messages
+	// for detected faults when the reward exceeds the message cost
+	ConsensusFaultReporterAutoReport bool
+
+	// ConsensusFaultReporterAddress sends the reports; the wallet default
+	// address when empty
+	ConsensusFaultReporterAddress string
+
+	ConsensusFaultReporterMaxFee types.FIL
+}
+
+type SyncConfig struct {
+	// ValidationBudget is how long validating a tipset may take before an
+	// alert is raised, 0 to disable the alert
+	ValidationBudget Duration
+
+	// ValidationTimingWindow is the number of recently validated tipsets
+	// SyncValidationTiming reports on
+	ValidationTimingWindow int
+}
+
+type ProofVerificationConfig struct {
+	// Workers are API infos (token:multiaddr) of workers with the verify task
+	// enabled, which seal and PoSt proofs in blocks are sent to in turn for
+	// verification. Workers are trusted with the result, only list workers
+	// you operate.
+	Workers []string
+
+	// LocalFallback verifies proofs locally when none of the workers could
+	// verify them
+	LocalFallback bool
+
+	// ParallelLocal limits the number of proofs verified locally at once, 0
+	// for no limit
+	ParallelLocal int
+
+	// WorkerTimeout is how long a worker has to verify a proof before it's
+	// sent to the next worker, or verified locally, 0 for no limit
+	WorkerTimeout Duration
 }
 
 func defCommon() Common {
@@ -240,6 +705,8 @@ func defCommon() Common {
 			ConnMgrLow:   150,
 			ConnMgrHigh:  180,
 			ConnMgrGrace: Duration(20 * time.Second),
+
+			ProtectMarketPeers: true,
 		},
 		Pubsub: Pubsub{
 			Bootstrapper: false,
@@ -258,7 +725,11 @@ func DefaultFullNode() *FullNode {
 	return &FullNode{
 		Common: defCommon(),
 		Fees: FeeConfig{
-			DefaultMaxFee: DefaultDefaultMaxFee,
+			DefaultMaxFee:  DefaultDefaultMaxFee,
+			GasUsageEpochs: 2880, // a day
+		},
+		Wallet: Wallet{
+			SafeSendThreshold: types.MustParseFIL("0"),
 		},
 		Client: Client{
 			SimultaneousTransfers: DefaultSimultaneousTransfers,
@@ -268,6 +739,37 @@ func DefaultFullNode() *FullNode {
 			Splitstore: Splitstore{
 				HotStoreType: "badger",
 			},
+			Archive: ChainArchive{
+				KeepEpochs: 7 * 2880, // a week
+				MinEpochs:  2880,
+				Interval:   Duration(time.Hour),
+			},
+			Snapshots: ChainSnapshots{
+				Interval:         Duration(24 * time.Hour),
+				Retention:        3,
+				RecentStateroots: 2000,
+			},
+		},
+		MessageSelection: MessageSelectionConfig{
+			MaxMessagesPerSender: 50,
+		},
+		FaultReporter: FaultReporterConfig{
+			ConsensusFaultReporterMaxFee: types.MustParseFIL("0.01"),
+		},
+		ProofVerification: ProofVerificationConfig{
+			LocalFallback: true,
+			WorkerTimeout: Duration(10 * time.Second),
+		},
+		Sync: SyncConfig{
+			// alert when less than a third of the block time is left
+			ValidationBudget:       Duration(time.Duration(build.BlockDelaySecs) * time.Second * 2 / 3),
+			ValidationTimingWindow: 120,
+		},
+		Alerting: AlertingConfig{
+			WebhookURLs: []string{},
+			PagerDuty: PagerDutyAlertsConfig{
+				URL: "https://events.pagerduty.com/v2/enqueue",
+			},
 		},
 	}
 }
@@ -276,6 +778,11 @@ func DefaultStorageMiner() *StorageMiner {
 	cfg := &StorageMiner{
 		Common: defCommon(),
 
+		Subsystems: MinerSubsystemConfig{
+			EnableMarkets: true,
+			EnableSealing: true,
+		},
+
 		Sealing: SealingConfig{
 			MaxWaitDealsSectors:       2, // 64G with 32G sectors
 			MaxSealingSectors:         0,
@@ -299,6 +806,16 @@ func DefaultStorageMiner() *StorageMiner {
 			TerminateBatchMin:  1,
 			TerminateBatchMax:  100,
 			TerminateBatchWait: Duration(5 * time.Minute),
+
+			CommittedCapacitySectorLifetime: 0,          // maximum lifetime
+			DealSectorExpiration:            "deal-end", // end sectors with their deals
+			ExpirationLadderSteps:           0,
+			ExpirationLadderStep:            Duration(7 * 24 * time.Hour),
+
+			MaxProofRegenerations: 2,
+
+			StateWebhookURLs:   []string{},
+			StateWebhookStates: []string{},
 		},
 
 		Storage: sectorstorage.SealerConfig{
@@ -345,12 +862,65 @@ func DefaultStorageMiner() *StorageMiner {
 			MaxWindowPoStGasFee:    types.MustParseFIL("5"),
 			MaxPublishDealsFee:     types.MustParseFIL("0.05"),
 			MaxMarketBalanceAddFee: types.MustParseFIL("0.007"),
+
+			MaxPublishDealsFeePerDeal: types.MustParseFIL("0"),
+
+			BreakerTripBaseFee:  types.MustParseFIL("0"),
+			BreakerResetBaseFee: types.MustParseFIL("0"),
 		},
 
 		Addresses: MinerAddressConfig{
 			PreCommitControl: []string{},
 			CommitControl:    []string{},
 		},
+
+		Alerting: AlertingConfig{
+			CheckInterval:       Duration(5 * time.Minute),
+			MinAddressBalance:   types.MustParseFIL("5"),
+			FailedSectorTimeout: Duration(6 * time.Hour),
+			WebhookURLs:         []string{},
+			PagerDuty: PagerDutyAlertsConfig{
+				URL: "https://events.pagerduty.com/v2/enqueue",
+			},
+		},
+
+		Tiering: TieringConfig{
+			HotGroup:      "hot",
+			ColdGroup:     "cold",
+			DemoteAfter:   Duration(30 * 24 * time.Hour),
+			CheckInterval: Duration(time.Hour),
+		},
+
+		FaultPrediction: FaultPredictionConfig{
+			CheckInterval:          Duration(10 * time.Minute),
+			MaxReallocatedSectors:  100,
+			MaxPendingSectors:      0,
+			MaxUncorrectableErrors: 0,
+			MaxLatency:             Duration(5 * time.Second),
+			LatencySamples:         3,
+			Action:                 "alert",
+		},
+
+		Payout: PayoutConfig{
+			Interval:   Duration(7 * 24 * time.Hour),
+			Float:      types.MustParseFIL("10"),
+			MinAmount:  types.MustParseFIL("1"),
+			MaxFee:     types.MustParseFIL("0.05"),
+			Recipients: []PayoutRecipient{},
+		},
+
+		WinningPoSt: WinningPoStConfig{
+			AlertMargin: Duration(10 * time.Second),
+		},
+
+		MultiMiner: MultiMinerConfig{
+			PoStParallel: 1,
+		},
+
+		ProofParams: ProofParamsConfig{
+			Segments:       4,
+			VerifyInterval: Duration(24 * time.Hour),
+		},
 	}
 	cfg.Common.API.ListenAddress = "/ip4/127.0.0.1/tcp/2345/http"
 	cfg.Common.API.RemoteListenAddress = "127.0.0.1:2345"
@@ -0,0 +1,282 @@
+package config
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/kelseyhightower/envconfig"
+	"golang.org/x/xerrors"
+
+	miner5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/miner"
+)
+
+// Severity tells whether the node can start with a config issue
+type Severity string
+
+const (
+	// SeverityError issues prevent the node from starting, or from working
+	SeverityError Severity = "error"
+	// SeverityWarning issues are likely mistakes
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a problem found in a config file
+type Issue struct {
+	// Key of the setting, e.g. Sealing.MinCommitBatch, empty for problems
+	// with the whole file
+	Key      string
+	Severity Severity
+	Message  string
+}
+
+// deprecatedMinerKeys are settings of older releases which are now ignored
+var deprecatedMinerKeys = map[string]string{
+	"Dealmaking.ConsiderOnlineDeals":  "renamed to Dealmaking.ConsiderOnlineStorageDeals",
+	"Dealmaking.ConsiderOfflineDeals": "renamed to Dealmaking.ConsiderOfflineStorageDeals",
+}
+
+// LoadedMiner is a miner config file loaded by LoadStorageMiner
+type LoadedMiner struct {
+	// File holds the defaults overridden by the settings in the file, nil if
+	// the file couldn't be decoded
+	File *StorageMiner
+	// Effective also applies the LOTUS_* environment variables, this is the
+	// config the node starts with
+	Effective *StorageMiner
+
+	Issues []Issue
+
+	inFile map[string]bool
+}
+
+// LoadStorageMiner loads the miner config file at path like the node does,
+// and checks it for settings which are unknown, deprecated, or contradict
+// each other. A missing file holds the default config.
+func LoadStorageMiner(path string) (*LoadedMiner, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, xerrors.Errorf("reading config file: %w", err)
+	}
+
+	out := &LoadedMiner{inFile: map[string]bool{}}
+
+	file := DefaultStorageMiner()
+	md, err := toml.DecodeReader(bytes.NewReader(b), file)
+	if err != nil {
+		out.Issues = append(out.Issues, Issue{Severity: SeverityError, Message: err.Error()})
+		return out, nil
+	}
+	out.File = file
+
+	for _, k := range md.Keys() {
+		out.inFile[k.String()] = true
+	}
+
+	for _, k := range md.Undecoded() {
+		msg := "unknown setting, ignored"
+		if d, ok := deprecatedMinerKeys[k.String()]; ok {
+			msg = "deprecated setting, ignored: " + d
+		}
+		out.Issues = append(out.Issues, Issue{Key: k.String(), Severity: SeverityWarning, Message: msg})
+	}
+
+	eff := DefaultStorageMiner()
+	if _, err := toml.DecodeReader(bytes.NewReader(b), eff); err != nil {
+		return nil, err
+	}
+	if err := envconfig.Process("LOTUS", eff); err != nil {
+		out.Issues = append(out.Issues, Issue{Severity: SeverityError, Message: fmt.Sprintf("processing env vars overrides: %s", err)})
+		return out, nil
+	}
+	out.Effective = eff
+
+	out.Issues = append(out.Issues, CheckStorageMiner(eff)...)
+
+	return out, nil
+}
+
+// HasErrors returns whether the node would fail to start, or to work, with
+// the config
+func (l *LoadedMiner) HasErrors() bool {
+	for _, i := range l.Issues {
+		if i.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckStorageMiner reports the settings of the miner config contradicting
+// each other or the network limits
+func CheckStorageMiner(cfg *StorageMiner) []Issue {
+	var out []Issue
+	report := func(sev Severity, key, format string, args ...interface{}) {
+		out = append(out, Issue{Key: key, Severity: sev, Message: fmt.Sprintf(format, args...)})
+	}
+
+	sub := cfg.Subsystems
+	if !sub.EnableMarkets && !sub.EnableSealing {
+		report(SeverityError, "Subsystems", "at least one of EnableMarkets and EnableSealing must be set")
+	}
+	if !sub.EnableSealing && sub.SealerApiInfo == "" {
+		report(SeverityError, "Subsystems.SealerApiInfo", "required when sealing is disabled")
+	}
+
+	s := cfg.Sealing
+	if s.BatchPreCommits {
+		if s.MinPreCommitBatch > s.MaxPreCommitBatch {
+			report(SeverityError, "Sealing.MinPreCommitBatch", "greater than MaxPreCommitBatch (%d > %d)", s.MinPreCommitBatch, s.MaxPreCommitBatch)
+		}
+		if s.MaxPreCommitBatch > miner5.PreCommitSectorBatchMaxSize {
+			report(SeverityError, "Sealing.MaxPreCommitBatch", "over the network limit of %d sectors", miner5.PreCommitSectorBatchMaxSize)
+		}
+		// precommit tickets expire after 31.5 hours
+		if time.Duration(s.PreCommitBatchWait) > 31*time.Hour {
+			report(SeverityWarning, "Sealing.PreCommitBatchWait", "sector tickets may expire before the batch is sent")
+		}
+	}
+
+	if s.AggregateCommits {
+		if s.MinCommitBatch > s.MaxCommitBatch {
+			report(SeverityError, "Sealing.MinCommitBatch", "greater than MaxCommitBatch (%d > %d)", s.MinCommitBatch, s.MaxCommitBatch)
+		}
+		if s.MinCommitBatch < miner5.MinAggregatedSectors {
+			report(SeverityWarning, "Sealing.MinCommitBatch", "batches under the network minimum of %d aggregated proofs are sent as individual proofs", miner5.MinAggregatedSectors)
+		}
+		if s.MaxCommitBatch > miner5.MaxAggregatedSectors {
+			report(SeverityError, "Sealing.MaxCommitBatch", "over the network limit of %d aggregated proofs", miner5.MaxAggregatedSectors)
+		}
+	}
+
+	if s.TerminateBatchMin > s.TerminateBatchMax {
+		report(SeverityError, "Sealing.TerminateBatchMin", "greater than TerminateBatchMax (%d > %d)", s.TerminateBatchMin, s.TerminateBatchMax)
+	}
+
+	switch s.DealSectorExpiration {
+	case "", "deal-end", "max":
+	default:
+		report(SeverityError, "Sealing.DealSectorExpiration", "unknown policy %q, expected \"deal-end\" or \"max\"", s.DealSectorExpiration)
+	}
+
+	if s.ExpirationLadderSteps < 0 {
+		report(SeverityError, "Sealing.ExpirationLadderSteps", "must not be negative")
+	}
+
+	for _, u := range s.StateWebhookURLs {
+		if pu, err := url.Parse(u); err != nil || (pu.Scheme != "http" && pu.Scheme != "https") {
+			report(SeverityError, "Sealing.StateWebhookURLs", "%q isn't an http(s) URL", u)
+		}
+	}
+
+	for _, k := range cfg.SealingService.ReceiptKeys {
+		if kb, err := hex.DecodeString(k); err != nil || len(kb) != ed25519.PublicKeySize {
+			report(SeverityError, "SealingService.ReceiptKeys", "%q isn't a hex-encoded ed25519 public key", k)
+		}
+	}
+
+	if cfg.Tiering.Enable && cfg.Tiering.HotGroup == cfg.Tiering.ColdGroup {
+		report(SeverityError, "Tiering.ColdGroup", "same storage group as HotGroup")
+	}
+
+	return out
+}
+
+// DiffEntry is a setting of the miner config set in the file, or whose
+// effective value isn't the default
+type DiffEntry struct {
+	Key       string
+	Default   string
+	File      string
+	Effective string
+	// InFile is set if the file sets the key, even to its default value
+	InFile bool
+}
+
+// Diff lists the settings of the config which the file or the environment
+// set, in the order of the config structure
+func (l *LoadedMiner) Diff() []DiffEntry {
+	if l.File == nil || l.Effective == nil {
+		return nil
+	}
+
+	var keys []string
+	def, file, eff := map[string]string{}, map[string]string{}, map[string]string{}
+	flattenConfig("", reflect.ValueOf(DefaultStorageMiner()).Elem(), def, &keys)
+	flattenConfig("", reflect.ValueOf(l.File).Elem(), file, nil)
+	flattenConfig("", reflect.ValueOf(l.Effective).Elem(), eff, nil)
+
+	var out []DiffEntry
+	for _, k := range keys {
+		e := DiffEntry{
+			Key:       k,
+			Default:   def[k],
+			File:      file[k],
+			Effective: eff[k],
+			InFile:    l.inFile[k],
+		}
+		if !e.InFile && e.Effective == e.Default {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// flattenConfig formats the settings of a config struct by their dotted toml
+// keys. Embedded structs, like Common, share the keys of their parent.
+func flattenConfig(prefix string, v reflect.Value, out map[string]string, keys *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		fv := v.Field(i)
+
+		if f.Anonymous && fv.Kind() == reflect.Struct {
+			flattenConfig(prefix, fv, out, keys)
+			continue
+		}
+
+		key := f.Name
+		if prefix != "" {
+			key = prefix + "." + f.Name
+		}
+
+		if fv.Kind() == reflect.Struct && !f.Type.Implements(textMarshalerType) {
+			flattenConfig(key, fv, out, keys)
+			continue
+		}
+
+		out[key] = formatSetting(fv)
+		if keys != nil {
+			*keys = append(*keys, key)
+		}
+	}
+}
+
+func formatSetting(v reflect.Value) string {
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return fmt.Sprintf("<%s>", err)
+		}
+		return string(b)
+	}
+
+	if v.Kind() == reflect.String {
+		return fmt.Sprintf("%q", v.String())
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
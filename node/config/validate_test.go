@@ -0,0 +1,96 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeMinerConfig(t *testing.T, cfg string) string {
+	dir, err := ioutil.TempDir("", "config-validate")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "config.toml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(cfg), 0644))
+	return path
+}
+
+func TestLoadStorageMinerDefaults(t *testing.T) {
+	l, err := LoadStorageMiner("./does-not-exist.toml")
+	require.NoError(t, err)
+	require.Empty(t, l.Issues)
+	require.Empty(t, l.Diff())
+}
+
+func TestLoadStorageMinerIssues(t *testing.T) {
+	path := writeMinerConfig(t, `
+		[Dealmaking]
+		ConsiderOnlineDeals = false
+
+		[Sealing]
+		MinCommitBatch = 100
+		MaxCommitBatch = 10
+		DealSectorExpirations = "max"
+		StateWebhookURLs = ["localhost:8080/hook"]
+
+		[SealingService]
+		ReceiptKeys = ["not-a-key"]
+		`)
+
+	l, err := LoadStorageMiner(path)
+	require.NoError(t, err)
+	require.True(t, l.HasErrors())
+
+	issues := map[string]Severity{}
+	for _, i := range l.Issues {
+		issues[i.Key] = i.Severity
+	}
+	require.Equal(t, map[string]Severity{
+		"Dealmaking.ConsiderOnlineDeals": SeverityWarning,
+		"Sealing.DealSectorExpirations":  SeverityWarning,
+		"Sealing.MinCommitBatch":         SeverityError,
+		"Sealing.StateWebhookURLs":       SeverityError,
+		"SealingService.ReceiptKeys":     SeverityError,
+	}, issues)
+}
+
+func TestLoadStorageMinerTypeError(t *testing.T) {
+	path := writeMinerConfig(t, `
+		[Sealing]
+		MaxCommitBatch = "many"
+		`)
+
+	l, err := LoadStorageMiner(path)
+	require.NoError(t, err)
+	require.True(t, l.HasErrors())
+	require.Nil(t, l.File)
+	require.Nil(t, l.Diff())
+}
+
+func TestStorageMinerDiff(t *testing.T) {
+	path := writeMinerConfig(t, `
+		[API]
+		Timeout = "30s"
+
+		[Sealing]
+		MaxCommitBatch = 100
+		AggregateCommits = true
+		`)
+
+	require.NoError(t, os.Setenv("LOTUS_SEALING_MAXCOMMITBATCH", "200"))
+	defer os.Unsetenv("LOTUS_SEALING_MAXCOMMITBATCH") //nolint:errcheck
+
+	l, err := LoadStorageMiner(path)
+	require.NoError(t, err)
+	require.Empty(t, l.Issues)
+
+	require.Equal(t, []DiffEntry{
+		{Key: "API.Timeout", Default: "30s", File: "30s", Effective: "30s", InFile: true},
+		{Key: "Sealing.AggregateCommits", Default: "true", File: "true", Effective: "true", InFile: true},
+		{Key: "Sealing.MaxCommitBatch", Default: "819", File: "100", Effective: "200", InFile: true},
+	}, l.Diff())
+}
@@ -8,60 +8,93 @@ import (
 	"github.com/mitchellh/go-homedir"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/lib/backupds"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 )
 
 func backup(mds dtypes.MetadataDS, fpath string) error {
-	bb, ok := os.LookupEnv("LOTUS_BACKUP_BASE_PATH")
+	bds, ok := mds.(*backupds.Datastore)
 	if !ok {
-		return xerrors.Errorf("LOTUS_BACKUP_BASE_PATH env var not set")
+		return xerrors.Errorf("expected a backup datastore")
+	}
+
+	fpath, err := backupPath(fpath)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(fpath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return xerrors.Errorf("open %s: %w", fpath, err)
 	}
 
+	if err := bds.Backup(out); err != nil {
+		if cerr := out.Close(); cerr != nil {
+			log.Errorw("error closing backup file while handling backup error", "closeErr", cerr, "backupErr", err)
+		}
+		return xerrors.Errorf("backup error: %w", err)
+	}
+
+	if err := out.Close(); err != nil {
+		return xerrors.Errorf("closing backup file: %w", err)
+	}
+
+	return nil
+}
+
+// backupIncremental adds a snapshot of the metadata datastore and of the
+// keystore to the backup directory dir
+func backupIncremental(mds dtypes.MetadataDS, ks types.KeyStore, dir string) (*backupds.Snapshot, error) {
 	bds, ok := mds.(*backupds.Datastore)
 	if !ok {
-		return xerrors.Errorf("expected a backup datastore")
+		return nil, xerrors.Errorf("expected a backup datastore")
 	}
 
-	bb, err := homedir.Expand(bb)
+	dir, err := backupPath(dir)
 	if err != nil {
-		return xerrors.Errorf("expanding base path: %w", err)
+		return nil, err
 	}
 
-	bb, err = filepath.Abs(bb)
+	keys, err := backupds.KeystoreEntries(ks)
 	if err != nil {
-		return xerrors.Errorf("getting absolute base path: %w", err)
+		return nil, xerrors.Errorf("reading keystore: %w", err)
 	}
 
-	fpath, err = homedir.Expand(fpath)
-	if err != nil {
-		return xerrors.Errorf("expanding file path: %w", err)
+	return bds.BackupIncremental(dir, keys)
+}
+
+// backupPath resolves the path of a backup, which must be within
+// LOTUS_BACKUP_BASE_PATH
+func backupPath(fpath string) (string, error) {
+	bb, ok := os.LookupEnv("LOTUS_BACKUP_BASE_PATH")
+	if !ok {
+		return "", xerrors.Errorf("LOTUS_BACKUP_BASE_PATH env var not set")
 	}
 
-	fpath, err = filepath.Abs(fpath)
+	bb, err := homedir.Expand(bb)
 	if err != nil {
-		return xerrors.Errorf("getting absolute file path: %w", err)
+		return "", xerrors.Errorf("expanding base path: %w", err)
 	}
 
-	if !strings.HasPrefix(fpath, bb) {
-		return xerrors.Errorf("backup file name (%s) must be inside base path (%s)", fpath, bb)
+	bb, err = filepath.Abs(bb)
+	if err != nil {
+		return "", xerrors.Errorf("getting absolute base path: %w", err)
 	}
 
-	out, err := os.OpenFile(fpath, os.O_CREATE|os.O_WRONLY, 0644)
+	fpath, err = homedir.Expand(fpath)
 	if err != nil {
-		return xerrors.Errorf("open %s: %w", fpath, err)
+		return "", xerrors.Errorf("expanding file path: %w", err)
 	}
 
-	if err := bds.Backup(out); err != nil {
-		if cerr := out.Close(); cerr != nil {
-			log.Errorw("error closing backup file while handling backup error", "closeErr", cerr, "backupErr", err)
-		}
-		return xerrors.Errorf("backup error: %w", err)
+	fpath, err = filepath.Abs(fpath)
+	if err != nil {
+		return "", xerrors.Errorf("getting absolute file path: %w", err)
 	}
 
-	if err := out.Close(); err != nil {
-		return xerrors.Errorf("closing backup file: %w", err)
+	if !strings.HasPrefix(fpath, bb) {
+		return "", xerrors.Errorf("backup file name (%s) must be inside base path (%s)", fpath, bb)
 	}
 
-	return nil
+	return fpath, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/google/uuid"
@@ -26,6 +27,7 @@ import (
 	"github.com/filecoin-project/lotus/api"
 	apitypes "github.com/filecoin-project/lotus/api/types"
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/journal"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/lp2p"
 )
@@ -40,9 +42,11 @@ type CommonAPI struct {
 	Host         host.Host
 	Router       lp2p.BaseIpfsRouting
 	ConnGater    *conngater.BasicConnectionGater
+	Classifier   *lp2p.PeerClassifier `optional:"true"`
 	Reporter     metrics.Reporter
 	Sk           *dtypes.ScoreKeeper
 	ShutdownChan dtypes.ShutdownChan
+	Journal      journal.Journal
 }
 
 type jwtPayload struct {
@@ -60,7 +64,19 @@ func (a *CommonAPI) AuthVerify(ctx context.Context, token string) ([]auth.Permis
 
 func (a *CommonAPI) AuthNew(ctx context.Context, perms []auth.Permission) ([]byte, error) {
 	p := jwtPayload{
-		Allow: perms, // TODO: consider checking validity
+		Allow: make([]auth.Permission, 0, len(perms)),
+	}
+
+	for _, perm := range perms {
+		if err := api.ValidPermission(perm); err != nil {
+			return nil, xerrors.Errorf("invalid permission: %w", err)
+		}
+
+		// scopes are matched as strings, store them in canonical form
+		if sc, err := api.ParseScope(string(perm)); err == nil {
+			perm = auth.Permission(sc.String())
+		}
+		p.Allow = append(p.Allow, perm)
 	}
 
 	return jwt.Sign(&p, (*jwt.HMACSHA)(a.APISecret))
@@ -192,6 +208,13 @@ func (a *CommonAPI) NetAgentVersion(ctx context.Context, p peer.ID) (string, err
 	return ag.(string), nil
 }
 
+func (a *CommonAPI) NetClassify(ctx context.Context) ([]api.NetPeerClass, error) {
+	if a.Classifier == nil {
+		return nil, xerrors.Errorf("peer classifier not running")
+	}
+	return a.Classifier.Peers(), nil
+}
+
 func (a *CommonAPI) NetBandwidthStats(ctx context.Context) (metrics.Stats, error) {
 	return a.Reporter.GetBandwidthTotals(), nil
 }
@@ -230,6 +253,20 @@ func (a *CommonAPI) Version(context.Context) (api.APIVersion, error) {
 	}, nil
 }
 
+func (a *CommonAPI) JournalQuery(ctx context.Context, types []journal.EventType, from, to time.Time, limit int) ([]*journal.Event, error) {
+	jr, ok := a.Journal.(journal.Reader)
+	if !ok {
+		return nil, xerrors.Errorf("journal backend doesn't support queries")
+	}
+
+	return jr.Query(ctx, journal.Query{
+		Types: types,
+		From:  from,
+		To:    to,
+		Limit: limit,
+	})
+}
+
 func (a *CommonAPI) LogList(context.Context) ([]string, error) {
 	return logging.GetSubsystems(), nil
 }
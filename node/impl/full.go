@@ -10,6 +10,7 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/sub/propagation"
 	"github.com/filecoin-project/lotus/node/impl/client"
 	"github.com/filecoin-project/lotus/node/impl/common"
 	"github.com/filecoin-project/lotus/node/impl/full"
@@ -32,11 +33,13 @@ type FullNodeAPI struct {
 	full.StateAPI
 	full.MsigAPI
 	full.WalletAPI
+	full.AddressBookAPI
 	full.SyncAPI
 	full.BeaconAPI
 
 	DS          dtypes.MetadataDS
 	NetworkName dtypes.NetworkName
+	Propagation *propagation.Tracker
 }
 
 func (n *FullNodeAPI) CreateBackup(ctx context.Context, fpath string) error {
@@ -117,4 +120,13 @@ func (n *FullNodeAPI) NodeStatus(ctx context.Context, inclChainStatus bool) (sta
 	return status, nil
 }
 
+func (n *FullNodeAPI) NetPropagation(ctx context.Context) (*api.PropagationReport, error) {
+	return n.Propagation.Report(), nil
+}
+
+func (n *FullNodeAPI) NetPropagationReset(ctx context.Context) error {
+	n.Propagation.Reset()
+	return nil
+}
+
 var _ api.FullNode = &FullNodeAPI{}
@@ -0,0 +1,125 @@
+package full
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+var addressBookKey = datastore.NewKey("/addressbook")
+
+type AddressBookAPI struct {
+	fx.In
+
+	MetadataDS dtypes.MetadataDS
+}
+
+func (a *AddressBookAPI) AddressBookSet(ctx context.Context, entry api.AddressBookEntry) error {
+	if err := checkAddressBookName(entry.Name); err != nil {
+		return err
+	}
+	if entry.Address == address.Undef {
+		return xerrors.Errorf("address book entry %q has no address", entry.Name)
+	}
+	if entry.Added.IsZero() {
+		entry.Added = time.Now()
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return xerrors.Errorf("marshaling address book entry: %w", err)
+	}
+	if err := a.MetadataDS.Put(addressBookKey.ChildString(entry.Name), b); err != nil {
+		return xerrors.Errorf("storing address book entry: %w", err)
+	}
+	return nil
+}
+
+func (a *AddressBookAPI) AddressBookRemove(ctx context.Context, name string) error {
+	k := addressBookKey.ChildString(name)
+	has, err := a.MetadataDS.Has(k)
+	if err != nil {
+		return xerrors.Errorf("checking address book entry: %w", err)
+	}
+	if !has {
+		return xerrors.Errorf("no address book entry named %q", name)
+	}
+
+	if err := a.MetadataDS.Delete(k); err != nil {
+		return xerrors.Errorf("removing address book entry: %w", err)
+	}
+	return nil
+}
+
+func (a *AddressBookAPI) AddressBookGet(ctx context.Context, name string) (*api.AddressBookEntry, error) {
+	if err := checkAddressBookName(name); err != nil {
+		return nil, err
+	}
+
+	b, err := a.MetadataDS.Get(addressBookKey.ChildString(name))
+	if err == datastore.ErrNotFound {
+		return nil, xerrors.Errorf("no address book entry named %q", name)
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("getting address book entry: %w", err)
+	}
+
+	var entry api.AddressBookEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return nil, xerrors.Errorf("unmarshaling address book entry: %w", err)
+	}
+	return &entry, nil
+}
+
+func (a *AddressBookAPI) AddressBookList(ctx context.Context) ([]api.AddressBookEntry, error) {
+	res, err := a.MetadataDS.Query(query.Query{Prefix: addressBookKey.String()})
+	if err != nil {
+		return nil, xerrors.Errorf("querying address book: %w", err)
+	}
+
+	entries, err := res.Rest()
+	if err != nil {
+		return nil, xerrors.Errorf("reading address book: %w", err)
+	}
+
+	out := make([]api.AddressBookEntry, 0, len(entries))
+	for _, e := range entries {
+		var entry api.AddressBookEntry
+		if err := json.Unmarshal(e.Value, &entry); err != nil {
+			return nil, xerrors.Errorf("unmarshaling address book entry %s: %w", e.Key, err)
+		}
+		out = append(out, entry)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+// checkAddressBookName makes sure that names can't be confused with
+// addresses, and map to a single datastore key
+func checkAddressBookName(name string) error {
+	if name == "" {
+		return xerrors.Errorf("address book names can't be empty")
+	}
+	if strings.Contains(name, "/") {
+		return xerrors.Errorf("address book names can't contain '/'")
+	}
+	if _, err := address.NewFromString(name); err == nil {
+		return xerrors.Errorf("address book name %q is an address", name)
+	}
+	return nil
+}
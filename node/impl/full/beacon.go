@@ -6,14 +6,17 @@ import (
 
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/lotus/chain/beacon"
+	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
 	"go.uber.org/fx"
+	"golang.org/x/xerrors"
 )
 
 type BeaconAPI struct {
 	fx.In
 
 	Beacon beacon.Schedule
+	Chain  *store.ChainStore
 }
 
 func (a *BeaconAPI) BeaconGetEntry(ctx context.Context, epoch abi.ChainEpoch) (*types.BeaconEntry, error) {
@@ -34,3 +37,15 @@ func (a *BeaconAPI) BeaconGetEntry(ctx context.Context, epoch abi.ChainEpoch) (*
 		return nil, ctx.Err()
 	}
 }
+
+func (a *BeaconAPI) BeaconHealth(ctx context.Context) (*beacon.Health, error) {
+	b := a.Beacon.BeaconForEpoch(a.Chain.GetHeaviestTipSet().Height())
+
+	hr, ok := b.(beacon.HealthReporter)
+	if !ok {
+		return nil, xerrors.Errorf("beacon %T doesn't report its health", b)
+	}
+
+	h := hr.Health()
+	return &h, nil
+}
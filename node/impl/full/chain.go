@@ -34,9 +34,13 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/blockstore/splitstore"
+	"github.com/filecoin-project/lotus/chain/gasstats"
+	"github.com/filecoin-project/lotus/chain/snapshot"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/vm"
+	"github.com/filecoin-project/lotus/lib/subscription"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 )
 
@@ -83,12 +87,26 @@ type ChainAPI struct {
 	// expose externally. In the future, this will be segregated into two
 	// blockstores.
 	ExposedBlockstore dtypes.ExposedBlockstore
+
+	SnapshotImport      *store.SnapshotImport      `optional:"true"`
+	Snapshots           *snapshot.Service          `optional:"true"`
+	GasStats            *gasstats.Tracker          `optional:"true"`
+	BaseBlockstore      dtypes.BaseBlockstore      `optional:"true"`
+	UniversalBlockstore dtypes.UniversalBlockstore `optional:"true"`
 }
 
 func (m *ChainModule) ChainNotify(ctx context.Context) (<-chan []*api.HeadChange, error) {
 	return m.Chain.SubHeadChanges(ctx), nil
 }
 
+func (a *ChainAPI) ChainNotifyWithPolicy(ctx context.Context, policy subscription.Policy) (<-chan []*api.HeadChange, error) {
+	policy, err := policy.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return a.Chain.SubHeadChangesWithPolicy(ctx, policy), nil
+}
+
 func (m *ChainModule) ChainHead(context.Context) (*types.TipSet, error) {
 	return m.Chain.GetHeaviestTipSet(), nil
 }
@@ -228,6 +246,13 @@ func (a *ChainAPI) ChainGetParentReceipts(ctx context.Context, bcid cid.Cid) ([]
 	return out, nil
 }
 
+func (a *ChainAPI) ChainGasUsage(ctx context.Context, epochs abi.ChainEpoch) (*api.GasUsage, error) {
+	if a.GasStats == nil {
+		return nil, xerrors.Errorf("gas usage tracking not enabled, see Fees.GasUsageEpochs in the config")
+	}
+	return a.GasStats.Usage(epochs)
+}
+
 func (m *ChainModule) ChainGetTipSetByHeight(ctx context.Context, h abi.ChainEpoch, tsk types.TipSetKey) (*types.TipSet, error) {
 	ts, err := m.Chain.GetTipSetFromKey(tsk)
 	if err != nil {
@@ -617,3 +642,119 @@ func (a *ChainAPI) ChainExport(ctx context.Context, nroots abi.ChainEpoch, skipo
 
 	return out, nil
 }
+
+func (a *ChainAPI) ChainSnapshotImportProgress(ctx context.Context) (*api.SnapshotImportProgress, error) {
+	if a.SnapshotImport == nil {
+		return nil, xerrors.Errorf("no snapshot is being imported in the background")
+	}
+
+	p := a.SnapshotImport.Progress()
+	return &p, nil
+}
+
+func (a *ChainAPI) ChainLatestSnapshot(ctx context.Context) (*api.SnapshotInfo, error) {
+	if a.Snapshots == nil {
+		return nil, xerrors.Errorf("snapshots not enabled, see Chainstore.EnableSnapshots in the config")
+	}
+
+	info := a.Snapshots.Latest()
+	if info == nil {
+		return nil, xerrors.Errorf("no snapshot taken yet")
+	}
+	return info, nil
+}
+
+func (a *ChainAPI) splitstore() (*splitstore.SplitStore, error) {
+	ss, ok := a.BaseBlockstore.(*splitstore.SplitStore)
+	if !ok {
+		return nil, xerrors.Errorf("splitstore not enabled, see Chainstore.EnableSplitstore in the config")
+	}
+	return ss, nil
+}
+
+func (a *ChainAPI) ChainSplitstoreStatus(ctx context.Context) (*api.SplitstoreStatus, error) {
+	ss, err := a.splitstore()
+	if err != nil {
+		return nil, err
+	}
+
+	st := ss.CompactionStatus()
+	return &api.SplitstoreStatus{
+		Compacting:     st.Compacting,
+		Paused:         st.Paused,
+		Phase:          st.Phase,
+		Started:        st.Started,
+		BaseEpoch:      st.BaseEpoch,
+		Marked:         st.Marked,
+		Hot:            st.Hot,
+		Cold:           st.Cold,
+		Dead:           st.Dead,
+		Moved:          st.Moved,
+		Purged:         st.Purged,
+		LastCompaction: st.LastCompaction,
+		LastDuration:   st.LastDuration,
+		LastError:      st.LastError,
+		LastReclaimed:  st.LastReclaimed,
+	}, nil
+}
+
+func (a *ChainAPI) ChainSplitstorePauseCompaction(ctx context.Context) error {
+	ss, err := a.splitstore()
+	if err != nil {
+		return err
+	}
+
+	ss.PauseCompaction()
+	return nil
+}
+
+func (a *ChainAPI) ChainSplitstoreResumeCompaction(ctx context.Context) error {
+	ss, err := a.splitstore()
+	if err != nil {
+		return err
+	}
+
+	ss.ResumeCompaction()
+	return nil
+}
+
+func (a *ChainAPI) ChainBlockstoreGC(ctx context.Context, opts api.BlockstoreGCOpts) (<-chan api.BlockstoreGCProgress, error) {
+	gc, ok := a.UniversalBlockstore.(blockstore.BlockstoreGC)
+	if !ok {
+		return nil, xerrors.Errorf("chain blockstore doesn't support online garbage collection")
+	}
+
+	out := make(chan api.BlockstoreGCProgress, 16)
+	go func() {
+		defer close(out)
+
+		var last api.BlockstoreGCProgress
+		err := gc.CollectGarbageOnline(ctx, blockstore.GCOptions{
+			Threshold: opts.Threshold,
+			Compact:   opts.Compact,
+		}, func(p blockstore.GCProgress) {
+			last = api.BlockstoreGCProgress{
+				Rounds:     p.Rounds,
+				SizeBefore: p.SizeBefore,
+				Size:       p.Size,
+			}
+			select {
+			case out <- last:
+			case <-ctx.Done():
+			}
+		})
+
+		last.Done = true
+		if err != nil {
+			last.Error = err.Error()
+		}
+
+		select {
+		case out <- last:
+		case <-ctx.Done():
+			log.Warnf("blockstore gc progress writer failed: %s", ctx.Err())
+		}
+	}()
+
+	return out, nil
+}
@@ -185,6 +185,23 @@ func (m *GasModule) GasEstimateGasPremium(
 	return gasEstimateGasPremium(m.Chain, m.PriceCache, nblocksincl)
 }
 func gasEstimateGasPremium(cstore *store.ChainStore, cache *GasPriceCache, nblocksincl uint64) (types.BigInt, error) {
+	premium, err := chainGasPremium(cstore, cache, cstore.GetHeaviestTipSet(), nblocksincl)
+	if err != nil {
+		return types.BigInt{}, err
+	}
+
+	// add some noise to normalize behaviour of message selection
+	const precision = 32
+	// mean 1, stddev 0.005 => 95% within +-1%
+	noise := 1 + rand.NormFloat64()*0.005
+	premium = types.BigMul(premium, types.NewInt(uint64(noise*(1<<precision))+1))
+	premium = types.BigDiv(premium, types.NewInt(1<<precision))
+	return premium, nil
+}
+
+// chainGasPremium returns the premium paid by messages included in the
+// 2*nblocksincl tipsets before ts, with a floor of MinGasPremium
+func chainGasPremium(cstore *store.ChainStore, cache *GasPriceCache, ts *types.TipSet, nblocksincl uint64) (types.BigInt, error) {
 	if nblocksincl == 0 {
 		nblocksincl = 1
 	}
@@ -192,7 +209,6 @@ func gasEstimateGasPremium(cstore *store.ChainStore, cache *GasPriceCache, nbloc
 	var prices []GasMeta
 	var blocks int
 
-	ts := cstore.GetHeaviestTipSet()
 	for i := uint64(0); i < nblocksincl*2; i++ {
 		if ts.Height() == 0 {
 			break // genesis
@@ -226,15 +242,119 @@ func gasEstimateGasPremium(cstore *store.ChainStore, cache *GasPriceCache, nbloc
 		}
 	}
 
-	// add some noise to normalize behaviour of message selection
-	const precision = 32
-	// mean 1, stddev 0.005 => 95% within +-1%
-	noise := 1 + rand.NormFloat64()*0.005
-	premium = types.BigMul(premium, types.NewInt(uint64(noise*(1<<precision))+1))
-	premium = types.BigDiv(premium, types.NewInt(1<<precision))
 	return premium, nil
 }
 
+// mpoolGasPremium returns the premium a message needs to outbid enough
+// pending messages to fit in the blocks of the next `epochs` epochs, or zero
+// if all pending messages fit. Messages with fee caps below the base fee are
+// not includable, and only count with their effective premium otherwise.
+func mpoolGasPremium(pending []*types.SignedMessage, baseFee abi.TokenAmount, epochs int64) abi.TokenAmount {
+	prices := make([]GasMeta, 0, len(pending))
+	for _, m := range pending {
+		if m.Message.GasFeeCap.LessThan(baseFee) {
+			continue
+		}
+
+		price := big.Sub(m.Message.GasFeeCap, baseFee)
+		if m.Message.GasPremium.LessThan(price) {
+			price = m.Message.GasPremium
+		}
+
+		prices = append(prices, GasMeta{
+			Price: price,
+			Limit: m.Message.GasLimit,
+		})
+	}
+
+	sort.Slice(prices, func(i, j int) bool {
+		// sort desc by price
+		return prices[i].Price.GreaterThan(prices[j].Price)
+	})
+
+	space := build.BlockGasTarget * int64(build.BlocksPerEpoch) * epochs
+	for _, price := range prices {
+		space -= price.Limit
+		if space < 0 {
+			return big.Add(price.Price, big.NewInt(1))
+		}
+	}
+
+	return big.Zero()
+}
+
+type gasAdvisorTarget struct {
+	name   string
+	epochs int64
+
+	// feeCapEpochs is the number of epochs of base fee increase the fee cap
+	// allows for
+	feeCapEpochs int64
+	// premiumBlocks is passed as nblocksincl to the chain premium estimate
+	premiumBlocks uint64
+	// mpool sets whether the advice must outbid pending messages
+	mpool bool
+}
+
+// gasAdvisorTargets are ordered by decreasing urgency
+var gasAdvisorTargets = []gasAdvisorTarget{
+	{name: api.GasAdviceNextEpoch, epochs: 1, feeCapEpochs: 1, premiumBlocks: 1, mpool: true},
+	{name: api.GasAdviceFiveEpochs, epochs: 5, feeCapEpochs: 5, premiumBlocks: 5, mpool: true},
+	{name: api.GasAdviceBestEffort, epochs: 0, feeCapEpochs: 20, premiumBlocks: 10}, // same as GasEstimateMessageGas
+}
+
+func (a *GasAPI) GasAdvisorFees(ctx context.Context, tsk types.TipSetKey) (*api.GasAdvice, error) {
+	ts, err := a.Chain.GetTipSetFromKey(tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("getting tipset: %w", err)
+	}
+
+	pending, _ := a.Mpool.Pending(ctx)
+	return gasAdvisorFees(a.Chain, a.PriceCache, ts, pending)
+}
+func gasAdvisorFees(cstore *store.ChainStore, cache *GasPriceCache, ts *types.TipSet, pending []*types.SignedMessage) (*api.GasAdvice, error) {
+	baseFee := ts.Blocks()[0].ParentBaseFee
+
+	out := &api.GasAdvice{
+		Height:  ts.Height(),
+		BaseFee: baseFee,
+		Targets: make([]api.GasAdviceTarget, len(gasAdvisorTargets)),
+	}
+
+	// go from the least urgent target, so that more urgent ones never get
+	// advised a lower premium
+	minPremium := big.Zero()
+	for i := len(gasAdvisorTargets) - 1; i >= 0; i-- {
+		target := gasAdvisorTargets[i]
+
+		premium, err := chainGasPremium(cstore, cache, ts, target.premiumBlocks)
+		if err != nil {
+			return nil, xerrors.Errorf("estimating premium for %s: %w", target.name, err)
+		}
+		if target.mpool {
+			if mp := mpoolGasPremium(pending, baseFee, target.epochs); mp.GreaterThan(premium) {
+				premium = mp
+			}
+		}
+		if minPremium.GreaterThan(premium) {
+			premium = minPremium
+		}
+		minPremium = premium
+
+		increaseFactor := math.Pow(1.+1./float64(build.BaseFeeMaxChangeDenom), float64(target.feeCapEpochs))
+		feeCap := types.BigDiv(types.BigMul(baseFee, types.NewInt(uint64(increaseFactor*(1<<8)))), types.NewInt(1<<8))
+
+		out.Targets[i] = api.GasAdviceTarget{
+			Target:     target.name,
+			Epochs:     target.epochs,
+			GasFeeCap:  big.Add(feeCap, premium),
+			GasPremium: premium,
+		}
+	}
+
+	return out, nil
+}
+
 func (a *GasAPI) GasEstimateGasLimit(ctx context.Context, msgIn *types.Message, tsk types.TipSetKey) (int64, error) {
 	ts, err := a.Chain.GetTipSetFromKey(tsk)
 	if err != nil {
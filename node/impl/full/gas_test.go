@@ -38,3 +38,43 @@ func TestMedian(t *testing.T) {
 		{big.NewInt(30), build.BlockGasTarget / 2},
 	}, 2))
 }
+
+func TestMpoolGasPremium(t *testing.T) {
+	msg := func(premium, feecap int64, limit int64) *types.SignedMessage {
+		return &types.SignedMessage{Message: types.Message{
+			GasPremium: big.NewInt(premium),
+			GasFeeCap:  big.NewInt(feecap),
+			GasLimit:   limit,
+		}}
+	}
+	epochGas := build.BlockGasTarget * int64(build.BlocksPerEpoch)
+
+	// everything fits
+	require.Equal(t, big.Zero(), mpoolGasPremium([]*types.SignedMessage{
+		msg(10, 1000, epochGas/2),
+		msg(20, 1000, epochGas/2),
+	}, big.NewInt(100), 1))
+
+	// outbid the message which doesn't fit
+	require.Equal(t, big.NewInt(11), mpoolGasPremium([]*types.SignedMessage{
+		msg(10, 1000, epochGas/2),
+		msg(20, 1000, epochGas/2),
+		msg(30, 1000, epochGas/2),
+	}, big.NewInt(100), 1))
+
+	// more epochs make space for more messages
+	require.Equal(t, big.Zero(), mpoolGasPremium([]*types.SignedMessage{
+		msg(10, 1000, epochGas/2),
+		msg(20, 1000, epochGas/2),
+		msg(30, 1000, epochGas/2),
+	}, big.NewInt(100), 2))
+
+	// messages below the base fee are ignored, and capped ones only count
+	// with their effective premium
+	require.Equal(t, big.NewInt(6), mpoolGasPremium([]*types.SignedMessage{
+		msg(10, 50, epochGas/2),
+		msg(20, 1000, epochGas/2),
+		msg(30, 105, epochGas/2),
+		msg(40, 1000, epochGas/2),
+	}, big.NewInt(100), 1))
+}
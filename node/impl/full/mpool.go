@@ -3,6 +3,7 @@ package full
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/ipfs/go-cid"
@@ -10,6 +11,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/messagesigner"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -44,6 +46,8 @@ type MpoolAPI struct {
 	MessageSigner *messagesigner.MessageSigner
 
 	PushLocks *dtypes.MpoolLocker
+
+	GetSafeSendThreshold dtypes.SafeSendThresholdFunc
 }
 
 func (a *MpoolAPI) MpoolGetConfig(context.Context) (*types.MpoolConfig, error) {
@@ -180,6 +184,18 @@ func (a *MpoolAPI) MpoolPushMessage(ctx context.Context, msg *types.Message, spe
 		return nil, xerrors.Errorf("mpool push: not enough funds: %s < %s", b, msg.Value)
 	}
 
+	if spec != nil && spec.SafetyChecks {
+		checks, err := a.safetyChecks(ctx, msg)
+		if err != nil {
+			return nil, xerrors.Errorf("mpool push: running safety checks: %w", err)
+		}
+		for _, c := range checks {
+			if !c.OK {
+				return nil, xerrors.Errorf("mpool push: message failed the %s check: %s", c.Code, c.Err)
+			}
+		}
+	}
+
 	// Sign and push the message
 	return a.MessageSigner.SignMessage(ctx, msg, func(smsg *types.SignedMessage) error {
 		if _, err := a.MpoolModuleAPI.MpoolPush(ctx, smsg); err != nil {
@@ -226,7 +242,80 @@ func (a *MpoolAPI) MpoolBatchPushMessage(ctx context.Context, msgs []*types.Mess
 }
 
 func (a *MpoolAPI) MpoolCheckMessages(ctx context.Context, protos []*api.MessagePrototype) ([][]api.MessageCheckStatus, error) {
-	return a.Mpool.CheckMessages(ctx, protos)
+	checks, err := a.Mpool.CheckMessages(ctx, protos)
+	if err != nil {
+		return nil, err
+	}
+	if len(checks) != len(protos) {
+		// lite nodes don't run the mpool checks
+		checks = make([][]api.MessageCheckStatus, len(protos))
+	}
+
+	for i, p := range protos {
+		safety, err := a.safetyChecks(ctx, &p.Message)
+		if err != nil {
+			return nil, xerrors.Errorf("running safety checks: %w", err)
+		}
+		checks[i] = append(checks[i], safety...)
+	}
+	return checks, nil
+}
+
+// safetyChecks catches messages which are valid, but most likely not what the
+// sender meant to do
+func (a *MpoolAPI) safetyChecks(ctx context.Context, msg *types.Message) ([]api.MessageCheckStatus, error) {
+	var out []api.MessageCheckStatus
+
+	// Recipient: nothing can be created at an ID address, and funds sent to
+	// miners can only be withdrawn by their owner
+	check := api.MessageCheckStatus{
+		Cid: msg.Cid(),
+		CheckStatus: api.CheckStatus{
+			Code: api.CheckStatusMessageRecipient,
+			Hint: map[string]interface{}{},
+			OK:   true,
+		},
+	}
+	act, err := a.StateManagerAPI.LoadActorTsk(ctx, msg.To, types.EmptyTSK)
+	switch {
+	case xerrors.Is(err, types.ErrActorNotFound):
+		if msg.To.Protocol() == address.ID {
+			check.OK = false
+			check.Err = "recipient ID address doesn't exist"
+		}
+	case err != nil:
+		return nil, xerrors.Errorf("loading recipient actor: %w", err)
+	default:
+		check.Hint["actorType"] = builtin.ActorNameByCode(act.Code)
+		if builtin.IsStorageMinerActor(act.Code) && msg.Method == builtin.MethodSend && !msg.Value.IsZero() {
+			check.OK = false
+			check.Err = "recipient is a miner actor, funds sent to it can only be withdrawn by its owner"
+		}
+	}
+	out = append(out, check)
+
+	// Value
+	threshold, err := a.GetSafeSendThreshold()
+	if err != nil {
+		return nil, xerrors.Errorf("getting safe send threshold: %w", err)
+	}
+	check = api.MessageCheckStatus{
+		Cid: msg.Cid(),
+		CheckStatus: api.CheckStatus{
+			Code: api.CheckStatusMessageValue,
+			Hint: map[string]interface{}{
+				"threshold": threshold,
+			},
+			OK: true,
+		},
+	}
+	if !threshold.IsZero() && msg.Value.GreaterThan(threshold) {
+		check.OK = false
+		check.Err = fmt.Sprintf("value %s is above the safe send threshold of %s", types.FIL(msg.Value), types.FIL(threshold))
+	}
+	out = append(out, check)
+
+	return out, nil
 }
 
 func (a *MpoolAPI) MpoolCheckPendingMessages(ctx context.Context, from address.Address) ([][]api.MessageCheckStatus, error) {
@@ -237,6 +326,10 @@ func (a *MpoolAPI) MpoolCheckReplaceMessages(ctx context.Context, msgs []*types.
 	return a.Mpool.CheckReplaceMessages(ctx, msgs)
 }
 
+func (a *MpoolAPI) MpoolNonceGaps(ctx context.Context) ([]api.NonceGapReport, error) {
+	return a.Mpool.NonceGaps(ctx)
+}
+
 func (a *MpoolAPI) MpoolGetNonce(ctx context.Context, addr address.Address) (uint64, error) {
 	return a.Mpool.GetNonce(ctx, addr, types.EmptyTSK)
 }
@@ -244,3 +337,52 @@ func (a *MpoolAPI) MpoolGetNonce(ctx context.Context, addr address.Address) (uin
 func (a *MpoolAPI) MpoolSub(ctx context.Context) (<-chan api.MpoolUpdate, error) {
 	return a.Mpool.Updates(ctx)
 }
+
+func (a *MpoolAPI) MpoolReorgs(ctx context.Context) (<-chan api.MpoolReorg, error) {
+	sub, err := a.Mpool.Reorgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan api.MpoolReorg)
+	go func() {
+		defer close(out)
+
+		for r := range sub {
+			a.reorgReceipts(ctx, &r)
+
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// reorgReceipts looks up the execution results of re-included messages on
+// the current chain
+func (a *MpoolAPI) reorgReceipts(ctx context.Context, r *api.MpoolReorg) {
+	head := a.Chain.GetHeaviestTipSet()
+
+	for i, m := range r.Messages {
+		if !m.Reincluded {
+			continue
+		}
+
+		ts, err := a.Chain.GetTipSetFromKey(m.ReincludedIn)
+		if err != nil {
+			log.Warnf("loading tipset re-including message %s: %s", m.ReincludedCid, err)
+			continue
+		}
+
+		_, rct, _, err := a.Stmgr.SearchForMessage(ctx, head, m.ReincludedCid, head.Height()-ts.Height()+1, false)
+		if err != nil {
+			log.Warnf("searching for the receipt of message %s: %s", m.ReincludedCid, err)
+			continue
+		}
+		r.Messages[i].Receipt = rct
+	}
+}
@@ -16,8 +16,13 @@ import (
 	"github.com/filecoin-project/go-state-types/dline"
 	"github.com/filecoin-project/go-state-types/network"
 	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper"
+	miner5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/miner"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actorindex"
+	"github.com/filecoin-project/lotus/chain/actors"
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/market"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
@@ -68,6 +73,8 @@ type StateModule struct {
 
 	StateManager *stmgr.StateManager
 	Chain        *store.ChainStore
+
+	ActorIndex *actorindex.Index `optional:"true"`
 }
 
 var _ StateModuleAPI = (*StateModule)(nil)
@@ -86,6 +93,9 @@ type StateAPI struct {
 	StateManager  *stmgr.StateManager
 	Chain         *store.ChainStore
 	Beacon        beacon.Schedule
+	DeadlineCache *DeadlineSummaryCache
+
+	ActorIndex *actorindex.Index `optional:"true"`
 }
 
 func (a *StateAPI) StateNetworkName(ctx context.Context) (dtypes.NetworkName, error) {
@@ -106,6 +116,143 @@ func (a *StateAPI) StateMinerSectors(ctx context.Context, addr address.Address,
 	return mas.LoadSectors(sectorNos)
 }
 
+const defaultSectorsPageLimit = 1000
+
+func (a *StateAPI) StateMinerSectorsPage(ctx context.Context, addr address.Address, q api.MinerSectorsQuery, tsk types.TipSetKey) (*api.MinerSectorsPage, error) {
+	if q.Partition != nil && q.Deadline == nil {
+		return nil, xerrors.Errorf("selecting a partition requires a deadline")
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultSectorsPageLimit
+	}
+
+	act, err := a.StateManager.LoadActorTsk(ctx, addr, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load miner actor: %w", err)
+	}
+
+	mas, err := miner.Load(a.StateManager.ChainStore().ActorStore(ctx), act)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load miner actor state: %w", err)
+	}
+
+	// the numbers of the sectors in the sectors AMT are those assigned to
+	// partitions, select them first to only load the sectors of the page
+	var sectors bitfield.BitField
+	if q.Deadline != nil {
+		dl, err := mas.LoadDeadline(*q.Deadline)
+		if err != nil {
+			return nil, xerrors.Errorf("loading deadline %d: %w", *q.Deadline, err)
+		}
+
+		var parts []bitfield.BitField
+		err = dl.ForEachPartition(func(idx uint64, part miner.Partition) error {
+			if q.Partition != nil && idx != *q.Partition {
+				return nil
+			}
+			s, err := part.AllSectors()
+			if err != nil {
+				return xerrors.Errorf("getting sectors of partition %d: %w", idx, err)
+			}
+			parts = append(parts, s)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if sectors, err = bitfield.MultiMerge(parts...); err != nil {
+			return nil, err
+		}
+	} else {
+		if sectors, err = miner.AllPartSectors(mas, miner.Partition.AllSectors); err != nil {
+			return nil, xerrors.Errorf("getting sectors: %w", err)
+		}
+	}
+
+	// bound the sectors loaded by a call when the filters skip many of them
+	maxScan := 10 * limit
+
+	page := &api.MinerSectorsPage{}
+	next := uint64(q.Cursor)
+	for scanned := 0; len(page.Sectors) < limit && scanned < maxScan; {
+		batch, err := sectorNumbersFrom(sectors, next, limit-len(page.Sectors))
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			return page, nil
+		}
+		next = batch[len(batch)-1] + 1
+		scanned += len(batch)
+
+		bf := bitfield.NewFromSet(batch)
+		infos, err := mas.LoadSectors(&bf)
+		if err != nil {
+			return nil, xerrors.Errorf("loading sectors: %w", err)
+		}
+		for _, si := range infos {
+			if sectorMatches(&q, si) {
+				page.Sectors = append(page.Sectors, si)
+			}
+		}
+	}
+
+	more, err := sectorNumbersFrom(sectors, next, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(more) > 0 {
+		c := abi.SectorNumber(next)
+		page.NextCursor = &c
+	}
+	return page, nil
+}
+
+// sectorNumbersFrom returns up to n of the sector numbers set in the
+// bitfield, starting at start, skipping the runs before it
+func sectorNumbersFrom(bf bitfield.BitField, start uint64, n int) ([]uint64, error) {
+	rit, err := bf.RunIterator()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []uint64
+	var pos uint64
+	for rit.HasNext() && len(out) < n {
+		r, err := rit.NextRun()
+		if err != nil {
+			return nil, err
+		}
+
+		end := pos + r.Len
+		if r.Val && end > start {
+			i := pos
+			if i < start {
+				i = start
+			}
+			for ; i < end && len(out) < n; i++ {
+				out = append(out, i)
+			}
+		}
+		pos = end
+	}
+	return out, nil
+}
+
+func sectorMatches(q *api.MinerSectorsQuery, si *miner.SectorOnChainInfo) bool {
+	switch {
+	case q.ActivationMin != 0 && si.Activation < q.ActivationMin,
+		q.ActivationMax != 0 && si.Activation > q.ActivationMax,
+		q.ExpirationMin != 0 && si.Expiration < q.ExpirationMin,
+		q.ExpirationMax != 0 && si.Expiration > q.ExpirationMax,
+		q.WithDeals && len(si.DealIDs) == 0:
+		return false
+	}
+	return true
+}
+
 func (a *StateAPI) StateMinerActiveSectors(ctx context.Context, maddr address.Address, tsk types.TipSetKey) ([]*miner.SectorOnChainInfo, error) { // TODO: only used in cli
 	act, err := a.StateManager.LoadActorTsk(ctx, maddr, tsk)
 	if err != nil {
@@ -375,55 +522,81 @@ func (a *StateAPI) StateCall(ctx context.Context, msg *types.Message, tsk types.
 }
 
 func (a *StateAPI) StateReplay(ctx context.Context, tsk types.TipSetKey, mc cid.Cid) (*api.InvocResult, error) {
-	msgToReplay := mc
-	var ts *types.TipSet
-	var err error
-	if tsk == types.EmptyTSK {
-		mlkp, err := a.StateSearchMsg(ctx, types.EmptyTSK, mc, stmgr.LookbackNoLimit, true)
-		if err != nil {
-			return nil, xerrors.Errorf("searching for msg %s: %w", mc, err)
-		}
-		if mlkp == nil {
-			return nil, xerrors.Errorf("didn't find msg %s", mc)
-		}
+	ts, msgToReplay, err := a.replayTipSet(ctx, tsk, mc)
+	if err != nil {
+		return nil, err
+	}
 
-		msgToReplay = mlkp.Message
+	m, r, err := a.StateManager.Replay(ctx, ts, msgToReplay)
+	if err != nil {
+		return nil, err
+	}
 
-		executionTs, err := a.Chain.GetTipSetFromKey(mlkp.TipSet)
-		if err != nil {
-			return nil, xerrors.Errorf("loading tipset %s: %w", mlkp.TipSet, err)
-		}
+	return replayInvocResult(msgToReplay, m, r), nil
+}
 
-		ts, err = a.Chain.LoadTipSet(executionTs.Parents())
-		if err != nil {
-			return nil, xerrors.Errorf("loading parent tipset %s: %w", mlkp.TipSet, err)
-		}
-	} else {
-		ts, err = a.Chain.LoadTipSet(tsk)
+func (a *StateAPI) StateReplayDetailed(ctx context.Context, tsk types.TipSetKey, mc cid.Cid) (*api.ReplayTrace, error) {
+	ts, msgToReplay, err := a.replayTipSet(ctx, tsk, mc)
+	if err != nil {
+		return nil, err
+	}
+
+	m, r, trace, err := a.StateManager.ReplayDetailed(ctx, ts, msgToReplay)
+	if err != nil {
+		return nil, err
+	}
+
+	trace.InvocResult = replayInvocResult(msgToReplay, m, r)
+	return trace, nil
+}
+
+// replayTipSet returns the tipset on which to replay a message, and the cid
+// of the message to replay
+func (a *StateAPI) replayTipSet(ctx context.Context, tsk types.TipSetKey, mc cid.Cid) (*types.TipSet, cid.Cid, error) {
+	if tsk != types.EmptyTSK {
+		ts, err := a.Chain.LoadTipSet(tsk)
 		if err != nil {
-			return nil, xerrors.Errorf("loading specified tipset %s: %w", tsk, err)
+			return nil, cid.Undef, xerrors.Errorf("loading specified tipset %s: %w", tsk, err)
 		}
+		return ts, mc, nil
 	}
 
-	m, r, err := a.StateManager.Replay(ctx, ts, msgToReplay)
+	mlkp, err := a.StateSearchMsg(ctx, types.EmptyTSK, mc, stmgr.LookbackNoLimit, true)
 	if err != nil {
-		return nil, err
+		return nil, cid.Undef, xerrors.Errorf("searching for msg %s: %w", mc, err)
+	}
+	if mlkp == nil {
+		return nil, cid.Undef, xerrors.Errorf("didn't find msg %s", mc)
+	}
+
+	executionTs, err := a.Chain.GetTipSetFromKey(mlkp.TipSet)
+	if err != nil {
+		return nil, cid.Undef, xerrors.Errorf("loading tipset %s: %w", mlkp.TipSet, err)
 	}
 
+	ts, err := a.Chain.LoadTipSet(executionTs.Parents())
+	if err != nil {
+		return nil, cid.Undef, xerrors.Errorf("loading parent tipset %s: %w", mlkp.TipSet, err)
+	}
+
+	return ts, mlkp.Message, nil
+}
+
+func replayInvocResult(mc cid.Cid, m *types.Message, r *vm.ApplyRet) *api.InvocResult {
 	var errstr string
 	if r.ActorErr != nil {
 		errstr = r.ActorErr.Error()
 	}
 
 	return &api.InvocResult{
-		MsgCid:         msgToReplay,
+		MsgCid:         mc,
 		Msg:            m,
 		MsgRct:         &r.MessageReceipt,
 		GasCost:        stmgr.MakeMsgGasCost(m, r),
 		ExecutionTrace: r.ExecutionTrace,
 		Error:          errstr,
 		Duration:       r.Duration,
-	}, nil
+	}
 }
 
 func (m *StateModule) StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (a *types.Actor, err error) {
@@ -440,7 +613,47 @@ func (m *StateModule) StateLookupID(ctx context.Context, addr address.Address, t
 		return address.Undef, xerrors.Errorf("loading tipset %s: %w", tsk, err)
 	}
 
-	return m.StateManager.LookupID(ctx, addr, ts)
+	id, err := m.StateManager.LookupID(ctx, addr, ts)
+	if xerrors.Is(err, types.ErrActorNotFound) && m.ActorIndex != nil {
+		return address.Undef, m.ActorIndex.NotFoundError(addr, ts.Height(), err)
+	}
+	return id, err
+}
+
+func (a *StateAPI) StateActorCodeCIDs(ctx context.Context, nv network.Version) (map[string]cid.Cid, error) {
+	if nv > build.NewestNetworkVersion {
+		return nil, xerrors.Errorf("unsupported network version %d, the newest is %d", nv, build.NewestNetworkVersion)
+	}
+	return stmgr.BuiltinActorCodes(actors.VersionForNetwork(nv))
+}
+
+func (a *StateAPI) StateActorMethods(ctx context.Context, code cid.Cid) ([]api.ActorMethod, error) {
+	return stmgr.ActorMethods(code)
+}
+
+func (a *StateAPI) StateDecodeActorParams(ctx context.Context, code cid.Cid, method abi.MethodNum, params []byte) (interface{}, error) {
+	return stmgr.DecodeParams(code, method, params)
+}
+
+func (a *StateAPI) StateDecodeActorReturn(ctx context.Context, code cid.Cid, method abi.MethodNum, ret []byte) (interface{}, error) {
+	return stmgr.DecodeReturn(code, method, ret)
+}
+
+func (a *StateAPI) StateActorLifecycle(ctx context.Context, addr address.Address) (*api.ActorLifecycle, error) {
+	if a.ActorIndex == nil {
+		return nil, xerrors.Errorf("actor index not enabled, see Chainstore.EnableActorIndex in the config")
+	}
+	return a.ActorIndex.Lookup(addr)
+}
+
+func (a *StateAPI) StateListCreatedActors(ctx context.Context, from, to abi.ChainEpoch) ([]api.ActorLifecycle, error) {
+	if a.ActorIndex == nil {
+		return nil, xerrors.Errorf("actor index not enabled, see Chainstore.EnableActorIndex in the config")
+	}
+	if to < from {
+		return nil, xerrors.Errorf("epoch range end %d before its start %d", to, from)
+	}
+	return a.ActorIndex.Created(from, to)
 }
 
 func (m *StateModule) StateAccountKey(ctx context.Context, addr address.Address, tsk types.TipSetKey) (address.Address, error) {
@@ -485,16 +698,60 @@ func (a *StateAPI) StateDecodeParams(ctx context.Context, toAddr address.Address
 		return nil, xerrors.Errorf("getting actor: %w", err)
 	}
 
-	paramType, err := stmgr.GetParamType(act.Code, method)
+	return stmgr.DecodeParams(act.Code, method, params)
+}
+
+func (a *StateAPI) StateDecodeReturn(ctx context.Context, toAddr address.Address, method abi.MethodNum, ret []byte, tsk types.TipSetKey) (interface{}, error) {
+	act, err := a.StateGetActor(ctx, toAddr, tsk)
 	if err != nil {
-		return nil, xerrors.Errorf("getting params type: %w", err)
+		return nil, xerrors.Errorf("getting actor: %w", err)
 	}
 
-	if err = paramType.UnmarshalCBOR(bytes.NewReader(params)); err != nil {
-		return nil, err
+	return stmgr.DecodeReturn(act.Code, method, ret)
+}
+
+func (a *StateAPI) StateDecodeMessage(ctx context.Context, msg cid.Cid) (*api.DecodedMessage, error) {
+	cm, err := a.Chain.GetCMessage(msg)
+	if err != nil {
+		return nil, xerrors.Errorf("getting message: %w", err)
+	}
+
+	out := &api.DecodedMessage{
+		Cid:     msg,
+		Message: cm.VMMessage(),
 	}
 
-	return paramType, nil
+	lookup, err := a.StateSearchMsg(ctx, types.EmptyTSK, msg, api.LookbackNoLimit, true)
+	if err != nil {
+		return nil, xerrors.Errorf("searching message: %w", err)
+	}
+
+	tsk := types.EmptyTSK
+	if lookup != nil {
+		tsk = lookup.TipSet
+		out.Receipt = &lookup.Receipt
+	}
+
+	act, err := a.StateGetActor(ctx, out.Message.To, tsk)
+	if err != nil {
+		out.DecodeError = xerrors.Errorf("getting recipient actor: %w", err).Error()
+		return out, nil
+	}
+	out.Actor = builtin.ActorNameByCode(act.Code)
+	out.Method = stmgr.MethodsMap[act.Code][out.Message.Method].Name
+
+	if out.Params, err = stmgr.DecodeParams(act.Code, out.Message.Method, out.Message.Params); err != nil {
+		out.DecodeError = err.Error()
+		return out, nil
+	}
+
+	if out.Receipt != nil && out.Receipt.ExitCode.IsSuccess() {
+		if out.Return, err = stmgr.DecodeReturn(act.Code, out.Message.Method, out.Receipt.Return); err != nil {
+			out.DecodeError = err.Error()
+		}
+	}
+
+	return out, nil
 }
 
 // This is on StateAPI because miner.Miner requires this, and MinerAPI requires miner.Miner
@@ -557,6 +814,47 @@ func (m *StateModule) StateWaitMsg(ctx context.Context, msg cid.Cid, confidence
 	}, nil
 }
 
+func (a *StateAPI) StateWatchMsg(ctx context.Context, msg cid.Cid, confidence uint64, lookbackLimit abi.ChainEpoch, allowReplaced bool) (<-chan api.MsgWatchEvent, error) {
+	stages := []api.MsgWatchEvent{{Type: api.MsgExecuted, Confidence: 0}}
+	if confidence > 0 && confidence < build.FinalMessageConfidence {
+		stages = append(stages, api.MsgWatchEvent{Type: api.MsgConfirmed, Confidence: confidence})
+	}
+	stages = append(stages, api.MsgWatchEvent{Type: api.MsgFinal, Confidence: build.FinalMessageConfidence})
+
+	out := make(chan api.MsgWatchEvent, len(stages))
+	go func() {
+		defer close(out)
+
+		for _, ev := range stages {
+			// every stage searches the chain again, so a message re-orged out
+			// after an earlier event is only reported once it is back on chain
+			lookup, err := a.StateWaitMsg(ctx, msg, ev.Confidence, lookbackLimit, allowReplaced)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				ev.Error = err.Error()
+			}
+			ev.Lookup = lookup
+
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+
+			// the head moves on while waiting for later stages, which would push
+			// the message out of the lookback limit
+			lookbackLimit = api.LookbackNoLimit
+		}
+	}()
+
+	return out, nil
+}
+
 func (m *StateModule) StateSearchMsg(ctx context.Context, tsk types.TipSetKey, msg cid.Cid, lookbackLimit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error) {
 	fromTs, err := m.Chain.GetTipSetFromKey(tsk)
 	if err != nil {
@@ -797,6 +1095,18 @@ func (a *StateAPI) StateSectorPartition(ctx context.Context, maddr address.Addre
 	return mas.FindSector(sectorNumber)
 }
 
+func (a *StateAPI) StateVerifyAggregateSeals(ctx context.Context, aggregate proof5.AggregateSealVerifyProofAndInfos) (bool, error) {
+	if n := len(aggregate.Infos); n < miner5.MinAggregatedSectors || n > miner5.MaxAggregatedSectors {
+		return false, xerrors.Errorf("aggregate must hold between %d and %d proofs, got %d", miner5.MinAggregatedSectors, miner5.MaxAggregatedSectors, n)
+	}
+
+	ok, err := a.ProofVerifier.VerifyAggregateSeals(aggregate)
+	if err != nil {
+		return false, xerrors.Errorf("verifying aggregate: %w", err)
+	}
+	return ok, nil
+}
+
 func (a *StateAPI) StateListMessages(ctx context.Context, match *api.MessageMatch, tsk types.TipSetKey, toheight abi.ChainEpoch) ([]cid.Cid, error) {
 	ts, err := a.Chain.GetTipSetFromKey(tsk)
 	if err != nil {
@@ -1191,6 +1501,68 @@ func (a *StateAPI) StateMinerAvailableBalance(ctx context.Context, maddr address
 	return types.BigAdd(abal, vested), nil
 }
 
+func (a *StateAPI) StateMinerTerminationPenalty(ctx context.Context, maddr address.Address, sectors []abi.SectorNumber, tsk types.TipSetKey) (abi.TokenAmount, error) {
+	ts, err := a.Chain.GetTipSetFromKey(tsk)
+	if err != nil {
+		return types.EmptyInt, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+
+	state, err := a.StateManager.ParentState(ts)
+	if err != nil {
+		return types.EmptyInt, xerrors.Errorf("loading state %s: %w", tsk, err)
+	}
+
+	store := a.Chain.ActorStore(ctx)
+
+	act, err := state.GetActor(maddr)
+	if err != nil {
+		return types.EmptyInt, xerrors.Errorf("loading miner actor %s: %w", maddr, err)
+	}
+	mas, err := miner.Load(store, act)
+	if err != nil {
+		return types.EmptyInt, xerrors.Errorf("loading miner actor state %s: %w", maddr, err)
+	}
+
+	var powerSmoothed builtin.FilterEstimate
+	if act, err := state.GetActor(power.Address); err != nil {
+		return types.EmptyInt, xerrors.Errorf("loading power actor: %w", err)
+	} else if s, err := power.Load(store, act); err != nil {
+		return types.EmptyInt, xerrors.Errorf("loading power actor state: %w", err)
+	} else if p, err := s.TotalPowerSmoothed(); err != nil {
+		return types.EmptyInt, xerrors.Errorf("failed to determine total power: %w", err)
+	} else {
+		powerSmoothed = p
+	}
+
+	var rewardSmoothed builtin.FilterEstimate
+	if act, err := state.GetActor(reward.Address); err != nil {
+		return types.EmptyInt, xerrors.Errorf("loading reward actor: %w", err)
+	} else if s, err := reward.Load(store, act); err != nil {
+		return types.EmptyInt, xerrors.Errorf("loading reward actor state: %w", err)
+	} else if r, err := s.ThisEpochRewardSmoothed(); err != nil {
+		return types.EmptyInt, xerrors.Errorf("failed to determine reward estimate: %w", err)
+	} else {
+		rewardSmoothed = r
+	}
+
+	// the actor terminates each sector once, however many times it's listed
+	seen := make(map[abi.SectorNumber]struct{}, len(sectors))
+	unique := make([]abi.SectorNumber, 0, len(sectors))
+	for _, s := range sectors {
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		unique = append(unique, s)
+	}
+
+	penalty, err := mas.TerminationPenalty(unique, ts.Height(), rewardSmoothed, powerSmoothed)
+	if err != nil {
+		return types.EmptyInt, xerrors.Errorf("computing termination penalty: %w", err)
+	}
+	return penalty, nil
+}
+
 func (a *StateAPI) StateMinerSectorAllocated(ctx context.Context, maddr address.Address, s abi.SectorNumber, tsk types.TipSetKey) (bool, error) {
 	ts, err := a.Chain.GetTipSetFromKey(tsk)
 	if err != nil {
@@ -1380,6 +1752,139 @@ func stateVMCirculatingSupplyInternal(
 	return smgr.GetVMCirculatingSupplyDetailed(ctx, ts.Height(), sTree)
 }
 
+func (a *StateAPI) StateCirculatingSupplyBreakdown(ctx context.Context, tsk types.TipSetKey) (*api.CirculatingSupplyBreakdown, error) {
+	ts, err := a.Chain.GetTipSetFromKey(tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+
+	sTree, err := a.StateManager.ParentState(ts)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.StateManager.GetCirculatingSupplyBreakdown(ctx, ts.Height(), sTree)
+}
+
+// pledgeProjectionSampleEpochs is how far back StateSectorPledgeProjection
+// looks to measure the trend of network power and circulating supply.
+const pledgeProjectionSampleEpochs = abi.ChainEpoch(builtin.EpochsInDay)
+
+type pledgeInputs struct {
+	reward           reward.State
+	powerSmoothed    builtin.FilterEstimate
+	networkQAPower   abi.StoragePower
+	pledgeCollateral abi.TokenAmount
+	circulating      abi.TokenAmount
+}
+
+func (a *StateAPI) pledgeInputsAt(ctx context.Context, ts *types.TipSet) (*pledgeInputs, error) {
+	state, err := a.StateManager.ParentState(ts)
+	if err != nil {
+		return nil, xerrors.Errorf("loading state %s: %w", ts.Key(), err)
+	}
+
+	store := a.Chain.ActorStore(ctx)
+	var in pledgeInputs
+
+	if act, err := state.GetActor(power.Address); err != nil {
+		return nil, xerrors.Errorf("loading power actor: %w", err)
+	} else if s, err := power.Load(store, act); err != nil {
+		return nil, xerrors.Errorf("loading power actor state: %w", err)
+	} else if in.powerSmoothed, err = s.TotalPowerSmoothed(); err != nil {
+		return nil, xerrors.Errorf("failed to determine total power: %w", err)
+	} else if p, err := s.TotalPower(); err != nil {
+		return nil, xerrors.Errorf("failed to determine total power: %w", err)
+	} else if in.pledgeCollateral, err = s.TotalLocked(); err != nil {
+		return nil, xerrors.Errorf("failed to determine pledge collateral: %w", err)
+	} else {
+		in.networkQAPower = p.QualityAdjPower
+	}
+
+	if act, err := state.GetActor(reward.Address); err != nil {
+		return nil, xerrors.Errorf("loading reward actor: %w", err)
+	} else if in.reward, err = reward.Load(store, act); err != nil {
+		return nil, xerrors.Errorf("loading reward actor state: %w", err)
+	}
+
+	circSupply, err := a.StateManager.GetVMCirculatingSupplyDetailed(ctx, ts.Height(), state)
+	if err != nil {
+		return nil, xerrors.Errorf("getting circulating supply: %w", err)
+	}
+	in.circulating = circSupply.FilCirculating
+
+	return &in, nil
+}
+
+// extrapolate linearly projects cur forward by delta epochs, given that it
+// changed from prev over the preceding span epochs. The result is floored at zero.
+func extrapolate(cur, prev big.Int, span, delta abi.ChainEpoch) big.Int {
+	if span <= 0 {
+		return cur
+	}
+
+	change := big.Div(big.Mul(big.Sub(cur, prev), big.NewInt(int64(delta))), big.NewInt(int64(span)))
+	return big.Max(big.Add(cur, change), big.Zero())
+}
+
+func (a *StateAPI) StateSectorPledgeProjection(ctx context.Context, qaPower abi.StoragePower, epoch abi.ChainEpoch, tsk types.TipSetKey) (*api.SectorPledgeProjection, error) {
+	if qaPower.Sign() <= 0 {
+		return nil, xerrors.Errorf("sector power must be positive")
+	}
+
+	ts, err := a.Chain.GetTipSetFromKey(tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	if epoch < ts.Height() {
+		return nil, xerrors.Errorf("projection epoch %d is before tipset height %d", epoch, ts.Height())
+	}
+
+	cur, err := a.pledgeInputsAt(ctx, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	prev, span := cur, abi.ChainEpoch(0)
+	if ts.Height() > 0 {
+		sampleHeight := ts.Height() - pledgeProjectionSampleEpochs
+		if sampleHeight < 0 {
+			sampleHeight = 0
+		}
+
+		sts, err := a.Chain.GetTipsetByHeight(ctx, sampleHeight, ts, true)
+		if err != nil {
+			return nil, xerrors.Errorf("loading sample tipset at %d: %w", sampleHeight, err)
+		}
+
+		if prev, err = a.pledgeInputsAt(ctx, sts); err != nil {
+			return nil, xerrors.Errorf("loading sample at %d: %w", sts.Height(), err)
+		}
+		span = ts.Height() - sts.Height()
+	}
+
+	delta := epoch - ts.Height()
+	powerSmoothed := builtin.FilterEstimate{
+		PositionEstimate: extrapolate(cur.powerSmoothed.PositionEstimate, prev.powerSmoothed.PositionEstimate, span, delta),
+		VelocityEstimate: cur.powerSmoothed.VelocityEstimate,
+	}
+	circulating := extrapolate(cur.circulating, prev.circulating, span, delta)
+
+	initialPledge, err := cur.reward.InitialPledgeForPower(qaPower, cur.pledgeCollateral, &powerSmoothed, circulating)
+	if err != nil {
+		return nil, xerrors.Errorf("calculating initial pledge: %w", err)
+	}
+
+	return &api.SectorPledgeProjection{
+		Epoch:          epoch,
+		QAPower:        qaPower,
+		InitialPledge:  initialPledge,
+		NetworkQAPower: extrapolate(cur.networkQAPower, prev.networkQAPower, span, delta),
+		FilCirculating: circulating,
+		SampleEpochs:   span,
+	}, nil
+}
+
 func (m *StateModule) StateNetworkVersion(ctx context.Context, tsk types.TipSetKey) (network.Version, error) {
 	ts, err := m.Chain.GetTipSetFromKey(tsk)
 	if err != nil {
@@ -0,0 +1,305 @@
+package full
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// stateField resolves a single StateQuery field of an actor. The actor is
+// loaded once per actor query, and passed to all of its fields.
+type stateField func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error)
+
+var actorStateFields = map[string]stateField{
+	"Actor": func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error) {
+		return act, nil
+	},
+	"Balance": func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error) {
+		return act.Balance, nil
+	},
+	"ID": func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error) {
+		return a.StateLookupID(ctx, addr, tsk)
+	},
+}
+
+var minerStateFields = map[string]stateField{
+	"Info": func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error) {
+		return a.StateMinerInfo(ctx, addr, tsk)
+	},
+	"Power": func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error) {
+		return a.StateMinerPower(ctx, addr, tsk)
+	},
+	"AvailableBalance": func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error) {
+		return a.StateMinerAvailableBalance(ctx, addr, tsk)
+	},
+	"ProvingDeadline": func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error) {
+		return a.StateMinerProvingDeadline(ctx, addr, tsk)
+	},
+	"Deadlines": func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error) {
+		return a.StateMinerDeadlines(ctx, addr, tsk)
+	},
+	"DeadlineSummaries": func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error) {
+		return a.minerDeadlineSummaries(ctx, addr, act)
+	},
+	"SectorCount": func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error) {
+		return a.StateMinerSectorCount(ctx, addr, tsk)
+	},
+	"Faults": func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error) {
+		return a.StateMinerFaults(ctx, addr, tsk)
+	},
+	"Recoveries": func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error) {
+		return a.StateMinerRecoveries(ctx, addr, tsk)
+	},
+}
+
+var multisigStateFields = map[string]stateField{
+	"AvailableBalance": func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error) {
+		return a.MsigGetAvailableBalance(ctx, addr, tsk)
+	},
+	"Pending": func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error) {
+		return a.MsigGetPending(ctx, addr, tsk)
+	},
+	"VestingSchedule": func(ctx context.Context, a *StateAPI, addr address.Address, act *types.Actor, tsk types.TipSetKey) (interface{}, error) {
+		return a.MsigGetVestingSchedule(ctx, addr, tsk)
+	},
+}
+
+func stateFieldsFor(act *types.Actor) map[string]stateField {
+	var typed map[string]stateField
+	switch {
+	case builtin.IsStorageMinerActor(act.Code):
+		typed = minerStateFields
+	case builtin.IsMultisigActor(act.Code):
+		typed = multisigStateFields
+	}
+
+	out := make(map[string]stateField, len(actorStateFields)+len(typed))
+	for name, f := range actorStateFields {
+		out[name] = f
+	}
+	for name, f := range typed {
+		out[name] = f
+	}
+	return out
+}
+
+func (a *StateAPI) StateQuery(ctx context.Context, query []api.StateFieldQuery, tsk types.TipSetKey) (*api.StateQueryResult, error) {
+	// resolve all fields at the same tipset, even when tsk is empty and the
+	// head changes while the query runs
+	ts, err := a.Chain.GetTipSetFromKey(tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+
+	out := &api.StateQueryResult{
+		TipSet: ts.Key(),
+		Height: ts.Height(),
+		Actors: make([]api.StateActorFields, len(query)),
+	}
+
+	for i, q := range query {
+		res := api.StateActorFields{
+			Actor:  q.Actor,
+			Fields: map[string]json.RawMessage{},
+			Errors: map[string]string{},
+		}
+
+		act, err := a.StateManager.LoadActorTsk(ctx, q.Actor, ts.Key())
+		if err != nil {
+			// e.g. the actor doesn't exist (yet) at this tipset, which
+			// shouldn't fail the queries of the other actors
+			for _, name := range q.Fields {
+				res.Errors[name] = "loading actor: " + err.Error()
+			}
+			out.Actors[i] = res
+			continue
+		}
+
+		fields := stateFieldsFor(act)
+		for _, name := range q.Fields {
+			if _, done := res.Fields[name]; done {
+				continue
+			}
+
+			f, ok := fields[name]
+			if !ok {
+				res.Errors[name] = "unknown field, available fields: " + strings.Join(fieldNames(fields), ", ")
+				continue
+			}
+
+			v, err := f(ctx, a, q.Actor, act, ts.Key())
+			if err != nil {
+				res.Errors[name] = err.Error()
+				continue
+			}
+
+			b, err := json.Marshal(v)
+			if err != nil {
+				res.Errors[name] = "marshaling field: " + err.Error()
+				continue
+			}
+			res.Fields[name] = b
+		}
+
+		out.Actors[i] = res
+	}
+
+	return out, nil
+}
+
+func (a *StateAPI) minerDeadlineSummaries(ctx context.Context, addr address.Address, act *types.Actor) ([]api.DeadlineSummary, error) {
+	mas, err := miner.Load(a.StateManager.ChainStore().ActorStore(ctx), act)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load miner actor state: %w", err)
+	}
+
+	return a.DeadlineCache.summaries(addr, mas)
+}
+
+func (a *StateAPI) StateMinerProvingSummary(ctx context.Context, addr address.Address, tsk types.TipSetKey) (*api.MinerProvingSummary, error) {
+	ts, err := a.Chain.GetTipSetFromKey(tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+
+	act, err := a.StateManager.LoadActor(ctx, addr, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load miner actor: %w", err)
+	}
+
+	mas, err := miner.Load(a.StateManager.ChainStore().ActorStore(ctx), act)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load miner actor state: %w", err)
+	}
+
+	di, err := mas.DeadlineInfo(ts.Height())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get deadline info: %w", err)
+	}
+
+	summaries, err := a.DeadlineCache.summaries(addr, mas)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.MinerProvingSummary{
+		ProvingDeadline: di.NextNotElapsed(),
+		Deadlines:       summaries,
+	}, nil
+}
+
+// DeadlineSummaryCache keeps the last deadline summaries computed for each
+// miner, which stay valid until the deadlines of the miner change; dashboards
+// refreshing them every few seconds mostly hit the cache.
+type DeadlineSummaryCache struct {
+	c *lru.TwoQueueCache
+}
+
+type cachedSummaries struct {
+	state     miner.State
+	summaries []api.DeadlineSummary
+}
+
+func NewDeadlineSummaryCache() *DeadlineSummaryCache {
+	c, err := lru.New2Q(256)
+	if err != nil {
+		// err only if parameter is bad
+		panic(err)
+	}
+
+	return &DeadlineSummaryCache{
+		c: c,
+	}
+}
+
+func (dc *DeadlineSummaryCache) summaries(maddr address.Address, mas miner.State) ([]api.DeadlineSummary, error) {
+	if v, ok := dc.c.Get(maddr); ok {
+		cs := v.(*cachedSummaries)
+		changed, err := mas.DeadlinesChanged(cs.state)
+		if err != nil {
+			return nil, xerrors.Errorf("comparing deadlines: %w", err)
+		}
+		if !changed {
+			return cs.summaries, nil
+		}
+	}
+
+	out, err := deadlineSummaries(mas)
+	if err != nil {
+		return nil, err
+	}
+
+	dc.c.Add(maddr, &cachedSummaries{state: mas, summaries: out})
+	return out, nil
+}
+
+func deadlineSummaries(mas miner.State) ([]api.DeadlineSummary, error) {
+	var out []api.DeadlineSummary
+	if err := mas.ForEachDeadline(func(dlIdx uint64, dl miner.Deadline) error {
+		ds := api.DeadlineSummary{Index: dlIdx}
+
+		posted, err := dl.PartitionsPoSted()
+		if err != nil {
+			return xerrors.Errorf("getting posted partitions: %w", err)
+		}
+		if ds.PostedPartitions, err = posted.Count(); err != nil {
+			return err
+		}
+
+		if err := dl.ForEachPartition(func(_ uint64, part miner.Partition) error {
+			ds.Partitions++
+
+			for _, s := range []struct {
+				get   func() (bitfield.BitField, error)
+				count *uint64
+			}{
+				{part.LiveSectors, &ds.LiveSectors},
+				{part.ActiveSectors, &ds.ActiveSectors},
+				{part.FaultySectors, &ds.FaultySectors},
+				{part.RecoveringSectors, &ds.RecoveringSectors},
+			} {
+				bf, err := s.get()
+				if err != nil {
+					return err
+				}
+				n, err := bf.Count()
+				if err != nil {
+					return err
+				}
+				*s.count += n
+			}
+
+			return nil
+		}); err != nil {
+			return xerrors.Errorf("deadline %d: %w", dlIdx, err)
+		}
+
+		out = append(out, ds)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func fieldNames(fields map[string]stateField) []string {
+	out := make([]string, 0, len(fields))
+	for name := range fields {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,113 @@
+package full
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-bitfield"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+// summaryState is a miner state with a single deadline of one partition,
+// deadlines being identified by their epoch
+type summaryState struct {
+	miner.State
+
+	epoch  int
+	posted []uint64
+	loads  *int
+}
+
+func (s *summaryState) DeadlinesChanged(other miner.State) (bool, error) {
+	return s.epoch != other.(*summaryState).epoch, nil
+}
+
+func (s *summaryState) ForEachDeadline(cb func(uint64, miner.Deadline) error) error {
+	*s.loads++
+	return cb(0, &summaryDeadline{posted: s.posted})
+}
+
+type summaryDeadline struct {
+	miner.Deadline
+
+	posted []uint64
+}
+
+func (d *summaryDeadline) PartitionsPoSted() (bitfield.BitField, error) {
+	return bitfield.NewFromSet(d.posted), nil
+}
+
+func (d *summaryDeadline) ForEachPartition(cb func(uint64, miner.Partition) error) error {
+	return cb(0, &summaryPartition{})
+}
+
+type summaryPartition struct {
+	miner.Partition
+}
+
+func (p *summaryPartition) LiveSectors() (bitfield.BitField, error) {
+	return bitfield.NewFromSet([]uint64{1, 2, 3}), nil
+}
+
+func (p *summaryPartition) ActiveSectors() (bitfield.BitField, error) {
+	return bitfield.NewFromSet([]uint64{1, 2}), nil
+}
+
+func (p *summaryPartition) FaultySectors() (bitfield.BitField, error) {
+	return bitfield.NewFromSet([]uint64{3}), nil
+}
+
+func (p *summaryPartition) RecoveringSectors() (bitfield.BitField, error) {
+	return bitfield.New(), nil
+}
+
+func TestDeadlineSummaryCache(t *testing.T) {
+	dc := NewDeadlineSummaryCache()
+	maddr, other := mock.Address(1000), mock.Address(1001)
+	var loads int
+
+	summary := func(posted uint64) []api.DeadlineSummary {
+		return []api.DeadlineSummary{{
+			Partitions:       1,
+			PostedPartitions: posted,
+			LiveSectors:      3,
+			ActiveSectors:    2,
+			FaultySectors:    1,
+		}}
+	}
+
+	// the partition was proven in the open deadline
+	open := &summaryState{epoch: 100, posted: []uint64{0}, loads: &loads}
+	out, err := dc.summaries(maddr, open)
+	require.NoError(t, err)
+	require.Equal(t, summary(1), out)
+	require.Equal(t, 1, loads)
+
+	// refreshing within the deadline hits the cache
+	out, err = dc.summaries(maddr, &summaryState{epoch: 100, posted: []uint64{0}, loads: &loads})
+	require.NoError(t, err)
+	require.Equal(t, summary(1), out)
+	require.Equal(t, 1, loads)
+
+	// the proofs are cleared when the deadline closes, which changes the
+	// deadlines and invalidates the cached summaries
+	rolled := &summaryState{epoch: 160, loads: &loads}
+	out, err = dc.summaries(maddr, rolled)
+	require.NoError(t, err)
+	require.Equal(t, summary(0), out)
+	require.Equal(t, 2, loads)
+
+	out, err = dc.summaries(maddr, &summaryState{epoch: 160, loads: &loads})
+	require.NoError(t, err)
+	require.Equal(t, summary(0), out)
+	require.Equal(t, 2, loads)
+
+	// miners are cached separately
+	_, err = dc.summaries(other, open)
+	require.NoError(t, err)
+	require.Equal(t, 3, loads)
+}
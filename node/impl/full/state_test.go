@@ -0,0 +1,59 @@
+package full
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+)
+
+func TestSectorNumbersFrom(t *testing.T) {
+	bf := bitfield.NewFromSet([]uint64{2, 3, 4, 10, 11, 20})
+
+	for _, tc := range []struct {
+		start uint64
+		n     int
+		out   []uint64
+	}{
+		{0, 2, []uint64{2, 3}},
+		{3, 3, []uint64{3, 4, 10}},
+		{5, 10, []uint64{10, 11, 20}},
+		{11, 1, []uint64{11}},
+		{21, 5, nil},
+	} {
+		out, err := sectorNumbersFrom(bf, tc.start, tc.n)
+		require.NoError(t, err)
+		require.Equal(t, tc.out, out, "start %d, n %d", tc.start, tc.n)
+	}
+}
+
+func TestSectorMatches(t *testing.T) {
+	si := &miner.SectorOnChainInfo{
+		SectorNumber: 1,
+		Activation:   100,
+		Expiration:   1000,
+	}
+
+	require.True(t, sectorMatches(&api.MinerSectorsQuery{}, si))
+	require.True(t, sectorMatches(&api.MinerSectorsQuery{ActivationMin: 100, ActivationMax: 100}, si))
+	require.False(t, sectorMatches(&api.MinerSectorsQuery{ActivationMin: 101}, si))
+	require.False(t, sectorMatches(&api.MinerSectorsQuery{ExpirationMax: 999}, si))
+	require.False(t, sectorMatches(&api.MinerSectorsQuery{WithDeals: true}, si))
+
+	si.DealIDs = []abi.DealID{5}
+	require.True(t, sectorMatches(&api.MinerSectorsQuery{WithDeals: true, ExpirationMin: 1000}, si))
+}
+
+func TestExtrapolate(t *testing.T) {
+	require.Equal(t, big.NewInt(150), extrapolate(big.NewInt(100), big.NewInt(50), 10, 10))
+	require.Equal(t, big.NewInt(125), extrapolate(big.NewInt(100), big.NewInt(50), 10, 5))
+	require.Equal(t, big.NewInt(100), extrapolate(big.NewInt(100), big.NewInt(50), 0, 5))
+	require.Equal(t, big.NewInt(100), extrapolate(big.NewInt(100), big.NewInt(50), 10, 0))
+	require.Equal(t, big.Zero(), extrapolate(big.NewInt(100), big.NewInt(200), 10, 20))
+}
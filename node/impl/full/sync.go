@@ -13,6 +13,7 @@ import (
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain"
 	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
+	"github.com/filecoin-project/lotus/chain/gen/slashfilter/slashsvc"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/vm"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
@@ -25,6 +26,8 @@ type SyncAPI struct {
 	Syncer      *chain.Syncer
 	PubSub      *pubsub.PubSub
 	NetName     dtypes.NetworkName
+
+	FaultDetector *slashsvc.Detector `optional:"true"`
 }
 
 func (a *SyncAPI) SyncState(ctx context.Context) (*api.SyncState, error) {
@@ -102,6 +105,17 @@ func (a *SyncAPI) SyncIncomingBlocks(ctx context.Context) (<-chan *types.BlockHe
 	return a.Syncer.IncomingBlocks(ctx)
 }
 
+func (a *SyncAPI) SyncValidationTiming(ctx context.Context) (api.SyncValidationTiming, error) {
+	return a.Syncer.ValidationTiming(), nil
+}
+
+func (a *SyncAPI) SyncConsensusFaults(ctx context.Context) ([]api.ConsensusFault, error) {
+	if a.FaultDetector == nil {
+		return nil, xerrors.Errorf("consensus fault detector not enabled, see FaultReporter.EnableConsensusFaultDetector in the config")
+	}
+	return a.FaultDetector.Faults()
+}
+
 func (a *SyncAPI) SyncCheckpoint(ctx context.Context, tsk types.TipSetKey) error {
 	log.Warnf("Marking tipset %s as checkpoint", tsk)
 	return a.Syncer.SyncCheckpoint(ctx, tsk)
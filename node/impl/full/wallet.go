@@ -3,6 +3,9 @@ package full
 import (
 	"context"
 
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
@@ -15,6 +18,12 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/wallet"
 	"github.com/filecoin-project/lotus/lib/sigs"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+var (
+	walletAddrLabelsKey = datastore.NewKey("/wallet/labels/addr")
+	walletMsgLabelsKey  = datastore.NewKey("/wallet/labels/msg")
 )
 
 type WalletAPI struct {
@@ -22,6 +31,7 @@ type WalletAPI struct {
 
 	StateManagerAPI stmgr.StateManagerAPI
 	Default         wallet.Default
+	MetadataDS      dtypes.MetadataDS
 	api.Wallet
 }
 
@@ -85,3 +95,57 @@ func (a *WalletAPI) WalletSetDefault(ctx context.Context, addr address.Address)
 func (a *WalletAPI) WalletValidateAddress(ctx context.Context, str string) (address.Address, error) {
 	return address.NewFromString(str)
 }
+
+func (a *WalletAPI) WalletAddressLabelSet(ctx context.Context, addr address.Address, label string) error {
+	return a.setLabel(walletAddrLabelsKey.ChildString(addr.String()), label)
+}
+
+func (a *WalletAPI) WalletMessageLabelSet(ctx context.Context, msg cid.Cid, label string) error {
+	return a.setLabel(walletMsgLabelsKey.ChildString(msg.String()), label)
+}
+
+func (a *WalletAPI) setLabel(k datastore.Key, label string) error {
+	if label == "" {
+		if err := a.MetadataDS.Delete(k); err != nil {
+			return xerrors.Errorf("removing label: %w", err)
+		}
+		return nil
+	}
+
+	if err := a.MetadataDS.Put(k, []byte(label)); err != nil {
+		return xerrors.Errorf("storing label: %w", err)
+	}
+	return nil
+}
+
+func (a *WalletAPI) WalletLabels(ctx context.Context) (*api.WalletLabels, error) {
+	out := &api.WalletLabels{}
+
+	var err error
+	if out.Addresses, err = a.listLabels(walletAddrLabelsKey); err != nil {
+		return nil, xerrors.Errorf("listing address labels: %w", err)
+	}
+	if out.Messages, err = a.listLabels(walletMsgLabelsKey); err != nil {
+		return nil, xerrors.Errorf("listing message labels: %w", err)
+	}
+
+	return out, nil
+}
+
+func (a *WalletAPI) listLabels(prefix datastore.Key) (map[string]string, error) {
+	res, err := a.MetadataDS.Query(query.Query{Prefix: prefix.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := res.Rest()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]string, len(entries))
+	for _, e := range entries {
+		out[datastore.RawKey(e.Key).BaseNamespace()] = string(e.Value)
+	}
+	return out, nil
+}
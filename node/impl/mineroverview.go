@@ -0,0 +1,232 @@
+package impl
+
+import (
+	"context"
+
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+	sealing "github.com/filecoin-project/lotus/extern/storage-sealing"
+)
+
+func (sm *StorageMinerAPI) MinerOverview(ctx context.Context) (*api.MinerOverview, error) {
+	maddr := address.Address(sm.Maddr)
+
+	head, err := sm.Full.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	mi, err := sm.Full.StateMinerInfo(ctx, maddr, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	out := &api.MinerOverview{
+		Miner:            maddr,
+		Height:           head.Height(),
+		Owner:            mi.Owner,
+		Worker:           mi.Worker,
+		ControlAddresses: mi.ControlAddresses,
+		SectorSize:       mi.SectorSize,
+	}
+
+	// a section failing, e.g. because of a slow chain node, doesn't fail the
+	// whole overview
+	for _, s := range []struct {
+		name string
+		load func() error
+	}{
+		{"power", func() error { return sm.overviewPower(ctx, out, head.Key()) }},
+		{"balances", func() error { return sm.overviewBalances(ctx, out, mi, head.Key()) }},
+		{"sectors", func() error { return sm.overviewSectors(ctx, out, head.Key()) }},
+		{"proving", func() error { return sm.overviewProving(ctx, out, head.Key()) }},
+		{"deals", func() error { return sm.overviewDeals(out) }},
+	} {
+		if err := s.load(); err != nil {
+			log.Warnw("loading miner overview", "section", s.name, "error", err)
+			if out.Errors == nil {
+				out.Errors = map[string]string{}
+			}
+			out.Errors[s.name] = err.Error()
+		}
+	}
+
+	if sm.Alerting != nil {
+		for _, a := range sm.Alerting.GetAlerts() {
+			if !a.Active {
+				continue
+			}
+			out.Alerts.Active++
+			if !a.Acked {
+				out.Alerts.Unacked++
+			}
+			out.Alerts.Types = append(out.Alerts.Types, a.Type.String())
+		}
+	}
+
+	return out, nil
+}
+
+func (sm *StorageMinerAPI) overviewPower(ctx context.Context, out *api.MinerOverview, tsk types.TipSetKey) error {
+	pow, err := sm.Full.StateMinerPower(ctx, out.Miner, tsk)
+	if err != nil {
+		return xerrors.Errorf("getting miner power: %w", err)
+	}
+
+	out.Power = api.MinerOverviewPower{
+		RawBytePower:           pow.MinerPower.RawBytePower,
+		QualityAdjPower:        pow.MinerPower.QualityAdjPower,
+		NetworkQualityAdjPower: pow.TotalPower.QualityAdjPower,
+		HasMinPower:            pow.HasMinPower,
+	}
+	return nil
+}
+
+func (sm *StorageMinerAPI) overviewBalances(ctx context.Context, out *api.MinerOverview, mi miner.MinerInfo, tsk types.TipSetKey) error {
+	mact, err := sm.Full.StateGetActor(ctx, out.Miner, tsk)
+	if err != nil {
+		return xerrors.Errorf("loading miner actor: %w", err)
+	}
+	mas, err := miner.Load(adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(sm.Full))), mact)
+	if err != nil {
+		return xerrors.Errorf("loading miner actor state: %w", err)
+	}
+
+	locked, err := mas.LockedFunds()
+	if err != nil {
+		return xerrors.Errorf("getting locked funds: %w", err)
+	}
+	avail, err := mas.AvailableBalance(mact.Balance)
+	if err != nil {
+		return xerrors.Errorf("getting available balance: %w", err)
+	}
+	debt, err := mas.FeeDebt()
+	if err != nil {
+		return xerrors.Errorf("getting fee debt: %w", err)
+	}
+
+	mb, err := sm.Full.StateMarketBalance(ctx, out.Miner, tsk)
+	if err != nil {
+		return xerrors.Errorf("getting market balance: %w", err)
+	}
+
+	b := api.MinerOverviewBalances{
+		Miner:             mact.Balance,
+		PreCommitDeposits: locked.PreCommitDeposits,
+		InitialPledge:     locked.InitialPledgeRequirement,
+		Vesting:           locked.VestingFunds,
+		Available:         avail,
+		FeeDebt:           debt,
+		MarketEscrow:      mb.Escrow,
+		MarketLocked:      mb.Locked,
+		Control:           big.Zero(),
+	}
+
+	if b.Owner, err = sm.Full.WalletBalance(ctx, mi.Owner); err != nil {
+		return xerrors.Errorf("getting owner balance: %w", err)
+	}
+	if b.Worker, err = sm.Full.WalletBalance(ctx, mi.Worker); err != nil {
+		return xerrors.Errorf("getting worker balance: %w", err)
+	}
+	for _, ca := range mi.ControlAddresses {
+		cb, err := sm.Full.WalletBalance(ctx, ca)
+		if err != nil {
+			return xerrors.Errorf("getting control address %s balance: %w", ca, err)
+		}
+		b.Control = big.Add(b.Control, cb)
+	}
+
+	out.Balances = b
+	return nil
+}
+
+func (sm *StorageMinerAPI) overviewSectors(ctx context.Context, out *api.MinerOverview, tsk types.TipSetKey) error {
+	counts, err := sm.Full.StateMinerSectorCount(ctx, out.Miner, tsk)
+	if err != nil {
+		return xerrors.Errorf("getting sector counts: %w", err)
+	}
+	out.Sectors.Live = counts.Live
+	out.Sectors.Active = counts.Active
+	out.Sectors.Faulty = counts.Faulty
+
+	recoveries, err := sm.Full.StateMinerRecoveries(ctx, out.Miner, tsk)
+	if err != nil {
+		return xerrors.Errorf("getting recoveries: %w", err)
+	}
+	if out.Sectors.Recovering, err = recoveries.Count(); err != nil {
+		return xerrors.Errorf("counting recoveries: %w", err)
+	}
+
+	if sm.Miner == nil {
+		return nil // sealing subsystem not enabled
+	}
+
+	pipeline, err := sm.SectorsSummary(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting sealing pipeline: %w", err)
+	}
+	out.Sectors.Pipeline = pipeline
+	for st, n := range pipeline {
+		if sealing.IsFailedState(sealing.SectorState(st)) {
+			out.Sectors.Failed += n
+		}
+	}
+
+	return nil
+}
+
+func (sm *StorageMinerAPI) overviewProving(ctx context.Context, out *api.MinerOverview, tsk types.TipSetKey) error {
+	dl, err := sm.Full.StateMinerProvingDeadline(ctx, out.Miner, tsk)
+	if err != nil {
+		return xerrors.Errorf("getting proving deadline: %w", err)
+	}
+
+	out.Proving = api.MinerOverviewProving{
+		PeriodStart: dl.PeriodStart,
+		Deadline:    dl.Index,
+		Open:        dl.Open,
+		Close:       dl.Close,
+	}
+	return nil
+}
+
+func (sm *StorageMinerAPI) overviewDeals(out *api.MinerOverview) error {
+	if sm.StorageProvider == nil {
+		return nil // markets subsystem not enabled
+	}
+
+	deals, err := sm.StorageProvider.ListLocalDeals()
+	if err != nil {
+		return xerrors.Errorf("listing deals: %w", err)
+	}
+
+	d := &api.MinerOverviewDeals{}
+	for _, deal := range deals {
+		if deal.State == storagemarket.StorageDealError {
+			d.Failed++
+			continue
+		}
+
+		d.Total++
+		if deal.State == storagemarket.StorageDealActive {
+			d.Active++
+			d.ActiveBytes += deal.Proposal.PieceSize
+			if deal.Proposal.VerifiedDeal {
+				d.Verified++
+			}
+		}
+	}
+
+	out.Deals = d
+	return nil
+}
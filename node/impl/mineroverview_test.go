@@ -0,0 +1,107 @@
+package impl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/dline"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+// overviewAPI serves the sectors and proving sections of the overview, the
+// power and balances sections fail to load
+type overviewAPI struct {
+	api.FullNode
+
+	head *types.TipSet
+}
+
+func (a *overviewAPI) ChainHead(context.Context) (*types.TipSet, error) {
+	return a.head, nil
+}
+
+func (a *overviewAPI) StateMinerInfo(context.Context, address.Address, types.TipSetKey) (miner.MinerInfo, error) {
+	return miner.MinerInfo{Owner: testOwner, Worker: testWorker, SectorSize: 2048}, nil
+}
+
+func (a *overviewAPI) StateMinerPower(context.Context, address.Address, types.TipSetKey) (*api.MinerPower, error) {
+	return nil, xerrors.New("power unavailable")
+}
+
+func (a *overviewAPI) StateGetActor(context.Context, address.Address, types.TipSetKey) (*types.Actor, error) {
+	return nil, xerrors.New("actor unavailable")
+}
+
+func (a *overviewAPI) StateMinerSectorCount(context.Context, address.Address, types.TipSetKey) (api.MinerSectors, error) {
+	return api.MinerSectors{Live: 10, Active: 8, Faulty: 2}, nil
+}
+
+func (a *overviewAPI) StateMinerRecoveries(context.Context, address.Address, types.TipSetKey) (bitfield.BitField, error) {
+	return bitfield.NewFromSet([]uint64{3}), nil
+}
+
+func (a *overviewAPI) StateMinerProvingDeadline(context.Context, address.Address, types.TipSetKey) (*dline.Info, error) {
+	return &dline.Info{PeriodStart: 100, Index: 2, Open: 220, Close: 280}, nil
+}
+
+func TestMinerOverview(t *testing.T) {
+	head, err := types.NewTipSet([]*types.BlockHeader{mock.MkBlock(nil, 1, 1)})
+	require.NoError(t, err)
+
+	al := alerting.NewAlertingSystem(journal.NilJournal())
+	acked := al.AddAlertType("wdpost", "deadline-1")
+	raised := al.AddAlertType("wdpost", "deadline-2")
+	resolved := al.AddAlertType("wdpost", "deadline-3")
+	al.AddAlertType("wdpost", "deadline-4") // never raised
+	for _, at := range []alerting.AlertType{acked, raised, resolved} {
+		al.Raise(at, "missed")
+	}
+	require.NoError(t, al.Ack(acked))
+	al.Resolve(resolved, "ok")
+
+	sm := &StorageMinerAPI{
+		Maddr:    dtypes.MinerAddress(testMiner),
+		Full:     &overviewAPI{head: head},
+		Alerting: al,
+	}
+
+	out, err := sm.MinerOverview(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, testMiner, out.Miner)
+	require.Equal(t, head.Height(), out.Height)
+	require.Equal(t, testOwner, out.Owner)
+	require.Equal(t, testWorker, out.Worker)
+
+	// failing sections are reported, without failing the others
+	require.Len(t, out.Errors, 2)
+	require.Contains(t, out.Errors["power"], "power unavailable")
+	require.Contains(t, out.Errors["balances"], "actor unavailable")
+	require.Equal(t, api.MinerOverviewPower{}, out.Power)
+	require.Equal(t, api.MinerOverviewBalances{}, out.Balances)
+
+	require.Equal(t, api.MinerOverviewSectors{Live: 10, Active: 8, Faulty: 2, Recovering: 1}, out.Sectors)
+	require.Equal(t, api.MinerOverviewProving{PeriodStart: 100, Deadline: 2, Open: 220, Close: 280}, out.Proving)
+
+	// the sealing and markets subsystems aren't enabled
+	require.Nil(t, out.Sectors.Pipeline)
+	require.Nil(t, out.Deals)
+
+	require.Equal(t, api.MinerOverviewAlerts{
+		Active:  2,
+		Unacked: 1,
+		Types:   []string{acked.String(), raised.String()},
+	}, out.Alerts)
+}
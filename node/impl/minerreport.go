@@ -0,0 +1,441 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+	"github.com/filecoin-project/lotus/journal"
+)
+
+// reportPowerSampleInterval is the number of epochs between the samples of
+// miner power used to compute the expected number of wins, an hour
+const reportPowerSampleInterval = builtin.EpochsInDay / 24
+
+// penaltyMethods are the miner actor methods which can burn funds of the
+// miner. Messages calling them are replayed to account for penalties.
+var penaltyMethods = map[abi.MethodNum]struct{}{
+	miner.Methods.TerminateSectors:       {},
+	miner.Methods.DeclareFaultsRecovered: {},
+	miner.Methods.RepayDebt:              {},
+	miner.Methods.PreCommitSectorBatch:   {},
+	miner.Methods.ProveCommitAggregate:   {},
+}
+
+func (sm *StorageMinerAPI) MinerReport(ctx context.Context, maddr address.Address, from, to abi.ChainEpoch) (*api.MinerReport, error) {
+	if from > to {
+		return nil, xerrors.Errorf("range start %d is after its end %d", from, to)
+	}
+
+	head, err := sm.Full.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+	if to > head.Height() {
+		return nil, xerrors.Errorf("range end %d is after the chain head %d", to, head.Height())
+	}
+
+	toTs, err := sm.Full.ChainGetTipSetByHeight(ctx, to, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting tipset at %d: %w", to, err)
+	}
+	fromTs, err := sm.Full.ChainGetTipSetByHeight(ctx, from, toTs.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting tipset at %d: %w", from, err)
+	}
+
+	out := &api.MinerReport{
+		Miner: maddr,
+		From:  from,
+		To:    to,
+		PoSt: api.MinerReportPoSt{
+			Scheduler: map[string]int{},
+		},
+		Faults: api.MinerReportFaults{
+			Penalties: big.Zero(),
+			FeeDebt:   big.Zero(),
+		},
+		Onboarded: api.MinerReportOnboarding{
+			RawPower: big.Zero(),
+		},
+	}
+
+	if err := sm.reportChain(ctx, out, fromTs, toTs); err != nil {
+		return nil, err
+	}
+	if err := sm.reportExpectedWins(ctx, out, toTs); err != nil {
+		return nil, err
+	}
+	if err := sm.reportState(ctx, out, fromTs, toTs); err != nil {
+		return nil, err
+	}
+	if err := sm.reportJournal(ctx, out, fromTs, toTs); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// reportChain walks the tipsets in the range, accounting for the blocks mined
+// by the miner and for the messages it sent to its actor
+func (sm *StorageMinerAPI) reportChain(ctx context.Context, out *api.MinerReport, fromTs, toTs *types.TipSet) error {
+	maddr := out.Miner
+
+	mact, err := sm.Full.StateGetActor(ctx, maddr, toTs.Key())
+	if err != nil {
+		return xerrors.Errorf("loading miner actor: %w", err)
+	}
+
+	senders, err := sm.minerSenders(ctx, maddr, toTs.Key())
+	if err != nil {
+		return err
+	}
+
+	gas := map[abi.MethodNum]*api.MinerReportGas{}
+	var delays abi.ChainEpoch
+
+	err = sm.walkMessages(ctx, fromTs, toTs, func(ts *types.TipSet) {
+		for _, b := range ts.Blocks() {
+			if b.Miner != maddr {
+				continue
+			}
+			out.Blocks.Won++
+			if b.ElectionProof != nil {
+				out.Blocks.WinCount += b.ElectionProof.WinCount
+			}
+		}
+	}, func(mc *minerMessage) error {
+		if mc.Message.To != maddr {
+			return nil
+		}
+		if _, ok := senders[mc.Message.From]; !ok {
+			return nil
+		}
+
+		g, ok := gas[mc.Message.Method]
+		if !ok {
+			g = &api.MinerReportGas{
+				Method: methodName(mact.Code, mc.Message.Method),
+				Cost:   big.Zero(),
+			}
+			gas[mc.Message.Method] = g
+		}
+		g.Messages++
+		g.GasUsed += mc.Receipt.GasUsed
+		g.Cost = big.Add(g.Cost, mc.Cost)
+
+		if mc.Message.Method == miner.Methods.SubmitWindowedPoSt {
+			if !mc.Receipt.ExitCode.IsSuccess() {
+				out.PoSt.Failed++
+				return nil
+			}
+			out.PoSt.Submitted++
+
+			// proofs can only be submitted for the current deadline
+			di, err := sm.Full.StateMinerProvingDeadline(ctx, maddr, mc.Included.Key())
+			if err != nil {
+				return xerrors.Errorf("getting proving deadline at %d: %w", mc.Included.Height(), err)
+			}
+			delay := mc.Included.Height() - di.Open
+			delays += delay
+			if delay > out.PoSt.MaxDelay {
+				out.PoSt.MaxDelay = delay
+			}
+		}
+
+		if _, ok := penaltyMethods[mc.Message.Method]; ok && mc.Receipt.ExitCode.IsSuccess() {
+			res, err := sm.Full.StateReplay(ctx, mc.Included.Key(), mc.Cid)
+			if err != nil {
+				return xerrors.Errorf("replaying message %s: %w", mc.Cid, err)
+			}
+			out.Faults.Penalties = big.Add(out.Faults.Penalties, burntBy(maddr, res.ExecutionTrace))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if out.PoSt.Submitted > 0 {
+		out.PoSt.AvgDelay = float64(delays) / float64(out.PoSt.Submitted)
+	}
+
+	for _, g := range gas {
+		out.Gas = append(out.Gas, *g)
+	}
+	sort.Slice(out.Gas, func(i, j int) bool {
+		return out.Gas[i].Cost.GreaterThan(out.Gas[j].Cost)
+	})
+
+	return nil
+}
+
+// minerMessage is a message executed on chain, along with the gas it cost
+// its sender
+type minerMessage struct {
+	Cid     cid.Cid
+	Message *types.Message
+	Receipt *types.MessageReceipt
+
+	// Included is the tipset the message was included in, Executed the
+	// tipset it was executed in
+	Included *types.TipSet
+	Executed *types.TipSet
+
+	// Cost includes the burnt base fee, the overestimation burn and the tip
+	// paid to the block miner
+	Cost abi.TokenAmount
+}
+
+// walkMessages walks the tipsets in the range from the most recent one, and
+// the messages executed in each of them
+func (sm *StorageMinerAPI) walkMessages(ctx context.Context, fromTs, toTs *types.TipSet, tsCb func(*types.TipSet), msgCb func(*minerMessage) error) error {
+	ts := toTs
+	for ts.Height() >= fromTs.Height() && ts.Height() > 0 {
+		if tsCb != nil {
+			tsCb(ts)
+		}
+
+		pts, err := sm.Full.ChainGetTipSet(ctx, ts.Parents())
+		if err != nil {
+			return xerrors.Errorf("loading parent of tipset at %d: %w", ts.Height(), err)
+		}
+
+		// messages included in the parent tipset, and executed in this one
+		msgs, err := sm.Full.ChainGetParentMessages(ctx, ts.Cids()[0])
+		if err != nil {
+			return xerrors.Errorf("getting messages executed at %d: %w", ts.Height(), err)
+		}
+		rcts, err := sm.Full.ChainGetParentReceipts(ctx, ts.Cids()[0])
+		if err != nil {
+			return xerrors.Errorf("getting receipts of messages executed at %d: %w", ts.Height(), err)
+		}
+		if len(msgs) != len(rcts) {
+			return xerrors.Errorf("got %d messages and %d receipts at %d", len(msgs), len(rcts), ts.Height())
+		}
+
+		baseFee := ts.Blocks()[0].ParentBaseFee
+		for i, m := range msgs {
+			gout := vm.ComputeGasOutputs(rcts[i].GasUsed, m.Message.GasLimit, baseFee, m.Message.GasFeeCap, m.Message.GasPremium, true)
+			err := msgCb(&minerMessage{
+				Cid:      m.Cid,
+				Message:  m.Message,
+				Receipt:  rcts[i],
+				Included: pts,
+				Executed: ts,
+				Cost:     big.Sum(gout.BaseFeeBurn, gout.OverEstimationBurn, gout.MinerTip),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		ts = pts
+	}
+
+	return nil
+}
+
+// minerSenders returns the addresses which send messages on behalf of the
+// miner, both as ID and as key addresses. They map to the key address, or to
+// the ID address for actors without a key, like multisigs.
+func (sm *StorageMinerAPI) minerSenders(ctx context.Context, maddr address.Address, tsk types.TipSetKey) (map[address.Address]address.Address, error) {
+	mi, err := sm.Full.StateMinerInfo(ctx, maddr, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	out := map[address.Address]address.Address{}
+	for _, addr := range append([]address.Address{mi.Owner, mi.Worker}, mi.ControlAddresses...) {
+		out[addr] = addr
+
+		if ka, err := sm.Full.StateAccountKey(ctx, addr, tsk); err == nil {
+			out[addr] = ka
+			out[ka] = ka
+		}
+	}
+	return out, nil
+}
+
+// reportExpectedWins samples the power of the miner over the range
+func (sm *StorageMinerAPI) reportExpectedWins(ctx context.Context, out *api.MinerReport, toTs *types.TipSet) error {
+	for epoch := out.From; epoch <= out.To; epoch += reportPowerSampleInterval {
+		ts, err := sm.Full.ChainGetTipSetByHeight(ctx, epoch, toTs.Key())
+		if err != nil {
+			return xerrors.Errorf("getting tipset at %d: %w", epoch, err)
+		}
+
+		pow, err := sm.Full.StateMinerPower(ctx, out.Miner, ts.Key())
+		if err != nil {
+			return xerrors.Errorf("getting miner power at %d: %w", epoch, err)
+		}
+		if !pow.HasMinPower || pow.TotalPower.QualityAdjPower.IsZero() {
+			continue
+		}
+
+		epochs := reportPowerSampleInterval
+		if rem := out.To - epoch + 1; rem < epochs {
+			epochs = rem
+		}
+
+		qpercI := types.BigDiv(types.BigMul(pow.MinerPower.QualityAdjPower, types.NewInt(1000000)), pow.TotalPower.QualityAdjPower)
+		out.Blocks.Expected += float64(types.BigMul(qpercI, types.NewInt(build.BlocksPerEpoch)).Int64()) / 1000000 * float64(epochs)
+	}
+
+	return nil
+}
+
+// reportState compares the state of the miner actor at both ends of the range
+func (sm *StorageMinerAPI) reportState(ctx context.Context, out *api.MinerReport, fromTs, toTs *types.TipSet) error {
+	faults, err := sm.Full.StateMinerFaults(ctx, out.Miner, fromTs.Key())
+	if err != nil {
+		return xerrors.Errorf("getting faults at %d: %w", fromTs.Height(), err)
+	}
+	if out.Faults.FaultyStart, err = faults.Count(); err != nil {
+		return xerrors.Errorf("counting faults: %w", err)
+	}
+
+	faults, err = sm.Full.StateMinerFaults(ctx, out.Miner, toTs.Key())
+	if err != nil {
+		return xerrors.Errorf("getting faults at %d: %w", toTs.Height(), err)
+	}
+	if out.Faults.FaultyEnd, err = faults.Count(); err != nil {
+		return xerrors.Errorf("counting faults: %w", err)
+	}
+
+	mact, err := sm.Full.StateGetActor(ctx, out.Miner, toTs.Key())
+	if err != nil {
+		return xerrors.Errorf("loading miner actor: %w", err)
+	}
+	mas, err := miner.Load(adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(sm.Full))), mact)
+	if err != nil {
+		return xerrors.Errorf("loading miner actor state: %w", err)
+	}
+	if out.Faults.FeeDebt, err = mas.FeeDebt(); err != nil {
+		return xerrors.Errorf("getting fee debt: %w", err)
+	}
+
+	ssize, err := sm.ActorSectorSize(ctx, out.Miner)
+	if err != nil {
+		return xerrors.Errorf("getting sector size: %w", err)
+	}
+
+	sectors, err := sm.Full.StateMinerSectors(ctx, out.Miner, nil, toTs.Key())
+	if err != nil {
+		return xerrors.Errorf("listing sectors: %w", err)
+	}
+	for _, s := range sectors {
+		if s.Activation < out.From || s.Activation > out.To {
+			continue
+		}
+		out.Onboarded.Sectors++
+		if len(s.DealIDs) > 0 {
+			out.Onboarded.WithDeals++
+		}
+		out.Onboarded.RawPower = big.Add(out.Onboarded.RawPower, big.NewInt(int64(ssize)))
+	}
+
+	return nil
+}
+
+// reportJournal accounts for the window PoSt events recorded by this node
+// while the tipsets in the range were mined
+func (sm *StorageMinerAPI) reportJournal(ctx context.Context, out *api.MinerReport, fromTs, toTs *types.TipSet) error {
+	start := time.Unix(int64(fromTs.MinTimestamp()), 0)
+	end := time.Unix(int64(toTs.MinTimestamp()+build.BlockDelaySecs), 0)
+
+	evts, err := sm.JournalQuery(ctx, []journal.EventType{{System: "wdpost"}}, start, end, 0)
+	if err != nil {
+		log.Warnw("miner report: not accounting for journal events", "error", err)
+		return nil
+	}
+
+	for _, evt := range evts {
+		b, err := json.Marshal(evt.Data)
+		if err != nil {
+			return xerrors.Errorf("marshaling journal event: %w", err)
+		}
+
+		var we struct {
+			Miner        address.Address
+			State        string
+			Declarations []struct {
+				Sectors bitfield.BitField
+			}
+		}
+		if err := json.Unmarshal(b, &we); err != nil {
+			return xerrors.Errorf("unmarshaling %s:%s journal event: %w", evt.System, evt.Event, err)
+		}
+
+		// events recorded before they were tagged with the miner address
+		// come from the primary miner
+		if we.Miner == address.Undef {
+			we.Miner = address.Address(sm.Maddr)
+		}
+		if we.Miner != out.Miner {
+			continue
+		}
+
+		var declared uint64
+		for _, decl := range we.Declarations {
+			n, err := decl.Sectors.Count()
+			if err != nil {
+				return xerrors.Errorf("counting declared sectors: %w", err)
+			}
+			declared += n
+		}
+
+		switch evt.Event {
+		case "scheduler":
+			out.PoSt.Scheduler[we.State]++
+		case "faults_processed":
+			out.Faults.DeclaredFaulty += declared
+		case "recoveries_processed":
+			out.Faults.DeclaredRecovered += declared
+		}
+	}
+
+	return nil
+}
+
+// burntBy sums the funds sent by the actor to the burnt funds actor in the
+// execution trace of a message
+func burntBy(actor address.Address, et types.ExecutionTrace) abi.TokenAmount {
+	out := big.Zero()
+	if et.Msg != nil && et.MsgRct != nil && et.MsgRct.ExitCode.IsSuccess() &&
+		et.Msg.From == actor && et.Msg.To == builtin.BurntFundsActorAddr {
+		out = big.Add(out, et.Msg.Value)
+	}
+	for _, sub := range et.Subcalls {
+		out = big.Add(out, burntBy(actor, sub))
+	}
+	return out
+}
+
+func methodName(code cid.Cid, method abi.MethodNum) string {
+	if m, ok := stmgr.MethodsMap[code][method]; ok {
+		return m.Name
+	}
+	return fmt.Sprintf("%d", method)
+}
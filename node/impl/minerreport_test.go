@@ -0,0 +1,286 @@
+package impl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/dline"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	builtin5 "github.com/filecoin-project/specs-actors/v5/actors/builtin"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+var (
+	testMiner  = mock.Address(1000)
+	testOwner  = mock.Address(100)
+	testWorker = mock.Address(101)
+	testOther  = mock.Address(200)
+
+	// key addresses of the owner and worker
+	testOwnerKey  = mock.Address(1100)
+	testWorkerKey = mock.Address(1101)
+)
+
+// testBaseFee and testPremium make a message cost 110 per gas unit, with its
+// gas limit equal to the gas used
+const (
+	testBaseFee = 100
+	testPremium = 10
+)
+
+// testMsg is a message executed at an epoch
+type testMsg struct {
+	Exec    abi.ChainEpoch
+	From    address.Address
+	To      address.Address
+	Method  abi.MethodNum
+	GasUsed int64
+	Exit    exitcode.ExitCode
+	// Burnt is burnt by the miner actor when executing the message
+	Burnt int64
+}
+
+func testCost(gasUsed int64) abi.TokenAmount {
+	return big.NewInt(gasUsed * (testBaseFee + testPremium))
+}
+
+// reportChainAPI is a chain of tipsets at each epoch, without null rounds,
+// each holding one block. The blocks at the epochs in won are mined by the
+// test miner.
+type reportChainAPI struct {
+	api.FullNode
+
+	tipsets []*types.TipSet
+	byKey   map[types.TipSetKey]*types.TipSet
+	byBlock map[cid.Cid]*types.TipSet
+
+	msgs     map[abi.ChainEpoch][]api.Message
+	receipts map[abi.ChainEpoch][]*types.MessageReceipt
+	replays  map[cid.Cid]*api.InvocResult
+}
+
+// newReportChain returns a chain up to the height, blocks being mined every
+// six hours so that four tipsets are mined a day
+func newReportChain(t *testing.T, height abi.ChainEpoch, won map[abi.ChainEpoch]int64, msgs []testMsg) *reportChainAPI {
+	a := &reportChainAPI{
+		byKey:    map[types.TipSetKey]*types.TipSet{},
+		byBlock:  map[cid.Cid]*types.TipSet{},
+		msgs:     map[abi.ChainEpoch][]api.Message{},
+		receipts: map[abi.ChainEpoch][]*types.MessageReceipt{},
+		replays:  map[cid.Cid]*api.InvocResult{},
+	}
+
+	var parent *types.TipSet
+	for h := abi.ChainEpoch(0); h <= height; h++ {
+		blk := mock.MkBlock(parent, 1, uint64(h))
+		blk.Timestamp = uint64(h) * uint64((6 * time.Hour).Seconds())
+		blk.ParentBaseFee = types.NewInt(testBaseFee)
+		if wc, ok := won[h]; ok {
+			blk.Miner = testMiner
+			blk.ElectionProof.WinCount = wc
+		}
+
+		ts, err := types.NewTipSet([]*types.BlockHeader{blk})
+		require.NoError(t, err)
+		a.tipsets = append(a.tipsets, ts)
+		a.byKey[ts.Key()] = ts
+		a.byBlock[ts.Cids()[0]] = ts
+		parent = ts
+	}
+
+	for nonce, m := range msgs {
+		msg := &types.Message{
+			From:       m.From,
+			To:         m.To,
+			Method:     m.Method,
+			Nonce:      uint64(nonce),
+			Value:      big.Zero(),
+			GasLimit:   m.GasUsed,
+			GasFeeCap:  types.NewInt(testBaseFee + testPremium),
+			GasPremium: types.NewInt(testPremium),
+		}
+		a.msgs[m.Exec] = append(a.msgs[m.Exec], api.Message{Cid: msg.Cid(), Message: msg})
+		a.receipts[m.Exec] = append(a.receipts[m.Exec], &types.MessageReceipt{ExitCode: m.Exit, GasUsed: m.GasUsed})
+
+		if m.Burnt > 0 {
+			a.replays[msg.Cid()] = &api.InvocResult{ExecutionTrace: types.ExecutionTrace{
+				Msg:    msg,
+				MsgRct: &types.MessageReceipt{},
+				Subcalls: []types.ExecutionTrace{{
+					Msg:    &types.Message{From: m.To, To: builtin.BurntFundsActorAddr, Value: big.NewInt(m.Burnt)},
+					MsgRct: &types.MessageReceipt{},
+				}},
+			}}
+		}
+	}
+
+	return a
+}
+
+func (a *reportChainAPI) ChainHead(context.Context) (*types.TipSet, error) {
+	return a.tipsets[len(a.tipsets)-1], nil
+}
+
+func (a *reportChainAPI) ChainGetTipSetByHeight(_ context.Context, h abi.ChainEpoch, _ types.TipSetKey) (*types.TipSet, error) {
+	if h < 0 || int(h) >= len(a.tipsets) {
+		return nil, xerrors.Errorf("no tipset at %d", h)
+	}
+	return a.tipsets[h], nil
+}
+
+func (a *reportChainAPI) ChainGetTipSet(_ context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
+	ts, ok := a.byKey[tsk]
+	if !ok {
+		return nil, xerrors.Errorf("tipset %s not found", tsk)
+	}
+	return ts, nil
+}
+
+func (a *reportChainAPI) ChainGetParentMessages(_ context.Context, blk cid.Cid) ([]api.Message, error) {
+	return a.msgs[a.byBlock[blk].Height()], nil
+}
+
+func (a *reportChainAPI) ChainGetParentReceipts(_ context.Context, blk cid.Cid) ([]*types.MessageReceipt, error) {
+	return a.receipts[a.byBlock[blk].Height()], nil
+}
+
+func (a *reportChainAPI) StateMinerInfo(context.Context, address.Address, types.TipSetKey) (miner.MinerInfo, error) {
+	return miner.MinerInfo{Owner: testOwner, Worker: testWorker}, nil
+}
+
+func (a *reportChainAPI) StateAccountKey(_ context.Context, addr address.Address, _ types.TipSetKey) (address.Address, error) {
+	switch addr {
+	case testOwner:
+		return testOwnerKey, nil
+	case testWorker:
+		return testWorkerKey, nil
+	}
+	return address.Undef, xerrors.Errorf("%s isn't an account", addr)
+}
+
+func (a *reportChainAPI) StateGetActor(context.Context, address.Address, types.TipSetKey) (*types.Actor, error) {
+	return &types.Actor{Code: builtin5.StorageMinerActorCodeID, Balance: big.Zero()}, nil
+}
+
+// StateMinerProvingDeadline returns deadlines of ten epochs
+func (a *reportChainAPI) StateMinerProvingDeadline(ctx context.Context, _ address.Address, tsk types.TipSetKey) (*dline.Info, error) {
+	ts, err := a.ChainGetTipSet(ctx, tsk)
+	if err != nil {
+		return nil, err
+	}
+	h := ts.Height()
+	return &dline.Info{CurrentEpoch: h, Index: uint64(h / 10), Open: h - h%10}, nil
+}
+
+func (a *reportChainAPI) StateReplay(_ context.Context, _ types.TipSetKey, mc cid.Cid) (*api.InvocResult, error) {
+	res, ok := a.replays[mc]
+	if !ok {
+		return &api.InvocResult{ExecutionTrace: types.ExecutionTrace{MsgRct: &types.MessageReceipt{}}}, nil
+	}
+	return res, nil
+}
+
+func TestReportChain(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		won  map[abi.ChainEpoch]int64
+		msgs []testMsg
+
+		from, to abi.ChainEpoch
+		blocks   api.MinerReportBlocks
+		post     api.MinerReportPoSt
+		gas      []api.MinerReportGas
+		penalty  int64
+	}{{
+		name: "blocks",
+		won:  map[abi.ChainEpoch]int64{2: 1, 5: 3, 9: 1},
+		from: 3, to: 10,
+		// the block before the range isn't counted
+		blocks: api.MinerReportBlocks{Won: 2, WinCount: 4},
+	}, {
+		name: "post",
+		msgs: []testMsg{
+			// included at 11, in the deadline opened at 10
+			{Exec: 12, From: testWorker, To: testMiner, Method: miner.Methods.SubmitWindowedPoSt, GasUsed: 100},
+			// included at 25
+			{Exec: 26, From: testWorkerKey, To: testMiner, Method: miner.Methods.SubmitWindowedPoSt, GasUsed: 200},
+			{Exec: 27, From: testWorker, To: testMiner, Method: miner.Methods.SubmitWindowedPoSt, GasUsed: 50, Exit: exitcode.ErrIllegalArgument},
+		},
+		from: 1, to: 30,
+		post: api.MinerReportPoSt{Submitted: 2, Failed: 1, AvgDelay: 3, MaxDelay: 5},
+		gas: []api.MinerReportGas{
+			{Method: "SubmitWindowedPoSt", Messages: 3, GasUsed: 350, Cost: testCost(350)},
+		},
+	}, {
+		name: "gas",
+		msgs: []testMsg{
+			{Exec: 2, From: testWorker, To: testMiner, Method: miner.Methods.PreCommitSector, GasUsed: 100},
+			{Exec: 3, From: testWorker, To: testMiner, Method: miner.Methods.PreCommitSector, GasUsed: 100},
+			{Exec: 4, From: testOwnerKey, To: testMiner, Method: miner.Methods.ProveCommitSector, GasUsed: 500},
+			// not sent by the miner, or not to its actor
+			{Exec: 4, From: testOther, To: testMiner, Method: miner.Methods.ProveCommitSector, GasUsed: 500},
+			{Exec: 5, From: testWorker, To: testOther, Method: 0, GasUsed: 500},
+			// out of the range
+			{Exec: 9, From: testWorker, To: testMiner, Method: miner.Methods.PreCommitSector, GasUsed: 100},
+		},
+		from: 1, to: 8,
+		// most expensive first
+		gas: []api.MinerReportGas{
+			{Method: "ProveCommitSector", Messages: 1, GasUsed: 500, Cost: testCost(500)},
+			{Method: "PreCommitSector", Messages: 2, GasUsed: 200, Cost: testCost(200)},
+		},
+	}, {
+		name: "penalties",
+		msgs: []testMsg{
+			{Exec: 2, From: testWorker, To: testMiner, Method: miner.Methods.TerminateSectors, GasUsed: 300, Burnt: 1000},
+			{Exec: 3, From: testWorker, To: testMiner, Method: miner.Methods.DeclareFaultsRecovered, GasUsed: 200, Burnt: 20},
+			// failed messages don't burn anything
+			{Exec: 4, From: testWorker, To: testMiner, Method: miner.Methods.RepayDebt, GasUsed: 100, Burnt: 300, Exit: exitcode.ErrInsufficientFunds},
+		},
+		from: 1, to: 8,
+		gas: []api.MinerReportGas{
+			{Method: "TerminateSectors", Messages: 1, GasUsed: 300, Cost: testCost(300)},
+			{Method: "DeclareFaultsRecovered", Messages: 1, GasUsed: 200, Cost: testCost(200)},
+			{Method: "RepayDebt", Messages: 1, GasUsed: 100, Cost: testCost(100)},
+		},
+		penalty: 1020,
+	}} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			a := newReportChain(t, 40, tc.won, tc.msgs)
+			sm := &StorageMinerAPI{Full: a}
+
+			out := &api.MinerReport{
+				Miner: testMiner,
+				From:  tc.from,
+				To:    tc.to,
+				PoSt: api.MinerReportPoSt{
+					Scheduler: map[string]int{},
+				},
+				Faults: api.MinerReportFaults{
+					Penalties: big.Zero(),
+				},
+			}
+			require.NoError(t, sm.reportChain(context.Background(), out, a.tipsets[tc.from], a.tipsets[tc.to]))
+
+			require.Equal(t, tc.blocks, out.Blocks)
+			tc.post.Scheduler = map[string]int{}
+			require.Equal(t, tc.post, out.PoSt)
+			require.Equal(t, big.NewInt(tc.penalty), out.Faults.Penalties)
+			require.Equal(t, tc.gas, out.Gas)
+		})
+	}
+}
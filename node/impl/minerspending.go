@@ -0,0 +1,151 @@
+package impl
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/market"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+type spendingKey struct {
+	day      string
+	addr     address.Address
+	category string
+}
+
+func (sm *StorageMinerAPI) ActorSpending(ctx context.Context, maddr address.Address, from, to abi.ChainEpoch) (*api.MinerSpending, error) {
+	if from > to {
+		return nil, xerrors.Errorf("range start %d is after its end %d", from, to)
+	}
+
+	head, err := sm.Full.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+	if to > head.Height() {
+		return nil, xerrors.Errorf("range end %d is after the chain head %d", to, head.Height())
+	}
+
+	toTs, err := sm.Full.ChainGetTipSetByHeight(ctx, to, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting tipset at %d: %w", to, err)
+	}
+	fromTs, err := sm.Full.ChainGetTipSetByHeight(ctx, from, toTs.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting tipset at %d: %w", from, err)
+	}
+
+	senders, err := sm.minerSenders(ctx, maddr, toTs.Key())
+	if err != nil {
+		return nil, err
+	}
+
+	entries := map[spendingKey]*api.SpendingEntry{}
+	deadlines := map[uint64]*api.DeadlineSpending{}
+
+	err = sm.walkMessages(ctx, fromTs, toTs, nil, func(mc *minerMessage) error {
+		sender, ok := senders[mc.Message.From]
+		if !ok {
+			return nil
+		}
+
+		key := spendingKey{
+			day:      time.Unix(int64(mc.Included.MinTimestamp()), 0).UTC().Format("2006-01-02"),
+			addr:     sender,
+			category: spendingCategory(maddr, mc.Message),
+		}
+		e, ok := entries[key]
+		if !ok {
+			e = &api.SpendingEntry{
+				Day:      key.day,
+				Address:  key.addr,
+				Category: key.category,
+				Spent:    big.Zero(),
+			}
+			entries[key] = e
+		}
+		e.Messages++
+		e.Spent = big.Add(e.Spent, mc.Cost)
+
+		if mc.Message.To == maddr && mc.Message.Method == miner.Methods.SubmitWindowedPoSt {
+			// proofs can only be submitted for the current deadline
+			di, err := sm.Full.StateMinerProvingDeadline(ctx, maddr, mc.Included.Key())
+			if err != nil {
+				return xerrors.Errorf("getting proving deadline at %d: %w", mc.Included.Height(), err)
+			}
+
+			d, ok := deadlines[di.Index]
+			if !ok {
+				d = &api.DeadlineSpending{
+					Deadline: di.Index,
+					Spent:    big.Zero(),
+				}
+				deadlines[di.Index] = d
+			}
+			d.Messages++
+			d.Spent = big.Add(d.Spent, mc.Cost)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := &api.MinerSpending{
+		Miner: maddr,
+		From:  from,
+		To:    to,
+	}
+	for _, e := range entries {
+		out.Entries = append(out.Entries, *e)
+	}
+	sort.Slice(out.Entries, func(i, j int) bool {
+		a, b := out.Entries[i], out.Entries[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.Address != b.Address {
+			return a.Address.String() < b.Address.String()
+		}
+		return a.Category < b.Category
+	})
+
+	for _, d := range deadlines {
+		out.Deadlines = append(out.Deadlines, *d)
+	}
+	sort.Slice(out.Deadlines, func(i, j int) bool {
+		return out.Deadlines[i].Deadline < out.Deadlines[j].Deadline
+	})
+
+	return out, nil
+}
+
+func spendingCategory(maddr address.Address, msg *types.Message) string {
+	switch msg.To {
+	case maddr:
+		switch msg.Method {
+		case miner.Methods.PreCommitSector, miner.Methods.PreCommitSectorBatch:
+			return api.SpendingPreCommit
+		case miner.Methods.ProveCommitSector, miner.Methods.ProveCommitAggregate:
+			return api.SpendingCommit
+		case miner.Methods.SubmitWindowedPoSt, miner.Methods.DeclareFaults, miner.Methods.DeclareFaultsRecovered:
+			return api.SpendingPoSt
+		}
+	case market.Address:
+		if msg.Method == market.Methods.PublishStorageDeals {
+			return api.SpendingPublish
+		}
+	}
+	return api.SpendingOther
+}
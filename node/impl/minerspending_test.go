@@ -0,0 +1,114 @@
+package impl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/exitcode"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/market"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+)
+
+func TestActorSpending(t *testing.T) {
+	// tipsets are mined every six hours, messages executed at 1 to 4 are
+	// included on the first day
+	const day1, day2 = "1970-01-01", "1970-01-02"
+
+	for _, tc := range []struct {
+		name     string
+		msgs     []testMsg
+		from, to abi.ChainEpoch
+
+		entries   []api.SpendingEntry
+		deadlines []api.DeadlineSpending
+	}{{
+		name: "categories",
+		msgs: []testMsg{
+			{Exec: 2, From: testWorker, To: testMiner, Method: miner.Methods.PreCommitSector, GasUsed: 100},
+			{Exec: 3, From: testWorker, To: testMiner, Method: miner.Methods.PreCommitSectorBatch, GasUsed: 100},
+			{Exec: 2, From: testWorker, To: testMiner, Method: miner.Methods.ProveCommitAggregate, GasUsed: 200},
+			{Exec: 3, From: testWorker, To: testMiner, Method: miner.Methods.DeclareFaults, GasUsed: 50},
+			{Exec: 2, From: testWorker, To: market.Address, Method: market.Methods.PublishStorageDeals, GasUsed: 400},
+			{Exec: 3, From: testWorker, To: market.Address, Method: market.Methods.AddBalance, GasUsed: 10},
+			{Exec: 4, From: testWorker, To: testOther, Method: 0, GasUsed: 20},
+			// failed messages cost gas too
+			{Exec: 4, From: testWorker, To: testMiner, Method: miner.Methods.ProveCommitSector, GasUsed: 30, Exit: exitcode.ErrForbidden},
+		},
+		from: 1, to: 4,
+		entries: []api.SpendingEntry{
+			{Day: day1, Address: testWorkerKey, Category: api.SpendingCommit, Messages: 2, Spent: testCost(230)},
+			{Day: day1, Address: testWorkerKey, Category: api.SpendingOther, Messages: 2, Spent: testCost(30)},
+			{Day: day1, Address: testWorkerKey, Category: api.SpendingPoSt, Messages: 1, Spent: testCost(50)},
+			{Day: day1, Address: testWorkerKey, Category: api.SpendingPreCommit, Messages: 2, Spent: testCost(200)},
+			{Day: day1, Address: testWorkerKey, Category: api.SpendingPublish, Messages: 1, Spent: testCost(400)},
+		},
+	}, {
+		name: "days-and-senders",
+		msgs: []testMsg{
+			// the ID and key addresses of the owner are accounted together
+			{Exec: 2, From: testOwner, To: testMiner, Method: miner.Methods.PreCommitSector, GasUsed: 100},
+			{Exec: 3, From: testOwnerKey, To: testMiner, Method: miner.Methods.PreCommitSector, GasUsed: 100},
+			{Exec: 6, From: testOwnerKey, To: testMiner, Method: miner.Methods.PreCommitSector, GasUsed: 100},
+			{Exec: 6, From: testWorkerKey, To: testMiner, Method: miner.Methods.PreCommitSector, GasUsed: 10},
+			// not sent by the miner
+			{Exec: 6, From: testOther, To: testMiner, Method: miner.Methods.PreCommitSector, GasUsed: 100},
+			// out of the range
+			{Exec: 9, From: testOwner, To: testMiner, Method: miner.Methods.PreCommitSector, GasUsed: 100},
+		},
+		from: 1, to: 8,
+		entries: []api.SpendingEntry{
+			{Day: day1, Address: testOwnerKey, Category: api.SpendingPreCommit, Messages: 2, Spent: testCost(200)},
+			{Day: day2, Address: testOwnerKey, Category: api.SpendingPreCommit, Messages: 1, Spent: testCost(100)},
+			{Day: day2, Address: testWorkerKey, Category: api.SpendingPreCommit, Messages: 1, Spent: testCost(10)},
+		},
+	}, {
+		name: "deadlines",
+		msgs: []testMsg{
+			// included at 10 and 11, in deadline 1
+			{Exec: 11, From: testWorker, To: testMiner, Method: miner.Methods.SubmitWindowedPoSt, GasUsed: 100},
+			{Exec: 12, From: testWorker, To: testMiner, Method: miner.Methods.SubmitWindowedPoSt, GasUsed: 200},
+			// included at 24, in deadline 2
+			{Exec: 25, From: testWorker, To: testMiner, Method: miner.Methods.SubmitWindowedPoSt, GasUsed: 300},
+			// fault declarations aren't proofs
+			{Exec: 26, From: testWorker, To: testMiner, Method: miner.Methods.DeclareFaults, GasUsed: 400},
+		},
+		from: 10, to: 27,
+		entries: []api.SpendingEntry{
+			{Day: "1970-01-03", Address: testWorkerKey, Category: api.SpendingPoSt, Messages: 2, Spent: testCost(300)},
+			{Day: "1970-01-07", Address: testWorkerKey, Category: api.SpendingPoSt, Messages: 2, Spent: testCost(700)},
+		},
+		deadlines: []api.DeadlineSpending{
+			{Deadline: 1, Messages: 2, Spent: testCost(300)},
+			{Deadline: 2, Messages: 1, Spent: testCost(300)},
+		},
+	}} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			sm := &StorageMinerAPI{Full: newReportChain(t, 40, nil, tc.msgs)}
+
+			out, err := sm.ActorSpending(context.Background(), testMiner, tc.from, tc.to)
+			require.NoError(t, err)
+			require.Equal(t, testMiner, out.Miner)
+			require.Equal(t, tc.entries, out.Entries)
+			require.Equal(t, tc.deadlines, out.Deadlines)
+		})
+	}
+
+	// invalid ranges
+	sm := &StorageMinerAPI{Full: newReportChain(t, 40, nil, nil)}
+	_, err := sm.ActorSpending(context.Background(), testMiner, 10, 5)
+	require.Error(t, err)
+	_, err = sm.ActorSpending(context.Background(), testMiner, 10, 41)
+	require.Error(t, err)
+
+	// nothing spent
+	out, err := sm.ActorSpending(context.Background(), testMiner, 1, 40)
+	require.NoError(t, err)
+	require.Empty(t, out.Entries)
+	require.Empty(t, out.Deadlines)
+}
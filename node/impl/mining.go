@@ -0,0 +1,158 @@
+package impl
+
+import (
+	"context"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/reward"
+	"github.com/filecoin-project/lotus/chain/gen"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/miner"
+)
+
+func (n *FullNodeAPI) MinerComputeBlockTemplate(ctx context.Context, maddr address.Address, epoch abi.ChainEpoch, tsk types.TipSetKey) (*api.BlockTemplateReport, error) {
+	head, err := n.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	base := head
+	if tsk != types.EmptyTSK {
+		base, err = n.ChainGetTipSet(ctx, tsk)
+		if err != nil {
+			return nil, xerrors.Errorf("loading base tipset %s: %w", tsk, err)
+		}
+	}
+	if epoch <= base.Height() {
+		return nil, xerrors.Errorf("epoch %d isn't after the base tipset at %d", epoch, base.Height())
+	}
+	if !base.Equals(head) && epoch > head.Height() {
+		// there are neither messages in the chain nor in the message pool
+		// to build the block with
+		return nil, xerrors.Errorf("epoch %d is past the chain head at %d, but the base isn't the head", epoch, head.Height())
+	}
+
+	out := &api.BlockTemplateReport{
+		Miner:          maddr,
+		Epoch:          epoch,
+		Base:           base.Key(),
+		BlockReward:    big.Zero(),
+		MinerTips:      big.Zero(),
+		MinerPenalties: big.Zero(),
+	}
+
+	mbi, err := n.MinerGetBaseInfo(ctx, maddr, epoch, base.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting mining base info: %w", err)
+	}
+	if mbi == nil || !mbi.EligibleForMining {
+		return out, nil
+	}
+	out.Eligible = true
+
+	rbase := mbi.PrevBeaconEntry
+	if len(mbi.BeaconEntries) > 0 {
+		rbase = mbi.BeaconEntries[len(mbi.BeaconEntries)-1]
+	}
+
+	mbase := &miner.MiningBase{TipSet: base, NullRounds: epoch - base.Height() - 1}
+	out.Ticket, err = miner.ComputeTicket(ctx, n.WalletSign, maddr, &rbase, mbase, mbi.WorkerKey)
+	if err != nil {
+		return nil, xerrors.Errorf("computing ticket: %w", err)
+	}
+
+	out.Eproof, err = gen.IsRoundWinner(ctx, base, epoch, maddr, rbase, mbi, n)
+	if err != nil {
+		return nil, xerrors.Errorf("checking if the miner wins: %w", err)
+	}
+	if out.Eproof == nil {
+		return out, nil
+	}
+	out.Won = true
+	out.WinCount = out.Eproof.WinCount
+
+	ract, err := n.StateGetActor(ctx, reward.Address, base.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("loading reward actor: %w", err)
+	}
+	rst, err := reward.Load(n.StateAPI.StateManager.ChainStore().ActorStore(ctx), ract)
+	if err != nil {
+		return nil, xerrors.Errorf("loading reward actor state: %w", err)
+	}
+	epochReward, err := rst.ThisEpochReward()
+	if err != nil {
+		return nil, err
+	}
+	out.BlockReward = big.Div(big.Mul(epochReward, big.NewInt(out.WinCount)), big.NewIntUnsigned(build.BlocksPerEpoch))
+
+	out.Messages, err = n.templateMessages(ctx, head, base, epoch, out.Ticket)
+	if err != nil {
+		return nil, xerrors.Errorf("selecting messages: %w", err)
+	}
+	if len(out.Messages) == 0 {
+		return out, nil
+	}
+
+	msgs := make([]*types.Message, len(out.Messages))
+	for i, sm := range out.Messages {
+		msgs[i] = sm.VMMessage()
+	}
+
+	res, err := n.StateCompute(ctx, epoch, msgs, base.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("applying selected messages: %w", err)
+	}
+	for _, ir := range res.Trace {
+		out.MinerTips = big.Add(out.MinerTips, ir.GasCost.MinerTip)
+		out.MinerPenalties = big.Add(out.MinerPenalties, ir.GasCost.MinerPenalty)
+	}
+
+	return out, nil
+}
+
+// templateMessages returns the messages of a block mined on the base: the ones
+// selected from the message pool when the base is the chain head, and the ones
+// included in the chain at the epoch on top of the base otherwise, so that
+// past epochs are replayed with the messages blocks were actually mined with
+func (n *FullNodeAPI) templateMessages(ctx context.Context, head, base *types.TipSet, epoch abi.ChainEpoch, ticket *types.Ticket) ([]*types.SignedMessage, error) {
+	if base.Equals(head) {
+		return n.MpoolSelect(ctx, base.Key(), ticket.Quality())
+	}
+
+	ts, err := n.ChainGetTipSetByHeight(ctx, epoch, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting tipset at %d: %w", epoch, err)
+	}
+	if ts.Height() != epoch || ts.Parents() != base.Key() {
+		// a null round, or the base isn't in the chain
+		return nil, nil
+	}
+
+	cmsgs, err := n.StateAPI.StateManager.ChainStore().MessagesForTipset(ts)
+	if err != nil {
+		return nil, xerrors.Errorf("loading messages of tipset %s: %w", ts.Key(), err)
+	}
+
+	out := make([]*types.SignedMessage, 0, len(cmsgs))
+	for _, cm := range cmsgs {
+		switch m := cm.(type) {
+		case *types.SignedMessage:
+			out = append(out, m)
+		case *types.Message:
+			// the signatures of bls messages are aggregated in the blocks
+			out = append(out, &types.SignedMessage{Message: *m, Signature: crypto.Signature{Type: crypto.SigTypeBLS}})
+		default:
+			return nil, xerrors.Errorf("unexpected message type %T", cm)
+		}
+	}
+
+	return out, nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
 	datatransfer "github.com/filecoin-project/go-data-transfer"
 	"github.com/filecoin-project/go-fil-markets/piecestore"
 	retrievalmarket "github.com/filecoin-project/go-fil-markets/retrievalmarket"
@@ -26,6 +28,7 @@ import (
 	"github.com/filecoin-project/go-jsonrpc/auth"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/crypto"
 
 	sectorstorage "github.com/filecoin-project/lotus/extern/sector-storage"
 	"github.com/filecoin-project/lotus/extern/sector-storage/fsutil"
@@ -37,6 +40,11 @@ import (
 	"github.com/filecoin-project/lotus/api"
 	apitypes "github.com/filecoin-project/lotus/api/types"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/lib/rpctls"
+	"github.com/filecoin-project/lotus/lib/subscription"
+	"github.com/filecoin-project/lotus/lib/tunnel"
+	"github.com/filecoin-project/lotus/markets/retrievaladapter"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
 	"github.com/filecoin-project/lotus/miner"
 	"github.com/filecoin-project/lotus/node/impl/common"
@@ -49,25 +57,42 @@ import (
 type StorageMinerAPI struct {
 	common.CommonAPI
 
-	SectorBlocks *sectorblocks.SectorBlocks
-
-	PieceStore        dtypes.ProviderPieceStore
-	StorageProvider   storagemarket.StorageProvider
-	RetrievalProvider retrievalmarket.RetrievalProvider
-	Miner             *storage.Miner
-	BlockMiner        *miner.Miner
-	Full              api.FullNode
-	StorageMgr        *sectorstorage.Manager `optional:"true"`
-	IStorageMgr       sectorstorage.SectorManager
-	*stores.Index
-	storiface.WorkerReturn
-	DataTransfer  dtypes.ProviderDataTransfer
-	Host          host.Host
-	AddrSel       *storage.AddressSelector
-	DealPublisher *storageadapter.DealPublisher
-
-	Epp gen.WinningPoStProver
-	DS  dtypes.MetadataDS
+	Maddr dtypes.MinerAddress
+
+	// markets
+	SectorBlocks      *sectorblocks.SectorBlocks         `optional:"true"`
+	PieceStore        dtypes.ProviderPieceStore          `optional:"true"`
+	StorageProvider   storagemarket.StorageProvider      `optional:"true"`
+	RetrievalProvider retrievalmarket.RetrievalProvider  `optional:"true"`
+	RetrievalPricing  *retrievaladapter.RetrievalPricing `optional:"true"`
+	DataTransfer      dtypes.ProviderDataTransfer        `optional:"true"`
+	DealPublisher     *storageadapter.DealPublisher      `optional:"true"`
+
+	// sealing
+	Miner                  *storage.Miner              `optional:"true"`
+	AdditionalMiners       storage.AdditionalMiners    `optional:"true"`
+	BlockMiner             *miner.Miner                `optional:"true"`
+	StorageMgr             *sectorstorage.Manager      `optional:"true"`
+	UnsealQueue            *sectorstorage.UnsealQueue  `optional:"true"`
+	IStorageMgr            sectorstorage.SectorManager `optional:"true"`
+	*stores.Index          `optional:"true"`
+	Mover                  *stores.Mover     `optional:"true"`
+	URLSigner              *stores.URLSigner `optional:"true"`
+	WorkerTunnels          *tunnel.Remotes   `optional:"true"`
+	storiface.WorkerReturn `optional:"true"`
+	AddrSel                *storage.AddressSelector  `optional:"true"`
+	Epp                    gen.WinningPoStProver     `optional:"true"`
+	Payouts                *storage.PayoutScheduler  `optional:"true"`
+	WorkerRotator          *storage.WorkerRotator    `optional:"true"`
+	OwnerChange            *storage.OwnerChangeGuard `optional:"true"`
+
+	Full     api.FullNode
+	Host     host.Host
+	Alerting *alerting.Alerting
+	Certs    *rpctls.Certs `optional:"true"`
+
+	DS       dtypes.MetadataDS
+	KeyStore types.KeyStore
 
 	ConsiderOnlineStorageDealsConfigFunc        dtypes.ConsiderOnlineStorageDealsConfigFunc
 	SetConsiderOnlineStorageDealsConfigFunc     dtypes.SetConsiderOnlineStorageDealsConfigFunc
@@ -85,14 +110,24 @@ type StorageMinerAPI struct {
 	SetConsiderUnverifiedStorageDealsConfigFunc dtypes.SetConsiderUnverifiedStorageDealsConfigFunc
 	SetSealingConfigFunc                        dtypes.SetSealingConfigFunc
 	GetSealingConfigFunc                        dtypes.GetSealingConfigFunc
+	GetFeeConfigFunc                            sealing.GetFeeConfigFunc
 	GetExpectedSealDurationFunc                 dtypes.GetExpectedSealDurationFunc
 	SetExpectedSealDurationFunc                 dtypes.SetExpectedSealDurationFunc
 }
 
 func (sm *StorageMinerAPI) ServeRemote(w http.ResponseWriter, r *http.Request) {
-	if !auth.HasPerm(r.Context(), nil, api.PermAdmin) {
+	// signed URLs grant reading single sector files, see stores.URLSigner
+	signed := sm.URLSigner != nil && sm.URLSigner.Verify(r)
+
+	if !signed && !api.HasRemotePerm(r) {
 		w.WriteHeader(401)
-		_ = json.NewEncoder(w).Encode(struct{ Error string }{"unauthorized: missing write permission"})
+		_ = json.NewEncoder(w).Encode(struct{ Error string }{"unauthorized: missing admin or remote:read permission"})
+		return
+	}
+
+	if sm.StorageMgr == nil {
+		w.WriteHeader(404)
+		_ = json.NewEncoder(w).Encode(struct{ Error string }{"sealing subsystem is disabled"})
 		return
 	}
 
@@ -107,8 +142,190 @@ func (sm *StorageMinerAPI) WorkerJobs(ctx context.Context) (map[uuid.UUID][]stor
 	return sm.StorageMgr.WorkerJobs(), nil
 }
 
+func (sm *StorageMinerAPI) SectorsUnsealQueue(ctx context.Context) ([]storiface.UnsealJob, error) {
+	if sm.UnsealQueue == nil {
+		return nil, xerrors.Errorf("unseal queue not available")
+	}
+	return sm.UnsealQueue.Jobs(), nil
+}
+
+func (sm *StorageMinerAPI) SectorsUnsealPiece(ctx context.Context, sector sto.SectorRef, offset storiface.UnpaddedByteIndex, size abi.UnpaddedPieceSize, randomness abi.SealRandomness, commd *cid.Cid) error {
+	if sm.UnsealQueue != nil {
+		return sm.UnsealQueue.SectorsUnsealPiece(ctx, sector, offset, size, randomness, commd)
+	}
+	return sm.StorageMgr.SectorsUnsealPiece(ctx, sector, offset, size, randomness, commd)
+}
+
+func (sm *StorageMinerAPI) sectorsETA(ctx context.Context) ([]sealing.SectorETA, error) {
+	var queue map[abi.SectorID]sectorstorage.QueuePosition
+	if sm.StorageMgr != nil {
+		var err error
+		queue, err = sm.StorageMgr.QueuePositions(ctx)
+		if err != nil {
+			return nil, xerrors.Errorf("getting scheduler queue: %w", err)
+		}
+	}
+
+	return sm.Miner.SectorsETA(queue)
+}
+
+func (sm *StorageMinerAPI) SectorsETA(ctx context.Context) ([]api.SectorETA, error) {
+	etas, err := sm.sectorsETA(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	out := make([]api.SectorETA, len(etas))
+	for i, eta := range etas {
+		out[i] = api.SectorETA{
+			SectorID: eta.Number,
+			State:    api.SectorState(eta.State),
+			ETA:      now.Add(eta.Remaining),
+			Known:    eta.Known,
+		}
+		if eta.Queue != nil {
+			out[i].QueuedTask = eta.Queue.Task
+			out[i].QueuedBehind = eta.Queue.Ahead
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].SectorID < out[j].SectorID
+	})
+
+	return out, nil
+}
+
+func (sm *StorageMinerAPI) SealingForecast(ctx context.Context) (api.SealingForecast, error) {
+	etas, err := sm.sectorsETA(ctx)
+	if err != nil {
+		return api.SealingForecast{}, err
+	}
+
+	f := sm.Miner.SealingForecast(etas)
+
+	durations := make(map[api.SectorState]time.Duration, len(f.StateDurations))
+	for st, d := range f.StateDurations {
+		durations[api.SectorState(st)] = d
+	}
+
+	return api.SealingForecast{
+		InFlight:         f.InFlight,
+		ProjectedNextDay: f.ProjectedNextDay,
+		ObservedPerDay:   f.ObservedPerDay,
+		StateDurations:   durations,
+	}, nil
+}
+
+func (sm *StorageMinerAPI) SealingFSMGraph(ctx context.Context) (api.SealingFSMGraph, error) {
+	g, err := sm.Miner.SealingFSMGraph()
+	if err != nil {
+		return api.SealingFSMGraph{}, err
+	}
+
+	out := api.SealingFSMGraph{
+		States:      make([]api.SealingFSMState, len(g.States)),
+		Transitions: make([]api.SealingFSMTransition, len(g.Transitions)),
+	}
+	for i, st := range g.States {
+		out.States[i] = api.SealingFSMState{
+			State:     api.SectorState(st.State),
+			Sectors:   st.Sectors,
+			MeanDwell: st.MeanDwell,
+			Samples:   st.Samples,
+		}
+	}
+	for i, t := range g.Transitions {
+		out.Transitions[i] = api.SealingFSMTransition{
+			From:   api.SectorState(t.From),
+			Event:  t.Event,
+			To:     api.SectorState(t.To),
+			Global: t.Global,
+		}
+	}
+
+	return out, nil
+}
+
+func (sm *StorageMinerAPI) MaintenanceSet(ctx context.Context, enabled bool) error {
+	cfg, err := sm.GetSealingConfigFunc()
+	if err != nil {
+		return xerrors.Errorf("get config: %w", err)
+	}
+
+	cfg.MaintenanceMode = enabled
+
+	if err := sm.SetSealingConfigFunc(cfg); err != nil {
+		return xerrors.Errorf("set config: %w", err)
+	}
+
+	if sm.StorageMgr != nil {
+		sm.StorageMgr.SetDispatchPaused(enabled)
+	}
+
+	log.Infow("maintenance mode changed", "enabled", enabled)
+	return nil
+}
+
+func (sm *StorageMinerAPI) FeeBreakerStatus(ctx context.Context) (api.FeeBreakerStatus, error) {
+	st, err := sm.Miner.FeeBreaker().Status()
+	if err != nil {
+		return api.FeeBreakerStatus{}, err
+	}
+
+	return api.FeeBreakerStatus{
+		Enabled:      st.Enabled,
+		Tripped:      st.Tripped,
+		Since:        st.Since,
+		BaseFee:      st.BaseFee,
+		TripBaseFee:  st.TripBaseFee,
+		ResetBaseFee: st.ResetBaseFee,
+		Deferred:     st.Deferred,
+	}, nil
+}
+
+func (sm *StorageMinerAPI) MaintenanceMode(ctx context.Context) (bool, error) {
+	cfg, err := sm.GetSealingConfigFunc()
+	if err != nil {
+		return false, err
+	}
+	return cfg.MaintenanceMode, nil
+}
+
+func (sm *StorageMinerAPI) ConfigReload(ctx context.Context) error {
+	// don't apply a config which the sealing pipelines would fail to read
+	if _, err := sm.GetSealingConfigFunc(); err != nil {
+		return xerrors.Errorf("reading sealing config: %w", err)
+	}
+	if _, err := sm.GetFeeConfigFunc(); err != nil {
+		return xerrors.Errorf("reading fee config: %w", err)
+	}
+
+	if sm.Miner != nil {
+		sm.Miner.ReloadConfig()
+	}
+	for _, m := range sm.AdditionalMiners {
+		m.ReloadConfig()
+	}
+
+	log.Info("reloaded sealing and fee config")
+	return nil
+}
+
 func (sm *StorageMinerAPI) ActorAddress(context.Context) (address.Address, error) {
-	return sm.Miner.Address(), nil
+	return address.Address(sm.Maddr), nil
+}
+
+func (sm *StorageMinerAPI) ActorAddresses(context.Context) ([]address.Address, error) {
+	out := []address.Address{address.Address(sm.Maddr)}
+	for maddr := range sm.AdditionalMiners {
+		out = append(out, maddr)
+	}
+	sort.Slice(out[1:], func(i, j int) bool {
+		return out[1+i].String() < out[1+j].String()
+	})
+	return out, nil
 }
 
 func (sm *StorageMinerAPI) MiningBase(ctx context.Context) (*types.TipSet, error) {
@@ -119,6 +336,10 @@ func (sm *StorageMinerAPI) MiningBase(ctx context.Context) (*types.TipSet, error
 	return mb.TipSet, nil
 }
 
+func (sm *StorageMinerAPI) MiningAttempts(ctx context.Context) ([]api.MiningAttempt, error) {
+	return sm.BlockMiner.Attempts(), nil
+}
+
 func (sm *StorageMinerAPI) ActorSectorSize(ctx context.Context, addr address.Address) (abi.SectorSize, error) {
 	mi, err := sm.Full.StateMinerInfo(ctx, addr, types.EmptyTSK)
 	if err != nil {
@@ -128,7 +349,28 @@ func (sm *StorageMinerAPI) ActorSectorSize(ctx context.Context, addr address.Add
 }
 
 func (sm *StorageMinerAPI) PledgeSector(ctx context.Context) (abi.SectorID, error) {
-	sr, err := sm.Miner.PledgeSector(ctx)
+	return pledgeSector(ctx, sm.Miner)
+}
+
+func (sm *StorageMinerAPI) PledgeSectorFor(ctx context.Context, maddr address.Address) (abi.SectorID, error) {
+	maddr, err := sm.Full.StateLookupID(ctx, maddr, types.EmptyTSK)
+	if err != nil {
+		return abi.SectorID{}, xerrors.Errorf("resolving miner address: %w", err)
+	}
+
+	if maddr == sm.Miner.Address() {
+		return pledgeSector(ctx, sm.Miner)
+	}
+
+	m, ok := sm.AdditionalMiners[maddr]
+	if !ok {
+		return abi.SectorID{}, xerrors.Errorf("miner %s isn't served by this node", maddr)
+	}
+	return pledgeSector(ctx, m)
+}
+
+func pledgeSector(ctx context.Context, m *storage.Miner) (abi.SectorID, error) {
+	sr, err := m.PledgeSector(ctx)
 	if err != nil {
 		return abi.SectorID{}, err
 	}
@@ -136,7 +378,7 @@ func (sm *StorageMinerAPI) PledgeSector(ctx context.Context) (abi.SectorID, erro
 	// wait for the sector to enter the Packing state
 	// TODO: instead of polling implement some pubsub-type thing in storagefsm
 	for {
-		info, err := sm.Miner.GetSectorInfo(sr.ID.Number)
+		info, err := m.GetSectorInfo(sr.ID.Number)
 		if err != nil {
 			return abi.SectorID{}, xerrors.Errorf("getting pledged sector info: %w", err)
 		}
@@ -154,88 +396,34 @@ func (sm *StorageMinerAPI) PledgeSector(ctx context.Context) (abi.SectorID, erro
 }
 
 func (sm *StorageMinerAPI) SectorsStatus(ctx context.Context, sid abi.SectorNumber, showOnChainInfo bool) (api.SectorInfo, error) {
-	info, err := sm.Miner.GetSectorInfo(sid)
-	if err != nil {
-		return api.SectorInfo{}, err
-	}
-
-	deals := make([]abi.DealID, len(info.Pieces))
-	for i, piece := range info.Pieces {
-		if piece.DealInfo == nil {
-			continue
-		}
-		deals[i] = piece.DealInfo.DealID
-	}
-
-	log := make([]api.SectorLog, len(info.Log))
-	for i, l := range info.Log {
-		log[i] = api.SectorLog{
-			Kind:      l.Kind,
-			Timestamp: l.Timestamp,
-			Trace:     l.Trace,
-			Message:   l.Message,
-		}
-	}
-
-	sInfo := api.SectorInfo{
-		SectorID: sid,
-		State:    api.SectorState(info.State),
-		CommD:    info.CommD,
-		CommR:    info.CommR,
-		Proof:    info.Proof,
-		Deals:    deals,
-		Ticket: api.SealTicket{
-			Value: info.TicketValue,
-			Epoch: info.TicketEpoch,
-		},
-		Seed: api.SealSeed{
-			Value: info.SeedValue,
-			Epoch: info.SeedEpoch,
-		},
-		PreCommitMsg: info.PreCommitMessage,
-		CommitMsg:    info.CommitMessage,
-		Retries:      info.InvalidProofs,
-		ToUpgrade:    sm.Miner.IsMarkedForUpgrade(sid),
-
-		LastErr: info.LastErr,
-		Log:     log,
-		// on chain info
-		SealProof:          0,
-		Activation:         0,
-		Expiration:         0,
-		DealWeight:         big.Zero(),
-		VerifiedDealWeight: big.Zero(),
-		InitialPledge:      big.Zero(),
-		OnTime:             0,
-		Early:              0,
-	}
-
-	if !showOnChainInfo {
-		return sInfo, nil
-	}
+	return sm.Miner.SectorsStatus(ctx, sid, showOnChainInfo)
+}
 
-	onChainInfo, err := sm.Full.StateSectorGetInfo(ctx, sm.Miner.Address(), sid, types.EmptyTSK)
+func (sm *StorageMinerAPI) SectorLog(ctx context.Context, sid abi.SectorNumber) ([]api.SectorLogEntry, error) {
+	events, err := sm.Miner.SectorLog(sid)
 	if err != nil {
-		return sInfo, err
-	}
-	if onChainInfo == nil {
-		return sInfo, nil
+		return nil, err
 	}
-	sInfo.SealProof = onChainInfo.SealProof
-	sInfo.Activation = onChainInfo.Activation
-	sInfo.Expiration = onChainInfo.Expiration
-	sInfo.DealWeight = onChainInfo.DealWeight
-	sInfo.VerifiedDealWeight = onChainInfo.VerifiedDealWeight
-	sInfo.InitialPledge = onChainInfo.InitialPledge
 
-	ex, err := sm.Full.StateSectorExpiration(ctx, sm.Miner.Address(), sid, types.EmptyTSK)
-	if err != nil {
-		return sInfo, nil
+	out := make([]api.SectorLogEntry, len(events))
+	for i, e := range events {
+		out[i] = api.SectorLogEntry{
+			Time:        e.Time,
+			Kind:        string(e.Kind),
+			Event:       e.Event,
+			From:        api.SectorState(e.From),
+			To:          api.SectorState(e.To),
+			MessageType: e.MessageType,
+			Message:     e.Message,
+			Error:       e.Error,
+			ErrorCode:   e.ErrorCode,
+		}
 	}
-	sInfo.OnTime = ex.OnTime
-	sInfo.Early = ex.Early
+	return out, nil
+}
 
-	return sInfo, nil
+func (sm *StorageMinerAPI) SectorAddPieceToAny(ctx context.Context, size abi.UnpaddedPieceSize, r sto.Data, d api.PieceDealInfo) (api.SectorOffset, error) {
+	return sm.Miner.SectorAddPieceToAny(ctx, size, r, d)
 }
 
 // List all staged sectors
@@ -323,6 +511,26 @@ func (sm *StorageMinerAPI) StorageStat(ctx context.Context, id stores.ID) (fsuti
 	return sm.StorageMgr.FsStat(ctx, id)
 }
 
+func (sm *StorageMinerAPI) StorageMoveSector(ctx context.Context, sector abi.SectorNumber, dest stores.ID) error {
+	mid, err := address.IDFromAddress(sm.Miner.Address())
+	if err != nil {
+		return err
+	}
+
+	return sm.Mover.Move(ctx, abi.SectorID{
+		Miner:  abi.ActorID(mid),
+		Number: sector,
+	}, dest)
+}
+
+func (sm *StorageMinerAPI) StorageMoveList(ctx context.Context) ([]stores.SectorMove, error) {
+	return sm.Mover.List(), nil
+}
+
+func (sm *StorageMinerAPI) StorageTierList(ctx context.Context) ([]stores.SectorTier, error) {
+	return sm.Index.SectorTiers(), nil
+}
+
 func (sm *StorageMinerAPI) SectorStartSealing(ctx context.Context, number abi.SectorNumber) error {
 	return sm.Miner.StartPackingSector(number)
 }
@@ -362,6 +570,10 @@ func (sm *StorageMinerAPI) SectorRemove(ctx context.Context, id abi.SectorNumber
 	return sm.Miner.RemoveSector(ctx, id)
 }
 
+func (sm *StorageMinerAPI) SectorSetLabels(ctx context.Context, id abi.SectorNumber, labels map[string]string) error {
+	return sm.Miner.SetSectorLabels(ctx, id, labels)
+}
+
 func (sm *StorageMinerAPI) SectorTerminate(ctx context.Context, id abi.SectorNumber) error {
 	return sm.Miner.TerminateSector(ctx, id)
 }
@@ -386,6 +598,22 @@ func (sm *StorageMinerAPI) SectorMarkForUpgrade(ctx context.Context, id abi.Sect
 	return sm.Miner.MarkForUpgrade(id)
 }
 
+func (sm *StorageMinerAPI) SectorReceipts(ctx context.Context, sid abi.SectorNumber) ([]storiface.WorkReceipt, error) {
+	mid, err := address.IDFromAddress(sm.Miner.Address())
+	if err != nil {
+		return nil, err
+	}
+
+	return sm.StorageMgr.SectorReceipts(ctx, abi.SectorID{
+		Miner:  abi.ActorID(mid),
+		Number: sid,
+	})
+}
+
+func (sm *StorageMinerAPI) SectorRegenerateProof(ctx context.Context, id abi.SectorNumber) error {
+	return sm.Miner.RegenerateProof(id)
+}
+
 func (sm *StorageMinerAPI) SectorCommitFlush(ctx context.Context) ([]sealiface.CommitBatchRes, error) {
 	return sm.Miner.CommitFlush(ctx)
 }
@@ -394,8 +622,70 @@ func (sm *StorageMinerAPI) SectorCommitPending(ctx context.Context) ([]abi.Secto
 	return sm.Miner.CommitPending(ctx)
 }
 
+// stateChangesBuffer is the number of sector state changes buffered for an
+// API subscriber
+const stateChangesBuffer = 256
+
+func (sm *StorageMinerAPI) SectorsStateChanges(ctx context.Context, filter api.SectorStateFilter) (<-chan api.SectorStateChange, error) {
+	out := make(chan api.SectorStateChange, stateChangesBuffer)
+	unsub := sm.subscribeStateChanges(filter, out)
+
+	go func() {
+		<-ctx.Done()
+		unsub()
+		close(out)
+	}()
+
+	return out, nil
+}
+
+func (sm *StorageMinerAPI) SectorsStateChangesWithPolicy(ctx context.Context, filter api.SectorStateFilter, policy subscription.Policy) (<-chan api.SectorStateChange, error) {
+	policy, err := policy.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.BufferStateChanges(ctx, func(ch chan<- api.SectorStateChange) func() {
+		return sm.subscribeStateChanges(filter, ch)
+	}, policy), nil
+}
+
+// subscribeStateChanges subscribes ch to the state changes of all miner
+// actors of the node
+func (sm *StorageMinerAPI) subscribeStateChanges(filter api.SectorStateFilter, ch chan<- api.SectorStateChange) func() {
+	var unsubs []func()
+	if sm.Miner != nil {
+		unsubs = append(unsubs, sm.Miner.SubscribeStateChanges(filter, ch))
+	}
+	for _, m := range sm.AdditionalMiners {
+		unsubs = append(unsubs, m.SubscribeStateChanges(filter, ch))
+	}
+
+	return func() {
+		for _, unsub := range unsubs {
+			unsub()
+		}
+	}
+}
+
+func (sm *StorageMinerAPI) SectorBatchStatus(ctx context.Context) (api.SectorBatchStatus, error) {
+	return sm.Miner.BatchStatus(ctx)
+}
+
+func (sm *StorageMinerAPI) SectorNumReservations(ctx context.Context) (map[string]bitfield.BitField, error) {
+	return sm.Miner.NumReservations()
+}
+
+func (sm *StorageMinerAPI) SectorNumReserve(ctx context.Context, name string, numbers bitfield.BitField, force bool) error {
+	return sm.Miner.NumReserve(name, numbers, force)
+}
+
+func (sm *StorageMinerAPI) SectorNumFree(ctx context.Context, name string) error {
+	return sm.Miner.NumFree(name)
+}
+
 func (sm *StorageMinerAPI) WorkerConnect(ctx context.Context, url string) error {
-	w, err := connectRemoteWorker(ctx, sm, url)
+	w, err := connectRemoteWorker(ctx, sm, sm.Certs, url)
 	if err != nil {
 		return xerrors.Errorf("connecting remote storage failed: %w", err)
 	}
@@ -405,6 +695,71 @@ func (sm *StorageMinerAPI) WorkerConnect(ctx context.Context, url string) error
 	return sm.StorageMgr.AddWorker(ctx, w)
 }
 
+// ServeWorkerTunnel accepts tunnels from workers which can't be dialed, and
+// connects to them through the tunnel for as long as it is open
+func (sm *StorageMinerAPI) ServeWorkerTunnel(w http.ResponseWriter, r *http.Request) {
+	if !auth.HasPerm(r.Context(), nil, api.PermAdmin) {
+		w.WriteHeader(401)
+		_ = json.NewEncoder(w).Encode(struct{ Error string }{"unauthorized: missing admin permission"})
+		return
+	}
+
+	if sm.StorageMgr == nil {
+		w.WriteHeader(404)
+		_ = json.NewEncoder(w).Encode(struct{ Error string }{"sealing subsystem is disabled"})
+		return
+	}
+
+	id := r.Header.Get(tunnel.IDHeader)
+
+	sess, err := tunnel.Accept(w, r)
+	if err != nil {
+		log.Errorf("accepting worker tunnel: %+v", err)
+		return
+	}
+	defer sess.Close() // nolint:errcheck
+
+	addr, err := tunnel.Forward(sess)
+	if err != nil {
+		log.Errorf("forwarding worker tunnel: %+v", err)
+		return
+	}
+
+	// the worker declares its storage at tunnel.RemoteURL
+	if id != "" && sm.WorkerTunnels != nil {
+		defer sm.WorkerTunnels.Add(id, addr)()
+	}
+
+	// the request context is done once the handler returns, which is when the
+	// tunnel is closed
+	if err := sm.WorkerConnect(r.Context(), "http://"+addr+"/rpc/v0"); err != nil {
+		log.Errorf("connecting to worker %s through tunnel: %+v", r.RemoteAddr, err)
+		return
+	}
+	log.Infow("worker tunnel open", "remote", r.RemoteAddr, "local", addr)
+
+	<-sess.CloseChan()
+	log.Warnw("worker tunnel closed", "remote", r.RemoteAddr)
+}
+
+// ServeWorkerRemote serves the sector files of workers behind tunnels, see
+// tunnel.RemoteURL
+func (sm *StorageMinerAPI) ServeWorkerRemote(w http.ResponseWriter, r *http.Request) {
+	if !api.HasRemotePerm(r) {
+		w.WriteHeader(401)
+		_ = json.NewEncoder(w).Encode(struct{ Error string }{"unauthorized: missing admin or remote:read permission"})
+		return
+	}
+
+	if sm.WorkerTunnels == nil {
+		w.WriteHeader(404)
+		_ = json.NewEncoder(w).Encode(struct{ Error string }{"sealing subsystem is disabled"})
+		return
+	}
+
+	sm.WorkerTunnels.ServeHTTP(w, r)
+}
+
 func (sm *StorageMinerAPI) SealingSchedDiag(ctx context.Context, doSched bool) (interface{}, error) {
 	return sm.StorageMgr.SchedDiag(ctx, doSched)
 }
@@ -437,7 +792,7 @@ func (sm *StorageMinerAPI) listDeals(ctx context.Context) ([]api.MarketDeal, err
 	var out []api.MarketDeal
 
 	for _, deal := range allDeals {
-		if deal.Proposal.Provider == sm.Miner.Address() {
+		if deal.Proposal.Provider == address.Address(sm.Maddr) {
 			out = append(out, deal)
 		}
 	}
@@ -502,6 +857,30 @@ func (sm *StorageMinerAPI) MarketGetRetrievalAsk(ctx context.Context) (*retrieva
 	return sm.RetrievalProvider.GetAsk(), nil
 }
 
+func (sm *StorageMinerAPI) MarketSetRetrievalPricingPolicy(ctx context.Context, policy dtypes.RetrievalPricingPolicy) error {
+	if err := sm.RetrievalPricing.SetPolicy(policy); err != nil {
+		return err
+	}
+
+	if !sm.RetrievalPricing.Enabled() {
+		return nil
+	}
+
+	// the retrieval provider rejects proposals priced below the ask before
+	// the price for the client is checked, so the ask needs to be the lowest
+	// price of the policy
+	lowest := sm.RetrievalPricing.LowestPrice()
+	ask := *sm.RetrievalProvider.GetAsk()
+	ask.PricePerByte = lowest.PricePerByte
+	ask.UnsealPrice = lowest.UnsealPrice
+	sm.RetrievalProvider.SetAsk(&ask)
+	return nil
+}
+
+func (sm *StorageMinerAPI) MarketGetRetrievalPricingPolicy(ctx context.Context) (dtypes.RetrievalPricingPolicy, error) {
+	return sm.RetrievalPricing.Policy(), nil
+}
+
 func (sm *StorageMinerAPI) MarketListDataTransfers(ctx context.Context) ([]api.DataTransferChannel, error) {
 	inProgressChannels, err := sm.DataTransfer.InProgressChannels(ctx)
 	if err != nil {
@@ -679,6 +1058,20 @@ func (sm *StorageMinerAPI) CreateBackup(ctx context.Context, fpath string) error
 	return backup(sm.DS, fpath)
 }
 
+func (sm *StorageMinerAPI) CreateBackupIncremental(ctx context.Context, dir string) (*api.BackupSnapshot, error) {
+	snap, err := backupIncremental(sm.DS, sm.KeyStore, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.BackupSnapshot{
+		File:    snap.File,
+		Full:    snap.Full,
+		Entries: snap.Entries,
+		Deleted: len(snap.Deleted),
+	}, nil
+}
+
 func (sm *StorageMinerAPI) CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []sto.SectorRef, expensive bool) (map[abi.SectorNumber]string, error) {
 	var rg storiface.RGetter
 	if expensive {
@@ -717,7 +1110,62 @@ func (sm *StorageMinerAPI) Discover(ctx context.Context) (apitypes.OpenRPCDocume
 }
 
 func (sm *StorageMinerAPI) ComputeProof(ctx context.Context, ssi []builtin.SectorInfo, rand abi.PoStRandomness) ([]builtin.PoStProof, error) {
+	// the node asking may be the fallback prover of this node
+	if wpp, ok := sm.Epp.(*storage.StorageWpp); ok {
+		return wpp.ComputeLocalProof(ctx, ssi, rand)
+	}
 	return sm.Epp.ComputeProof(ctx, ssi, rand)
 }
 
+func (sm *StorageMinerAPI) AlertsList(ctx context.Context) ([]alerting.Alert, error) {
+	return sm.Alerting.GetAlerts(), nil
+}
+
+func (sm *StorageMinerAPI) AlertsAck(ctx context.Context, at alerting.AlertType) error {
+	return sm.Alerting.Ack(at)
+}
+
+func (sm *StorageMinerAPI) PayoutSchedule(ctx context.Context) (*api.PayoutSchedule, error) {
+	if sm.Payouts == nil {
+		return nil, xerrors.Errorf("scheduled payouts not enabled, see Payout.Enable in the config")
+	}
+	return sm.Payouts.Schedule(), nil
+}
+
+func (sm *StorageMinerAPI) ActorWorkerRotate(ctx context.Context, newWorker address.Address) (*api.WorkerRotation, error) {
+	if sm.WorkerRotator == nil {
+		return nil, xerrors.Errorf("worker key rotation is only available on the sealing node")
+	}
+	return sm.WorkerRotator.Rotate(ctx, newWorker)
+}
+
+func (sm *StorageMinerAPI) ActorWorkerRotation(ctx context.Context) (*api.WorkerRotation, error) {
+	if sm.WorkerRotator == nil {
+		return nil, xerrors.Errorf("worker key rotation is only available on the sealing node")
+	}
+	return sm.WorkerRotator.Rotation(), nil
+}
+
+func (sm *StorageMinerAPI) ActorOwnerChangePrepare(ctx context.Context, newOwner address.Address) (*api.OwnerChange, error) {
+	if sm.OwnerChange == nil {
+		return nil, xerrors.Errorf("guarded owner change is only available on the sealing node")
+	}
+	return sm.OwnerChange.Prepare(ctx, newOwner)
+}
+
+func (sm *StorageMinerAPI) ActorOwnerChangeConfirm(ctx context.Context, sig crypto.Signature) (cid.Cid, error) {
+	if sm.OwnerChange == nil {
+		return cid.Undef, xerrors.Errorf("guarded owner change is only available on the sealing node")
+	}
+	return sm.OwnerChange.Confirm(ctx, sig)
+}
+
+func (sm *StorageMinerAPI) ActorControlSetPlan(ctx context.Context, addrs []address.Address, minBalance abi.TokenAmount) (*api.ControlAddressPlan, error) {
+	return storage.PlanControlAddresses(ctx, sm.Full, address.Address(sm.Maddr), sm.AddrSel, addrs, minBalance)
+}
+
+func (sm *StorageMinerAPI) ActorControlSet(ctx context.Context, addrs []address.Address) (cid.Cid, error) {
+	return storage.SetControlAddresses(ctx, sm.Full, address.Address(sm.Maddr), sm.AddrSel, addrs)
+}
+
 var _ api.StorageMiner = &StorageMinerAPI{}
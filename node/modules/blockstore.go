@@ -5,14 +5,23 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	bstore "github.com/ipfs/go-ipfs-blockstore"
+	"github.com/multiformats/go-multiaddr"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api/client"
 	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/blockstore/archive"
 	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
 	"github.com/filecoin-project/lotus/blockstore/splitstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/lib/apiinfo"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/helpers"
@@ -37,6 +46,181 @@ func UniversalBlockstore(lc fx.Lifecycle, mctx helpers.MetricsCtx, r repo.Locked
 	return bs, err
 }
 
+// ChainArchive opens the archives holding the chain data moved out of the
+// universal blockstore.
+func ChainArchive(cfg *config.Chainstore) func(lc fx.Lifecycle, r repo.LockedRepo) (*archive.Store, error) {
+	return func(lc fx.Lifecycle, r repo.LockedRepo) (*archive.Store, error) {
+		path := cfg.Archive.Path
+		if path == "" {
+			path = filepath.Join(r.Path(), "archive")
+		}
+
+		as, err := archive.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		lc.Append(fx.Hook{
+			OnStop: func(_ context.Context) error {
+				return as.Close()
+			},
+		})
+		return as, nil
+	}
+}
+
+type ReadThroughUniversalBlockstoreParams struct {
+	fx.In
+
+	Lifecycle  fx.Lifecycle
+	MetricsCtx helpers.MetricsCtx
+	Repo       repo.LockedRepo
+
+	Archive *archive.Store          `optional:"true"`
+	Shared  dtypes.SharedBlockstore `optional:"true"`
+}
+
+// ReadThroughUniversalBlockstore returns the universal blockstore, falling
+// back to the chain archives and then to the shared blockstore for reads,
+// when they are configured.
+func ReadThroughUniversalBlockstore(p ReadThroughUniversalBlockstoreParams) (dtypes.UniversalBlockstore, error) {
+	bs, err := UniversalBlockstore(p.Lifecycle, p.MetricsCtx, p.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Archive != nil {
+		bs = archive.Wrap(bs, p.Archive)
+	}
+	if p.Shared != nil {
+		bs = blockstore.NewSharedStore(bs, p.Shared)
+	}
+	return bs, nil
+}
+
+// SharedBlockstore connects to the shared, read-only, blockstore holding the
+// chain history.
+func SharedBlockstore(cfg *config.Chainstore) func(mctx helpers.MetricsCtx, lc fx.Lifecycle) (dtypes.SharedBlockstore, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle) (dtypes.SharedBlockstore, error) {
+		scfg := cfg.SharedBlockstore
+		switch scfg.Type {
+		case "lotus":
+			ai := apiinfo.Parse(scfg.Endpoint)
+			url, err := ai.DialArgs("v1")
+			if err != nil {
+				return nil, err
+			}
+
+			fapi, closer, err := client.NewFullNodeRPCV1(mctx, url, ai.AuthHeader())
+			if err != nil {
+				return nil, xerrors.Errorf("creating jsonrpc client: %w", err)
+			}
+			lc.Append(fx.Hook{
+				OnStop: func(_ context.Context) error {
+					closer()
+					return nil
+				},
+			})
+
+			return blockstore.NewAPIBlockstore(fapi), nil
+		case "ipfs":
+			maddr, err := multiaddr.NewMultiaddr(scfg.Endpoint)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing ipfs api multiaddr: %w", err)
+			}
+			return blockstore.NewRemoteIPFSBlockstore(helpers.LifecycleCtx(mctx, lc), maddr, false)
+		default:
+			return nil, xerrors.Errorf("unknown shared blockstore type %q, expected lotus or ipfs", scfg.Type)
+		}
+	}
+}
+
+// chainArchiveMaxEpochs bounds the size of each archive
+const chainArchiveMaxEpochs = 30 * 2880
+
+// catchUpChainArchive archives the chain below the last keep epochs, in
+// archives of at least minEpochs and at most maxEpochs epochs
+func catchUpChainArchive(ctx context.Context, cs *store.ChainStore, as *archive.Store, bs blockstore.Blockstore, keep, minEpochs, maxEpochs abi.ChainEpoch) error {
+	for ctx.Err() == nil {
+		head := cs.GetHeaviestTipSet()
+		if head == nil {
+			return nil
+		}
+
+		last := as.MaxEpoch()
+		boundary := head.Height() - keep
+		if boundary-last < minEpochs {
+			return nil
+		}
+		if boundary > last+maxEpochs {
+			boundary = last + maxEpochs
+		}
+
+		info, err := cs.ArchiveChain(ctx, as, boundary, bs)
+		if err != nil {
+			return err
+		}
+		if info == nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+// RunChainArchiver periodically archives the chain data older than the
+// configured number of epochs.
+func RunChainArchiver(cfg *config.Chainstore) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore, as *archive.Store, bs dtypes.UniversalBlockstore) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore, as *archive.Store, bs dtypes.UniversalBlockstore) error {
+		acfg := cfg.Archive
+		if acfg.KeepEpochs < int64(build.Finality) {
+			return xerrors.Errorf("Chainstore.Archive.KeepEpochs must be at least %d", build.Finality)
+		}
+		if acfg.MinEpochs < 1 {
+			acfg.MinEpochs = 1
+		}
+		if acfg.Interval <= 0 {
+			return xerrors.Errorf("Chainstore.Archive.Interval must be positive")
+		}
+
+		ctx, cancel := context.WithCancel(helpers.LifecycleCtx(mctx, lc))
+		done := make(chan struct{})
+
+		run := func() {
+			err := catchUpChainArchive(ctx, cs, as, bs, abi.ChainEpoch(acfg.KeepEpochs), abi.ChainEpoch(acfg.MinEpochs), chainArchiveMaxEpochs)
+			if err != nil {
+				log.Errorf("archiving chain: %s", err)
+			}
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go func() {
+					defer close(done)
+
+					ticker := build.Clock.Ticker(time.Duration(acfg.Interval))
+					defer ticker.Stop()
+
+					for {
+						run()
+
+						select {
+						case <-ticker.C:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				cancel()
+				<-done
+				return nil
+			},
+		})
+		return nil
+	}
+}
+
 func BadgerHotBlockstore(lc fx.Lifecycle, r repo.LockedRepo) (dtypes.HotBlockstore, error) {
 	path, err := r.SplitstorePath()
 	if err != nil {
@@ -79,6 +263,9 @@ func SplitBlockstore(cfg *config.Chainstore) func(lc fx.Lifecycle, r repo.Locked
 			EnableFullCompaction: cfg.Splitstore.EnableFullCompaction,
 			EnableGC:             cfg.Splitstore.EnableGC,
 			Archival:             cfg.Splitstore.Archival,
+
+			CompactionHours:        cfg.Splitstore.CompactionHours,
+			CompactionMaxBandwidth: cfg.Splitstore.CompactionMaxBandwidth,
 		}
 		ss, err := splitstore.Open(path, ds, hot, cold, cfg)
 		if err != nil {
@@ -0,0 +1,100 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	datastore "github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/blockstore/archive"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/gen"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+// archivedChain returns a chain store with a generated chain of n tipsets,
+// in a blockstore falling back to the archives
+func archivedChain(t *testing.T, n int) (*store.ChainStore, *archive.Store, blockstore.Blockstore) {
+	ctx := context.Background()
+
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+	for i := 0; i < n; i++ {
+		_, err := cg.NextTipSet()
+		require.NoError(t, err)
+	}
+
+	head := cg.CurTipset.TipSet()
+	buf := new(bytes.Buffer)
+	require.NoError(t, cg.ChainStore().Export(ctx, head, head.Height(), false, buf))
+
+	as, err := archive.Open(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = as.Close() })
+
+	bs := archive.Wrap(blockstore.NewMemory(), as)
+	cs := store.NewChainStore(bs, bs, datastore.NewMapDatastore(), nil, nil)
+	t.Cleanup(func() { _ = cs.Close() })
+
+	head, err = cs.Import(buf)
+	require.NoError(t, err)
+	genesis, err := cs.GetTipsetByHeight(ctx, 0, head, true)
+	require.NoError(t, err)
+	require.NoError(t, cs.SetGenesis(genesis.Blocks()[0]))
+	require.NoError(t, cs.SetHead(head))
+
+	return cs, as, bs
+}
+
+func TestCatchUpChainArchive(t *testing.T) {
+	ctx := context.Background()
+	cs, as, bs := archivedChain(t, 60)
+
+	// everything but the last 20 epochs is archived, in archives of at most
+	// 15 epochs
+	require.NoError(t, catchUpChainArchive(ctx, cs, as, bs, 20, 5, 15))
+
+	archives := as.Archives()
+	require.True(t, len(archives) >= 3, "expected at least 3 archives, got %d", len(archives))
+	for i, a := range archives {
+		require.True(t, a.To-a.From < 15, "archive %d covers %d-%d", i, a.From, a.To)
+		if i > 0 {
+			require.True(t, a.To < archives[i-1].From, "archive %d overlaps the next one", i)
+		}
+	}
+	require.True(t, cs.GetHeaviestTipSet().Height()-20-as.MaxEpoch() < 5)
+
+	// with less than the minimum epochs to archive, nothing is done
+	require.NoError(t, catchUpChainArchive(ctx, cs, as, bs, 20, 5, 15))
+	require.Len(t, as.Archives(), len(archives))
+}
+
+func TestRunChainArchiver(t *testing.T) {
+	cs, as, bs := archivedChain(t, 10)
+
+	run := func(acfg config.ChainArchive) (*fxtest.Lifecycle, error) {
+		lc := fxtest.NewLifecycle(t)
+		err := RunChainArchiver(&config.Chainstore{Archive: acfg})(helpers.MetricsCtx(context.Background()), lc, cs, as, bs)
+		return lc, err
+	}
+
+	_, err := run(config.ChainArchive{KeepEpochs: int64(build.Finality) - 1, Interval: config.Duration(time.Hour)})
+	require.Error(t, err)
+	_, err = run(config.ChainArchive{KeepEpochs: int64(build.Finality)})
+	require.Error(t, err)
+
+	// the chain is shorter than finality, there is nothing to archive yet,
+	// and the archiver stops with the node
+	lc, err := run(config.ChainArchive{KeepEpochs: int64(build.Finality), Interval: config.Duration(time.Hour)})
+	require.NoError(t, err)
+	lc.RequireStart()
+	lc.RequireStop()
+	require.Empty(t, as.Archives())
+}
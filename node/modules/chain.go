@@ -2,6 +2,7 @@ package modules
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/ipfs/go-bitswap"
@@ -12,19 +13,28 @@ import (
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api/client"
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/blockstore/splitstore"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
 	"github.com/filecoin-project/lotus/chain/beacon"
 	"github.com/filecoin-project/lotus/chain/exchange"
 	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
 	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/vm"
 	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper"
+	"github.com/filecoin-project/lotus/extern/sector-storage/verifpool"
 	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/lib/apiinfo"
+	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/helpers"
 )
@@ -71,6 +81,67 @@ func MessagePool(lc fx.Lifecycle, mpp messagepool.Provider, ds dtypes.MetadataDS
 	return mp, nil
 }
 
+// MessageSelectionPolicy sets up the policy used by the mpool to select
+// messages for blocks
+func MessageSelectionPolicy(cfg config.MessageSelectionConfig) func(mp *messagepool.MessagePool, sm *stmgr.StateManager) error {
+	return func(mp *messagepool.MessagePool, sm *stmgr.StateManager) error {
+		var policies messagepool.SelectionPolicies
+		for _, name := range cfg.Policies {
+			switch name {
+			case "own-messages":
+				miners := make([]address.Address, 0, len(cfg.OwnMiners))
+				for _, s := range cfg.OwnMiners {
+					maddr, err := address.NewFromString(s)
+					if err != nil {
+						return xerrors.Errorf("parsing own miner address %q: %w", s, err)
+					}
+					miners = append(miners, maddr)
+				}
+				policies = append(policies, &messagepool.OwnMessagesPolicy{
+					Senders: minerControlAddrs(sm, miners),
+				})
+			case "deal-publishes":
+				policies = append(policies, messagepool.DealPublishPolicy{})
+			case "sender-limit":
+				if cfg.MaxMessagesPerSender <= 0 {
+					return xerrors.Errorf("sender-limit policy requires a positive MaxMessagesPerSender")
+				}
+				policies = append(policies, messagepool.SenderLimitPolicy{Max: cfg.MaxMessagesPerSender})
+			default:
+				return xerrors.Errorf("unknown message selection policy %q", name)
+			}
+		}
+
+		if len(policies) > 0 {
+			mp.SetSelectionPolicy(policies)
+		}
+		return nil
+	}
+}
+
+func minerControlAddrs(sm *stmgr.StateManager, miners []address.Address) func(context.Context, *types.TipSet) ([]address.Address, error) {
+	return func(ctx context.Context, ts *types.TipSet) ([]address.Address, error) {
+		var out []address.Address
+		for _, maddr := range miners {
+			act, err := sm.LoadActor(ctx, maddr, ts)
+			if err != nil {
+				return nil, xerrors.Errorf("loading miner actor %s: %w", maddr, err)
+			}
+			mas, err := miner.Load(sm.ChainStore().ActorStore(ctx), act)
+			if err != nil {
+				return nil, xerrors.Errorf("loading miner actor state %s: %w", maddr, err)
+			}
+			info, err := mas.Info()
+			if err != nil {
+				return nil, xerrors.Errorf("getting miner info %s: %w", maddr, err)
+			}
+			out = append(out, info.Owner, info.Worker)
+			out = append(out, info.ControlAddresses...)
+		}
+		return out, nil
+	}
+}
+
 func ChainStore(lc fx.Lifecycle, cbs dtypes.ChainBlockstore, sbs dtypes.StateBlockstore, ds dtypes.MetadataDS, basebs dtypes.BaseBlockstore, syscalls vm.SyscallBuilder, j journal.Journal) *store.ChainStore {
 	chain := store.NewChainStore(cbs, sbs, ds, syscalls, j)
 
@@ -115,6 +186,38 @@ func NetworkName(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore,
 	return netName, err
 }
 
+// ProofVerifier verifies proofs on the configured verification workers.
+func ProofVerifier(cfg config.ProofVerificationConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle) ffiwrapper.Verifier {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle) ffiwrapper.Verifier {
+		var workers []verifpool.Worker
+		for _, info := range cfg.Workers {
+			ai := apiinfo.Parse(info)
+
+			url, err := ai.DialArgs("v0")
+			if err != nil {
+				log.Errorf("parsing verification worker API info: %s", err)
+				continue
+			}
+
+			w, closer, err := client.NewWorkerRPCV0(mctx, url, ai.AuthHeader())
+			if err != nil {
+				log.Errorf("connecting to verification worker %s: %s", ai.Addr, err)
+				continue
+			}
+			lc.Append(fx.Hook{
+				OnStop: func(context.Context) error {
+					closer()
+					return nil
+				},
+			})
+
+			workers = append(workers, w)
+		}
+
+		return verifpool.New(ffiwrapper.ProofVerifier, cfg.ParallelLocal, cfg.LocalFallback, time.Duration(cfg.WorkerTimeout), workers...)
+	}
+}
+
 type SyncerParams struct {
 	fx.In
 
@@ -160,3 +263,63 @@ func NewSyncer(params SyncerParams) (*chain.Syncer, error) {
 func NewSlashFilter(ds dtypes.MetadataDS) *slashfilter.SlashFilter {
 	return slashfilter.New(ds)
 }
+
+// SnapshotImport reads the header of a chain snapshot to be imported while
+// the node is running
+func SnapshotImport(r io.ReadCloser, size int64) func(lc fx.Lifecycle, cs *store.ChainStore) (*store.SnapshotImport, error) {
+	return func(lc fx.Lifecycle, cs *store.ChainStore) (*store.SnapshotImport, error) {
+		si, err := cs.NewSnapshotImport(r, size)
+		if err != nil {
+			return nil, err
+		}
+
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				// unblocks the import if it's waiting for the snapshot
+				return r.Close()
+			},
+		})
+
+		return si, nil
+	}
+}
+
+// RunSnapshotImport imports the part of the snapshot the node needs to sync
+// new blocks, a finality of headers and state, and makes the snapshot root
+// the head of the node. The rest of the snapshot is imported in the
+// background.
+func RunSnapshotImport(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore, si *store.SnapshotImport) error {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+
+	log.Infow("importing recent chain from snapshot", "root", si.Root())
+	if err := si.Import(ctx, policy.ChainFinality); err != nil {
+		return xerrors.Errorf("importing recent chain: %w", err)
+	}
+
+	ts, err := cs.LoadTipSet(si.Root())
+	if err != nil {
+		return xerrors.Errorf("loading snapshot root tipset: %w", err)
+	}
+
+	if err := cs.FlushValidationCache(); err != nil {
+		return xerrors.Errorf("flushing validation cache failed: %w", err)
+	}
+
+	log.Infof("accepting %s as new head", ts.Cids())
+	if err := cs.ForceHeadSilent(ctx, ts); err != nil {
+		return err
+	}
+
+	go func() {
+		start := build.Clock.Now()
+		if err := si.Import(ctx, -1); err != nil {
+			log.Errorw("importing snapshot in the background", "error", err)
+			return
+		}
+
+		p := si.Progress()
+		log.Infow("snapshot import done", "blocks", p.Blocks, "took", build.Clock.Since(start))
+	}()
+
+	return nil
+}
@@ -214,6 +214,15 @@ func NewDefaultMaxFeeFunc(r repo.LockedRepo) dtypes.DefaultMaxFeeFunc {
 	}
 }
 
+func NewSafeSendThresholdFunc(r repo.LockedRepo) dtypes.SafeSendThresholdFunc {
+	return func() (out abi.TokenAmount, err error) {
+		err = readNodeCfg(r, func(cfg *config.FullNode) {
+			out = abi.TokenAmount(cfg.Wallet.SafeSendThreshold)
+		})
+		return
+	}
+}
+
 func readNodeCfg(r repo.LockedRepo, accessor func(node *config.FullNode)) error {
 	raw, err := r.Config()
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
@@ -92,3 +93,31 @@ type GetExpectedSealDurationFunc func() (time.Duration, error)
 
 type StorageDealFilter func(ctx context.Context, deal storagemarket.MinerDeal) (bool, string, error)
 type RetrievalDealFilter func(ctx context.Context, deal retrievalmarket.ProviderDealState) (bool, string, error)
+
+// RetrievalPricingPolicy prices retrievals depending on the client and the
+// retrieved piece. A policy with no rules which isn't free for deal clients is
+// disabled, and retrievals are priced by the retrieval ask alone.
+type RetrievalPricingPolicy struct {
+	// Retrievals of pieces for which the client has an active storage deal
+	// with the miner are free
+	FreeForDealClients bool
+	// The first rule matching the client and piece of a retrieval sets its
+	// price
+	Rules []RetrievalPricingRule
+	// The price of retrievals matching no rule
+	Default RetrievalPrice
+}
+
+type RetrievalPricingRule struct {
+	// The client the rule applies to, empty for all clients
+	Client peer.ID
+	// The piece the rule applies to, nil for all pieces
+	PieceCID *cid.Cid
+
+	Price RetrievalPrice
+}
+
+type RetrievalPrice struct {
+	PricePerByte abi.TokenAmount
+	UnsealPrice  abi.TokenAmount
+}
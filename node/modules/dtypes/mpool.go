@@ -36,3 +36,7 @@ func (ml *MpoolLocker) TakeLock(ctx context.Context, a address.Address) (func(),
 }
 
 type DefaultMaxFeeFunc func() (abi.TokenAmount, error)
+
+// SafeSendThresholdFunc returns the value above which messages fail the
+// safety checks, zero if there is no threshold
+type SafeSendThresholdFunc func() (abi.TokenAmount, error)
@@ -27,6 +27,10 @@ type (
 	// UniversalBlockstore is the cold blockstore.
 	UniversalBlockstore blockstore.Blockstore
 
+	// SharedBlockstore is a read-only blockstore shared by several nodes,
+	// serving the blocks missing in the UniversalBlockstore.
+	SharedBlockstore blockstore.Blockstore
+
 	// HotBlockstore is the Hot blockstore abstraction for the splitstore
 	HotBlockstore blockstore.Blockstore
 
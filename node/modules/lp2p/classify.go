@@ -0,0 +1,249 @@
+package lp2p
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"go.uber.org/fx"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+// Peer classes, from the most to the least valuable. A peer is in the most
+// valuable class of the protocols it has streams open with.
+const (
+	PeerClassBootstrap = "bootstrap"
+	PeerClassProtected = "protected"
+	PeerClassMarket    = "market"
+	PeerClassGraphsync = "graphsync"
+	PeerClassPubsub    = "pubsub"
+	PeerClassOther     = "other"
+)
+
+var classRank = map[string]int{
+	PeerClassBootstrap: 0,
+	PeerClassProtected: 1,
+	PeerClassMarket:    2,
+	PeerClassGraphsync: 3,
+	PeerClassPubsub:    4,
+	PeerClassOther:     5,
+}
+
+var protocolClasses = []struct {
+	prefix string
+	class  string
+}{
+	{"/fil/storage/", PeerClassMarket},
+	{"/fil/retrieval/", PeerClassMarket},
+	{"/fil/datatransfer/", PeerClassMarket},
+	{"/ipfs/graphsync/", PeerClassGraphsync},
+	{"/meshsub/", PeerClassPubsub},
+	{"/floodsub/", PeerClassPubsub},
+}
+
+// Connection manager protection tags
+const (
+	protectTagConfig    = "config-prot"
+	protectTagBootstrap = "bootstrap"
+	protectTagMarket    = "market"
+)
+
+const (
+	classifyInterval = 30 * time.Second
+
+	// classMemory is how long a peer keeps its class without open streams,
+	// protocols like graphsync only open streams while transferring data
+	classMemory = 10 * time.Minute
+)
+
+type classified struct {
+	class string
+	seen  time.Time
+}
+
+// PeerClassifier classifies the connected peers, protects the market peers
+// and trims the pubsub and graphsync peers over their configured limits
+type PeerClassifier struct {
+	h   host.Host
+	cfg config.Libp2p
+
+	lk      sync.Mutex
+	classes map[peer.ID]classified
+}
+
+func NewPeerClassifier(cfg config.Libp2p) func(h host.Host) *PeerClassifier {
+	return func(h host.Host) *PeerClassifier {
+		return &PeerClassifier{
+			h:       h,
+			cfg:     cfg,
+			classes: map[peer.ID]classified{},
+		}
+	}
+}
+
+func RunPeerClassifier(mctx helpers.MetricsCtx, lc fx.Lifecycle, pc *PeerClassifier) {
+	go pc.Run(helpers.LifecycleCtx(mctx, lc))
+}
+
+func (pc *PeerClassifier) Run(ctx context.Context) {
+	tick := build.Clock.Ticker(classifyInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			pc.enforce()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Classify returns the class of a peer
+func (pc *PeerClassifier) Classify(p peer.ID) string {
+	cm := pc.h.ConnManager()
+	switch {
+	case cm.IsProtected(p, protectTagBootstrap):
+		return PeerClassBootstrap
+	case cm.IsProtected(p, protectTagConfig):
+		return PeerClassProtected
+	}
+
+	class := PeerClassOther
+	for _, c := range pc.h.Network().ConnsToPeer(p) {
+		for _, s := range c.GetStreams() {
+			if sc := protocolClass(s.Protocol()); classRank[sc] < classRank[class] {
+				class = sc
+			}
+		}
+	}
+
+	pc.lk.Lock()
+	defer pc.lk.Unlock()
+
+	now := build.Clock.Now()
+	if prev, ok := pc.classes[p]; ok && classRank[prev.class] <= classRank[class] && now.Sub(prev.seen) < classMemory {
+		if prev.class == class {
+			pc.classes[p] = classified{class: class, seen: now}
+		}
+		return prev.class
+	}
+	if class != PeerClassOther {
+		pc.classes[p] = classified{class: class, seen: now}
+	}
+	return class
+}
+
+// Peers returns the class of the connected peers
+func (pc *PeerClassifier) Peers() []api.NetPeerClass {
+	cm := pc.h.ConnManager()
+
+	peers := pc.h.Network().Peers()
+	out := make([]api.NetPeerClass, 0, len(peers))
+	for _, p := range peers {
+		out = append(out, api.NetPeerClass{
+			ID:        p,
+			Class:     pc.Classify(p),
+			Protected: cm.IsProtected(p, ""),
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Class != out[j].Class {
+			return classRank[out[i].Class] < classRank[out[j].Class]
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+func (pc *PeerClassifier) enforce() {
+	cm := pc.h.ConnManager()
+
+	byClass := map[string][]peer.ID{}
+	connected := map[peer.ID]struct{}{}
+	for _, p := range pc.h.Network().Peers() {
+		connected[p] = struct{}{}
+
+		class := pc.Classify(p)
+		byClass[class] = append(byClass[class], p)
+
+		if class == PeerClassMarket && pc.cfg.ProtectMarketPeers {
+			cm.Protect(p, protectTagMarket)
+		} else {
+			cm.Unprotect(p, protectTagMarket)
+		}
+	}
+
+	pc.lk.Lock()
+	for p, c := range pc.classes {
+		if _, ok := connected[p]; ok {
+			continue
+		}
+		cm.Unprotect(p, protectTagMarket)
+		if build.Clock.Since(c.seen) >= classMemory {
+			delete(pc.classes, p)
+		}
+	}
+	pc.lk.Unlock()
+
+	pc.trim(PeerClassPubsub, byClass[PeerClassPubsub], pc.cfg.PubsubPeersHigh)
+	pc.trim(PeerClassGraphsync, byClass[PeerClassGraphsync], pc.cfg.GraphsyncPeersHigh)
+}
+
+// trim closes the connections to the unprotected peers of a class over its
+// limit, least valuable to the connection manager first
+func (pc *PeerClassifier) trim(class string, peers []peer.ID, high uint) {
+	if high == 0 || len(peers) <= int(high) {
+		return
+	}
+	cm := pc.h.ConnManager()
+
+	type candidate struct {
+		p     peer.ID
+		value int
+	}
+	var candidates []candidate
+	for _, p := range peers {
+		if cm.IsProtected(p, "") {
+			continue
+		}
+		c := candidate{p: p}
+		if ti := cm.GetTagInfo(p); ti != nil {
+			c.value = ti.Value
+		}
+		candidates = append(candidates, c)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].value < candidates[j].value
+	})
+
+	excess := len(peers) - int(high)
+	if excess > len(candidates) {
+		excess = len(candidates)
+	}
+	for _, c := range candidates[:excess] {
+		log.Debugw("closing connection over the peer class limit", "peer", c.p, "class", class, "limit", high)
+		if err := pc.h.Network().ClosePeer(c.p); err != nil {
+			log.Warnw("closing connection to peer", "peer", c.p, "error", err)
+		}
+	}
+}
+
+func protocolClass(proto protocol.ID) string {
+	for _, pc := range protocolClasses {
+		if strings.HasPrefix(string(proto), pc.prefix) {
+			return pc.class
+		}
+	}
+	return PeerClassOther
+}
@@ -76,7 +76,7 @@ func ConnectionManager(low, high uint, grace time.Duration, protected []string)
 				return Libp2pOpts{}, xerrors.Errorf("failed to parse peer ID in protected peers array: %w", err)
 			}
 
-			cm.Protect(pid, "config-prot")
+			cm.Protect(pid, protectTagConfig)
 		}
 
 		infos, err := build.BuiltinBootstrap()
@@ -85,7 +85,7 @@ func ConnectionManager(low, high uint, grace time.Duration, protected []string)
 		}
 
 		for _, inf := range infos {
-			cm.Protect(inf.ID, "bootstrap")
+			cm.Protect(inf.ID, protectTagBootstrap)
 		}
 
 		return Libp2pOpts{
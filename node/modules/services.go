@@ -3,6 +3,7 @@ package modules
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -16,23 +17,33 @@ import (
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-fil-markets/discovery"
 	discoveryimpl "github.com/filecoin-project/go-fil-markets/discovery/impl"
+	"github.com/filecoin-project/go-state-types/abi"
 
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain"
+	"github.com/filecoin-project/lotus/chain/actorindex"
 	"github.com/filecoin-project/lotus/chain/beacon"
 	"github.com/filecoin-project/lotus/chain/beacon/drand"
 	"github.com/filecoin-project/lotus/chain/exchange"
+	"github.com/filecoin-project/lotus/chain/gasstats"
+	"github.com/filecoin-project/lotus/chain/gen/slashfilter/slashsvc"
 	"github.com/filecoin-project/lotus/chain/messagepool"
+	"github.com/filecoin-project/lotus/chain/snapshot"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/sub"
+	"github.com/filecoin-project/lotus/chain/sub/propagation"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
 	"github.com/filecoin-project/lotus/lib/peermgr"
 	marketevents "github.com/filecoin-project/lotus/markets/loggers"
+	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/hello"
+	"github.com/filecoin-project/lotus/node/impl/full"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/helpers"
 	"github.com/filecoin-project/lotus/node/repo"
@@ -135,11 +146,20 @@ func waitForSync(stmgr *stmgr.StateManager, epochs int, subscribe func()) {
 	})
 }
 
-func HandleIncomingBlocks(mctx helpers.MetricsCtx, lc fx.Lifecycle, ps *pubsub.PubSub, s *chain.Syncer, bserv dtypes.ChainBlockService, chain *store.ChainStore, stmgr *stmgr.StateManager, h host.Host, nn dtypes.NetworkName) {
+func NewPropagationTracker(_ dtypes.AfterGenesisSet, cs *store.ChainStore) (*propagation.Tracker, error) {
+	gen, err := cs.GetGenesis()
+	if err != nil {
+		return nil, xerrors.Errorf("getting genesis: %w", err)
+	}
+
+	return propagation.NewTracker(time.Unix(int64(gen.Timestamp), 0)), nil
+}
+
+func HandleIncomingBlocks(mctx helpers.MetricsCtx, lc fx.Lifecycle, ps *pubsub.PubSub, s *chain.Syncer, bserv dtypes.ChainBlockService, chain *store.ChainStore, stmgr *stmgr.StateManager, h host.Host, nn dtypes.NetworkName, pt *propagation.Tracker) {
 	ctx := helpers.LifecycleCtx(mctx, lc)
 
 	v := sub.NewBlockValidator(
-		h.ID(), chain, stmgr,
+		h.ID(), chain, stmgr, pt,
 		func(p peer.ID) {
 			ps.BlacklistPeer(p)
 			h.ConnManager().TagPeer(p, "badblock", -1000)
@@ -159,10 +179,10 @@ func HandleIncomingBlocks(mctx helpers.MetricsCtx, lc fx.Lifecycle, ps *pubsub.P
 	go sub.HandleIncomingBlocks(ctx, blocksub, s, bserv, h.ConnManager())
 }
 
-func HandleIncomingMessages(mctx helpers.MetricsCtx, lc fx.Lifecycle, ps *pubsub.PubSub, stmgr *stmgr.StateManager, mpool *messagepool.MessagePool, h host.Host, nn dtypes.NetworkName, bootstrapper dtypes.Bootstrapper) {
+func HandleIncomingMessages(mctx helpers.MetricsCtx, lc fx.Lifecycle, ps *pubsub.PubSub, stmgr *stmgr.StateManager, mpool *messagepool.MessagePool, h host.Host, nn dtypes.NetworkName, bootstrapper dtypes.Bootstrapper, pt *propagation.Tracker) {
 	ctx := helpers.LifecycleCtx(mctx, lc)
 
-	v := sub.NewMessageValidator(h.ID(), mpool)
+	v := sub.NewMessageValidator(h.ID(), mpool, pt)
 
 	if err := ps.RegisterTopicValidator(build.MessagesTopic(nn), v.Validate); err != nil {
 		panic(err)
@@ -218,6 +238,19 @@ func BuiltinDrandConfig() dtypes.DrandSchedule {
 	return build.DrandConfigSchedule()
 }
 
+// DrandConfigWithServers adds servers to the drand network currently in use,
+// after the built-in ones
+func DrandConfigWithServers(servers []string) func() dtypes.DrandSchedule {
+	return func() dtypes.DrandSchedule {
+		sched := build.DrandConfigSchedule()
+
+		cur := &sched[len(sched)-1]
+		cur.Config.Servers = append(append([]string{}, cur.Config.Servers...), servers...)
+
+		return sched
+	}
+}
+
 func RandomSchedule(p RandomBeaconParams, _ dtypes.AfterGenesisSet) (beacon.Schedule, error) {
 	gen, err := p.Cs.GetGenesis()
 	if err != nil {
@@ -248,3 +281,114 @@ func OpenFilesystemJournal(lr repo.LockedRepo, lc fx.Lifecycle, disabled journal
 
 	return jrnl, err
 }
+
+type ConsensusFaultReportAPI struct {
+	fx.In
+
+	full.GasAPI
+	full.MpoolAPI
+	full.WalletAPI
+}
+
+var _ slashsvc.ReportAPI = &ConsensusFaultReportAPI{}
+
+func ConsensusFaultDetector(cfg config.FaultReporterConfig) func(sm *stmgr.StateManager, ds dtypes.MetadataDS, api ConsensusFaultReportAPI) (*slashsvc.Detector, error) {
+	return func(sm *stmgr.StateManager, ds dtypes.MetadataDS, api ConsensusFaultReportAPI) (*slashsvc.Detector, error) {
+		var from address.Address
+		if cfg.ConsensusFaultReporterAddress != "" {
+			var err error
+			from, err = address.NewFromString(cfg.ConsensusFaultReporterAddress)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing consensus fault reporter address: %w", err)
+			}
+		}
+
+		return slashsvc.New(slashsvc.Config{
+			AutoReport: cfg.ConsensusFaultReporterAutoReport,
+			From:       from,
+			MaxFee:     abi.TokenAmount(cfg.ConsensusFaultReporterMaxFee),
+		}, &api, sm, namespace.Wrap(ds, slashsvc.FaultsPrefix)), nil
+	}
+}
+
+func RunConsensusFaultDetector(mctx helpers.MetricsCtx, lc fx.Lifecycle, s *chain.Syncer, d *slashsvc.Detector) error {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+
+	blocks, err := s.IncomingBlocks(ctx)
+	if err != nil {
+		return xerrors.Errorf("subscribing to incoming blocks: %w", err)
+	}
+
+	go d.Run(ctx, blocks)
+	return nil
+}
+
+// SetValidationBudget makes the syncer alert when validating a tipset takes
+// longer than the configured budget.
+func SetValidationBudget(cfg config.SyncConfig) func(s *chain.Syncer, al *alerting.Alerting) {
+	return func(s *chain.Syncer, al *alerting.Alerting) {
+		s.SetValidationBudget(al, time.Duration(cfg.ValidationBudget), cfg.ValidationTimingWindow)
+	}
+}
+
+func ActorIndex(cfg config.ActorIndex) func(cs *store.ChainStore, ds dtypes.MetadataDS) (*actorindex.Index, error) {
+	return func(cs *store.ChainStore, ds dtypes.MetadataDS) (*actorindex.Index, error) {
+		return actorindex.New(cs, namespace.Wrap(ds, actorindex.Prefix), abi.ChainEpoch(cfg.BackfillEpochs))
+	}
+}
+
+func RunActorIndex(mctx helpers.MetricsCtx, lc fx.Lifecycle, ix *actorindex.Index) {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	go ix.Run(ctx)
+}
+
+func GasStats(cfg config.FeeConfig) func(cs *store.ChainStore) *gasstats.Tracker {
+	return func(cs *store.ChainStore) *gasstats.Tracker {
+		return gasstats.New(cs, abi.ChainEpoch(cfg.GasUsageEpochs))
+	}
+}
+
+func RunGasStats(mctx helpers.MetricsCtx, lc fx.Lifecycle, t *gasstats.Tracker) {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	go t.Run(ctx)
+}
+
+// ChainSnapshots creates the service exporting chain snapshots.
+func ChainSnapshots(cfg config.ChainSnapshots) func(cs *store.ChainStore, r repo.LockedRepo) (*snapshot.Service, error) {
+	return func(cs *store.ChainStore, r repo.LockedRepo) (*snapshot.Service, error) {
+		if cfg.Interval <= 0 {
+			return nil, xerrors.Errorf("Chainstore.Snapshots.Interval must be positive")
+		}
+		if cfg.RecentStateroots < int64(build.Finality) {
+			return nil, xerrors.Errorf("Chainstore.Snapshots.RecentStateroots must be at least %d", build.Finality)
+		}
+
+		scfg := snapshot.Config{
+			Path:             cfg.Path,
+			Interval:         time.Duration(cfg.Interval),
+			Retention:        cfg.Retention,
+			RecentStateroots: abi.ChainEpoch(cfg.RecentStateroots),
+			PublicURL:        cfg.PublicURL,
+		}
+		if scfg.Path == "" {
+			scfg.Path = filepath.Join(r.Path(), "snapshots")
+		}
+		if cfg.S3Endpoint != "" {
+			scfg.S3 = &snapshot.S3Config{
+				Endpoint:  cfg.S3Endpoint,
+				Region:    cfg.S3Region,
+				Bucket:    cfg.S3Bucket,
+				Prefix:    cfg.S3Prefix,
+				AccessKey: cfg.S3AccessKey,
+				SecretKey: cfg.S3SecretKey,
+			}
+		}
+
+		return snapshot.New(cs, scfg)
+	}
+}
+
+func RunChainSnapshots(mctx helpers.MetricsCtx, lc fx.Lifecycle, s *snapshot.Service) {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	go s.Run(ctx)
+}
@@ -3,8 +3,14 @@ package modules
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -47,6 +53,7 @@ import (
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-statestore"
 	"github.com/filecoin-project/go-storedcounter"
+	storage2 "github.com/filecoin-project/specs-storage/storage"
 
 	"github.com/filecoin-project/lotus/api"
 	sectorstorage "github.com/filecoin-project/lotus/extern/sector-storage"
@@ -55,6 +62,7 @@ import (
 	sealing "github.com/filecoin-project/lotus/extern/storage-sealing"
 	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
 
+	"github.com/filecoin-project/lotus/api/client"
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/api/v1api"
 	"github.com/filecoin-project/lotus/blockstore"
@@ -65,6 +73,10 @@ import (
 	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/lib/apiinfo"
+	"github.com/filecoin-project/lotus/lib/proofparams"
+	"github.com/filecoin-project/lotus/lib/rpctls"
 	"github.com/filecoin-project/lotus/markets"
 	marketevents "github.com/filecoin-project/lotus/markets/loggers"
 	"github.com/filecoin-project/lotus/markets/retrievaladapter"
@@ -74,6 +86,7 @@ import (
 	"github.com/filecoin-project/lotus/node/modules/helpers"
 	"github.com/filecoin-project/lotus/node/repo"
 	"github.com/filecoin-project/lotus/storage"
+	"github.com/filecoin-project/lotus/storage/sectorblocks"
 )
 
 var StorageCounterDSPrefix = "/storage/nextid"
@@ -107,6 +120,75 @@ func GetParams(spt abi.RegisteredSealProof) error {
 	return nil
 }
 
+// GetParamsFrom fetches the proof parameters from the configured mirrors,
+// with the default fetcher when there are none
+func GetParamsFrom(cfg config.ProofParamsConfig) func(spt abi.RegisteredSealProof) error {
+	if len(cfg.Mirrors) == 0 {
+		return GetParams
+	}
+
+	return func(spt abi.RegisteredSealProof) error {
+		if build.DisableBuiltinAssets {
+			return nil
+		}
+
+		files, err := paramFiles(spt)
+		if err != nil {
+			return err
+		}
+
+		f := &proofparams.Fetcher{Mirrors: cfg.Mirrors, Segments: cfg.Segments}
+		if err := f.Fetch(context.TODO(), proofparams.Dir(), files); err != nil {
+			return xerrors.Errorf("fetching proof parameters: %w", err)
+		}
+		return nil
+	}
+}
+
+// RunParamsVerifier checks the proof parameter files in the background, and
+// fetches the corrupted ones again
+func RunParamsVerifier(cfg config.ProofParamsConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, spt abi.RegisteredSealProof) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, spt abi.RegisteredSealProof) error {
+		if cfg.VerifyInterval == 0 || build.DisableBuiltinAssets {
+			return nil
+		}
+
+		files, err := paramFiles(spt)
+		if err != nil {
+			return err
+		}
+
+		f := &proofparams.Fetcher{Mirrors: cfg.Mirrors, Segments: cfg.Segments}
+		ctx := helpers.LifecycleCtx(mctx, lc)
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go f.RunVerifier(ctx, proofparams.Dir(), files, time.Duration(cfg.VerifyInterval))
+				return nil
+			},
+		})
+		return nil
+	}
+}
+
+// ProofProver aggregates proofs with the external prover configured for
+// aggregation, or in process
+func ProofProver(m *sectorstorage.Manager) ffiwrapper.Prover {
+	return m.ProofProver()
+}
+
+func paramFiles(spt abi.RegisteredSealProof) ([]proofparams.File, error) {
+	ssize, err := spt.SectorSize()
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := proofparams.ParseManifest(build.ParametersJSON(), build.SrsJSON())
+	if err != nil {
+		return nil, err
+	}
+	return m.For(uint64(ssize)), nil
+}
+
 func MinerAddress(ds dtypes.MetadataDS) (dtypes.MinerAddress, error) {
 	ma, err := minerAddrFromDS(ds)
 	return dtypes.MinerAddress(ma), err
@@ -138,7 +220,9 @@ func SealProofType(maddr dtypes.MinerAddress, fnapi v1api.FullNode) (abi.Registe
 }
 
 type sidsc struct {
-	sc *storedcounter.StoredCounter
+	ds   datastore.Datastore
+	name datastore.Key
+	sc   *storedcounter.StoredCounter
 }
 
 func (s *sidsc) Next() (abi.SectorNumber, error) {
@@ -146,9 +230,24 @@ func (s *sidsc) Next() (abi.SectorNumber, error) {
 	return abi.SectorNumber(i), err
 }
 
+func (s *sidsc) SkipTo(n abi.SectorNumber) error {
+	if n == 0 {
+		return nil
+	}
+
+	// the counter holds the last returned number
+	buf := make([]byte, binary.MaxVarintLen64)
+	size := binary.PutUvarint(buf, uint64(n)-1)
+	return s.ds.Put(s.name, buf[:size])
+}
+
 func SectorIDCounter(ds dtypes.MetadataDS) sealing.SectorIDCounter {
-	sc := storedcounter.New(ds, datastore.NewKey(StorageCounterDSPrefix))
-	return &sidsc{sc}
+	name := datastore.NewKey(StorageCounterDSPrefix)
+	return &sidsc{
+		ds:   ds,
+		name: name,
+		sc:   storedcounter.New(ds, name),
+	}
 }
 
 func AddressSelector(addrConf *config.MinerAddressConfig) func() (*storage.AddressSelector, error) {
@@ -205,56 +304,234 @@ type StorageMinerParams struct {
 	Verifier           ffiwrapper.Verifier
 	Prover             ffiwrapper.Prover
 	GetSealingConfigFn dtypes.GetSealingConfigFunc
+	GetFeeConfigFn     sealing.GetFeeConfigFunc
 	Journal            journal.Journal
 	AddrSel            *storage.AddressSelector
+	PoStCoordinator    *storage.PoStCoordinator `optional:"true"`
+	FaultPredictor     *stores.FaultPredictor   `optional:"true"`
 }
 
-func StorageMiner(fc config.MinerFeeConfig) func(params StorageMinerParams) (*storage.Miner, error) {
-	return func(params StorageMinerParams) (*storage.Miner, error) {
-		var (
-			ds     = params.MetadataDS
-			mctx   = params.MetricsCtx
-			lc     = params.Lifecycle
-			api    = params.API
-			sealer = params.Sealer
-			h      = params.Host
-			sc     = params.SectorIDCounter
-			verif  = params.Verifier
-			prover = params.Prover
-			gsd    = params.GetSealingConfigFn
-			j      = params.Journal
-			as     = params.AddrSel
-		)
-
-		maddr, err := minerAddrFromDS(ds)
+func StorageMiner(params StorageMinerParams) (*storage.Miner, error) {
+	var (
+		ds     = params.MetadataDS
+		mctx   = params.MetricsCtx
+		lc     = params.Lifecycle
+		api    = params.API
+		sealer = params.Sealer
+		h      = params.Host
+		sc     = params.SectorIDCounter
+		verif  = params.Verifier
+		prover = params.Prover
+		gsd    = params.GetSealingConfigFn
+		gfc    = params.GetFeeConfigFn
+		j      = params.Journal
+		as     = params.AddrSel
+	)
+
+	maddr, err := minerAddrFromDS(ds)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := helpers.LifecycleCtx(mctx, lc)
+
+	fps, err := storage.NewWindowedPoStScheduler(api, gfc, as, sealer, verif, sealer, j, maddr)
+	if err != nil {
+		return nil, err
+	}
+	fps.SetCoordinator(params.PoStCoordinator)
+	fps.SetFaultPredictor(params.FaultPredictor)
+
+	sm, err := storage.NewMiner(api, maddr, h, ds, sealer, sc, verif, prover, gsd, gfc, j, as)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go fps.Run(ctx)
+			return sm.Run(ctx)
+		},
+		OnStop: sm.Stop,
+	})
+
+	return sm, nil
+}
+
+// AdditionalMiners sets up the sealing pipelines and window PoSt schedulers
+// of the additional miner actors served by this miner, sharing its sector
+// storage and workers.
+func AdditionalMiners(cfg config.MultiMinerConfig) func(params StorageMinerParams, mgr *sectorstorage.Manager) (storage.AdditionalMiners, error) {
+	return func(params StorageMinerParams, mgr *sectorstorage.Manager) (storage.AdditionalMiners, error) {
+		primary, err := minerAddrFromDS(params.MetadataDS)
 		if err != nil {
 			return nil, err
 		}
 
-		ctx := helpers.LifecycleCtx(mctx, lc)
+		if cfg.PrimaryQuota > 0 {
+			mid, err := address.IDFromAddress(primary)
+			if err != nil {
+				return nil, err
+			}
+			mgr.SetMinerQuota(abi.ActorID(mid), cfg.PrimaryQuota)
+		}
 
-		fps, err := storage.NewWindowedPoStScheduler(api, fc, as, sealer, verif, sealer, j, maddr)
-		if err != nil {
-			return nil, err
+		ctx := helpers.LifecycleCtx(params.MetricsCtx, params.Lifecycle)
+
+		miners := storage.AdditionalMiners{}
+		for _, mc := range cfg.Miners {
+			maddr, err := address.NewFromString(mc.Address)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing additional miner address: %w", err)
+			}
+			mid, err := address.IDFromAddress(maddr)
+			if err != nil {
+				return nil, xerrors.Errorf("additional miner %s: must be an ID address: %w", maddr, err)
+			}
+			if maddr == primary {
+				return nil, xerrors.Errorf("additional miner %s is the primary miner", maddr)
+			}
+			if _, ok := miners[maddr]; ok {
+				return nil, xerrors.Errorf("additional miner %s is configured twice", maddr)
+			}
+
+			as, err := AddressSelector(&mc.Addresses)()
+			if err != nil {
+				return nil, xerrors.Errorf("additional miner %s: %w", maddr, err)
+			}
+
+			// sealing state and sector numbers of each miner are kept apart
+			// from the primary miner's
+			ds := namespace.Wrap(params.MetadataDS, datastore.NewKey("/miners").ChildString(maddr.String()))
+			sc := SectorIDCounter(ds)
+
+			fps, err := storage.NewWindowedPoStScheduler(params.API, params.GetFeeConfigFn, as, params.Sealer, params.Verifier, params.Sealer, params.Journal, maddr)
+			if err != nil {
+				return nil, err
+			}
+			fps.SetCoordinator(params.PoStCoordinator)
+			fps.SetFaultPredictor(params.FaultPredictor)
+
+			sm, err := storage.NewMiner(params.API, maddr, params.Host, ds, params.Sealer, sc, params.Verifier, params.Prover, params.GetSealingConfigFn, params.GetFeeConfigFn, params.Journal, as)
+			if err != nil {
+				return nil, err
+			}
+
+			mgr.SetMinerQuota(abi.ActorID(mid), mc.Quota)
+
+			params.Lifecycle.Append(fx.Hook{
+				OnStart: func(context.Context) error {
+					go fps.Run(ctx)
+					return sm.Run(ctx)
+				},
+				OnStop: sm.Stop,
+			})
+
+			miners[maddr] = sm
 		}
 
-		sm, err := storage.NewMiner(api, maddr, h, ds, sealer, sc, verif, prover, gsd, fc, j, as)
+		return miners, nil
+	}
+}
+
+// PoStCoordinator takes turns between the window PoSt schedulers of the
+// primary and additional miners, so that proofs of overlapping deadlines
+// don't compete for the GPUs
+func PoStCoordinator(cfg config.MultiMinerConfig) func(ds dtypes.MetadataDS) (*storage.PoStCoordinator, error) {
+	return func(ds dtypes.MetadataDS) (*storage.PoStCoordinator, error) {
+		primary, err := minerAddrFromDS(ds)
 		if err != nil {
 			return nil, err
 		}
 
+		c := storage.NewPoStCoordinator(cfg.PoStParallel)
+		c.SetPriority(primary, cfg.PrimaryPoStPriority)
+		for _, mc := range cfg.Miners {
+			maddr, err := address.NewFromString(mc.Address)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing additional miner address: %w", err)
+			}
+			c.SetPriority(maddr, mc.PoStPriority)
+		}
+		return c, nil
+	}
+}
+
+func NewAlerting(cfg config.AlertingConfig) func(j journal.Journal) *alerting.Alerting {
+	return func(j journal.Journal) *alerting.Alerting {
+		var sinks []alerting.Sink
+		for _, u := range cfg.WebhookURLs {
+			sinks = append(sinks, &alerting.WebhookSink{URL: u})
+		}
+		if cfg.SMTP.Server != "" {
+			sinks = append(sinks, &alerting.SMTPSink{
+				Server:   cfg.SMTP.Server,
+				Username: cfg.SMTP.Username,
+				Password: cfg.SMTP.Password,
+				From:     cfg.SMTP.From,
+				To:       cfg.SMTP.To,
+			})
+		}
+		if cfg.PagerDuty.RoutingKey != "" {
+			sinks = append(sinks, &alerting.PagerDutySink{
+				URL:        cfg.PagerDuty.URL,
+				RoutingKey: cfg.PagerDuty.RoutingKey,
+			})
+		}
+
+		return alerting.NewAlertingSystem(j, sinks...)
+	}
+}
+
+func RunAlertChecker(cfg config.AlertingConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, api v1api.FullNode, maddr dtypes.MinerAddress, m *storage.Miner, index *stores.Index, fp *stores.FaultPredictor, as *storage.AddressSelector, al *alerting.Alerting) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, api v1api.FullNode, maddr dtypes.MinerAddress, m *storage.Miner, index *stores.Index, fp *stores.FaultPredictor, as *storage.AddressSelector, al *alerting.Alerting) {
+		ac := storage.NewAlertChecker(api, address.Address(maddr), m, index, fp, as, al, cfg)
+
+		ctx := helpers.LifecycleCtx(mctx, lc)
 		lc.Append(fx.Hook{
 			OnStart: func(context.Context) error {
-				go fps.Run(ctx)
-				return sm.Run(ctx)
+				go ac.Run(ctx)
+				return nil
 			},
-			OnStop: sm.Stop,
 		})
+	}
+}
 
-		return sm, nil
+func PayoutScheduler(cfg config.PayoutConfig) func(api v1api.FullNode, maddr dtypes.MinerAddress, ds dtypes.MetadataDS, j journal.Journal) (*storage.PayoutScheduler, error) {
+	return func(api v1api.FullNode, maddr dtypes.MinerAddress, ds dtypes.MetadataDS, j journal.Journal) (*storage.PayoutScheduler, error) {
+		return storage.NewPayoutScheduler(api, address.Address(maddr), ds, j, cfg)
 	}
 }
 
+func RunPayoutScheduler(mctx helpers.MetricsCtx, lc fx.Lifecycle, ps *storage.PayoutScheduler, m *storage.Miner) {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	ps.SetFeeBreaker(m.FeeBreaker())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go ps.Run(ctx)
+			return nil
+		},
+	})
+}
+
+func WorkerRotator(api v1api.FullNode, maddr dtypes.MinerAddress, ds dtypes.MetadataDS, j journal.Journal, al *alerting.Alerting) (*storage.WorkerRotator, error) {
+	return storage.NewWorkerRotator(api, address.Address(maddr), ds, j, al)
+}
+
+func RunWorkerRotator(mctx helpers.MetricsCtx, lc fx.Lifecycle, wr *storage.WorkerRotator) {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go wr.Run(ctx)
+			return nil
+		},
+	})
+}
+
+func OwnerChangeGuard(api v1api.FullNode, maddr dtypes.MinerAddress) *storage.OwnerChangeGuard {
+	return storage.NewOwnerChangeGuard(api, maddr)
+}
+
 func HandleRetrieval(host host.Host, lc fx.Lifecycle, m retrievalmarket.RetrievalProvider, j journal.Journal) {
 	m.OnReady(marketevents.ReadyLogger("retrieval provider"))
 	lc.Append(fx.Hook{
@@ -440,27 +717,68 @@ func StagingGraphsync(mctx helpers.MetricsCtx, lc fx.Lifecycle, ibs dtypes.Stagi
 	return gs
 }
 
-func SetupBlockProducer(lc fx.Lifecycle, ds dtypes.MetadataDS, api v1api.FullNode, epp gen.WinningPoStProver, sf *slashfilter.SlashFilter, j journal.Journal) (*lotusminer.Miner, error) {
-	minerAddr, err := minerAddrFromDS(ds)
-	if err != nil {
-		return nil, err
+func SetupBlockProducer(cfg config.WinningPoStConfig) func(lc fx.Lifecycle, ds dtypes.MetadataDS, api v1api.FullNode, epp gen.WinningPoStProver, sf *slashfilter.SlashFilter, j journal.Journal, al *alerting.Alerting) (*lotusminer.Miner, error) {
+	return func(lc fx.Lifecycle, ds dtypes.MetadataDS, api v1api.FullNode, epp gen.WinningPoStProver, sf *slashfilter.SlashFilter, j journal.Journal, al *alerting.Alerting) (*lotusminer.Miner, error) {
+		minerAddr, err := minerAddrFromDS(ds)
+		if err != nil {
+			return nil, err
+		}
+
+		m := lotusminer.NewMiner(api, epp, minerAddr, sf, j)
+		m.SetLatencyAlert(al, time.Duration(cfg.AlertMargin))
+		if err := m.SetAttemptStore(ds); err != nil {
+			return nil, xerrors.Errorf("loading mining attempts: %w", err)
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				if err := m.Start(ctx); err != nil {
+					return err
+				}
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				return m.Stop(ctx)
+			},
+		})
+
+		return m, nil
 	}
+}
 
-	m := lotusminer.NewMiner(api, epp, minerAddr, sf, j)
+// WinningPoStProverWithFallback races the local winning PoSt prover against
+// the configured fallback node.
+func WinningPoStProverWithFallback(cfg config.WinningPoStConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, api v1api.FullNode, prover storage2.Prover, verifier ffiwrapper.Verifier, miner dtypes.MinerID, certs *rpctls.Certs) (*storage.StorageWpp, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, api v1api.FullNode, prover storage2.Prover, verifier ffiwrapper.Verifier, miner dtypes.MinerID, certs *rpctls.Certs) (*storage.StorageWpp, error) {
+		wpp, err := storage.NewWinningPoStProver(api, prover, verifier, miner)
+		if err != nil {
+			return nil, err
+		}
 
-	lc.Append(fx.Hook{
-		OnStart: func(ctx context.Context) error {
-			if err := m.Start(ctx); err != nil {
-				return err
-			}
-			return nil
-		},
-		OnStop: func(ctx context.Context) error {
-			return m.Stop(ctx)
-		},
-	})
+		ai := apiinfo.Parse(cfg.FallbackAPIInfo)
+
+		url, err := ai.DialArgs("v0")
+		if err != nil {
+			return nil, err
+		}
+		if url, err = certs.ClientURL(url); err != nil {
+			return nil, err
+		}
+
+		fb, closer, err := client.NewStorageMinerRPCV0(mctx, url, ai.AuthHeader())
+		if err != nil {
+			return nil, xerrors.Errorf("creating jsonrpc client: %w", err)
+		}
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				closer()
+				return nil
+			},
+		})
 
-	return m, nil
+		wpp.SetFallback(fb)
+		return wpp, nil
+	}
 }
 
 func NewStorageAsk(ctx helpers.MetricsCtx, fapi v1api.FullNode, ds dtypes.MetadataDS, minerAddress dtypes.MinerAddress, spn storagemarket.StorageProviderNode) (*storedask.StoredAsk, error) {
@@ -486,6 +804,7 @@ func BasicDealFilter(user dtypes.StorageDealFilter) func(onlineOk dtypes.Conside
 	unverifiedOk dtypes.ConsiderUnverifiedStorageDealsConfigFunc,
 	blocklistFunc dtypes.StorageDealPieceCidBlocklistConfigFunc,
 	expectedSealTimeFunc dtypes.GetExpectedSealDurationFunc,
+	sealingCfg dtypes.GetSealingConfigFunc,
 	spn storagemarket.StorageProviderNode) dtypes.StorageDealFilter {
 	return func(onlineOk dtypes.ConsiderOnlineStorageDealsConfigFunc,
 		offlineOk dtypes.ConsiderOfflineStorageDealsConfigFunc,
@@ -493,9 +812,20 @@ func BasicDealFilter(user dtypes.StorageDealFilter) func(onlineOk dtypes.Conside
 		unverifiedOk dtypes.ConsiderUnverifiedStorageDealsConfigFunc,
 		blocklistFunc dtypes.StorageDealPieceCidBlocklistConfigFunc,
 		expectedSealTimeFunc dtypes.GetExpectedSealDurationFunc,
+		sealingCfg dtypes.GetSealingConfigFunc,
 		spn storagemarket.StorageProviderNode) dtypes.StorageDealFilter {
 
 		return func(ctx context.Context, deal storagemarket.MinerDeal) (bool, string, error) {
+			scfg, err := sealingCfg()
+			if err != nil {
+				return false, "miner error", err
+			}
+
+			if scfg.MaintenanceMode {
+				log.Warnf("miner is in maintenance mode; rejecting storage deal proposal from client: %s", deal.Client.String())
+				return false, "miner is in maintenance mode", nil
+			}
+
 			b, err := onlineOk()
 			if err != nil {
 				return false, "miner error", err
@@ -602,9 +932,9 @@ func StorageProvider(minerAddress dtypes.MinerAddress,
 }
 
 func RetrievalDealFilter(userFilter dtypes.RetrievalDealFilter) func(onlineOk dtypes.ConsiderOnlineRetrievalDealsConfigFunc,
-	offlineOk dtypes.ConsiderOfflineRetrievalDealsConfigFunc) dtypes.RetrievalDealFilter {
+	offlineOk dtypes.ConsiderOfflineRetrievalDealsConfigFunc, pricing *retrievaladapter.RetrievalPricing) dtypes.RetrievalDealFilter {
 	return func(onlineOk dtypes.ConsiderOnlineRetrievalDealsConfigFunc,
-		offlineOk dtypes.ConsiderOfflineRetrievalDealsConfigFunc) dtypes.RetrievalDealFilter {
+		offlineOk dtypes.ConsiderOfflineRetrievalDealsConfigFunc, pricing *retrievaladapter.RetrievalPricing) dtypes.RetrievalDealFilter {
 		return func(ctx context.Context, state retrievalmarket.ProviderDealState) (bool, string, error) {
 			b, err := onlineOk()
 			if err != nil {
@@ -625,6 +955,29 @@ func RetrievalDealFilter(userFilter dtypes.RetrievalDealFilter) func(onlineOk dt
 				log.Info("offline retrieval has not been implemented yet")
 			}
 
+			// The retrieval ask is quoted to all clients and checked by the
+			// retrieval provider before deals get here, so the price for the
+			// client and piece is checked here
+			if pricing.Enabled() {
+				var piece cid.Cid
+				switch {
+				case state.PieceInfo != nil:
+					piece = state.PieceInfo.PieceCID
+				case state.PieceCID != nil:
+					piece = *state.PieceCID
+				}
+
+				price, err := pricing.Price(state.Receiver, piece)
+				if err != nil {
+					return false, "miner error", err
+				}
+
+				if state.PricePerByte.LessThan(price.PricePerByte) || state.UnsealPrice.LessThan(price.UnsealPrice) {
+					return false, fmt.Sprintf("retrieval price for this client is %s per byte with an unseal price of %s",
+						types.FIL(price.PricePerByte), types.FIL(price.UnsealPrice)), nil
+				}
+			}
+
 			if userFilter != nil {
 				return userFilter(ctx, state)
 			}
@@ -634,9 +987,13 @@ func RetrievalDealFilter(userFilter dtypes.RetrievalDealFilter) func(onlineOk dt
 	}
 }
 
+func RetrievalPricing(ds dtypes.MetadataDS, sp storagemarket.StorageProvider) (*retrievaladapter.RetrievalPricing, error) {
+	return retrievaladapter.NewRetrievalPricing(ds, sp.ListLocalDeals)
+}
+
 // RetrievalProvider creates a new retrieval provider attached to the provider blockstore
 func RetrievalProvider(h host.Host,
-	miner *storage.Miner,
+	sb sectorblocks.SectorBuilder,
 	full v1api.FullNode,
 	ds dtypes.MetadataDS,
 	pieceStore dtypes.ProviderPieceStore,
@@ -644,14 +1001,15 @@ func RetrievalProvider(h host.Host,
 	dt dtypes.ProviderDataTransfer,
 	pieceProvider sectorstorage.PieceProvider,
 	userFilter dtypes.RetrievalDealFilter,
+	tierer *stores.Tierer,
 ) (retrievalmarket.RetrievalProvider, error) {
-	adapter := retrievaladapter.NewRetrievalProviderNode(miner, pieceProvider, full)
-
 	maddr, err := minerAddrFromDS(ds)
 	if err != nil {
 		return nil, err
 	}
 
+	adapter := retrievaladapter.NewRetrievalProviderNode(maddr, sb, pieceProvider, full, tierer)
+
 	netwk := rmnet.NewFromLibp2pHost(h)
 	opt := retrievalimpl.DealDeciderOpt(retrievalimpl.DealDecider(userFilter))
 
@@ -659,28 +1017,176 @@ func RetrievalProvider(h host.Host,
 }
 
 var WorkerCallsPrefix = datastore.NewKey("/worker/calls")
+var WorkerResultsPrefix = datastore.NewKey("/worker/results")
 var ManagerWorkPrefix = datastore.NewKey("/stmgr/calls")
+var SealingServicePrefix = datastore.NewKey("/sealsvc/sectors")
+var SealingServiceLocalPrefix = datastore.NewKey("/sealsvc/local")
+var WorkReceiptsPrefix = datastore.NewKey("/sealsvc/receipts")
 
 func LocalStorage(mctx helpers.MetricsCtx, lc fx.Lifecycle, ls stores.LocalStorage, si stores.SectorIndex, urls sectorstorage.URLs) (*stores.Local, error) {
 	ctx := helpers.LifecycleCtx(mctx, lc)
 	return stores.NewLocal(ctx, ls, si, urls)
 }
 
-func RemoteStorage(lstor *stores.Local, si stores.SectorIndex, sa sectorstorage.StorageAuth, sc sectorstorage.SealerConfig) *stores.Remote {
-	return stores.NewRemote(lstor, si, http.Header(sa), sc.ParallelFetchLimit, &stores.DefaultPartialFileHandler{})
+// ObjectStorage returns nil when no object store is configured.
+func ObjectStorage(cfg stores.ObjectStoreConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, lstor *stores.Local, si stores.SectorIndex, r repo.LockedRepo) (*stores.ObjectStore, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, lstor *stores.Local, si stores.SectorIndex, r repo.LockedRepo) (*stores.ObjectStore, error) {
+		if cfg.Endpoint == "" {
+			return nil, nil
+		}
+
+		if cfg.CacheDir == "" {
+			cfg.CacheDir = filepath.Join(r.Path(), "objcache")
+		}
+
+		ctx := helpers.LifecycleCtx(mctx, lc)
+		return stores.NewObjectStore(ctx, lstor, si, cfg)
+	}
+}
+
+func RemoteStorage(lstor *stores.Local, obj *stores.ObjectStore, si stores.SectorIndex, sa sectorstorage.StorageAuth, sc sectorstorage.SealerConfig, certs *rpctls.Certs) *stores.Remote {
+	var local stores.Store = lstor
+	if obj != nil {
+		local = obj
+	}
+	remote := stores.NewRemote(local, si, http.Header(sa), sc.ParallelFetchLimit, &stores.DefaultPartialFileHandler{})
+	remote.SetHTTPClient(certs.HTTPClient())
+	return remote
+}
+
+func SectorMover(mctx helpers.MetricsCtx, lc fx.Lifecycle, ds dtypes.MetadataDS, lstor *stores.Local, idx *stores.Index) *stores.Mover {
+	mover := stores.NewMover(helpers.LifecycleCtx(mctx, lc), lstor, idx, namespace.Wrap(ds, datastore.NewKey("/storage/moves")))
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return mover.Resume()
+		},
+	})
+	return mover
 }
 
-func SectorStorage(mctx helpers.MetricsCtx, lc fx.Lifecycle, lstor *stores.Local, stor *stores.Remote, ls stores.LocalStorage, si stores.SectorIndex, sc sectorstorage.SealerConfig, ds dtypes.MetadataDS) (*sectorstorage.Manager, error) {
+// SectorTierer returns nil when tiering is disabled.
+func SectorTierer(cfg config.TieringConfig) func(maddr dtypes.MinerAddress, ds dtypes.MetadataDS, idx *stores.Index, mover *stores.Mover) (*stores.Tierer, error) {
+	return func(maddr dtypes.MinerAddress, ds dtypes.MetadataDS, idx *stores.Index, mover *stores.Mover) (*stores.Tierer, error) {
+		if !cfg.Enable {
+			return nil, nil
+		}
+
+		mid, err := address.IDFromAddress(address.Address(maddr))
+		if err != nil {
+			return nil, err
+		}
+
+		return stores.NewTierer(stores.TieringPolicy{
+			HotGroup:      cfg.HotGroup,
+			ColdGroup:     cfg.ColdGroup,
+			DemoteAfter:   time.Duration(cfg.DemoteAfter),
+			CheckInterval: time.Duration(cfg.CheckInterval),
+		}, abi.ActorID(mid), idx, mover, namespace.Wrap(ds, datastore.NewKey("/storage/tiers")))
+	}
+}
+
+// SectorFaultPredictor returns nil when fault prediction is disabled.
+func SectorFaultPredictor(cfg config.FaultPredictionConfig) func(local *stores.Local, idx *stores.Index, mover *stores.Mover) (*stores.FaultPredictor, error) {
+	return func(local *stores.Local, idx *stores.Index, mover *stores.Mover) (*stores.FaultPredictor, error) {
+		if !cfg.Enable {
+			return nil, nil
+		}
+
+		collectors := []stores.TelemetryCollector{stores.LatencyProbe{}}
+		if cfg.TelemetryCommand != "" {
+			collectors = append(collectors, stores.CommandCollector{Command: cfg.TelemetryCommand})
+		}
+
+		return stores.NewFaultPredictor(stores.PredictionPolicy{
+			CheckInterval:          time.Duration(cfg.CheckInterval),
+			MaxReallocatedSectors:  cfg.MaxReallocatedSectors,
+			MaxPendingSectors:      cfg.MaxPendingSectors,
+			MaxUncorrectableErrors: cfg.MaxUncorrectableErrors,
+			MaxLatency:             time.Duration(cfg.MaxLatency),
+			LatencySamples:         cfg.LatencySamples,
+			Action:                 stores.PredictionAction(cfg.Action),
+		}, local, idx, mover, collectors...)
+	}
+}
+
+func RunFaultPredictor(mctx helpers.MetricsCtx, lc fx.Lifecycle, fp *stores.FaultPredictor) {
+	if fp == nil {
+		return
+	}
+
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go fp.Run(ctx)
+			return nil
+		},
+	})
+}
+
+type RunSectorTieringParams struct {
+	fx.In
+
+	Lifecycle  fx.Lifecycle
+	MetricsCtx helpers.MetricsCtx
+	Tierer     *stores.Tierer
+
+	// not set when the markets subsystem is disabled
+	RetrievalProvider retrievalmarket.RetrievalProvider `optional:"true"`
+}
+
+func RunSectorTiering(p RunSectorTieringParams) {
+	mctx, lc, t, rp := p.MetricsCtx, p.Lifecycle, p.Tierer, p.RetrievalProvider
+	if t == nil {
+		return
+	}
+
+	// sectors of retrieval deals which are still transferring data
+	active := func(ctx context.Context) (map[abi.SectorNumber]struct{}, error) {
+		out := map[abi.SectorNumber]struct{}{}
+		if rp == nil {
+			return out, nil
+		}
+		for _, deal := range rp.ListDeals() {
+			switch {
+			case deal.PieceInfo == nil,
+				retrievalmarket.IsTerminalStatus(deal.Status),
+				deal.Status == retrievalmarket.DealStatusErrored,
+				deal.Status == retrievalmarket.DealStatusCancelled:
+				continue
+			}
+			for _, d := range deal.PieceInfo.Deals {
+				out[d.SectorID] = struct{}{}
+			}
+		}
+		return out, nil
+	}
+
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go t.Run(ctx, active)
+			return nil
+		},
+	})
+}
+
+func SectorStorage(mctx helpers.MetricsCtx, lc fx.Lifecycle, lstor *stores.Local, stor *stores.Remote, ls stores.LocalStorage, si stores.SectorIndex, sc sectorstorage.SealerConfig, ds dtypes.MetadataDS, sealingCfg dtypes.GetSealingConfigFunc, certs *rpctls.Certs) (*sectorstorage.Manager, error) {
 	ctx := helpers.LifecycleCtx(mctx, lc)
 
 	wsts := statestore.New(namespace.Wrap(ds, WorkerCallsPrefix))
 	smsts := statestore.New(namespace.Wrap(ds, ManagerWorkPrefix))
 
-	sst, err := sectorstorage.New(ctx, lstor, stor, ls, si, sc, wsts, smsts)
+	sst, err := sectorstorage.New(ctx, lstor, stor, ls, si, sc, wsts, smsts, certs)
 	if err != nil {
 		return nil, err
 	}
 
+	scfg, err := sealingCfg()
+	if err != nil {
+		return nil, xerrors.Errorf("getting sealing config: %w", err)
+	}
+	sst.SetDispatchPaused(scfg.MaintenanceMode)
+
 	lc.Append(fx.Hook{
 		OnStop: sst.Close,
 	})
@@ -688,6 +1194,91 @@ func SectorStorage(mctx helpers.MetricsCtx, lc fx.Lifecycle, lstor *stores.Local
 	return sst, nil
 }
 
+// UnsealQueue queues unseals done by the sector manager, limiting the
+// concurrent unseals per storage path as configured.
+func UnsealQueue(m *sectorstorage.Manager, si stores.SectorIndex, sc sectorstorage.SealerConfig) *sectorstorage.UnsealQueue {
+	return sectorstorage.NewUnsealQueue(si, m, sc.ParallelUnsealsPerPath)
+}
+
+const SectorURLSecretName = "sector-url-secret" //nolint:gosec
+
+// SectorURLSigner signs URLs of sector files handed to third parties, with a
+// secret kept in the keystore so that signed URLs outlive restarts.
+func SectorURLSigner(keystore types.KeyStore) (*stores.URLSigner, error) {
+	key, err := keystore.Get(SectorURLSecretName)
+
+	if errors.Is(err, types.ErrKeyInfoNotFound) {
+		log.Warn("Generating new sector URL secret")
+
+		sk, err := ioutil.ReadAll(io.LimitReader(rand.Reader, 32))
+		if err != nil {
+			return nil, err
+		}
+
+		key = types.KeyInfo{
+			Type:       KTJwtHmacSecret,
+			PrivateKey: sk,
+		}
+
+		if err := keystore.Put(SectorURLSecretName, key); err != nil {
+			return nil, xerrors.Errorf("writing sector URL secret: %w", err)
+		}
+	} else if err != nil {
+		return nil, xerrors.Errorf("could not get sector URL secret: %w", err)
+	}
+
+	return stores.NewURLSigner(key.PrivateKey), nil
+}
+
+// ConnectSealingService makes the sector manager delegate sealing of new
+// sectors to the configured sealing service.
+func ConnectSealingService(cfg config.SealingServiceConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, m *sectorstorage.Manager, us *stores.URLSigner, ds dtypes.MetadataDS) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, m *sectorstorage.Manager, us *stores.URLSigner, ds dtypes.MetadataDS) error {
+		ai := apiinfo.Parse(cfg.APIInfo)
+
+		var keys []ed25519.PublicKey
+		for _, k := range cfg.ReceiptKeys {
+			kb, err := hex.DecodeString(k)
+			if err != nil || len(kb) != ed25519.PublicKeySize {
+				return xerrors.Errorf("invalid receipt key %q", k)
+			}
+			keys = append(keys, kb)
+		}
+
+		url, err := ai.DialArgs("v0")
+		if err != nil {
+			return err
+		}
+
+		svc, closer, err := client.NewSealingServiceRPCV0(mctx, url, ai.AuthHeader())
+		if err != nil {
+			return xerrors.Errorf("creating jsonrpc client: %w", err)
+		}
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				closer()
+				return nil
+			},
+		})
+
+		v, err := svc.Version(mctx)
+		if err != nil {
+			return xerrors.Errorf("getting sealing service version: %w", err)
+		}
+		if !v.EqMajorMinor(api.SealingServiceAPIVersion0) {
+			return xerrors.Errorf("sealing service API version doesn't match: expected: %s, got: %s", api.SealingServiceAPIVersion0, v)
+		}
+
+		m.SetSealingService(svc, sectorstorage.SealingServiceOpts{
+			MaxSectors:  cfg.MaxSectors,
+			URLSigner:   us,
+			ServiceAuth: ai.AuthHeader(),
+			ReceiptKeys: keys,
+		}, namespace.Wrap(ds, SealingServicePrefix), namespace.Wrap(ds, SealingServiceLocalPrefix), namespace.Wrap(ds, WorkReceiptsPrefix))
+		return nil
+	}
+}
+
 func StorageAuth(ctx helpers.MetricsCtx, ca v0api.Common) (sectorstorage.StorageAuth, error) {
 	token, err := ca.AuthNew(ctx, []auth.Permission{"admin"})
 	if err != nil {
@@ -851,6 +1442,18 @@ func NewSetSealConfigFunc(r repo.LockedRepo) (dtypes.SetSealingConfigFunc, error
 				TerminateBatchMax:  cfg.TerminateBatchMax,
 				TerminateBatchMin:  cfg.TerminateBatchMin,
 				TerminateBatchWait: config.Duration(cfg.TerminateBatchWait),
+
+				CommittedCapacitySectorLifetime: config.Duration(cfg.CommittedCapacitySectorLifetime),
+				DealSectorExpiration:            cfg.DealSectorExpiration,
+				ExpirationLadderSteps:           cfg.ExpirationLadderSteps,
+				ExpirationLadderStep:            config.Duration(cfg.ExpirationLadderStep),
+
+				MaxProofRegenerations: cfg.MaxProofRegenerations,
+
+				MaintenanceMode: cfg.MaintenanceMode,
+
+				StateWebhookURLs:   cfg.StateWebhookURLs,
+				StateWebhookStates: cfg.StateWebhookStates,
 			}
 		})
 		return
@@ -883,12 +1486,33 @@ func NewGetSealConfigFunc(r repo.LockedRepo) (dtypes.GetSealingConfigFunc, error
 				TerminateBatchMax:  cfg.Sealing.TerminateBatchMax,
 				TerminateBatchMin:  cfg.Sealing.TerminateBatchMin,
 				TerminateBatchWait: time.Duration(cfg.Sealing.TerminateBatchWait),
+
+				CommittedCapacitySectorLifetime: time.Duration(cfg.Sealing.CommittedCapacitySectorLifetime),
+				DealSectorExpiration:            cfg.Sealing.DealSectorExpiration,
+				ExpirationLadderSteps:           cfg.Sealing.ExpirationLadderSteps,
+				ExpirationLadderStep:            time.Duration(cfg.Sealing.ExpirationLadderStep),
+
+				MaxProofRegenerations: cfg.Sealing.MaxProofRegenerations,
+
+				MaintenanceMode: cfg.Sealing.MaintenanceMode,
+
+				StateWebhookURLs:   cfg.Sealing.StateWebhookURLs,
+				StateWebhookStates: cfg.Sealing.StateWebhookStates,
 			}
 		})
 		return
 	}, nil
 }
 
+func NewGetFeeConfigFunc(r repo.LockedRepo) (sealing.GetFeeConfigFunc, error) {
+	return func() (out config.MinerFeeConfig, err error) {
+		err = readCfg(r, func(cfg *config.StorageMiner) {
+			out = cfg.Fees
+		})
+		return
+	}, nil
+}
+
 func NewSetExpectedSealDurationFunc(r repo.LockedRepo) (dtypes.SetExpectedSealDurationFunc, error) {
 	return func(delay time.Duration) (err error) {
 		err = mutateCfg(r, func(cfg *config.StorageMiner) {
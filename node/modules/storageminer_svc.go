@@ -0,0 +1,111 @@
+package modules
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/client"
+	sectorstorage "github.com/filecoin-project/lotus/extern/sector-storage"
+	"github.com/filecoin-project/lotus/lib/apiinfo"
+	"github.com/filecoin-project/lotus/lib/rpcenc"
+	"github.com/filecoin-project/lotus/lib/rpctls"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+// MinerSealingService is the storage miner API of the process running the
+// sealing subsystem, used by a markets-only node
+type MinerSealingService api.StorageMiner
+
+// ConnectSealerAPI connects to the storage miner API of the sealing process
+// at the given api info
+func ConnectSealerAPI(apiInfo string) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, certs *rpctls.Certs) (MinerSealingService, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, certs *rpctls.Certs) (MinerSealingService, error) {
+		ctx := helpers.LifecycleCtx(mctx, lc)
+
+		ai := apiinfo.Parse(apiInfo)
+		addr, err := ai.DialArgs("v0")
+		if err != nil {
+			return nil, xerrors.Errorf("parsing sealer api info: %w", err)
+		}
+
+		// the push URL of streamed readers is derived from the forwarded address
+		if addr, err = certs.ClientURL(addr); err != nil {
+			return nil, xerrors.Errorf("sealer api url: %w", err)
+		}
+
+		pushURL, err := streamsPushURL(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Infof("connecting to the sealing subsystem at %s", addr)
+		mapi, closer, err := client.NewStorageMinerRPCV0(ctx, addr, ai.AuthHeader(), rpcenc.ReaderParamEncoder(pushURL))
+		if err != nil {
+			return nil, xerrors.Errorf("creating jsonrpc client: %w", err)
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				v, err := mapi.Version(ctx)
+				if err != nil {
+					return xerrors.Errorf("getting sealer api version: %w", err)
+				}
+				if !v.APIVersion.EqMajorMinor(api.MinerAPIVersion0) {
+					return xerrors.Errorf("sealer API version doesn't match: expected: %s, got: %s", api.MinerAPIVersion0, v.APIVersion)
+				}
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				closer()
+				return nil
+			},
+		})
+
+		return mapi, nil
+	}
+}
+
+// SealerStorageAuth authenticates the storage requests of a markets-only
+// node, like reads of unsealed sector data, with a token minted by the sealer
+// for them. The token only grants reading sector files, unlike the admin
+// token the sealer API is called with.
+func SealerStorageAuth(mctx helpers.MetricsCtx, lc fx.Lifecycle, sealer MinerSealingService) (sectorstorage.StorageAuth, error) {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+
+	token, err := sealer.AuthNew(ctx, []auth.Permission{auth.Permission(api.RemoteReadScope.String())})
+	if err != nil {
+		return nil, xerrors.Errorf("creating storage token on the sealer: %w", err)
+	}
+
+	headers := http.Header{}
+	headers.Add("Authorization", "Bearer "+string(token))
+	return sectorstorage.StorageAuth(headers), nil
+}
+
+// streamsPushURL returns the endpoint receiving the streamed readers of rpc
+// calls, e.g. the piece data of SectorAddPieceToAny
+func streamsPushURL(addr string) (string, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", xerrors.Errorf("parsing address: %w", err)
+	}
+
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	}
+	// /rpc/v0 -> /rpc/streams/v0/push
+	u.Path = path.Join(u.Path, "../streams/v0/push")
+
+	return u.String(), nil
+}
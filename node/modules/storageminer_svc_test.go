@@ -0,0 +1,19 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamsPushURL(t *testing.T) {
+	for addr, expect := range map[string]string{
+		"ws://127.0.0.1:2345/rpc/v0":      "http://127.0.0.1:2345/rpc/streams/v0/push",
+		"wss://miner.example:2345/rpc/v0": "https://miner.example:2345/rpc/streams/v0/push",
+		"http://127.0.0.1:2345/rpc/v0":    "http://127.0.0.1:2345/rpc/streams/v0/push",
+	} {
+		u, err := streamsPushURL(addr)
+		require.NoError(t, err, addr)
+		require.Equal(t, expect, u, addr)
+	}
+}
@@ -2,6 +2,7 @@ package node
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"net"
 	"net/http"
@@ -23,6 +24,9 @@ import (
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/filecoin-project/lotus/journal/audit"
+	"github.com/filecoin-project/lotus/lib/rpcenc"
+	"github.com/filecoin-project/lotus/lib/tunnel"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/node/impl"
 )
@@ -42,6 +46,20 @@ func ServeRPC(h http.Handler, id string, addr multiaddr.Multiaddr) (StopFunc, er
 		return nil, xerrors.Errorf("could not listen: %w", err)
 	}
 
+	return serveRPC(h, id, manet.NetListener(lst))
+}
+
+// ServeTLSRPC is ServeRPC over TLS, with the given config.
+func ServeTLSRPC(h http.Handler, id string, addr multiaddr.Multiaddr, cfg *tls.Config) (StopFunc, error) {
+	lst, err := manet.Listen(addr)
+	if err != nil {
+		return nil, xerrors.Errorf("could not listen: %w", err)
+	}
+
+	return serveRPC(h, id, tls.NewListener(manet.NetListener(lst), cfg))
+}
+
+func serveRPC(h http.Handler, id string, lst net.Listener) (StopFunc, error) {
 	// Instantiate the server and start listening.
 	srv := &http.Server{
 		Handler: h,
@@ -52,13 +70,13 @@ func ServeRPC(h http.Handler, id string, addr multiaddr.Multiaddr) (StopFunc, er
 	}
 
 	go func() {
-		err = srv.Serve(manet.NetListener(lst))
+		err := srv.Serve(lst)
 		if err != http.ErrServerClosed {
 			rpclog.Warnf("rpc server failed: %s", err)
 		}
 	}()
 
-	return srv.Shutdown, err
+	return srv.Shutdown, nil
 }
 
 // FullNodeHandler returns a full node handler, to be mounted as-is on the server.
@@ -71,7 +89,7 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 
 		var handler http.Handler = rpcServer
 		if permissioned {
-			handler = &auth.Handler{Verify: a.AuthVerify, Next: rpcServer.ServeHTTP}
+			handler = audit.TokenHandler(&auth.Handler{Verify: a.AuthVerify, Next: rpcServer.ServeHTTP})
 		}
 
 		m.Handle(path, handler)
@@ -80,6 +98,11 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 	fnapi := metrics.MetricedFullAPI(a)
 	if permissioned {
 		fnapi = api.PermissionedFullAPI(fnapi)
+		if fa, ok := a.(*impl.FullNodeAPI); ok {
+			fnapi = audit.AuditedFullAPI(fnapi, fa.Journal)
+		} else {
+			rpclog.Warnf("full node API %T has no journal, privileged calls won't be audited", a)
+		}
 	}
 
 	serveRpc("/rpc/v1", fnapi)
@@ -115,13 +138,22 @@ func MinerHandler(a api.StorageMiner, permissioned bool) (http.Handler, error) {
 	mapi := metrics.MetricedStorMinerAPI(a)
 	if permissioned {
 		mapi = api.PermissionedStorMinerAPI(mapi)
+		if ma, ok := a.(*impl.StorageMinerAPI); ok {
+			mapi = audit.AuditedStorMinerAPI(mapi, ma.Journal)
+		} else {
+			rpclog.Warnf("miner API %T has no journal, privileged calls won't be audited", a)
+		}
 	}
 
-	rpcServer := jsonrpc.NewServer()
+	readerHandler, readerServerOpt := rpcenc.ReaderParamDecoder()
+	rpcServer := jsonrpc.NewServer(readerServerOpt)
 	rpcServer.Register("Filecoin", mapi)
 
 	m.Handle("/rpc/v0", rpcServer)
+	m.Handle("/rpc/streams/v0/push/{uuid}", readerHandler)
 	m.PathPrefix("/remote").HandlerFunc(a.(*impl.StorageMinerAPI).ServeRemote)
+	m.HandleFunc("/rpc/worker-tunnel/v0", a.(*impl.StorageMinerAPI).ServeWorkerTunnel)
+	m.PathPrefix(tunnel.RemotePrefix).HandlerFunc(a.(*impl.StorageMinerAPI).ServeWorkerRemote)
 
 	// debugging
 	m.Handle("/debug/metrics", metrics.Exporter())
@@ -135,7 +167,7 @@ func MinerHandler(a api.StorageMiner, permissioned bool) (http.Handler, error) {
 		Verify: a.AuthVerify,
 		Next:   m.ServeHTTP,
 	}
-	return ah, nil
+	return audit.TokenHandler(ah), nil
 }
 
 func handleImport(a *impl.FullNodeAPI) func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,287 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
+	sealing "github.com/filecoin-project/lotus/extern/storage-sealing"
+	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+// wdPoStAlertMargin is how close to the deadline close we need to be to
+// consider an unproven partition as missed.
+const wdPoStAlertMargin = abi.ChainEpoch(20)
+
+// AlertChecker periodically evaluates the miner alert conditions, raising and
+// resolving the related alerts:
+//   - window PoSt deadlines which are about to close with unproven partitions
+//   - worker and control addresses with balance below the configured threshold
+//   - sectors stuck in a failed state for longer than the configured timeout
+//   - storage paths which stopped sending heartbeats, or report errors
+//   - local storage paths which the fault predictor flags as at risk
+type AlertChecker struct {
+	api     fullNodeFilteredAPI
+	maddr   address.Address
+	miner   *Miner
+	index   *stores.Index
+	faults  *stores.FaultPredictor
+	addrSel *AddressSelector
+	al      *alerting.Alerting
+	cfg     config.AlertingConfig
+
+	failedSectors alerting.AlertType
+}
+
+// NewAlertChecker creates an alert checker; fp is nil when fault prediction
+// is disabled.
+func NewAlertChecker(api fullNodeFilteredAPI, maddr address.Address, m *Miner, index *stores.Index, fp *stores.FaultPredictor, as *AddressSelector, al *alerting.Alerting, cfg config.AlertingConfig) *AlertChecker {
+	return &AlertChecker{
+		api:     api,
+		maddr:   maddr,
+		miner:   m,
+		index:   index,
+		faults:  fp,
+		addrSel: as,
+		al:      al,
+		cfg:     cfg,
+
+		failedSectors: al.AddAlertType("sealing", "failed-sectors"),
+	}
+}
+
+func (ac *AlertChecker) Run(ctx context.Context) {
+	tick := time.NewTicker(time.Duration(ac.cfg.CheckInterval))
+	defer tick.Stop()
+
+	for {
+		ac.check(ctx)
+
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (ac *AlertChecker) check(ctx context.Context) {
+	if err := ac.checkWindowPoSt(ctx); err != nil {
+		log.Errorw("checking window PoSt alerts", "error", err)
+	}
+	if err := ac.checkBalances(ctx); err != nil {
+		log.Errorw("checking address balance alerts", "error", err)
+	}
+	if err := ac.checkFailedSectors(); err != nil {
+		log.Errorw("checking failed sector alerts", "error", err)
+	}
+	if err := ac.checkStorage(ctx); err != nil {
+		log.Errorw("checking storage path alerts", "error", err)
+	}
+}
+
+func (ac *AlertChecker) checkWindowPoSt(ctx context.Context) error {
+	head, err := ac.api.ChainHead(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	di, err := ac.api.StateMinerProvingDeadline(ctx, ac.maddr, head.Key())
+	if err != nil {
+		return xerrors.Errorf("getting proving deadline: %w", err)
+	}
+	deadlines, err := ac.api.StateMinerDeadlines(ctx, ac.maddr, head.Key())
+	if err != nil {
+		return xerrors.Errorf("getting deadlines: %w", err)
+	}
+	if di.Index >= uint64(len(deadlines)) {
+		return xerrors.Errorf("proving deadline %d out of range", di.Index)
+	}
+
+	partitions, err := ac.api.StateMinerPartitions(ctx, ac.maddr, di.Index, head.Key())
+	if err != nil {
+		return xerrors.Errorf("getting partitions: %w", err)
+	}
+
+	var unproven []uint64
+	for pIdx, p := range partitions {
+		active, err := p.ActiveSectors.Count()
+		if err != nil {
+			return xerrors.Errorf("counting active sectors: %w", err)
+		}
+		recovering, err := p.RecoveringSectors.Count()
+		if err != nil {
+			return xerrors.Errorf("counting recovering sectors: %w", err)
+		}
+		if active+recovering == 0 {
+			continue
+		}
+
+		proven, err := deadlines[di.Index].PostSubmissions.IsSet(uint64(pIdx))
+		if err != nil {
+			return xerrors.Errorf("checking post submissions: %w", err)
+		}
+		if !proven {
+			unproven = append(unproven, uint64(pIdx))
+		}
+	}
+
+	at := ac.al.AddAlertType("wdpost", fmt.Sprintf("deadline-%d", di.Index))
+	switch {
+	case len(unproven) == 0:
+		ac.al.Resolve(at, map[string]interface{}{
+			"deadline": di.Index,
+			"epoch":    di.CurrentEpoch,
+		})
+	case di.Close-di.CurrentEpoch <= wdPoStAlertMargin:
+		ac.al.Raise(at, map[string]interface{}{
+			"deadline":   di.Index,
+			"partitions": unproven,
+			"close":      di.Close,
+			"epoch":      di.CurrentEpoch,
+		})
+	}
+
+	return nil
+}
+
+func (ac *AlertChecker) checkBalances(ctx context.Context) error {
+	mi, err := ac.api.StateMinerInfo(ctx, ac.maddr, types.EmptyTSK)
+	if err != nil {
+		return xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	addrs := append([]address.Address{mi.Worker}, mi.ControlAddresses...)
+	if ac.addrSel != nil {
+		addrs = append(addrs, ac.addrSel.PreCommitControl...)
+		addrs = append(addrs, ac.addrSel.CommitControl...)
+		addrs = append(addrs, ac.addrSel.TerminateControl...)
+	}
+
+	seen := map[address.Address]struct{}{}
+	for _, addr := range addrs {
+		if addr.Protocol() != address.ID {
+			id, err := ac.api.StateLookupID(ctx, addr, types.EmptyTSK)
+			if err != nil {
+				log.Warnw("looking up control address", "address", addr, "error", err)
+				continue
+			}
+			addr = id
+		}
+		if _, ok := seen[addr]; ok {
+			continue
+		}
+		seen[addr] = struct{}{}
+
+		bal, err := ac.api.WalletBalance(ctx, addr)
+		if err != nil {
+			return xerrors.Errorf("getting balance of %s: %w", addr, err)
+		}
+
+		at := ac.al.AddAlertType("balance", addr.String())
+		msg := map[string]interface{}{
+			"address":   addr.String(),
+			"balance":   types.FIL(bal).String(),
+			"threshold": ac.cfg.MinAddressBalance.String(),
+		}
+		if big.Cmp(bal, big.Int(ac.cfg.MinAddressBalance)) < 0 {
+			ac.al.Raise(at, msg)
+		} else {
+			ac.al.Resolve(at, msg)
+		}
+	}
+
+	return nil
+}
+
+func (ac *AlertChecker) checkFailedSectors() error {
+	sectors, err := ac.miner.ListSectors()
+	if err != nil {
+		return xerrors.Errorf("listing sectors: %w", err)
+	}
+
+	timeout := time.Duration(ac.cfg.FailedSectorTimeout)
+
+	stuck := map[string][]abi.SectorNumber{}
+	for _, si := range sectors {
+		if !sealing.IsFailedState(si.State) || len(si.Log) == 0 {
+			continue
+		}
+
+		since := time.Unix(int64(si.Log[len(si.Log)-1].Timestamp), 0)
+		if time.Since(since) < timeout {
+			continue
+		}
+
+		stuck[string(si.State)] = append(stuck[string(si.State)], si.SectorNumber)
+	}
+
+	if len(stuck) == 0 {
+		ac.al.Resolve(ac.failedSectors, "no sectors stuck in failed states")
+		return nil
+	}
+
+	for _, sns := range stuck {
+		sort.Slice(sns, func(i, j int) bool {
+			return sns[i] < sns[j]
+		})
+	}
+
+	ac.al.Raise(ac.failedSectors, map[string]interface{}{
+		"timeout": timeout.String(),
+		"sectors": stuck,
+	})
+
+	return nil
+}
+
+func (ac *AlertChecker) checkStorage(ctx context.Context) error {
+	health, err := ac.index.StorageHealth(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting storage health: %w", err)
+	}
+
+	for id, hi := range health {
+		at := ac.al.AddAlertType("storage", "path-"+string(id))
+		msg := map[string]interface{}{
+			"id":            id,
+			"lastHeartbeat": hi.LastHeartbeat,
+			"error":         hi.Err,
+		}
+		if hi.Online() {
+			ac.al.Resolve(at, msg)
+		} else {
+			ac.al.Raise(at, msg)
+		}
+	}
+
+	if ac.faults == nil {
+		return nil
+	}
+
+	for _, pr := range ac.faults.PathRisks() {
+		at := ac.al.AddAlertType("storage", "at-risk-"+string(pr.ID))
+		msg := map[string]interface{}{
+			"id":        pr.ID,
+			"reasons":   pr.Reasons,
+			"telemetry": pr.Telemetry,
+		}
+		if pr.AtRisk {
+			ac.al.Raise(at, msg)
+		} else {
+			ac.al.Resolve(at, msg)
+		}
+	}
+
+	return nil
+}
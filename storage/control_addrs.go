@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	miner2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+type controlAddrsAPI interface {
+	addrSelectApi
+
+	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (miner.MinerInfo, error)
+	MpoolPushMessage(context.Context, *types.Message, *api.MessageSendSpec) (*types.SignedMessage, error)
+}
+
+// PlanControlAddresses checks addrs as the full list of control addresses of
+// the miner, and compares it to the current list. as holds the control
+// addresses the config uses, it's nil on markets nodes.
+func PlanControlAddresses(ctx context.Context, a controlAddrsAPI, maddr address.Address, as *AddressSelector, addrs []address.Address, minBalance abi.TokenAmount) (*api.ControlAddressPlan, error) {
+	mi, err := a.StateMinerInfo(ctx, maddr, types.EmptyTSK)
+	if err != nil {
+		return nil, xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	plan := &api.ControlAddressPlan{}
+
+	var next []address.Address
+	seen := map[address.Address]struct{}{}
+	for _, addr := range addrs {
+		id, err := a.StateLookupID(ctx, addr, types.EmptyTSK)
+		if err != nil {
+			plan.Errors = append(plan.Errors, fmt.Sprintf("%s not found on chain, it must receive funds first", addr))
+			continue
+		}
+		key, err := a.StateAccountKey(ctx, id, types.EmptyTSK)
+		if err != nil {
+			plan.Errors = append(plan.Errors, fmt.Sprintf("%s is not an account: %s", addr, err))
+			continue
+		}
+
+		if _, ok := seen[id]; ok {
+			plan.Errors = append(plan.Errors, fmt.Sprintf("%s listed more than once", addr))
+			continue
+		}
+		seen[id] = struct{}{}
+
+		switch id {
+		case mi.Owner:
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s is the owner address", key))
+		case mi.Worker:
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s is the worker address", key))
+		}
+
+		bal, err := a.WalletBalance(ctx, id)
+		if err != nil {
+			return nil, xerrors.Errorf("getting balance of %s: %w", addr, err)
+		}
+		if bal.LessThan(minBalance) {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s balance %s is below %s", key, types.FIL(bal), types.FIL(minBalance)))
+		}
+
+		has, err := a.WalletHas(ctx, key)
+		if err != nil {
+			return nil, xerrors.Errorf("checking wallet: %w", err)
+		}
+		if !has {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s is not in the wallet of the node, the miner can't send messages from it", key))
+		}
+
+		plan.Addresses = append(plan.Addresses, api.ControlAddressCheck{
+			ID:       id,
+			Key:      key,
+			Balance:  bal,
+			InWallet: has,
+		})
+		next = append(next, id)
+	}
+
+	plan.Keep, plan.Add, plan.Remove = diffControlAddrs(mi.ControlAddresses, next)
+
+	if as != nil {
+		for _, id := range plan.Remove {
+			for _, use := range configUses(ctx, a, as, id) {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("removed %s is configured as %s control address", id, use))
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// diffControlAddrs compares the current and next control addresses, all ID
+// addresses.
+func diffControlAddrs(cur, next []address.Address) (keep, add, remove []address.Address) {
+	curSet := map[address.Address]struct{}{}
+	for _, id := range cur {
+		curSet[id] = struct{}{}
+	}
+	nextSet := map[address.Address]struct{}{}
+	for _, id := range next {
+		nextSet[id] = struct{}{}
+
+		if _, ok := curSet[id]; ok {
+			keep = append(keep, id)
+		} else {
+			add = append(add, id)
+		}
+	}
+	for _, id := range cur {
+		if _, ok := nextSet[id]; !ok {
+			remove = append(remove, id)
+		}
+	}
+	return keep, add, remove
+}
+
+// configUses returns the uses the address selector config has the control
+// address for.
+func configUses(ctx context.Context, a addrSelectApi, as *AddressSelector, id address.Address) []string {
+	var uses []string
+	for _, c := range []struct {
+		use   string
+		addrs []address.Address
+	}{
+		{"precommit", as.PreCommitControl},
+		{"commit", as.CommitControl},
+		{"terminate", as.TerminateControl},
+	} {
+		for _, addr := range c.addrs {
+			if addr.Protocol() != address.ID {
+				var err error
+				if addr, err = a.StateLookupID(ctx, addr, types.EmptyTSK); err != nil {
+					continue
+				}
+			}
+			if addr == id {
+				uses = append(uses, c.use)
+				break
+			}
+		}
+	}
+	return uses
+}
+
+// SetControlAddresses replaces the control addresses of the miner with addrs
+// in a single message, refusing to when PlanControlAddresses reports errors.
+// A pending worker change is kept.
+func SetControlAddresses(ctx context.Context, a controlAddrsAPI, maddr address.Address, as *AddressSelector, addrs []address.Address) (cid.Cid, error) {
+	plan, err := PlanControlAddresses(ctx, a, maddr, as, addrs, big.Zero())
+	if err != nil {
+		return cid.Undef, err
+	}
+	if len(plan.Errors) > 0 {
+		return cid.Undef, xerrors.Errorf("invalid control addresses: %s", plan.Errors[0])
+	}
+	if len(plan.Add) == 0 && len(plan.Remove) == 0 {
+		return cid.Undef, xerrors.Errorf("control addresses already set")
+	}
+
+	mi, err := a.StateMinerInfo(ctx, maddr, types.EmptyTSK)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	// keep proposing the pending worker, so that the pending change is left
+	// as it is
+	worker := mi.Worker
+	if !mi.NewWorker.Empty() {
+		worker = mi.NewWorker
+	}
+
+	ids := make([]address.Address, 0, len(plan.Addresses))
+	for _, c := range plan.Addresses {
+		ids = append(ids, c.ID)
+	}
+
+	params, err := actors.SerializeParams(&miner2.ChangeWorkerAddressParams{
+		NewWorker:       worker,
+		NewControlAddrs: ids,
+	})
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("serializing params: %w", err)
+	}
+
+	smsg, err := a.MpoolPushMessage(ctx, &types.Message{
+		From:   mi.Owner,
+		To:     maddr,
+		Method: miner.Methods.ChangeWorkerAddress,
+		Value:  big.Zero(),
+		Params: params,
+	}, nil)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("pushing message: %w", err)
+	}
+
+	log.Infow("control addresses change sent", "miner", maddr, "add", plan.Add, "remove", plan.Remove, "message", smsg.Cid())
+	return smsg.Cid(), nil
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+)
+
+func TestDiffControlAddrs(t *testing.T) {
+	id := func(i uint64) address.Address {
+		a, err := address.NewIDAddress(i)
+		require.NoError(t, err)
+		return a
+	}
+
+	keep, add, remove := diffControlAddrs([]address.Address{id(1), id(2), id(3)}, []address.Address{id(3), id(4), id(1)})
+	require.Equal(t, []address.Address{id(3), id(1)}, keep)
+	require.Equal(t, []address.Address{id(4)}, add)
+	require.Equal(t, []address.Address{id(2)}, remove)
+
+	keep, add, remove = diffControlAddrs(nil, []address.Address{id(1)})
+	require.Empty(t, keep)
+	require.Equal(t, []address.Address{id(1)}, add)
+	require.Empty(t, remove)
+
+	keep, add, remove = diffControlAddrs([]address.Address{id(1)}, nil)
+	require.Empty(t, keep)
+	require.Empty(t, add)
+	require.Equal(t, []address.Address{id(1)}, remove)
+}
@@ -35,7 +35,6 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 	sealing "github.com/filecoin-project/lotus/extern/storage-sealing"
 	"github.com/filecoin-project/lotus/journal"
-	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 )
 
@@ -51,7 +50,7 @@ var log = logging.Logger("storageminer")
 // Miner#Run starts the sealing FSM.
 type Miner struct {
 	api     fullNodeFilteredAPI
-	feeCfg  config.MinerFeeConfig
+	feeCfg  sealing.GetFeeConfigFunc
 	h       host.Host
 	sealer  sectorstorage.SectorManager
 	ds      datastore.Batching
@@ -66,6 +65,9 @@ type Miner struct {
 	sealing       *sealing.Sealing
 
 	sealingEvtType journal.EventType
+	events         *sectorEvents
+
+	feeBreaker *sealing.FeeBreaker
 
 	journal journal.Journal
 }
@@ -87,6 +89,7 @@ type fullNodeFilteredAPI interface {
 	StateMinerSectors(context.Context, address.Address, *bitfield.BitField, types.TipSetKey) ([]*miner.SectorOnChainInfo, error)
 	StateSectorPreCommitInfo(context.Context, address.Address, abi.SectorNumber, types.TipSetKey) (miner.SectorPreCommitOnChainInfo, error)
 	StateSectorGetInfo(context.Context, address.Address, abi.SectorNumber, types.TipSetKey) (*miner.SectorOnChainInfo, error)
+	StateSectorExpiration(context.Context, address.Address, abi.SectorNumber, types.TipSetKey) (*miner.SectorExpiration, error)
 	StateSectorPartition(ctx context.Context, maddr address.Address, sectorNumber abi.SectorNumber, tok types.TipSetKey) (*miner.SectorLocation, error)
 	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (miner.MinerInfo, error)
 	StateMinerDeadlines(context.Context, address.Address, types.TipSetKey) ([]api.Deadline, error)
@@ -137,7 +140,7 @@ func NewMiner(api fullNodeFilteredAPI,
 	verif ffiwrapper.Verifier,
 	prover ffiwrapper.Prover,
 	gsd dtypes.GetSealingConfigFunc,
-	feeCfg config.MinerFeeConfig,
+	feeCfg sealing.GetFeeConfigFunc,
 	journal journal.Journal,
 	as *AddressSelector) (*Miner, error) {
 	m := &Miner{
@@ -155,6 +158,8 @@ func NewMiner(api fullNodeFilteredAPI,
 		getSealConfig:  gsd,
 		journal:        journal,
 		sealingEvtType: journal.RegisterEventType("storage", "sealing_states"),
+		events:         newSectorEvents(),
+		feeBreaker:     sealing.NewFeeBreaker(feeCfg),
 	}
 
 	return m, nil
@@ -186,24 +191,26 @@ func (m *Miner) Run(ctx context.Context) error {
 		defaultDuration = policy.GetMaxSectorExpirationExtension() - (md.WPoStProvingPeriod * 2)
 		provingBoundary = md.PeriodStart % md.WPoStProvingPeriod
 
+		// sealing configuration.
+		cfg = sealing.GetSealingConfigFunc(m.getSealConfig)
+
 		// TODO: Maybe we update this policy after actor upgrades?
-		pcp = sealing.NewBasicPreCommitPolicy(adaptedAPI, defaultDuration, provingBoundary)
+		pcp = sealing.NewBasicPreCommitPolicy(adaptedAPI, cfg, defaultDuration, provingBoundary)
 
 		// address selector.
 		as = func(ctx context.Context, mi miner.MinerInfo, use api.AddrUse, goodFunds, minFunds abi.TokenAmount) (address.Address, abi.TokenAmount, error) {
 			return m.addrSel.AddressFor(ctx, m.api, mi, use, goodFunds, minFunds)
 		}
-
-		// sealing configuration.
-		cfg = sealing.GetSealingConfigFunc(m.getSealConfig)
 	)
 
 	// Instantiate the sealing FSM.
-	m.sealing = sealing.New(adaptedAPI, m.feeCfg, evtsAdapter, m.maddr, m.ds, m.sealer, m.sc, m.verif, m.prover, &pcp, cfg, m.handleSealingNotifications, as)
+	m.sealing = sealing.New(adaptedAPI, m.feeCfg, evtsAdapter, m.maddr, m.ds, m.sealer, m.sc, m.verif, m.prover, &pcp, cfg, m.handleSealingNotifications, as, m.feeBreaker)
 
 	// Run the sealing FSM.
 	go m.sealing.Run(ctx) //nolint:errcheck // logged intside the function
 
+	go m.events.runWebhooks(ctx, m.getSealConfig)
+
 	return nil
 }
 
@@ -217,12 +224,33 @@ func (m *Miner) handleSealingNotifications(before, after sealing.SectorInfo) {
 			Error:        after.LastErr,
 		}
 	})
+
+	m.events.publish(api.SectorStateChange{
+		Miner:        m.maddr,
+		SectorNumber: before.SectorNumber,
+		From:         api.SectorState(before.State),
+		To:           api.SectorState(after.State),
+		Error:        after.LastErr,
+		Time:         time.Now(),
+	})
+}
+
+// SubscribeStateChanges sends the sector state changes matching the filter to
+// ch, without blocking, until the returned function is called
+func (m *Miner) SubscribeStateChanges(filter api.SectorStateFilter, ch chan<- api.SectorStateChange) func() {
+	return m.events.subscribe(filter, ch)
 }
 
 func (m *Miner) Stop(ctx context.Context) error {
 	return m.sealing.Stop(ctx)
 }
 
+// ReloadConfig makes the sealing pipeline apply the current sealing and fee
+// config
+func (m *Miner) ReloadConfig() {
+	m.sealing.ReloadConfig()
+}
+
 // runPreflightChecks verifies that preconditions to run the miner are satisfied.
 func (m *Miner) runPreflightChecks(ctx context.Context) error {
 	mi, err := m.api.StateMinerInfo(ctx, m.maddr, types.EmptyTSK)
@@ -253,6 +281,8 @@ type StorageWpp struct {
 	verifier ffiwrapper.Verifier
 	miner    abi.ActorID
 	winnRpt  abi.RegisteredPoStProof
+
+	fallback WinningPoStFallback
 }
 
 func NewWinningPoStProver(api v1api.FullNode, prover storage.Prover, verifier ffiwrapper.Verifier, miner dtypes.MinerID) (*StorageWpp, error) {
@@ -272,7 +302,12 @@ func NewWinningPoStProver(api v1api.FullNode, prover storage.Prover, verifier ff
 		log.Warn("*****************************************************************************")
 	}
 
-	return &StorageWpp{prover, verifier, abi.ActorID(miner), mi.WindowPoStProofType}, nil
+	return &StorageWpp{
+		prover:   prover,
+		verifier: verifier,
+		miner:    abi.ActorID(miner),
+		winnRpt:  mi.WindowPoStProofType,
+	}, nil
 }
 
 var _ gen.WinningPoStProver = (*StorageWpp)(nil)
@@ -289,17 +324,8 @@ func (wpp *StorageWpp) GenerateCandidates(ctx context.Context, randomness abi.Po
 }
 
 func (wpp *StorageWpp) ComputeProof(ctx context.Context, ssi []builtin.SectorInfo, rand abi.PoStRandomness) ([]builtin.PoStProof, error) {
-	if build.InsecurePoStValidation {
-		return []builtin.PoStProof{{ProofBytes: []byte("valid proof")}}, nil
-	}
-
-	log.Infof("Computing WinningPoSt ;%+v; %v", ssi, rand)
-
-	start := build.Clock.Now()
-	proof, err := wpp.prover.GenerateWinningPoSt(ctx, wpp.miner, ssi, rand)
-	if err != nil {
-		return nil, err
+	if wpp.fallback == nil || build.InsecurePoStValidation {
+		return wpp.ComputeLocalProof(ctx, ssi, rand)
 	}
-	log.Infof("GenerateWinningPoSt took %s", time.Since(start))
-	return proof, nil
+	return wpp.raceFallback(ctx, ssi, rand)
 }
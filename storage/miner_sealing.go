@@ -5,11 +5,17 @@ import (
 	"io"
 
 	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/specs-storage/storage"
 
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	sectorstorage "github.com/filecoin-project/lotus/extern/sector-storage"
 	sealing "github.com/filecoin-project/lotus/extern/storage-sealing"
 	"github.com/filecoin-project/lotus/extern/storage-sealing/sealiface"
 )
@@ -24,6 +30,24 @@ func (m *Miner) AddPieceToAnySector(ctx context.Context, size abi.UnpaddedPieceS
 	return m.sealing.AddPieceToAnySector(ctx, size, r, d)
 }
 
+func (m *Miner) SectorAddPieceToAny(ctx context.Context, size abi.UnpaddedPieceSize, r storage.Data, d api.PieceDealInfo) (api.SectorOffset, error) {
+	sn, offset, err := m.sealing.AddPieceToAnySector(ctx, size, r, sealing.DealInfo{
+		PublishCid:   d.PublishCid,
+		DealID:       d.DealID,
+		DealProposal: d.DealProposal,
+		DealSchedule: sealing.DealSchedule{
+			StartEpoch: d.DealSchedule.StartEpoch,
+			EndEpoch:   d.DealSchedule.EndEpoch,
+		},
+		KeepUnsealed: d.KeepUnsealed,
+	})
+	if err != nil {
+		return api.SectorOffset{}, err
+	}
+
+	return api.SectorOffset{Sector: sn, Offset: offset}, nil
+}
+
 func (m *Miner) StartPackingSector(sectorNum abi.SectorNumber) error {
 	return m.sealing.StartPacking(sectorNum)
 }
@@ -36,10 +60,105 @@ func (m *Miner) GetSectorInfo(sid abi.SectorNumber) (sealing.SectorInfo, error)
 	return m.sealing.GetSectorInfo(sid)
 }
 
+func (m *Miner) SectorLog(sid abi.SectorNumber) ([]sealing.SectorEvent, error) {
+	return m.sealing.SectorLog(sid)
+}
+
 func (m *Miner) PledgeSector(ctx context.Context) (storage.SectorRef, error) {
 	return m.sealing.PledgeSector(ctx)
 }
 
+func (m *Miner) SectorsStatus(ctx context.Context, sid abi.SectorNumber, showOnChainInfo bool) (api.SectorInfo, error) {
+	info, err := m.GetSectorInfo(sid)
+	if err != nil {
+		return api.SectorInfo{}, err
+	}
+
+	deals := make([]abi.DealID, len(info.Pieces))
+	for i, piece := range info.Pieces {
+		if piece.DealInfo == nil {
+			continue
+		}
+		deals[i] = piece.DealInfo.DealID
+	}
+
+	labels := make(map[string]string, len(info.Labels))
+	for _, l := range info.Labels {
+		labels[l.Key] = l.Value
+	}
+
+	log := make([]api.SectorLog, len(info.Log))
+	for i, l := range info.Log {
+		log[i] = api.SectorLog{
+			Kind:      l.Kind,
+			Timestamp: l.Timestamp,
+			Trace:     l.Trace,
+			Message:   l.Message,
+		}
+	}
+
+	sInfo := api.SectorInfo{
+		SectorID: sid,
+		State:    api.SectorState(info.State),
+		CommD:    info.CommD,
+		CommR:    info.CommR,
+		Proof:    info.Proof,
+		Deals:    deals,
+		Ticket: api.SealTicket{
+			Value: info.TicketValue,
+			Epoch: info.TicketEpoch,
+		},
+		Seed: api.SealSeed{
+			Value: info.SeedValue,
+			Epoch: info.SeedEpoch,
+		},
+		PreCommitMsg: info.PreCommitMessage,
+		CommitMsg:    info.CommitMessage,
+		Retries:      info.InvalidProofs,
+		ToUpgrade:    m.IsMarkedForUpgrade(sid),
+
+		LastErr: info.LastErr,
+		Log:     log,
+		Labels:  labels,
+		// on chain info
+		SealProof:          info.SectorType,
+		Activation:         0,
+		Expiration:         0,
+		DealWeight:         big.Zero(),
+		VerifiedDealWeight: big.Zero(),
+		InitialPledge:      big.Zero(),
+		OnTime:             0,
+		Early:              0,
+	}
+
+	if !showOnChainInfo {
+		return sInfo, nil
+	}
+
+	onChainInfo, err := m.api.StateSectorGetInfo(ctx, m.Address(), sid, types.EmptyTSK)
+	if err != nil {
+		return sInfo, err
+	}
+	if onChainInfo == nil {
+		return sInfo, nil
+	}
+	sInfo.SealProof = onChainInfo.SealProof
+	sInfo.Activation = onChainInfo.Activation
+	sInfo.Expiration = onChainInfo.Expiration
+	sInfo.DealWeight = onChainInfo.DealWeight
+	sInfo.VerifiedDealWeight = onChainInfo.VerifiedDealWeight
+	sInfo.InitialPledge = onChainInfo.InitialPledge
+
+	ex, err := m.api.StateSectorExpiration(ctx, m.Address(), sid, types.EmptyTSK)
+	if err != nil {
+		return sInfo, nil
+	}
+	sInfo.OnTime = ex.OnTime
+	sInfo.Early = ex.Early
+
+	return sInfo, nil
+}
+
 func (m *Miner) ForceSectorState(ctx context.Context, id abi.SectorNumber, state sealing.SectorState) error {
 	return m.sealing.ForceSectorState(ctx, id, state)
 }
@@ -48,6 +167,10 @@ func (m *Miner) RemoveSector(ctx context.Context, id abi.SectorNumber) error {
 	return m.sealing.Remove(ctx, id)
 }
 
+func (m *Miner) SetSectorLabels(ctx context.Context, id abi.SectorNumber, labels map[string]string) error {
+	return m.sealing.SetLabels(ctx, id, labels)
+}
+
 func (m *Miner) TerminateSector(ctx context.Context, id abi.SectorNumber) error {
 	return m.sealing.Terminate(ctx, id)
 }
@@ -76,10 +199,58 @@ func (m *Miner) CommitPending(ctx context.Context) ([]abi.SectorID, error) {
 	return m.sealing.CommitPending(ctx)
 }
 
+func (m *Miner) BatchStatus(ctx context.Context) (api.SectorBatchStatus, error) {
+	pc, err := m.sealing.PreCommitBatchStatus(ctx)
+	if err != nil {
+		return api.SectorBatchStatus{}, xerrors.Errorf("getting precommit batch status: %w", err)
+	}
+
+	c, err := m.sealing.CommitBatchStatus(ctx)
+	if err != nil {
+		return api.SectorBatchStatus{}, xerrors.Errorf("getting commit batch status: %w", err)
+	}
+
+	return api.SectorBatchStatus{PreCommit: pc, Commit: c}, nil
+}
+
 func (m *Miner) MarkForUpgrade(id abi.SectorNumber) error {
 	return m.sealing.MarkForUpgrade(id)
 }
 
+func (m *Miner) RegenerateProof(id abi.SectorNumber) error {
+	return m.sealing.RegenerateProof(id)
+}
+
 func (m *Miner) IsMarkedForUpgrade(id abi.SectorNumber) bool {
 	return m.sealing.IsMarkedForUpgrade(id)
 }
+
+func (m *Miner) NumReservations() (map[string]bitfield.BitField, error) {
+	return m.sealing.NumReservations()
+}
+
+func (m *Miner) NumReserve(name string, numbers bitfield.BitField, force bool) error {
+	return m.sealing.NumReserve(name, numbers, force)
+}
+
+func (m *Miner) NumFree(name string) error {
+	return m.sealing.NumFree(name)
+}
+
+func (m *Miner) SectorsETA(queue map[abi.SectorID]sectorstorage.QueuePosition) ([]sealing.SectorETA, error) {
+	return m.sealing.SectorsETA(queue)
+}
+
+func (m *Miner) SealingForecast(etas []sealing.SectorETA) sealing.SealingForecast {
+	return m.sealing.Forecast(etas)
+}
+
+// FeeBreaker returns the base fee circuit breaker deferring the non
+// time-critical messages of the miner
+func (m *Miner) FeeBreaker() *sealing.FeeBreaker {
+	return m.feeBreaker
+}
+
+func (m *Miner) SealingFSMGraph() (sealing.FSMGraph, error) {
+	return m.sealing.FSMGraph()
+}
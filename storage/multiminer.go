@@ -0,0 +1,10 @@
+package storage
+
+import (
+	"github.com/filecoin-project/go-address"
+)
+
+// AdditionalMiners are the miner actors sealing and proving sectors with the
+// storage and workers of the primary miner, keyed by ID address. Each of them
+// has its own sealing pipeline, message batchers and window PoSt scheduler.
+type AdditionalMiners map[address.Address]*Miner
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/multisig"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/sigs"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+// ownerChangeWindow is how long a prepared owner change can be confirmed for
+const ownerChangeWindow = 10 * time.Minute
+
+type ownerChangeAPI interface {
+	ChainReadObj(context.Context, cid.Cid) ([]byte, error)
+	ChainHasObj(context.Context, cid.Cid) (bool, error)
+	StateGetActor(context.Context, address.Address, types.TipSetKey) (*types.Actor, error)
+	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (miner.MinerInfo, error)
+	StateLookupID(context.Context, address.Address, types.TipSetKey) (address.Address, error)
+	StateAccountKey(context.Context, address.Address, types.TipSetKey) (address.Address, error)
+	MpoolPushMessage(context.Context, *types.Message, *api.MessageSendSpec) (*types.SignedMessage, error)
+}
+
+// OwnerChangeGuard guards the proposal of a new owner of the miner, which
+// can't be undone once the new owner confirms it: the new owner must exist on
+// chain, be an account or a multisig, and a key controlling it must sign a
+// challenge before the change is proposed.
+type OwnerChangeGuard struct {
+	api   ownerChangeAPI
+	maddr address.Address
+
+	lk      sync.Mutex
+	pending *api.OwnerChange
+}
+
+func NewOwnerChangeGuard(fapi ownerChangeAPI, maddr dtypes.MinerAddress) *OwnerChangeGuard {
+	return &OwnerChangeGuard{
+		api:   fapi,
+		maddr: address.Address(maddr),
+	}
+}
+
+// Prepare checks the new owner and returns the challenge to sign to confirm
+// the change. It replaces any change prepared before.
+func (g *OwnerChangeGuard) Prepare(ctx context.Context, newOwner address.Address) (*api.OwnerChange, error) {
+	id, err := g.api.StateLookupID(ctx, newOwner, types.EmptyTSK)
+	if err != nil {
+		return nil, xerrors.Errorf("new owner %s not found on chain, it must receive funds first: %w", newOwner, err)
+	}
+
+	mi, err := g.api.StateMinerInfo(ctx, g.maddr, types.EmptyTSK)
+	if err != nil {
+		return nil, xerrors.Errorf("getting miner info: %w", err)
+	}
+	if mi.Owner == id {
+		return nil, xerrors.Errorf("owner address already set to %s", newOwner)
+	}
+
+	signers, err := g.ownerKeys(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, xerrors.Errorf("generating challenge: %w", err)
+	}
+
+	oc := &api.OwnerChange{
+		OldOwner:  mi.Owner,
+		NewOwner:  id,
+		Signers:   signers,
+		Challenge: ownerChallenge(g.maddr, id, challenge),
+		Expires:   build.Clock.Now().Add(ownerChangeWindow),
+	}
+
+	g.lk.Lock()
+	g.pending = oc
+	g.lk.Unlock()
+
+	out := *oc
+	return &out, nil
+}
+
+// ownerChallenge is the message signed to confirm an owner change
+func ownerChallenge(maddr, newOwner address.Address, nonce []byte) []byte {
+	return []byte(fmt.Sprintf("lotus-owner-change/v1\nminer:%s\nowner:%s\nnonce:%x\n", maddr, newOwner, nonce))
+}
+
+// ownerKeys returns the keys which control the owner: the key of an account,
+// or the keys of the signers of a multisig.
+func (g *OwnerChangeGuard) ownerKeys(ctx context.Context, owner address.Address) ([]address.Address, error) {
+	act, err := g.api.StateGetActor(ctx, owner, types.EmptyTSK)
+	if err != nil {
+		return nil, xerrors.Errorf("getting new owner actor: %w", err)
+	}
+
+	var ids []address.Address
+	switch {
+	case builtin.IsAccountActor(act.Code):
+		ids = []address.Address{owner}
+	case builtin.IsMultisigActor(act.Code):
+		ms, err := multisig.Load(store.ActorStore(ctx, blockstore.NewAPIBlockstore(g.api)), act)
+		if err != nil {
+			return nil, xerrors.Errorf("loading multisig state: %w", err)
+		}
+		if ids, err = ms.Signers(); err != nil {
+			return nil, xerrors.Errorf("getting multisig signers: %w", err)
+		}
+	default:
+		return nil, xerrors.Errorf("new owner %s is a %s actor, must be an account or a multisig", owner, builtin.ActorNameByCode(act.Code))
+	}
+
+	keys := make([]address.Address, 0, len(ids))
+	for _, id := range ids {
+		key, err := g.api.StateAccountKey(ctx, id, types.EmptyTSK)
+		if err != nil {
+			return nil, xerrors.Errorf("getting key of %s: %w", id, err)
+		}
+		if key.Protocol() != address.SECP256K1 && key.Protocol() != address.BLS {
+			return nil, xerrors.Errorf("%s has no signing key", id)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Confirm checks the signature of the prepared challenge and proposes the
+// new owner, returning the message CID. The new owner then has to confirm it
+// with set-owner.
+func (g *OwnerChangeGuard) Confirm(ctx context.Context, sig crypto.Signature) (cid.Cid, error) {
+	g.lk.Lock()
+	defer g.lk.Unlock()
+
+	oc := g.pending
+	if oc == nil {
+		return cid.Undef, xerrors.Errorf("no owner change prepared")
+	}
+	if build.Clock.Now().After(oc.Expires) {
+		g.pending = nil
+		return cid.Undef, xerrors.Errorf("owner change prepared at %s expired, prepare it again", oc.Expires.Add(-ownerChangeWindow).Format(time.RFC3339))
+	}
+
+	if _, err := challengeSigner(oc, &sig); err != nil {
+		return cid.Undef, err
+	}
+
+	mi, err := g.api.StateMinerInfo(ctx, g.maddr, types.EmptyTSK)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("getting miner info: %w", err)
+	}
+	if mi.Owner != oc.OldOwner {
+		g.pending = nil
+		return cid.Undef, xerrors.Errorf("owner changed to %s since the change was prepared", mi.Owner)
+	}
+
+	params, err := actors.SerializeParams(&oc.NewOwner)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("serializing params: %w", err)
+	}
+
+	smsg, err := g.api.MpoolPushMessage(ctx, &types.Message{
+		From:   mi.Owner,
+		To:     g.maddr,
+		Method: miner.Methods.ChangeOwnerAddress,
+		Value:  big.Zero(),
+		Params: params,
+	}, nil)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("pushing owner change proposal: %w", err)
+	}
+	g.pending = nil
+
+	log.Infow("owner change proposed", "miner", g.maddr, "owner", oc.NewOwner, "message", smsg.Cid())
+	return smsg.Cid(), nil
+}
+
+// challengeSigner returns the signer key the signature of the challenge is
+// from.
+func challengeSigner(oc *api.OwnerChange, sig *crypto.Signature) (address.Address, error) {
+	for _, key := range oc.Signers {
+		if sigs.Verify(sig, key, oc.Challenge) == nil {
+			return key, nil
+		}
+	}
+	return address.Undef, xerrors.Errorf("challenge not signed by a key of the new owner %s", oc.NewOwner)
+}
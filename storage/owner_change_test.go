@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/lib/sigs"
+	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
+)
+
+func TestChallengeSigner(t *testing.T) {
+	key := func() ([]byte, address.Address) {
+		priv, err := sigs.Generate(crypto.SigTypeSecp256k1)
+		require.NoError(t, err)
+		pub, err := sigs.ToPublic(crypto.SigTypeSecp256k1, priv)
+		require.NoError(t, err)
+		addr, err := address.NewSecp256k1Address(pub)
+		require.NoError(t, err)
+		return priv, addr
+	}
+	priv1, key1 := key()
+	priv2, key2 := key()
+	other, _ := key()
+
+	oc := &api.OwnerChange{
+		NewOwner:  address.TestAddress,
+		Signers:   []address.Address{key1, key2},
+		Challenge: ownerChallenge(address.TestAddress2, address.TestAddress, []byte{1, 2, 3}),
+	}
+
+	for _, tc := range []struct {
+		priv []byte
+		addr address.Address
+	}{{priv1, key1}, {priv2, key2}} {
+		sig, err := sigs.Sign(crypto.SigTypeSecp256k1, tc.priv, oc.Challenge)
+		require.NoError(t, err)
+		signer, err := challengeSigner(oc, sig)
+		require.NoError(t, err)
+		require.Equal(t, tc.addr, signer)
+	}
+
+	sig, err := sigs.Sign(crypto.SigTypeSecp256k1, other, oc.Challenge)
+	require.NoError(t, err)
+	_, err = challengeSigner(oc, sig)
+	require.Error(t, err)
+
+	// signature of another challenge
+	sig, err = sigs.Sign(crypto.SigTypeSecp256k1, priv1, ownerChallenge(address.TestAddress2, address.TestAddress, []byte{4}))
+	require.NoError(t, err)
+	_, err = challengeSigner(oc, sig)
+	require.Error(t, err)
+}
@@ -0,0 +1,332 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	miner2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+	sealing "github.com/filecoin-project/lotus/extern/storage-sealing"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+var payoutHistoryKey = datastore.NewKey("/payouts/history")
+
+// payoutHistoryLen is the number of past payouts kept for review
+const payoutHistoryLen = 20
+
+// payoutFeeRecheck is how often a payout deferred by the base fee circuit
+// breaker is retried
+const payoutFeeRecheck = 10 * time.Minute
+
+type payoutAPI interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (miner.MinerInfo, error)
+	StateMinerAvailableBalance(context.Context, address.Address, types.TipSetKey) (types.BigInt, error)
+	StateWaitMsg(ctx context.Context, cid cid.Cid, confidence uint64, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error)
+	MpoolPushMessage(context.Context, *types.Message, *api.MessageSendSpec) (*types.SignedMessage, error)
+}
+
+// PayoutScheduler periodically withdraws the miner available balance above
+// the configured float to the owner address, and sends the configured shares
+// of it from there to the payout addresses.
+type PayoutScheduler struct {
+	api     payoutAPI
+	maddr   address.Address
+	ds      datastore.Batching
+	cfg     config.PayoutConfig
+	shares  []api.PayoutShare
+	journal journal.Journal
+	evtType journal.EventType
+	fb      *sealing.FeeBreaker
+
+	lk      sync.Mutex
+	history []api.PayoutRecord // most recent first
+}
+
+// PayoutEvt is the journal record of a scheduled payout
+type PayoutEvt struct {
+	Miner address.Address
+	api.PayoutRecord
+}
+
+func NewPayoutScheduler(fapi payoutAPI, maddr address.Address, ds dtypes.MetadataDS, j journal.Journal, cfg config.PayoutConfig) (*PayoutScheduler, error) {
+	if cfg.Interval <= 0 {
+		return nil, xerrors.Errorf("Payout.Interval must be positive")
+	}
+
+	shares, err := parsePayoutShares(cfg.Recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &PayoutScheduler{
+		api:     fapi,
+		maddr:   maddr,
+		ds:      ds,
+		cfg:     cfg,
+		shares:  shares,
+		journal: j,
+		evtType: j.RegisterEventType("payout", "payout"),
+	}
+
+	b, err := ds.Get(payoutHistoryKey)
+	switch {
+	case err == datastore.ErrNotFound:
+	case err != nil:
+		return nil, xerrors.Errorf("loading payout history: %w", err)
+	default:
+		if err := json.Unmarshal(b, &p.history); err != nil {
+			return nil, xerrors.Errorf("decoding payout history: %w", err)
+		}
+	}
+
+	return p, nil
+}
+
+// SetFeeBreaker makes payouts wait while the base fee circuit breaker is
+// tripped. Must be called before Run.
+func (p *PayoutScheduler) SetFeeBreaker(fb *sealing.FeeBreaker) {
+	p.fb = fb
+}
+
+func parsePayoutShares(recipients []config.PayoutRecipient) ([]api.PayoutShare, error) {
+	var total uint64
+	out := make([]api.PayoutShare, 0, len(recipients))
+	for _, r := range recipients {
+		addr, err := address.NewFromString(r.Address)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing payout address %q: %w", r.Address, err)
+		}
+		if r.Percent == 0 {
+			return nil, xerrors.Errorf("payout share of %s must be positive", addr)
+		}
+
+		total += r.Percent
+		out = append(out, api.PayoutShare{Address: addr, Percent: r.Percent})
+	}
+	if total > 100 {
+		return nil, xerrors.Errorf("payout shares add up to %d%%, more than 100%%", total)
+	}
+	return out, nil
+}
+
+// Schedule returns the payout settings, with the time of the next payout and
+// the recent payouts.
+func (p *PayoutScheduler) Schedule() *api.PayoutSchedule {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	return &api.PayoutSchedule{
+		Interval:  time.Duration(p.cfg.Interval),
+		Float:     abi.TokenAmount(p.cfg.Float),
+		MinAmount: abi.TokenAmount(p.cfg.MinAmount),
+		Shares:    p.shares,
+		Next:      p.next(),
+		History:   append([]api.PayoutRecord{}, p.history...),
+	}
+}
+
+// call with p.lk
+func (p *PayoutScheduler) next() time.Time {
+	if len(p.history) == 0 {
+		return build.Clock.Now()
+	}
+	return p.history[0].Time.Add(time.Duration(p.cfg.Interval))
+}
+
+func (p *PayoutScheduler) Run(ctx context.Context) {
+	for {
+		p.lk.Lock()
+		wait := build.Clock.Until(p.next())
+		p.lk.Unlock()
+
+		if wait > 0 {
+			select {
+			case <-build.Clock.After(wait):
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if p.feeDeferred(ctx) {
+			select {
+			case <-build.Clock.After(payoutFeeRecheck):
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+
+		rec := p.payout(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		p.journal.RecordEvent(p.evtType, func() interface{} {
+			return PayoutEvt{Miner: p.maddr, PayoutRecord: rec}
+		})
+		if err := p.record(rec); err != nil {
+			log.Errorf("storing payout record: %s", err)
+		}
+	}
+}
+
+// feeDeferred returns whether the base fee circuit breaker defers the payout
+func (p *PayoutScheduler) feeDeferred(ctx context.Context) bool {
+	if p.fb == nil {
+		return false
+	}
+
+	ts, err := p.api.ChainHead(ctx)
+	if err != nil {
+		log.Warnw("getting chain head to check the base fee", "error", err)
+		return false
+	}
+
+	if !p.fb.Tripped(ts.MinTicketBlock().ParentBaseFee) {
+		p.fb.Defer("withdraw", 0)
+		return false
+	}
+
+	log.Infow("deferring scheduled payout, base fee over the circuit breaker threshold", "miner", p.maddr)
+	p.fb.Defer("withdraw", 1)
+	return true
+}
+
+func (p *PayoutScheduler) record(rec api.PayoutRecord) error {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	p.history = append([]api.PayoutRecord{rec}, p.history...)
+	if len(p.history) > payoutHistoryLen {
+		p.history = p.history[:payoutHistoryLen]
+	}
+
+	b, err := json.Marshal(p.history)
+	if err != nil {
+		return err
+	}
+	return p.ds.Put(payoutHistoryKey, b)
+}
+
+func (p *PayoutScheduler) payout(ctx context.Context) api.PayoutRecord {
+	rec := api.PayoutRecord{
+		Time:      build.Clock.Now(),
+		Available: big.Zero(),
+		Withdrawn: big.Zero(),
+	}
+
+	if err := p.doPayout(ctx, &rec); err != nil {
+		log.Errorw("scheduled payout failed", "miner", p.maddr, "error", err)
+		rec.Error = err.Error()
+	}
+	return rec
+}
+
+func (p *PayoutScheduler) doPayout(ctx context.Context, rec *api.PayoutRecord) error {
+	available, err := p.api.StateMinerAvailableBalance(ctx, p.maddr, types.EmptyTSK)
+	if err != nil {
+		return xerrors.Errorf("getting available balance: %w", err)
+	}
+	rec.Available = available
+
+	amount := payoutAmount(available, abi.TokenAmount(p.cfg.Float), abi.TokenAmount(p.cfg.MinAmount))
+	if amount.IsZero() {
+		log.Infow("skipping payout, not enough available balance above the float", "miner", p.maddr, "available", types.FIL(available))
+		rec.Skipped = true
+		return nil
+	}
+
+	mi, err := p.api.StateMinerInfo(ctx, p.maddr, types.EmptyTSK)
+	if err != nil {
+		return xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	params, err := actors.SerializeParams(&miner2.WithdrawBalanceParams{
+		AmountRequested: amount,
+	})
+	if err != nil {
+		return err
+	}
+
+	spec := &api.MessageSendSpec{MaxFee: abi.TokenAmount(p.cfg.MaxFee)}
+	smsg, err := p.api.MpoolPushMessage(ctx, &types.Message{
+		To:     p.maddr,
+		From:   mi.Owner,
+		Value:  big.Zero(),
+		Method: miner.Methods.WithdrawBalance,
+		Params: params,
+	}, spec)
+	if err != nil {
+		return xerrors.Errorf("pushing withdraw message: %w", err)
+	}
+	wc := smsg.Cid()
+	rec.WithdrawMessage = &wc
+
+	lookup, err := p.api.StateWaitMsg(ctx, wc, build.MessageConfidence, api.LookbackNoLimit, true)
+	if err != nil {
+		return xerrors.Errorf("waiting for withdraw message: %w", err)
+	}
+	if lookup.Receipt.ExitCode.IsError() {
+		return xerrors.Errorf("withdraw message %s failed: exit code %d", lookup.Message, lookup.Receipt.ExitCode)
+	}
+	rec.Withdrawn = amount
+
+	for _, pay := range payoutPayments(amount, p.shares) {
+		smsg, err := p.api.MpoolPushMessage(ctx, &types.Message{
+			To:    pay.To,
+			From:  mi.Owner,
+			Value: pay.Amount,
+		}, spec)
+		if err != nil {
+			return xerrors.Errorf("sending %s to %s: %w", types.FIL(pay.Amount), pay.To, err)
+		}
+
+		pay.Message = smsg.Cid()
+		rec.Payments = append(rec.Payments, pay)
+	}
+
+	log.Infow("payout sent", "miner", p.maddr, "withdrawn", types.FIL(amount), "payments", len(rec.Payments))
+	return nil
+}
+
+// payoutAmount is the available balance above the float, or zero when it's
+// below the minimum payout.
+func payoutAmount(available, float, min abi.TokenAmount) abi.TokenAmount {
+	amount := big.Sub(available, float)
+	if amount.Sign() <= 0 || amount.LessThan(min) {
+		return big.Zero()
+	}
+	return amount
+}
+
+// payoutPayments splits the withdrawn amount between the shares; the
+// remainder stays with the owner.
+func payoutPayments(amount abi.TokenAmount, shares []api.PayoutShare) []api.PayoutPayment {
+	var out []api.PayoutPayment
+	for _, s := range shares {
+		v := big.Div(big.Mul(amount, big.NewInt(int64(s.Percent))), big.NewInt(100))
+		if v.IsZero() {
+			continue
+		}
+		out = append(out, api.PayoutPayment{To: s.Address, Amount: v})
+	}
+	return out
+}
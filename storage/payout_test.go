@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+func TestPayoutAmount(t *testing.T) {
+	float, min := big.NewInt(100), big.NewInt(10)
+
+	require.EqualValues(t, big.Zero(), payoutAmount(big.NewInt(50), float, min))
+	require.EqualValues(t, big.Zero(), payoutAmount(big.NewInt(105), float, min))
+	require.EqualValues(t, big.NewInt(10), payoutAmount(big.NewInt(110), float, min))
+	require.EqualValues(t, big.NewInt(900), payoutAmount(big.NewInt(1000), float, min))
+}
+
+func TestPayoutPayments(t *testing.T) {
+	a, b := address.TestAddress, address.TestAddress2
+	shares := []api.PayoutShare{{Address: a, Percent: 60}, {Address: b, Percent: 25}}
+
+	pays := payoutPayments(big.NewInt(1001), shares)
+	require.Len(t, pays, 2)
+	require.Equal(t, a, pays[0].To)
+	require.EqualValues(t, big.NewInt(600), pays[0].Amount)
+	require.Equal(t, b, pays[1].To)
+	require.EqualValues(t, big.NewInt(250), pays[1].Amount)
+
+	// shares rounding down to nothing are not sent
+	pays = payoutPayments(big.NewInt(3), shares)
+	require.Len(t, pays, 1)
+	require.EqualValues(t, big.NewInt(1), pays[0].Amount)
+}
+
+func TestParsePayoutShares(t *testing.T) {
+	_, err := parsePayoutShares([]config.PayoutRecipient{{Address: "f01234", Percent: 60}, {Address: "f01235", Percent: 50}})
+	require.Error(t, err)
+
+	_, err = parsePayoutShares([]config.PayoutRecipient{{Address: "f01234", Percent: 0}})
+	require.Error(t, err)
+
+	shares, err := parsePayoutShares([]config.PayoutRecipient{{Address: "f01234", Percent: 100}})
+	require.NoError(t, err)
+	require.Len(t, shares, 1)
+	require.EqualValues(t, 100, shares[0].Percent)
+}
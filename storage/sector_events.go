@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/lib/subscription"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+// webhookQueueSize is the number of state changes waiting to be POSTed to
+// webhooks, further changes are dropped
+const webhookQueueSize = 1024
+
+// sectorEvents fans out the sector state changes of a miner to API
+// subscribers and webhooks. Publishing never blocks the sealing FSM; changes
+// are dropped for subscribers which don't keep up.
+type sectorEvents struct {
+	lk   sync.Mutex
+	subs map[chan<- api.SectorStateChange]api.SectorStateFilter
+
+	webhooks chan api.SectorStateChange
+}
+
+func newSectorEvents() *sectorEvents {
+	return &sectorEvents{
+		subs:     map[chan<- api.SectorStateChange]api.SectorStateFilter{},
+		webhooks: make(chan api.SectorStateChange, webhookQueueSize),
+	}
+}
+
+// subscribe sends the changes matching the filter to ch until the returned
+// function is called. Nothing is sent to ch once it returns.
+func (e *sectorEvents) subscribe(filter api.SectorStateFilter, ch chan<- api.SectorStateChange) func() {
+	e.lk.Lock()
+	defer e.lk.Unlock()
+
+	e.subs[ch] = filter
+
+	return func() {
+		e.lk.Lock()
+		defer e.lk.Unlock()
+
+		delete(e.subs, ch)
+	}
+}
+
+func (e *sectorEvents) publish(change api.SectorStateChange) {
+	e.lk.Lock()
+	for ch, filter := range e.subs {
+		if !filter.Matches(change) {
+			continue
+		}
+
+		select {
+		case ch <- change:
+		default:
+			log.Warnw("sector state change subscriber isn't keeping up, dropping change", "sector", change.SectorNumber, "state", change.To)
+		}
+	}
+	e.lk.Unlock()
+
+	select {
+	case e.webhooks <- change:
+	default:
+		log.Warnw("sector state webhook queue full, dropping change", "sector", change.SectorNumber, "state", change.To)
+	}
+}
+
+// BufferStateChanges subscribes with subscribe, and sends the state changes
+// to the returned channel through a buffer with the given policy, so that the
+// subscription never falls behind. The channel is closed when ctx is
+// cancelled, or when the policy disconnects the subscriber.
+func BufferStateChanges(ctx context.Context, subscribe func(chan<- api.SectorStateChange) func(), p subscription.Policy) <-chan api.SectorStateChange {
+	buf := subscription.NewBuffer("sectorstate", p, coalesceStateChanges)
+
+	in := make(chan api.SectorStateChange, 16)
+	unsub := subscribe(in)
+
+	go func() {
+		defer buf.Close()
+		defer unsub()
+
+		for {
+			select {
+			case change := <-in:
+				if !buf.Push([]api.SectorStateChange{change}) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	out := make(chan api.SectorStateChange)
+	go func() {
+		defer close(out)
+
+		for {
+			val, ok := buf.Next(ctx)
+			if !ok {
+				return
+			}
+
+			for _, change := range val.([]api.SectorStateChange) {
+				select {
+				case out <- change:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// coalesceStateChanges merges the buffered changes into one change per
+// sector, from the state of its first change to the state of its last one.
+func coalesceStateChanges(pending []interface{}) interface{} {
+	type sectorKey struct {
+		miner  address.Address
+		number abi.SectorNumber
+	}
+
+	var out []api.SectorStateChange
+	idx := map[sectorKey]int{}
+	for _, p := range pending {
+		for _, change := range p.([]api.SectorStateChange) {
+			key := sectorKey{change.Miner, change.SectorNumber}
+			if i, ok := idx[key]; ok {
+				change.From = out[i].From
+				out[i] = change
+				continue
+			}
+
+			idx[key] = len(out)
+			out = append(out, change)
+		}
+	}
+	return out
+}
+
+// runWebhooks POSTs the state changes to the webhooks of the sealing config,
+// in order
+func (e *sectorEvents) runWebhooks(ctx context.Context, getCfg dtypes.GetSealingConfigFunc) {
+	for {
+		var change api.SectorStateChange
+		select {
+		case change = <-e.webhooks:
+		case <-ctx.Done():
+			return
+		}
+
+		cfg, err := getCfg()
+		if err != nil {
+			log.Errorw("getting sealing config for sector state webhooks", "error", err)
+			continue
+		}
+
+		filter := api.SectorStateFilter{}
+		for _, st := range cfg.StateWebhookStates {
+			filter.States = append(filter.States, api.SectorState(st))
+		}
+		if !filter.Matches(change) {
+			continue
+		}
+
+		for _, url := range cfg.StateWebhookURLs {
+			if err := alerting.PostJSON(url, change); err != nil {
+				log.Warnw("sending sector state change to webhook", "url", url, "sector", change.SectorNumber, "state", change.To, "error", err)
+			}
+		}
+	}
+}
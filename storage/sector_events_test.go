@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/lib/subscription"
+)
+
+func TestSectorEventsFilter(t *testing.T) {
+	e := newSectorEvents()
+
+	all := make(chan api.SectorStateChange, 10)
+	failed := make(chan api.SectorStateChange, 10)
+	unsubAll := e.subscribe(api.SectorStateFilter{}, all)
+	unsubFailed := e.subscribe(api.SectorStateFilter{
+		Sectors: []abi.SectorNumber{1},
+		States:  []api.SectorState{"CommitFailed"},
+	}, failed)
+
+	e.publish(api.SectorStateChange{SectorNumber: 1, From: "Committing", To: "CommitFailed"})
+	e.publish(api.SectorStateChange{SectorNumber: 2, From: "Committing", To: "CommitFailed"})
+	e.publish(api.SectorStateChange{SectorNumber: 1, From: "CommitFailed", To: "Committing"})
+
+	require.Len(t, all, 3)
+	require.Len(t, failed, 1)
+	require.Equal(t, abi.SectorNumber(1), (<-failed).SectorNumber)
+
+	unsubFailed()
+	e.publish(api.SectorStateChange{SectorNumber: 1, From: "Committing", To: "CommitFailed"})
+	require.Len(t, all, 4)
+	require.Len(t, failed, 0)
+
+	unsubAll()
+	require.Len(t, e.webhooks, 4)
+}
+
+func TestSectorEventsSlowSubscriber(t *testing.T) {
+	e := newSectorEvents()
+
+	ch := make(chan api.SectorStateChange, 1)
+	defer e.subscribe(api.SectorStateFilter{}, ch)()
+
+	// publishing doesn't block on the full channel
+	e.publish(api.SectorStateChange{SectorNumber: 1, To: "Proving"})
+	e.publish(api.SectorStateChange{SectorNumber: 2, To: "Proving"})
+
+	require.Len(t, ch, 1)
+	require.Equal(t, abi.SectorNumber(1), (<-ch).SectorNumber)
+}
+
+func TestCoalesceStateChanges(t *testing.T) {
+	merged := coalesceStateChanges([]interface{}{
+		[]api.SectorStateChange{{SectorNumber: 1, From: "PreCommit1", To: "PreCommit2"}},
+		[]api.SectorStateChange{{SectorNumber: 2, From: "Committing", To: "CommitFailed"}},
+		[]api.SectorStateChange{{SectorNumber: 1, From: "PreCommit2", To: "PreCommitting"}},
+	}).([]api.SectorStateChange)
+
+	require.Equal(t, []api.SectorStateChange{
+		{SectorNumber: 1, From: "PreCommit1", To: "PreCommitting"},
+		{SectorNumber: 2, From: "Committing", To: "CommitFailed"},
+	}, merged)
+}
+
+func TestBufferStateChanges(t *testing.T) {
+	e := newSectorEvents()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out := BufferStateChanges(ctx, func(ch chan<- api.SectorStateChange) func() {
+		return e.subscribe(api.SectorStateFilter{}, ch)
+	}, subscription.Policy{Mode: subscription.Disconnect, Buffer: 1})
+
+	e.publish(api.SectorStateChange{SectorNumber: 1, To: "Proving"})
+	require.Equal(t, abi.SectorNumber(1), (<-out).SectorNumber)
+
+	// the subscriber is dropped once cancelled
+	cancel()
+	_, ok := <-out
+	require.False(t, ok)
+	require.Eventually(t, func() bool {
+		e.lk.Lock()
+		defer e.lk.Unlock()
+		return len(e.subs) == 0
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/dline"
+)
+
+// PoStCoordinator limits the number of window PoSt proofs generated at once
+// by the miners served by this node. When the deadlines of several miners
+// overlap, their proofs are generated one after the other instead of at the
+// same time on the same GPUs: miners with a higher priority first, then the
+// deadline closing first.
+type PoStCoordinator struct {
+	parallel int
+
+	lk       sync.Mutex
+	running  int
+	waiting  []*postTurn
+	priority map[address.Address]int
+}
+
+type postTurn struct {
+	miner    address.Address
+	priority int
+	close    abi.ChainEpoch
+	since    time.Time
+
+	ready chan struct{}
+}
+
+// NewPoStCoordinator returns a coordinator running at most parallel proofs at
+// once, 0 for no limit
+func NewPoStCoordinator(parallel int) *PoStCoordinator {
+	return &PoStCoordinator{
+		parallel: parallel,
+		priority: map[address.Address]int{},
+	}
+}
+
+// SetPriority sets the priority of the proofs of a miner, 0 by default
+func (c *PoStCoordinator) SetPriority(maddr address.Address, priority int) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	c.priority[maddr] = priority
+}
+
+// Acquire waits for the turn of the miner to generate the proofs of the
+// deadline. The returned function must be called once the proofs are done.
+// A nil coordinator doesn't limit anything.
+func (c *PoStCoordinator) Acquire(ctx context.Context, maddr address.Address, di dline.Info) (func(), error) {
+	if c == nil || c.parallel <= 0 {
+		return func() {}, nil
+	}
+
+	c.lk.Lock()
+	t := &postTurn{
+		miner:    maddr,
+		priority: c.priority[maddr],
+		close:    di.Close,
+		since:    time.Now(),
+		ready:    make(chan struct{}),
+	}
+	c.waiting = append(c.waiting, t)
+	c.dispatch()
+
+	select {
+	case <-t.ready:
+		c.lk.Unlock()
+	default:
+		var ahead []address.Address
+		for _, r := range c.waiting {
+			if r != t {
+				ahead = append(ahead, r.miner)
+			}
+		}
+		c.lk.Unlock()
+		log.Infow("window PoSt waiting for other miners' proofs", "miner", maddr, "deadline", di.Index, "waiting", ahead)
+	}
+
+	select {
+	case <-t.ready:
+	case <-ctx.Done():
+		c.lk.Lock()
+		defer c.lk.Unlock()
+
+		select {
+		case <-t.ready:
+			// the turn came while the context got cancelled
+			c.done()
+		default:
+			c.remove(t)
+		}
+		return nil, ctx.Err()
+	}
+
+	if wait := time.Since(t.since); wait > time.Second {
+		log.Infow("window PoSt turn started", "miner", maddr, "deadline", di.Index, "waited", wait.Truncate(time.Second))
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.lk.Lock()
+			defer c.lk.Unlock()
+			c.done()
+		})
+	}, nil
+}
+
+// done must be called with the lock held
+func (c *PoStCoordinator) done() {
+	c.running--
+	c.dispatch()
+}
+
+// remove must be called with the lock held
+func (c *PoStCoordinator) remove(t *postTurn) {
+	for i, r := range c.waiting {
+		if r == t {
+			c.waiting = append(c.waiting[:i], c.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+// dispatch must be called with the lock held
+func (c *PoStCoordinator) dispatch() {
+	sort.SliceStable(c.waiting, func(i, j int) bool {
+		a, b := c.waiting[i], c.waiting[j]
+		if a.priority != b.priority {
+			return a.priority > b.priority
+		}
+		return a.close < b.close
+	})
+
+	for c.running < c.parallel && len(c.waiting) > 0 {
+		t := c.waiting[0]
+		c.waiting = c.waiting[1:]
+		c.running++
+		close(t.ready)
+	}
+}
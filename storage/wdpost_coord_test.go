@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/dline"
+)
+
+func TestPoStCoordinator(t *testing.T) {
+	ctx := context.Background()
+
+	m1, m2 := address.TestAddress, address.TestAddress2
+	m3, err := address.NewIDAddress(1002)
+	require.NoError(t, err)
+
+	c := NewPoStCoordinator(1)
+	c.SetPriority(m3, 10)
+
+	release1, err := c.Acquire(ctx, m1, dline.Info{Close: 100})
+	require.NoError(t, err)
+
+	turns := make(chan address.Address, 2)
+	acquire := func(maddr address.Address, close abi.ChainEpoch) {
+		release, err := c.Acquire(ctx, maddr, dline.Info{Close: close})
+		require.NoError(t, err)
+		turns <- maddr
+		release()
+	}
+
+	// m2's deadline closes first, but m3 has the higher priority
+	go acquire(m2, 50)
+	waitWaiting(t, c, 1)
+	go acquire(m3, 200)
+	waitWaiting(t, c, 2)
+
+	select {
+	case <-turns:
+		t.Fatal("turn given while the slot is taken")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release1()
+	release1() // releasing twice is a no-op
+	require.Equal(t, m3, <-turns)
+	require.Equal(t, m2, <-turns)
+
+	// cancelled waits leave the queue
+	release, err := c.Acquire(ctx, m1, dline.Info{})
+	require.NoError(t, err)
+
+	cctx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() {
+		_, err := c.Acquire(cctx, m2, dline.Info{})
+		done <- err
+	}()
+	waitWaiting(t, c, 1)
+	cancel()
+	require.Error(t, <-done)
+	waitWaiting(t, c, 0)
+
+	release()
+	c.lk.Lock()
+	require.Zero(t, c.running)
+	c.lk.Unlock()
+}
+
+func TestPoStCoordinatorNil(t *testing.T) {
+	var c *PoStCoordinator
+	release, err := c.Acquire(context.Background(), address.TestAddress, dline.Info{})
+	require.NoError(t, err)
+	release()
+}
+
+func waitWaiting(t *testing.T, c *PoStCoordinator, n int) {
+	require.Eventually(t, func() bool {
+		c.lk.Lock()
+		defer c.lk.Unlock()
+		return len(c.waiting) == n
+	}, 5*time.Second, time.Millisecond)
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/dline"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
@@ -37,6 +38,7 @@ const (
 
 // evtCommon is a common set of attributes for Windowed PoSt journal events.
 type evtCommon struct {
+	Miner    address.Address
 	Deadline *dline.Info
 	Height   abi.ChainEpoch
 	TipSet   []cid.Cid
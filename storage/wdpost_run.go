@@ -34,7 +34,7 @@ import (
 // recordPoStFailure records a failure in the journal.
 func (s *WindowPoStScheduler) recordPoStFailure(err error, ts *types.TipSet, deadline *dline.Info) {
 	s.journal.RecordEvent(s.evtTypes[evtTypeWdPoStScheduler], func() interface{} {
-		c := evtCommon{Error: err}
+		c := evtCommon{Miner: s.actor, Error: err}
 		if ts != nil {
 			c.Deadline = deadline
 			c.Height = ts.Height()
@@ -234,6 +234,36 @@ func (s *WindowPoStScheduler) checkSectors(ctx context.Context, check bitfield.B
 	return sbf, nil
 }
 
+// withoutPredictedFaults removes the sectors which the fault predictor
+// expects to fail from the good sectors, so that they are declared faulty
+// ahead of time, and not declared recovered while at risk.
+func (s *WindowPoStScheduler) withoutPredictedFaults(good bitfield.BitField) (bitfield.BitField, error) {
+	if s.predictor == nil {
+		return good, nil
+	}
+
+	mid, err := address.IDFromAddress(s.actor)
+	if err != nil {
+		return bitfield.BitField{}, err
+	}
+
+	faulty := s.predictor.FaultySectors(abi.ActorID(mid))
+	if len(faulty) == 0 {
+		return good, nil
+	}
+
+	atRisk := bitfield.New()
+	for n := range faulty {
+		atRisk.Set(uint64(n))
+	}
+
+	out, err := bitfield.SubtractBitField(good, atRisk)
+	if err != nil {
+		return bitfield.BitField{}, xerrors.Errorf("subtracting sectors at risk: %w", err)
+	}
+	return out, nil
+}
+
 // declareRecoveries identifies sectors that were previously marked as faulty
 // for our miner, but are now recovered (i.e. are now provable again) and
 // still not reported as such.
@@ -281,6 +311,10 @@ func (s *WindowPoStScheduler) declareRecoveries(ctx context.Context, dlIdx uint6
 		if err != nil {
 			return nil, nil, xerrors.Errorf("checking unrecovered sectors: %w", err)
 		}
+		recovered, err = s.withoutPredictedFaults(recovered)
+		if err != nil {
+			return nil, nil, err
+		}
 
 		// if all sectors failed to recover, don't declare recoveries
 		recoveredCount, err := recovered.Count()
@@ -319,12 +353,15 @@ func (s *WindowPoStScheduler) declareRecoveries(ctx context.Context, dlIdx uint6
 		Params: enc,
 		Value:  types.NewInt(0),
 	}
-	spec := &api.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg.MaxWindowPoStGasFee)}
+	spec, err := s.messageSpec()
+	if err != nil {
+		return recoveries, nil, err
+	}
 	if err := s.prepareMessage(ctx, msg, spec); err != nil {
 		return recoveries, nil, err
 	}
 
-	sm, err := s.api.MpoolPushMessage(ctx, msg, &api.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg.MaxWindowPoStGasFee)})
+	sm, err := s.api.MpoolPushMessage(ctx, msg, &api.MessageSendSpec{MaxFee: spec.MaxFee})
 	if err != nil {
 		return recoveries, sm, xerrors.Errorf("pushing message to mpool: %w", err)
 	}
@@ -375,6 +412,10 @@ func (s *WindowPoStScheduler) declareFaults(ctx context.Context, dlIdx uint64, p
 		if err != nil {
 			return nil, nil, xerrors.Errorf("checking sectors: %w", err)
 		}
+		good, err = s.withoutPredictedFaults(good)
+		if err != nil {
+			return nil, nil, err
+		}
 
 		newFaulty, err := bitfield.SubtractBitField(nonFaulty, good)
 		if err != nil {
@@ -417,7 +458,10 @@ func (s *WindowPoStScheduler) declareFaults(ctx context.Context, dlIdx uint64, p
 		Params: enc,
 		Value:  types.NewInt(0), // TODO: Is there a fee?
 	}
-	spec := &api.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg.MaxWindowPoStGasFee)}
+	spec, err := s.messageSpec()
+	if err != nil {
+		return faults, nil, err
+	}
 	if err := s.prepareMessage(ctx, msg, spec); err != nil {
 		return faults, nil, err
 	}
@@ -546,6 +590,13 @@ func (s *WindowPoStScheduler) runPoStCycle(ctx context.Context, di dline.Info, t
 		return nil, err
 	}
 
+	// Take turns with the other miners served by this node
+	release, err := s.coord.Acquire(ctx, s.actor, di)
+	if err != nil {
+		return nil, xerrors.Errorf("waiting for the turn to generate proofs: %w", err)
+	}
+	defer release()
+
 	// Generate proofs in batches
 	posts := make([]miner.SubmitWindowedPoStParams, 0, len(partitionBatches))
 	for batchIdx, batch := range partitionBatches {
@@ -822,7 +873,10 @@ func (s *WindowPoStScheduler) submitPoStMessage(ctx context.Context, proof *mine
 		Params: enc,
 		Value:  types.NewInt(0),
 	}
-	spec := &api.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg.MaxWindowPoStGasFee)}
+	spec, err := s.messageSpec()
+	if err != nil {
+		return nil, err
+	}
 	if err := s.prepareMessage(ctx, msg, spec); err != nil {
 		return nil, err
 	}
@@ -855,6 +909,15 @@ func (s *WindowPoStScheduler) submitPoStMessage(ctx context.Context, proof *mine
 //
 // * the sender (from the AddressSelector, falling back to the worker address if none set)
 // * the right gas parameters
+// messageSpec limits the fee of window PoSt messages as currently configured
+func (s *WindowPoStScheduler) messageSpec() (*api.MessageSendSpec, error) {
+	feeCfg, err := s.getFeeCfg()
+	if err != nil {
+		return nil, xerrors.Errorf("getting fee config: %w", err)
+	}
+	return &api.MessageSendSpec{MaxFee: abi.TokenAmount(feeCfg.MaxWindowPoStGasFee)}, nil
+}
+
 func (s *WindowPoStScheduler) prepareMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) error {
 	mi, err := s.api.StateMinerInfo(ctx, s.actor, types.EmptyTSK)
 	if err != nil {
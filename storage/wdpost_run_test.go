@@ -34,6 +34,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
 	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/node/config"
 )
 
 type mockStorageMinerAPI struct {
@@ -222,6 +223,9 @@ func TestWDPostDoPost(t *testing.T) {
 		actor:        postAct,
 		journal:      journal.NilJournal(),
 		addrSel:      &AddressSelector{},
+		getFeeCfg: func() (config.MinerFeeConfig, error) {
+			return config.MinerFeeConfig{}, nil
+		},
 	}
 
 	di := &dline.Info{
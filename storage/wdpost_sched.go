@@ -17,8 +17,9 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 	sectorstorage "github.com/filecoin-project/lotus/extern/sector-storage"
 	"github.com/filecoin-project/lotus/extern/sector-storage/ffiwrapper"
+	"github.com/filecoin-project/lotus/extern/sector-storage/stores"
+	sealing "github.com/filecoin-project/lotus/extern/storage-sealing"
 	"github.com/filecoin-project/lotus/journal"
-	"github.com/filecoin-project/lotus/node/config"
 
 	"go.opencensus.io/trace"
 )
@@ -31,7 +32,7 @@ import (
 // turn calls the scheduler when the time arrives to do work.
 type WindowPoStScheduler struct {
 	api              fullNodeFilteredAPI
-	feeCfg           config.MinerFeeConfig
+	getFeeCfg        sealing.GetFeeConfigFunc
 	addrSel          *AddressSelector
 	prover           storage.Prover
 	verifier         ffiwrapper.Verifier
@@ -39,6 +40,8 @@ type WindowPoStScheduler struct {
 	proofType        abi.RegisteredPoStProof
 	partitionSectors uint64
 	ch               *changeHandler
+	coord            *PoStCoordinator
+	predictor        *stores.FaultPredictor
 
 	actor address.Address
 
@@ -51,7 +54,7 @@ type WindowPoStScheduler struct {
 
 // NewWindowedPoStScheduler creates a new WindowPoStScheduler scheduler.
 func NewWindowedPoStScheduler(api fullNodeFilteredAPI,
-	cfg config.MinerFeeConfig,
+	gfc sealing.GetFeeConfigFunc,
 	as *AddressSelector,
 	sp storage.Prover,
 	verif ffiwrapper.Verifier,
@@ -65,7 +68,7 @@ func NewWindowedPoStScheduler(api fullNodeFilteredAPI,
 
 	return &WindowPoStScheduler{
 		api:              api,
-		feeCfg:           cfg,
+		getFeeCfg:        gfc,
 		addrSel:          as,
 		prover:           sp,
 		verifier:         verif,
@@ -84,6 +87,18 @@ func NewWindowedPoStScheduler(api fullNodeFilteredAPI,
 	}, nil
 }
 
+// SetCoordinator makes the scheduler take turns with the other miners of the
+// coordinator to generate proofs
+func (s *WindowPoStScheduler) SetCoordinator(c *PoStCoordinator) {
+	s.coord = c
+}
+
+// SetFaultPredictor makes the scheduler declare the sectors stored in paths
+// at risk faulty, when the prediction policy says so
+func (s *WindowPoStScheduler) SetFaultPredictor(p *stores.FaultPredictor) {
+	s.predictor = p
+}
+
 func (s *WindowPoStScheduler) Run(ctx context.Context) {
 	// Initialize change handler.
 
@@ -184,7 +199,7 @@ func (s *WindowPoStScheduler) update(ctx context.Context, revert, apply *types.T
 // onAbort is called when generating proofs or submitting proofs is aborted
 func (s *WindowPoStScheduler) onAbort(ts *types.TipSet, deadline *dline.Info) {
 	s.journal.RecordEvent(s.evtTypes[evtTypeWdPoStScheduler], func() interface{} {
-		c := evtCommon{}
+		c := evtCommon{Miner: s.actor}
 		if ts != nil {
 			c.Deadline = deadline
 			c.Height = ts.Height()
@@ -198,7 +213,7 @@ func (s *WindowPoStScheduler) onAbort(ts *types.TipSet, deadline *dline.Info) {
 }
 
 func (s *WindowPoStScheduler) getEvtCommon(err error) evtCommon {
-	c := evtCommon{Error: err}
+	c := evtCommon{Miner: s.actor, Error: err}
 	currentTS, currentDeadline := s.ch.currentTSDI()
 	if currentTS != nil {
 		c.Deadline = currentDeadline
@@ -0,0 +1,381 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/crypto"
+	miner2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/lib/sigs"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+var workerRotationKey = datastore.NewKey("/worker-rotation")
+
+// workerRotationReminder is how long, about an hour, before the worker change
+// becomes effective the rotation alert is raised
+var workerRotationReminder = abi.ChainEpoch(60 * 60 / build.BlockDelaySecs)
+
+// workerKeyCheckMsg is signed with the new worker key to check that the node
+// can sign blocks with it
+var workerKeyCheckMsg = []byte("lotus worker key rotation check")
+
+type workerRotationAPI interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (miner.MinerInfo, error)
+	StateLookupID(context.Context, address.Address, types.TipSetKey) (address.Address, error)
+	StateAccountKey(context.Context, address.Address, types.TipSetKey) (address.Address, error)
+	StateWaitMsg(ctx context.Context, cid cid.Cid, confidence uint64, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error)
+	MpoolPushMessage(context.Context, *types.Message, *api.MessageSendSpec) (*types.SignedMessage, error)
+	WalletHas(context.Context, address.Address) (bool, error)
+	WalletSign(context.Context, address.Address, []byte) (*crypto.Signature, error)
+}
+
+// WorkerRotator drives a worker key change through its steps: it proposes the
+// change, reminds about it through an alert before it becomes effective,
+// confirms it once it can be, and checks that the node can sign blocks with
+// the new worker key.
+type WorkerRotator struct {
+	api     workerRotationAPI
+	maddr   address.Address
+	ds      datastore.Batching
+	journal journal.Journal
+	evtType journal.EventType
+	al      *alerting.Alerting
+	alert   alerting.AlertType
+
+	// rotateLk serializes the changes of the rotation, which wait for
+	// messages, lk guards cur
+	rotateLk sync.Mutex
+	lk       sync.Mutex
+	cur      *api.WorkerRotation
+}
+
+// WorkerRotationEvt is the journal record of a worker rotation phase change
+type WorkerRotationEvt struct {
+	Miner address.Address
+	api.WorkerRotation
+}
+
+func NewWorkerRotator(fapi workerRotationAPI, maddr address.Address, ds dtypes.MetadataDS, j journal.Journal, al *alerting.Alerting) (*WorkerRotator, error) {
+	wr := &WorkerRotator{
+		api:     fapi,
+		maddr:   maddr,
+		ds:      ds,
+		journal: j,
+		evtType: j.RegisterEventType("worker", "rotation"),
+		al:      al,
+		alert:   al.AddAlertType("worker", "rotation"),
+	}
+
+	b, err := ds.Get(workerRotationKey)
+	switch {
+	case err == datastore.ErrNotFound:
+	case err != nil:
+		return nil, xerrors.Errorf("loading worker rotation: %w", err)
+	default:
+		if err := json.Unmarshal(b, &wr.cur); err != nil {
+			return nil, xerrors.Errorf("decoding worker rotation: %w", err)
+		}
+	}
+
+	return wr, nil
+}
+
+// Rotation returns the last worker rotation, or nil if there was none.
+func (wr *WorkerRotator) Rotation() *api.WorkerRotation {
+	wr.lk.Lock()
+	defer wr.lk.Unlock()
+
+	if wr.cur == nil {
+		return nil
+	}
+	r := *wr.cur
+	return &r
+}
+
+func (wr *WorkerRotator) update(r api.WorkerRotation) error {
+	wr.lk.Lock()
+	wr.cur = &r
+	wr.lk.Unlock()
+
+	wr.journal.RecordEvent(wr.evtType, func() interface{} {
+		return WorkerRotationEvt{Miner: wr.maddr, WorkerRotation: r}
+	})
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return wr.ds.Put(workerRotationKey, b)
+}
+
+// Rotate proposes changing the worker to newWorker, and waits for the
+// proposal to land on chain. A change to newWorker which is already pending
+// is picked up without sending a new proposal.
+func (wr *WorkerRotator) Rotate(ctx context.Context, newWorker address.Address) (*api.WorkerRotation, error) {
+	wr.rotateLk.Lock()
+	defer wr.rotateLk.Unlock()
+
+	if r := wr.Rotation(); r != nil && (r.Phase == api.WorkerRotationProposed || r.Phase == api.WorkerRotationConfirming) {
+		return nil, xerrors.Errorf("rotation to worker %s already in progress", r.NewWorker)
+	}
+
+	newID, err := wr.api.StateLookupID(ctx, newWorker, types.EmptyTSK)
+	if err != nil {
+		return nil, xerrors.Errorf("looking up new worker: %w", err)
+	}
+	key, err := wr.checkKey(ctx, newID)
+	if err != nil {
+		return nil, err
+	}
+
+	mi, err := wr.api.StateMinerInfo(ctx, wr.maddr, types.EmptyTSK)
+	if err != nil {
+		return nil, xerrors.Errorf("getting miner info: %w", err)
+	}
+	if mi.Worker == newID {
+		return nil, xerrors.Errorf("worker address already set to %s", newWorker)
+	}
+
+	r := api.WorkerRotation{
+		OldWorker: mi.Worker,
+		NewWorker: newID,
+		NewKey:    key,
+		Phase:     api.WorkerRotationProposed,
+		Started:   build.Clock.Now(),
+	}
+
+	if mi.NewWorker != newID {
+		params, err := actors.SerializeParams(&miner2.ChangeWorkerAddressParams{
+			NewWorker:       newID,
+			NewControlAddrs: mi.ControlAddresses,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("serializing params: %w", err)
+		}
+
+		smsg, err := wr.api.MpoolPushMessage(ctx, &types.Message{
+			From:   mi.Owner,
+			To:     wr.maddr,
+			Method: miner.Methods.ChangeWorkerAddress,
+			Value:  big.Zero(),
+			Params: params,
+		}, nil)
+		if err != nil {
+			return nil, xerrors.Errorf("pushing worker change proposal: %w", err)
+		}
+		mc := smsg.Cid()
+		r.ProposeMessage = &mc
+
+		lookup, err := wr.api.StateWaitMsg(ctx, mc, build.MessageConfidence, api.LookbackNoLimit, true)
+		if err != nil {
+			return nil, xerrors.Errorf("waiting for worker change proposal: %w", err)
+		}
+		if lookup.Receipt.ExitCode.IsError() {
+			return nil, xerrors.Errorf("worker change proposal %s failed: exit code %d", lookup.Message, lookup.Receipt.ExitCode)
+		}
+
+		if mi, err = wr.api.StateMinerInfo(ctx, wr.maddr, lookup.TipSet); err != nil {
+			return nil, xerrors.Errorf("getting miner info: %w", err)
+		}
+		if mi.NewWorker != newID {
+			return nil, xerrors.Errorf("proposed worker change not reflected on chain: expected %s, found %s", newID, mi.NewWorker)
+		}
+	}
+	r.EffectiveEpoch = mi.WorkerChangeEpoch
+
+	log.Infow("worker change proposed", "miner", wr.maddr, "worker", newID, "effective", r.EffectiveEpoch)
+	if err := wr.update(r); err != nil {
+		return nil, xerrors.Errorf("storing worker rotation: %w", err)
+	}
+	return &r, nil
+}
+
+// checkKey returns the key of the worker, after checking that the node can
+// sign with it.
+func (wr *WorkerRotator) checkKey(ctx context.Context, worker address.Address) (address.Address, error) {
+	key, err := wr.api.StateAccountKey(ctx, worker, types.EmptyTSK)
+	if err != nil {
+		return address.Undef, xerrors.Errorf("getting key of worker %s: %w", worker, err)
+	}
+	if key.Protocol() != address.BLS {
+		return address.Undef, xerrors.Errorf("worker key %s must be a BLS key", key)
+	}
+
+	has, err := wr.api.WalletHas(ctx, key)
+	if err != nil {
+		return address.Undef, xerrors.Errorf("checking wallet: %w", err)
+	}
+	if !has {
+		return address.Undef, xerrors.Errorf("worker key %s is not in the wallet of the node", key)
+	}
+
+	sig, err := wr.api.WalletSign(ctx, key, workerKeyCheckMsg)
+	if err != nil {
+		return address.Undef, xerrors.Errorf("signing with worker key %s: %w", key, err)
+	}
+	if err := sigs.Verify(sig, key, workerKeyCheckMsg); err != nil {
+		return address.Undef, xerrors.Errorf("signature of worker key %s doesn't verify: %w", key, err)
+	}
+
+	return key, nil
+}
+
+func (wr *WorkerRotator) Run(ctx context.Context) {
+	for {
+		if err := wr.step(ctx); err != nil {
+			log.Errorw("worker rotation step", "miner", wr.maddr, "error", err)
+		}
+
+		select {
+		case <-build.Clock.After(time.Duration(build.BlockDelaySecs) * time.Second):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+type rotationStep int
+
+const (
+	rotationWait rotationStep = iota
+	rotationRemind
+	rotationConfirm
+	rotationVerify
+	rotationSuperseded
+)
+
+// nextRotationStep is what to do with a pending rotation given the miner info
+// at height.
+func nextRotationStep(r *api.WorkerRotation, mi miner.MinerInfo, height abi.ChainEpoch) rotationStep {
+	switch {
+	case mi.Worker == r.NewWorker:
+		// confirmed, possibly from the CLI
+		return rotationVerify
+	case mi.NewWorker != r.NewWorker:
+		return rotationSuperseded
+	case height >= mi.WorkerChangeEpoch:
+		return rotationConfirm
+	case mi.WorkerChangeEpoch-height <= workerRotationReminder:
+		return rotationRemind
+	default:
+		return rotationWait
+	}
+}
+
+func (wr *WorkerRotator) step(ctx context.Context) error {
+	wr.rotateLk.Lock()
+	defer wr.rotateLk.Unlock()
+
+	cur := wr.Rotation()
+	if cur == nil || (cur.Phase != api.WorkerRotationProposed && cur.Phase != api.WorkerRotationConfirming) {
+		return nil
+	}
+	r := *cur
+
+	if r.ConfirmMessage != nil {
+		lookup, err := wr.api.StateWaitMsg(ctx, *r.ConfirmMessage, build.MessageConfidence, api.LookbackNoLimit, true)
+		if err != nil {
+			return xerrors.Errorf("waiting for worker change confirmation: %w", err)
+		}
+		if lookup.Receipt.ExitCode.IsError() {
+			return wr.fail(r, xerrors.Errorf("worker change confirmation %s failed: exit code %d", lookup.Message, lookup.Receipt.ExitCode))
+		}
+	}
+
+	head, err := wr.api.ChainHead(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting chain head: %w", err)
+	}
+	mi, err := wr.api.StateMinerInfo(ctx, wr.maddr, head.Key())
+	if err != nil {
+		return xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	switch nextRotationStep(&r, mi, head.Height()) {
+	case rotationWait:
+		return nil
+	case rotationRemind:
+		if !wr.al.IsRaised(wr.alert) {
+			wr.al.Raise(wr.alert, map[string]interface{}{
+				"message":        "worker key change becomes effective soon, keep the node running with the new worker key in the wallet to have it confirmed",
+				"newWorker":      r.NewWorker.String(),
+				"newKey":         r.NewKey.String(),
+				"effectiveEpoch": r.EffectiveEpoch,
+			})
+		}
+		return nil
+	case rotationSuperseded:
+		return wr.fail(r, xerrors.Errorf("pending worker change replaced on chain, by %s", mi.NewWorker))
+	case rotationConfirm:
+		if r.ConfirmMessage != nil {
+			return wr.fail(r, xerrors.Errorf("worker change still pending after confirmation %s", r.ConfirmMessage))
+		}
+
+		smsg, err := wr.api.MpoolPushMessage(ctx, &types.Message{
+			From:   mi.Owner,
+			To:     wr.maddr,
+			Method: miner.Methods.ConfirmUpdateWorkerKey,
+			Value:  big.Zero(),
+		}, nil)
+		if err != nil {
+			return xerrors.Errorf("pushing worker change confirmation: %w", err)
+		}
+		mc := smsg.Cid()
+		r.ConfirmMessage = &mc
+		r.Phase = api.WorkerRotationConfirming
+
+		log.Infow("worker change confirmation sent", "miner", wr.maddr, "worker", r.NewWorker, "message", mc)
+		return wr.update(r)
+	case rotationVerify:
+		if _, err := wr.checkKey(ctx, mi.Worker); err != nil {
+			return wr.fail(r, xerrors.Errorf("new worker active but the node can't sign blocks with it: %w", err))
+		}
+
+		r.Phase = api.WorkerRotationDone
+		r.Finished = build.Clock.Now()
+
+		wr.al.Resolve(wr.alert, map[string]interface{}{
+			"message":   "worker key change done",
+			"newWorker": r.NewWorker.String(),
+		})
+		log.Infow("worker change done", "miner", wr.maddr, "worker", r.NewWorker)
+		return wr.update(r)
+	}
+
+	return nil
+}
+
+func (wr *WorkerRotator) fail(r api.WorkerRotation, err error) error {
+	r.Phase = api.WorkerRotationFailed
+	r.Finished = build.Clock.Now()
+	r.Error = err.Error()
+
+	wr.al.Raise(wr.alert, map[string]interface{}{
+		"message":   "worker key change failed",
+		"newWorker": r.NewWorker.String(),
+		"error":     r.Error,
+	})
+	if err := wr.update(r); err != nil {
+		return xerrors.Errorf("storing worker rotation: %w", err)
+	}
+	return nil
+}
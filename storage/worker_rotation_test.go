@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+)
+
+func TestNextRotationStep(t *testing.T) {
+	oldW, newW := address.TestAddress, address.TestAddress2
+	r := &api.WorkerRotation{OldWorker: oldW, NewWorker: newW, EffectiveEpoch: 1000}
+	mi := miner.MinerInfo{Worker: oldW, NewWorker: newW, WorkerChangeEpoch: 1000}
+
+	require.Equal(t, rotationWait, nextRotationStep(r, mi, 1000-workerRotationReminder-1))
+	require.Equal(t, rotationRemind, nextRotationStep(r, mi, 1000-workerRotationReminder))
+	require.Equal(t, rotationConfirm, nextRotationStep(r, mi, 1000))
+	require.Equal(t, rotationConfirm, nextRotationStep(r, mi, 1200))
+
+	// confirmed by someone else
+	require.Equal(t, rotationVerify, nextRotationStep(r, miner.MinerInfo{Worker: newW}, 1200))
+
+	// proposal replaced or cancelled
+	require.Equal(t, rotationSuperseded, nextRotationStep(r, miner.MinerInfo{Worker: oldW, NewWorker: oldW, WorkerChangeEpoch: 1000}, 900))
+	require.Equal(t, rotationSuperseded, nextRotationStep(r, miner.MinerInfo{Worker: oldW}, 900))
+}
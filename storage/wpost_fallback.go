@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+)
+
+// WinningPoStFallback computes winning PoSts on a second prover, e.g. a
+// standby miner node for the same actor, with its own GPU and access to the
+// sealed sectors.
+type WinningPoStFallback interface {
+	ComputeProof(ctx context.Context, ssi []builtin.SectorInfo, rand abi.PoStRandomness) ([]builtin.PoStProof, error)
+}
+
+// SetFallback makes ComputeProof race the fallback prover against the local
+// prover, using whichever proof is computed first.
+func (wpp *StorageWpp) SetFallback(fb WinningPoStFallback) {
+	wpp.fallback = fb
+}
+
+// ComputeLocalProof computes the proof with the local prover only.
+func (wpp *StorageWpp) ComputeLocalProof(ctx context.Context, ssi []builtin.SectorInfo, rand abi.PoStRandomness) ([]builtin.PoStProof, error) {
+	if build.InsecurePoStValidation {
+		return []builtin.PoStProof{{ProofBytes: []byte("valid proof")}}, nil
+	}
+
+	log.Infof("Computing WinningPoSt ;%+v; %v", ssi, rand)
+
+	start := build.Clock.Now()
+	proof, err := wpp.prover.GenerateWinningPoSt(ctx, wpp.miner, ssi, rand)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("GenerateWinningPoSt took %s", time.Since(start))
+	return proof, nil
+}
+
+func (wpp *StorageWpp) raceFallback(ctx context.Context, ssi []builtin.SectorInfo, rand abi.PoStRandomness) ([]builtin.PoStProof, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // the slower prover is cancelled
+
+	type result struct {
+		fallback bool
+		proof    []builtin.PoStProof
+		err      error
+	}
+
+	results := make(chan result, 2)
+	go func() {
+		proof, err := wpp.ComputeLocalProof(ctx, ssi, rand)
+		results <- result{false, proof, err}
+	}()
+	go func() {
+		proof, err := wpp.fallback.ComputeProof(ctx, ssi, rand)
+		results <- result{true, proof, err}
+	}()
+
+	start := build.Clock.Now()
+	var localErr, fallbackErr error
+	for i := 0; i < 2; i++ {
+		res := <-results
+		if res.err != nil {
+			if res.fallback {
+				log.Warnf("fallback winning PoSt prover failed: %+v", res.err)
+				fallbackErr = res.err
+			} else {
+				log.Warnf("local winning PoSt prover failed: %+v", res.err)
+				localErr = res.err
+			}
+			continue
+		}
+
+		if res.fallback {
+			log.Warnw("using winning PoSt computed by the fallback prover", "took", time.Since(start))
+		}
+		return res.proof, nil
+	}
+
+	return nil, xerrors.Errorf("both winning PoSt provers failed: local: %v; fallback: %v", localErr, fallbackErr)
+}
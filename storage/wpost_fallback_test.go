@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+)
+
+// racingProver is both a local prover and a fallback. A fast prover returns
+// its proof or error right away, a slow one waits to be cancelled.
+type racingProver struct {
+	storage.Prover
+
+	name string
+	fast bool
+	err  error
+
+	cancelled chan struct{}
+}
+
+func newRacingProver(name string, fast bool, err error) *racingProver {
+	return &racingProver{
+		name:      name,
+		fast:      fast,
+		err:       err,
+		cancelled: make(chan struct{}),
+	}
+}
+
+func (p *racingProver) GenerateWinningPoSt(ctx context.Context, _ abi.ActorID, _ []builtin.SectorInfo, _ abi.PoStRandomness) ([]builtin.PoStProof, error) {
+	if !p.fast {
+		<-ctx.Done()
+		close(p.cancelled)
+		return nil, ctx.Err()
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return []builtin.PoStProof{{ProofBytes: []byte(p.name)}}, nil
+}
+
+func (p *racingProver) ComputeProof(ctx context.Context, ssi []builtin.SectorInfo, rand abi.PoStRandomness) ([]builtin.PoStProof, error) {
+	return p.GenerateWinningPoSt(ctx, 0, ssi, rand)
+}
+
+func TestWinningPoStFallbackRace(t *testing.T) {
+	// the provers are only called with proof validation on
+	insecure := build.InsecurePoStValidation
+	build.InsecurePoStValidation = false
+	defer func() {
+		build.InsecurePoStValidation = insecure
+	}()
+
+	for _, tc := range []struct {
+		name            string
+		local, fallback *racingProver
+
+		proof string
+		err   string
+	}{{
+		name:     "local-wins",
+		local:    newRacingProver("local", true, nil),
+		fallback: newRacingProver("fallback", false, nil),
+		proof:    "local",
+	}, {
+		name:     "fallback-wins",
+		local:    newRacingProver("local", false, nil),
+		fallback: newRacingProver("fallback", true, nil),
+		proof:    "fallback",
+	}, {
+		// a failing prover doesn't win, the other one is waited for
+		name:     "local-fails",
+		local:    newRacingProver("local", true, xerrors.New("gpu lost")),
+		fallback: newRacingProver("fallback", true, nil),
+		proof:    "fallback",
+	}, {
+		name:     "both-fail",
+		local:    newRacingProver("local", true, xerrors.New("gpu lost")),
+		fallback: newRacingProver("fallback", true, xerrors.New("connection refused")),
+		err:      "both winning PoSt provers failed: local: gpu lost; fallback: connection refused",
+	}} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			wpp := &StorageWpp{prover: tc.local}
+			wpp.SetFallback(tc.fallback)
+
+			proof, err := wpp.ComputeProof(context.Background(), nil, abi.PoStRandomness("rand"))
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []builtin.PoStProof{{ProofBytes: []byte(tc.proof)}}, proof)
+
+			// the computation which lost the race is cancelled
+			for _, p := range []*racingProver{tc.local, tc.fallback} {
+				if p.fast {
+					continue
+				}
+				select {
+				case <-p.cancelled:
+				case <-time.After(time.Second):
+					t.Fatalf("%s prover not cancelled", p.name)
+				}
+			}
+		})
+	}
+
+	// without a fallback only the local prover is used
+	wpp := &StorageWpp{prover: newRacingProver("local", true, nil)}
+	proof, err := wpp.ComputeProof(context.Background(), nil, abi.PoStRandomness("rand"))
+	require.NoError(t, err)
+	require.Equal(t, []builtin.PoStProof{{ProofBytes: []byte("local")}}, proof)
+}